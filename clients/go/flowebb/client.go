@@ -0,0 +1,136 @@
+// Package flowebb is a typed client for the Flowebb REST and GraphQL APIs. The types and
+// operations in client_gen.go are generated from api/openapi.json and
+// graph/schema.graphql by cmd/sdkgen; this file holds the hand-written transport.
+package flowebb
+
+//go:generate go run ../../../cmd/sdkgen -openapi ../../../api/openapi.json -graphql ../../../graph/schema.graphql -go client_gen.go -ts ../../ts/src/index.ts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls the Flowebb API at BaseURL
+type Client struct {
+	BaseURL     string
+	GraphQLPath string
+	HTTPClient  *http.Client
+	// Header is sent with every request, e.g. X-Api-Key or Authorization, which the
+	// user profile queries and mutations need to identify the caller
+	Header http.Header
+}
+
+// New creates a client for the API at baseURL, e.g. https://example.com/Prod
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		GraphQLPath: "/graphql",
+		HTTPClient:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error is returned for non-2xx responses and GraphQL errors
+type Error struct {
+	StatusCode int
+	// Code identifies the kind of error, e.g. STATION_NOT_FOUND or RANGE_TOO_LARGE; for
+	// GraphQL it's the first error's
+	Code    string
+	Message string
+	Details []ParamError // Set for invalid parameters
+}
+
+func (e *Error) Error() string {
+	if len(e.Details) == 0 {
+		return fmt.Sprintf("flowebb: %d: %s", e.StatusCode, e.Message)
+	}
+	problems := make([]string, len(e.Details))
+	for i, d := range e.Details {
+		problems[i] = d.Parameter + " " + d.Message
+	}
+	return fmt.Sprintf("flowebb: %d: %s: %s", e.StatusCode, e.Message, strings.Join(problems, "; "))
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	return c.do(req, out)
+}
+
+func (c *Client) graphQL(ctx context.Context, query string, variables interface{}, out interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+c.GraphQLPath, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message    string `json:"message"`
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if err := c.do(req, &envelope); err != nil {
+		return err
+	}
+	if len(envelope.Errors) > 0 {
+		messages := make([]string, len(envelope.Errors))
+		for i, e := range envelope.Errors {
+			messages[i] = e.Message
+		}
+		return &Error{StatusCode: http.StatusOK, Code: envelope.Errors[0].Extensions.Code, Message: strings.Join(messages, "; ")}
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: resp.Status}
+		var errBody struct {
+			Code    string       `json:"code"`
+			Error   string       `json:"error"`
+			Details []ParamError `json:"details"`
+		}
+		if json.Unmarshal(body, &errBody) == nil && errBody.Error != "" {
+			apiErr.Code = errBody.Code
+			apiErr.Message = errBody.Error
+			apiErr.Details = errBody.Details
+		}
+		return apiErr
+	}
+	return json.Unmarshal(body, out)
+}
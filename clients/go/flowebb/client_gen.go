@@ -0,0 +1,2849 @@
+// Code generated by cmd/sdkgen. DO NOT EDIT.
+
+package flowebb
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+type AccuracyStats struct {
+	Bias              *float64 `json:"bias,omitempty"`
+	Confidence        *string  `json:"confidence,omitempty"`
+	Days              int64    `json:"days"`
+	MeanAbsoluteError *float64 `json:"meanAbsoluteError,omitempty"`
+	ResponseType      string   `json:"responseType"`
+	Samples           int64    `json:"samples"`
+	StationID         string   `json:"stationId"`
+	StationName       string   `json:"stationName"`
+}
+
+type AccuracyStatsEnvelope struct {
+	Data     AccuracyStats     `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type CompactExtreme struct {
+	Height    float64 `json:"height"`
+	Time      string  `json:"time"`
+	Timestamp int64   `json:"timestamp"`
+	Type      string  `json:"type"`
+}
+
+type ComparedStation struct {
+	Heights    []float64 `json:"heights"`
+	ID         string    `json:"id"`
+	LagMinutes *float64  `json:"lagMinutes,omitempty"`
+	Name       string    `json:"name"`
+	RangeRatio *float64  `json:"rangeRatio,omitempty"`
+}
+
+type DailyExtremes struct {
+	Date     string           `json:"date"`
+	Extremes []CompactExtreme `json:"extremes"`
+}
+
+type DayPredictions struct {
+	Date        string           `json:"date"`
+	Extremes    []TideExtreme    `json:"extremes"`
+	Predictions []TidePrediction `json:"predictions"`
+}
+
+type DayUsage struct {
+	Date      string           `json:"date"`
+	Endpoints map[string]int64 `json:"endpoints"`
+	Requests  int64            `json:"requests"`
+}
+
+type DaylightDay struct {
+	Date            string           `json:"date"`
+	DaylightMinutes int64            `json:"daylightMinutes"`
+	Lows            []CompactExtreme `json:"lows"`
+	Sunrise         *int64           `json:"sunrise,omitempty"`
+	Sunset          *int64           `json:"sunset,omitempty"`
+}
+
+type DaylightLows struct {
+	Below        *float64      `json:"below,omitempty"`
+	Days         []DaylightDay `json:"days"`
+	ResponseType string        `json:"responseType"`
+	StationID    string        `json:"stationId"`
+	StationName  string        `json:"stationName"`
+	TimeZone     *string       `json:"timeZone,omitempty"`
+}
+
+type DaylightLowsEnvelope struct {
+	Data     DaylightLows      `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type ErrorResponse struct {
+	Code         string `json:"code"`
+	Error        string `json:"error"`
+	ResponseType string `json:"responseType"`
+}
+
+type ExtendedTideResponse struct {
+	Anomaly               *WaterLevelAnomaly    `json:"anomaly,omitempty"`
+	Astronomy             *TideAstronomy        `json:"astronomy,omitempty"`
+	CalculationMethod     string                `json:"calculationMethod"`
+	Conditions            *WaterConditions      `json:"conditions,omitempty"`
+	Confidence            *PredictionConfidence `json:"confidence,omitempty"`
+	Extremes              []TideExtreme         `json:"extremes"`
+	Latitude              float64               `json:"latitude"`
+	LocalTime             string                `json:"localTime"`
+	Location              *string               `json:"location,omitempty"`
+	Longitude             float64               `json:"longitude"`
+	NearestStation        string                `json:"nearestStation"`
+	Offsets               *TideOffsets          `json:"offsets,omitempty"`
+	PredictedLevel        *float64              `json:"predictedLevel,omitempty"`
+	Predictions           []TidePrediction      `json:"predictions"`
+	ResponseType          string                `json:"responseType"`
+	StationDistance       float64               `json:"stationDistance"`
+	Summary               *TideSummary          `json:"summary,omitempty"`
+	TideType              *string               `json:"tideType,omitempty"`
+	TimeZoneOffsetSeconds *int64                `json:"timeZoneOffsetSeconds,omitempty"`
+	Timestamp             int64                 `json:"timestamp"`
+	Warnings              []ResponseWarning     `json:"warnings,omitempty"`
+	WaterLevel            *float64              `json:"waterLevel,omitempty"`
+	Weather               *MarineWeather        `json:"weather,omitempty"`
+}
+
+type ExtremesSummary struct {
+	Days         []DailyExtremes `json:"days"`
+	ResponseType string          `json:"responseType"`
+	StationID    string          `json:"stationId"`
+	StationName  string          `json:"stationName"`
+	TimeZone     *string         `json:"timeZone,omitempty"`
+}
+
+type ExtremesSummaryEnvelope struct {
+	Data     ExtremesSummary   `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type Feature struct {
+	Geometry   Point           `json:"geometry"`
+	ID         *string         `json:"id,omitempty"`
+	Properties json.RawMessage `json:"properties"`
+	Type       string          `json:"type"`
+}
+
+type FeatureCollection struct {
+	Features   []Feature   `json:"features"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Stale      *bool       `json:"stale,omitempty"`
+	Type       string      `json:"type"`
+}
+
+type MarineWeather struct {
+	Available bool              `json:"available"`
+	Forecast  []WeatherForecast `json:"forecast"`
+	Source    string            `json:"source"`
+}
+
+type Meta struct {
+	CacheStatus *string `json:"cacheStatus,omitempty"`
+	Datum       *string `json:"datum,omitempty"`
+	GeneratedAt int64   `json:"generatedAt"`
+	Source      *string `json:"source,omitempty"`
+	Units       *string `json:"units,omitempty"`
+}
+
+type NearestStation struct {
+	DistanceKm float64 `json:"distanceKm"`
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+}
+
+type NextExtremes struct {
+	Extremes     []TideExtreme `json:"extremes"`
+	ResponseType string        `json:"responseType"`
+	StationID    string        `json:"stationId"`
+	StationName  string        `json:"stationName"`
+	TimeZone     *string       `json:"timeZone,omitempty"`
+}
+
+type NextExtremesEnvelope struct {
+	Data     NextExtremes      `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type NoNearbyStationResponse struct {
+	Code           string         `json:"code"`
+	Error          string         `json:"error"`
+	MaxDistanceKm  float64        `json:"maxDistanceKm"`
+	NearestStation NearestStation `json:"nearestStation"`
+	PlaceName      *string        `json:"placeName,omitempty"`
+	ResponseType   string         `json:"responseType"`
+}
+
+type OEmbed struct {
+	Cache_age     int64  `json:"cache_age"`
+	Height        int64  `json:"height"`
+	Html          string `json:"html"`
+	Provider_name string `json:"provider_name"`
+	Provider_url  string `json:"provider_url"`
+	Title         string `json:"title"`
+	Type          string `json:"type"`
+	Version       string `json:"version"`
+	Width         int64  `json:"width"`
+}
+
+type Observation struct {
+	LocalTime string  `json:"localTime"`
+	Timestamp int64   `json:"timestamp"`
+	Units     string  `json:"units"`
+	Value     float64 `json:"value"`
+}
+
+type ObservationResponse struct {
+	Observation  Observation `json:"observation"`
+	Product      string      `json:"product"`
+	ResponseType string      `json:"responseType"`
+	StationID    string      `json:"stationId"`
+	StationName  string      `json:"stationName"`
+}
+
+type ObservationResponseEnvelope struct {
+	Data     ObservationResponse `json:"data"`
+	Meta     Meta                `json:"meta"`
+	Warnings []ResponseWarning   `json:"warnings"`
+}
+
+type Pagination struct {
+	HasMore bool  `json:"hasMore"`
+	Limit   int64 `json:"limit"`
+	Offset  int64 `json:"offset"`
+	Total   int64 `json:"total"`
+}
+
+type ParamError struct {
+	Allowed    *string `json:"allowed,omitempty"`
+	Message    string  `json:"message"`
+	Parameter  string  `json:"parameter"`
+	Suggestion *string `json:"suggestion,omitempty"`
+	Value      *string `json:"value,omitempty"`
+}
+
+type Point struct {
+	Coordinates []float64 `json:"coordinates"`
+	Type        string    `json:"type"`
+}
+
+type PredictionConfidence struct {
+	Bias              float64 `json:"bias"`
+	Days              int64   `json:"days"`
+	Level             string  `json:"level"`
+	MeanAbsoluteError float64 `json:"meanAbsoluteError"`
+	Samples           int64   `json:"samples"`
+}
+
+type PredictionSync struct {
+	ResponseType string        `json:"responseType"`
+	Stations     []StationSync `json:"stations"`
+	SyncedAt     int64         `json:"syncedAt"`
+}
+
+type PredictionSyncEnvelope struct {
+	Data     PredictionSync    `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type RegionsResponse struct {
+	Regions      []StationRegion `json:"regions"`
+	ResponseType string          `json:"responseType"`
+}
+
+type RegionsResponseEnvelope struct {
+	Data     RegionsResponse   `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type ResponseWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Station struct {
+	Bearing        *float64     `json:"bearing,omitempty"`
+	Capabilities   []string     `json:"capabilities"`
+	Distance       float64      `json:"distance"`
+	DistanceUnit   *string      `json:"distanceUnit,omitempty"`
+	ID             string       `json:"id"`
+	Latitude       float64      `json:"latitude"`
+	Level          *string      `json:"level,omitempty"`
+	Longitude      float64      `json:"longitude"`
+	Name           string       `json:"name"`
+	Offsets        *TideOffsets `json:"offsets,omitempty"`
+	Region         *string      `json:"region,omitempty"`
+	Source         string       `json:"source"`
+	State          *string      `json:"state,omitempty"`
+	StationType    *string      `json:"stationType,omitempty"`
+	TimeZone       *string      `json:"timeZone,omitempty"`
+	TimeZoneOffset int64        `json:"timeZoneOffset"`
+}
+
+type StationComparison struct {
+	IntervalMinutes int64             `json:"intervalMinutes"`
+	ResponseType    string            `json:"responseType"`
+	Stations        []ComparedStation `json:"stations"`
+	Timestamps      []int64           `json:"timestamps"`
+}
+
+type StationComparisonEnvelope struct {
+	Data     StationComparison `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type StationNotFoundResponse struct {
+	Code         string              `json:"code"`
+	Error        string              `json:"error"`
+	ResponseType string              `json:"responseType"`
+	Suggestions  []StationSuggestion `json:"suggestions"`
+}
+
+type StationRegion struct {
+	Region          *string   `json:"region,omitempty"`
+	Representatives []Station `json:"representatives"`
+	State           *string   `json:"state,omitempty"`
+	StationCount    int64     `json:"stationCount"`
+}
+
+type StationRetiredResponse struct {
+	Code         string            `json:"code"`
+	Error        string            `json:"error"`
+	ResponseType string            `json:"responseType"`
+	StationID    string            `json:"stationId"`
+	Successor    Station           `json:"successor"`
+	Warnings     []ResponseWarning `json:"warnings"`
+}
+
+type StationSuggestion struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type StationSync struct {
+	Days        []DayPredictions `json:"days"`
+	EndDate     string           `json:"endDate"`
+	Full        bool             `json:"full"`
+	StartDate   string           `json:"startDate"`
+	StationID   string           `json:"stationId"`
+	StationName string           `json:"stationName"`
+	TimeZone    *string          `json:"timeZone,omitempty"`
+}
+
+type StationsResponse struct {
+	Pagination   *Pagination `json:"pagination,omitempty"`
+	ResponseType string      `json:"responseType"`
+	Stale        *bool       `json:"stale,omitempty"`
+	Stations     []Station   `json:"stations"`
+}
+
+type StationsResponseEnvelope struct {
+	Data     StationsResponse  `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type TideAstronomy struct {
+	DaysSinceFullMoon float64 `json:"daysSinceFullMoon"`
+	DaysSinceNewMoon  float64 `json:"daysSinceNewMoon"`
+	MoonPhase         string  `json:"moonPhase"`
+	TideCycle         string  `json:"tideCycle"`
+}
+
+type TideExtreme struct {
+	Height    float64 `json:"height"`
+	LocalTime string  `json:"localTime"`
+	Timestamp int64   `json:"timestamp"`
+	Type      string  `json:"type"`
+}
+
+type TideLevelV2 struct {
+	Predicted *float64 `json:"predicted,omitempty"`
+	Trend     *string  `json:"trend,omitempty"`
+}
+
+type TideOffsets struct {
+	HeightAdjustment      string  `json:"heightAdjustment"`
+	HeightOffsetHigh      float64 `json:"heightOffsetHigh"`
+	HeightOffsetLow       float64 `json:"heightOffsetLow"`
+	ReferenceStationID    string  `json:"referenceStationId"`
+	ReferenceStationName  *string `json:"referenceStationName,omitempty"`
+	TimeOffsetHighMinutes int64   `json:"timeOffsetHighMinutes"`
+	TimeOffsetLowMinutes  int64   `json:"timeOffsetLowMinutes"`
+}
+
+type TidePrediction struct {
+	Height    float64 `json:"height"`
+	LocalTime string  `json:"localTime"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+type TideRange struct {
+	High float64 `json:"high"`
+	Low  float64 `json:"low"`
+}
+
+type TideResponseV2 struct {
+	Astronomy             *TideAstronomy    `json:"astronomy,omitempty"`
+	CalculationMethod     string            `json:"calculationMethod"`
+	Conditions            *WaterConditions  `json:"conditions,omitempty"`
+	Extremes              []TideExtreme     `json:"extremes"`
+	Level                 TideLevelV2       `json:"level"`
+	LocalTime             string            `json:"localTime"`
+	Predictions           []TidePrediction  `json:"predictions"`
+	ResponseType          string            `json:"responseType"`
+	Station               TideStationV2     `json:"station"`
+	Summary               *TideSummary      `json:"summary,omitempty"`
+	TimeZoneOffsetSeconds *int64            `json:"timeZoneOffsetSeconds,omitempty"`
+	Timestamp             int64             `json:"timestamp"`
+	Warnings              []ResponseWarning `json:"warnings,omitempty"`
+	Weather               *MarineWeather    `json:"weather,omitempty"`
+}
+
+type TideResponseV2Envelope struct {
+	Data     TideResponseV2    `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type TideStationV2 struct {
+	DistanceKm float64 `json:"distanceKm"`
+	ID         string  `json:"id"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	Name       *string `json:"name,omitempty"`
+}
+
+type TideSummary struct {
+	CycleElapsedPercent *float64     `json:"cycleElapsedPercent,omitempty"`
+	NextHigh            *TideExtreme `json:"nextHigh,omitempty"`
+	NextLow             *TideExtreme `json:"nextLow,omitempty"`
+	TodayRange          *TideRange   `json:"todayRange,omitempty"`
+	Trend               *string      `json:"trend,omitempty"`
+}
+
+type TideTableExport struct {
+	DownloadUrl  *string `json:"downloadUrl,omitempty"`
+	EndDate      *string `json:"endDate,omitempty"`
+	Error        *string `json:"error,omitempty"`
+	ExpiresAt    *int64  `json:"expiresAt,omitempty"`
+	Format       string  `json:"format"`
+	JobID        string  `json:"jobId"`
+	Kind         string  `json:"kind"`
+	ResponseType string  `json:"responseType"`
+	StartDate    *string `json:"startDate,omitempty"`
+	StationID    string  `json:"stationId"`
+	Status       string  `json:"status"`
+	StatusUrl    *string `json:"statusUrl,omitempty"`
+	Year         *int64  `json:"year,omitempty"`
+}
+
+type TideTableExportEnvelope struct {
+	Data     TideTableExport   `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type TideWidget struct {
+	LocalTime    string        `json:"localTime"`
+	Next         []TideExtreme `json:"next"`
+	ResponseType string        `json:"responseType"`
+	StationID    string        `json:"stationId"`
+	StationName  string        `json:"stationName"`
+	Theme        WidgetTheme   `json:"theme"`
+	TideType     *string       `json:"tideType,omitempty"`
+	TimeZone     *string       `json:"timeZone,omitempty"`
+	Timestamp    int64         `json:"timestamp"`
+	Url          string        `json:"url"`
+	WaterLevel   *float64      `json:"waterLevel,omitempty"`
+}
+
+type TideWidgetEnvelope struct {
+	Data     TideWidget        `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type UsageResponse struct {
+	Days         []DayUsage `json:"days"`
+	Month        string     `json:"month"`
+	Quota        int64      `json:"quota"`
+	Remaining    *int64     `json:"remaining,omitempty"`
+	ResetsAt     int64      `json:"resetsAt"`
+	ResponseType string     `json:"responseType"`
+	Used         int64      `json:"used"`
+}
+
+type UsageResponseEnvelope struct {
+	Data     UsageResponse     `json:"data"`
+	Meta     Meta              `json:"meta"`
+	Warnings []ResponseWarning `json:"warnings"`
+}
+
+type ValidationErrorResponse struct {
+	Code         string       `json:"code"`
+	Details      []ParamError `json:"details"`
+	Error        string       `json:"error"`
+	ResponseType string       `json:"responseType"`
+}
+
+type WaterConditions struct {
+	Conductivity     *Observation `json:"conductivity,omitempty"`
+	WaterTemperature *Observation `json:"waterTemperature,omitempty"`
+}
+
+type WaterLevelAnomaly struct {
+	Detected       bool    `json:"detected"`
+	LocalTime      string  `json:"localTime"`
+	ObservedLevel  float64 `json:"observedLevel"`
+	PredictedLevel float64 `json:"predictedLevel"`
+	Residual       float64 `json:"residual"`
+	Threshold      float64 `json:"threshold"`
+	Timestamp      int64   `json:"timestamp"`
+}
+
+type WeatherForecast struct {
+	LocalTime            string   `json:"localTime"`
+	PressureHpa          *float64 `json:"pressureHpa,omitempty"`
+	Timestamp            int64    `json:"timestamp"`
+	WindDirectionDegrees *float64 `json:"windDirectionDegrees,omitempty"`
+	WindGustKnots        *float64 `json:"windGustKnots,omitempty"`
+	WindSpeedKnots       *float64 `json:"windSpeedKnots,omitempty"`
+}
+
+type WidgetTheme struct {
+	Accent     string `json:"accent"`
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+	Name       string `json:"name"`
+}
+
+// GetAccuracyParams are the query parameters of GET /api/accuracy
+type GetAccuracyParams struct {
+	// Station ID
+	StationID string
+	// Number of UTC days, today included; defaults to 7
+	Days *int64
+}
+
+// GetAccuracy calls GET /api/accuracy. Get the mean absolute error and bias of a tracked station's recent predictions.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetAccuracy(ctx context.Context, params GetAccuracyParams) (*AccuracyStats, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out AccuracyStats
+	if err := c.get(ctx, "/api/accuracy", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CompareStationsParams are the query parameters of GET /api/compare
+type CompareStationsParams struct {
+	// Comma-separated station IDs; the first is the reference for lag and range ratio
+	StationIds string
+	// Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the first station's current day
+	StartDateTime *string
+	// End of the range, in any form startDateTime takes; a date alone runs through that day
+	EndDateTime *string
+	// IANA time zone of dates and times without an offset; defaults to the first station's
+	Tz *string
+	// Minutes between samples; defaults to 6
+	Interval *int64
+}
+
+// CompareStations calls GET /api/compare. Compare 2 to 5 stations' tides on a shared timeline.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) CompareStations(ctx context.Context, params CompareStationsParams) (*StationComparison, error) {
+	query := url.Values{}
+	query.Set("stationIds", params.StationIds)
+	if params.StartDateTime != nil {
+		query.Set("startDateTime", *params.StartDateTime)
+	}
+	if params.EndDateTime != nil {
+		query.Set("endDateTime", *params.EndDateTime)
+	}
+	if params.Tz != nil {
+		query.Set("tz", *params.Tz)
+	}
+	if params.Interval != nil {
+		query.Set("interval", strconv.FormatInt(*params.Interval, 10))
+	}
+
+	var out StationComparison
+	if err := c.get(ctx, "/api/compare", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDaylightLowsParams are the query parameters of GET /api/daylight-lows
+type GetDaylightLowsParams struct {
+	// Station ID
+	StationID string
+	// Only lows under this height, in feet above MLLW
+	Below *float64
+	// First day in the station's local time; defaults to today
+	StartDate *string
+	// Number of days; defaults to 7
+	Days *int64
+}
+
+// GetDaylightLows calls GET /api/daylight-lows. Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetDaylightLows(ctx context.Context, params GetDaylightLowsParams) (*DaylightLows, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Below != nil {
+		query.Set("below", strconv.FormatFloat(*params.Below, 'f', -1, 64))
+	}
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out DaylightLows
+	if err := c.get(ctx, "/api/daylight-lows", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportTideTableParams are the query parameters of GET /api/exports
+type ExportTideTableParams struct {
+	// Station ID
+	StationID string
+	// What to render; defaults to table
+	Kind *string
+	// Calendar year of a table
+	Year *int64
+	// First day of predictions or a chart, in the station's local time
+	StartDate *string
+	// Last day of predictions or a chart, at most 365 days from startDate
+	EndDate *string
+	// Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts
+	Format *string
+}
+
+// ExportTideTable calls GET /api/exports. Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) ExportTideTable(ctx context.Context, params ExportTideTableParams) (*TideTableExport, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Kind != nil {
+		query.Set("kind", *params.Kind)
+	}
+	if params.Year != nil {
+		query.Set("year", strconv.FormatInt(*params.Year, 10))
+	}
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.EndDate != nil {
+		query.Set("endDate", *params.EndDate)
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+
+	var out TideTableExport
+	if err := c.get(ctx, "/api/exports", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExportStatusParams are the query parameters of GET /api/exports/status
+type GetExportStatusParams struct {
+	// Job ID returned by exportTideTable
+	JobID string
+}
+
+// GetExportStatus calls GET /api/exports/status. Get the progress of a submitted export, and its download URL once ready.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetExportStatus(ctx context.Context, params GetExportStatusParams) (*TideTableExport, error) {
+	query := url.Values{}
+	query.Set("jobId", params.JobID)
+
+	var out TideTableExport
+	if err := c.get(ctx, "/api/exports/status", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExtremesParams are the query parameters of GET /api/extremes
+type GetExtremesParams struct {
+	// Station ID
+	StationID string
+	// First day in the station's local time; defaults to today
+	StartDate *string
+	// Number of days; defaults to 7
+	Days *int64
+}
+
+// GetExtremes calls GET /api/extremes. Get a station's daily high and low tides for up to 31 days.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetExtremes(ctx context.Context, params GetExtremesParams) (*ExtremesSummary, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out ExtremesSummary
+	if err := c.get(ctx, "/api/extremes", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetNextExtremesParams are the query parameters of GET /api/extremes/next
+type GetNextExtremesParams struct {
+	// Station ID
+	StationID string
+	// Number of highs and lows; defaults to 4
+	Count *int64
+}
+
+// GetNextExtremes calls GET /api/extremes/next. Get a station's next high and low tides from now.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetNextExtremes(ctx context.Context, params GetNextExtremesParams) (*NextExtremes, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Count != nil {
+		query.Set("count", strconv.FormatInt(*params.Count, 10))
+	}
+
+	var out NextExtremes
+	if err := c.get(ctx, "/api/extremes/next", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetObservationParams are the query parameters of GET /api/observations
+type GetObservationParams struct {
+	// Station ID
+	StationID string
+	// Sensor product; the station must have a sensor for it
+	Product string
+}
+
+// GetObservation calls GET /api/observations. Get a station's latest reading of a sensor product it measures.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetObservation(ctx context.Context, params GetObservationParams) (*ObservationResponse, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	query.Set("product", params.Product)
+
+	var out ObservationResponse
+	if err := c.get(ctx, "/api/observations", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOEmbedParams are the query parameters of GET /api/oembed
+type GetOEmbedParams struct {
+	// Widget page URL, with the stationId and optionally theme and accent
+	Url string
+	// Largest width in pixels the embed may take
+	Maxwidth *int64
+	// Largest height in pixels the embed may take
+	Maxheight *int64
+	// Response format; only json is implemented
+	Format *string
+}
+
+// GetOEmbed calls GET /api/oembed. Get the oEmbed response embedding a station's widget page.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetOEmbed(ctx context.Context, params GetOEmbedParams) (*OEmbed, error) {
+	query := url.Values{}
+	query.Set("url", params.Url)
+	if params.Maxwidth != nil {
+		query.Set("maxwidth", strconv.FormatInt(*params.Maxwidth, 10))
+	}
+	if params.Maxheight != nil {
+		query.Set("maxheight", strconv.FormatInt(*params.Maxheight, 10))
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+
+	var out OEmbed
+	if err := c.get(ctx, "/api/oembed", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRegionsParams are the query parameters of GET /api/regions
+type GetRegionsParams struct {
+	// Only the regions of this state, e.g. WA; ignores case
+	State *string
+	// Only count reference (R) or subordinate (S) stations
+	StationType *string
+	// Only count stations with this capability
+	Capability *string
+	// Only count stations from this data source
+	Source *string
+}
+
+// GetRegions calls GET /api/regions. List the stations' states and regions with their station counts and a few stations each.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetRegions(ctx context.Context, params GetRegionsParams) (*RegionsResponse, error) {
+	query := url.Values{}
+	if params.State != nil {
+		query.Set("state", *params.State)
+	}
+	if params.StationType != nil {
+		query.Set("stationType", *params.StationType)
+	}
+	if params.Capability != nil {
+		query.Set("capability", *params.Capability)
+	}
+	if params.Source != nil {
+		query.Set("source", *params.Source)
+	}
+
+	var out RegionsResponse
+	if err := c.get(ctx, "/api/regions", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStationsParams are the query parameters of GET /api/stations
+type GetStationsParams struct {
+	// Station ID
+	StationID *string
+	// Latitude in degrees
+	Lat *float64
+	// Longitude in degrees
+	Lon *float64
+	// Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise)
+	Limit *int64
+	// Number of nearest stations to skip, for paging through results
+	Offset *int64
+	// Only reference (R) or subordinate (S) stations
+	StationType *string
+	// Only stations with this capability
+	Capability *string
+	// Only stations from this data source
+	Source *string
+	// Unit of each station's distance from the point; defaults to km
+	DistanceUnit *string
+	// Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations
+	Format *string
+	// Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored
+	Fields *string
+}
+
+// GetStations calls GET /api/stations. Find a station by ID, or the stations nearest a point.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetStations(ctx context.Context, params GetStationsParams) (*StationsResponse, error) {
+	query := url.Values{}
+	if params.StationID != nil {
+		query.Set("stationId", *params.StationID)
+	}
+	if params.Lat != nil {
+		query.Set("lat", strconv.FormatFloat(*params.Lat, 'f', -1, 64))
+	}
+	if params.Lon != nil {
+		query.Set("lon", strconv.FormatFloat(*params.Lon, 'f', -1, 64))
+	}
+	if params.Limit != nil {
+		query.Set("limit", strconv.FormatInt(*params.Limit, 10))
+	}
+	if params.Offset != nil {
+		query.Set("offset", strconv.FormatInt(*params.Offset, 10))
+	}
+	if params.StationType != nil {
+		query.Set("stationType", *params.StationType)
+	}
+	if params.Capability != nil {
+		query.Set("capability", *params.Capability)
+	}
+	if params.Source != nil {
+		query.Set("source", *params.Source)
+	}
+	if params.DistanceUnit != nil {
+		query.Set("distanceUnit", *params.DistanceUnit)
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+	if params.Fields != nil {
+		query.Set("fields", *params.Fields)
+	}
+
+	var out StationsResponse
+	if err := c.get(ctx, "/api/stations", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SyncPredictionsParams are the query parameters of GET /api/sync
+type SyncPredictionsParams struct {
+	// Comma-separated IDs of up to 20 stations
+	StationIds string
+	// syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day
+	Since *int64
+	// Days the client keeps from today, the same each sync; defaults to 7
+	Days *int64
+}
+
+// SyncPredictions calls GET /api/sync. Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) SyncPredictions(ctx context.Context, params SyncPredictionsParams) (*PredictionSync, error) {
+	query := url.Values{}
+	query.Set("stationIds", params.StationIds)
+	if params.Since != nil {
+		query.Set("since", strconv.FormatInt(*params.Since, 10))
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out PredictionSync
+	if err := c.get(ctx, "/api/sync", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTidesParams are the query parameters of GET /api/tides
+type GetTidesParams struct {
+	// Station ID
+	StationID *string
+	// Latitude in degrees
+	Lat *float64
+	// Longitude in degrees
+	Lon *float64
+	// Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the station's current day
+	StartDateTime *string
+	// End of the range, in any form startDateTime takes; a date alone runs through that day
+	EndDateTime *string
+	// IANA time zone of dates and times without an offset; defaults to the station's
+	Tz *string
+	// Interpolation between known points
+	Interpolation *string
+	// Downsample predictions to at most this many, keeping highs and lows
+	Points *int64
+	// Attach the NWS wind and pressure forecast for the station
+	IncludeWeather *bool
+	// Write localTime values as this locale does instead of ISO 8601
+	Locale *string
+	// Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's
+	Hour12 *bool
+	// Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored
+	Fields *string
+}
+
+// GetTides calls GET /api/tides. Get tide predictions for a station, or for the station nearest a point.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetTides(ctx context.Context, params GetTidesParams) (*ExtendedTideResponse, error) {
+	query := url.Values{}
+	if params.StationID != nil {
+		query.Set("stationId", *params.StationID)
+	}
+	if params.Lat != nil {
+		query.Set("lat", strconv.FormatFloat(*params.Lat, 'f', -1, 64))
+	}
+	if params.Lon != nil {
+		query.Set("lon", strconv.FormatFloat(*params.Lon, 'f', -1, 64))
+	}
+	if params.StartDateTime != nil {
+		query.Set("startDateTime", *params.StartDateTime)
+	}
+	if params.EndDateTime != nil {
+		query.Set("endDateTime", *params.EndDateTime)
+	}
+	if params.Tz != nil {
+		query.Set("tz", *params.Tz)
+	}
+	if params.Interpolation != nil {
+		query.Set("interpolation", *params.Interpolation)
+	}
+	if params.Points != nil {
+		query.Set("points", strconv.FormatInt(*params.Points, 10))
+	}
+	if params.IncludeWeather != nil {
+		query.Set("includeWeather", strconv.FormatBool(*params.IncludeWeather))
+	}
+	if params.Locale != nil {
+		query.Set("locale", *params.Locale)
+	}
+	if params.Hour12 != nil {
+		query.Set("hour12", strconv.FormatBool(*params.Hour12))
+	}
+	if params.Fields != nil {
+		query.Set("fields", *params.Fields)
+	}
+
+	var out ExtendedTideResponse
+	if err := c.get(ctx, "/api/tides", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsageParams are the query parameters of GET /api/usage
+type GetUsageParams struct {
+	// Month as YYYY-MM in UTC; defaults to the current month
+	Month *string
+}
+
+// GetUsage calls GET /api/usage. Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetUsage(ctx context.Context, params GetUsageParams) (*UsageResponse, error) {
+	query := url.Values{}
+	if params.Month != nil {
+		query.Set("month", *params.Month)
+	}
+
+	var out UsageResponse
+	if err := c.get(ctx, "/api/usage", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAccuracyV2Params are the query parameters of GET /api/v2/accuracy
+type GetAccuracyV2Params struct {
+	// Station ID
+	StationID string
+	// Number of UTC days, today included; defaults to 7
+	Days *int64
+}
+
+// GetAccuracyV2 calls GET /api/v2/accuracy. Get the mean absolute error and bias of a tracked station's recent predictions.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetAccuracyV2(ctx context.Context, params GetAccuracyV2Params) (*AccuracyStats, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out AccuracyStats
+	if err := c.get(ctx, "/api/v2/accuracy", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CompareStationsV2Params are the query parameters of GET /api/v2/compare
+type CompareStationsV2Params struct {
+	// Comma-separated station IDs; the first is the reference for lag and range ratio
+	StationIds string
+	// Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the first station's current day
+	StartDateTime *string
+	// End of the range, in any form startDateTime takes; a date alone runs through that day
+	EndDateTime *string
+	// IANA time zone of dates and times without an offset; defaults to the first station's
+	Tz *string
+	// Minutes between samples; defaults to 6
+	Interval *int64
+}
+
+// CompareStationsV2 calls GET /api/v2/compare. Compare 2 to 5 stations' tides on a shared timeline.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) CompareStationsV2(ctx context.Context, params CompareStationsV2Params) (*StationComparison, error) {
+	query := url.Values{}
+	query.Set("stationIds", params.StationIds)
+	if params.StartDateTime != nil {
+		query.Set("startDateTime", *params.StartDateTime)
+	}
+	if params.EndDateTime != nil {
+		query.Set("endDateTime", *params.EndDateTime)
+	}
+	if params.Tz != nil {
+		query.Set("tz", *params.Tz)
+	}
+	if params.Interval != nil {
+		query.Set("interval", strconv.FormatInt(*params.Interval, 10))
+	}
+
+	var out StationComparison
+	if err := c.get(ctx, "/api/v2/compare", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDaylightLowsV2Params are the query parameters of GET /api/v2/daylight-lows
+type GetDaylightLowsV2Params struct {
+	// Station ID
+	StationID string
+	// Only lows under this height, in feet above MLLW
+	Below *float64
+	// First day in the station's local time; defaults to today
+	StartDate *string
+	// Number of days; defaults to 7
+	Days *int64
+}
+
+// GetDaylightLowsV2 calls GET /api/v2/daylight-lows. Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetDaylightLowsV2(ctx context.Context, params GetDaylightLowsV2Params) (*DaylightLows, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Below != nil {
+		query.Set("below", strconv.FormatFloat(*params.Below, 'f', -1, 64))
+	}
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out DaylightLows
+	if err := c.get(ctx, "/api/v2/daylight-lows", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportTideTableV2Params are the query parameters of GET /api/v2/exports
+type ExportTideTableV2Params struct {
+	// Station ID
+	StationID string
+	// What to render; defaults to table
+	Kind *string
+	// Calendar year of a table
+	Year *int64
+	// First day of predictions or a chart, in the station's local time
+	StartDate *string
+	// Last day of predictions or a chart, at most 365 days from startDate
+	EndDate *string
+	// Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts
+	Format *string
+}
+
+// ExportTideTableV2 calls GET /api/v2/exports. Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) ExportTideTableV2(ctx context.Context, params ExportTideTableV2Params) (*TideTableExport, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Kind != nil {
+		query.Set("kind", *params.Kind)
+	}
+	if params.Year != nil {
+		query.Set("year", strconv.FormatInt(*params.Year, 10))
+	}
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.EndDate != nil {
+		query.Set("endDate", *params.EndDate)
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+
+	var out TideTableExport
+	if err := c.get(ctx, "/api/v2/exports", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExportStatusV2Params are the query parameters of GET /api/v2/exports/status
+type GetExportStatusV2Params struct {
+	// Job ID returned by exportTideTable
+	JobID string
+}
+
+// GetExportStatusV2 calls GET /api/v2/exports/status. Get the progress of a submitted export, and its download URL once ready.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetExportStatusV2(ctx context.Context, params GetExportStatusV2Params) (*TideTableExport, error) {
+	query := url.Values{}
+	query.Set("jobId", params.JobID)
+
+	var out TideTableExport
+	if err := c.get(ctx, "/api/v2/exports/status", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExtremesV2Params are the query parameters of GET /api/v2/extremes
+type GetExtremesV2Params struct {
+	// Station ID
+	StationID string
+	// First day in the station's local time; defaults to today
+	StartDate *string
+	// Number of days; defaults to 7
+	Days *int64
+}
+
+// GetExtremesV2 calls GET /api/v2/extremes. Get a station's daily high and low tides for up to 31 days.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetExtremesV2(ctx context.Context, params GetExtremesV2Params) (*ExtremesSummary, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out ExtremesSummary
+	if err := c.get(ctx, "/api/v2/extremes", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetNextExtremesV2Params are the query parameters of GET /api/v2/extremes/next
+type GetNextExtremesV2Params struct {
+	// Station ID
+	StationID string
+	// Number of highs and lows; defaults to 4
+	Count *int64
+}
+
+// GetNextExtremesV2 calls GET /api/v2/extremes/next. Get a station's next high and low tides from now.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetNextExtremesV2(ctx context.Context, params GetNextExtremesV2Params) (*NextExtremes, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Count != nil {
+		query.Set("count", strconv.FormatInt(*params.Count, 10))
+	}
+
+	var out NextExtremes
+	if err := c.get(ctx, "/api/v2/extremes/next", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetObservationV2Params are the query parameters of GET /api/v2/observations
+type GetObservationV2Params struct {
+	// Station ID
+	StationID string
+	// Sensor product; the station must have a sensor for it
+	Product string
+}
+
+// GetObservationV2 calls GET /api/v2/observations. Get a station's latest reading of a sensor product it measures.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetObservationV2(ctx context.Context, params GetObservationV2Params) (*ObservationResponse, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	query.Set("product", params.Product)
+
+	var out ObservationResponse
+	if err := c.get(ctx, "/api/v2/observations", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOEmbedV2Params are the query parameters of GET /api/v2/oembed
+type GetOEmbedV2Params struct {
+	// Widget page URL, with the stationId and optionally theme and accent
+	Url string
+	// Largest width in pixels the embed may take
+	Maxwidth *int64
+	// Largest height in pixels the embed may take
+	Maxheight *int64
+	// Response format; only json is implemented
+	Format *string
+}
+
+// GetOEmbedV2 calls GET /api/v2/oembed. Get the oEmbed response embedding a station's widget page.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetOEmbedV2(ctx context.Context, params GetOEmbedV2Params) (*OEmbed, error) {
+	query := url.Values{}
+	query.Set("url", params.Url)
+	if params.Maxwidth != nil {
+		query.Set("maxwidth", strconv.FormatInt(*params.Maxwidth, 10))
+	}
+	if params.Maxheight != nil {
+		query.Set("maxheight", strconv.FormatInt(*params.Maxheight, 10))
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+
+	var out OEmbed
+	if err := c.get(ctx, "/api/v2/oembed", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRegionsV2Params are the query parameters of GET /api/v2/regions
+type GetRegionsV2Params struct {
+	// Only the regions of this state, e.g. WA; ignores case
+	State *string
+	// Only count reference (R) or subordinate (S) stations
+	StationType *string
+	// Only count stations with this capability
+	Capability *string
+	// Only count stations from this data source
+	Source *string
+}
+
+// GetRegionsV2 calls GET /api/v2/regions. List the stations' states and regions with their station counts and a few stations each.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetRegionsV2(ctx context.Context, params GetRegionsV2Params) (*RegionsResponse, error) {
+	query := url.Values{}
+	if params.State != nil {
+		query.Set("state", *params.State)
+	}
+	if params.StationType != nil {
+		query.Set("stationType", *params.StationType)
+	}
+	if params.Capability != nil {
+		query.Set("capability", *params.Capability)
+	}
+	if params.Source != nil {
+		query.Set("source", *params.Source)
+	}
+
+	var out RegionsResponse
+	if err := c.get(ctx, "/api/v2/regions", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStationsV2Params are the query parameters of GET /api/v2/stations
+type GetStationsV2Params struct {
+	// Station ID
+	StationID *string
+	// Latitude in degrees
+	Lat *float64
+	// Longitude in degrees
+	Lon *float64
+	// Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise)
+	Limit *int64
+	// Number of nearest stations to skip, for paging through results
+	Offset *int64
+	// Only reference (R) or subordinate (S) stations
+	StationType *string
+	// Only stations with this capability
+	Capability *string
+	// Only stations from this data source
+	Source *string
+	// Unit of each station's distance from the point; defaults to km
+	DistanceUnit *string
+	// Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations
+	Format *string
+	// Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored
+	Fields *string
+}
+
+// GetStationsV2 calls GET /api/v2/stations. Find a station by ID, or the stations nearest a point.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetStationsV2(ctx context.Context, params GetStationsV2Params) (*StationsResponse, error) {
+	query := url.Values{}
+	if params.StationID != nil {
+		query.Set("stationId", *params.StationID)
+	}
+	if params.Lat != nil {
+		query.Set("lat", strconv.FormatFloat(*params.Lat, 'f', -1, 64))
+	}
+	if params.Lon != nil {
+		query.Set("lon", strconv.FormatFloat(*params.Lon, 'f', -1, 64))
+	}
+	if params.Limit != nil {
+		query.Set("limit", strconv.FormatInt(*params.Limit, 10))
+	}
+	if params.Offset != nil {
+		query.Set("offset", strconv.FormatInt(*params.Offset, 10))
+	}
+	if params.StationType != nil {
+		query.Set("stationType", *params.StationType)
+	}
+	if params.Capability != nil {
+		query.Set("capability", *params.Capability)
+	}
+	if params.Source != nil {
+		query.Set("source", *params.Source)
+	}
+	if params.DistanceUnit != nil {
+		query.Set("distanceUnit", *params.DistanceUnit)
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+	if params.Fields != nil {
+		query.Set("fields", *params.Fields)
+	}
+
+	var out StationsResponse
+	if err := c.get(ctx, "/api/v2/stations", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SyncPredictionsV2Params are the query parameters of GET /api/v2/sync
+type SyncPredictionsV2Params struct {
+	// Comma-separated IDs of up to 20 stations
+	StationIds string
+	// syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day
+	Since *int64
+	// Days the client keeps from today, the same each sync; defaults to 7
+	Days *int64
+}
+
+// SyncPredictionsV2 calls GET /api/v2/sync. Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) SyncPredictionsV2(ctx context.Context, params SyncPredictionsV2Params) (*PredictionSync, error) {
+	query := url.Values{}
+	query.Set("stationIds", params.StationIds)
+	if params.Since != nil {
+		query.Set("since", strconv.FormatInt(*params.Since, 10))
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out PredictionSync
+	if err := c.get(ctx, "/api/v2/sync", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTidesV2Params are the query parameters of GET /api/v2/tides
+type GetTidesV2Params struct {
+	// Station ID
+	StationID *string
+	// Latitude in degrees
+	Lat *float64
+	// Longitude in degrees
+	Lon *float64
+	// Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the station's current day
+	StartDateTime *string
+	// End of the range, in any form startDateTime takes; a date alone runs through that day
+	EndDateTime *string
+	// IANA time zone of dates and times without an offset; defaults to the station's
+	Tz *string
+	// Interpolation between known points
+	Interpolation *string
+	// Downsample predictions to at most this many, keeping highs and lows
+	Points *int64
+	// Attach the NWS wind and pressure forecast for the station
+	IncludeWeather *bool
+	// Write localTime values as this locale does instead of ISO 8601
+	Locale *string
+	// Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's
+	Hour12 *bool
+	// Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored
+	Fields *string
+}
+
+// GetTidesV2 calls GET /api/v2/tides. Get tide predictions for a station, or for the station nearest a point.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetTidesV2(ctx context.Context, params GetTidesV2Params) (*TideResponseV2, error) {
+	query := url.Values{}
+	if params.StationID != nil {
+		query.Set("stationId", *params.StationID)
+	}
+	if params.Lat != nil {
+		query.Set("lat", strconv.FormatFloat(*params.Lat, 'f', -1, 64))
+	}
+	if params.Lon != nil {
+		query.Set("lon", strconv.FormatFloat(*params.Lon, 'f', -1, 64))
+	}
+	if params.StartDateTime != nil {
+		query.Set("startDateTime", *params.StartDateTime)
+	}
+	if params.EndDateTime != nil {
+		query.Set("endDateTime", *params.EndDateTime)
+	}
+	if params.Tz != nil {
+		query.Set("tz", *params.Tz)
+	}
+	if params.Interpolation != nil {
+		query.Set("interpolation", *params.Interpolation)
+	}
+	if params.Points != nil {
+		query.Set("points", strconv.FormatInt(*params.Points, 10))
+	}
+	if params.IncludeWeather != nil {
+		query.Set("includeWeather", strconv.FormatBool(*params.IncludeWeather))
+	}
+	if params.Locale != nil {
+		query.Set("locale", *params.Locale)
+	}
+	if params.Hour12 != nil {
+		query.Set("hour12", strconv.FormatBool(*params.Hour12))
+	}
+	if params.Fields != nil {
+		query.Set("fields", *params.Fields)
+	}
+
+	var out TideResponseV2
+	if err := c.get(ctx, "/api/v2/tides", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsageV2Params are the query parameters of GET /api/v2/usage
+type GetUsageV2Params struct {
+	// Month as YYYY-MM in UTC; defaults to the current month
+	Month *string
+}
+
+// GetUsageV2 calls GET /api/v2/usage. Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetUsageV2(ctx context.Context, params GetUsageV2Params) (*UsageResponse, error) {
+	query := url.Values{}
+	if params.Month != nil {
+		query.Set("month", *params.Month)
+	}
+
+	var out UsageResponse
+	if err := c.get(ctx, "/api/v2/usage", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWidgetV2Params are the query parameters of GET /api/v2/widget
+type GetWidgetV2Params struct {
+	// Station ID
+	StationID string
+	// Color scheme; defaults to light
+	Theme *string
+	// Accent color as rrggbb, replacing the theme's
+	Accent *string
+}
+
+// GetWidgetV2 calls GET /api/v2/widget. Get a station's level now and next highs and lows for the embeddable tide module.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetWidgetV2(ctx context.Context, params GetWidgetV2Params) (*TideWidget, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Theme != nil {
+		query.Set("theme", *params.Theme)
+	}
+	if params.Accent != nil {
+		query.Set("accent", *params.Accent)
+	}
+
+	var out TideWidget
+	if err := c.get(ctx, "/api/v2/widget", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAccuracyV3Params are the query parameters of GET /api/v3/accuracy
+type GetAccuracyV3Params struct {
+	// Station ID
+	StationID string
+	// Number of UTC days, today included; defaults to 7
+	Days *int64
+}
+
+// GetAccuracyV3 calls GET /api/v3/accuracy. Get the mean absolute error and bias of a tracked station's recent predictions.
+func (c *Client) GetAccuracyV3(ctx context.Context, params GetAccuracyV3Params) (*AccuracyStatsEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out AccuracyStatsEnvelope
+	if err := c.get(ctx, "/api/v3/accuracy", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CompareStationsV3Params are the query parameters of GET /api/v3/compare
+type CompareStationsV3Params struct {
+	// Comma-separated station IDs; the first is the reference for lag and range ratio
+	StationIds string
+	// Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the first station's current day
+	StartDateTime *string
+	// End of the range, in any form startDateTime takes; a date alone runs through that day
+	EndDateTime *string
+	// IANA time zone of dates and times without an offset; defaults to the first station's
+	Tz *string
+	// Minutes between samples; defaults to 6
+	Interval *int64
+}
+
+// CompareStationsV3 calls GET /api/v3/compare. Compare 2 to 5 stations' tides on a shared timeline.
+func (c *Client) CompareStationsV3(ctx context.Context, params CompareStationsV3Params) (*StationComparisonEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationIds", params.StationIds)
+	if params.StartDateTime != nil {
+		query.Set("startDateTime", *params.StartDateTime)
+	}
+	if params.EndDateTime != nil {
+		query.Set("endDateTime", *params.EndDateTime)
+	}
+	if params.Tz != nil {
+		query.Set("tz", *params.Tz)
+	}
+	if params.Interval != nil {
+		query.Set("interval", strconv.FormatInt(*params.Interval, 10))
+	}
+
+	var out StationComparisonEnvelope
+	if err := c.get(ctx, "/api/v3/compare", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDaylightLowsV3Params are the query parameters of GET /api/v3/daylight-lows
+type GetDaylightLowsV3Params struct {
+	// Station ID
+	StationID string
+	// Only lows under this height, in feet above MLLW
+	Below *float64
+	// First day in the station's local time; defaults to today
+	StartDate *string
+	// Number of days; defaults to 7
+	Days *int64
+}
+
+// GetDaylightLowsV3 calls GET /api/v3/daylight-lows. Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling.
+func (c *Client) GetDaylightLowsV3(ctx context.Context, params GetDaylightLowsV3Params) (*DaylightLowsEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Below != nil {
+		query.Set("below", strconv.FormatFloat(*params.Below, 'f', -1, 64))
+	}
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out DaylightLowsEnvelope
+	if err := c.get(ctx, "/api/v3/daylight-lows", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportTideTableV3Params are the query parameters of GET /api/v3/exports
+type ExportTideTableV3Params struct {
+	// Station ID
+	StationID string
+	// What to render; defaults to table
+	Kind *string
+	// Calendar year of a table
+	Year *int64
+	// First day of predictions or a chart, in the station's local time
+	StartDate *string
+	// Last day of predictions or a chart, at most 365 days from startDate
+	EndDate *string
+	// Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts
+	Format *string
+}
+
+// ExportTideTableV3 calls GET /api/v3/exports. Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready.
+func (c *Client) ExportTideTableV3(ctx context.Context, params ExportTideTableV3Params) (*TideTableExportEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Kind != nil {
+		query.Set("kind", *params.Kind)
+	}
+	if params.Year != nil {
+		query.Set("year", strconv.FormatInt(*params.Year, 10))
+	}
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.EndDate != nil {
+		query.Set("endDate", *params.EndDate)
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+
+	var out TideTableExportEnvelope
+	if err := c.get(ctx, "/api/v3/exports", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExportStatusV3Params are the query parameters of GET /api/v3/exports/status
+type GetExportStatusV3Params struct {
+	// Job ID returned by exportTideTable
+	JobID string
+}
+
+// GetExportStatusV3 calls GET /api/v3/exports/status. Get the progress of a submitted export, and its download URL once ready.
+func (c *Client) GetExportStatusV3(ctx context.Context, params GetExportStatusV3Params) (*TideTableExportEnvelope, error) {
+	query := url.Values{}
+	query.Set("jobId", params.JobID)
+
+	var out TideTableExportEnvelope
+	if err := c.get(ctx, "/api/v3/exports/status", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetExtremesV3Params are the query parameters of GET /api/v3/extremes
+type GetExtremesV3Params struct {
+	// Station ID
+	StationID string
+	// First day in the station's local time; defaults to today
+	StartDate *string
+	// Number of days; defaults to 7
+	Days *int64
+}
+
+// GetExtremesV3 calls GET /api/v3/extremes. Get a station's daily high and low tides for up to 31 days.
+func (c *Client) GetExtremesV3(ctx context.Context, params GetExtremesV3Params) (*ExtremesSummaryEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.StartDate != nil {
+		query.Set("startDate", *params.StartDate)
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out ExtremesSummaryEnvelope
+	if err := c.get(ctx, "/api/v3/extremes", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetNextExtremesV3Params are the query parameters of GET /api/v3/extremes/next
+type GetNextExtremesV3Params struct {
+	// Station ID
+	StationID string
+	// Number of highs and lows; defaults to 4
+	Count *int64
+}
+
+// GetNextExtremesV3 calls GET /api/v3/extremes/next. Get a station's next high and low tides from now.
+func (c *Client) GetNextExtremesV3(ctx context.Context, params GetNextExtremesV3Params) (*NextExtremesEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Count != nil {
+		query.Set("count", strconv.FormatInt(*params.Count, 10))
+	}
+
+	var out NextExtremesEnvelope
+	if err := c.get(ctx, "/api/v3/extremes/next", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetObservationV3Params are the query parameters of GET /api/v3/observations
+type GetObservationV3Params struct {
+	// Station ID
+	StationID string
+	// Sensor product; the station must have a sensor for it
+	Product string
+}
+
+// GetObservationV3 calls GET /api/v3/observations. Get a station's latest reading of a sensor product it measures.
+func (c *Client) GetObservationV3(ctx context.Context, params GetObservationV3Params) (*ObservationResponseEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	query.Set("product", params.Product)
+
+	var out ObservationResponseEnvelope
+	if err := c.get(ctx, "/api/v3/observations", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOEmbedV3Params are the query parameters of GET /api/v3/oembed
+type GetOEmbedV3Params struct {
+	// Widget page URL, with the stationId and optionally theme and accent
+	Url string
+	// Largest width in pixels the embed may take
+	Maxwidth *int64
+	// Largest height in pixels the embed may take
+	Maxheight *int64
+	// Response format; only json is implemented
+	Format *string
+}
+
+// GetOEmbedV3 calls GET /api/v3/oembed. Get the oEmbed response embedding a station's widget page.
+func (c *Client) GetOEmbedV3(ctx context.Context, params GetOEmbedV3Params) (*OEmbed, error) {
+	query := url.Values{}
+	query.Set("url", params.Url)
+	if params.Maxwidth != nil {
+		query.Set("maxwidth", strconv.FormatInt(*params.Maxwidth, 10))
+	}
+	if params.Maxheight != nil {
+		query.Set("maxheight", strconv.FormatInt(*params.Maxheight, 10))
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+
+	var out OEmbed
+	if err := c.get(ctx, "/api/v3/oembed", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRegionsV3Params are the query parameters of GET /api/v3/regions
+type GetRegionsV3Params struct {
+	// Only the regions of this state, e.g. WA; ignores case
+	State *string
+	// Only count reference (R) or subordinate (S) stations
+	StationType *string
+	// Only count stations with this capability
+	Capability *string
+	// Only count stations from this data source
+	Source *string
+}
+
+// GetRegionsV3 calls GET /api/v3/regions. List the stations' states and regions with their station counts and a few stations each.
+func (c *Client) GetRegionsV3(ctx context.Context, params GetRegionsV3Params) (*RegionsResponseEnvelope, error) {
+	query := url.Values{}
+	if params.State != nil {
+		query.Set("state", *params.State)
+	}
+	if params.StationType != nil {
+		query.Set("stationType", *params.StationType)
+	}
+	if params.Capability != nil {
+		query.Set("capability", *params.Capability)
+	}
+	if params.Source != nil {
+		query.Set("source", *params.Source)
+	}
+
+	var out RegionsResponseEnvelope
+	if err := c.get(ctx, "/api/v3/regions", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStationsV3Params are the query parameters of GET /api/v3/stations
+type GetStationsV3Params struct {
+	// Station ID
+	StationID *string
+	// Latitude in degrees
+	Lat *float64
+	// Longitude in degrees
+	Lon *float64
+	// Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise)
+	Limit *int64
+	// Number of nearest stations to skip, for paging through results
+	Offset *int64
+	// Only reference (R) or subordinate (S) stations
+	StationType *string
+	// Only stations with this capability
+	Capability *string
+	// Only stations from this data source
+	Source *string
+	// Unit of each station's distance from the point; defaults to km
+	DistanceUnit *string
+	// Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations
+	Format *string
+	// Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored
+	Fields *string
+}
+
+// GetStationsV3 calls GET /api/v3/stations. Find a station by ID, or the stations nearest a point.
+func (c *Client) GetStationsV3(ctx context.Context, params GetStationsV3Params) (*StationsResponseEnvelope, error) {
+	query := url.Values{}
+	if params.StationID != nil {
+		query.Set("stationId", *params.StationID)
+	}
+	if params.Lat != nil {
+		query.Set("lat", strconv.FormatFloat(*params.Lat, 'f', -1, 64))
+	}
+	if params.Lon != nil {
+		query.Set("lon", strconv.FormatFloat(*params.Lon, 'f', -1, 64))
+	}
+	if params.Limit != nil {
+		query.Set("limit", strconv.FormatInt(*params.Limit, 10))
+	}
+	if params.Offset != nil {
+		query.Set("offset", strconv.FormatInt(*params.Offset, 10))
+	}
+	if params.StationType != nil {
+		query.Set("stationType", *params.StationType)
+	}
+	if params.Capability != nil {
+		query.Set("capability", *params.Capability)
+	}
+	if params.Source != nil {
+		query.Set("source", *params.Source)
+	}
+	if params.DistanceUnit != nil {
+		query.Set("distanceUnit", *params.DistanceUnit)
+	}
+	if params.Format != nil {
+		query.Set("format", *params.Format)
+	}
+	if params.Fields != nil {
+		query.Set("fields", *params.Fields)
+	}
+
+	var out StationsResponseEnvelope
+	if err := c.get(ctx, "/api/v3/stations", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SyncPredictionsV3Params are the query parameters of GET /api/v3/sync
+type SyncPredictionsV3Params struct {
+	// Comma-separated IDs of up to 20 stations
+	StationIds string
+	// syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day
+	Since *int64
+	// Days the client keeps from today, the same each sync; defaults to 7
+	Days *int64
+}
+
+// SyncPredictionsV3 calls GET /api/v3/sync. Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced.
+func (c *Client) SyncPredictionsV3(ctx context.Context, params SyncPredictionsV3Params) (*PredictionSyncEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationIds", params.StationIds)
+	if params.Since != nil {
+		query.Set("since", strconv.FormatInt(*params.Since, 10))
+	}
+	if params.Days != nil {
+		query.Set("days", strconv.FormatInt(*params.Days, 10))
+	}
+
+	var out PredictionSyncEnvelope
+	if err := c.get(ctx, "/api/v3/sync", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTidesV3Params are the query parameters of GET /api/v3/tides
+type GetTidesV3Params struct {
+	// Station ID
+	StationID *string
+	// Latitude in degrees
+	Lat *float64
+	// Longitude in degrees
+	Lon *float64
+	// Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the station's current day
+	StartDateTime *string
+	// End of the range, in any form startDateTime takes; a date alone runs through that day
+	EndDateTime *string
+	// IANA time zone of dates and times without an offset; defaults to the station's
+	Tz *string
+	// Interpolation between known points
+	Interpolation *string
+	// Downsample predictions to at most this many, keeping highs and lows
+	Points *int64
+	// Attach the NWS wind and pressure forecast for the station
+	IncludeWeather *bool
+	// Write localTime values as this locale does instead of ISO 8601
+	Locale *string
+	// Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's
+	Hour12 *bool
+	// Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored
+	Fields *string
+}
+
+// GetTidesV3 calls GET /api/v3/tides. Get tide predictions for a station, or for the station nearest a point.
+func (c *Client) GetTidesV3(ctx context.Context, params GetTidesV3Params) (*TideResponseV2Envelope, error) {
+	query := url.Values{}
+	if params.StationID != nil {
+		query.Set("stationId", *params.StationID)
+	}
+	if params.Lat != nil {
+		query.Set("lat", strconv.FormatFloat(*params.Lat, 'f', -1, 64))
+	}
+	if params.Lon != nil {
+		query.Set("lon", strconv.FormatFloat(*params.Lon, 'f', -1, 64))
+	}
+	if params.StartDateTime != nil {
+		query.Set("startDateTime", *params.StartDateTime)
+	}
+	if params.EndDateTime != nil {
+		query.Set("endDateTime", *params.EndDateTime)
+	}
+	if params.Tz != nil {
+		query.Set("tz", *params.Tz)
+	}
+	if params.Interpolation != nil {
+		query.Set("interpolation", *params.Interpolation)
+	}
+	if params.Points != nil {
+		query.Set("points", strconv.FormatInt(*params.Points, 10))
+	}
+	if params.IncludeWeather != nil {
+		query.Set("includeWeather", strconv.FormatBool(*params.IncludeWeather))
+	}
+	if params.Locale != nil {
+		query.Set("locale", *params.Locale)
+	}
+	if params.Hour12 != nil {
+		query.Set("hour12", strconv.FormatBool(*params.Hour12))
+	}
+	if params.Fields != nil {
+		query.Set("fields", *params.Fields)
+	}
+
+	var out TideResponseV2Envelope
+	if err := c.get(ctx, "/api/v3/tides", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsageV3Params are the query parameters of GET /api/v3/usage
+type GetUsageV3Params struct {
+	// Month as YYYY-MM in UTC; defaults to the current month
+	Month *string
+}
+
+// GetUsageV3 calls GET /api/v3/usage. Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left.
+func (c *Client) GetUsageV3(ctx context.Context, params GetUsageV3Params) (*UsageResponseEnvelope, error) {
+	query := url.Values{}
+	if params.Month != nil {
+		query.Set("month", *params.Month)
+	}
+
+	var out UsageResponseEnvelope
+	if err := c.get(ctx, "/api/v3/usage", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWidgetV3Params are the query parameters of GET /api/v3/widget
+type GetWidgetV3Params struct {
+	// Station ID
+	StationID string
+	// Color scheme; defaults to light
+	Theme *string
+	// Accent color as rrggbb, replacing the theme's
+	Accent *string
+}
+
+// GetWidgetV3 calls GET /api/v3/widget. Get a station's level now and next highs and lows for the embeddable tide module.
+func (c *Client) GetWidgetV3(ctx context.Context, params GetWidgetV3Params) (*TideWidgetEnvelope, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Theme != nil {
+		query.Set("theme", *params.Theme)
+	}
+	if params.Accent != nil {
+		query.Set("accent", *params.Accent)
+	}
+
+	var out TideWidgetEnvelope
+	if err := c.get(ctx, "/api/v3/widget", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetWidgetParams are the query parameters of GET /api/widget
+type GetWidgetParams struct {
+	// Station ID
+	StationID string
+	// Color scheme; defaults to light
+	Theme *string
+	// Accent color as rrggbb, replacing the theme's
+	Accent *string
+}
+
+// GetWidget calls GET /api/widget. Get a station's level now and next highs and lows for the embeddable tide module.
+//
+// Deprecated: use the latest version of this operation.
+func (c *Client) GetWidget(ctx context.Context, params GetWidgetParams) (*TideWidget, error) {
+	query := url.Values{}
+	query.Set("stationId", params.StationID)
+	if params.Theme != nil {
+		query.Set("theme", *params.Theme)
+	}
+	if params.Accent != nil {
+		query.Set("accent", *params.Accent)
+	}
+
+	var out TideWidget
+	if err := c.get(ctx, "/api/widget", query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+type GraphQLStation struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	State          *string  `json:"state"`
+	Region         *string  `json:"region"`
+	Distance       float64  `json:"distance"`
+	DistanceUnit   string   `json:"distanceUnit"`
+	Bearing        *float64 `json:"bearing"`
+	Latitude       float64  `json:"latitude"`
+	Longitude      float64  `json:"longitude"`
+	Source         string   `json:"source"`
+	Capabilities   []string `json:"capabilities"`
+	TimeZoneOffset int64    `json:"timeZoneOffset"`
+	TimeZone       *string  `json:"timeZone"`
+	StationType    *string  `json:"stationType"`
+}
+
+type GraphQLStationConnection struct {
+	Edges      []GraphQLStationEdge `json:"edges"`
+	PageInfo   GraphQLPageInfo      `json:"pageInfo"`
+	TotalCount int64                `json:"totalCount"`
+	Stale      bool                 `json:"stale"`
+}
+
+type GraphQLStationMap struct {
+	Stations   []GraphQLStation        `json:"stations"`
+	Clusters   []GraphQLStationCluster `json:"clusters"`
+	TotalCount int64                   `json:"totalCount"`
+}
+
+type GraphQLStationRegion struct {
+	State           *string          `json:"state"`
+	Region          *string          `json:"region"`
+	StationCount    int64            `json:"stationCount"`
+	Representatives []GraphQLStation `json:"representatives"`
+}
+
+type GraphQLStationCluster struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int64   `json:"count"`
+	North     float64 `json:"north"`
+	South     float64 `json:"south"`
+	East      float64 `json:"east"`
+	West      float64 `json:"west"`
+}
+
+type GraphQLStationEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   GraphQLStation `json:"node"`
+}
+
+type GraphQLPageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type GraphQLTideData struct {
+	Timestamp             int64                        `json:"timestamp"`
+	LocalTime             string                       `json:"localTime"`
+	WaterLevel            float64                      `json:"waterLevel"`
+	PredictedLevel        float64                      `json:"predictedLevel"`
+	NearestStation        string                       `json:"nearestStation"`
+	Location              *string                      `json:"location"`
+	Latitude              float64                      `json:"latitude"`
+	Longitude             float64                      `json:"longitude"`
+	StationDistance       float64                      `json:"stationDistance"`
+	TideType              string                       `json:"tideType"`
+	CalculationMethod     string                       `json:"calculationMethod"`
+	Predictions           []GraphQLTidePrediction      `json:"predictions"`
+	Extremes              []GraphQLTideExtreme         `json:"extremes"`
+	TimeZoneOffsetSeconds int64                        `json:"timeZoneOffsetSeconds"`
+	Summary               *GraphQLTideSummary          `json:"summary"`
+	Astronomy             *GraphQLTideAstronomy        `json:"astronomy"`
+	Conditions            *GraphQLWaterConditions      `json:"conditions"`
+	Weather               *GraphQLMarineWeather        `json:"weather"`
+	Confidence            *GraphQLPredictionConfidence `json:"confidence"`
+	Offsets               *GraphQLTideOffsets          `json:"offsets"`
+	Anomaly               *GraphQLWaterLevelAnomaly    `json:"anomaly"`
+	Warnings              []GraphQLResponseWarning     `json:"warnings"`
+}
+
+type GraphQLResponseWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type GraphQLTideSummary struct {
+	NextHigh            *GraphQLTideExtreme `json:"nextHigh"`
+	NextLow             *GraphQLTideExtreme `json:"nextLow"`
+	Trend               *string             `json:"trend"`
+	TodayRange          *GraphQLTideRange   `json:"todayRange"`
+	CycleElapsedPercent *float64            `json:"cycleElapsedPercent"`
+}
+
+type GraphQLTideAstronomy struct {
+	TideCycle         string  `json:"tideCycle"`
+	MoonPhase         string  `json:"moonPhase"`
+	DaysSinceNewMoon  float64 `json:"daysSinceNewMoon"`
+	DaysSinceFullMoon float64 `json:"daysSinceFullMoon"`
+}
+
+type GraphQLWaterConditions struct {
+	WaterTemperature *GraphQLObservation `json:"waterTemperature"`
+	Conductivity     *GraphQLObservation `json:"conductivity"`
+}
+
+type GraphQLPredictionConfidence struct {
+	Level             string  `json:"level"`
+	MeanAbsoluteError float64 `json:"meanAbsoluteError"`
+	Bias              float64 `json:"bias"`
+	Samples           int64   `json:"samples"`
+	Days              int64   `json:"days"`
+}
+
+type GraphQLTideOffsets struct {
+	ReferenceStationID    string  `json:"referenceStationId"`
+	ReferenceStationName  *string `json:"referenceStationName"`
+	TimeOffsetHighMinutes int64   `json:"timeOffsetHighMinutes"`
+	TimeOffsetLowMinutes  int64   `json:"timeOffsetLowMinutes"`
+	HeightOffsetHigh      float64 `json:"heightOffsetHigh"`
+	HeightOffsetLow       float64 `json:"heightOffsetLow"`
+	HeightAdjustment      string  `json:"heightAdjustment"`
+}
+
+type GraphQLWaterLevelAnomaly struct {
+	Detected       bool    `json:"detected"`
+	Residual       float64 `json:"residual"`
+	Threshold      float64 `json:"threshold"`
+	ObservedLevel  float64 `json:"observedLevel"`
+	PredictedLevel float64 `json:"predictedLevel"`
+	Timestamp      int64   `json:"timestamp"`
+	LocalTime      string  `json:"localTime"`
+}
+
+type GraphQLAccuracyStats struct {
+	StationID         string   `json:"stationId"`
+	StationName       string   `json:"stationName"`
+	Days              int64    `json:"days"`
+	Samples           int64    `json:"samples"`
+	MeanAbsoluteError *float64 `json:"meanAbsoluteError"`
+	Bias              *float64 `json:"bias"`
+	Confidence        *string  `json:"confidence"`
+}
+
+type GraphQLStationListChange struct {
+	ID         string                 `json:"id"`
+	Source     string                 `json:"source"`
+	DetectedAt int64                  `json:"detectedAt"`
+	Stations   []GraphQLStationChange `json:"stations"`
+	Omitted    int64                  `json:"omitted"`
+}
+
+type GraphQLStationChange struct {
+	StationID string                      `json:"stationId"`
+	Name      string                      `json:"name"`
+	Change    string                      `json:"change"`
+	Fields    []GraphQLStationFieldChange `json:"fields"`
+}
+
+type GraphQLStationFieldChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+type GraphQLStationObservation struct {
+	StationID   string             `json:"stationId"`
+	StationName string             `json:"stationName"`
+	Product     string             `json:"product"`
+	Observation GraphQLObservation `json:"observation"`
+}
+
+type GraphQLObservation struct {
+	Timestamp int64   `json:"timestamp"`
+	LocalTime string  `json:"localTime"`
+	Value     float64 `json:"value"`
+	Units     string  `json:"units"`
+}
+
+type GraphQLMarineWeather struct {
+	Source    string                   `json:"source"`
+	Available bool                     `json:"available"`
+	Forecast  []GraphQLWeatherForecast `json:"forecast"`
+}
+
+type GraphQLWeatherForecast struct {
+	Timestamp            int64    `json:"timestamp"`
+	LocalTime            string   `json:"localTime"`
+	WindSpeedKnots       *float64 `json:"windSpeedKnots"`
+	WindGustKnots        *float64 `json:"windGustKnots"`
+	WindDirectionDegrees *float64 `json:"windDirectionDegrees"`
+	PressureHpa          *float64 `json:"pressureHpa"`
+}
+
+type GraphQLTideRange struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+type GraphQLTidePrediction struct {
+	Timestamp int64   `json:"timestamp"`
+	LocalTime string  `json:"localTime"`
+	Height    float64 `json:"height"`
+}
+
+type GraphQLTideExtreme struct {
+	Type      string  `json:"type"`
+	Timestamp int64   `json:"timestamp"`
+	LocalTime string  `json:"localTime"`
+	Height    float64 `json:"height"`
+}
+
+type GraphQLExtremesSummary struct {
+	StationID   string                 `json:"stationId"`
+	StationName string                 `json:"stationName"`
+	TimeZone    *string                `json:"timeZone"`
+	Days        []GraphQLDailyExtremes `json:"days"`
+}
+
+type GraphQLTideExtremes struct {
+	StationID   string               `json:"stationId"`
+	StationName string               `json:"stationName"`
+	TimeZone    *string              `json:"timeZone"`
+	Extremes    []GraphQLTideExtreme `json:"extremes"`
+}
+
+type GraphQLNextExtremes struct {
+	StationID   string               `json:"stationId"`
+	StationName string               `json:"stationName"`
+	TimeZone    *string              `json:"timeZone"`
+	Extremes    []GraphQLTideExtreme `json:"extremes"`
+}
+
+type GraphQLDailyExtremes struct {
+	Date     string                  `json:"date"`
+	Extremes []GraphQLCompactExtreme `json:"extremes"`
+}
+
+type GraphQLCompactExtreme struct {
+	Type      string  `json:"type"`
+	Time      string  `json:"time"`
+	Timestamp int64   `json:"timestamp"`
+	Height    float64 `json:"height"`
+}
+
+type GraphQLDaylightLows struct {
+	StationID   string               `json:"stationId"`
+	StationName string               `json:"stationName"`
+	TimeZone    *string              `json:"timeZone"`
+	Below       *float64             `json:"below"`
+	Days        []GraphQLDaylightDay `json:"days"`
+}
+
+type GraphQLDaylightDay struct {
+	Date            string                  `json:"date"`
+	Sunrise         *int64                  `json:"sunrise"`
+	Sunset          *int64                  `json:"sunset"`
+	DaylightMinutes int64                   `json:"daylightMinutes"`
+	Lows            []GraphQLCompactExtreme `json:"lows"`
+}
+
+type GraphQLStationComparison struct {
+	IntervalMinutes int64                    `json:"intervalMinutes"`
+	Timestamps      []int64                  `json:"timestamps"`
+	Stations        []GraphQLComparedStation `json:"stations"`
+}
+
+type GraphQLComparedStation struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Heights    []float64 `json:"heights"`
+	LagMinutes *float64  `json:"lagMinutes"`
+	RangeRatio *float64  `json:"rangeRatio"`
+}
+
+type GraphQLUserProfile struct {
+	UserID           string                   `json:"userId"`
+	Favorites        []GraphQLFavoriteStation `json:"favorites"`
+	DeletedFavorites []GraphQLFavoriteStation `json:"deletedFavorites"`
+	Units            string                   `json:"units"`
+	Datum            string                   `json:"datum"`
+	UpdatedAt        int64                    `json:"updatedAt"`
+}
+
+type GraphQLFavoriteStation struct {
+	StationID string `json:"stationId"`
+	Name      string `json:"name"`
+	AddedAt   int64  `json:"addedAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	DeletedAt *int64 `json:"deletedAt"`
+}
+
+type GraphQLObservationReport struct {
+	ID             string   `json:"id"`
+	StationID      string   `json:"stationId"`
+	Timestamp      int64    `json:"timestamp"`
+	ObservedHeight *float64 `json:"observedHeight"`
+	Condition      *string  `json:"condition"`
+	PhotoUrl       *string  `json:"photoUrl"`
+	SubmittedAt    int64    `json:"submittedAt"`
+}
+
+type GraphQLTideTableExport struct {
+	StationID   string  `json:"stationId"`
+	Year        int64   `json:"year"`
+	Format      string  `json:"format"`
+	Status      string  `json:"status"`
+	DownloadUrl *string `json:"downloadUrl"`
+	ExpiresAt   *int64  `json:"expiresAt"`
+	Error       *string `json:"error"`
+}
+
+// QueryStationsArgs are the arguments of the GraphQL stations query
+type QueryStationsArgs struct {
+	Ids          []string `json:"ids,omitempty"`
+	Lat          *float64 `json:"lat,omitempty"`
+	Lon          *float64 `json:"lon,omitempty"`
+	Limit        *int64   `json:"limit,omitempty"`
+	DistanceUnit *string  `json:"distanceUnit,omitempty"`
+	StationType  *string  `json:"stationType,omitempty"`
+	Capability   *string  `json:"capability,omitempty"`
+	Source       *string  `json:"source,omitempty"`
+}
+
+// QueryStations runs the GraphQL stations query, selecting every field
+func (c *Client) QueryStations(ctx context.Context, args QueryStationsArgs) ([]GraphQLStation, error) {
+	const query = "query($ids: [ID!], $lat: Float, $lon: Float, $limit: Int, $distanceUnit: String, $stationType: String, $capability: String, $source: String) { stations(ids: $ids, lat: $lat, lon: $lon, limit: $limit, distanceUnit: $distanceUnit, stationType: $stationType, capability: $capability, source: $source) { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } }"
+	var out struct {
+		Value []GraphQLStation `json:"stations"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryNearbyStationsArgs are the arguments of the GraphQL nearbyStations query
+type QueryNearbyStationsArgs struct {
+	Lat          float64 `json:"lat"`
+	Lon          float64 `json:"lon"`
+	First        *int64  `json:"first,omitempty"`
+	After        *string `json:"after,omitempty"`
+	DistanceUnit *string `json:"distanceUnit,omitempty"`
+	StationType  *string `json:"stationType,omitempty"`
+	Capability   *string `json:"capability,omitempty"`
+	Source       *string `json:"source,omitempty"`
+}
+
+// QueryNearbyStations runs the GraphQL nearbyStations query, selecting every field
+func (c *Client) QueryNearbyStations(ctx context.Context, args QueryNearbyStationsArgs) (GraphQLStationConnection, error) {
+	const query = "query($lat: Float!, $lon: Float!, $first: Int, $after: String, $distanceUnit: String, $stationType: String, $capability: String, $source: String) { nearbyStations(lat: $lat, lon: $lon, first: $first, after: $after, distanceUnit: $distanceUnit, stationType: $stationType, capability: $capability, source: $source) { edges { cursor node { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } } pageInfo { hasNextPage hasPreviousPage startCursor endCursor } totalCount stale } }"
+	var out struct {
+		Value GraphQLStationConnection `json:"nearbyStations"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryStationsInBoundsArgs are the arguments of the GraphQL stationsInBounds query
+type QueryStationsInBoundsArgs struct {
+	North       float64 `json:"north"`
+	South       float64 `json:"south"`
+	East        float64 `json:"east"`
+	West        float64 `json:"west"`
+	Zoom        *int64  `json:"zoom,omitempty"`
+	StationType *string `json:"stationType,omitempty"`
+	Capability  *string `json:"capability,omitempty"`
+	Source      *string `json:"source,omitempty"`
+}
+
+// QueryStationsInBounds runs the GraphQL stationsInBounds query, selecting every field
+func (c *Client) QueryStationsInBounds(ctx context.Context, args QueryStationsInBoundsArgs) (GraphQLStationMap, error) {
+	const query = "query($north: Float!, $south: Float!, $east: Float!, $west: Float!, $zoom: Int, $stationType: String, $capability: String, $source: String) { stationsInBounds(north: $north, south: $south, east: $east, west: $west, zoom: $zoom, stationType: $stationType, capability: $capability, source: $source) { stations { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } clusters { latitude longitude count north south east west } totalCount } }"
+	var out struct {
+		Value GraphQLStationMap `json:"stationsInBounds"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryRegionsArgs are the arguments of the GraphQL regions query
+type QueryRegionsArgs struct {
+	State       *string `json:"state,omitempty"`
+	StationType *string `json:"stationType,omitempty"`
+	Capability  *string `json:"capability,omitempty"`
+	Source      *string `json:"source,omitempty"`
+}
+
+// QueryRegions runs the GraphQL regions query, selecting every field
+func (c *Client) QueryRegions(ctx context.Context, args QueryRegionsArgs) ([]GraphQLStationRegion, error) {
+	const query = "query($state: String, $stationType: String, $capability: String, $source: String) { regions(state: $state, stationType: $stationType, capability: $capability, source: $source) { state region stationCount representatives { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } } }"
+	var out struct {
+		Value []GraphQLStationRegion `json:"regions"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryTidesArgs are the arguments of the GraphQL tides query
+type QueryTidesArgs struct {
+	StationID      string  `json:"stationId"`
+	StartDateTime  string  `json:"startDateTime"`
+	EndDateTime    string  `json:"endDateTime"`
+	Interpolation  *string `json:"interpolation,omitempty"`
+	Points         *int64  `json:"points,omitempty"`
+	IncludeWeather *bool   `json:"includeWeather,omitempty"`
+	Tz             *string `json:"tz,omitempty"`
+	Locale         *string `json:"locale,omitempty"`
+	Hour12         *bool   `json:"hour12,omitempty"`
+}
+
+// QueryTides runs the GraphQL tides query, selecting every field
+func (c *Client) QueryTides(ctx context.Context, args QueryTidesArgs) (GraphQLTideData, error) {
+	const query = "query($stationId: ID!, $startDateTime: String!, $endDateTime: String!, $interpolation: String, $points: Int, $includeWeather: Boolean, $tz: String, $locale: String, $hour12: Boolean) { tides(stationId: $stationId, startDateTime: $startDateTime, endDateTime: $endDateTime, interpolation: $interpolation, points: $points, includeWeather: $includeWeather, tz: $tz, locale: $locale, hour12: $hour12) { timestamp localTime waterLevel predictedLevel nearestStation location latitude longitude stationDistance tideType calculationMethod predictions { timestamp localTime height } extremes { type timestamp localTime height } timeZoneOffsetSeconds summary { nextHigh { type timestamp localTime height } nextLow { type timestamp localTime height } trend todayRange { low high } cycleElapsedPercent } astronomy { tideCycle moonPhase daysSinceNewMoon daysSinceFullMoon } conditions { waterTemperature { timestamp localTime value units } conductivity { timestamp localTime value units } } weather { source available forecast { timestamp localTime windSpeedKnots windGustKnots windDirectionDegrees pressureHpa } } confidence { level meanAbsoluteError bias samples days } offsets { referenceStationId referenceStationName timeOffsetHighMinutes timeOffsetLowMinutes heightOffsetHigh heightOffsetLow heightAdjustment } anomaly { detected residual threshold observedLevel predictedLevel timestamp localTime } warnings { code message } } }"
+	var out struct {
+		Value GraphQLTideData `json:"tides"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryExtremesArgs are the arguments of the GraphQL extremes query
+type QueryExtremesArgs struct {
+	StationID string  `json:"stationId"`
+	StartDate *string `json:"startDate,omitempty"`
+	Days      *int64  `json:"days,omitempty"`
+}
+
+// QueryExtremes runs the GraphQL extremes query, selecting every field
+func (c *Client) QueryExtremes(ctx context.Context, args QueryExtremesArgs) (GraphQLExtremesSummary, error) {
+	const query = "query($stationId: ID!, $startDate: String, $days: Int) { extremes(stationId: $stationId, startDate: $startDate, days: $days) { stationId stationName timeZone days { date extremes { type time timestamp height } } } }"
+	var out struct {
+		Value GraphQLExtremesSummary `json:"extremes"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryTideExtremesArgs are the arguments of the GraphQL tideExtremes query
+type QueryTideExtremesArgs struct {
+	StationID     string  `json:"stationId"`
+	StartDateTime string  `json:"startDateTime"`
+	EndDateTime   string  `json:"endDateTime"`
+	Tz            *string `json:"tz,omitempty"`
+}
+
+// QueryTideExtremes runs the GraphQL tideExtremes query, selecting every field
+func (c *Client) QueryTideExtremes(ctx context.Context, args QueryTideExtremesArgs) (GraphQLTideExtremes, error) {
+	const query = "query($stationId: ID!, $startDateTime: String!, $endDateTime: String!, $tz: String) { tideExtremes(stationId: $stationId, startDateTime: $startDateTime, endDateTime: $endDateTime, tz: $tz) { stationId stationName timeZone extremes { type timestamp localTime height } } }"
+	var out struct {
+		Value GraphQLTideExtremes `json:"tideExtremes"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryDaylightLowsArgs are the arguments of the GraphQL daylightLows query
+type QueryDaylightLowsArgs struct {
+	StationID string   `json:"stationId"`
+	Below     *float64 `json:"below,omitempty"`
+	StartDate *string  `json:"startDate,omitempty"`
+	Days      *int64   `json:"days,omitempty"`
+}
+
+// QueryDaylightLows runs the GraphQL daylightLows query, selecting every field
+func (c *Client) QueryDaylightLows(ctx context.Context, args QueryDaylightLowsArgs) (GraphQLDaylightLows, error) {
+	const query = "query($stationId: ID!, $below: Float, $startDate: String, $days: Int) { daylightLows(stationId: $stationId, below: $below, startDate: $startDate, days: $days) { stationId stationName timeZone below days { date sunrise sunset daylightMinutes lows { type time timestamp height } } } }"
+	var out struct {
+		Value GraphQLDaylightLows `json:"daylightLows"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryNextExtremesArgs are the arguments of the GraphQL nextExtremes query
+type QueryNextExtremesArgs struct {
+	StationID string `json:"stationId"`
+	Count     *int64 `json:"count,omitempty"`
+}
+
+// QueryNextExtremes runs the GraphQL nextExtremes query, selecting every field
+func (c *Client) QueryNextExtremes(ctx context.Context, args QueryNextExtremesArgs) (GraphQLNextExtremes, error) {
+	const query = "query($stationId: ID!, $count: Int) { nextExtremes(stationId: $stationId, count: $count) { stationId stationName timeZone extremes { type timestamp localTime height } } }"
+	var out struct {
+		Value GraphQLNextExtremes `json:"nextExtremes"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryCompareStationsArgs are the arguments of the GraphQL compareStations query
+type QueryCompareStationsArgs struct {
+	StationIds    []string `json:"stationIds"`
+	StartDateTime *string  `json:"startDateTime,omitempty"`
+	EndDateTime   *string  `json:"endDateTime,omitempty"`
+	Interval      *int64   `json:"interval,omitempty"`
+	Tz            *string  `json:"tz,omitempty"`
+}
+
+// QueryCompareStations runs the GraphQL compareStations query, selecting every field
+func (c *Client) QueryCompareStations(ctx context.Context, args QueryCompareStationsArgs) (GraphQLStationComparison, error) {
+	const query = "query($stationIds: [ID!]!, $startDateTime: String, $endDateTime: String, $interval: Int, $tz: String) { compareStations(stationIds: $stationIds, startDateTime: $startDateTime, endDateTime: $endDateTime, interval: $interval, tz: $tz) { intervalMinutes timestamps stations { id name heights lagMinutes rangeRatio } } }"
+	var out struct {
+		Value GraphQLStationComparison `json:"compareStations"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryObservationArgs are the arguments of the GraphQL observation query
+type QueryObservationArgs struct {
+	StationID string `json:"stationId"`
+	Product   string `json:"product"`
+}
+
+// QueryObservation runs the GraphQL observation query, selecting every field
+func (c *Client) QueryObservation(ctx context.Context, args QueryObservationArgs) (GraphQLStationObservation, error) {
+	const query = "query($stationId: ID!, $product: String!) { observation(stationId: $stationId, product: $product) { stationId stationName product observation { timestamp localTime value units } } }"
+	var out struct {
+		Value GraphQLStationObservation `json:"observation"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryAccuracyArgs are the arguments of the GraphQL accuracy query
+type QueryAccuracyArgs struct {
+	StationID string `json:"stationId"`
+	Days      *int64 `json:"days,omitempty"`
+}
+
+// QueryAccuracy runs the GraphQL accuracy query, selecting every field
+func (c *Client) QueryAccuracy(ctx context.Context, args QueryAccuracyArgs) (GraphQLAccuracyStats, error) {
+	const query = "query($stationId: ID!, $days: Int) { accuracy(stationId: $stationId, days: $days) { stationId stationName days samples meanAbsoluteError bias confidence } }"
+	var out struct {
+		Value GraphQLAccuracyStats `json:"accuracy"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryStationChangesArgs are the arguments of the GraphQL stationChanges query
+type QueryStationChangesArgs struct {
+	Since *string `json:"since,omitempty"`
+	Limit *int64  `json:"limit,omitempty"`
+}
+
+// QueryStationChanges runs the GraphQL stationChanges query, selecting every field
+func (c *Client) QueryStationChanges(ctx context.Context, args QueryStationChangesArgs) ([]GraphQLStationListChange, error) {
+	const query = "query($since: String, $limit: Int) { stationChanges(since: $since, limit: $limit) { id source detectedAt stations { stationId name change fields { field before after } } omitted } }"
+	var out struct {
+		Value []GraphQLStationListChange `json:"stationChanges"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// QueryMeArgs are the arguments of the GraphQL me query
+type QueryMeArgs struct {
+}
+
+// QueryMe runs the GraphQL me query, selecting every field
+func (c *Client) QueryMe(ctx context.Context, args QueryMeArgs) (GraphQLUserProfile, error) {
+	const query = "query { me { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }"
+	var out struct {
+		Value GraphQLUserProfile `json:"me"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// MutateAddFavoriteArgs are the arguments of the GraphQL addFavorite mutation
+type MutateAddFavoriteArgs struct {
+	StationID string `json:"stationId"`
+}
+
+// MutateAddFavorite runs the GraphQL addFavorite mutation, selecting every field
+func (c *Client) MutateAddFavorite(ctx context.Context, args MutateAddFavoriteArgs) (GraphQLUserProfile, error) {
+	const query = "mutation($stationId: ID!) { addFavorite(stationId: $stationId) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }"
+	var out struct {
+		Value GraphQLUserProfile `json:"addFavorite"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// MutateRemoveFavoriteArgs are the arguments of the GraphQL removeFavorite mutation
+type MutateRemoveFavoriteArgs struct {
+	StationID string `json:"stationId"`
+}
+
+// MutateRemoveFavorite runs the GraphQL removeFavorite mutation, selecting every field
+func (c *Client) MutateRemoveFavorite(ctx context.Context, args MutateRemoveFavoriteArgs) (GraphQLUserProfile, error) {
+	const query = "mutation($stationId: ID!) { removeFavorite(stationId: $stationId) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }"
+	var out struct {
+		Value GraphQLUserProfile `json:"removeFavorite"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// MutateRestoreFavoriteArgs are the arguments of the GraphQL restoreFavorite mutation
+type MutateRestoreFavoriteArgs struct {
+	StationID string `json:"stationId"`
+}
+
+// MutateRestoreFavorite runs the GraphQL restoreFavorite mutation, selecting every field
+func (c *Client) MutateRestoreFavorite(ctx context.Context, args MutateRestoreFavoriteArgs) (GraphQLUserProfile, error) {
+	const query = "mutation($stationId: ID!) { restoreFavorite(stationId: $stationId) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }"
+	var out struct {
+		Value GraphQLUserProfile `json:"restoreFavorite"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// MutateUpdatePreferencesArgs are the arguments of the GraphQL updatePreferences mutation
+type MutateUpdatePreferencesArgs struct {
+	Units *string `json:"units,omitempty"`
+	Datum *string `json:"datum,omitempty"`
+}
+
+// MutateUpdatePreferences runs the GraphQL updatePreferences mutation, selecting every field
+func (c *Client) MutateUpdatePreferences(ctx context.Context, args MutateUpdatePreferencesArgs) (GraphQLUserProfile, error) {
+	const query = "mutation($units: String, $datum: String) { updatePreferences(units: $units, datum: $datum) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }"
+	var out struct {
+		Value GraphQLUserProfile `json:"updatePreferences"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// MutateExportTideTableArgs are the arguments of the GraphQL exportTideTable mutation
+type MutateExportTideTableArgs struct {
+	StationID string  `json:"stationId"`
+	Year      int64   `json:"year"`
+	Format    *string `json:"format,omitempty"`
+}
+
+// MutateExportTideTable runs the GraphQL exportTideTable mutation, selecting every field
+func (c *Client) MutateExportTideTable(ctx context.Context, args MutateExportTideTableArgs) (GraphQLTideTableExport, error) {
+	const query = "mutation($stationId: ID!, $year: Int!, $format: String) { exportTideTable(stationId: $stationId, year: $year, format: $format) { stationId year format status downloadUrl expiresAt error } }"
+	var out struct {
+		Value GraphQLTideTableExport `json:"exportTideTable"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+
+// MutateReportObservationArgs are the arguments of the GraphQL reportObservation mutation
+type MutateReportObservationArgs struct {
+	StationID      string   `json:"stationId"`
+	Timestamp      int64    `json:"timestamp"`
+	ObservedHeight *float64 `json:"observedHeight,omitempty"`
+	Condition      *string  `json:"condition,omitempty"`
+	PhotoUrl       *string  `json:"photoUrl,omitempty"`
+}
+
+// MutateReportObservation runs the GraphQL reportObservation mutation, selecting every field
+func (c *Client) MutateReportObservation(ctx context.Context, args MutateReportObservationArgs) (GraphQLObservationReport, error) {
+	const query = "mutation($stationId: ID!, $timestamp: Int!, $observedHeight: Float, $condition: String, $photoUrl: String) { reportObservation(stationId: $stationId, timestamp: $timestamp, observedHeight: $observedHeight, condition: $condition, photoUrl: $photoUrl) { id stationId timestamp observedHeight condition photoUrl submittedAt } }"
+	var out struct {
+		Value GraphQLObservationReport `json:"reportObservation"`
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
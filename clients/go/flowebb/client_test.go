@@ -0,0 +1,87 @@
+package flowebb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTidesV2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/tides", r.URL.Path)
+		assert.Equal(t, "lat=47.6&lon=-122.3&startDateTime=2024-01-01T00%3A00%3A00", r.URL.RawQuery)
+		assert.Equal(t, "application/json", r.Header.Get("Accept"))
+		_, _ = w.Write([]byte(`{"responseType":"tide","station":{"id":"9447130"},"level":{"predicted":1.5,"trend":"RISING"}}`))
+	}))
+	defer server.Close()
+
+	lat, lon, start := 47.6, -122.3, "2024-01-01T00:00:00"
+	resp, err := New(server.URL+"/").GetTidesV2(context.Background(), GetTidesV2Params{Lat: &lat, Lon: &lon, StartDateTime: &start})
+	require.NoError(t, err)
+	assert.Equal(t, "9447130", resp.Station.ID)
+	require.NotNil(t, resp.Level.Predicted)
+	assert.Equal(t, 1.5, *resp.Level.Predicted)
+	require.NotNil(t, resp.Level.Trend)
+	assert.Equal(t, "RISING", *resp.Level.Trend)
+}
+
+func TestErrorDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"INVALID_REQUEST","error":"Invalid request parameters","details":[{"parameter":"lat","message":"must be a number"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).GetStations(context.Background(), GetStationsParams{})
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "INVALID_REQUEST", apiErr.Code)
+	assert.Equal(t, []ParamError{{Parameter: "lat", Message: "must be a number"}}, apiErr.Details)
+	assert.Equal(t, "flowebb: 400: Invalid request parameters: lat must be a number", err.Error())
+}
+
+func TestQueryStations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "s3cret", r.Header.Get("X-Api-Key"))
+
+		var body struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Contains(t, body.Query, "stations(ids: $ids, lat: $lat, lon: $lon, limit: $limit, ")
+		assert.Equal(t, map[string]interface{}{"lat": 47.6, "lon": -122.3}, body.Variables)
+		_, _ = w.Write([]byte(`{"data":{"stations":[{"id":"9447130","name":"Seattle"}]}}`))
+	}))
+	defer server.Close()
+
+	lat, lon := 47.6, -122.3
+	client := New(server.URL)
+	client.Header = http.Header{"X-Api-Key": {"s3cret"}}
+	stations, err := client.QueryStations(context.Background(), QueryStationsArgs{Lat: &lat, Lon: &lon})
+	require.NoError(t, err)
+	require.Len(t, stations, 1)
+	assert.Equal(t, "Seattle", stations[0].Name)
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errors":[{"message":"station not found","extensions":{"code":"STATION_NOT_FOUND"}},{"message":"try again"}]}`))
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).QueryTides(context.Background(), QueryTidesArgs{StationID: "1"})
+	assert.EqualError(t, err, "flowebb: 200: station not found; try again")
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "STATION_NOT_FOUND", apiErr.Code)
+}
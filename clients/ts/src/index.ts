@@ -0,0 +1,2146 @@
+// Code generated by cmd/sdkgen. DO NOT EDIT.
+
+/** Raised for non-2xx responses and GraphQL errors */
+export class FlowebbError extends Error {
+  constructor(
+    message: string,
+    readonly status: number,
+    readonly details: ParamError[] = [],
+    /** e.g. STATION_NOT_FOUND or RANGE_TOO_LARGE; for GraphQL, the first error's */
+    readonly code?: string,
+  ) {
+    super(message);
+    this.name = "FlowebbError";
+  }
+}
+
+export interface FlowebbClientOptions {
+  /** Path of the GraphQL endpoint, default "/graphql" */
+  graphQLPath?: string;
+  /** fetch implementation, default the global fetch */
+  fetch?: typeof fetch;
+  /** Sent with every request, e.g. x-api-key or Authorization for the user profile operations */
+  headers?: Record<string, string>;
+}
+
+type QueryValue = string | number | boolean | undefined | null;
+
+export interface AccuracyStats {
+  bias?: number | null;
+  confidence?: string;
+  days: number;
+  meanAbsoluteError?: number | null;
+  responseType: string;
+  samples: number;
+  stationId: string;
+  stationName: string;
+}
+
+export interface AccuracyStatsEnvelope {
+  data: AccuracyStats;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface CompactExtreme {
+  height: number;
+  time: string;
+  timestamp: number;
+  type: string;
+}
+
+export interface ComparedStation {
+  heights: number[] | null;
+  id: string;
+  lagMinutes?: number | null;
+  name: string;
+  rangeRatio?: number | null;
+}
+
+export interface DailyExtremes {
+  date: string;
+  extremes: CompactExtreme[] | null;
+}
+
+export interface DayPredictions {
+  date: string;
+  extremes: TideExtreme[] | null;
+  predictions: TidePrediction[] | null;
+}
+
+export interface DayUsage {
+  date: string;
+  endpoints: Record<string, number>;
+  requests: number;
+}
+
+export interface DaylightDay {
+  date: string;
+  daylightMinutes: number;
+  lows: CompactExtreme[] | null;
+  sunrise?: number | null;
+  sunset?: number | null;
+}
+
+export interface DaylightLows {
+  below?: number | null;
+  days: DaylightDay[] | null;
+  responseType: string;
+  stationId: string;
+  stationName: string;
+  timeZone?: string;
+}
+
+export interface DaylightLowsEnvelope {
+  data: DaylightLows;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface ErrorResponse {
+  code: string;
+  error: string;
+  responseType: string;
+}
+
+export interface ExtendedTideResponse {
+  anomaly?: WaterLevelAnomaly | null;
+  astronomy?: TideAstronomy | null;
+  calculationMethod: string;
+  conditions?: WaterConditions | null;
+  confidence?: PredictionConfidence | null;
+  extremes: TideExtreme[] | null;
+  latitude: number;
+  localTime: string;
+  location?: string | null;
+  longitude: number;
+  nearestStation: string;
+  offsets?: TideOffsets | null;
+  predictedLevel?: number | null;
+  predictions: TidePrediction[] | null;
+  responseType: string;
+  stationDistance: number;
+  summary?: TideSummary | null;
+  tideType?: string | null;
+  timeZoneOffsetSeconds?: number | null;
+  timestamp: number;
+  warnings?: ResponseWarning[] | null;
+  waterLevel?: number | null;
+  weather?: MarineWeather | null;
+}
+
+export interface ExtremesSummary {
+  days: DailyExtremes[] | null;
+  responseType: string;
+  stationId: string;
+  stationName: string;
+  timeZone?: string;
+}
+
+export interface ExtremesSummaryEnvelope {
+  data: ExtremesSummary;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface Feature {
+  geometry: Point;
+  id?: string;
+  properties: unknown;
+  type: string;
+}
+
+export interface FeatureCollection {
+  features: Feature[] | null;
+  pagination?: Pagination | null;
+  stale?: boolean;
+  type: string;
+}
+
+export interface MarineWeather {
+  available: boolean;
+  forecast: WeatherForecast[] | null;
+  source: string;
+}
+
+export interface Meta {
+  cacheStatus?: string;
+  datum?: string;
+  generatedAt: number;
+  source?: string;
+  units?: string;
+}
+
+export interface NearestStation {
+  distanceKm: number;
+  id: string;
+  name: string;
+}
+
+export interface NextExtremes {
+  extremes: TideExtreme[] | null;
+  responseType: string;
+  stationId: string;
+  stationName: string;
+  timeZone?: string;
+}
+
+export interface NextExtremesEnvelope {
+  data: NextExtremes;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface NoNearbyStationResponse {
+  code: string;
+  error: string;
+  maxDistanceKm: number;
+  nearestStation: NearestStation;
+  placeName?: string | null;
+  responseType: string;
+}
+
+export interface OEmbed {
+  cache_age: number;
+  height: number;
+  html: string;
+  provider_name: string;
+  provider_url: string;
+  title: string;
+  type: string;
+  version: string;
+  width: number;
+}
+
+export interface Observation {
+  localTime: string;
+  timestamp: number;
+  units: string;
+  value: number;
+}
+
+export interface ObservationResponse {
+  observation: Observation;
+  product: string;
+  responseType: string;
+  stationId: string;
+  stationName: string;
+}
+
+export interface ObservationResponseEnvelope {
+  data: ObservationResponse;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface Pagination {
+  hasMore: boolean;
+  limit: number;
+  offset: number;
+  total: number;
+}
+
+export interface ParamError {
+  allowed?: string;
+  message: string;
+  parameter: string;
+  suggestion?: string;
+  value?: string;
+}
+
+export interface Point {
+  coordinates: number[];
+  type: string;
+}
+
+export interface PredictionConfidence {
+  bias: number;
+  days: number;
+  level: string;
+  meanAbsoluteError: number;
+  samples: number;
+}
+
+export interface PredictionSync {
+  responseType: string;
+  stations: StationSync[] | null;
+  syncedAt: number;
+}
+
+export interface PredictionSyncEnvelope {
+  data: PredictionSync;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface RegionsResponse {
+  regions: StationRegion[] | null;
+  responseType: string;
+}
+
+export interface RegionsResponseEnvelope {
+  data: RegionsResponse;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface ResponseWarning {
+  code: string;
+  message: string;
+}
+
+export interface Station {
+  bearing?: number | null;
+  capabilities: string[] | null;
+  distance: number;
+  distanceUnit?: string;
+  id: string;
+  latitude: number;
+  level?: string | null;
+  longitude: number;
+  name: string;
+  offsets?: TideOffsets | null;
+  region?: string | null;
+  source: string;
+  state?: string | null;
+  stationType?: string;
+  timeZone?: string;
+  timeZoneOffset: number;
+}
+
+export interface StationComparison {
+  intervalMinutes: number;
+  responseType: string;
+  stations: ComparedStation[] | null;
+  timestamps: number[] | null;
+}
+
+export interface StationComparisonEnvelope {
+  data: StationComparison;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface StationNotFoundResponse {
+  code: string;
+  error: string;
+  responseType: string;
+  suggestions: StationSuggestion[] | null;
+}
+
+export interface StationRegion {
+  region?: string;
+  representatives: Station[] | null;
+  state?: string;
+  stationCount: number;
+}
+
+export interface StationRetiredResponse {
+  code: string;
+  error: string;
+  responseType: string;
+  stationId: string;
+  successor: Station;
+  warnings: ResponseWarning[] | null;
+}
+
+export interface StationSuggestion {
+  id: string;
+  name: string;
+}
+
+export interface StationSync {
+  days: DayPredictions[] | null;
+  endDate: string;
+  full: boolean;
+  startDate: string;
+  stationId: string;
+  stationName: string;
+  timeZone?: string;
+}
+
+export interface StationsResponse {
+  pagination?: Pagination | null;
+  responseType: string;
+  stale?: boolean;
+  stations: Station[] | null;
+}
+
+export interface StationsResponseEnvelope {
+  data: StationsResponse;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface TideAstronomy {
+  daysSinceFullMoon: number;
+  daysSinceNewMoon: number;
+  moonPhase: string;
+  tideCycle: string;
+}
+
+export interface TideExtreme {
+  height: number;
+  localTime: string;
+  timestamp: number;
+  type: string;
+}
+
+export interface TideLevelV2 {
+  predicted?: number | null;
+  trend?: string | null;
+}
+
+export interface TideOffsets {
+  heightAdjustment: string;
+  heightOffsetHigh: number;
+  heightOffsetLow: number;
+  referenceStationId: string;
+  referenceStationName?: string;
+  timeOffsetHighMinutes: number;
+  timeOffsetLowMinutes: number;
+}
+
+export interface TidePrediction {
+  height: number;
+  localTime: string;
+  timestamp: number;
+}
+
+export interface TideRange {
+  high: number;
+  low: number;
+}
+
+export interface TideResponseV2 {
+  astronomy?: TideAstronomy | null;
+  calculationMethod: string;
+  conditions?: WaterConditions | null;
+  extremes: TideExtreme[] | null;
+  level: TideLevelV2;
+  localTime: string;
+  predictions: TidePrediction[] | null;
+  responseType: string;
+  station: TideStationV2;
+  summary?: TideSummary | null;
+  timeZoneOffsetSeconds?: number | null;
+  timestamp: number;
+  warnings?: ResponseWarning[] | null;
+  weather?: MarineWeather | null;
+}
+
+export interface TideResponseV2Envelope {
+  data: TideResponseV2;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface TideStationV2 {
+  distanceKm: number;
+  id: string;
+  latitude: number;
+  longitude: number;
+  name?: string | null;
+}
+
+export interface TideSummary {
+  cycleElapsedPercent?: number | null;
+  nextHigh?: TideExtreme | null;
+  nextLow?: TideExtreme | null;
+  todayRange?: TideRange | null;
+  trend?: string | null;
+}
+
+export interface TideTableExport {
+  downloadUrl?: string | null;
+  endDate?: string | null;
+  error?: string | null;
+  expiresAt?: number | null;
+  format: string;
+  jobId: string;
+  kind: string;
+  responseType: string;
+  startDate?: string | null;
+  stationId: string;
+  status: string;
+  statusUrl?: string;
+  year?: number;
+}
+
+export interface TideTableExportEnvelope {
+  data: TideTableExport;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface TideWidget {
+  localTime: string;
+  next: TideExtreme[] | null;
+  responseType: string;
+  stationId: string;
+  stationName: string;
+  theme: WidgetTheme;
+  tideType?: string | null;
+  timeZone?: string;
+  timestamp: number;
+  url: string;
+  waterLevel?: number | null;
+}
+
+export interface TideWidgetEnvelope {
+  data: TideWidget;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface UsageResponse {
+  days: DayUsage[] | null;
+  month: string;
+  quota: number;
+  remaining?: number | null;
+  resetsAt: number;
+  responseType: string;
+  used: number;
+}
+
+export interface UsageResponseEnvelope {
+  data: UsageResponse;
+  meta: Meta;
+  warnings: ResponseWarning[];
+}
+
+export interface ValidationErrorResponse {
+  code: string;
+  details: ParamError[] | null;
+  error: string;
+  responseType: string;
+}
+
+export interface WaterConditions {
+  conductivity?: Observation | null;
+  waterTemperature?: Observation | null;
+}
+
+export interface WaterLevelAnomaly {
+  detected: boolean;
+  localTime: string;
+  observedLevel: number;
+  predictedLevel: number;
+  residual: number;
+  threshold: number;
+  timestamp: number;
+}
+
+export interface WeatherForecast {
+  localTime: string;
+  pressureHpa?: number | null;
+  timestamp: number;
+  windDirectionDegrees?: number | null;
+  windGustKnots?: number | null;
+  windSpeedKnots?: number | null;
+}
+
+export interface WidgetTheme {
+  accent: string;
+  background: string;
+  foreground: string;
+  name: string;
+}
+
+/** Query parameters of GET /api/accuracy */
+export interface GetAccuracyParams {
+  /** Station ID */
+  stationId: string;
+  /** Number of UTC days, today included; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/compare */
+export interface CompareStationsParams {
+  /** Comma-separated station IDs; the first is the reference for lag and range ratio */
+  stationIds: string;
+  /** Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the first station's current day */
+  startDateTime?: string;
+  /** End of the range, in any form startDateTime takes; a date alone runs through that day */
+  endDateTime?: string;
+  /** IANA time zone of dates and times without an offset; defaults to the first station's */
+  tz?: string;
+  /** Minutes between samples; defaults to 6 */
+  interval?: number;
+}
+
+/** Query parameters of GET /api/daylight-lows */
+export interface GetDaylightLowsParams {
+  /** Station ID */
+  stationId: string;
+  /** Only lows under this height, in feet above MLLW */
+  below?: number;
+  /** First day in the station's local time; defaults to today */
+  startDate?: string;
+  /** Number of days; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/exports */
+export interface ExportTideTableParams {
+  /** Station ID */
+  stationId: string;
+  /** What to render; defaults to table */
+  kind?: string;
+  /** Calendar year of a table */
+  year?: number;
+  /** First day of predictions or a chart, in the station's local time */
+  startDate?: string;
+  /** Last day of predictions or a chart, at most 365 days from startDate */
+  endDate?: string;
+  /** Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts */
+  format?: string;
+}
+
+/** Query parameters of GET /api/exports/status */
+export interface GetExportStatusParams {
+  /** Job ID returned by exportTideTable */
+  jobId: string;
+}
+
+/** Query parameters of GET /api/extremes */
+export interface GetExtremesParams {
+  /** Station ID */
+  stationId: string;
+  /** First day in the station's local time; defaults to today */
+  startDate?: string;
+  /** Number of days; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/extremes/next */
+export interface GetNextExtremesParams {
+  /** Station ID */
+  stationId: string;
+  /** Number of highs and lows; defaults to 4 */
+  count?: number;
+}
+
+/** Query parameters of GET /api/observations */
+export interface GetObservationParams {
+  /** Station ID */
+  stationId: string;
+  /** Sensor product; the station must have a sensor for it */
+  product: string;
+}
+
+/** Query parameters of GET /api/oembed */
+export interface GetOEmbedParams {
+  /** Widget page URL, with the stationId and optionally theme and accent */
+  url: string;
+  /** Largest width in pixels the embed may take */
+  maxwidth?: number;
+  /** Largest height in pixels the embed may take */
+  maxheight?: number;
+  /** Response format; only json is implemented */
+  format?: string;
+}
+
+/** Query parameters of GET /api/regions */
+export interface GetRegionsParams {
+  /** Only the regions of this state, e.g. WA; ignores case */
+  state?: string;
+  /** Only count reference (R) or subordinate (S) stations */
+  stationType?: string;
+  /** Only count stations with this capability */
+  capability?: string;
+  /** Only count stations from this data source */
+  source?: string;
+}
+
+/** Query parameters of GET /api/stations */
+export interface GetStationsParams {
+  /** Station ID */
+  stationId?: string;
+  /** Latitude in degrees */
+  lat?: number;
+  /** Longitude in degrees */
+  lon?: number;
+  /** Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise) */
+  limit?: number;
+  /** Number of nearest stations to skip, for paging through results */
+  offset?: number;
+  /** Only reference (R) or subordinate (S) stations */
+  stationType?: string;
+  /** Only stations with this capability */
+  capability?: string;
+  /** Only stations from this data source */
+  source?: string;
+  /** Unit of each station's distance from the point; defaults to km */
+  distanceUnit?: string;
+  /** Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations */
+  format?: string;
+  /** Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored */
+  fields?: string;
+}
+
+/** Query parameters of GET /api/sync */
+export interface SyncPredictionsParams {
+  /** Comma-separated IDs of up to 20 stations */
+  stationIds: string;
+  /** syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day */
+  since?: number;
+  /** Days the client keeps from today, the same each sync; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/tides */
+export interface GetTidesParams {
+  /** Station ID */
+  stationId?: string;
+  /** Latitude in degrees */
+  lat?: number;
+  /** Longitude in degrees */
+  lon?: number;
+  /** Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the station's current day */
+  startDateTime?: string;
+  /** End of the range, in any form startDateTime takes; a date alone runs through that day */
+  endDateTime?: string;
+  /** IANA time zone of dates and times without an offset; defaults to the station's */
+  tz?: string;
+  /** Interpolation between known points */
+  interpolation?: string;
+  /** Downsample predictions to at most this many, keeping highs and lows */
+  points?: number;
+  /** Attach the NWS wind and pressure forecast for the station */
+  includeWeather?: boolean;
+  /** Write localTime values as this locale does instead of ISO 8601 */
+  locale?: string;
+  /** Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's */
+  hour12?: boolean;
+  /** Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored */
+  fields?: string;
+}
+
+/** Query parameters of GET /api/usage */
+export interface GetUsageParams {
+  /** Month as YYYY-MM in UTC; defaults to the current month */
+  month?: string;
+}
+
+/** Query parameters of GET /api/v2/accuracy */
+export interface GetAccuracyV2Params {
+  /** Station ID */
+  stationId: string;
+  /** Number of UTC days, today included; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v2/compare */
+export interface CompareStationsV2Params {
+  /** Comma-separated station IDs; the first is the reference for lag and range ratio */
+  stationIds: string;
+  /** Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the first station's current day */
+  startDateTime?: string;
+  /** End of the range, in any form startDateTime takes; a date alone runs through that day */
+  endDateTime?: string;
+  /** IANA time zone of dates and times without an offset; defaults to the first station's */
+  tz?: string;
+  /** Minutes between samples; defaults to 6 */
+  interval?: number;
+}
+
+/** Query parameters of GET /api/v2/daylight-lows */
+export interface GetDaylightLowsV2Params {
+  /** Station ID */
+  stationId: string;
+  /** Only lows under this height, in feet above MLLW */
+  below?: number;
+  /** First day in the station's local time; defaults to today */
+  startDate?: string;
+  /** Number of days; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v2/exports */
+export interface ExportTideTableV2Params {
+  /** Station ID */
+  stationId: string;
+  /** What to render; defaults to table */
+  kind?: string;
+  /** Calendar year of a table */
+  year?: number;
+  /** First day of predictions or a chart, in the station's local time */
+  startDate?: string;
+  /** Last day of predictions or a chart, at most 365 days from startDate */
+  endDate?: string;
+  /** Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts */
+  format?: string;
+}
+
+/** Query parameters of GET /api/v2/exports/status */
+export interface GetExportStatusV2Params {
+  /** Job ID returned by exportTideTable */
+  jobId: string;
+}
+
+/** Query parameters of GET /api/v2/extremes */
+export interface GetExtremesV2Params {
+  /** Station ID */
+  stationId: string;
+  /** First day in the station's local time; defaults to today */
+  startDate?: string;
+  /** Number of days; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v2/extremes/next */
+export interface GetNextExtremesV2Params {
+  /** Station ID */
+  stationId: string;
+  /** Number of highs and lows; defaults to 4 */
+  count?: number;
+}
+
+/** Query parameters of GET /api/v2/observations */
+export interface GetObservationV2Params {
+  /** Station ID */
+  stationId: string;
+  /** Sensor product; the station must have a sensor for it */
+  product: string;
+}
+
+/** Query parameters of GET /api/v2/oembed */
+export interface GetOEmbedV2Params {
+  /** Widget page URL, with the stationId and optionally theme and accent */
+  url: string;
+  /** Largest width in pixels the embed may take */
+  maxwidth?: number;
+  /** Largest height in pixels the embed may take */
+  maxheight?: number;
+  /** Response format; only json is implemented */
+  format?: string;
+}
+
+/** Query parameters of GET /api/v2/regions */
+export interface GetRegionsV2Params {
+  /** Only the regions of this state, e.g. WA; ignores case */
+  state?: string;
+  /** Only count reference (R) or subordinate (S) stations */
+  stationType?: string;
+  /** Only count stations with this capability */
+  capability?: string;
+  /** Only count stations from this data source */
+  source?: string;
+}
+
+/** Query parameters of GET /api/v2/stations */
+export interface GetStationsV2Params {
+  /** Station ID */
+  stationId?: string;
+  /** Latitude in degrees */
+  lat?: number;
+  /** Longitude in degrees */
+  lon?: number;
+  /** Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise) */
+  limit?: number;
+  /** Number of nearest stations to skip, for paging through results */
+  offset?: number;
+  /** Only reference (R) or subordinate (S) stations */
+  stationType?: string;
+  /** Only stations with this capability */
+  capability?: string;
+  /** Only stations from this data source */
+  source?: string;
+  /** Unit of each station's distance from the point; defaults to km */
+  distanceUnit?: string;
+  /** Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations */
+  format?: string;
+  /** Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored */
+  fields?: string;
+}
+
+/** Query parameters of GET /api/v2/sync */
+export interface SyncPredictionsV2Params {
+  /** Comma-separated IDs of up to 20 stations */
+  stationIds: string;
+  /** syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day */
+  since?: number;
+  /** Days the client keeps from today, the same each sync; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v2/tides */
+export interface GetTidesV2Params {
+  /** Station ID */
+  stationId?: string;
+  /** Latitude in degrees */
+  lat?: number;
+  /** Longitude in degrees */
+  lon?: number;
+  /** Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the station's current day */
+  startDateTime?: string;
+  /** End of the range, in any form startDateTime takes; a date alone runs through that day */
+  endDateTime?: string;
+  /** IANA time zone of dates and times without an offset; defaults to the station's */
+  tz?: string;
+  /** Interpolation between known points */
+  interpolation?: string;
+  /** Downsample predictions to at most this many, keeping highs and lows */
+  points?: number;
+  /** Attach the NWS wind and pressure forecast for the station */
+  includeWeather?: boolean;
+  /** Write localTime values as this locale does instead of ISO 8601 */
+  locale?: string;
+  /** Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's */
+  hour12?: boolean;
+  /** Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored */
+  fields?: string;
+}
+
+/** Query parameters of GET /api/v2/usage */
+export interface GetUsageV2Params {
+  /** Month as YYYY-MM in UTC; defaults to the current month */
+  month?: string;
+}
+
+/** Query parameters of GET /api/v2/widget */
+export interface GetWidgetV2Params {
+  /** Station ID */
+  stationId: string;
+  /** Color scheme; defaults to light */
+  theme?: string;
+  /** Accent color as rrggbb, replacing the theme's */
+  accent?: string;
+}
+
+/** Query parameters of GET /api/v3/accuracy */
+export interface GetAccuracyV3Params {
+  /** Station ID */
+  stationId: string;
+  /** Number of UTC days, today included; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v3/compare */
+export interface CompareStationsV3Params {
+  /** Comma-separated station IDs; the first is the reference for lag and range ratio */
+  stationIds: string;
+  /** Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the first station's current day */
+  startDateTime?: string;
+  /** End of the range, in any form startDateTime takes; a date alone runs through that day */
+  endDateTime?: string;
+  /** IANA time zone of dates and times without an offset; defaults to the first station's */
+  tz?: string;
+  /** Minutes between samples; defaults to 6 */
+  interval?: number;
+}
+
+/** Query parameters of GET /api/v3/daylight-lows */
+export interface GetDaylightLowsV3Params {
+  /** Station ID */
+  stationId: string;
+  /** Only lows under this height, in feet above MLLW */
+  below?: number;
+  /** First day in the station's local time; defaults to today */
+  startDate?: string;
+  /** Number of days; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v3/exports */
+export interface ExportTideTableV3Params {
+  /** Station ID */
+  stationId: string;
+  /** What to render; defaults to table */
+  kind?: string;
+  /** Calendar year of a table */
+  year?: number;
+  /** First day of predictions or a chart, in the station's local time */
+  startDate?: string;
+  /** Last day of predictions or a chart, at most 365 days from startDate */
+  endDate?: string;
+  /** Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts */
+  format?: string;
+}
+
+/** Query parameters of GET /api/v3/exports/status */
+export interface GetExportStatusV3Params {
+  /** Job ID returned by exportTideTable */
+  jobId: string;
+}
+
+/** Query parameters of GET /api/v3/extremes */
+export interface GetExtremesV3Params {
+  /** Station ID */
+  stationId: string;
+  /** First day in the station's local time; defaults to today */
+  startDate?: string;
+  /** Number of days; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v3/extremes/next */
+export interface GetNextExtremesV3Params {
+  /** Station ID */
+  stationId: string;
+  /** Number of highs and lows; defaults to 4 */
+  count?: number;
+}
+
+/** Query parameters of GET /api/v3/observations */
+export interface GetObservationV3Params {
+  /** Station ID */
+  stationId: string;
+  /** Sensor product; the station must have a sensor for it */
+  product: string;
+}
+
+/** Query parameters of GET /api/v3/oembed */
+export interface GetOEmbedV3Params {
+  /** Widget page URL, with the stationId and optionally theme and accent */
+  url: string;
+  /** Largest width in pixels the embed may take */
+  maxwidth?: number;
+  /** Largest height in pixels the embed may take */
+  maxheight?: number;
+  /** Response format; only json is implemented */
+  format?: string;
+}
+
+/** Query parameters of GET /api/v3/regions */
+export interface GetRegionsV3Params {
+  /** Only the regions of this state, e.g. WA; ignores case */
+  state?: string;
+  /** Only count reference (R) or subordinate (S) stations */
+  stationType?: string;
+  /** Only count stations with this capability */
+  capability?: string;
+  /** Only count stations from this data source */
+  source?: string;
+}
+
+/** Query parameters of GET /api/v3/stations */
+export interface GetStationsV3Params {
+  /** Station ID */
+  stationId?: string;
+  /** Latitude in degrees */
+  lat?: number;
+  /** Longitude in degrees */
+  lon?: number;
+  /** Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise) */
+  limit?: number;
+  /** Number of nearest stations to skip, for paging through results */
+  offset?: number;
+  /** Only reference (R) or subordinate (S) stations */
+  stationType?: string;
+  /** Only stations with this capability */
+  capability?: string;
+  /** Only stations from this data source */
+  source?: string;
+  /** Unit of each station's distance from the point; defaults to km */
+  distanceUnit?: string;
+  /** Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations */
+  format?: string;
+  /** Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored */
+  fields?: string;
+}
+
+/** Query parameters of GET /api/v3/sync */
+export interface SyncPredictionsV3Params {
+  /** Comma-separated IDs of up to 20 stations */
+  stationIds: string;
+  /** syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day */
+  since?: number;
+  /** Days the client keeps from today, the same each sync; defaults to 7 */
+  days?: number;
+}
+
+/** Query parameters of GET /api/v3/tides */
+export interface GetTidesV3Params {
+  /** Station ID */
+  stationId?: string;
+  /** Latitude in degrees */
+  lat?: number;
+  /** Longitude in degrees */
+  lon?: number;
+  /** Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, now, or an offset from now such as -6h; defaults to the station's current day */
+  startDateTime?: string;
+  /** End of the range, in any form startDateTime takes; a date alone runs through that day */
+  endDateTime?: string;
+  /** IANA time zone of dates and times without an offset; defaults to the station's */
+  tz?: string;
+  /** Interpolation between known points */
+  interpolation?: string;
+  /** Downsample predictions to at most this many, keeping highs and lows */
+  points?: number;
+  /** Attach the NWS wind and pressure forecast for the station */
+  includeWeather?: boolean;
+  /** Write localTime values as this locale does instead of ISO 8601 */
+  locale?: string;
+  /** Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's */
+  hour12?: boolean;
+  /** Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored */
+  fields?: string;
+}
+
+/** Query parameters of GET /api/v3/usage */
+export interface GetUsageV3Params {
+  /** Month as YYYY-MM in UTC; defaults to the current month */
+  month?: string;
+}
+
+/** Query parameters of GET /api/v3/widget */
+export interface GetWidgetV3Params {
+  /** Station ID */
+  stationId: string;
+  /** Color scheme; defaults to light */
+  theme?: string;
+  /** Accent color as rrggbb, replacing the theme's */
+  accent?: string;
+}
+
+/** Query parameters of GET /api/widget */
+export interface GetWidgetParams {
+  /** Station ID */
+  stationId: string;
+  /** Color scheme; defaults to light */
+  theme?: string;
+  /** Accent color as rrggbb, replacing the theme's */
+  accent?: string;
+}
+
+export interface GraphQLStation {
+  id: string;
+  name: string;
+  state: string | null;
+  region: string | null;
+  distance: number;
+  distanceUnit: string;
+  bearing: number | null;
+  latitude: number;
+  longitude: number;
+  source: string;
+  capabilities: string[];
+  timeZoneOffset: number;
+  timeZone: string | null;
+  stationType: string | null;
+}
+
+export interface GraphQLStationConnection {
+  edges: GraphQLStationEdge[];
+  pageInfo: GraphQLPageInfo;
+  totalCount: number;
+  stale: boolean;
+}
+
+export interface GraphQLStationMap {
+  stations: GraphQLStation[];
+  clusters: GraphQLStationCluster[];
+  totalCount: number;
+}
+
+export interface GraphQLStationRegion {
+  state: string | null;
+  region: string | null;
+  stationCount: number;
+  representatives: GraphQLStation[];
+}
+
+export interface GraphQLStationCluster {
+  latitude: number;
+  longitude: number;
+  count: number;
+  north: number;
+  south: number;
+  east: number;
+  west: number;
+}
+
+export interface GraphQLStationEdge {
+  cursor: string;
+  node: GraphQLStation;
+}
+
+export interface GraphQLPageInfo {
+  hasNextPage: boolean;
+  hasPreviousPage: boolean;
+  startCursor: string | null;
+  endCursor: string | null;
+}
+
+export interface GraphQLTideData {
+  timestamp: number;
+  localTime: string;
+  waterLevel: number;
+  predictedLevel: number;
+  nearestStation: string;
+  location: string | null;
+  latitude: number;
+  longitude: number;
+  stationDistance: number;
+  tideType: string;
+  calculationMethod: string;
+  predictions: GraphQLTidePrediction[];
+  extremes: GraphQLTideExtreme[];
+  timeZoneOffsetSeconds: number;
+  summary: GraphQLTideSummary | null;
+  astronomy: GraphQLTideAstronomy | null;
+  conditions: GraphQLWaterConditions | null;
+  weather: GraphQLMarineWeather | null;
+  confidence: GraphQLPredictionConfidence | null;
+  offsets: GraphQLTideOffsets | null;
+  anomaly: GraphQLWaterLevelAnomaly | null;
+  warnings: GraphQLResponseWarning[];
+}
+
+export interface GraphQLResponseWarning {
+  code: string;
+  message: string;
+}
+
+export interface GraphQLTideSummary {
+  nextHigh: GraphQLTideExtreme | null;
+  nextLow: GraphQLTideExtreme | null;
+  trend: string | null;
+  todayRange: GraphQLTideRange | null;
+  cycleElapsedPercent: number | null;
+}
+
+export interface GraphQLTideAstronomy {
+  tideCycle: string;
+  moonPhase: string;
+  daysSinceNewMoon: number;
+  daysSinceFullMoon: number;
+}
+
+export interface GraphQLWaterConditions {
+  waterTemperature: GraphQLObservation | null;
+  conductivity: GraphQLObservation | null;
+}
+
+export interface GraphQLPredictionConfidence {
+  level: string;
+  meanAbsoluteError: number;
+  bias: number;
+  samples: number;
+  days: number;
+}
+
+export interface GraphQLTideOffsets {
+  referenceStationId: string;
+  referenceStationName: string | null;
+  timeOffsetHighMinutes: number;
+  timeOffsetLowMinutes: number;
+  heightOffsetHigh: number;
+  heightOffsetLow: number;
+  heightAdjustment: string;
+}
+
+export interface GraphQLWaterLevelAnomaly {
+  detected: boolean;
+  residual: number;
+  threshold: number;
+  observedLevel: number;
+  predictedLevel: number;
+  timestamp: number;
+  localTime: string;
+}
+
+export interface GraphQLAccuracyStats {
+  stationId: string;
+  stationName: string;
+  days: number;
+  samples: number;
+  meanAbsoluteError: number | null;
+  bias: number | null;
+  confidence: string | null;
+}
+
+export interface GraphQLStationListChange {
+  id: string;
+  source: string;
+  detectedAt: number;
+  stations: GraphQLStationChange[];
+  omitted: number;
+}
+
+export interface GraphQLStationChange {
+  stationId: string;
+  name: string;
+  change: string;
+  fields: GraphQLStationFieldChange[];
+}
+
+export interface GraphQLStationFieldChange {
+  field: string;
+  before: string | null;
+  after: string | null;
+}
+
+export interface GraphQLStationObservation {
+  stationId: string;
+  stationName: string;
+  product: string;
+  observation: GraphQLObservation;
+}
+
+export interface GraphQLObservation {
+  timestamp: number;
+  localTime: string;
+  value: number;
+  units: string;
+}
+
+export interface GraphQLMarineWeather {
+  source: string;
+  available: boolean;
+  forecast: GraphQLWeatherForecast[];
+}
+
+export interface GraphQLWeatherForecast {
+  timestamp: number;
+  localTime: string;
+  windSpeedKnots: number | null;
+  windGustKnots: number | null;
+  windDirectionDegrees: number | null;
+  pressureHpa: number | null;
+}
+
+export interface GraphQLTideRange {
+  low: number;
+  high: number;
+}
+
+export interface GraphQLTidePrediction {
+  timestamp: number;
+  localTime: string;
+  height: number;
+}
+
+export interface GraphQLTideExtreme {
+  type: string;
+  timestamp: number;
+  localTime: string;
+  height: number;
+}
+
+export interface GraphQLExtremesSummary {
+  stationId: string;
+  stationName: string;
+  timeZone: string | null;
+  days: GraphQLDailyExtremes[];
+}
+
+export interface GraphQLTideExtremes {
+  stationId: string;
+  stationName: string;
+  timeZone: string | null;
+  extremes: GraphQLTideExtreme[];
+}
+
+export interface GraphQLNextExtremes {
+  stationId: string;
+  stationName: string;
+  timeZone: string | null;
+  extremes: GraphQLTideExtreme[];
+}
+
+export interface GraphQLDailyExtremes {
+  date: string;
+  extremes: GraphQLCompactExtreme[];
+}
+
+export interface GraphQLCompactExtreme {
+  type: string;
+  time: string;
+  timestamp: number;
+  height: number;
+}
+
+export interface GraphQLDaylightLows {
+  stationId: string;
+  stationName: string;
+  timeZone: string | null;
+  below: number | null;
+  days: GraphQLDaylightDay[];
+}
+
+export interface GraphQLDaylightDay {
+  date: string;
+  sunrise: number | null;
+  sunset: number | null;
+  daylightMinutes: number;
+  lows: GraphQLCompactExtreme[];
+}
+
+export interface GraphQLStationComparison {
+  intervalMinutes: number;
+  timestamps: number[];
+  stations: GraphQLComparedStation[];
+}
+
+export interface GraphQLComparedStation {
+  id: string;
+  name: string;
+  heights: number[];
+  lagMinutes: number | null;
+  rangeRatio: number | null;
+}
+
+export interface GraphQLUserProfile {
+  userId: string;
+  favorites: GraphQLFavoriteStation[];
+  deletedFavorites: GraphQLFavoriteStation[];
+  units: string;
+  datum: string;
+  updatedAt: number;
+}
+
+export interface GraphQLFavoriteStation {
+  stationId: string;
+  name: string;
+  addedAt: number;
+  updatedAt: number;
+  deletedAt: number | null;
+}
+
+export interface GraphQLObservationReport {
+  id: string;
+  stationId: string;
+  timestamp: number;
+  observedHeight: number | null;
+  condition: string | null;
+  photoUrl: string | null;
+  submittedAt: number;
+}
+
+export interface GraphQLTideTableExport {
+  stationId: string;
+  year: number;
+  format: string;
+  status: string;
+  downloadUrl: string | null;
+  expiresAt: number | null;
+  error: string | null;
+}
+
+/** Arguments of the GraphQL stations query */
+export interface QueryStationsArgs {
+  ids?: string[] | null;
+  lat?: number | null;
+  lon?: number | null;
+  limit?: number | null;
+  distanceUnit?: string | null;
+  stationType?: string | null;
+  capability?: string | null;
+  source?: string | null;
+}
+
+/** Arguments of the GraphQL nearbyStations query */
+export interface QueryNearbyStationsArgs {
+  lat: number;
+  lon: number;
+  first?: number | null;
+  after?: string | null;
+  distanceUnit?: string | null;
+  stationType?: string | null;
+  capability?: string | null;
+  source?: string | null;
+}
+
+/** Arguments of the GraphQL stationsInBounds query */
+export interface QueryStationsInBoundsArgs {
+  north: number;
+  south: number;
+  east: number;
+  west: number;
+  zoom?: number | null;
+  stationType?: string | null;
+  capability?: string | null;
+  source?: string | null;
+}
+
+/** Arguments of the GraphQL regions query */
+export interface QueryRegionsArgs {
+  state?: string | null;
+  stationType?: string | null;
+  capability?: string | null;
+  source?: string | null;
+}
+
+/** Arguments of the GraphQL tides query */
+export interface QueryTidesArgs {
+  stationId: string;
+  startDateTime: string;
+  endDateTime: string;
+  interpolation?: string | null;
+  points?: number | null;
+  includeWeather?: boolean | null;
+  tz?: string | null;
+  locale?: string | null;
+  hour12?: boolean | null;
+}
+
+/** Arguments of the GraphQL extremes query */
+export interface QueryExtremesArgs {
+  stationId: string;
+  startDate?: string | null;
+  days?: number | null;
+}
+
+/** Arguments of the GraphQL tideExtremes query */
+export interface QueryTideExtremesArgs {
+  stationId: string;
+  startDateTime: string;
+  endDateTime: string;
+  tz?: string | null;
+}
+
+/** Arguments of the GraphQL daylightLows query */
+export interface QueryDaylightLowsArgs {
+  stationId: string;
+  below?: number | null;
+  startDate?: string | null;
+  days?: number | null;
+}
+
+/** Arguments of the GraphQL nextExtremes query */
+export interface QueryNextExtremesArgs {
+  stationId: string;
+  count?: number | null;
+}
+
+/** Arguments of the GraphQL compareStations query */
+export interface QueryCompareStationsArgs {
+  stationIds: string[];
+  startDateTime?: string | null;
+  endDateTime?: string | null;
+  interval?: number | null;
+  tz?: string | null;
+}
+
+/** Arguments of the GraphQL observation query */
+export interface QueryObservationArgs {
+  stationId: string;
+  product: string;
+}
+
+/** Arguments of the GraphQL accuracy query */
+export interface QueryAccuracyArgs {
+  stationId: string;
+  days?: number | null;
+}
+
+/** Arguments of the GraphQL stationChanges query */
+export interface QueryStationChangesArgs {
+  since?: string | null;
+  limit?: number | null;
+}
+
+/** Arguments of the GraphQL me query */
+export interface QueryMeArgs {
+}
+
+/** Arguments of the GraphQL addFavorite mutation */
+export interface MutateAddFavoriteArgs {
+  stationId: string;
+}
+
+/** Arguments of the GraphQL removeFavorite mutation */
+export interface MutateRemoveFavoriteArgs {
+  stationId: string;
+}
+
+/** Arguments of the GraphQL restoreFavorite mutation */
+export interface MutateRestoreFavoriteArgs {
+  stationId: string;
+}
+
+/** Arguments of the GraphQL updatePreferences mutation */
+export interface MutateUpdatePreferencesArgs {
+  units?: string | null;
+  datum?: string | null;
+}
+
+/** Arguments of the GraphQL exportTideTable mutation */
+export interface MutateExportTideTableArgs {
+  stationId: string;
+  year: number;
+  format?: string | null;
+}
+
+/** Arguments of the GraphQL reportObservation mutation */
+export interface MutateReportObservationArgs {
+  stationId: string;
+  timestamp: number;
+  observedHeight?: number | null;
+  condition?: string | null;
+  photoUrl?: string | null;
+}
+
+export class FlowebbClient {
+  private readonly baseUrl: string;
+  private readonly graphQLPath: string;
+  private readonly fetchImpl: typeof fetch;
+  private readonly headers: Record<string, string>;
+
+  constructor(baseUrl: string, options: FlowebbClientOptions = {}) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.graphQLPath = options.graphQLPath ?? "/graphql";
+    this.fetchImpl = options.fetch ?? fetch;
+    this.headers = options.headers ?? {};
+  }
+
+  /**
+   * Get the mean absolute error and bias of a tracked station's recent predictions (GET /api/accuracy)
+   * @deprecated use the latest version of this operation
+   */
+  getAccuracy(params: GetAccuracyParams): Promise<AccuracyStats> {
+    return this.get<AccuracyStats>("/api/accuracy", { ...params });
+  }
+
+  /**
+   * Compare 2 to 5 stations' tides on a shared timeline (GET /api/compare)
+   * @deprecated use the latest version of this operation
+   */
+  compareStations(params: CompareStationsParams): Promise<StationComparison> {
+    return this.get<StationComparison>("/api/compare", { ...params });
+  }
+
+  /**
+   * Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling (GET /api/daylight-lows)
+   * @deprecated use the latest version of this operation
+   */
+  getDaylightLows(params: GetDaylightLowsParams): Promise<DaylightLows> {
+    return this.get<DaylightLows>("/api/daylight-lows", { ...params });
+  }
+
+  /**
+   * Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready (GET /api/exports)
+   * @deprecated use the latest version of this operation
+   */
+  exportTideTable(params: ExportTideTableParams): Promise<TideTableExport> {
+    return this.get<TideTableExport>("/api/exports", { ...params });
+  }
+
+  /**
+   * Get the progress of a submitted export, and its download URL once ready (GET /api/exports/status)
+   * @deprecated use the latest version of this operation
+   */
+  getExportStatus(params: GetExportStatusParams): Promise<TideTableExport> {
+    return this.get<TideTableExport>("/api/exports/status", { ...params });
+  }
+
+  /**
+   * Get a station's daily high and low tides for up to 31 days (GET /api/extremes)
+   * @deprecated use the latest version of this operation
+   */
+  getExtremes(params: GetExtremesParams): Promise<ExtremesSummary> {
+    return this.get<ExtremesSummary>("/api/extremes", { ...params });
+  }
+
+  /**
+   * Get a station's next high and low tides from now (GET /api/extremes/next)
+   * @deprecated use the latest version of this operation
+   */
+  getNextExtremes(params: GetNextExtremesParams): Promise<NextExtremes> {
+    return this.get<NextExtremes>("/api/extremes/next", { ...params });
+  }
+
+  /**
+   * Get a station's latest reading of a sensor product it measures (GET /api/observations)
+   * @deprecated use the latest version of this operation
+   */
+  getObservation(params: GetObservationParams): Promise<ObservationResponse> {
+    return this.get<ObservationResponse>("/api/observations", { ...params });
+  }
+
+  /**
+   * Get the oEmbed response embedding a station's widget page (GET /api/oembed)
+   * @deprecated use the latest version of this operation
+   */
+  getOEmbed(params: GetOEmbedParams): Promise<OEmbed> {
+    return this.get<OEmbed>("/api/oembed", { ...params });
+  }
+
+  /**
+   * List the stations' states and regions with their station counts and a few stations each (GET /api/regions)
+   * @deprecated use the latest version of this operation
+   */
+  getRegions(params: GetRegionsParams = {}): Promise<RegionsResponse> {
+    return this.get<RegionsResponse>("/api/regions", { ...params });
+  }
+
+  /**
+   * Find a station by ID, or the stations nearest a point (GET /api/stations)
+   * @deprecated use the latest version of this operation
+   */
+  getStations(params: GetStationsParams = {}): Promise<StationsResponse> {
+    return this.get<StationsResponse>("/api/stations", { ...params });
+  }
+
+  /**
+   * Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced (GET /api/sync)
+   * @deprecated use the latest version of this operation
+   */
+  syncPredictions(params: SyncPredictionsParams): Promise<PredictionSync> {
+    return this.get<PredictionSync>("/api/sync", { ...params });
+  }
+
+  /**
+   * Get tide predictions for a station, or for the station nearest a point (GET /api/tides)
+   * @deprecated use the latest version of this operation
+   */
+  getTides(params: GetTidesParams = {}): Promise<ExtendedTideResponse> {
+    return this.get<ExtendedTideResponse>("/api/tides", { ...params });
+  }
+
+  /**
+   * Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left (GET /api/usage)
+   * @deprecated use the latest version of this operation
+   */
+  getUsage(params: GetUsageParams = {}): Promise<UsageResponse> {
+    return this.get<UsageResponse>("/api/usage", { ...params });
+  }
+
+  /**
+   * Get the mean absolute error and bias of a tracked station's recent predictions (GET /api/v2/accuracy)
+   * @deprecated use the latest version of this operation
+   */
+  getAccuracyV2(params: GetAccuracyV2Params): Promise<AccuracyStats> {
+    return this.get<AccuracyStats>("/api/v2/accuracy", { ...params });
+  }
+
+  /**
+   * Compare 2 to 5 stations' tides on a shared timeline (GET /api/v2/compare)
+   * @deprecated use the latest version of this operation
+   */
+  compareStationsV2(params: CompareStationsV2Params): Promise<StationComparison> {
+    return this.get<StationComparison>("/api/v2/compare", { ...params });
+  }
+
+  /**
+   * Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling (GET /api/v2/daylight-lows)
+   * @deprecated use the latest version of this operation
+   */
+  getDaylightLowsV2(params: GetDaylightLowsV2Params): Promise<DaylightLows> {
+    return this.get<DaylightLows>("/api/v2/daylight-lows", { ...params });
+  }
+
+  /**
+   * Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready (GET /api/v2/exports)
+   * @deprecated use the latest version of this operation
+   */
+  exportTideTableV2(params: ExportTideTableV2Params): Promise<TideTableExport> {
+    return this.get<TideTableExport>("/api/v2/exports", { ...params });
+  }
+
+  /**
+   * Get the progress of a submitted export, and its download URL once ready (GET /api/v2/exports/status)
+   * @deprecated use the latest version of this operation
+   */
+  getExportStatusV2(params: GetExportStatusV2Params): Promise<TideTableExport> {
+    return this.get<TideTableExport>("/api/v2/exports/status", { ...params });
+  }
+
+  /**
+   * Get a station's daily high and low tides for up to 31 days (GET /api/v2/extremes)
+   * @deprecated use the latest version of this operation
+   */
+  getExtremesV2(params: GetExtremesV2Params): Promise<ExtremesSummary> {
+    return this.get<ExtremesSummary>("/api/v2/extremes", { ...params });
+  }
+
+  /**
+   * Get a station's next high and low tides from now (GET /api/v2/extremes/next)
+   * @deprecated use the latest version of this operation
+   */
+  getNextExtremesV2(params: GetNextExtremesV2Params): Promise<NextExtremes> {
+    return this.get<NextExtremes>("/api/v2/extremes/next", { ...params });
+  }
+
+  /**
+   * Get a station's latest reading of a sensor product it measures (GET /api/v2/observations)
+   * @deprecated use the latest version of this operation
+   */
+  getObservationV2(params: GetObservationV2Params): Promise<ObservationResponse> {
+    return this.get<ObservationResponse>("/api/v2/observations", { ...params });
+  }
+
+  /**
+   * Get the oEmbed response embedding a station's widget page (GET /api/v2/oembed)
+   * @deprecated use the latest version of this operation
+   */
+  getOEmbedV2(params: GetOEmbedV2Params): Promise<OEmbed> {
+    return this.get<OEmbed>("/api/v2/oembed", { ...params });
+  }
+
+  /**
+   * List the stations' states and regions with their station counts and a few stations each (GET /api/v2/regions)
+   * @deprecated use the latest version of this operation
+   */
+  getRegionsV2(params: GetRegionsV2Params = {}): Promise<RegionsResponse> {
+    return this.get<RegionsResponse>("/api/v2/regions", { ...params });
+  }
+
+  /**
+   * Find a station by ID, or the stations nearest a point (GET /api/v2/stations)
+   * @deprecated use the latest version of this operation
+   */
+  getStationsV2(params: GetStationsV2Params = {}): Promise<StationsResponse> {
+    return this.get<StationsResponse>("/api/v2/stations", { ...params });
+  }
+
+  /**
+   * Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced (GET /api/v2/sync)
+   * @deprecated use the latest version of this operation
+   */
+  syncPredictionsV2(params: SyncPredictionsV2Params): Promise<PredictionSync> {
+    return this.get<PredictionSync>("/api/v2/sync", { ...params });
+  }
+
+  /**
+   * Get tide predictions for a station, or for the station nearest a point (GET /api/v2/tides)
+   * @deprecated use the latest version of this operation
+   */
+  getTidesV2(params: GetTidesV2Params = {}): Promise<TideResponseV2> {
+    return this.get<TideResponseV2>("/api/v2/tides", { ...params });
+  }
+
+  /**
+   * Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left (GET /api/v2/usage)
+   * @deprecated use the latest version of this operation
+   */
+  getUsageV2(params: GetUsageV2Params = {}): Promise<UsageResponse> {
+    return this.get<UsageResponse>("/api/v2/usage", { ...params });
+  }
+
+  /**
+   * Get a station's level now and next highs and lows for the embeddable tide module (GET /api/v2/widget)
+   * @deprecated use the latest version of this operation
+   */
+  getWidgetV2(params: GetWidgetV2Params): Promise<TideWidget> {
+    return this.get<TideWidget>("/api/v2/widget", { ...params });
+  }
+
+  /**
+   * Get the mean absolute error and bias of a tracked station's recent predictions (GET /api/v3/accuracy)
+   */
+  getAccuracyV3(params: GetAccuracyV3Params): Promise<AccuracyStatsEnvelope> {
+    return this.get<AccuracyStatsEnvelope>("/api/v3/accuracy", { ...params });
+  }
+
+  /**
+   * Compare 2 to 5 stations' tides on a shared timeline (GET /api/v3/compare)
+   */
+  compareStationsV3(params: CompareStationsV3Params): Promise<StationComparisonEnvelope> {
+    return this.get<StationComparisonEnvelope>("/api/v3/compare", { ...params });
+  }
+
+  /**
+   * Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling (GET /api/v3/daylight-lows)
+   */
+  getDaylightLowsV3(params: GetDaylightLowsV3Params): Promise<DaylightLowsEnvelope> {
+    return this.get<DaylightLowsEnvelope>("/api/v3/daylight-lows", { ...params });
+  }
+
+  /**
+   * Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready (GET /api/v3/exports)
+   */
+  exportTideTableV3(params: ExportTideTableV3Params): Promise<TideTableExportEnvelope> {
+    return this.get<TideTableExportEnvelope>("/api/v3/exports", { ...params });
+  }
+
+  /**
+   * Get the progress of a submitted export, and its download URL once ready (GET /api/v3/exports/status)
+   */
+  getExportStatusV3(params: GetExportStatusV3Params): Promise<TideTableExportEnvelope> {
+    return this.get<TideTableExportEnvelope>("/api/v3/exports/status", { ...params });
+  }
+
+  /**
+   * Get a station's daily high and low tides for up to 31 days (GET /api/v3/extremes)
+   */
+  getExtremesV3(params: GetExtremesV3Params): Promise<ExtremesSummaryEnvelope> {
+    return this.get<ExtremesSummaryEnvelope>("/api/v3/extremes", { ...params });
+  }
+
+  /**
+   * Get a station's next high and low tides from now (GET /api/v3/extremes/next)
+   */
+  getNextExtremesV3(params: GetNextExtremesV3Params): Promise<NextExtremesEnvelope> {
+    return this.get<NextExtremesEnvelope>("/api/v3/extremes/next", { ...params });
+  }
+
+  /**
+   * Get a station's latest reading of a sensor product it measures (GET /api/v3/observations)
+   */
+  getObservationV3(params: GetObservationV3Params): Promise<ObservationResponseEnvelope> {
+    return this.get<ObservationResponseEnvelope>("/api/v3/observations", { ...params });
+  }
+
+  /**
+   * Get the oEmbed response embedding a station's widget page (GET /api/v3/oembed)
+   */
+  getOEmbedV3(params: GetOEmbedV3Params): Promise<OEmbed> {
+    return this.get<OEmbed>("/api/v3/oembed", { ...params });
+  }
+
+  /**
+   * List the stations' states and regions with their station counts and a few stations each (GET /api/v3/regions)
+   */
+  getRegionsV3(params: GetRegionsV3Params = {}): Promise<RegionsResponseEnvelope> {
+    return this.get<RegionsResponseEnvelope>("/api/v3/regions", { ...params });
+  }
+
+  /**
+   * Find a station by ID, or the stations nearest a point (GET /api/v3/stations)
+   */
+  getStationsV3(params: GetStationsV3Params = {}): Promise<StationsResponseEnvelope> {
+    return this.get<StationsResponseEnvelope>("/api/v3/stations", { ...params });
+  }
+
+  /**
+   * Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced (GET /api/v3/sync)
+   */
+  syncPredictionsV3(params: SyncPredictionsV3Params): Promise<PredictionSyncEnvelope> {
+    return this.get<PredictionSyncEnvelope>("/api/v3/sync", { ...params });
+  }
+
+  /**
+   * Get tide predictions for a station, or for the station nearest a point (GET /api/v3/tides)
+   */
+  getTidesV3(params: GetTidesV3Params = {}): Promise<TideResponseV2Envelope> {
+    return this.get<TideResponseV2Envelope>("/api/v3/tides", { ...params });
+  }
+
+  /**
+   * Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left (GET /api/v3/usage)
+   */
+  getUsageV3(params: GetUsageV3Params = {}): Promise<UsageResponseEnvelope> {
+    return this.get<UsageResponseEnvelope>("/api/v3/usage", { ...params });
+  }
+
+  /**
+   * Get a station's level now and next highs and lows for the embeddable tide module (GET /api/v3/widget)
+   */
+  getWidgetV3(params: GetWidgetV3Params): Promise<TideWidgetEnvelope> {
+    return this.get<TideWidgetEnvelope>("/api/v3/widget", { ...params });
+  }
+
+  /**
+   * Get a station's level now and next highs and lows for the embeddable tide module (GET /api/widget)
+   * @deprecated use the latest version of this operation
+   */
+  getWidget(params: GetWidgetParams): Promise<TideWidget> {
+    return this.get<TideWidget>("/api/widget", { ...params });
+  }
+
+  /** Runs the GraphQL stations query, selecting every field */
+  async queryStations(args: QueryStationsArgs = {}): Promise<GraphQLStation[]> {
+    const data = await this.graphQL<{ stations: GraphQLStation[] }>(
+      "query($ids: [ID!], $lat: Float, $lon: Float, $limit: Int, $distanceUnit: String, $stationType: String, $capability: String, $source: String) { stations(ids: $ids, lat: $lat, lon: $lon, limit: $limit, distanceUnit: $distanceUnit, stationType: $stationType, capability: $capability, source: $source) { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } }",
+      { ...args },
+    );
+    return data.stations;
+  }
+
+  /** Runs the GraphQL nearbyStations query, selecting every field */
+  async queryNearbyStations(args: QueryNearbyStationsArgs): Promise<GraphQLStationConnection> {
+    const data = await this.graphQL<{ nearbyStations: GraphQLStationConnection }>(
+      "query($lat: Float!, $lon: Float!, $first: Int, $after: String, $distanceUnit: String, $stationType: String, $capability: String, $source: String) { nearbyStations(lat: $lat, lon: $lon, first: $first, after: $after, distanceUnit: $distanceUnit, stationType: $stationType, capability: $capability, source: $source) { edges { cursor node { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } } pageInfo { hasNextPage hasPreviousPage startCursor endCursor } totalCount stale } }",
+      { ...args },
+    );
+    return data.nearbyStations;
+  }
+
+  /** Runs the GraphQL stationsInBounds query, selecting every field */
+  async queryStationsInBounds(args: QueryStationsInBoundsArgs): Promise<GraphQLStationMap> {
+    const data = await this.graphQL<{ stationsInBounds: GraphQLStationMap }>(
+      "query($north: Float!, $south: Float!, $east: Float!, $west: Float!, $zoom: Int, $stationType: String, $capability: String, $source: String) { stationsInBounds(north: $north, south: $south, east: $east, west: $west, zoom: $zoom, stationType: $stationType, capability: $capability, source: $source) { stations { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } clusters { latitude longitude count north south east west } totalCount } }",
+      { ...args },
+    );
+    return data.stationsInBounds;
+  }
+
+  /** Runs the GraphQL regions query, selecting every field */
+  async queryRegions(args: QueryRegionsArgs = {}): Promise<GraphQLStationRegion[]> {
+    const data = await this.graphQL<{ regions: GraphQLStationRegion[] }>(
+      "query($state: String, $stationType: String, $capability: String, $source: String) { regions(state: $state, stationType: $stationType, capability: $capability, source: $source) { state region stationCount representatives { id name state region distance distanceUnit bearing latitude longitude source capabilities timeZoneOffset timeZone stationType } } }",
+      { ...args },
+    );
+    return data.regions;
+  }
+
+  /** Runs the GraphQL tides query, selecting every field */
+  async queryTides(args: QueryTidesArgs): Promise<GraphQLTideData> {
+    const data = await this.graphQL<{ tides: GraphQLTideData }>(
+      "query($stationId: ID!, $startDateTime: String!, $endDateTime: String!, $interpolation: String, $points: Int, $includeWeather: Boolean, $tz: String, $locale: String, $hour12: Boolean) { tides(stationId: $stationId, startDateTime: $startDateTime, endDateTime: $endDateTime, interpolation: $interpolation, points: $points, includeWeather: $includeWeather, tz: $tz, locale: $locale, hour12: $hour12) { timestamp localTime waterLevel predictedLevel nearestStation location latitude longitude stationDistance tideType calculationMethod predictions { timestamp localTime height } extremes { type timestamp localTime height } timeZoneOffsetSeconds summary { nextHigh { type timestamp localTime height } nextLow { type timestamp localTime height } trend todayRange { low high } cycleElapsedPercent } astronomy { tideCycle moonPhase daysSinceNewMoon daysSinceFullMoon } conditions { waterTemperature { timestamp localTime value units } conductivity { timestamp localTime value units } } weather { source available forecast { timestamp localTime windSpeedKnots windGustKnots windDirectionDegrees pressureHpa } } confidence { level meanAbsoluteError bias samples days } offsets { referenceStationId referenceStationName timeOffsetHighMinutes timeOffsetLowMinutes heightOffsetHigh heightOffsetLow heightAdjustment } anomaly { detected residual threshold observedLevel predictedLevel timestamp localTime } warnings { code message } } }",
+      { ...args },
+    );
+    return data.tides;
+  }
+
+  /** Runs the GraphQL extremes query, selecting every field */
+  async queryExtremes(args: QueryExtremesArgs): Promise<GraphQLExtremesSummary> {
+    const data = await this.graphQL<{ extremes: GraphQLExtremesSummary }>(
+      "query($stationId: ID!, $startDate: String, $days: Int) { extremes(stationId: $stationId, startDate: $startDate, days: $days) { stationId stationName timeZone days { date extremes { type time timestamp height } } } }",
+      { ...args },
+    );
+    return data.extremes;
+  }
+
+  /** Runs the GraphQL tideExtremes query, selecting every field */
+  async queryTideExtremes(args: QueryTideExtremesArgs): Promise<GraphQLTideExtremes> {
+    const data = await this.graphQL<{ tideExtremes: GraphQLTideExtremes }>(
+      "query($stationId: ID!, $startDateTime: String!, $endDateTime: String!, $tz: String) { tideExtremes(stationId: $stationId, startDateTime: $startDateTime, endDateTime: $endDateTime, tz: $tz) { stationId stationName timeZone extremes { type timestamp localTime height } } }",
+      { ...args },
+    );
+    return data.tideExtremes;
+  }
+
+  /** Runs the GraphQL daylightLows query, selecting every field */
+  async queryDaylightLows(args: QueryDaylightLowsArgs): Promise<GraphQLDaylightLows> {
+    const data = await this.graphQL<{ daylightLows: GraphQLDaylightLows }>(
+      "query($stationId: ID!, $below: Float, $startDate: String, $days: Int) { daylightLows(stationId: $stationId, below: $below, startDate: $startDate, days: $days) { stationId stationName timeZone below days { date sunrise sunset daylightMinutes lows { type time timestamp height } } } }",
+      { ...args },
+    );
+    return data.daylightLows;
+  }
+
+  /** Runs the GraphQL nextExtremes query, selecting every field */
+  async queryNextExtremes(args: QueryNextExtremesArgs): Promise<GraphQLNextExtremes> {
+    const data = await this.graphQL<{ nextExtremes: GraphQLNextExtremes }>(
+      "query($stationId: ID!, $count: Int) { nextExtremes(stationId: $stationId, count: $count) { stationId stationName timeZone extremes { type timestamp localTime height } } }",
+      { ...args },
+    );
+    return data.nextExtremes;
+  }
+
+  /** Runs the GraphQL compareStations query, selecting every field */
+  async queryCompareStations(args: QueryCompareStationsArgs): Promise<GraphQLStationComparison> {
+    const data = await this.graphQL<{ compareStations: GraphQLStationComparison }>(
+      "query($stationIds: [ID!]!, $startDateTime: String, $endDateTime: String, $interval: Int, $tz: String) { compareStations(stationIds: $stationIds, startDateTime: $startDateTime, endDateTime: $endDateTime, interval: $interval, tz: $tz) { intervalMinutes timestamps stations { id name heights lagMinutes rangeRatio } } }",
+      { ...args },
+    );
+    return data.compareStations;
+  }
+
+  /** Runs the GraphQL observation query, selecting every field */
+  async queryObservation(args: QueryObservationArgs): Promise<GraphQLStationObservation> {
+    const data = await this.graphQL<{ observation: GraphQLStationObservation }>(
+      "query($stationId: ID!, $product: String!) { observation(stationId: $stationId, product: $product) { stationId stationName product observation { timestamp localTime value units } } }",
+      { ...args },
+    );
+    return data.observation;
+  }
+
+  /** Runs the GraphQL accuracy query, selecting every field */
+  async queryAccuracy(args: QueryAccuracyArgs): Promise<GraphQLAccuracyStats> {
+    const data = await this.graphQL<{ accuracy: GraphQLAccuracyStats }>(
+      "query($stationId: ID!, $days: Int) { accuracy(stationId: $stationId, days: $days) { stationId stationName days samples meanAbsoluteError bias confidence } }",
+      { ...args },
+    );
+    return data.accuracy;
+  }
+
+  /** Runs the GraphQL stationChanges query, selecting every field */
+  async queryStationChanges(args: QueryStationChangesArgs = {}): Promise<GraphQLStationListChange[]> {
+    const data = await this.graphQL<{ stationChanges: GraphQLStationListChange[] }>(
+      "query($since: String, $limit: Int) { stationChanges(since: $since, limit: $limit) { id source detectedAt stations { stationId name change fields { field before after } } omitted } }",
+      { ...args },
+    );
+    return data.stationChanges;
+  }
+
+  /** Runs the GraphQL me query, selecting every field */
+  async queryMe(args: QueryMeArgs = {}): Promise<GraphQLUserProfile> {
+    const data = await this.graphQL<{ me: GraphQLUserProfile }>(
+      "query { me { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }",
+      { ...args },
+    );
+    return data.me;
+  }
+
+  /** Runs the GraphQL addFavorite mutation, selecting every field */
+  async mutateAddFavorite(args: MutateAddFavoriteArgs): Promise<GraphQLUserProfile> {
+    const data = await this.graphQL<{ addFavorite: GraphQLUserProfile }>(
+      "mutation($stationId: ID!) { addFavorite(stationId: $stationId) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }",
+      { ...args },
+    );
+    return data.addFavorite;
+  }
+
+  /** Runs the GraphQL removeFavorite mutation, selecting every field */
+  async mutateRemoveFavorite(args: MutateRemoveFavoriteArgs): Promise<GraphQLUserProfile> {
+    const data = await this.graphQL<{ removeFavorite: GraphQLUserProfile }>(
+      "mutation($stationId: ID!) { removeFavorite(stationId: $stationId) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }",
+      { ...args },
+    );
+    return data.removeFavorite;
+  }
+
+  /** Runs the GraphQL restoreFavorite mutation, selecting every field */
+  async mutateRestoreFavorite(args: MutateRestoreFavoriteArgs): Promise<GraphQLUserProfile> {
+    const data = await this.graphQL<{ restoreFavorite: GraphQLUserProfile }>(
+      "mutation($stationId: ID!) { restoreFavorite(stationId: $stationId) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }",
+      { ...args },
+    );
+    return data.restoreFavorite;
+  }
+
+  /** Runs the GraphQL updatePreferences mutation, selecting every field */
+  async mutateUpdatePreferences(args: MutateUpdatePreferencesArgs = {}): Promise<GraphQLUserProfile> {
+    const data = await this.graphQL<{ updatePreferences: GraphQLUserProfile }>(
+      "mutation($units: String, $datum: String) { updatePreferences(units: $units, datum: $datum) { userId favorites { stationId name addedAt updatedAt deletedAt } deletedFavorites { stationId name addedAt updatedAt deletedAt } units datum updatedAt } }",
+      { ...args },
+    );
+    return data.updatePreferences;
+  }
+
+  /** Runs the GraphQL exportTideTable mutation, selecting every field */
+  async mutateExportTideTable(args: MutateExportTideTableArgs): Promise<GraphQLTideTableExport> {
+    const data = await this.graphQL<{ exportTideTable: GraphQLTideTableExport }>(
+      "mutation($stationId: ID!, $year: Int!, $format: String) { exportTideTable(stationId: $stationId, year: $year, format: $format) { stationId year format status downloadUrl expiresAt error } }",
+      { ...args },
+    );
+    return data.exportTideTable;
+  }
+
+  /** Runs the GraphQL reportObservation mutation, selecting every field */
+  async mutateReportObservation(args: MutateReportObservationArgs): Promise<GraphQLObservationReport> {
+    const data = await this.graphQL<{ reportObservation: GraphQLObservationReport }>(
+      "mutation($stationId: ID!, $timestamp: Int!, $observedHeight: Float, $condition: String, $photoUrl: String) { reportObservation(stationId: $stationId, timestamp: $timestamp, observedHeight: $observedHeight, condition: $condition, photoUrl: $photoUrl) { id stationId timestamp observedHeight condition photoUrl submittedAt } }",
+      { ...args },
+    );
+    return data.reportObservation;
+  }
+
+  private async get<T>(path: string, params: Record<string, QueryValue>): Promise<T> {
+    const query = new URLSearchParams();
+    for (const [key, value] of Object.entries(params)) {
+      if (value !== undefined && value !== null) {
+        query.set(key, String(value));
+      }
+    }
+    const qs = query.toString();
+    const response = await this.fetchImpl(this.baseUrl + path + (qs ? "?" + qs : ""), {
+      headers: { ...this.headers, Accept: "application/json" },
+    });
+    const body = await response.json();
+    if (!response.ok) {
+      throw new FlowebbError(body?.error ?? response.statusText, response.status, body?.details ?? [], body?.code);
+    }
+    return body as T;
+  }
+
+  private async graphQL<T>(query: string, variables: Record<string, unknown>): Promise<T> {
+    const response = await this.fetchImpl(this.baseUrl + this.graphQLPath, {
+      method: "POST",
+      headers: { ...this.headers, "Content-Type": "application/json", Accept: "application/json" },
+      body: JSON.stringify({ query, variables }),
+    });
+    const body = await response.json();
+    if (!response.ok || body?.errors?.length) {
+      const message = body?.errors?.map((e: { message: string }) => e.message).join("; ");
+      throw new FlowebbError(message || response.statusText, response.status, [], body?.errors?.[0]?.extensions?.code);
+    }
+    return body.data as T;
+  }
+}
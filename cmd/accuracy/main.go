@@ -0,0 +1,59 @@
+// Command accuracy is the Lambda function EventBridge runs on a schedule to compare the
+// tracked reference stations' gauge readings with their predictions
+package main
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	lambdaStart = lambda.Start // Allow mocking of lambda.Start in tests
+	tracker     *accuracy.Tracker
+	tideService *tide.Service
+	ready       = startup.New(initializeService)
+
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
+)
+
+// initializeService creates the tracker on the first run, and again on a later one if
+// it fails
+func initializeService() error {
+	job, err := app.BuildAccuracy(context.Background(), app.WithFinderFactory(finderFactory))
+	if err != nil {
+		return err
+	}
+	if len(job.Tracker.Stations) == 0 {
+		log.Warn().Msg("ACCURACY_STATIONS is not set, no stations are sampled")
+	}
+	tracker = job.Tracker
+	tideService = job.Service
+	return nil
+}
+
+// handleEvent samples every tracked station. Stations that fail are logged rather than
+// failing the run: Lambda would retry it, and the next scheduled run samples them again.
+func handleEvent(ctx context.Context, _ events.CloudWatchEvent) error {
+	if err := ready.Do(); err != nil {
+		return err
+	}
+	defer app.FlushCacheWrites(ctx, tideService)
+
+	if err := tracker.Run(ctx); err != nil {
+		log.Error().Err(err).Msg("Some stations weren't sampled")
+	}
+	return nil
+}
+
+func main() {
+	lambdaStart(recovery.Guard(handleEvent))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gauges reads a fixed water level from the stations it knows
+type gauges map[string]float64
+
+func (g gauges) Latest(_ context.Context, stationID string, _ observation.Product) (*models.Observation, error) {
+	level, ok := g[stationID]
+	if !ok {
+		return nil, errors.New("no gauge")
+	}
+	return &models.Observation{Timestamp: models.MillisOf(time.Now()), Value: level, Units: "ft"}, nil
+}
+
+type flatPredictor float64
+
+func (p flatPredictor) PredictedLevel(context.Context, string, time.Time) (float64, error) {
+	return float64(p), nil
+}
+
+func TestHandleEvent(t *testing.T) {
+	require.NoError(t, ready.Do())
+	require.NotNil(t, tracker)
+
+	original := tracker
+	defer func() { tracker = original }()
+	store := accuracy.NewMemoryStore()
+	tracker = &accuracy.Tracker{
+		Store:     store,
+		Observer:  gauges{"9447130": 5.5},
+		Predictor: flatPredictor(5),
+		Stations:  []string{"9447130", "8454000"},
+	}
+
+	require.NoError(t, handleEvent(context.Background(), events.CloudWatchEvent{}), "a station without a reading doesn't fail the run")
+
+	today := time.Now().UTC().Format("2006-01-02")
+	totals, err := store.Totals(context.Background(), "9447130", []string{today})
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, 1, totals[0].Samples)
+	assert.InDelta(t, 0.5, totals[0].SumError, 1e-9)
+}
+
+func TestMain_StartsLambda(t *testing.T) {
+	original := lambdaStart
+	defer func() { lambdaStart = original }()
+	var handler interface{}
+	lambdaStart = func(h interface{}) { handler = h }
+
+	main()
+	assert.NotNil(t, handler)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/handler"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	lambdaStart  = lambda.Start // Allow mocking of lambda.Start in tests
+	adminHandler *handler.AdminHandler
+	ready        = startup.New(initializeService)
+
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
+)
+
+// initializeService creates the admin handler on the first request, and again on a later
+// one if it fails
+func initializeService() error {
+	admin, err := app.BuildAdmin(context.Background(), app.WithFinderFactory(finderFactory))
+	if err != nil {
+		return err
+	}
+	if admin.Config.AdminAPIKey == "" {
+		log.Warn().Msg("ADMIN_API_KEY is not set, admin API is disabled")
+	}
+	adminHandler = admin.Handler
+	return nil
+}
+
+func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := ready.Do(); err != nil {
+		return api.ErrorFor(err)
+	}
+	return adminHandler.HandleRequest(ctx, request)
+}
+
+func main() {
+	lambdaStart(api.Recover(handleRequest))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestHandleRequest_RequiresAdminKey(t *testing.T) {
+	require.NoError(t, ready.Do())
+	require.NotNil(t, adminHandler)
+
+	// ADMIN_API_KEY isn't set in tests, so the API stays disabled
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/admin/cache",
+		Headers:    map[string]string{"X-Admin-Key": ""},
+		QueryStringParameters: map[string]string{
+			"stationId": "9447130",
+			"date":      "2024-01-01",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,82 @@
+// Command export is the Lambda function that renders tide tables into the export bucket. S3
+// invokes it when a job is written there, and EventBridge once a year to render the next
+// year's tables for the stations in EXPORT_STATIONS ahead of demand.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	lambdaStart = lambda.Start // Allow mocking of lambda.Start in tests
+	exporter    *export.Exporter
+	tideService *tide.Service
+	stations    []string
+	ready       = startup.New(initializeService)
+
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
+	exportBucket  export.Bucket         = export.NewS3Bucket()
+)
+
+// initializeService creates the exporter on the first run, and again on a later one if
+// it fails
+func initializeService() error {
+	job, err := app.BuildExport(context.Background(), app.WithFinderFactory(finderFactory), app.WithExportBucket(exportBucket))
+	if err != nil {
+		return err
+	}
+	exporter = job.Exporter
+	tideService = job.Service
+	stations = job.Config.ExportStations
+	return nil
+}
+
+// handleEvent runs the jobs an S3 notification names, or the yearly tables when the
+// schedule invokes it. Failures are recorded in the jobs and logged rather than failing
+// the run: Lambda would retry it, and a later request submits a failed table again.
+func handleEvent(ctx context.Context, event json.RawMessage) error {
+	if err := ready.Do(); err != nil {
+		return err
+	}
+	defer app.FlushCacheWrites(ctx, tideService)
+
+	var notification events.S3Event
+	if err := json.Unmarshal(event, &notification); err == nil && len(notification.Records) > 0 {
+		for _, record := range notification.Records {
+			key := record.S3.Object.URLDecodedKey
+			if !strings.HasPrefix(key, export.JobPrefix) {
+				continue
+			}
+			if err := exporter.RunJob(ctx, key); err != nil {
+				log.Error().Err(err).Str("key", key).Msg("Export job failed")
+			}
+		}
+		return nil
+	}
+
+	if len(stations) == 0 {
+		log.Warn().Msg("EXPORT_STATIONS is not set, no tables are rendered")
+		return nil
+	}
+	if err := exporter.RunScheduled(ctx, stations, time.Now().Year()+1); err != nil {
+		log.Error().Err(err).Msg("Some tables weren't exported")
+	}
+	return nil
+}
+
+func main() {
+	lambdaStart(recovery.Guard(handleEvent))
+}
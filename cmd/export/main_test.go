@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBucket keeps objects in memory
+type memBucket struct {
+	export.Bucket
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *memBucket) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	body, ok := b.objects[*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (b *memBucket) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[*params.Key] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+// extremesProvider answers with a high every day
+type extremesProvider struct {
+	models.TideProvider
+}
+
+func (extremesProvider) GetDailyExtremes(_ context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error) {
+	start, err := time.Parse("2006-01-02", *startDate)
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.ExtremesSummary{StationID: stationID, StationName: "Seattle"}
+	for i := 0; i < days; i++ {
+		summary.Days = append(summary.Days, models.DailyExtremes{
+			Date:     start.AddDate(0, 0, i).Format("2006-01-02"),
+			Extremes: []models.CompactExtreme{{Type: models.TideTypeHigh, Time: "04:12", Height: 11.5}},
+		})
+	}
+	return summary, nil
+}
+
+// useExporter runs the handler against an in-memory bucket and canned extremes
+func useExporter(t *testing.T, stationIDs []string) *memBucket {
+	t.Setenv("EXPORT_BUCKET", "flowebb-exports")
+	require.NoError(t, ready.Do())
+
+	originalExporter, originalStations := exporter, stations
+	t.Cleanup(func() { exporter, stations = originalExporter, originalStations })
+	bucket := &memBucket{objects: map[string][]byte{}}
+	exporter = export.NewExporter(export.NewStore(bucket, "flowebb-exports", time.Hour), extremesProvider{})
+	stations = stationIDs
+	return bucket
+}
+
+func TestHandleEvent_S3Notification(t *testing.T) {
+	bucket := useExporter(t, nil)
+	req := export.Request{StationID: "9447130", Year: 2025, Format: export.FormatCSV}
+	require.NoError(t, exporter.Store.PutJob(context.Background(), &export.Job{Request: req, Status: models.ExportPending}))
+
+	notification := events.S3Event{Records: []events.S3EventRecord{
+		{S3: events.S3Entity{Object: events.S3Object{Key: export.JobKey(req)}}},
+		{S3: events.S3Entity{Object: events.S3Object{Key: export.FileKey(req)}}},
+	}}
+	event, err := json.Marshal(notification)
+	require.NoError(t, err)
+
+	require.NoError(t, handleEvent(context.Background(), event))
+	assert.Contains(t, string(bucket.objects[export.FileKey(req)]), "2025-01-01,04:12,HIGH,11.50")
+	job, err := exporter.Store.Job(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportComplete, job.Status)
+}
+
+func TestHandleEvent_Schedule(t *testing.T) {
+	bucket := useExporter(t, []string{"9447130"})
+	event, err := json.Marshal(events.CloudWatchEvent{DetailType: "Scheduled Event", Source: "aws.events"})
+	require.NoError(t, err)
+
+	require.NoError(t, handleEvent(context.Background(), event))
+	year := strconv.Itoa(time.Now().Year() + 1)
+	for _, format := range export.TableFormats {
+		key := "tide-tables/9447130/" + year + "." + format
+		assert.NotEmpty(t, bucket.objects[key], key)
+	}
+}
+
+func TestMain_StartsLambda(t *testing.T) {
+	original := lambdaStart
+	defer func() { lambdaStart = original }()
+	var handler interface{}
+	lambdaStart = func(h interface{}) { handler = h }
+
+	main()
+	assert.NotNil(t, handler)
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	dateLayout      = "2006-01-02"
+	localTimeLayout = "2006-01-02T15:04:05"
+
+	// maxDays matches the longest range the tide service accepts
+	maxDays = 30
+)
+
+func newStationsNearCmd(a *app) *cobra.Command {
+	var limit int
+	format := formatTable
+	cmd := &cobra.Command{
+		Use:   "near LAT LON",
+		Short: "Nearest stations to a point",
+		// Flags are parsed by the command so negative coordinates aren't taken for flags
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values, err := parseCoordinateArgs(cmd, args)
+			if err != nil {
+				return err
+			}
+			if help, _ := cmd.Flags().GetBool("help"); help {
+				return cmd.Help()
+			}
+			if err := positional("LAT", "LON")(cmd, values); err != nil {
+				return err
+			}
+
+			lat, errLat := strconv.ParseFloat(values[0], 64)
+			lon, errLon := strconv.ParseFloat(values[1], 64)
+			if errLat != nil || errLon != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+				return fmt.Errorf("%w: invalid coordinates %s %s", errUsage, values[0], values[1])
+			}
+			if _, err := (models.StationLimits{}).Resolve("limit", &limit); err != nil {
+				return fmt.Errorf("%w: %v", errUsage, err)
+			}
+
+			s, err := a.services(cmd.Context())
+			if err != nil {
+				return err
+			}
+			stations, err := s.finder.FindNearestStations(cmd.Context(), lat, lon, limit)
+			if err != nil {
+				return fmt.Errorf("finding stations: %w", err)
+			}
+			return writeResult(a.stdout, format, stations, stationsTable(stations))
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", models.DefaultStationLimit, "number of stations")
+	cmd.Flags().Var(&format, "format", "table, json or csv")
+	return cmd
+}
+
+func newStationsGetCmd(a *app) *cobra.Command {
+	format := formatTable
+	cmd := &cobra.Command{
+		Use:   "get STATION",
+		Short: "A single station",
+		Args:  positional("STATION"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			s, err := a.services(ctx)
+			if err != nil {
+				return err
+			}
+			station, err := s.finder.FindStation(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("finding station: %w", err)
+			}
+			models.AddSensorCapabilities(ctx, s.finder, station)
+			station.Offsets = models.FindTideOffsets(ctx, s.finder, *station)
+			return writeResult(a.stdout, format, station, stationsTable([]models.Station{*station}))
+		},
+	}
+	cmd.Flags().Var(&format, "format", "table, json or csv")
+	return cmd
+}
+
+func stationsTable(stations []models.Station) table {
+	t := table{header: []string{"ID", "NAME", "STATE", "DISTANCE_KM", "LATITUDE", "LONGITUDE", "TIME_ZONE"}}
+	for _, s := range stations {
+		t.rows = append(t.rows, []string{
+			s.ID,
+			s.Name,
+			stringOrEmpty(s.State),
+			formatFloat(s.Distance, 1),
+			formatFloat(s.Latitude, 4),
+			formatFloat(s.Longitude, 4),
+			s.TimeZone,
+		})
+	}
+	return t
+}
+
+// rangeFlags are the --start and --days flags shared by tides and cache stats
+type rangeFlags struct {
+	start string
+	days  int
+}
+
+func (r *rangeFlags) register(fs *pflag.FlagSet) {
+	fs.StringVar(&r.start, "start", "", "first day, YYYY-MM-DD in the station's time zone (default today)")
+	fs.IntVar(&r.days, "days", 1, "number of days")
+}
+
+// resolve turns the flags into the local start and end times the tide service takes.
+// Without --start the range begins today in the station's time zone.
+func (r *rangeFlags) resolve(ctx context.Context, s *services, stationID string) (start, end string, err error) {
+	if r.days < 1 || r.days > maxDays {
+		return "", "", fmt.Errorf("%w: --days must be between 1 and %d", errUsage, maxDays)
+	}
+
+	var first time.Time
+	if r.start != "" {
+		first, err = time.Parse(dateLayout, r.start)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: --start must be YYYY-MM-DD", errUsage)
+		}
+	} else {
+		station, err := s.finder.FindStation(ctx, stationID)
+		if err != nil {
+			return "", "", fmt.Errorf("finding station: %w", err)
+		}
+		now := time.Now().In(station.Location())
+		first = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	last := first.AddDate(0, 0, r.days).Add(-time.Second)
+	return first.Format(localTimeLayout), last.Format(localTimeLayout), nil
+}
+
+func newTidesCmd(a *app) *cobra.Command {
+	var r rangeFlags
+	var interpolation string
+	var predictions bool
+	format := formatTable
+	cmd := &cobra.Command{
+		Use:   "tides STATION",
+		Short: "Tides for a station",
+		Args:  positional("STATION"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if interpolation != "" {
+				interpolator, err := tide.NewInterpolator(interpolation)
+				if err != nil {
+					return fmt.Errorf("%w: %v", errUsage, err)
+				}
+				ctx = tide.WithInterpolator(ctx, interpolator)
+			}
+
+			s, err := a.services(ctx)
+			if err != nil {
+				return err
+			}
+			response, err := lookupTides(ctx, s, args[0], &r)
+			if err != nil {
+				return err
+			}
+
+			t := table{header: []string{"TYPE", "LOCAL_TIME", "TIMESTAMP", "HEIGHT"}}
+			if predictions {
+				t.header = t.header[1:]
+				for _, p := range response.Predictions {
+					t.rows = append(t.rows, []string{p.LocalTime, strconv.FormatInt(int64(p.Timestamp), 10), formatFloat(p.Height, 3)})
+				}
+			} else {
+				for _, e := range response.Extremes {
+					t.rows = append(t.rows, []string{string(e.Type), e.LocalTime, strconv.FormatInt(int64(e.Timestamp), 10), formatFloat(e.Height, 3)})
+				}
+			}
+
+			if format == formatTable {
+				fmt.Fprintf(a.stdout, "Station %s, %s\n\n", response.NearestStation, response.CalculationMethod)
+			}
+			return writeResult(a.stdout, format, response, t)
+		},
+	}
+	r.register(cmd.Flags())
+	cmd.Flags().StringVar(&interpolation, "interpolation", "", "interpolation between known points")
+	cmd.Flags().BoolVar(&predictions, "predictions", false, "list every prediction instead of highs and lows (table and csv)")
+	cmd.Flags().Var(&format, "format", "table, json or csv")
+	return cmd
+}
+
+func lookupTides(ctx context.Context, s *services, stationID string, r *rangeFlags) (*models.ExtendedTideResponse, error) {
+	start, end, err := r.resolve(ctx, s, stationID)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.tides.GetCurrentTideForStation(ctx, stationID, &start, &end)
+	if err != nil {
+		return nil, fmt.Errorf("getting tides: %w", err)
+	}
+	return response, nil
+}
+
+func newCacheStatsCmd(a *app) *cobra.Command {
+	var r rangeFlags
+	format := formatTable
+	cmd := &cobra.Command{
+		Use:   "stats [STATION]",
+		Short: "Cache hits and misses, after looking up STATION's predictions when given",
+		Args:  positional("[STATION]"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			s, err := a.services(ctx)
+			if err != nil {
+				return err
+			}
+			if s.cache == nil {
+				return fmt.Errorf("the prediction cache doesn't report stats")
+			}
+
+			// Stats are per process, so look the predictions up first to see which tier serves them
+			if len(args) == 1 {
+				if _, err := lookupTides(ctx, s, args[0], &r); err != nil {
+					return err
+				}
+			}
+
+			stats := s.cache.GetCacheStats()
+			names := make([]string, 0, len(stats))
+			for name := range stats {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			t := table{header: []string{"STAT", "VALUE"}}
+			for _, name := range names {
+				t.rows = append(t.rows, []string{name, strconv.FormatUint(stats[name], 10)})
+			}
+			return writeResult(a.stdout, format, stats, t)
+		},
+	}
+	r.register(cmd.Flags())
+	cmd.Flags().Var(&format, "format", "table, json or csv")
+	return cmd
+}
+
+func newCacheInspectCmd(a *app) *cobra.Command {
+	format := formatTable
+	cmd := &cobra.Command{
+		Use:   "inspect STATION DATE",
+		Short: "What each cache tier holds for a day",
+		Args:  positional("STATION", "DATE"),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			date, err := time.Parse(dateLayout, args[1])
+			if err != nil {
+				return fmt.Errorf("%w: DATE must be YYYY-MM-DD", errUsage)
+			}
+
+			ctx := cmd.Context()
+			s, err := a.services(ctx)
+			if err != nil {
+				return err
+			}
+			if s.cache == nil {
+				return fmt.Errorf("the prediction cache doesn't support inspection")
+			}
+			info, err := s.cache.Inspect(ctx, args[0], date)
+			if err != nil {
+				return fmt.Errorf("inspecting cache: %w", err)
+			}
+
+			t := table{header: []string{"TIER", "CACHED", "EXPIRES_AT", "TTL_SECONDS", "SIZE_BYTES", "PREDICTIONS", "EXTREMES"}}
+			for _, tier := range []struct {
+				name string
+				info *cache.TierEntryInfo
+			}{{"lru", info.LRU}, {info.StoreName, info.Store}} {
+				if tier.info == nil {
+					t.rows = append(t.rows, []string{tier.name, "no", "", "", "", "", ""})
+					continue
+				}
+				t.rows = append(t.rows, []string{
+					tier.name,
+					"yes",
+					tier.info.ExpiresAt.UTC().Format(time.RFC3339),
+					strconv.FormatInt(tier.info.TTLSeconds, 10),
+					strconv.FormatInt(tier.info.SizeBytes, 10),
+					strconv.Itoa(tier.info.Predictions),
+					strconv.Itoa(tier.info.Extremes),
+				})
+			}
+			return writeResult(a.stdout, format, info, t)
+		},
+	}
+	cmd.Flags().Var(&format, "format", "table, json or csv")
+	return cmd
+}
@@ -0,0 +1,260 @@
+// Command flowebb looks up stations and tides from the command line using the same
+// services as the Lambda handlers, for scripting and for debugging cache behavior:
+//
+//	flowebb stations near 47.6 -122.3 --limit 5
+//	flowebb stations get 9447130
+//	flowebb tides 9447130 --days 3 --format table|json|csv
+//	flowebb cache stats [STATION] [--days N]
+//	flowebb cache inspect 9447130 2024-01-01
+//
+// Configuration comes from the same environment variables as the Lambdas.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	wiring "github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// cacheFlushTimeout bounds how long the CLI waits for queued cache writes before exiting
+const cacheFlushTimeout = 5 * time.Second
+
+// errUsage is returned for malformed command lines; run prints the command's usage
+var errUsage = errors.New("invalid usage")
+
+// cacheInspector is the part of the prediction cache the cache commands use
+type cacheInspector interface {
+	GetCacheStats() map[string]uint64
+	Inspect(ctx context.Context, stationID string, date time.Time) (*cache.CacheEntryInfo, error)
+}
+
+// services are what the commands talk to
+type services struct {
+	finder models.StationFinder
+	tides  models.TideProvider
+	cache  cacheInspector // nil when the cache doesn't support inspection
+	flush  func(ctx context.Context) error
+}
+
+// Variables exposed for testing
+var (
+	newServices = defaultServices
+
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
+)
+
+func defaultServices(ctx context.Context) (*services, error) {
+	tides, err := wiring.BuildTides(ctx, wiring.AsCommand(), wiring.WithFinderFactory(finderFactory))
+	if err != nil {
+		return nil, err
+	}
+
+	inspector, _ := tides.Service.PredictionCache.(cacheInspector)
+	return &services{
+		finder: tides.Finder,
+		tides:  tides.Service,
+		cache:  inspector,
+		flush:  tides.Service.FlushCacheWrites,
+	}, nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes a command line and returns the process exit code: 0 on success, 1 when
+// the command fails and 2 for usage errors
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	a := &app{stdout: stdout}
+	root := newRootCmd(a)
+	root.SetArgs(args)
+	// Results go to stdout; help and errors to stderr so they never mix with them
+	root.SetOut(stderr)
+	root.SetErr(stderr)
+
+	cmd, err := root.ExecuteContextC(ctx)
+	a.flush()
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(stderr, "flowebb: %v\n\n%s", err, cmd.UsageString())
+		return 2
+	default:
+		fmt.Fprintf(stderr, "flowebb: %v\n", err)
+		return 1
+	}
+}
+
+func newRootCmd(a *app) *cobra.Command {
+	var verbose bool
+	root := &cobra.Command{
+		Use:   "flowebb",
+		Short: "Look up stations and tides using the same services as the Lambda handlers",
+		Long: "Look up stations and tides using the same services as the Lambda handlers.\n\n" +
+			"Configuration comes from the same environment variables, and CONFIG_FILE, as the Lambdas.",
+		Args:          cobra.ArbitraryArgs,
+		RunE:          group,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setupLogging(cmd.ErrOrStderr(), verbose)
+		},
+	}
+	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "log debug output to stderr")
+	root.CompletionOptions.DisableDefaultCmd = true
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	})
+
+	stations := &cobra.Command{Use: "stations", Short: "Find stations", RunE: group}
+	stations.AddCommand(newStationsNearCmd(a), newStationsGetCmd(a))
+	cacheCmd := &cobra.Command{Use: "cache", Short: "Look at the prediction cache", RunE: group}
+	cacheCmd.AddCommand(newCacheStatsCmd(a), newCacheInspectCmd(a))
+	root.AddCommand(stations, newTidesCmd(a), cacheCmd)
+	return root
+}
+
+// group runs commands that only hold subcommands, which cobra would otherwise answer with
+// their help whatever the arguments
+func group(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+	return fmt.Errorf("%w: unknown command %q", errUsage, strings.TrimPrefix(cmd.CommandPath()+" "+args[0], "flowebb "))
+}
+
+// app is what the commands share: where results go, and the services, created on first
+// use so usage errors don't wait on cache setup
+type app struct {
+	stdout io.Writer
+	svc    *services
+}
+
+func (a *app) services(ctx context.Context) (*services, error) {
+	if a.svc == nil {
+		svc, err := newServices(ctx)
+		if err != nil {
+			return nil, err
+		}
+		a.svc = svc
+	}
+	return a.svc, nil
+}
+
+// flush waits for queued cache writes, if any services were created
+func (a *app) flush() {
+	if a.svc == nil || a.svc.flush == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheFlushTimeout)
+	defer cancel()
+	if err := a.svc.flush(ctx); err != nil {
+		log.Warn().Err(err).Msg("Cache writes did not finish before exiting")
+	}
+}
+
+// setupLogging sends logs to stderr so they never mix with command output. Without -v
+// only warnings and errors are logged, unless LOG_LEVEL says otherwise.
+func setupLogging(stderr io.Writer, verbose bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	level := cfg.LogLevel
+	if !cfg.IsSet("LOG_LEVEL") {
+		level = zerolog.WarnLevel
+	}
+	if verbose {
+		level = zerolog.DebugLevel
+	}
+	zerolog.SetGlobalLevel(level)
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: stderr})
+	cfg.LogDump()
+	return nil
+}
+
+// positional checks a command's positional arguments against their names. Optional ones
+// are named in brackets and must come last.
+func positional(names ...string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		required := 0
+		for _, name := range names {
+			if !strings.HasPrefix(name, "[") {
+				required++
+			}
+		}
+		if len(args) < required || len(args) > len(names) {
+			return fmt.Errorf("%w: %s expects %s", errUsage, strings.TrimPrefix(cmd.CommandPath(), "flowebb "), strings.Join(names, " "))
+		}
+		return nil
+	}
+}
+
+// parseCoordinateArgs parses the flags of a command that has flag parsing disabled,
+// treating negative numbers as positional so coordinates like -122.3 aren't mistaken for
+// flags, and returns the positional arguments
+func parseCoordinateArgs(cmd *cobra.Command, args []string) ([]string, error) {
+	// Flags inherited from the root are only merged in when cobra parses flags itself
+	cmd.InheritedFlags()
+
+	var flags, values []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			values = append(values, args[i+1:]...)
+			break
+		}
+		if _, err := strconv.ParseFloat(arg, 64); err == nil || !strings.HasPrefix(arg, "-") || arg == "-" {
+			values = append(values, arg)
+			continue
+		}
+
+		flags = append(flags, arg)
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		if f := lookupFlag(cmd.Flags(), arg); f != nil && f.NoOptDefVal == "" && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+
+	if err := cmd.Flags().Parse(flags); err != nil {
+		return nil, cmd.FlagErrorFunc()(cmd, err)
+	}
+	// The root's pre-run saw the flags unparsed, so -v takes effect only now
+	if err := cmd.Root().PersistentPreRunE(cmd, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// lookupFlag finds the flag a --name or -n argument names
+func lookupFlag(fs *pflag.FlagSet, arg string) *pflag.Flag {
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		return fs.Lookup(name)
+	}
+	if name := strings.TrimPrefix(arg, "-"); len(name) == 1 {
+		return fs.ShorthandLookup(name)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTides struct {
+	start, end string
+	calls      int
+}
+
+func (f *fakeTides) GetCurrentTide(context.Context, float64, float64, *string, *string) (*models.ExtendedTideResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetCurrentTideForStation(_ context.Context, stationID string, start, end *string) (*models.ExtendedTideResponse, error) {
+	f.calls++
+	f.start, f.end = *start, *end
+	return &models.ExtendedTideResponse{
+		NearestStation:    stationID,
+		CalculationMethod: "NOAA API",
+		Extremes: []models.TideExtreme{
+			{Type: models.TideTypeHigh, Timestamp: 1704096000000, LocalTime: "2024-01-01T00:00:00", Height: 3.5},
+			{Type: models.TideTypeLow, Timestamp: 1704118800000, LocalTime: "2024-01-01T06:20:00", Height: -0.25},
+		},
+		Predictions: []models.TidePrediction{
+			{Timestamp: 1704096000000, LocalTime: "2024-01-01T00:00:00", Height: 3.5},
+		},
+	}, nil
+}
+
+func (f *fakeTides) GetDailyExtremes(context.Context, string, *string, int) (*models.ExtremesSummary, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetDailyPredictions(context.Context, string, *string, int) (*models.DailyPredictions, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetNextExtremes(context.Context, string, int) (*models.NextExtremes, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetTideExtremes(context.Context, string, *string, *string) (*models.TideExtremes, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetDaylightLows(context.Context, string, *float64, *string, int) (*models.DaylightLows, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetAccuracy(context.Context, string, int) (*models.AccuracyStats, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) CompareStations(context.Context, []string, *string, *string, int) (*models.StationComparison, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTides) GetLatestObservation(context.Context, string, string) (*models.ObservationResponse, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+type fakeCache struct{}
+
+func (fakeCache) GetCacheStats() map[string]uint64 {
+	return map[string]uint64{"lru_hits": 2, "lru_misses": 1, "dynamo_hits": 1}
+}
+
+func (fakeCache) Inspect(_ context.Context, stationID string, date time.Time) (*cache.CacheEntryInfo, error) {
+	return &cache.CacheEntryInfo{
+		StationID: stationID,
+		Date:      date.Format(dateLayout),
+		StoreName: "dynamo",
+		Store: &cache.TierEntryInfo{
+			ExpiresAt:   time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+			TTLSeconds:  3600,
+			SizeBytes:   2048,
+			Predictions: 240,
+			Extremes:    4,
+		},
+	}, nil
+}
+
+func withFakeServices(t *testing.T) (*fakeTides, *int) {
+	t.Helper()
+	state := "WA"
+	tides := &fakeTides{}
+	flushes := 0
+	orig := newServices
+	newServices = func(context.Context) (*services, error) {
+		return &services{
+			finder: &testsupport.StationFinder{Stations: []models.Station{
+				{ID: "9447130", Name: "Seattle", State: &state, Latitude: 47.6026, Longitude: -122.3393, TimeZone: "America/Los_Angeles"},
+				{ID: "9446484", Name: "Tacoma", State: &state, Latitude: 47.27, Longitude: -122.413},
+			}},
+			tides: tides,
+			cache: fakeCache{},
+			flush: func(context.Context) error {
+				flushes++
+				return nil
+			},
+		}, nil
+	}
+	t.Cleanup(func() { newServices = orig })
+	return tides, &flushes
+}
+
+func runCLI(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestStationsNear(t *testing.T) {
+	withFakeServices(t)
+
+	code, stdout, stderr := runCLI("stations", "near", "47.6", "-122.3", "--limit", "1")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "ID       NAME     STATE  DISTANCE_KM  LATITUDE  LONGITUDE  TIME_ZONE\n"+
+		"9447130  Seattle  WA     3.0          47.6026   -122.3393  America/Los_Angeles\n", stdout)
+
+	// Flags may come anywhere, including between negative coordinates
+	code, stdout, _ = runCLI("stations", "near", "--format=csv", "-47.6", "-v", "-122.3")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "ID,NAME,STATE,DISTANCE_KM,LATITUDE,LONGITUDE,TIME_ZONE\n"+
+		"9446484,Tacoma,WA,10549.1,47.2700,-122.4130,\n"+
+		"9447130,Seattle,WA,10586.0,47.6026,-122.3393,America/Los_Angeles\n", stdout)
+}
+
+func TestStationsGetJSON(t *testing.T) {
+	withFakeServices(t)
+
+	code, stdout, stderr := runCLI("stations", "get", "9447130", "--format", "json")
+	require.Equal(t, 0, code, stderr)
+	var station models.Station
+	require.NoError(t, json.Unmarshal([]byte(stdout), &station))
+	assert.Equal(t, "Seattle", station.Name)
+
+	code, _, stderr = runCLI("stations", "get", "0000000")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "flowebb: finding station: station not found: 0000000\n", stderr)
+}
+
+func TestTides(t *testing.T) {
+	tides, flushes := withFakeServices(t)
+
+	code, stdout, stderr := runCLI("tides", "9447130", "--start", "2024-01-01", "--days", "3")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "2024-01-01T00:00:00", tides.start)
+	assert.Equal(t, "2024-01-03T23:59:59", tides.end)
+	assert.Equal(t, 1, *flushes, "queued cache writes are flushed before exiting")
+	assert.Equal(t, "Station 9447130, NOAA API\n\n"+
+		"TYPE  LOCAL_TIME           TIMESTAMP      HEIGHT\n"+
+		"HIGH  2024-01-01T00:00:00  1704096000000  3.500\n"+
+		"LOW   2024-01-01T06:20:00  1704118800000  -0.250\n", stdout)
+
+	code, stdout, _ = runCLI("tides", "9447130", "--start=2024-01-01", "--predictions", "--format", "csv")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "2024-01-01T23:59:59", tides.end)
+	assert.Equal(t, "LOCAL_TIME,TIMESTAMP,HEIGHT\n2024-01-01T00:00:00,1704096000000,3.500\n", stdout)
+
+	// Without --start the range begins today at the station
+	code, _, _ = runCLI("tides", "9447130")
+	require.Equal(t, 0, code)
+	today := time.Now().In(time.FixedZone("", 0))
+	start, err := time.Parse(localTimeLayout, tides.start)
+	require.NoError(t, err)
+	assert.WithinDuration(t, today, start, 48*time.Hour)
+}
+
+func TestCacheStats(t *testing.T) {
+	tides, _ := withFakeServices(t)
+
+	code, stdout, stderr := runCLI("cache", "stats")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "STAT         VALUE\ndynamo_hits  1\nlru_hits     2\nlru_misses   1\n", stdout)
+	assert.Zero(t, tides.calls)
+
+	code, stdout, _ = runCLI("cache", "stats", "9447130", "--start", "2024-01-01", "--format", "json")
+	require.Equal(t, 0, code)
+	assert.Equal(t, 1, tides.calls, "looks up the station's predictions first")
+	assert.JSONEq(t, `{"dynamo_hits":1,"lru_hits":2,"lru_misses":1}`, stdout)
+}
+
+func TestCacheInspect(t *testing.T) {
+	withFakeServices(t)
+
+	code, stdout, stderr := runCLI("cache", "inspect", "9447130", "2024-01-01")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "TIER    CACHED  EXPIRES_AT            TTL_SECONDS  SIZE_BYTES  PREDICTIONS  EXTREMES\n"+
+		"lru     no\n"+
+		"dynamo  yes     2024-01-08T00:00:00Z  3600         2048        240          4\n", stdout)
+}
+
+func TestUsageErrors(t *testing.T) {
+	withFakeServices(t)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "no command", args: nil, want: "missing command"},
+		{name: "unknown command", args: []string{"stations", "far"}, want: `unknown command "stations far"`},
+		{name: "missing argument", args: []string{"stations", "near", "47.6"}, want: "stations near expects LAT LON"},
+		{name: "extra argument", args: []string{"tides", "9447130", "9446484"}, want: "tides expects STATION"},
+		{name: "unknown flag among coordinates", args: []string{"stations", "near", "47.6", "-122.3", "--radius", "5"}, want: "unknown flag: --radius"},
+		{name: "bad coordinates", args: []string{"stations", "near", "95", "0"}, want: "invalid coordinates 95 0"},
+		{name: "bad format", args: []string{"tides", "9447130", "--format", "xml"}, want: "format must be table, json or csv"},
+		{name: "unknown flag", args: []string{"tides", "9447130", "--weeks", "2"}, want: "unknown flag: --weeks"},
+		{name: "too many days", args: []string{"tides", "9447130", "--days", "31"}, want: "--days must be between 1 and 30"},
+		{name: "bad start", args: []string{"tides", "9447130", "--start", "01/01/2024"}, want: "--start must be YYYY-MM-DD"},
+		{name: "bad interpolation", args: []string{"tides", "9447130", "--interpolation", "Cubic"}, want: "Cubic"},
+		{name: "bad date", args: []string{"cache", "inspect", "9447130", "tomorrow"}, want: "DATE must be YYYY-MM-DD"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(tt.args...)
+			assert.Equal(t, 2, code)
+			assert.Empty(t, stdout)
+			assert.Contains(t, stderr, tt.want)
+			assert.Contains(t, stderr, "Usage:\n  flowebb")
+		})
+	}
+}
+
+func TestHelp(t *testing.T) {
+	code, _, stderr := runCLI("help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "Usage:\n  flowebb")
+
+	code, _, stderr = runCLI("tides", "-h")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "Usage:\n  flowebb tides STATION")
+
+	code, _, stderr = runCLI("stations", "near", "--help")
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stderr, "Usage:\n  flowebb stations near LAT LON")
+}
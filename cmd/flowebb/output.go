@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// outputFormat is the --format flag; Set rejects unknown formats so they fail before any
+// lookups run
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatCSV   outputFormat = "csv"
+)
+
+func (f *outputFormat) String() string { return string(*f) }
+
+func (f *outputFormat) Type() string { return "format" }
+
+func (f *outputFormat) Set(value string) error {
+	switch format := outputFormat(value); format {
+	case formatTable, formatJSON, formatCSV:
+		*f = format
+		return nil
+	default:
+		return fmt.Errorf("format must be table, json or csv")
+	}
+}
+
+// table is the tabular form of a result, used by the table and csv formats
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// writeResult writes v as indented JSON, or t as CSV or an aligned table
+func writeResult(w io.Writer, format outputFormat, v interface{}, t table) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(t.header); err != nil {
+			return err
+		}
+		return cw.WriteAll(t.rows)
+	default:
+		var buf bytes.Buffer
+		tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		// Rows ending in empty cells are padded out to the last column
+		lines := strings.SplitAfter(buf.String(), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \n")
+			if strings.HasSuffix(line, "\n") {
+				lines[i] += "\n"
+			}
+		}
+		_, err := io.WriteString(w, strings.Join(lines, ""))
+		return err
+	}
+}
+
+func formatFloat(f float64, precision int) string {
+	return strconv.FormatFloat(f, 'f', precision, 64)
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
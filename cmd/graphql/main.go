@@ -6,86 +6,82 @@ import (
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/bbernstein/flowebb-go/graph"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
 	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
 	"github.com/bbernstein/flowebb-go/internal/tide"
-	"github.com/bbernstein/flowebb-go/pkg/http/client"
 	"github.com/rs/zerolog/log"
-	"net/http"
-	"sync"
-	"time"
 )
 
 var (
-	handler       *graph.Handler
-	setupOnce     sync.Once
+	handler     *graph.Handler
+	tideService models.TideProvider
+	// rateLimiter is nil outside demo mode
+	rateLimiter *ratelimit.Limiter
+	// tenants is nil unless TENANTS is set
+	tenants *tenant.Registry
+	// quotas is nil unless QUOTA_TABLE is set
+	quotas *quota.Tracker
+	// idempotency is nil unless retried writes are deduplicated
+	idempotency *api.Idempotency
+	// recorder is nil unless METRICS_TABLE is set
+	recorder      *metrics.Recorder
+	ready                               = startup.New(InitializeService)
 	tideFactory   tide.ServiceFactory   = &tide.DefaultServiceFactory{}
 	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
 	initHandler                         = defaultInitHandler
 )
 
 func defaultInitHandler(ctx context.Context) (*graph.Handler, error) {
-	httpClient := client.New(client.Options{
-		BaseURL: "https://api.tidesandcurrents.noaa.gov",
-		Timeout: 30 * time.Second,
-	})
-
-	stationFinder, err := finderFactory.NewFinder(httpClient, nil)
-	if err != nil {
-		return nil, fmt.Errorf("initializing station finder: %w", err)
-	}
-
-	tideService, err := tideFactory.NewService(ctx, httpClient, stationFinder)
+	graphQL, err := app.BuildGraphQL(ctx, app.WithTideFactory(tideFactory), app.WithFinderFactory(finderFactory))
 	if err != nil {
-		return nil, fmt.Errorf("initializing tide service: %w", err)
+		return nil, err
 	}
-
-	resolver := &graph.Resolver{
-		TideService:   tideService,
-		StationFinder: stationFinder,
-	}
-
-	return graph.NewHandler(resolver, nil), nil
+	tideService = graphQL.Service
+	rateLimiter = graphQL.Limiter
+	tenants = graphQL.Tenants
+	quotas = graphQL.Quotas
+	idempotency = graphQL.Idempotency
+	recorder = graphQL.Metrics
+	return graphQL.Handler, nil
 }
 
 func handleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	if handler == nil {
-		return events.APIGatewayProxyResponse{
-			StatusCode: http.StatusInternalServerError,
-			Body:       `{"errors": ["Handler not initialized"]}`,
-		}, fmt.Errorf("handler not initialized")
+	if err := ready.Do(); err != nil {
+		return graph.Reject(err)
 	}
-	return handler.HandleRequest(ctx, event)
-}
-
-func InitializeService() error { // Return error instead of fatal
-	var initError error
-	setupOnce.Do(func() {
-		if tideFactory == nil {
-			tideFactory = &tide.DefaultServiceFactory{}
-		}
-		if finderFactory == nil {
-			finderFactory = &station.DefaultFinderFactory{}
-		}
-		ctx := context.Background()
-		log.Debug().Msg("Initializing GraphQL service...")
-		var err error
-		handler, err = initHandler(ctx)
-		if err != nil {
-			initError = fmt.Errorf("failed to initialize handler: %v", err)
-			log.Error().Err(err).Msg("Failed to initialize handler")
-			return
-		}
-		log.Debug().Msg("GraphQL service initialized successfully")
-	})
-	return initError
+	if err := rateLimiter.Allow(event.RequestContext.Identity.SourceIP); err != nil {
+		return graph.Reject(err)
+	}
+	defer app.FlushCacheWrites(ctx, tideService)
+	return recorder.Observe(api.RecoverWith(tenants.Serve(quotas.Wrap(idempotency.Wrap(handler.HandleRequest, graph.Reject), graph.Reject), graph.Reject), graph.Reject))(ctx, event)
 }
 
-func init() {
-	if err := InitializeService(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize service")
+// InitializeService creates the GraphQL handler on the first request, and again on a
+// later one if it fails
+func InitializeService() error {
+	if tideFactory == nil {
+		tideFactory = &tide.DefaultServiceFactory{}
+	}
+	if finderFactory == nil {
+		finderFactory = &station.DefaultFinderFactory{}
+	}
+	log.Debug().Msg("Initializing GraphQL service...")
+	h, err := initHandler(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to initialize handler: %w", err)
 	}
+	handler = h
+	log.Debug().Msg("GraphQL service initialized successfully")
+	return nil
 }
 
 func main() {
-	lambda.Start(handleRequest)
+	lambda.Start(api.RecoverWith(handleRequest, graph.Reject))
 }
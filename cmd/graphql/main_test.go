@@ -2,17 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/bbernstein/flowebb-go/graph"
+	"github.com/bbernstein/flowebb-go/internal/api"
 	"github.com/bbernstein/flowebb-go/internal/cache"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
 	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
 	"github.com/bbernstein/flowebb-go/internal/tide"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"sync"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"os"
 	"testing"
 	"time"
 )
@@ -29,48 +37,52 @@ func (m *MockService) GetCurrentTideForStation(_ context.Context, _ string, _, _
 	panic("implement me")
 }
 
-func (m *MockService) GetPredictions(ctx context.Context, stationID string, start time.Time, end time.Time) ([]models.TidePrediction, error) {
-	args := m.Called(ctx, stationID, start, end)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]models.TidePrediction), args.Error(1)
+func (m *MockService) GetDailyExtremes(_ context.Context, _ string, _ *string, _ int) (*models.ExtremesSummary, error) {
+	panic("implement me")
 }
 
-func (m *MockService) GetExtremes(ctx context.Context, stationID string, start time.Time, end time.Time) ([]models.TideExtreme, error) {
-	args := m.Called(ctx, stationID, start, end)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).([]models.TideExtreme), args.Error(1)
+func (m *MockService) GetDailyPredictions(_ context.Context, _ string, _ *string, _ int) (*models.DailyPredictions, error) {
+	panic("implement me")
 }
 
-type MockFinder struct {
-	mock.Mock
+func (m *MockService) GetNextExtremes(_ context.Context, _ string, _ int) (*models.NextExtremes, error) {
+	panic("implement me")
 }
 
-func (m *MockFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
-	args := m.Called(ctx, stationID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Station), args.Error(1)
+func (m *MockService) GetTideExtremes(_ context.Context, _ string, _, _ *string) (*models.TideExtremes, error) {
+	panic("implement me")
+}
+
+func (m *MockService) GetDaylightLows(_ context.Context, _ string, _ *float64, _ *string, _ int) (*models.DaylightLows, error) {
+	panic("implement me")
+}
+
+func (m *MockService) GetAccuracy(_ context.Context, _ string, _ int) (*models.AccuracyStats, error) {
+	panic("implement me")
 }
 
-func (m *MockFinder) FindNearestStations(ctx context.Context, lat float64, lon float64, limit int) ([]models.Station, error) {
-	args := m.Called(ctx, lat, lon, limit)
+func (m *MockService) CompareStations(_ context.Context, _ []string, _, _ *string, _ int) (*models.StationComparison, error) {
+	panic("implement me")
+}
+
+func (m *MockService) GetLatestObservation(_ context.Context, _, _ string) (*models.ObservationResponse, error) {
+	panic("implement me")
+}
+
+func (m *MockService) GetPredictions(ctx context.Context, stationID string, start time.Time, end time.Time) ([]models.TidePrediction, error) {
+	args := m.Called(ctx, stationID, start, end)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]models.Station), args.Error(1)
+	return args.Get(0).([]models.TidePrediction), args.Error(1)
 }
 
-func (m *MockFinder) GetStations(ctx context.Context) ([]models.Station, error) {
-	args := m.Called(ctx)
+func (m *MockService) GetExtremes(ctx context.Context, stationID string, start time.Time, end time.Time) ([]models.TideExtreme, error) {
+	args := m.Called(ctx, stationID, start, end)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]models.Station), args.Error(1)
+	return args.Get(0).([]models.TideExtreme), args.Error(1)
 }
 
 type mockFinderFactory struct {
@@ -148,7 +160,7 @@ func TestHandleRequest(t *testing.T) {
 	}()
 
 	// Create a mock resolver
-	mockStationFinder := &MockFinder{}
+	mockStationFinder := &testsupport.StationFinder{}
 	mockTideService := &MockService{}
 
 	resolver := &graph.Resolver{
@@ -241,8 +253,10 @@ func TestInitialization(t *testing.T) {
 	originalHandler := handler
 	defer func() { handler = originalHandler }()
 
+	originalInitHandler := initHandler
+	defer func() { initHandler = originalInitHandler }()
+
 	handler = nil
-	setupOnce = sync.Once{}
 	initHandler = func(ctx context.Context) (*graph.Handler, error) {
 		return nil, errors.New("mock error initializing handler")
 	}
@@ -252,3 +266,60 @@ func TestInitialization(t *testing.T) {
 	assert.Contains(t, err.Error(), "mock error initializing handler")
 	assert.Nil(t, handler)
 }
+
+func TestHandleRequest_NotReady(t *testing.T) {
+	originalReady := ready
+	defer func() { ready = originalReady }()
+
+	calls := 0
+	ready = startup.New(func() error {
+		calls++
+		return errors.New("dynamo unavailable")
+	})
+
+	for range 2 {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Body:       `{"query": "{ stations { id name } }"}`,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		assert.Equal(t, "1", response.Headers["Retry-After"])
+
+		var body struct {
+			Errors []struct {
+				Message    string
+				Extensions map[string]interface{}
+			}
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		require.Len(t, body.Errors, 1)
+		assert.Contains(t, body.Errors[0].Message, "dynamo unavailable")
+		assert.Equal(t, string(api.CodeServiceUnavailable), body.Errors[0].Extensions["code"])
+	}
+	assert.Equal(t, 1, calls, "initialization isn't retried before the backoff passes")
+}
+
+func TestHandleRequest_RateLimited(t *testing.T) {
+	original := rateLimiter
+	defer func() { rateLimiter = original }()
+	rateLimiter = ratelimit.New(1, time.Minute)
+	require.NoError(t, rateLimiter.Allow("198.51.100.7"))
+
+	request := events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: `{"query": "{ stations { id name } }"}`}
+	request.RequestContext.Identity.SourceIP = "198.51.100.7"
+	response, err := handleRequest(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "60", response.Headers["Retry-After"])
+	assert.Contains(t, response.Body, `"code":"RATE_LIMITED"`)
+}
+
+func TestMain(m *testing.M) {
+	// Initialize as the first request would, so tests can replace what it creates
+	if err := ready.Do(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
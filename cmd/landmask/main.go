@@ -0,0 +1,119 @@
+// Command landmask rasterizes Natural Earth's land polygons into the coarse land mask
+// that's built into the station finder for coastal snapping:
+//
+//	go generate ./internal/geo
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+)
+
+// defaultLandURL is Natural Earth's 1:10m land polygons, which are in the public domain
+const defaultLandURL = "https://raw.githubusercontent.com/nvkelso/natural-earth-vector/master/geojson/ne_10m_land.geojson"
+
+// downloadTimeout bounds the download of the land polygons, about 10MB
+const downloadTimeout = 2 * time.Minute
+
+func main() {
+	output := flag.String("o", "internal/geo/landmask/land.bin.gz", "file to write the land mask to")
+	landURL := flag.String("url", defaultLandURL, "GeoJSON of the world's land polygons")
+	cell := flag.Float64("cell", geo.DefaultLandMaskCell, "cell size in degrees; must divide 180")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+	if err := run(ctx, http.DefaultClient, *landURL, *cell, *output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// featureCollection is the part of a GeoJSON FeatureCollection of polygons the mask needs
+type featureCollection struct {
+	Features []struct {
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+	} `json:"features"`
+}
+
+// run writes the mask of the land at landURL to output, leaving the previous one in place
+// if the download fails
+func run(ctx context.Context, httpClient *http.Client, landURL string, cell float64, output string) error {
+	mask, err := geo.NewLandMask(cell)
+	if err != nil {
+		return err
+	}
+	if err := fill(ctx, httpClient, landURL, mask); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(output), ".landmask-*")
+	if err != nil {
+		return fmt.Errorf("creating land mask: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := mask.Write(file); err != nil {
+		file.Close()
+		return fmt.Errorf("writing land mask: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing land mask: %w", err)
+	}
+	return os.Rename(file.Name(), output)
+}
+
+// fill downloads the land polygons and marks them on mask
+func fill(ctx context.Context, httpClient *http.Client, landURL string, mask *geo.LandMask) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, landURL, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("downloading land polygons: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("downloading land polygons: status %d", resp.StatusCode)
+	}
+
+	var land featureCollection
+	if err := json.NewDecoder(resp.Body).Decode(&land); err != nil {
+		return fmt.Errorf("decoding land polygons: %w", err)
+	}
+	for i, feature := range land.Features {
+		var polygons [][][][2]float64
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][2]float64
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygon)
+			polygons = append(polygons, polygon)
+		case "MultiPolygon":
+			err = json.Unmarshal(feature.Geometry.Coordinates, &polygons)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("decoding feature %d: %w", i, err)
+		}
+		for _, polygon := range polygons {
+			mask.FillPolygon(polygon)
+		}
+	}
+	if mask.LandCells() == 0 {
+		return fmt.Errorf("no land in %s", landURL)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"type":"FeatureCollection","features":[
+			{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[-123,47],[-122,47],[-122,48],[-123,48],[-123,47]]]}},
+			{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[10,10],[11,10],[11,11],[10,11],[10,10]]]]}},
+			{"type":"Feature","geometry":{"type":"Point","coordinates":[0,0]}}
+		]}`))
+	}))
+	defer srv.Close()
+
+	output := filepath.Join(t.TempDir(), "land.bin.gz")
+	require.NoError(t, run(context.Background(), srv.Client(), srv.URL, 0.5, output))
+
+	file, err := os.Open(output)
+	require.NoError(t, err)
+	defer file.Close()
+	mask, err := geo.ReadLandMask(file)
+	require.NoError(t, err)
+	assert.Equal(t, 8, mask.LandCells())
+	assert.True(t, mask.IsLand(47.5, -122.5))
+	assert.True(t, mask.IsLand(10.5, 10.5))
+	assert.False(t, mask.IsLand(0, 0))
+}
+
+func TestRun_KeepsMaskOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"type":"FeatureCollection","features":[]}`))
+	}))
+	defer srv.Close()
+	output := filepath.Join(t.TempDir(), "land.bin.gz")
+	require.NoError(t, os.WriteFile(output, []byte("previous"), 0o644))
+
+	assert.Error(t, run(context.Background(), srv.Client(), srv.URL, 0.5, output), "no land")
+	assert.Error(t, run(context.Background(), srv.Client(), srv.URL+"/missing\x7f", 0.5, output))
+	data, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(data))
+}
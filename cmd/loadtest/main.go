@@ -0,0 +1,155 @@
+// Command loadtest sends a realistic mix of tide lookups to the service and reports latency
+// percentiles and cache hit rates. With -base-url it drives a running server, such as the
+// one scripts/gostart.sh starts; otherwise it runs the service in process, configured from
+// the environment like the Lambdas, which also reports how often each cache tier answered.
+// It exits non-zero when a request fails or a percentile is over its budget:
+//
+//	go run ./cmd/loadtest -requests 2000 -workers 16 -p95 250ms
+//	go run ./cmd/loadtest -base-url http://localhost:8080 -stations 9447130,8443970
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/loadtest"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+// defaultStations are busy harbors on each coast, most requested first
+const defaultStations = "9414290,8518750,9447130,8443970,8723214,9410170,1612340,8638610,9455920,8771450"
+
+type options struct {
+	baseURL  string
+	stations string
+	start    string
+	span     int
+	maxDays  int
+	requests int
+	workers  int
+	seed     int64
+	budget   loadtest.Budget
+}
+
+// newTarget is replaced in tests to avoid building the real service
+var newTarget = defaultTarget
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.baseURL, "base-url", "", "server to drive, e.g. http://localhost:8080; empty runs the service in process")
+	flag.StringVar(&opts.stations, "stations", defaultStations, "comma-separated station IDs, most requested first")
+	flag.StringVar(&opts.start, "start", "", "first date to request, YYYY-MM-DD (default today)")
+	flag.IntVar(&opts.span, "span", 14, "days after -start that requests fall within")
+	flag.IntVar(&opts.maxDays, "max-days", 3, "longest range to request, in days")
+	flag.IntVar(&opts.requests, "requests", 500, "number of requests to send")
+	flag.IntVar(&opts.workers, "workers", 8, "requests in flight at once")
+	flag.Int64Var(&opts.seed, "seed", 1, "seed for the request mix, so runs can be compared")
+	flag.DurationVar(&opts.budget.P50, "p50", 0, "p50 latency budget (0 disables)")
+	flag.DurationVar(&opts.budget.P95, "p95", 0, "p95 latency budget (0 disables)")
+	flag.DurationVar(&opts.budget.P99, "p99", 0, "p99 latency budget (0 disables)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ok, err := run(ctx, opts, time.Now(), os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run sends the requests and prints the report, returning whether every request succeeded
+// within the budget
+func run(ctx context.Context, opts options, now time.Time, stdout io.Writer) (bool, error) {
+	mix, err := opts.mix(now)
+	if err != nil {
+		return false, err
+	}
+	target, stats, flush, err := newTarget(ctx, opts.baseURL)
+	if err != nil {
+		return false, err
+	}
+	if flush != nil {
+		defer func() { _ = flush(context.Background()) }()
+	}
+
+	report := loadtest.Run(ctx, target, mix.Requests(opts.requests, opts.seed), loadtest.Options{
+		Workers: opts.workers,
+		Stats:   stats,
+	})
+	report.Write(stdout)
+
+	over := report.Exceeds(opts.budget)
+	for _, problem := range over {
+		fmt.Fprintf(stdout, "over budget: %s\n", problem)
+	}
+	return report.Errors == 0 && len(over) == 0, nil
+}
+
+func (opts options) mix(now time.Time) (loadtest.Mix, error) {
+	start := now
+	if opts.start != "" {
+		var err error
+		if start, err = time.Parse("2006-01-02", opts.start); err != nil {
+			return loadtest.Mix{}, fmt.Errorf("invalid -start %q: want YYYY-MM-DD", opts.start)
+		}
+	}
+	var stations []string
+	for _, id := range strings.Split(opts.stations, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			stations = append(stations, id)
+		}
+	}
+	if len(stations) == 0 {
+		return loadtest.Mix{}, fmt.Errorf("-stations names no stations")
+	}
+	return loadtest.Mix{Stations: stations, Start: start, Span: opts.span, MaxDays: opts.maxDays}, nil
+}
+
+// defaultTarget drives the server at baseURL, or builds the service in process when it's
+// empty. Only the in-process service has cache stats and queued writes to flush.
+func defaultTarget(ctx context.Context, baseURL string) (loadtest.Target, loadtest.StatsSource, func(context.Context) error, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if baseURL != "" {
+		return loadtest.HTTPTarget(baseURL, &http.Client{Timeout: cfg.RequestTimeout}), nil, nil, nil
+	}
+
+	cassette, err := client.NewCassette(client.CassetteMode(cfg.HTTPCassetteMode), cfg.HTTPCassetteDir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("configuring HTTP cassette: %w", err)
+	}
+	httpClient := client.New(client.Options{
+		Timeout:    cfg.HTTPTimeout,
+		MaxRetries: cfg.MaxRetries,
+		BaseURL:    cfg.NOAABaseURL,
+		Limiter:    client.NewLimiter(cfg.NOAAMaxConcurrentRequests),
+		Cassette:   cassette,
+	})
+	stationFinder, err := (&station.DefaultFinderFactory{}).NewFinder(httpClient, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("creating station finder: %w", err)
+	}
+	tideService, err := tide.NewService(ctx, httpClient, stationFinder)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("creating tide service: %w", err)
+	}
+	stats, _ := tideService.PredictionCache.(loadtest.StatsSource)
+	return loadtest.ServiceTarget(tideService), stats, tideService.FlushCacheWrites, nil
+}
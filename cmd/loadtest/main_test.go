@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/loadtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedStats map[string]uint64
+
+func (s fixedStats) GetCacheStats() map[string]uint64 { return s }
+
+func TestRun(t *testing.T) {
+	now := time.Date(2024, 6, 1, 15, 0, 0, 0, time.UTC)
+	var seen []loadtest.Request
+	fail := ""
+	flushed := false
+	newTarget = func(ctx context.Context, baseURL string) (loadtest.Target, loadtest.StatsSource, func(context.Context) error, error) {
+		assert.Equal(t, "http://localhost:8080", baseURL)
+		target := func(ctx context.Context, r loadtest.Request) error {
+			seen = append(seen, r)
+			if r.StationID == fail {
+				return errors.New("failed")
+			}
+			return nil
+		}
+		flush := func(context.Context) error {
+			flushed = true
+			return nil
+		}
+		return target, fixedStats{"lru_hits": 0}, flush, nil
+	}
+	defer func() { newTarget = defaultTarget }()
+
+	opts := options{
+		baseURL:  "http://localhost:8080",
+		stations: " 9447130, ,8443970",
+		span:     7,
+		maxDays:  1,
+		requests: 20,
+		workers:  1,
+		seed:     1,
+	}
+	var out bytes.Buffer
+	ok, err := run(context.Background(), opts, now, &out)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, flushed)
+	assert.Contains(t, out.String(), "requests: 20 in ")
+	require.Len(t, seen, 20)
+	for _, r := range seen {
+		assert.Contains(t, []string{"9447130", "8443970"}, r.StationID)
+		assert.False(t, r.Date.Before(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+	}
+
+	// A failed request or a blown budget fails the run
+	fail = "9447130"
+	out.Reset()
+	ok, err = run(context.Background(), opts, now, &out)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "first error: failed")
+
+	fail = ""
+	opts.budget = loadtest.Budget{P99: time.Nanosecond}
+	opts.start = "2024-07-04"
+	seen = nil
+	out.Reset()
+	ok, err = run(context.Background(), opts, now, &out)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "over budget: p99 ")
+	july4 := time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC)
+	for _, r := range seen {
+		assert.False(t, r.Date.Before(july4))
+		assert.True(t, r.Date.Before(july4.AddDate(0, 0, 7)))
+	}
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	now := time.Now()
+	_, err := run(context.Background(), options{stations: "9447130", start: "July 4"}, now, &bytes.Buffer{})
+	assert.EqualError(t, err, `invalid -start "July 4": want YYYY-MM-DD`)
+
+	_, err = run(context.Background(), options{stations: " , "}, now, &bytes.Buffer{})
+	assert.EqualError(t, err, "-stations names no stations")
+}
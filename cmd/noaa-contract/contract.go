@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// format is what a response field's value must look like for our decoders to read it
+type format int
+
+const (
+	formatString format = iota
+	formatNumber
+	formatNoaaTime
+	formatDecimal
+	formatTideType
+)
+
+// contract is one NOAA endpoint we call and the record fields our code reads from it
+type contract struct {
+	name string
+	path string
+	// records is the top-level key of the array of records the fields belong to
+	records string
+	fields  map[string]format
+	// decode is the decoder the service uses on this response, if it has a shared one
+	decode func([]byte) error
+}
+
+// contracts returns the endpoints called for stationID, with predictions starting on day
+func contracts(stationID string, day time.Time) []contract {
+	station := url.QueryEscape(stationID)
+	begin := day.Format("20060102")
+	end := day.AddDate(0, 0, 1).Format("20060102")
+	predictions := func(interval string) string {
+		return fmt.Sprintf("/api/prod/datagetter?station=%s&begin_date=%s&end_date=%s&product=predictions"+
+			"&datum=MLLW&units=english&time_zone=lst_ldt&format=json&interval=%s", station, begin, end, interval)
+	}
+	// The decoder leaves unreadable predictions out rather than failing, but any at all
+	// break the contract
+	decodePredictions := func(body []byte) error {
+		resp, err := models.DecodeNoaaResponse(body)
+		if err != nil {
+			return err
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if resp.Skipped > 0 {
+			return fmt.Errorf("%d unreadable predictions: %w", resp.Skipped, resp.SkipReason)
+		}
+		return nil
+	}
+
+	return []contract{
+		{
+			name:    "predictions",
+			path:    predictions("6"),
+			records: "predictions",
+			fields:  map[string]format{"t": formatNoaaTime, "v": formatDecimal},
+			decode:  decodePredictions,
+		},
+		{
+			name:    "extremes",
+			path:    predictions("hilo"),
+			records: "predictions",
+			fields:  map[string]format{"t": formatNoaaTime, "v": formatDecimal, "type": formatTideType},
+			decode:  decodePredictions,
+		},
+		{
+			name: "water_temperature",
+			path: fmt.Sprintf("/api/prod/datagetter?station=%s&date=latest&product=water_temperature"+
+				"&units=english&time_zone=gmt&format=json", station),
+			records: "data",
+			fields:  map[string]format{"t": formatNoaaTime, "v": formatDecimal},
+		},
+		{
+			name:    "stations",
+			path:    "/mdapi/prod/webapi/tidepredstations.json",
+			records: "stationList",
+			fields: map[string]format{
+				"stationId": formatString, "name": formatString, "lat": formatNumber, "lon": formatNumber,
+				"timeZoneCorr": formatString, "stationType": formatString,
+			},
+		},
+		{
+			name:    "sensor_stations",
+			path:    "/mdapi/prod/webapi/stations.json?type=watertemp",
+			records: "stations",
+			fields:  map[string]format{"id": formatString},
+		},
+		{
+			name:    "sensors",
+			path:    "/mdapi/prod/webapi/stations/" + url.PathEscape(stationID) + "/sensors.json",
+			records: "sensors",
+			fields:  map[string]format{"sensorID": formatString, "name": formatString},
+		},
+	}
+}
+
+// check returns how body breaks the contract: fields our code reads that are missing or
+// no longer in the format it parses
+func (c contract) check(body []byte) []string {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return []string{fmt.Sprintf("response isn't a JSON object: %v", err)}
+	}
+	if raw, ok := doc["error"]; ok {
+		return []string{fmt.Sprintf("NOAA returned an error: %s", raw)}
+	}
+	if c.decode != nil {
+		if err := c.decode(body); err != nil {
+			return []string{fmt.Sprintf("decoding: %v", err)}
+		}
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(doc[c.records], &records); err != nil || len(records) == 0 {
+		return []string{fmt.Sprintf("no %s records", c.records)}
+	}
+
+	var problems []string
+	for _, name := range sortedKeys(c.fields) {
+		missing, bad := 0, ""
+		for _, record := range records {
+			value, ok := record[name]
+			if !ok {
+				missing++
+				continue
+			}
+			if bad == "" && !c.fields[name].matches(value) {
+				bad = fmt.Sprint(value)
+			}
+		}
+		switch {
+		case missing == len(records):
+			problems = append(problems, fmt.Sprintf("missing field %s[].%s", c.records, name))
+		case bad != "":
+			problems = append(problems, fmt.Sprintf("changed format %s[].%s: %q", c.records, name, bad))
+		}
+	}
+	return problems
+}
+
+func (f format) matches(value any) bool {
+	switch f {
+	case formatNumber:
+		_, ok := value.(float64)
+		return ok
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	switch f {
+	case formatNoaaTime:
+		_, err := models.ParseNoaaTime([]byte(s))
+		return err == nil
+	case formatDecimal:
+		// Observations leave the value empty when the sensor reported nothing
+		if s == "" {
+			return true
+		}
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	case formatTideType:
+		return s == "H" || s == "L"
+	}
+	return true
+}
+
+// shape maps each field path in a response to the kinds of value seen there, e.g.
+// "predictions[].t" to "time"
+type shape map[string][]string
+
+// shapeOf returns the shape of a JSON response
+func shapeOf(body []byte) (shape, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	s := shape{}
+	s.add("", doc)
+	return s, nil
+}
+
+func (s shape) add(path string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if path == "" {
+				s.add(key, child)
+			} else {
+				s.add(path+"."+key, child)
+			}
+		}
+	case []any:
+		for _, child := range v {
+			s.add(path+"[]", child)
+		}
+	default:
+		if _, ok := s[path]; !ok {
+			s[path] = []string{}
+		}
+		if kind := kindOf(v); kind != "" && !contains(s[path], kind) {
+			s[path] = append(s[path], kind)
+			sort.Strings(s[path])
+		}
+	}
+}
+
+// kindOf classifies a JSON scalar; strings are split by the formats NOAA uses so a change
+// from "2024-01-01 05:12" to another layout shows up. Empty strings and nulls have no kind,
+// as most fields are empty on some records.
+func kindOf(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		if v == "" {
+			return ""
+		}
+		if _, err := models.ParseNoaaTime([]byte(v)); err == nil {
+			return "time"
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return "decimal"
+		}
+		return "string"
+	}
+	return ""
+}
+
+// drift returns how current differs from the baseline shape: fields that are new, fields
+// that are gone, and fields holding a kind of value the baseline never saw
+func drift(baseline, current shape) []string {
+	var changes []string
+	for _, path := range sortedKeys(current) {
+		kinds, ok := baseline[path]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("new field %s (%s)", path, describe(current[path])))
+			continue
+		}
+		for _, kind := range current[path] {
+			if !contains(kinds, kind) {
+				changes = append(changes, fmt.Sprintf("changed format %s: %s, was %s",
+					path, describe(current[path]), describe(kinds)))
+				break
+			}
+		}
+	}
+	for _, path := range sortedKeys(baseline) {
+		if _, ok := current[path]; !ok {
+			changes = append(changes, "removed field "+path)
+		}
+	}
+	return changes
+}
+
+// merge adds the fields and kinds of other to s
+func (s shape) merge(other shape) {
+	for path, kinds := range other {
+		if _, ok := s[path]; !ok {
+			s[path] = []string{}
+		}
+		for _, kind := range kinds {
+			if !contains(s[path], kind) {
+				s[path] = append(s[path], kind)
+			}
+		}
+		sort.Strings(s[path])
+	}
+}
+
+func describe(kinds []string) string {
+	if len(kinds) == 0 {
+		return "empty"
+	}
+	return strings.Join(kinds, "|")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,208 @@
+// Command noaa-contract calls the NOAA CO-OPS endpoints the service depends on for a known
+// station and reports when their responses no longer match what our decoders expect, or
+// drift from a recorded baseline (new fields, removed fields, changed formats). It exits
+// non-zero when anything is reported, so it can run on a schedule:
+//
+//	go run ./cmd/noaa-contract -station 9447130
+//	go run ./cmd/noaa-contract -update   # record the current responses as the baseline
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+// defaultBaseline is where the baseline shapes are kept, relative to the repository root
+const defaultBaseline = "cmd/noaa-contract/baseline.json"
+
+type options struct {
+	stationID string
+	baseline  string
+	update    bool
+	json      bool
+}
+
+// report is the outcome of checking every endpoint
+type report struct {
+	Station   string           `json:"station"`
+	Endpoints []endpointReport `json:"endpoints"`
+	// Note says why drift wasn't checked, when it wasn't
+	Note string `json:"note,omitempty"`
+}
+
+type endpointReport struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	// Broken lists ways the response breaks what our code reads from it
+	Broken []string `json:"broken,omitempty"`
+	// Drift lists differences from the baseline that don't break anything yet
+	Drift []string `json:"drift,omitempty"`
+}
+
+// ok reports whether nothing was found
+func (r report) ok() bool {
+	for _, endpoint := range r.Endpoints {
+		if len(endpoint.Broken) > 0 || len(endpoint.Drift) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var opts options
+	flag.StringVar(&opts.stationID, "station", "9447130", "NOAA station ID to check the endpoints with")
+	flag.StringVar(&opts.baseline, "baseline", defaultBaseline, "file holding the baseline response shapes")
+	flag.BoolVar(&opts.update, "update", false, "add the current response shapes to the baseline")
+	flag.BoolVar(&opts.json, "json", false, "print the report as JSON")
+	baseURL := flag.String("base-url", cfg.NOAABaseURL, "NOAA CO-OPS API base URL")
+	flag.Parse()
+
+	cassette, err := client.NewCassette(client.CassetteMode(cfg.HTTPCassetteMode), cfg.HTTPCassetteDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	httpClient := client.New(client.Options{
+		Timeout:    cfg.HTTPTimeout,
+		MaxRetries: cfg.MaxRetries,
+		BaseURL:    *baseURL,
+		Limiter:    client.NewLimiter(cfg.NOAAMaxConcurrentRequests),
+		Cassette:   cassette,
+	})
+
+	ok, err := run(context.Background(), httpClient, opts, time.Now(), os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run checks every endpoint and prints the report, returning whether it found nothing
+func run(ctx context.Context, httpClient *client.Client, opts options, now time.Time, stdout io.Writer) (bool, error) {
+	baseline, err := loadBaseline(opts.baseline)
+	if err != nil {
+		return false, err
+	}
+
+	r := report{Station: opts.stationID}
+	if baseline == nil && !opts.update {
+		r.Note = fmt.Sprintf("no baseline at %s; run with -update to record one", opts.baseline)
+	}
+	shapes := map[string]shape{}
+	for _, c := range contracts(opts.stationID, now) {
+		endpoint := endpointReport{Name: c.name, Path: c.path}
+		resp, err := httpClient.Get(ctx, c.path)
+		switch {
+		case err != nil:
+			endpoint.Broken = []string{err.Error()}
+		case resp.StatusCode != http.StatusOK:
+			endpoint.Broken = []string{fmt.Sprintf("status %d", resp.StatusCode)}
+		default:
+			endpoint.Broken = c.check(resp.Body)
+			if current, err := shapeOf(resp.Body); err == nil {
+				shapes[c.name] = current
+				if baseline != nil && !opts.update {
+					endpoint.Drift = drift(baseline[c.name], current)
+				}
+			}
+		}
+		r.Endpoints = append(r.Endpoints, endpoint)
+	}
+
+	if opts.update {
+		if baseline == nil {
+			baseline = map[string]shape{}
+		}
+		for name, current := range shapes {
+			if baseline[name] == nil {
+				baseline[name] = shape{}
+			}
+			baseline[name].merge(current)
+		}
+		if err := saveBaseline(opts.baseline, baseline); err != nil {
+			return false, err
+		}
+	}
+
+	if err := printReport(stdout, r, opts.json); err != nil {
+		return false, err
+	}
+	return r.ok(), nil
+}
+
+// loadBaseline reads the baseline shapes by endpoint name, or returns nil if there's none
+func loadBaseline(path string) (map[string]shape, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+	var baseline map[string]shape
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("decoding baseline %s: %w", path, err)
+	}
+	return baseline, nil
+}
+
+func saveBaseline(path string, baseline map[string]shape) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing baseline: %w", err)
+	}
+	return nil
+}
+
+func printReport(w io.Writer, r report, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	fmt.Fprintf(w, "NOAA contract for station %s\n", r.Station)
+	if r.Note != "" {
+		fmt.Fprintf(w, "note: %s\n", r.Note)
+	}
+	for _, endpoint := range r.Endpoints {
+		status := "ok"
+		switch {
+		case len(endpoint.Broken) > 0:
+			status = "BROKEN"
+		case len(endpoint.Drift) > 0:
+			status = "drift"
+		}
+		fmt.Fprintf(w, "%-18s %s\n", endpoint.Name, status)
+		for _, problem := range endpoint.Broken {
+			fmt.Fprintf(w, "  broken: %s\n", problem)
+		}
+		for _, change := range endpoint.Drift {
+			fmt.Fprintf(w, "  drift:  %s\n", change)
+		}
+	}
+	return nil
+}
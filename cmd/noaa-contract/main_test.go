@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noaaBodies are responses shaped like the live API's, by endpoint name
+var noaaBodies = map[string]string{
+	"predictions":       `{"predictions":[{"t":"2024-01-01 00:00","v":"8.123"},{"t":"2024-01-01 00:06","v":"8.201"}]}`,
+	"extremes":          `{"predictions":[{"t":"2024-01-01 05:12","v":"11.2","type":"H"},{"t":"2024-01-01 11:40","v":"-1.1","type":"L"}]}`,
+	"water_temperature": `{"metadata":{"id":"9447130","name":"Seattle"},"data":[{"t":"2024-01-01 00:00","v":"48.2","f":"0,0,0"}]}`,
+	"stations":          `{"count":1,"stationList":[{"stationId":"9447130","name":"Seattle","lat":47.6,"lon":-122.3,"timeZoneCorr":"-8","stationType":"R"}]}`,
+	"sensor_stations":   `{"count":1,"stations":[{"id":"9447130","name":"Seattle"}]}`,
+	"sensors":           `{"sensors":[{"sensorID":"E1","name":"Water Temperature"}]}`,
+}
+
+var testDay = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestContractCheck(t *testing.T) {
+	t.Parallel()
+	byName := map[string]contract{}
+	for _, c := range contracts("9447130", testDay) {
+		byName[c.name] = c
+	}
+
+	for name, body := range noaaBodies {
+		assert.Empty(t, byName[name].check([]byte(body)), name)
+	}
+
+	tests := []struct {
+		name     string
+		contract string
+		body     string
+		want     []string
+	}{
+		{
+			name:     "time layout changed",
+			contract: "predictions",
+			body:     `{"predictions":[{"t":"2024-01-01T00:00:00Z","v":"8.1"}]}`,
+			want:     []string{"decoding: "},
+		},
+		{
+			name:     "number became a string",
+			contract: "stations",
+			body:     `{"stationList":[{"stationId":"1","name":"A","lat":"47.6","lon":-122.3,"timeZoneCorr":"-8","stationType":"R"}]}`,
+			want:     []string{`changed format stationList[].lat: "47.6"`},
+		},
+		{
+			name:     "field renamed",
+			contract: "sensors",
+			body:     `{"sensors":[{"sensorId":"E1","name":"Water Temperature"}]}`,
+			want:     []string{"missing field sensors[].sensorID"},
+		},
+		{
+			name:     "new tide type",
+			contract: "extremes",
+			body:     `{"predictions":[{"t":"2024-01-01 05:12","v":"11.2","type":"HH"}]}`,
+			want:     []string{`changed format predictions[].type: "HH"`},
+		},
+		{
+			name:     "api error",
+			contract: "water_temperature",
+			body:     `{"error":{"message":"No data was found"}}`,
+			want:     []string{"NOAA returned an error: "},
+		},
+		{
+			name:     "no records",
+			contract: "sensor_stations",
+			body:     `{"count":0,"stations":[]}`,
+			want:     []string{"no stations records"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := byName[tt.contract].check([]byte(tt.body))
+			require.Len(t, problems, len(tt.want))
+			for i, want := range tt.want {
+				assert.True(t, strings.HasPrefix(problems[i], want), "%q should start with %q", problems[i], want)
+			}
+		})
+	}
+}
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+	baseline, err := shapeOf([]byte(`{"data":[{"t":"2024-01-01 00:00","v":"48.2","f":"0,0,0","q":""}],"units":"english"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"time"}, baseline["data[].t"])
+	assert.Equal(t, []string{}, baseline["data[].q"])
+
+	assert.Empty(t, drift(baseline, baseline))
+
+	current, err := shapeOf([]byte(`{"data":[{"t":"2024-01-01T00:00Z","v":48.2,"f":"0,0,0","s":"1"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"new field data[].s (decimal)",
+		"changed format data[].t: string, was time",
+		"changed format data[].v: number, was decimal",
+		"removed field data[].q",
+		"removed field units",
+	}, drift(baseline, current))
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	bodies := map[string]string{}
+	for k, v := range noaaBodies {
+		bodies[k] = v
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := "sensors"
+		switch {
+		case r.URL.Query().Get("interval") == "6":
+			name = "predictions"
+		case r.URL.Query().Get("interval") == "hilo":
+			name = "extremes"
+		case r.URL.Query().Get("product") == "water_temperature":
+			name = "water_temperature"
+		case strings.HasSuffix(r.URL.Path, "tidepredstations.json"):
+			name = "stations"
+		case strings.HasSuffix(r.URL.Path, "/stations.json"):
+			name = "sensor_stations"
+		}
+		_, _ = w.Write([]byte(bodies[name]))
+	}))
+	defer server.Close()
+	httpClient := client.New(client.Options{BaseURL: server.URL, MaxRetries: 1})
+	opts := options{stationID: "9447130", baseline: filepath.Join(t.TempDir(), "baseline.json")}
+
+	// Without a baseline only the contract is checked
+	var out bytes.Buffer
+	ok, err := run(context.Background(), httpClient, opts, testDay, &out)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Contains(t, out.String(), "note: no baseline at")
+
+	opts.update = true
+	out.Reset()
+	ok, err = run(context.Background(), httpClient, opts, testDay, &out)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NotContains(t, out.String(), "note:")
+
+	// A new field drifts without breaking anything; a changed format breaks the contract
+	bodies["sensor_stations"] = `{"count":1,"stations":[{"id":"9447130","name":"Seattle","state":"WA"}]}`
+	bodies["predictions"] = `{"predictions":[{"t":"2024-01-01 00:00","v":"high"}]}`
+	opts.update, opts.json = false, true
+	out.Reset()
+	ok, err = run(context.Background(), httpClient, opts, testDay, &out)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var r report
+	require.NoError(t, json.Unmarshal(out.Bytes(), &r))
+	require.Len(t, r.Endpoints, 6)
+	byName := map[string]endpointReport{}
+	for _, endpoint := range r.Endpoints {
+		byName[endpoint.Name] = endpoint
+	}
+	assert.Equal(t, []string{"new field stations[].state (string)"}, byName["sensor_stations"].Drift)
+	assert.Empty(t, byName["sensor_stations"].Broken)
+	assert.NotEmpty(t, byName["predictions"].Broken)
+	assert.Equal(t, []string{"changed format predictions[].v: string, was decimal"}, byName["predictions"].Drift)
+	assert.Empty(t, byName["stations"].Drift)
+}
@@ -0,0 +1,40 @@
+// Command openapi writes the OpenAPI document for the REST API, for generating client SDKs:
+//
+//	go run ./cmd/openapi -o api/openapi.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bbernstein/flowebb-go/internal/api"
+)
+
+func main() {
+	output := flag.String("o", "", "file to write the document to (default stdout)")
+	flag.Parse()
+
+	if err := run(*output, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(output string, stdout io.Writer) error {
+	spec, err := api.OpenAPISpec()
+	if err != nil {
+		return fmt.Errorf("building OpenAPI document: %w", err)
+	}
+	spec = append(spec, '\n')
+
+	if output == "" {
+		_, err = stdout.Write(spec)
+		return err
+	}
+	if err := os.WriteFile(output, spec, 0o644); err != nil {
+		return fmt.Errorf("writing OpenAPI document: %w", err)
+	}
+	return nil
+}
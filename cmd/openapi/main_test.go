@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	var stdout bytes.Buffer
+	require.NoError(t, run("", &stdout))
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	output := filepath.Join(t.TempDir(), "openapi.json")
+	require.NoError(t, run(output, &bytes.Buffer{}))
+	written, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, stdout.Bytes(), written)
+}
+
+// The checked-in document must match the code; regenerate it with go generate ./internal/api
+func TestCheckedInDocumentIsCurrent(t *testing.T) {
+	var stdout bytes.Buffer
+	require.NoError(t, run("", &stdout))
+
+	checkedIn, err := os.ReadFile("../../api/openapi.json")
+	require.NoError(t, err)
+	assert.Equal(t, stdout.String(), string(checkedIn), "api/openapi.json is stale; run go generate ./internal/api")
+}
@@ -0,0 +1,75 @@
+// Command sdkgen generates the Go and TypeScript API clients under clients/ from the
+// OpenAPI document and the GraphQL schema:
+//
+//	go generate ./clients/go/flowebb
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bbernstein/flowebb-go/internal/sdkgen"
+)
+
+func main() {
+	openAPIPath := flag.String("openapi", "api/openapi.json", "OpenAPI document")
+	graphQLPath := flag.String("graphql", "graph/schema.graphql", "GraphQL schema")
+	goOut := flag.String("go", "", "Go client file to write")
+	goPackage := flag.String("package", "flowebb", "package of the Go client")
+	tsOut := flag.String("ts", "", "TypeScript client file to write")
+	flag.Parse()
+
+	if err := run(*openAPIPath, *graphQLPath, *goOut, *goPackage, *tsOut); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(openAPIPath, graphQLPath, goOut, goPackage, tsOut string) error {
+	api, err := load(openAPIPath, graphQLPath)
+	if err != nil {
+		return err
+	}
+
+	if goOut != "" {
+		src, err := sdkgen.GenerateGo(api, goPackage)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(goOut, src, 0o644); err != nil {
+			return fmt.Errorf("writing Go client: %w", err)
+		}
+	}
+	if tsOut != "" {
+		src, err := sdkgen.GenerateTypeScript(api)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(tsOut, src, 0o644); err != nil {
+			return fmt.Errorf("writing TypeScript client: %w", err)
+		}
+	}
+	return nil
+}
+
+func load(openAPIPath, graphQLPath string) (*sdkgen.API, error) {
+	doc, err := os.ReadFile(openAPIPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading OpenAPI document: %w", err)
+	}
+	api, err := sdkgen.FromOpenAPI(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := os.ReadFile(graphQLPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading GraphQL schema: %w", err)
+	}
+	api.GraphQLTypes, api.GraphQLQueries, err = sdkgen.ParseGraphQLSchema(string(schema))
+	if err != nil {
+		return nil, err
+	}
+	return api, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The checked-in clients must match the OpenAPI document and schema; regenerate them
+// with go generate ./clients/go/flowebb
+func TestCheckedInClientsAreCurrent(t *testing.T) {
+	dir := t.TempDir()
+	goOut := filepath.Join(dir, "client_gen.go")
+	tsOut := filepath.Join(dir, "index.ts")
+	require.NoError(t, run("../../api/openapi.json", "../../graph/schema.graphql", goOut, "flowebb", tsOut))
+
+	for generated, checkedIn := range map[string]string{
+		goOut: "../../clients/go/flowebb/client_gen.go",
+		tsOut: "../../clients/ts/src/index.ts",
+	} {
+		want, err := os.ReadFile(generated)
+		require.NoError(t, err)
+		got, err := os.ReadFile(checkedIn)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), checkedIn+" is stale; run go generate ./clients/go/flowebb")
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	dir := t.TempDir()
+	assert.ErrorContains(t, run(filepath.Join(dir, "missing.json"), "../../graph/schema.graphql", "", "flowebb", ""), "reading OpenAPI document")
+	assert.ErrorContains(t, run("../../api/openapi.json", filepath.Join(dir, "missing.graphql"), "", "flowebb", ""), "reading GraphQL schema")
+}
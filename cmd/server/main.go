@@ -0,0 +1,200 @@
+// Command server serves every endpoint from one process on one port, for running flowebb
+// off AWS, like on a boat's Raspberry Pi:
+//
+//	CACHE_BACKEND=sqlite CACHE_DIR=/var/lib/flowebb server -addr :8080
+//
+// It answers the same paths API Gateway routes to the Lambda functions, plus /health and
+// /metrics, and syncs the station list and SYNC_STATIONS' predictions with NOAA every
+// SYNC_INTERVAL so they're cached for when NOAA can't be reached. Configuration comes from
+// the same environment variables as the Lambdas; keep the cache in SQLite, files or Redis,
+// since the DynamoDB default needs AWS. SQLite needs the server built with cgo.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/graph"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/offline"
+	"github.com/rs/zerolog/log"
+)
+
+// shutdownTimeout is how long requests in flight get to finish once the server is told to
+// stop
+const shutdownTimeout = 10 * time.Second
+
+// now is when the metrics dashboard is loaded; tests replace it
+var now = time.Now
+
+// tidePaths are the tide endpoints' paths, as API Gateway routes them
+var tidePaths = []string{
+	"/api/tides", "/api/{version}/tides",
+	"/api/extremes", "/api/{version}/extremes",
+	"/api/extremes/next", "/api/{version}/extremes/next",
+	"/api/daylight-lows", "/api/{version}/daylight-lows",
+	"/api/tides/chart",
+	"/api/tides/table",
+	"/api/compare", "/api/{version}/compare",
+	"/api/observations", "/api/{version}/observations",
+	"/api/accuracy", "/api/{version}/accuracy",
+	"/api/sync", "/api/{version}/sync",
+	"/api/exports", "/api/{version}/exports",
+	"/api/exports/status", "/api/{version}/exports/status",
+	"/api/widget", "/api/{version}/widget",
+	"/api/oembed", "/api/{version}/oembed",
+	"/api/usage", "/api/{version}/usage",
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, *addr); err != nil {
+		log.Fatal().Err(err).Msg("Server failed")
+	}
+}
+
+// run serves on addr until ctx is done, then lets requests in flight and queued cache
+// writes finish
+func run(ctx context.Context, addr string, opts ...app.Option) error {
+	server, err := app.BuildServer(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	if server.Syncer != nil {
+		go server.Syncer.Run(ctx, server.Config.SyncInterval)
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           routes(server),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	stopped := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		stopped <- httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Info().Str("addr", addr).Msg("Serving")
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	err = <-stopped
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), app.CacheFlushTimeout)
+	defer cancel()
+	if flushErr := server.Service.FlushCacheWrites(flushCtx); flushErr != nil {
+		log.Warn().Err(flushErr).Msg("Cache writes did not finish before shutting down")
+	}
+	server.Metrics.Flush(flushCtx)
+	return err
+}
+
+// routes mounts each endpoint on its path, wrapped as its Lambda function wraps it
+func routes(server *app.Server) *http.ServeMux {
+	mux := http.NewServeMux()
+	stations := api.HTTPHandler(server.Metrics.Observe(api.Recover(server.Tenants.Serve(api.Branded(serveStations(server)), api.ErrorFor))))
+	for _, path := range []string{"/api/stations", "/api/{version}/stations", "/api/regions", "/api/{version}/regions"} {
+		mux.Handle("GET "+path, stations)
+	}
+	tides := api.HTTPHandler(server.Metrics.Observe(api.Recover(server.Tenants.Serve(api.Branded(serveTides(server)), api.ErrorFor))))
+	for _, path := range tidePaths {
+		mux.Handle("GET "+path, tides)
+	}
+	mux.Handle("POST /graphql", api.HTTPHandler(serveGraphQL(server)))
+	mux.Handle("GET /health", api.HTTPHandler(getHealth(server)))
+	mux.Handle("GET /metrics", api.HTTPHandler(getMetrics(server)))
+	return mux
+}
+
+// serveStations serves the stations and regions endpoints as the stations function does
+func serveStations(server *app.Server) api.HandlerFunc {
+	metered := func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if strings.HasSuffix(request.Path, "/regions") {
+			return api.ValidateRequest(api.RegionsOperation, server.Stations.HandleRegions)(ctx, request)
+		}
+		return api.ValidateRequest(api.StationsOperation, api.SelectFields(server.Stations.HandleRequest))(ctx, request)
+	}
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if err := server.Limiter.Allow(request.RequestContext.Identity.SourceIP); err != nil {
+			return api.ErrorFor(err)
+		}
+		return server.Quotas.Wrap(metered, api.ErrorFor)(ctx, request)
+	}
+}
+
+// serveTides serves the tide endpoints as the tides function does
+func serveTides(server *app.Server) api.HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if err := server.Limiter.Allow(request.RequestContext.Identity.SourceIP); err != nil {
+			return api.ErrorFor(err)
+		}
+		return server.Tides.HandleRequest(ctx, request)
+	}
+}
+
+// serveGraphQL serves the GraphQL endpoint as the GraphQL function does
+func serveGraphQL(server *app.Server) api.HandlerFunc {
+	wrapped := server.Metrics.Observe(api.RecoverWith(server.Tenants.Serve(server.Quotas.Wrap(server.Idempotency.Wrap(server.GraphQL.HandleRequest, graph.Reject), graph.Reject), graph.Reject), graph.Reject))
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if err := server.Limiter.Allow(request.RequestContext.Identity.SourceIP); err != nil {
+			return graph.Reject(err)
+		}
+		return wrapped(ctx, request)
+	}
+}
+
+func getHealth(server *app.Server) api.HandlerFunc {
+	return func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return api.Success(newHealthResponse(server.Syncer))
+	}
+}
+
+// getMetrics serves the dashboard the admin endpoint serves in AWS, over the requests
+// this process has served
+func getMetrics(server *app.Server) api.HandlerFunc {
+	return func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		// The recorder adds a minute's counts on the first request after it, so a quiet
+		// server could still be holding some
+		server.Metrics.Flush(ctx)
+		dashboard, err := metrics.LoadDashboard(ctx, server.MetricsStore, now())
+		if err != nil {
+			return api.ErrorFor(err)
+		}
+		return api.Success(api.NewDashboardResponse(dashboard))
+	}
+}
+
+// healthResponse reports that the server is up, and how its last sync with NOAA went. A
+// failed sync doesn't make it unhealthy: it's expected whenever NOAA is out of reach, and
+// the server answers from its caches meanwhile.
+type healthResponse struct {
+	Status string `json:"status"`
+	// Sync is left out when syncing is off
+	Sync *offline.Status `json:"sync,omitempty"`
+}
+
+func newHealthResponse(syncer *offline.Syncer) healthResponse {
+	response := healthResponse{Status: "ok"}
+	if syncer != nil {
+		status := syncer.Status()
+		response.Sync = &status
+	}
+	return response
+}
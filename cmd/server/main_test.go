@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer builds the server over demo mode's canned NOAA data, syncing Seattle
+func newServer(t *testing.T) *app.Server {
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("DEMO_LATENCY", "0s")
+	t.Setenv("SYNC_STATIONS", "9447130")
+	t.Setenv("SYNC_DAYS", "2")
+	server, err := app.BuildServer(context.Background(), app.WithConfig(config.LoadFromEnv()), app.AsCommand(), app.WithDynamoClient(nil))
+	require.NoError(t, err)
+	return server
+}
+
+func get(t *testing.T, url string) (int, string) {
+	response, err := http.Get(url)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response.StatusCode, string(body)
+}
+
+func TestRoutes(t *testing.T) {
+	server := newServer(t)
+	srv := httptest.NewServer(routes(server))
+	defer srv.Close()
+
+	status, body := get(t, srv.URL+"/api/stations?stationId=9447130")
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, `"Seattle"`)
+
+	status, body = get(t, srv.URL+"/api/v2/tides?stationId=9447130")
+	require.Equal(t, http.StatusOK, status, body)
+	assert.Contains(t, body, `"predictions"`)
+
+	status, body = get(t, srv.URL+"/api/extremes?stationId=9447130&days=100")
+	assert.Equal(t, http.StatusBadRequest, status, "requests are validated as in Lambda")
+	assert.Contains(t, body, `"code":"INVALID_REQUEST"`)
+
+	response, err := http.Post(srv.URL+"/graphql", "application/json", strings.NewReader(`{"query":"{ stations(ids: [\"9447130\"]) { name } }"}`))
+	require.NoError(t, err)
+	graphQLBody, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode, string(graphQLBody))
+	assert.JSONEq(t, `{"data":{"stations":[{"name":"Seattle"}]}}`, string(graphQLBody))
+
+	status, _ = get(t, srv.URL+"/api/unknown")
+	assert.Equal(t, http.StatusNotFound, status, "only the paths API Gateway routes are served")
+	status, _ = get(t, srv.URL+"/graphql")
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+
+	// The dashboard reports the minutes that are over
+	original := now
+	defer func() { now = original }()
+	now = func() time.Time { return time.Now().Add(time.Minute) }
+	status, body = get(t, srv.URL+"/metrics")
+	require.Equal(t, http.StatusOK, status, body)
+	var dashboard api.DashboardResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &dashboard))
+	assert.Contains(t, dashboard.Endpoints, "/api/stations", "the dashboard counts what this process served")
+	assert.Contains(t, dashboard.Endpoints, "/graphql")
+}
+
+func TestRoutes_Health(t *testing.T) {
+	server := newServer(t)
+	srv := httptest.NewServer(routes(server))
+	defer srv.Close()
+
+	status, body := get(t, srv.URL+"/health")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `{"status":"ok","sync":{"stations":0}}`, body, "nothing is synced yet")
+
+	server.Syncer.Sync(context.Background())
+	_, body = get(t, srv.URL+"/health")
+	var health healthResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &health))
+	require.NotNil(t, health.Sync)
+	assert.Equal(t, 1, health.Sync.Stations, body)
+	assert.Empty(t, health.Sync.Errors)
+	assert.NotZero(t, health.Sync.At)
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("SYNC_INTERVAL", "0s")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, addr, app.WithConfig(config.LoadFromEnv()), app.AsCommand(), app.WithDynamoClient(nil))
+	}()
+
+	require.Eventually(t, func() bool {
+		response, err := http.Get("http://" + addr + "/health")
+		if err != nil {
+			return false
+		}
+		response.Body.Close()
+		return response.StatusCode == http.StatusOK
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err, "stopping isn't a failure")
+	case <-time.After(5 * time.Second):
+		t.Fatal("run didn't return once its context was done")
+	}
+}
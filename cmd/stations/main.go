@@ -4,62 +4,75 @@ import (
 	"context"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/app"
 	"github.com/bbernstein/flowebb-go/internal/handler"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
 	"github.com/bbernstein/flowebb-go/internal/station"
-	"github.com/bbernstein/flowebb-go/pkg/http/client"
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
-	"os"
-	"sync"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"strings"
 )
 
 var (
 	lambdaStart     = lambda.Start // Allow mocking of lambda.Start in tests
 	stationsHandler *handler.StationsHandler
-	setupOnce       sync.Once
-)
-
-func init() {
-	setupOnce.Do(func() {
-		cfg := config.LoadFromEnv()
-		cfg.InitializeLogging()
+	// stationFinder loads the station list in the background; each request waits for it
+	stationFinder app.CacheFlusher
+	// rateLimiter is nil outside demo mode
+	rateLimiter *ratelimit.Limiter
+	// tenants is nil unless TENANTS is set
+	tenants *tenant.Registry
+	// quotas is nil unless QUOTA_TABLE is set
+	quotas *quota.Tracker
+	// recorder is nil unless METRICS_TABLE is set
+	recorder *metrics.Recorder
+	ready    = startup.New(initializeService)
 
-		httpClient := client.New(client.Options{
-			Timeout:    cfg.HTTPTimeout,
-			MaxRetries: cfg.MaxRetries,
-			BaseURL:    cfg.NOAABaseURL,
-		})
-
-		// Initialize logger
-		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-		levelStr := os.Getenv("LOG_LEVEL")
-		if levelStr == "" {
-			levelStr = "info"
-		}
-		level, err := zerolog.ParseLevel(levelStr)
-		if err != nil {
-			level = zerolog.InfoLevel
-		}
-		zerolog.SetGlobalLevel(level)
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
+)
 
-		// Setup console logger for development
-		if env := os.Getenv("ENV"); env == "local" || env == "development" {
-			log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
-		}
+// initializeService creates the stations handler on the first request, and again on a
+// later one if it fails
+func initializeService() error {
+	stations, err := app.BuildStations(context.Background(), app.WithFinderFactory(finderFactory))
+	if err != nil {
+		return err
+	}
+	stationsHandler = stations.Handler
+	stationFinder = stations.Finder
+	rateLimiter = stations.Limiter
+	tenants = stations.Tenants
+	quotas = stations.Quotas
+	recorder = stations.Metrics
+	return nil
+}
 
-		// Initialize station finder with cache
-		stationFinder, _ := station.NewNOAAStationFinder(httpClient, nil)
+func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := ready.Do(); err != nil {
+		return api.ErrorFor(err)
+	}
+	return recorder.Observe(api.Recover(tenants.Serve(api.Branded(serveRequest), api.ErrorFor)))(ctx, request)
+}
 
-		// Initialize handler
-		stationsHandler = handler.NewStationsHandler(stationFinder)
-	})
+func serveRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := rateLimiter.Allow(request.RequestContext.Identity.SourceIP); err != nil {
+		return api.ErrorFor(err)
+	}
+	defer app.FlushCacheWrites(ctx, stationFinder)
+	return quotas.Wrap(serveMetered, api.ErrorFor)(ctx, request)
 }
 
-func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	return stationsHandler.HandleRequest(ctx, request)
+// serveMetered serves the endpoints counted against the API key's quota
+func serveMetered(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if strings.HasSuffix(request.Path, "/regions") {
+		return api.ValidateRequest(api.RegionsOperation, stationsHandler.HandleRegions)(ctx, request)
+	}
+	return api.ValidateRequest(api.StationsOperation, api.SelectFields(stationsHandler.HandleRequest))(ctx, request)
 }
 
 func main() {
-	lambdaStart(handleRequest)
+	lambdaStart(api.Recover(handleRequest))
 }
@@ -3,61 +3,23 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/bbernstein/flowebb-go/internal/handler"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 )
 
-// mockStationFinder implements model.StationFinder interface for testing
-type mockStationFinder struct {
-	findStationFn         func(ctx context.Context, stationID string) (*models.Station, error)
-	findNearestStationsFn func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error)
-}
-
-func (m *mockStationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
-	if m.findStationFn != nil {
-		return m.findStationFn(ctx, stationID)
-	}
-	return nil, nil
-}
-
-func (m *mockStationFinder) FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
-	if m.findNearestStationsFn != nil {
-		return m.findNearestStationsFn(ctx, lat, lon, limit)
-	}
-	return nil, nil
-}
-
-// Helper function to create test stations
-func createTestStation(id string) models.Station {
-	state := "WA"
-	region := "Puget Sound"
-	level := "R"
-	stationType := "R"
-	return models.Station{
-		ID:             id,
-		Name:           "Test Station " + id,
-		State:          &state,
-		Region:         &region,
-		Distance:       0,
-		Latitude:       47.6062,
-		Longitude:      -122.3321,
-		Source:         models.SourceNOAA,
-		Capabilities:   []string{"WATER_LEVEL"},
-		TimeZoneOffset: -8 * 3600,
-		Level:          &level,
-		StationType:    &stationType,
-	}
-}
-
 var (
 	mu sync.Mutex // Protect lambdaStart in tests
 )
@@ -147,6 +109,12 @@ func TestMain(m *testing.M) {
 		return
 	}
 
+	// Initialize as the first request would, so tests can replace what it creates
+	if err := ready.Do(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	// Run tests
 	code := m.Run()
 
@@ -170,9 +138,9 @@ func TestHandleRequest(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
-						testStation := createTestStation(stationID)
+				return &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+						testStation := testsupport.Station(stationID)
 						return &testStation, nil
 					},
 				}
@@ -190,11 +158,11 @@ func TestHandleRequest(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return &testsupport.StationFinder{
+					FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 						return []models.Station{
-							createTestStation("TEST001"),
-							createTestStation("TEST002"),
+							testsupport.Station("TEST001"),
+							testsupport.Station("TEST002"),
 						}, nil
 					},
 				}
@@ -239,6 +207,7 @@ func TestParameterValidation(t *testing.T) {
 		request        events.APIGatewayProxyRequest
 		expectedStatus int
 		expectedError  string
+		expectedDetail map[string]interface{}
 	}{
 		{
 			name: "invalid latitude",
@@ -249,7 +218,8 @@ func TestParameterValidation(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid coordinates",
+			expectedError:  "Invalid request parameters",
+			expectedDetail: map[string]interface{}{"parameter": "lat", "message": "must be at most 90", "value": "91", "allowed": "-90 to 90"},
 		},
 		{
 			name: "invalid longitude",
@@ -260,7 +230,8 @@ func TestParameterValidation(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid coordinates",
+			expectedError:  "Invalid request parameters",
+			expectedDetail: map[string]interface{}{"parameter": "lon", "message": "must be at most 180", "value": "181", "allowed": "-180 to 180"},
 		},
 		{
 			name: "non-numeric coordinates",
@@ -271,14 +242,39 @@ func TestParameterValidation(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid parameters",
+			expectedError:  "Invalid request parameters",
+			expectedDetail: map[string]interface{}{"parameter": "lat", "message": "must be a number", "value": "invalid"},
+		},
+		{
+			name: "lat without lon",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"lat": "47.6062",
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request parameters",
+			expectedDetail: map[string]interface{}{"parameter": "lon", "message": "is required with lat"},
+		},
+		{
+			name: "invalid limit",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"lat":   "47.6062",
+					"lon":   "-122.3321",
+					"limit": "0",
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "Invalid request parameters",
+			expectedDetail: map[string]interface{}{"parameter": "limit", "message": "must be at least 1", "value": "0", "allowed": "1 or more"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create handler with empty mock
-			stationsHandler = handler.NewStationsHandler(&mockStationFinder{})
+			stationsHandler = handler.NewStationsHandler(&testsupport.StationFinder{})
 
 			// Call handler
 			response, err := handleRequest(context.Background(), tt.request)
@@ -295,6 +291,7 @@ func TestParameterValidation(t *testing.T) {
 
 			assert.Equal(t, "error", responseBody["responseType"])
 			assert.Equal(t, tt.expectedError, responseBody["error"])
+			assert.Equal(t, []interface{}{tt.expectedDetail}, responseBody["details"])
 		})
 	}
 }
@@ -315,8 +312,8 @@ func TestErrorHandling(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
 						return nil, nil // Simulate station not found
 					},
 				}
@@ -333,8 +330,8 @@ func TestErrorHandling(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return &testsupport.StationFinder{
+					FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 						return nil, assert.AnError // Simulate internal error
 					},
 				}
@@ -367,3 +364,63 @@ func TestErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleRequest_Regions(t *testing.T) {
+	stationsHandler = handler.NewStationsHandler(&testsupport.StationFinder{Stations: []models.Station{testsupport.Station("TEST001")}})
+
+	for _, path := range []string{"/api/regions", "/api/v2/regions"} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{Path: path})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		assert.Contains(t, response.Body, `"responseType":"regions"`)
+	}
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/regions",
+		QueryStringParameters: map[string]string{"source": "ELSEWHERE"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestHandleRequest_Fields(t *testing.T) {
+	stationsHandler = handler.NewStationsHandler(&testsupport.StationFinder{Stations: []models.Station{testsupport.Station("TEST001")}})
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/stations",
+		QueryStringParameters: map[string]string{"stationId": "TEST001", "fields": "pagination"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, `{"responseType":"stations"}`, response.Body)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/stations",
+		QueryStringParameters: map[string]string{"stationId": "TEST001", "fields": "stations;drop"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestHandleRequest_Tenant(t *testing.T) {
+	stationsHandler = handler.NewStationsHandler(&testsupport.StationFinder{Stations: []models.Station{testsupport.Station("TEST001")}})
+	registry, err := tenant.NewRegistry([]tenant.Tenant{{ID: "acme", Hosts: []string{"tides.acme.com"}, RateLimit: 1, Branding: tenant.Branding{Name: "Acme Tides"}}})
+	require.NoError(t, err)
+	tenants = registry
+	t.Cleanup(func() { tenants = nil })
+
+	request := events.APIGatewayProxyRequest{Path: "/api/regions", Headers: map[string]string{"Host": "tides.acme.com"}}
+	response, err := handleRequest(context.Background(), request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.True(t, strings.HasPrefix(response.Body, `{"tenant":{"id":"acme","name":"Acme Tides"},"responseType":"regions"`), response.Body)
+
+	response, err = handleRequest(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode, "the tenant allows one request a minute")
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{Path: "/api/regions"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotContains(t, response.Body, `"tenant"`)
+}
@@ -0,0 +1,62 @@
+// Command stationsnapshot downloads the NOAA station list and writes the snapshot that's
+// built into the station finder, so a cold start can answer station lookups at once:
+//
+//	go generate ./internal/station
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	output := flag.String("o", "internal/station/snapshot/noaa.json.gz", "file to write the snapshot to")
+	baseURL := flag.String("base-url", cfg.NOAABaseURL, "NOAA CO-OPS API base URL")
+	flag.Parse()
+
+	httpClient := client.New(client.Options{
+		Timeout:    cfg.HTTPTimeout,
+		MaxRetries: cfg.MaxRetries,
+		BaseURL:    *baseURL,
+		Limiter:    client.NewLimiter(cfg.NOAAMaxConcurrentRequests),
+	})
+	if err := run(context.Background(), httpClient, *output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run writes the snapshot to output, leaving the previous one in place if the download fails
+func run(ctx context.Context, httpClient *client.Client, output string) error {
+	finder, err := station.NewNOAAStationFinder(httpClient, nil)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(output), ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("creating snapshot: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := finder.WriteSnapshot(ctx, file); err != nil {
+		file.Close()
+		return fmt.Errorf("downloading station list: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	return os.Rename(file.Name(), output)
+}
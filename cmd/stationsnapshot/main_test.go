@@ -0,0 +1,60 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mdapi/prod/webapi/tidepredstations.json" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"stationList":[{"stationId":"9447130","name":"Seattle","state":"WA","lat":47.6026,"lon":-122.3393,"timeZoneCorr":"-8"}]}`))
+	}))
+	defer srv.Close()
+	httpClient := client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second})
+
+	output := filepath.Join(t.TempDir(), "noaa.json.gz")
+	require.NoError(t, run(context.Background(), httpClient, output))
+
+	file, err := os.Open(output)
+	require.NoError(t, err)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	var record struct {
+		Stations []struct {
+			ID string `json:"id"`
+		} `json:"stations"`
+	}
+	require.NoError(t, json.NewDecoder(gz).Decode(&record))
+	require.Len(t, record.Stations, 1)
+	assert.Equal(t, "9447130", record.Stations[0].ID)
+}
+
+func TestRun_KeepsSnapshotOnFailure(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	httpClient := client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second})
+
+	output := filepath.Join(t.TempDir(), "noaa.json.gz")
+	require.NoError(t, os.WriteFile(output, []byte("previous"), 0o644))
+	assert.Error(t, run(context.Background(), httpClient, output))
+
+	kept, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "previous", string(kept))
+}
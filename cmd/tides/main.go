@@ -2,117 +2,62 @@ package main
 
 import (
 	"context"
-	"errors"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/bbernstein/flowebb-go/internal/api"
-	"github.com/bbernstein/flowebb-go/internal/config"
-	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/handler"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
 	"github.com/bbernstein/flowebb-go/internal/station"
-	"github.com/bbernstein/flowebb-go/internal/tide"
-	"github.com/bbernstein/flowebb-go/pkg/http/client"
-	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
-	"net/http"
-	"os"
-	"sync"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
 )
 
 // Variables exposed for testing
 var (
-	lambdaStart = lambda.Start // Allow mocking of lambda.Start in tests
-	tideService *tide.Service
-	setupOnce   sync.Once
+	lambdaStart  = lambda.Start // Allow mocking of lambda.Start in tests
+	tidesHandler = &handler.TidesHandler{}
+	// rateLimiter is nil outside demo mode
+	rateLimiter *ratelimit.Limiter
+	// tenants is nil unless TENANTS is set
+	tenants *tenant.Registry
+	// recorder is nil unless METRICS_TABLE is set
+	recorder *metrics.Recorder
+	ready    = startup.New(initializeService)
+
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
 )
 
-// initializeService is exposed for testing
-func initializeService() {
-	setupOnce.Do(func() {
-		cfg := config.LoadFromEnv()
-		cfg.InitializeLogging()
-
-		ctx := context.Background()
-		httpClient := client.New(client.Options{
-			Timeout:    cfg.HTTPTimeout,
-			MaxRetries: cfg.MaxRetries,
-			BaseURL:    cfg.NOAABaseURL,
-		})
-
-		// Initialize logger
-		zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-		levelStr := os.Getenv("LOG_LEVEL")
-		if levelStr == "" {
-			levelStr = "info"
-		}
-		var err error
-		var level zerolog.Level
-		level, err = zerolog.ParseLevel(levelStr)
-		if err != nil {
-			level = zerolog.InfoLevel
-		}
-		zerolog.SetGlobalLevel(level)
-
-		// Setup console logger for development
-		if env := os.Getenv("ENV"); env == "local" || env == "development" {
-			log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
-		}
-
-		stationFinder, _ := station.NewNOAAStationFinder(httpClient, nil)
-
-		tideService, err = tide.NewService(ctx, httpClient, stationFinder)
-		if err != nil {
-			log.Fatal().Err(err).Msgf("Failed to create tide service: %v", err)
-		}
-	})
-}
-
-func init() {
-	initializeService()
+// initializeService creates the tide service on the first request, and again on a later
+// one if it fails
+func initializeService() error {
+	tides, err := app.BuildTides(context.Background(), app.WithFinderFactory(finderFactory))
+	if err != nil {
+		return err
+	}
+	tidesHandler = tides.Handler
+	rateLimiter = tides.Limiter
+	tenants = tides.Tenants
+	recorder = tides.Metrics
+	return nil
 }
 
 func handleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
-	params := request.QueryStringParameters
-	log.Info().Msg("Handling tides request")
-
-	var startTimeStr, endTimeStr *string
-	if str, ok := params["startDateTime"]; ok {
-		startTimeStr = &str
-	}
-	if str, ok := params["endDateTime"]; ok {
-		endTimeStr = &str
-	}
-
-	var response *models.ExtendedTideResponse
-	var err error
-	var lat, lon float64
-
-	// Check if we're looking up by station ID or coordinates
-	if stationID, ok := params["stationId"]; ok {
-		response, err = tideService.GetCurrentTideForStation(ctx, stationID, startTimeStr, endTimeStr)
-	} else if lat, lon, err = api.ParseCoordinates(params); err == nil {
-		response, err = tideService.GetCurrentTide(ctx, lat, lon, startTimeStr, endTimeStr)
-	} else {
-		return api.Error("Missing required parameters", http.StatusBadRequest)
+	if err := ready.Do(); err != nil {
+		return api.ErrorFor(err)
 	}
+	return recorder.Observe(api.Recover(tenants.Serve(api.Branded(serveRequest), api.ErrorFor)))(ctx, request)
+}
 
-	if err != nil {
-		var noaaErr *tide.NoaaAPIError
-		var rangeErr *tide.InvalidRangeError
-		if errors.As(err, &noaaErr) {
-			log.Error().Err(err).Msg("Error from NOAA API")
-			return api.Error("Error fetching tide data from upstream service: "+err.Error(), http.StatusBadGateway)
-		} else if errors.As(err, &rangeErr) {
-			log.Error().Err(err).Msg("Invalid range")
-			return api.Error("Invalid range: "+err.Error(), http.StatusBadRequest)
-		} else {
-			log.Error().Err(err).Msg("Error getting tide data")
-			return api.Error("Error getting tide data: "+err.Error(), http.StatusInternalServerError)
-		}
+func serveRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := rateLimiter.Allow(request.RequestContext.Identity.SourceIP); err != nil {
+		return api.ErrorFor(err)
 	}
-
-	return api.Success(response)
+	defer app.FlushCacheWrites(ctx, tidesHandler.Service)
+	return tidesHandler.HandleRequest(ctx, request)
 }
 
 func main() {
-	lambdaStart(handleRequest)
+	lambdaStart(api.Recover(handleRequest))
 }
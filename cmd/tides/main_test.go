@@ -1,20 +1,37 @@
 package main
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"image/png"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/export"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/bbernstein/flowebb-go/internal/offline"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
 	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/widget"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,72 +40,16 @@ import (
 	"reflect"
 )
 
-type mockCacheService struct{}
-
-func (m *mockCacheService) GetPredictions(_ context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
-	return &models.TidePredictionRecord{
-		StationID:   stationID,
-		Date:        date.Format("2006-01-02"),
-		Predictions: []models.TidePrediction{},
-		Extremes:    []models.TideExtreme{},
-	}, nil
-}
-
-func (m *mockCacheService) SavePredictionsBatch(_ context.Context, _ []models.TidePredictionRecord) error {
-	return nil
-}
-
-func newMockTideService() *tide.Service {
-	return &tide.Service{
-		HttpClient:      &client.Client{},     // Mock or real HTTP client
-		StationFinder:   &mockStationFinder{}, // Mock station finder
-		PredictionCache: &mockCacheService{},  // Mock cache service
-	}
-}
-
-type mockStationFinder struct {
-	findStationFunc         func(ctx context.Context, stationID string) (*models.Station, error)
-	findNearestStationsFunc func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error)
-}
-
-func (m *mockStationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
-	if m.findStationFunc != nil {
-		return m.findStationFunc(ctx, stationID)
-	}
-	// Default successful response instead of error
-	stationType := "R"
-	return &models.Station{
-		ID:             stationID,
-		Name:           "Test Station",
-		Latitude:       47.6062,
-		Longitude:      -122.3321,
-		TimeZoneOffset: -28800,
-		StationType:    &stationType,
-	}, nil
-}
-
-func (m *mockStationFinder) FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
-	if m.findNearestStationsFunc != nil {
-		return m.findNearestStationsFunc(ctx, lat, lon, limit)
-	}
-	// Default successful response instead of empty slice
-	stationType := "R"
-	return []models.Station{
-		{
-			ID:             "TEST001",
-			Name:           "Test Station",
-			Latitude:       lat,
-			Longitude:      lon,
-			TimeZoneOffset: -28800,
-			StationType:    &stationType,
-		},
-	}, nil
+// newMockTideService returns a tide service over the testsupport NOAA mock that knows
+// any station ID
+func newMockTideService(t *testing.T) *tide.Service {
+	return testsupport.New(t, testsupport.AnyStation()).Service
 }
 
 func TestHandleRequest(t *testing.T) {
 	// Replace the real tide service with our mock
-	originalTideService := tideService
-	defer func() { tideService = originalTideService }()
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
 
 	testCases := []struct {
 		name         string
@@ -104,7 +65,7 @@ func TestHandleRequest(t *testing.T) {
 				},
 			},
 			setupMock: func() *tide.Service {
-				return newMockTideService()
+				return newMockTideService(t)
 			},
 			expectedCode: http.StatusOK,
 		},
@@ -117,17 +78,69 @@ func TestHandleRequest(t *testing.T) {
 				},
 			},
 			setupMock: func() *tide.Service {
-				return newMockTideService()
+				return newMockTideService(t)
 			},
 			expectedCode: http.StatusOK,
 		},
+		{
+			name: "invalid parameters are rejected before the lookup",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"stationId":     "1234567",
+					"interpolation": "cubic",
+				},
+			},
+			setupMock: func() *tide.Service {
+				return newMockTideService(t)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "downsampled request",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"stationId": "1234567",
+					"points":    "300",
+				},
+			},
+			setupMock: func() *tide.Service {
+				return newMockTideService(t)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "too few points",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"stationId": "1234567",
+					"points":    "2",
+				},
+			},
+			setupMock: func() *tide.Service {
+				return newMockTideService(t)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown locale",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"stationId": "1234567",
+					"locale":    "en-UK",
+				},
+			},
+			setupMock: func() *tide.Service {
+				return newMockTideService(t)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
 		// ... other test cases remain the same
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Set up mock for this test case
-			tideService = tc.setupMock()
+			tidesHandler.Service = tc.setupMock()
 
 			ctx := context.Background()
 			response, err := handleRequest(ctx, tc.request)
@@ -142,6 +155,462 @@ func TestHandleRequest(t *testing.T) {
 	}
 }
 
+func TestHandleRequest_Weather(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	weatherOf := func(params map[string]string) (map[string]interface{}, bool) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		weather, ok := body["weather"].(map[string]interface{})
+		return weather, ok
+	}
+
+	_, ok := weatherOf(map[string]string{"stationId": "1234567"})
+	assert.False(t, ok, "weather is only included when requested")
+
+	_, ok = weatherOf(map[string]string{"stationId": "1234567", "includeWeather": "false"})
+	assert.False(t, ok)
+
+	// Without a forecaster the tides still come back, with the weather marked unavailable
+	weather, ok := weatherOf(map[string]string{"stationId": "1234567", "includeWeather": "true"})
+	require.True(t, ok)
+	assert.Equal(t, "NWS", weather["source"])
+	assert.Equal(t, false, weather["available"])
+	assert.Equal(t, []interface{}{}, weather["forecast"])
+}
+
+func TestHandleRequest_TimeFormat(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	localTimeOf := func(params map[string]string) string {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		var body struct {
+			LocalTime string `json:"localTime"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		return body.LocalTime
+	}
+
+	iso := localTimeOf(map[string]string{"stationId": "1234567"})
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`, iso)
+	assert.Regexp(t, `^\d{1,2}/\d{1,2}/\d{4} \d{1,2}:\d{2}:\d{2} [AP]M$`,
+		localTimeOf(map[string]string{"stationId": "1234567", "locale": "en-US"}))
+	assert.Regexp(t, `^\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2}$`,
+		localTimeOf(map[string]string{"stationId": "1234567", "locale": "en-gb", "hour12": "false"}))
+}
+
+func TestHandleRequest_Fields(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	for _, path := range []string{"/api/tides", "/api/v2/tides"} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  path,
+			QueryStringParameters: map[string]string{"stationId": "1234567", "fields": "extremes,timeZoneOffsetSeconds"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		var body map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		var fields []string
+		for field := range body {
+			fields = append(fields, field)
+		}
+		assert.ElementsMatch(t, []string{"responseType", "extremes", "timeZoneOffsetSeconds"}, fields, path)
+	}
+}
+
+func TestHandleRequest_Versions(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	params := map[string]string{"stationId": "1234567"}
+
+	t.Run("v1 by default", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "1", response.Headers["API-Version"])
+		assert.Equal(t, "true", response.Headers["Deprecation"])
+		assert.Equal(t, `</api/v3/tides>; rel="successor-version"`, response.Headers["Link"])
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, "1234567", body["nearestStation"])
+		assert.Contains(t, body, "waterLevel")
+	})
+
+	t.Run("v2 by path", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/v2/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "2", response.Headers["API-Version"])
+		assert.Equal(t, "true", response.Headers["Deprecation"])
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.NotContains(t, body, "nearestStation")
+		station, ok := body["station"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "1234567", station["id"])
+		level, ok := body["level"].(map[string]interface{})
+		require.True(t, ok)
+		assert.Contains(t, level, "predicted")
+		assert.NotContains(t, level, "observed")
+	})
+
+	t.Run("v3 by path", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/v3/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		assert.Equal(t, "3", response.Headers["API-Version"])
+		assert.Empty(t, response.Headers["Deprecation"])
+
+		var body struct {
+			Data     api.TideResponseV2       `json:"data"`
+			Meta     api.Meta                 `json:"meta"`
+			Warnings []models.ResponseWarning `json:"warnings"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, "1234567", body.Data.Station.ID, "the data is in v2's format")
+		assert.NotZero(t, body.Meta.GeneratedAt)
+		assert.Contains(t, []string{models.CacheHit, models.CacheMiss}, body.Meta.CacheStatus)
+		assert.Equal(t, "NOAA", body.Meta.Source)
+		assert.Equal(t, "ft", body.Meta.Units)
+		assert.Equal(t, "MLLW", body.Meta.Datum)
+		assert.NotNil(t, body.Warnings)
+
+		// The same day again is a cache hit
+		response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/v3/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, models.CacheHit, body.Meta.CacheStatus)
+	})
+
+	t.Run("v2 by Accept header", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/tides",
+			Headers:               map[string]string{"accept": "application/vnd.flowebb.v2+json"},
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "2", response.Headers["API-Version"])
+	})
+
+	t.Run("v2 as MessagePack", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/tides",
+			Headers:               map[string]string{"accept": "application/vnd.flowebb.v2+msgpack"},
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "2", response.Headers["API-Version"])
+		assert.Equal(t, api.MessagePackMediaType, response.Headers["Content-Type"])
+		require.True(t, response.IsBase64Encoded)
+		body, err := base64.StdEncoding.DecodeString(response.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "\xa7station", "v2 fields are encoded")
+		assert.NotContains(t, string(body), "nearestStation")
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/v9/tides",
+			QueryStringParameters: params,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotAcceptable, response.StatusCode)
+	})
+}
+
+// stubProvider is a tide provider that serves extremes from functions, to show the
+// handlers don't depend on tide.Service
+type stubProvider struct {
+	models.TideProvider
+	current  func(stationID string) (*models.ExtendedTideResponse, error)
+	extremes func(stationID string, days int) (*models.ExtremesSummary, error)
+	next     func(stationID string, count int) (*models.NextExtremes, error)
+	daylight func(stationID string, below *float64, days int) (*models.DaylightLows, error)
+	accuracy func(stationID string, days int) (*models.AccuracyStats, error)
+	daily    func(stationID, startDate string, days int) (*models.DailyPredictions, error)
+}
+
+func (p stubProvider) GetCurrentTideForStation(_ context.Context, stationID string, _, _ *string) (*models.ExtendedTideResponse, error) {
+	return p.current(stationID)
+}
+
+func (p stubProvider) GetDailyExtremes(_ context.Context, stationID string, _ *string, days int) (*models.ExtremesSummary, error) {
+	return p.extremes(stationID, days)
+}
+
+func (p stubProvider) GetNextExtremes(_ context.Context, stationID string, count int) (*models.NextExtremes, error) {
+	return p.next(stationID, count)
+}
+
+func (p stubProvider) GetDaylightLows(_ context.Context, stationID string, below *float64, _ *string, days int) (*models.DaylightLows, error) {
+	return p.daylight(stationID, below, days)
+}
+
+func (p stubProvider) GetAccuracy(_ context.Context, stationID string, days int) (*models.AccuracyStats, error) {
+	return p.accuracy(stationID, days)
+}
+
+func (p stubProvider) GetDailyPredictions(_ context.Context, stationID string, startDate *string, days int) (*models.DailyPredictions, error) {
+	return p.daily(stationID, *startDate, days)
+}
+
+func TestHandleRequest_OtherProvider(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = stubProvider{extremes: func(stationID string, days int) (*models.ExtremesSummary, error) {
+		return &models.ExtremesSummary{ResponseType: "extremes", StationID: stationID, StationName: "Stub"}, nil
+	}}
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/extremes",
+		QueryStringParameters: map[string]string{"stationId": "1234567"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Contains(t, response.Body, `"stationName":"Stub"`)
+}
+
+func TestHandleRequest_Extremes(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	t.Run("days from the start date", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/v2/extremes",
+			QueryStringParameters: map[string]string{"stationId": "1234567", "startDate": "2024-01-30", "days": "3"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		assert.Equal(t, "2", response.Headers["API-Version"])
+
+		var body models.ExtremesSummary
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, "extremes", body.ResponseType)
+		assert.Equal(t, "1234567", body.StationID)
+		require.Len(t, body.Days, 3)
+		assert.Equal(t, "2024-01-30", body.Days[0].Date)
+		assert.Equal(t, "2024-02-01", body.Days[2].Date)
+	})
+
+	t.Run("a week by default", func(t *testing.T) {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/extremes",
+			QueryStringParameters: map[string]string{"stationId": "1234567"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var body models.ExtremesSummary
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Len(t, body.Days, tide.DefaultExtremesDays)
+	})
+
+	for name, params := range map[string]map[string]string{
+		"missing station":  {"days": "3"},
+		"too many days":    {"stationId": "1234567", "days": "32"},
+		"malformed date":   {"stationId": "1234567", "startDate": "2024-01-30T00:00:00"},
+		"impossible date":  {"stationId": "1234567", "startDate": "2024-02-30"},
+		"non-integer days": {"stationId": "1234567", "days": "two"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+				Path:                  "/api/extremes",
+				QueryStringParameters: params,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		})
+	}
+}
+
+func TestHandleRequest_Chart(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	params := map[string]string{
+		"stationId":     "1234567",
+		"startDateTime": "2024-01-01T00:00:00",
+		"endDateTime":   "2024-01-02T00:00:00",
+	}
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides/chart",
+		QueryStringParameters: params,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "image/svg+xml", response.Headers["Content-Type"])
+	assert.True(t, strings.HasPrefix(response.Body, "<svg"))
+	assert.Contains(t, response.Body, "Test Station")
+
+	params["format"], params["width"] = "png", "400"
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides/chart",
+		QueryStringParameters: params,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "image/png", response.Headers["Content-Type"])
+	assert.True(t, response.IsBase64Encoded)
+	data, err := base64.StdEncoding.DecodeString(response.Body)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+
+	for name, params := range map[string]map[string]string{
+		"missing station": {"format": "svg"},
+		"unknown format":  {"stationId": "1234567", "format": "gif"},
+		"too wide":        {"stationId": "1234567", "width": "5000"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+				Path:                  "/api/tides/chart",
+				QueryStringParameters: params,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		})
+	}
+}
+
+type mockObserver struct{}
+
+func (mockObserver) Latest(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+	return &models.Observation{Timestamp: 1704110760000, Value: 48.4, Units: product.Units}, nil
+}
+
+func TestHandleRequest_Observation(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	service := newMockTideService(t)
+	service.Observer = mockObserver{}
+	service.StationFinder = &testsupport.StationFinder{
+		FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+			return &models.Station{
+				ID:             stationID,
+				Name:           "Test Station",
+				TimeZoneOffset: -28800,
+				Capabilities:   []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature},
+			}, nil
+		},
+	}
+	tidesHandler.Service = service
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/observations",
+		QueryStringParameters: map[string]string{"stationId": "1234567", "product": "water_temperature"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+
+	var body models.ObservationResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "observation", body.ResponseType)
+	assert.Equal(t, "1234567", body.StationID)
+	assert.Equal(t, "2024-01-01T04:06:00", body.Observation.LocalTime)
+	assert.Equal(t, 48.4, body.Observation.Value)
+
+	for name, params := range map[string]map[string]string{
+		"missing product":    {"stationId": "1234567"},
+		"unknown product":    {"stationId": "1234567", "product": "salinity"},
+		"product not sensed": {"stationId": "1234567", "product": "air_pressure"},
+		"missing station ID": {"product": "water_temperature"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+				Path:                  "/api/observations",
+				QueryStringParameters: params,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode, response.Body)
+		})
+	}
+}
+
+func TestHandleRequest_Compare(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	tidesHandler.Service = newMockTideService(t)
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path: "/api/v2/compare",
+		QueryStringParameters: map[string]string{
+			"stationIds":    "1234567, 7654321",
+			"startDateTime": "2024-01-01T00:00:00",
+			"endDateTime":   "2024-01-01T01:00:00",
+			"interval":      "30",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "2", response.Headers["API-Version"])
+
+	var body models.StationComparison
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "comparison", body.ResponseType)
+	assert.Equal(t, 30, body.IntervalMinutes)
+	assert.Len(t, body.Timestamps, 3)
+	require.Len(t, body.Stations, 2)
+	assert.Equal(t, "1234567", body.Stations[0].ID)
+	assert.Equal(t, "7654321", body.Stations[1].ID)
+	assert.Len(t, body.Stations[1].Heights, 3)
+
+	for name, params := range map[string]map[string]string{
+		"missing stations": {"interval": "6"},
+		"one station":      {"stationIds": "1234567"},
+		"too many":         {"stationIds": "1,2,3,4,5,6"},
+		"interval too big": {"stationIds": "1234567,7654321", "interval": "61"},
+		"malformed start":  {"stationIds": "1234567,7654321", "startDateTime": "01/01/2024"},
+		"unknown zone":     {"stationIds": "1234567,7654321", "tz": "Pacific/Atlantis"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+				Path:                  "/api/compare",
+				QueryStringParameters: params,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		})
+	}
+}
+
 var (
 	mu sync.Mutex // Protect lambdaStart in tests
 )
@@ -258,9 +727,7 @@ func TestLoggerSetup(t *testing.T) {
 			err := os.Setenv("ENV", tt.env)
 			require.NoError(t, err)
 
-			// Reset the setup to force reinitialization
-			setupOnce = sync.Once{}
-			initializeService()
+			require.NoError(t, initializeService())
 		})
 	}
 }
@@ -317,25 +784,6 @@ func TestServiceInitializationError(t *testing.T) {
 	}
 }
 
-type mockCacheService2 struct {
-	getPredictionsFn       func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error)
-	savePredictionsBatchFn func(ctx context.Context, records []models.TidePredictionRecord) error
-}
-
-func (m *mockCacheService2) GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
-	if m.getPredictionsFn != nil {
-		return m.getPredictionsFn(ctx, stationID, date)
-	}
-	return nil, nil
-}
-
-func (m *mockCacheService2) SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error {
-	if m.savePredictionsBatchFn != nil {
-		return m.savePredictionsBatchFn(ctx, records)
-	}
-	return nil
-}
-
 // Update the test to use the mock service
 func TestNoaaAPIErrorHandling(t *testing.T) {
 	tests := []struct {
@@ -344,6 +792,7 @@ func TestNoaaAPIErrorHandling(t *testing.T) {
 		setupMock      func() *tide.Service
 		expectedStatus int
 		expectedError  string
+		expectedCode   api.ErrorCode
 	}{
 		{
 			name: "NOAA API error",
@@ -353,28 +802,22 @@ func TestNoaaAPIErrorHandling(t *testing.T) {
 				},
 			},
 			setupMock: func() *tide.Service {
-				mockFinder := &mockStationFinder{
-					findStationFunc: func(ctx context.Context, stationID string) (*models.Station, error) {
+				mockFinder := &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
 						// Return a valid station first, as the error should come from the tide service
-						stationType := "R"
 						return &models.Station{
 							ID:             stationID,
 							Name:           "Test Station",
 							Latitude:       47.6062,
 							Longitude:      -122.3321,
 							TimeZoneOffset: -28800,
-							StationType:    &stationType,
+							StationType:    models.StationKindReference,
 						}, nil
 					},
 				}
 
-				// Create mock cache
-				mockCache := &mockCacheService2{
-					getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
-						// Return nil to force API call
-						return nil, nil
-					},
-				}
+				// An empty cache forces the API call
+				mockCache := testsupport.NewMemoryCache()
 
 				// Create mock HTTP client
 				mockClient := &client.Client{
@@ -396,7 +839,8 @@ func TestNoaaAPIErrorHandling(t *testing.T) {
 				return service
 			},
 			expectedStatus: http.StatusBadGateway,
-			expectedError:  "Error fetching tide data from upstream service",
+			expectedError:  "Error fetching data from upstream service",
+			expectedCode:   api.CodeUpstreamUnavailable,
 		},
 		{
 			name: "general error",
@@ -406,28 +850,72 @@ func TestNoaaAPIErrorHandling(t *testing.T) {
 				},
 			},
 			setupMock: func() *tide.Service {
-				mockFinder := &mockStationFinder{
-					findStationFunc: func(ctx context.Context, stationID string) (*models.Station, error) {
+				mockFinder := &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
 						return nil, errors.New("general error")
 					},
 				}
 				return &tide.Service{
 					HttpClient:      &client.Client{},
 					StationFinder:   mockFinder,
-					PredictionCache: &mockCacheService{},
+					PredictionCache: testsupport.NewMemoryCache(),
 				}
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  "Error getting tide data",
+			expectedError:  "Internal error: ",
+			expectedCode:   api.CodeInternal,
+		},
+		{
+			name: "unknown station",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"stationId": "9999999",
+				},
+			},
+			setupMock: func() *tide.Service {
+				return &tide.Service{
+					HttpClient:      &client.Client{},
+					StationFinder:   &testsupport.StationFinder{},
+					PredictionCache: testsupport.NewMemoryCache(),
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "station not found: 9999999",
+			expectedCode:   api.CodeStationNotFound,
+		},
+		{
+			name: "no station close enough",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"lat": "30.0",
+					"lon": "-140.0",
+				},
+			},
+			setupMock: func() *tide.Service {
+				mockFinder := &testsupport.StationFinder{
+					FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+						return []models.Station{{ID: "1612340", Name: "Honolulu", Distance: 1900}}, nil
+					},
+				}
+				return &tide.Service{
+					HttpClient:         &client.Client{},
+					StationFinder:      mockFinder,
+					PredictionCache:    testsupport.NewMemoryCache(),
+					MaxStationDistance: 100,
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "the nearest station is Honolulu (1612340), 1900 km away",
+			expectedCode:   api.CodeNoNearbyStation,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Save original service and set mock
-			originalService := tideService
-			tideService = tt.setupMock()
-			defer func() { tideService = originalService }()
+			originalService := tidesHandler.Service
+			tidesHandler.Service = tt.setupMock()
+			defer func() { tidesHandler.Service = originalService }()
 
 			// Call handler
 			response, err := handleRequest(context.Background(), tt.request)
@@ -442,6 +930,506 @@ func TestNoaaAPIErrorHandling(t *testing.T) {
 
 			assert.Equal(t, "error", responseBody["responseType"])
 			assert.Contains(t, responseBody["error"], tt.expectedError)
+			assert.Equal(t, string(tt.expectedCode), responseBody["code"])
+			if tt.expectedCode == api.CodeNoNearbyStation {
+				assert.Equal(t, map[string]interface{}{"id": "1612340", "name": "Honolulu", "distanceKm": 1900.0}, responseBody["nearestStation"])
+				assert.Equal(t, 100.0, responseBody["maxDistanceKm"])
+			}
 		})
 	}
 }
+
+func TestHandleRequest_NotReady(t *testing.T) {
+	originalReady := ready
+	defer func() { ready = originalReady }()
+	ready = startup.New(func() error { return errors.New("dynamo unavailable") })
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides",
+		QueryStringParameters: map[string]string{"stationId": "9447130"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "1", response.Headers["Retry-After"])
+	assert.Contains(t, response.Body, string(api.CodeServiceUnavailable))
+}
+
+func TestMain(m *testing.M) {
+	// Initialize as the first request would, so tests can replace what it creates
+	if err := ready.Do(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func TestHandleRequest_NextExtremes(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	var counts []int
+	tidesHandler.Service = stubProvider{next: func(stationID string, count int) (*models.NextExtremes, error) {
+		counts = append(counts, count)
+		return &models.NextExtremes{ResponseType: "nextExtremes", StationID: stationID, Extremes: []models.TideExtreme{}}, nil
+	}}
+
+	for _, path := range []string{"/api/extremes/next", "/api/v2/extremes/next"} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  path,
+			QueryStringParameters: map[string]string{"stationId": "9447130"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		assert.Contains(t, response.Body, `"responseType":"nextExtremes"`)
+	}
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/extremes/next",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "count": "21"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/extremes/next",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "count": "2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, []int{tide.DefaultNextExtremes, tide.DefaultNextExtremes, 2}, counts)
+}
+
+func TestHandleRequest_DaylightLows(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	var belows []*float64
+	tidesHandler.Service = stubProvider{daylight: func(stationID string, below *float64, days int) (*models.DaylightLows, error) {
+		belows = append(belows, below)
+		return &models.DaylightLows{ResponseType: "daylightLows", StationID: stationID, Days: []models.DaylightDay{}}, nil
+	}}
+
+	for _, path := range []string{"/api/daylight-lows", "/api/v2/daylight-lows"} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  path,
+			QueryStringParameters: map[string]string{"stationId": "9447130"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		assert.Contains(t, response.Body, `"responseType":"daylightLows"`)
+	}
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/daylight-lows",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "below": "low"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/daylight-lows",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "below": "-0.5"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	require.Len(t, belows, 3)
+	assert.Nil(t, belows[0])
+	require.NotNil(t, belows[2])
+	assert.Equal(t, -0.5, *belows[2])
+}
+
+func TestHandleRequest_Accuracy(t *testing.T) {
+	originalTideService := tidesHandler.Service
+	defer func() { tidesHandler.Service = originalTideService }()
+	var days []int
+	tidesHandler.Service = stubProvider{accuracy: func(stationID string, d int) (*models.AccuracyStats, error) {
+		days = append(days, d)
+		return &models.AccuracyStats{ResponseType: "accuracy", StationID: stationID, Days: d}, nil
+	}}
+
+	for _, path := range []string{"/api/accuracy", "/api/v2/accuracy"} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  path,
+			QueryStringParameters: map[string]string{"stationId": "9447130"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		assert.Contains(t, response.Body, `"responseType":"accuracy"`)
+		assert.Contains(t, response.Body, `"meanAbsoluteError":null`, "no samples is told apart from no error")
+	}
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/accuracy",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "days": "31"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/accuracy",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "days": "30"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, []int{accuracy.DefaultDays, accuracy.DefaultDays, 30}, days)
+}
+
+func TestHandleRequest_Sync(t *testing.T) {
+	original := tidesHandler.Delta
+	defer func() { tidesHandler.Delta = original }()
+	var days []int
+	tidesHandler.Delta = offline.NewDelta(stubProvider{daily: func(stationID, startDate string, d int) (*models.DailyPredictions, error) {
+		days = append(days, d)
+		return &models.DailyPredictions{StationID: stationID, Days: []models.DayPredictions{{Date: startDate}}}, nil
+	}}, &testsupport.StationFinder{Stations: []models.Station{
+		{ID: "9447130", Name: "Seattle", TimeZone: "America/Los_Angeles"},
+		{ID: "9444900", Name: "Port Townsend", TimeZone: "America/Los_Angeles"},
+	}}, nil)
+
+	for _, path := range []string{"/api/sync", "/api/v2/sync"} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  path,
+			QueryStringParameters: map[string]string{"stationIds": "9447130, 9444900"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		var body models.PredictionSync
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, "sync", body.ResponseType)
+		require.Len(t, body.Stations, 2)
+		assert.Equal(t, "Port Townsend", body.Stations[1].StationName)
+		assert.True(t, body.Stations[0].Full)
+	}
+	assert.Equal(t, []int{offline.DefaultSyncDays, offline.DefaultSyncDays, offline.DefaultSyncDays, offline.DefaultSyncDays}, days)
+
+	// Synced a minute ago, nothing has come into the window
+	days = nil
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/sync",
+		QueryStringParameters: map[string]string{"stationIds": "9447130", "since": fmt.Sprint(time.Now().Add(-time.Minute).UnixMilli()), "days": "3"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Contains(t, response.Body, `"full":false`)
+	assert.Empty(t, days)
+
+	for name, test := range map[string]struct {
+		params map[string]string
+		status int
+	}{
+		"missing stations": {map[string]string{"days": "3"}, http.StatusBadRequest},
+		"too many days":    {map[string]string{"stationIds": "9447130", "days": "15"}, http.StatusBadRequest},
+		"negative since":   {map[string]string{"stationIds": "9447130", "since": "-1"}, http.StatusBadRequest},
+		"unknown station":  {map[string]string{"stationIds": "9447130,0000000"}, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{Path: "/api/sync", QueryStringParameters: test.params})
+			require.NoError(t, err)
+			assert.Equal(t, test.status, response.StatusCode, response.Body)
+		})
+	}
+}
+
+func TestHandleRequest_Export(t *testing.T) {
+	original := tidesHandler.Exports
+	defer func() { tidesHandler.Exports = original }()
+	tidesHandler.Exports = nil
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/exports",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "year": "2025"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode, "exports are off without a bucket")
+
+	for _, params := range []map[string]string{
+		{"stationId": "9447130"},
+		{"stationId": "9447130", "year": "1850"},
+		{"stationId": "9447130", "year": "2025", "format": "xls"},
+	} {
+		response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{Path: "/api/exports", QueryStringParameters: params})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, params)
+	}
+
+	tidesHandler.Exports = export.NewService(export.NewStore(&memBucket{objects: map[string][]byte{}}, "exports", time.Hour),
+		&testsupport.StationFinder{Stations: []models.Station{{ID: "9447130", Name: "Seattle"}}})
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/exports",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "kind": "chart", "startDate": "2025-01-01", "endDate": "2025-01-31"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	var submitted models.TideTableExport
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &submitted))
+	assert.Equal(t, "9447130/chart-2025-01-01-2025-01-31.svg", submitted.JobID, "charts are SVG by default")
+	assert.Equal(t, models.ExportPending, submitted.Status)
+	assert.Equal(t, "/api/v2/exports/status?jobId=9447130%2Fchart-2025-01-01-2025-01-31.svg", submitted.StatusURL)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/exports/status",
+		QueryStringParameters: map[string]string{"jobId": submitted.JobID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	var polled models.TideTableExport
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &polled))
+	assert.Equal(t, submitted, polled)
+
+	for params, status := range map[string]int{
+		"9447130/2025.csv": http.StatusNotFound,
+		"9447130":          http.StatusBadRequest,
+	} {
+		response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Path:                  "/api/exports/status",
+			QueryStringParameters: map[string]string{"jobId": params},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, status, response.StatusCode, params)
+	}
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/exports",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "kind": "predictions", "startDate": "2025-01-31", "endDate": "2025-01-01"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode, "a range that ends before it starts")
+}
+
+// memBucket keeps exports and their jobs in memory
+type memBucket struct {
+	export.Bucket
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *memBucket) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	body, ok := b.objects[*params.Key]
+	if !ok {
+		return nil, &s3types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (b *memBucket) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[*params.Key] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+func TestHandleRequest_TideTable(t *testing.T) {
+	originalTideService, originalFinder := tidesHandler.Service, tidesHandler.Finder
+	defer func() { tidesHandler.Service, tidesHandler.Finder = originalTideService, originalFinder }()
+	tidesHandler.Finder = &testsupport.StationFinder{Stations: []models.Station{
+		{ID: "9447130", Name: "Seattle", TimeZone: "America/Los_Angeles"},
+	}}
+	var days []int
+	tidesHandler.Service = stubProvider{extremes: func(stationID string, d int) (*models.ExtremesSummary, error) {
+		days = append(days, d)
+		return &models.ExtremesSummary{StationID: stationID, Days: []models.DailyExtremes{
+			{Date: "2024-02-01", Extremes: []models.CompactExtreme{{Type: models.TideTypeHigh, Time: "04:12", Height: 11.5}}},
+		}}, nil
+	}}
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides/table",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "month": "2024-02"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "text/html; charset=utf-8", response.Headers["Content-Type"])
+	assert.Contains(t, response.Body, "Tide table for February 2024")
+	assert.Contains(t, response.Body, "04:12")
+	assert.Equal(t, []int{29}, days, "a leap February")
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides/table",
+		QueryStringParameters: map[string]string{"stationId": "9447130"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Contains(t, response.Body, time.Now().In(mustLoadLocation(t, "America/Los_Angeles")).Format("January 2006"), "the station's current month by default")
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides/table",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "month": "2024-13"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/tides/table",
+		QueryStringParameters: map[string]string{"stationId": "1234567"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	location, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return location
+}
+
+func TestHandleRequest_Widget(t *testing.T) {
+	original := tidesHandler.Widgets
+	defer func() { tidesHandler.Widgets = original }()
+	level, rising := 6.2, models.TideTypeRising
+	tidesHandler.Widgets = widget.NewService(stubProvider{
+		current: func(stationID string) (*models.ExtendedTideResponse, error) {
+			return &models.ExtendedTideResponse{WaterLevel: &level, TideType: &rising}, nil
+		},
+		next: func(stationID string, count int) (*models.NextExtremes, error) {
+			return &models.NextExtremes{StationID: stationID, StationName: "Seattle", Extremes: []models.TideExtreme{}}, nil
+		},
+	}, &testsupport.StationFinder{Stations: []models.Station{{ID: "9447130", Name: "Seattle"}}}, "https://app.flowebb.com/widget")
+
+	response, err := handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/widget",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "theme": "dark", "accent": "ff6600"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "public, max-age=300", response.Headers["Cache-Control"])
+	assert.Contains(t, response.Body, `"responseType":"widget"`)
+	assert.Contains(t, response.Body, `"accent":"#ff6600"`)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/widget",
+		QueryStringParameters: map[string]string{"stationId": "9447130", "theme": "neon"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/oembed",
+		QueryStringParameters: map[string]string{"url": "https://app.flowebb.com/widget?stationId=9447130", "maxwidth": "200"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "public, max-age=86400", response.Headers["Cache-Control"])
+	assert.Contains(t, response.Body, `"type":"rich"`)
+	assert.Contains(t, response.Body, `"width":200`)
+
+	// oEmbed consumers read the body as the spec defines it, so v3 doesn't envelope it
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v3/oembed",
+		QueryStringParameters: map[string]string{"url": "https://app.flowebb.com/widget?stationId=9447130"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, "3", response.Headers["API-Version"])
+	assert.True(t, strings.HasPrefix(response.Body, `{"type":"rich"`), response.Body)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/oembed",
+		QueryStringParameters: map[string]string{"url": "https://example.com/?stationId=9447130"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/oembed",
+		QueryStringParameters: map[string]string{"url": "https://app.flowebb.com/widget?stationId=9447130", "format": "xml"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotImplemented, response.StatusCode)
+}
+
+func TestHandleRequest_RateLimited(t *testing.T) {
+	original := rateLimiter
+	defer func() { rateLimiter = original }()
+	rateLimiter = ratelimit.New(1, time.Minute)
+
+	request := events.APIGatewayProxyRequest{Path: "/api/widget"}
+	request.RequestContext.Identity.SourceIP = "198.51.100.7"
+	response, err := handleRequest(context.Background(), request)
+	require.NoError(t, err)
+	assert.NotEqual(t, http.StatusTooManyRequests, response.StatusCode)
+
+	response, err = handleRequest(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "60", response.Headers["Retry-After"])
+	assert.Contains(t, response.Body, `"code":"RATE_LIMITED"`)
+}
+
+// memoryQuotaStore counts usage in memory, by key, month and day|endpoint
+type memoryQuotaStore map[string]map[string]int
+
+func (s memoryQuotaStore) Add(_ context.Context, key string, at time.Time, endpoint string, limit int) (int, error) {
+	month := s[key+at.Format("#2006-01")]
+	if month == nil {
+		month = map[string]int{}
+		s[key+at.Format("#2006-01")] = month
+	}
+	if limit > 0 && month["total"] >= limit {
+		return limit, quota.ErrExhausted
+	}
+	month["total"]++
+	month[at.Format("2006-01-02")+"|"+endpoint]++
+	return month["total"], nil
+}
+
+func (s memoryQuotaStore) Load(_ context.Context, key string, month time.Time) (map[string]int, error) {
+	return s[key+month.Format("#2006-01")], nil
+}
+
+func TestHandleRequest_Quota(t *testing.T) {
+	original := tidesHandler.Quotas
+	defer func() { tidesHandler.Quotas = original }()
+	tidesHandler.Quotas = nil
+
+	keyed := func(path string, params map[string]string) events.APIGatewayProxyRequest {
+		request := events.APIGatewayProxyRequest{Path: path, QueryStringParameters: params}
+		request.RequestContext.Identity.APIKeyID = "key-1"
+		return request
+	}
+	ctx := context.Background()
+	response, err := handleRequest(ctx, keyed("/api/usage", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode, "usage isn't reported without a quota table")
+
+	tidesHandler.Quotas = quota.NewTracker(memoryQuotaStore{}, 1, nil)
+	response, err = handleRequest(ctx, keyed("/api/widget", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode, "a rejected request still counts")
+	assert.Equal(t, "0", response.Headers[quota.RemainingHeader])
+
+	response, err = handleRequest(ctx, keyed("/api/widget", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Contains(t, response.Body, `"code":"QUOTA_EXCEEDED"`)
+	assert.NotEmpty(t, response.Headers["Retry-After"])
+	assert.NotEmpty(t, response.Headers[quota.ResetHeader])
+
+	response, err = handleRequest(ctx, keyed("/api/usage", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, "usage reports don't count against the quota")
+	var usage api.UsageResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &usage))
+	assert.Equal(t, "usage", usage.ResponseType)
+	assert.Equal(t, time.Now().UTC().Format("2006-01"), usage.Month)
+	assert.Equal(t, 1, usage.Used)
+	require.Len(t, usage.Days, 1)
+	assert.Equal(t, map[string]int{"/api/widget": 1}, usage.Days[0].Endpoints)
+
+	response, err = handleRequest(ctx, keyed("/api/usage", map[string]string{"month": "2020-01"}))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &usage))
+	assert.Equal(t, "2020-01", usage.Month)
+	assert.Zero(t, usage.Used)
+
+	response, err = handleRequest(ctx, keyed("/api/usage", map[string]string{"month": "2020-13"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = handleRequest(ctx, events.APIGatewayProxyRequest{Path: "/api/usage"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
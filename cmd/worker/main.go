@@ -0,0 +1,104 @@
+// Command worker is the Lambda function that runs the tasks the other functions defer to
+// the task queue: cache warming, exports and accuracy sampling. SQS invokes it with a
+// batch of tasks, and delivers again only those that failed and may succeed on a retry.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/handler"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/task"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	lambdaStart = lambda.Start // Allow mocking of lambda.Start in tests
+	tasks       *task.Registry
+	tideService *tide.Service
+	ready       = startup.New(initializeService)
+
+	finderFactory station.FinderFactory = &station.DefaultFinderFactory{}
+	exportBucket  export.Bucket         = export.NewS3Bucket()
+)
+
+// initializeService creates the task handlers on the first run, and again on a later one
+// if it fails
+func initializeService() error {
+	worker, err := app.BuildWorker(context.Background(), app.WithFinderFactory(finderFactory), app.WithExportBucket(exportBucket))
+	if err != nil {
+		return err
+	}
+	tasks = registerTasks(worker)
+	tideService = worker.Service
+	return nil
+}
+
+// registerTasks registers a handler for each task the worker can run. Exports are only
+// run when an export bucket is configured; without one their tasks are dropped.
+func registerTasks(worker *app.Worker) *task.Registry {
+	registry := task.NewRegistry()
+	registry.Register(task.TypeWarmCache, task.Typed(func(ctx context.Context, payload task.WarmCache) error {
+		return warmCache(ctx, worker.Service, payload)
+	}))
+	registry.Register(task.TypeSampleAccuracy, task.Typed(func(ctx context.Context, payload task.SampleAccuracy) error {
+		tracker := *worker.Tracker
+		if len(payload.Stations) > 0 {
+			tracker.Stations = payload.Stations
+		}
+		return tracker.Run(ctx)
+	}))
+	if worker.Exporter != nil {
+		registry.Register(task.TypeExport, task.Typed(func(ctx context.Context, payload task.Export) error {
+			return worker.Exporter.RunJob(ctx, payload.JobKey)
+		}))
+	}
+	return registry
+}
+
+// warmCache refetches the payload's range into the cache. An unknown station or a range
+// WarmPredictions rejects fails the same way every time, so it isn't retried.
+func warmCache(ctx context.Context, warmer handler.PredictionWarmer, payload task.WarmCache) error {
+	startDate, err := time.Parse(time.DateOnly, payload.StartDate)
+	if err != nil {
+		return task.Permanent(fmt.Errorf("startDate: %w", err))
+	}
+	endDate, err := time.Parse(time.DateOnly, payload.EndDate)
+	if err != nil {
+		return task.Permanent(fmt.Errorf("endDate: %w", err))
+	}
+
+	_, err = warmer.WarmPredictions(ctx, payload.StationID, startDate, endDate)
+	var rangeErr *tide.InvalidRangeError
+	if errors.Is(err, models.ErrStationNotFound) || errors.As(err, &rangeErr) {
+		return task.Permanent(err)
+	}
+	return err
+}
+
+// handleEvent runs the batch's tasks, reporting the ones to deliver again. The registry
+// recovers a task's panic, so it fails that task rather than the batch.
+func handleEvent(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	if err := ready.Do(); err != nil {
+		return events.SQSEventResponse{}, err
+	}
+	defer app.FlushCacheWrites(ctx, tideService)
+
+	response := tasks.HandleSQS(ctx, event)
+	log.Info().Int("tasks", len(event.Records)).Int("failed", len(response.BatchItemFailures)).Msg("Ran tasks")
+	return response, nil
+}
+
+func main() {
+	lambdaStart(handleEvent)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/app"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/bbernstein/flowebb-go/internal/task"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBucket keeps objects in memory
+type memBucket struct {
+	export.Bucket
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (b *memBucket) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	body, ok := b.objects[*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (b *memBucket) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[*params.Key] = body
+	return &s3.PutObjectOutput{}, nil
+}
+
+// extremesProvider answers with a high every day
+type extremesProvider struct {
+	models.TideProvider
+}
+
+func (extremesProvider) GetDailyExtremes(_ context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error) {
+	start, err := time.Parse(time.DateOnly, *startDate)
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.ExtremesSummary{StationID: stationID, StationName: "Seattle"}
+	for i := range days {
+		summary.Days = append(summary.Days, models.DailyExtremes{
+			Date:     start.AddDate(0, 0, i).Format(time.DateOnly),
+			Extremes: []models.CompactExtreme{{Type: models.TideTypeHigh, Time: "04:12", Height: 11.5}},
+		})
+	}
+	return summary, nil
+}
+
+// offlineObserver can't reach NOAA's gauges
+type offlineObserver struct{}
+
+func (offlineObserver) Latest(context.Context, string, observation.Product) (*models.Observation, error) {
+	return nil, errors.New("gauge offline")
+}
+
+// stubWarmer records the ranges it's asked to warm, failing for the stations in errs
+type stubWarmer struct {
+	warmed []string
+	errs   map[string]error
+}
+
+func (w *stubWarmer) WarmPredictions(_ context.Context, stationID string, startDate, endDate time.Time) (int, error) {
+	if err := w.errs[stationID]; err != nil {
+		return 0, err
+	}
+	w.warmed = append(w.warmed, stationID+" "+startDate.Format(time.DateOnly)+" "+endDate.Format(time.DateOnly))
+	return int(endDate.Sub(startDate).Hours()/24) + 1, nil
+}
+
+func message(t *testing.T, id, taskType string, payload any) events.SQSMessage {
+	t.Helper()
+	queued, err := task.New(taskType, payload, time.Now())
+	require.NoError(t, err)
+	body, err := json.Marshal(queued)
+	require.NoError(t, err)
+	return events.SQSMessage{MessageId: id, Body: string(body)}
+}
+
+// useWorker runs the handler's tasks against an in-memory export bucket, canned extremes
+// and gauges that can't be read
+func useWorker(t *testing.T) *export.Exporter {
+	require.NoError(t, ready.Do())
+	original := tasks
+	t.Cleanup(func() { tasks = original })
+	exporter := export.NewExporter(export.NewStore(&memBucket{objects: map[string][]byte{}}, "flowebb-exports", time.Hour), extremesProvider{})
+	tasks = registerTasks(&app.Worker{
+		Service:  tideService,
+		Exporter: exporter,
+		Tracker:  &accuracy.Tracker{Store: accuracy.NewMemoryStore(), Observer: offlineObserver{}, Stations: []string{"9447130"}},
+	})
+	return exporter
+}
+
+func TestHandleEvent(t *testing.T) {
+	exporter := useWorker(t)
+	req := export.Request{StationID: "9447130", Year: 2025, Format: export.FormatCSV}
+	require.NoError(t, exporter.Store.PutJob(context.Background(), &export.Job{Request: req, Status: models.ExportPending}))
+
+	response, err := handleEvent(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		message(t, "export", task.TypeExport, task.Export{JobKey: export.JobKey(req)}),
+		message(t, "accuracy", task.TypeSampleAccuracy, task.SampleAccuracy{}),
+		message(t, "webhook", "webhook.deliver", struct{}{}),
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "accuracy"}}, response.BatchItemFailures,
+		"sampling is retried while the gauge is offline, and tasks nothing handles are dropped")
+
+	job, err := exporter.Store.Job(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportComplete, job.Status)
+}
+
+func TestRegisterTasks_WithoutExports(t *testing.T) {
+	registry := registerTasks(&app.Worker{Tracker: &accuracy.Tracker{}})
+	queued, err := task.New(task.TypeExport, task.Export{JobKey: "export-jobs/9447130/2025.csv.json"}, time.Now())
+	require.NoError(t, err)
+	assert.ErrorIs(t, registry.Handle(context.Background(), queued), task.ErrPermanent, "exports are dropped without a bucket")
+}
+
+func TestWarmCache(t *testing.T) {
+	warmer := &stubWarmer{errs: map[string]error{
+		"1234567": models.ErrStationNotFound,
+		"8454000": tide.NewRangeTooLargeError("date range cannot exceed 30 days"),
+		"9414290": errors.New("NOAA timed out"),
+	}}
+	ctx := context.Background()
+
+	require.NoError(t, warmCache(ctx, warmer, task.WarmCache{StationID: "9447130", StartDate: "2024-03-01", EndDate: "2024-03-07"}))
+	assert.Equal(t, []string{"9447130 2024-03-01 2024-03-07"}, warmer.warmed)
+
+	for _, stationID := range []string{"1234567", "8454000"} {
+		err := warmCache(ctx, warmer, task.WarmCache{StationID: stationID, StartDate: "2024-03-01", EndDate: "2024-03-07"})
+		assert.ErrorIs(t, err, task.ErrPermanent, stationID)
+	}
+	err := warmCache(ctx, warmer, task.WarmCache{StationID: "9447130", StartDate: "March 1", EndDate: "2024-03-07"})
+	assert.ErrorIs(t, err, task.ErrPermanent)
+
+	err = warmCache(ctx, warmer, task.WarmCache{StationID: "9414290", StartDate: "2024-03-01", EndDate: "2024-03-07"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, task.ErrPermanent, "NOAA failures are retried")
+}
+
+func TestMain_StartsLambda(t *testing.T) {
+	original := lambdaStart
+	defer func() { lambdaStart = original }()
+	var handler interface{}
+	lambdaStart = func(h interface{}) { handler = h }
+
+	main()
+	assert.NotNil(t, handler)
+}
@@ -3,14 +3,24 @@ package graph
 import (
 	"bytes"
 	"context"
+	"errors"
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/bbernstein/flowebb-go/graph/generated"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
 	"github.com/rs/zerolog/log"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 	"net/http"
+	"runtime/debug"
 )
 
 type RequestCreator func(ctx context.Context, method, url string, body *bytes.Buffer) (*http.Request, error)
@@ -18,6 +28,9 @@ type RequestCreator func(ctx context.Context, method, url string, body *bytes.Bu
 type Handler struct {
 	srv            *handler.Server
 	requestCreator RequestCreator
+	// responses caches whole responses when set; see UseResponseCache
+	responses *cache.GraphQLCache
+	ttls      ResponseTTLs
 }
 
 func defaultRequestCreator(ctx context.Context, method, url string, body *bytes.Buffer) (*http.Request, error) {
@@ -48,8 +61,9 @@ func NewHandler(resolver *Resolver, requestCreator RequestCreator) *Handler {
 
 	// Add standard middleware
 	srv.Use(extension.Introspection{})
-	srv.SetErrorPresenter(graphql.DefaultErrorPresenter)
-	srv.SetRecoverFunc(graphql.DefaultRecover)
+	srv.SetErrorPresenter(presentError)
+	srv.SetRecoverFunc(recoverPanic)
+	srv.AroundResponses(brandResponse)
 
 	return &Handler{
 		srv:            srv,
@@ -57,6 +71,65 @@ func NewHandler(resolver *Resolver, requestCreator RequestCreator) *Handler {
 	}
 }
 
+// brandResponse adds the branding of the tenant a request is for to the response's
+// extensions, for its app to present itself with
+func brandResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	response := next(ctx)
+	if t := tenant.FromContext(ctx); t != nil && response != nil {
+		if response.Extensions == nil {
+			response.Extensions = map[string]interface{}{}
+		}
+		response.Extensions["tenant"] = t.Metadata()
+	}
+	return response
+}
+
+// recoverPanic tracks a panic in a resolver, failing only the field it was resolving
+func recoverPanic(_ context.Context, value interface{}) error {
+	return recovery.Default.Recovered(value, debug.Stack())
+}
+
+// presentError adds the error's code to its extensions, where clients can branch on it,
+// along with the offending argument for validation errors and the stations an unknown
+// station ID may have meant
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	if _, ok := gqlErr.Extensions["code"]; ok {
+		return gqlErr
+	}
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = map[string]interface{}{}
+	}
+	gqlErr.Extensions["code"] = string(errorCode(err))
+
+	var (
+		paramErr    *validate.Error
+		panicErr    *recovery.Error
+		notFoundErr *models.StationNotFoundError
+		retiredErr  *models.StationRetiredError
+	)
+	if errors.As(err, &panicErr) {
+		gqlErr.Extensions["fingerprint"] = panicErr.Fingerprint
+	}
+	if errors.As(err, &notFoundErr) && len(notFoundErr.Suggestions) > 0 {
+		gqlErr.Extensions["suggestions"] = notFoundErr.Suggestions
+	}
+	if errors.As(err, &retiredErr) {
+		gqlErr.Extensions["successor"] = models.StationSuggestion{ID: retiredErr.Successor.ID, Name: retiredErr.Successor.Name}
+	}
+	if errors.As(err, &paramErr) {
+		gqlErr.Extensions["parameter"] = paramErr.Parameter
+		gqlErr.Extensions["value"] = paramErr.Value
+		if paramErr.Allowed != "" {
+			gqlErr.Extensions["allowed"] = paramErr.Allowed
+		}
+		if paramErr.Suggestion != "" {
+			gqlErr.Extensions["suggestion"] = paramErr.Suggestion
+		}
+	}
+	return gqlErr
+}
+
 func (h *Handler) HandleRequest(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	if event.HTTPMethod == "" {
 		event.HTTPMethod = "POST"
@@ -68,6 +141,23 @@ func (h *Handler) HandleRequest(ctx context.Context, event events.APIGatewayProx
 		}, nil
 	}
 
+	ctx = feature.FromHeaders(ctx, event.Headers)
+	ctx = models.WithStationLookups(ctx)
+
+	// Identify the caller for the profile query and mutations
+	if userID := userdata.UserIDFromRequest(event); userID != "" {
+		ctx = userdata.WithUserID(ctx, userID)
+	}
+
+	key, cacheable := h.responseKey(ctx, event.Body, event.Headers)
+	var policy *responsePolicy
+	if cacheable {
+		if body, ok := h.responses.GetResponse(key); ok {
+			return jsonResponse(http.StatusOK, body), nil
+		}
+		ctx, policy = withResponsePolicy(ctx)
+	}
+
 	// Create a new request with the proper URL
 	req, err := http.NewRequestWithContext(ctx, event.HTTPMethod, "http://localhost/graphql", bytes.NewBufferString(event.Body))
 	if err != nil {
@@ -96,13 +186,20 @@ func (h *Handler) HandleRequest(ctx context.Context, event events.APIGatewayProx
 	// Handle the request
 	h.srv.ServeHTTP(w, req)
 
+	if cacheable {
+		h.storeResponse(key, policy, w.code, w.body.String())
+	}
+	return jsonResponse(w.code, w.body.String()), nil
+}
+
+func jsonResponse(status int, body string) events.APIGatewayProxyResponse {
 	return events.APIGatewayProxyResponse{
-		StatusCode: w.code,
+		StatusCode: status,
 		Headers: map[string]string{
 			"Content-Type": "application/json",
 		},
-		Body: w.body.String(),
-	}, nil
+		Body: body,
+	}
 }
 
 // responseWriter implements http.ResponseWriter
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
@@ -14,6 +17,13 @@ import (
 
 type mockTideService struct {
 	getCurrentTideForStationFn func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error)
+	getDailyExtremesFn         func(ctx context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error)
+	getNextExtremesFn          func(ctx context.Context, stationID string, count int) (*models.NextExtremes, error)
+	getTideExtremesFn          func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.TideExtremes, error)
+	getDaylightLowsFn          func(ctx context.Context, stationID string, below *float64, startDate *string, days int) (*models.DaylightLows, error)
+	compareStationsFn          func(ctx context.Context, stationIDs []string, startTimeStr, endTimeStr *string, intervalMinutes int) (*models.StationComparison, error)
+	getLatestObservationFn     func(ctx context.Context, stationID, product string) (*models.ObservationResponse, error)
+	getAccuracyFn              func(ctx context.Context, stationID string, days int) (*models.AccuracyStats, error)
 }
 
 func (m *mockTideService) GetCurrentTide(_ context.Context, _, _ float64, _, _ *string) (*models.ExtendedTideResponse, error) {
@@ -31,21 +41,55 @@ func (m *mockTideService) GetCurrentTideForStation(ctx context.Context, stationI
 	return nil, nil
 }
 
-type mockStationFinder struct {
-	findStationFn         func(ctx context.Context, stationID string) (*models.Station, error)
-	findNearestStationsFn func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error)
+func (m *mockTideService) GetDailyExtremes(ctx context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error) {
+	if m.getDailyExtremesFn != nil {
+		return m.getDailyExtremesFn(ctx, stationID, startDate, days)
+	}
+	return nil, nil
+}
+
+func (m *mockTideService) GetDailyPredictions(ctx context.Context, stationID string, startDate *string, days int) (*models.DailyPredictions, error) {
+	return nil, nil
+}
+
+func (m *mockTideService) GetNextExtremes(ctx context.Context, stationID string, count int) (*models.NextExtremes, error) {
+	if m.getNextExtremesFn != nil {
+		return m.getNextExtremesFn(ctx, stationID, count)
+	}
+	return nil, nil
+}
+
+func (m *mockTideService) GetTideExtremes(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.TideExtremes, error) {
+	if m.getTideExtremesFn != nil {
+		return m.getTideExtremesFn(ctx, stationID, startTimeStr, endTimeStr)
+	}
+	return nil, nil
+}
+
+func (m *mockTideService) GetDaylightLows(ctx context.Context, stationID string, below *float64, startDate *string, days int) (*models.DaylightLows, error) {
+	if m.getDaylightLowsFn != nil {
+		return m.getDaylightLowsFn(ctx, stationID, below, startDate, days)
+	}
+	return nil, nil
 }
 
-func (m *mockStationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
-	if m.findStationFn != nil {
-		return m.findStationFn(ctx, stationID)
+func (m *mockTideService) CompareStations(ctx context.Context, stationIDs []string, startTimeStr, endTimeStr *string, intervalMinutes int) (*models.StationComparison, error) {
+	if m.compareStationsFn != nil {
+		return m.compareStationsFn(ctx, stationIDs, startTimeStr, endTimeStr, intervalMinutes)
 	}
 	return nil, nil
 }
 
-func (m *mockStationFinder) FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
-	if m.findNearestStationsFn != nil {
-		return m.findNearestStationsFn(ctx, lat, lon, limit)
+func (m *mockTideService) GetLatestObservation(ctx context.Context, stationID, product string) (*models.ObservationResponse, error) {
+	if m.getLatestObservationFn != nil {
+		return m.getLatestObservationFn(ctx, stationID, product)
+	}
+	return nil, nil
+}
+
+func (m *mockTideService) GetAccuracy(ctx context.Context, stationID string, days int) (*models.AccuracyStats, error) {
+	if m.getAccuracyFn != nil {
+		return m.getAccuracyFn(ctx, stationID, days)
 	}
 	return nil, nil
 }
@@ -66,8 +110,8 @@ func TestHandler_HandleRequest(t *testing.T) {
 			httpMethod: "POST",
 			setupMock: func() *Resolver {
 				return &Resolver{
-					StationFinder: &mockStationFinder{
-						findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+					StationFinder: &testsupport.StationFinder{
+						FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 							return []models.Station{
 								{
 									ID:        "TEST001",
@@ -96,8 +140,8 @@ func TestHandler_HandleRequest(t *testing.T) {
 			httpMethod: "",
 			setupMock: func() *Resolver {
 				return &Resolver{
-					StationFinder: &mockStationFinder{
-						findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+					StationFinder: &testsupport.StationFinder{
+						FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 							return []models.Station{
 								{
 									ID:        "TEST001",
@@ -131,15 +175,15 @@ func TestHandler_HandleRequest(t *testing.T) {
 			httpMethod: "POST",
 			setupMock: func() *Resolver {
 				return &Resolver{
-					StationFinder: &mockStationFinder{
-						findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+					StationFinder: &testsupport.StationFinder{
+						FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 							return nil, errors.New("mock error")
 						},
 					},
 				}
 			},
 			wantCode:     200,
-			wantResponse: `{"errors":[{"message":"mock error","path":["stations"]}],"data":null}`,
+			wantResponse: `{"errors":[{"message":"mock error","path":["stations"],"extensions":{"code":"INTERNAL_ERROR"}}],"data":null}`,
 			wantErr:      false,
 		},
 		{
@@ -148,15 +192,88 @@ func TestHandler_HandleRequest(t *testing.T) {
 			httpMethod: "POST",
 			setupMock: func() *Resolver {
 				return &Resolver{
-					StationFinder: &mockStationFinder{
-						findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+					StationFinder: &testsupport.StationFinder{
+						FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 							return nil, errors.New("mock error")
 						},
 					},
 				}
 			},
 			wantCode:     200,
-			wantResponse: `{"errors":[{"message":"mock error","path":["stations"]}],"data":null}`,
+			wantResponse: `{"errors":[{"message":"mock error","path":["stations"],"extensions":{"code":"INTERNAL_ERROR"}}],"data":null}`,
+			wantErr:      false,
+		},
+		{
+			name:       "invalid arguments",
+			query:      `{"query": "query { stations(limit: 2) { id } }"}`,
+			httpMethod: "POST",
+			setupMock: func() *Resolver {
+				return &Resolver{StationFinder: &testsupport.StationFinder{}}
+			},
+			wantCode:     200,
+			wantResponse: `{"errors":[{"message":"lat and lon are required","path":["stations"],"extensions":{"code":"INVALID_REQUEST"}}],"data":null}`,
+			wantErr:      false,
+		},
+		{
+			name:       "argument out of range",
+			query:      `{"query": "query { nearbyStations(lat: 47.6, lon: -122.3, first: 0) { totalCount } }"}`,
+			httpMethod: "POST",
+			setupMock: func() *Resolver {
+				return &Resolver{StationFinder: &testsupport.StationFinder{}}
+			},
+			wantCode:     200,
+			wantResponse: `{"errors":[{"message":"invalid first \"0\": must be at least 1","path":["nearbyStations"],"extensions":{"allowed":"1 to 100","code":"INVALID_REQUEST","parameter":"first","value":"0"}}],"data":null}`,
+			wantErr:      false,
+		},
+		{
+			name:       "unknown station",
+			query:      `{"query": "query { observation(stationId: \"9999999\", product: \"water_temperature\") { stationId } }"}`,
+			httpMethod: "POST",
+			setupMock: func() *Resolver {
+				return &Resolver{TideService: &mockTideService{
+					getLatestObservationFn: func(ctx context.Context, stationID, product string) (*models.ObservationResponse, error) {
+						return nil, fmt.Errorf("finding station: %w", fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID))
+					},
+				}}
+			},
+			wantCode:     200,
+			wantResponse: `{"errors":[{"message":"finding station: station not found: 9999999","path":["observation"],"extensions":{"code":"STATION_NOT_FOUND"}}],"data":null}`,
+			wantErr:      false,
+		},
+		{
+			name:       "unknown station with suggestions",
+			query:      `{"query": "query { observation(stationId: \"9447131\", product: \"water_temperature\") { stationId } }"}`,
+			httpMethod: "POST",
+			setupMock: func() *Resolver {
+				return &Resolver{TideService: &mockTideService{
+					getLatestObservationFn: func(ctx context.Context, stationID, product string) (*models.ObservationResponse, error) {
+						return nil, fmt.Errorf("finding station: %w", &models.StationNotFoundError{
+							StationID:   stationID,
+							Suggestions: []models.StationSuggestion{{ID: "9447130", Name: "Seattle"}},
+						})
+					},
+				}}
+			},
+			wantCode:     200,
+			wantResponse: `{"errors":[{"message":"finding station: station not found: 9447131","path":["observation"],"extensions":{"code":"STATION_NOT_FOUND","suggestions":[{"id":"9447130","name":"Seattle"}]}}],"data":null}`,
+			wantErr:      false,
+		},
+		{
+			name:       "retired station",
+			query:      `{"query": "query { observation(stationId: \"9447110\", product: \"water_temperature\") { stationId } }"}`,
+			httpMethod: "POST",
+			setupMock: func() *Resolver {
+				return &Resolver{TideService: &mockTideService{
+					getLatestObservationFn: func(ctx context.Context, stationID, product string) (*models.ObservationResponse, error) {
+						return nil, fmt.Errorf("finding station: %w", &models.StationRetiredError{
+							StationID: stationID,
+							Successor: models.Station{ID: "9447130", Name: "Seattle"},
+						})
+					},
+				}}
+			},
+			wantCode:     200,
+			wantResponse: `{"errors":[{"message":"finding station: station 9447110 was retired; its successor is 9447130 (Seattle)","path":["observation"],"extensions":{"code":"STATION_RETIRED","successor":{"id":"9447130","name":"Seattle"}}}],"data":null}`,
 			wantErr:      false,
 		},
 	}
@@ -184,6 +301,28 @@ func TestHandler_HandleRequest(t *testing.T) {
 	}
 }
 
+func TestHandler_UserIdentity(t *testing.T) {
+	resolver := &Resolver{
+		UserData: userdata.NewService(&memoryProfileStore{profiles: map[string]models.UserProfile{}}, &testsupport.StationFinder{}),
+	}
+	handler := NewHandler(resolver, nil)
+	query := `{"query": "query { me { userId units } }"}`
+
+	response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Body:       query,
+		HTTPMethod: "POST",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{APIKeyID: "key-1"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"me":{"userId":"apikey:key-1","units":"english"}}}`, response.Body)
+
+	response, err = handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{Body: query, HTTPMethod: "POST"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"errors":[{"message":"authentication required: send a Cognito token or API key","path":["me"],"extensions":{"code":"UNAUTHENTICATED"}}],"data":null}`, response.Body)
+}
+
 func TestHandler_NewRequestWithContextError(t *testing.T) {
 	mockRequestCreator := func(ctx context.Context, method, url string, body *bytes.Buffer) (*http.Request, error) {
 		return nil, errors.New("mock error")
@@ -201,5 +340,6 @@ func TestHandler_NewRequestWithContextError(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Equal(t, 200, response.StatusCode)
-	assert.Contains(t, response.Body, "internal system error")
+	assert.Contains(t, response.Body, "internal error (fingerprint ")
+	assert.Contains(t, response.Body, `"code":"INTERNAL_ERROR","fingerprint":"`)
 }
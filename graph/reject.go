@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"strconv"
+	"time"
+)
+
+// Reject answers a request that arrives before the handler could be initialized, beyond
+// its client's rate limit or API key's quota, reusing an idempotency key or that panicked
+// outside a resolver, in the shape of a GraphQL error
+func Reject(err error) (events.APIGatewayProxyResponse, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	var (
+		notReadyErr *startup.NotReadyError
+		limitedErr  *ratelimit.Error
+		quotaErr    *quota.Error
+	)
+	code := api.CodeFor(err)
+	extensions := map[string]interface{}{"code": string(code)}
+	switch {
+	case errors.As(err, &notReadyErr):
+		headers["Retry-After"] = strconv.Itoa(notReadyErr.RetryAfterSeconds())
+	case errors.As(err, &limitedErr):
+		headers["Retry-After"] = strconv.Itoa(limitedErr.RetryAfterSeconds())
+	case errors.As(err, &quotaErr):
+		headers["Retry-After"] = strconv.Itoa(quotaErr.RetryAfterSeconds())
+		headers[quota.ResetHeader] = quotaErr.ResetsAt.Format(time.RFC3339)
+		extensions["resetsAt"] = models.MillisOf(quotaErr.ResetsAt)
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"errors": gqlerror.List{{
+			Message:    err.Error(),
+			Extensions: extensions,
+		}},
+	})
+	return events.APIGatewayProxyResponse{
+		StatusCode: api.StatusFor(code),
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
@@ -0,0 +1,22 @@
+package graph
+
+import (
+	"fmt"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReject_QuotaExceeded(t *testing.T) {
+	resetsAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	response, err := Reject(&quota.Error{Quota: 100, ResetsAt: resetsAt})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.NotEmpty(t, response.Headers["Retry-After"])
+	assert.Equal(t, resetsAt.Format(time.RFC3339), response.Headers[quota.ResetHeader])
+	assert.Contains(t, response.Body, `"code":"QUOTA_EXCEEDED"`)
+	assert.Contains(t, response.Body, fmt.Sprintf(`"resetsAt":%d`, resetsAt.UnixMilli()))
+}
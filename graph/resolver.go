@@ -1,15 +1,794 @@
 package graph
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/bbernstein/flowebb-go/graph/generated"
+	"github.com/bbernstein/flowebb-go/graph/model"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/geo"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/reports"
+	"github.com/bbernstein/flowebb-go/internal/stationchange"
 	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/timefmt"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
 )
 
 type Resolver struct {
-	TideService   tide.TideService
+	TideService   models.TideProvider
 	StationFinder models.StationFinder
+	// StationLimits bound the stations and nearbyStations limits; the zero value uses the
+	// package defaults
+	StationLimits models.StationLimits
+	// UserData serves the profile query and mutations; nil disables them
+	UserData *userdata.Service
+	// Exports serves the exportTideTable mutation; nil disables it
+	Exports *export.Service
+	// Reports serves the reportObservation mutation; nil disables it
+	Reports *reports.Service
+	// StationChanges serves the stationChanges query; nil disables it
+	StationChanges stationchange.Store
 }
 
 // Ensure Resolver implements the ResolverRoot interface
 var _ generated.ResolverRoot = &Resolver{}
+
+var (
+	errUnauthenticated  = errors.New("authentication required: send a Cognito token or API key")
+	errUserDataDisabled = errors.New("user data is not configured")
+	errExportsDisabled  = errors.New("exports are not configured")
+	errReportsDisabled  = errors.New("observation reports are not configured")
+	errChangesDisabled  = errors.New("station changes are not recorded")
+)
+
+// argumentError is a resolver error caused by the query's arguments
+type argumentError struct {
+	err error
+}
+
+func (e argumentError) Error() string {
+	return e.err.Error()
+}
+
+func (e argumentError) Unwrap() error {
+	return e.err
+}
+
+// errorCode is the code the error presenter adds to a resolver error's extensions
+func errorCode(err error) api.ErrorCode {
+	var argErr argumentError
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		return api.CodeUnauthenticated
+	case errors.Is(err, errUserDataDisabled), errors.Is(err, errExportsDisabled), errors.Is(err, errReportsDisabled), errors.Is(err, errChangesDisabled):
+		return api.CodeForbidden
+	case errors.As(err, &argErr):
+		// Validation errors from the models keep their more specific codes
+		if code := api.CodeFor(argErr.err); code != api.CodeInternal {
+			return code
+		}
+		return api.CodeInvalidRequest
+	default:
+		return api.CodeFor(err)
+	}
+}
+
+// userData returns the user data service and the caller's user ID, which the profile
+// query and mutations all need
+func (r *Resolver) userData(ctx context.Context) (*userdata.Service, string, error) {
+	if r.UserData == nil {
+		return nil, "", errUserDataDisabled
+	}
+	userID, ok := userdata.UserIDFromContext(ctx)
+	if !ok {
+		return nil, "", errUnauthenticated
+	}
+	return r.UserData, userID, nil
+}
+
+// reports returns the reports service and the caller's user ID, which reports are
+// attributed to and rate limited by
+func (r *Resolver) reports(ctx context.Context) (*reports.Service, string, error) {
+	if r.Reports == nil {
+		return nil, "", errReportsDisabled
+	}
+	userID, ok := userdata.UserIDFromContext(ctx)
+	if !ok {
+		return nil, "", errUnauthenticated
+	}
+	return r.Reports, userID, nil
+}
+
+// parseDistanceUnit reads the optional distanceUnit argument of the station queries
+func parseDistanceUnit(distanceUnit *string) (models.DistanceUnit, error) {
+	if distanceUnit == nil {
+		return models.DistanceKilometers, nil
+	}
+	unit, err := models.ParseDistanceUnit(*distanceUnit)
+	if err != nil {
+		return "", argumentError{err}
+	}
+	return unit, nil
+}
+
+// stationFilter builds a filter from the optional stationType, capability and source
+// arguments of the station queries
+func stationFilter(stationType, capability, source *string) (models.StationFilter, error) {
+	var filter models.StationFilter
+	if stationType != nil {
+		filter.StationType = *stationType
+	}
+	if capability != nil {
+		filter.Capability = *capability
+	}
+	if source != nil {
+		filter.Source = models.Source(*source)
+	}
+	if err := filter.Validate(); err != nil {
+		return filter, argumentError{err}
+	}
+	return filter, nil
+}
+
+// maxStationIDs caps how many stations the stations query looks up by ID
+const maxStationIDs = 100
+
+// stationsByID looks up the stations query's ids in order, leaving out those that aren't
+// stations or don't pass filter
+func (r *Resolver) stationsByID(ctx context.Context, ids []string, filter models.StationFilter, unit models.DistanceUnit) ([]*model.Station, error) {
+	if len(ids) > maxStationIDs {
+		return nil, argumentError{fmt.Errorf("ids can list at most %d stations", maxStationIDs)}
+	}
+	for _, id := range ids {
+		if err := validate.StationID("ids", id); err != nil {
+			return nil, argumentError{err}
+		}
+	}
+
+	stations := make([]models.Station, 0, len(ids))
+	for _, id := range ids {
+		station, err := models.FindStation(ctx, r.StationFinder, id)
+		if errors.Is(err, models.ErrStationNotFound) || (err == nil && station == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(*station) {
+			stations = append(stations, *station)
+		}
+	}
+
+	result := make([]*model.Station, len(stations))
+	for i, s := range models.WithDistanceUnit(stations, unit) {
+		result[i] = toStation(s)
+	}
+	return result, nil
+}
+
+// regions groups the stations by state and region, only state's when it's given
+func (r *Resolver) regions(ctx context.Context, state *string, filter models.StationFilter) ([]*model.StationRegion, error) {
+	finder, ok := r.StationFinder.(models.RegionFinder)
+	if !ok {
+		return nil, fmt.Errorf("the station finder can't list stations by region")
+	}
+	var stateFilter string
+	if state != nil {
+		stateFilter = *state
+	}
+
+	regions, err := finder.FindRegions(ctx, filter, stateFilter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*model.StationRegion, len(regions))
+	for i, region := range regions {
+		representatives := make([]*model.Station, len(region.Representatives))
+		for j, s := range region.Representatives {
+			representatives[j] = toStation(s)
+		}
+		result[i] = &model.StationRegion{
+			StationCount:    region.StationCount,
+			Representatives: representatives,
+		}
+		if region.State != "" {
+			result[i].State = &region.State
+		}
+		if region.Region != "" {
+			result[i].Region = &region.Region
+		}
+	}
+	return result, nil
+}
+
+// stationMap lists the stations in bounds, grouping those that overlap at zoom into
+// clusters when a zoom is given
+func (r *Resolver) stationMap(ctx context.Context, bounds geo.Bounds, zoom *int, filter models.StationFilter) (*model.StationMap, error) {
+	finder, ok := r.StationFinder.(models.BoundsFinder)
+	if !ok {
+		return nil, fmt.Errorf("the station finder can't list stations by bounds")
+	}
+	if zoom != nil {
+		if err := validate.Between("zoom", float64(*zoom), 0, geo.MaxZoom); err != nil {
+			return nil, argumentError{err}
+		}
+	}
+
+	stations, err := finder.FindStationsInBounds(ctx, bounds, filter)
+	if err != nil {
+		return nil, err
+	}
+	stations = models.WithDistanceUnit(stations, models.DistanceKilometers)
+
+	result := &model.StationMap{Stations: []*model.Station{}, Clusters: []*model.StationCluster{}, TotalCount: len(stations)}
+	if zoom == nil {
+		for _, s := range stations {
+			result.Stations = append(result.Stations, toStation(s))
+		}
+		return result, nil
+	}
+
+	lats := make([]float64, len(stations))
+	lons := make([]float64, len(stations))
+	for i, s := range stations {
+		lats[i], lons[i] = s.Latitude, s.Longitude
+	}
+	for _, c := range geo.GridClusters(lats, lons, *zoom) {
+		if len(c.Members) == 1 {
+			result.Stations = append(result.Stations, toStation(stations[c.Members[0]]))
+			continue
+		}
+		result.Clusters = append(result.Clusters, &model.StationCluster{
+			Latitude:  c.Latitude,
+			Longitude: c.Longitude,
+			Count:     len(c.Members),
+			North:     c.Bounds.North,
+			South:     c.Bounds.South,
+			East:      c.Bounds.East,
+			West:      c.Bounds.West,
+		})
+	}
+	return result, nil
+}
+
+// Defaults and caps of the stationChanges query
+const (
+	defaultStationChanges = 20
+	maxStationChanges     = 100
+)
+
+// stationChanges lists the station list's changes detected since, a YYYY-MM-DD day
+func (r *Resolver) stationChanges(ctx context.Context, since *string, limit *int) ([]*model.StationListChange, error) {
+	if r.StationChanges == nil {
+		return nil, errChangesDisabled
+	}
+	var sinceDay time.Time
+	if since != nil {
+		day, err := validate.Date("since", *since, time.UTC)
+		if err != nil {
+			return nil, argumentError{err}
+		}
+		sinceDay = day
+	}
+	n := defaultStationChanges
+	if limit != nil {
+		if err := validate.Between("limit", float64(*limit), 1, maxStationChanges); err != nil {
+			return nil, argumentError{err}
+		}
+		n = *limit
+	}
+
+	changes, err := r.StationChanges.List(ctx, models.SourceNOAA, sinceDay, n)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*model.StationListChange, len(changes))
+	for i, c := range changes {
+		result[i] = toStationListChange(c)
+	}
+	return result, nil
+}
+
+const cursorPrefix = "station:"
+
+// encodeCursor returns the opaque cursor of the station at index in the nearest-first list
+func encodeCursor(index int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(index)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil {
+		digits, ok := strings.CutPrefix(string(decoded), cursorPrefix)
+		if index, err := strconv.Atoi(digits); ok && err == nil && index >= 0 {
+			return index, nil
+		}
+	}
+	return 0, argumentError{fmt.Errorf("invalid cursor %q", cursor)}
+}
+
+// withTimeZone applies a tz argument, the zone date-times without an offset are read in
+func withTimeZone(ctx context.Context, tz *string) (context.Context, error) {
+	if tz == nil {
+		return ctx, nil
+	}
+	location, err := validate.TimeZone("tz", *tz)
+	if err != nil {
+		return ctx, argumentError{err}
+	}
+	return tide.WithTimeZone(ctx, location), nil
+}
+
+// newTimeFormat reads the locale and hour12 arguments, which choose how localTime strings
+// are written
+func newTimeFormat(locale *string, hour12 *bool) (timefmt.Options, error) {
+	var tag string
+	if locale != nil {
+		tag = *locale
+	}
+	options, err := timefmt.New(tag, hour12)
+	if err != nil {
+		return options, argumentError{err}
+	}
+	return options, nil
+}
+
+func toStation(s models.Station) *model.Station {
+	var timeZone *string
+	if s.TimeZone != "" {
+		timeZone = &s.TimeZone
+	}
+	stationType := string(s.Kind())
+	return &model.Station{
+		ID:             s.ID,
+		Name:           s.Name,
+		State:          s.State,
+		Region:         s.Region,
+		Distance:       s.Distance,
+		DistanceUnit:   string(s.DistanceUnit),
+		Bearing:        s.Bearing,
+		Latitude:       s.Latitude,
+		Longitude:      s.Longitude,
+		Source:         string(s.Source),
+		Capabilities:   s.Capabilities,
+		TimeZoneOffset: s.TimeZoneOffset,
+		TimeZone:       timeZone,
+		StationType:    &stationType,
+	}
+}
+
+func toUserProfile(p *models.UserProfile) *model.UserProfile {
+	return &model.UserProfile{
+		UserID:           p.UserID,
+		Favorites:        toFavoriteStations(p.Favorites),
+		DeletedFavorites: toFavoriteStations(p.DeletedFavorites),
+		Units:            p.Units,
+		Datum:            p.Datum,
+		UpdatedAt:        int(p.UpdatedAt),
+	}
+}
+
+func toFavoriteStations(favorites []models.FavoriteStation) []*model.FavoriteStation {
+	result := make([]*model.FavoriteStation, len(favorites))
+	for i, f := range favorites {
+		result[i] = &model.FavoriteStation{
+			StationID: f.StationID,
+			Name:      f.Name,
+			AddedAt:   int(f.CreatedAt),
+			UpdatedAt: int(f.UpdatedAt),
+		}
+		if f.Deleted() {
+			deletedAt := int(f.DeletedAt)
+			result[i].DeletedAt = &deletedAt
+		}
+	}
+	return result
+}
+
+func toExtremesSummary(s *models.ExtremesSummary) *model.ExtremesSummary {
+	days := make([]*model.DailyExtremes, len(s.Days))
+	for i, day := range s.Days {
+		extremes := make([]*model.CompactExtreme, len(day.Extremes))
+		for j, e := range day.Extremes {
+			extremes[j] = &model.CompactExtreme{
+				Type:      string(e.Type),
+				Time:      e.Time,
+				Timestamp: int(e.Timestamp),
+				Height:    e.Height,
+			}
+		}
+		days[i] = &model.DailyExtremes{Date: day.Date, Extremes: extremes}
+	}
+
+	var timeZone *string
+	if s.TimeZone != "" {
+		timeZone = &s.TimeZone
+	}
+	return &model.ExtremesSummary{
+		StationID:   s.StationID,
+		StationName: s.StationName,
+		TimeZone:    timeZone,
+		Days:        days,
+	}
+}
+
+func toDaylightLows(d *models.DaylightLows) *model.DaylightLows {
+	days := make([]*model.DaylightDay, len(d.Days))
+	for i, day := range d.Days {
+		lows := make([]*model.CompactExtreme, len(day.Lows))
+		for j, e := range day.Lows {
+			lows[j] = &model.CompactExtreme{
+				Type:      string(e.Type),
+				Time:      e.Time,
+				Timestamp: int(e.Timestamp),
+				Height:    e.Height,
+			}
+		}
+		days[i] = &model.DaylightDay{
+			Date:            day.Date,
+			Sunrise:         millisPtr(day.Sunrise),
+			Sunset:          millisPtr(day.Sunset),
+			DaylightMinutes: day.DaylightMinutes,
+			Lows:            lows,
+		}
+	}
+
+	var timeZone *string
+	if d.TimeZone != "" {
+		timeZone = &d.TimeZone
+	}
+	return &model.DaylightLows{
+		StationID:   d.StationID,
+		StationName: d.StationName,
+		TimeZone:    timeZone,
+		Below:       d.Below,
+		Days:        days,
+	}
+}
+
+func toNextExtremes(n *models.NextExtremes) *model.NextExtremes {
+	extremes := make([]*model.TideExtreme, len(n.Extremes))
+	for i, e := range n.Extremes {
+		extremes[i] = toTideExtreme(e)
+	}
+
+	var timeZone *string
+	if n.TimeZone != "" {
+		timeZone = &n.TimeZone
+	}
+	return &model.NextExtremes{
+		StationID:   n.StationID,
+		StationName: n.StationName,
+		TimeZone:    timeZone,
+		Extremes:    extremes,
+	}
+}
+
+func toStationComparison(c *models.StationComparison) *model.StationComparison {
+	timestamps := make([]int, len(c.Timestamps))
+	for i, t := range c.Timestamps {
+		timestamps[i] = int(t)
+	}
+	stations := make([]*model.ComparedStation, len(c.Stations))
+	for i, s := range c.Stations {
+		stations[i] = &model.ComparedStation{
+			ID:         s.ID,
+			Name:       s.Name,
+			Heights:    s.Heights,
+			LagMinutes: s.LagMinutes,
+			RangeRatio: s.RangeRatio,
+		}
+	}
+	return &model.StationComparison{
+		IntervalMinutes: c.IntervalMinutes,
+		Timestamps:      timestamps,
+		Stations:        stations,
+	}
+}
+
+func toTideExtremes(t *models.TideExtremes) *model.TideExtremes {
+	extremes := make([]*model.TideExtreme, len(t.Extremes))
+	for i, e := range t.Extremes {
+		extremes[i] = toTideExtreme(e)
+	}
+
+	var timeZone *string
+	if t.TimeZone != "" {
+		timeZone = &t.TimeZone
+	}
+	return &model.TideExtremes{
+		StationID:   t.StationID,
+		StationName: t.StationName,
+		TimeZone:    timeZone,
+		Extremes:    extremes,
+	}
+}
+
+// toTideData maps a tide response to GraphQL's. Every field of the REST response must
+// reach it; TestToTideData_FieldParity fails for one that doesn't.
+func toTideData(response *models.ExtendedTideResponse) *model.TideData {
+	predictions := make([]*model.TidePrediction, len(response.Predictions))
+	for i, p := range response.Predictions {
+		predictions[i] = &model.TidePrediction{
+			Timestamp: int(p.Timestamp),
+			LocalTime: p.LocalTime,
+			Height:    p.Height,
+		}
+	}
+
+	extremes := make([]*model.TideExtreme, len(response.Extremes))
+	for i, e := range response.Extremes {
+		extremes[i] = toTideExtreme(e)
+	}
+
+	var tideType string
+	if response.TideType != nil {
+		tideType = string(*response.TideType)
+	}
+
+	var waterLevel, predictedLevel float64
+	if response.WaterLevel != nil {
+		waterLevel = *response.WaterLevel
+	}
+	if response.PredictedLevel != nil {
+		predictedLevel = *response.PredictedLevel
+	}
+
+	tzOffset := 0
+	if response.TimeZoneOffsetSeconds != nil {
+		tzOffset = *response.TimeZoneOffsetSeconds
+	}
+
+	return &model.TideData{
+		Timestamp:             int(response.Timestamp),
+		LocalTime:             response.LocalTime,
+		WaterLevel:            waterLevel,
+		PredictedLevel:        predictedLevel,
+		NearestStation:        response.NearestStation,
+		Location:              response.Location,
+		Latitude:              response.Latitude,
+		Longitude:             response.Longitude,
+		StationDistance:       response.StationDistance,
+		TideType:              tideType,
+		CalculationMethod:     response.CalculationMethod,
+		Predictions:           predictions,
+		Extremes:              extremes,
+		TimeZoneOffsetSeconds: tzOffset,
+		Summary:               toTideSummary(response.Summary),
+		Astronomy:             toTideAstronomy(response.Astronomy),
+		Conditions:            toWaterConditions(response.Conditions),
+		Weather:               toMarineWeather(response.Weather),
+		Confidence:            toPredictionConfidence(response.Confidence),
+		Offsets:               toTideOffsets(response.Offsets),
+		Anomaly:               toWaterLevelAnomaly(response.Anomaly),
+		Warnings:              toResponseWarnings(response.Warnings),
+	}
+}
+
+func toTideExtreme(e models.TideExtreme) *model.TideExtreme {
+	return &model.TideExtreme{
+		Type:      string(e.Type),
+		Timestamp: int(e.Timestamp),
+		LocalTime: e.LocalTime,
+		Height:    e.Height,
+	}
+}
+
+func toTideAstronomy(a *models.TideAstronomy) *model.TideAstronomy {
+	if a == nil {
+		return nil
+	}
+	return &model.TideAstronomy{
+		TideCycle:         a.TideCycle,
+		MoonPhase:         a.MoonPhase,
+		DaysSinceNewMoon:  a.DaysSinceNewMoon,
+		DaysSinceFullMoon: a.DaysSinceFullMoon,
+	}
+}
+
+func toWaterConditions(c *models.WaterConditions) *model.WaterConditions {
+	if c == nil {
+		return nil
+	}
+	return &model.WaterConditions{
+		WaterTemperature: toObservation(c.WaterTemperature),
+		Conductivity:     toObservation(c.Conductivity),
+	}
+}
+
+func toPredictionConfidence(c *models.PredictionConfidence) *model.PredictionConfidence {
+	if c == nil {
+		return nil
+	}
+	return &model.PredictionConfidence{
+		Level:             c.Level,
+		MeanAbsoluteError: c.MeanAbsoluteError,
+		Bias:              c.Bias,
+		Samples:           c.Samples,
+		Days:              c.Days,
+	}
+}
+
+func toTideOffsets(o *models.TideOffsets) *model.TideOffsets {
+	if o == nil {
+		return nil
+	}
+	var referenceName *string
+	if o.ReferenceStationName != "" {
+		referenceName = &o.ReferenceStationName
+	}
+	return &model.TideOffsets{
+		ReferenceStationID:    o.ReferenceStationID,
+		ReferenceStationName:  referenceName,
+		TimeOffsetHighMinutes: o.TimeOffsetHighMinutes,
+		TimeOffsetLowMinutes:  o.TimeOffsetLowMinutes,
+		HeightOffsetHigh:      o.HeightOffsetHigh,
+		HeightOffsetLow:       o.HeightOffsetLow,
+		HeightAdjustment:      o.HeightAdjustment,
+	}
+}
+
+func toWaterLevelAnomaly(a *models.WaterLevelAnomaly) *model.WaterLevelAnomaly {
+	if a == nil {
+		return nil
+	}
+	return &model.WaterLevelAnomaly{
+		Detected:       a.Detected,
+		Residual:       a.Residual,
+		Threshold:      a.Threshold,
+		ObservedLevel:  a.ObservedLevel,
+		PredictedLevel: a.PredictedLevel,
+		Timestamp:      int(a.Timestamp),
+		LocalTime:      a.LocalTime,
+	}
+}
+
+func toAccuracyStats(s *models.AccuracyStats) *model.AccuracyStats {
+	var confidence *string
+	if s.Confidence != "" {
+		confidence = &s.Confidence
+	}
+	return &model.AccuracyStats{
+		StationID:         s.StationID,
+		StationName:       s.StationName,
+		Days:              s.Days,
+		Samples:           s.Samples,
+		MeanAbsoluteError: s.MeanAbsoluteError,
+		Bias:              s.Bias,
+		Confidence:        confidence,
+	}
+}
+
+func toTideTableExport(e *models.TideTableExport) *model.TideTableExport {
+	return &model.TideTableExport{
+		StationID:   e.StationID,
+		Year:        e.Year,
+		Format:      e.Format,
+		Status:      e.Status,
+		DownloadURL: e.DownloadURL,
+		ExpiresAt:   millisPtr(e.ExpiresAt),
+		Error:       e.Error,
+	}
+}
+
+func toStationListChange(c models.StationListChange) *model.StationListChange {
+	stations := make([]*model.StationChange, len(c.Stations))
+	for i, s := range c.Stations {
+		fields := make([]*model.StationFieldChange, len(s.Fields))
+		for j, f := range s.Fields {
+			fields[j] = &model.StationFieldChange{Field: f.Field}
+			if f.Before != "" {
+				fields[j].Before = &f.Before
+			}
+			if f.After != "" {
+				fields[j].After = &f.After
+			}
+		}
+		stations[i] = &model.StationChange{StationID: s.StationID, Name: s.Name, Change: s.Change, Fields: fields}
+	}
+	return &model.StationListChange{
+		ID:         c.ChangeID,
+		Source:     string(c.Source),
+		DetectedAt: int(c.DetectedAt),
+		Stations:   stations,
+		Omitted:    c.Omitted,
+	}
+}
+
+// millisPtr converts an optional timestamp to GraphQL's Int
+func millisPtr(m *models.Millis) *int {
+	if m == nil {
+		return nil
+	}
+	millis := int(*m)
+	return &millis
+}
+
+func toObservationReport(r *models.ObservationReport) *model.ObservationReport {
+	report := &model.ObservationReport{
+		ID:             r.ReportID,
+		StationID:      r.StationID,
+		Timestamp:      int(r.Timestamp),
+		ObservedHeight: r.ObservedHeight,
+		SubmittedAt:    int(r.SubmittedAt),
+	}
+	if r.Condition != "" {
+		report.Condition = &r.Condition
+	}
+	if r.PhotoURL != "" {
+		report.PhotoURL = &r.PhotoURL
+	}
+	return report
+}
+
+func toObservation(o *models.Observation) *model.Observation {
+	if o == nil {
+		return nil
+	}
+	return &model.Observation{
+		Timestamp: int(o.Timestamp),
+		LocalTime: o.LocalTime,
+		Value:     o.Value,
+		Units:     o.Units,
+	}
+}
+
+func toMarineWeather(w *models.MarineWeather) *model.MarineWeather {
+	if w == nil {
+		return nil
+	}
+	forecast := make([]*model.WeatherForecast, len(w.Forecast))
+	for i, f := range w.Forecast {
+		forecast[i] = &model.WeatherForecast{
+			Timestamp:            int(f.Timestamp),
+			LocalTime:            f.LocalTime,
+			WindSpeedKnots:       f.WindSpeedKnots,
+			WindGustKnots:        f.WindGustKnots,
+			WindDirectionDegrees: f.WindDirectionDegrees,
+			PressureHpa:          f.PressureHpa,
+		}
+	}
+	return &model.MarineWeather{
+		Source:    w.Source,
+		Available: w.Available,
+		Forecast:  forecast,
+	}
+}
+
+func toResponseWarnings(warnings []models.ResponseWarning) []*model.ResponseWarning {
+	converted := make([]*model.ResponseWarning, len(warnings))
+	for i, w := range warnings {
+		converted[i] = &model.ResponseWarning{Code: w.Code, Message: w.Message}
+	}
+	return converted
+}
+
+func toTideSummary(s *models.TideSummary) *model.TideSummary {
+	if s == nil {
+		return nil
+	}
+	summary := &model.TideSummary{CycleElapsedPercent: s.CycleElapsedPercent}
+	if s.NextHigh != nil {
+		summary.NextHigh = toTideExtreme(*s.NextHigh)
+	}
+	if s.NextLow != nil {
+		summary.NextLow = toTideExtreme(*s.NextLow)
+	}
+	if s.Trend != nil {
+		trend := string(*s.Trend)
+		summary.Trend = &trend
+	}
+	if s.TodayRange != nil {
+		summary.TodayRange = &model.TideRange{Low: s.TodayRange.Low, High: s.TodayRange.High}
+	}
+	return summary
+}
@@ -2,12 +2,21 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/bbernstein/flowebb-go/graph/model"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/export"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/reports"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestResolver_Stations(t *testing.T) {
@@ -16,6 +25,7 @@ func TestResolver_Stations(t *testing.T) {
 		lat       float64
 		lon       float64
 		limit     *int
+		unit      *string
 		setupMock func() *Resolver
 		want      []*model.Station
 		wantErr   bool
@@ -27,14 +37,16 @@ func TestResolver_Stations(t *testing.T) {
 			limit: func() *int { limit := 2; return &limit }(),
 			setupMock: func() *Resolver {
 				return &Resolver{
-					StationFinder: &mockStationFinder{
-						findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+					StationFinder: &testsupport.StationFinder{
+						FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 							return []models.Station{
 								{
 									ID:        "TEST001",
 									Name:      "Test Station 1",
 									Latitude:  lat,
 									Longitude: lon,
+									Distance:  3.704,
+									Bearing:   func() *float64 { b := 90.0; return &b }(),
 								},
 							}, nil
 						},
@@ -43,14 +55,43 @@ func TestResolver_Stations(t *testing.T) {
 			},
 			want: []*model.Station{
 				{
-					ID:        "TEST001",
-					Name:      "Test Station 1",
-					Latitude:  47.6062,
-					Longitude: -122.3321,
+					ID:           "TEST001",
+					Name:         "Test Station 1",
+					Latitude:     47.6062,
+					Longitude:    -122.3321,
+					Distance:     3.704,
+					DistanceUnit: "km",
+					Bearing:      func() *float64 { b := 90.0; return &b }(),
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "distance in nautical miles",
+			lat:  47.6062,
+			lon:  -122.3321,
+			unit: func() *string { unit := "nmi"; return &unit }(),
+			setupMock: func() *Resolver {
+				return &Resolver{
+					StationFinder: &testsupport.StationFinder{
+						FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+							return []models.Station{{ID: "TEST001", Latitude: lat, Longitude: lon, Distance: 3.704}}, nil
+						},
+					},
+				}
+			},
+			want: []*model.Station{
+				{ID: "TEST001", Latitude: 47.6062, Longitude: -122.3321, Distance: 2, DistanceUnit: "nmi"},
+			},
+		},
+		{
+			name:      "unknown distance unit",
+			lat:       47.6062,
+			lon:       -122.3321,
+			unit:      func() *string { unit := "leagues"; return &unit }(),
+			setupMock: func() *Resolver { return &Resolver{StationFinder: &testsupport.StationFinder{}} },
+			wantErr:   true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -58,7 +99,7 @@ func TestResolver_Stations(t *testing.T) {
 			resolver := tt.setupMock()
 			queryResolver := resolver.Query()
 
-			got, err := queryResolver.Stations(context.Background(), &tt.lat, &tt.lon, tt.limit)
+			got, err := queryResolver.Stations(context.Background(), nil, &tt.lat, &tt.lon, tt.limit, tt.unit, nil, nil, nil)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -73,6 +114,9 @@ func TestResolver_Stations(t *testing.T) {
 				assert.Equal(t, station.Name, got[i].Name)
 				assert.Equal(t, station.Latitude, got[i].Latitude)
 				assert.Equal(t, station.Longitude, got[i].Longitude)
+				assert.InDelta(t, station.Distance, got[i].Distance, 1e-9)
+				assert.Equal(t, station.DistanceUnit, got[i].DistanceUnit)
+				assert.Equal(t, station.Bearing, got[i].Bearing)
 			}
 		})
 	}
@@ -98,6 +142,8 @@ func TestResolver_Tides(t *testing.T) {
 				predictedLevel := 1.6
 				tideType := models.TideTypeHigh
 				timeZoneOffset := -28800
+				falling := models.TideFalling
+				elapsed := 0.0
 
 				return &Resolver{
 					TideService: &mockTideService{
@@ -120,6 +166,13 @@ func TestResolver_Tides(t *testing.T) {
 								Extremes: []models.TideExtreme{
 									{Type: models.TideTypeHigh, Timestamp: 1704067200000, LocalTime: "2024-01-01T00:00:00", Height: 1.5},
 								},
+								Summary: &models.TideSummary{
+									NextLow:             &models.TideExtreme{Type: models.TideTypeLow, Timestamp: 1704088800000, LocalTime: "2024-01-01T06:00:00", Height: -0.5},
+									Trend:               &falling,
+									TodayRange:          &models.TideRange{Low: -0.5, High: 1.5},
+									CycleElapsedPercent: &elapsed,
+								},
+								Astronomy: &models.TideAstronomy{TideCycle: "NEAP", MoonPhase: "FIRST_QUARTER", DaysSinceNewMoon: 8.2, DaysSinceFullMoon: 22.9},
 							}, nil
 						},
 					},
@@ -143,6 +196,13 @@ func TestResolver_Tides(t *testing.T) {
 				Extremes: []*model.TideExtreme{
 					{Type: "HIGH", Timestamp: 1704067200000, LocalTime: "2024-01-01T00:00:00", Height: 1.5},
 				},
+				Summary: &model.TideSummary{
+					NextLow:             &model.TideExtreme{Type: "LOW", Timestamp: 1704088800000, LocalTime: "2024-01-01T06:00:00", Height: -0.5},
+					Trend:               func() *string { s := "FALLING"; return &s }(),
+					TodayRange:          &model.TideRange{Low: -0.5, High: 1.5},
+					CycleElapsedPercent: func() *float64 { f := 0.0; return &f }(),
+				},
+				Astronomy: &model.TideAstronomy{TideCycle: "NEAP", MoonPhase: "FIRST_QUARTER", DaysSinceNewMoon: 8.2, DaysSinceFullMoon: 22.9},
 			},
 			wantErr: false,
 		},
@@ -187,7 +247,7 @@ func TestResolver_Tides(t *testing.T) {
 			resolver := tt.setupMock()
 			queryResolver := resolver.Query()
 
-			got, err := queryResolver.Tides(context.Background(), tt.stationID, tt.startTime, tt.endTime)
+			got, err := queryResolver.Tides(context.Background(), tt.stationID, tt.startTime, tt.endTime, nil, nil, nil, nil, nil, nil)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -212,6 +272,7 @@ func TestResolver_Tides(t *testing.T) {
 				assert.Equal(t, p.LocalTime, got.Predictions[i].LocalTime)
 				assert.Equal(t, p.Height, got.Predictions[i].Height)
 			}
+			assert.Equal(t, tt.want.Astronomy, got.Astronomy)
 			assert.Equal(t, len(tt.want.Extremes), len(got.Extremes))
 			for i, e := range tt.want.Extremes {
 				assert.Equal(t, e.Type, got.Extremes[i].Type)
@@ -222,3 +283,933 @@ func TestResolver_Tides(t *testing.T) {
 		})
 	}
 }
+
+// memoryProfileStore is an in-memory userdata.Store
+type memoryProfileStore struct {
+	profiles map[string]models.UserProfile
+}
+
+func (m *memoryProfileStore) GetProfile(_ context.Context, userID string) (*models.UserProfile, error) {
+	profile, ok := m.profiles[userID]
+	if !ok {
+		return nil, nil
+	}
+	profile.Favorites = append([]models.FavoriteStation{}, profile.Favorites...)
+	return &profile, nil
+}
+
+func (m *memoryProfileStore) SaveProfile(_ context.Context, profile *models.UserProfile) error {
+	profile.Version++
+	m.profiles[profile.UserID] = *profile
+	return nil
+}
+
+func TestResolver_NearbyStations(t *testing.T) {
+	all := []models.Station{{ID: "A", Distance: 1.609344}, {ID: "B", Distance: 3.218688}, {ID: "C", Distance: 4.828032}}
+	resolver := &Resolver{
+		StationFinder: &testsupport.StationFinder{
+			FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return all, nil
+			},
+		},
+	}
+	ctx := context.Background()
+	first, miles := 2, "mi"
+
+	page, err := resolver.Query().NearbyStations(ctx, 47.6, -122.3, &first, nil, &miles, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, page.Edges, 2)
+	assert.Equal(t, "A", page.Edges[0].Node.ID)
+	assert.InDelta(t, 2, page.Edges[1].Node.Distance, 1e-9)
+	assert.Equal(t, "mi", page.Edges[1].Node.DistanceUnit)
+	assert.Equal(t, 3, page.TotalCount)
+	assert.True(t, page.PageInfo.HasNextPage)
+	assert.False(t, page.PageInfo.HasPreviousPage)
+	assert.Equal(t, page.Edges[0].Cursor, *page.PageInfo.StartCursor)
+	assert.False(t, page.Stale)
+
+	page, err = resolver.Query().NearbyStations(ctx, 47.6, -122.3, &first, page.PageInfo.EndCursor, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, page.Edges, 1)
+	assert.Equal(t, "C", page.Edges[0].Node.ID)
+	assert.Equal(t, "km", page.Edges[0].Node.DistanceUnit)
+	assert.False(t, page.PageInfo.HasNextPage)
+	assert.True(t, page.PageInfo.HasPreviousPage)
+
+	bad := "not-a-cursor"
+	_, err = resolver.Query().NearbyStations(ctx, 47.6, -122.3, nil, &bad, nil, nil, nil, nil)
+	assert.EqualError(t, err, `invalid cursor "not-a-cursor"`)
+	zero := 0
+	_, err = resolver.Query().NearbyStations(ctx, 47.6, -122.3, &zero, nil, nil, nil, nil, nil)
+	assert.EqualError(t, err, `invalid first "0": must be at least 1`)
+
+	resolver.StationFinder.(*testsupport.StationFinder).Stale = true
+	page, err = resolver.Query().NearbyStations(ctx, 47.6, -122.3, &first, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, page.Stale)
+}
+
+func TestResolver_StationLimits(t *testing.T) {
+	var limits []int
+	resolver := &Resolver{
+		StationFinder: &testsupport.StationFinder{
+			FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				limits = append(limits, limit)
+				return nil, nil
+			},
+		},
+		StationLimits: models.StationLimits{Default: 3, Max: 10},
+	}
+	ctx := context.Background()
+	lat, lon := 47.6, -122.3
+
+	_, err := resolver.Query().Stations(ctx, nil, &lat, &lon, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = resolver.Query().NearbyStations(ctx, lat, lon, nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 3}, limits, "both queries use the configured default")
+
+	tooMany := 11
+	_, err = resolver.Query().Stations(ctx, nil, &lat, &lon, &tooMany, nil, nil, nil, nil)
+	assert.EqualError(t, err, `invalid limit "11": must be at most 10`)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+	_, err = resolver.Query().NearbyStations(ctx, lat, lon, &tooMany, nil, nil, nil, nil, nil)
+	assert.EqualError(t, err, `invalid first "11": must be at most 10`)
+}
+
+func TestResolver_StationFilters(t *testing.T) {
+	reference, subordinate := "R", "S"
+	resolver := &Resolver{
+		StationFinder: &testsupport.StationFinder{
+			FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return []models.Station{
+					{ID: "SUB", Source: models.SourceNOAA, StationType: models.StationKindSubordinate},
+					{ID: "REF", Source: models.SourceNOAA, StationType: models.StationKindReference},
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+	lat, lon := 47.6, -122.3
+
+	stations, err := resolver.Query().Stations(ctx, nil, &lat, &lon, nil, nil, &reference, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, stations, 1)
+	assert.Equal(t, "REF", stations[0].ID)
+	assert.Equal(t, &reference, stations[0].StationType)
+
+	page, err := resolver.Query().NearbyStations(ctx, lat, lon, nil, nil, nil, &subordinate, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, page.Edges, 1)
+	assert.Equal(t, "SUB", page.Edges[0].Node.ID)
+	assert.Equal(t, 1, page.TotalCount)
+
+	unknown := "BOM"
+	_, err = resolver.Query().Stations(ctx, nil, &lat, &lon, nil, nil, nil, nil, &unknown)
+	assert.EqualError(t, err, `invalid source "BOM": must be one of NOAA, UKHO, CHS`)
+}
+
+func TestResolver_TidesDownsampled(t *testing.T) {
+	predictions := make([]models.TidePrediction, 240)
+	for i := range predictions {
+		predictions[i] = models.TidePrediction{Timestamp: 1704067200000 + models.Millis(i)*360000, Height: float64(i % 60)}
+	}
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getCurrentTideForStationFn: func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+				return &models.ExtendedTideResponse{NearestStation: stationID, Predictions: predictions}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	points := 20
+	got, err := resolver.Query().Tides(ctx, "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, &points, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(got.Predictions), 20)
+	var highest float64
+	for _, p := range got.Predictions {
+		highest = max(highest, p.Height)
+	}
+	assert.Equal(t, 59.0, highest)
+
+	points = 5
+	_, err = resolver.Query().Tides(ctx, "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, &points, nil, nil, nil, nil)
+	assert.EqualError(t, err, `invalid points "5": must be between 10 and 5000`)
+}
+
+func TestResolver_TidesTimeFormat(t *testing.T) {
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getCurrentTideForStationFn: func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+				return &models.ExtendedTideResponse{
+					NearestStation: stationID,
+					LocalTime:      "2024-01-01T13:05:00",
+					Predictions:    []models.TidePrediction{{Timestamp: 1704143100000, LocalTime: "2024-01-01T13:05:00", Height: 4.2}},
+					Extremes:       []models.TideExtreme{{Type: models.TideTypeLow, Timestamp: 1704150000000, LocalTime: "2024-01-01T14:20:00", Height: 1.1}},
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	locale, hour12 := "de-DE", true
+	got, err := resolver.Query().Tides(ctx, "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, nil, nil, nil, &locale, &hour12)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.2024 1:05:00 PM", got.LocalTime)
+	assert.Equal(t, "1.1.2024 1:05:00 PM", got.Predictions[0].LocalTime)
+	assert.Equal(t, "1.1.2024 2:20:00 PM", got.Extremes[0].LocalTime)
+
+	locale = "xx-XX"
+	_, err = resolver.Query().Tides(ctx, "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, nil, nil, nil, &locale, nil)
+	assert.ErrorContains(t, err, `invalid locale "xx-XX"`)
+}
+
+func TestResolver_TidesWeather(t *testing.T) {
+	speed, direction := 12.5, 225.0
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getCurrentTideForStationFn: func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+				return &models.ExtendedTideResponse{
+					NearestStation: stationID,
+					Weather: &models.MarineWeather{
+						Source:    models.WeatherSourceNWS,
+						Available: true,
+						Forecast: []models.WeatherForecast{{
+							Timestamp:            1704067200000,
+							LocalTime:            "2023-12-31T16:00:00",
+							WindSpeedKnots:       &speed,
+							WindDirectionDegrees: &direction,
+						}},
+					},
+				}, nil
+			},
+		},
+	}
+
+	includeWeather := true
+	got, err := resolver.Query().Tides(context.Background(), "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, nil, &includeWeather, nil, nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, got.Weather)
+	assert.Equal(t, "NWS", got.Weather.Source)
+	assert.True(t, got.Weather.Available)
+	require.Len(t, got.Weather.Forecast, 1)
+	assert.Equal(t, 1704067200000, got.Weather.Forecast[0].Timestamp)
+	assert.Equal(t, &speed, got.Weather.Forecast[0].WindSpeedKnots)
+	assert.Equal(t, &direction, got.Weather.Forecast[0].WindDirectionDegrees)
+	assert.Nil(t, got.Weather.Forecast[0].PressureHpa)
+}
+
+func TestResolver_TidesConditions(t *testing.T) {
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getCurrentTideForStationFn: func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+				return &models.ExtendedTideResponse{
+					NearestStation: stationID,
+					Conditions: &models.WaterConditions{
+						WaterTemperature: &models.Observation{Timestamp: 1704110760000, LocalTime: "2024-01-01T04:06:00", Value: 48.4, Units: "degF"},
+					},
+					Confidence: &models.PredictionConfidence{Level: models.ConfidenceMedium, MeanAbsoluteError: 0.3, Bias: 0.1, Samples: 120, Days: 7},
+					Offsets:    &models.TideOffsets{ReferenceStationID: "9447130", ReferenceStationName: "Seattle", TimeOffsetHighMinutes: 24, TimeOffsetLowMinutes: 31, HeightOffsetHigh: 0.98, HeightOffsetLow: 0.98, HeightAdjustment: models.HeightAdjustmentRatio},
+					Anomaly:    &models.WaterLevelAnomaly{Detected: true, Residual: 1.4, Threshold: 1, ObservedLevel: 9.2, PredictedLevel: 7.8, Timestamp: 1704110760000, LocalTime: "2024-01-01T04:06:00"},
+				}, nil
+			},
+		},
+	}
+
+	got, err := resolver.Query().Tides(context.Background(), "9447130", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, got.Conditions)
+	assert.Nil(t, got.Conditions.Conductivity)
+	require.NotNil(t, got.Conditions.WaterTemperature)
+	assert.Equal(t, 1704110760000, got.Conditions.WaterTemperature.Timestamp)
+	assert.Equal(t, 48.4, got.Conditions.WaterTemperature.Value)
+	assert.Equal(t, "degF", got.Conditions.WaterTemperature.Units)
+	assert.Equal(t, &model.PredictionConfidence{Level: "MEDIUM", MeanAbsoluteError: 0.3, Bias: 0.1, Samples: 120, Days: 7}, got.Confidence)
+	require.NotNil(t, got.Offsets)
+	assert.Equal(t, "9447130", got.Offsets.ReferenceStationID)
+	assert.Equal(t, "Seattle", *got.Offsets.ReferenceStationName)
+	assert.Equal(t, 24, got.Offsets.TimeOffsetHighMinutes)
+	require.NotNil(t, got.Anomaly)
+	assert.True(t, got.Anomaly.Detected)
+	assert.Equal(t, 1.4, got.Anomaly.Residual)
+	assert.Equal(t, 1704110760000, got.Anomaly.Timestamp)
+}
+
+func TestResolver_TidesWarnings(t *testing.T) {
+	var warnings []models.ResponseWarning
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getCurrentTideForStationFn: func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+				return &models.ExtendedTideResponse{NearestStation: stationID, Warnings: warnings}, nil
+			},
+		},
+	}
+
+	// The list is non-null even when nothing is missing
+	got, err := resolver.Query().Tides(context.Background(), "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, got.Warnings)
+	assert.Empty(t, got.Warnings)
+
+	warnings = []models.ResponseWarning{{Code: models.WarningExtremesUnavailable, Message: "high and low tides are unavailable"}}
+	got, err = resolver.Query().Tides(context.Background(), "TEST001", "2024-01-01T00:00:00", "2024-01-02T00:00:00", nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, got.Warnings, 1)
+	assert.Equal(t, "EXTREMES_UNAVAILABLE", got.Warnings[0].Code)
+	assert.Equal(t, "high and low tides are unavailable", got.Warnings[0].Message)
+}
+
+func TestResolver_Observation(t *testing.T) {
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getLatestObservationFn: func(ctx context.Context, stationID, product string) (*models.ObservationResponse, error) {
+				if product != "water_temperature" {
+					return nil, &tide.UnsupportedProductError{StationID: stationID, Product: product, Supported: []string{"water_temperature"}}
+				}
+				return &models.ObservationResponse{
+					ResponseType: "observation",
+					StationID:    stationID,
+					StationName:  "Seattle",
+					Product:      product,
+					Observation:  models.Observation{Timestamp: 1704110760000, LocalTime: "2024-01-01T04:06:00", Value: 48.4, Units: "degF"},
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	got, err := resolver.Query().Observation(ctx, "9447130", "water_temperature")
+	require.NoError(t, err)
+	assert.Equal(t, "Seattle", got.StationName)
+	assert.Equal(t, "water_temperature", got.Product)
+	assert.Equal(t, 48.4, got.Observation.Value)
+	assert.Equal(t, "2024-01-01T04:06:00", got.Observation.LocalTime)
+
+	_, err = resolver.Query().Observation(ctx, "9447130", "air_pressure")
+	assert.EqualError(t, err, "station 9447130 does not measure air_pressure; it measures water_temperature")
+}
+
+func TestResolver_Extremes(t *testing.T) {
+	var gotDays int
+	var gotStart *string
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getDailyExtremesFn: func(ctx context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error) {
+				gotStart, gotDays = startDate, days
+				return &models.ExtremesSummary{
+					ResponseType: "extremes",
+					StationID:    stationID,
+					StationName:  "Seattle",
+					Days: []models.DailyExtremes{{
+						Date: "2024-01-01",
+						Extremes: []models.CompactExtreme{
+							{Type: models.TideTypeHigh, Time: "04:30", Timestamp: 1704112200000, Height: 3.2},
+						},
+					}},
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	summary, err := resolver.Query().Extremes(ctx, "9447130", nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, gotStart)
+	assert.Equal(t, 7, gotDays)
+	assert.Equal(t, &model.ExtremesSummary{
+		StationID:   "9447130",
+		StationName: "Seattle",
+		Days: []*model.DailyExtremes{{
+			Date: "2024-01-01",
+			Extremes: []*model.CompactExtreme{
+				{Type: "HIGH", Time: "04:30", Timestamp: 1704112200000, Height: 3.2},
+			},
+		}},
+	}, summary)
+
+	start, days := "2024-01-01", 31
+	_, err = resolver.Query().Extremes(ctx, "9447130", &start, &days)
+	require.NoError(t, err)
+	assert.Equal(t, &start, gotStart)
+	assert.Equal(t, 31, gotDays)
+
+	_, err = (&Resolver{}).Query().Extremes(ctx, "9447130", nil, nil)
+	assert.EqualError(t, err, "TideService is not initialized")
+}
+
+func TestResolver_NextExtremes(t *testing.T) {
+	var gotCount int
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getNextExtremesFn: func(ctx context.Context, stationID string, count int) (*models.NextExtremes, error) {
+				gotCount = count
+				return &models.NextExtremes{
+					ResponseType: "nextExtremes",
+					StationID:    stationID,
+					StationName:  "Seattle",
+					TimeZone:     "America/Los_Angeles",
+					Extremes: []models.TideExtreme{
+						{Type: models.TideTypeHigh, Timestamp: 1704112200000, LocalTime: "2024-01-01T04:30:00", Height: 3.2},
+					},
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	next, err := resolver.Query().NextExtremes(ctx, "9447130", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4, gotCount)
+	timeZone := "America/Los_Angeles"
+	assert.Equal(t, &model.NextExtremes{
+		StationID:   "9447130",
+		StationName: "Seattle",
+		TimeZone:    &timeZone,
+		Extremes: []*model.TideExtreme{
+			{Type: "HIGH", Timestamp: 1704112200000, LocalTime: "2024-01-01T04:30:00", Height: 3.2},
+		},
+	}, next)
+
+	count := 10
+	_, err = resolver.Query().NextExtremes(ctx, "9447130", &count)
+	require.NoError(t, err)
+	assert.Equal(t, 10, gotCount)
+
+	_, err = (&Resolver{}).Query().NextExtremes(ctx, "9447130", nil)
+	assert.EqualError(t, err, "TideService is not initialized")
+}
+
+func TestResolver_StationsByID(t *testing.T) {
+	reference := "R"
+	resolver := &Resolver{
+		StationFinder: &testsupport.StationFinder{Stations: []models.Station{
+			{ID: "9447130", Name: "Seattle", Source: models.SourceNOAA, StationType: models.StationKindReference},
+			{ID: "9414290", Name: "San Francisco", Source: models.SourceNOAA, StationType: models.StationKindSubordinate},
+		}},
+	}
+	ctx := context.Background()
+
+	stations, err := resolver.Query().Stations(ctx, []string{"9414290", "1234567", "9447130"}, nil, nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, stations, 2, "unknown IDs are left out")
+	assert.Equal(t, "9414290", stations[0].ID)
+	assert.Equal(t, "9447130", stations[1].ID)
+
+	stations, err = resolver.Query().Stations(ctx, []string{"9414290", "9447130"}, nil, nil, nil, nil, &reference, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, stations, 1, "filters still apply")
+	assert.Equal(t, "9447130", stations[0].ID)
+
+	lat := 47.6
+	_, err = resolver.Query().Stations(ctx, []string{"9447130"}, &lat, &lat, nil, nil, nil, nil, nil)
+	assert.EqualError(t, err, "ids can't be combined with lat and lon")
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+
+	_, err = resolver.Query().Stations(ctx, make([]string, maxStationIDs+1), nil, nil, nil, nil, nil, nil, nil)
+	assert.EqualError(t, err, "ids can list at most 100 stations")
+}
+
+func TestResolver_StationsInBounds(t *testing.T) {
+	resolver := &Resolver{
+		StationFinder: &testsupport.StationFinder{Stations: []models.Station{
+			{ID: "9447130", Name: "Seattle", Latitude: 47.6026, Longitude: -122.3393, Source: models.SourceNOAA},
+			{ID: "9446484", Name: "Tacoma", Latitude: 47.2690, Longitude: -122.4138, Source: models.SourceNOAA},
+			{ID: "9439040", Name: "Astoria", Latitude: 46.2073, Longitude: -123.7683, Source: models.SourceNOAA},
+			{ID: "9414290", Name: "San Francisco", Latitude: 37.8063, Longitude: -122.4659, Source: models.SourceNOAA},
+		}},
+	}
+	ctx := context.Background()
+
+	stationMap, err := resolver.Query().StationsInBounds(ctx, 49, 45, -120, -125, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stationMap.TotalCount)
+	require.Len(t, stationMap.Stations, 3, "without a zoom every station is listed")
+	assert.Equal(t, "km", stationMap.Stations[0].DistanceUnit)
+	assert.Empty(t, stationMap.Clusters)
+
+	zoom := 5
+	stationMap, err = resolver.Query().StationsInBounds(ctx, 49, 45, -120, -125, &zoom, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stationMap.TotalCount)
+	require.Len(t, stationMap.Clusters, 1)
+	assert.Equal(t, 2, stationMap.Clusters[0].Count)
+	assert.InDelta(t, (47.6026+47.2690)/2, stationMap.Clusters[0].Latitude, 1e-9)
+	assert.Equal(t, 47.6026, stationMap.Clusters[0].North)
+	assert.Equal(t, -122.4138, stationMap.Clusters[0].West)
+	require.Len(t, stationMap.Stations, 1, "a station alone in its cell isn't clustered")
+	assert.Equal(t, "9439040", stationMap.Stations[0].ID)
+
+	zoom = 23
+	_, err = resolver.Query().StationsInBounds(ctx, 49, 45, -120, -125, &zoom, nil, nil, nil)
+	assert.EqualError(t, err, `invalid zoom "23": must be between 0 and 22`)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+
+	// Embedding only the interface hides the finder's FindStationsInBounds
+	nearestOnly := struct{ models.StationFinder }{resolver.StationFinder}
+	_, err = (&Resolver{StationFinder: nearestOnly}).Query().StationsInBounds(ctx, 49, 45, -120, -125, nil, nil, nil, nil)
+	assert.EqualError(t, err, "the station finder can't list stations by bounds")
+}
+
+func TestResolver_Regions(t *testing.T) {
+	seattle, tacoma, portland := testsupport.Station("9447130"), testsupport.Station("9446484"), testsupport.Station("8418150")
+	tacoma.StationType = models.StationKindSubordinate
+	maine, cascoBay := "ME", "Casco Bay"
+	portland.State, portland.Region = &maine, &cascoBay
+	resolver := &Resolver{StationFinder: &testsupport.StationFinder{Stations: []models.Station{tacoma, seattle, portland}}}
+	ctx := context.Background()
+
+	regions, err := resolver.Query().Regions(ctx, nil, nil, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, regions, 2)
+	assert.Equal(t, "ME", *regions[0].State)
+	assert.Equal(t, "Puget Sound", *regions[1].Region)
+	assert.Equal(t, 2, regions[1].StationCount)
+	require.Len(t, regions[1].Representatives, 2)
+	assert.Equal(t, "9447130", regions[1].Representatives[0].ID, "reference stations first")
+	assert.Equal(t, "km", regions[1].Representatives[0].DistanceUnit)
+
+	state, stationType := "wa", "S"
+	regions, err = resolver.Query().Regions(ctx, &state, &stationType, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, regions, 1)
+	assert.Equal(t, 1, regions[0].StationCount)
+
+	stationType = "X"
+	_, err = resolver.Query().Regions(ctx, nil, &stationType, nil, nil)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+
+	nearestOnly := struct{ models.StationFinder }{resolver.StationFinder}
+	_, err = (&Resolver{StationFinder: nearestOnly}).Query().Regions(ctx, nil, nil, nil, nil)
+	assert.EqualError(t, err, "the station finder can't list stations by region")
+}
+
+func TestResolver_TideExtremes(t *testing.T) {
+	var gotStart, gotEnd string
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getTideExtremesFn: func(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.TideExtremes, error) {
+				gotStart, gotEnd = *startTimeStr, *endTimeStr
+				return &models.TideExtremes{
+					ResponseType: "tideExtremes",
+					StationID:    stationID,
+					StationName:  "Seattle",
+					Extremes: []models.TideExtreme{
+						{Type: models.TideTypeLow, Timestamp: 1704112200000, LocalTime: "2024-01-01T04:30:00", Height: -0.4},
+					},
+				}, nil
+			},
+		},
+	}
+
+	extremes, err := resolver.Query().TideExtremes(context.Background(), "9447130", "2024-01-01", "2024-01-31", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01", gotStart)
+	assert.Equal(t, "2024-01-31", gotEnd)
+	assert.Equal(t, &model.TideExtremes{
+		StationID:   "9447130",
+		StationName: "Seattle",
+		Extremes: []*model.TideExtreme{
+			{Type: "LOW", Timestamp: 1704112200000, LocalTime: "2024-01-01T04:30:00", Height: -0.4},
+		},
+	}, extremes)
+
+	bad := "Mars/Olympus"
+	_, err = resolver.Query().TideExtremes(context.Background(), "9447130", "2024-01-01", "2024-01-31", &bad)
+	assert.Error(t, err)
+}
+
+func TestResolver_CompareStations(t *testing.T) {
+	var gotIDs []string
+	var gotInterval int
+	lag, ratio := 42.5, 0.8
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			compareStationsFn: func(ctx context.Context, stationIDs []string, startTimeStr, endTimeStr *string, intervalMinutes int) (*models.StationComparison, error) {
+				gotIDs, gotInterval = stationIDs, intervalMinutes
+				return &models.StationComparison{
+					ResponseType:    "comparison",
+					IntervalMinutes: intervalMinutes,
+					Timestamps:      []models.Millis{1704067200000},
+					Stations: []models.ComparedStation{
+						{ID: stationIDs[0], Name: "Seattle", Heights: []float64{1.5}},
+						{ID: stationIDs[1], Name: "Tacoma", Heights: []float64{1.2}, LagMinutes: &lag, RangeRatio: &ratio},
+					},
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	comparison, err := resolver.Query().CompareStations(ctx, []string{"9447130", "9446484"}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"9447130", "9446484"}, gotIDs)
+	assert.Equal(t, 6, gotInterval)
+	assert.Equal(t, &model.StationComparison{
+		IntervalMinutes: 6,
+		Timestamps:      []int{1704067200000},
+		Stations: []*model.ComparedStation{
+			{ID: "9447130", Name: "Seattle", Heights: []float64{1.5}},
+			{ID: "9446484", Name: "Tacoma", Heights: []float64{1.2}, LagMinutes: &lag, RangeRatio: &ratio},
+		},
+	}, comparison)
+
+	interval := 30
+	_, err = resolver.Query().CompareStations(ctx, []string{"9447130", "9446484"}, nil, nil, &interval, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 30, gotInterval)
+
+	unknownZone := "Mars/Olympus_Mons"
+	_, err = resolver.Query().CompareStations(ctx, []string{"9447130", "9446484"}, nil, nil, nil, &unknownZone)
+	assert.EqualError(t, err, `invalid tz "Mars/Olympus_Mons": must be an IANA time zone`)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+
+	_, err = (&Resolver{}).Query().CompareStations(ctx, []string{"9447130", "9446484"}, nil, nil, nil, nil)
+	assert.EqualError(t, err, "TideService is not initialized")
+}
+
+func TestResolver_UserData(t *testing.T) {
+	finder := &testsupport.StationFinder{
+		FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+			return &models.Station{ID: stationID, Name: "Seattle"}, nil
+		},
+	}
+	resolver := &Resolver{
+		StationFinder: finder,
+		UserData:      userdata.NewService(&memoryProfileStore{profiles: map[string]models.UserProfile{}}, finder),
+	}
+	ctx := userdata.WithUserID(context.Background(), "cognito:abc-123")
+
+	profile, err := resolver.Query().Me(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "cognito:abc-123", profile.UserID)
+	assert.Equal(t, models.DefaultUnits, profile.Units)
+	assert.Empty(t, profile.Favorites)
+
+	profile, err = resolver.Mutation().AddFavorite(ctx, "9447130")
+	require.NoError(t, err)
+	require.Len(t, profile.Favorites, 1)
+	assert.Equal(t, "9447130", profile.Favorites[0].StationID)
+	assert.Equal(t, "Seattle", profile.Favorites[0].Name)
+
+	metric := models.UnitsMetric
+	profile, err = resolver.Mutation().UpdatePreferences(ctx, &metric, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.UnitsMetric, profile.Units)
+	assert.Len(t, profile.Favorites, 1)
+
+	profile, err = resolver.Mutation().RemoveFavorite(ctx, "9447130")
+	require.NoError(t, err)
+	assert.Empty(t, profile.Favorites)
+	require.Len(t, profile.DeletedFavorites, 1)
+	assert.NotNil(t, profile.DeletedFavorites[0].DeletedAt)
+
+	profile, err = resolver.Mutation().RestoreFavorite(ctx, "9447130")
+	require.NoError(t, err)
+	require.Len(t, profile.Favorites, 1)
+	assert.Nil(t, profile.Favorites[0].DeletedAt)
+	assert.Empty(t, profile.DeletedFavorites)
+
+	invalid := "furlongs"
+	_, err = resolver.Mutation().UpdatePreferences(ctx, &invalid, nil)
+	assert.ErrorContains(t, err, "invalid units")
+}
+
+func TestResolver_UserDataRequiresIdentity(t *testing.T) {
+	resolver := &Resolver{UserData: userdata.NewService(&memoryProfileStore{profiles: map[string]models.UserProfile{}}, &testsupport.StationFinder{})}
+	_, err := resolver.Query().Me(context.Background())
+	assert.ErrorIs(t, err, errUnauthenticated)
+
+	_, err = (&Resolver{}).Mutation().AddFavorite(userdata.WithUserID(context.Background(), "cognito:abc-123"), "9447130")
+	assert.ErrorIs(t, err, errUserDataDisabled)
+}
+
+func TestErrorCode(t *testing.T) {
+	furlongs := "furlongs"
+	_, unitErr := parseDistanceUnit(&furlongs)
+	_, cursorErr := decodeCursor("bogus")
+	tests := []struct {
+		name string
+		err  error
+		want api.ErrorCode
+	}{
+		{"unauthenticated", errUnauthenticated, api.CodeUnauthenticated},
+		{"user data disabled", errUserDataDisabled, api.CodeForbidden},
+		{"exports disabled", errExportsDisabled, api.CodeForbidden},
+		{"station changes disabled", errChangesDisabled, api.CodeForbidden},
+		{"bad distance unit", unitErr, api.CodeInvalidRequest},
+		{"bad cursor", cursorErr, api.CodeInvalidRequest},
+		{"bad datum", fmt.Errorf("updating preferences: %w", models.ValidateDatum("XYZ")), api.CodeInvalidDatum},
+		{"range too large", tide.NewRangeTooLargeError("date range cannot exceed 5 days"), api.CodeRangeTooLarge},
+		{"upstream", tide.NewNoaaAPIError("error decoding predictions response", nil), api.CodeUpstreamUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.err)
+			assert.Equal(t, tt.want, errorCode(tt.err))
+		})
+	}
+}
+
+func TestResolver_Accuracy(t *testing.T) {
+	var gotDays int
+	mae, bias := 0.18, -0.05
+	resolver := &Resolver{
+		TideService: &mockTideService{
+			getAccuracyFn: func(ctx context.Context, stationID string, days int) (*models.AccuracyStats, error) {
+				gotDays = days
+				return &models.AccuracyStats{
+					ResponseType:      "accuracy",
+					StationID:         stationID,
+					StationName:       "Seattle",
+					Days:              days,
+					Samples:           150,
+					MeanAbsoluteError: &mae,
+					Bias:              &bias,
+					Confidence:        models.ConfidenceHigh,
+				}, nil
+			},
+		},
+	}
+	ctx := context.Background()
+
+	stats, err := resolver.Query().Accuracy(ctx, "9447130", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 7, gotDays)
+	confidence := "HIGH"
+	assert.Equal(t, &model.AccuracyStats{
+		StationID:         "9447130",
+		StationName:       "Seattle",
+		Days:              7,
+		Samples:           150,
+		MeanAbsoluteError: &mae,
+		Bias:              &bias,
+		Confidence:        &confidence,
+	}, stats)
+
+	_, err = (&Resolver{}).Query().Accuracy(ctx, "9447130", nil)
+	assert.EqualError(t, err, "TideService is not initialized")
+}
+
+// reportStore keeps saved reports in memory
+type reportStore struct {
+	saved []*models.ObservationReport
+}
+
+func (s *reportStore) SaveReport(_ context.Context, report *models.ObservationReport) error {
+	s.saved = append(s.saved, report)
+	return nil
+}
+
+func TestResolver_ReportObservation(t *testing.T) {
+	ctx := context.Background()
+	timestamp := int(time.Now().Add(-time.Hour).UnixMilli())
+	condition := models.ConditionLowerThanPredicted
+	_, err := (&Resolver{}).Mutation().ReportObservation(ctx, "9447130", timestamp, nil, &condition, nil)
+	assert.ErrorIs(t, err, errReportsDisabled)
+	assert.Equal(t, api.CodeForbidden, errorCode(err))
+
+	store := &reportStore{}
+	finder := &testsupport.StationFinder{Stations: []models.Station{{ID: "9447130", Name: "Seattle"}}}
+	resolver := &Resolver{Reports: reports.NewService(store, finder, 1)}
+	_, err = resolver.Mutation().ReportObservation(ctx, "9447130", timestamp, nil, &condition, nil)
+	assert.ErrorIs(t, err, errUnauthenticated)
+
+	ctx = userdata.WithUserID(ctx, "apikey:key-1")
+	photo := "https://photos.example.com/low.jpg"
+	report, err := resolver.Mutation().ReportObservation(ctx, "9447130", timestamp, nil, &condition, &photo)
+	require.NoError(t, err)
+	require.Len(t, store.saved, 1)
+	assert.Equal(t, &model.ObservationReport{
+		ID:          store.saved[0].ReportID,
+		StationID:   "9447130",
+		Timestamp:   timestamp,
+		Condition:   &condition,
+		PhotoURL:    &photo,
+		SubmittedAt: int(store.saved[0].SubmittedAt),
+	}, report)
+	assert.Equal(t, "apikey:key-1", store.saved[0].UserID)
+
+	_, err = resolver.Mutation().ReportObservation(ctx, "9447130", timestamp, nil, &condition, nil)
+	assert.Equal(t, api.CodeRateLimited, errorCode(err))
+
+	unknown := "CHOPPY"
+	_, err = resolver.Mutation().ReportObservation(userdata.WithUserID(ctx, "apikey:key-2"), "9447130", timestamp, nil, &unknown, nil)
+	assert.ErrorContains(t, err, `invalid condition "CHOPPY"`)
+}
+
+func TestResolver_ExportTideTable(t *testing.T) {
+	ctx := context.Background()
+	_, err := (&Resolver{}).Mutation().ExportTideTable(ctx, "9447130", 2025, nil)
+	assert.ErrorIs(t, err, errExportsDisabled)
+
+	// Arguments are checked before the bucket is reached
+	resolver := &Resolver{Exports: export.NewService(export.NewStore(nil, "flowebb-exports", time.Hour), &testsupport.StationFinder{})}
+	xls := "xls"
+	_, err = resolver.Mutation().ExportTideTable(ctx, "9447130", 2025, &xls)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+	_, err = resolver.Mutation().ExportTideTable(ctx, "9447130", 1850, nil)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+}
+
+// changeStore lists its changes, recording the arguments it's given
+type changeStore struct {
+	changes []models.StationListChange
+	since   time.Time
+	limit   int
+}
+
+func (s *changeStore) Put(context.Context, *models.StationListChange) (bool, error) {
+	return true, nil
+}
+
+func (s *changeStore) List(_ context.Context, _ models.Source, since time.Time, limit int) ([]models.StationListChange, error) {
+	s.since, s.limit = since, limit
+	return s.changes, nil
+}
+
+func TestResolver_StationChanges(t *testing.T) {
+	ctx := context.Background()
+	_, err := (&Resolver{}).Query().StationChanges(ctx, nil, nil)
+	assert.ErrorIs(t, err, errChangesDisabled)
+
+	store := &changeStore{changes: []models.StationListChange{{
+		Source:     models.SourceNOAA,
+		ChangeID:   "2024-03-15#0a1b2c3d4e5f",
+		DetectedAt: 1710482400000,
+		Stations: []models.StationChange{
+			{StationID: "9414290", Name: "San Francisco", Change: models.StationRemoved},
+			{StationID: "9446484", Name: "Tacoma", Change: models.StationModified, Fields: []models.StationFieldChange{{Field: "level", Before: "R"}}},
+		},
+	}}}
+	resolver := &Resolver{StationChanges: store}
+	changes, err := resolver.Query().StationChanges(ctx, nil, nil)
+	require.NoError(t, err)
+	assert.True(t, store.since.IsZero())
+	assert.Equal(t, 20, store.limit)
+	before := "R"
+	assert.Equal(t, []*model.StationListChange{{
+		ID:         "2024-03-15#0a1b2c3d4e5f",
+		Source:     "NOAA",
+		DetectedAt: 1710482400000,
+		Stations: []*model.StationChange{
+			{StationID: "9414290", Name: "San Francisco", Change: "REMOVED", Fields: []*model.StationFieldChange{}},
+			{StationID: "9446484", Name: "Tacoma", Change: "MODIFIED", Fields: []*model.StationFieldChange{{Field: "level", Before: &before}}},
+		},
+	}}, changes)
+
+	since, limit := "2024-03-01", 5
+	_, err = resolver.Query().StationChanges(ctx, &since, &limit)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), store.since)
+	assert.Equal(t, 5, store.limit)
+
+	march := "March 1"
+	_, err = resolver.Query().StationChanges(ctx, &march, nil)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+	limit = 500
+	_, err = resolver.Query().StationChanges(ctx, nil, &limit)
+	assert.Equal(t, api.CodeInvalidRequest, errorCode(err))
+}
+
+func TestToTideTableExport(t *testing.T) {
+	url := "https://flowebb-exports.s3.amazonaws.com/tide-tables/9447130/2025.pdf"
+	expiresAt, millis := models.Millis(1735689600000), 1735689600000
+	assert.Equal(t, &model.TideTableExport{
+		StationID:   "9447130",
+		Year:        2025,
+		Format:      "pdf",
+		Status:      models.ExportComplete,
+		DownloadURL: &url,
+		ExpiresAt:   &millis,
+	}, toTideTableExport(&models.TideTableExport{
+		StationID:   "9447130",
+		Year:        2025,
+		Format:      "pdf",
+		Status:      models.ExportComplete,
+		DownloadURL: &url,
+		ExpiresAt:   &expiresAt,
+	}))
+}
+
+// restOnlyTideFields are the tide response's JSON fields GraphQL deliberately leaves out
+var restOnlyTideFields = map[string]bool{
+	"responseType": true, // GraphQL has __typename
+}
+
+// TestToTideData_FieldParity fills every field of a tide response and checks each one
+// reaches GraphQL under the same name with the same value, so a field added to the REST
+// response without a GraphQL counterpart fails here rather than going missing
+func TestToTideData_FieldParity(t *testing.T) {
+	var response models.ExtendedTideResponse
+	fillFields(reflect.ValueOf(&response).Elem(), new(int))
+
+	restJSON, err := json.Marshal(response)
+	require.NoError(t, err)
+	graphJSON, err := json.Marshal(toTideData(&response))
+	require.NoError(t, err)
+
+	var rest, graph map[string]any
+	require.NoError(t, json.Unmarshal(restJSON, &rest))
+	require.NoError(t, json.Unmarshal(graphJSON, &graph))
+	for key := range restOnlyTideFields {
+		delete(rest, key)
+	}
+	assertSameFields(t, "TideData", rest, graph)
+}
+
+// fillFields sets every exported field under v to a value distinct from every other, so a
+// field mapped from the wrong source shows up as a mismatch
+func fillFields(v reflect.Value, n *int) {
+	*n++
+	switch v.Kind() {
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillFields(v.Elem(), n)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fillFields(v.Field(i), n)
+			}
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillFields(v.Index(0), n)
+	case reflect.String:
+		v.SetString(fmt.Sprintf("value %d", *n))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int64:
+		v.SetInt(int64(*n))
+	case reflect.Float64:
+		v.SetFloat(float64(*n) + 0.5)
+	}
+}
+
+func assertSameFields(t *testing.T, path string, rest, graph any) {
+	t.Helper()
+	switch restValue := rest.(type) {
+	case map[string]any:
+		graphValue, ok := graph.(map[string]any)
+		if !assert.Truef(t, ok, "%s is an object in REST but not in GraphQL", path) {
+			return
+		}
+		for key, value := range restValue {
+			if _, ok := graphValue[key]; !assert.Truef(t, ok, "%s.%s doesn't reach GraphQL", path, key) {
+				continue
+			}
+			assertSameFields(t, path+"."+key, value, graphValue[key])
+		}
+	case []any:
+		graphValue, ok := graph.([]any)
+		if !assert.Truef(t, ok, "%s is a list in REST but not in GraphQL", path) ||
+			!assert.Lenf(t, graphValue, len(restValue), "%s", path) {
+			return
+		}
+		for i := range restValue {
+			assertSameFields(t, fmt.Sprintf("%s[%d]", path, i), restValue[i], graphValue[i])
+		}
+	default:
+		assert.Equalf(t, rest, graph, "%s", path)
+	}
+}
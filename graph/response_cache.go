@@ -0,0 +1,212 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/bbernstein/flowebb-go/graph/model"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// relativeTTL is how long a response for a range relative to now, or defaulting to today,
+// is kept: long enough to absorb a dashboard's repeated refreshes, short enough that "now"
+// doesn't drift far
+const relativeTTL = time.Minute
+
+// ResponseTTLs are how long responses built from each kind of data may be cached. Each is
+// capped by the response cache's own TTL.
+type ResponseTTLs struct {
+	// Stations is for station searches
+	Stations time.Duration
+	// Predictions is for tides, extremes and station comparisons
+	Predictions time.Duration
+	// Weather is for tides that include the weather forecast
+	Weather time.Duration
+	// Observations is for sensor readings
+	Observations time.Duration
+}
+
+// UseResponseCache has the handler answer repeated identical queries from responses,
+// each kept for as long as the data behind it allows
+func (h *Handler) UseResponseCache(responses *cache.GraphQLCache, ttls ResponseTTLs) {
+	h.responses = responses
+	h.ttls = ttls
+	h.srv.AroundFields(h.interceptRootField)
+}
+
+// queryRequest is the part of a GraphQL request body its response is keyed by
+type queryRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// responseKey returns the request's response key, or false when its response mustn't be
+// cached or served from the cache: feature overrides change what a query returns. A
+// tenant's responses are kept apart, since they carry its branding and flags.
+func (h *Handler) responseKey(ctx context.Context, body string, headers map[string]string) (string, bool) {
+	if h.responses == nil {
+		return "", false
+	}
+	for name := range headers {
+		if strings.EqualFold(name, feature.Header) {
+			return "", false
+		}
+	}
+	var request queryRequest
+	if err := json.Unmarshal([]byte(body), &request); err != nil || request.Query == "" {
+		return "", false
+	}
+	key := cache.ResponseKey(request.Query, request.OperationName, request.Variables)
+	if t := tenant.FromContext(ctx); t != nil {
+		key = "tenant:" + t.ID + ":" + key
+	}
+	return key, true
+}
+
+// responsePolicy collects, while a query runs, whether its response may be cached, for
+// how long and which stations' predictions it shows
+type responsePolicy struct {
+	mu          sync.Mutex
+	uncacheable bool
+	ttl         time.Duration
+	stations    []string
+}
+
+type responsePolicyKey struct{}
+
+func withResponsePolicy(ctx context.Context) (context.Context, *responsePolicy) {
+	policy := &responsePolicy{}
+	return context.WithValue(ctx, responsePolicyKey{}, policy), policy
+}
+
+// limit caps how long the response may be kept and adds stations it shows. A ttl that
+// isn't positive makes the response uncacheable.
+func (p *responsePolicy) limit(ttl time.Duration, stations ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if ttl <= 0 {
+		p.uncacheable = true
+		return
+	}
+	if p.ttl == 0 || ttl < p.ttl {
+		p.ttl = ttl
+	}
+	p.stations = append(p.stations, stations...)
+}
+
+// cacheFor returns how long to keep the response, zero when it mustn't be
+func (p *responsePolicy) cacheFor() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.uncacheable {
+		return 0
+	}
+	return p.ttl
+}
+
+// storeResponse caches a successful response under key
+func (h *Handler) storeResponse(key string, policy *responsePolicy, status int, body string) {
+	ttl := policy.cacheFor()
+	if ttl <= 0 || status != http.StatusOK {
+		return
+	}
+	var result struct {
+		Errors json.RawMessage `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil || len(result.Errors) > 0 {
+		return
+	}
+	h.responses.AddResponse(key, body, ttl, policy.stations)
+}
+
+// interceptRootField limits the request's response policy by each root field it resolves
+func (h *Handler) interceptRootField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	res, err := next(ctx)
+	policy, ok := ctx.Value(responsePolicyKey{}).(*responsePolicy)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc == nil || len(fc.Path()) != 1 {
+		return res, err
+	}
+	if fc.Object != "Query" {
+		// Mutations change what later queries return, and aren't repeated anyway
+		policy.limit(0)
+		return res, err
+	}
+	h.limitByField(policy, fc, res, time.Now())
+	return res, err
+}
+
+// limitByField limits the policy by the data behind a root query field
+func (h *Handler) limitByField(policy *responsePolicy, fc *graphql.FieldContext, res interface{}, now time.Time) {
+	args := fc.Args
+	stationID, _ := args["stationId"].(string)
+	switch fc.Field.Name {
+	case "__typename", "__schema", "__type", "stations", "nearbyStations", "stationsInBounds", "regions":
+		policy.limit(h.ttls.Stations)
+	case "tides", "tideExtremes":
+		ttl := h.ttls.Predictions
+		if relative(args["startDateTime"]) || relative(args["endDateTime"]) {
+			ttl = relativeTTL
+		}
+		if weather, _ := args["includeWeather"].(*bool); weather != nil && *weather {
+			ttl = min(ttl, h.ttls.Weather)
+		}
+		policy.limit(ttl, stationID)
+	case "extremes", "daylightLows":
+		ttl := h.ttls.Predictions
+		if relative(args["startDate"]) {
+			ttl = relativeTTL
+		}
+		policy.limit(ttl, stationID)
+	case "compareStations":
+		ttl := h.ttls.Predictions
+		if relative(args["startDateTime"]) || relative(args["endDateTime"]) {
+			ttl = relativeTTL
+		}
+		stationIDs, _ := args["stationIds"].([]string)
+		policy.limit(ttl, stationIDs...)
+	case "nextExtremes":
+		// The response holds until the first of its extremes passes
+		next, ok := res.(*model.NextExtremes)
+		if !ok || next == nil || len(next.Extremes) == 0 {
+			policy.limit(0)
+			return
+		}
+		until := time.UnixMilli(int64(next.Extremes[0].Timestamp))
+		policy.limit(min(h.ttls.Predictions, until.Sub(now)), stationID)
+	case "observation":
+		policy.limit(h.ttls.Observations)
+	case "accuracy":
+		// Accuracy is scored hourly
+		policy.limit(now.Truncate(time.Hour).Add(time.Hour).Sub(now))
+	default:
+		// me is the caller's own, and fields added later are uncached until considered here
+		policy.limit(0)
+	}
+}
+
+// relative reports whether a range argument moves with the clock: omitted, so defaulting
+// to today, now or an offset from now
+func relative(arg interface{}) bool {
+	var value string
+	switch v := arg.(type) {
+	case string:
+		value = v
+	case *string:
+		if v == nil {
+			return true
+		}
+		value = *v
+	case nil:
+		return true
+	}
+	value = strings.TrimSpace(value)
+	return value == "" || strings.EqualFold(value, "now") || strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-")
+}
@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/graph/model"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"testing"
+	"time"
+)
+
+func newCachingHandler(t *testing.T, tides *mockTideService) (*Handler, *cache.GraphQLCache) {
+	t.Helper()
+	responses, err := cache.NewGraphQLCache(&config.CacheConfig{GraphQLLRUSize: 100, GraphQLLRUTTLMinutes: 60})
+	require.NoError(t, err)
+	handler := NewHandler(&Resolver{TideService: tides}, nil)
+	handler.UseResponseCache(responses, ResponseTTLs{
+		Stations:     time.Hour,
+		Predictions:  15 * time.Minute,
+		Weather:      30 * time.Minute,
+		Observations: 6 * time.Minute,
+	})
+	return handler, responses
+}
+
+func TestHandler_ResponseCache(t *testing.T) {
+	calls := 0
+	tides := &mockTideService{
+		getDailyExtremesFn: func(_ context.Context, stationID string, _ *string, _ int) (*models.ExtremesSummary, error) {
+			calls++
+			return &models.ExtremesSummary{StationID: stationID, StationName: "Seattle"}, nil
+		},
+	}
+	handler, responses := newCachingHandler(t, tides)
+	query := func(body string, headers map[string]string) string {
+		response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{Body: body, HTTPMethod: "POST", Headers: headers})
+		require.NoError(t, err)
+		require.Equal(t, 200, response.StatusCode)
+		return response.Body
+	}
+	const extremes = `{"query": "query { extremes(stationId: \"9447130\", startDate: \"2025-01-01\") { stationId stationName } }"}`
+	const want = `{"data":{"extremes":{"stationId":"9447130","stationName":"Seattle"}}}`
+
+	assert.Equal(t, want, query(extremes, nil))
+	assert.Equal(t, want, query(`{"query": "query {\n  extremes(stationId: \"9447130\", startDate: \"2025-01-01\") {\n    stationId\n    stationName\n  }\n}"}`, nil))
+	assert.Equal(t, 1, calls, "the same query, however formatted, is answered from the cache")
+
+	query(extremes, map[string]string{feature.Header: "harmonic-fallback"})
+	assert.Equal(t, 2, calls, "feature overrides bypass the cache")
+
+	responses.InvalidateStation("9447130")
+	assert.Equal(t, want, query(extremes, nil))
+	assert.Equal(t, 3, calls, "refreshing the station's predictions drops its responses")
+
+	query(`{"query": "query { extremes(stationId: \"9447130\") { stationId } }"}`, nil)
+	query(`{"query": "query { extremes(stationId: \"9447130\") { stationId } }"}`, nil)
+	assert.Equal(t, 4, calls, "ranges relative to today are cached briefly")
+}
+
+func TestHandler_ResponseCacheTenants(t *testing.T) {
+	calls := 0
+	tides := &mockTideService{
+		getDailyExtremesFn: func(_ context.Context, stationID string, _ *string, _ int) (*models.ExtremesSummary, error) {
+			calls++
+			return &models.ExtremesSummary{StationID: stationID}, nil
+		},
+	}
+	handler, _ := newCachingHandler(t, tides)
+	query := func(ctx context.Context) string {
+		response, err := handler.HandleRequest(ctx, events.APIGatewayProxyRequest{
+			Body:       `{"query": "query { extremes(stationId: \"9447130\", startDate: \"2025-01-01\") { stationId } }"}`,
+			HTTPMethod: "POST",
+		})
+		require.NoError(t, err)
+		return response.Body
+	}
+	acme := tenant.WithTenant(context.Background(), &tenant.Tenant{ID: "acme", Branding: tenant.Branding{Name: "Acme Tides"}})
+
+	assert.Equal(t, `{"data":{"extremes":{"stationId":"9447130"}}}`, query(context.Background()))
+	const branded = `{"data":{"extremes":{"stationId":"9447130"}},"extensions":{"tenant":{"id":"acme","name":"Acme Tides"}}}`
+	assert.Equal(t, branded, query(acme), "a tenant's responses carry its branding")
+	assert.Equal(t, branded, query(acme))
+	assert.Equal(t, 2, calls, "each tenant's responses are cached apart")
+}
+
+func TestHandler_ResponseCacheSkipsErrors(t *testing.T) {
+	calls := 0
+	tides := &mockTideService{
+		getDailyExtremesFn: func(_ context.Context, stationID string, _ *string, _ int) (*models.ExtremesSummary, error) {
+			calls++
+			return nil, models.ErrStationNotFound
+		},
+	}
+	handler, _ := newCachingHandler(t, tides)
+	for range 2 {
+		response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+			Body:       `{"query": "query { extremes(stationId: \"9447130\", startDate: \"2025-01-01\") { stationId } }"}`,
+			HTTPMethod: "POST",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, response.Body, `"errors"`)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestResponseTTL(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 20, 0, 0, time.UTC)
+	handler, _ := newCachingHandler(t, &mockTideService{})
+	weather := true
+	next := &model.NextExtremes{StationID: "1", Extremes: []*model.TideExtreme{{Timestamp: int(now.Add(5 * time.Minute).UnixMilli())}}}
+
+	tests := []struct {
+		name     string
+		field    string
+		args     map[string]interface{}
+		res      interface{}
+		wantTTL  time.Duration
+		stations []string
+	}{
+		{"stations", "stations", nil, nil, time.Hour, nil},
+		{"fixed tides", "tides", map[string]interface{}{"stationId": "1", "startDateTime": "2025-01-01", "endDateTime": "2025-01-02"}, nil, 15 * time.Minute, []string{"1"}},
+		{"tides from now", "tides", map[string]interface{}{"stationId": "1", "startDateTime": "now", "endDateTime": "+24h"}, nil, relativeTTL, []string{"1"}},
+		{"tides with weather", "tides", map[string]interface{}{"stationId": "1", "startDateTime": "2025-01-01", "endDateTime": "2025-01-02", "includeWeather": &weather}, nil, 15 * time.Minute, []string{"1"}},
+		{"comparison", "compareStations", map[string]interface{}{"stationIds": []string{"1", "2"}, "startDateTime": (*string)(nil)}, nil, relativeTTL, []string{"1", "2"}},
+		{"next extremes", "nextExtremes", map[string]interface{}{"stationId": "1"}, next, 5 * time.Minute, []string{"1"}},
+		{"no next extremes", "nextExtremes", map[string]interface{}{"stationId": "1"}, &model.NextExtremes{}, 0, nil},
+		{"observation", "observation", map[string]interface{}{"stationId": "1"}, nil, 6 * time.Minute, nil},
+		{"accuracy", "accuracy", map[string]interface{}{"stationId": "1"}, nil, 40 * time.Minute, nil},
+		{"me", "me", nil, nil, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &responsePolicy{}
+			handler.limitByField(policy, rootField(tt.field, tt.args), tt.res, now)
+			assert.Equal(t, tt.wantTTL, policy.cacheFor())
+			if tt.wantTTL > 0 {
+				assert.Equal(t, tt.stations, policy.stations)
+			}
+		})
+	}
+}
+
+func rootField(name string, args map[string]interface{}) *graphql.FieldContext {
+	return &graphql.FieldContext{Object: "Query", Field: graphql.CollectedField{Field: &ast.Field{Name: name}}, Args: args}
+}
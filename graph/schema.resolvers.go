@@ -10,50 +10,143 @@ import (
 
 	generated1 "github.com/bbernstein/flowebb-go/graph/generated"
 	"github.com/bbernstein/flowebb-go/graph/model"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/geo"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/reports"
+	"github.com/bbernstein/flowebb-go/internal/tide"
 )
 
 // Stations is the resolver for the stations field.
-func (r *queryResolver) Stations(ctx context.Context, lat *float64, lon *float64, limit *int) ([]*model.Station, error) {
-	if lat == nil || lon == nil {
-		return nil, fmt.Errorf("lat and lon are required")
+func (r *queryResolver) Stations(ctx context.Context, ids []string, lat *float64, lon *float64, limit *int, distanceUnit *string, stationType *string, capability *string, source *string) ([]*model.Station, error) {
+	if ids != nil && (lat != nil || lon != nil) {
+		return nil, argumentError{fmt.Errorf("ids can't be combined with lat and lon")}
+	}
+	if ids == nil && (lat == nil || lon == nil) {
+		return nil, argumentError{fmt.Errorf("lat and lon are required")}
+	}
+
+	unit, err := parseDistanceUnit(distanceUnit)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := stationFilter(stationType, capability, source)
+	if err != nil {
+		return nil, err
+	}
+	if ids != nil {
+		return r.stationsByID(ctx, ids, filter, unit)
 	}
 
-	limitVal := 5
-	if limit != nil {
-		limitVal = *limit
+	limitVal, err := r.StationLimits.Resolve("limit", limit)
+	if err != nil {
+		return nil, argumentError{err}
 	}
 
-	stations, err := r.StationFinder.FindNearestStations(ctx, *lat, *lon, limitVal)
+	page, err := r.StationFinder.FindNearestStationsPage(ctx, *lat, *lon, filter, 0, limitVal)
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert internal models to GraphQL models
-	result := make([]*model.Station, len(stations))
-	for i, s := range stations {
-		result[i] = &model.Station{
-			ID:             s.ID,
-			Name:           s.Name,
-			State:          s.State,
-			Region:         s.Region,
-			Distance:       s.Distance,
-			Latitude:       s.Latitude,
-			Longitude:      s.Longitude,
-			Source:         string(s.Source),
-			Capabilities:   s.Capabilities,
-			TimeZoneOffset: s.TimeZoneOffset,
-		}
+	result := make([]*model.Station, len(page.Stations))
+	for i, s := range models.WithDistanceUnit(page.Stations, unit) {
+		result[i] = toStation(s)
 	}
 
 	return result, nil
 }
 
+// NearbyStations is the resolver for the nearbyStations field.
+func (r *queryResolver) NearbyStations(ctx context.Context, lat float64, lon float64, first *int, after *string, distanceUnit *string, stationType *string, capability *string, source *string) (*model.StationConnection, error) {
+	unit, err := parseDistanceUnit(distanceUnit)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := stationFilter(stationType, capability, source)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := r.StationLimits.Resolve("first", first)
+	if err != nil {
+		return nil, argumentError{err}
+	}
+	offset := 0
+	if after != nil {
+		index, err := decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		offset = index + 1
+	}
+
+	page, err := r.StationFinder.FindNearestStationsPage(ctx, lat, lon, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]*model.StationEdge, len(page.Stations))
+	for i, s := range models.WithDistanceUnit(page.Stations, unit) {
+		edges[i] = &model.StationEdge{Cursor: encodeCursor(offset + i), Node: toStation(s)}
+	}
+	pageInfo := &model.PageInfo{
+		HasNextPage:     page.HasMore(),
+		HasPreviousPage: offset > 0,
+	}
+	if len(edges) > 0 {
+		pageInfo.StartCursor = &edges[0].Cursor
+		pageInfo.EndCursor = &edges[len(edges)-1].Cursor
+	}
+
+	return &model.StationConnection{Edges: edges, PageInfo: pageInfo, TotalCount: page.Total, Stale: page.Stale}, nil
+}
+
+// StationsInBounds is the resolver for the stationsInBounds field.
+func (r *queryResolver) StationsInBounds(ctx context.Context, north float64, south float64, east float64, west float64, zoom *int, stationType *string, capability *string, source *string) (*model.StationMap, error) {
+	filter, err := stationFilter(stationType, capability, source)
+	if err != nil {
+		return nil, err
+	}
+	return r.stationMap(ctx, geo.Bounds{North: north, South: south, East: east, West: west}, zoom, filter)
+}
+
+// Regions is the resolver for the regions field.
+func (r *queryResolver) Regions(ctx context.Context, state *string, stationType *string, capability *string, source *string) ([]*model.StationRegion, error) {
+	filter, err := stationFilter(stationType, capability, source)
+	if err != nil {
+		return nil, err
+	}
+	return r.regions(ctx, state, filter)
+}
+
 // Tides is the resolver for the tides field.
-func (r *queryResolver) Tides(ctx context.Context, stationID string, startDateTime string, endDateTime string) (*model.TideData, error) {
+func (r *queryResolver) Tides(ctx context.Context, stationID string, startDateTime string, endDateTime string, interpolation *string, points *int, includeWeather *bool, tz *string, locale *string, hour12 *bool) (*model.TideData, error) {
 	if r.TideService == nil {
 		return nil, fmt.Errorf("TideService is not initialized")
 	}
 
+	ctx, err := withTimeZone(ctx, tz)
+	if err != nil {
+		return nil, err
+	}
+	timeFormat, err := newTimeFormat(locale, hour12)
+	if err != nil {
+		return nil, err
+	}
+
+	if interpolation != nil {
+		interpolator, err := tide.NewInterpolator(*interpolation)
+		if err != nil {
+			return nil, err
+		}
+		ctx = tide.WithInterpolator(ctx, interpolator)
+	}
+	if includeWeather != nil && *includeWeather {
+		ctx = tide.WithWeather(ctx)
+	}
+
 	response, err := r.TideService.GetCurrentTideForStation(ctx, stationID, &startDateTime, &endDateTime)
 	if err != nil {
 		return nil, err
@@ -63,62 +156,251 @@ func (r *queryResolver) Tides(ctx context.Context, stationID string, startDateTi
 		return nil, fmt.Errorf("response is nil")
 	}
 
-	predictions := make([]*model.TidePrediction, len(response.Predictions))
-	for i, p := range response.Predictions {
-		predictions[i] = &model.TidePrediction{
-			Timestamp: int(p.Timestamp),
-			LocalTime: p.LocalTime,
-			Height:    p.Height,
+	if points != nil {
+		if response.Predictions, err = tide.Downsample(response.Predictions, *points); err != nil {
+			return nil, err
 		}
 	}
+	timeFormat.Apply(response)
 
-	extremes := make([]*model.TideExtreme, len(response.Extremes))
-	for i, e := range response.Extremes {
-		extremes[i] = &model.TideExtreme{
-			Type:      string(e.Type),
-			Timestamp: int(e.Timestamp),
-			LocalTime: e.LocalTime,
-			Height:    e.Height,
-		}
+	return toTideData(response), nil
+}
+
+// Extremes is the resolver for the extremes field.
+func (r *queryResolver) Extremes(ctx context.Context, stationID string, startDate *string, days *int) (*model.ExtremesSummary, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
 	}
 
-	var tideType string
-	if response.TideType != nil {
-		tideType = string(*response.TideType)
+	numDays := tide.DefaultExtremesDays
+	if days != nil {
+		numDays = *days
+	}
+	summary, err := r.TideService.GetDailyExtremes(ctx, stationID, startDate, numDays)
+	if err != nil {
+		return nil, err
 	}
+	return toExtremesSummary(summary), nil
+}
 
-	var waterLevel, predictedLevel float64
-	if response.WaterLevel != nil {
-		waterLevel = *response.WaterLevel
+// TideExtremes is the resolver for the tideExtremes field.
+func (r *queryResolver) TideExtremes(ctx context.Context, stationID string, startDateTime string, endDateTime string, tz *string) (*model.TideExtremes, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
 	}
-	if response.PredictedLevel != nil {
-		predictedLevel = *response.PredictedLevel
+
+	ctx, err := withTimeZone(ctx, tz)
+	if err != nil {
+		return nil, err
 	}
+	extremes, err := r.TideService.GetTideExtremes(ctx, stationID, &startDateTime, &endDateTime)
+	if err != nil {
+		return nil, err
+	}
+	return toTideExtremes(extremes), nil
+}
+
+// NextExtremes is the resolver for the nextExtremes field.
+func (r *queryResolver) NextExtremes(ctx context.Context, stationID string, count *int) (*model.NextExtremes, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
+	}
+
+	numExtremes := tide.DefaultNextExtremes
+	if count != nil {
+		numExtremes = *count
+	}
+	next, err := r.TideService.GetNextExtremes(ctx, stationID, numExtremes)
+	if err != nil {
+		return nil, err
+	}
+	return toNextExtremes(next), nil
+}
 
-	tzOffset := 0
-	if response.TimeZoneOffsetSeconds != nil {
-		tzOffset = *response.TimeZoneOffsetSeconds
+// DaylightLows is the resolver for the daylightLows field.
+func (r *queryResolver) DaylightLows(ctx context.Context, stationID string, below *float64, startDate *string, days *int) (*model.DaylightLows, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
 	}
 
-	return &model.TideData{
-		Timestamp:             int(response.Timestamp),
-		LocalTime:             response.LocalTime,
-		WaterLevel:            waterLevel,
-		PredictedLevel:        predictedLevel,
-		NearestStation:        response.NearestStation,
-		Location:              response.Location,
-		Latitude:              response.Latitude,
-		Longitude:             response.Longitude,
-		StationDistance:       response.StationDistance,
-		TideType:              tideType,
-		CalculationMethod:     response.CalculationMethod,
-		Predictions:           predictions,
-		Extremes:              extremes,
-		TimeZoneOffsetSeconds: tzOffset,
+	numDays := tide.DefaultExtremesDays
+	if days != nil {
+		numDays = *days
+	}
+	lows, err := r.TideService.GetDaylightLows(ctx, stationID, below, startDate, numDays)
+	if err != nil {
+		return nil, err
+	}
+	return toDaylightLows(lows), nil
+}
+
+// CompareStations is the resolver for the compareStations field.
+func (r *queryResolver) CompareStations(ctx context.Context, stationIds []string, startDateTime *string, endDateTime *string, interval *int, tz *string) (*model.StationComparison, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
+	}
+
+	ctx, err := withTimeZone(ctx, tz)
+	if err != nil {
+		return nil, err
+	}
+
+	intervalMinutes := tide.DefaultCompareInterval
+	if interval != nil {
+		intervalMinutes = *interval
+	}
+	comparison, err := r.TideService.CompareStations(ctx, stationIds, startDateTime, endDateTime, intervalMinutes)
+	if err != nil {
+		return nil, err
+	}
+	return toStationComparison(comparison), nil
+}
+
+// Observation is the resolver for the observation field.
+func (r *queryResolver) Observation(ctx context.Context, stationID string, product string) (*model.StationObservation, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
+	}
+
+	response, err := r.TideService.GetLatestObservation(ctx, stationID, product)
+	if err != nil {
+		return nil, err
+	}
+	return &model.StationObservation{
+		StationID:   response.StationID,
+		StationName: response.StationName,
+		Product:     response.Product,
+		Observation: toObservation(&response.Observation),
 	}, nil
 }
 
+// Accuracy is the resolver for the accuracy field.
+func (r *queryResolver) Accuracy(ctx context.Context, stationID string, days *int) (*model.AccuracyStats, error) {
+	if r.TideService == nil {
+		return nil, fmt.Errorf("TideService is not initialized")
+	}
+
+	numDays := accuracy.DefaultDays
+	if days != nil {
+		numDays = *days
+	}
+	stats, err := r.TideService.GetAccuracy(ctx, stationID, numDays)
+	if err != nil {
+		return nil, err
+	}
+	return toAccuracyStats(stats), nil
+}
+
+// StationChanges is the resolver for the stationChanges field.
+func (r *queryResolver) StationChanges(ctx context.Context, since *string, limit *int) ([]*model.StationListChange, error) {
+	return r.stationChanges(ctx, since, limit)
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*model.UserProfile, error) {
+	service, userID, err := r.userData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := service.Profile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserProfile(profile), nil
+}
+
+// AddFavorite is the resolver for the addFavorite field.
+func (r *mutationResolver) AddFavorite(ctx context.Context, stationID string) (*model.UserProfile, error) {
+	service, userID, err := r.userData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := service.AddFavorite(ctx, userID, stationID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserProfile(profile), nil
+}
+
+// RemoveFavorite is the resolver for the removeFavorite field.
+func (r *mutationResolver) RemoveFavorite(ctx context.Context, stationID string) (*model.UserProfile, error) {
+	service, userID, err := r.userData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := service.RemoveFavorite(ctx, userID, stationID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserProfile(profile), nil
+}
+
+// RestoreFavorite is the resolver for the restoreFavorite field.
+func (r *mutationResolver) RestoreFavorite(ctx context.Context, stationID string) (*model.UserProfile, error) {
+	service, userID, err := r.userData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := service.RestoreFavorite(ctx, userID, stationID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserProfile(profile), nil
+}
+
+// UpdatePreferences is the resolver for the updatePreferences field.
+func (r *mutationResolver) UpdatePreferences(ctx context.Context, units *string, datum *string) (*model.UserProfile, error) {
+	service, userID, err := r.userData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := service.UpdatePreferences(ctx, userID, units, datum)
+	if err != nil {
+		return nil, err
+	}
+	return toUserProfile(profile), nil
+}
+
+// ExportTideTable is the resolver for the exportTideTable field.
+func (r *mutationResolver) ExportTideTable(ctx context.Context, stationID string, year int, format *string) (*model.TideTableExport, error) {
+	if r.Exports == nil {
+		return nil, errExportsDisabled
+	}
+	req := export.Request{StationID: stationID, Year: year, Format: export.FormatPDF}
+	if format != nil {
+		req.Format = export.Format(*format)
+	}
+	result, err := r.Exports.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return toTideTableExport(result), nil
+}
+
+// ReportObservation is the resolver for the reportObservation field.
+func (r *mutationResolver) ReportObservation(ctx context.Context, stationID string, timestamp int, observedHeight *float64, condition *string, photoURL *string) (*model.ObservationReport, error) {
+	service, userID, err := r.reports(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report, err := service.Submit(ctx, userID, reports.Submission{
+		StationID:      stationID,
+		Timestamp:      models.Millis(timestamp),
+		ObservedHeight: observedHeight,
+		Condition:      condition,
+		PhotoURL:       photoURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toObservationReport(report), nil
+}
+
+// Mutation returns generated1.MutationResolver implementation.
+func (r *Resolver) Mutation() generated1.MutationResolver { return &mutationResolver{r} }
+
 // Query returns generated1.QueryResolver implementation.
 func (r *Resolver) Query() generated1.QueryResolver { return &queryResolver{r} }
 
+type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -0,0 +1,123 @@
+// Package accuracy tracks how closely tide predictions match what reference stations'
+// water level gauges observe. A scheduled job samples each tracked station's latest
+// reading against the prediction for the same moment, and sums the errors by day; the
+// sums give each station's mean absolute error and bias, and from them the confidence
+// tide responses carry.
+package accuracy
+
+import (
+	"math"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+const (
+	// DefaultDays is how many days accuracy is summarized over when a request doesn't say
+	DefaultDays = 7
+	// MaxDays is the most days accuracy can be summarized over
+	MaxDays = 30
+	// MinSamples is how many samples a summary needs before confidence is judged from it,
+	// a day's worth at the job's hourly schedule
+	MinSamples = 24
+
+	// Mean absolute errors, in feet, below which confidence is high or medium
+	highConfidenceError   = 0.25
+	mediumConfidenceError = 0.5
+
+	// retention is how long daily totals are kept, a little past the longest summary
+	retention = (MaxDays + 5) * 24 * time.Hour
+)
+
+// Sample is a gauge reading and the level predicted for the same moment, both in feet
+// above MLLW
+type Sample struct {
+	StationID string
+	Timestamp models.Millis
+	Predicted float64
+	Observed  float64
+}
+
+// DayTotals are a station's sample errors summed over a UTC day, the stored record the
+// summaries are built from
+type DayTotals struct {
+	StationID   string  `dynamodbav:"stationId"`
+	Date        string  `dynamodbav:"date"`
+	Samples     int     `dynamodbav:"samples"`
+	SumError    float64 `dynamodbav:"sumError"`
+	SumAbsError float64 `dynamodbav:"sumAbsError"`
+	// LastObserved is the newest sample's reading time, so a reading the job sees twice
+	// is only counted once
+	LastObserved models.Millis `dynamodbav:"lastObserved"`
+	// TTL is when DynamoDB may delete the item, in Unix seconds
+	TTL int64 `dynamodbav:"ttl"`
+}
+
+// Add counts sample in the totals, reporting false when it's no newer than the last
+// sample counted
+func (d *DayTotals) Add(sample Sample) bool {
+	if d.Samples > 0 && sample.Timestamp <= d.LastObserved {
+		return false
+	}
+	diff := sample.Observed - sample.Predicted
+	d.Samples++
+	d.SumError += diff
+	d.SumAbsError += math.Abs(diff)
+	d.LastObserved = sample.Timestamp
+	return true
+}
+
+// dateOf returns the UTC day a sample's totals are kept under
+func dateOf(timestamp models.Millis) string {
+	return timestamp.Time().Format("2006-01-02")
+}
+
+// Summary is a station's accuracy over Days days
+type Summary struct {
+	Days    int
+	Samples int
+	// MeanAbsoluteError and Bias are in feet, and zero without samples
+	MeanAbsoluteError float64
+	Bias              float64
+}
+
+// Summarize combines daily totals into a summary over days days
+func Summarize(days int, totals []DayTotals) Summary {
+	summary := Summary{Days: days}
+	var sumError, sumAbsError float64
+	for _, t := range totals {
+		summary.Samples += t.Samples
+		sumError += t.SumError
+		sumAbsError += t.SumAbsError
+	}
+	if summary.Samples > 0 {
+		summary.MeanAbsoluteError = sumAbsError / float64(summary.Samples)
+		summary.Bias = sumError / float64(summary.Samples)
+	}
+	return summary
+}
+
+// Confidence judges the summary's predictions HIGH, MEDIUM or LOW by their mean absolute
+// error, or returns "" when there are fewer than MinSamples samples to judge by
+func (s Summary) Confidence() string {
+	switch {
+	case s.Samples < MinSamples:
+		return ""
+	case s.MeanAbsoluteError < highConfidenceError:
+		return models.ConfidenceHigh
+	case s.MeanAbsoluteError < mediumConfidenceError:
+		return models.ConfidenceMedium
+	default:
+		return models.ConfidenceLow
+	}
+}
+
+// dates returns the days UTC dates ending with now's
+func dates(now time.Time, days int) []string {
+	now = now.UTC()
+	result := make([]string, days)
+	for i := range result {
+		result[i] = now.AddDate(0, 0, i-days+1).Format("2006-01-02")
+	}
+	return result
+}
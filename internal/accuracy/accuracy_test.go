@@ -0,0 +1,61 @@
+package accuracy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDayTotals_Add(t *testing.T) {
+	noon := models.MillisOf(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	var totals DayTotals
+
+	assert.True(t, totals.Add(Sample{Timestamp: noon, Predicted: 5, Observed: 5.4}))
+	assert.False(t, totals.Add(Sample{Timestamp: noon, Predicted: 5, Observed: 5.4}), "a reading seen twice is counted once")
+	assert.True(t, totals.Add(Sample{Timestamp: noon.Add(time.Hour), Predicted: 3, Observed: 2.8}))
+
+	assert.Equal(t, 2, totals.Samples)
+	assert.InDelta(t, 0.2, totals.SumError, 1e-9)
+	assert.InDelta(t, 0.6, totals.SumAbsError, 1e-9)
+	assert.Equal(t, noon.Add(time.Hour), totals.LastObserved)
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(7, []DayTotals{
+		{Samples: 10, SumError: 1, SumAbsError: 2},
+		{Samples: 30, SumError: -3, SumAbsError: 6},
+	})
+	assert.Equal(t, 7, summary.Days)
+	assert.Equal(t, 40, summary.Samples)
+	assert.InDelta(t, 0.2, summary.MeanAbsoluteError, 1e-9)
+	assert.InDelta(t, -0.05, summary.Bias, 1e-9)
+
+	empty := Summarize(7, nil)
+	assert.Zero(t, empty.Samples)
+	assert.Zero(t, empty.MeanAbsoluteError)
+}
+
+func TestSummary_Confidence(t *testing.T) {
+	tests := []struct {
+		samples int
+		mae     float64
+		want    string
+	}{
+		{MinSamples - 1, 0.01, ""},
+		{MinSamples, 0.1, models.ConfidenceHigh},
+		{MinSamples, 0.25, models.ConfidenceMedium},
+		{100, 0.49, models.ConfidenceMedium},
+		{100, 0.8, models.ConfidenceLow},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Summary{Samples: tt.samples, MeanAbsoluteError: tt.mae}.Confidence(), "%d samples, MAE %.2f", tt.samples, tt.mae)
+	}
+}
+
+func TestDates(t *testing.T) {
+	// Dates are UTC whatever the zone now is given in
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.FixedZone("PST", -8*3600))
+	assert.Equal(t, []string{"2024-02-29", "2024-03-01", "2024-03-02"}, dates(now, 3))
+}
@@ -0,0 +1,69 @@
+package accuracy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+// DefaultReportTTL is how long a summary is reused. The job adds a sample an hour, so
+// summaries change little within one.
+const DefaultReportTTL = time.Hour
+
+type reportEntry struct {
+	summary   Summary
+	expiresAt time.Time
+}
+
+// Reporter summarizes tracked stations' accuracy from a Store, reusing each summary for
+// a while so tide lookups don't each read the store. Stations that aren't tracked have no
+// samples and aren't looked up.
+type Reporter struct {
+	store   Store
+	tracked map[string]bool
+	entries *lru.Cache[string, reportEntry]
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewReporter returns a Reporter for the tracked stations
+func NewReporter(store Store, stations []string, ttl time.Duration) (*Reporter, error) {
+	tracked := make(map[string]bool, len(stations))
+	for _, id := range stations {
+		tracked[id] = true
+	}
+	// Each station is summarized over a few day counts at most
+	entries, err := lru.New[string, reportEntry](4*len(stations) + 1)
+	if err != nil {
+		return nil, fmt.Errorf("creating accuracy cache: %w", err)
+	}
+	return &Reporter{store: store, tracked: tracked, entries: entries, ttl: ttl, now: time.Now}, nil
+}
+
+// Tracked reports whether the station's accuracy is tracked
+func (r *Reporter) Tracked(stationID string) bool {
+	return r.tracked[stationID]
+}
+
+// Summary returns the station's accuracy over the last days UTC days, today included
+func (r *Reporter) Summary(ctx context.Context, stationID string, days int) (Summary, error) {
+	if !r.tracked[stationID] {
+		return Summary{Days: days}, nil
+	}
+	key := stationID + ":" + strconv.Itoa(days)
+	now := r.now()
+	if entry, ok := r.entries.Get(key); ok && now.Before(entry.expiresAt) {
+		return entry.summary, nil
+	}
+
+	totals, err := r.store.Totals(ctx, stationID, dates(now, days))
+	if err != nil {
+		return Summary{}, err
+	}
+	summary := Summarize(days, totals)
+	r.entries.Add(key, reportEntry{summary: summary, expiresAt: now.Add(r.ttl)})
+	return summary, nil
+}
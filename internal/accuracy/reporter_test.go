@@ -0,0 +1,69 @@
+package accuracy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore counts the totals read through it
+type countingStore struct {
+	Store
+	reads int
+	err   error
+}
+
+func (c *countingStore) Totals(ctx context.Context, stationID string, dates []string) ([]DayTotals, error) {
+	c.reads++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.Store.Totals(ctx, stationID, dates)
+}
+
+func TestReporter_Summary(t *testing.T) {
+	ctx := context.Background()
+	store := &countingStore{Store: NewMemoryStore()}
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-03", 1), Predicted: 4, Observed: 4.3}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-01", 1), Predicted: 4, Observed: 4.1}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-05-01", 1), Predicted: 4, Observed: 9}))
+
+	reporter, err := NewReporter(store, []string{"9447130"}, time.Hour)
+	require.NoError(t, err)
+	reporter.now = func() time.Time { return now }
+
+	summary, err := reporter.Summary(ctx, "9447130", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Samples, "days before the window aren't counted")
+	assert.InDelta(t, 0.2, summary.MeanAbsoluteError, 1e-9)
+	assert.InDelta(t, 0.2, summary.Bias, 1e-9)
+
+	_, err = reporter.Summary(ctx, "9447130", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.reads, "the summary is reused")
+
+	now = now.Add(2 * time.Hour)
+	_, err = reporter.Summary(ctx, "9447130", 7)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.reads, "an expired summary is read again")
+}
+
+func TestReporter_Untracked(t *testing.T) {
+	store := &countingStore{Store: NewMemoryStore(), err: errors.New("unreachable")}
+	reporter, err := NewReporter(store, []string{"9447130"}, time.Hour)
+	require.NoError(t, err)
+
+	assert.False(t, reporter.Tracked("8454000"))
+	summary, err := reporter.Summary(context.Background(), "8454000", 7)
+	require.NoError(t, err)
+	assert.Zero(t, summary.Samples)
+	assert.Zero(t, store.reads, "untracked stations aren't looked up")
+
+	_, err = reporter.Summary(context.Background(), "9447130", 7)
+	assert.ErrorContains(t, err, "unreachable")
+}
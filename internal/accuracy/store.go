@@ -0,0 +1,159 @@
+package accuracy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+)
+
+// maxBatchAttempts bounds how many times keys DynamoDB leaves unprocessed are asked for again
+const maxBatchAttempts = 3
+
+// Store persists stations' daily totals
+type Store interface {
+	// Add counts sample in its station's totals for the sample's UTC date
+	Add(ctx context.Context, sample Sample) error
+	// Totals returns the station's totals for those of dates that have any, in date order
+	Totals(ctx context.Context, stationID string, dates []string) ([]DayTotals, error)
+}
+
+// DynamoStore keeps one item per station and UTC date, keyed by stationId and date, and
+// lets DynamoDB expire them once they're too old to be summarized. Only the scheduled job
+// writes, so an item is read, added to and put back without a condition.
+type DynamoStore struct {
+	client cache.DynamoDBClient
+	table  string
+	now    func() time.Time
+}
+
+var _ Store = (*DynamoStore)(nil)
+
+func NewDynamoStore(client cache.DynamoDBClient, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table, now: time.Now}
+}
+
+func totalsKey(stationID, date string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"stationId": &types.AttributeValueMemberS{Value: stationID},
+		"date":      &types.AttributeValueMemberS{Value: date},
+	}
+}
+
+func (s *DynamoStore) Add(ctx context.Context, sample Sample) error {
+	date := dateOf(sample.Timestamp)
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            totalsKey(sample.StationID, date),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("getting accuracy totals from DynamoDB: %w", err)
+	}
+
+	totals := DayTotals{StationID: sample.StationID, Date: date}
+	if result.Item != nil {
+		if err := attributevalue.UnmarshalMap(result.Item, &totals); err != nil {
+			return fmt.Errorf("unmarshaling accuracy totals: %w", err)
+		}
+	}
+	if !totals.Add(sample) {
+		return nil
+	}
+	totals.TTL = s.now().Add(retention).Unix()
+
+	item, err := attributevalue.MarshalMap(totals)
+	if err != nil {
+		return fmt.Errorf("marshaling accuracy totals: %w", err)
+	}
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      item,
+	}); err != nil {
+		return fmt.Errorf("putting accuracy totals in DynamoDB: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStore) Totals(ctx context.Context, stationID string, dates []string) ([]DayTotals, error) {
+	if len(dates) == 0 {
+		return nil, nil
+	}
+	pending := make([]map[string]types.AttributeValue, len(dates))
+	for i, date := range dates {
+		pending[i] = totalsKey(stationID, date)
+	}
+
+	var totals []DayTotals
+	for attempt := 0; len(pending) > 0 && attempt < maxBatchAttempts; attempt++ {
+		output, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				s.table: {Keys: pending},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("batch getting accuracy totals from DynamoDB: %w", err)
+		}
+		for _, item := range output.Responses[s.table] {
+			var day DayTotals
+			if err := attributevalue.UnmarshalMap(item, &day); err != nil {
+				return nil, fmt.Errorf("unmarshaling accuracy totals: %w", err)
+			}
+			totals = append(totals, day)
+		}
+		pending = output.UnprocessedKeys[s.table].Keys
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("DynamoDB left %d accuracy totals unprocessed", len(pending))
+	}
+
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date < totals[j].Date })
+	return totals, nil
+}
+
+// MemoryStore keeps totals in memory, for tests and local runs
+type MemoryStore struct {
+	mu     sync.Mutex
+	totals map[string]DayTotals
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{totals: map[string]DayTotals{}}
+}
+
+func (m *MemoryStore) Add(_ context.Context, sample Sample) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	date := dateOf(sample.Timestamp)
+	key := sample.StationID + ":" + date
+	totals, ok := m.totals[key]
+	if !ok {
+		totals = DayTotals{StationID: sample.StationID, Date: date}
+	}
+	if totals.Add(sample) {
+		m.totals[key] = totals
+	}
+	return nil
+}
+
+func (m *MemoryStore) Totals(_ context.Context, stationID string, dates []string) ([]DayTotals, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var totals []DayTotals
+	for _, date := range dates {
+		if day, ok := m.totals[stationID+":"+date]; ok {
+			totals = append(totals, day)
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Date < totals[j].Date })
+	return totals, nil
+}
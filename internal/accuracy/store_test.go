@@ -0,0 +1,168 @@
+package accuracy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB keeps items in memory keyed by stationId and date. BatchGetItem answers
+// at most perBatch keys a call, leaving the rest unprocessed, when perBatch is set.
+type fakeDynamoDB struct {
+	mu       sync.Mutex
+	items    map[string]map[string]types.AttributeValue
+	perBatch int
+	err      error
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+}
+
+func keyOf(item map[string]types.AttributeValue) string {
+	return item["stationId"].(*types.AttributeValueMemberS).Value + "#" + item["date"].(*types.AttributeValueMemberS).Value
+}
+
+func (f *fakeDynamoDB) GetItem(_ context.Context, params *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.GetItemOutput{Item: f.items[keyOf(params.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.items[keyOf(params.Item)] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchGetItem(_ context.Context, params *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	output := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]types.AttributeValue{},
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+	for table, request := range params.RequestItems {
+		keys := request.Keys
+		if f.perBatch > 0 && len(keys) > f.perBatch {
+			output.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: keys[f.perBatch:]}
+			keys = keys[:f.perBatch]
+		}
+		for _, key := range keys {
+			if item, ok := f.items[keyOf(key)]; ok {
+				output.Responses[table] = append(output.Responses[table], item)
+			}
+		}
+	}
+	return output, nil
+}
+
+func (f *fakeDynamoDB) BatchWriteItem(context.Context, *dynamodb.BatchWriteItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (f *fakeDynamoDB) ListTables(context.Context, *dynamodb.ListTablesInput, ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	return &dynamodb.ListTablesOutput{}, nil
+}
+
+func at(date string, hour int) models.Millis {
+	day, _ := time.Parse("2006-01-02", date)
+	return models.MillisOf(day.Add(time.Duration(hour) * time.Hour))
+}
+
+func TestDynamoStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB()
+	store := NewDynamoStore(client, "accuracy")
+	now := time.Date(2024, 6, 2, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-01", 10), Predicted: 4, Observed: 4.5}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-01", 11), Predicted: 4, Observed: 3.9}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-01", 11), Predicted: 4, Observed: 3.9}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-02", 1), Predicted: 2, Observed: 2.2}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "8454000", Timestamp: at("2024-06-02", 1), Predicted: 1, Observed: 9}))
+
+	totals, err := store.Totals(ctx, "9447130", []string{"2024-05-31", "2024-06-01", "2024-06-02"})
+	require.NoError(t, err)
+	require.Len(t, totals, 2, "days without samples are skipped")
+	assert.Equal(t, "2024-06-01", totals[0].Date)
+	assert.Equal(t, 2, totals[0].Samples, "a reading seen twice is counted once")
+	assert.InDelta(t, 0.4, totals[0].SumError, 1e-9)
+	assert.InDelta(t, 0.6, totals[0].SumAbsError, 1e-9)
+	assert.Equal(t, now.Add(retention).Unix(), totals[0].TTL)
+	assert.Equal(t, "2024-06-02", totals[1].Date)
+	assert.Equal(t, 1, totals[1].Samples)
+}
+
+func TestDynamoStore_UnprocessedKeys(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB()
+	store := NewDynamoStore(client, "accuracy")
+	for _, date := range []string{"2024-06-01", "2024-06-02", "2024-06-03"} {
+		require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at(date, 0), Observed: 1}))
+	}
+
+	client.perBatch = 1
+	totals, err := store.Totals(ctx, "9447130", []string{"2024-06-03", "2024-06-02", "2024-06-01"})
+	require.NoError(t, err)
+	require.Len(t, totals, 3, "unprocessed keys are asked for again")
+	assert.Equal(t, "2024-06-01", totals[0].Date)
+
+	_, err = store.Totals(ctx, "9447130", []string{"2024-06-01", "2024-06-02", "2024-06-03", "2024-06-04"})
+	assert.ErrorContains(t, err, "left 1 accuracy totals unprocessed")
+}
+
+func TestDynamoStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB()
+	client.err = errors.New("throttled")
+	store := NewDynamoStore(client, "accuracy")
+
+	assert.ErrorContains(t, store.Add(ctx, Sample{StationID: "9447130"}), "getting accuracy totals from DynamoDB: throttled")
+	_, err := store.Totals(ctx, "9447130", []string{"2024-06-01"})
+	assert.ErrorContains(t, err, "batch getting accuracy totals from DynamoDB: throttled")
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-02", 1), Predicted: 1, Observed: 2}))
+	require.NoError(t, store.Add(ctx, Sample{StationID: "9447130", Timestamp: at("2024-06-01", 1), Predicted: 1, Observed: 0}))
+
+	totals, err := store.Totals(ctx, "9447130", []string{"2024-06-02", "2024-06-01"})
+	require.NoError(t, err)
+	require.Len(t, totals, 2)
+	assert.Equal(t, "2024-06-01", totals[0].Date)
+	assert.InDelta(t, -1, totals[0].SumError, 1e-9)
+}
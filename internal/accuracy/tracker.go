@@ -0,0 +1,59 @@
+package accuracy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/rs/zerolog/log"
+)
+
+// Predictor gives the water level predicted at a station for a moment, in feet above MLLW
+type Predictor interface {
+	PredictedLevel(ctx context.Context, stationID string, at time.Time) (float64, error)
+}
+
+// Tracker records a sample for each tracked station. Run it on a schedule; each run
+// compares the stations' latest gauge readings with their predictions.
+type Tracker struct {
+	Store     Store
+	Observer  observation.Observer
+	Predictor Predictor
+	Stations  []string
+}
+
+// Run samples every station, going on past those that fail and returning their errors
+// joined
+func (t *Tracker) Run(ctx context.Context) error {
+	var errs []error
+	recorded := 0
+	for _, stationID := range t.Stations {
+		if err := t.sample(ctx, stationID); err != nil {
+			log.Warn().Err(err).Str("station_id", stationID).Msg("Sampling prediction accuracy failed")
+			errs = append(errs, fmt.Errorf("station %s: %w", stationID, err))
+			continue
+		}
+		recorded++
+	}
+	log.Info().Int("stations", len(t.Stations)).Int("sampled", recorded).Msg("Sampled prediction accuracy")
+	return errors.Join(errs...)
+}
+
+func (t *Tracker) sample(ctx context.Context, stationID string) error {
+	reading, err := t.Observer.Latest(ctx, stationID, observation.WaterLevel)
+	if err != nil {
+		return fmt.Errorf("reading water level: %w", err)
+	}
+	predicted, err := t.Predictor.PredictedLevel(ctx, stationID, reading.Timestamp.Time())
+	if err != nil {
+		return fmt.Errorf("predicting water level: %w", err)
+	}
+	return t.Store.Add(ctx, Sample{
+		StationID: stationID,
+		Timestamp: reading.Timestamp,
+		Predicted: predicted,
+		Observed:  reading.Value,
+	})
+}
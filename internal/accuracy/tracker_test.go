@@ -0,0 +1,65 @@
+package accuracy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubObserver map[string]*models.Observation
+
+func (s stubObserver) Latest(_ context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+	if product != observation.WaterLevel {
+		return nil, errors.New("unexpected product " + product.Name)
+	}
+	if reading, ok := s[stationID]; ok {
+		return reading, nil
+	}
+	return nil, errors.New("no water_level reading")
+}
+
+// predictorFunc adapts a function to Predictor
+type predictorFunc func(ctx context.Context, stationID string, at time.Time) (float64, error)
+
+func (f predictorFunc) PredictedLevel(ctx context.Context, stationID string, at time.Time) (float64, error) {
+	return f(ctx, stationID, at)
+}
+
+func TestTracker_Run(t *testing.T) {
+	ctx := context.Background()
+	readAt := at("2024-06-01", 10)
+	store := NewMemoryStore()
+	var predictedAt time.Time
+	tracker := &Tracker{
+		Store: store,
+		Observer: stubObserver{
+			"9447130": {Timestamp: readAt, Value: 6.2},
+			"9446484": {Timestamp: readAt, Value: 1},
+		},
+		Predictor: predictorFunc(func(_ context.Context, stationID string, at time.Time) (float64, error) {
+			if stationID == "9446484" {
+				return 0, errors.New("NOAA is down")
+			}
+			predictedAt = at
+			return 6, nil
+		}),
+		Stations: []string{"9447130", "8454000", "9446484"},
+	}
+
+	err := tracker.Run(ctx)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "station 8454000: reading water level: no water_level reading")
+	assert.ErrorContains(t, err, "station 9446484: predicting water level: NOAA is down")
+	assert.Equal(t, readAt.Time(), predictedAt, "the prediction is for the reading's time")
+
+	totals, err := store.Totals(ctx, "9447130", []string{"2024-06-01"})
+	require.NoError(t, err)
+	require.Len(t, totals, 1, "stations that fail don't stop the others")
+	assert.InDelta(t, 0.2, totals[0].SumError, 1e-9)
+}
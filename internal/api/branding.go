@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/rs/zerolog/log"
+)
+
+// Branded wraps next so the JSON objects it answers a tenant's requests with carry the
+// tenant's branding in a top-level tenant field, for its app to present itself with.
+// Other responses, like images, GeoJSON and MessagePack, are left as they are.
+func Branded(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := next(ctx, request)
+		t := tenant.FromContext(ctx)
+		if err != nil || t == nil || response.IsBase64Encoded || headerValue(response.Headers, "Content-Type") != "application/json" {
+			return response, err
+		}
+		body := strings.TrimSpace(response.Body)
+		if !strings.HasPrefix(body, "{") {
+			return response, err
+		}
+		metadata, merr := json.Marshal(t.Metadata())
+		if merr != nil {
+			log.Warn().Err(merr).Str("tenant", t.ID).Msg("Failed to encode tenant branding")
+			return response, err
+		}
+		// Spliced in rather than decoded and encoded again, so the body keeps its field order
+		rest := strings.TrimSpace(body[1:])
+		if rest != "}" {
+			rest = "," + rest
+		}
+		response.Body = `{"tenant":` + string(metadata) + rest
+		return response, err
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranded(t *testing.T) {
+	acme := &tenant.Tenant{ID: "acme", Branding: tenant.Branding{Name: "Acme Tides", PrimaryColor: "#004488"}}
+	respond := func(response events.APIGatewayProxyResponse) HandlerFunc {
+		return Branded(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			return response, nil
+		})
+	}
+	ctx := tenant.WithTenant(context.Background(), acme)
+
+	response, err := respond(events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"responseType":"stations","stations":[]}`,
+	})(ctx, events.APIGatewayProxyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"tenant":{"id":"acme","name":"Acme Tides","primaryColor":"#004488"},"responseType":"stations","stations":[]}`, response.Body)
+
+	response, _ = respond(events.APIGatewayProxyResponse{Headers: map[string]string{"content-type": "application/json"}, Body: "{ }"})(ctx, events.APIGatewayProxyRequest{})
+	assert.Equal(t, `{"tenant":{"id":"acme","name":"Acme Tides","primaryColor":"#004488"}}`, response.Body)
+
+	unbranded := []events.APIGatewayProxyResponse{
+		{Headers: map[string]string{"Content-Type": GeoJSONMediaType}, Body: `{"type":"FeatureCollection"}`},
+		{Headers: map[string]string{"Content-Type": "image/png"}, Body: "iVBOR", IsBase64Encoded: true},
+		{Headers: map[string]string{"Content-Type": "application/json"}, Body: `[1,2]`},
+	}
+	for _, want := range unbranded {
+		response, _ = respond(want)(ctx, events.APIGatewayProxyRequest{})
+		assert.Equal(t, want, response)
+	}
+
+	response, _ = respond(events.APIGatewayProxyResponse{Headers: map[string]string{"Content-Type": "application/json"}, Body: `{}`})(context.Background(), events.APIGatewayProxyRequest{})
+	assert.Equal(t, `{}`, response.Body, "requests that aren't a tenant's are left alone")
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Envelope is the V3 success body: the versioned response as data, where it came from
+// as meta, and the parts of it that are missing or approximated as warnings
+type Envelope struct {
+	Data     interface{}              `json:"data"`
+	Meta     Meta                     `json:"meta"`
+	Warnings []models.ResponseWarning `json:"warnings"`
+}
+
+// Meta is an envelope's data provenance, for clients to display beside it
+type Meta struct {
+	GeneratedAt models.Millis `json:"generatedAt"`
+	// CacheStatus is hit when all of the data came from the cache and miss when some of it
+	// was fetched from its source; it's left out for data that isn't cached
+	CacheStatus string `json:"cacheStatus,omitempty"`
+	// Source names the data sources, e.g. NOAA, comma-separated when there are several
+	Source string `json:"source,omitempty"`
+	// Units and Datum are those of the data's measurements, e.g. ft and MLLW for tide
+	// heights; they're left out for data without any
+	Units string `json:"units,omitempty"`
+	Datum string `json:"datum,omitempty"`
+}
+
+// warnedResponse is a body that carries its own warnings, which an envelope moves up
+// beside the data
+type warnedResponse interface {
+	TakeWarnings() []models.ResponseWarning
+}
+
+// envelopeScope says which responses are enveloped, for the OpenAPI document
+const envelopeScope = "From v3, the JSON success body of every operation here is wrapped in an envelope " +
+	"holding it as data, with meta and warnings, except getOEmbed's, which oEmbed consumers read as it is. " +
+	"Errors and GeoJSON aren't enveloped, nor are the endpoints this document leaves out: /graphql, " +
+	"/api/tides/chart, /api/tides/table, the admin API under /admin, and the self-hosted server's " +
+	"/health and /metrics."
+
+// envelopes reports whether version wraps its bodies in an Envelope. Only versioned
+// operations are; the unversioned admin, /health and /metrics responses never are.
+func envelopes(version Version) bool {
+	return version >= V3
+}
+
+// NewEnvelope wraps data with the provenance ctx's request recorded
+func NewEnvelope(ctx context.Context, data interface{}) *Envelope {
+	envelope := &Envelope{
+		Data:     data,
+		Meta:     Meta{GeneratedAt: models.MillisOf(time.Now())},
+		Warnings: []models.ResponseWarning{},
+	}
+	if warned, ok := data.(warnedResponse); ok {
+		if warnings := warned.TakeWarnings(); len(warnings) > 0 {
+			envelope.Warnings = warnings
+		}
+	}
+	if provenance := models.ProvenanceFrom(ctx); provenance != nil {
+		envelope.Meta.CacheStatus = provenance.CacheStatus()
+		envelope.Meta.Source = provenance.Source()
+		envelope.Meta.Units, envelope.Meta.Datum = provenance.Units()
+	}
+	return envelope
+}
+
+// versionedBody is body as version serves it
+func versionedBody(ctx context.Context, version Version, body interface{}) interface{} {
+	if envelopes(version) {
+		return NewEnvelope(ctx, body)
+	}
+	return body
+}
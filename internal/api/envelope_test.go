@@ -0,0 +1,32 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	ctx := models.WithProvenance(context.Background())
+	models.RecordSource(ctx, models.SourceNOAA)
+	models.RecordUnits(ctx, models.HeightUnits, models.DefaultDatum)
+	models.RecordCacheLookups(ctx, 3, 0)
+	warnings := []models.ResponseWarning{{Code: models.WarningWeatherUnavailable, Message: "weather is unavailable"}}
+	tide := &TideResponseV2{APIResponse: APIResponse{ResponseType: "tide"}, Warnings: warnings}
+
+	before := models.MillisOf(time.Now())
+	envelope := NewEnvelope(ctx, tide)
+	assert.Same(t, tide, envelope.Data)
+	assert.GreaterOrEqual(t, envelope.Meta.GeneratedAt, before)
+	assert.Equal(t, Meta{GeneratedAt: envelope.Meta.GeneratedAt, CacheStatus: models.CacheHit, Source: "NOAA", Units: "ft", Datum: "MLLW"}, envelope.Meta)
+	assert.Equal(t, warnings, envelope.Warnings)
+	assert.Nil(t, tide.Warnings, "the warnings are moved, not copied")
+
+	// Without recorded provenance there's only when it was generated
+	envelope = NewEnvelope(context.Background(), NewStationsResponse(nil))
+	assert.Equal(t, Meta{GeneratedAt: envelope.Meta.GeneratedAt}, envelope.Meta)
+	assert.Equal(t, []models.ResponseWarning{}, envelope.Warnings)
+}
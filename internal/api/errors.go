@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrorCode identifies the kind of an error in REST error bodies and GraphQL error
+// extensions, so clients can branch on it rather than on the message, which may change
+type ErrorCode string
+
+const (
+	CodeInvalidRequest       ErrorCode = "INVALID_REQUEST"
+	CodeInvalidCoordinates   ErrorCode = "INVALID_COORDINATES"
+	CodeInvalidRange         ErrorCode = "INVALID_RANGE"
+	CodeRangeTooLarge        ErrorCode = "RANGE_TOO_LARGE"
+	CodeInvalidUnits         ErrorCode = "INVALID_UNITS"
+	CodeInvalidDatum         ErrorCode = "INVALID_DATUM"
+	CodeUnsupportedProduct   ErrorCode = "UNSUPPORTED_PRODUCT"
+	CodeUnsupportedVersion   ErrorCode = "UNSUPPORTED_VERSION"
+	CodeStationNotFound      ErrorCode = "STATION_NOT_FOUND"
+	CodeStationRetired       ErrorCode = "STATION_RETIRED"
+	CodeNoNearbyStation      ErrorCode = "NO_NEARBY_STATION"
+	CodeExportNotFound       ErrorCode = "EXPORT_NOT_FOUND"
+	CodeUnauthenticated      ErrorCode = "UNAUTHENTICATED"
+	CodeForbidden            ErrorCode = "FORBIDDEN"
+	CodeMethodNotAllowed     ErrorCode = "METHOD_NOT_ALLOWED"
+	CodeConflict             ErrorCode = "CONFLICT"
+	CodeIdempotencyKeyReused ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	CodeRateLimited          ErrorCode = "RATE_LIMITED"
+	CodeQuotaExceeded        ErrorCode = "QUOTA_EXCEEDED"
+	CodeRenderFailed         ErrorCode = "RENDER_FAILED"
+	CodeUpstreamUnavailable  ErrorCode = "UPSTREAM_UNAVAILABLE"
+	CodeServiceUnavailable   ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeInternal             ErrorCode = "INTERNAL_ERROR"
+)
+
+// CodeFor classifies an error returned by the services, falling back to CodeInternal
+// for errors the caller didn't cause
+func CodeFor(err error) ErrorCode {
+	var (
+		noStationErr *tide.NoNearbyStationError
+		rangeErr     *tide.InvalidRangeError
+		productErr   *tide.UnsupportedProductError
+		noaaErr      *tide.NoaaAPIError
+		versionErr   UnsupportedVersionError
+		coordErr     InvalidCoordinatesError
+		paramErr     *validate.Error
+		notReadyErr  *startup.NotReadyError
+		limitedErr   *ratelimit.Error
+		quotaErr     *quota.Error
+		retiredErr   *models.StationRetiredError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &noStationErr):
+		return CodeNoNearbyStation
+	case errors.As(err, &retiredErr):
+		return CodeStationRetired
+	case errors.Is(err, models.ErrStationNotFound):
+		return CodeStationNotFound
+	case errors.Is(err, export.ErrJobNotFound):
+		return CodeExportNotFound
+	case errors.As(err, &rangeErr):
+		if rangeErr.TooLarge {
+			return CodeRangeTooLarge
+		}
+		return CodeInvalidRange
+	case errors.As(err, &productErr):
+		return CodeUnsupportedProduct
+	case errors.Is(err, models.ErrInvalidUnits):
+		return CodeInvalidUnits
+	case errors.Is(err, models.ErrInvalidDatum):
+		return CodeInvalidDatum
+	case errors.As(err, &versionErr):
+		return CodeUnsupportedVersion
+	case errors.As(err, &coordErr):
+		return CodeInvalidCoordinates
+	case errors.As(err, &paramErr):
+		if paramErr.Parameter == "lat" || paramErr.Parameter == "lon" {
+			return CodeInvalidCoordinates
+		}
+		return CodeInvalidRequest
+	case errors.Is(err, userdata.ErrConflict), errors.Is(err, ErrIdempotencyInProgress):
+		return CodeConflict
+	case errors.Is(err, ErrIdempotencyKeyReused):
+		return CodeIdempotencyKeyReused
+	case errors.As(err, &noaaErr):
+		return CodeUpstreamUnavailable
+	case errors.As(err, &notReadyErr):
+		return CodeServiceUnavailable
+	case errors.As(err, &limitedErr):
+		return CodeRateLimited
+	case errors.As(err, &quotaErr):
+		return CodeQuotaExceeded
+	default:
+		return CodeInternal
+	}
+}
+
+// StatusFor returns the HTTP status a REST response with the given code is sent with
+func StatusFor(code ErrorCode) int {
+	switch code {
+	case CodeInvalidRequest, CodeInvalidCoordinates, CodeInvalidRange, CodeRangeTooLarge,
+		CodeInvalidUnits, CodeInvalidDatum, CodeUnsupportedProduct:
+		return http.StatusBadRequest
+	case CodeUnauthenticated:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeStationRetired:
+		return http.StatusMovedPermanently
+	case CodeStationNotFound, CodeNoNearbyStation, CodeExportNotFound:
+		return http.StatusNotFound
+	case CodeMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case CodeUnsupportedVersion:
+		return http.StatusNotAcceptable
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeRateLimited, CodeQuotaExceeded:
+		return http.StatusTooManyRequests
+	case CodeRenderFailed, CodeIdempotencyKeyReused:
+		return http.StatusUnprocessableEntity
+	case CodeUpstreamUnavailable:
+		return http.StatusBadGateway
+	case CodeServiceUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorFor answers an error returned by the services, taking the status from the code
+// CodeFor gives it so the two always agree. Errors that carry more than a message, such
+// as an invalid parameter, an unknown station or no nearby station, get their fuller body, and a function
+// that's still starting up or a client over its rate limit or quota is told when to retry
+// in a Retry-After header.
+func ErrorFor(err error) (events.APIGatewayProxyResponse, error) {
+	code := CodeFor(err)
+	status := StatusFor(code)
+	if status >= http.StatusInternalServerError {
+		log.Error().Err(err).Str("code", string(code)).Msg("Request failed")
+	} else {
+		log.Info().Err(err).Str("code", string(code)).Msg("Request rejected")
+	}
+
+	var (
+		noStationErr *tide.NoNearbyStationError
+		paramErr     *validate.Error
+		notReadyErr  *startup.NotReadyError
+		limitedErr   *ratelimit.Error
+		quotaErr     *quota.Error
+		panicErr     *recovery.Error
+	)
+	switch {
+	case errors.As(err, &panicErr):
+		return Error(code, "Internal error (fingerprint "+panicErr.Fingerprint+")", status)
+	case errors.As(err, &notReadyErr):
+		response, err := Error(code, "Service unavailable: "+err.Error(), status)
+		response.Headers["Retry-After"] = strconv.Itoa(notReadyErr.RetryAfterSeconds())
+		return response, err
+	case errors.As(err, &limitedErr):
+		response, err := Error(code, "Too many requests: "+err.Error(), status)
+		response.Headers["Retry-After"] = strconv.Itoa(limitedErr.RetryAfterSeconds())
+		return response, err
+	case errors.As(err, &quotaErr):
+		response, err := ErrorBody(NewQuotaExceededResponse(err.Error(), quotaErr), status)
+		response.Headers["Retry-After"] = strconv.Itoa(quotaErr.RetryAfterSeconds())
+		response.Headers[quota.ResetHeader] = quotaErr.ResetsAt.Format(time.RFC3339)
+		return response, err
+	case code == CodeStationNotFound, code == CodeStationRetired:
+		return StationNotFound(err.Error(), err)
+	case errors.As(err, &noStationErr):
+		return ErrorBody(NewNoNearbyStationResponse(err.Error(), noStationErr.Station,
+			noStationErr.MaxDistanceKm, noStationErr.PlaceName), status)
+	case errors.As(err, &paramErr) && code == CodeInvalidRequest:
+		return InvalidParameter(paramErr)
+	}
+
+	message := err.Error()
+	switch code {
+	case CodeInvalidRange, CodeRangeTooLarge:
+		message = "Invalid range: " + message
+	case CodeUpstreamUnavailable:
+		message = "Error fetching data from upstream service: " + message
+	case CodeInternal:
+		message = "Internal error: " + message
+	}
+	return Error(code, message, status)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/startup"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
+)
+
+func TestCodeFor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorCode
+	}{
+		{"nil", nil, ""},
+		{"station not found", fmt.Errorf("finding localStation: %w", fmt.Errorf("%w: 9999999", models.ErrStationNotFound)), CodeStationNotFound},
+		{"no nearby station", &tide.NoNearbyStationError{}, CodeNoNearbyStation},
+		{"range too large", fmt.Errorf("getting predictions: %w", tide.NewRangeTooLargeError("date range cannot exceed 5 days")), CodeRangeTooLarge},
+		{"invalid range", tide.NewInvalidRangeError("days must be between 1 and 31"), CodeInvalidRange},
+		{"unsupported product", &tide.UnsupportedProductError{StationID: "TEST001", Product: "wind"}, CodeUnsupportedProduct},
+		{"invalid units", models.ValidateUnits("imperial"), CodeInvalidUnits},
+		{"invalid datum", models.ValidateDatum("XYZ"), CodeInvalidDatum},
+		{"unsupported version", UnsupportedVersionError{Requested: "v9"}, CodeUnsupportedVersion},
+		{"invalid coordinates", InvalidCoordinatesError{}, CodeInvalidCoordinates},
+		{"out of range", fmt.Errorf("finding station: %w", validate.Latitude("lat", 91)), CodeInvalidCoordinates},
+		{"bad station ID", validate.StationID("stationId", "94 47130"), CodeInvalidRequest},
+		{"conflict", fmt.Errorf("saving profile: %w", userdata.ErrConflict), CodeConflict},
+		{"idempotent request in progress", ErrIdempotencyInProgress, CodeConflict},
+		{"idempotency key reused", ErrIdempotencyKeyReused, CodeIdempotencyKeyReused},
+		{"upstream", tide.NewNoaaAPIError("error making HTTP request for predictions", errors.New("timeout")), CodeUpstreamUnavailable},
+		{"starting up", &startup.NotReadyError{Err: errors.New("timeout")}, CodeServiceUnavailable},
+		{"rate limited", &ratelimit.Error{RetryAfter: time.Second}, CodeRateLimited},
+		{"export not found", fmt.Errorf("%w: 9447130/2025.pdf", export.ErrJobNotFound), CodeExportNotFound},
+		{"quota exceeded", &quota.Error{Quota: 100, ResetsAt: time.Now().Add(time.Hour)}, CodeQuotaExceeded},
+		{"anything else", errors.New("boom"), CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, CodeFor(tt.err))
+		})
+	}
+}
+
+func TestErrorFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   ErrorCode
+		wantError  string
+	}{
+		{"station not found", fmt.Errorf("%w: 9999999", models.ErrStationNotFound), http.StatusNotFound, CodeStationNotFound, "station not found: 9999999"},
+		{"no nearby station", &tide.NoNearbyStationError{MaxDistanceKm: 50}, http.StatusNotFound, CodeNoNearbyStation, ""},
+		{"range too large", tide.NewRangeTooLargeError("date range cannot exceed 5 days"), http.StatusBadRequest, CodeRangeTooLarge, "Invalid range: date range cannot exceed 5 days"},
+		{"unsupported product", &tide.UnsupportedProductError{StationID: "TEST001", Product: "wind"}, http.StatusBadRequest, CodeUnsupportedProduct, ""},
+		{"unsupported version", UnsupportedVersionError{Requested: "v9"}, http.StatusNotAcceptable, CodeUnsupportedVersion, ""},
+		{"bad station ID", validate.StationID("stationId", "94 47130"), http.StatusBadRequest, CodeInvalidRequest, "Invalid request parameters"},
+		{"upstream", tide.NewNoaaAPIError("error making HTTP request for predictions", errors.New("timeout")), http.StatusBadGateway, CodeUpstreamUnavailable, "Error fetching data from upstream service: "},
+		{"starting up", &startup.NotReadyError{Err: errors.New("timeout")}, http.StatusServiceUnavailable, CodeServiceUnavailable, "Service unavailable: service is starting up: timeout"},
+		{"rate limited", &ratelimit.Error{RetryAfter: time.Second}, http.StatusTooManyRequests, CodeRateLimited, "Too many requests: rate limit exceeded, retry in 1 seconds"},
+		{"anything else", errors.New("boom"), http.StatusInternalServerError, CodeInternal, "Internal error: boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := ErrorFor(tt.err)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, response.StatusCode)
+			assert.Equal(t, tt.wantStatus, StatusFor(CodeFor(tt.err)))
+
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+			assert.Equal(t, string(tt.wantCode), body["code"])
+			assert.Contains(t, body["error"], tt.wantError)
+		})
+	}
+}
+
+func TestErrorFor_StationSuggestions(t *testing.T) {
+	response, err := ErrorFor(fmt.Errorf("finding station: %w", &models.StationNotFoundError{
+		StationID:   "9447131",
+		Suggestions: []models.StationSuggestion{{ID: "9447130", Name: "Seattle"}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	assert.JSONEq(t, `{"responseType":"error","code":"STATION_NOT_FOUND","error":"finding station: station not found: 9447131",
+		"suggestions":[{"id":"9447130","name":"Seattle"}]}`, response.Body)
+
+	// Without any the list is empty rather than missing
+	response, err = ErrorFor(fmt.Errorf("%w: 9999999", models.ErrStationNotFound))
+	assert.NoError(t, err)
+	assert.Contains(t, response.Body, `"suggestions":[]`)
+}
+
+func TestErrorFor_StationRetired(t *testing.T) {
+	successor := models.Station{ID: "9447130", Name: "Seattle", Capabilities: []string{}}
+	err := fmt.Errorf("finding station: %w", &models.StationRetiredError{StationID: "9447110", Successor: successor})
+	assert.Equal(t, CodeStationRetired, CodeFor(err))
+
+	response, e := ErrorFor(err)
+	assert.NoError(t, e)
+	assert.Equal(t, http.StatusMovedPermanently, response.StatusCode)
+	assert.Equal(t, "9447130", response.Headers[successorHeader])
+	var body StationRetiredResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, CodeStationRetired, body.Code)
+	assert.Equal(t, "9447110", body.StationID)
+	assert.Equal(t, "9447130", body.Successor.ID)
+	assert.Equal(t, models.DistanceKilometers, body.Successor.DistanceUnit)
+	require.Len(t, body.Warnings, 1)
+	assert.Equal(t, models.WarningStationRetired, body.Warnings[0].Code)
+}
+
+func TestErrorFor_RetryAfter(t *testing.T) {
+	response, err := ErrorFor(fmt.Errorf("initializing: %w", &startup.NotReadyError{Err: errors.New("timeout"), RetryAfter: 1500 * time.Millisecond}))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "2", response.Headers["Retry-After"])
+
+	response, err = ErrorFor(&ratelimit.Error{RetryAfter: 2200 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, "3", response.Headers["Retry-After"])
+}
+
+func TestErrorFor_QuotaExceeded(t *testing.T) {
+	resetsAt := time.Now().UTC().Add(90 * time.Minute).Truncate(time.Second)
+	response, err := ErrorFor(&quota.Error{Quota: 100, ResetsAt: resetsAt})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	assert.Equal(t, resetsAt.Format(time.RFC3339), response.Headers[quota.ResetHeader])
+	retryAfter, _ := strconv.Atoi(response.Headers["Retry-After"])
+	assert.InDelta(t, 5400, retryAfter, 2)
+
+	var body QuotaExceededResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, CodeQuotaExceeded, body.Code)
+	assert.Equal(t, 100, body.Quota)
+	assert.Equal(t, models.MillisOf(resetsAt), body.ResetsAt)
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog/log"
+)
+
+// fieldsParam lets a client that doesn't use GraphQL ask for only the top-level fields it
+// reads, for operations whose handler is wrapped in SelectFields
+var fieldsParam = Param{
+	Name:        "fields",
+	Description: "Comma-separated top-level fields to return, leaving out the rest; responseType is always returned and unknown names are ignored",
+	Type:        "string",
+	Pattern:     `^\s*[A-Za-z][A-Za-z0-9]*(\s*,\s*[A-Za-z][A-Za-z0-9]*)*\s*$`,
+	Example:     "extremes,timeZoneOffsetSeconds",
+}
+
+// SelectFields wraps next so the JSON object it answers with, or its data for an enveloped
+// version, is pruned to the fields named by the request's fields parameter. Errors and
+// other responses, like GeoJSON and MessagePack, are left as they are.
+func SelectFields(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := next(ctx, request)
+		fields := parseFields(request.QueryStringParameters["fields"])
+		if err != nil || len(fields) == 0 || response.StatusCode != http.StatusOK || response.IsBase64Encoded ||
+			headerValue(response.Headers, "Content-Type") != "application/json" {
+			return response, err
+		}
+		project := Project
+		if version, _ := strconv.Atoi(response.Headers["API-Version"]); envelopes(Version(version)) {
+			project = projectData
+		}
+		body, perr := project([]byte(response.Body), fields)
+		if perr != nil {
+			log.Warn().Err(perr).Msg("Failed to select response fields, answering with all of them")
+			return response, err
+		}
+		response.Body = string(body)
+		return response, err
+	}
+}
+
+// parseFields splits a comma-separated list of field names, dropping blanks
+func parseFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Project returns the JSON object body with only its top-level fields named in fields,
+// and responseType, which clients branch on. The fields kept are copied as they are, in
+// the order body has them.
+func Project(body []byte, fields []string) ([]byte, error) {
+	keep := map[string]bool{"responseType": true}
+	for _, field := range fields {
+		keep[field] = true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err != nil {
+		return nil, err
+	} else if token != json.Delim('{') {
+		return nil, errors.New("body isn't a JSON object")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		// An object's tokens alternate between names and values, so this is a name
+		name := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		if !keep[name] {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		encoded, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encoded)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// projectData is Project for an Envelope, pruning its data
+func projectData(body []byte, fields []string) ([]byte, error) {
+	var envelope struct {
+		Data     json.RawMessage `json:"data"`
+		Meta     json.RawMessage `json:"meta"`
+		Warnings json.RawMessage `json:"warnings"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	data, err := Project(envelope.Data, fields)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Data = data
+	return json.Marshal(envelope)
+}
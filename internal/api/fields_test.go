@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProject(t *testing.T) {
+	body := `{"responseType":"tide","timestamp":1,"timeZoneOffsetSeconds":-25200,"extremes":[{"type":"HIGH","height":3.2}],"weather":{"wind":null},"location":"Seattle \"Pier\""}`
+
+	projected, err := Project([]byte(body), []string{"extremes", "timeZoneOffsetSeconds", "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, `{"responseType":"tide","timeZoneOffsetSeconds":-25200,"extremes":[{"type":"HIGH","height":3.2}]}`, string(projected),
+		"fields keep body's order and values as they are")
+
+	projected, err = Project([]byte(body), []string{"location"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"responseType":"tide","location":"Seattle \"Pier\""}`, string(projected))
+
+	projected, err = Project([]byte(`{"stations":[]}`), []string{"pagination"})
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(projected))
+
+	_, err = Project([]byte(`[1,2]`), []string{"stations"})
+	assert.Error(t, err)
+	_, err = Project([]byte(`{"stations":`), []string{"stations"})
+	assert.Error(t, err)
+}
+
+func TestSelectFields(t *testing.T) {
+	respond := func(response events.APIGatewayProxyResponse) HandlerFunc {
+		return SelectFields(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+			return response, nil
+		})
+	}
+	ok := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       `{"responseType":"stations","stations":[],"stale":true}`,
+	}
+	request := func(fields string) events.APIGatewayProxyRequest {
+		return events.APIGatewayProxyRequest{QueryStringParameters: map[string]string{"fields": fields}}
+	}
+
+	response, err := respond(ok)(context.Background(), request(" stations , "))
+	require.NoError(t, err)
+	assert.Equal(t, `{"responseType":"stations","stations":[]}`, response.Body)
+
+	// Without fields, or with none named, everything is returned
+	response, _ = respond(ok)(context.Background(), events.APIGatewayProxyRequest{})
+	assert.Equal(t, ok, response)
+	response, _ = respond(ok)(context.Background(), request(","))
+	assert.Equal(t, ok, response)
+
+	untouched := []events.APIGatewayProxyResponse{
+		{StatusCode: http.StatusNotFound, Headers: map[string]string{"Content-Type": "application/json"}, Body: `{"responseType":"error","error":"nope"}`},
+		{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": GeoJSONMediaType}, Body: `{"type":"FeatureCollection"}`},
+		{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": MessagePackMediaType}, Body: "gA==", IsBase64Encoded: true},
+		{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": "application/json"}, Body: `[1,2]`},
+	}
+	for _, want := range untouched {
+		response, _ = respond(want)(context.Background(), request("stations"))
+		assert.Equal(t, want, response)
+	}
+}
+
+func TestSelectFields_Envelope(t *testing.T) {
+	handler := SelectFields(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return VersionedSuccess(ctx, V3, request.Path, NewStationsResponse(nil))
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v3/stations",
+		QueryStringParameters: map[string]string{"fields": "pagination"},
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^\{"data":\{"responseType":"stations"\},"meta":\{"generatedAt":\d+\},"warnings":\[\]\}$`, response.Body)
+}
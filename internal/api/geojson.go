@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// GeoJSONMediaType is the media type of GeoJSON documents (RFC 7946)
+const GeoJSONMediaType = "application/geo+json"
+
+// FeatureCollection is a GeoJSON FeatureCollection. Pagination and Stale are foreign
+// members carrying what the JSON response has besides its items; GeoJSON readers ignore
+// them.
+type FeatureCollection struct {
+	Type       string      `json:"type"`
+	Features   []Feature   `json:"features"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Stale      bool        `json:"stale,omitempty"`
+}
+
+// Feature is a GeoJSON Feature located at a point
+type Feature struct {
+	Type       string      `json:"type"`
+	ID         string      `json:"id,omitempty"`
+	Geometry   Point       `json:"geometry"`
+	Properties interface{} `json:"properties"`
+}
+
+// Point is a GeoJSON Point. Its coordinates are longitude then latitude, the reverse of
+// the lat and lon parameters.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// NewPointFeature returns a feature at lat and lon described by properties
+func NewPointFeature(id string, lat, lon float64, properties interface{}) Feature {
+	return Feature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   Point{Type: "Point", Coordinates: [2]float64{lon, lat}},
+		Properties: properties,
+	}
+}
+
+// NewStationFeatureCollection converts a stations response to GeoJSON. Each station is a
+// point feature whose properties are the fields it has in JSON.
+func NewStationFeatureCollection(response *StationsResponse) *FeatureCollection {
+	features := make([]Feature, len(response.Stations))
+	for i, station := range response.Stations {
+		features[i] = NewPointFeature(station.ID, station.Latitude, station.Longitude, station)
+	}
+	return &FeatureCollection{
+		Type:       "FeatureCollection",
+		Features:   features,
+		Pagination: response.Pagination,
+		Stale:      response.Stale,
+	}
+}
+
+// WantsGeoJSON reports whether the request asks for GeoJSON, with format=geojson or an
+// Accept header listing application/geo+json
+func WantsGeoJSON(request events.APIGatewayProxyRequest) bool {
+	if strings.EqualFold(request.QueryStringParameters["format"], "geojson") {
+		return true
+	}
+	for _, mediaType := range strings.Split(headerValue(request.Headers, "Accept"), ",") {
+		mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+		if strings.EqualFold(mediaType, GeoJSONMediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// GeoJSON answers with a GeoJSON document
+func GeoJSON(body interface{}) (events.APIGatewayProxyResponse, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return Error(CodeInternal, "Internal Server Error", http.StatusInternalServerError)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                GeoJSONMediaType,
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(jsonBody),
+	}, nil
+}
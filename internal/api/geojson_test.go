@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWantsGeoJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		accept string
+		want   bool
+	}{
+		{name: "neither", want: false},
+		{name: "format", format: "geojson", want: true},
+		{name: "format case", format: "GeoJSON", want: true},
+		{name: "json format", format: "json", want: false},
+		{name: "accept", accept: "application/geo+json", want: true},
+		{name: "accept among others", accept: "text/html, application/geo+json;q=0.8", want: true},
+		{name: "plain json accept", accept: "application/json", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{},
+				Headers:               map[string]string{"Accept": tt.accept},
+			}
+			if tt.format != "" {
+				request.QueryStringParameters["format"] = tt.format
+			}
+			assert.Equal(t, tt.want, WantsGeoJSON(request))
+		})
+	}
+}
+
+func TestNewStationFeatureCollection(t *testing.T) {
+	response := NewStationsResponse([]models.Station{{ID: "9447130", Name: "Seattle", Latitude: 47.6026, Longitude: -122.3393}})
+	response.Stale = true
+
+	body, err := json.Marshal(NewStationFeatureCollection(response))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "FeatureCollection",
+		"stale": true,
+		"features": [{
+			"type": "Feature",
+			"id": "9447130",
+			"geometry": {"type": "Point", "coordinates": [-122.3393, 47.6026]},
+			"properties": {"id": "9447130", "name": "Seattle", "distance": 0, "latitude": 47.6026, "longitude": -122.3393, "source": "", "capabilities": null, "timeZoneOffset": 0}
+		}]
+	}`, string(body))
+
+	empty, err := json.Marshal(NewStationFeatureCollection(NewStationsResponse(nil)))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, string(empty))
+}
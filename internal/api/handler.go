@@ -1,11 +1,17 @@
 package api
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/quota"
 	"net/http"
-	"strconv"
 )
 
 type APIResponder interface {
@@ -22,7 +28,14 @@ func (r APIResponse) GetResponseType() string {
 
 var (
 	_ APIResponder = (*StationsResponse)(nil)
+	_ APIResponder = (*RegionsResponse)(nil)
 	_ APIResponder = (*ErrorResponse)(nil)
+	_ APIResponder = (*StationRetiredResponse)(nil)
+	_ APIResponder = (*CacheEntryResponse)(nil)
+	_ APIResponder = (*CacheWarmResponse)(nil)
+	_ APIResponder = (*NoNearbyStationResponse)(nil)
+	_ APIResponder = (*QuotaExceededResponse)(nil)
+	_ APIResponder = (*UsageResponse)(nil)
 )
 
 type APIError struct {
@@ -32,12 +45,118 @@ type APIError struct {
 
 type StationsResponse struct {
 	APIResponse
-	Stations []models.Station `json:"stations"`
+	Stations   []models.Station `json:"stations"`
+	Pagination *Pagination      `json:"pagination,omitempty"`
+	// Stale is set when the stations came from a list that may be out of date, e.g. right
+	// after a cold start
+	Stale bool `json:"stale,omitempty"`
+}
+
+// RegionsResponse lists the stations' states and regions, for browsing stations by area
+type RegionsResponse struct {
+	APIResponse
+	Regions []models.StationRegion `json:"regions"`
+}
+
+// Pagination describes where a page of nearest stations sits in the full list
+type Pagination struct {
+	Offset  int  `json:"offset"`
+	Limit   int  `json:"limit"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"hasMore"`
 }
 
 type ErrorResponse struct {
 	APIResponse
-	Error string `json:"error"`
+	Code  ErrorCode `json:"code"`
+	Error string    `json:"error"`
+}
+
+// NoNearbyStationResponse is the 404 body for a coordinate lookup whose nearest station is
+// too far away, with enough detail for a client to explain why
+type NoNearbyStationResponse struct {
+	APIResponse
+	Code           ErrorCode      `json:"code"`
+	Error          string         `json:"error"`
+	NearestStation NearestStation `json:"nearestStation"`
+	MaxDistanceKm  float64        `json:"maxDistanceKm"`
+	// PlaceName describes the requested point, e.g. "40 km W of Astoria, OR"
+	PlaceName *string `json:"placeName,omitempty"`
+}
+
+// QuotaExceededResponse is the 429 body for a request beyond its API key's monthly quota,
+// saying when the quota starts over
+type QuotaExceededResponse struct {
+	APIResponse
+	Code     ErrorCode     `json:"code"`
+	Error    string        `json:"error"`
+	Quota    int           `json:"quota"`
+	ResetsAt models.Millis `json:"resetsAt"`
+}
+
+// UsageResponse reports what the requesting API key used in a month, by day and endpoint
+type UsageResponse struct {
+	APIResponse
+	quota.Usage
+}
+
+// StationNotFoundResponse is the 404 body for a station ID no source knows, with up to 3
+// stations whose ID or name is close to it that a client can offer instead
+type StationNotFoundResponse struct {
+	APIResponse
+	Code        ErrorCode                  `json:"code"`
+	Error       string                     `json:"error"`
+	Suggestions []models.StationSuggestion `json:"suggestions"`
+}
+
+// StationRetiredResponse is the 301 body for a station NOAA retired, naming the current
+// station that replaces it; the Location header is the same request for the successor
+type StationRetiredResponse struct {
+	APIResponse
+	Code      ErrorCode                `json:"code"`
+	Error     string                   `json:"error"`
+	StationID string                   `json:"stationId"`
+	Successor models.Station           `json:"successor"`
+	Warnings  []models.ResponseWarning `json:"warnings"`
+}
+
+// NearestStation identifies the closest station to a point and how far away it is
+type NearestStation struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// CacheEntryResponse reports what the cache holds for a station and date
+type CacheEntryResponse struct {
+	APIResponse
+	Entry *cache.CacheEntryInfo `json:"entry"`
+	// Note points out anything the entry can't show, e.g. what an invalidation didn't reach
+	Note string `json:"note,omitempty"`
+}
+
+// CacheWarmResponse reports how many days were refetched into the cache
+type CacheWarmResponse struct {
+	APIResponse
+	StationID string `json:"stationId"`
+	Days      int    `json:"days"`
+	// Queued is set when the days were queued for the worker rather than warmed already
+	Queued bool `json:"queued,omitempty"`
+}
+
+// DashboardResponse reports upstream health, cache hit ratios and endpoint latencies over
+// the last hour
+type DashboardResponse struct {
+	APIResponse
+	*metrics.Dashboard
+}
+
+// LogLevelResponse reports an instance's log level and how many debug logs on hot paths
+// it keeps one of
+type LogLevelResponse struct {
+	APIResponse
+	Level      string `json:"level"`
+	SampleRate int    `json:"sampleRate"`
 }
 
 func NewStationsResponse(stations []models.Station) *StationsResponse {
@@ -47,9 +166,139 @@ func NewStationsResponse(stations []models.Station) *StationsResponse {
 	}
 }
 
-func NewErrorResponse(message string) *ErrorResponse {
+func NewRegionsResponse(regions []models.StationRegion) *RegionsResponse {
+	return &RegionsResponse{
+		APIResponse: APIResponse{ResponseType: "regions"},
+		Regions:     regions,
+	}
+}
+
+// NewStationsPageResponse returns stations, the page's stations after any unit
+// conversion, along with where the page sits in the full list
+func NewStationsPageResponse(stations []models.Station, page *models.StationPage, limit int) *StationsResponse {
+	response := NewStationsResponse(stations)
+	response.Pagination = &Pagination{
+		Offset:  page.Offset,
+		Limit:   limit,
+		Total:   page.Total,
+		HasMore: page.HasMore(),
+	}
+	response.Stale = page.Stale
+	return response
+}
+
+// NewCacheEntryResponse wraps entry; responseType distinguishes an inspection from the
+// state left behind by an invalidation
+func NewCacheEntryResponse(responseType string, entry *cache.CacheEntryInfo) *CacheEntryResponse {
+	return &CacheEntryResponse{
+		APIResponse: APIResponse{ResponseType: responseType},
+		Entry:       entry,
+	}
+}
+
+func NewCacheWarmResponse(stationID string, days int) *CacheWarmResponse {
+	return &CacheWarmResponse{
+		APIResponse: APIResponse{ResponseType: "cacheWarm"},
+		StationID:   stationID,
+		Days:        days,
+	}
+}
+
+func NewDashboardResponse(dashboard *metrics.Dashboard) *DashboardResponse {
+	return &DashboardResponse{
+		APIResponse: APIResponse{ResponseType: "dashboard"},
+		Dashboard:   dashboard,
+	}
+}
+
+func NewLogLevelResponse(level string, sampleRate int) *LogLevelResponse {
+	return &LogLevelResponse{
+		APIResponse: APIResponse{ResponseType: "logLevel"},
+		Level:       level,
+		SampleRate:  sampleRate,
+	}
+}
+
+func NewNoNearbyStationResponse(message string, nearest models.Station, maxDistanceKm float64, placeName string) *NoNearbyStationResponse {
+	response := &NoNearbyStationResponse{
+		APIResponse: APIResponse{ResponseType: "error"},
+		Code:        CodeNoNearbyStation,
+		Error:       message,
+		NearestStation: NearestStation{
+			ID:         nearest.ID,
+			Name:       nearest.Name,
+			DistanceKm: nearest.Distance,
+		},
+		MaxDistanceKm: maxDistanceKm,
+	}
+	if placeName != "" {
+		response.PlaceName = &placeName
+	}
+	return response
+}
+
+func NewQuotaExceededResponse(message string, err *quota.Error) *QuotaExceededResponse {
+	return &QuotaExceededResponse{
+		APIResponse: APIResponse{ResponseType: "error"},
+		Code:        CodeQuotaExceeded,
+		Error:       message,
+		Quota:       err.Quota,
+		ResetsAt:    models.MillisOf(err.ResetsAt),
+	}
+}
+
+func NewUsageResponse(usage *quota.Usage) *UsageResponse {
+	return &UsageResponse{
+		APIResponse: APIResponse{ResponseType: "usage"},
+		Usage:       *usage,
+	}
+}
+
+// StationNotFound answers a lookup of an unknown station, suggesting the stations err
+// carries, if any, or redirecting to the successor of a retired one
+func StationNotFound(message string, err error) (events.APIGatewayProxyResponse, error) {
+	var retiredErr *models.StationRetiredError
+	if errors.As(err, &retiredErr) {
+		return stationRetired(retiredErr)
+	}
+	response := &StationNotFoundResponse{
+		APIResponse: APIResponse{ResponseType: "error"},
+		Code:        CodeStationNotFound,
+		Error:       message,
+		Suggestions: []models.StationSuggestion{},
+	}
+	var notFoundErr *models.StationNotFoundError
+	if errors.As(err, &notFoundErr) && notFoundErr.Suggestions != nil {
+		response.Suggestions = notFoundErr.Suggestions
+	}
+	return ErrorBody(response, http.StatusNotFound)
+}
+
+// successorHeader carries a retired station's successor from stationRetired to
+// ValidateRequest, which has the request to build the Location header from
+const successorHeader = "X-Flowebb-Successor"
+
+func stationRetired(err *models.StationRetiredError) (events.APIGatewayProxyResponse, error) {
+	successor := models.WithDistanceUnit([]models.Station{err.Successor}, models.DistanceKilometers)[0]
+	response, e := ErrorBody(&StationRetiredResponse{
+		APIResponse: APIResponse{ResponseType: "error"},
+		Code:        CodeStationRetired,
+		Error:       err.Error(),
+		StationID:   err.StationID,
+		Successor:   successor,
+		Warnings: []models.ResponseWarning{{
+			Code:    models.WarningStationRetired,
+			Message: fmt.Sprintf("Station %s is retired; use station %s instead", err.StationID, successor.ID),
+		}},
+	}, http.StatusMovedPermanently)
+	response.Headers[successorHeader] = successor.ID
+	return response, e
+}
+
+func NewErrorResponse(code ErrorCode, message string) *ErrorResponse {
 	return &ErrorResponse{
 		APIResponse: APIResponse{ResponseType: "error"},
+		Code:        code,
 		Error:       message,
 	}
 }
@@ -58,7 +307,7 @@ func NewErrorResponse(message string) *ErrorResponse {
 func Success(body interface{}) (events.APIGatewayProxyResponse, error) {
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return Error("Internal Server Error", http.StatusInternalServerError)
+		return Error(CodeInternal, "Internal Server Error", http.StatusInternalServerError)
 	}
 
 	return events.APIGatewayProxyResponse{
@@ -71,8 +320,44 @@ func Success(body interface{}) (events.APIGatewayProxyResponse, error) {
 	}, nil
 }
 
-func Error(message string, statusCode int) (events.APIGatewayProxyResponse, error) {
-	body, _ := json.Marshal(NewErrorResponse(message))
+// Error answers statusCode with a message and the code clients should branch on
+func Error(code ErrorCode, message string, statusCode int) (events.APIGatewayProxyResponse, error) {
+	return ErrorBody(NewErrorResponse(code, message), statusCode)
+}
+
+// Image answers with a rendered image. Binary images are base64 encoded, which API
+// Gateway decodes for content types listed in its binary media types; SVG is sent as text.
+func Image(contentType string, body []byte) (events.APIGatewayProxyResponse, error) {
+	response := events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                contentType,
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}
+	if contentType != "image/svg+xml" {
+		response.Body = base64.StdEncoding.EncodeToString(body)
+		response.IsBase64Encoded = true
+	}
+	return response, nil
+}
+
+// HTML answers with a rendered page
+func HTML(body []byte) (events.APIGatewayProxyResponse, error) {
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type":                "text/html; charset=utf-8",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// ErrorBody answers statusCode with an error body that carries more than a message
+func ErrorBody(body APIResponder, statusCode int) (events.APIGatewayProxyResponse, error) {
+	jsonBody, _ := json.Marshal(body)
 
 	return events.APIGatewayProxyResponse{
 		StatusCode: statusCode,
@@ -80,7 +365,7 @@ func Error(message string, statusCode int) (events.APIGatewayProxyResponse, erro
 			"Content-Type":                "application/json",
 			"Access-Control-Allow-Origin": "*",
 		},
-		Body: string(body),
+		Body: string(jsonBody),
 	}, nil
 }
 
@@ -93,25 +378,36 @@ func ParseCoordinates(params map[string]string) (float64, float64, error) {
 		return 0, 0, InvalidCoordinatesError{}
 	}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
+	lat, err := validate.Number("lat", latStr)
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, InvalidCoordinatesError{Err: err}
 	}
 
-	lon, err := strconv.ParseFloat(lonStr, 64)
+	lon, err := validate.Number("lon", lonStr)
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, InvalidCoordinatesError{Err: err}
 	}
 
-	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
-		return 0, 0, InvalidCoordinatesError{}
+	if err := validate.Coordinates(lat, lon); err != nil {
+		return 0, 0, InvalidCoordinatesError{Err: err}
 	}
 
 	return lat, lon, nil
 }
 
-type InvalidCoordinatesError struct{}
+// InvalidCoordinatesError is a missing or unusable lat and lon; Err says which, when
+// one was sent
+type InvalidCoordinatesError struct {
+	Err error
+}
 
 func (e InvalidCoordinatesError) Error() string {
-	return "Invalid coordinates"
+	if e.Err == nil {
+		return "Invalid coordinates"
+	}
+	return "Invalid coordinates: " + e.Err.Error()
+}
+
+func (e InvalidCoordinatesError) Unwrap() error {
+	return e.Err
 }
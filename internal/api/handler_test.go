@@ -51,21 +51,47 @@ func TestSuccess(t *testing.T) {
 	}
 }
 
+func TestImage(t *testing.T) {
+	got, err := Image("image/svg+xml", []byte("<svg/>"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "image/svg+xml", got.Headers["Content-Type"])
+	assert.Equal(t, "<svg/>", got.Body)
+	assert.False(t, got.IsBase64Encoded)
+
+	got, err = Image("image/png", []byte{0x89, 'P', 'N', 'G'})
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", got.Headers["Content-Type"])
+	assert.Equal(t, "iVBORw==", got.Body)
+	assert.True(t, got.IsBase64Encoded)
+}
+
+func TestHTML(t *testing.T) {
+	got, err := HTML([]byte("<p>hi</p>"))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", got.Headers["Content-Type"])
+	assert.Equal(t, "<p>hi</p>", got.Body)
+}
+
 func TestError(t *testing.T) {
 	tests := []struct {
 		name       string
+		code       ErrorCode
 		message    string
 		statusCode int
 		want       string
 	}{
 		{
 			name:       "basic error",
+			code:       CodeInvalidRequest,
 			message:    "test error",
 			statusCode: http.StatusBadRequest,
 			want:       "test error",
 		},
 		{
 			name:       "server error",
+			code:       CodeInternal,
 			message:    "internal server error",
 			statusCode: http.StatusInternalServerError,
 			want:       "internal server error",
@@ -74,7 +100,7 @@ func TestError(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Error(tt.message, tt.statusCode)
+			got, err := Error(tt.code, tt.message, tt.statusCode)
 			require.NoError(t, err)
 			assert.Equal(t, tt.statusCode, got.StatusCode)
 
@@ -83,6 +109,7 @@ func TestError(t *testing.T) {
 			err = json.Unmarshal([]byte(got.Body), &errorResp)
 			require.NoError(t, err)
 			assert.Equal(t, "error", errorResp.ResponseType)
+			assert.Equal(t, tt.code, errorResp.Code)
 			assert.Equal(t, tt.want, errorResp.Error)
 
 			// Verify CORS headers
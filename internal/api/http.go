@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxRequestBytes is the largest request body HTTPHandler reads: API Gateway's payload
+// limit, so a request the self-hosted server takes would be taken in AWS too
+const MaxRequestBytes = 10 << 20
+
+// APIKeyHeader carries the API key a request is made with, as API Gateway reads it
+const APIKeyHeader = "X-Api-Key"
+
+// MinCompressionBytes is the smallest body HTTPHandler gzips for clients that accept it:
+// the MinimumCompressionSize API Gateway is given in the SAM template
+const MinCompressionBytes = 1024
+
+// HTTPHandler serves h over net/http, translating each request into the API Gateway
+// proxy event Lambda would receive and its response back. The client's address becomes
+// the source IP rate limits go by, and the X-Api-Key header the key quotas and user data
+// go by, as API Gateway would set them. Responses are compressed as API Gateway
+// compresses them.
+func HTTPHandler(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, err := proxyRequest(r)
+		if err != nil {
+			response, err := Error(CodeInvalidRequest, "Request body is too large or unreadable", http.StatusRequestEntityTooLarge)
+			Write(w, response, err)
+			return
+		}
+		response, err := h(r.Context(), request)
+		if err == nil && acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			response, err = gzipResponse(response)
+		}
+		Write(w, response, err)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header accepts gzip
+func acceptsGzip(header string) bool {
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		// gzip;q=0 refuses it
+		if weight, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(weight, 64)
+			return err != nil || q > 0
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponse compresses a response's body unless it's smaller than MinCompressionBytes
+// or already encoded
+func gzipResponse(response events.APIGatewayProxyResponse) (events.APIGatewayProxyResponse, error) {
+	if response.Headers["Content-Encoding"] != "" {
+		return response, nil
+	}
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			return response, err
+		}
+		body = decoded
+	}
+	if len(body) < MinCompressionBytes {
+		return response, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return response, err
+	}
+	if err := zw.Close(); err != nil {
+		return response, err
+	}
+
+	headers := make(map[string]string, len(response.Headers)+2)
+	for name, value := range response.Headers {
+		headers[name] = value
+	}
+	headers["Content-Encoding"] = "gzip"
+	if vary := headers["Vary"]; vary != "" {
+		headers["Vary"] = vary + ", Accept-Encoding"
+	} else {
+		headers["Vary"] = "Accept-Encoding"
+	}
+	response.Headers = headers
+	response.Body = base64.StdEncoding.EncodeToString(buf.Bytes())
+	response.IsBase64Encoded = true
+	return response, nil
+}
+
+// proxyRequest translates r into an API Gateway proxy event
+func proxyRequest(r *http.Request) (events.APIGatewayProxyRequest, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxRequestBytes))
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod: r.Method,
+		Path:       r.URL.Path,
+		Body:       string(body),
+	}
+	if !utf8.Valid(body) {
+		request.Body = base64.StdEncoding.EncodeToString(body)
+		request.IsBase64Encoded = true
+	}
+	if len(r.Header) > 0 {
+		request.Headers = make(map[string]string, len(r.Header))
+		request.MultiValueHeaders = make(map[string][]string, len(r.Header))
+		for name, values := range r.Header {
+			request.Headers[name] = values[0]
+			request.MultiValueHeaders[name] = values
+		}
+	}
+	if query := r.URL.Query(); len(query) > 0 {
+		request.QueryStringParameters = make(map[string]string, len(query))
+		request.MultiValueQueryStringParameters = query
+		for name, values := range query {
+			request.QueryStringParameters[name] = values[0]
+		}
+	}
+	request.RequestContext.HTTPMethod = r.Method
+	request.RequestContext.Path = r.URL.Path
+	request.RequestContext.Identity.SourceIP = r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		request.RequestContext.Identity.SourceIP = host
+	}
+	request.RequestContext.Identity.APIKey = r.Header.Get(APIKeyHeader)
+	return request, nil
+}
+
+// Write writes a handler's response to w. A handler that fails rather than answering is
+// answered with a 502, as API Gateway answers a Lambda function that fails.
+func Write(w http.ResponseWriter, response events.APIGatewayProxyResponse, err error) {
+	if err != nil {
+		log.Error().Err(err).Msg("Handler failed")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = io.WriteString(w, `{"message":"Internal server error"}`)
+		return
+	}
+
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			Write(w, response, err)
+			return
+		}
+		body = decoded
+	}
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range response.MultiValueHeaders {
+		w.Header()[http.CanonicalHeaderKey(name)] = values
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	status := response.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
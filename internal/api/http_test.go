@@ -0,0 +1,134 @@
+package api
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	var received events.APIGatewayProxyRequest
+	server := httptest.NewServer(HTTPHandler(func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		received = request
+		return events.APIGatewayProxyResponse{
+			StatusCode:        http.StatusCreated,
+			Headers:           map[string]string{"Content-Type": "text/plain"},
+			MultiValueHeaders: map[string][]string{"vary": {"Accept", "X-Tenant"}},
+			Body:              "created",
+		}, nil
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+"/api/tides?stationId=9447130&day=1&day=2", strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	request.Header.Set("X-Api-Key", "secret")
+	request.Header.Set("Accept", "application/json")
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+	assert.Equal(t, "created", string(body))
+	assert.Equal(t, "text/plain", response.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"Accept", "X-Tenant"}, response.Header.Values("Vary"))
+
+	assert.Equal(t, http.MethodPost, received.HTTPMethod)
+	assert.Equal(t, "/api/tides", received.Path)
+	assert.Equal(t, "/api/tides", received.RequestContext.Path)
+	assert.Equal(t, `{"a":1}`, received.Body)
+	assert.False(t, received.IsBase64Encoded)
+	assert.Equal(t, "9447130", received.QueryStringParameters["stationId"])
+	assert.Equal(t, []string{"1", "2"}, received.MultiValueQueryStringParameters["day"])
+	assert.Equal(t, "application/json", received.Headers["Accept"])
+	assert.Equal(t, "127.0.0.1", received.RequestContext.Identity.SourceIP, "the port is left out")
+	assert.Equal(t, "secret", received.RequestContext.Identity.APIKey)
+}
+
+func TestHTTPHandler_Binary(t *testing.T) {
+	image := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	var received events.APIGatewayProxyRequest
+	handler := HTTPHandler(func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		received = request
+		return events.APIGatewayProxyResponse{
+			StatusCode:      http.StatusOK,
+			Body:            base64.StdEncoding.EncodeToString(image),
+			IsBase64Encoded: true,
+		}, nil
+	})
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(string(image))))
+	assert.True(t, received.IsBase64Encoded, "a body that isn't text is passed on encoded")
+	assert.Equal(t, base64.StdEncoding.EncodeToString(image), received.Body)
+	assert.Equal(t, image, recorder.Body.Bytes(), "an encoded response is written decoded")
+}
+
+func TestHTTPHandler_Errors(t *testing.T) {
+	handler := HTTPHandler(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{}, errors.New("boom")
+	})
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/tides", nil))
+	assert.Equal(t, http.StatusBadGateway, recorder.Code, "a failed handler is answered as API Gateway would")
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(strings.Repeat("x", MaxRequestBytes+1))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"INVALID_REQUEST"`)
+}
+
+func TestHTTPHandler_Gzip(t *testing.T) {
+	large := strings.Repeat(`{"height":1.5}`, 100)
+	handler := HTTPHandler(func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		body := "small"
+		if request.Path == "/large" {
+			body = large
+		}
+		return events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Headers:    map[string]string{"Content-Type": "application/json", "Vary": "Accept"},
+			Body:       body,
+		}, nil
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/large", nil)
+	request.Header.Set("Accept-Encoding", "br, gzip;q=0.8")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept, Accept-Encoding", recorder.Header().Get("Vary"))
+	assert.Less(t, recorder.Body.Len(), len(large))
+	zr, err := gzip.NewReader(recorder.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(body))
+
+	request = httptest.NewRequest(http.MethodGet, "/small", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	assert.Empty(t, recorder.Header().Get("Content-Encoding"), "bodies under the minimum aren't worth it")
+	assert.Equal(t, "small", recorder.Body.String())
+
+	for _, accept := range []string{"", "identity", "gzip;q=0"} {
+		request = httptest.NewRequest(http.MethodGet, "/large", nil)
+		request.Header.Set("Accept-Encoding", accept)
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"), accept)
+		assert.Equal(t, large, recorder.Body.String(), accept)
+	}
+}
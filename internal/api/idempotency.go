@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// IdempotencyHeader names the client-chosen key, e.g. a UUID, that marks a retried
+	// write as the same request
+	IdempotencyHeader = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed for a key
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+var (
+	// ErrIdempotencyKeyReused is a key sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("the Idempotency-Key was already used for a different request")
+	// ErrIdempotencyInProgress is a key sent again before its first request finished
+	ErrIdempotencyInProgress = errors.New("a request with this Idempotency-Key is still in progress")
+)
+
+// IdempotencyRecord is what's kept for a key: a fingerprint of the request it was first
+// sent with and, once that finished, the response to replay
+type IdempotencyRecord struct {
+	// Key is the caller's key, scoped to the caller
+	Key         string `dynamodbav:"idempotencyKey"`
+	Fingerprint string `dynamodbav:"fingerprint"`
+	Complete    bool   `dynamodbav:"complete"`
+	StatusCode  int    `dynamodbav:"statusCode,omitempty"`
+	ContentType string `dynamodbav:"contentType,omitempty"`
+	Body        string `dynamodbav:"body,omitempty"`
+	// TTL is when the record expires, in Unix seconds
+	TTL int64 `dynamodbav:"ttl"`
+}
+
+// IdempotencyStore keeps idempotency records
+type IdempotencyStore interface {
+	// Reserve saves record unless an unexpired record holds its key, which it returns instead
+	Reserve(ctx context.Context, record *IdempotencyRecord, now time.Time) (*IdempotencyRecord, error)
+	// Save replaces the key's record, e.g. with its response
+	Save(ctx context.Context, record *IdempotencyRecord) error
+	// Release deletes the key's record, so the request runs again when retried
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency guards writes with the Idempotency-Key header: a write sent again with the
+// same key, after a timeout say, gets the first response replayed instead of running
+// twice and adding a second report or favorite
+type Idempotency struct {
+	store IdempotencyStore
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// NewIdempotency keeps each key's response for ttl
+func NewIdempotency(store IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{store: store, ttl: ttl, now: time.Now}
+}
+
+// Wrap returns next guarded by idempotency keys; a nil Idempotency returns next as is.
+// Requests without a key pass straight through. reject answers those turned away, so each
+// API can shape the error the way its clients expect.
+//
+// Responses that failed on the server's side aren't kept, so a retry runs again. Nor is
+// a request's key honored when the store can't be reached: the write goes ahead.
+func (i *Idempotency) Wrap(next HandlerFunc, reject func(error) (events.APIGatewayProxyResponse, error)) HandlerFunc {
+	if i == nil {
+		return next
+	}
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		key := headerValue(request.Headers, IdempotencyHeader)
+		if key == "" {
+			return next(ctx, request)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return reject(&validate.Error{
+				Parameter: IdempotencyHeader,
+				Value:     key,
+				Message:   fmt.Sprintf("must be at most %d characters", maxIdempotencyKeyLength),
+				Allowed:   fmt.Sprintf("up to %d characters", maxIdempotencyKeyLength),
+			})
+		}
+
+		now := i.now()
+		record := &IdempotencyRecord{
+			Key:         idempotencyScope(ctx, request) + " " + key,
+			Fingerprint: requestFingerprint(request),
+			TTL:         now.Add(i.ttl).Unix(),
+		}
+		existing, err := i.store.Reserve(ctx, record, now)
+		if err != nil {
+			log.Warn().Err(err).Msg("Idempotency key not reserved; handling the request without it")
+			return next(ctx, request)
+		}
+		switch {
+		case existing == nil:
+		case existing.Fingerprint != record.Fingerprint:
+			return reject(ErrIdempotencyKeyReused)
+		case !existing.Complete:
+			return reject(ErrIdempotencyInProgress)
+		default:
+			return replay(existing), nil
+		}
+
+		response, err := next(ctx, request)
+		if err != nil || serverFailed(response) {
+			if releaseErr := i.store.Release(ctx, record.Key); releaseErr != nil {
+				log.Warn().Err(releaseErr).Msg("Idempotency key not released")
+			}
+			return response, err
+		}
+		record.Complete = true
+		record.StatusCode = response.StatusCode
+		record.ContentType = headerValue(response.Headers, "Content-Type")
+		record.Body = response.Body
+		if err := i.store.Save(ctx, record); err != nil {
+			log.Warn().Err(err).Msg("Idempotent response not saved")
+		}
+		return response, nil
+	}
+}
+
+// idempotencyScope is whose keys a request's key is among: the caller's or, for an
+// anonymous request, those of its tenant's requests from the same source IP, so strangers
+// who pick the same key don't get each other's responses
+func idempotencyScope(ctx context.Context, request events.APIGatewayProxyRequest) string {
+	if userID := userdata.UserIDFromRequest(request); userID != "" {
+		return userID
+	}
+	tenantID := ""
+	if t := tenant.FromContext(ctx); t != nil {
+		tenantID = t.ID
+	}
+	return "anonymous:" + tenantID + "@" + request.RequestContext.Identity.SourceIP
+}
+
+// requestFingerprint identifies what a request asks for, to tell a retry from a key reused
+// for something else
+func requestFingerprint(request events.APIGatewayProxyRequest) string {
+	sum := sha256.Sum256([]byte(request.HTTPMethod + " " + request.Path + "\x00" + request.Body))
+	return hex.EncodeToString(sum[:])
+}
+
+func replay(record *IdempotencyRecord) events.APIGatewayProxyResponse {
+	headers := map[string]string{ReplayedHeader: "true"}
+	if record.ContentType != "" {
+		headers["Content-Type"] = record.ContentType
+	}
+	return events.APIGatewayProxyResponse{StatusCode: record.StatusCode, Headers: headers, Body: record.Body}
+}
+
+// serverFailed reports whether a response is a server-side failure a retry may get past:
+// a 5xx status, or a GraphQL response, which is sent with a 200, carrying an error with a
+// code that would have been a 5xx
+func serverFailed(response events.APIGatewayProxyResponse) bool {
+	if response.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	var body struct {
+		Errors []struct {
+			Extensions struct {
+				Code ErrorCode `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal([]byte(response.Body), &body) != nil {
+		return false
+	}
+	for _, e := range body.Errors {
+		if StatusFor(e.Extensions.Code) >= http.StatusInternalServerError {
+			return true
+		}
+	}
+	return false
+}
+
+// DynamoIdempotencyStore keeps idempotency records as items keyed by idempotencyKey.
+// DynamoDB's TTL on the ttl attribute deletes them, eventually, so Reserve also treats a
+// record past its ttl as gone.
+type DynamoIdempotencyStore struct {
+	client cache.DynamoDBClient
+	table  string
+}
+
+func NewDynamoIdempotencyStore(client cache.DynamoDBClient, table string) *DynamoIdempotencyStore {
+	return &DynamoIdempotencyStore{client: client, table: table}
+}
+
+func (s *DynamoIdempotencyStore) Reserve(ctx context.Context, record *IdempotencyRecord, now time.Time) (*IdempotencyRecord, error) {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling idempotency record: %w", err)
+	}
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(s.table),
+		Item:                      item,
+		ConditionExpression:       aws.String("attribute_not_exists(idempotencyKey) OR #ttl < :now"),
+		ExpressionAttributeNames:  map[string]string{"#ttl": "ttl"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)}},
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	switch {
+	case err == nil:
+		return nil, nil
+	case !errors.As(err, &conditionErr):
+		return nil, fmt.Errorf("putting idempotency record in DynamoDB: %w", err)
+	}
+
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            map[string]types.AttributeValue{"idempotencyKey": &types.AttributeValueMemberS{Value: record.Key}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting idempotency record from DynamoDB: %w", err)
+	}
+	if len(result.Item) == 0 {
+		// Released since the put; the request holding it may be retrying already
+		return &IdempotencyRecord{Key: record.Key, Fingerprint: record.Fingerprint}, nil
+	}
+	var existing IdempotencyRecord
+	if err := attributevalue.UnmarshalMap(result.Item, &existing); err != nil {
+		return nil, fmt.Errorf("unmarshaling idempotency record: %w", err)
+	}
+	return &existing, nil
+}
+
+func (s *DynamoIdempotencyStore) Save(ctx context.Context, record *IdempotencyRecord) error {
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return fmt.Errorf("marshaling idempotency record: %w", err)
+	}
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: item}); err != nil {
+		return fmt.Errorf("putting idempotency record in DynamoDB: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoIdempotencyStore) Release(ctx context.Context, key string) error {
+	if _, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key:       map[string]types.AttributeValue{"idempotencyKey": &types.AttributeValueMemberS{Value: key}},
+	}); err != nil {
+		return fmt.Errorf("deleting idempotency record from DynamoDB: %w", err)
+	}
+	return nil
+}
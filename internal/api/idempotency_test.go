@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore keeps records in a map
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+	err     error
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, record *IdempotencyRecord, now time.Time) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	if existing, ok := s.records[record.Key]; ok && existing.TTL >= now.Unix() {
+		return &existing, nil
+	}
+	s.records[record.Key] = *record
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(_ context.Context, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.Key] = *record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+	idempotency := NewIdempotency(store, time.Hour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	idempotency.now = func() time.Time { return now }
+
+	calls := 0
+	status := http.StatusOK
+	handler := idempotency.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		calls++
+		return events.APIGatewayProxyResponse{
+			StatusCode: status,
+			Headers:    map[string]string{"Content-Type": "application/json"},
+			Body:       `{"data":{"call":` + strconv.Itoa(calls) + `}}`,
+		}, nil
+	}, ErrorFor)
+
+	request := func(key, body string) events.APIGatewayProxyRequest {
+		r := events.APIGatewayProxyRequest{HTTPMethod: "POST", Path: "/graphql", Body: body, Headers: map[string]string{}}
+		if key != "" {
+			r.Headers["idempotency-key"] = key
+		}
+		r.RequestContext.Identity.APIKeyID = "key-1"
+		return r
+	}
+	ctx := context.Background()
+
+	response, err := handler(ctx, request("k1", `{"query":"mutation { addFavorite }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"call":1}}`, response.Body)
+	assert.Empty(t, response.Headers[ReplayedHeader])
+
+	response, err = handler(ctx, request("k1", `{"query":"mutation { addFavorite }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "a retry isn't run again")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, `{"data":{"call":1}}`, response.Body)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.Equal(t, "true", response.Headers[ReplayedHeader])
+
+	response, err = handler(ctx, request("k1", `{"query":"mutation { removeFavorite }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, response.StatusCode)
+	assert.Contains(t, response.Body, `"code":"IDEMPOTENCY_KEY_REUSED"`)
+
+	other := request("k1", `{"query":"mutation { addFavorite }"}`)
+	other.RequestContext.Identity.APIKeyID = "key-2"
+	_, err = handler(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "keys are scoped to the caller")
+
+	_, err = handler(ctx, request("", `{"query":"mutation { addFavorite }"}`))
+	require.NoError(t, err)
+	_, err = handler(ctx, request("", `{"query":"mutation { addFavorite }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls, "requests without a key always run")
+
+	now = now.Add(2 * time.Hour)
+	_, err = handler(ctx, request("k1", `{"query":"mutation { addFavorite }"}`))
+	require.NoError(t, err)
+	assert.Equal(t, 5, calls, "keys expire")
+}
+
+func TestIdempotency_AnonymousCallers(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+	calls := 0
+	handler := NewIdempotency(store, time.Hour).Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		calls++
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: `{"data":{"call":` + strconv.Itoa(calls) + `}}`}, nil
+	}, ErrorFor)
+	request := func(sourceIP string) events.APIGatewayProxyRequest {
+		r := events.APIGatewayProxyRequest{
+			HTTPMethod: "POST",
+			Path:       "/graphql",
+			Body:       `{"query":"mutation { submitReport }"}`,
+			Headers:    map[string]string{IdempotencyHeader: "retry-1"},
+		}
+		r.RequestContext.Identity.SourceIP = sourceIP
+		return r
+	}
+	ctx := context.Background()
+
+	first, err := handler(ctx, request("198.51.100.7"))
+	require.NoError(t, err)
+	second, err := handler(ctx, request("203.0.113.9"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "anonymous callers with the same key don't share it")
+	assert.NotEqual(t, first.Body, second.Body)
+	assert.Empty(t, second.Headers[ReplayedHeader])
+
+	tenantCtx := tenant.WithTenant(ctx, &tenant.Tenant{ID: "acme"})
+	_, err = handler(tenantCtx, request("198.51.100.7"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls, "nor do the same address's requests for different tenants")
+
+	replayed, err := handler(ctx, request("198.51.100.7"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls, "a retry from the same address is replayed")
+	assert.Equal(t, first.Body, replayed.Body)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+	idempotency := NewIdempotency(store, time.Hour)
+
+	var handler HandlerFunc
+	var inner events.APIGatewayProxyResponse
+	handler = idempotency.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		// The client retries while the first request is still running
+		inner, _ = handler(ctx, request)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "{}"}, nil
+	}, ErrorFor)
+
+	_, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{IdempotencyHeader: "k1"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, inner.StatusCode)
+	assert.Contains(t, inner.Body, `"code":"CONFLICT"`)
+}
+
+func TestIdempotency_ServerFailuresArentKept(t *testing.T) {
+	tests := []struct {
+		name     string
+		response events.APIGatewayProxyResponse
+		err      error
+	}{
+		{name: "5xx", response: events.APIGatewayProxyResponse{StatusCode: http.StatusBadGateway}},
+		{name: "error", err: errors.New("boom")},
+		{name: "GraphQL internal error", response: events.APIGatewayProxyResponse{
+			StatusCode: http.StatusOK,
+			Body:       `{"errors":[{"message":"throttled","extensions":{"code":"INTERNAL_ERROR"}}],"data":null}`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+			calls := 0
+			handler := NewIdempotency(store, time.Hour).Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				calls++
+				return tt.response, tt.err
+			}, ErrorFor)
+			request := events.APIGatewayProxyRequest{Headers: map[string]string{IdempotencyHeader: "k1"}}
+
+			_, _ = handler(context.Background(), request)
+			_, _ = handler(context.Background(), request)
+			assert.Equal(t, 2, calls)
+			assert.Empty(t, store.records)
+		})
+	}
+
+	// A GraphQL error the client caused is its answer, and is kept
+	store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+	handler := NewIdempotency(store, time.Hour).Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: `{"errors":[{"extensions":{"code":"RATE_LIMITED"}}]}`}, nil
+	}, ErrorFor)
+	_, _ = handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{IdempotencyHeader: "k1"}})
+	assert.Len(t, store.records, 1)
+}
+
+func TestIdempotency_Rejections(t *testing.T) {
+	store := &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}, err: errors.New("table unavailable")}
+	calls := 0
+	handler := NewIdempotency(store, time.Hour).Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		calls++
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}, ErrorFor)
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{IdempotencyHeader: "k1"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode, "the write goes ahead without the store")
+	assert.Equal(t, 1, calls)
+
+	long := make([]byte, maxIdempotencyKeyLength+1)
+	for i := range long {
+		long[i] = 'k'
+	}
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{Headers: map[string]string{IdempotencyHeader: string(long)}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Contains(t, response.Body, `"parameter":"Idempotency-Key"`)
+
+	var off *Idempotency
+	_, err = off.Wrap(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		calls++
+		return events.APIGatewayProxyResponse{}, nil
+	}, ErrorFor)(context.Background(), events.APIGatewayProxyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+// fakeIdempotencyDynamoDB answers puts with a conditional check failure while it holds an
+// item
+type fakeIdempotencyDynamoDB struct {
+	cache.DynamoDBClient
+	item    map[string]types.AttributeValue
+	puts    []*dynamodb.PutItemInput
+	deletes int
+}
+
+func (f *fakeIdempotencyDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.puts = append(f.puts, params)
+	if params.ConditionExpression != nil && f.item != nil {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	f.item = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeIdempotencyDynamoDB) GetItem(_ context.Context, _ *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: f.item}, nil
+}
+
+func (f *fakeIdempotencyDynamoDB) DeleteItem(_ context.Context, _ *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.deletes++
+	f.item = nil
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestDynamoIdempotencyStore(t *testing.T) {
+	client := &fakeIdempotencyDynamoDB{}
+	store := NewDynamoIdempotencyStore(client, "idempotency")
+	ctx := context.Background()
+	now := time.Unix(1735740000, 0)
+	record := &IdempotencyRecord{Key: "apikey:key-1 k1", Fingerprint: "abc", TTL: now.Add(time.Hour).Unix()}
+
+	existing, err := store.Reserve(ctx, record, now)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, "idempotency", *client.puts[0].TableName)
+	assert.Equal(t, "attribute_not_exists(idempotencyKey) OR #ttl < :now", *client.puts[0].ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1735740000"}, client.puts[0].ExpressionAttributeValues[":now"])
+
+	record.Complete, record.StatusCode, record.Body = true, http.StatusOK, "{}"
+	require.NoError(t, store.Save(ctx, record))
+	var saved IdempotencyRecord
+	require.NoError(t, attributevalue.UnmarshalMap(client.item, &saved))
+	assert.Equal(t, *record, saved)
+
+	existing, err = store.Reserve(ctx, &IdempotencyRecord{Key: record.Key, Fingerprint: "abc"}, now)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, *record, *existing)
+
+	require.NoError(t, store.Release(ctx, record.Key))
+	assert.Equal(t, 1, client.deletes)
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeMessagePack(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []byte
+	}{
+		{name: "null", value: nil, want: []byte{0xc0}},
+		{name: "booleans", value: []bool{true, false}, want: []byte{0x92, 0xc3, 0xc2}},
+		{name: "fixints", value: []int{0, 127, -1, -32}, want: []byte{0x94, 0x00, 0x7f, 0xff, 0xe0}},
+		{name: "unsigned", value: []int{200, 65535, 70000}, want: []byte{0x93, 0xcc, 0xc8, 0xcd, 0xff, 0xff, 0xce, 0x00, 0x01, 0x11, 0x70}},
+		{name: "signed", value: []int{-100, -1000}, want: []byte{0x92, 0xd0, 0x9c, 0xd1, 0xfc, 0x18}},
+		{name: "timestamp", value: int64(1704112200000), want: []byte{0xd3, 0x00, 0x00, 0x01, 0x8c, 0xc5, 0x00, 0x99, 0x40}},
+		{name: "exact float", value: 1.5, want: []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}},
+		{name: "short decimal", value: 0.1, want: []byte{0xca, 0x3d, 0xcc, 0xcc, 0xcd}},
+		{name: "long decimal", value: 0.123456789, want: []byte{0xcb, 0x3f, 0xbf, 0x9a, 0xdd, 0x37, 0x39, 0x63, 0x5f}},
+		{name: "fixstr", value: "abc", want: []byte{0xa3, 'a', 'b', 'c'}},
+		{name: "str8", value: strings.Repeat("x", 40), want: append([]byte{0xd9, 40}, strings.Repeat("x", 40)...)},
+		{name: "map with sorted keys", value: map[string]interface{}{"b": "x", "a": 1}, want: []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0xa1, 'x'}},
+		{name: "json tags", value: models.TidePrediction{Timestamp: 0, LocalTime: "", Height: 2}, want: []byte{0x83, 0xa6, 'h', 'e', 'i', 'g', 'h', 't', 0x02, 0xa9, 'l', 'o', 'c', 'a', 'l', 'T', 'i', 'm', 'e', 0xa0, 0xa9, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p', 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeMessagePack(tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	long, err := EncodeMessagePack(make([]int, 20))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xdc, 0x00, 20}, long[:3], "arrays of 16 or more take a 16-bit length")
+}
+
+func TestEncodeMessagePack_Smaller(t *testing.T) {
+	// A day of 6-minute predictions, as a chart requests them
+	response := &models.ExtendedTideResponse{ResponseType: "tide"}
+	for i := range 240 {
+		response.Predictions = append(response.Predictions, models.TidePrediction{
+			Timestamp: models.Millis(1704112200000 + int64(i)*360000),
+			LocalTime: "2024-01-01T04:30:00",
+			Height:    float64(i%97*137) / 1000,
+		})
+	}
+	jsonBody, err := json.Marshal(response)
+	require.NoError(t, err)
+	encoded, err := EncodeMessagePack(response)
+	require.NoError(t, err)
+	assert.Less(t, len(encoded), len(jsonBody)*17/20)
+}
+
+func TestWantsMessagePack(t *testing.T) {
+	for accept, want := range map[string]bool{
+		"":                                     false,
+		"application/json":                     false,
+		"application/msgpack":                  true,
+		"application/x-msgpack":                true,
+		"text/html, Application/MsgPack;q=0.9": true,
+		"application/vnd.flowebb.v2+msgpack":   true,
+		"application/vnd.flowebb.v2+json":      false,
+	} {
+		request := events.APIGatewayProxyRequest{Headers: map[string]string{"Accept": accept}}
+		assert.Equal(t, want, WantsMessagePack(request), accept)
+	}
+}
+
+func TestVersionedNegotiated(t *testing.T) {
+	body := map[string]interface{}{"responseType": "tide"}
+
+	response, err := VersionedNegotiated(context.Background(), events.APIGatewayProxyRequest{Path: "/api/tides"}, V1, body)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.JSONEq(t, `{"responseType": "tide"}`, response.Body)
+
+	response, err = VersionedNegotiated(context.Background(), events.APIGatewayProxyRequest{
+		Path:    "/api/tides",
+		Headers: map[string]string{"accept": "application/msgpack"},
+	}, V1, body)
+	require.NoError(t, err)
+	assert.Equal(t, MessagePackMediaType, response.Headers["Content-Type"])
+	assert.Equal(t, "1", response.Headers["API-Version"])
+	assert.Equal(t, "Accept", response.Headers["Vary"])
+	assert.Equal(t, "true", response.Headers["Deprecation"])
+	require.True(t, response.IsBase64Encoded)
+	decoded, err := base64.StdEncoding.DecodeString(response.Body)
+	require.NoError(t, err)
+	want := append(append([]byte{0x81, 0xac}, "responseType"...), 0xa4)
+	assert.Equal(t, append(want, "tide"...), decoded)
+}
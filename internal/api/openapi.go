@@ -0,0 +1,711 @@
+package api
+
+//go:generate go run ../../cmd/openapi -o ../../api/openapi.json
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/bbernstein/flowebb-go/internal/offline"
+	"github.com/bbernstein/flowebb-go/internal/tidetable"
+	"github.com/bbernstein/flowebb-go/internal/timefmt"
+	"github.com/bbernstein/flowebb-go/internal/widget"
+)
+
+// Param describes a query parameter. The same definition is published in the OpenAPI
+// document and enforced by ValidateRequest.
+type Param struct {
+	Name        string
+	Description string
+	Type        string // string, number, integer or boolean
+	Required    bool
+	Minimum     *float64
+	Maximum     *float64
+	Enum        []string
+	Pattern     string
+	Example     string
+	// Check replaces the Pattern check for parameters whose forms a pattern can list but
+	// not explain; Pattern still documents them
+	Check func(param, value string) error
+}
+
+// Operation describes a REST endpoint
+type Operation struct {
+	Path        string
+	Method      string
+	OperationID string
+	Summary     string
+	Params      []Param
+	// RequireOneOf lists groups of parameters; a request must include every parameter of
+	// at least one group
+	RequireOneOf [][]string
+	// Responses maps a version to the Go type of its success body, or of its data for a
+	// version that envelopes it. A version it leaves out has the type of the version
+	// before.
+	Responses map[Version]reflect.Type
+	// ErrorResponses documents error statuses whose bodies carry more than an ErrorResponse
+	ErrorResponses map[int]ErrorResponseSpec
+	// GeoJSONResponse is the Go type of the success body sent as GeoJSON, for operations
+	// that offer it
+	GeoJSONResponse reflect.Type
+	// Unenveloped keeps every version's success body as it is, for operations whose
+	// consumers read it as another spec defines it
+	Unenveloped bool
+}
+
+// ErrorResponseSpec documents an error status with its own body
+type ErrorResponseSpec struct {
+	Description string
+	Type        reflect.Type
+}
+
+const localDatePattern = `^\d{4}-\d{2}-\d{2}$`
+
+func bound(v float64) *float64 {
+	return &v
+}
+
+// rangeParams are the startDateTime, endDateTime and tz parameters of a range at station,
+// whose start defaults as startDefault says
+func rangeParams(station, startDefault string) []Param {
+	return []Param{
+		{
+			Name: "startDateTime",
+			Description: "Start of the range: YYYY-MM-DDTHH:MM:SS or YYYY-MM-DD in tz, RFC 3339 with an offset, " +
+				"now, or an offset from now such as -6h; defaults to " + startDefault,
+			Type:    "string",
+			Pattern: validate.InstantPattern,
+			Check:   checkInstant,
+			Example: "2024-01-01T00:00:00",
+		},
+		{
+			Name:        "endDateTime",
+			Description: "End of the range, in any form startDateTime takes; a date alone runs through that day",
+			Type:        "string",
+			Pattern:     validate.InstantPattern,
+			Check:       checkInstant,
+			Example:     "2024-01-02T00:00:00",
+		},
+		{
+			Name:        "tz",
+			Description: "IANA time zone of dates and times without an offset; defaults to " + station + "'s",
+			Type:        "string",
+			Check:       checkTimeZone,
+			Example:     "America/New_York",
+		},
+	}
+}
+
+// checkInstant accepts what the tide service will; the zone and time don't matter here
+func checkInstant(param, value string) error {
+	_, err := validate.Instant(param, value, time.UTC, time.Now(), false)
+	return err
+}
+
+func checkTimeZone(param, value string) error {
+	_, err := validate.TimeZone(param, value)
+	return err
+}
+
+var locationParams = []Param{
+	{Name: "stationId", Description: "Station ID", Type: "string", Pattern: validate.StationIDPattern, Example: "9447130"},
+	{Name: "lat", Description: "Latitude in degrees", Type: "number", Minimum: bound(-90), Maximum: bound(90), Example: "47.6062"},
+	{Name: "lon", Description: "Longitude in degrees", Type: "number", Minimum: bound(-180), Maximum: bound(180), Example: "-122.3321"},
+}
+
+// stationNotFound documents the 404 of operations that look a station up by ID, and the
+// 301 to a retired station's successor
+var stationNotFound = map[int]ErrorResponseSpec{
+	http.StatusMovedPermanently: {
+		Description: "NOAA retired the station; Location is the same request for successor, the current station nearest it",
+		Type:        reflect.TypeOf(StationRetiredResponse{}),
+	},
+	http.StatusNotFound: {
+		Description: "The station ID isn't a station; suggestions lists stations it may have meant",
+		Type:        reflect.TypeOf(StationNotFoundResponse{}),
+	},
+}
+
+// StationsOperation finds a station by ID or the stations nearest a point
+var StationsOperation = Operation{
+	Path:        "/api/stations",
+	Method:      http.MethodGet,
+	OperationID: "getStations",
+	Summary:     "Find a station by ID, or the stations nearest a point",
+	Params: append(append([]Param{}, locationParams...),
+		Param{Name: "limit", Description: "Maximum number of stations to return; defaults to 5, and the service rejects more than its cap (100 unless configured otherwise)", Type: "integer", Minimum: bound(1), Example: "5"},
+		Param{Name: "offset", Description: "Number of nearest stations to skip, for paging through results", Type: "integer", Minimum: bound(0), Example: "0"},
+		Param{Name: "stationType", Description: "Only reference (R) or subordinate (S) stations", Type: "string", Enum: []string{"R", "S"}},
+		Param{Name: "capability", Description: "Only stations with this capability", Type: "string", Enum: models.FilterableCapabilities},
+		Param{Name: "source", Description: "Only stations from this data source", Type: "string", Enum: []string{"NOAA", "UKHO", "CHS"}},
+		Param{Name: "distanceUnit", Description: "Unit of each station's distance from the point; defaults to km", Type: "string", Enum: []string{"km", "mi", "nmi"}},
+		Param{Name: "format", Description: "Response format; geojson, like an Accept header of application/geo+json, answers with a FeatureCollection of the stations", Type: "string", Enum: []string{"json", "geojson"}},
+		fieldsParam,
+	),
+	RequireOneOf: [][]string{{"stationId"}, {"lat", "lon"}},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(StationsResponse{}),
+		V2: reflect.TypeOf(StationsResponse{}),
+	},
+	ErrorResponses:  stationNotFound,
+	GeoJSONResponse: reflect.TypeOf(FeatureCollection{}),
+}
+
+// RegionsOperation groups the stations by state and region
+var RegionsOperation = Operation{
+	Path:        "/api/regions",
+	Method:      http.MethodGet,
+	OperationID: "getRegions",
+	Summary:     "List the stations' states and regions with their station counts and a few stations each",
+	Params: []Param{
+		{Name: "state", Description: "Only the regions of this state, e.g. WA; ignores case", Type: "string", Example: "WA"},
+		{Name: "stationType", Description: "Only count reference (R) or subordinate (S) stations", Type: "string", Enum: []string{"R", "S"}},
+		{Name: "capability", Description: "Only count stations with this capability", Type: "string", Enum: models.FilterableCapabilities},
+		{Name: "source", Description: "Only count stations from this data source", Type: "string", Enum: []string{"NOAA", "UKHO", "CHS"}},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(RegionsResponse{}),
+		V2: reflect.TypeOf(RegionsResponse{}),
+	},
+}
+
+// TidesOperation gets tide predictions for a station or the station nearest a point
+var TidesOperation = Operation{
+	Path:        "/api/tides",
+	Method:      http.MethodGet,
+	OperationID: "getTides",
+	Summary:     "Get tide predictions for a station, or for the station nearest a point",
+	Params: append(append(append([]Param{}, locationParams...), rangeParams("the station", "the station's current day")...),
+		Param{Name: "interpolation", Description: "Interpolation between known points", Type: "string", Enum: []string{"linear", "spline", "harmonic"}},
+		Param{Name: "points", Description: "Downsample predictions to at most this many, keeping highs and lows", Type: "integer", Minimum: bound(10), Maximum: bound(5000), Example: "300"},
+		Param{Name: "includeWeather", Description: "Attach the NWS wind and pressure forecast for the station", Type: "boolean"},
+		Param{Name: "locale", Description: "Write localTime values as this locale does instead of ISO 8601", Type: "string", Enum: timefmt.Locales},
+		Param{Name: "hour12", Description: "Write localTime values with a 12-hour clock, or a 24-hour one; defaults to the locale's", Type: "boolean"},
+		fieldsParam,
+	),
+	RequireOneOf: [][]string{{"stationId"}, {"lat", "lon"}},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.ExtendedTideResponse{}),
+		V2: reflect.TypeOf(TideResponseV2{}),
+	},
+	ErrorResponses: map[int]ErrorResponseSpec{
+		http.StatusNotFound: {
+			Description: "No station is close enough to the requested point",
+			Type:        reflect.TypeOf(NoNearbyStationResponse{}),
+		},
+	},
+}
+
+// ExtremesOperation gets a station's daily highs and lows, without the 6-minute curve
+var ExtremesOperation = Operation{
+	Path:        "/api/extremes",
+	Method:      http.MethodGet,
+	OperationID: "getExtremes",
+	Summary:     "Get a station's daily high and low tides for up to 31 days",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "startDate", Description: "First day in the station's local time; defaults to today", Type: "string", Pattern: localDatePattern, Example: "2024-01-01"},
+		{Name: "days", Description: "Number of days; defaults to 7", Type: "integer", Minimum: bound(1), Maximum: bound(31), Example: "7"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.ExtremesSummary{}),
+		V2: reflect.TypeOf(models.ExtremesSummary{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// NextExtremesOperation gets a station's next highs and lows from now
+var NextExtremesOperation = Operation{
+	Path:        "/api/extremes/next",
+	Method:      http.MethodGet,
+	OperationID: "getNextExtremes",
+	Summary:     "Get a station's next high and low tides from now",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "count", Description: "Number of highs and lows; defaults to 4", Type: "integer", Minimum: bound(1), Maximum: bound(20), Example: "4"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.NextExtremes{}),
+		V2: reflect.TypeOf(models.NextExtremes{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// DaylightLowsOperation gets a station's low tides between sunrise and sunset
+var DaylightLowsOperation = Operation{
+	Path:        "/api/daylight-lows",
+	Method:      http.MethodGet,
+	OperationID: "getDaylightLows",
+	Summary:     "Get a station's low tides between sunrise and sunset for up to 31 days, for beachcombing and tidepooling",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "below", Description: "Only lows under this height, in feet above MLLW", Type: "number", Minimum: bound(-100), Maximum: bound(100), Example: "0"},
+		{Name: "startDate", Description: "First day in the station's local time; defaults to today", Type: "string", Pattern: localDatePattern, Example: "2024-01-01"},
+		{Name: "days", Description: "Number of days; defaults to 7", Type: "integer", Minimum: bound(1), Maximum: bound(31), Example: "7"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.DaylightLows{}),
+		V2: reflect.TypeOf(models.DaylightLows{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// CompareOperation lines up several stations' predictions on one timeline
+var CompareOperation = Operation{
+	Path:        "/api/compare",
+	Method:      http.MethodGet,
+	OperationID: "compareStations",
+	Summary:     "Compare 2 to 5 stations' tides on a shared timeline",
+	Params: append(append([]Param{
+		{Name: "stationIds", Description: "Comma-separated station IDs; the first is the reference for lag and range ratio", Type: "string", Required: true, Example: "9447130,9446484"},
+	}, rangeParams("the first station", "the first station's current day")...),
+		Param{Name: "interval", Description: "Minutes between samples; defaults to 6", Type: "integer", Minimum: bound(6), Maximum: bound(60), Example: "6"},
+	),
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.StationComparison{}),
+		V2: reflect.TypeOf(models.StationComparison{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// SyncOperation gets the days of predictions an offline client is missing since it last
+// synced
+var SyncOperation = Operation{
+	Path:        "/api/sync",
+	Method:      http.MethodGet,
+	OperationID: "syncPredictions",
+	Summary:     "Get the days of predictions and highs and lows a client keeping the next days of its stations' tides offline is missing since it last synced",
+	Params: []Param{
+		{Name: "stationIds", Description: fmt.Sprintf("Comma-separated IDs of up to %d stations", offline.MaxSyncStations), Type: "string", Required: true, Example: "9447130,9444900"},
+		{Name: "since", Description: "syncedAt of the client's last sync, in epoch milliseconds; leave out, or 0, for every day", Type: "integer", Minimum: bound(0), Example: "1735689600000"},
+		{Name: "days", Description: fmt.Sprintf("Days the client keeps from today, the same each sync; defaults to %d", offline.DefaultSyncDays), Type: "integer", Minimum: bound(1), Maximum: bound(offline.MaxSyncDays), Example: "7"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.PredictionSync{}),
+		V2: reflect.TypeOf(models.PredictionSync{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// ObservationOperation gets a station's latest sensor reading
+var ObservationOperation = Operation{
+	Path:        "/api/observations",
+	Method:      http.MethodGet,
+	OperationID: "getObservation",
+	Summary:     "Get a station's latest reading of a sensor product it measures",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "product", Description: "Sensor product; the station must have a sensor for it", Type: "string", Required: true, Enum: observation.Names(observation.Products)},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.ObservationResponse{}),
+		V2: reflect.TypeOf(models.ObservationResponse{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// AccuracyOperation gets how closely a station's predictions have matched its gauge
+var AccuracyOperation = Operation{
+	Path:        "/api/accuracy",
+	Method:      http.MethodGet,
+	OperationID: "getAccuracy",
+	Summary:     "Get the mean absolute error and bias of a tracked station's recent predictions",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "days", Description: "Number of UTC days, today included; defaults to 7", Type: "integer", Minimum: bound(1), Maximum: bound(accuracy.MaxDays), Example: "7"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.AccuracyStats{}),
+		V2: reflect.TypeOf(models.AccuracyStats{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// ExportOperation submits a station's yearly tide table, predictions or chart for
+// rendering and reports its progress. Repeating the request polls it, so it's a GET like
+// the rest.
+var ExportOperation = Operation{
+	Path:        "/api/exports",
+	Method:      http.MethodGet,
+	OperationID: "exportTideTable",
+	Summary:     "Render a station's highs and lows for a year as CSV or PDF, or its predictions as CSV or tide curve as an image for up to a year, returning a download URL once ready",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "kind", Description: "What to render; defaults to table", Type: "string", Enum: export.Kinds},
+		{Name: "year", Description: "Calendar year of a table", Type: "integer", Minimum: bound(export.MinYear), Maximum: bound(export.MaxYear), Example: "2025"},
+		{Name: "startDate", Description: "First day of predictions or a chart, in the station's local time", Type: "string", Pattern: localDatePattern, Example: "2025-01-01"},
+		{Name: "endDate", Description: fmt.Sprintf("Last day of predictions or a chart, at most %d days from startDate", export.MaxRangeDays-1), Type: "string", Pattern: localDatePattern, Example: "2025-12-31"},
+		{Name: "format", Description: "Format: csv or pdf for tables (default pdf), csv for predictions, svg (default) or png for charts", Type: "string", Enum: export.Formats},
+	},
+	RequireOneOf: [][]string{{"year"}, {"startDate", "endDate"}},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.TideTableExport{}),
+		V2: reflect.TypeOf(models.TideTableExport{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// ExportStatusOperation polls an export by the job ID its submission returned
+var ExportStatusOperation = Operation{
+	Path:        "/api/exports/status",
+	Method:      http.MethodGet,
+	OperationID: "getExportStatus",
+	Summary:     "Get the progress of a submitted export, and its download URL once ready",
+	Params: []Param{
+		{Name: "jobId", Description: "Job ID returned by exportTideTable", Type: "string", Required: true, Pattern: export.IDPattern, Example: "9447130/2025.pdf"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.TideTableExport{}),
+		V2: reflect.TypeOf(models.TideTableExport{}),
+	},
+}
+
+// WidgetOperation gets the compact payload of the embeddable tide module
+var WidgetOperation = Operation{
+	Path:        "/api/widget",
+	Method:      http.MethodGet,
+	OperationID: "getWidget",
+	Summary:     "Get a station's level now and next highs and lows for the embeddable tide module",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "theme", Description: "Color scheme; defaults to light", Type: "string", Enum: widget.ThemeNames},
+		{Name: "accent", Description: "Accent color as rrggbb, replacing the theme's", Type: "string", Pattern: widget.AccentPattern, Example: "ff6600"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.TideWidget{}),
+		V2: reflect.TypeOf(models.TideWidget{}),
+	},
+	ErrorResponses: stationNotFound,
+}
+
+// OEmbedOperation answers oEmbed consumers with an iframe of a widget page
+var OEmbedOperation = Operation{
+	Path:        "/api/oembed",
+	Method:      http.MethodGet,
+	OperationID: "getOEmbed",
+	Summary:     "Get the oEmbed response embedding a station's widget page",
+	Params: []Param{
+		{Name: "url", Description: "Widget page URL, with the stationId and optionally theme and accent", Type: "string", Required: true, Example: "https://app.flowebb.com/widget?stationId=9447130"},
+		{Name: "maxwidth", Description: "Largest width in pixels the embed may take", Type: "integer", Minimum: bound(1), Example: "320"},
+		{Name: "maxheight", Description: "Largest height in pixels the embed may take", Type: "integer", Minimum: bound(1), Example: "180"},
+		{Name: "format", Description: "Response format; only json is implemented", Type: "string", Enum: []string{"json", "xml"}},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(models.OEmbed{}),
+		V2: reflect.TypeOf(models.OEmbed{}),
+	},
+	// oEmbed consumers read the body as the oEmbed spec defines it
+	Unenveloped: true,
+}
+
+// UsageOperation reports what the requesting API key used in a month. It isn't counted
+// against the key's quota.
+var UsageOperation = Operation{
+	Path:        "/api/usage",
+	Method:      http.MethodGet,
+	OperationID: "getUsage",
+	Summary:     "Get the requesting API key's requests in a month by day and endpoint, and how much of its monthly quota is left",
+	Params: []Param{
+		{Name: "month", Description: "Month as YYYY-MM in UTC; defaults to the current month", Type: "string", Pattern: tidetable.MonthPattern, Example: "2025-07"},
+	},
+	Responses: map[Version]reflect.Type{
+		V1: reflect.TypeOf(UsageResponse{}),
+		V2: reflect.TypeOf(UsageResponse{}),
+	},
+}
+
+// ChartOperation renders a station's tide curve as an image. It answers with an image
+// rather than JSON, so it's left out of Operations and the generated clients.
+var ChartOperation = Operation{
+	Path:        "/api/tides/chart",
+	Method:      http.MethodGet,
+	OperationID: "getTideChart",
+	Summary:     "Render a station's tide curve with its highs and lows labeled",
+	Params: append(append([]Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+	}, rangeParams("the station", "today")...),
+		Param{Name: "format", Description: "Image format; defaults to svg", Type: "string", Enum: []string{"svg", "png"}},
+		Param{Name: "width", Description: "Width in pixels; defaults to 800", Type: "integer", Minimum: bound(200), Maximum: bound(2000), Example: "800"},
+		Param{Name: "height", Description: "Height in pixels; defaults to 300", Type: "integer", Minimum: bound(100), Maximum: bound(1000), Example: "300"},
+	),
+}
+
+// TableOperation renders a station's month of highs and lows as a printable page. It answers
+// with HTML rather than JSON, so it's left out of Operations and the generated clients.
+var TableOperation = Operation{
+	Path:        "/api/tides/table",
+	Method:      http.MethodGet,
+	OperationID: "getTideTable",
+	Summary:     "Render a station's highs and lows for a month as a printable HTML table",
+	Params: []Param{
+		{Name: "stationId", Description: "Station ID", Type: "string", Required: true, Pattern: validate.StationIDPattern, Example: "9447130"},
+		{Name: "month", Description: "Month as YYYY-MM; defaults to the station's current month", Type: "string", Pattern: tidetable.MonthPattern, Example: "2025-07"},
+	},
+}
+
+// Operations lists every documented REST endpoint
+var Operations = []Operation{StationsOperation, RegionsOperation, TidesOperation, ExtremesOperation, NextExtremesOperation, DaylightLowsOperation, CompareOperation, ObservationOperation, AccuracyOperation, SyncOperation, ExportOperation, ExportStatusOperation, WidgetOperation, OEmbedOperation, UsageOperation}
+
+// OpenAPISpec builds the OpenAPI 3 document for the REST API. Response schemas are
+// derived from the Go response types, so they can't drift from what's served.
+func OpenAPISpec() ([]byte, error) {
+	schemas := map[string]interface{}{}
+	addSchema(schemas, reflect.TypeOf(ErrorResponse{}))
+	addSchema(schemas, reflect.TypeOf(ValidationErrorResponse{}))
+
+	paths := map[string]interface{}{}
+	for _, op := range Operations {
+		for version := V1; version <= LatestVersion; version++ {
+			path := op.Path
+			if version != DefaultVersion {
+				path = versionPath(op.Path, version)
+			}
+			paths[path] = map[string]interface{}{
+				strings.ToLower(op.Method): operationSpec(op, version, schemas),
+			}
+		}
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Flowebb API",
+			"version":     "3",
+			"description": envelopeScope,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}, "", "  ")
+}
+
+func operationSpec(op Operation, version Version, schemas map[string]interface{}) map[string]interface{} {
+	params := make([]interface{}, len(op.Params))
+	for i, p := range op.Params {
+		params[i] = paramSpec(p)
+	}
+
+	description := ""
+	if len(op.RequireOneOf) > 0 {
+		groups := make([]string, len(op.RequireOneOf))
+		for i, group := range op.RequireOneOf {
+			groups[i] = strings.Join(group, " and ")
+		}
+		description = "Requires " + strings.Join(groups, ", or ") + "."
+	}
+
+	operationID := op.OperationID
+	if version != DefaultVersion {
+		operationID = fmt.Sprintf("%sV%d", operationID, version)
+	}
+
+	success := addSchema(schemas, op.responseType(version))
+	if envelopes(version) {
+		if op.Unenveloped {
+			description = strings.TrimSpace(description + " The success body isn't enveloped.")
+		} else {
+			success = addEnvelopeSchema(schemas, success)
+		}
+	}
+
+	spec := map[string]interface{}{
+		"operationId": operationID,
+		"summary":     op.Summary,
+		"description": description,
+		"parameters":  params,
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Success",
+				"content":     jsonContent(success),
+			},
+			"400": map[string]interface{}{
+				"description": "Invalid parameters",
+				"content":     jsonContent(schemaName(reflect.TypeOf(ValidationErrorResponse{}))),
+			},
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     jsonContent(schemaName(reflect.TypeOf(ErrorResponse{}))),
+			},
+		},
+	}
+	responses := spec["responses"].(map[string]interface{})
+	if op.GeoJSONResponse != nil {
+		success := responses["200"].(map[string]interface{})
+		success["content"].(map[string]interface{})[GeoJSONMediaType] = map[string]interface{}{
+			"schema": ref(addSchema(schemas, op.GeoJSONResponse)),
+		}
+	}
+	for status, errSpec := range op.ErrorResponses {
+		responses[strconv.Itoa(status)] = map[string]interface{}{
+			"description": errSpec.Description,
+			"content":     jsonContent(addSchema(schemas, errSpec.Type)),
+		}
+	}
+	if version < LatestVersion {
+		spec["deprecated"] = true
+	}
+	return spec
+}
+
+// responseType is the Go type of the operation's success body, or data, at version
+func (op Operation) responseType(version Version) reflect.Type {
+	for ; version > V1; version-- {
+		if t, ok := op.Responses[version]; ok {
+			return t
+		}
+	}
+	return op.Responses[V1]
+}
+
+// addEnvelopeSchema adds the schema of an Envelope whose data is the named schema, and
+// returns its name. Data is an interface, so it can't be derived from the Go type.
+func addEnvelopeSchema(schemas map[string]interface{}, data string) string {
+	name := data + "Envelope"
+	if _, ok := schemas[name]; ok {
+		return name
+	}
+	warnings := typeSchema(schemas, reflect.TypeOf(Envelope{}.Warnings))
+	delete(warnings, "nullable") // Envelopes always have a list
+	schemas[name] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"data":     ref(data),
+			"meta":     ref(addSchema(schemas, reflect.TypeOf(Meta{}))),
+			"warnings": warnings,
+		},
+		"required": []string{"data", "meta", "warnings"},
+	}
+	return name
+}
+
+func paramSpec(p Param) map[string]interface{} {
+	schema := map[string]interface{}{"type": p.Type}
+	if p.Minimum != nil {
+		schema["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		schema["maximum"] = *p.Maximum
+	}
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum
+	}
+	if p.Pattern != "" {
+		schema["pattern"] = p.Pattern
+	}
+
+	spec := map[string]interface{}{
+		"name":        p.Name,
+		"in":          "query",
+		"description": p.Description,
+		"required":    p.Required,
+		"schema":      schema,
+	}
+	if p.Example != "" {
+		spec["example"] = p.Example
+	}
+	return spec
+}
+
+func jsonContent(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": ref(name),
+		},
+	}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func schemaName(t reflect.Type) string {
+	return t.Name()
+}
+
+// addSchema adds t's schema, and those of the structs it uses, to schemas and returns
+// its name
+func addSchema(schemas map[string]interface{}, t reflect.Type) string {
+	name := schemaName(t)
+	if _, ok := schemas[name]; ok {
+		return name
+	}
+	schemas[name] = nil // Placeholder so recursive types terminate
+
+	properties := map[string]interface{}{}
+	var required []string
+	addProperties(schemas, t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	schemas[name] = schema
+	return name
+}
+
+func addProperties(schemas map[string]interface{}, t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			addProperties(schemas, field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = typeSchema(schemas, field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func typeSchema(schemas map[string]interface{}, t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := typeSchema(schemas, t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		// nil slices encode as null
+		return map[string]interface{}{"type": "array", "items": typeSchema(schemas, t.Elem()), "nullable": true}
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(schemas, t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(schemas, t.Elem())}
+	case reflect.Struct:
+		return ref(addSchema(schemas, t))
+	default:
+		return map[string]interface{}{}
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+)
+
+// Recover wraps next so a panic in it is tracked by recovery.Default and answered with a
+// 500 INTERNAL_ERROR naming the panic's fingerprint, rather than failing the invocation
+func Recover(next HandlerFunc) HandlerFunc {
+	return RecoverWith(next, ErrorFor)
+}
+
+// RecoverWith is Recover for handlers whose errors have a shape of their own, like
+// GraphQL's: answer turns the recovered *recovery.Error into the response
+func RecoverWith(next HandlerFunc, answer func(err error) (events.APIGatewayProxyResponse, error)) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (response events.APIGatewayProxyResponse, err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				response, err = answer(recovery.Default.Recovered(value, debug.Stack()))
+			}
+		}()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+)
+
+func panicking(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	var stations map[string]int
+	stations["9447130"]++
+	return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+}
+
+func TestRecover(t *testing.T) {
+	var bodies []string
+	// The same panic from the same call site has the same fingerprint
+	for range 2 {
+		response, err := Recover(panicking)(context.Background(), events.APIGatewayProxyRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+
+		var body ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+		assert.Equal(t, CodeInternal, body.Code)
+		assert.Regexp(t, `^Internal error \(fingerprint [0-9a-f]{12}\)$`, body.Error)
+		bodies = append(bodies, response.Body)
+	}
+	assert.Equal(t, bodies[0], bodies[1])
+}
+
+func TestRecover_PassesThrough(t *testing.T) {
+	ok := func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "ok"}, nil
+	}
+	response, err := Recover(ok)(context.Background(), events.APIGatewayProxyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response.Body)
+}
+
+func TestRecoverWith(t *testing.T) {
+	var recovered *recovery.Error
+	answer := func(err error) (events.APIGatewayProxyResponse, error) {
+		require.ErrorAs(t, err, &recovered)
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: recovered.Fingerprint}, nil
+	}
+	response, err := RecoverWith(panicking, answer)(context.Background(), events.APIGatewayProxyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, recovered.Fingerprint, response.Body)
+}
@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// HandlerFunc handles an API Gateway request
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// ParamError describes one invalid query parameter
+type ParamError struct {
+	Parameter string `json:"parameter"`
+	Message   string `json:"message"`
+	// Value is what the request sent, Allowed what it could have sent instead
+	Value      string `json:"value,omitempty"`
+	Allowed    string `json:"allowed,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// NewParamError describes a validation error for the response details
+func NewParamError(err *validate.Error) ParamError {
+	return ParamError{
+		Parameter:  err.Parameter,
+		Message:    err.Message,
+		Value:      err.Value,
+		Allowed:    err.Allowed,
+		Suggestion: err.Suggestion,
+	}
+}
+
+// ValidationErrorResponse is the 400 body for requests that don't match an Operation
+type ValidationErrorResponse struct {
+	APIResponse
+	Code    ErrorCode    `json:"code"`
+	Error   string       `json:"error"`
+	Details []ParamError `json:"details"`
+}
+
+var _ APIResponder = (*ValidationErrorResponse)(nil)
+
+func NewValidationErrorResponse(details []ParamError) *ValidationErrorResponse {
+	return &ValidationErrorResponse{
+		APIResponse: APIResponse{ResponseType: "error"},
+		Code:        CodeInvalidRequest,
+		Error:       "Invalid request parameters",
+		Details:     details,
+	}
+}
+
+// InvalidParameter answers a 400 for a parameter the services rejected after
+// ValidateRequest let it through, e.g. a station ID with spaces in it
+func InvalidParameter(err *validate.Error) (events.APIGatewayProxyResponse, error) {
+	return ErrorBody(NewValidationErrorResponse([]ParamError{NewParamError(err)}), http.StatusBadRequest)
+}
+
+// ValidateRequest checks the request's query parameters against op before calling next,
+// answering a 400 that lists every problem if they don't match. It also applies the
+// request's feature flag overrides to the context next gets, and has it collect the
+// provenance an Envelope reports.
+func ValidateRequest(op Operation, next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		ctx = feature.FromHeaders(ctx, request.Headers)
+		ctx = models.WithStationLookups(ctx)
+		ctx = models.WithProvenance(ctx)
+		if details := op.Validate(request.QueryStringParameters); len(details) > 0 {
+			return ErrorBody(NewValidationErrorResponse(details), http.StatusBadRequest)
+		}
+		response, err := next(ctx, request)
+		if successorID, ok := response.Headers[successorHeader]; ok {
+			delete(response.Headers, successorHeader)
+			response.Headers["Location"] = successorLocation(request, successorID)
+		}
+		return response, err
+	}
+}
+
+// successorLocation is the request, asking for a retired station's successor in its place.
+// The request context's path includes the API Gateway stage, which Path leaves out.
+func successorLocation(request events.APIGatewayProxyRequest, successorID string) string {
+	path := request.RequestContext.Path
+	if path == "" {
+		path = request.Path
+	}
+	query := url.Values{}
+	for name, value := range request.QueryStringParameters {
+		query.Set(name, value)
+	}
+	query.Set("stationId", successorID)
+	return path + "?" + query.Encode()
+}
+
+// Validate checks params against the operation's parameters. Parameters it doesn't
+// define are ignored.
+func (op Operation) Validate(params map[string]string) []ParamError {
+	var details []ParamError
+	for _, p := range op.Params {
+		value, ok := params[p.Name]
+		if !ok {
+			if p.Required {
+				details = append(details, ParamError{Parameter: p.Name, Message: "is required"})
+			}
+			continue
+		}
+		var verr *validate.Error
+		if errors.As(p.check(value), &verr) {
+			details = append(details, NewParamError(verr))
+		}
+	}
+
+	if len(op.RequireOneOf) > 0 && !op.satisfiesOneOf(params) {
+		details = append(details, op.oneOfError(params))
+	}
+	return details
+}
+
+func (p Param) check(value string) error {
+	switch p.Type {
+	case "number", "integer":
+		var n float64
+		if p.Type == "integer" {
+			i, err := validate.Integer(p.Name, value)
+			if err != nil {
+				return err
+			}
+			n = float64(i)
+		} else {
+			f, err := validate.Number(p.Name, value)
+			if err != nil {
+				return err
+			}
+			n = f
+		}
+		if err := validate.Bounds(p.Name, n, p.Minimum, p.Maximum); err != nil {
+			return err
+		}
+	case "boolean":
+		if _, err := validate.Bool(p.Name, value); err != nil {
+			return err
+		}
+	case "string":
+		if err := validate.NotEmpty(p.Name, value); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Enum) > 0 {
+		return validate.OneOfFold(p.Name, value, p.Enum...)
+	}
+	if p.Check != nil {
+		return p.Check(p.Name, value)
+	}
+	if p.Pattern != "" {
+		return validate.Format(p.Name, value, compiledPattern(p.Pattern), p.Example)
+	}
+	return nil
+}
+
+func (op Operation) satisfiesOneOf(params map[string]string) bool {
+	for _, group := range op.RequireOneOf {
+		complete := true
+		for _, name := range group {
+			if _, ok := params[name]; !ok {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return true
+		}
+	}
+	return false
+}
+
+// oneOfError points at the first partly supplied group, e.g. lat without lon, or else
+// lists the alternatives
+func (op Operation) oneOfError(params map[string]string) ParamError {
+	for _, group := range op.RequireOneOf {
+		var present, missing []string
+		for _, name := range group {
+			if _, ok := params[name]; ok {
+				present = append(present, name)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+		if len(present) > 0 {
+			return ParamError{
+				Parameter: missing[0],
+				Message:   "is required with " + strings.Join(present, " and "),
+			}
+		}
+	}
+
+	groups := make([]string, len(op.RequireOneOf))
+	for i, group := range op.RequireOneOf {
+		groups[i] = strings.Join(group, " and ")
+	}
+	return ParamError{
+		Parameter: strings.Join(op.RequireOneOf[0], ","),
+		Message:   "requires " + strings.Join(groups, ", or "),
+	}
+}
+
+var patterns sync.Map // pattern -> *regexp.Regexp
+
+func compiledPattern(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     Operation
+		params map[string]string
+		want   []ParamError
+	}{
+		{
+			name:   "station ID",
+			op:     TidesOperation,
+			params: map[string]string{"stationId": "9447130"},
+		},
+		{
+			name:   "coordinates with options",
+			op:     TidesOperation,
+			params: map[string]string{"lat": "47.6", "lon": "-122.3", "startDateTime": "2024-01-01T00:00:00", "endDateTime": "+48h", "tz": "UTC", "interpolation": "Spline", "includeWeather": "true"},
+		},
+		{
+			name:   "unknown parameters are ignored",
+			op:     StationsOperation,
+			params: map[string]string{"stationId": "9447130", "debug": "1"},
+		},
+		{
+			name:   "nothing to look up",
+			op:     TidesOperation,
+			params: map[string]string{},
+			want:   []ParamError{{Parameter: "stationId", Message: "requires stationId, or lat and lon"}},
+		},
+		{
+			name:   "every problem is reported",
+			op:     TidesOperation,
+			params: map[string]string{"lon": "-200", "startDateTime": "tomorrow", "tz": "Pacific/Atlantis", "interpolation": "cubic"},
+			want: []ParamError{
+				{Parameter: "lon", Message: "must be at least -180", Value: "-200", Allowed: "-180 to 180"},
+				{
+					Parameter: "startDateTime",
+					Message:   "must be a date, a date-time, now or an offset",
+					Value:     "tomorrow",
+					Allowed:   "YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS, RFC 3339, now, +48h",
+				},
+				{Parameter: "tz", Message: "must be an IANA time zone", Value: "Pacific/Atlantis", Allowed: "an IANA time zone such as America/New_York"},
+				{Parameter: "interpolation", Message: "must be one of linear, spline, harmonic", Value: "cubic", Allowed: "linear, spline, harmonic"},
+				{Parameter: "lat", Message: "is required with lon"},
+			},
+		},
+		{
+			name:   "integer",
+			op:     StationsOperation,
+			params: map[string]string{"stationId": "9447130", "limit": "2.5"},
+			want:   []ParamError{{Parameter: "limit", Message: "must be an integer", Value: "2.5"}},
+		},
+		{
+			name:   "boolean",
+			op:     TidesOperation,
+			params: map[string]string{"stationId": "9447130", "includeWeather": "yes"},
+			want:   []ParamError{{Parameter: "includeWeather", Message: "must be true or false", Value: "yes", Allowed: "true, false"}},
+		},
+		{
+			name:   "near miss",
+			op:     TidesOperation,
+			params: map[string]string{"stationId": "9447130", "interpolation": "splin"},
+			want: []ParamError{{
+				Parameter:  "interpolation",
+				Message:    "must be one of linear, spline, harmonic",
+				Value:      "splin",
+				Allowed:    "linear, spline, harmonic",
+				Suggestion: "spline",
+			}},
+		},
+		{
+			name:   "station ID",
+			op:     TidesOperation,
+			params: map[string]string{"stationId": "9447130; DROP"},
+			want:   []ParamError{{Parameter: "stationId", Message: "must look like 9447130", Value: "9447130; DROP", Allowed: "9447130"}},
+		},
+		{
+			name:   "capability the station list doesn't carry",
+			op:     StationsOperation,
+			params: map[string]string{"lat": "47.6", "lon": "-122.3", "capability": "WIND"},
+			want: []ParamError{{
+				Parameter: "capability",
+				Message:   "must be one of WATER_LEVEL, WATER_TEMPERATURE, CONDUCTIVITY",
+				Value:     "WIND",
+				Allowed:   "WATER_LEVEL, WATER_TEMPERATURE, CONDUCTIVITY",
+			}},
+		},
+		{
+			name:   "empty string",
+			op:     StationsOperation,
+			params: map[string]string{"stationId": ""},
+			want:   []ParamError{{Parameter: "stationId", Message: "must not be empty"}},
+		},
+		{
+			name:   "required",
+			op:     Operation{Params: []Param{{Name: "q", Type: "string", Required: true}}},
+			params: map[string]string{},
+			want:   []ParamError{{Parameter: "q", Message: "is required"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.op.Validate(tt.params))
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	called := false
+	handler := ValidateRequest(StationsOperation, func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		called = true
+		return Success(NewStationsResponse(nil))
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"lat": "north", "lon": "0"},
+	})
+	require.NoError(t, err)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+	assert.JSONEq(t, `{
+		"responseType": "error",
+		"code": "INVALID_REQUEST",
+		"error": "Invalid request parameters",
+		"details": [{"parameter": "lat", "message": "must be a number", "value": "north"}]
+	}`, response.Body)
+
+	response, err = handler(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"lat": "47.6", "lon": "-122.3"},
+	})
+	require.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestValidateRequest_RetiredStation(t *testing.T) {
+	handler := ValidateRequest(TidesOperation, func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return ErrorFor(&models.StationRetiredError{StationID: request.QueryStringParameters["stationId"], Successor: models.Station{ID: "9447130"}})
+	})
+
+	request := events.APIGatewayProxyRequest{
+		Path:                  "/api/tides",
+		QueryStringParameters: map[string]string{"stationId": "9447110", "startDateTime": "now"},
+	}
+	request.RequestContext.Path = "/Prod/api/tides"
+	response, err := handler(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMovedPermanently, response.StatusCode)
+	assert.Equal(t, "/Prod/api/tides?startDateTime=now&stationId=9447130", response.Headers["Location"])
+	assert.NotContains(t, response.Headers, successorHeader)
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	spec, err := OpenAPISpec()
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Description string `json:"description"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Deprecated  bool   `json:"deprecated"`
+			Parameters  []struct {
+				Name string `json:"name"`
+			} `json:"parameters"`
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema map[string]string `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	v1 := doc.Paths["/api/tides"]["get"]
+	assert.Equal(t, "getTides", v1.OperationID)
+	assert.True(t, v1.Deprecated)
+	assert.Equal(t, "#/components/schemas/ExtendedTideResponse", v1.Responses["200"].Content["application/json"].Schema["$ref"])
+	assert.Len(t, v1.Parameters, len(TidesOperation.Params))
+
+	v2 := doc.Paths["/api/v2/tides"]["get"]
+	assert.Equal(t, "getTidesV2", v2.OperationID)
+	assert.True(t, v2.Deprecated)
+	assert.Equal(t, "#/components/schemas/TideResponseV2", v2.Responses["200"].Content["application/json"].Schema["$ref"])
+	assert.Contains(t, doc.Paths, "/api/v2/stations")
+
+	// V3 envelopes V2's data
+	v3 := doc.Paths["/api/v3/tides"]["get"]
+	assert.Equal(t, "getTidesV3", v3.OperationID)
+	assert.False(t, v3.Deprecated)
+	assert.Equal(t, "#/components/schemas/TideResponseV2Envelope", v3.Responses["200"].Content["application/json"].Schema["$ref"])
+	envelope := doc.Components.Schemas["TideResponseV2Envelope"]
+	assert.Equal(t, "#/components/schemas/TideResponseV2", envelope.Properties["data"]["$ref"])
+	assert.Equal(t, "#/components/schemas/Meta", envelope.Properties["meta"]["$ref"])
+	assert.ElementsMatch(t, []string{"data", "meta", "warnings"}, envelope.Required)
+	assert.Contains(t, doc.Components.Schemas["Meta"].Properties, "cacheStatus")
+
+	// oEmbed's body is never enveloped
+	oEmbed := doc.Paths["/api/v3/oembed"]["get"]
+	assert.Equal(t, "#/components/schemas/OEmbed", oEmbed.Responses["200"].Content["application/json"].Schema["$ref"])
+	assert.NotContains(t, doc.Components.Schemas, "OEmbedEnvelope")
+	assert.Contains(t, doc.Info.Description, "getOEmbed")
+
+	// Schemas follow the Go types, including embedded and nested structs
+	station := doc.Components.Schemas["Station"]
+	assert.Equal(t, "string", station.Properties["id"]["type"])
+	assert.Equal(t, true, station.Properties["state"]["nullable"])
+	assert.NotContains(t, station.Required, "state")
+	assert.Contains(t, doc.Components.Schemas["StationsResponse"].Properties, "responseType")
+	assert.Contains(t, doc.Components.Schemas, "TideLevelV2")
+	assert.Contains(t, doc.Components.Schemas, "ValidationErrorResponse")
+}
@@ -0,0 +1,378 @@
+// Package validate checks request parameters. Its errors name the parameter, the value
+// that was rejected and what would have been accepted, so the REST validation details,
+// GraphQL error extensions and service errors all describe a bad input the same way.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DateFormat is the layout of calendar dates, read in the station's time zone
+	DateFormat = "2006-01-02"
+	// DateTimeFormat is the layout of local date-times, read in the station's time zone
+	DateTimeFormat = "2006-01-02T15:04:05"
+
+	// InstantPattern matches every form Instant accepts
+	InstantPattern = `^(now|[+-](\d+[dhms])+|\d{4}-\d{2}-\d{2}(T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?)?)$`
+
+	// StationIDPattern matches the characters NOAA, UKHO, CHS and virtual station IDs
+	// are made of
+	StationIDPattern = `^[A-Za-z0-9:._*@+-]+$`
+
+	maxStationIDLength = 256
+)
+
+// Error is a parameter value that failed validation
+type Error struct {
+	Parameter string
+	Value     string
+	// Message says what's wrong, e.g. "must be at most 90"
+	Message string
+	// Allowed describes the accepted values: a range, a list or a format
+	Allowed string
+	// Suggestion is the allowed value the caller most likely meant, if any is close
+	Suggestion string
+	// Err is the kind of error, for errors.Is; see Kind
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("invalid %s %q: %s", e.Parameter, e.Value, e.Message)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Kind marks a validation error as kind, so callers can test for it with errors.Is.
+// Errors that didn't come from this package are returned as is.
+func Kind(err, kind error) error {
+	var verr *Error
+	if errors.As(err, &verr) {
+		verr.Err = kind
+	}
+	return err
+}
+
+// Number parses a decimal number
+func Number(param, value string) (float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &Error{Parameter: param, Value: value, Message: "must be a number"}
+	}
+	return n, nil
+}
+
+// Integer parses a whole number
+func Integer(param, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &Error{Parameter: param, Value: value, Message: "must be an integer"}
+	}
+	return n, nil
+}
+
+// Bool parses true or false, in any of the forms strconv.ParseBool accepts
+func Bool(param, value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &Error{Parameter: param, Value: value, Message: "must be true or false", Allowed: "true, false"}
+	}
+	return b, nil
+}
+
+// NotEmpty rejects the empty string
+func NotEmpty(param, value string) error {
+	if value == "" {
+		return &Error{Parameter: param, Value: value, Message: "must not be empty"}
+	}
+	return nil
+}
+
+// Between checks that value is within min and max, inclusive
+func Between(param string, value, min, max float64) error {
+	if value < min || value > max {
+		return &Error{
+			Parameter: param,
+			Value:     formatNumber(value),
+			Message:   fmt.Sprintf("must be between %g and %g", min, max),
+			Allowed:   rangeText(&min, &max),
+		}
+	}
+	return nil
+}
+
+// Bounds checks value against whichever of min and max are set, naming the bound it
+// broke
+func Bounds(param string, value float64, min, max *float64) error {
+	var message string
+	switch {
+	case min != nil && value < *min:
+		message = fmt.Sprintf("must be at least %g", *min)
+	case max != nil && value > *max:
+		message = fmt.Sprintf("must be at most %g", *max)
+	default:
+		return nil
+	}
+	return &Error{Parameter: param, Value: formatNumber(value), Message: message, Allowed: rangeText(min, max)}
+}
+
+// AtLeast checks that value is min or more
+func AtLeast(param string, value, min float64) error {
+	return Bounds(param, value, &min, nil)
+}
+
+// Latitude checks a latitude in degrees
+func Latitude(param string, value float64) error {
+	return Between(param, value, -90, 90)
+}
+
+// Longitude checks a longitude in degrees
+func Longitude(param string, value float64) error {
+	return Between(param, value, -180, 180)
+}
+
+// Coordinates checks a lat and lon pair, reporting the latitude first
+func Coordinates(lat, lon float64) error {
+	if err := Latitude("lat", lat); err != nil {
+		return err
+	}
+	return Longitude("lon", lon)
+}
+
+// OneOf checks that value is exactly one of allowed
+func OneOf(param, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return oneOfError(param, value, allowed)
+}
+
+// OneOfFold is OneOf ignoring case
+func OneOfFold(param, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return nil
+		}
+	}
+	return oneOfError(param, value, allowed)
+}
+
+func oneOfError(param, value string, allowed []string) error {
+	return &Error{
+		Parameter:  param,
+		Value:      value,
+		Message:    "must be one of " + strings.Join(allowed, ", "),
+		Allowed:    strings.Join(allowed, ", "),
+		Suggestion: closest(value, allowed),
+	}
+}
+
+// Format checks value against pattern, describing the format with example
+func Format(param, value string, pattern *regexp.Regexp, example string) error {
+	if pattern.MatchString(value) {
+		return nil
+	}
+	if example == "" {
+		return &Error{Parameter: param, Value: value, Message: "must match " + pattern.String(), Allowed: pattern.String()}
+	}
+	return &Error{Parameter: param, Value: value, Message: "must look like " + example, Allowed: example}
+}
+
+// Date parses a YYYY-MM-DD calendar date in location
+func Date(param, value string, location *time.Location) (time.Time, error) {
+	return parseTime(param, value, DateFormat, "YYYY-MM-DD", location)
+}
+
+// DateTime parses a YYYY-MM-DDTHH:MM:SS local date-time in location
+func DateTime(param, value string, location *time.Location) (time.Time, error) {
+	return parseTime(param, value, DateTimeFormat, "YYYY-MM-DDTHH:MM:SS", location)
+}
+
+// Instant parses a point in time given as a local date-time or date read in location, an
+// RFC 3339 time with its own offset, "now", or an offset from now such as "+48h" or
+// "-2d". A date alone is the start of that day, or its last second when endOfDay is set.
+// The result is in location either way.
+func Instant(param, value string, location *time.Location, now time.Time, endOfDay bool) (time.Time, error) {
+	switch {
+	case value == "now":
+		return now.In(location), nil
+	case strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"):
+		offset, ok := parseOffset(value)
+		if !ok {
+			return time.Time{}, &Error{Parameter: param, Value: value, Message: "must be an offset like +48h or -2d", Allowed: instantFormats}
+		}
+		return now.Add(offset).In(location), nil
+	case strings.ContainsAny(value[min(len(value), len(DateFormat)):], "Z+-"):
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, &Error{Parameter: param, Value: value, Message: "must be RFC 3339, e.g. 2024-01-01T00:00:00-08:00", Allowed: instantFormats}
+		}
+		return t.In(location), nil
+	case len(value) == len(DateFormat):
+		t, err := parseTime(param, value, DateFormat, "YYYY-MM-DD", location)
+		if err == nil && endOfDay {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		return t, err
+	}
+	t, err := time.ParseInLocation(DateTimeFormat, value, location)
+	if err != nil {
+		return time.Time{}, &Error{Parameter: param, Value: value, Message: "must be a date, a date-time, now or an offset", Allowed: instantFormats}
+	}
+	return t, nil
+}
+
+const instantFormats = "YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS, RFC 3339, now, +48h"
+
+// parseOffset reads a signed run of days, hours, minutes and seconds such as -1d12h
+func parseOffset(value string) (time.Duration, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+	sign := time.Duration(1)
+	if value[0] == '-' {
+		sign = -1
+	}
+	rest := value[1:]
+	var total time.Duration
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(rest) {
+			return 0, false
+		}
+		n, err := strconv.Atoi(rest[:i])
+		if err != nil {
+			return 0, false
+		}
+		unit, ok := offsetUnits[rest[i]]
+		if !ok {
+			return 0, false
+		}
+		total += time.Duration(n) * unit
+		rest = rest[i+1:]
+	}
+	return sign * total, true
+}
+
+var offsetUnits = map[byte]time.Duration{'d': 24 * time.Hour, 'h': time.Hour, 'm': time.Minute, 's': time.Second}
+
+// TimeZone loads an IANA time zone such as America/New_York
+func TimeZone(param, value string) (*time.Location, error) {
+	// LoadLocation treats "" and "UTC" alike and reads "Local" as the server's zone
+	if value == "" || value == "Local" {
+		return nil, &Error{Parameter: param, Value: value, Message: "must be an IANA time zone", Allowed: "an IANA time zone such as America/New_York"}
+	}
+	location, err := time.LoadLocation(value)
+	if err != nil {
+		return nil, &Error{Parameter: param, Value: value, Message: "must be an IANA time zone", Allowed: "an IANA time zone such as America/New_York"}
+	}
+	return location, nil
+}
+
+func parseTime(param, value, layout, format string, location *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(layout, value, location)
+	if err == nil {
+		return t, nil
+	}
+	// A value in the right shape failed on a field, e.g. February 30th
+	message := "must be " + format
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) && strings.HasSuffix(parseErr.Message, "out of range") {
+		message = "has a " + strings.TrimPrefix(parseErr.Message, ": ")
+	}
+	return time.Time{}, &Error{Parameter: param, Value: value, Message: message, Allowed: format}
+}
+
+// StationID rejects IDs no finder could match: empty, overlong or holding characters
+// outside StationIDPattern
+func StationID(param, value string) error {
+	switch {
+	case value == "":
+		return &Error{Parameter: param, Value: value, Message: "must not be empty"}
+	case len(value) > maxStationIDLength:
+		return &Error{
+			Parameter: param,
+			Value:     value[:32] + "...",
+			Message:   fmt.Sprintf("must be at most %d characters", maxStationIDLength),
+		}
+	case !stationIDPattern.MatchString(value):
+		return &Error{
+			Parameter: param,
+			Value:     value,
+			Message:   "must contain only letters, digits and : . _ - * @ +",
+			Allowed:   "a station ID such as 9447130",
+		}
+	}
+	return nil
+}
+
+var stationIDPattern = regexp.MustCompile(StationIDPattern)
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
+
+func rangeText(min, max *float64) string {
+	switch {
+	case min != nil && max != nil:
+		return fmt.Sprintf("%g to %g", *min, *max)
+	case min != nil:
+		return fmt.Sprintf("%g or more", *min)
+	case max != nil:
+		return fmt.Sprintf("%g or less", *max)
+	}
+	return ""
+}
+
+// closest returns the allowed value nearest to value, if it's close enough to be a typo:
+// a different case, or at most two edits away from a value longer than the edits
+func closest(value string, allowed []string) string {
+	best, bestDistance := "", 3
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return a
+		}
+		d := EditDistance(strings.ToLower(value), strings.ToLower(a))
+		if d < bestDistance && d < len(a) {
+			best, bestDistance = a, d
+		}
+	}
+	return best
+}
+
+// EditDistance is the Levenshtein distance between a and b
+func EditDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,143 @@
+package validate
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorMessages(t *testing.T) {
+	floatPtr := func(f float64) *float64 { return &f }
+	pattern := regexp.MustCompile(`^\d{4}$`)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"latitude", Latitude("lat", 91), `invalid lat "91": must be between -90 and 90`},
+		{"longitude", Longitude("lon", -180.5), `invalid lon "-180.5": must be between -180 and 180`},
+		{"coordinates check lat first", Coordinates(-95, 200), `invalid lat "-95": must be between -90 and 90`},
+		{"below minimum", AtLeast("limit", 0, 1), `invalid limit "0": must be at least 1`},
+		{"above maximum", Bounds("points", 6000, floatPtr(10), floatPtr(5000)), `invalid points "6000": must be at most 5000`},
+		{"case sensitive", OneOf("distanceUnit", "MI", "km", "mi", "nmi"), `invalid distanceUnit "MI": must be one of km, mi, nmi (did you mean "mi"?)`},
+		{"typo", OneOfFold("interpolation", "splin", "linear", "spline", "harmonic"), `invalid interpolation "splin": must be one of linear, spline, harmonic (did you mean "spline"?)`},
+		{"nothing close", OneOf("datum", "XYZ", "MLLW", "MSL"), `invalid datum "XYZ": must be one of MLLW, MSL`},
+		{"format", Format("year", "24", pattern, "2024"), `invalid year "24": must look like 2024`},
+		{"empty station", StationID("stationId", ""), `invalid stationId "": must not be empty`},
+		{"station with spaces", StationID("stationId", "94 47130"), `invalid stationId "94 47130": must contain only letters, digits and : . _ - * @ +`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, tt.err, tt.want)
+		})
+	}
+}
+
+func TestValidValues(t *testing.T) {
+	assert.NoError(t, Coordinates(47.6, -122.3))
+	assert.NoError(t, Between("days", 31, 1, 31))
+	assert.NoError(t, Bounds("offset", 0, new(float64), nil))
+	assert.NoError(t, OneOfFold("stationType", "r", "R", "S"))
+	assert.NoError(t, StationID("stationId", "virtual:47.6:-122.3:9447130*0.5@-10:9446484*0.5"))
+}
+
+func TestErrorDetails(t *testing.T) {
+	var verr *Error
+	require.ErrorAs(t, Between("days", 40, 1, 31), &verr)
+	assert.Equal(t, "days", verr.Parameter)
+	assert.Equal(t, "40", verr.Value)
+	assert.Equal(t, "1 to 31", verr.Allowed)
+
+	require.ErrorAs(t, OneOfFold("source", "NOA", "NOAA", "UKHO", "CHS"), &verr)
+	assert.Equal(t, "NOAA, UKHO, CHS", verr.Allowed)
+	assert.Equal(t, "NOAA", verr.Suggestion)
+}
+
+func TestDates(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	date, err := Date("startDate", "2024-03-10", location)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, location), date)
+
+	_, err = Date("startDate", "03/10/2024", location)
+	assert.EqualError(t, err, `invalid startDate "03/10/2024": must be YYYY-MM-DD`)
+
+	_, err = Date("startDate", "2024-02-30", location)
+	assert.EqualError(t, err, `invalid startDate "2024-02-30": has a day out of range`)
+
+	dateTime, err := DateTime("startDateTime", "2024-03-10T06:30:00", location)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 10, 6, 30, 0, 0, location), dateTime)
+
+	_, err = DateTime("endDateTime", "2024-03-10", location)
+	assert.EqualError(t, err, `invalid endDateTime "2024-03-10": must be YYYY-MM-DDTHH:MM:SS`)
+}
+
+func TestKind(t *testing.T) {
+	errInvalidUnits := errors.New("invalid units")
+
+	err := Kind(OneOf("units", "imperial", "english", "metric"), errInvalidUnits)
+	assert.ErrorIs(t, err, errInvalidUnits)
+	assert.True(t, strings.HasPrefix(err.Error(), `invalid units "imperial"`))
+
+	assert.NoError(t, Kind(nil, errInvalidUnits))
+	other := errors.New("boom")
+	assert.Equal(t, other, Kind(other, errInvalidUnits))
+}
+
+func TestInstant(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		endOfDay bool
+		want     time.Time
+	}{
+		{value: "2024-03-10T06:30:00", want: time.Date(2024, 3, 10, 6, 30, 0, 0, location)},
+		{value: "2024-03-10T06:30:00Z", want: time.Date(2024, 3, 9, 22, 30, 0, 0, location)},
+		{value: "2024-03-10T06:30:00-05:00", want: time.Date(2024, 3, 10, 4, 30, 0, 0, location)},
+		{value: "2024-03-10", want: time.Date(2024, 3, 10, 0, 0, 0, 0, location)},
+		{value: "2024-03-10", endOfDay: true, want: time.Date(2024, 3, 10, 23, 59, 59, 0, location)},
+		{value: "now", want: now},
+		{value: "+48h", want: now.Add(48 * time.Hour)},
+		{value: "-1d12h", want: now.Add(-36 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Instant("startDateTime", tt.value, location, now, tt.endOfDay)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got "+got.String())
+			assert.Equal(t, location, got.Location())
+		})
+	}
+
+	for _, value := range []string{"", "tomorrow", "+", "+48", "+2w", "2024-03-10T06:30", "2024-03-10T06:30:00+5"} {
+		_, err := Instant("startDateTime", value, location, now, false)
+		var verr *Error
+		assert.True(t, errors.As(err, &verr), value)
+	}
+	assert.True(t, regexp.MustCompile(InstantPattern).MatchString("-1d12h"))
+}
+
+func TestTimeZone(t *testing.T) {
+	location, err := TimeZone("tz", "America/New_York")
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", location.String())
+
+	_, err = TimeZone("tz", "Mars/Olympus_Mons")
+	assert.EqualError(t, err, `invalid tz "Mars/Olympus_Mons": must be an IANA time zone`)
+	_, err = TimeZone("tz", "Local")
+	assert.Error(t, err)
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Version is a major version of the REST response format
+type Version int
+
+const (
+	// V1 is the original format. Requests that don't ask for a version get it, so existing
+	// clients keep working, but it's deprecated in favor of LatestVersion.
+	V1 Version = 1
+	// V2 may change field names and shapes; see TideResponseV2
+	V2 Version = 2
+	// V3 wraps V2's bodies in an Envelope with their provenance and warnings
+	V3 Version = 3
+
+	DefaultVersion = V1
+	LatestVersion  = V3
+)
+
+// UnsupportedVersionError is returned for a version this API doesn't serve
+type UnsupportedVersionError struct {
+	Requested string
+}
+
+func (e UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("Unsupported API version: %s", e.Requested)
+}
+
+// NegotiateVersion picks the response version for request. A version segment in the path
+// (/api/v2/tides) takes precedence over the Accept header
+// (application/vnd.flowebb.v2+json, or +msgpack); with neither, DefaultVersion is used, or V2 when the
+// v2-default feature flag is on.
+func NegotiateVersion(ctx context.Context, request events.APIGatewayProxyRequest) (Version, error) {
+	for _, segment := range strings.Split(request.Path, "/") {
+		if v, ok := parseVersion(segment); ok {
+			return checkVersion(v, segment)
+		}
+	}
+
+	for _, mediaType := range strings.Split(headerValue(request.Headers, "Accept"), ",") {
+		mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+		rest, ok := strings.CutPrefix(strings.ToLower(mediaType), "application/vnd.flowebb.")
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSuffix(strings.TrimSuffix(rest, "+json"), "+msgpack")
+		if v, ok := parseVersion(rest); ok {
+			return checkVersion(v, mediaType)
+		}
+		return 0, UnsupportedVersionError{Requested: mediaType}
+	}
+
+	if feature.Enabled(ctx, feature.V2Default) {
+		return V2, nil
+	}
+	return DefaultVersion, nil
+}
+
+// VersionedSuccess is like Success but reports the version served, and wraps body in an
+// Envelope with the provenance ctx's request recorded for versions that do. Responses of
+// versions before the latest are marked deprecated, with a link to the same resource
+// under the latest version.
+func VersionedSuccess(ctx context.Context, version Version, path string, body interface{}) (events.APIGatewayProxyResponse, error) {
+	response, err := Success(versionedBody(ctx, version, body))
+	if err != nil {
+		return response, err
+	}
+	return withVersion(response, version, path), nil
+}
+
+// VersionedNegotiated is VersionedSuccess, but answers with MessagePack when the request's
+// Accept header asks for it
+func VersionedNegotiated(ctx context.Context, request events.APIGatewayProxyRequest, version Version, body interface{}) (events.APIGatewayProxyResponse, error) {
+	if !WantsMessagePack(request) {
+		return VersionedSuccess(ctx, version, request.Path, body)
+	}
+	response, err := MessagePack(versionedBody(ctx, version, body))
+	if err != nil {
+		return response, err
+	}
+	return withVersion(response, version, request.Path), nil
+}
+
+// VersionedUnenveloped is VersionedSuccess for the body of an Unenveloped operation, which
+// no version wraps
+func VersionedUnenveloped(version Version, path string, body interface{}) (events.APIGatewayProxyResponse, error) {
+	response, err := Success(body)
+	if err != nil {
+		return response, err
+	}
+	return withVersion(response, version, path), nil
+}
+
+// VersionedGeoJSON is VersionedSuccess for a GeoJSON document, which is never enveloped
+// since mapping libraries read it as it is
+func VersionedGeoJSON(version Version, path string, body interface{}) (events.APIGatewayProxyResponse, error) {
+	response, err := GeoJSON(body)
+	if err != nil {
+		return response, err
+	}
+	return withVersion(response, version, path), nil
+}
+
+func withVersion(response events.APIGatewayProxyResponse, version Version, path string) events.APIGatewayProxyResponse {
+	response.Headers["API-Version"] = strconv.Itoa(int(version))
+	response.Headers["Vary"] = "Accept"
+	if version < LatestVersion {
+		response.Headers["Deprecation"] = "true"
+		if successor := successorPath(path); successor != "" {
+			response.Headers["Link"] = fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+		}
+	}
+	return response
+}
+
+// TideResponseV2 is the v2 tide format. Compared to v1 it groups the station fields,
+// replaces the duplicated waterLevel/predictedLevel pair with a level object, and renames
+// stationDistance to distanceKm. Observed water levels can join the level object later
+// without breaking v2 clients.
+type TideResponseV2 struct {
+	APIResponse
+	Timestamp             models.Millis            `json:"timestamp"`
+	LocalTime             string                   `json:"localTime"`
+	TimeZoneOffsetSeconds *int                     `json:"timeZoneOffsetSeconds"`
+	Station               TideStationV2            `json:"station"`
+	Level                 TideLevelV2              `json:"level"`
+	CalculationMethod     string                   `json:"calculationMethod"`
+	Extremes              []models.TideExtreme     `json:"extremes"`
+	Predictions           []models.TidePrediction  `json:"predictions"`
+	Summary               *models.TideSummary      `json:"summary,omitempty"`
+	Astronomy             *models.TideAstronomy    `json:"astronomy,omitempty"`
+	Conditions            *models.WaterConditions  `json:"conditions,omitempty"`
+	Weather               *models.MarineWeather    `json:"weather,omitempty"`
+	Warnings              []models.ResponseWarning `json:"warnings,omitempty"`
+}
+
+type TideStationV2 struct {
+	ID         string  `json:"id"`
+	Name       *string `json:"name"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+type TideLevelV2 struct {
+	Predicted *float64         `json:"predicted"`
+	Trend     *models.TideType `json:"trend"`
+}
+
+var _ APIResponder = (*TideResponseV2)(nil)
+
+// TakeWarnings removes the response's warnings and returns them, for an Envelope to carry
+func (r *TideResponseV2) TakeWarnings() []models.ResponseWarning {
+	warnings := r.Warnings
+	r.Warnings = nil
+	return warnings
+}
+
+// NewTideResponseV2 converts a tide response to the v2 format
+func NewTideResponseV2(response *models.ExtendedTideResponse) *TideResponseV2 {
+	return &TideResponseV2{
+		APIResponse:           APIResponse{ResponseType: response.ResponseType},
+		Timestamp:             response.Timestamp,
+		LocalTime:             response.LocalTime,
+		TimeZoneOffsetSeconds: response.TimeZoneOffsetSeconds,
+		Station: TideStationV2{
+			ID:         response.NearestStation,
+			Name:       response.Location,
+			Latitude:   response.Latitude,
+			Longitude:  response.Longitude,
+			DistanceKm: response.StationDistance,
+		},
+		Level: TideLevelV2{
+			Predicted: response.PredictedLevel,
+			Trend:     response.TideType,
+		},
+		CalculationMethod: response.CalculationMethod,
+		Extremes:          response.Extremes,
+		Predictions:       response.Predictions,
+		Summary:           response.Summary,
+		Astronomy:         response.Astronomy,
+		Conditions:        response.Conditions,
+		Weather:           response.Weather,
+		Warnings:          response.Warnings,
+	}
+}
+
+func parseVersion(value string) (Version, bool) {
+	if len(value) < 2 || (value[0] != 'v' && value[0] != 'V') {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value[1:])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return Version(n), true
+}
+
+func checkVersion(v Version, requested string) (Version, error) {
+	if v < V1 || v > LatestVersion {
+		return 0, UnsupportedVersionError{Requested: requested}
+	}
+	return v, nil
+}
+
+// successorPath maps a path to the latest version's, e.g. /api/tides or /api/v1/tides to
+// /api/v3/tides. It returns "" for paths it doesn't recognize.
+func successorPath(path string) string {
+	return versionPath(path, LatestVersion)
+}
+
+// versionPath maps a path to version's, or returns "" for paths it doesn't recognize
+func versionPath(path string, version Version) string {
+	segment := fmt.Sprintf("v%d", version)
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if _, ok := parseVersion(s); ok {
+			segments[i] = segment
+			return strings.Join(segments, "/")
+		}
+	}
+	if rest, ok := strings.CutPrefix(path, "/api/"); ok {
+		return "/api/" + segment + "/" + rest
+	}
+	return ""
+}
+
+// headerValue looks a header up case-insensitively, since API Gateway doesn't normalize
+// header names
+func headerValue(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
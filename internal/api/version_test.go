@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		accept  string
+		want    Version
+		wantErr bool
+	}{
+		{name: "default", path: "/api/tides", want: V1},
+		{name: "path v1", path: "/api/v1/tides", want: V1},
+		{name: "path v2", path: "/api/v2/stations", want: V2},
+		{name: "accept v2", path: "/api/tides", accept: "application/vnd.flowebb.v2+json", want: V2},
+		{name: "accept list", path: "/api/tides", accept: "text/html, application/vnd.flowebb.v1+json;q=0.9", want: V1},
+		{name: "plain json", path: "/api/tides", accept: "application/json", want: V1},
+		{name: "path wins over accept", path: "/api/v1/tides", accept: "application/vnd.flowebb.v2+json", want: V1},
+		{name: "path v3", path: "/api/v3/tides", want: V3},
+		{name: "unknown path version", path: "/api/v4/tides", wantErr: true},
+		{name: "unknown accept version", path: "/api/tides", accept: "application/vnd.flowebb.v4+json", wantErr: true},
+		{name: "malformed accept version", path: "/api/tides", accept: "application/vnd.flowebb.latest+json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := events.APIGatewayProxyRequest{Path: tt.path}
+			if tt.accept != "" {
+				request.Headers = map[string]string{"Accept": tt.accept}
+			}
+
+			got, err := NegotiateVersion(context.Background(), request)
+			if tt.wantErr {
+				var versionErr UnsupportedVersionError
+				assert.ErrorAs(t, err, &versionErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNegotiateVersion_V2DefaultFlag(t *testing.T) {
+	t.Cleanup(func() { feature.Configure(nil) })
+	feature.Configure([]string{string(feature.V2Default)})
+
+	version, err := NegotiateVersion(context.Background(), events.APIGatewayProxyRequest{Path: "/api/tides"})
+	require.NoError(t, err)
+	assert.Equal(t, V2, version)
+
+	version, err = NegotiateVersion(context.Background(), events.APIGatewayProxyRequest{Path: "/api/v1/tides"})
+	require.NoError(t, err)
+	assert.Equal(t, V1, version, "a requested version wins over the flag")
+
+	// ValidateRequest applies a request's override of the flag
+	handler := ValidateRequest(StationsOperation, func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		version, err := NegotiateVersion(ctx, request)
+		require.NoError(t, err)
+		return VersionedSuccess(ctx, version, request.Path, NewStationsResponse(nil))
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/stations",
+		Headers:               map[string]string{feature.Header: "-v2-default"},
+		QueryStringParameters: map[string]string{"lat": "47.6", "lon": "-122.3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "1", response.Headers["API-Version"])
+}
+
+func TestVersionedSuccess(t *testing.T) {
+	response, err := VersionedSuccess(context.Background(), V1, "/api/v1/stations", NewStationsResponse(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "1", response.Headers["API-Version"])
+	assert.Equal(t, "Accept", response.Headers["Vary"])
+	assert.Equal(t, "true", response.Headers["Deprecation"])
+	assert.Equal(t, `</api/v3/stations>; rel="successor-version"`, response.Headers["Link"])
+
+	response, err = VersionedSuccess(context.Background(), V1, "", NewStationsResponse(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "true", response.Headers["Deprecation"])
+	assert.NotContains(t, response.Headers, "Link")
+
+	response, err = VersionedSuccess(context.Background(), V2, "/api/v2/stations", NewStationsResponse(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "2", response.Headers["API-Version"])
+	assert.Equal(t, "true", response.Headers["Deprecation"])
+	assert.Equal(t, `</api/v3/stations>; rel="successor-version"`, response.Headers["Link"])
+	assert.JSONEq(t, `{"responseType":"stations","stations":null}`, response.Body, "V2 isn't enveloped")
+
+	response, err = VersionedSuccess(context.Background(), V3, "/api/v3/stations", NewStationsResponse(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "3", response.Headers["API-Version"])
+	assert.NotContains(t, response.Headers, "Deprecation")
+	assert.NotContains(t, response.Headers, "Link")
+	var envelope struct {
+		Data     StationsResponse `json:"data"`
+		Meta     Meta             `json:"meta"`
+		Warnings []interface{}    `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &envelope))
+	assert.Equal(t, "stations", envelope.Data.ResponseType)
+	assert.NotZero(t, envelope.Meta.GeneratedAt)
+	assert.NotNil(t, envelope.Warnings)
+}
+
+func TestNewTideResponseV2(t *testing.T) {
+	level := 4.2
+	rising := models.TideTypeRising
+	name := "Seattle"
+	response := NewTideResponseV2(&models.ExtendedTideResponse{
+		ResponseType:      "tide",
+		Timestamp:         1704067200000,
+		LocalTime:         "2023-12-31T16:00:00",
+		WaterLevel:        &level,
+		PredictedLevel:    &level,
+		NearestStation:    "9447130",
+		Location:          &name,
+		StationDistance:   1.5,
+		TideType:          &rising,
+		CalculationMethod: "NOAA API",
+		Summary:           &models.TideSummary{Trend: &rising},
+		Astronomy:         &models.TideAstronomy{TideCycle: "SPRING", MoonPhase: "FULL", DaysSinceNewMoon: 15.1, DaysSinceFullMoon: 0.4},
+	})
+
+	body, err := json.Marshal(response)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"responseType": "tide",
+		"timestamp": 1704067200000,
+		"localTime": "2023-12-31T16:00:00",
+		"timeZoneOffsetSeconds": null,
+		"station": {"id": "9447130", "name": "Seattle", "latitude": 0, "longitude": 0, "distanceKm": 1.5},
+		"level": {"predicted": 4.2, "trend": "RISING"},
+		"calculationMethod": "NOAA API",
+		"extremes": null,
+		"predictions": null,
+		"summary": {"nextHigh": null, "nextLow": null, "trend": "RISING", "todayRange": null, "cycleElapsedPercent": null},
+		"astronomy": {"tideCycle": "SPRING", "moonPhase": "FULL", "daysSinceNewMoon": 15.1, "daysSinceFullMoon": 0.4}
+	}`, string(body))
+}
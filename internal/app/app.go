@@ -0,0 +1,389 @@
+// Package app wires the clients, caches, finders and services each entrypoint serves
+// requests with, so they're built the same way everywhere. A new entrypoint calls the
+// Build function for what it serves; tests pass options to swap in fakes.
+package app
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/demo"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/geo"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/stationchange"
+	"github.com/bbernstein/flowebb-go/internal/task"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/rs/zerolog/log"
+)
+
+// CacheFlushTimeout bounds how long a Lambda function waits for queued cache writes, at the
+// end of a request and when the instance is shut down
+const CacheFlushTimeout = 2 * time.Second
+
+// CacheFlusher is implemented by what writes to the caches in the background, like
+// tide.Service
+type CacheFlusher interface {
+	FlushCacheWrites(ctx context.Context) error
+}
+
+// FlushCacheWrites waits up to CacheFlushTimeout for provider's background cache writes,
+// so they finish before Lambda can freeze the instance. A provider that doesn't write in
+// the background, like a test fake, is skipped.
+func FlushCacheWrites(ctx context.Context, provider interface{}) {
+	flusher, ok := provider.(CacheFlusher)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, CacheFlushTimeout)
+	defer cancel()
+	if err := flusher.FlushCacheWrites(ctx); err != nil {
+		log.Warn().Err(err).Msg("Cache writes did not finish before the instance could be frozen")
+	}
+}
+
+// Option changes how an entrypoint's dependencies are built
+type Option func(*options)
+
+type options struct {
+	config          *config.Config
+	command         bool
+	finderFactory   station.FinderFactory
+	tideFactory     tide.ServiceFactory
+	newDynamoClient func(ctx context.Context) (cache.DynamoDBClient, error)
+	exportBucket    export.Bucket
+	sqsClient       task.SQSClient
+	snsClient       stationchange.SNSClient
+	// metricsStore replaces the metrics table when set
+	metricsStore metrics.Store
+	// recorder counts what the entrypoint serves; nil when metrics are off
+	recorder *metrics.Recorder
+}
+
+// WithConfig builds from cfg rather than loading the configuration
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		o.config = cfg
+	}
+}
+
+// AsCommand builds for a command that runs once rather than a Lambda function: logging is
+// left as the command set it up, the configuration isn't watched for changes, and queued
+// cache writes aren't flushed on SIGTERM, so the command must flush them before it exits
+func AsCommand() Option {
+	return func(o *options) {
+		o.command = true
+	}
+}
+
+// WithFinderFactory creates the station finder with factory
+func WithFinderFactory(factory station.FinderFactory) Option {
+	return func(o *options) {
+		o.finderFactory = factory
+	}
+}
+
+// WithTideFactory creates the tide service with factory
+func WithTideFactory(factory tide.ServiceFactory) Option {
+	return func(o *options) {
+		o.tideFactory = factory
+	}
+}
+
+// WithDynamoClient keeps user data and accuracy totals in DynamoDB through dynamoClient
+// rather than a client created from the AWS configuration
+func WithDynamoClient(dynamoClient cache.DynamoDBClient) Option {
+	return func(o *options) {
+		o.newDynamoClient = func(context.Context) (cache.DynamoDBClient, error) {
+			return dynamoClient, nil
+		}
+	}
+}
+
+// WithExportBucket keeps exported tide tables in bucket rather than an S3 bucket reached
+// with a client created from the AWS configuration
+func WithExportBucket(bucket export.Bucket) Option {
+	return func(o *options) {
+		o.exportBucket = bucket
+	}
+}
+
+// WithSQSClient queues tasks through client rather than an SQS client created from the
+// AWS configuration
+func WithSQSClient(client task.SQSClient) Option {
+	return func(o *options) {
+		o.sqsClient = client
+	}
+}
+
+// WithSNSClient publishes station list changes through client rather than an SNS client
+// created from the AWS configuration
+func WithSNSClient(client stationchange.SNSClient) Option {
+	return func(o *options) {
+		o.snsClient = client
+	}
+}
+
+// WithMetricsStore counts metrics in store rather than the metrics table, turning them on
+// when no table is configured
+func WithMetricsStore(store metrics.Store) Option {
+	return func(o *options) {
+		o.metricsStore = store
+	}
+}
+
+// newOptions applies opts over the defaults and loads the configuration if none was given
+func newOptions(opts []Option) (*options, error) {
+	defer logElapsed("config", time.Now())
+	o := &options{
+		finderFactory: &station.DefaultFinderFactory{},
+		tideFactory:   &tide.DefaultServiceFactory{},
+		newDynamoClient: func(context.Context) (cache.DynamoDBClient, error) {
+			return cache.NewLazyDynamoClient("user data", cache.NewDynamoClient), nil
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.exportBucket == nil {
+		o.exportBucket = export.NewS3Bucket()
+	}
+	if o.sqsClient == nil {
+		o.sqsClient = task.NewSQSClient()
+	}
+	if o.snsClient == nil {
+		o.snsClient = stationchange.NewSNSClient()
+	}
+
+	if o.config == nil {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+		o.config = cfg
+	}
+	if !o.command {
+		o.config.InitializeLogging()
+		o.config.LogDump()
+	}
+	feature.Configure(o.config.FeatureFlags)
+	return o, nil
+}
+
+// logElapsed logs how long a step of building an entrypoint's dependencies took since
+// start, so cold-start latency can be broken down
+func logElapsed(step string, start time.Time) {
+	log.Debug().Str("step", step).Dur("elapsed", time.Since(start)).Msg("Initialized")
+}
+
+// noaa is how every entrypoint reaches NOAA
+type noaa struct {
+	limiter *client.Limiter
+	client  *client.Client
+	finder  *station.NOAAStationFinder
+}
+
+// newNOAA creates the NOAA client, waiting timeout for each request, and the station
+// finder that uses it
+func (o *options) newNOAA(ctx context.Context, timeout time.Duration) (*noaa, error) {
+	defer logElapsed("NOAA client", time.Now())
+	cfg := o.config
+	cassette, err := client.NewCassette(client.CassetteMode(cfg.HTTPCassetteMode), cfg.HTTPCassetteDir)
+	if err != nil {
+		return nil, fmt.Errorf("configuring HTTP cassette: %w", err)
+	}
+	limiter := client.NewLimiter(cfg.NOAAMaxConcurrentRequests)
+	httpClient := client.New(client.Options{
+		Timeout:    timeout,
+		MaxRetries: cfg.MaxRetries,
+		BaseURL:    cfg.NOAABaseURL,
+		Limiter:    limiter,
+		Cassette:   cassette,
+		OnResponse: o.recorder.ObserveUpstream,
+	})
+
+	if cfg.DemoMode {
+		upstream, err := demo.New(cfg.DemoLatency)
+		if err != nil {
+			return nil, fmt.Errorf("loading demo data: %w", err)
+		}
+		httpClient.GetFunc = upstream.Get
+	}
+
+	finder, err := o.finderFactory.NewFinder(httpClient, nil)
+	if err != nil {
+		return nil, fmt.Errorf("initializing station finder: %w", err)
+	}
+	if cfg.DemoMode {
+		// The snapshot's stations have no demo data; wait for the canned list instead
+		finder.SetSnapshot(nil)
+	}
+	if cfg.CoastalSnapping {
+		mask := geo.EmbeddedLandMask()
+		if mask == nil {
+			log.Warn().Msg("Coastal snapping is on but the built-in land mask has no land; nearest stations are ranked by distance")
+		}
+		finder.SetLandMask(mask)
+	}
+	if err := o.recordStationChanges(ctx, finder); err != nil {
+		return nil, err
+	}
+	return &noaa{limiter: limiter, client: httpClient, finder: finder}, nil
+}
+
+// newStationChangeStore returns the store changes to the station list are recorded in, or
+// nil when they aren't recorded
+func (o *options) newStationChangeStore(ctx context.Context) (stationchange.Store, error) {
+	if o.config.StationChangesTable == "" {
+		return nil, nil
+	}
+	dynamoClient, err := o.newDynamoClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("initializing DynamoDB client: %w", err)
+	}
+	return stationchange.NewDynamoStore(dynamoClient, o.config.StationChangesTable), nil
+}
+
+// recordStationChanges has finder record how each refresh changes the station list, and
+// publish the change when a topic is configured. Whichever instance refreshes first
+// records it, so every entrypoint does.
+func (o *options) recordStationChanges(ctx context.Context, finder *station.NOAAStationFinder) error {
+	store, err := o.newStationChangeStore(ctx)
+	if err != nil || store == nil {
+		return err
+	}
+	var publisher *stationchange.Publisher
+	if o.config.StationChangesTopicARN != "" {
+		publisher = stationchange.NewPublisher(o.snsClient, o.config.StationChangesTopicARN)
+	}
+	finder.OnListChange(stationchange.NewRecorder(store, publisher).Record)
+	return nil
+}
+
+// newMetricsStore returns the store every instance adds its metrics up in, or nil when
+// metrics are off
+func (o *options) newMetricsStore(ctx context.Context) (metrics.Store, error) {
+	if o.metricsStore != nil {
+		return o.metricsStore, nil
+	}
+	if o.config.MetricsTable == "" {
+		return nil, nil
+	}
+	dynamoClient, err := o.newDynamoClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("initializing DynamoDB client: %w", err)
+	}
+	return metrics.NewDynamoStore(dynamoClient, o.config.MetricsTable), nil
+}
+
+// newRecorder creates the recorder counting what the entrypoint serves, the NOAA requests
+// it makes and the panics it recovers from. It must come before newNOAA.
+func (o *options) newRecorder(ctx context.Context) error {
+	store, err := o.newMetricsStore(ctx)
+	if err != nil || store == nil {
+		return err
+	}
+	o.recorder = metrics.NewRecorder(store)
+	recovery.Default.OnPanic(o.recorder.ObservePanic)
+	return nil
+}
+
+// countCacheHits has the recorder count the hits and misses of the prediction cache, when
+// it reports them
+func (o *options) countCacheHits(service *tide.Service) {
+	if stats, ok := service.PredictionCache.(metrics.CacheStats); ok {
+		o.recorder.UseCacheStats(stats)
+	}
+}
+
+// newRateLimiter returns the per-client request limiter of demo mode, or nil, which
+// limits nothing, outside it
+func (o *options) newRateLimiter() *ratelimit.Limiter {
+	if !o.config.DemoMode {
+		return nil
+	}
+	return ratelimit.New(o.config.DemoRateLimit, time.Minute)
+}
+
+// newTenants indexes the white-label tenants the deployment serves, counting each one's
+// requests, or returns nil when there are none. It must come after newRecorder.
+func (o *options) newTenants() (*tenant.Registry, error) {
+	tenants, err := tenant.NewRegistry(o.config.Tenants)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenants: %w", err)
+	}
+	tenants.OnRequest(o.recorder.ObserveTenant)
+	return tenants, nil
+}
+
+// newQuotas returns the tracker counting each API key's requests against its monthly
+// quota, or nil when quotas are off
+func (o *options) newQuotas(ctx context.Context) (*quota.Tracker, error) {
+	if o.config.QuotaTable == "" {
+		return nil, nil
+	}
+	dynamoClient, err := o.newDynamoClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("initializing DynamoDB client: %w", err)
+	}
+	store := quota.NewDynamoStore(dynamoClient, o.config.QuotaTable)
+	return quota.NewTracker(store, o.config.APIKeyMonthlyQuota, o.config.APIKeyQuotas), nil
+}
+
+// newTideService creates the tide service over n
+func (o *options) newTideService(ctx context.Context, n *noaa) (*tide.Service, error) {
+	defer logElapsed("tide service", time.Now())
+	service, err := o.tideFactory.NewService(ctx, n.client, n.finder)
+	if err != nil {
+		return nil, fmt.Errorf("initializing tide service: %w", err)
+	}
+	return service, nil
+}
+
+// newExportStore returns the store tide tables are exported to, or nil when exports are
+// off
+func (o *options) newExportStore() *export.Store {
+	if o.config.ExportBucket == "" {
+		return nil
+	}
+	return export.NewStore(o.exportBucket, o.config.ExportBucket, o.config.ExportURLTTL)
+}
+
+// newQueue returns the queue slow work is deferred to, or nil when it runs inline
+func (o *options) newQueue() task.Queue {
+	if o.config.TaskQueueURL == "" {
+		return nil
+	}
+	return task.NewSQSQueue(o.sqsClient, o.config.TaskQueueURL)
+}
+
+// start applies reloaded settings to what was built and, for a Lambda function, watches
+// the configuration until ctx is done and flushes the tide service's queued cache writes
+// on SIGTERM. service is nil for entrypoints without one.
+func (o *options) start(ctx context.Context, n *noaa, service *tide.Service) {
+	config.OnReload(func(cfg *config.Config) {
+		n.limiter.SetLimit(cfg.NOAAMaxConcurrentRequests)
+		feature.Configure(cfg.FeatureFlags)
+		if service != nil {
+			service.ApplyConfig(cfg)
+		}
+	})
+	if o.command {
+		return
+	}
+	if service != nil && service.CacheWriter != nil {
+		service.CacheWriter.FlushOnSignal(CacheFlushTimeout, syscall.SIGTERM)
+	}
+	config.Watch(ctx)
+}
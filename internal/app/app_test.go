@@ -0,0 +1,187 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type finderFactoryFunc func(*client.Client, *cache.StationCache) (*station.NOAAStationFinder, error)
+
+func (f finderFactoryFunc) NewFinder(httpClient *client.Client, memCache *cache.StationCache) (*station.NOAAStationFinder, error) {
+	return f(httpClient, memCache)
+}
+
+type tideFactoryFunc func(context.Context, *client.Client, models.StationFinder) (*tide.Service, error)
+
+func (f tideFactoryFunc) NewService(ctx context.Context, httpClient *client.Client, finder models.StationFinder) (*tide.Service, error) {
+	return f(ctx, httpClient, finder)
+}
+
+// testOptions build from the environment's configuration, without touching logging
+func testOptions(opts ...Option) []Option {
+	return append([]Option{WithConfig(config.LoadFromEnv()), AsCommand()}, opts...)
+}
+
+func TestBuildStations(t *testing.T) {
+	var httpClient *client.Client
+	factory := finderFactoryFunc(func(c *client.Client, memCache *cache.StationCache) (*station.NOAAStationFinder, error) {
+		httpClient = c
+		return (&station.DefaultFinderFactory{}).NewFinder(c, memCache)
+	})
+
+	stations, err := BuildStations(context.Background(), testOptions(WithFinderFactory(factory))...)
+	require.NoError(t, err)
+	assert.NotNil(t, stations.Handler)
+	assert.NotNil(t, httpClient, "the finder is created with the NOAA client")
+	assert.Equal(t, config.LoadFromEnv().StationLimit, stations.Config.StationLimit)
+}
+
+func TestBuildStations_DemoMode(t *testing.T) {
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("DEMO_LATENCY", "0s")
+
+	stations, err := BuildStations(context.Background(), testOptions()...)
+	require.NoError(t, err)
+	assert.NotNil(t, stations.Limiter)
+
+	seattle, err := stations.Finder.FindStation(context.Background(), "9447130")
+	require.NoError(t, err)
+	require.NotNil(t, seattle)
+	assert.Equal(t, "Seattle", seattle.Name, "the canned station rather than the snapshot's")
+	_, err = stations.Finder.FindStation(context.Background(), "9446484")
+	assert.ErrorIs(t, err, models.ErrStationNotFound, "only the canned stations are listed")
+}
+
+func TestBuildTides(t *testing.T) {
+	var finder models.StationFinder
+	factory := tideFactoryFunc(func(ctx context.Context, c *client.Client, f models.StationFinder) (*tide.Service, error) {
+		finder = f
+		return tide.NewService(ctx, c, f)
+	})
+
+	tides, err := BuildTides(context.Background(), testOptions(WithTideFactory(factory))...)
+	require.NoError(t, err)
+	assert.NotNil(t, tides.Service)
+	assert.Same(t, tides.Finder, finder, "the tide service uses the entrypoint's finder")
+}
+
+func TestBuildGraphQL(t *testing.T) {
+	graphQL, err := BuildGraphQL(context.Background(), testOptions(WithDynamoClient(nil))...)
+	require.NoError(t, err)
+	assert.NotNil(t, graphQL.Handler)
+	assert.NotNil(t, graphQL.Service)
+}
+
+func TestBuildServer(t *testing.T) {
+	cfg := config.LoadFromEnv()
+	cfg.SyncInterval = 0
+	server, err := BuildServer(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	assert.NotNil(t, server.Stations)
+	assert.NotNil(t, server.GraphQL)
+	assert.Same(t, server.Service, server.Tides.Service, "every endpoint shares the tide service")
+	assert.Same(t, server.Finder, server.Tides.Finder)
+	assert.NotNil(t, server.Metrics, "metrics are kept in memory without a table")
+	assert.IsType(t, &metrics.MemoryStore{}, server.MetricsStore)
+	assert.Nil(t, server.Syncer, "syncing is off without an interval")
+
+	cfg.SyncInterval = time.Hour
+	server, err = BuildServer(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	assert.NotNil(t, server.Syncer)
+}
+
+func TestBuildAdmin(t *testing.T) {
+	admin, err := BuildAdmin(context.Background(), testOptions()...)
+	require.NoError(t, err)
+	assert.NotNil(t, admin.Handler)
+}
+
+func TestBuildAccuracy(t *testing.T) {
+	cfg := config.LoadFromEnv()
+	cfg.AccuracyStations = []string{"9447130"}
+
+	job, err := BuildAccuracy(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"9447130"}, job.Tracker.Stations)
+	assert.Same(t, job.Service, job.Tracker.Predictor, "levels are predicted by the tide service")
+}
+
+func TestBuildExport(t *testing.T) {
+	cfg := config.LoadFromEnv()
+	cfg.ExportBucket = ""
+	_, err := BuildExport(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	assert.EqualError(t, err, "EXPORT_BUCKET is not set")
+
+	cfg.ExportBucket = "flowebb-exports"
+	job, err := BuildExport(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	assert.NotNil(t, job.Exporter)
+	assert.Same(t, job.Service, job.Exporter.Tides, "tables are rendered from the tide service")
+
+	tides, err := BuildTides(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	assert.NotNil(t, tides.Exports, "the tide endpoints submit exports when a bucket is configured")
+}
+
+func TestBuildWorker(t *testing.T) {
+	cfg := config.LoadFromEnv()
+	cfg.AccuracyStations = []string{"9447130"}
+	cfg.ExportBucket = ""
+	worker, err := BuildWorker(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	assert.Nil(t, worker.Exporter, "exports are off without a bucket")
+	assert.Equal(t, []string{"9447130"}, worker.Tracker.Stations)
+	assert.Same(t, worker.Service, worker.Tracker.Predictor)
+
+	cfg.ExportBucket = "flowebb-exports"
+	worker, err = BuildWorker(context.Background(), WithConfig(cfg), AsCommand(), WithDynamoClient(nil))
+	require.NoError(t, err)
+	require.NotNil(t, worker.Exporter)
+	assert.Same(t, worker.Service, worker.Exporter.Tides)
+}
+
+func TestBuild_Errors(t *testing.T) {
+	failingFinder := finderFactoryFunc(func(*client.Client, *cache.StationCache) (*station.NOAAStationFinder, error) {
+		return nil, errors.New("no stations")
+	})
+	_, err := BuildStations(context.Background(), testOptions(WithFinderFactory(failingFinder))...)
+	assert.EqualError(t, err, "initializing station finder: no stations")
+
+	failingTides := tideFactoryFunc(func(context.Context, *client.Client, models.StationFinder) (*tide.Service, error) {
+		return nil, errors.New("no cache")
+	})
+	_, err = BuildTides(context.Background(), testOptions(WithTideFactory(failingTides))...)
+	assert.EqualError(t, err, "initializing tide service: no cache")
+}
+
+// flusherFunc flushes by calling itself
+type flusherFunc func(ctx context.Context) error
+
+func (f flusherFunc) FlushCacheWrites(ctx context.Context) error {
+	return f(ctx)
+}
+
+func TestFlushCacheWrites(t *testing.T) {
+	var deadline time.Time
+	start := time.Now()
+	FlushCacheWrites(context.Background(), flusherFunc(func(ctx context.Context) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	}))
+	assert.WithinDuration(t, start.Add(CacheFlushTimeout), deadline, time.Second, "the flush is bounded by CacheFlushTimeout")
+
+	assert.NotPanics(t, func() { FlushCacheWrites(context.Background(), struct{}{}) }, "a provider without cache writes is skipped")
+}
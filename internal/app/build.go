@@ -0,0 +1,540 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bbernstein/flowebb-go/graph"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/audit"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/handler"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/bbernstein/flowebb-go/internal/offline"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/reports"
+	"github.com/bbernstein/flowebb-go/internal/station"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
+	"github.com/bbernstein/flowebb-go/internal/widget"
+)
+
+// Stations serves the stations endpoint
+type Stations struct {
+	Config  *config.Config
+	Finder  *station.NOAAStationFinder
+	Handler *handler.StationsHandler
+	// Limiter is nil outside demo mode
+	Limiter *ratelimit.Limiter
+	// Tenants is nil unless tenants are configured
+	Tenants *tenant.Registry
+	// Quotas is nil unless a quota table is configured
+	Quotas *quota.Tracker
+	// Metrics is nil unless a metrics table is configured
+	Metrics *metrics.Recorder
+}
+
+// BuildStations builds what the stations endpoint needs
+func BuildStations(ctx context.Context, opts ...Option) (*Stations, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.newRecorder(ctx); err != nil {
+		return nil, err
+	}
+	tenants, err := o.newTenants()
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := o.newQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	stationsHandler := handler.NewStationsHandler(n.finder)
+	stationsHandler.SetLimits(stationLimits(o.config))
+
+	o.start(ctx, n, nil)
+	return &Stations{
+		Config:  o.config,
+		Finder:  n.finder,
+		Handler: stationsHandler,
+		Limiter: o.newRateLimiter(),
+		Tenants: tenants,
+		Quotas:  quotas,
+		Metrics: o.recorder,
+	}, nil
+}
+
+// Tides serves the tides, extremes, chart, compare, observation and export endpoints, and
+// the CLI
+type Tides struct {
+	Config  *config.Config
+	Finder  *station.NOAAStationFinder
+	Service *tide.Service
+	Handler *handler.TidesHandler
+	// Exports is nil when no export bucket is configured
+	Exports *export.Service
+	Widgets *widget.Service
+	// Limiter is nil outside demo mode
+	Limiter *ratelimit.Limiter
+	// Tenants is nil unless tenants are configured
+	Tenants *tenant.Registry
+	// Quotas is nil unless a quota table is configured
+	Quotas *quota.Tracker
+	// Metrics is nil unless a metrics table is configured
+	Metrics *metrics.Recorder
+}
+
+// BuildTides builds what the tide endpoints need
+func BuildTides(ctx context.Context, opts ...Option) (*Tides, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.newRecorder(ctx); err != nil {
+		return nil, err
+	}
+	tenants, err := o.newTenants()
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := o.newQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	tidesHandler, err := o.newTidesHandler(ctx, n, service, quotas)
+	if err != nil {
+		return nil, err
+	}
+	o.countCacheHits(service)
+
+	o.start(ctx, n, service)
+	return &Tides{
+		Config:  o.config,
+		Finder:  n.finder,
+		Service: service,
+		Handler: tidesHandler,
+		Exports: tidesHandler.Exports,
+		Widgets: tidesHandler.Widgets,
+		Limiter: o.newRateLimiter(),
+		Tenants: tenants,
+		Quotas:  quotas,
+		Metrics: o.recorder,
+	}, nil
+}
+
+// newTidesHandler creates the handler of the tide endpoints over n and service
+func (o *options) newTidesHandler(ctx context.Context, n *noaa, service *tide.Service, quotas *quota.Tracker) (*handler.TidesHandler, error) {
+	changes, err := o.newStationChangeStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h := &handler.TidesHandler{
+		Service: service,
+		Finder:  n.finder,
+		Widgets: widget.NewService(service, n.finder, o.config.WidgetURL),
+		Delta:   offline.NewDelta(service, n.finder, changes),
+		Quotas:  quotas,
+	}
+	if store := o.newExportStore(); store != nil {
+		h.Exports = export.NewService(store, n.finder)
+	}
+	return h, nil
+}
+
+// GraphQL serves the GraphQL endpoint
+type GraphQL struct {
+	Config  *config.Config
+	Service *tide.Service
+	Handler *graph.Handler
+	// Limiter is nil outside demo mode
+	Limiter *ratelimit.Limiter
+	// Tenants is nil unless tenants are configured
+	Tenants *tenant.Registry
+	// Quotas is nil unless a quota table is configured
+	Quotas *quota.Tracker
+	// Idempotency is nil unless an idempotency table is configured
+	Idempotency *api.Idempotency
+	// Metrics is nil unless a metrics table is configured
+	Metrics *metrics.Recorder
+}
+
+// BuildGraphQL builds what the GraphQL endpoint needs, including the user data store
+func BuildGraphQL(ctx context.Context, opts ...Option) (*GraphQL, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.newRecorder(ctx); err != nil {
+		return nil, err
+	}
+	tenants, err := o.newTenants()
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := o.newQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.GraphQLHTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	gqlHandler, idempotency, err := o.newGraphQLHandler(ctx, n, service)
+	if err != nil {
+		return nil, err
+	}
+
+	o.countCacheHits(service)
+	o.start(ctx, n, service)
+	return &GraphQL{
+		Config:      o.config,
+		Service:     service,
+		Handler:     gqlHandler,
+		Limiter:     o.newRateLimiter(),
+		Tenants:     tenants,
+		Quotas:      quotas,
+		Idempotency: idempotency,
+		Metrics:     o.recorder,
+	}, nil
+}
+
+// newGraphQLHandler creates the GraphQL handler over n and service, with the user data
+// store, and the deduplication of retried writes when an idempotency table is configured
+func (o *options) newGraphQLHandler(ctx context.Context, n *noaa, service *tide.Service) (*graph.Handler, *api.Idempotency, error) {
+	dynamoClient, err := o.newDynamoClient(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("initializing DynamoDB client: %w", err)
+	}
+
+	userData := userdata.NewService(userdata.NewDynamoStore(dynamoClient, o.config.UserDataTable), n.finder)
+	if o.config.AuditTable != "" {
+		userData.UseAuditLog(audit.NewDynamoLog(dynamoClient, o.config.AuditTable, o.config.AuditRetention))
+	}
+	resolver := &graph.Resolver{
+		TideService:   service,
+		StationFinder: n.finder,
+		StationLimits: stationLimits(o.config),
+		UserData:      userData,
+		Reports:       reports.NewService(reports.NewDynamoStore(dynamoClient, o.config.ReportsTable), n.finder, o.config.ReportRateLimit),
+	}
+	if store := o.newExportStore(); store != nil {
+		resolver.Exports = export.NewService(store, n.finder)
+	}
+	if resolver.StationChanges, err = o.newStationChangeStore(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	gqlHandler := graph.NewHandler(resolver, nil)
+	if err := useResponseCache(gqlHandler, o.config, service); err != nil {
+		return nil, nil, err
+	}
+
+	var idempotency *api.Idempotency
+	if o.config.IdempotencyTable != "" {
+		idempotency = api.NewIdempotency(api.NewDynamoIdempotencyStore(dynamoClient, o.config.IdempotencyTable), o.config.IdempotencyTTL)
+	}
+
+	return gqlHandler, idempotency, nil
+}
+
+// Server serves every endpoint from one long-running process, for running flowebb off
+// AWS
+type Server struct {
+	Config   *config.Config
+	Finder   *station.NOAAStationFinder
+	Service  *tide.Service
+	Stations *handler.StationsHandler
+	Tides    *handler.TidesHandler
+	GraphQL  *graph.Handler
+	// Limiter is nil outside demo mode
+	Limiter *ratelimit.Limiter
+	// Tenants is nil unless tenants are configured
+	Tenants *tenant.Registry
+	// Quotas is nil unless a quota table is configured
+	Quotas *quota.Tracker
+	// Idempotency is nil unless an idempotency table is configured
+	Idempotency *api.Idempotency
+	Metrics     *metrics.Recorder
+	// MetricsStore is what Metrics adds its counts to: the metrics table when one is
+	// configured, or else memory, since the one process sees every request
+	MetricsStore metrics.Store
+	// Syncer is nil when syncing with NOAA is off
+	Syncer *offline.Syncer
+}
+
+// BuildServer builds what every endpoint needs over one NOAA client and tide service,
+// rather than one per Lambda function. It uses the longer GraphQL timeout for NOAA
+// requests, as the GraphQL endpoint would.
+func BuildServer(ctx context.Context, opts ...Option) (*Server, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	if o.metricsStore == nil && o.config.MetricsTable == "" {
+		o.metricsStore = metrics.NewMemoryStore()
+	}
+	metricsStore, err := o.newMetricsStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.newRecorder(ctx); err != nil {
+		return nil, err
+	}
+	tenants, err := o.newTenants()
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := o.newQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.GraphQLHTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	gqlHandler, idempotency, err := o.newGraphQLHandler(ctx, n, service)
+	if err != nil {
+		return nil, err
+	}
+
+	tidesHandler, err := o.newTidesHandler(ctx, n, service, quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	stationsHandler := handler.NewStationsHandler(n.finder)
+	stationsHandler.SetLimits(stationLimits(o.config))
+	server := &Server{
+		Config:       o.config,
+		Finder:       n.finder,
+		Service:      service,
+		Stations:     stationsHandler,
+		Tides:        tidesHandler,
+		GraphQL:      gqlHandler,
+		Limiter:      o.newRateLimiter(),
+		Tenants:      tenants,
+		Quotas:       quotas,
+		Idempotency:  idempotency,
+		Metrics:      o.recorder,
+		MetricsStore: metricsStore,
+	}
+	if o.config.SyncInterval > 0 {
+		server.Syncer = offline.NewSyncer(n.finder, service, o.config.SyncStations, o.config.SyncDays)
+	}
+	o.countCacheHits(service)
+
+	o.start(ctx, n, service)
+	return server, nil
+}
+
+// useResponseCache has the handler cache responses for as long as the data behind them
+// lasts, dropping those showing a station whose predictions are refreshed
+func useResponseCache(h *graph.Handler, cfg *config.Config, service *tide.Service) error {
+	responses, err := cache.NewGraphQLCache(cfg.Cache)
+	if err != nil {
+		return fmt.Errorf("creating GraphQL response cache: %w", err)
+	}
+	if notifier, ok := service.PredictionCache.(cache.RefreshNotifier); ok {
+		notifier.OnRefresh(responses.InvalidateStation)
+	}
+	h.UseResponseCache(responses, graph.ResponseTTLs{
+		Stations:     cfg.Cache.GetStationListTTL(),
+		Predictions:  cfg.Cache.GetTidePredictionLRUTTL(),
+		Weather:      cfg.WeatherCacheTTL,
+		Observations: observation.DefaultTTL,
+	})
+	return nil
+}
+
+// Admin serves the cache admin and dashboard endpoints
+type Admin struct {
+	Config  *config.Config
+	Handler *handler.AdminHandler
+}
+
+// BuildAdmin builds what the admin endpoints need. The prediction cache must support
+// admin operations; the dashboard is served when a metrics table is configured.
+func BuildAdmin(ctx context.Context, opts ...Option) (*Admin, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	cacheAdmin, ok := service.PredictionCache.(handler.CacheAdmin)
+	if !ok {
+		return nil, errors.New("prediction cache does not support admin operations")
+	}
+
+	adminHandler := handler.NewAdminHandler(o.config.AdminAPIKey, cacheAdmin, service)
+	adminHandler.UseRemoteConfig(config.SetRemote)
+	if queue := o.newQueue(); queue != nil {
+		adminHandler.UseQueue(queue)
+	}
+	store, err := o.newMetricsStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		adminHandler.UseDashboard(store)
+	}
+
+	o.start(ctx, n, service)
+	return &Admin{Config: o.config, Handler: adminHandler}, nil
+}
+
+// Accuracy runs the scheduled prediction accuracy job
+type Accuracy struct {
+	Config  *config.Config
+	Service *tide.Service
+	Tracker *accuracy.Tracker
+}
+
+// BuildAccuracy builds what the accuracy job needs: the tide service predicts the levels
+// the tracked stations' gauges are compared with
+func BuildAccuracy(ctx context.Context, opts ...Option) (*Accuracy, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := o.newAccuracyTracker(ctx, n, service)
+	if err != nil {
+		return nil, err
+	}
+
+	o.start(ctx, n, service)
+	return &Accuracy{Config: o.config, Service: service, Tracker: tracker}, nil
+}
+
+// newAccuracyTracker creates the tracker comparing the tracked stations' gauge readings
+// with the levels service predicts
+func (o *options) newAccuracyTracker(ctx context.Context, n *noaa, service *tide.Service) (*accuracy.Tracker, error) {
+	dynamoClient, err := o.newDynamoClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("initializing DynamoDB client: %w", err)
+	}
+	return &accuracy.Tracker{
+		Store:     accuracy.NewDynamoStore(dynamoClient, o.config.AccuracyTable),
+		Observer:  observation.NewNOAA(n.client),
+		Predictor: service,
+		Stations:  o.config.AccuracyStations,
+	}, nil
+}
+
+// Export runs the tide table export jobs
+type Export struct {
+	Config   *config.Config
+	Service  *tide.Service
+	Exporter *export.Exporter
+}
+
+// BuildExport builds what the export jobs need: the tide service looks up the extremes the
+// tables are rendered from. An export bucket must be configured.
+func BuildExport(ctx context.Context, opts ...Option) (*Export, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	store := o.newExportStore()
+	if store == nil {
+		return nil, errors.New("EXPORT_BUCKET is not set")
+	}
+	n, err := o.newNOAA(ctx, o.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+
+	o.start(ctx, n, service)
+	return &Export{Config: o.config, Service: service, Exporter: export.NewExporter(store, service)}, nil
+}
+
+// Worker runs the tasks deferred to the task queue
+type Worker struct {
+	Config  *config.Config
+	Service *tide.Service
+	// Exporter is nil when no export bucket is configured
+	Exporter *export.Exporter
+	Tracker  *accuracy.Tracker
+}
+
+// BuildWorker builds what the deferred tasks need: the tide service warms the cache and
+// looks up what exports are rendered from and accuracy is compared with
+func BuildWorker(ctx context.Context, opts ...Option) (*Worker, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	n, err := o.newNOAA(ctx, o.config.HTTPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	service, err := o.newTideService(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	tracker, err := o.newAccuracyTracker(ctx, n, service)
+	if err != nil {
+		return nil, err
+	}
+
+	worker := &Worker{Config: o.config, Service: service, Tracker: tracker}
+	if store := o.newExportStore(); store != nil {
+		worker.Exporter = export.NewExporter(store, service)
+	}
+	o.start(ctx, n, service)
+	return worker, nil
+}
+
+func stationLimits(cfg *config.Config) models.StationLimits {
+	return models.StationLimits{Default: cfg.StationLimit, Max: cfg.MaxStationLimit}
+}
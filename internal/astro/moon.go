@@ -0,0 +1,100 @@
+// Package astro computes the lunar cycle behind the tides, to explain why ranges grow and
+// shrink over a month, and when the sun is up, for planning around the tides by daylight.
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// SynodicMonth is the mean time from one new moon to the next, in days
+const SynodicMonth = 29.530588853
+
+// referenceNewMoon is the first new moon of 2000. Counting whole mean lunations from it
+// puts new and full moons within about half a day of the true ones.
+var referenceNewMoon = time.Date(2000, 1, 6, 18, 14, 0, 0, time.UTC)
+
+// springLagDays is the age of the tide: the largest ranges follow new and full moon by
+// about a day and a half, and the smallest follow the quarters the same way
+const springLagDays = 1.5
+
+// springWindowDays is how close to the lagged syzygy, or quarter for neaps, a day must
+// be to count as spring, or neap, tides
+const springWindowDays = 2.0
+
+// Phase is one of the eight named phases of the moon
+type Phase string
+
+const (
+	PhaseNew            Phase = "NEW"
+	PhaseWaxingCrescent Phase = "WAXING_CRESCENT"
+	PhaseFirstQuarter   Phase = "FIRST_QUARTER"
+	PhaseWaxingGibbous  Phase = "WAXING_GIBBOUS"
+	PhaseFull           Phase = "FULL"
+	PhaseWaningGibbous  Phase = "WANING_GIBBOUS"
+	PhaseLastQuarter    Phase = "LAST_QUARTER"
+	PhaseWaningCrescent Phase = "WANING_CRESCENT"
+)
+
+var phases = []Phase{
+	PhaseNew, PhaseWaxingCrescent, PhaseFirstQuarter, PhaseWaxingGibbous,
+	PhaseFull, PhaseWaningGibbous, PhaseLastQuarter, PhaseWaningCrescent,
+}
+
+// TideCycle says where the tides are in their fortnightly swing between large and small ranges
+type TideCycle string
+
+const (
+	// TideCycleSpring tides have the largest ranges, with the sun and moon pulling in line
+	TideCycleSpring TideCycle = "SPRING"
+	// TideCycleNeap tides have the smallest ranges, with the sun and moon at right angles
+	TideCycleNeap TideCycle = "NEAP"
+	// TideCycleIntermediate tides are between the two
+	TideCycleIntermediate TideCycle = "INTERMEDIATE"
+)
+
+// Moon describes the lunar cycle at an instant
+type Moon struct {
+	// Age is the days since the last new moon
+	Age float64
+	// DaysSinceFull is the days since the last full moon
+	DaysSinceFull float64
+	Phase         Phase
+	TideCycle     TideCycle
+}
+
+// MoonAt returns the mean lunar cycle at t
+func MoonAt(t time.Time) Moon {
+	age := wrap(t.Sub(referenceNewMoon).Hours()/24, SynodicMonth)
+	return Moon{
+		Age:           age,
+		DaysSinceFull: wrap(age-SynodicMonth/2, SynodicMonth),
+		Phase:         phases[int(math.Floor(age/SynodicMonth*8+0.5))%len(phases)],
+		TideCycle:     tideCycle(age),
+	}
+}
+
+// tideCycle classifies the tides by how far the moon's age, less the age of the tide, is
+// from the nearest new or full moon
+func tideCycle(age float64) TideCycle {
+	halfMonth := SynodicMonth / 2
+	sinceSyzygy := wrap(age-springLagDays, halfMonth)
+	fromSyzygy := math.Min(sinceSyzygy, halfMonth-sinceSyzygy)
+	switch {
+	case fromSyzygy <= springWindowDays:
+		return TideCycleSpring
+	case halfMonth/2-fromSyzygy <= springWindowDays:
+		return TideCycleNeap
+	default:
+		return TideCycleIntermediate
+	}
+}
+
+// wrap returns x modulo period, in [0, period)
+func wrap(x, period float64) float64 {
+	x = math.Mod(x, period)
+	if x < 0 {
+		x += period
+	}
+	return x
+}
@@ -0,0 +1,45 @@
+package astro
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoonAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		at    time.Time
+		phase Phase
+		cycle TideCycle
+	}{
+		// Published new and full moons; the mean cycle is within a day of them
+		{"new moon", time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC), PhaseNew, TideCycleSpring},
+		{"full moon", time.Date(2024, 1, 25, 17, 54, 0, 0, time.UTC), PhaseFull, TideCycleSpring},
+		{"springs after the new moon", time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC), PhaseWaxingCrescent, TideCycleSpring},
+		{"neap after the first quarter", time.Date(2024, 1, 19, 12, 0, 0, 0, time.UTC), PhaseFirstQuarter, TideCycleNeap},
+		{"last quarter", time.Date(2024, 2, 2, 23, 18, 0, 0, time.UTC), PhaseLastQuarter, TideCycleNeap},
+		{"waxing crescent", time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC), PhaseWaxingCrescent, TideCycleIntermediate},
+		{"waxing gibbous", time.Date(2024, 1, 22, 12, 0, 0, 0, time.UTC), PhaseWaxingGibbous, TideCycleIntermediate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moon := MoonAt(tt.at)
+			assert.Equal(t, tt.phase, moon.Phase)
+			assert.Equal(t, tt.cycle, moon.TideCycle)
+			assert.GreaterOrEqual(t, moon.Age, 0.0)
+			assert.Less(t, moon.Age, SynodicMonth)
+		})
+	}
+
+	newMoon := MoonAt(time.Date(2024, 1, 11, 11, 57, 0, 0, time.UTC))
+	assert.InDelta(t, 0, min(newMoon.Age, SynodicMonth-newMoon.Age), 1, fmt.Sprint(newMoon.Age))
+	assert.InDelta(t, SynodicMonth/2, newMoon.DaysSinceFull, 1)
+
+	// Before the reference new moon
+	old := MoonAt(time.Date(1999, 12, 22, 17, 31, 0, 0, time.UTC))
+	assert.Equal(t, PhaseFull, old.Phase)
+	assert.InDelta(t, 0, min(old.DaysSinceFull, SynodicMonth-old.DaysSinceFull), 1)
+}
@@ -0,0 +1,82 @@
+package astro
+
+import (
+	"math"
+	"time"
+)
+
+// j2000 is the Julian date of 2000-01-01 12:00 UTC, the epoch of the solar formulas
+const j2000 = 2451545.0
+
+// sunriseAltitude is the sun's altitude, in degrees, as its upper edge meets the horizon:
+// its radius and the refraction of the atmosphere put it that far below
+const sunriseAltitude = -0.833
+
+// axialTilt is the obliquity of the ecliptic, in degrees
+const axialTilt = 23.4397
+
+// Daylight is when the sun is up on one day
+type Daylight struct {
+	// Sunrise and Sunset are zero on days the sun neither rises nor sets: all of them is
+	// daylight when AllDay is set, as in a polar summer, and none of it otherwise
+	Sunrise time.Time
+	Sunset  time.Time
+	AllDay  bool
+}
+
+// DaylightOn returns when the sun is up on the given day at a place, from the sunrise
+// equation, which is within a minute or two of published times outside the polar regions
+func DaylightOn(year int, month time.Month, day int, lat, lon float64) Daylight {
+	noon := time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	// Mean solar noon at the place, in days since J2000
+	meanNoon := math.Round(julianDate(noon)-j2000) + 0.0008 - lon/360
+
+	anomaly := wrap(357.5291+0.98560028*meanNoon, 360) * math.Pi / 180
+	center := 1.9148*math.Sin(anomaly) + 0.02*math.Sin(2*anomaly) + 0.0003*math.Sin(3*anomaly)
+	longitude := wrap(anomaly*180/math.Pi+center+180+102.9372, 360) * math.Pi / 180
+	transit := j2000 + meanNoon + 0.0053*math.Sin(anomaly) - 0.0069*math.Sin(2*longitude)
+
+	declination := math.Asin(math.Sin(longitude) * math.Sin(axialTilt*math.Pi/180))
+	phi := lat * math.Pi / 180
+	cosHourAngle := (math.Sin(sunriseAltitude*math.Pi/180) - math.Sin(phi)*math.Sin(declination)) /
+		(math.Cos(phi) * math.Cos(declination))
+	switch {
+	case cosHourAngle < -1:
+		return Daylight{AllDay: true}
+	case cosHourAngle > 1:
+		return Daylight{}
+	}
+
+	halfDay := math.Acos(cosHourAngle) * 180 / math.Pi / 360
+	return Daylight{
+		Sunrise: fromJulianDate(transit - halfDay),
+		Sunset:  fromJulianDate(transit + halfDay),
+	}
+}
+
+// Contains reports whether the sun is up at t, which should fall on the day d is for
+func (d Daylight) Contains(t time.Time) bool {
+	if d.Sunrise.IsZero() {
+		return d.AllDay
+	}
+	return !t.Before(d.Sunrise) && !t.After(d.Sunset)
+}
+
+// Duration is how long the sun is up
+func (d Daylight) Duration() time.Duration {
+	if d.Sunrise.IsZero() {
+		if d.AllDay {
+			return 24 * time.Hour
+		}
+		return 0
+	}
+	return d.Sunset.Sub(d.Sunrise)
+}
+
+func julianDate(t time.Time) float64 {
+	return float64(t.UnixMilli())/86400000 + 2440587.5
+}
+
+func fromJulianDate(jd float64) time.Time {
+	return time.UnixMilli(int64(math.Round((jd - 2440587.5) * 86400000))).UTC()
+}
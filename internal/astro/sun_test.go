@@ -0,0 +1,59 @@
+package astro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDaylightOn(t *testing.T) {
+	seattle, _ := time.LoadLocation("America/Los_Angeles")
+	tests := []struct {
+		name            string
+		year            int
+		month           time.Month
+		day             int
+		lat, lon        float64
+		sunrise, sunset time.Time
+	}{
+		// Published times for the day, to the minute
+		{"Seattle in summer", 2024, time.June, 20, 47.6062, -122.3321,
+			time.Date(2024, 6, 20, 5, 11, 0, 0, seattle), time.Date(2024, 6, 20, 21, 10, 0, 0, seattle)},
+		{"Seattle in winter", 2024, time.December, 21, 47.6062, -122.3321,
+			time.Date(2024, 12, 21, 7, 55, 0, 0, seattle), time.Date(2024, 12, 21, 16, 20, 0, 0, seattle)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daylight := DaylightOn(tt.year, tt.month, tt.day, tt.lat, tt.lon)
+			assert.WithinDuration(t, tt.sunrise, daylight.Sunrise, 3*time.Minute)
+			assert.WithinDuration(t, tt.sunset, daylight.Sunset, 3*time.Minute)
+			assert.False(t, daylight.AllDay)
+
+			assert.True(t, daylight.Contains(tt.sunrise.Add(time.Hour)))
+			assert.False(t, daylight.Contains(tt.sunrise.Add(-time.Hour)))
+			assert.False(t, daylight.Contains(tt.sunset.Add(time.Hour)))
+			assert.InDelta(t, tt.sunset.Sub(tt.sunrise).Minutes(), daylight.Duration().Minutes(), 6)
+		})
+	}
+}
+
+func TestDaylightOn_SouthernHemisphere(t *testing.T) {
+	// Sydney's January days are over 14 hours long, and its June days under 10
+	assert.Greater(t, DaylightOn(2024, time.January, 15, -33.8688, 151.2093).Duration(), 14*time.Hour)
+	assert.Less(t, DaylightOn(2024, time.June, 15, -33.8688, 151.2093).Duration(), 10*time.Hour)
+}
+
+func TestDaylightOn_Polar(t *testing.T) {
+	// Utqiagvik, Alaska, has two months of each
+	summer := DaylightOn(2024, time.June, 21, 71.29, -156.79)
+	assert.True(t, summer.AllDay)
+	assert.True(t, summer.Sunrise.IsZero())
+	assert.True(t, summer.Contains(time.Date(2024, 6, 21, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 24*time.Hour, summer.Duration())
+
+	winter := DaylightOn(2024, time.December, 21, 71.29, -156.79)
+	assert.False(t, winter.AllDay)
+	assert.False(t, winter.Contains(time.Date(2024, 12, 21, 22, 0, 0, 0, time.UTC)))
+	assert.Zero(t, winter.Duration())
+}
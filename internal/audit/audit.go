@@ -0,0 +1,117 @@
+// Package audit keeps a log of every change users make to the resources they create, such
+// as favorite stations, so changes can be traced and accidental ones undone
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+)
+
+// Action is what a change did to a resource
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+)
+
+// Change is one change to a user's resource, as recorded in the log
+type Change struct {
+	UserID string `json:"userId" dynamodbav:"userId"`
+	// ChangeID sorts a user's changes by when they were made
+	ChangeID string `json:"changeId" dynamodbav:"changeId"`
+	// Resource is the kind of resource, such as favorite, and ResourceID which one
+	Resource   string `json:"resource" dynamodbav:"resource"`
+	ResourceID string `json:"resourceId" dynamodbav:"resourceId"`
+	Action     Action `json:"action" dynamodbav:"action"`
+	// Before and After are the resource as JSON; Before is empty for a create
+	Before string `json:"before,omitempty" dynamodbav:"before,omitempty"`
+	After  string `json:"after,omitempty" dynamodbav:"after,omitempty"`
+	At     int64  `json:"at" dynamodbav:"at"`
+	// TTL is when DynamoDB may drop the change
+	TTL int64 `json:"-" dynamodbav:"ttl"`
+}
+
+// NewChange describes a change to a resource made at now, with before and after marshaled
+// as JSON. before is nil for a create.
+func NewChange(userID, resource, resourceID string, action Action, before, after interface{}, now time.Time) (Change, error) {
+	change := Change{
+		UserID:     userID,
+		ChangeID:   changeID(now),
+		Resource:   resource,
+		ResourceID: resourceID,
+		Action:     action,
+		At:         now.Unix(),
+	}
+	var err error
+	if change.Before, err = marshalState(before); err != nil {
+		return Change{}, err
+	}
+	if change.After, err = marshalState(after); err != nil {
+		return Change{}, err
+	}
+	return change, nil
+}
+
+func marshalState(state interface{}) (string, error) {
+	if state == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("marshaling audited state: %w", err)
+	}
+	return string(data), nil
+}
+
+// changeID sorts by when the change was made, then by random bytes that keep two changes
+// of the same moment apart
+func changeID(now time.Time) string {
+	suffix := make([]byte, 6)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%013d#%s", now.UnixMilli(), hex.EncodeToString(suffix))
+}
+
+// Log records changes to users' resources
+type Log interface {
+	Record(ctx context.Context, change Change) error
+}
+
+// DynamoLog keeps each change as an item keyed by userId and changeId, so a user's
+// changes can be queried in the order they were made, and lets DynamoDB drop it after
+// retention. The table's stream carries every change to whatever needs to follow them.
+type DynamoLog struct {
+	client    cache.DynamoDBClient
+	table     string
+	retention time.Duration
+}
+
+func NewDynamoLog(client cache.DynamoDBClient, table string, retention time.Duration) *DynamoLog {
+	return &DynamoLog{client: client, table: table, retention: retention}
+}
+
+func (l *DynamoLog) Record(ctx context.Context, change Change) error {
+	change.TTL = time.Unix(change.At, 0).Add(l.retention).Unix()
+	item, err := attributevalue.MarshalMap(change)
+	if err != nil {
+		return fmt.Errorf("marshaling change: %w", err)
+	}
+	if _, err := l.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(l.table),
+		Item:      item,
+	}); err != nil {
+		return fmt.Errorf("putting change in DynamoDB: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB records the puts it's given
+type fakeDynamoDB struct {
+	cache.DynamoDBClient
+	puts []*dynamodb.PutItemInput
+	err  error
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.puts = append(f.puts, params)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestNewChange(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	after := map[string]string{"stationId": "9447130"}
+	change, err := NewChange("user-1", "favorite", "9447130", ActionCreate, nil, after, now)
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^1700000000123#[0-9a-f]{12}$`), change.ChangeID)
+	assert.Empty(t, change.Before)
+	assert.JSONEq(t, `{"stationId":"9447130"}`, change.After)
+	assert.Equal(t, int64(1700000000), change.At)
+
+	other, err := NewChange("user-1", "favorite", "9447130", ActionDelete, after, after, now)
+	require.NoError(t, err)
+	assert.NotEqual(t, change.ChangeID, other.ChangeID, "changes at the same moment stay apart")
+
+	_, err = NewChange("user-1", "favorite", "9447130", ActionUpdate, nil, func() {}, now)
+	assert.ErrorContains(t, err, "marshaling audited state")
+}
+
+func TestDynamoLog_Record(t *testing.T) {
+	client := &fakeDynamoDB{}
+	log := NewDynamoLog(client, "audit", 24*time.Hour)
+	change, err := NewChange("user-1", "favorite", "9447130", ActionDelete, map[string]int{"deletedAt": 0}, map[string]int{"deletedAt": 1700000000}, time.Unix(1700000000, 0))
+	require.NoError(t, err)
+
+	require.NoError(t, log.Record(context.Background(), change))
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, "audit", *client.puts[0].TableName)
+	var saved Change
+	require.NoError(t, attributevalue.UnmarshalMap(client.puts[0].Item, &saved))
+	change.TTL = 1700000000 + 24*60*60
+	assert.Equal(t, change, saved)
+
+	client.err = errors.New("throttled")
+	assert.ErrorContains(t, log.Record(context.Background(), change), "putting change in DynamoDB: throttled")
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// CacheEntryInfo describes what each cache tier holds for a station and date
+type CacheEntryInfo struct {
+	StationID string `json:"stationId"`
+	Date      string `json:"date"`
+	StoreName string `json:"storeName"`
+	// LRU and Store are nil when that tier has no live record
+	LRU   *TierEntryInfo `json:"lru,omitempty"`
+	Store *TierEntryInfo `json:"store,omitempty"`
+	// LRUTTLSeconds is how long an LRU entry lives. Each instance has its own LRU, so after
+	// an invalidation other instances may serve the record they hold for up to this long.
+	LRUTTLSeconds int64 `json:"lruTtlSeconds"`
+}
+
+// TierEntryInfo describes a single cached record
+type TierEntryInfo struct {
+	ExpiresAt  time.Time `json:"expiresAt"`
+	TTLSeconds int64     `json:"ttlSeconds"`
+	// SizeBytes is what the tier holds, after any compression; UncompressedBytes is the
+	// record's JSON size, when that differs
+	SizeBytes         int64 `json:"sizeBytes"`
+	UncompressedBytes int64 `json:"uncompressedBytes,omitempty"`
+	Predictions       int   `json:"predictions"`
+	Extremes          int   `json:"extremes"`
+	LastUpdated       int64 `json:"lastUpdated,omitempty"`
+}
+
+// Inspect reports the cached record for a station and date in both tiers without
+// touching hit/miss stats or LRU recency
+func (c *LRUCacheService) Inspect(ctx context.Context, stationID string, date time.Time) (*CacheEntryInfo, error) {
+	dateStr := date.Format("2006-01-02")
+	now := c.clock.Now()
+	info := &CacheEntryInfo{
+		StationID: stationID,
+		Date:      dateStr,
+		StoreName: c.store.Name(),
+
+		LRUTTLSeconds: int64(time.Duration(c.ttl.Load()).Seconds()),
+	}
+
+	if entry, ok := c.lru.Peek(getCacheKey(stationID, dateStr)); ok && entry.ExpiresAt.After(now) {
+		info.LRU = tierEntryInfo(entry.Data, entry.ExpiresAt, entry.Size, now)
+	}
+
+	record, err := c.store.GetPredictions(ctx, stationID, date)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions from %s: %w", c.store.Name(), err)
+	}
+	if record != nil {
+		// Report the serialized size, which is what counts against store item limits
+		data, err := json.Marshal(record)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling prediction record: %w", err)
+		}
+		size := int64(len(data))
+		if sizer, ok := c.store.(RecordSizer); ok {
+			if size, err = sizer.StoredSize(*record); err != nil {
+				return nil, fmt.Errorf("sizing prediction record: %w", err)
+			}
+		}
+		info.Store = tierEntryInfo(record, time.Unix(record.TTL, 0), size, now)
+		info.Store.LastUpdated = record.LastUpdated
+		if size != int64(len(data)) {
+			info.Store.UncompressedBytes = int64(len(data))
+		}
+	}
+
+	return info, nil
+}
+
+// Invalidate removes a station's record for a date from this instance's LRU and the
+// prediction store, and the station from the extremes index, and tells OnRefresh's
+// listeners. Other instances' LRUs aren't reached; see CacheEntryInfo.LRUTTLSeconds.
+func (c *LRUCacheService) Invalidate(ctx context.Context, stationID string, date time.Time) error {
+	c.lru.Remove(getCacheKey(stationID, date.Format("2006-01-02")))
+	if c.extremes != nil {
+		c.extremes.Remove(stationID)
+	}
+	c.notifyRefresh(stationID)
+
+	if err := c.store.DeletePredictions(ctx, stationID, date); err != nil {
+		return fmt.Errorf("deleting predictions from %s: %w", c.store.Name(), err)
+	}
+	return nil
+}
+
+func tierEntryInfo(record *models.TidePredictionRecord, expiresAt time.Time, size int64, now time.Time) *TierEntryInfo {
+	return &TierEntryInfo{
+		ExpiresAt:   expiresAt.UTC(),
+		TTLSeconds:  int64(expiresAt.Sub(now).Seconds()),
+		SizeBytes:   size,
+		Predictions: len(record.Predictions),
+		Extremes:    len(record.Extremes),
+	}
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCacheService_InspectAndInvalidate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.CacheConfig{
+		TidePredictionLRUSize:       10,
+		TidePredictionLRUTTLMinutes: 15,
+		TidePredictionDynamoTTLDays: 1,
+	}
+	service := createTestCacheService(t, cfg)
+	service.store = NewFilePredictionCache(dir, cfg)
+
+	ctx := context.Background()
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Nothing cached yet
+	info, err := service.Inspect(ctx, "9447130", date)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01", info.Date)
+	assert.Equal(t, "file", info.StoreName)
+	assert.Nil(t, info.LRU)
+	assert.Nil(t, info.Store)
+
+	require.NoError(t, service.SavePredictions(ctx, createTestRecord("9447130", "2024-01-01")))
+
+	info, err = service.Inspect(ctx, "9447130", date)
+	require.NoError(t, err)
+	require.NotNil(t, info.LRU)
+	require.NotNil(t, info.Store)
+	assert.InDelta(t, 15*60, info.LRU.TTLSeconds, 1)
+	assert.Equal(t, 1, info.LRU.Predictions)
+	assert.Equal(t, 1, info.Store.Extremes)
+	assert.Positive(t, info.Store.SizeBytes)
+	assert.Zero(t, info.Store.UncompressedBytes, "the file store keeps plain JSON")
+	assert.Positive(t, info.Store.LastUpdated)
+	assert.Equal(t, int64(15*60), info.LRUTTLSeconds)
+
+	// Inspecting doesn't count as a cache lookup
+	stats := service.GetCacheStats()
+	assert.Equal(t, uint64(0), stats["lru_hits"])
+	assert.Equal(t, uint64(0), stats["file_hits"])
+
+	require.NoError(t, service.Invalidate(ctx, "9447130", date))
+	assert.NoFileExists(t, filepath.Join(dir, "predictions", "9447130", "2024-01-01.json"))
+
+	info, err = service.Inspect(ctx, "9447130", date)
+	require.NoError(t, err)
+	assert.Nil(t, info.LRU)
+	assert.Nil(t, info.Store)
+
+	// Invalidating something that isn't cached is fine
+	require.NoError(t, service.Invalidate(ctx, "9447130", date))
+}
+
+func TestLRUCacheService_InspectReportsStoredSize(t *testing.T) {
+	cfg := &config.CacheConfig{
+		TidePredictionLRUSize:       10,
+		TidePredictionLRUTTLMinutes: 15,
+		TidePredictionDynamoTTLDays: 1,
+		DynamoCompressMinBytes:      4096,
+	}
+	var stored map[string]types.AttributeValue
+	mock := &mockDynamoDBClient{
+		putItemFunc: func(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			stored = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItemFunc: func(_ context.Context, _ *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+	service := createTestCacheService(t, cfg)
+	service.store = NewDynamoPredictionCache(mock, cfg)
+
+	ctx := context.Background()
+	record := createFullDayRecord()
+	require.NoError(t, service.SavePredictions(ctx, record))
+
+	date, _ := time.Parse("2006-01-02", record.Date)
+	info, err := service.Inspect(ctx, record.StationID, date)
+	require.NoError(t, err)
+	require.NotNil(t, info.Store)
+	assert.Positive(t, info.Store.SizeBytes)
+	assert.Less(t, info.Store.SizeBytes, info.Store.UncompressedBytes, "records this size are stored gzipped")
+}
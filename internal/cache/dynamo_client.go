@@ -5,23 +5,50 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/rs/zerolog/log"
-	"os"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	appconfig "github.com/bbernstein/flowebb-go/internal/config"
 )
 
 // DynamoDBClient interface defines the DynamoDB operations we use
 type DynamoDBClient interface {
 	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	BatchGetItem(context.Context, *dynamodb.BatchGetItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
 	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 	BatchWriteItem(context.Context, *dynamodb.BatchWriteItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 	ListTables(context.Context, *dynamodb.ListTablesInput, ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error)
 }
 
+// NewRegionalDynamoClientFromEnv creates a client for the prediction table that reads from
+// the replicas in CACHE_FALLBACK_REGIONS when the own region's fails. Without fallback
+// regions, or against a local endpoint, it's the client NewDynamoClient creates.
+func NewRegionalDynamoClientFromEnv(ctx context.Context, cacheConfig *appconfig.CacheConfig) (DynamoDBClient, error) {
+	if len(cacheConfig.FallbackRegions) == 0 || cacheConfig.DynamoDBEndpoint != "" {
+		return NewDynamoClient(ctx)
+	}
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	local := Replica[DynamoDBClient]{Region: cfg.Region, Client: dynamodb.NewFromConfig(cfg)}
+	var fallbacks []Replica[DynamoDBClient]
+	for _, region := range otherRegions(cfg.Region, cacheConfig.FallbackRegions) {
+		client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+			o.Region = region
+		})
+		fallbacks = append(fallbacks, Replica[DynamoDBClient]{Region: region, Client: client})
+	}
+	return NewRegionalDynamoClient(local, fallbacks...), nil
+}
+
 // NewDynamoClient creates a new DynamoDB client based on environment
 func NewDynamoClient(ctx context.Context) (DynamoDBClient, error) {
-	if endpoint := os.Getenv("DYNAMODB_ENDPOINT"); endpoint != "" {
+	if endpoint := appconfig.GetCacheConfig().DynamoDBEndpoint; endpoint != "" {
 		// Local development configuration
 		log.Debug().Str("endpoint", endpoint).Msg("Using local DynamoDB endpoint")
 
@@ -1,27 +1,39 @@
 package cache
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/logging"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/rs/zerolog/log"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
 const (
-	tableName         = "tide-predictions-cache"
-	cacheValidityDays = 7
+	tableName = "tide-predictions-cache"
+
+	// maxBatchGetKeys is DynamoDB's limit on keys per BatchGetItem request
+	maxBatchGetKeys = 100
+
+	// formatCompressed marks items whose predictions and extremes are stored as one gzipped
+	// JSON payload. Items without a format attribute use the original list-of-maps layout.
+	formatCompressed = 2
 )
 
 // DynamoPredictionCache handles caching tide predictions in DynamoDB
 type DynamoPredictionCache struct {
 	client DynamoDBClient
-	config *config.CacheConfig
+	config atomic.Pointer[config.CacheConfig]
 	clock  clock
 }
 
@@ -29,11 +41,21 @@ func NewDynamoPredictionCache(client DynamoDBClient, cacheConfig *config.CacheCo
 	if cacheConfig == nil {
 		cacheConfig = config.GetCacheConfig()
 	}
-	return &DynamoPredictionCache{
+	c := &DynamoPredictionCache{
 		client: client,
-		config: cacheConfig,
 		clock:  &systemClock{},
 	}
+	c.config.Store(cacheConfig)
+	return c
+}
+
+// SetConfig replaces the TTLs and batch settings used by later calls
+func (c *DynamoPredictionCache) SetConfig(cacheConfig *config.CacheConfig) {
+	c.config.Store(cacheConfig)
+}
+
+func (c *DynamoPredictionCache) Name() string {
+	return config.BackendDynamo
 }
 
 // GetPredictions retrieves cached predictions for a station and date
@@ -57,21 +79,89 @@ func (c *DynamoPredictionCache) GetPredictions(ctx context.Context, stationID st
 		return nil, nil
 	}
 
-	var record models.TidePredictionRecord
-	if err := attributevalue.UnmarshalMap(result.Item, &record); err != nil {
+	record, err := unmarshalPredictionItem(result.Item)
+	if err != nil {
 		return nil, fmt.Errorf("unmarshaling prediction record: %w", err)
 	}
 
 	// Check if cache is valid
-	if !c.isValid(record) {
-		log.Debug().
+	if !c.isValid(*record) {
+		logging.Hot().Debug().
 			Str("station_id", stationID).
 			Str("date", dateStr).
 			Msg("Cache expired")
 		return nil, nil
 	}
 
-	return &record, nil
+	return record, nil
+}
+
+// GetPredictionsBatch retrieves cached predictions for several dates with BatchGetItem,
+// returning one entry per date with nil for misses. Keys DynamoDB leaves unprocessed are
+// retried with backoff and, if still unprocessed, reported as misses.
+func (c *DynamoPredictionCache) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	records := make([]*models.TidePredictionRecord, len(dates))
+
+	// BatchGetItem rejects duplicate keys, so request each date once and fan the result out
+	positions := make(map[string][]int, len(dates))
+	var keys []map[string]types.AttributeValue
+	for i, date := range dates {
+		dateStr := date.Format("2006-01-02")
+		if _, seen := positions[dateStr]; !seen {
+			keys = append(keys, map[string]types.AttributeValue{
+				"stationId": &types.AttributeValueMemberS{Value: stationID},
+				"date":      &types.AttributeValueMemberS{Value: dateStr},
+			})
+		}
+		positions[dateStr] = append(positions[dateStr], i)
+	}
+
+	for i := 0; i < len(keys); i += maxBatchGetKeys {
+		end := i + maxBatchGetKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		pending := keys[i:end]
+		for retry := 0; len(pending) > 0; retry++ {
+			if retry > c.config.Load().MaxBatchRetries {
+				log.Warn().
+					Str("station_id", stationID).
+					Int("unprocessed", len(pending)).
+					Msg("Giving up on unprocessed BatchGetItem keys")
+				break
+			}
+			if retry > 0 {
+				time.Sleep(time.Duration(1<<(retry-1)) * 100 * time.Millisecond)
+			}
+
+			output, err := c.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: map[string]types.KeysAndAttributes{
+					tableName: {Keys: pending},
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("batch getting predictions from DynamoDB: %w", err)
+			}
+
+			for _, item := range output.Responses[tableName] {
+				record, err := unmarshalPredictionItem(item)
+				if err != nil {
+					return nil, fmt.Errorf("unmarshaling prediction record: %w", err)
+				}
+				if !c.isValid(*record) {
+					continue
+				}
+				for _, pos := range positions[record.Date] {
+					records[pos] = record
+				}
+			}
+
+			pending = output.UnprocessedKeys[tableName].Keys
+		}
+	}
+
+	return records, nil
 }
 
 // SavePredictions saves predictions to the cache
@@ -81,11 +171,12 @@ func (c *DynamoPredictionCache) SavePredictions(ctx context.Context, record mode
 		return fmt.Errorf("invalid prediction record: %w", err)
 	}
 
-	now := c.clock.Now().Unix()
-	record.LastUpdated = now
-	record.TTL = now + (cacheValidityDays * 24 * 60 * 60)
+	now := c.clock.Now()
+	record.LastUpdated = now.Unix()
+	// Use configured TTL, which is longer for days in the past
+	record.TTL = now.Add(c.config.Load().GetPredictionTTL(record.Date, now)).Unix()
 
-	item, err := attributevalue.MarshalMap(record)
+	item, err := c.marshalPredictionItem(record)
 	if err != nil {
 		return fmt.Errorf("marshaling prediction record: %w", err)
 	}
@@ -112,7 +203,8 @@ func (c *DynamoPredictionCache) SavePredictionsBatch(ctx context.Context, record
 	}
 
 	// Process in batches using configured batch size
-	batchSize := c.config.BatchSize
+	cfg := c.config.Load()
+	batchSize := cfg.BatchSize
 	for i := 0; i < len(records); i += batchSize {
 		end := i + batchSize
 		if end > len(records) {
@@ -123,12 +215,12 @@ func (c *DynamoPredictionCache) SavePredictionsBatch(ctx context.Context, record
 		var writeRequests []types.WriteRequest
 
 		for _, record := range batch {
-			now := c.clock.Now().Unix()
-			record.LastUpdated = now
-			// Use configured TTL
-			record.TTL = now + int64(c.config.GetDynamoTTL().Seconds())
+			now := c.clock.Now()
+			record.LastUpdated = now.Unix()
+			// Use configured TTL, which is longer for days in the past
+			record.TTL = now.Add(cfg.GetPredictionTTL(record.Date, now)).Unix()
 
-			item, err := attributevalue.MarshalMap(record)
+			item, err := c.marshalPredictionItem(record)
 			if err != nil {
 				return fmt.Errorf("marshaling prediction record: %w", err)
 			}
@@ -142,7 +234,7 @@ func (c *DynamoPredictionCache) SavePredictionsBatch(ctx context.Context, record
 
 		// Add retry logic with configured max retries
 		var lastErr error
-		for retry := 0; retry < c.config.MaxBatchRetries; retry++ {
+		for retry := 0; retry < cfg.MaxBatchRetries; retry++ {
 			input := &dynamodb.BatchWriteItemInput{
 				RequestItems: map[string][]types.WriteRequest{
 					tableName: writeRequests,
@@ -160,14 +252,155 @@ func (c *DynamoPredictionCache) SavePredictionsBatch(ctx context.Context, record
 		}
 		if lastErr != nil {
 			return fmt.Errorf("batch writing predictions after %d retries: %w",
-				c.config.MaxBatchRetries, lastErr)
+				cfg.MaxBatchRetries, lastErr)
 		}
 	}
 
 	return nil
 }
 
+// DeletePredictions removes the cached record for a station and date, if any
+func (c *DynamoPredictionCache) DeletePredictions(ctx context.Context, stationID string, date time.Time) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"stationId": &types.AttributeValueMemberS{Value: stationID},
+			"date":      &types.AttributeValueMemberS{Value: date.Format("2006-01-02")},
+		},
+	}
+
+	if _, err := c.client.DeleteItem(ctx, input); err != nil {
+		return fmt.Errorf("deleting predictions from DynamoDB: %w", err)
+	}
+	return nil
+}
+
 func (c *DynamoPredictionCache) isValid(record models.TidePredictionRecord) bool {
 	now := c.clock.Now().Unix()
 	return now < record.TTL
 }
+
+// compressedPayload is the JSON document gzipped into the payload attribute
+type compressedPayload struct {
+	Predictions []models.TidePrediction `json:"predictions"`
+	Extremes    []models.TideExtreme    `json:"extremes"`
+}
+
+// marshalPredictionItem converts a record to a DynamoDB item. Records whose predictions and
+// extremes encode to at least DynamoCompressMinBytes are stored compressed; smaller ones keep
+// the original layout so they stay readable by older deployments.
+func (c *DynamoPredictionCache) marshalPredictionItem(record models.TidePredictionRecord) (map[string]types.AttributeValue, error) {
+	minBytes := c.config.Load().DynamoCompressMinBytes
+	if minBytes < 0 {
+		return attributevalue.MarshalMap(record)
+	}
+
+	data, err := json.Marshal(compressedPayload{Predictions: record.Predictions, Extremes: record.Extremes})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < minBytes {
+		return attributevalue.MarshalMap(record)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	record.Predictions = nil
+	record.Extremes = nil
+	item, err := attributevalue.MarshalMap(record)
+	if err != nil {
+		return nil, err
+	}
+	delete(item, "predictions")
+	delete(item, "extremes")
+	item["format"] = &types.AttributeValueMemberN{Value: strconv.Itoa(formatCompressed)}
+	item["payload"] = &types.AttributeValueMemberB{Value: buf.Bytes()}
+	return item, nil
+}
+
+// StoredSize returns the size of the item record is saved as, which is what counts against
+// DynamoDB's 400 KB item limit and its read and write units
+func (c *DynamoPredictionCache) StoredSize(record models.TidePredictionRecord) (int64, error) {
+	item, err := c.marshalPredictionItem(record)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for name, value := range item {
+		size += int64(len(name)) + attributeSize(value)
+	}
+	return size, nil
+}
+
+// attributeSize follows DynamoDB's item size rules: strings and binaries count their bytes,
+// numbers about one byte per two digits, and lists and maps three bytes of overhead plus
+// one per element on top of their contents
+func attributeSize(value types.AttributeValue) int64 {
+	switch v := value.(type) {
+	case *types.AttributeValueMemberS:
+		return int64(len(v.Value))
+	case *types.AttributeValueMemberN:
+		return int64((len(v.Value)+1)/2 + 1)
+	case *types.AttributeValueMemberB:
+		return int64(len(v.Value))
+	case *types.AttributeValueMemberL:
+		size := int64(3 + len(v.Value))
+		for _, element := range v.Value {
+			size += attributeSize(element)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := int64(3 + len(v.Value))
+		for name, element := range v.Value {
+			size += int64(len(name)) + attributeSize(element)
+		}
+		return size
+	default:
+		return 1
+	}
+}
+
+// unmarshalPredictionItem reads either item layout back into a record
+func unmarshalPredictionItem(item map[string]types.AttributeValue) (*models.TidePredictionRecord, error) {
+	var record models.TidePredictionRecord
+	if err := attributevalue.UnmarshalMap(item, &record); err != nil {
+		return nil, err
+	}
+
+	formatAttr, ok := item["format"]
+	if !ok {
+		return &record, nil
+	}
+	var format int
+	if err := attributevalue.Unmarshal(formatAttr, &format); err != nil {
+		return nil, fmt.Errorf("reading record format: %w", err)
+	}
+	if format != formatCompressed {
+		return nil, fmt.Errorf("unsupported record format: %d", format)
+	}
+
+	payloadAttr, ok := item["payload"].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, fmt.Errorf("compressed record has no payload")
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payloadAttr.Value))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing payload: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	var payload compressedPayload
+	if err := json.NewDecoder(zr).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+	record.Predictions = payload.Predictions
+	record.Extremes = payload.Extremes
+	return &record, nil
+}
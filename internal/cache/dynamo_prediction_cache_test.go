@@ -3,11 +3,13 @@ package cache
 import (
 	"context"
 	"github.com/bbernstein/flowebb-go/internal/config"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,6 +23,12 @@ type mockDynamoDBClient struct {
 	putItemFunc        func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	batchWriteItemFunc func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
 	listTablesFunc     func(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error)
+	deleteItemFunc     func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	batchGetItemFunc   func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+}
+
+func (m *mockDynamoDBClient) Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
 }
 
 func (m *mockDynamoDBClient) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
@@ -51,6 +59,24 @@ func (m *mockDynamoDBClient) BatchWriteItem(ctx context.Context, params *dynamod
 	return &dynamodb.BatchWriteItemOutput{}, nil
 }
 
+func (m *mockDynamoDBClient) UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if m.deleteItemFunc != nil {
+		return m.deleteItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if m.batchGetItemFunc != nil {
+		return m.batchGetItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.BatchGetItemOutput{}, nil
+}
+
 func createTestPredictionRecord() models.TidePredictionRecord {
 	now := time.Now()
 	return models.TidePredictionRecord{
@@ -59,7 +85,7 @@ func createTestPredictionRecord() models.TidePredictionRecord {
 		StationType: "R",
 		Predictions: []models.TidePrediction{
 			{
-				Timestamp: now.UnixMilli(),
+				Timestamp: models.MillisOf(now),
 				LocalTime: now.Format("2006-01-02T15:04:05"),
 				Height:    1.5,
 			},
@@ -67,7 +93,7 @@ func createTestPredictionRecord() models.TidePredictionRecord {
 		Extremes: []models.TideExtreme{
 			{
 				Type:      models.TideTypeHigh,
-				Timestamp: now.UnixMilli(),
+				Timestamp: models.MillisOf(now),
 				LocalTime: now.Format("2006-01-02T15:04:05"),
 				Height:    2.0,
 			},
@@ -187,6 +213,32 @@ func TestSavePredictions(t *testing.T) {
 	}
 }
 
+func TestSavePredictionsTTL(t *testing.T) {
+	cfg := &config.CacheConfig{TidePredictionDynamoTTLDays: 7, HistoricalTTLDays: 365, BatchSize: 25}
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	var item map[string]types.AttributeValue
+	cache := NewDynamoPredictionCache(&mockDynamoDBClient{
+		putItemFunc: func(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			item = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}, cfg)
+	cache.clock = &fakeClock{now: now}
+
+	for date, want := range map[string]time.Duration{
+		"2024-06-15": 7 * 24 * time.Hour,
+		"2024-05-01": 365 * 24 * time.Hour,
+	} {
+		record := createTestPredictionRecord()
+		record.Date = date
+		require.NoError(t, cache.SavePredictions(context.Background(), record))
+		ttl, ok := item["ttl"].(*types.AttributeValueMemberN)
+		require.True(t, ok)
+		assert.Equal(t, strconv.FormatInt(now.Add(want).Unix(), 10), ttl.Value, date)
+	}
+}
+
 func TestSavePredictionsBatch(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -236,6 +288,29 @@ func TestSavePredictionsBatch(t *testing.T) {
 	}
 }
 
+func TestDeletePredictions(t *testing.T) {
+	var deleted map[string]types.AttributeValue
+	mock := &mockDynamoDBClient{
+		deleteItemFunc: func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+			deleted = params.Key
+			return &dynamodb.DeleteItemOutput{}, nil
+		},
+	}
+
+	cache := NewDynamoPredictionCache(mock, testConfig)
+	err := cache.DeletePredictions(context.Background(), "TEST-001", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "TEST-001"}, deleted["stationId"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02"}, deleted["date"])
+
+	mock.deleteItemFunc = func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+		return nil, assert.AnError
+	}
+	err = cache.DeletePredictions(context.Background(), "TEST-001", time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deleting predictions from DynamoDB")
+}
+
 func TestCacheValidation(t *testing.T) {
 	now := time.Now()
 	tests := []struct {
@@ -274,3 +349,208 @@ func TestCacheValidation(t *testing.T) {
 		})
 	}
 }
+
+// createFullDayRecord returns a record with a day of 6-minute predictions, large enough to compress
+func createFullDayRecord() models.TidePredictionRecord {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	record := models.TidePredictionRecord{
+		StationID:   "TEST-001",
+		Date:        "2024-01-01",
+		StationType: "R",
+		Extremes: []models.TideExtreme{
+			{Type: models.TideTypeHigh, Timestamp: models.MillisOf(start.Add(6 * time.Hour)), LocalTime: "2024-01-01T06:00:00", Height: 9.1},
+		},
+	}
+	for i := 0; i < 240; i++ {
+		ts := start.Add(time.Duration(i) * 6 * time.Minute)
+		record.Predictions = append(record.Predictions, models.TidePrediction{
+			Timestamp: models.MillisOf(ts),
+			LocalTime: ts.Format("2006-01-02T15:04:05"),
+			Height:    float64(i%50) / 10,
+		})
+	}
+	return record
+}
+
+func TestPredictionCompression(t *testing.T) {
+	var stored map[string]types.AttributeValue
+	mock := &mockDynamoDBClient{
+		putItemFunc: func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			stored = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItemFunc: func(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+	ctx := context.Background()
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("large records are compressed", func(t *testing.T) {
+		cache := NewDynamoPredictionCache(mock, &config.CacheConfig{TidePredictionDynamoTTLDays: 7, DynamoCompressMinBytes: 4096})
+		record := createFullDayRecord()
+		require.NoError(t, cache.SavePredictions(ctx, record))
+
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "2"}, stored["format"])
+		assert.NotContains(t, stored, "predictions")
+		assert.NotContains(t, stored, "extremes")
+		payload, ok := stored["payload"].(*types.AttributeValueMemberB)
+		require.True(t, ok)
+		assert.Less(t, len(payload.Value), 4096)
+
+		got, err := cache.GetPredictions(ctx, "TEST-001", date)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, record.Predictions, got.Predictions)
+		assert.Equal(t, record.Extremes, got.Extremes)
+		assert.Equal(t, models.StationKindReference, got.StationType)
+	})
+
+	t.Run("small records keep the original layout", func(t *testing.T) {
+		cache := NewDynamoPredictionCache(mock, &config.CacheConfig{TidePredictionDynamoTTLDays: 7, DynamoCompressMinBytes: 4096})
+		require.NoError(t, cache.SavePredictions(ctx, createTestPredictionRecord()))
+
+		assert.NotContains(t, stored, "format")
+		assert.Contains(t, stored, "predictions")
+	})
+
+	t.Run("negative threshold disables compression", func(t *testing.T) {
+		cache := NewDynamoPredictionCache(mock, &config.CacheConfig{TidePredictionDynamoTTLDays: 7, DynamoCompressMinBytes: -1})
+		require.NoError(t, cache.SavePredictions(ctx, createFullDayRecord()))
+
+		assert.NotContains(t, stored, "payload")
+		assert.Contains(t, stored, "predictions")
+	})
+
+	t.Run("legacy items are still readable", func(t *testing.T) {
+		record := createFullDayRecord()
+		record.TTL = time.Now().Add(time.Hour).Unix()
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		stored = item
+
+		cache := NewDynamoPredictionCache(mock, &config.CacheConfig{TidePredictionDynamoTTLDays: 7, DynamoCompressMinBytes: 0})
+		got, err := cache.GetPredictions(ctx, "TEST-001", date)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, record.Predictions, got.Predictions)
+	})
+
+	t.Run("unknown formats are rejected", func(t *testing.T) {
+		stored = map[string]types.AttributeValue{
+			"stationId": &types.AttributeValueMemberS{Value: "TEST-001"},
+			"date":      &types.AttributeValueMemberS{Value: "2024-01-01"},
+			"format":    &types.AttributeValueMemberN{Value: "3"},
+		}
+
+		cache := NewDynamoPredictionCache(mock, &config.CacheConfig{})
+		_, err := cache.GetPredictions(ctx, "TEST-001", date)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported record format")
+	})
+}
+
+func TestGetPredictionsBatch(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := &config.CacheConfig{MaxBatchRetries: 2, DynamoCompressMinBytes: -1}
+
+	itemFor := func(date string, ttl time.Duration) map[string]types.AttributeValue {
+		record := createFullDayRecord()
+		record.Date = date
+		record.TTL = time.Now().Add(ttl).Unix()
+		item, err := attributevalue.MarshalMap(record)
+		require.NoError(t, err)
+		return item
+	}
+
+	t.Run("one request for the whole range", func(t *testing.T) {
+		var calls int
+		mock := &mockDynamoDBClient{
+			batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+				calls++
+				assert.Len(t, params.RequestItems[tableName].Keys, 4)
+				return &dynamodb.BatchGetItemOutput{
+					Responses: map[string][]map[string]types.AttributeValue{
+						tableName: {
+							itemFor("2024-01-03", time.Hour),
+							itemFor("2024-01-01", time.Hour),
+							itemFor("2024-01-04", -time.Hour), // expired
+						},
+					},
+				}, nil
+			},
+		}
+
+		cache := NewDynamoPredictionCache(mock, cfg)
+		// Duplicate dates are requested once but filled in everywhere
+		dates := []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 3), start.AddDate(0, 0, 2), start}
+
+		records, err := cache.GetPredictionsBatch(ctx, "TEST-001", dates)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		require.Len(t, records, 5)
+		assert.Equal(t, "2024-01-01", records[0].Date)
+		assert.Nil(t, records[1])
+		assert.Nil(t, records[2])
+		assert.Equal(t, "2024-01-01", records[4].Date)
+		assert.Equal(t, "2024-01-03", records[3].Date)
+	})
+
+	t.Run("unprocessed keys are retried", func(t *testing.T) {
+		var calls int
+		mock := &mockDynamoDBClient{
+			batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+				calls++
+				keys := params.RequestItems[tableName].Keys
+				date := keys[0]["date"].(*types.AttributeValueMemberS).Value
+				output := &dynamodb.BatchGetItemOutput{
+					Responses: map[string][]map[string]types.AttributeValue{tableName: {itemFor(date, time.Hour)}},
+				}
+				if len(keys) > 1 {
+					output.UnprocessedKeys = map[string]types.KeysAndAttributes{tableName: {Keys: keys[1:]}}
+				}
+				return output, nil
+			},
+		}
+
+		cache := NewDynamoPredictionCache(mock, cfg)
+		records, err := cache.GetPredictionsBatch(ctx, "TEST-001", []time.Time{start, start.AddDate(0, 0, 1)})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NotNil(t, records[0])
+		assert.NotNil(t, records[1])
+	})
+
+	t.Run("requests are split at the key limit", func(t *testing.T) {
+		var sizes []int
+		mock := &mockDynamoDBClient{
+			batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+				sizes = append(sizes, len(params.RequestItems[tableName].Keys))
+				return &dynamodb.BatchGetItemOutput{}, nil
+			},
+		}
+
+		var dates []time.Time
+		for i := 0; i < 150; i++ {
+			dates = append(dates, start.AddDate(0, 0, i))
+		}
+
+		records, err := NewDynamoPredictionCache(mock, cfg).GetPredictionsBatch(ctx, "TEST-001", dates)
+		require.NoError(t, err)
+		assert.Len(t, records, 150)
+		assert.Equal(t, []int{100, 50}, sizes)
+	})
+
+	t.Run("errors are returned", func(t *testing.T) {
+		mock := &mockDynamoDBClient{
+			batchGetItemFunc: func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+				return nil, assert.AnError
+			},
+		}
+
+		_, err := NewDynamoPredictionCache(mock, cfg).GetPredictionsBatch(ctx, "TEST-001", []time.Time{start})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "batch getting predictions from DynamoDB")
+	})
+}
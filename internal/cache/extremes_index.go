@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	// ExtremesIndexStations is how many stations' extremes the index holds; the least
+	// recently used station is dropped beyond it
+	ExtremesIndexStations = 1000
+	// extremesIndexDays caps how many days of extremes the index holds per station
+	extremesIndexDays = 62
+)
+
+// ExtremesIndexer is implemented by prediction caches that index upcoming extremes, so a
+// "next high tide" lookup needn't read whole day records
+type ExtremesIndexer interface {
+	// NextExtremes returns the count extremes after after, or false when the index doesn't
+	// hold every day they could fall on. Days are dates in location.
+	NextExtremes(stationID string, after time.Time, count int, location *time.Location) ([]models.TideExtreme, bool)
+}
+
+// stationExtremes is one station's indexed extremes, sorted by time, and the local dates
+// they cover. A covered date may have no extremes.
+type stationExtremes struct {
+	extremes []models.TideExtreme
+	days     map[string]bool
+}
+
+// ExtremesIndex maps each recently cached station to its extremes sorted by time, built
+// from the day records saved to or read from the prediction cache. Finding the next
+// extremes is a binary search.
+type ExtremesIndex struct {
+	mu       sync.Mutex
+	stations *lru.Cache[string, *stationExtremes]
+}
+
+var _ ExtremesIndexer = (*ExtremesIndex)(nil)
+
+// NewExtremesIndex creates an index holding up to size stations
+func NewExtremesIndex(size int) (*ExtremesIndex, error) {
+	stations, err := lru.New[string, *stationExtremes](size)
+	if err != nil {
+		return nil, fmt.Errorf("creating extremes index: %w", err)
+	}
+	return &ExtremesIndex{stations: stations}, nil
+}
+
+// Add indexes a day record's extremes unless its day already is; predictions for a day
+// don't change. Days before yesterday, which no lookup from now on can need, are dropped,
+// and a station already holding extremesIndexDays days takes no more until they are.
+func (x *ExtremesIndex) Add(record *models.TidePredictionRecord, now time.Time) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	// Dates are local to the station, which may be a day behind or ahead of UTC
+	cutoff := now.UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	if record.Date < cutoff {
+		return
+	}
+
+	station, ok := x.stations.Get(record.StationID)
+	if !ok {
+		station = &stationExtremes{days: map[string]bool{}}
+		x.stations.Add(record.StationID, station)
+	}
+	if station.days[record.Date] {
+		return
+	}
+
+	for day := range station.days {
+		if day < cutoff {
+			delete(station.days, day)
+		}
+	}
+	cutoffMillis := models.Millis(now.AddDate(0, 0, -2).UnixMilli())
+	first := sort.Search(len(station.extremes), func(i int) bool { return station.extremes[i].Timestamp >= cutoffMillis })
+	station.extremes = station.extremes[first:]
+	if len(station.days) >= extremesIndexDays {
+		return
+	}
+
+	extremes := slices.Concat(station.extremes, record.Extremes)
+	slices.SortStableFunc(extremes, func(a, b models.TideExtreme) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+	station.extremes = extremes
+	station.days[record.Date] = true
+}
+
+// Remove drops a station's extremes, e.g. when one of its days is invalidated
+func (x *ExtremesIndex) Remove(stationID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.stations.Remove(stationID)
+}
+
+// Purge drops every station's extremes
+func (x *ExtremesIndex) Purge() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.stations.Purge()
+}
+
+// NextExtremes finds the first extreme after after with a binary search
+func (x *ExtremesIndex) NextExtremes(stationID string, after time.Time, count int, location *time.Location) ([]models.TideExtreme, bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	station, ok := x.stations.Get(stationID)
+	if !ok || count <= 0 {
+		return nil, false
+	}
+
+	afterMillis := models.Millis(after.UnixMilli())
+	first := sort.Search(len(station.extremes), func(i int) bool { return station.extremes[i].Timestamp > afterMillis })
+	if first+count > len(station.extremes) {
+		return nil, false
+	}
+	next := station.extremes[first : first+count]
+
+	// Every day from after's to the last extreme's must be indexed, or an extreme on a
+	// missing day could come first
+	last := next[len(next)-1].Timestamp.In(location)
+	for day := startOfLocalDay(after.In(location)); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if !station.days[day.Format("2006-01-02")] {
+			return nil, false
+		}
+	}
+	return append([]models.TideExtreme(nil), next...), true
+}
+
+func startOfLocalDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
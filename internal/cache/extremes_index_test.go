@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dayOfExtremes returns a record for the day starting at day with a high at 05:00 and a
+// low at 11:00
+func dayOfExtremes(stationID string, day time.Time) *models.TidePredictionRecord {
+	high, low := day.Add(5*time.Hour), day.Add(11*time.Hour)
+	return &models.TidePredictionRecord{
+		StationID:   stationID,
+		Date:        day.Format("2006-01-02"),
+		StationType: "R",
+		Extremes: []models.TideExtreme{
+			{Type: models.TideTypeLow, Timestamp: models.MillisOf(low), Height: -0.2},
+			{Type: models.TideTypeHigh, Timestamp: models.MillisOf(high), Height: 3.1},
+		},
+	}
+}
+
+func TestExtremesIndex(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	today := time.Date(2024, 6, 1, 0, 0, 0, 0, location)
+	now := today.Add(8 * time.Hour)
+
+	index, err := NewExtremesIndex(10)
+	require.NoError(t, err)
+
+	_, ok := index.NextExtremes("9447130", now, 1, location)
+	assert.False(t, ok, "nothing is indexed")
+
+	index.Add(dayOfExtremes("9447130", today), now)
+	index.Add(dayOfExtremes("9447130", today.AddDate(0, 0, 2)), now)
+
+	next, ok := index.NextExtremes("9447130", now, 1, location)
+	require.True(t, ok)
+	assert.Equal(t, models.TideTypeLow, next[0].Type, "extremes are sorted and those before now skipped")
+	assert.Equal(t, today.Add(11*time.Hour).UnixMilli(), int64(next[0].Timestamp))
+
+	_, ok = index.NextExtremes("9447130", now, 2, location)
+	assert.False(t, ok, "the next extreme could fall on the missing day")
+
+	index.Add(dayOfExtremes("9447130", today.AddDate(0, 0, 1)), now)
+	next, ok = index.NextExtremes("9447130", now, 4, location)
+	require.True(t, ok)
+	require.Len(t, next, 4)
+	assert.Equal(t, today.AddDate(0, 0, 2).Add(5*time.Hour).UnixMilli(), int64(next[3].Timestamp))
+
+	_, ok = index.NextExtremes("9447130", now, 6, location)
+	assert.False(t, ok, "there aren't that many indexed")
+	_, ok = index.NextExtremes("8454000", now, 1, location)
+	assert.False(t, ok)
+
+	index.Remove("9447130")
+	_, ok = index.NextExtremes("9447130", now, 1, location)
+	assert.False(t, ok)
+}
+
+func TestExtremesIndex_DropsPastDays(t *testing.T) {
+	today := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	index, err := NewExtremesIndex(10)
+	require.NoError(t, err)
+
+	index.Add(dayOfExtremes("9447130", today.AddDate(0, 0, -5)), today)
+	station, ok := index.stations.Get("9447130")
+	assert.False(t, ok, "a past day isn't indexed")
+	assert.Nil(t, station)
+
+	for day := 0; day < extremesIndexDays+5; day++ {
+		index.Add(dayOfExtremes("9447130", today.AddDate(0, 0, day)), today)
+	}
+	station, ok = index.stations.Get("9447130")
+	require.True(t, ok)
+	assert.Len(t, station.days, extremesIndexDays, "days beyond the cap aren't indexed")
+
+	later := today.AddDate(0, 0, 3)
+	index.Add(dayOfExtremes("9447130", later.AddDate(0, 0, extremesIndexDays)), later)
+	assert.Len(t, station.days, extremesIndexDays-1, "past days are dropped as days are added")
+	assert.GreaterOrEqual(t, station.extremes[0].Timestamp, models.MillisOf(later.AddDate(0, 0, -2)))
+}
+
+func TestLRUCacheService_NextExtremes(t *testing.T) {
+	cfg := &config.CacheConfig{TidePredictionLRUSize: 100, TidePredictionLRUTTLMinutes: 15}
+	service := createTestCacheService(t, cfg)
+	now := service.clock.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	for day := 0; day < 3; day++ {
+		require.NoError(t, service.SavePredictions(context.Background(), *dayOfExtremes("9447130", today.AddDate(0, 0, day))))
+	}
+	next, ok := service.NextExtremes("9447130", now, 3, time.UTC)
+	require.True(t, ok)
+	assert.Len(t, next, 3)
+
+	service.Clear()
+	_, ok = service.NextExtremes("9447130", now, 3, time.UTC)
+	assert.False(t, ok)
+}
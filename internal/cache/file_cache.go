@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// FilePredictionCache stores tide predictions as JSON files, one per station and day,
+// so local development works without AWS credentials or DynamoDB Local
+type FilePredictionCache struct {
+	dir    string
+	config atomic.Pointer[config.CacheConfig]
+	clock  clock
+}
+
+func NewFilePredictionCache(dir string, cacheConfig *config.CacheConfig) *FilePredictionCache {
+	if cacheConfig == nil {
+		cacheConfig = config.GetCacheConfig()
+	}
+	c := &FilePredictionCache{
+		dir:   filepath.Join(dir, "predictions"),
+		clock: &systemClock{},
+	}
+	c.config.Store(cacheConfig)
+	return c
+}
+
+// SetConfig replaces the TTLs used by later saves
+func (c *FilePredictionCache) SetConfig(cacheConfig *config.CacheConfig) {
+	c.config.Store(cacheConfig)
+}
+
+func (c *FilePredictionCache) Name() string {
+	return config.BackendFile
+}
+
+// GetPredictions retrieves cached predictions for a station and date
+func (c *FilePredictionCache) GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+	var record models.TidePredictionRecord
+	found, err := readJSONFile(c.path(stationID, date.Format("2006-01-02")), &record)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	if c.clock.Now().Unix() >= record.TTL {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// GetPredictionsBatch retrieves cached predictions for several dates, returning one entry
+// per date with nil for misses
+func (c *FilePredictionCache) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	records := make([]*models.TidePredictionRecord, len(dates))
+	for i, date := range dates {
+		record, err := c.GetPredictions(ctx, stationID, date)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// SavePredictions saves predictions to the cache
+func (c *FilePredictionCache) SavePredictions(ctx context.Context, record models.TidePredictionRecord) error {
+	return c.SavePredictionsBatch(ctx, []models.TidePredictionRecord{record})
+}
+
+// SavePredictionsBatch saves multiple prediction records to the cache
+func (c *FilePredictionCache) SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error {
+	now := c.clock.Now()
+	for _, record := range records {
+		if err := record.Validate(); err != nil {
+			return fmt.Errorf("invalid prediction record: %w", err)
+		}
+
+		record.LastUpdated = now.Unix()
+		record.TTL = now.Add(c.config.Load().GetPredictionTTL(record.Date, now)).Unix()
+		if err := writeJSONFile(c.path(record.StationID, record.Date), record); err != nil {
+			return fmt.Errorf("saving predictions to file: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeletePredictions removes the cached record for a station and date, if any
+func (c *FilePredictionCache) DeletePredictions(ctx context.Context, stationID string, date time.Time) error {
+	err := os.Remove(c.path(stationID, date.Format("2006-01-02")))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting cache file: %w", err)
+	}
+	return nil
+}
+
+func (c *FilePredictionCache) path(stationID, date string) string {
+	return filepath.Join(c.dir, safeFileName(stationID), date+".json")
+}
+
+// FileStationCache stores a source's station list as a JSON file
+type FileStationCache struct {
+	path  string
+	ttl   time.Duration
+	clock clock
+}
+
+var _ StationListCacheProvider = (*FileStationCache)(nil)
+
+func NewFileStationCache(dir string, source models.Source, cacheConfig *config.CacheConfig) *FileStationCache {
+	if cacheConfig == nil {
+		cacheConfig = config.GetCacheConfig()
+	}
+	return &FileStationCache{
+		path:  filepath.Join(dir, "stations", strings.ToLower(string(source))+".json"),
+		ttl:   cacheConfig.GetStationListTTLForSource(string(source)),
+		clock: &systemClock{},
+	}
+}
+
+// GetStations returns the cached stations, or nil if there are none or they have expired
+func (c *FileStationCache) GetStations(ctx context.Context) ([]models.Station, error) {
+	var record StationListCacheRecord
+	found, err := readJSONFile(c.path, &record)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	if c.clock.Now().Unix() > record.TTL {
+		log.Debug().Str("path", c.path).Msg("Station list file cache expired")
+		return nil, nil
+	}
+	return record.Stations, nil
+}
+
+// SaveStations writes the stations to the cache file
+func (c *FileStationCache) SaveStations(ctx context.Context, stations []models.Station) error {
+	now := c.clock.Now().Unix()
+	record := StationListCacheRecord{
+		Stations:    stations,
+		LastUpdated: now,
+		TTL:         now + int64(c.ttl.Seconds()),
+	}
+	if err := writeJSONFile(c.path, record); err != nil {
+		return fmt.Errorf("saving stations to file: %w", err)
+	}
+	return nil
+}
+
+// readJSONFile decodes path into v, reporting false if the file doesn't exist
+func readJSONFile(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading cache file: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("decoding cache file %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeJSONFile writes v to path through a temp file so readers never see a partial write
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding cache file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing temp file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// safeFileName keeps IDs from escaping the cache directory
+func safeFileName(name string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(name)
+}
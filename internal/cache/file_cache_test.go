@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilePredictionCache(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.CacheConfig{TidePredictionDynamoTTLDays: 1}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := NewFilePredictionCache(dir, cfg)
+	cache.clock = &fakeClock{now: now}
+	assert.Equal(t, "file", cache.Name())
+
+	ctx := context.Background()
+
+	// Nothing cached yet
+	record, err := cache.GetPredictions(ctx, "9447130", now)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	saved := createTestRecord("9447130", "2024-01-01")
+	require.NoError(t, cache.SavePredictions(ctx, saved))
+	assert.FileExists(t, filepath.Join(dir, "predictions", "9447130", "2024-01-01.json"))
+
+	record, err = cache.GetPredictions(ctx, "9447130", now)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, saved.Predictions, record.Predictions)
+	assert.Equal(t, saved.Extremes, record.Extremes)
+
+	// Expired records are misses
+	cache.clock = &fakeClock{now: now.Add(25 * time.Hour)}
+	record, err = cache.GetPredictions(ctx, "9447130", now)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	// Invalid records are rejected before anything is written
+	err = cache.SavePredictionsBatch(ctx, []models.TidePredictionRecord{{StationID: "9447130"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid prediction record")
+
+	// Corrupt files surface as errors rather than misses
+	path := filepath.Join(dir, "predictions", "9447130", "2024-01-02.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o644))
+	_, err = cache.GetPredictions(ctx, "9447130", now.AddDate(0, 0, 1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decoding cache file")
+}
+
+func TestFilePredictionCache_StationIDCannotEscapeDirectory(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewFilePredictionCache(dir, &config.CacheConfig{TidePredictionDynamoTTLDays: 1})
+
+	require.NoError(t, cache.SavePredictions(context.Background(), createTestRecord("../../etc", "2024-01-01")))
+
+	matches, err := filepath.Glob(filepath.Join(dir, "predictions", "*", "2024-01-01.json"))
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+}
+
+func TestFileStationCache(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.CacheConfig{
+		StationListTTLDays:         1,
+		StationListTTLDaysBySource: map[string]int{"CHS": 3},
+	}
+	now := time.Now()
+	ctx := context.Background()
+
+	noaa := NewFileStationCache(dir, models.SourceNOAA, cfg)
+	noaa.clock = &mockClock{now: now}
+	chs := NewFileStationCache(dir, models.SourceCHS, cfg)
+	chs.clock = &mockClock{now: now}
+
+	stations, err := noaa.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stations)
+
+	require.NoError(t, noaa.SaveStations(ctx, createTestStations()))
+	assert.FileExists(t, filepath.Join(dir, "stations", "noaa.json"))
+
+	stations, err = noaa.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, createTestStations(), stations)
+
+	// Sources are stored separately
+	stations, err = chs.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stations)
+
+	// Each source expires on its own TTL
+	require.NoError(t, chs.SaveStations(ctx, createTestStations()))
+	noaa.clock = &mockClock{now: now.Add(48 * time.Hour)}
+	chs.clock = &mockClock{now: now.Add(48 * time.Hour)}
+
+	stations, err = noaa.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stations)
+
+	stations, err = chs.GetStations(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, stations)
+}
+
+func TestNewPredictionStore_File(t *testing.T) {
+	store, err := NewPredictionStore(context.Background(), &config.CacheConfig{Backend: "file", FileCacheDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, "file", store.Name())
+}
+
+func TestNewPredictionStore_Memory(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewPredictionStore(ctx, &config.CacheConfig{Backend: "memory"})
+	require.NoError(t, err)
+	assert.Equal(t, "memory", store.Name())
+
+	date := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.SavePredictions(ctx, models.TidePredictionRecord{StationID: "9447130", Date: "2025-01-01"}))
+	record, err := store.GetPredictions(ctx, "9447130", date)
+	require.NoError(t, err)
+	assert.Nil(t, record, "nothing is kept behind the LRU")
+	records, err := store.GetPredictionsBatch(ctx, "9447130", []time.Time{date, date.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	assert.Equal(t, []*models.TidePredictionRecord{nil, nil}, records)
+}
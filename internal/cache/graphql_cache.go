@@ -1,36 +1,52 @@
 package cache
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"github.com/bbernstein/flowebb-go/internal/config"
 	"github.com/hashicorp/golang-lru/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
 	"sync"
 	"time"
 )
 
 type GraphQLCacheEntry struct {
-	Data      string // Store the actual query string
+	Data      string // Store the actual query string, or a response's body
 	ExpiresAt time.Time
+	// Stations are the stations a response's data came from; refreshing any of their
+	// predictions invalidates it
+	Stations []string
 }
 
+// GraphQLCache holds parsed queries and whole query responses. Responses are kept for the
+// TTL their data allows, capped at the cache's own, and dropped as soon as the predictions
+// of a station they show are refreshed.
 type GraphQLCache struct {
 	lru   *lru.Cache[string, *GraphQLCacheEntry]
 	ttl   time.Duration
 	clock clock
-	mu    sync.RWMutex
+	mu    sync.Mutex
+	// byStation indexes the keys of cached responses by the stations they show
+	byStation map[string]map[string]struct{}
 }
 
 func NewGraphQLCache(cfg *config.CacheConfig) (*GraphQLCache, error) {
-	lruCache, err := lru.New[string, *GraphQLCacheEntry](cfg.GraphQLLRUSize)
+	c := &GraphQLCache{
+		ttl:       cfg.GetGraphQLLRUTTL(),
+		clock:     &systemClock{},
+		byStation: make(map[string]map[string]struct{}),
+	}
+	lruCache, err := lru.NewWithEvict[string, *GraphQLCacheEntry](cfg.GraphQLLRUSize, c.onEvict)
 	if err != nil {
 		return nil, err
 	}
-
-	return &GraphQLCache{
-		lru:   lruCache,
-		ttl:   cfg.GetGraphQLLRUTTL(),
-		clock: &systemClock{},
-	}, nil
+	c.lru = lruCache
+	return c, nil
 }
 
 func (c *GraphQLCache) Add(_ context.Context, key string, value interface{}) {
@@ -46,8 +62,71 @@ func (c *GraphQLCache) Add(_ context.Context, key string, value interface{}) {
 }
 
 func (c *GraphQLCache) Get(_ context.Context, key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	return c.get(key)
+}
+
+// ResponseKey identifies a query's response by the query with its formatting and comments
+// normalized away, the operation and the variables, so the same query sent by two clients
+// is the same key. A query that doesn't parse is keyed as sent.
+func ResponseKey(query, operationName string, variables map[string]interface{}) string {
+	if doc, err := parser.ParseQuery(&ast.Source{Input: query}); err == nil {
+		var normalized bytes.Buffer
+		formatter.NewFormatter(&normalized).FormatQueryDocument(doc)
+		query = normalized.String()
+	}
+	// Maps marshal with sorted keys, so the order variables were sent in doesn't matter
+	vars, _ := json.Marshal(variables)
+	sum := sha256.Sum256([]byte(query + "\x00" + operationName + "\x00" + string(vars)))
+	return "response:" + hex.EncodeToString(sum[:])
+}
+
+// GetResponse returns the cached response body for key, a ResponseKey
+func (c *GraphQLCache) GetResponse(key string) (string, bool) {
+	data, ok := c.get(key)
+	if !ok {
+		return "", false
+	}
+	return data.(string), true
+}
+
+// AddResponse caches a response body for ttl, or the cache's TTL if that's shorter, and
+// until the predictions of any of stations are refreshed
+func (c *GraphQLCache) AddResponse(key, body string, ttl time.Duration, stations []string) {
+	ttl = min(ttl, c.ttl)
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Remove any previous response first so it leaves the station index through onEvict
+	c.lru.Remove(key)
+	c.lru.Add(key, &GraphQLCacheEntry{
+		Data:      body,
+		ExpiresAt: c.clock.Now().Add(ttl),
+		Stations:  stations,
+	})
+	for _, station := range stations {
+		if c.byStation[station] == nil {
+			c.byStation[station] = make(map[string]struct{})
+		}
+		c.byStation[station][key] = struct{}{}
+	}
+}
+
+// InvalidateStation drops every cached response showing the station
+func (c *GraphQLCache) InvalidateStation(stationID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.byStation[stationID] {
+		c.lru.Remove(key)
+	}
+}
+
+func (c *GraphQLCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	entry, ok := c.lru.Get(key)
 	if !ok {
@@ -62,6 +141,17 @@ func (c *GraphQLCache) Get(_ context.Context, key string) (interface{}, bool) {
 	return entry.Data, true
 }
 
+// onEvict takes a dropped response out of the station index. The LRU calls it from within
+// the calls above, which already hold mu.
+func (c *GraphQLCache) onEvict(key string, entry *GraphQLCacheEntry) {
+	for _, station := range entry.Stations {
+		delete(c.byStation[station], key)
+		if len(c.byStation[station]) == 0 {
+			delete(c.byStation, station)
+		}
+	}
+}
+
 func (c *GraphQLCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -169,3 +169,72 @@ func TestLRUCacheService_GraphQLCacheClear(t *testing.T) {
 	_, hit2 = cache.Get(context.Background(), "key2")
 	assert.False(t, hit2)
 }
+
+func TestResponseKey(t *testing.T) {
+	key := ResponseKey(`query Tides($id: ID!) { tides(stationId: $id, startDateTime: "now", endDateTime: "+24h") { stationId } }`,
+		"Tides", map[string]interface{}{"id": "9447130"})
+
+	reformatted := ResponseKey(`
+		# the dashboard's query
+		query Tides($id: ID!) {
+			tides(stationId: $id, startDateTime: "now", endDateTime: "+24h") {
+				stationId
+			}
+		}`, "Tides", map[string]interface{}{"id": "9447130"})
+	assert.Equal(t, key, reformatted, "formatting and comments don't change the key")
+
+	assert.NotEqual(t, key, ResponseKey(`query Tides($id: ID!) { tides(stationId: $id, startDateTime: "now", endDateTime: "+24h") { stationId } }`,
+		"Tides", map[string]interface{}{"id": "9414290"}), "variables do")
+	assert.NotEqual(t, key, ResponseKey(`query Tides($id: ID!) { tides(stationId: $id, startDateTime: "now", endDateTime: "+24h") { stationId } }`,
+		"Other", map[string]interface{}{"id": "9447130"}), "and so does the operation")
+
+	assert.Equal(t, ResponseKey("{ not valid", "", map[string]interface{}{"a": 1, "b": 2}),
+		ResponseKey("{ not valid", "", map[string]interface{}{"b": 2, "a": 1}), "unparseable queries are keyed as sent")
+}
+
+func TestGraphQLCache_Responses(t *testing.T) {
+	cache, err := NewGraphQLCache(&config.CacheConfig{GraphQLLRUSize: 2, GraphQLLRUTTLMinutes: 15})
+	require.NoError(t, err)
+	clock := &mockClock{now: time.Now()}
+	cache.clock = clock
+
+	cache.AddResponse("seattle", `{"data":1}`, time.Minute, []string{"9447130"})
+	cache.AddResponse("both", `{"data":2}`, time.Hour, []string{"9447130", "9414290"})
+	cache.AddResponse("never", `{"data":3}`, 0, nil)
+
+	body, ok := cache.GetResponse("seattle")
+	require.True(t, ok)
+	assert.Equal(t, `{"data":1}`, body)
+	_, ok = cache.GetResponse("never")
+	assert.False(t, ok, "a TTL of zero isn't cached")
+
+	clock.now = clock.now.Add(2 * time.Minute)
+	_, ok = cache.GetResponse("seattle")
+	assert.False(t, ok, "responses expire with their own TTL")
+	_, ok = cache.GetResponse("both")
+	assert.True(t, ok)
+
+	clock.now = clock.now.Add(14 * time.Minute)
+	_, ok = cache.GetResponse("both")
+	assert.False(t, ok, "TTLs are capped by the cache's")
+}
+
+func TestGraphQLCache_InvalidateStation(t *testing.T) {
+	cache, err := NewGraphQLCache(&config.CacheConfig{GraphQLLRUSize: 2, GraphQLLRUTTLMinutes: 15})
+	require.NoError(t, err)
+
+	cache.AddResponse("seattle", `{"data":1}`, time.Hour, []string{"9447130"})
+	cache.AddResponse("sf", `{"data":2}`, time.Hour, []string{"9414290"})
+	cache.InvalidateStation("9447130")
+
+	_, ok := cache.GetResponse("seattle")
+	assert.False(t, ok)
+	_, ok = cache.GetResponse("sf")
+	assert.True(t, ok)
+
+	// Evicting a response takes it out of the station index
+	cache.AddResponse("boston", `{"data":3}`, time.Hour, []string{"8443970"})
+	cache.AddResponse("battery", `{"data":4}`, time.Hour, []string{"8518750"})
+	assert.NotContains(t, cache.byStation, "9414290")
+	assert.Len(t, cache.byStation, 2)
+}
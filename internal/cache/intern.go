@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// interner hands out one copy of each distinct value, so the thousands of stations in a
+// list share the few dozen states, regions, zones and capability sets between them
+type interner struct {
+	strings  map[string]string
+	pointers map[string]*string
+	lists    map[string][]string
+}
+
+func newInterner() *interner {
+	return &interner{strings: map[string]string{}, pointers: map[string]*string{}, lists: map[string][]string{}}
+}
+
+func (in *interner) string(s string) string {
+	if interned, ok := in.strings[s]; ok {
+		return interned
+	}
+	in.strings[s] = s
+	return s
+}
+
+func (in *interner) pointer(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	if interned, ok := in.pointers[*s]; ok {
+		return interned
+	}
+	interned := in.string(*s)
+	in.pointers[interned] = &interned
+	return &interned
+}
+
+// list interns a list of strings. The list is clipped, so appending to it copies it rather
+// than writing over the stations sharing it.
+func (in *interner) list(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	key := strings.Join(list, "\x00")
+	if interned, ok := in.lists[key]; ok {
+		return interned
+	}
+	interned := make([]string, len(list))
+	for i, s := range list {
+		interned[i] = in.string(s)
+	}
+	interned = slices.Clip(interned)
+	in.lists[key] = interned
+	return interned
+}
+
+// compactStations copies stations into a slice of its own whose repeated values are
+// interned. IDs and names are nearly all distinct, so they're kept as they are.
+func compactStations(stations []models.Station) []models.Station {
+	in := newInterner()
+	compact := make([]models.Station, len(stations))
+	for i, s := range stations {
+		s.State = in.pointer(s.State)
+		s.Region = in.pointer(s.Region)
+		s.Level = in.pointer(s.Level)
+		s.Source = models.Source(in.string(string(s.Source)))
+		s.TimeZone = in.string(s.TimeZone)
+		s.StationType = models.StationKind(in.string(string(s.StationType)))
+		s.Capabilities = in.list(s.Capabilities)
+		compact[i] = s
+	}
+	return compact
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog/log"
+)
+
+// lazy creates a client the first time it's used rather than when the function starts,
+// so a cold start whose requests are answered from memory never loads the AWS
+// configuration
+type lazy[C any] struct {
+	name   string
+	create func(ctx context.Context) (C, error)
+
+	once   sync.Once
+	client C
+	err    error
+}
+
+func (l *lazy[C]) get(ctx context.Context) (C, error) {
+	l.once.Do(func() {
+		start := time.Now()
+		// The client outlives the request that happens to create it
+		l.client, l.err = l.create(context.WithoutCancel(ctx))
+		event := log.Debug()
+		if l.err != nil {
+			event = log.Error().Err(l.err)
+		}
+		event.Str("client", l.name).Dur("elapsed", time.Since(start)).Msg("Created client")
+	})
+	return l.client, l.err
+}
+
+// LazyDynamoClient creates a DynamoDB client on first use. If that fails, every call
+// returns the error.
+type LazyDynamoClient struct {
+	lazy[DynamoDBClient]
+}
+
+var _ DynamoDBClient = (*LazyDynamoClient)(nil)
+
+// NewLazyDynamoClient returns a client that calls create, named name in the logs, the
+// first time it's used
+func NewLazyDynamoClient(name string, create func(ctx context.Context) (DynamoDBClient, error)) *LazyDynamoClient {
+	return &LazyDynamoClient{lazy[DynamoDBClient]{name: name, create: create}}
+}
+
+func (c *LazyDynamoClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetItem(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.BatchGetItem(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.PutItem(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.UpdateItem(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.BatchWriteItem(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.DeleteItem(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Query(ctx, params, optFns...)
+}
+
+func (c *LazyDynamoClient) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListTables(ctx, params, optFns...)
+}
+
+// LazyS3Client creates an S3 client on first use. If that fails, every call returns the
+// error.
+type LazyS3Client struct {
+	lazy[S3Client]
+}
+
+var _ S3Client = (*LazyS3Client)(nil)
+
+// NewLazyS3Client returns a client that calls create, named name in the logs, the first
+// time it's used
+func NewLazyS3Client(name string, create func(ctx context.Context) (S3Client, error)) *LazyS3Client {
+	return &LazyS3Client{lazy[S3Client]{name: name, create: create}}
+}
+
+func (c *LazyS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetObject(ctx, params, optFns...)
+}
+
+func (c *LazyS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	client, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.PutObject(ctx, params, optFns...)
+}
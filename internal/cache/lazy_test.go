@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyDynamoClient(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		created int
+		gets    int
+	)
+	client := NewLazyDynamoClient("test", func(ctx context.Context) (DynamoDBClient, error) {
+		assert.NoError(t, ctx.Err(), "creation outlives the request's context")
+		mu.Lock()
+		defer mu.Unlock()
+		created++
+		return &mockDynamoDBClient{
+			getItemFunc: func(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				gets++
+				return &dynamodb.GetItemOutput{}, nil
+			},
+		}, nil
+	})
+	assert.Zero(t, created, "nothing is created until the client is used")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetItem(ctx, &dynamodb.GetItemInput{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 10, gets)
+}
+
+func TestLazyS3Client_Error(t *testing.T) {
+	created := 0
+	failure := errors.New("no credentials")
+	client := NewLazyS3Client("test", func(context.Context) (S3Client, error) {
+		created++
+		return nil, failure
+	})
+
+	_, err := client.GetObject(context.Background(), &s3.GetObjectInput{})
+	require.ErrorIs(t, err, failure)
+	_, err = client.PutObject(context.Background(), &s3.PutObjectInput{})
+	require.ErrorIs(t, err, failure)
+	assert.Equal(t, 1, created, "a failed creation isn't retried")
+}
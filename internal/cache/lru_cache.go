@@ -7,7 +7,9 @@ import (
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/hashicorp/golang-lru/v2"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 )
 
 type clock interface {
@@ -24,44 +26,89 @@ func (s *systemClock) Now() time.Time {
 type LRUCacheEntry struct {
 	Data      *models.TidePredictionRecord
 	ExpiresAt time.Time
+	Size      int64 // Approximate bytes held by Data
 }
 
 type CacheService interface {
 	GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error)
+	// GetPredictionsBatch returns one entry per date, nil where nothing is cached
+	GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error)
 	SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error
 }
 
-// LRUCacheService provides a two-layer caching system using LRU and DynamoDB
+// LRUCacheService provides a two-layer caching system using LRU and a shared PredictionStore
 type LRUCacheService struct {
-	lru          *lru.Cache[string, *LRUCacheEntry]
-	dynamoCache  *DynamoPredictionCache
-	ttl          time.Duration
-	clock        clock
-	statsMutex   sync.RWMutex
-	lruHits      uint64
-	lruMisses    uint64
-	dynamoHits   uint64
-	dynamoMisses uint64
-}
-
-// NewCacheService creates a new cache service with both LRU and DynamoDB caching
+	lru         *lru.Cache[string, *LRUCacheEntry]
+	store       PredictionStore
+	ttl         atomic.Int64 // time.Duration
+	clock       clock
+	statsMutex  sync.RWMutex
+	lruHits     uint64
+	lruMisses   uint64
+	storeHits   uint64
+	storeMisses uint64
+
+	// Byte-size bounding: entries are evicted oldest-first once usedBytes exceeds maxBytes
+	maxBytes      int64
+	usedBytes     atomic.Int64
+	sizeEvictions atomic.Uint64
+	addMutex      sync.Mutex
+
+	// extremes indexes the extremes of every record that passes through, including those
+	// too large for the LRU; nil indexes nothing
+	extremes *ExtremesIndex
+
+	refreshMutex sync.RWMutex
+	onRefresh    []func(stationID string)
+}
+
+var (
+	_ ExtremesIndexer = (*LRUCacheService)(nil)
+	_ RefreshNotifier = (*LRUCacheService)(nil)
+)
+
+// RefreshNotifier is a prediction cache that says when a station's cached predictions are
+// replaced, so anything built from them can be dropped
+type RefreshNotifier interface {
+	// OnRefresh registers fn to be called with the station whenever a record it already
+	// held for the station is replaced or invalidated. First fills aren't refreshes.
+	OnRefresh(fn func(stationID string))
+}
+
+// NewCacheService creates a new cache service with LRU caching in front of the configured store
 func NewCacheService(ctx context.Context, config *config.CacheConfig) (*LRUCacheService, error) {
-	lruCache, err := lru.New[string, *LRUCacheEntry](config.TidePredictionLRUSize)
+	service := &LRUCacheService{
+		clock:    &systemClock{},
+		maxBytes: config.GetTidePredictionLRUMaxBytes(),
+	}
+	service.ttl.Store(int64(config.GetTidePredictionLRUTTL()))
+
+	lruCache, err := lru.NewWithEvict[string, *LRUCacheEntry](config.TidePredictionLRUSize, service.onEvict)
 	if err != nil {
 		return nil, fmt.Errorf("creating LRU cache: %w", err)
 	}
+	service.lru = lruCache
 
-	dynamoClient, err := NewDynamoClient(ctx)
+	if service.extremes, err = NewExtremesIndex(ExtremesIndexStations); err != nil {
+		return nil, err
+	}
+
+	store, err := NewPredictionStore(ctx, config)
 	if err != nil {
-		return nil, fmt.Errorf("creating DynamoDB client: %w", err)
+		return nil, fmt.Errorf("creating prediction store: %w", err)
 	}
+	service.store = store
 
-	return &LRUCacheService{
-		lru:         lruCache,
-		dynamoCache: NewDynamoPredictionCache(dynamoClient, config),
-		ttl:         config.GetTidePredictionLRUTTL(),
-		clock:       &systemClock{},
-	}, nil
+	return service, nil
+}
+
+// SetConfig applies a reloaded configuration's TTLs to records cached from now on. The LRU's
+// size and memory budget stay as they were created.
+func (c *LRUCacheService) SetConfig(cacheConfig *config.CacheConfig) {
+	c.ttl.Store(int64(cacheConfig.GetTidePredictionLRUTTL()))
+	if store, ok := c.store.(Reconfigurable); ok {
+		store.SetConfig(cacheConfig)
+	}
 }
 
 // getCacheKey generates a unique cache key for a station and date string
@@ -69,13 +116,14 @@ func getCacheKey(stationID string, date string) string {
 	return fmt.Sprintf("%s:%s", stationID, date)
 }
 
-// GetPredictions tries to get predictions first from LRU cache, then from DynamoDB
+// GetPredictions tries to get predictions first from LRU cache, then from the prediction store
 func (c *LRUCacheService) GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
 	// Try LRU cache
 	key := getCacheKey(stationID, date.Format("2006-01-02"))
 	if entry, ok := c.lru.Get(key); ok {
 		if entry.ExpiresAt.After(c.clock.Now()) {
 			c.incrementLRUHits()
+			c.indexExtremes(entry.Data)
 			return entry.Data, nil
 		} else {
 			c.lru.Remove(key)
@@ -84,26 +132,69 @@ func (c *LRUCacheService) GetPredictions(ctx context.Context, stationID string,
 
 	c.incrementLRUMisses()
 
-	// Try DynamoDB cache
-	record, err := c.dynamoCache.GetPredictions(ctx, stationID, date)
+	// Try the prediction store
+	record, err := c.store.GetPredictions(ctx, stationID, date)
 	if err != nil {
-		return nil, fmt.Errorf("getting predictions from DynamoDB: %w", err)
+		return nil, fmt.Errorf("getting predictions from %s: %w", c.store.Name(), err)
 	}
 
 	if record != nil {
-		c.incrementDynamoHits()
-		// Save to LRU cache
-		if err := c.SavePredictions(ctx, *record); err != nil {
-			return nil, fmt.Errorf("saving predictions to LRU cache: %w", err)
-		}
+		c.incrementStoreHits()
+		// Only the LRU needs it; saving it back to the store would reset its TTL
+		c.addEntry(key, record)
 		return record, nil
 	}
-	c.incrementDynamoMisses()
+	c.incrementStoreMisses()
 
 	return nil, nil
 }
 
-// SavePredictions saves predictions to both LRU and DynamoDB caches
+// GetPredictionsBatch looks each date up in the LRU cache and fetches all the misses from
+// the prediction store in a single call. Records found in the store are added to the LRU.
+func (c *LRUCacheService) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	records := make([]*models.TidePredictionRecord, len(dates))
+
+	var missingDates []time.Time
+	var missingIndexes []int
+	for i, date := range dates {
+		key := getCacheKey(stationID, date.Format("2006-01-02"))
+		if entry, ok := c.lru.Get(key); ok {
+			if entry.ExpiresAt.After(c.clock.Now()) {
+				c.incrementLRUHits()
+				c.indexExtremes(entry.Data)
+				records[i] = entry.Data
+				continue
+			}
+			c.lru.Remove(key)
+		}
+		c.incrementLRUMisses()
+		missingDates = append(missingDates, date)
+		missingIndexes = append(missingIndexes, i)
+	}
+
+	if len(missingDates) == 0 {
+		return records, nil
+	}
+
+	stored, err := c.store.GetPredictionsBatch(ctx, stationID, missingDates)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions from %s: %w", c.store.Name(), err)
+	}
+
+	for j, record := range stored {
+		if record == nil {
+			c.incrementStoreMisses()
+			continue
+		}
+		c.incrementStoreHits()
+		c.addEntry(getCacheKey(record.StationID, record.Date), record)
+		records[missingIndexes[j]] = record
+	}
+
+	return records, nil
+}
+
+// SavePredictions saves predictions to both the LRU cache and the prediction store
 func (c *LRUCacheService) SavePredictions(ctx context.Context, record models.TidePredictionRecord) error {
 	if err := record.Validate(); err != nil {
 		return fmt.Errorf("invalid prediction record: %w", err)
@@ -112,14 +203,11 @@ func (c *LRUCacheService) SavePredictions(ctx context.Context, record models.Tid
 	key := getCacheKey(record.StationID, record.Date)
 
 	// Save to LRU cache
-	c.lru.Add(key, &LRUCacheEntry{
-		Data:      &record,
-		ExpiresAt: c.clock.Now().Truncate(time.Second).Add(c.ttl),
-	})
+	c.addEntry(key, &record)
 
-	// Save to DynamoDB
-	if err := c.dynamoCache.SavePredictions(ctx, record); err != nil {
-		return fmt.Errorf("saving predictions to DynamoDB: %w", err)
+	// Save to the prediction store
+	if err := c.store.SavePredictions(ctx, record); err != nil {
+		return fmt.Errorf("saving predictions to %s: %w", c.store.Name(), err)
 	}
 
 	return nil
@@ -133,36 +221,129 @@ func (c *LRUCacheService) SavePredictionsBatch(ctx context.Context, records []mo
 		recordCopy := record // Make a copy of the record
 
 		key := getCacheKey(recordCopy.StationID, recordCopy.Date)
-		c.lru.Add(key, &LRUCacheEntry{
-			Data:      &recordCopy,
-			ExpiresAt: c.clock.Now().Truncate(time.Second).Add(c.ttl),
-		})
+		c.addEntry(key, &recordCopy)
 	}
 
-	// Save to DynamoDB
-	if err := c.dynamoCache.SavePredictionsBatch(ctx, records); err != nil {
-		return fmt.Errorf("saving predictions batch to DynamoDB: %w", err)
+	// Save to the prediction store
+	if err := c.store.SavePredictionsBatch(ctx, records); err != nil {
+		return fmt.Errorf("saving predictions batch to %s: %w", c.store.Name(), err)
 	}
 
 	return nil
 }
 
-// GetCacheStats returns statistics about cache hits and misses
+// GetCacheStats returns statistics about cache hits and misses. Store stats are keyed
+// by the store's name, e.g. dynamo_hits or redis_hits.
 func (c *LRUCacheService) GetCacheStats() map[string]uint64 {
 	c.statsMutex.RLock()
 	defer c.statsMutex.RUnlock()
 
+	name := c.store.Name()
 	return map[string]uint64{
-		"lru_hits":      c.lruHits,
-		"lru_misses":    c.lruMisses,
-		"dynamo_hits":   c.dynamoHits,
-		"dynamo_misses": c.dynamoMisses,
+		"lru_hits":           c.lruHits,
+		"lru_misses":         c.lruMisses,
+		"lru_bytes":          uint64(c.usedBytes.Load()),
+		"lru_size_evictions": c.sizeEvictions.Load(),
+		name + "_hits":       c.storeHits,
+		name + "_misses":     c.storeMisses,
 	}
 }
 
-// Clear removes all entries from the LRU cache
+// addEntry caches a record, evicting the least recently used entries until the cache fits
+// in maxBytes. Records too large to ever fit are not cached in memory at all.
+func (c *LRUCacheService) addEntry(key string, record *models.TidePredictionRecord) {
+	c.indexExtremes(record)
+	entry := &LRUCacheEntry{
+		Data:      record,
+		ExpiresAt: c.clock.Now().Truncate(time.Second).Add(time.Duration(c.ttl.Load())),
+		Size:      recordSize(record),
+	}
+
+	if c.putEntry(key, entry) {
+		c.notifyRefresh(record.StationID)
+	}
+}
+
+// putEntry does addEntry's work under addMutex, reporting whether it replaced an entry
+func (c *LRUCacheService) putEntry(key string, entry *LRUCacheEntry) bool {
+	c.addMutex.Lock()
+	defer c.addMutex.Unlock()
+
+	// Remove any previous entry first so its size is released through onEvict
+	replaced := c.lru.Remove(key)
+	if c.maxBytes > 0 && entry.Size > c.maxBytes {
+		return replaced
+	}
+
+	c.lru.Add(key, entry)
+	c.usedBytes.Add(entry.Size)
+
+	for c.maxBytes > 0 && c.usedBytes.Load() > c.maxBytes {
+		if _, _, ok := c.lru.RemoveOldest(); !ok {
+			break
+		}
+		c.sizeEvictions.Add(1)
+	}
+	return replaced
+}
+
+// OnRefresh registers fn to hear of refreshed stations. It's called outside the cache's
+// locks, so it may read the cache.
+func (c *LRUCacheService) OnRefresh(fn func(stationID string)) {
+	c.refreshMutex.Lock()
+	defer c.refreshMutex.Unlock()
+	c.onRefresh = append(c.onRefresh, fn)
+}
+
+func (c *LRUCacheService) notifyRefresh(stationID string) {
+	c.refreshMutex.RLock()
+	listeners := c.onRefresh
+	c.refreshMutex.RUnlock()
+	for _, fn := range listeners {
+		fn(stationID)
+	}
+}
+
+// indexExtremes adds a record's extremes to the index. Records read from the LRU are
+// indexed too, since the index may have dropped their station since they were added.
+func (c *LRUCacheService) indexExtremes(record *models.TidePredictionRecord) {
+	if c.extremes != nil {
+		c.extremes.Add(record, c.clock.Now())
+	}
+}
+
+// NextExtremes answers from the extremes index
+func (c *LRUCacheService) NextExtremes(stationID string, after time.Time, count int, location *time.Location) ([]models.TideExtreme, bool) {
+	if c.extremes == nil {
+		return nil, false
+	}
+	return c.extremes.NextExtremes(stationID, after, count, location)
+}
+
+// onEvict releases an entry's bytes whenever the LRU drops it, whatever the reason
+func (c *LRUCacheService) onEvict(_ string, entry *LRUCacheEntry) {
+	c.usedBytes.Add(-entry.Size)
+}
+
+// recordSize approximates the memory held by a record: struct sizes plus string contents
+func recordSize(record *models.TidePredictionRecord) int64 {
+	size := int64(unsafe.Sizeof(*record)) +
+		int64(len(record.StationID)+len(record.Date)+len(record.StationType))
+	for _, p := range record.Predictions {
+		size += int64(unsafe.Sizeof(p)) + int64(len(p.LocalTime))
+	}
+	for _, e := range record.Extremes {
+		size += int64(unsafe.Sizeof(e)) + int64(len(e.LocalTime)+len(e.Type))
+	}
+	return size
+}
+
+// Clear removes all entries from the LRU cache and the extremes index
 func (c *LRUCacheService) Clear() {
 	c.lru.Purge()
+	if c.extremes != nil {
+		c.extremes.Purge()
+	}
 }
 
 func (c *LRUCacheService) incrementLRUHits() {
@@ -177,14 +358,14 @@ func (c *LRUCacheService) incrementLRUMisses() {
 	c.statsMutex.Unlock()
 }
 
-func (c *LRUCacheService) incrementDynamoHits() {
+func (c *LRUCacheService) incrementStoreHits() {
 	c.statsMutex.Lock()
-	c.dynamoHits++
+	c.storeHits++
 	c.statsMutex.Unlock()
 }
 
-func (c *LRUCacheService) incrementDynamoMisses() {
+func (c *LRUCacheService) incrementStoreMisses() {
 	c.statsMutex.Lock()
-	c.dynamoMisses++
+	c.storeMisses++
 	c.statsMutex.Unlock()
 }
@@ -34,6 +34,8 @@ type mockDynamoDBClientLRU struct {
 	putItemFunc        func(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
 	batchWriteItemFunc func(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
 	listTablesFunc     func(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error)
+	deleteItemFunc     func(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	batchGetItemFunc   func(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
 }
 
 func (m *mockDynamoDBClientLRU) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
@@ -57,6 +59,28 @@ func (m *mockDynamoDBClientLRU) BatchWriteItem(ctx context.Context, params *dyna
 	return &dynamodb.BatchWriteItemOutput{}, nil
 }
 
+func (m *mockDynamoDBClientLRU) UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClientLRU) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if m.deleteItemFunc != nil {
+		return m.deleteItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClientLRU) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if m.batchGetItemFunc != nil {
+		return m.batchGetItemFunc(ctx, params, optFns...)
+	}
+	return &dynamodb.BatchGetItemOutput{}, nil
+}
+
+func (m *mockDynamoDBClientLRU) Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
 func (m *mockDynamoDBClientLRU) ListTables(ctx context.Context, params *dynamodb.ListTablesInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
 	if m.listTablesFunc != nil {
 		return m.listTablesFunc(ctx, params, optFns...)
@@ -98,8 +122,9 @@ func createTestCacheService(t *testing.T, cfg *config.CacheConfig) *LRUCacheServ
 	}
 	// Pass the fake clock to DynamoPredictionCache
 	fakeClock := &fakeClock{now: time.Now().UTC()}
-	service.dynamoCache = NewDynamoPredictionCache(mockDynamo, cfg)
-	service.dynamoCache.clock = fakeClock // Use the fake clock
+	dynamoCache := NewDynamoPredictionCache(mockDynamo, cfg)
+	dynamoCache.clock = fakeClock // Use the fake clock
+	service.store = dynamoCache
 	service.clock = fakeClock
 
 	return service
@@ -143,7 +168,7 @@ func TestNewCacheService(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, service)
 				assert.NotNil(t, service.lru)
-				assert.NotNil(t, service.dynamoCache)
+				assert.NotNil(t, service.store)
 			}
 		})
 	}
@@ -169,7 +194,7 @@ func TestCacheHitAndMiss(t *testing.T) {
 		StationType: "R",
 		Predictions: []models.TidePrediction{
 			{
-				Timestamp: date.Unix() * 1000,
+				Timestamp: models.MillisOf(date),
 				LocalTime: date.Format("2006-01-02T15:04:05"),
 				Height:    1.5,
 			},
@@ -274,7 +299,7 @@ func TestLRUSavePredictionsBatch(t *testing.T) {
 			Date:        baseTime.AddDate(0, 0, i).Format("2006-01-02"),
 			StationType: "R",
 			Predictions: []models.TidePrediction{{
-				Timestamp: baseTime.AddDate(0, 0, i).Unix() * 1000,
+				Timestamp: models.MillisOf(baseTime.AddDate(0, 0, i)),
 				LocalTime: baseTime.AddDate(0, 0, i).Format("2006-01-02T15:04:05"),
 				Height:    float64(i),
 			}},
@@ -309,7 +334,7 @@ func TestDynamoDBHitFallback(t *testing.T) {
 		Date:        time.Now().Format("2006-01-02"),
 		StationType: "R",
 		Predictions: []models.TidePrediction{{
-			Timestamp: time.Now().Unix() * 1000,
+			Timestamp: models.MillisOf(time.Now()),
 			LocalTime: time.Now().Format("2006-01-02T15:04:05"),
 			Height:    1.5,
 		}},
@@ -328,7 +353,7 @@ func TestDynamoDBHitFallback(t *testing.T) {
 	}
 
 	service := createTestCacheService(t, cfg)
-	service.dynamoCache = NewDynamoPredictionCache(mockDynamo, cfg)
+	service.store = NewDynamoPredictionCache(mockDynamo, cfg)
 	service.Clear()
 
 	// First access should miss LRU but hit DynamoDB
@@ -351,6 +376,173 @@ func TestDynamoDBHitFallback(t *testing.T) {
 	assert.Equal(t, uint64(0), stats["dynamo_misses"])
 }
 
+func TestSetConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.CacheConfig{
+		TidePredictionLRUSize:       1000,
+		TidePredictionLRUTTLMinutes: 15,
+		TidePredictionDynamoTTLDays: 2,
+		BatchSize:                   25,
+		MaxBatchRetries:             1,
+	}
+	service := createTestCacheService(t, cfg)
+	clock := service.clock.(*fakeClock)
+	record := models.TidePredictionRecord{StationID: "TEST001", Date: clock.Now().Format("2006-01-02"), StationType: "R"}
+
+	reloaded := *cfg
+	reloaded.TidePredictionLRUTTLMinutes = 60
+	reloaded.TidePredictionDynamoTTLDays = 5
+	service.SetConfig(&reloaded)
+	require.NoError(t, service.SavePredictionsBatch(context.Background(), []models.TidePredictionRecord{record}))
+
+	entry, ok := service.lru.Peek(getCacheKey(record.StationID, record.Date))
+	require.True(t, ok)
+	assert.Equal(t, clock.Now().Truncate(time.Second).Add(time.Hour), entry.ExpiresAt)
+	assert.Equal(t, 5*24*time.Hour, service.store.(*DynamoPredictionCache).config.Load().GetDynamoTTL(), "the store gets the new TTLs too")
+}
+
+func TestStoreHitKeepsHistoricalTTL(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.CacheConfig{
+		TidePredictionLRUSize:       1000,
+		TidePredictionLRUTTLMinutes: 15,
+		TidePredictionDynamoTTLDays: 7,
+		HistoricalTTLDays:           365,
+	}
+	now := time.Now().UTC()
+	record := sizedRecord("TEST001", now.AddDate(0, 0, -30).Format("2006-01-02"), 10)
+
+	var mu sync.Mutex
+	stored := map[string]types.AttributeValue{}
+	var puts int
+	mockDynamo := &mockDynamoDBClientLRU{
+		putItemFunc: func(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			puts++
+			stored = params.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		getItemFunc: func(_ context.Context, _ *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return &dynamodb.GetItemOutput{Item: stored}, nil
+		},
+	}
+
+	service, err := NewCacheService(context.Background(), cfg)
+	require.NoError(t, err)
+	service.store = NewDynamoPredictionCache(mockDynamo, cfg)
+	require.NoError(t, service.SavePredictions(context.Background(), record))
+	require.Equal(t, 1, puts)
+	service.Clear()
+
+	date, _ := time.Parse("2006-01-02", record.Date)
+	result, err := service.GetPredictions(context.Background(), record.StationID, date)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, 1, puts, "a store hit shouldn't be written back to the store")
+	assert.Greater(t, result.TTL, now.AddDate(0, 0, 300).Unix(), "historical records keep their long TTL")
+}
+
+// sizedRecord builds a record holding n six-minute predictions
+func sizedRecord(stationID, date string, n int) models.TidePredictionRecord {
+	record := models.TidePredictionRecord{StationID: stationID, Date: date, StationType: "R"}
+	start, _ := time.Parse("2006-01-02", date)
+	for i := 0; i < n; i++ {
+		t := start.Add(time.Duration(i) * 6 * time.Minute)
+		record.Predictions = append(record.Predictions, models.TidePrediction{
+			Timestamp: models.MillisOf(t),
+			LocalTime: t.Format("2006-01-02T15:04:05"),
+			Height:    float64(i) / 10,
+		})
+	}
+	return record
+}
+
+func TestLRUGetPredictionsBatch(t *testing.T) {
+	cfg := &config.CacheConfig{
+		TidePredictionLRUSize:       10,
+		TidePredictionLRUTTLMinutes: 15,
+		TidePredictionDynamoTTLDays: 1,
+	}
+	service := createTestCacheService(t, cfg)
+	store := NewFilePredictionCache(t.TempDir(), cfg)
+	service.store = store
+	ctx := context.Background()
+
+	// Day one is in both tiers, day two only in the store, day three nowhere
+	require.NoError(t, service.SavePredictions(ctx, createTestRecord("9447130", "2024-01-01")))
+	require.NoError(t, store.SavePredictions(ctx, createTestRecord("9447130", "2024-01-02")))
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dates := []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 2)}
+
+	records, err := service.GetPredictionsBatch(ctx, "9447130", dates)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "2024-01-01", records[0].Date)
+	assert.Equal(t, "2024-01-02", records[1].Date)
+	assert.Nil(t, records[2])
+
+	stats := service.GetCacheStats()
+	assert.Equal(t, uint64(1), stats["lru_hits"])
+	assert.Equal(t, uint64(2), stats["lru_misses"])
+	assert.Equal(t, uint64(1), stats["file_hits"])
+	assert.Equal(t, uint64(1), stats["file_misses"])
+
+	// Store hits are promoted into the LRU
+	assert.True(t, service.lru.Contains(getCacheKey("9447130", "2024-01-02")))
+}
+
+func TestLRUSizeBounding(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.CacheConfig{
+		TidePredictionLRUSize:       1000,
+		TidePredictionLRUTTLMinutes: 15,
+		BatchSize:                   25,
+		MaxBatchRetries:             1,
+	}
+	service := createTestCacheService(t, cfg)
+	ctx := context.Background()
+
+	day := sizedRecord("9447130", "2024-01-01", 240)
+	daySize := recordSize(&day)
+	assert.Greater(t, daySize, recordSize(&models.TidePredictionRecord{}), "size should grow with predictions")
+
+	// Room for two full days
+	service.maxBytes = 2*daySize + daySize/2
+
+	require.NoError(t, service.SavePredictionsBatch(ctx, []models.TidePredictionRecord{
+		sizedRecord("9447130", "2024-01-01", 240),
+		sizedRecord("9447130", "2024-01-02", 240),
+	}))
+	assert.Equal(t, 2, service.lru.Len())
+	assert.Equal(t, uint64(2*daySize), service.GetCacheStats()["lru_bytes"])
+
+	// A third day pushes out the least recently used one
+	require.NoError(t, service.SavePredictions(ctx, sizedRecord("9447130", "2024-01-03", 240)))
+	assert.Equal(t, 2, service.lru.Len())
+	assert.False(t, service.lru.Contains(getCacheKey("9447130", "2024-01-01")))
+	assert.Equal(t, uint64(1), service.GetCacheStats()["lru_size_evictions"])
+
+	// Replacing an entry doesn't double count it
+	require.NoError(t, service.SavePredictions(ctx, sizedRecord("9447130", "2024-01-03", 240)))
+	assert.Equal(t, uint64(2*daySize), service.GetCacheStats()["lru_bytes"])
+
+	// A record bigger than the whole budget is served from the store, not kept in memory
+	require.NoError(t, service.SavePredictions(ctx, sizedRecord("9447130", "2024-01-04", 240*4)))
+	assert.False(t, service.lru.Contains(getCacheKey("9447130", "2024-01-04")))
+	assert.Equal(t, 2, service.lru.Len())
+
+	// Clearing releases everything
+	service.Clear()
+	assert.Equal(t, uint64(0), service.GetCacheStats()["lru_bytes"])
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping concurrent test in short mode")
@@ -447,3 +639,22 @@ func BenchmarkCacheOperations(b *testing.B) {
 		}
 	})
 }
+
+func TestOnRefresh(t *testing.T) {
+	service := createTestCacheService(t, &config.CacheConfig{
+		TidePredictionLRUSize:       1000,
+		TidePredictionLRUTTLMinutes: 15,
+	})
+	var refreshed []string
+	service.OnRefresh(func(stationID string) { refreshed = append(refreshed, stationID) })
+
+	record := models.TidePredictionRecord{StationID: "TEST001", Date: "2025-01-01", StationType: "R"}
+	require.NoError(t, service.SavePredictions(context.Background(), record))
+	assert.Empty(t, refreshed, "the first fill isn't a refresh")
+
+	require.NoError(t, service.SavePredictions(context.Background(), record))
+	assert.Equal(t, []string{"TEST001"}, refreshed)
+
+	require.NoError(t, service.Invalidate(context.Background(), "TEST001", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []string{"TEST001", "TEST001"}, refreshed)
+}
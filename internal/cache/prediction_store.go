@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// PredictionStore is the shared cache tier that sits behind the in-process LRU
+type PredictionStore interface {
+	// Name identifies the backend in logs and cache stats
+	Name() string
+	GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error)
+	// GetPredictionsBatch returns one entry per date, nil where nothing valid is cached
+	GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error)
+	SavePredictions(ctx context.Context, record models.TidePredictionRecord) error
+	SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error
+	// DeletePredictions removes a station's record for a date; deleting a missing record is not an error
+	DeletePredictions(ctx context.Context, stationID string, date time.Time) error
+}
+
+// RecordSizer is implemented by stores that hold records in a different form than their
+// JSON, e.g. compressed, and can say how many bytes a record takes there
+type RecordSizer interface {
+	StoredSize(record models.TidePredictionRecord) (int64, error)
+}
+
+// Reconfigurable is implemented by stores whose TTLs can change while they're in use,
+// when the configuration is reloaded
+type Reconfigurable interface {
+	SetConfig(cacheConfig *config.CacheConfig)
+}
+
+var (
+	_ RecordSizer     = (*DynamoPredictionCache)(nil)
+	_ Reconfigurable  = (*DynamoPredictionCache)(nil)
+	_ Reconfigurable  = (*RedisPredictionCache)(nil)
+	_ Reconfigurable  = (*FilePredictionCache)(nil)
+	_ Reconfigurable  = (*SQLitePredictionCache)(nil)
+	_ PredictionStore = (*DynamoPredictionCache)(nil)
+	_ PredictionStore = (*RedisPredictionCache)(nil)
+	_ PredictionStore = (*FilePredictionCache)(nil)
+	_ PredictionStore = (*SQLitePredictionCache)(nil)
+	_ PredictionStore = memoryStore{}
+)
+
+// NewPredictionStore creates the prediction store selected by cacheConfig.Backend. The
+// DynamoDB client is created when the store is first used.
+func NewPredictionStore(ctx context.Context, cacheConfig *config.CacheConfig) (PredictionStore, error) {
+	switch backend := strings.ToLower(strings.TrimSpace(cacheConfig.Backend)); backend {
+	case "", config.BackendDynamo:
+		dynamoClient := NewLazyDynamoClient("prediction cache", func(ctx context.Context) (DynamoDBClient, error) {
+			client, err := NewRegionalDynamoClientFromEnv(ctx, cacheConfig)
+			if err != nil {
+				return nil, fmt.Errorf("creating DynamoDB client: %w", err)
+			}
+			return client, nil
+		})
+		return NewDynamoPredictionCache(dynamoClient, cacheConfig), nil
+	case config.BackendRedis:
+		return NewRedisPredictionCache(NewRedisClient(RedisOptions{
+			Addr:     cacheConfig.RedisAddr,
+			Password: cacheConfig.RedisPassword,
+			DB:       cacheConfig.RedisDB,
+			TLS:      cacheConfig.RedisTLS,
+		}), cacheConfig), nil
+	case config.BackendFile:
+		return NewFilePredictionCache(cacheConfig.FileCacheDir, cacheConfig), nil
+	case config.BackendSQLite:
+		db, err := OpenSQLite(ctx, cacheConfig.GetSQLitePath())
+		if err != nil {
+			return nil, fmt.Errorf("opening SQLite cache: %w", err)
+		}
+		return NewSQLitePredictionCache(db, cacheConfig), nil
+	case config.BackendMemory:
+		return memoryStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %q", cacheConfig.Backend)
+	}
+}
+
+// memoryStore stands in for the tier behind the LRU when there's none: it has nothing
+// cached and drops what it's given, so predictions are kept only in the LRU
+type memoryStore struct{}
+
+func (memoryStore) Name() string {
+	return config.BackendMemory
+}
+
+func (memoryStore) GetPredictions(context.Context, string, time.Time) (*models.TidePredictionRecord, error) {
+	return nil, nil
+}
+
+func (memoryStore) GetPredictionsBatch(_ context.Context, _ string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	return make([]*models.TidePredictionRecord, len(dates)), nil
+}
+
+func (memoryStore) SavePredictions(context.Context, models.TidePredictionRecord) error {
+	return nil
+}
+
+func (memoryStore) SavePredictionsBatch(context.Context, []models.TidePredictionRecord) error {
+	return nil
+}
+
+func (memoryStore) DeletePredictions(context.Context, string, time.Time) error {
+	return nil
+}
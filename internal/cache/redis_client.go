@@ -0,0 +1,281 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisClient defines the Redis operations we use
+type RedisClient interface {
+	// Get returns the value stored at key, or nil if the key doesn't exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// GetMany returns one value per key, nil where the key doesn't exist, in a single round trip
+	GetMany(ctx context.Context, keys []string) ([][]byte, error)
+	// SetMany stores each value with the same expiry in a single round trip
+	SetMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error
+	// Del removes the given keys, ignoring any that don't exist
+	Del(ctx context.Context, keys ...string) error
+}
+
+// RedisOptions configures the connection to a Redis (or ElastiCache) server
+type RedisOptions struct {
+	Addr        string
+	Password    string
+	DB          int
+	TLS         bool
+	DialTimeout time.Duration
+}
+
+// errRedisNil marks a missing key in a RESP reply
+var errRedisNil = errors.New("redis: nil")
+
+// respClient is a minimal RESP2 client holding a single connection. Lambda handles one
+// request per instance at a time, so the connection is shared under a mutex rather than pooled.
+type respClient struct {
+	opts RedisOptions
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+var _ RedisClient = (*respClient)(nil)
+
+// NewRedisClient creates a client that connects lazily on first use
+func NewRedisClient(opts RedisOptions) RedisClient {
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = 2 * time.Second
+	}
+	return &respClient{opts: opts}
+}
+
+func (c *respClient) Get(ctx context.Context, key string) ([]byte, error) {
+	replies, err := c.do(ctx, [][]string{{"GET", key}})
+	if err != nil {
+		return nil, err
+	}
+	if errors.Is(replies[0].err, errRedisNil) {
+		return nil, nil
+	}
+	if replies[0].err != nil {
+		return nil, replies[0].err
+	}
+	return replies[0].value, nil
+}
+
+func (c *respClient) GetMany(ctx context.Context, keys []string) ([][]byte, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	commands := make([][]string, len(keys))
+	for i, key := range keys {
+		commands[i] = []string{"GET", key}
+	}
+
+	replies, err := c.do(ctx, commands)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(keys))
+	for i, reply := range replies {
+		if errors.Is(reply.err, errRedisNil) {
+			continue
+		}
+		if reply.err != nil {
+			return nil, reply.err
+		}
+		values[i] = reply.value
+	}
+	return values, nil
+}
+
+func (c *respClient) SetMany(ctx context.Context, values map[string][]byte, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	seconds := strconv.Itoa(int(ttl.Seconds()))
+	commands := make([][]string, 0, len(values))
+	for key, value := range values {
+		commands = append(commands, []string{"SET", key, string(value), "EX", seconds})
+	}
+
+	replies, err := c.do(ctx, commands)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if reply.err != nil {
+			return reply.err
+		}
+	}
+	return nil
+}
+
+func (c *respClient) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	replies, err := c.do(ctx, [][]string{append([]string{"DEL"}, keys...)})
+	if err != nil {
+		return err
+	}
+	return replies[0].err
+}
+
+type respReply struct {
+	value []byte
+	err   error
+}
+
+// do pipelines the commands and reads one reply per command. Connection-level failures
+// drop the connection so the next call reconnects.
+func (c *respClient) do(ctx context.Context, commands [][]string) ([]respReply, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.connect(ctx); err != nil {
+		return nil, err
+	}
+
+	replies, err := c.roundTrip(ctx, commands)
+	if err != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+		return nil, err
+	}
+	return replies, nil
+}
+
+func (c *respClient) roundTrip(ctx context.Context, commands [][]string) ([]respReply, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.opts.DialTimeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("setting redis deadline: %w", err)
+	}
+
+	w := bufio.NewWriter(c.conn)
+	for _, args := range commands {
+		writeCommand(w, args)
+	}
+	if err := w.Flush(); err != nil {
+		return nil, fmt.Errorf("writing redis commands: %w", err)
+	}
+
+	replies := make([]respReply, len(commands))
+	for i := range commands {
+		value, err := readReply(c.rd)
+		var serverErr redisError
+		if err != nil && !errors.Is(err, errRedisNil) && !errors.As(err, &serverErr) {
+			return nil, fmt.Errorf("reading redis reply: %w", err)
+		}
+		replies[i] = respReply{value: value, err: err}
+	}
+	return replies, nil
+}
+
+func (c *respClient) connect(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: c.opts.DialTimeout}
+	var conn net.Conn
+	var err error
+	if c.opts.TLS {
+		host, _, _ := net.SplitHostPort(c.opts.Addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", c.opts.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to redis at %s: %w", c.opts.Addr, err)
+	}
+	c.conn = conn
+	c.rd = bufio.NewReader(conn)
+
+	var setup [][]string
+	if c.opts.Password != "" {
+		setup = append(setup, []string{"AUTH", c.opts.Password})
+	}
+	if c.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+	if len(setup) == 0 {
+		return nil
+	}
+
+	replies, err := c.roundTrip(ctx, setup)
+	if err == nil {
+		for _, reply := range replies {
+			if reply.err != nil {
+				err = reply.err
+				break
+			}
+		}
+	}
+	if err != nil {
+		_ = conn.Close()
+		c.conn = nil
+		return fmt.Errorf("initializing redis connection: %w", err)
+	}
+	return nil
+}
+
+// redisError is an error reply sent by the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+func writeCommand(w *bufio.Writer, args []string) {
+	_, _ = fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readReply reads a single RESP2 reply. Arrays aren't needed by the commands we send.
+func readReply(rd *bufio.Reader) ([]byte, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed redis reply: %q", line)
+	}
+	payload := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+', ':':
+		return []byte(payload), nil
+	case '-':
+		return nil, redisError(payload)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis bulk length: %q", payload)
+		}
+		if n < 0 {
+			return nil, errRedisNil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply type: %q", line[0])
+	}
+}
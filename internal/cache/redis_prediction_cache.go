@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+const redisKeyPrefix = "tide-predictions:"
+
+// RedisPredictionCache handles caching tide predictions in Redis, e.g. ElastiCache
+type RedisPredictionCache struct {
+	client RedisClient
+	config atomic.Pointer[config.CacheConfig]
+	clock  clock
+}
+
+func NewRedisPredictionCache(client RedisClient, cacheConfig *config.CacheConfig) *RedisPredictionCache {
+	if cacheConfig == nil {
+		cacheConfig = config.GetCacheConfig()
+	}
+	c := &RedisPredictionCache{
+		client: client,
+		clock:  &systemClock{},
+	}
+	c.config.Store(cacheConfig)
+	return c
+}
+
+// SetConfig replaces the TTLs used by later saves
+func (c *RedisPredictionCache) SetConfig(cacheConfig *config.CacheConfig) {
+	c.config.Store(cacheConfig)
+}
+
+func (c *RedisPredictionCache) Name() string {
+	return config.BackendRedis
+}
+
+// GetPredictions retrieves cached predictions for a station and date
+func (c *RedisPredictionCache) GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+	data, err := c.client.Get(ctx, redisKey(stationID, date.Format("2006-01-02")))
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions from Redis: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	return c.decodeRecord(data)
+}
+
+// GetPredictionsBatch retrieves cached predictions for several dates in one pipelined round
+// trip, returning one entry per date with nil for misses
+func (c *RedisPredictionCache) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	keys := make([]string, len(dates))
+	for i, date := range dates {
+		keys[i] = redisKey(stationID, date.Format("2006-01-02"))
+	}
+
+	values, err := c.client.GetMany(ctx, keys)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions from Redis: %w", err)
+	}
+
+	records := make([]*models.TidePredictionRecord, len(dates))
+	for i, data := range values {
+		if data == nil {
+			continue
+		}
+		if records[i], err = c.decodeRecord(data); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func (c *RedisPredictionCache) decodeRecord(data []byte) (*models.TidePredictionRecord, error) {
+	var record models.TidePredictionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("unmarshaling prediction record: %w", err)
+	}
+
+	// Redis expires keys itself, but guard against clock skew with the record's own TTL
+	if c.clock.Now().Unix() >= record.TTL {
+		return nil, nil
+	}
+
+	return &record, nil
+}
+
+// SavePredictions saves predictions to the cache
+func (c *RedisPredictionCache) SavePredictions(ctx context.Context, record models.TidePredictionRecord) error {
+	return c.SavePredictionsBatch(ctx, []models.TidePredictionRecord{record})
+}
+
+// SavePredictionsBatch saves multiple prediction records in one pipelined round trip per TTL
+func (c *RedisPredictionCache) SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error {
+	// The second tier shares CACHE_DYNAMO_TTL_DAYS and CACHE_HISTORICAL_TTL_DAYS whichever
+	// backend holds it
+	now := c.clock.Now()
+
+	valuesByTTL := make(map[time.Duration]map[string][]byte)
+	for _, record := range records {
+		if err := record.Validate(); err != nil {
+			return fmt.Errorf("invalid prediction record: %w", err)
+		}
+
+		ttl := c.config.Load().GetPredictionTTL(record.Date, now)
+		record.LastUpdated = now.Unix()
+		record.TTL = now.Add(ttl).Unix()
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("marshaling prediction record: %w", err)
+		}
+		if valuesByTTL[ttl] == nil {
+			valuesByTTL[ttl] = make(map[string][]byte)
+		}
+		valuesByTTL[ttl][redisKey(record.StationID, record.Date)] = data
+	}
+
+	for ttl, values := range valuesByTTL {
+		if err := c.client.SetMany(ctx, values, ttl); err != nil {
+			return fmt.Errorf("saving predictions to Redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeletePredictions removes the cached record for a station and date, if any
+func (c *RedisPredictionCache) DeletePredictions(ctx context.Context, stationID string, date time.Time) error {
+	if err := c.client.Del(ctx, redisKey(stationID, date.Format("2006-01-02"))); err != nil {
+		return fmt.Errorf("deleting predictions from Redis: %w", err)
+	}
+	return nil
+}
+
+func redisKey(stationID, date string) string {
+	return redisKeyPrefix + getCacheKey(stationID, date)
+}
@@ -0,0 +1,291 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedisServer speaks just enough RESP to serve AUTH, SELECT, GET, SET and DEL
+type fakeRedisServer struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	expiries map[string]int
+	commands []string
+}
+
+func newFakeRedisServer(t *testing.T, password string) *fakeRedisServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &fakeRedisServer{
+		listener: listener,
+		password: password,
+		data:     make(map[string]string),
+		expiries: make(map[string]int),
+	}
+	go srv.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+	return srv
+}
+
+func (s *fakeRedisServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedisServer) handle(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	rd := bufio.NewReader(conn)
+	authed := s.password == ""
+
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, strings.ToUpper(args[0]))
+		var reply string
+		switch strings.ToUpper(args[0]) {
+		case "AUTH":
+			if args[1] == s.password {
+				authed = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case "SELECT":
+			reply = "+OK\r\n"
+		case "GET":
+			if !authed {
+				reply = "-NOAUTH Authentication required.\r\n"
+			} else if value, ok := s.data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "SET":
+			if !authed {
+				reply = "-NOAUTH Authentication required.\r\n"
+			} else {
+				s.data[args[1]] = args[2]
+				s.expiries[args[1]], _ = strconv.Atoi(args[4])
+				reply = "+OK\r\n"
+			}
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := s.data[key]; ok {
+					delete(s.data, key)
+					deleted++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", deleted)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		s.mu.Unlock()
+
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		if _, err := rd.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func createTestRecord(stationID, date string) models.TidePredictionRecord {
+	return models.TidePredictionRecord{
+		StationID:   stationID,
+		Date:        date,
+		StationType: "R",
+		Predictions: []models.TidePrediction{
+			{Timestamp: 1704067200000, LocalTime: date + "T00:00:00", Height: 5.2},
+		},
+		Extremes: []models.TideExtreme{
+			{Type: models.TideTypeHigh, Timestamp: 1704088800000, LocalTime: date + "T06:00:00", Height: 9.1},
+		},
+	}
+}
+
+func TestRedisPredictionCache_RoundTrip(t *testing.T) {
+	srv := newFakeRedisServer(t, "secret")
+	cfg := &config.CacheConfig{TidePredictionDynamoTTLDays: 2}
+
+	client := NewRedisClient(RedisOptions{Addr: srv.listener.Addr().String(), Password: "secret", DB: 2})
+	cache := NewRedisPredictionCache(client, cfg)
+	cache.clock = &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	assert.Equal(t, "redis", cache.Name())
+
+	ctx := context.Background()
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Missing keys are a miss, not an error
+	record, err := cache.GetPredictions(ctx, "9447130", date)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	records := []models.TidePredictionRecord{
+		createTestRecord("9447130", "2024-01-01"),
+		createTestRecord("9447130", "2024-01-02"),
+	}
+	require.NoError(t, cache.SavePredictionsBatch(ctx, records))
+
+	srv.mu.Lock()
+	assert.Equal(t, 2*24*60*60, srv.expiries["tide-predictions:9447130:2024-01-01"])
+	assert.Equal(t, []string{"AUTH", "SELECT", "GET", "SET", "SET"}, srv.commands)
+	srv.mu.Unlock()
+
+	record, err = cache.GetPredictions(ctx, "9447130", date)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, records[0].Predictions, record.Predictions)
+	assert.Equal(t, records[0].Extremes, record.Extremes)
+	assert.Equal(t, int64(1704067200+2*24*60*60), record.TTL)
+
+	// Records past their own TTL are treated as misses
+	cache.clock = &fakeClock{now: date.Add(72 * time.Hour)}
+	record, err = cache.GetPredictions(ctx, "9447130", date)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	// Batch reads pipeline one GET per date
+	cache.clock = &fakeClock{now: date}
+	srv.mu.Lock()
+	srv.commands = nil
+	srv.mu.Unlock()
+	batch, err := cache.GetPredictionsBatch(ctx, "9447130", []time.Time{date, date.AddDate(0, 0, 1), date.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+	assert.Equal(t, "2024-01-01", batch[0].Date)
+	assert.Equal(t, "2024-01-02", batch[1].Date)
+	assert.Nil(t, batch[2])
+	srv.mu.Lock()
+	assert.Equal(t, []string{"GET", "GET", "GET"}, srv.commands)
+	srv.mu.Unlock()
+
+	require.NoError(t, cache.DeletePredictions(ctx, "9447130", date))
+	srv.mu.Lock()
+	assert.NotContains(t, srv.data, "tide-predictions:9447130:2024-01-01")
+	assert.Contains(t, srv.data, "tide-predictions:9447130:2024-01-02")
+	srv.mu.Unlock()
+}
+
+func TestRedisPredictionCache_HistoricalTTL(t *testing.T) {
+	srv := newFakeRedisServer(t, "")
+	cfg := &config.CacheConfig{TidePredictionDynamoTTLDays: 2, HistoricalTTLDays: 365}
+	cache := NewRedisPredictionCache(NewRedisClient(RedisOptions{Addr: srv.listener.Addr().String()}), cfg)
+	cache.clock = &fakeClock{now: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)}
+
+	require.NoError(t, cache.SavePredictionsBatch(context.Background(), []models.TidePredictionRecord{
+		createTestRecord("9447130", "2023-12-31"),
+		createTestRecord("9447130", "2024-01-02"),
+	}))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, 365*24*60*60, srv.expiries["tide-predictions:9447130:2023-12-31"])
+	assert.Equal(t, 2*24*60*60, srv.expiries["tide-predictions:9447130:2024-01-02"])
+}
+
+func TestRedisPredictionCache_Errors(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("wrong password", func(t *testing.T) {
+		srv := newFakeRedisServer(t, "secret")
+		cache := NewRedisPredictionCache(NewRedisClient(RedisOptions{Addr: srv.listener.Addr().String(), Password: "nope"}), &config.CacheConfig{})
+
+		_, err := cache.GetPredictions(ctx, "9447130", date)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "WRONGPASS")
+	})
+
+	t.Run("server unavailable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		cache := NewRedisPredictionCache(NewRedisClient(RedisOptions{Addr: addr, DialTimeout: 100 * time.Millisecond}), &config.CacheConfig{})
+		_, err = cache.GetPredictions(ctx, "9447130", date)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connecting to redis")
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		cache := NewRedisPredictionCache(NewRedisClient(RedisOptions{Addr: "127.0.0.1:1"}), &config.CacheConfig{})
+		err := cache.SavePredictions(ctx, models.TidePredictionRecord{StationID: "9447130"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid prediction record")
+	})
+}
+
+func TestNewPredictionStore(t *testing.T) {
+	store, err := NewPredictionStore(context.Background(), &config.CacheConfig{Backend: "Redis", RedisAddr: "localhost:6379"})
+	require.NoError(t, err)
+	assert.Equal(t, "redis", store.Name())
+
+	_, err = NewPredictionStore(context.Background(), &config.CacheConfig{Backend: "memcached"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown cache backend")
+}
+
+func TestLRUCacheService_StatsUseStoreName(t *testing.T) {
+	srv := newFakeRedisServer(t, "")
+	cfg := &config.CacheConfig{TidePredictionLRUSize: 10, TidePredictionLRUTTLMinutes: 15, TidePredictionDynamoTTLDays: 1}
+
+	service := createTestCacheService(t, cfg)
+	service.store = NewRedisPredictionCache(NewRedisClient(RedisOptions{Addr: srv.listener.Addr().String()}), cfg)
+
+	_, err := service.GetPredictions(context.Background(), "9447130", time.Now())
+	require.NoError(t, err)
+
+	stats := service.GetCacheStats()
+	assert.Equal(t, uint64(1), stats["redis_misses"])
+	assert.Equal(t, uint64(0), stats["redis_hits"])
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rs/zerolog/log"
+)
+
+// Replica is a client for one region's copy of a table or bucket
+type Replica[C any] struct {
+	Region string
+	Client C
+	// Bucket names the region's replica of an S3 bucket; tables have the same name in
+	// every region
+	Bucket string
+}
+
+// otherRegions returns regions without own and duplicates, in order
+func otherRegions(own string, regions []string) []string {
+	var others []string
+	for _, region := range regions {
+		if region != own && !slices.Contains(others, region) {
+			others = append(others, region)
+		}
+	}
+	return others
+}
+
+// readReplicas reads from each replica in turn until one succeeds, returning the last
+// error when none does
+func readReplicas[C any, O any](ctx context.Context, replicas []Replica[C], read func(Replica[C]) (O, error)) (O, error) {
+	var (
+		output O
+		err    error
+	)
+	for i, replica := range replicas {
+		if output, err = read(replica); err == nil {
+			if i > 0 {
+				log.Info().Str("region", replica.Region).Msg("Read from a fallback region")
+			}
+			return output, nil
+		}
+		if ctx.Err() != nil || i == len(replicas)-1 {
+			break
+		}
+		log.Warn().Err(err).Str("region", replica.Region).Msg("Read failed, trying the next region")
+	}
+	return output, err
+}
+
+// RegionalDynamoClient reads a DynamoDB global table from the own region's replica and,
+// when that fails, from the others in order. Writes go to the own region, from which
+// DynamoDB replicates them. A miss isn't a failure: it's answered by the own region.
+type RegionalDynamoClient struct {
+	DynamoDBClient
+	replicas []Replica[DynamoDBClient]
+}
+
+var _ DynamoDBClient = (*RegionalDynamoClient)(nil)
+
+// NewRegionalDynamoClient reads from local, then from fallbacks in order
+func NewRegionalDynamoClient(local Replica[DynamoDBClient], fallbacks ...Replica[DynamoDBClient]) *RegionalDynamoClient {
+	return &RegionalDynamoClient{
+		DynamoDBClient: local.Client,
+		replicas:       append([]Replica[DynamoDBClient]{local}, fallbacks...),
+	}
+}
+
+func (c *RegionalDynamoClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return readReplicas(ctx, c.replicas, func(r Replica[DynamoDBClient]) (*dynamodb.GetItemOutput, error) {
+		return r.Client.GetItem(ctx, params, optFns...)
+	})
+}
+
+func (c *RegionalDynamoClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return readReplicas(ctx, c.replicas, func(r Replica[DynamoDBClient]) (*dynamodb.BatchGetItemOutput, error) {
+		return r.Client.BatchGetItem(ctx, params, optFns...)
+	})
+}
+
+// RegionalS3Client reads objects from the own region's bucket and, when that fails or the
+// object isn't there yet, from the replica buckets in order. Writes go to the own region's
+// bucket, from which S3 replication copies them.
+type RegionalS3Client struct {
+	S3Client
+	replicas []Replica[S3Client]
+}
+
+var _ S3Client = (*RegionalS3Client)(nil)
+
+// NewRegionalS3Client reads from local, then from fallbacks in order. A replica without a
+// Bucket reads the bucket the request names.
+func NewRegionalS3Client(local Replica[S3Client], fallbacks ...Replica[S3Client]) *RegionalS3Client {
+	return &RegionalS3Client{
+		S3Client: local.Client,
+		replicas: append([]Replica[S3Client]{local}, fallbacks...),
+	}
+}
+
+func (c *RegionalS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return readReplicas(ctx, c.replicas, func(r Replica[S3Client]) (*s3.GetObjectOutput, error) {
+		input := params
+		if r.Bucket != "" {
+			replicaInput := *params
+			replicaInput.Bucket = aws.String(r.Bucket)
+			input = &replicaInput
+		}
+		return r.Client.GetObject(ctx, input, optFns...)
+	})
+}
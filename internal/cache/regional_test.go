@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegionalDynamoClient(t *testing.T) {
+	var reads, writes []string
+	replica := func(region string, err error) Replica[DynamoDBClient] {
+		return Replica[DynamoDBClient]{Region: region, Client: &mockDynamoDBClient{
+			getItemFunc: func(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+				reads = append(reads, region)
+				if err != nil {
+					return nil, err
+				}
+				return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+					"region": &types.AttributeValueMemberS{Value: region},
+				}}, nil
+			},
+			putItemFunc: func(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+				writes = append(writes, region)
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		}}
+	}
+	outage := errors.New("service unavailable")
+
+	client := NewRegionalDynamoClient(replica("us-west-2", outage), replica("us-east-1", outage), replica("eu-west-1", nil))
+	output, err := client.GetItem(context.Background(), &dynamodb.GetItemInput{})
+	require.NoError(t, err)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "eu-west-1"}, output.Item["region"])
+	assert.Equal(t, []string{"us-west-2", "us-east-1", "eu-west-1"}, reads, "replicas are read in order")
+
+	_, err = client.PutItem(context.Background(), &dynamodb.PutItemInput{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"us-west-2"}, writes, "writes go to the own region")
+
+	reads = nil
+	client = NewRegionalDynamoClient(replica("us-west-2", nil), replica("us-east-1", nil))
+	_, err = client.GetItem(context.Background(), &dynamodb.GetItemInput{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"us-west-2"}, reads, "the own region is read first")
+
+	client = NewRegionalDynamoClient(replica("us-west-2", outage), replica("us-east-1", errors.New("throttled")))
+	_, err = client.GetItem(context.Background(), &dynamodb.GetItemInput{})
+	assert.EqualError(t, err, "throttled", "the last region's error is returned")
+}
+
+func TestRegionalS3Client(t *testing.T) {
+	var buckets []string
+	client := func(err error) S3Client {
+		return &mockS3Client{getObjectFunc: func(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+			buckets = append(buckets, aws.ToString(params.Bucket))
+			return &s3.GetObjectOutput{}, err
+		}}
+	}
+
+	regional := NewRegionalS3Client(
+		Replica[S3Client]{Region: "us-west-2", Client: client(errors.New("NoSuchKey"))},
+		Replica[S3Client]{Region: "us-east-1", Client: client(nil), Bucket: "stations-use1"},
+	)
+	_, err := regional.GetObject(context.Background(), &s3.GetObjectInput{Bucket: aws.String("stations-usw2"), Key: aws.String("stations/noaa.json")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stations-usw2", "stations-use1"}, buckets)
+}
+
+func TestOtherRegions(t *testing.T) {
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, otherRegions("us-west-2", []string{"us-east-1", "us-west-2", "eu-west-1", "us-east-1"}))
+	assert.Empty(t, otherRegions("us-west-2", nil))
+}
@@ -6,10 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/bbernstein/flowebb-go/internal/config"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/rs/zerolog/log"
 	"io"
+	"strings"
 	"time"
 )
 
@@ -27,10 +30,101 @@ const (
 type S3StationCache struct {
 	client     S3Client
 	bucketName string
+	source     models.Source // Partitions the cache so each data source has its own station list
 	ttl        time.Duration
 	clock      clock // Use the same clock interface from cache package
 }
 
+// NewS3StationCache creates an S3 cache for one source's station list, using that source's TTL
+func NewS3StationCache(client S3Client, bucketName string, source models.Source, cacheConfig *config.CacheConfig) *S3StationCache {
+	if cacheConfig == nil {
+		cacheConfig = config.GetCacheConfig()
+	}
+
+	return &S3StationCache{
+		client:     client,
+		bucketName: bucketName,
+		source:     source,
+		ttl:        cacheConfig.GetStationListTTLForSource(string(source)),
+		clock:      &systemClock{},
+	}
+}
+
+// NewStationListCacheFromEnv creates the persistent station list cache for the source: a local
+// file cache when CACHE_BACKEND=file, the SQLite database when it's sqlite, none when it's
+// memory, otherwise S3 when STATION_LIST_BUCKET is set. It returns nil without error when there's none.
+func NewStationListCacheFromEnv(ctx context.Context, source models.Source) (StationListCacheProvider, error) {
+	cacheConfig := config.GetCacheConfig()
+	if strings.EqualFold(cacheConfig.Backend, config.BackendFile) {
+		return NewFileStationCache(cacheConfig.FileCacheDir, source, cacheConfig), nil
+	}
+	if strings.EqualFold(cacheConfig.Backend, config.BackendSQLite) {
+		db, err := OpenSQLite(ctx, cacheConfig.GetSQLitePath())
+		if err != nil {
+			return nil, fmt.Errorf("opening SQLite cache: %w", err)
+		}
+		return NewSQLiteStationCache(db, source, cacheConfig), nil
+	}
+	if strings.EqualFold(cacheConfig.Backend, config.BackendMemory) {
+		return nil, nil
+	}
+
+	s3Cache, err := NewS3StationCacheFromEnv(ctx, source)
+	if err != nil || s3Cache == nil {
+		return nil, err
+	}
+	return s3Cache, nil
+}
+
+// NewS3StationCacheFromEnv creates an S3 station cache for the source in the bucket named by
+// STATION_LIST_BUCKET, reading from the STATION_LIST_REPLICA_BUCKETS in the fallback regions
+// when the list can't be read from it. The S3 client is created when the cache is first
+// used. It returns nil without error when no bucket is configured.
+func NewS3StationCacheFromEnv(ctx context.Context, source models.Source) (*S3StationCache, error) {
+	cacheConfig := config.GetCacheConfig()
+	bucketName := cacheConfig.StationListBucket
+	if bucketName == "" {
+		return nil, nil
+	}
+
+	client := NewLazyS3Client("station list", func(ctx context.Context) (S3Client, error) {
+		return newRegionalS3ClientFromEnv(ctx, cacheConfig)
+	})
+	return NewS3StationCache(client, bucketName, source, cacheConfig), nil
+}
+
+// newRegionalS3ClientFromEnv creates an S3 client that falls back to the replica buckets
+// in cacheConfig
+func newRegionalS3ClientFromEnv(ctx context.Context, cacheConfig *config.CacheConfig) (S3Client, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	var client S3Client = s3.NewFromConfig(cfg)
+	var fallbacks []Replica[S3Client]
+	for _, region := range otherRegions(cfg.Region, cacheConfig.FallbackRegions) {
+		if bucket, ok := cacheConfig.StationListReplicaBuckets[region]; ok {
+			regionClient := s3.NewFromConfig(cfg, func(o *s3.Options) {
+				o.Region = region
+			})
+			fallbacks = append(fallbacks, Replica[S3Client]{Region: region, Client: regionClient, Bucket: bucket})
+		}
+	}
+	if len(fallbacks) > 0 {
+		client = NewRegionalS3Client(Replica[S3Client]{Region: cfg.Region, Client: client}, fallbacks...)
+	}
+	return client, nil
+}
+
+// objectKey returns the S3 key for the cache's source. Caches without a source use the original shared key.
+func (c *S3StationCache) objectKey() string {
+	if c.source == "" {
+		return cacheKey
+	}
+	return fmt.Sprintf("stations/%s.json", strings.ToLower(string(c.source)))
+}
+
 // StationListCacheRecord represents the cached station list with metadata
 type StationListCacheRecord struct {
 	Stations    []models.Station `json:"stations"`
@@ -53,7 +147,7 @@ func (c *S3StationCache) GetStations(ctx context.Context) ([]models.Station, err
 	// Get object from S3
 	result, err := c.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucketName),
-		Key:    aws.String(cacheKey),
+		Key:    aws.String(c.objectKey()),
 	})
 	if err != nil {
 		// If object doesn't exist, return nil without error
@@ -104,13 +198,16 @@ func (c *S3StationCache) SaveStations(ctx context.Context, stations []models.Sta
 	// Save to S3
 	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(c.bucketName),
-		Key:    aws.String(cacheKey),
+		Key:    aws.String(c.objectKey()),
 		Body:   bytes.NewReader(buf.Bytes()),
 	})
 	if err != nil {
 		return fmt.Errorf("saving to S3: %w", err)
 	}
 
-	log.Debug().Int("station_count", len(stations)).Msg("Saved station list to S3 cache")
+	log.Debug().
+		Str("source", string(c.source)).
+		Int("station_count", len(stations)).
+		Msg("Saved station list to S3 cache")
 	return nil
 }
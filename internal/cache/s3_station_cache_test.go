@@ -7,6 +7,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/bbernstein/flowebb-go/internal/config"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -354,3 +355,55 @@ func TestS3StationCache_ObjectKeyHandling(t *testing.T) {
 	_, _ = cache.GetStations(context.Background())
 	_ = cache.SaveStations(context.Background(), createTestStations())
 }
+
+func TestS3StationCache_SourcePartitioning(t *testing.T) {
+	cacheConfig := &config.CacheConfig{
+		StationListTTLDays:         2,
+		StationListTTLDaysBySource: map[string]int{"UKHO": 7},
+	}
+
+	tests := []struct {
+		source  models.Source
+		wantKey string
+		wantTTL time.Duration
+	}{
+		{source: models.SourceNOAA, wantKey: "stations/noaa.json", wantTTL: 48 * time.Hour},
+		{source: models.SourceUKHO, wantKey: "stations/ukho.json", wantTTL: 7 * 24 * time.Hour},
+		{source: models.SourceCHS, wantKey: "stations/chs.json", wantTTL: 48 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.source), func(t *testing.T) {
+			var savedKey string
+			mockS3 := &mockS3Client{
+				putObjectFunc: func(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+					savedKey = aws.ToString(params.Key)
+					return &s3.PutObjectOutput{}, nil
+				},
+			}
+
+			cache := NewS3StationCache(mockS3, "test-bucket", tt.source, cacheConfig)
+			assert.Equal(t, tt.wantTTL, cache.ttl)
+
+			require.NoError(t, cache.SaveStations(context.Background(), createTestStations()))
+			assert.Equal(t, tt.wantKey, savedKey)
+		})
+	}
+}
+
+func TestNewS3StationCacheFromEnv_NoBucket(t *testing.T) {
+	t.Setenv("STATION_LIST_BUCKET", "")
+
+	cache, err := NewS3StationCacheFromEnv(context.Background(), models.SourceNOAA)
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+}
+
+func TestNewStationListCacheFromEnv_Memory(t *testing.T) {
+	t.Setenv("CACHE_BACKEND", "memory")
+	t.Setenv("STATION_LIST_BUCKET", "stations")
+
+	cache, err := NewStationListCacheFromEnv(context.Background(), models.SourceNOAA)
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+}
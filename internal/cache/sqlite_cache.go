@@ -0,0 +1,322 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+
+	// Registers the sqlite3 driver. It needs cgo: in a binary built without it, like the
+	// Lambdas, opening a database fails.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations are the schema changes of the sqlite backend, in order. A database's
+// user_version counts those already applied; never edit one that has shipped, append
+// another.
+var sqliteMigrations = []string{
+	`CREATE TABLE predictions (
+		station_id   TEXT    NOT NULL,
+		date         TEXT    NOT NULL,
+		record       BLOB    NOT NULL,
+		last_updated INTEGER NOT NULL,
+		ttl          INTEGER NOT NULL,
+		PRIMARY KEY (station_id, date)
+	);
+	CREATE INDEX predictions_ttl ON predictions (ttl);
+	CREATE TABLE station_lists (
+		source       TEXT    PRIMARY KEY,
+		stations     BLOB    NOT NULL,
+		last_updated INTEGER NOT NULL,
+		ttl          INTEGER NOT NULL
+	);`,
+}
+
+var (
+	sqliteMu  sync.Mutex
+	sqliteDBs = make(map[string]*sql.DB)
+)
+
+// OpenSQLite opens the database at path, creating it and migrating its schema to the
+// latest version as needed. The prediction and station caches of a process share one
+// handle per file, so it's never closed.
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	sqliteMu.Lock()
+	defer sqliteMu.Unlock()
+	if db, ok := sqliteDBs[path]; ok {
+		return db, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("creating database directory: %w", err)
+	}
+	// WAL lets the station list be read while predictions are written, and the busy
+	// timeout waits out another process's write rather than failing
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL")
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	if err := migrateSQLite(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	sqliteDBs[path] = db
+	return db, nil
+}
+
+// migrateSQLite applies the migrations the database hasn't had, each in its own
+// transaction along with the version it brings the database to
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("schema version %d is newer than this build's %d", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, sqliteMigrations[i]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		// PRAGMA doesn't take parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		log.Info().Int("version", i+1).Msg("Migrated SQLite cache schema")
+	}
+	return nil
+}
+
+// SQLitePredictionCache stores tide predictions in a SQLite database, so a self-hosted
+// server keeps them across restarts without DynamoDB
+type SQLitePredictionCache struct {
+	db     *sql.DB
+	config atomic.Pointer[config.CacheConfig]
+	clock  clock
+}
+
+func NewSQLitePredictionCache(db *sql.DB, cacheConfig *config.CacheConfig) *SQLitePredictionCache {
+	if cacheConfig == nil {
+		cacheConfig = config.GetCacheConfig()
+	}
+	c := &SQLitePredictionCache{
+		db:    db,
+		clock: &systemClock{},
+	}
+	c.config.Store(cacheConfig)
+	return c
+}
+
+// SetConfig replaces the TTLs used by later saves
+func (c *SQLitePredictionCache) SetConfig(cacheConfig *config.CacheConfig) {
+	c.config.Store(cacheConfig)
+}
+
+func (c *SQLitePredictionCache) Name() string {
+	return config.BackendSQLite
+}
+
+// GetPredictions retrieves cached predictions for a station and date
+func (c *SQLitePredictionCache) GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+	var data []byte
+	err := c.db.QueryRowContext(ctx,
+		"SELECT record FROM predictions WHERE station_id = ? AND date = ? AND ttl > ?",
+		stationID, date.Format("2006-01-02"), c.clock.Now().Unix(),
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading predictions: %w", err)
+	}
+
+	var record models.TidePredictionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("decoding predictions for %s on %s: %w", stationID, date.Format("2006-01-02"), err)
+	}
+	return &record, nil
+}
+
+// GetPredictionsBatch retrieves cached predictions for several dates in one query,
+// returning one entry per date with nil for misses
+func (c *SQLitePredictionCache) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	records := make([]*models.TidePredictionRecord, len(dates))
+	if len(dates) == 0 {
+		return records, nil
+	}
+
+	index := make(map[string][]int, len(dates))
+	args := []interface{}{stationID, c.clock.Now().Unix()}
+	for i, date := range dates {
+		key := date.Format("2006-01-02")
+		if _, ok := index[key]; !ok {
+			args = append(args, key)
+		}
+		index[key] = append(index[key], i)
+	}
+
+	rows, err := c.db.QueryContext(ctx,
+		"SELECT date, record FROM predictions WHERE station_id = ? AND ttl > ? AND date IN (?"+strings.Repeat(", ?", len(index)-1)+")",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("reading predictions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var date string
+		var data []byte
+		if err := rows.Scan(&date, &data); err != nil {
+			return nil, fmt.Errorf("reading predictions: %w", err)
+		}
+		var record models.TidePredictionRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("decoding predictions for %s on %s: %w", stationID, date, err)
+		}
+		for _, i := range index[date] {
+			records[i] = &record
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading predictions: %w", err)
+	}
+	return records, nil
+}
+
+// SavePredictions saves predictions to the cache
+func (c *SQLitePredictionCache) SavePredictions(ctx context.Context, record models.TidePredictionRecord) error {
+	return c.SavePredictionsBatch(ctx, []models.TidePredictionRecord{record})
+}
+
+// SavePredictionsBatch saves multiple prediction records in one transaction, and deletes
+// the records that have expired, since nothing else would
+func (c *SQLitePredictionCache) SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error {
+	for _, record := range records {
+		if err := record.Validate(); err != nil {
+			return fmt.Errorf("invalid prediction record: %w", err)
+		}
+	}
+
+	now := c.clock.Now()
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("saving predictions: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, record := range records {
+		record.LastUpdated = now.Unix()
+		record.TTL = now.Add(c.config.Load().GetPredictionTTL(record.Date, now)).Unix()
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("encoding predictions: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR REPLACE INTO predictions (station_id, date, record, last_updated, ttl) VALUES (?, ?, ?, ?, ?)",
+			record.StationID, record.Date, data, record.LastUpdated, record.TTL,
+		); err != nil {
+			return fmt.Errorf("saving predictions: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM predictions WHERE ttl <= ?", now.Unix()); err != nil {
+		return fmt.Errorf("deleting expired predictions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("saving predictions: %w", err)
+	}
+	return nil
+}
+
+// DeletePredictions removes the cached record for a station and date, if any
+func (c *SQLitePredictionCache) DeletePredictions(ctx context.Context, stationID string, date time.Time) error {
+	if _, err := c.db.ExecContext(ctx,
+		"DELETE FROM predictions WHERE station_id = ? AND date = ?",
+		stationID, date.Format("2006-01-02"),
+	); err != nil {
+		return fmt.Errorf("deleting predictions: %w", err)
+	}
+	return nil
+}
+
+// SQLiteStationCache stores a source's station list in a SQLite database
+type SQLiteStationCache struct {
+	db     *sql.DB
+	source string
+	ttl    time.Duration
+	clock  clock
+}
+
+var _ StationListCacheProvider = (*SQLiteStationCache)(nil)
+
+func NewSQLiteStationCache(db *sql.DB, source models.Source, cacheConfig *config.CacheConfig) *SQLiteStationCache {
+	if cacheConfig == nil {
+		cacheConfig = config.GetCacheConfig()
+	}
+	return &SQLiteStationCache{
+		db:     db,
+		source: strings.ToLower(string(source)),
+		ttl:    cacheConfig.GetStationListTTLForSource(string(source)),
+		clock:  &systemClock{},
+	}
+}
+
+// GetStations returns the cached stations, or nil if there are none or they have expired
+func (c *SQLiteStationCache) GetStations(ctx context.Context) ([]models.Station, error) {
+	var data []byte
+	var ttl int64
+	err := c.db.QueryRowContext(ctx, "SELECT stations, ttl FROM station_lists WHERE source = ?", c.source).Scan(&data, &ttl)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading station list: %w", err)
+	}
+
+	if c.clock.Now().Unix() > ttl {
+		log.Debug().Str("source", c.source).Msg("Station list SQLite cache expired")
+		return nil, nil
+	}
+	var stations []models.Station
+	if err := json.Unmarshal(data, &stations); err != nil {
+		return nil, fmt.Errorf("decoding station list: %w", err)
+	}
+	return stations, nil
+}
+
+// SaveStations replaces the source's cached stations
+func (c *SQLiteStationCache) SaveStations(ctx context.Context, stations []models.Station) error {
+	data, err := json.Marshal(stations)
+	if err != nil {
+		return fmt.Errorf("encoding station list: %w", err)
+	}
+	now := c.clock.Now().Unix()
+	if _, err := c.db.ExecContext(ctx,
+		"INSERT OR REPLACE INTO station_lists (source, stations, last_updated, ttl) VALUES (?, ?, ?, ?)",
+		c.source, data, now, now+int64(c.ttl.Seconds()),
+	); err != nil {
+		return fmt.Errorf("saving station list: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordOn is createTestRecord's record for Seattle, moved to another day of January 2024
+func recordOn(date string) models.TidePredictionRecord {
+	record := createTestRecord("9447130", date)
+	day, _ := time.Parse("2006-01-02", date)
+	offset := day.Sub(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)).Milliseconds()
+	record.Predictions[0].Timestamp += models.Millis(offset)
+	record.Extremes[0].Timestamp += models.Millis(offset)
+	return record
+}
+
+func TestSQLitePredictionCache(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "flowebb.db"))
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cache := NewSQLitePredictionCache(db, &config.CacheConfig{TidePredictionDynamoTTLDays: 1})
+	cache.clock = &fakeClock{now: now}
+	assert.Equal(t, "sqlite", cache.Name())
+
+	// Nothing cached yet
+	record, err := cache.GetPredictions(ctx, "9447130", now)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	saved := createTestRecord("9447130", "2024-01-01")
+	require.NoError(t, cache.SavePredictionsBatch(ctx, []models.TidePredictionRecord{saved, recordOn("2024-01-03")}))
+
+	record, err = cache.GetPredictions(ctx, "9447130", now)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, saved.Predictions, record.Predictions)
+	assert.Equal(t, saved.Extremes, record.Extremes)
+	assert.Equal(t, now.Add(24*time.Hour).Unix(), record.TTL)
+
+	dates := []time.Time{now, now.AddDate(0, 0, 1), now.AddDate(0, 0, 2), now}
+	records, err := cache.GetPredictionsBatch(ctx, "9447130", dates)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.NotNil(t, records[0])
+	assert.Nil(t, records[1])
+	assert.Equal(t, "2024-01-03", records[2].Date)
+	assert.NotNil(t, records[3], "a date asked for twice is answered twice")
+
+	// Deleting a record, or one that isn't there, isn't an error
+	require.NoError(t, cache.DeletePredictions(ctx, "9447130", now.AddDate(0, 0, 2)))
+	require.NoError(t, cache.DeletePredictions(ctx, "9447130", now.AddDate(0, 0, 2)))
+	record, err = cache.GetPredictions(ctx, "9447130", now.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	// Expired records are misses, and are deleted by the next save
+	cache.clock = &fakeClock{now: now.Add(25 * time.Hour)}
+	record, err = cache.GetPredictions(ctx, "9447130", now)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	require.NoError(t, cache.SavePredictions(ctx, recordOn("2024-01-02")))
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM predictions").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// Invalid records are rejected before anything is written
+	err = cache.SavePredictionsBatch(ctx, []models.TidePredictionRecord{recordOn("2024-01-04"), {StationID: "9447130"}})
+	require.ErrorContains(t, err, "invalid prediction record")
+	record, err = cache.GetPredictions(ctx, "9447130", now.AddDate(0, 0, 3))
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestSQLiteStationCache(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(ctx, filepath.Join(t.TempDir(), "flowebb.db"))
+	require.NoError(t, err)
+	cfg := &config.CacheConfig{
+		StationListTTLDays:         1,
+		StationListTTLDaysBySource: map[string]int{"CHS": 3},
+	}
+	now := time.Now()
+
+	noaa := NewSQLiteStationCache(db, models.SourceNOAA, cfg)
+	noaa.clock = &mockClock{now: now}
+	chs := NewSQLiteStationCache(db, models.SourceCHS, cfg)
+	chs.clock = &mockClock{now: now}
+
+	stations, err := noaa.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stations)
+
+	require.NoError(t, noaa.SaveStations(ctx, createTestStations()))
+	stations, err = noaa.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, createTestStations(), stations)
+
+	// Sources are stored separately
+	stations, err = chs.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stations)
+
+	// Each source expires on its own TTL
+	require.NoError(t, chs.SaveStations(ctx, createTestStations()))
+	noaa.clock = &mockClock{now: now.Add(48 * time.Hour)}
+	chs.clock = &mockClock{now: now.Add(48 * time.Hour)}
+
+	stations, err = noaa.GetStations(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stations)
+
+	stations, err = chs.GetStations(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, stations)
+}
+
+func TestOpenSQLite_Migrations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "flowebb.db")
+	db, err := OpenSQLite(ctx, path)
+	require.NoError(t, err)
+	assert.FileExists(t, path, "the directory is created")
+
+	var version int
+	require.NoError(t, db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, len(sqliteMigrations), version)
+
+	again, err := OpenSQLite(ctx, path)
+	require.NoError(t, err)
+	assert.Same(t, db, again, "a file is opened once per process")
+
+	// Reopening a migrated database leaves it as it is
+	require.NoError(t, migrateSQLite(ctx, db))
+
+	_, err = db.Exec("PRAGMA user_version = 99")
+	require.NoError(t, err)
+	assert.ErrorContains(t, migrateSQLite(ctx, db), "newer than this build's")
+}
+
+func TestNewPredictionStore_SQLite(t *testing.T) {
+	store, err := NewPredictionStore(context.Background(), &config.CacheConfig{Backend: "sqlite", FileCacheDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", store.Name())
+}
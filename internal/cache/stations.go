@@ -3,15 +3,25 @@ package cache
 import (
 	"github.com/bbernstein/flowebb-go/internal/config"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"slices"
 	"sync"
 	"time"
 )
 
+// StationCache keeps the station list in memory. The list is stored once, with its
+// repeated values interned (see compactStations), and every reader shares it rather than
+// getting a copy per request, so treat the stations it returns as read-only: copy a station
+// before changing it, and its State, Region, Level and Capabilities before changing those.
 type StationCache struct {
 	stations    []models.Station
 	lastUpdated time.Time
 	mu          sync.RWMutex
 	ttl         time.Duration
+	maxStale    time.Duration
+
+	// HTTP validators from the response the stations came from, for conditional refreshes
+	etag         string
+	lastModified string
 }
 
 func NewStationCache(cacheConfig *config.CacheConfig) *StationCache {
@@ -25,6 +35,7 @@ func NewStationCache(cacheConfig *config.CacheConfig) *StationCache {
 		stations:    make([]models.Station, 0),
 		lastUpdated: time.Time{}, // Zero time to ensure first fetch
 		ttl:         ttl,
+		maxStale:    cacheConfig.GetStationListMaxStale(),
 	}
 }
 
@@ -35,22 +46,58 @@ func (c *StationCache) GetStations() []models.Station {
 	if c.isExpired() {
 		return nil
 	}
+	// Clipped so appending to the list copies it
+	return slices.Clip(c.stations)
+}
+
+// SetStations stores a compacted copy of stations and returns it, so callers can serve it
+// and let the original go
+func (c *StationCache) SetStations(stations []models.Station) []models.Station {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	// Create a copy of the stations slice to prevent race conditions
-	stations := make([]models.Station, len(c.stations))
-	copy(stations, c.stations)
-	return stations
+	c.stations = compactStations(stations)
+	c.lastUpdated = time.Now()
+	c.etag = ""
+	c.lastModified = ""
+	return slices.Clip(c.stations)
 }
 
-func (c *StationCache) SetStations(stations []models.Station) {
+// GetStaleStations returns expired stations that are still within the max-stale window,
+// so callers can serve them while refreshing. It returns nil if the cache is fresh or empty.
+func (c *StationCache) GetStaleStations() []models.Station {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.isExpired() || len(c.stations) == 0 || time.Since(c.lastUpdated) > c.ttl+c.maxStale {
+		return nil
+	}
+
+	return slices.Clip(c.stations)
+}
+
+// SetValidators records the ETag and Last-Modified values of the response the cached stations came from
+func (c *StationCache) SetValidators(etag, lastModified string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Create a copy of the input slice
-	newStations := make([]models.Station, len(stations))
-	copy(newStations, stations)
+	c.etag = etag
+	c.lastModified = lastModified
+}
+
+// Validators returns the ETag and Last-Modified values recorded for the cached stations
+func (c *StationCache) Validators() (etag, lastModified string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.etag, c.lastModified
+}
+
+// Touch marks the cached stations as fresh again, e.g. after the source reports they haven't changed
+func (c *StationCache) Touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	c.stations = newStations
 	c.lastUpdated = time.Now()
 }
 
@@ -115,6 +115,80 @@ func TestStationCacheExpiration(t *testing.T) {
 	assert.Nil(t, got)
 }
 
+func TestStationCacheStaleAndValidators(t *testing.T) {
+	t.Parallel()
+
+	cache := NewStationCache(&config.CacheConfig{
+		StationListTTLDays:      1,
+		StationListMaxStaleDays: 1,
+	})
+	testStations := []models.Station{{ID: "TEST001", Source: models.SourceNOAA}}
+
+	// Empty and fresh caches have nothing stale to serve
+	assert.Nil(t, cache.GetStaleStations())
+	cache.SetStations(testStations)
+	cache.SetValidators(`"abc"`, "Mon, 01 Jan 2024 00:00:00 GMT")
+	assert.Nil(t, cache.GetStaleStations())
+
+	// Expired but within the max-stale window
+	cache.lastUpdated = time.Now().Add(-36 * time.Hour)
+	assert.Nil(t, cache.GetStations())
+	assert.Equal(t, testStations, cache.GetStaleStations())
+
+	etag, lastModified := cache.Validators()
+	assert.Equal(t, `"abc"`, etag)
+	assert.Equal(t, "Mon, 01 Jan 2024 00:00:00 GMT", lastModified)
+
+	// Touch makes the same stations fresh again
+	cache.Touch()
+	assert.Equal(t, testStations, cache.GetStations())
+
+	// Past the max-stale window nothing is served
+	cache.lastUpdated = time.Now().Add(-49 * time.Hour)
+	assert.Nil(t, cache.GetStaleStations())
+
+	// New stations clear validators from the old response
+	cache.SetStations(testStations)
+	etag, lastModified = cache.Validators()
+	assert.Empty(t, etag)
+	assert.Empty(t, lastModified)
+}
+
+func TestStationCacheCompaction(t *testing.T) {
+	t.Parallel()
+
+	state := func() *string { s := "WA"; return &s }
+	stations := []models.Station{
+		{ID: "9447130", State: state(), TimeZone: "America/Los_Angeles", Capabilities: []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature}},
+		{ID: "9446484", State: state(), TimeZone: "America/Los_Angeles", Capabilities: []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature}},
+		{ID: "9444900"},
+	}
+
+	cache := NewStationCache(&config.CacheConfig{StationListTTLDays: 1})
+	stored := cache.SetStations(stations)
+	got := cache.GetStations()
+	assert.Equal(t, stations, got)
+	assert.Equal(t, stored, got)
+
+	// Repeated values are stored once
+	assert.Same(t, got[0].State, got[1].State)
+	assert.Same(t, &got[0].Capabilities[0], &got[1].Capabilities[0])
+	assert.Nil(t, got[2].State)
+	assert.Nil(t, got[2].Capabilities)
+
+	// Changing what was set doesn't reach the cache
+	*stations[0].State = "OR"
+	stations[0].Capabilities[0] = "CHANGED"
+	assert.Equal(t, "WA", *cache.GetStations()[0].State)
+	assert.Equal(t, models.CapabilityWaterLevel, cache.GetStations()[0].Capabilities[0])
+
+	// Shared lists are clipped, so appending to one copies it
+	_ = append(got[0].Capabilities, models.CapabilityConductivity)
+	assert.Len(t, cache.GetStations()[1].Capabilities, 2)
+	_ = append(got, models.Station{ID: "extra"})
+	assert.Len(t, cache.GetStations(), 3)
+}
+
 func TestConcurrentStationAccess(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping concurrent test in short mode")
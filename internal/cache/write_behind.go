@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// BatchSaver persists prediction records
+type BatchSaver interface {
+	SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error
+}
+
+// WriteBehindOptions configures a WriteBehindQueue
+type WriteBehindOptions struct {
+	Workers      int
+	QueueSize    int
+	MaxRetries   int
+	RetryDelay   time.Duration // Doubled after each failed attempt
+	WriteTimeout time.Duration // Per attempt
+}
+
+// WriteBehindQueue saves prediction records in the background with a bounded pool of
+// workers. Unlike a bare goroutine, callers can Flush it before Lambda freezes the
+// instance, and writes that can't be saved are retried and then logged as dead letters.
+type WriteBehindQueue struct {
+	saver BatchSaver
+	opts  WriteBehindOptions
+	jobs  chan []models.TidePredictionRecord
+
+	mu      sync.Mutex
+	closed  bool
+	pending int
+	idle    chan struct{} // Closed whenever pending is zero
+
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+// NewWriteBehindQueue starts the queue's workers
+func NewWriteBehindQueue(saver BatchSaver, opts WriteBehindOptions) *WriteBehindQueue {
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 100 * time.Millisecond
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+
+	q := &WriteBehindQueue{
+		saver: saver,
+		opts:  opts,
+		jobs:  make(chan []models.TidePredictionRecord, opts.QueueSize),
+		idle:  make(chan struct{}),
+	}
+	close(q.idle)
+
+	for i := 0; i < opts.Workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+// Enqueue schedules records to be saved without blocking. If the queue is full or closed
+// the records are dead-lettered; they can always be refetched from NOAA.
+func (q *WriteBehindQueue) Enqueue(records []models.TidePredictionRecord) {
+	if len(records) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		q.dropped.Add(1)
+		deadLetter(records, "queue closed", nil)
+		return
+	}
+
+	select {
+	case q.jobs <- records:
+		if q.pending == 0 {
+			q.idle = make(chan struct{})
+		}
+		q.pending++
+		q.mu.Unlock()
+	default:
+		q.mu.Unlock()
+		q.dropped.Add(1)
+		deadLetter(records, "queue full", nil)
+	}
+}
+
+// Flush waits until every queued write has finished or ctx is done
+func (q *WriteBehindQueue) Flush(ctx context.Context) error {
+	q.mu.Lock()
+	idle := q.idle
+	q.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flushing cache writes: %w", ctx.Err())
+	}
+}
+
+// Close stops accepting writes and flushes the ones already queued
+func (q *WriteBehindQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	return q.Flush(ctx)
+}
+
+// FlushOnSignal closes the queue when the process receives one of sigs, e.g. the SIGTERM
+// Lambda sends before shutting an instance down
+func (q *WriteBehindQueue) FlushOnSignal(timeout time.Duration, sigs ...os.Signal) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	go func() {
+		sig := <-ch
+		log.Info().Str("signal", sig.String()).Msg("Flushing cache writes before shutdown")
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := q.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("Cache writes still pending at shutdown")
+		}
+	}()
+}
+
+// Stats reports the queue's backlog and how many writes it has given up on
+func (q *WriteBehindQueue) Stats() map[string]uint64 {
+	q.mu.Lock()
+	pending := q.pending
+	q.mu.Unlock()
+
+	return map[string]uint64{
+		"write_pending": uint64(pending),
+		"write_dropped": q.dropped.Load(),
+		"write_failed":  q.failed.Load(),
+	}
+}
+
+func (q *WriteBehindQueue) worker() {
+	for records := range q.jobs {
+		q.save(records)
+
+		q.mu.Lock()
+		q.pending--
+		if q.pending == 0 {
+			close(q.idle)
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *WriteBehindQueue) save(records []models.TidePredictionRecord) {
+	var err error
+	for attempt := 0; attempt <= q.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(q.opts.RetryDelay << (attempt - 1))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), q.opts.WriteTimeout)
+		err = q.saver.SavePredictionsBatch(ctx, records)
+		cancel()
+		if err == nil {
+			return
+		}
+		log.Warn().Err(err).Int("attempt", attempt+1).Msg("Error saving predictions to cache")
+	}
+
+	q.failed.Add(1)
+	deadLetter(records, "retries exhausted", err)
+}
+
+// deadLetter logs enough about abandoned records to find and re-warm them later
+func deadLetter(records []models.TidePredictionRecord, reason string, err error) {
+	keys := make([]string, len(records))
+	for i, record := range records {
+		keys[i] = getCacheKey(record.StationID, record.Date)
+	}
+	log.Error().Err(err).
+		Str("reason", reason).
+		Strs("keys", keys).
+		Msg("Dead-lettered cache write")
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSaver records saved batches, failing the first failures calls and blocking while gate is open
+type fakeSaver struct {
+	mu       sync.Mutex
+	saved    [][]models.TidePredictionRecord
+	calls    int
+	failures int
+	gate     chan struct{}
+}
+
+func (f *fakeSaver) SavePredictionsBatch(_ context.Context, records []models.TidePredictionRecord) error {
+	if f.gate != nil {
+		<-f.gate
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("throttled")
+	}
+	f.saved = append(f.saved, records)
+	return nil
+}
+
+func (f *fakeSaver) snapshot() (int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls, len(f.saved)
+}
+
+func TestWriteBehindQueue_FlushWaitsForWrites(t *testing.T) {
+	saver := &fakeSaver{gate: make(chan struct{})}
+	q := NewWriteBehindQueue(saver, WriteBehindOptions{Workers: 2, QueueSize: 10})
+
+	q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-01")})
+	q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-02")})
+	assert.Equal(t, uint64(2), q.Stats()["write_pending"])
+
+	// Flush gives up at the deadline while writes are still blocked
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := q.Flush(ctx)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(saver.gate)
+	require.NoError(t, q.Flush(context.Background()))
+
+	_, saved := saver.snapshot()
+	assert.Equal(t, 2, saved)
+	assert.Equal(t, uint64(0), q.Stats()["write_pending"])
+}
+
+func TestWriteBehindQueue_RetriesThenDeadLetters(t *testing.T) {
+	t.Run("recovers after transient errors", func(t *testing.T) {
+		saver := &fakeSaver{failures: 2}
+		q := NewWriteBehindQueue(saver, WriteBehindOptions{MaxRetries: 2, RetryDelay: time.Millisecond})
+
+		q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-01")})
+		require.NoError(t, q.Flush(context.Background()))
+
+		calls, saved := saver.snapshot()
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 1, saved)
+		assert.Equal(t, uint64(0), q.Stats()["write_failed"])
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		saver := &fakeSaver{failures: 10}
+		q := NewWriteBehindQueue(saver, WriteBehindOptions{MaxRetries: 1, RetryDelay: time.Millisecond})
+
+		q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-01")})
+		require.NoError(t, q.Flush(context.Background()))
+
+		calls, saved := saver.snapshot()
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 0, saved)
+		assert.Equal(t, uint64(1), q.Stats()["write_failed"])
+	})
+}
+
+func TestWriteBehindQueue_Bounded(t *testing.T) {
+	saver := &fakeSaver{gate: make(chan struct{})}
+	q := NewWriteBehindQueue(saver, WriteBehindOptions{Workers: 1, QueueSize: 1})
+
+	// One write in the worker, one in the buffer; the next has nowhere to go
+	q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-01")})
+	require.Eventually(t, func() bool { return len(q.jobs) == 0 }, time.Second, time.Millisecond)
+	q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-02")})
+	q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-03")})
+	assert.Equal(t, uint64(1), q.Stats()["write_dropped"])
+
+	close(saver.gate)
+	require.NoError(t, q.Close(context.Background()))
+	_, saved := saver.snapshot()
+	assert.Equal(t, 2, saved)
+
+	// Writes after Close are dropped rather than panicking
+	q.Enqueue([]models.TidePredictionRecord{createTestRecord("9447130", "2024-01-04")})
+	assert.Equal(t, uint64(2), q.Stats()["write_dropped"])
+}
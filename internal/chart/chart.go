@@ -0,0 +1,145 @@
+// Package chart renders tide curves as images for places without a JS charting stack,
+// such as emails, alerts and embeds.
+package chart
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Format is an image format a chart can be rendered in
+type Format string
+
+const (
+	FormatSVG Format = "svg"
+	FormatPNG Format = "png"
+)
+
+// ContentType returns the format's MIME type
+func (f Format) ContentType() string {
+	if f == FormatPNG {
+		return "image/png"
+	}
+	return "image/svg+xml"
+}
+
+// Size bounds in pixels
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 300
+	MinWidth      = 200
+	MaxWidth      = 2000
+	MinHeight     = 100
+	MaxHeight     = 1000
+)
+
+// Options control how a chart is drawn. Zero sizes use the defaults.
+type Options struct {
+	Width  int
+	Height int
+	// Title is drawn across the top of SVG charts; PNG charts have no room for free text
+	Title string
+}
+
+// Render draws predictions as a curve with each extreme marked and labeled with its type,
+// height and local time
+func Render(format Format, predictions []models.TidePrediction, extremes []models.TideExtreme, opts Options) ([]byte, error) {
+	if opts.Width == 0 {
+		opts.Width = DefaultWidth
+	}
+	if opts.Height == 0 {
+		opts.Height = DefaultHeight
+	}
+	if opts.Width < MinWidth || opts.Width > MaxWidth || opts.Height < MinHeight || opts.Height > MaxHeight {
+		return nil, fmt.Errorf("chart size must be %d to %d wide and %d to %d high", MinWidth, MaxWidth, MinHeight, MaxHeight)
+	}
+	if len(predictions) < 2 {
+		return nil, fmt.Errorf("a chart needs at least 2 predictions, got %d", len(predictions))
+	}
+
+	l := newLayout(predictions, opts)
+	switch format {
+	case FormatSVG:
+		return renderSVG(l, predictions, extremes, opts), nil
+	case FormatPNG:
+		return renderPNG(l, predictions, extremes)
+	default:
+		return nil, fmt.Errorf("invalid chart format %q: must be svg or png", format)
+	}
+}
+
+// Margins around the plot, leaving room for the title, axis labels and extreme labels
+const (
+	marginLeft   = 44
+	marginRight  = 16
+	marginTop    = 36
+	marginBottom = 36
+)
+
+// layout maps times and heights onto the plot area
+type layout struct {
+	width, height int
+	minT, maxT    models.Millis
+	minH, maxH    float64
+	plotW, plotH  float64
+	dayTicks      []int // indexes of predictions at local midnight
+}
+
+func newLayout(predictions []models.TidePrediction, opts Options) layout {
+	l := layout{
+		width:  opts.Width,
+		height: opts.Height,
+		minT:   predictions[0].Timestamp,
+		maxT:   predictions[len(predictions)-1].Timestamp,
+		minH:   math.Inf(1),
+		maxH:   math.Inf(-1),
+		plotW:  float64(opts.Width - marginLeft - marginRight),
+		plotH:  float64(opts.Height - marginTop - marginBottom),
+	}
+	for i, p := range predictions {
+		l.minH = math.Min(l.minH, p.Height)
+		l.maxH = math.Max(l.maxH, p.Height)
+		if strings.HasSuffix(p.LocalTime, "T00:00:00") {
+			l.dayTicks = append(l.dayTicks, i)
+		}
+	}
+	if l.maxT == l.minT {
+		l.maxT++
+	}
+	if l.maxH == l.minH {
+		l.minH, l.maxH = l.minH-1, l.maxH+1
+	}
+	return l
+}
+
+func (l layout) x(timestamp models.Millis) float64 {
+	return marginLeft + float64(timestamp-l.minT)/float64(l.maxT-l.minT)*l.plotW
+}
+
+func (l layout) y(height float64) float64 {
+	return marginTop + (l.maxH-height)/(l.maxH-l.minH)*l.plotH
+}
+
+// extremeLabel describes an extreme as, e.g., "H 9.4 07:12" in the station's local time
+func extremeLabel(e models.TideExtreme) string {
+	typ := "L"
+	if e.Type == models.TideTypeHigh {
+		typ = "H"
+	}
+	label := fmt.Sprintf("%s %.1f", typ, e.Height)
+	if len(e.LocalTime) >= len("2006-01-02T15:04") {
+		label += " " + e.LocalTime[11:16]
+	}
+	return label
+}
+
+// dayLabel names the local day starting at a midnight prediction, e.g. "01-02"
+func dayLabel(p models.TidePrediction) string {
+	if len(p.LocalTime) < len("2006-01-02") {
+		return ""
+	}
+	return p.LocalTime[5:10]
+}
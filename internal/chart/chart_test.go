@@ -0,0 +1,96 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSeries is two days of 6-minute predictions, low at midnight and high at noon
+func testSeries() ([]models.TidePrediction, []models.TideExtreme) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var predictions []models.TidePrediction
+	for t := start; !t.After(start.AddDate(0, 0, 2)); t = t.Add(6 * time.Minute) {
+		hours := t.Sub(start).Hours()
+		predictions = append(predictions, models.TidePrediction{
+			Timestamp: models.MillisOf(t),
+			LocalTime: t.Format("2006-01-02T15:04:05"),
+			Height:    4 - 4*math.Cos(hours*math.Pi/12),
+		})
+	}
+	high := start.Add(12 * time.Hour)
+	extremes := []models.TideExtreme{
+		{Type: models.TideTypeHigh, Timestamp: models.MillisOf(high), LocalTime: high.Format("2006-01-02T15:04:05"), Height: 8},
+		{Type: models.TideTypeLow, Timestamp: models.MillisOf(start.AddDate(0, 0, 1)), LocalTime: "2024-01-02T00:00:00", Height: 0},
+	}
+	return predictions, extremes
+}
+
+func TestRender_SVG(t *testing.T) {
+	predictions, extremes := testSeries()
+
+	svg, err := Render(FormatSVG, predictions, extremes, Options{Title: "Seattle & Tacoma"})
+	require.NoError(t, err)
+	s := string(svg)
+	assert.True(t, strings.HasPrefix(s, `<svg xmlns="http://www.w3.org/2000/svg" width="800" height="300"`))
+	assert.Contains(t, s, "Seattle &amp; Tacoma")
+	assert.Contains(t, s, "<polyline")
+	assert.Contains(t, s, ">H 8.0 12:00<")
+	assert.Contains(t, s, ">L 0.0 00:00<")
+	assert.Contains(t, s, ">01-02<", "day ticks are labeled")
+	assert.Equal(t, len(predictions), len(strings.Fields(s[strings.Index(s, `points="`):strings.Index(s, `"/>`+"<circle")])))
+}
+
+func TestRender_PNG(t *testing.T) {
+	predictions, extremes := testSeries()
+
+	data, err := Render(FormatPNG, predictions, extremes, Options{Width: 400, Height: 200})
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 400, img.Bounds().Dx())
+	assert.Equal(t, 200, img.Bounds().Dy())
+
+	// The high tide's marker is drawn in the curve color
+	l := newLayout(predictions, Options{Width: 400, Height: 200})
+	r, g, b, _ := img.At(int(l.x(extremes[0].Timestamp)), int(l.y(8))).RGBA()
+	assert.Equal(t, [3]uint32{0x1f, 0x77, 0xb4}, [3]uint32{r >> 8, g >> 8, b >> 8})
+	r, g, b, _ = img.At(0, 0).RGBA()
+	assert.Equal(t, [3]uint32{0xff, 0xff, 0xff}, [3]uint32{r >> 8, g >> 8, b >> 8})
+}
+
+func TestRender_Errors(t *testing.T) {
+	predictions, extremes := testSeries()
+
+	for name, tc := range map[string]struct {
+		format      Format
+		predictions []models.TidePrediction
+		opts        Options
+	}{
+		"unknown format": {"gif", predictions, Options{}},
+		"too narrow":     {FormatSVG, predictions, Options{Width: MinWidth - 1}},
+		"too tall":       {FormatPNG, predictions, Options{Height: MaxHeight + 1}},
+		"no curve":       {FormatSVG, predictions[:1], Options{}},
+	} {
+		_, err := Render(tc.format, tc.predictions, extremes, tc.opts)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestExtremeLabel(t *testing.T) {
+	for want, e := range map[string]models.TideExtreme{
+		"H 9.4 07:12":  {Type: models.TideTypeHigh, LocalTime: "2024-01-01T07:12:00", Height: 9.38},
+		"L -1.2 19:48": {Type: models.TideTypeLow, LocalTime: "2024-01-01T19:48:00", Height: -1.2},
+		"L 0.5":        {Type: models.TideTypeLow, Height: 0.5},
+	} {
+		assert.Equal(t, want, extremeLabel(e), fmt.Sprint(e))
+	}
+}
@@ -0,0 +1,90 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+var (
+	curveRGBA = color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}
+	axisRGBA  = color.RGBA{R: 0x88, G: 0x88, B: 0x88, A: 0xff}
+	textRGBA  = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+)
+
+func renderPNG(l layout, predictions []models.TidePrediction, extremes []models.TideExtreme) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	bottom := float64(l.height - marginBottom)
+	drawLine(img, marginLeft, marginTop, marginLeft, bottom, 1, axisRGBA)
+	drawLine(img, marginLeft, bottom, float64(l.width-marginRight), bottom, 1, axisRGBA)
+	for _, h := range []float64{l.minH, l.maxH} {
+		label := fmt.Sprintf("%.1f", h)
+		drawText(img, marginLeft-4-textWidth(label), int(l.y(h))-glyphHeight/2, label, textRGBA)
+	}
+	for _, i := range l.dayTicks {
+		x := l.x(predictions[i].Timestamp)
+		for y := float64(marginTop); y < bottom; y += 5 {
+			drawLine(img, x, y, x, math.Min(y+2, bottom), 1, axisRGBA)
+		}
+		label := dayLabel(predictions[i])
+		drawText(img, int(x)-textWidth(label)/2, int(bottom)+6, label, textRGBA)
+	}
+
+	for i := 1; i < len(predictions); i++ {
+		prev, p := predictions[i-1], predictions[i]
+		drawLine(img, l.x(prev.Timestamp), l.y(prev.Height), l.x(p.Timestamp), l.y(p.Height), 2, curveRGBA)
+	}
+
+	for _, e := range extremes {
+		if e.Timestamp < l.minT || e.Timestamp > l.maxT {
+			continue
+		}
+		x, y := l.x(e.Timestamp), l.y(e.Height)
+		fillCircle(img, x, y, 3, curveRGBA)
+		label := extremeLabel(e)
+		labelY := int(y) - 8 - glyphHeight
+		if e.Type != models.TideTypeHigh {
+			labelY = int(y) + 8
+		}
+		drawText(img, int(x)-textWidth(label)/2, labelY, label, textRGBA)
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, fmt.Errorf("encoding chart: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// drawLine draws a line width pixels thick by stamping squares along it
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, width int, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / float64(steps)
+		x, y := int(math.Round(x0+(x1-x0)*t)), int(math.Round(y0+(y1-y0)*t))
+		for dx := 0; dx < width; dx++ {
+			for dy := 0; dy < width; dy++ {
+				img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+}
+
+func fillCircle(img *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			if dx, dy := float64(x)-cx, float64(y)-cy; dx*dx+dy*dy <= r*r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
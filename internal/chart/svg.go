@@ -0,0 +1,62 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+const (
+	curveColor = "#1f77b4"
+	axisColor  = "#888888"
+	textColor  = "#333333"
+)
+
+func renderSVG(l layout, predictions []models.TidePrediction, extremes []models.TideExtreme, opts Options) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		l.width, l.height, l.width, l.height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`)
+	if opts.Title != "" {
+		fmt.Fprintf(&b, `<text x="%d" y="16" fill="%s" font-size="13">%s</text>`, marginLeft, textColor, html.EscapeString(opts.Title))
+	}
+
+	// Axes, with the height range on the left and local days along the bottom
+	bottom := float64(l.height - marginBottom)
+	fmt.Fprintf(&b, `<path d="M%d %d V%.1f H%d" fill="none" stroke="%s"/>`, marginLeft, marginTop, bottom, l.width-marginRight, axisColor)
+	for _, h := range []float64{l.minH, l.maxH} {
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" fill="%s" text-anchor="end">%.1f</text>`, marginLeft-4, l.y(h)+4, textColor, h)
+	}
+	for _, i := range l.dayTicks {
+		x := l.x(predictions[i].Timestamp)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%.1f" stroke="%s" stroke-dasharray="2,3"/>`, x, marginTop, x, bottom, axisColor)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="middle">%s</text>`, x, bottom+14, textColor, dayLabel(predictions[i]))
+	}
+
+	fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="2" points="`, curveColor)
+	for i, p := range predictions {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f", l.x(p.Timestamp), l.y(p.Height))
+	}
+	b.WriteString(`"/>`)
+
+	for _, e := range extremes {
+		if e.Timestamp < l.minT || e.Timestamp > l.maxT {
+			continue
+		}
+		x, y := l.x(e.Timestamp), l.y(e.Height)
+		// Highs are labeled above the curve and lows below it
+		labelY := y - 8
+		if e.Type != models.TideTypeHigh {
+			labelY = y + 16
+		}
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`, x, y, curveColor)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" fill="%s" text-anchor="middle">%s</text>`, x, labelY, textColor, extremeLabel(e))
+	}
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
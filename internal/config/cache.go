@@ -2,10 +2,9 @@ package config
 
 import (
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
 	"time"
-
-	"github.com/rs/zerolog/log"
 )
 
 // CacheConfig holds all cache-related configuration
@@ -13,10 +12,41 @@ type CacheConfig struct {
 	// LRU Cache settings
 	TidePredictionLRUSize       int
 	TidePredictionLRUTTLMinutes int
+	TidePredictionLRUMaxMB      int // Approximate memory budget for cached records; 0 disables the limit
 
 	// DynamoDB Cache settings
 	TidePredictionDynamoTTLDays int
+	HistoricalTTLDays           int // For days already over, whose predictions never change; 0 uses the TTL above
+	DynamoCompressMinBytes      int // Gzip predictions/extremes once their JSON reaches this size; negative disables
 	StationListTTLDays          int
+	StationListMaxStaleDays     int
+	// Per-source overrides of StationListTTLDays, keyed by source name (NOAA, UKHO, CHS)
+	StationListTTLDaysBySource map[string]int
+	// StationListBucket is the S3 bucket station lists are kept in; empty keeps them in memory
+	StationListBucket string
+
+	// DynamoDBEndpoint points the DynamoDB client at e.g. DynamoDB Local instead of AWS
+	DynamoDBEndpoint string
+
+	// FallbackRegions are tried in order when a read from the function's own region's
+	// prediction table or station list bucket fails. The table is a DynamoDB global table
+	// with a replica in each; writes go to the own region and replicate from there.
+	FallbackRegions []string
+	// StationListReplicaBuckets name the replica of StationListBucket in fallback regions,
+	// keyed by region, for buckets kept in step by S3 replication
+	StationListReplicaBuckets map[string]string
+
+	// Second cache tier behind the LRU: "dynamo" (default), "redis", "file", "sqlite" or
+	// "memory", which has none. Demo mode always uses memory.
+	Backend      string
+	FileCacheDir string
+	// SQLitePath is the database file of the sqlite backend, flowebb.db in FileCacheDir
+	// when empty
+	SQLitePath    string
+	RedisAddr     string
+	RedisPassword string `config:"secret"`
+	RedisDB       int
+	RedisTLS      bool
 
 	// GraphQL Cache settings
 	GraphQLLRUSize       int
@@ -26,53 +56,107 @@ type CacheConfig struct {
 	BatchSize       int
 	MaxBatchRetries int
 
+	// Write-behind queue for saving fetched predictions outside the request path
+	WriteBehindWorkers    int
+	WriteBehindQueueSize  int
+	WriteBehindMaxRetries int
+
 	// General settings
 	EnableLRUCache    bool
 	EnableDynamoCache bool
 }
 
+// Prediction cache backends
+const (
+	BackendDynamo = "dynamo"
+	BackendRedis  = "redis"
+	BackendFile   = "file"
+	BackendSQLite = "sqlite"
+	BackendMemory = "memory"
+)
+
+// historicalAfter is how long after the UTC start of a day it's over in every time zone,
+// UTC-12 being the last
+const historicalAfter = 36 * time.Hour
+
+// stationListSources are the data sources whose station lists can have their own TTL
+var stationListSources = []string{"NOAA", "UKHO", "CHS"}
+
 const (
 	// Default values
 	defaultTidePredictionLRUSize    = 1000
 	defaultTidePredictionTTLMinutes = 15
+	defaultTidePredictionLRUMaxMB   = 64
 	defaultDynamoTTLDays            = 2
+	defaultHistoricalTTLDays        = 365
+	defaultDynamoCompressMinBytes   = 4096
 	defaultStationListTTLDays       = 2
+	defaultStationListMaxStaleDays  = 7
 	defaultGraphQLLRUSize           = 5000
 	defaultGraphQLTTLMinutes        = 60
 	defaultBatchSize                = 25
 	defaultMaxBatchRetries          = 3
+	defaultRedisAddr                = "localhost:6379"
+	defaultWriteBehindWorkers       = 2
+	defaultWriteBehindQueueSize     = 64
+	defaultWriteBehindMaxRetries    = 2
 )
 
-// GetCacheConfig returns the cache configuration from environment variables or defaults
+// GetCacheConfig reads the cache configuration from environment variables, with defaults
+// for those that aren't set
 func GetCacheConfig() *CacheConfig {
-	config := &CacheConfig{
-		// Set defaults
-		TidePredictionLRUSize:       getEnvInt("CACHE_TIDE_LRU_SIZE", defaultTidePredictionLRUSize),
-		TidePredictionLRUTTLMinutes: getEnvInt("CACHE_TIDE_LRU_TTL_MINUTES", defaultTidePredictionTTLMinutes),
-		TidePredictionDynamoTTLDays: getEnvInt("CACHE_DYNAMO_TTL_DAYS", defaultDynamoTTLDays),
-		StationListTTLDays:          getEnvInt("CACHE_STATION_LIST_TTL_DAYS", defaultStationListTTLDays),
-		GraphQLLRUSize:              getEnvInt("CACHE_GRAPHQL_LRU_SIZE", defaultGraphQLLRUSize),
-		GraphQLLRUTTLMinutes:        getEnvInt("CACHE_GRAPHQL_TTL_MINUTES", defaultGraphQLTTLMinutes),
-		BatchSize:                   getEnvInt("CACHE_BATCH_SIZE", defaultBatchSize),
-		MaxBatchRetries:             getEnvInt("CACHE_MAX_BATCH_RETRIES", defaultMaxBatchRetries),
-		EnableLRUCache:              getEnvBool("CACHE_ENABLE_LRU", true),
-		EnableDynamoCache:           getEnvBool("CACHE_ENABLE_DYNAMO", true),
+	return newLoader(nil).cacheConfig()
+}
+
+func (l *loader) cacheConfig() *CacheConfig {
+	return &CacheConfig{
+		TidePredictionLRUSize:       l.int("CACHE_TIDE_LRU_SIZE", defaultTidePredictionLRUSize),
+		TidePredictionLRUTTLMinutes: l.int("CACHE_TIDE_LRU_TTL_MINUTES", defaultTidePredictionTTLMinutes),
+		TidePredictionLRUMaxMB:      l.int("CACHE_TIDE_LRU_MAX_MB", defaultTidePredictionLRUMaxMB),
+		TidePredictionDynamoTTLDays: l.int("CACHE_DYNAMO_TTL_DAYS", defaultDynamoTTLDays),
+		HistoricalTTLDays:           l.int("CACHE_HISTORICAL_TTL_DAYS", defaultHistoricalTTLDays),
+		DynamoCompressMinBytes:      l.int("CACHE_DYNAMO_COMPRESS_MIN_BYTES", defaultDynamoCompressMinBytes),
+		StationListTTLDays:          l.int("CACHE_STATION_LIST_TTL_DAYS", defaultStationListTTLDays),
+		StationListMaxStaleDays:     l.int("CACHE_STATION_LIST_MAX_STALE_DAYS", defaultStationListMaxStaleDays),
+		StationListTTLDaysBySource:  l.sourceTTLDays("CACHE_STATION_LIST_TTL_DAYS_", stationListSources),
+		StationListBucket:           l.string("STATION_LIST_BUCKET", ""),
+		DynamoDBEndpoint:            l.string("DYNAMODB_ENDPOINT", ""),
+		FallbackRegions:             l.list("CACHE_FALLBACK_REGIONS"),
+		StationListReplicaBuckets:   l.pairs("STATION_LIST_REPLICA_BUCKETS"),
+		Backend:                     l.cacheBackend(),
+		FileCacheDir:                l.string("CACHE_DIR", filepath.Join(os.TempDir(), "flowebb-cache")),
+		SQLitePath:                  l.string("CACHE_SQLITE_PATH", ""),
+		RedisAddr:                   l.string("CACHE_REDIS_ADDR", defaultRedisAddr),
+		RedisPassword:               l.string("CACHE_REDIS_PASSWORD", ""),
+		RedisDB:                     l.int("CACHE_REDIS_DB", 0),
+		RedisTLS:                    l.bool("CACHE_REDIS_TLS", false),
+		GraphQLLRUSize:              l.int("CACHE_GRAPHQL_LRU_SIZE", defaultGraphQLLRUSize),
+		GraphQLLRUTTLMinutes:        l.int("CACHE_GRAPHQL_TTL_MINUTES", defaultGraphQLTTLMinutes),
+		BatchSize:                   l.int("CACHE_BATCH_SIZE", defaultBatchSize),
+		MaxBatchRetries:             l.int("CACHE_MAX_BATCH_RETRIES", defaultMaxBatchRetries),
+		WriteBehindWorkers:          l.int("CACHE_WRITE_WORKERS", defaultWriteBehindWorkers),
+		WriteBehindQueueSize:        l.int("CACHE_WRITE_QUEUE_SIZE", defaultWriteBehindQueueSize),
+		WriteBehindMaxRetries:       l.int("CACHE_WRITE_MAX_RETRIES", defaultWriteBehindMaxRetries),
+		EnableLRUCache:              l.bool("CACHE_ENABLE_LRU", true),
+		EnableDynamoCache:           l.bool("CACHE_ENABLE_DYNAMO", true),
 	}
+}
 
-	log.Debug().
-		Int("TidePredictionLRUSize", config.TidePredictionLRUSize).
-		Int("TidePredictionLRUTTLMinutes", config.TidePredictionLRUTTLMinutes).
-		Int("TidePredictionDynamoTTLDays", config.TidePredictionDynamoTTLDays).
-		Int("StationListTTLDays", config.StationListTTLDays).
-		Int("GraphQLLRUSize", config.GraphQLLRUSize).
-		Int("GraphQLLRUTTLMinutes", config.GraphQLLRUTTLMinutes).
-		Int("BatchSize", config.BatchSize).
-		Int("MaxBatchRetries", config.MaxBatchRetries).
-		Bool("EnableLRUCache", config.EnableLRUCache).
-		Bool("EnableDynamoCache", config.EnableDynamoCache).
-		Msg("Cache configuration loaded")
-
-	return config
+// cacheBackend reads CACHE_BACKEND, except in demo mode, which keeps everything in memory
+// so it needs no AWS resources
+func (l *loader) cacheBackend() string {
+	if l.bool("DEMO_MODE", false) {
+		return BackendMemory
+	}
+	return l.string("CACHE_BACKEND", BackendDynamo)
+}
+
+// GetSQLitePath returns the sqlite backend's database file
+func (c *CacheConfig) GetSQLitePath() string {
+	if c.SQLitePath != "" {
+		return c.SQLitePath
+	}
+	return filepath.Join(c.FileCacheDir, "flowebb.db")
 }
 
 // Helper methods for the CacheConfig struct
@@ -80,6 +164,11 @@ func (c *CacheConfig) GetTidePredictionLRUTTL() time.Duration {
 	return time.Duration(c.TidePredictionLRUTTLMinutes) * time.Minute
 }
 
+// GetTidePredictionLRUMaxBytes returns the LRU memory budget in bytes, or 0 if unlimited
+func (c *CacheConfig) GetTidePredictionLRUMaxBytes() int64 {
+	return int64(c.TidePredictionLRUMaxMB) * 1024 * 1024
+}
+
 func (c *CacheConfig) GetGraphQLLRUTTL() time.Duration {
 	return time.Duration(c.GraphQLLRUTTLMinutes) * time.Minute
 }
@@ -88,24 +177,32 @@ func (c *CacheConfig) GetDynamoTTL() time.Duration {
 	return time.Duration(c.TidePredictionDynamoTTLDays) * 24 * time.Hour
 }
 
+// GetPredictionTTL returns how long the second tier keeps a station's predictions for
+// date, a YYYY-MM-DD day in the station's time zone. Once that day is over in every time
+// zone its predictions are immutable, so it gets the historical TTL.
+func (c *CacheConfig) GetPredictionTTL(date string, now time.Time) time.Duration {
+	if c.HistoricalTTLDays <= 0 {
+		return c.GetDynamoTTL()
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil || now.Before(day.Add(historicalAfter)) {
+		return c.GetDynamoTTL()
+	}
+	return time.Duration(c.HistoricalTTLDays) * 24 * time.Hour
+}
+
 func (c *CacheConfig) GetStationListTTL() time.Duration {
 	return time.Duration(c.StationListTTLDays) * 24 * time.Hour
 }
 
-// Helper functions to get environment variables with defaults
-func getEnvInt(key string, defaultVal int) int {
-	if val, exists := os.LookupEnv(key); exists {
-		if intVal, err := strconv.Atoi(val); err == nil {
-			return intVal
-		}
-		log.Warn().Str("key", key).Msg("Invalid integer value in environment variable, using default")
+// GetStationListTTLForSource returns the station list TTL for a data source, falling back to the shared TTL
+func (c *CacheConfig) GetStationListTTLForSource(source string) time.Duration {
+	if days, ok := c.StationListTTLDaysBySource[strings.ToUpper(source)]; ok {
+		return time.Duration(days) * 24 * time.Hour
 	}
-	return defaultVal
+	return c.GetStationListTTL()
 }
 
-func getEnvBool(key string, defaultVal bool) bool {
-	if val, exists := os.LookupEnv(key); exists {
-		return val == "true" || val == "1" || val == "yes"
-	}
-	return defaultVal
+func (c *CacheConfig) GetStationListMaxStale() time.Duration {
+	return time.Duration(c.StationListMaxStaleDays) * 24 * time.Hour
 }
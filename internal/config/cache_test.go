@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var envMutex sync.Mutex
@@ -183,6 +184,21 @@ func TestEnvironmentOverrides(t *testing.T) {
 				assert.Equal(t, 14*24*time.Hour, c.GetDynamoTTL())
 			},
 		},
+		{
+			name: "per-source station list TTL",
+			envVars: map[string]string{
+				"CACHE_STATION_LIST_TTL_DAYS":      "2",
+				"CACHE_STATION_LIST_TTL_DAYS_UKHO": "7",
+				"CACHE_STATION_LIST_TTL_DAYS_CHS":  "bogus",
+			},
+			check: func(t *testing.T, c *CacheConfig) {
+				assert.Equal(t, 7*24*time.Hour, c.GetStationListTTLForSource("UKHO"))
+				assert.Equal(t, 7*24*time.Hour, c.GetStationListTTLForSource("ukho"))
+				// Unset and invalid overrides use the shared TTL
+				assert.Equal(t, 2*24*time.Hour, c.GetStationListTTLForSource("NOAA"))
+				assert.Equal(t, 2*24*time.Hour, c.GetStationListTTLForSource("CHS"))
+			},
+		},
 		{
 			name: "invalid numeric values",
 			envVars: map[string]string{
@@ -205,6 +221,8 @@ func TestEnvironmentOverrides(t *testing.T) {
 		"CACHE_TIDE_LRU_TTL_MINUTES",
 		"CACHE_DYNAMO_TTL_DAYS",
 		"CACHE_STATION_LIST_TTL_DAYS",
+		"CACHE_STATION_LIST_TTL_DAYS_UKHO",
+		"CACHE_STATION_LIST_TTL_DAYS_CHS",
 		"CACHE_BATCH_SIZE",
 		"CACHE_MAX_BATCH_RETRIES",
 		"CACHE_ENABLE_LRU",
@@ -268,15 +286,72 @@ func TestDefaultValues(t *testing.T) {
 	// Verify all default values
 	assert.Equal(t, defaultTidePredictionLRUSize, config.TidePredictionLRUSize)
 	assert.Equal(t, defaultTidePredictionTTLMinutes, config.TidePredictionLRUTTLMinutes)
+	assert.Equal(t, defaultTidePredictionLRUMaxMB, config.TidePredictionLRUMaxMB)
 	assert.Equal(t, defaultDynamoTTLDays, config.TidePredictionDynamoTTLDays)
+	assert.Equal(t, defaultHistoricalTTLDays, config.HistoricalTTLDays)
+	assert.Equal(t, defaultDynamoCompressMinBytes, config.DynamoCompressMinBytes)
 	assert.Equal(t, defaultStationListTTLDays, config.StationListTTLDays)
+	assert.Equal(t, defaultStationListMaxStaleDays, config.StationListMaxStaleDays)
 	assert.Equal(t, defaultBatchSize, config.BatchSize)
 	assert.Equal(t, defaultMaxBatchRetries, config.MaxBatchRetries)
+	assert.Equal(t, defaultWriteBehindWorkers, config.WriteBehindWorkers)
+	assert.Equal(t, defaultWriteBehindQueueSize, config.WriteBehindQueueSize)
+	assert.Equal(t, defaultWriteBehindMaxRetries, config.WriteBehindMaxRetries)
 	assert.True(t, config.EnableLRUCache)
 	assert.True(t, config.EnableDynamoCache)
 
 	// Verify helper methods return expected values
 	assert.Equal(t, time.Duration(defaultTidePredictionTTLMinutes)*time.Minute, config.GetTidePredictionLRUTTL())
+	assert.Equal(t, int64(defaultTidePredictionLRUMaxMB)*1024*1024, config.GetTidePredictionLRUMaxBytes())
 	assert.Equal(t, time.Duration(defaultDynamoTTLDays)*24*time.Hour, config.GetDynamoTTL())
 	assert.Equal(t, time.Duration(defaultStationListTTLDays)*24*time.Hour, config.GetStationListTTL())
+	assert.Equal(t, time.Duration(defaultStationListMaxStaleDays)*24*time.Hour, config.GetStationListMaxStale())
+}
+
+func TestGetPredictionTTL(t *testing.T) {
+	config := &CacheConfig{TidePredictionDynamoTTLDays: 2, HistoricalTTLDays: 365}
+	recent := 2 * 24 * time.Hour
+	historical := 365 * 24 * time.Hour
+	now := time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, recent, config.GetPredictionTTL("2024-06-20", now), "future")
+	assert.Equal(t, recent, config.GetPredictionTTL("2024-06-15", now), "today")
+	assert.Equal(t, recent, config.GetPredictionTTL("2024-06-14", now), "still today west of UTC")
+	assert.Equal(t, historical, config.GetPredictionTTL("2024-06-13", now), "over everywhere")
+	assert.Equal(t, historical, config.GetPredictionTTL("2023-09-01", now))
+	assert.Equal(t, recent, config.GetPredictionTTL("not a date", now))
+
+	config.HistoricalTTLDays = 0
+	assert.Equal(t, recent, config.GetPredictionTTL("2023-09-01", now), "disabled")
+}
+
+func TestFallbackRegions(t *testing.T) {
+	t.Setenv("CACHE_FALLBACK_REGIONS", "us-east-1, eu-west-1")
+	t.Setenv("STATION_LIST_REPLICA_BUCKETS", "us-east-1=stations-use1,eu-west-1 = stations-euw1")
+	cfg := GetCacheConfig()
+	assert.Equal(t, []string{"us-east-1", "eu-west-1"}, cfg.FallbackRegions)
+	assert.Equal(t, map[string]string{"us-east-1": "stations-use1", "eu-west-1": "stations-euw1"}, cfg.StationListReplicaBuckets)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("STATION_LIST_REPLICA_BUCKETS", "ap-south-1=stations-aps1,us-east-1")
+	l := newLoader(nil)
+	cfg = l.cacheConfig()
+	assert.Equal(t, map[string]string{"ap-south-1": "stations-aps1"}, cfg.StationListReplicaBuckets)
+	require.Len(t, l.problems, 1)
+	assert.EqualError(t, l.problems[0], `STATION_LIST_REPLICA_BUCKETS="us-east-1" is not a list of key=value pairs`)
+	assert.EqualError(t, cfg.Validate(), "STATION_LIST_REPLICA_BUCKETS has a bucket in ap-south-1, which isn't in CACHE_FALLBACK_REGIONS")
+}
+
+func TestSQLitePath(t *testing.T) {
+	t.Setenv("CACHE_BACKEND", BackendSQLite)
+	t.Setenv("CACHE_DIR", "/var/lib/flowebb")
+	cfg := GetCacheConfig()
+	assert.Equal(t, "/var/lib/flowebb/flowebb.db", cfg.GetSQLitePath(), "the database goes in the cache directory")
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("CACHE_SQLITE_PATH", "/data/tides.db")
+	t.Setenv("CACHE_DIR", "")
+	cfg = GetCacheConfig()
+	assert.Equal(t, "/data/tides.db", cfg.GetSQLitePath())
+	assert.NoError(t, cfg.Validate(), "the directory isn't needed with a path")
 }
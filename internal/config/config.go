@@ -1,19 +1,173 @@
 package config
 
 import (
+	"github.com/bbernstein/flowebb-go/internal/logging"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"os"
 	"time"
 )
 
+const (
+	defaultNWSBaseURL      = "https://api.weather.gov"
+	defaultNWSUserAgent    = "flowebb (https://github.com/bbernstein/flowebb-go)"
+	defaultWeatherCacheTTL = 30 * time.Minute
+	defaultAccuracyTable   = "flowebb-prediction-accuracy"
+	defaultReportsTable    = "flowebb-observation-reports"
+	defaultReportRateLimit = 10
+	defaultIdempotencyTTL  = 24 * time.Hour
+	defaultAuditRetention  = 365 * 24 * time.Hour
+	defaultExportURLTTL    = time.Hour
+	defaultWidgetURL       = "https://app.flowebb.com/widget"
+	maxExportURLTTL        = 7 * 24 * time.Hour
+	defaultDemoLatency     = 250 * time.Millisecond
+	defaultDemoRateLimit   = 60
+	defaultSyncInterval    = 6 * time.Hour
+	defaultSyncDays        = 14
+	// maxSyncDays is tide.MaxWarmDays, the most days predictions are refetched at once
+	maxSyncDays = 30
+
+	defaultNOAAMaxConcurrentRequests = 8
+	defaultGraphQLHTTPTimeout        = 30 * time.Second
+	defaultHTTPCassetteDir           = "testdata/cassettes"
+	defaultStationLimit              = 5
+	defaultMaxStationLimit           = 100
+	defaultAnomalyThresholdFt        = 1.0
+	// defaultMaxResponsePredictions is about 41 days at NOAA's 6-minute spacing, a payload
+	// of under 1MB
+	defaultMaxResponsePredictions = 10000
+)
+
 type Config struct {
 	Environment string
 	LogLevel    zerolog.Level
-	HTTPTimeout time.Duration
-	MaxRetries  int
-	NOAABaseURL string
-	// Add other common configurations here
+	// LogSampleRate keeps one in LogSampleRate debug logs on hot paths, like each day of a
+	// cache lookup; 0 or 1 keeps them all
+	LogSampleRate int
+	HTTPTimeout   time.Duration
+	MaxRetries    int
+	NOAABaseURL   string
+	// GraphQLHTTPTimeout replaces HTTPTimeout in the GraphQL entrypoint, where one query can
+	// ask NOAA for several stations' data
+	GraphQLHTTPTimeout time.Duration
+	// InterpolationMethod selects the tide interpolation strategy (linear, spline, harmonic).
+	// Empty keeps the per-path defaults.
+	InterpolationMethod string
+	// AdminAPIKey guards the cache admin API. Empty disables it.
+	AdminAPIKey string `config:"secret"`
+	// UpstreamTimeout bounds each NOAA fetch, CacheTimeout each cache read and RequestTimeout
+	// a whole tide lookup. Zero disables the limit.
+	UpstreamTimeout time.Duration
+	CacheTimeout    time.Duration
+	RequestTimeout  time.Duration
+	// UserDataTable is the DynamoDB table holding user profiles and favorite stations
+	UserDataTable string
+	// ReportsTable is the DynamoDB table observation reports are saved in. Each user may
+	// submit ReportRateLimit reports an hour per instance; zero doesn't limit them.
+	ReportsTable    string
+	ReportRateLimit int
+	// IdempotencyTable is the DynamoDB table the responses to writes sent with an
+	// Idempotency-Key are kept in for IdempotencyTTL, to be replayed to retries. Empty
+	// turns idempotency keys off.
+	IdempotencyTable string
+	IdempotencyTTL   time.Duration
+	// AuditTable is the DynamoDB table every change to a user's favorites and preferences
+	// is logged in, for AuditRetention. Empty turns the audit log off.
+	AuditTable     string
+	AuditRetention time.Duration
+	// MetricsTable is the DynamoDB table every instance adds its request, NOAA and cache
+	// counts to each minute, for the admin dashboard. Empty turns metrics off.
+	MetricsTable string
+	// QuotaTable is the DynamoDB table every request made with an API key is counted in by
+	// month, day and endpoint. Each key may make APIKeyMonthlyQuota requests a month, or
+	// its entry in APIKeyQuotas, keyed by API Gateway key ID; zero doesn't limit a key.
+	// Empty turns quotas and usage reports off.
+	QuotaTable         string
+	APIKeyMonthlyQuota int
+	APIKeyQuotas       map[string]int
+	// AccuracyTable is the DynamoDB table holding the daily prediction accuracy totals of
+	// AccuracyStations, the reference stations whose gauges predictions are checked
+	// against. No stations turns accuracy tracking off.
+	AccuracyTable    string
+	AccuracyStations []string
+	// ExportBucket is the S3 bucket yearly tide tables are rendered into, and the jobs
+	// rendering them kept in. Empty turns exports off. ExportURLTTL is how long a table's
+	// download URL works for, and ExportStations the stations whose next year's tables are
+	// rendered on a schedule.
+	ExportBucket   string
+	ExportURLTTL   time.Duration
+	ExportStations []string
+	// TaskQueueURL is the SQS queue slow work is deferred to, for the worker function to
+	// run. Empty runs it inline in the request instead.
+	TaskQueueURL string
+	// StationChangesTable is the DynamoDB table the changes NOAA makes to its station list
+	// are recorded in, for the stationChanges query, and StationChangesTopicARN the SNS
+	// topic each new change is published to. An empty table turns recording off; an empty
+	// topic publishes nothing.
+	StationChangesTable    string
+	StationChangesTopicARN string
+	// SyncInterval is how often the self-hosted server refreshes the station list from NOAA
+	// and refetches SyncDays of predictions, from today on, for SyncStations, so they're
+	// cached for when it can't reach NOAA. Zero turns syncing off.
+	SyncInterval time.Duration
+	SyncStations []string
+	SyncDays     int
+	// WidgetURL is the page that draws the embeddable tide module, which oEmbed responses
+	// embed and widget payloads link to
+	WidgetURL string
+	// AnomalyThresholdFt is how far, in feet, a station's observed water level may stray
+	// from its predicted level before tide responses flag an anomaly such as a storm surge.
+	// Zero turns the check off.
+	AnomalyThresholdFt float64
+	// MaxStationDistanceKm rejects coordinate lookups whose nearest station is farther
+	// away. Zero disables the limit.
+	MaxStationDistanceKm float64
+	// CoastalSnapping ranks nearest stations behind others when the straight way to them
+	// crosses land, using the land mask built into the binary
+	CoastalSnapping bool
+	// MaxResponsePredictions is the most predictions a tide response carries; longer ranges
+	// are thinned to it. Zero disables the limit.
+	MaxResponsePredictions int
+	// NWSBaseURL and NWSUserAgent configure the National Weather Service API that marine
+	// weather comes from; NWS asks every client to identify itself in its User-Agent
+	NWSBaseURL   string
+	NWSUserAgent string
+	// WeatherCacheTTL is how long a weather forecast is reused
+	WeatherCacheTTL time.Duration
+	// NOAAMaxConcurrentRequests caps how many requests one instance has open to NOAA at
+	// once, so bursts stay under NOAA's informal rate limits. Zero removes the cap.
+	NOAAMaxConcurrentRequests int
+	// NOAAStrictDecoding fails NOAA responses with fields the decoders don't read, which
+	// are otherwise only logged; it's meant for development, where a new field should stop
+	// things rather than risk being misread
+	NOAAStrictDecoding bool
+	// HTTPCassetteMode, record or replay, saves upstream responses to HTTPCassetteDir or
+	// answers requests from the ones saved there. Empty talks to the upstreams as usual.
+	HTTPCassetteMode string
+	HTTPCassetteDir  string
+	// StationLimit is how many nearest stations a lookup returns when it doesn't give a
+	// limit, and MaxStationLimit the largest limit it may give
+	StationLimit    int
+	MaxStationLimit int
+	// FeatureFlags are the feature flags turned on for every request
+	FeatureFlags []string
+	// Tenants are the white-label apps the deployment serves, each recognized by its API
+	// keys or hosts. None serves every request alike.
+	Tenants []tenant.Tenant
+	// DemoMode answers NOAA requests from canned stations and predictions, each taking about
+	// DemoLatency as a trip to NOAA would, and lets each client make DemoRateLimit requests
+	// a minute; zero lifts the limit. It needs no AWS resources: caches stay in memory, and
+	// accuracy tracking and exports are off.
+	DemoMode      bool
+	DemoLatency   time.Duration
+	DemoRateLimit int
+	// Cache configures the prediction and station list caches. New leaves it nil; Load and
+	// LoadFromEnv read it with the rest.
+	Cache *CacheConfig
+
+	// values holds the settings given a value, as given
+	values map[string]string
 }
 
 type Option func(*Config)
@@ -36,6 +190,13 @@ func WithLogLevel(level string) Option {
 	}
 }
 
+// WithLogSampleRate allows setting how many debug logs on hot paths one is kept of
+func WithLogSampleRate(n int) Option {
+	return func(c *Config) {
+		c.LogSampleRate = n
+	}
+}
+
 // WithHTTPTimeout allows setting the HTTP timeout
 func WithHTTPTimeout(timeout time.Duration) Option {
 	return func(c *Config) {
@@ -43,14 +204,296 @@ func WithHTTPTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithGraphQLHTTPTimeout allows setting the GraphQL entrypoint's HTTP timeout
+func WithGraphQLHTTPTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.GraphQLHTTPTimeout = timeout
+	}
+}
+
+// WithInterpolationMethod allows setting the tide interpolation strategy
+func WithInterpolationMethod(method string) Option {
+	return func(c *Config) {
+		c.InterpolationMethod = method
+	}
+}
+
+// WithAdminAPIKey allows setting the key required by the admin API
+func WithAdminAPIKey(key string) Option {
+	return func(c *Config) {
+		c.AdminAPIKey = key
+	}
+}
+
+// WithUpstreamTimeout allows setting the deadline for each NOAA fetch
+func WithUpstreamTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.UpstreamTimeout = timeout
+	}
+}
+
+// WithCacheTimeout allows setting the deadline for each cache read
+func WithCacheTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.CacheTimeout = timeout
+	}
+}
+
+// WithRequestTimeout allows setting the deadline for a whole tide lookup
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.RequestTimeout = timeout
+	}
+}
+
+// WithUserDataTable allows setting the DynamoDB table for user profiles
+func WithUserDataTable(table string) Option {
+	return func(c *Config) {
+		c.UserDataTable = table
+	}
+}
+
+// WithReports allows setting the DynamoDB table for observation reports and how many each
+// user may submit an hour
+func WithReports(table string, rateLimit int) Option {
+	return func(c *Config) {
+		c.ReportsTable = table
+		c.ReportRateLimit = rateLimit
+	}
+}
+
+// WithIdempotency allows setting the DynamoDB table idempotency keys are kept in and for
+// how long
+func WithIdempotency(table string, ttl time.Duration) Option {
+	return func(c *Config) {
+		c.IdempotencyTable = table
+		c.IdempotencyTTL = ttl
+	}
+}
+
+// WithAudit allows setting the DynamoDB table changes to user profiles are logged in and
+// for how long
+func WithAudit(table string, retention time.Duration) Option {
+	return func(c *Config) {
+		c.AuditTable = table
+		c.AuditRetention = retention
+	}
+}
+
+// WithMetricsTable allows setting the DynamoDB table metrics are added up in
+func WithMetricsTable(table string) Option {
+	return func(c *Config) {
+		c.MetricsTable = table
+	}
+}
+
+// WithAccuracyTracking allows setting the stations whose prediction accuracy is tracked
+// and the DynamoDB table it's kept in
+func WithAccuracyTracking(table string, stations []string) Option {
+	return func(c *Config) {
+		c.AccuracyTable = table
+		c.AccuracyStations = stations
+	}
+}
+
+// WithExports allows setting the S3 bucket tide tables are exported to, how long their
+// download URLs last and the stations exported on a schedule
+func WithExports(bucket string, urlTTL time.Duration, stations []string) Option {
+	return func(c *Config) {
+		c.ExportBucket = bucket
+		c.ExportURLTTL = urlTTL
+		c.ExportStations = stations
+	}
+}
+
+// WithTaskQueue allows setting the SQS queue slow work is deferred to
+func WithTaskQueue(url string) Option {
+	return func(c *Config) {
+		c.TaskQueueURL = url
+	}
+}
+
+// WithStationChanges allows setting the DynamoDB table station list changes are recorded
+// in and the SNS topic they're published to
+func WithStationChanges(table, topicARN string) Option {
+	return func(c *Config) {
+		c.StationChangesTable = table
+		c.StationChangesTopicARN = topicARN
+	}
+}
+
+// WithSync allows setting how often the self-hosted server syncs with NOAA, the stations
+// whose predictions it keeps cached and for how many days ahead
+func WithSync(interval time.Duration, stations []string, days int) Option {
+	return func(c *Config) {
+		c.SyncInterval = interval
+		c.SyncStations = stations
+		c.SyncDays = days
+	}
+}
+
+// WithWidgetURL allows setting the page that draws the embeddable tide module
+func WithWidgetURL(url string) Option {
+	return func(c *Config) {
+		c.WidgetURL = url
+	}
+}
+
+// WithAnomalyThreshold allows setting how far, in feet, observed water levels may stray
+// from predictions before an anomaly is flagged
+func WithAnomalyThreshold(ft float64) Option {
+	return func(c *Config) {
+		c.AnomalyThresholdFt = ft
+	}
+}
+
+// WithMaxStationDistance allows setting how far away, in kilometers, the nearest station
+// to a coordinate lookup may be
+func WithMaxStationDistance(km float64) Option {
+	return func(c *Config) {
+		c.MaxStationDistanceKm = km
+	}
+}
+
+// WithCoastalSnapping allows ranking nearest stations by how much of the way to them is water
+func WithCoastalSnapping(enabled bool) Option {
+	return func(c *Config) {
+		c.CoastalSnapping = enabled
+	}
+}
+
+// WithMaxResponsePredictions allows setting the most predictions a tide response carries
+func WithMaxResponsePredictions(n int) Option {
+	return func(c *Config) {
+		c.MaxResponsePredictions = n
+	}
+}
+
+// WithNWSBaseURL allows setting the National Weather Service API URL
+func WithNWSBaseURL(url string) Option {
+	return func(c *Config) {
+		c.NWSBaseURL = url
+	}
+}
+
+// WithNWSUserAgent allows setting the User-Agent sent to the National Weather Service
+func WithNWSUserAgent(userAgent string) Option {
+	return func(c *Config) {
+		c.NWSUserAgent = userAgent
+	}
+}
+
+// WithWeatherCacheTTL allows setting how long weather forecasts are cached
+func WithWeatherCacheTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.WeatherCacheTTL = ttl
+	}
+}
+
+// WithNOAAMaxConcurrentRequests allows setting how many NOAA requests may be open at once
+func WithNOAAMaxConcurrentRequests(n int) Option {
+	return func(c *Config) {
+		c.NOAAMaxConcurrentRequests = n
+	}
+}
+
+// WithNOAAStrictDecoding allows failing NOAA responses with fields the decoders don't read
+func WithNOAAStrictDecoding(strict bool) Option {
+	return func(c *Config) {
+		c.NOAAStrictDecoding = strict
+	}
+}
+
+// WithHTTPCassette allows recording upstream responses to dir, or replaying them from it
+func WithHTTPCassette(mode, dir string) Option {
+	return func(c *Config) {
+		c.HTTPCassetteMode = mode
+		c.HTTPCassetteDir = dir
+	}
+}
+
+// WithStationLimits allows setting the default and largest nearest-station limits
+func WithStationLimits(defaultLimit, maxLimit int) Option {
+	return func(c *Config) {
+		c.StationLimit = defaultLimit
+		c.MaxStationLimit = maxLimit
+	}
+}
+
+// WithQuotas allows setting the DynamoDB table API key usage is counted in, every key's
+// monthly quota and the keys with quotas of their own
+func WithQuotas(table string, quota int, quotas map[string]int) Option {
+	return func(c *Config) {
+		c.QuotaTable = table
+		c.APIKeyMonthlyQuota = quota
+		c.APIKeyQuotas = quotas
+	}
+}
+
+// WithFeatureFlags allows setting the feature flags turned on for every request
+func WithFeatureFlags(flags []string) Option {
+	return func(c *Config) {
+		c.FeatureFlags = flags
+	}
+}
+
+// WithTenants allows setting the white-label apps the deployment serves
+func WithTenants(tenants []tenant.Tenant) Option {
+	return func(c *Config) {
+		c.Tenants = tenants
+	}
+}
+
+// WithDemoMode allows serving canned data, with the latency and per-client rate limit of
+// demo mode
+func WithDemoMode(enabled bool, latency time.Duration, rateLimit int) Option {
+	return func(c *Config) {
+		c.DemoMode = enabled
+		c.DemoLatency = latency
+		c.DemoRateLimit = rateLimit
+	}
+}
+
+// WithCache allows setting the cache configuration
+func WithCache(cache *CacheConfig) Option {
+	return func(c *Config) {
+		c.Cache = cache
+	}
+}
+
 // New creates a new configuration with default values
 func New(opts ...Option) *Config {
 	cfg := &Config{
-		Environment: "production",
-		LogLevel:    zerolog.InfoLevel,
-		HTTPTimeout: 10 * time.Second,
-		MaxRetries:  3,
-		NOAABaseURL: "https://api.tidesandcurrents.noaa.gov",
+		Environment:     "production",
+		LogLevel:        zerolog.InfoLevel,
+		HTTPTimeout:     10 * time.Second,
+		MaxRetries:      3,
+		NOAABaseURL:     "https://api.tidesandcurrents.noaa.gov",
+		UpstreamTimeout: 8 * time.Second,
+		CacheTimeout:    time.Second,
+		RequestTimeout:  20 * time.Second,
+		UserDataTable:   "flowebb-user-profiles",
+		ReportsTable:    defaultReportsTable,
+		ReportRateLimit: defaultReportRateLimit,
+		IdempotencyTTL:  defaultIdempotencyTTL,
+		AuditRetention:  defaultAuditRetention,
+		AccuracyTable:   defaultAccuracyTable,
+		ExportURLTTL:    defaultExportURLTTL,
+		SyncInterval:    defaultSyncInterval,
+		SyncDays:        defaultSyncDays,
+		WidgetURL:       defaultWidgetURL,
+		NWSBaseURL:      defaultNWSBaseURL,
+		NWSUserAgent:    defaultNWSUserAgent,
+		WeatherCacheTTL: defaultWeatherCacheTTL,
+		DemoLatency:     defaultDemoLatency,
+		DemoRateLimit:   defaultDemoRateLimit,
+
+		NOAAMaxConcurrentRequests: defaultNOAAMaxConcurrentRequests,
+		GraphQLHTTPTimeout:        defaultGraphQLHTTPTimeout,
+		StationLimit:              defaultStationLimit,
+		MaxStationLimit:           defaultMaxStationLimit,
+		AnomalyThresholdFt:        defaultAnomalyThresholdFt,
+		MaxResponsePredictions:    defaultMaxResponsePredictions,
 	}
 
 	// Apply options
@@ -70,29 +513,79 @@ func (c *Config) InitializeLogging() {
 	if c.Environment == "local" || c.Environment == "development" {
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout})
 	}
+	logging.SetSampleRate(c.LogSampleRate)
 }
 
-// LoadFromEnv loads configuration from environment variables
+// LoadFromEnv reads the configuration from environment variables alone, every time it's
+// called and without validating it. Entrypoints use Load instead.
 func LoadFromEnv() *Config {
-	return New(
-		WithEnvironment(getEnvOrDefault("ENV", "production")),
-		WithLogLevel(getEnvOrDefault("LOG_LEVEL", "info")),
-		WithHTTPTimeout(getDurationEnvOrDefault("HTTP_TIMEOUT", 10*time.Second)),
-	)
+	return newLoader(nil).config()
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func (l *loader) config() *Config {
+	cfg := New(
+		WithEnvironment(l.string("ENV", "production")),
+		WithLogLevel(l.logLevel("LOG_LEVEL", "info")),
+		WithLogSampleRate(l.int("LOG_SAMPLE_RATE", 1)),
+		WithHTTPTimeout(l.duration("HTTP_TIMEOUT", 10*time.Second)),
+		WithGraphQLHTTPTimeout(l.duration("GRAPHQL_HTTP_TIMEOUT", defaultGraphQLHTTPTimeout)),
+		WithInterpolationMethod(l.string("TIDE_INTERPOLATION", "")),
+		WithAdminAPIKey(l.string("ADMIN_API_KEY", "")),
+		WithUpstreamTimeout(l.duration("TIDE_UPSTREAM_TIMEOUT", 8*time.Second)),
+		WithCacheTimeout(l.duration("TIDE_CACHE_TIMEOUT", time.Second)),
+		WithRequestTimeout(l.duration("TIDE_REQUEST_TIMEOUT", 20*time.Second)),
+		WithUserDataTable(l.string("USER_DATA_TABLE", "flowebb-user-profiles")),
+		WithReports(l.string("REPORTS_TABLE", defaultReportsTable), l.int("REPORT_RATE_LIMIT", defaultReportRateLimit)),
+		WithIdempotency(l.string("IDEMPOTENCY_TABLE", ""), l.duration("IDEMPOTENCY_TTL", defaultIdempotencyTTL)),
+		WithAudit(l.string("AUDIT_TABLE", ""), l.duration("AUDIT_RETENTION", defaultAuditRetention)),
+		WithMetricsTable(l.string("METRICS_TABLE", "")),
+		WithQuotas(l.string("QUOTA_TABLE", ""), l.int("API_KEY_MONTHLY_QUOTA", 0), l.quotas("API_KEY_QUOTAS")),
+		WithAccuracyTracking(l.string("ACCURACY_TABLE", defaultAccuracyTable), l.list("ACCURACY_STATIONS")),
+		WithExports(l.string("EXPORT_BUCKET", ""), l.duration("EXPORT_URL_TTL", defaultExportURLTTL), l.list("EXPORT_STATIONS")),
+		WithTaskQueue(l.string("TASK_QUEUE_URL", "")),
+		WithStationChanges(l.string("STATION_CHANGES_TABLE", ""), l.string("STATION_CHANGES_TOPIC_ARN", "")),
+		WithSync(l.duration("SYNC_INTERVAL", defaultSyncInterval), l.list("SYNC_STATIONS"), l.int("SYNC_DAYS", defaultSyncDays)),
+		WithWidgetURL(l.string("WIDGET_URL", defaultWidgetURL)),
+		WithMaxStationDistance(l.float("TIDE_MAX_STATION_DISTANCE_KM", 0)),
+		WithCoastalSnapping(l.bool("COASTAL_SNAPPING", false)),
+		WithAnomalyThreshold(l.float("TIDE_ANOMALY_THRESHOLD_FT", defaultAnomalyThresholdFt)),
+		WithMaxResponsePredictions(l.int("TIDE_MAX_RESPONSE_PREDICTIONS", defaultMaxResponsePredictions)),
+		WithNWSBaseURL(l.string("NWS_BASE_URL", defaultNWSBaseURL)),
+		WithNWSUserAgent(l.string("NWS_USER_AGENT", defaultNWSUserAgent)),
+		WithWeatherCacheTTL(l.duration("WEATHER_CACHE_TTL", defaultWeatherCacheTTL)),
+		WithNOAAMaxConcurrentRequests(l.int("NOAA_MAX_CONCURRENT_REQUESTS", defaultNOAAMaxConcurrentRequests)),
+		WithNOAAStrictDecoding(l.bool("NOAA_STRICT_DECODING", false)),
+		WithHTTPCassette(l.string("HTTP_CASSETTE_MODE", ""), l.string("HTTP_CASSETTE_DIR", defaultHTTPCassetteDir)),
+		WithStationLimits(l.stationLimits()),
+		WithFeatureFlags(l.list("FEATURE_FLAGS")),
+		WithTenants(l.tenants("TENANTS")),
+		WithDemoMode(l.bool("DEMO_MODE", false), l.duration("DEMO_LATENCY", defaultDemoLatency), l.int("DEMO_RATE_LIMIT", defaultDemoRateLimit)),
+		WithCache(l.cacheConfig()),
+	)
+	if cfg.DemoMode {
+		// They keep their results in AWS, which demo mode runs without
+		cfg.AccuracyStations = nil
+		cfg.ExportBucket, cfg.ExportStations = "", nil
+		cfg.QuotaTable, cfg.APIKeyMonthlyQuota, cfg.APIKeyQuotas = "", 0, nil
+		cfg.TaskQueueURL = ""
+		cfg.StationChangesTable, cfg.StationChangesTopicARN = "", ""
 	}
-	return defaultValue
+	cfg.values = l.values
+	return cfg
 }
 
-func getDurationEnvOrDefault(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
+// stationLimits reads STATIONS_DEFAULT_LIMIT and STATIONS_MAX_LIMIT, falling back to the
+// defaults for values below 1 and lowering the default to a cap set beneath it
+func (l *loader) stationLimits() (int, int) {
+	defaultLimit := l.int("STATIONS_DEFAULT_LIMIT", defaultStationLimit)
+	maxLimit := l.int("STATIONS_MAX_LIMIT", defaultMaxStationLimit)
+	if maxLimit < 1 {
+		log.Warn().Int("limit", maxLimit).Msg("STATIONS_MAX_LIMIT must be at least 1, using default")
+		maxLimit = defaultMaxStationLimit
+	}
+	if defaultLimit < 1 {
+		log.Warn().Int("limit", defaultLimit).Msg("STATIONS_DEFAULT_LIMIT must be at least 1, using default")
+		defaultLimit = defaultStationLimit
 	}
-	return defaultValue
+	return min(defaultLimit, maxLimit), maxLimit
 }
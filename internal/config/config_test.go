@@ -1,10 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/bbernstein/flowebb-go/internal/tenant"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
@@ -37,6 +39,393 @@ func TestWithHTTPTimeout(t *testing.T) {
 	assert.Equal(t, 30*time.Second, cfg.HTTPTimeout)
 }
 
+func TestWithInterpolationMethod(t *testing.T) {
+	cfg := New(WithInterpolationMethod("harmonic"))
+
+	assert.Equal(t, "harmonic", cfg.InterpolationMethod)
+}
+
+func TestWithAdminAPIKey(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "s3cret")
+
+	assert.Equal(t, "s3cret", LoadFromEnv().AdminAPIKey)
+	assert.Empty(t, New().AdminAPIKey)
+}
+
+func TestWithUserDataTable(t *testing.T) {
+	assert.Equal(t, "flowebb-user-profiles", New().UserDataTable)
+
+	t.Setenv("USER_DATA_TABLE", "profiles-dev")
+	assert.Equal(t, "profiles-dev", LoadFromEnv().UserDataTable)
+}
+
+func TestWithReports(t *testing.T) {
+	cfg := New()
+	assert.Equal(t, "flowebb-observation-reports", cfg.ReportsTable)
+	assert.Equal(t, 10, cfg.ReportRateLimit)
+
+	t.Setenv("REPORTS_TABLE", "reports-dev")
+	t.Setenv("REPORT_RATE_LIMIT", "0")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "reports-dev", cfg.ReportsTable)
+	assert.Equal(t, 0, cfg.ReportRateLimit)
+
+	t.Setenv("REPORT_RATE_LIMIT", "-1")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "REPORT_RATE_LIMIT")
+}
+
+func TestWithIdempotency(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.IdempotencyTable, "idempotency keys are off by default")
+	assert.Equal(t, 24*time.Hour, cfg.IdempotencyTTL)
+
+	t.Setenv("IDEMPOTENCY_TABLE", "idempotency-dev")
+	t.Setenv("IDEMPOTENCY_TTL", "1h")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "idempotency-dev", cfg.IdempotencyTable)
+	assert.Equal(t, time.Hour, cfg.IdempotencyTTL)
+
+	t.Setenv("IDEMPOTENCY_TTL", "0s")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "IDEMPOTENCY_TTL")
+}
+
+func TestWithAudit(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.AuditTable, "the audit log is off by default")
+	assert.Equal(t, 365*24*time.Hour, cfg.AuditRetention)
+
+	t.Setenv("AUDIT_TABLE", "audit-dev")
+	t.Setenv("AUDIT_RETENTION", "720h")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "audit-dev", cfg.AuditTable)
+	assert.Equal(t, 30*24*time.Hour, cfg.AuditRetention)
+
+	t.Setenv("AUDIT_RETENTION", "-1h")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "AUDIT_RETENTION")
+}
+
+func TestWithQuotas(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.QuotaTable, "quotas are off by default")
+	assert.Zero(t, cfg.APIKeyMonthlyQuota)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("QUOTA_TABLE", "usage-dev")
+	t.Setenv("API_KEY_MONTHLY_QUOTA", "10000")
+	t.Setenv("API_KEY_QUOTAS", "partner=0, trial=100")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "usage-dev", cfg.QuotaTable)
+	assert.Equal(t, 10000, cfg.APIKeyMonthlyQuota)
+	assert.Equal(t, map[string]int{"partner": 0, "trial": 100}, cfg.APIKeyQuotas)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("DEMO_MODE", "true")
+	cfg = LoadFromEnv()
+	assert.Empty(t, cfg.QuotaTable, "demo mode has no DynamoDB")
+	assert.NoError(t, cfg.Validate())
+	t.Setenv("DEMO_MODE", "false")
+
+	t.Setenv("API_KEY_QUOTAS", "trial=lots")
+	l := newLoader(nil)
+	assert.Empty(t, l.config().APIKeyQuotas)
+	assert.EqualError(t, errors.Join(l.problems...), `API_KEY_QUOTAS="trial=lots" is not a list of keyId=quota pairs`)
+
+	t.Setenv("API_KEY_QUOTAS", "trial=-1")
+	t.Setenv("QUOTA_TABLE", "")
+	err := LoadFromEnv().Validate()
+	assert.ErrorContains(t, err, "API_KEY_QUOTAS trial")
+	assert.ErrorContains(t, err, "QUOTA_TABLE")
+}
+
+func TestWithLogSampleRate(t *testing.T) {
+	assert.Equal(t, 1, LoadFromEnv().LogSampleRate, "every log is kept by default")
+
+	t.Setenv("LOG_SAMPLE_RATE", "10")
+	assert.Equal(t, 10, LoadFromEnv().LogSampleRate)
+
+	t.Setenv("LOG_SAMPLE_RATE", "-1")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "LOG_SAMPLE_RATE")
+}
+
+func TestWithMetricsTable(t *testing.T) {
+	assert.Empty(t, LoadFromEnv().MetricsTable, "metrics are off by default")
+
+	t.Setenv("METRICS_TABLE", "metrics-dev")
+	assert.Equal(t, "metrics-dev", LoadFromEnv().MetricsTable)
+}
+
+func TestWithAccuracyTracking(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Equal(t, "flowebb-prediction-accuracy", cfg.AccuracyTable)
+	assert.Empty(t, cfg.AccuracyStations, "tracking is off by default")
+
+	t.Setenv("ACCURACY_TABLE", "accuracy-dev")
+	t.Setenv("ACCURACY_STATIONS", "9447130, 8454000")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "accuracy-dev", cfg.AccuracyTable)
+	assert.Equal(t, []string{"9447130", "8454000"}, cfg.AccuracyStations)
+}
+
+func TestWithExports(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.ExportBucket, "exports are off by default")
+	assert.Equal(t, time.Hour, cfg.ExportURLTTL)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("EXPORT_STATIONS", "9447130")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "EXPORT_BUCKET")
+
+	t.Setenv("EXPORT_BUCKET", "flowebb-exports")
+	t.Setenv("EXPORT_URL_TTL", "24h")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "flowebb-exports", cfg.ExportBucket)
+	assert.Equal(t, 24*time.Hour, cfg.ExportURLTTL)
+	assert.Equal(t, []string{"9447130"}, cfg.ExportStations)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("EXPORT_URL_TTL", "200h")
+	assert.EqualError(t, LoadFromEnv().Validate(), "EXPORT_URL_TTL must be at most 168h0m0s, not 200h0m0s")
+}
+
+func TestWithTaskQueue(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.TaskQueueURL, "slow work runs inline by default")
+
+	t.Setenv("TASK_QUEUE_URL", "https://sqs.us-east-1.amazonaws.com/123456789012/flowebb-tasks")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/flowebb-tasks", cfg.TaskQueueURL)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("TASK_QUEUE_URL", "flowebb-tasks")
+	assert.EqualError(t, LoadFromEnv().Validate(), `TASK_QUEUE_URL="flowebb-tasks" is not an absolute URL`)
+}
+
+func TestWithStationChanges(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.StationChangesTable, "station changes aren't recorded by default")
+	assert.Empty(t, cfg.StationChangesTopicARN)
+
+	t.Setenv("STATION_CHANGES_TOPIC_ARN", "arn:aws:sns:us-east-1:123456789012:flowebb-station-changes")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "STATION_CHANGES_TABLE", "only changes the table hasn't seen are published")
+
+	t.Setenv("STATION_CHANGES_TABLE", "station-changes-dev")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "station-changes-dev", cfg.StationChangesTable)
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:flowebb-station-changes", cfg.StationChangesTopicARN)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestWithSync(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Equal(t, 6*time.Hour, cfg.SyncInterval)
+	assert.Empty(t, cfg.SyncStations, "no predictions are kept cached by default")
+	assert.Equal(t, 14, cfg.SyncDays)
+
+	t.Setenv("SYNC_INTERVAL", "1h")
+	t.Setenv("SYNC_STATIONS", "9447130,9444900")
+	t.Setenv("SYNC_DAYS", "7")
+	cfg = LoadFromEnv()
+	assert.Equal(t, time.Hour, cfg.SyncInterval)
+	assert.Equal(t, []string{"9447130", "9444900"}, cfg.SyncStations)
+	assert.Equal(t, 7, cfg.SyncDays)
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("SYNC_DAYS", "31")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "SYNC_DAYS", "predictions are refetched a month at most")
+	t.Setenv("SYNC_DAYS", "7")
+	t.Setenv("SYNC_INTERVAL", "-1h")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "SYNC_INTERVAL")
+}
+
+func TestWithWidgetURL(t *testing.T) {
+	assert.Equal(t, "https://app.flowebb.com/widget", New().WidgetURL)
+
+	t.Setenv("WIDGET_URL", "http://localhost:3000/widget")
+	assert.Equal(t, "http://localhost:3000/widget", LoadFromEnv().WidgetURL)
+
+	t.Setenv("WIDGET_URL", "/widget")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "WIDGET_URL")
+}
+
+func TestWithDemoMode(t *testing.T) {
+	cfg := New()
+	assert.False(t, cfg.DemoMode)
+	assert.Equal(t, 250*time.Millisecond, cfg.DemoLatency)
+	assert.Equal(t, 60, cfg.DemoRateLimit)
+
+	t.Setenv("CACHE_BACKEND", BackendRedis)
+	t.Setenv("EXPORT_BUCKET", "exports")
+	t.Setenv("ACCURACY_STATIONS", "9447130")
+	t.Setenv("TASK_QUEUE_URL", "https://sqs.us-east-1.amazonaws.com/123456789012/flowebb-tasks")
+	t.Setenv("STATION_CHANGES_TABLE", "station-changes")
+	t.Setenv("DEMO_MODE", "true")
+	t.Setenv("DEMO_LATENCY", "50ms")
+	t.Setenv("DEMO_RATE_LIMIT", "10")
+	cfg = LoadFromEnv()
+	assert.True(t, cfg.DemoMode)
+	assert.Equal(t, 50*time.Millisecond, cfg.DemoLatency)
+	assert.Equal(t, 10, cfg.DemoRateLimit)
+	assert.Equal(t, BackendMemory, cfg.Cache.Backend, "demo mode keeps the cache in memory")
+	assert.Equal(t, BackendMemory, GetCacheConfig().Backend)
+	assert.Empty(t, cfg.ExportBucket, "exports need AWS")
+	assert.Empty(t, cfg.AccuracyStations, "accuracy tracking needs AWS")
+	assert.Empty(t, cfg.TaskQueueURL, "the task queue needs AWS")
+	assert.Empty(t, cfg.StationChangesTable, "station changes are recorded in AWS")
+	assert.NoError(t, cfg.Validate())
+
+	t.Setenv("DEMO_RATE_LIMIT", "-1")
+	assert.ErrorContains(t, LoadFromEnv().Validate(), "DEMO_RATE_LIMIT")
+}
+
+func TestWithAnomalyThreshold(t *testing.T) {
+	assert.Equal(t, 1.0, New().AnomalyThresholdFt)
+	assert.Equal(t, 0.5, New(WithAnomalyThreshold(0.5)).AnomalyThresholdFt)
+
+	t.Setenv("TIDE_ANOMALY_THRESHOLD_FT", "2.5")
+	assert.Equal(t, 2.5, LoadFromEnv().AnomalyThresholdFt)
+
+	t.Setenv("TIDE_ANOMALY_THRESHOLD_FT", "-1")
+	assert.Error(t, LoadFromEnv().Validate())
+}
+
+func TestWithMaxResponsePredictions(t *testing.T) {
+	assert.Equal(t, 10000, New().MaxResponsePredictions)
+
+	t.Setenv("TIDE_MAX_RESPONSE_PREDICTIONS", "0")
+	assert.Equal(t, 0, LoadFromEnv().MaxResponsePredictions)
+	assert.NoError(t, LoadFromEnv().Validate(), "zero disables the limit")
+
+	t.Setenv("TIDE_MAX_RESPONSE_PREDICTIONS", "20")
+	assert.Error(t, LoadFromEnv().Validate())
+}
+
+func TestWithNOAAStrictDecoding(t *testing.T) {
+	assert.False(t, New().NOAAStrictDecoding)
+	assert.True(t, New(WithNOAAStrictDecoding(true)).NOAAStrictDecoding)
+
+	t.Setenv("NOAA_STRICT_DECODING", "true")
+	assert.True(t, LoadFromEnv().NOAAStrictDecoding)
+}
+
+func TestWithCoastalSnapping(t *testing.T) {
+	assert.False(t, New().CoastalSnapping)
+	assert.True(t, New(WithCoastalSnapping(true)).CoastalSnapping)
+
+	t.Setenv("COASTAL_SNAPPING", "true")
+	assert.True(t, LoadFromEnv().CoastalSnapping)
+}
+
+func TestWithMaxStationDistance(t *testing.T) {
+	assert.Zero(t, New().MaxStationDistanceKm)
+	assert.Equal(t, 150.0, New(WithMaxStationDistance(150)).MaxStationDistanceKm)
+
+	t.Setenv("TIDE_MAX_STATION_DISTANCE_KM", "80.5")
+	assert.Equal(t, 80.5, LoadFromEnv().MaxStationDistanceKm)
+
+	t.Setenv("TIDE_MAX_STATION_DISTANCE_KM", "far")
+	assert.Zero(t, LoadFromEnv().MaxStationDistanceKm)
+}
+
+func TestWeatherConfig(t *testing.T) {
+	cfg := New()
+	assert.Equal(t, "https://api.weather.gov", cfg.NWSBaseURL)
+	assert.Contains(t, cfg.NWSUserAgent, "flowebb")
+	assert.Equal(t, 30*time.Minute, cfg.WeatherCacheTTL)
+
+	t.Setenv("NWS_BASE_URL", "http://localhost:9000")
+	t.Setenv("NWS_USER_AGENT", "flowebb-dev (dev@example.com)")
+	t.Setenv("WEATHER_CACHE_TTL", "5m")
+
+	cfg = LoadFromEnv()
+	assert.Equal(t, "http://localhost:9000", cfg.NWSBaseURL)
+	assert.Equal(t, "flowebb-dev (dev@example.com)", cfg.NWSUserAgent)
+	assert.Equal(t, 5*time.Minute, cfg.WeatherCacheTTL)
+}
+
+func TestNOAAMaxConcurrentRequests(t *testing.T) {
+	assert.Equal(t, 8, New().NOAAMaxConcurrentRequests)
+	assert.Equal(t, 2, New(WithNOAAMaxConcurrentRequests(2)).NOAAMaxConcurrentRequests)
+
+	t.Setenv("NOAA_MAX_CONCURRENT_REQUESTS", "0")
+	assert.Zero(t, LoadFromEnv().NOAAMaxConcurrentRequests)
+}
+
+func TestStationLimits(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Equal(t, 5, cfg.StationLimit)
+	assert.Equal(t, 100, cfg.MaxStationLimit)
+
+	t.Setenv("STATIONS_DEFAULT_LIMIT", "10")
+	t.Setenv("STATIONS_MAX_LIMIT", "25")
+	cfg = LoadFromEnv()
+	assert.Equal(t, 10, cfg.StationLimit)
+	assert.Equal(t, 25, cfg.MaxStationLimit)
+
+	t.Setenv("STATIONS_MAX_LIMIT", "3")
+	cfg = LoadFromEnv()
+	assert.Equal(t, 3, cfg.StationLimit, "the default never exceeds the cap")
+	assert.Equal(t, 3, cfg.MaxStationLimit)
+
+	t.Setenv("STATIONS_DEFAULT_LIMIT", "0")
+	t.Setenv("STATIONS_MAX_LIMIT", "-1")
+	cfg = LoadFromEnv()
+	assert.Equal(t, 5, cfg.StationLimit)
+	assert.Equal(t, 100, cfg.MaxStationLimit)
+}
+
+func TestFeatureFlags(t *testing.T) {
+	assert.Empty(t, LoadFromEnv().FeatureFlags)
+
+	t.Setenv("FEATURE_FLAGS", "harmonic-fallback, v2-default,,")
+	assert.Equal(t, []string{"harmonic-fallback", "v2-default"}, LoadFromEnv().FeatureFlags)
+}
+
+func TestTenants(t *testing.T) {
+	assert.Empty(t, LoadFromEnv().Tenants)
+	acme := tenant.Tenant{ID: "acme", Hosts: []string{"tides.acme.com"}}
+	assert.Equal(t, []tenant.Tenant{acme}, New(WithTenants([]tenant.Tenant{acme})).Tenants)
+
+	t.Setenv("TENANTS", `[{"id":"acme","apiKeyIds":["k1"],"rateLimit":30,"featureFlags":["v2-default"],"branding":{"name":"Acme Tides"}}]`)
+	assert.Equal(t, []tenant.Tenant{{
+		ID:           "acme",
+		APIKeyIDs:    []string{"k1"},
+		RateLimit:    30,
+		FeatureFlags: []string{"v2-default"},
+		Branding:     tenant.Branding{Name: "Acme Tides"},
+	}}, LoadFromEnv().Tenants)
+
+	t.Setenv("TENANTS", `[{"id":"acme","colour":"red"}]`)
+	assert.Empty(t, LoadFromEnv().Tenants, "unknown fields are likely misspellings")
+}
+
+func TestHTTPCassette(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.Empty(t, cfg.HTTPCassetteMode)
+	assert.Equal(t, "testdata/cassettes", cfg.HTTPCassetteDir)
+
+	t.Setenv("HTTP_CASSETTE_MODE", "replay")
+	t.Setenv("HTTP_CASSETTE_DIR", "/tmp/noaa")
+	cfg = LoadFromEnv()
+	assert.Equal(t, "replay", cfg.HTTPCassetteMode)
+	assert.Equal(t, "/tmp/noaa", cfg.HTTPCassetteDir)
+}
+
+func TestStageTimeouts(t *testing.T) {
+	cfg := New()
+	assert.Equal(t, 8*time.Second, cfg.UpstreamTimeout)
+	assert.Equal(t, time.Second, cfg.CacheTimeout)
+	assert.Equal(t, 20*time.Second, cfg.RequestTimeout)
+
+	t.Setenv("TIDE_UPSTREAM_TIMEOUT", "3s")
+	t.Setenv("TIDE_CACHE_TIMEOUT", "250ms")
+	t.Setenv("TIDE_REQUEST_TIMEOUT", "0s")
+
+	cfg = LoadFromEnv()
+	assert.Equal(t, 3*time.Second, cfg.UpstreamTimeout)
+	assert.Equal(t, 250*time.Millisecond, cfg.CacheTimeout)
+	assert.Equal(t, time.Duration(0), cfg.RequestTimeout)
+}
+
 func TestInitializeLogging(t *testing.T) {
 	cfg := New(WithEnvironment("local"), WithLogLevel("debug"))
 	cfg.InitializeLogging()
@@ -63,6 +452,10 @@ func TestLoadFromEnv(t *testing.T) {
 	assert.Equal(t, "test", cfg.Environment)
 	assert.Equal(t, zerolog.WarnLevel, cfg.LogLevel)
 	assert.Equal(t, 5*time.Second, cfg.HTTPTimeout)
+	assert.Equal(t, 30*time.Second, cfg.GraphQLHTTPTimeout, "HTTP_TIMEOUT doesn't lower the GraphQL timeout")
+
+	t.Setenv("GRAPHQL_HTTP_TIMEOUT", "45s")
+	assert.Equal(t, 45*time.Second, LoadFromEnv().GraphQLHTTPTimeout)
 
 	// Clean up
 	err = os.Unsetenv("ENV")
@@ -79,7 +472,7 @@ func TestLoadFromEnv(t *testing.T) {
 	}
 }
 
-func TestGetEnvOrDefault(t *testing.T) {
+func TestLoaderString(t *testing.T) {
 	err := os.Setenv("TEST_ENV_VAR", "value")
 	if err != nil {
 		return
@@ -91,11 +484,12 @@ func TestGetEnvOrDefault(t *testing.T) {
 		}
 	}()
 
-	assert.Equal(t, "value", getEnvOrDefault("TEST_ENV_VAR", "default"))
-	assert.Equal(t, "default", getEnvOrDefault("NON_EXISTENT_ENV_VAR", "default"))
+	l := newLoader(nil)
+	assert.Equal(t, "value", l.string("TEST_ENV_VAR", "default"))
+	assert.Equal(t, "default", l.string("NON_EXISTENT_ENV_VAR", "default"))
 }
 
-func TestGetDurationEnvOrDefault(t *testing.T) {
+func TestLoaderDuration(t *testing.T) {
 	err := os.Setenv("TEST_DURATION_ENV_VAR", "2s")
 	if err != nil {
 		return
@@ -107,6 +501,12 @@ func TestGetDurationEnvOrDefault(t *testing.T) {
 		}
 	}()
 
-	assert.Equal(t, 2*time.Second, getDurationEnvOrDefault("TEST_DURATION_ENV_VAR", 1*time.Second))
-	assert.Equal(t, 1*time.Second, getDurationEnvOrDefault("NON_EXISTENT_DURATION_ENV_VAR", 1*time.Second))
+	l := newLoader(nil)
+	assert.Equal(t, 2*time.Second, l.duration("TEST_DURATION_ENV_VAR", 1*time.Second))
+	assert.Equal(t, 1*time.Second, l.duration("NON_EXISTENT_DURATION_ENV_VAR", 1*time.Second))
+	assert.Empty(t, l.problems)
+
+	t.Setenv("TEST_BAD_DURATION_ENV_VAR", "soon")
+	assert.Equal(t, 1*time.Second, l.duration("TEST_BAD_DURATION_ENV_VAR", 1*time.Second))
+	assert.EqualError(t, errors.Join(l.problems...), `TEST_BAD_DURATION_ENV_VAR="soon" is not a duration such as 10s`)
 }
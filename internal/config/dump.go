@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/rs/zerolog/log"
+)
+
+// redacted stands in for a secret's value in Dump
+const redacted = "[redacted]"
+
+// Dump returns every setting keyed by field name, cache settings under "Cache.", for
+// logging what a process started with. Fields tagged config:"secret" show as "[redacted]"
+// when set, so a dump never leaks an API key or password.
+func (c *Config) Dump() map[string]any {
+	fields := map[string]any{}
+	dumpFields(fields, "", reflect.ValueOf(c).Elem())
+	return fields
+}
+
+func dumpFields(fields map[string]any, prefix string, v reflect.Value) {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+		switch {
+		case value.Kind() == reflect.Pointer && value.Type().Elem().Kind() == reflect.Struct:
+			if !value.IsNil() {
+				dumpFields(fields, prefix+field.Name+".", value.Elem())
+			}
+		case field.Tag.Get("config") == "secret":
+			if !value.IsZero() {
+				fields[prefix+field.Name] = redacted
+			} else {
+				fields[prefix+field.Name] = ""
+			}
+		default:
+			// Durations and log levels read better as text than as numbers
+			if stringer, ok := value.Interface().(fmt.Stringer); ok {
+				fields[prefix+field.Name] = stringer.String()
+			} else {
+				fields[prefix+field.Name] = value.Interface()
+			}
+		}
+	}
+}
+
+// LogDump logs the settings from Dump at debug level
+func (c *Config) LogDump() {
+	log.Debug().Fields(c.Dump()).Msg("Configuration loaded")
+}
@@ -0,0 +1,333 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv names the environment variable holding the path of an optional YAML
+// config file. The file maps setting names, the same as the environment variables, to
+// values; a variable set in the environment wins over the file.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// sourceSettings say where the configuration comes from rather than configuring anything,
+// so nothing else reads them
+var sourceSettings = []string{ConfigFileEnv, SSMPathEnv, AppConfigEnv, RefreshIntervalEnv}
+
+var (
+	loadMu sync.Mutex
+	loaded *Config
+)
+
+// Load reads the configuration the first time it's called, from the environment, the
+// file ConfigFileEnv names and the SSM path or AppConfig configuration named by
+// SSMPathEnv or AppConfigEnv, and validates it. A remote setting wins over the
+// environment, which wins over the file. Later calls return the same Config, so every
+// part of a process sees one configuration; Watch applies later remote changes. The error
+// lists every setting that couldn't be parsed or is out of range. A configuration that
+// couldn't be loaded is read again on the next call, so an entrypoint can retry after,
+// say, SSM was briefly unavailable.
+func Load() (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	if loaded != nil {
+		return loaded, nil
+	}
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	loaded = cfg
+	return cfg, nil
+}
+
+func load() (*Config, error) {
+	var file map[string]string
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		var err error
+		if file, err = readFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	l := newLoader(file)
+	ctx, cancel := context.WithTimeout(context.Background(), remoteTimeout)
+	defer cancel()
+	source, err := newRemoteSource(ctx, l.string(SSMPathEnv, ""), l.string(AppConfigEnv, ""))
+	if err != nil {
+		return nil, fmt.Errorf("configuring remote configuration: %w", err)
+	}
+	interval := l.duration(RefreshIntervalEnv, defaultRefreshInterval)
+	if source != nil {
+		if l.remote, err = source.Fetch(ctx); err != nil {
+			return nil, fmt.Errorf("reading configuration from %s: %w", source, err)
+		}
+	}
+
+	cfg := l.config()
+	if err := l.check(cfg); err != nil {
+		return nil, err
+	}
+	watched = nil
+	if source != nil {
+		watched = &watcher{file: file, source: source, interval: interval, current: cfg}
+	}
+	return cfg, nil
+}
+
+// check returns an error listing the settings the loader couldn't parse, the names in the
+// file or remote configuration that aren't settings, and cfg's invalid values
+func (l *loader) check(cfg *Config) error {
+	problems := l.problems
+	for _, key := range l.unknownKeys(l.file) {
+		problems = append(problems, fmt.Errorf("unknown setting %s in config file", key))
+	}
+	for _, key := range l.unknownKeys(l.remote) {
+		problems = append(problems, fmt.Errorf("unknown setting %s in remote configuration", key))
+	}
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// readFile reads a YAML mapping of setting names to scalar values
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// loader reads settings by name from the remote configuration, the environment or the
+// config file, in that order. A value it can't parse is logged and recorded as a problem,
+// and the setting keeps its default.
+type loader struct {
+	remote   map[string]string
+	file     map[string]string
+	asked    map[string]bool
+	values   map[string]string
+	problems []error
+}
+
+func newLoader(file map[string]string) *loader {
+	return &loader{file: file, asked: map[string]bool{}, values: map[string]string{}}
+}
+
+// lookup returns the setting's value; an empty value counts as unset
+func (l *loader) lookup(key string) (string, bool) {
+	l.asked[key] = true
+	value, ok := l.remote[key]
+	if !ok {
+		value, ok = os.LookupEnv(key)
+	}
+	if !ok {
+		value, ok = l.file[key]
+	}
+	if !ok || value == "" {
+		return "", false
+	}
+	l.values[key] = value
+	return value, true
+}
+
+func (l *loader) invalid(key, value, want string) {
+	log.Warn().Str("key", key).Str("value", value).Msgf("Setting is not %s, using default", want)
+	l.problems = append(l.problems, fmt.Errorf("%s=%q is not %s", key, value, want))
+}
+
+func (l *loader) string(key, defaultValue string) string {
+	if value, ok := l.lookup(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, value, "an integer")
+		return defaultValue
+	}
+	return n
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, value, "a number")
+		return defaultValue
+	}
+	return f
+}
+
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.invalid(key, value, "a duration such as 10s")
+		return defaultValue
+	}
+	return d
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	switch strings.ToLower(value) {
+	case "true", "1", "yes":
+		return true
+	case "false", "0", "no":
+		return false
+	}
+	l.invalid(key, value, "true or false")
+	return defaultValue
+}
+
+// list reads a comma-separated list, dropping empty items
+func (l *loader) list(key string) []string {
+	value, ok := l.lookup(key)
+	if !ok {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// pairs reads a comma-separated list of key=value pairs
+func (l *loader) pairs(key string) map[string]string {
+	items := l.list(key)
+	if items == nil {
+		return nil
+	}
+	pairs := make(map[string]string, len(items))
+	for _, item := range items {
+		k, v, ok := strings.Cut(item, "=")
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if !ok || k == "" || v == "" {
+			l.invalid(key, item, "a list of key=value pairs")
+			continue
+		}
+		pairs[k] = v
+	}
+	return pairs
+}
+
+// quotas reads a comma-separated list of keyId=quota pairs
+func (l *loader) quotas(key string) map[string]int {
+	pairs := l.pairs(key)
+	if pairs == nil {
+		return nil
+	}
+	quotas := make(map[string]int, len(pairs))
+	for id, value := range pairs {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			l.invalid(key, id+"="+value, "a list of keyId=quota pairs")
+			continue
+		}
+		quotas[id] = n
+	}
+	return quotas
+}
+
+// tenants reads a JSON list of tenants
+func (l *loader) tenants(key string) []tenant.Tenant {
+	value, ok := l.lookup(key)
+	if !ok {
+		return nil
+	}
+	var tenants []tenant.Tenant
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&tenants); err != nil {
+		l.invalid(key, value, "a JSON list of tenants")
+		return nil
+	}
+	return tenants
+}
+
+func (l *loader) logLevel(key, defaultValue string) string {
+	value := l.string(key, defaultValue)
+	if _, err := zerolog.ParseLevel(value); err != nil {
+		l.invalid(key, value, "a log level such as info")
+		return defaultValue
+	}
+	return value
+}
+
+// sourceTTLDays reads prefix+SOURCE for each source, returning only the ones that are set
+func (l *loader) sourceTTLDays(prefix string, sources []string) map[string]int {
+	days := make(map[string]int)
+	for _, source := range sources {
+		value, ok := l.lookup(prefix + source)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			l.invalid(prefix+source, value, "an integer")
+			continue
+		}
+		days[source] = n
+	}
+	return days
+}
+
+// unknownKeys returns the names in values that nothing read, e.g. misspellings
+func (l *loader) unknownKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		if !l.asked[key] && !slices.Contains(sourceSettings, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// IsSet reports whether the remote configuration, environment or config file gave the
+// setting a value, rather than it taking its default
+func (c *Config) IsSet(key string) bool {
+	_, ok := c.values[key]
+	return ok
+}
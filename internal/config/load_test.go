@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile writes a config file and points ConfigFileEnv at it
+func writeConfigFile(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "flowebb.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv(ConfigFileEnv, path)
+}
+
+func TestLoad_ConfigFile(t *testing.T) {
+	writeConfigFile(t, `
+LOG_LEVEL: debug
+HTTP_TIMEOUT: 4s
+NOAA_MAX_CONCURRENT_REQUESTS: 3
+CACHE_BACKEND: file
+CACHE_ENABLE_DYNAMO: false
+TIDE_INTERPOLATION: spline
+`)
+	t.Setenv("TIDE_INTERPOLATION", "harmonic")
+
+	cfg, err := load()
+	require.NoError(t, err)
+	assert.Equal(t, zerolog.DebugLevel, cfg.LogLevel)
+	assert.Equal(t, 4*time.Second, cfg.HTTPTimeout)
+	assert.Equal(t, 3, cfg.NOAAMaxConcurrentRequests)
+	assert.Equal(t, BackendFile, cfg.Cache.Backend)
+	assert.False(t, cfg.Cache.EnableDynamoCache)
+	assert.Equal(t, "harmonic", cfg.InterpolationMethod, "the environment wins over the file")
+	assert.True(t, cfg.IsSet("LOG_LEVEL"))
+	assert.False(t, cfg.IsSet("ENV"))
+}
+
+func TestLoad_ReportsEveryProblem(t *testing.T) {
+	writeConfigFile(t, `
+HTTP_TIMEOUT: soon
+CACHE_BACKND: redis
+`)
+	t.Setenv("CACHE_BACKEND", "mongo")
+	t.Setenv("TIDE_REQUEST_TIMEOUT", "-1s")
+	t.Setenv("CACHE_ENABLE_LRU", "maybe")
+
+	cfg, err := load()
+	assert.Nil(t, cfg)
+	require.Error(t, err)
+	for _, want := range []string{
+		`HTTP_TIMEOUT="soon" is not a duration such as 10s`,
+		`CACHE_ENABLE_LRU="maybe" is not true or false`,
+		"unknown setting CACHE_BACKND in config file",
+		"TIDE_REQUEST_TIMEOUT must not be negative, not -1s",
+		`invalid CACHE_BACKEND "mongo": must be one of dynamo, redis, file`,
+	} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestLoad_UnreadableFile(t *testing.T) {
+	t.Setenv(ConfigFileEnv, filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err := load()
+	assert.ErrorContains(t, err, "reading config file")
+
+	writeConfigFile(t, "- not\n- a mapping\n")
+	_, err = load()
+	assert.ErrorContains(t, err, "parsing config file")
+}
+
+func TestLoad_Once(t *testing.T) {
+	first, err := Load()
+	require.NoError(t, err)
+	t.Setenv("ENV", "changed")
+	second, err := Load()
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := LoadFromEnv()
+	assert.NoError(t, cfg.Validate(), "the defaults are valid")
+
+	cfg.HTTPTimeout = 0
+	cfg.InterpolationMethod = "cubic"
+	cfg.NWSBaseURL = "weather.gov"
+	cfg.Cache.BatchSize = 100
+	cfg.Tenants = []tenant.Tenant{{ID: "acme", Hosts: []string{"tides.acme.com"}}, {ID: "globex", Hosts: []string{"Tides.Acme.com"}}}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP_TIMEOUT must be positive, not 0s")
+	assert.Contains(t, err.Error(), `invalid TIDE_INTERPOLATION "cubic"`)
+	assert.Contains(t, err.Error(), `NWS_BASE_URL="weather.gov" is not an absolute URL`)
+	assert.Contains(t, err.Error(), `invalid CACHE_BATCH_SIZE "100": must be between 1 and 25`)
+	assert.Contains(t, err.Error(), "TENANTS: host tides.acme.com belongs to tenants acme and globex")
+}
+
+func TestDump(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "s3cret")
+	t.Setenv("CACHE_REDIS_PASSWORD", "hunter2")
+	cfg := LoadFromEnv()
+
+	fields := cfg.Dump()
+	assert.Equal(t, "[redacted]", fields["AdminAPIKey"])
+	assert.Equal(t, "[redacted]", fields["Cache.RedisPassword"])
+	assert.Equal(t, "10s", fields["HTTPTimeout"])
+	assert.Equal(t, "info", fields["LogLevel"])
+	assert.Equal(t, BackendDynamo, fields["Cache.Backend"])
+	assert.Equal(t, 8, fields["NOAAMaxConcurrentRequests"])
+	for key, value := range fields {
+		assert.NotContains(t, []any{"s3cret", "hunter2"}, value, key)
+	}
+
+	cfg.AdminAPIKey = ""
+	assert.Equal(t, "", cfg.Dump()["AdminAPIKey"], "an unset secret shows as unset")
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/logging"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// reloadable are the settings a process applies when the remote configuration changes
+// while it runs: TTLs and batch settings for cached predictions, the NOAA request cap,
+// feature flags and the log level and sampling. A change to any other setting waits for the next cold
+// start.
+var reloadable = []string{
+	"LOG_LEVEL",
+	"LOG_SAMPLE_RATE",
+	"FEATURE_FLAGS",
+	"NOAA_MAX_CONCURRENT_REQUESTS",
+	"CACHE_TIDE_LRU_TTL_MINUTES",
+	"CACHE_DYNAMO_TTL_DAYS",
+	"CACHE_HISTORICAL_TTL_DAYS",
+	"CACHE_DYNAMO_COMPRESS_MIN_BYTES",
+	"CACHE_BATCH_SIZE",
+	"CACHE_MAX_BATCH_RETRIES",
+}
+
+var (
+	// watched re-reads the remote configuration Load found, if any
+	watched *watcher
+
+	listenersMu sync.Mutex
+	listeners   []func(*Config)
+)
+
+// OnReload registers fn to be called with the new configuration whenever Watch sees a
+// reloadable setting change. fn should apply only the reloadable settings it uses.
+func OnReload(fn func(*Config)) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Watch reads the remote configuration every CONFIG_REFRESH_INTERVAL until ctx is done,
+// applying the log level and sampling and calling the OnReload functions when a reloadable setting
+// changes. A configuration that can't be read or doesn't validate is logged and the
+// current one kept. Watch does nothing unless Load found a remote configuration.
+func Watch(ctx context.Context) {
+	w := watched
+	if w == nil || w.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refreshCtx, cancel := context.WithTimeout(ctx, remoteTimeout)
+				if err := w.refresh(refreshCtx); err != nil {
+					log.Warn().Err(err).Msg("Keeping the current configuration")
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// ErrNotWritable is returned by SetRemote when there's no remote configuration to write to
+var ErrNotWritable = errors.New("the remote configuration can't be written; set " + SSMPathEnv)
+
+// SetRemote writes a reloadable setting to the remote configuration Load found and applies
+// it to this process at once; every other process applies it the next time Watch reads
+// the remote configuration. Only SSM parameters can be written.
+func SetRemote(ctx context.Context, name, value string) error {
+	if !slices.Contains(reloadable, name) {
+		return fmt.Errorf("%s can't be changed while running", name)
+	}
+	w := watched
+	if w == nil {
+		return ErrNotWritable
+	}
+	writer, ok := w.source.(RemoteWriter)
+	if !ok {
+		return ErrNotWritable
+	}
+
+	// Check the value before every process reads it, rather than have them all keep
+	// rejecting it
+	remote, err := w.source.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("reading configuration from %s: %w", w.source, err)
+	}
+	remote = maps.Clone(remote)
+	if remote == nil {
+		remote = map[string]string{}
+	}
+	remote[name] = value
+	l := newLoader(w.file)
+	l.remote = remote
+	if err := l.check(l.config()); err != nil {
+		return err
+	}
+
+	if err := writer.Put(ctx, name, value); err != nil {
+		return fmt.Errorf("writing %s to %s: %w", name, w.source, err)
+	}
+	return w.refresh(ctx)
+}
+
+// watcher holds what's needed to build the configuration again with new remote values
+type watcher struct {
+	file     map[string]string
+	source   RemoteSource
+	interval time.Duration
+
+	mu      sync.Mutex
+	current *Config
+}
+
+// refresh reads the remote configuration and, if it's valid and a reloadable setting
+// changed, applies it
+func (w *watcher) refresh(ctx context.Context) error {
+	remote, err := w.source.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("reading configuration from %s: %w", w.source, err)
+	}
+	l := newLoader(w.file)
+	l.remote = remote
+	cfg := l.config()
+	if err := l.check(cfg); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	live, restart := changedSettings(w.current, cfg)
+	w.current = cfg
+	w.mu.Unlock()
+
+	if len(restart) > 0 {
+		log.Warn().Strs("settings", restart).Msg("Changed settings take effect after a restart")
+	}
+	if len(live) == 0 {
+		return nil
+	}
+	log.Info().Strs("settings", live).Msg("Reloading configuration")
+	zerolog.SetGlobalLevel(cfg.LogLevel)
+	logging.SetSampleRate(cfg.LogSampleRate)
+
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	for _, fn := range listeners {
+		fn(cfg)
+	}
+	return nil
+}
+
+// changedSettings returns the names of the settings whose values differ between two
+// configurations, split into those that can be applied live and those that can't
+func changedSettings(before, after *Config) (live, restart []string) {
+	keys := slices.Collect(maps.Keys(before.values))
+	for key := range after.values {
+		if _, ok := before.values[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if before.values[key] == after.values[key] {
+			continue
+		}
+		if slices.Contains(reloadable, key) {
+			live = append(live, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	return live, restart
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreListeners drops the OnReload functions the test registers when it ends
+func restoreListeners(t *testing.T) {
+	t.Helper()
+	previous := listeners
+	t.Cleanup(func() {
+		listenersMu.Lock()
+		defer listenersMu.Unlock()
+		listeners = previous
+	})
+}
+
+// captureReloads registers an OnReload function for the test and returns what it receives
+func captureReloads(t *testing.T) *[]*Config {
+	t.Helper()
+	restoreListeners(t)
+	var reloads []*Config
+	OnReload(func(cfg *Config) { reloads = append(reloads, cfg) })
+	return &reloads
+}
+
+func TestWatcherRefresh(t *testing.T) {
+	level := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	source := &fakeSource{values: map[string]string{"CACHE_DYNAMO_TTL_DAYS": "2"}}
+	useRemoteSource(t, source)
+	_, err := load()
+	require.NoError(t, err)
+	reloads := captureReloads(t)
+
+	source.values = map[string]string{"CACHE_DYNAMO_TTL_DAYS": "2", "CACHE_BACKEND": BackendRedis}
+	require.NoError(t, watched.refresh(context.Background()))
+	assert.Empty(t, *reloads, "settings that need a restart aren't applied")
+
+	source.values = map[string]string{"CACHE_DYNAMO_TTL_DAYS": "5", "CACHE_BACKEND": BackendRedis, "LOG_LEVEL": "error"}
+	require.NoError(t, watched.refresh(context.Background()))
+	require.Len(t, *reloads, 1)
+	assert.Equal(t, 5, (*reloads)[0].Cache.TidePredictionDynamoTTLDays)
+	assert.Equal(t, zerolog.ErrorLevel, zerolog.GlobalLevel())
+
+	source.values = map[string]string{"CACHE_DYNAMO_TTL_DAYS": "-1"}
+	assert.ErrorContains(t, watched.refresh(context.Background()), "CACHE_DYNAMO_TTL_DAYS")
+	source.err = errors.New("throttled")
+	assert.EqualError(t, watched.refresh(context.Background()), "reading configuration from fake source: throttled")
+	assert.Len(t, *reloads, 1, "a configuration that can't be read or is invalid isn't applied")
+	assert.Equal(t, 5, watched.current.Cache.TidePredictionDynamoTTLDays)
+}
+
+func TestWatch(t *testing.T) {
+	source := &fakeSource{values: map[string]string{"CACHE_TIDE_LRU_TTL_MINUTES": "15"}}
+	useRemoteSource(t, source)
+	t.Setenv(RefreshIntervalEnv, "10ms")
+	_, err := load()
+	require.NoError(t, err)
+
+	reloaded := make(chan *Config, 1)
+	restoreListeners(t)
+	OnReload(func(cfg *Config) {
+		select {
+		case reloaded <- cfg:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source.values = map[string]string{"CACHE_TIDE_LRU_TTL_MINUTES": "30"}
+	Watch(ctx)
+
+	select {
+	case cfg := <-reloaded:
+		assert.Equal(t, 30*time.Minute, cfg.Cache.GetTidePredictionLRUTTL())
+	case <-time.After(time.Second):
+		t.Fatal("the changed TTL wasn't reloaded")
+	}
+}
+
+func TestChangedSettings(t *testing.T) {
+	before := &Config{values: map[string]string{"LOG_LEVEL": "info", "CACHE_BACKEND": "dynamo", "ENV": "prod"}}
+	after := &Config{values: map[string]string{"LOG_LEVEL": "warn", "CACHE_BACKEND": "redis", "ENV": "prod", "CACHE_BATCH_SIZE": "10"}}
+
+	live, restart := changedSettings(before, after)
+	assert.Equal(t, []string{"CACHE_BATCH_SIZE", "LOG_LEVEL"}, live)
+	assert.Equal(t, []string{"CACHE_BACKEND"}, restart)
+}
+
+// writableSource is a fakeSource settings can be written to
+type writableSource struct {
+	fakeSource
+	err error
+}
+
+func (s *writableSource) Put(_ context.Context, name, value string) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.values[name] = value
+	return nil
+}
+
+func TestSetRemote(t *testing.T) {
+	level := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+	ctx := context.Background()
+
+	useRemoteSource(t, &fakeSource{values: map[string]string{}})
+	_, err := load()
+	require.NoError(t, err)
+	assert.ErrorIs(t, SetRemote(ctx, "LOG_LEVEL", "debug"), ErrNotWritable, "AppConfig can't be written")
+
+	source := &writableSource{fakeSource: fakeSource{values: map[string]string{"LOG_LEVEL": "info"}}}
+	useRemoteSource(t, source)
+	_, err = load()
+	require.NoError(t, err)
+	reloads := captureReloads(t)
+
+	require.NoError(t, SetRemote(ctx, "LOG_LEVEL", "warn"))
+	assert.Equal(t, "warn", source.values["LOG_LEVEL"])
+	assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel(), "this process applies it at once")
+	assert.Len(t, *reloads, 1)
+
+	assert.ErrorContains(t, SetRemote(ctx, "LOG_SAMPLE_RATE", "-1"), "LOG_SAMPLE_RATE")
+	assert.ErrorContains(t, SetRemote(ctx, "LOG_LEVEL", "loud"), "LOG_LEVEL")
+	assert.ErrorContains(t, SetRemote(ctx, "CACHE_BACKEND", BackendRedis), "can't be changed while running")
+	assert.NotContains(t, source.values, "LOG_SAMPLE_RATE", "invalid values aren't written")
+	assert.Equal(t, "warn", source.values["LOG_LEVEL"])
+
+	source.err = errors.New("access denied")
+	assert.ErrorContains(t, SetRemote(ctx, "LOG_LEVEL", "debug"), "access denied")
+
+	watched = nil
+	assert.ErrorIs(t, SetRemote(ctx, "LOG_LEVEL", "debug"), ErrNotWritable)
+}
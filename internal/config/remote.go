@@ -0,0 +1,179 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings naming where the configuration is read from. They can only be given in the
+// environment or the config file.
+const (
+	// SSMPathEnv names an SSM Parameter Store path, e.g. /flowebb/prod, whose parameters
+	// are settings named like the environment variables, e.g. /flowebb/prod/LOG_LEVEL
+	SSMPathEnv = "CONFIG_SSM_PATH"
+	// AppConfigEnv names an AppConfig configuration as application/environment/profile,
+	// read through the AppConfig Lambda extension. The configuration is a YAML or JSON
+	// mapping of setting names to values, like the config file.
+	AppConfigEnv = "CONFIG_APPCONFIG"
+	// RefreshIntervalEnv is how often Watch reads the remote configuration again; zero
+	// reads it only at startup
+	RefreshIntervalEnv = "CONFIG_REFRESH_INTERVAL"
+)
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	// remoteTimeout bounds each read of the remote configuration
+	remoteTimeout = 5 * time.Second
+	// defaultAppConfigPort is where the AppConfig Lambda extension listens unless
+	// AWS_APPCONFIG_EXTENSION_HTTP_PORT says otherwise
+	defaultAppConfigPort = "2772"
+)
+
+// RemoteSource supplies settings kept outside the deployment, so they can change without
+// redeploying the functions
+type RemoteSource interface {
+	// Fetch returns the settings by name
+	Fetch(ctx context.Context) (map[string]string, error)
+	// String describes the source in errors and logs
+	String() string
+}
+
+// newRemoteSource creates the source named by the ssmPath or appConfig settings, or
+// returns nil when neither is set. Tests replace it.
+var newRemoteSource = defaultRemoteSource
+
+func defaultRemoteSource(ctx context.Context, ssmPath, appConfig string) (RemoteSource, error) {
+	switch {
+	case ssmPath != "" && appConfig != "":
+		return nil, fmt.Errorf("set %s or %s, not both", SSMPathEnv, AppConfigEnv)
+	case ssmPath != "":
+		awsConfig, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("loading AWS config: %w", err)
+		}
+		return &SSMSource{Client: ssm.NewFromConfig(awsConfig), Path: ssmPath}, nil
+	case appConfig != "":
+		return NewAppConfigSource(appConfig)
+	}
+	return nil, nil
+}
+
+// SSMClient is the part of the SSM API SSMSource uses
+type SSMClient interface {
+	GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error)
+	PutParameter(ctx context.Context, params *ssm.PutParameterInput, optFns ...func(*ssm.Options)) (*ssm.PutParameterOutput, error)
+}
+
+// RemoteWriter is a RemoteSource settings can be written to
+type RemoteWriter interface {
+	RemoteSource
+	// Put sets the setting name to value
+	Put(ctx context.Context, name, value string) error
+}
+
+// SSMSource reads the parameters directly under Path, decrypting SecureStrings, and names
+// each setting after the last element of its parameter's name
+type SSMSource struct {
+	Client SSMClient
+	Path   string
+}
+
+func (s *SSMSource) Fetch(ctx context.Context) (map[string]string, error) {
+	values := map[string]string{}
+	paginator := ssm.NewGetParametersByPathPaginator(s.Client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(s.Path),
+		WithDecryption: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, parameter := range page.Parameters {
+			values[path.Base(aws.ToString(parameter.Name))] = aws.ToString(parameter.Value)
+		}
+	}
+	return values, nil
+}
+
+// Put writes the parameter for the setting name under Path, as a plain String
+func (s *SSMSource) Put(ctx context.Context, name, value string) error {
+	_, err := s.Client.PutParameter(ctx, &ssm.PutParameterInput{
+		Name:      aws.String(path.Join(s.Path, name)),
+		Value:     aws.String(value),
+		Type:      ssmtypes.ParameterTypeString,
+		Overwrite: aws.Bool(true),
+	})
+	return err
+}
+
+func (s *SSMSource) String() string {
+	return "SSM parameters under " + s.Path
+}
+
+// AppConfigSource reads a configuration from the AppConfig Lambda extension, which polls
+// AppConfig and serves the latest deployed version locally
+type AppConfigSource struct {
+	Client *http.Client
+	URL    string
+}
+
+// NewAppConfigSource reads the configuration named application/environment/profile
+func NewAppConfigSource(name string) (*AppConfigSource, error) {
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return nil, fmt.Errorf("%s=%q is not application/environment/profile", AppConfigEnv, name)
+	}
+	port := os.Getenv("AWS_APPCONFIG_EXTENSION_HTTP_PORT")
+	if port == "" {
+		port = defaultAppConfigPort
+	}
+	return &AppConfigSource{
+		Client: &http.Client{Timeout: remoteTimeout},
+		URL: fmt.Sprintf("http://localhost:%s/applications/%s/environments/%s/configurations/%s",
+			port, parts[0], parts[1], parts[2]),
+	}, nil
+}
+
+func (s *AppConfigSource) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AppConfig extension returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	// JSON is YAML too, so either format of configuration profile works
+	var values map[string]string
+	if err := yaml.Unmarshal(body, &values); err != nil {
+		return nil, fmt.Errorf("parsing AppConfig configuration: %w", err)
+	}
+	return values, nil
+}
+
+func (s *AppConfigSource) String() string {
+	return "AppConfig configuration " + s.URL
+}
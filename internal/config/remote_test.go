@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource is a RemoteSource serving whatever the test last set
+type fakeSource struct {
+	values map[string]string
+	err    error
+}
+
+func (s *fakeSource) Fetch(context.Context) (map[string]string, error) {
+	return s.values, s.err
+}
+
+func (s *fakeSource) String() string {
+	return "fake source"
+}
+
+// useRemoteSource makes Load read source, as if CONFIG_SSM_PATH were set
+func useRemoteSource(t *testing.T, source RemoteSource) {
+	t.Helper()
+	previous := newRemoteSource
+	newRemoteSource = func(context.Context, string, string) (RemoteSource, error) {
+		return source, nil
+	}
+	t.Cleanup(func() { newRemoteSource = previous })
+}
+
+type mockSSMClient struct {
+	pages [][]types.Parameter
+	calls []*ssm.GetParametersByPathInput
+	puts  []*ssm.PutParameterInput
+}
+
+func (m *mockSSMClient) PutParameter(_ context.Context, params *ssm.PutParameterInput, _ ...func(*ssm.Options)) (*ssm.PutParameterOutput, error) {
+	m.puts = append(m.puts, params)
+	return &ssm.PutParameterOutput{}, nil
+}
+
+func (m *mockSSMClient) GetParametersByPath(_ context.Context, params *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	m.calls = append(m.calls, params)
+	page := len(m.calls) - 1
+	output := &ssm.GetParametersByPathOutput{Parameters: m.pages[page]}
+	if page+1 < len(m.pages) {
+		output.NextToken = aws.String("next")
+	}
+	return output, nil
+}
+
+func TestSSMSource(t *testing.T) {
+	client := &mockSSMClient{pages: [][]types.Parameter{
+		{{Name: aws.String("/flowebb/prod/LOG_LEVEL"), Value: aws.String("warn")}},
+		{{Name: aws.String("/flowebb/prod/CACHE_DYNAMO_TTL_DAYS"), Value: aws.String("3")}},
+	}}
+	source := &SSMSource{Client: client, Path: "/flowebb/prod"}
+
+	values, err := source.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "warn", "CACHE_DYNAMO_TTL_DAYS": "3"}, values)
+	require.Len(t, client.calls, 2)
+	assert.Equal(t, "/flowebb/prod", aws.ToString(client.calls[0].Path))
+	assert.True(t, aws.ToBool(client.calls[0].WithDecryption))
+	assert.Equal(t, "next", aws.ToString(client.calls[1].NextToken))
+}
+
+func TestSSMSource_Put(t *testing.T) {
+	client := &mockSSMClient{}
+	source := &SSMSource{Client: client, Path: "/flowebb/prod"}
+
+	require.NoError(t, source.Put(context.Background(), "LOG_LEVEL", "debug"))
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, "/flowebb/prod/LOG_LEVEL", aws.ToString(client.puts[0].Name))
+	assert.Equal(t, "debug", aws.ToString(client.puts[0].Value))
+	assert.Equal(t, types.ParameterTypeString, client.puts[0].Type)
+	assert.True(t, aws.ToBool(client.puts[0].Overwrite))
+}
+
+func TestAppConfigSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/applications/flowebb/environments/prod/configurations/settings":
+			_, _ = w.Write([]byte(`{"LOG_LEVEL": "warn", "NOAA_MAX_CONCURRENT_REQUESTS": 4}`))
+		default:
+			http.Error(w, "no such configuration", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AWS_APPCONFIG_EXTENSION_HTTP_PORT", server.URL[len("http://127.0.0.1:"):])
+
+	source, err := NewAppConfigSource("flowebb/prod/settings")
+	require.NoError(t, err)
+	values, err := source.Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "warn", "NOAA_MAX_CONCURRENT_REQUESTS": "4"}, values)
+
+	source, err = NewAppConfigSource("flowebb/prod/missing")
+	require.NoError(t, err)
+	_, err = source.Fetch(context.Background())
+	assert.ErrorContains(t, err, "404 Not Found: no such configuration")
+
+	_, err = NewAppConfigSource("flowebb/prod")
+	assert.EqualError(t, err, `CONFIG_APPCONFIG="flowebb/prod" is not application/environment/profile`)
+}
+
+func TestDefaultRemoteSource(t *testing.T) {
+	source, err := defaultRemoteSource(context.Background(), "", "")
+	require.NoError(t, err)
+	assert.Nil(t, source)
+
+	_, err = defaultRemoteSource(context.Background(), "/flowebb/prod", "flowebb/prod/settings")
+	assert.EqualError(t, err, "set CONFIG_SSM_PATH or CONFIG_APPCONFIG, not both")
+}
+
+func TestLoad_RemoteConfiguration(t *testing.T) {
+	useRemoteSource(t, &fakeSource{values: map[string]string{
+		"LOG_LEVEL":            "warn",
+		"CACHE_DYNAMO_TTL_DAY": "3",
+	}})
+	t.Setenv("LOG_LEVEL", "debug")
+
+	_, err := load()
+	assert.ErrorContains(t, err, "unknown setting CACHE_DYNAMO_TTL_DAY in remote configuration")
+
+	useRemoteSource(t, &fakeSource{values: map[string]string{"LOG_LEVEL": "warn"}})
+	cfg, err := load()
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.LogLevel.String(), "the remote configuration wins over the environment")
+	require.NotNil(t, watched)
+	assert.Equal(t, defaultRefreshInterval, watched.interval)
+
+	useRemoteSource(t, &fakeSource{err: errors.New("access denied")})
+	_, err = load()
+	assert.EqualError(t, err, "reading configuration from fake source: access denied")
+}
+
+func TestLoad_RetriesAfterFailure(t *testing.T) {
+	previous := loaded
+	loaded = nil
+	t.Cleanup(func() { loaded = previous })
+
+	useRemoteSource(t, &fakeSource{err: errors.New("throttled")})
+	_, err := Load()
+	assert.EqualError(t, err, "reading configuration from fake source: throttled")
+
+	useRemoteSource(t, &fakeSource{values: map[string]string{"LOG_LEVEL": "warn"}})
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.LogLevel.String())
+
+	useRemoteSource(t, &fakeSource{err: errors.New("throttled")})
+	again, err := Load()
+	require.NoError(t, err)
+	assert.Same(t, cfg, again, "a loaded configuration isn't read again")
+}
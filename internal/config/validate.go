@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/tenant"
+)
+
+// Validate reports every setting that is out of range or isn't one of its allowed values,
+// naming each by its environment variable
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive, not %s", key, d))
+		}
+	}
+	notNegative := func(key string, d time.Duration) {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative, not %s", key, d))
+		}
+	}
+	absoluteURL := func(key, value string) {
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s=%q is not an absolute URL", key, value))
+		}
+	}
+
+	check(validate.AtLeast("LOG_SAMPLE_RATE", float64(c.LogSampleRate), 0))
+	positive("HTTP_TIMEOUT", c.HTTPTimeout)
+	positive("GRAPHQL_HTTP_TIMEOUT", c.GraphQLHTTPTimeout)
+	notNegative("TIDE_UPSTREAM_TIMEOUT", c.UpstreamTimeout)
+	notNegative("TIDE_CACHE_TIMEOUT", c.CacheTimeout)
+	notNegative("TIDE_REQUEST_TIMEOUT", c.RequestTimeout)
+	notNegative("WEATHER_CACHE_TTL", c.WeatherCacheTTL)
+	if c.InterpolationMethod != "" {
+		check(validate.OneOf("TIDE_INTERPOLATION", c.InterpolationMethod, "linear", "spline", "harmonic"))
+	}
+	if c.HTTPCassetteMode != "" {
+		check(validate.OneOf("HTTP_CASSETTE_MODE", c.HTTPCassetteMode, "record", "replay"))
+	}
+	check(validate.AtLeast("TIDE_MAX_STATION_DISTANCE_KM", c.MaxStationDistanceKm, 0))
+	check(validate.AtLeast("TIDE_ANOMALY_THRESHOLD_FT", c.AnomalyThresholdFt, 0))
+	if c.MaxResponsePredictions != 0 {
+		// Fewer than a chart's worth of points isn't a usable curve
+		check(validate.AtLeast("TIDE_MAX_RESPONSE_PREDICTIONS", float64(c.MaxResponsePredictions), 100))
+	}
+	check(validate.AtLeast("NOAA_MAX_CONCURRENT_REQUESTS", float64(c.NOAAMaxConcurrentRequests), 0))
+	check(validate.NotEmpty("USER_DATA_TABLE", c.UserDataTable))
+	check(validate.NotEmpty("REPORTS_TABLE", c.ReportsTable))
+	check(validate.AtLeast("REPORT_RATE_LIMIT", float64(c.ReportRateLimit), 0))
+	if c.IdempotencyTable != "" {
+		positive("IDEMPOTENCY_TTL", c.IdempotencyTTL)
+	}
+	if c.AuditTable != "" {
+		positive("AUDIT_RETENTION", c.AuditRetention)
+	}
+	check(validate.AtLeast("API_KEY_MONTHLY_QUOTA", float64(c.APIKeyMonthlyQuota), 0))
+	for _, id := range slices.Sorted(maps.Keys(c.APIKeyQuotas)) {
+		check(validate.AtLeast("API_KEY_QUOTAS "+id, float64(c.APIKeyQuotas[id]), 0))
+	}
+	if c.APIKeyMonthlyQuota > 0 || len(c.APIKeyQuotas) > 0 {
+		check(validate.NotEmpty("QUOTA_TABLE", c.QuotaTable))
+	}
+	if len(c.AccuracyStations) > 0 {
+		check(validate.NotEmpty("ACCURACY_TABLE", c.AccuracyTable))
+	}
+	if c.ExportBucket != "" || len(c.ExportStations) > 0 {
+		check(validate.NotEmpty("EXPORT_BUCKET", c.ExportBucket))
+		positive("EXPORT_URL_TTL", c.ExportURLTTL)
+		// Presigned URLs can't outlive a week
+		if c.ExportURLTTL > maxExportURLTTL {
+			errs = append(errs, fmt.Errorf("EXPORT_URL_TTL must be at most %s, not %s", maxExportURLTTL, c.ExportURLTTL))
+		}
+	}
+	if c.TaskQueueURL != "" {
+		absoluteURL("TASK_QUEUE_URL", c.TaskQueueURL)
+	}
+	if c.StationChangesTopicARN != "" {
+		// Only changes the table hasn't seen are published, so it can't be left out
+		check(validate.NotEmpty("STATION_CHANGES_TABLE", c.StationChangesTable))
+	}
+	notNegative("SYNC_INTERVAL", c.SyncInterval)
+	if len(c.SyncStations) > 0 {
+		check(validate.Between("SYNC_DAYS", float64(c.SyncDays), 1, maxSyncDays))
+	}
+	if c.DemoMode {
+		notNegative("DEMO_LATENCY", c.DemoLatency)
+		check(validate.AtLeast("DEMO_RATE_LIMIT", float64(c.DemoRateLimit), 0))
+	}
+	check(validate.NotEmpty("NWS_USER_AGENT", c.NWSUserAgent))
+	absoluteURL("NWS_BASE_URL", c.NWSBaseURL)
+	absoluteURL("WIDGET_URL", c.WidgetURL)
+	if _, err := tenant.NewRegistry(c.Tenants); err != nil {
+		errs = append(errs, fmt.Errorf("TENANTS: %w", err))
+	}
+	if c.Cache != nil {
+		check(c.Cache.Validate())
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every cache setting that is out of range or isn't one of its allowed
+// values
+func (c *CacheConfig) Validate() error {
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	atLeast := func(key string, value, min int) {
+		check(validate.AtLeast(key, float64(value), float64(min)))
+	}
+
+	check(validate.OneOf("CACHE_BACKEND", c.Backend, BackendDynamo, BackendRedis, BackendFile, BackendSQLite, BackendMemory))
+	if c.EnableLRUCache {
+		atLeast("CACHE_TIDE_LRU_SIZE", c.TidePredictionLRUSize, 1)
+	}
+	atLeast("CACHE_TIDE_LRU_TTL_MINUTES", c.TidePredictionLRUTTLMinutes, 0)
+	atLeast("CACHE_TIDE_LRU_MAX_MB", c.TidePredictionLRUMaxMB, 0)
+	atLeast("CACHE_DYNAMO_TTL_DAYS", c.TidePredictionDynamoTTLDays, 0)
+	atLeast("CACHE_HISTORICAL_TTL_DAYS", c.HistoricalTTLDays, 0)
+	atLeast("CACHE_STATION_LIST_TTL_DAYS", c.StationListTTLDays, 0)
+	atLeast("CACHE_STATION_LIST_MAX_STALE_DAYS", c.StationListMaxStaleDays, 0)
+	for _, source := range stationListSources {
+		if days, ok := c.StationListTTLDaysBySource[source]; ok {
+			atLeast("CACHE_STATION_LIST_TTL_DAYS_"+source, days, 0)
+		}
+	}
+	atLeast("CACHE_GRAPHQL_LRU_SIZE", c.GraphQLLRUSize, 1)
+	atLeast("CACHE_GRAPHQL_TTL_MINUTES", c.GraphQLLRUTTLMinutes, 0)
+	// DynamoDB writes at most 25 items per batch
+	check(validate.Between("CACHE_BATCH_SIZE", float64(c.BatchSize), 1, 25))
+	atLeast("CACHE_MAX_BATCH_RETRIES", c.MaxBatchRetries, 0)
+	atLeast("CACHE_WRITE_WORKERS", c.WriteBehindWorkers, 0)
+	atLeast("CACHE_WRITE_QUEUE_SIZE", c.WriteBehindQueueSize, 0)
+	atLeast("CACHE_WRITE_MAX_RETRIES", c.WriteBehindMaxRetries, 0)
+	atLeast("CACHE_REDIS_DB", c.RedisDB, 0)
+	for _, region := range slices.Sorted(maps.Keys(c.StationListReplicaBuckets)) {
+		if !slices.Contains(c.FallbackRegions, region) {
+			check(fmt.Errorf("STATION_LIST_REPLICA_BUCKETS has a bucket in %s, which isn't in CACHE_FALLBACK_REGIONS", region))
+		}
+	}
+	switch c.Backend {
+	case BackendFile:
+		check(validate.NotEmpty("CACHE_DIR", c.FileCacheDir))
+	case BackendSQLite:
+		if c.SQLitePath == "" {
+			check(validate.NotEmpty("CACHE_DIR", c.FileCacheDir))
+		}
+	case BackendRedis:
+		check(validate.NotEmpty("CACHE_REDIS_ADDR", c.RedisAddr))
+	}
+	return errors.Join(errs...)
+}
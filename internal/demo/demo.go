@@ -0,0 +1,234 @@
+// Package demo stands in for NOAA with a handful of canned stations whose predictions are
+// computed from embedded tidal constituents, so the API can be tried in docs and sandboxes
+// without spending NOAA's quota or standing up AWS.
+package demo
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+// stations.json holds NOAA's listing of each canned station and the constituents its
+// tide is computed from. The amplitudes are close to the stations' own but the phases
+// aren't, so the curves look like each station's tide without being its predictions.
+//
+//go:embed stations.json
+var fixtures []byte
+
+// speeds are the constituents' angular speeds, in degrees an hour
+var speeds = map[string]float64{
+	"M2": 28.9841042,
+	"S2": 30.0,
+	"N2": 28.4397295,
+	"K1": 15.0410686,
+	"O1": 13.9430356,
+}
+
+// epoch is when every constituent's phase is measured from
+var epoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// noData is NOAA's answer for a product or station it has nothing for
+const noData = "No data was found. This product may not be offered at this station at the requested time."
+
+// listing is a station as NOAA's prediction station list has it
+type listing struct {
+	ID           string  `json:"stationId"`
+	Name         string  `json:"name"`
+	State        string  `json:"state"`
+	Region       string  `json:"region"`
+	Lat          float64 `json:"lat"`
+	Lon          float64 `json:"lon"`
+	TimeZoneCorr string  `json:"timeZoneCorr"`
+	StationType  string  `json:"stationType"`
+}
+
+type station struct {
+	listing
+	// TimeZone is the zone lst_ldt times are given in
+	TimeZone string `json:"timeZone"`
+	// MeanSeaLevel is the height, in feet above MLLW, the tide swings about
+	MeanSeaLevel float64       `json:"meanSeaLevel"`
+	Constituents []constituent `json:"constituents"`
+
+	location *time.Location
+}
+
+// constituent is one harmonic of a station's tide: amplitude in feet, phase in degrees
+type constituent struct {
+	Name      string  `json:"name"`
+	Amplitude float64 `json:"amplitude"`
+	Phase     float64 `json:"phase"`
+}
+
+// Upstream answers the requests the NOAA client makes, as its GetFunc, from the canned
+// stations. Each answer waits about Latency, somewhere between half and one and a half
+// times it, as a trip to NOAA would.
+type Upstream struct {
+	Latency  time.Duration
+	stations map[string]*station
+	// list is the body of the prediction station list
+	list []byte
+}
+
+// New loads the canned stations into an Upstream whose answers take about latency
+func New(latency time.Duration) (*Upstream, error) {
+	var stations []*station
+	if err := json.Unmarshal(fixtures, &stations); err != nil {
+		return nil, fmt.Errorf("decoding demo stations: %w", err)
+	}
+	u := &Upstream{Latency: latency, stations: make(map[string]*station, len(stations))}
+	listings := make([]listing, len(stations))
+	for i, s := range stations {
+		location, err := time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("demo station %s: %w", s.ID, err)
+		}
+		for _, c := range s.Constituents {
+			if _, ok := speeds[c.Name]; !ok {
+				return nil, fmt.Errorf("demo station %s: unknown constituent %s", s.ID, c.Name)
+			}
+		}
+		s.location = location
+		u.stations[s.ID] = s
+		listings[i] = s.listing
+	}
+	list, err := json.Marshal(map[string][]listing{"stationList": listings})
+	if err != nil {
+		return nil, fmt.Errorf("encoding demo station list: %w", err)
+	}
+	u.list = list
+	return u, nil
+}
+
+// Get answers a NOAA request for path. The canned stations are reference stations with
+// only a water level gauge, so sensor and offset lookups find nothing, and the datagetter
+// has predictions but no observations.
+func (u *Upstream) Get(ctx context.Context, path string) (*client.Response, error) {
+	if err := u.wait(ctx); err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("parsing request: %w", err)
+	}
+	switch target.Path {
+	case "/mdapi/prod/webapi/tidepredstations.json":
+		return respond(http.StatusOK, u.list), nil
+	case "/mdapi/prod/webapi/stations.json":
+		return respond(http.StatusOK, []byte(`{"stations":[]}`)), nil
+	case "/api/prod/datagetter":
+		return respond(http.StatusOK, u.datagetter(target.Query())), nil
+	default:
+		return respond(http.StatusNotFound, nil), nil
+	}
+}
+
+// wait holds an answer for about Latency, or until ctx is done
+func (u *Upstream) wait(ctx context.Context) error {
+	if u.Latency <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(u.Latency/2 + rand.N(u.Latency))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// datagetter answers a datagetter request: 6-minute or high/low predictions, in feet above
+// MLLW, for begin_date through end_date in the station's local time or GMT
+func (u *Upstream) datagetter(query url.Values) []byte {
+	s, ok := u.stations[query.Get("station")]
+	if !ok || query.Get("product") != "predictions" {
+		return noaaError(noData)
+	}
+	location := s.location
+	if query.Get("time_zone") == "gmt" {
+		location = time.UTC
+	}
+	begin, err1 := time.ParseInLocation("20060102", query.Get("begin_date"), location)
+	end, err2 := time.ParseInLocation("20060102", query.Get("end_date"), location)
+	if err1 != nil || err2 != nil {
+		return noaaError("begin_date and end_date must be dates like 20250101")
+	}
+	end = end.AddDate(0, 0, 1)
+
+	var body bytes.Buffer
+	body.WriteString(`{"predictions":[`)
+	write := func(at time.Time, format string, args ...any) {
+		if body.Len() > len(`{"predictions":[`) {
+			body.WriteString(",")
+		}
+		_, _ = fmt.Fprintf(&body, `{"t":"%s","v":"%.3f"`, at.In(location).Format("2006-01-02 15:04"), s.height(at))
+		_, _ = fmt.Fprintf(&body, format, args...)
+	}
+	if query.Get("interval") == "hilo" {
+		for _, e := range s.extremes(begin, end) {
+			write(e.at, `,"type":"%s"}`, e.kind)
+		}
+	} else {
+		for at := begin; at.Before(end); at = at.Add(6 * time.Minute) {
+			write(at, "}")
+		}
+	}
+	body.WriteString("]}")
+	return body.Bytes()
+}
+
+// height is the station's predicted level at a time, in feet above MLLW
+func (s *station) height(at time.Time) float64 {
+	hours := at.Sub(epoch).Hours()
+	h := s.MeanSeaLevel
+	for _, c := range s.Constituents {
+		h += c.Amplitude * math.Cos((speeds[c.Name]*hours-c.Phase)*math.Pi/180)
+	}
+	return h
+}
+
+type extreme struct {
+	at   time.Time
+	kind string
+}
+
+// extremes finds the station's highs (H) and lows (L) from begin until end to the minute,
+// where the level stops rising or falling
+func (s *station) extremes(begin, end time.Time) []extreme {
+	var found []extreme
+	prev, cur := s.height(begin.Add(-time.Minute)), s.height(begin)
+	for at := begin; at.Before(end); at = at.Add(time.Minute) {
+		next := s.height(at.Add(time.Minute))
+		switch {
+		case cur > prev && cur >= next:
+			found = append(found, extreme{at: at, kind: "H"})
+		case cur < prev && cur <= next:
+			found = append(found, extreme{at: at, kind: "L"})
+		}
+		prev, cur = cur, next
+	}
+	return found
+}
+
+func noaaError(message string) []byte {
+	return []byte(fmt.Sprintf(`{"error":{"message":%q}}`, message))
+}
+
+func respond(status int, body []byte) *client.Response {
+	return &client.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       body,
+	}
+}
@@ -0,0 +1,97 @@
+package demo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstream_StationList(t *testing.T) {
+	upstream, err := New(0)
+	require.NoError(t, err)
+
+	resp, err := upstream.Get(context.Background(), "/mdapi/prod/webapi/tidepredstations.json")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Stations []listing `json:"stationList"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body, &list))
+	require.Len(t, list.Stations, 6)
+	assert.Equal(t, listing{ID: "9447130", Name: "Seattle", State: "WA", Region: "Puget Sound", Lat: 47.6026, Lon: -122.3393, TimeZoneCorr: "-8", StationType: "R"}, list.Stations[0])
+
+	resp, err = upstream.Get(context.Background(), "/mdapi/prod/webapi/stations/9447130/tidepredoffsets.json")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "the canned stations are all reference stations")
+}
+
+func TestUpstream_Predictions(t *testing.T) {
+	upstream, err := New(0)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	resp, err := upstream.Get(ctx, "/api/prod/datagetter?station=9447130&begin_date=20250309&end_date=20250310"+
+		"&product=predictions&datum=MLLW&units=english&time_zone=lst_ldt&format=json&interval=6")
+	require.NoError(t, err)
+	predictions, err := models.DecodeNoaaResponse(resp.Body)
+	require.NoError(t, err)
+	require.Nil(t, predictions.Error)
+	// Clocks go forward on the 9th, so the two days have an hour less than 48
+	assert.Len(t, predictions.Predictions, 47*10)
+	first := predictions.Predictions[0]
+	assert.Equal(t, models.NoaaTime{Year: 2025, Month: time.March, Day: 9}, first.Time)
+	for _, p := range predictions.Predictions {
+		assert.InDelta(t, 6.8, p.Height, 9.3, "within the constituents' reach of mean sea level")
+	}
+
+	resp, err = upstream.Get(ctx, "/api/prod/datagetter?station=9447130&begin_date=20250101&end_date=20250107"+
+		"&product=predictions&datum=MLLW&units=english&time_zone=lst_ldt&format=json&interval=hilo")
+	require.NoError(t, err)
+	extremes, err := models.DecodeNoaaResponse(resp.Body)
+	require.NoError(t, err)
+	// Mixed semidiurnal: about two highs and two lows a day
+	assert.InDelta(t, 28, len(extremes.Predictions), 2)
+	for i, e := range extremes.Predictions {
+		if i > 0 {
+			assert.NotEqual(t, extremes.Predictions[i-1].Type, e.Type, "highs and lows alternate")
+		}
+	}
+}
+
+func TestUpstream_NoData(t *testing.T) {
+	upstream, err := New(0)
+	require.NoError(t, err)
+
+	for _, path := range []string{
+		"/api/prod/datagetter?station=9447130&date=latest&product=water_level&units=english&time_zone=gmt&format=json&datum=MLLW",
+		"/api/prod/datagetter?station=9446484&begin_date=20250101&end_date=20250101&product=predictions&interval=hilo",
+	} {
+		resp, err := upstream.Get(context.Background(), path)
+		require.NoError(t, err)
+		noaaResp, err := models.DecodeNoaaResponse(resp.Body)
+		require.NoError(t, err)
+		require.NotNil(t, noaaResp.Error, path)
+		assert.Contains(t, noaaResp.Error.Message, "No data was found")
+	}
+}
+
+func TestUpstream_Latency(t *testing.T) {
+	upstream, err := New(40 * time.Millisecond)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = upstream.Get(context.Background(), "/mdapi/prod/webapi/tidepredstations.json")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = upstream.Get(ctx, "/mdapi/prod/webapi/tidepredstations.json")
+	assert.ErrorIs(t, err, context.Canceled)
+}
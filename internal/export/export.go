@@ -0,0 +1,276 @@
+// Package export renders what's too heavy to render while a client waits into S3: a
+// station's tide table for a year, every day's highs and lows, as CSV or PDF for marinas
+// that print annual tables; its 6-minute predictions over up to a year as CSV; and its
+// tide curve over up to a year as an SVG or PNG chart. Each takes a dozen NOAA lookups, so
+// they're rendered in the background: a request writes a PENDING job to the bucket, whose
+// creation invokes the export function, and is answered from the job, by the request or
+// its job ID, until the file is ready to download.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Kind is what an export renders
+type Kind string
+
+const (
+	// KindTable is a year's highs and lows as CSV or PDF
+	KindTable Kind = "table"
+	// KindPredictions is the 6-minute predictions of a range of days as CSV
+	KindPredictions Kind = "predictions"
+	// KindChart is the tide curve of a range of days as an SVG or PNG image
+	KindChart Kind = "chart"
+)
+
+// Kinds lists what can be exported
+var Kinds = []string{string(KindTable), string(KindPredictions), string(KindChart)}
+
+// Format is how an export is rendered
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatPDF Format = "pdf"
+	FormatSVG Format = "svg"
+	FormatPNG Format = "png"
+)
+
+// Formats lists every format an export can be rendered in, and TableFormats the ones a
+// table can
+var (
+	Formats      = []string{string(FormatCSV), string(FormatPDF), string(FormatSVG), string(FormatPNG)}
+	TableFormats = []string{string(FormatCSV), string(FormatPDF)}
+	formats      = map[Kind][]string{
+		KindTable:       TableFormats,
+		KindPredictions: {string(FormatCSV)},
+		KindChart:       {string(FormatSVG), string(FormatPNG)},
+	}
+)
+
+// DefaultFormat is the format a kind is rendered in when the request doesn't give one
+func DefaultFormat(kind Kind) Format {
+	switch kind {
+	case KindPredictions:
+		return FormatCSV
+	case KindChart:
+		return FormatSVG
+	default:
+		return FormatPDF
+	}
+}
+
+// The years tables can be rendered for, which NOAA has predictions for
+const (
+	MinYear = 2000
+	MaxYear = 2100
+)
+
+// MaxRangeDays is the most days a predictions or chart export may span
+const MaxRangeDays = 366
+
+// IDPattern matches a job ID: the station ID, then what's rendered
+const IDPattern = `^[A-Za-z0-9:._*@+-]+/[a-z0-9.-]+$`
+
+var idPattern = regexp.MustCompile(IDPattern)
+
+// ErrJobNotFound is asking for the status of a job ID that was never submitted
+var ErrJobNotFound = errors.New("export job not found")
+
+const (
+	// jobTimeout is how long a PENDING job may go unfinished before a request submits it
+	// again, as when the export function never ran
+	jobTimeout = 15 * time.Minute
+	// retryAfter is how long a FAILED job is reported before a request submits it again
+	retryAfter = 15 * time.Minute
+)
+
+// Request names an export: a station's table for a year, or its predictions or chart from
+// StartDate through EndDate in its time zone, in a format
+type Request struct {
+	// Kind is empty for tables, as jobs were before there were other kinds
+	Kind      Kind   `json:"kind,omitempty"`
+	StationID string `json:"stationId"`
+	Year      int    `json:"year,omitempty"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+	Format    Format `json:"format"`
+}
+
+// kind returns the request's kind, reading an empty one as a table
+func (r Request) kind() Kind {
+	if r.Kind == "" {
+		return KindTable
+	}
+	return r.Kind
+}
+
+// Validate checks the request names a station ID, a known kind and a format it's rendered
+// in, and a year tables cover or a range of at most MaxRangeDays
+func (r Request) Validate() error {
+	if err := validate.StationID("stationId", r.StationID); err != nil {
+		return err
+	}
+	kind := r.kind()
+	if err := validate.OneOf("kind", string(kind), Kinds...); err != nil {
+		return err
+	}
+	if kind == KindTable {
+		if err := validate.Between("year", float64(r.Year), MinYear, MaxYear); err != nil {
+			return err
+		}
+	} else if err := r.validateRange(); err != nil {
+		return err
+	}
+	return validate.OneOf("format", string(r.Format), formats[kind]...)
+}
+
+func (r Request) validateRange() error {
+	start, err := validate.Date("startDate", r.StartDate, time.UTC)
+	if err != nil {
+		return err
+	}
+	end, err := validate.Date("endDate", r.EndDate, time.UTC)
+	if err != nil {
+		return err
+	}
+	if end.Before(start) || end.After(start.AddDate(0, 0, MaxRangeDays-1)) {
+		return &validate.Error{
+			Parameter: "endDate",
+			Value:     r.EndDate,
+			Message:   fmt.Sprintf("must be on or after startDate and span at most %d days", MaxRangeDays),
+		}
+	}
+	return nil
+}
+
+// ID identifies the request's job, and where it and its file are kept
+func (r Request) ID() string {
+	if r.kind() == KindTable {
+		return fmt.Sprintf("%s/%d.%s", r.StationID, r.Year, r.Format)
+	}
+	return fmt.Sprintf("%s/%s-%s-%s.%s", r.StationID, r.Kind, r.StartDate, r.EndDate, r.Format)
+}
+
+// filename is what a download of the request's file is saved as
+func (r Request) filename() string {
+	if r.kind() == KindTable {
+		return fmt.Sprintf("%s-%d.%s", r.StationID, r.Year, r.Format)
+	}
+	return fmt.Sprintf("%s-%s-%s-to-%s.%s", r.StationID, r.Kind, r.StartDate, r.EndDate, r.Format)
+}
+
+// Job records an export's progress. It's kept in the bucket next to the files.
+type Job struct {
+	Request
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	UpdatedAt models.Millis `json:"updatedAt"`
+}
+
+// stale reports whether a request for the job's file should submit it again
+func (j *Job) stale(now time.Time) bool {
+	age := now.Sub(j.UpdatedAt.Time())
+	switch j.Status {
+	case models.ExportPending:
+		return age > jobTimeout
+	case models.ExportFailed:
+		return age > retryAfter
+	default:
+		return false
+	}
+}
+
+// Service answers export requests from the jobs in the store
+type Service struct {
+	Store    *Store
+	Stations models.StationFinder
+	now      func() time.Time
+}
+
+// NewService creates a service that submits jobs to store for the stations finder knows
+func NewService(store *Store, stations models.StationFinder) *Service {
+	return &Service{Store: store, Stations: stations, now: time.Now}
+}
+
+// Request returns the status of the export req names, submitting it when it hasn't been or
+// its last attempt timed out or failed a while ago. A complete export comes with a
+// download URL.
+func (s *Service) Request(ctx context.Context, req Request) (*models.TideTableExport, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	station, err := models.FindStation(ctx, s.Stations, req.StationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if station == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, req.StationID)
+	}
+	req.StationID = station.ID
+
+	now := s.now()
+	job, err := s.Store.Job(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.stale(now) {
+		job = &Job{Request: req, Status: models.ExportPending, UpdatedAt: models.MillisOf(now)}
+		if err := s.Store.PutJob(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+	return s.response(ctx, job, now)
+}
+
+// Status returns the status of the job with the given ID without submitting it again, or
+// ErrJobNotFound when there's no such job
+func (s *Service) Status(ctx context.Context, id string) (*models.TideTableExport, error) {
+	if err := validate.Format("jobId", id, idPattern, "9447130/2025.pdf"); err != nil {
+		return nil, err
+	}
+	job, err := s.Store.JobAt(ctx, JobPrefix+id+".json")
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return s.response(ctx, job, s.now())
+}
+
+func (s *Service) response(ctx context.Context, job *Job, now time.Time) (*models.TideTableExport, error) {
+	req := job.Request
+	response := &models.TideTableExport{
+		ResponseType: "tideTableExport",
+		JobID:        req.ID(),
+		Kind:         string(req.kind()),
+		StationID:    req.StationID,
+		Year:         req.Year,
+		Format:       string(req.Format),
+		Status:       job.Status,
+	}
+	if req.kind() != KindTable {
+		response.StartDate, response.EndDate = &req.StartDate, &req.EndDate
+	}
+	switch job.Status {
+	case models.ExportComplete:
+		url, err := s.Store.DownloadURL(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := models.MillisOf(now.Add(s.Store.URLTTL))
+		response.DownloadURL = &url
+		response.ExpiresAt = &expiresAt
+	case models.ExportFailed:
+		response.Error = &job.Error
+	}
+	return response, nil
+}
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBucket keeps objects in memory and presigns URLs that name the key and expiry
+type memBucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newMemBucket() *memBucket {
+	return &memBucket{objects: map[string][]byte{}, types: map[string]string{}}
+}
+
+func (b *memBucket) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	body, ok := b.objects[*params.Key]
+	if !ok {
+		return nil, &types.NoSuchKey{}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func (b *memBucket) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.objects[*params.Key] = body
+	b.types[*params.Key] = *params.ContentType
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (b *memBucket) PresignGetObject(_ context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	var options s3.PresignOptions
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	url := fmt.Sprintf("https://%s.s3.amazonaws.com/%s?X-Amz-Expires=%d", *params.Bucket, *params.Key, int(options.Expires.Seconds()))
+	return &v4.PresignedHTTPRequest{URL: url}, nil
+}
+
+type stationFinder struct {
+	models.StationFinder
+}
+
+func (stationFinder) FindStation(_ context.Context, stationID string) (*models.Station, error) {
+	if stationID != "9447130" {
+		return nil, nil
+	}
+	return &models.Station{ID: stationID, Name: "Seattle"}, nil
+}
+
+func TestRequestValidate(t *testing.T) {
+	var validationErr *validate.Error
+	assert.NoError(t, Request{StationID: "9447130", Year: 2025, Format: FormatCSV}.Validate())
+	assert.ErrorAs(t, Request{StationID: "9447130", Year: 1999, Format: FormatCSV}.Validate(), &validationErr)
+	assert.ErrorAs(t, Request{StationID: "9447130", Year: 2025, Format: "xls"}.Validate(), &validationErr)
+	assert.ErrorAs(t, Request{StationID: "", Year: 2025, Format: FormatPDF}.Validate(), &validationErr)
+	assert.ErrorAs(t, Request{StationID: "9447130", Year: 2025, Format: FormatPNG}.Validate(), &validationErr, "tables aren't images")
+
+	chart := Request{Kind: KindChart, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2025-12-31", Format: FormatPNG}
+	assert.NoError(t, chart.Validate())
+	predictions := Request{Kind: KindPredictions, StationID: "9447130", StartDate: "2024-01-01", EndDate: "2024-12-31", Format: FormatCSV}
+	assert.NoError(t, predictions.Validate(), "a leap year is 366 days")
+	for _, req := range []Request{
+		{Kind: KindChart, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2025-01-31", Format: FormatCSV},
+		{Kind: KindPredictions, StationID: "9447130", StartDate: "2025-01-31", EndDate: "2025-01-01", Format: FormatCSV},
+		{Kind: KindPredictions, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2026-01-02", Format: FormatCSV},
+		{Kind: KindPredictions, StationID: "9447130", StartDate: "2025-01-01", Format: FormatCSV},
+		{Kind: "tiles", StationID: "9447130", Year: 2025, Format: FormatCSV},
+	} {
+		assert.ErrorAs(t, req.Validate(), &validationErr, req)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	table := Request{StationID: "9447130", Year: 2025, Format: FormatPDF}
+	assert.Equal(t, "9447130/2025.pdf", table.ID())
+	assert.Equal(t, "export-jobs/9447130/2025.pdf.json", JobKey(table), "tables keep the keys they had before other kinds")
+	assert.Equal(t, "tide-tables/9447130/2025.pdf", FileKey(table))
+
+	chart := Request{Kind: KindChart, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2025-01-31", Format: FormatPNG}
+	assert.Equal(t, "9447130/chart-2025-01-01-2025-01-31.png", chart.ID())
+	assert.Equal(t, "exports/9447130/chart-2025-01-01-2025-01-31.png", FileKey(chart))
+	assert.Regexp(t, IDPattern, table.ID())
+	assert.Regexp(t, IDPattern, chart.ID())
+}
+
+func TestServiceRequest(t *testing.T) {
+	ctx := context.Background()
+	bucket := newMemBucket()
+	service := NewService(NewStore(bucket, "exports", time.Hour), stationFinder{})
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	req := Request{StationID: "9447130", Year: 2025, Format: FormatCSV}
+
+	t.Run("submits a new table", func(t *testing.T) {
+		result, err := service.Request(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, models.ExportPending, result.Status)
+		assert.Equal(t, "9447130", result.StationID)
+		assert.Nil(t, result.DownloadURL)
+
+		job, err := service.Store.Job(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, job)
+		assert.Equal(t, models.ExportPending, job.Status)
+		assert.Equal(t, "application/json", bucket.types[JobKey(req)])
+	})
+
+	t.Run("leaves a pending table alone until it times out", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, err := service.Request(ctx, req)
+		require.NoError(t, err)
+		job, err := service.Store.Job(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(-time.Minute).UnixMilli(), int64(job.UpdatedAt), "the job isn't rewritten, which would run it again")
+
+		now = now.Add(jobTimeout)
+		_, err = service.Request(ctx, req)
+		require.NoError(t, err)
+		job, err = service.Store.Job(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, now.UnixMilli(), int64(job.UpdatedAt))
+	})
+
+	t.Run("answers a complete table with a download URL", func(t *testing.T) {
+		require.NoError(t, service.Store.PutJob(ctx, &Job{Request: req, Status: models.ExportComplete, UpdatedAt: models.MillisOf(now)}))
+		result, err := service.Request(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, models.ExportComplete, result.Status)
+		require.NotNil(t, result.DownloadURL)
+		assert.Equal(t, "https://exports.s3.amazonaws.com/tide-tables/9447130/2025.csv?X-Amz-Expires=3600", *result.DownloadURL)
+		require.NotNil(t, result.ExpiresAt)
+		assert.Equal(t, now.Add(time.Hour).UnixMilli(), int64(*result.ExpiresAt))
+	})
+
+	t.Run("reports a failure until it's retried", func(t *testing.T) {
+		require.NoError(t, service.Store.PutJob(ctx, &Job{Request: req, Status: models.ExportFailed, Error: "NOAA is down", UpdatedAt: models.MillisOf(now)}))
+		result, err := service.Request(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, models.ExportFailed, result.Status)
+		require.NotNil(t, result.Error)
+		assert.Equal(t, "NOAA is down", *result.Error)
+
+		now = now.Add(retryAfter + time.Second)
+		result, err = service.Request(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, models.ExportPending, result.Status)
+		assert.Nil(t, result.Error)
+	})
+
+	t.Run("unknown station", func(t *testing.T) {
+		_, err := service.Request(ctx, Request{StationID: "1234567", Year: 2025, Format: FormatCSV})
+		assert.True(t, errors.Is(err, models.ErrStationNotFound))
+	})
+}
+
+func TestServiceStatus(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(NewStore(newMemBucket(), "exports", time.Hour), stationFinder{})
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	req := Request{Kind: KindChart, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2025-03-31", Format: FormatSVG}
+
+	_, err := service.Status(ctx, req.ID())
+	assert.ErrorIs(t, err, ErrJobNotFound, "status doesn't submit a job")
+	var validationErr *validate.Error
+	_, err = service.Status(ctx, "9447130")
+	assert.ErrorAs(t, err, &validationErr)
+
+	submitted, err := service.Request(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, req.ID(), submitted.JobID)
+	assert.Equal(t, "chart", submitted.Kind)
+	assert.Zero(t, submitted.Year)
+	require.NotNil(t, submitted.StartDate)
+	assert.Equal(t, "2025-01-01", *submitted.StartDate)
+
+	status, err := service.Status(ctx, submitted.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, submitted, status)
+
+	require.NoError(t, service.Store.PutJob(ctx, &Job{Request: req, Status: models.ExportComplete, UpdatedAt: models.MillisOf(now)}))
+	status, err = service.Status(ctx, submitted.JobID)
+	require.NoError(t, err)
+	require.NotNil(t, status.DownloadURL)
+	assert.Equal(t, "https://exports.s3.amazonaws.com/exports/9447130/chart-2025-01-01-2025-03-31.svg?X-Amz-Expires=3600", *status.DownloadURL)
+}
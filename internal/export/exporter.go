@@ -0,0 +1,94 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Exporter renders exports into the store
+type Exporter struct {
+	Store *Store
+	Tides models.TideProvider
+	now   func() time.Time
+}
+
+// NewExporter creates an exporter that renders exports from tides into store
+func NewExporter(store *Store, tides models.TideProvider) *Exporter {
+	return &Exporter{Store: store, Tides: tides, now: time.Now}
+}
+
+// Run renders req's export and saves it, recording the outcome in its job
+func (e *Exporter) Run(ctx context.Context, req Request) error {
+	start := time.Now()
+	file, err := e.render(ctx, req)
+	if err == nil {
+		err = e.Store.PutFile(ctx, req, file)
+	}
+
+	job := &Job{Request: req, Status: models.ExportComplete, UpdatedAt: models.MillisOf(e.now())}
+	if err != nil {
+		job.Status = models.ExportFailed
+		job.Error = err.Error()
+	}
+	if putErr := e.Store.PutJob(ctx, job); putErr != nil {
+		return errors.Join(err, putErr)
+	}
+	if err != nil {
+		return err
+	}
+	log.Info().Str("station_id", req.StationID).Str("job", req.ID()).
+		Int("bytes", len(file)).Dur("elapsed", time.Since(start)).Msg("Exported")
+	return nil
+}
+
+func (e *Exporter) render(ctx context.Context, req Request) ([]byte, error) {
+	if req.kind() == KindTable {
+		table, err := BuildTable(ctx, e.Tides, req.StationID, req.Year)
+		if err != nil {
+			return nil, err
+		}
+		return table.Render(req.Format)
+	}
+
+	series, err := BuildSeries(ctx, e.Tides, req.StationID, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	if req.Kind == KindChart {
+		return series.Chart(req.Format)
+	}
+	return series.CSV()
+}
+
+// RunJob runs the job kept at key. Jobs that are gone or have already finished are
+// skipped, since the export function is also invoked when it records an outcome.
+func (e *Exporter) RunJob(ctx context.Context, key string) error {
+	job, err := e.Store.JobAt(ctx, key)
+	if err != nil {
+		return err
+	}
+	if job == nil || job.Status != models.ExportPending {
+		return nil
+	}
+	return e.Run(ctx, job.Request)
+}
+
+// RunScheduled renders year's tables for stations in every table format. Tables that fail
+// don't stop the others; their errors are joined.
+func (e *Exporter) RunScheduled(ctx context.Context, stations []string, year int) error {
+	var errs []error
+	for _, stationID := range stations {
+		for _, format := range TableFormats {
+			req := Request{StationID: stationID, Year: year, Format: Format(format)}
+			if err := e.Run(ctx, req); err != nil {
+				errs = append(errs, fmt.Errorf("station %s %s: %w", stationID, format, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
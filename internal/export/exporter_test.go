@@ -0,0 +1,84 @@
+package export
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExporterRunJob(t *testing.T) {
+	ctx := context.Background()
+	bucket := newMemBucket()
+	store := NewStore(bucket, "exports", time.Hour)
+	req := Request{StationID: "9447130", Year: 2025, Format: FormatPDF}
+	require.NoError(t, store.PutJob(ctx, &Job{Request: req, Status: models.ExportPending}))
+
+	provider := &extremesProvider{}
+	exporter := NewExporter(store, provider)
+	require.NoError(t, exporter.RunJob(ctx, JobKey(req)))
+
+	assert.Equal(t, "application/pdf", bucket.types[FileKey(req)])
+	assert.NotEmpty(t, bucket.objects[FileKey(req)])
+	job, err := store.Job(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportComplete, job.Status)
+
+	// Recording the outcome invokes the function again, which leaves the job alone
+	calls := provider.calls
+	require.NoError(t, exporter.RunJob(ctx, JobKey(req)))
+	assert.Equal(t, calls, provider.calls)
+
+	require.NoError(t, exporter.RunJob(ctx, JobKey(Request{StationID: "9447130", Year: 2026, Format: FormatPDF})), "a missing job is skipped")
+}
+
+func TestExporterRunRecordsFailure(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(newMemBucket(), "exports", time.Hour)
+	req := Request{StationID: "9447130", Year: 2025, Format: FormatCSV}
+
+	exporter := NewExporter(store, &extremesProvider{fail: map[time.Month]bool{time.June: true}})
+	assert.ErrorContains(t, exporter.Run(ctx, req), "NOAA is down")
+
+	job, err := store.Job(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportFailed, job.Status)
+	assert.Contains(t, job.Error, "June")
+}
+
+func TestExporterRunScheduled(t *testing.T) {
+	ctx := context.Background()
+	bucket := newMemBucket()
+	exporter := NewExporter(NewStore(bucket, "exports", time.Hour), &extremesProvider{})
+
+	require.NoError(t, exporter.RunScheduled(ctx, []string{"9447130", "8454000"}, 2026))
+	for _, stationID := range []string{"9447130", "8454000"} {
+		for _, format := range TableFormats {
+			req := Request{StationID: stationID, Year: 2026, Format: Format(format)}
+			assert.NotEmpty(t, bucket.objects[FileKey(req)], "%s %s", stationID, format)
+		}
+	}
+}
+
+func TestExporterRunChart(t *testing.T) {
+	ctx := context.Background()
+	bucket := newMemBucket()
+	store := NewStore(bucket, "exports", time.Hour)
+	req := Request{Kind: KindChart, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2025-02-15", Format: FormatPNG}
+	require.NoError(t, store.PutJob(ctx, &Job{Request: req, Status: models.ExportPending}))
+
+	require.NoError(t, NewExporter(store, &curveProvider{}).RunJob(ctx, JobKey(req)))
+	assert.Equal(t, "image/png", bucket.types[FileKey(req)])
+	assert.NotEmpty(t, bucket.objects[FileKey(req)])
+	job, err := store.Job(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportComplete, job.Status)
+
+	req = Request{Kind: KindPredictions, StationID: "9447130", StartDate: "2025-01-01", EndDate: "2025-01-02", Format: FormatCSV}
+	require.NoError(t, NewExporter(store, &curveProvider{}).Run(ctx, req))
+	assert.Equal(t, "text/csv", bucket.types[FileKey(req)])
+	assert.Contains(t, string(bucket.objects[FileKey(req)]), "2025-01-02T23:00:00Z")
+}
@@ -0,0 +1,121 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PDF page layout, in points: US Letter with a one-inch top margin and fixed-width type so
+// the columns line up without measuring text
+const (
+	pageWidth    = 612
+	pageHeight   = 792
+	marginLeft   = 54
+	marginTop    = 72
+	fontSize     = 9
+	lineHeight   = 12
+	titleSize    = 14
+	titleLeading = 20
+)
+
+// pdf renders a page per month, a line per day listing its highs and lows in order. The
+// document is written directly: it only needs the standard Courier font, which every
+// reader has, so no PDF library is pulled in.
+func (t *Table) pdf() []byte {
+	zone := "local time"
+	if t.TimeZone != "" {
+		zone = t.TimeZone
+	}
+	var pages [][]string
+	for month := time.January; month <= time.December; month++ {
+		lines := []string{
+			fmt.Sprintf("Tide table %s %d", month, t.Year),
+			fmt.Sprintf("%s (%s)", t.StationName, t.StationID),
+			fmt.Sprintf("Times in %s, heights in feet", zone),
+			"",
+		}
+		prefix := fmt.Sprintf("%d-%02d-", t.Year, month)
+		for _, day := range t.Days {
+			if !strings.HasPrefix(day.Date, prefix) {
+				continue
+			}
+			line := day.Date[len(prefix):]
+			if date, err := time.Parse("2006-01-02", day.Date); err == nil {
+				line = date.Format("Mon 02")
+			}
+			for _, e := range day.Extremes {
+				line += fmt.Sprintf("   %s %s %6.2f", e.Type[:1], e.Time, e.Height)
+			}
+			lines = append(lines, line)
+		}
+		pages = append(pages, lines)
+	}
+	return writePDF(pages)
+}
+
+// writePDF writes a document with a page for each list of lines. The first line of each
+// page is its title.
+func writePDF(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	// Objects 1 to 3 are the catalog, page tree and font; each page is followed by its content
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, lines := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d %d Td\n", titleSize, marginLeft, pageHeight-marginTop)
+		for j, line := range lines {
+			switch j {
+			case 0:
+				fmt.Fprintf(&content, "(%s) Tj\n/F1 %d Tf\n%d TL\n0 -%d Td\n", pdfString(line), fontSize, lineHeight, titleLeading)
+			default:
+				fmt.Fprintf(&content, "(%s) Tj\nT*\n", pdfString(line))
+			}
+		}
+		content.WriteString("ET")
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfString escapes s for a PDF literal string. Courier's encoding only covers Latin-1,
+// so other characters, rare in station names, are replaced.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		case r > 0x7e:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
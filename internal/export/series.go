@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/chart"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// chunkDays is how many days each lookup of a series covers, within the tide service's
+// limit on one lookup's range
+const chunkDays = 28
+
+// Series is a station's predictions and highs and lows over a range of days
+type Series struct {
+	StationID   string
+	StationName string
+	Predictions []models.TidePrediction
+	Extremes    []models.TideExtreme
+}
+
+// BuildSeries looks up the station's predictions from startDate through endDate, in its
+// time zone, a few weeks at a time
+func BuildSeries(ctx context.Context, tides models.TideProvider, stationID, startDate, endDate string) (*Series, error) {
+	start, err := time.Parse(validate.DateFormat, startDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(validate.DateFormat, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	series := &Series{StationID: stationID}
+	for from := start; !from.After(end); from = from.AddDate(0, 0, chunkDays) {
+		to := from.AddDate(0, 0, chunkDays-1)
+		if to.After(end) {
+			to = end
+		}
+		fromDate, toDate := from.Format(validate.DateFormat), to.Format(validate.DateFormat)
+		response, err := tides.GetCurrentTideForStation(ctx, stationID, &fromDate, &toDate)
+		if err != nil {
+			return nil, fmt.Errorf("getting predictions from %s: %w", fromDate, err)
+		}
+		series.StationID = response.NearestStation
+		if response.Location != nil {
+			series.StationName = *response.Location
+		}
+		// Neighboring lookups may both hold the midnight between them
+		for _, p := range response.Predictions {
+			if n := len(series.Predictions); n == 0 || p.Timestamp > series.Predictions[n-1].Timestamp {
+				series.Predictions = append(series.Predictions, p)
+			}
+		}
+		for _, e := range response.Extremes {
+			if n := len(series.Extremes); n == 0 || e.Timestamp > series.Extremes[n-1].Timestamp {
+				series.Extremes = append(series.Extremes, e)
+			}
+		}
+	}
+	return series, nil
+}
+
+// CSV renders one row per prediction, with times local to the station
+func (s *Series) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"timestamp", "local_time", "height_ft"})
+	for _, p := range s.Predictions {
+		_ = w.Write([]string{p.Timestamp.Time().Format(time.RFC3339), p.LocalTime, strconv.FormatFloat(p.Height, 'f', 3, 64)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("writing CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Chart renders the series as a chart in format, titled with the station's name
+func (s *Series) Chart(format Format) ([]byte, error) {
+	return chart.Render(chart.Format(format), s.Predictions, s.Extremes, chart.Options{Title: s.StationName})
+}
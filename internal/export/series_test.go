@@ -0,0 +1,78 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// curveProvider answers GetCurrentTideForStation with hourly predictions from the start
+// date's midnight through the midnight after the end date, and a high every noon, failing
+// for ranges starting on fail
+type curveProvider struct {
+	models.TideProvider
+	fail   string
+	ranges [][2]string
+}
+
+func (p *curveProvider) GetCurrentTideForStation(_ context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+	p.ranges = append(p.ranges, [2]string{*startTimeStr, *endTimeStr})
+	if *startTimeStr == p.fail {
+		return nil, errors.New("NOAA is down")
+	}
+	start, _ := time.Parse("2006-01-02", *startTimeStr)
+	end, _ := time.Parse("2006-01-02", *endTimeStr)
+	name := "Seattle"
+	response := &models.ExtendedTideResponse{NearestStation: stationID, Location: &name}
+	for at := start; !at.After(end.AddDate(0, 0, 1)); at = at.Add(time.Hour) {
+		response.Predictions = append(response.Predictions, models.TidePrediction{
+			Timestamp: models.MillisOf(at), LocalTime: at.Format("2006-01-02T15:04:05"), Height: float64(at.Hour()) / 4,
+		})
+		if at.Hour() == 12 {
+			response.Extremes = append(response.Extremes, models.TideExtreme{Type: models.TideTypeHigh, Timestamp: models.MillisOf(at), Height: 3})
+		}
+	}
+	return response, nil
+}
+
+func TestBuildSeries(t *testing.T) {
+	provider := &curveProvider{}
+	series, err := BuildSeries(context.Background(), provider, "9447130", "2024-01-01", "2024-03-01")
+	require.NoError(t, err)
+	assert.Equal(t, [][2]string{{"2024-01-01", "2024-01-28"}, {"2024-01-29", "2024-02-25"}, {"2024-02-26", "2024-03-01"}}, provider.ranges)
+	assert.Equal(t, "Seattle", series.StationName)
+	assert.Len(t, series.Predictions, 61*24+1, "midnights between lookups aren't repeated")
+	assert.Len(t, series.Extremes, 61)
+	for i := 1; i < len(series.Predictions); i++ {
+		require.Greater(t, series.Predictions[i].Timestamp, series.Predictions[i-1].Timestamp)
+	}
+
+	_, err = BuildSeries(context.Background(), &curveProvider{fail: "2024-01-29"}, "9447130", "2024-01-01", "2024-03-01")
+	assert.ErrorContains(t, err, "getting predictions from 2024-01-29: NOAA is down")
+}
+
+func TestSeries_Render(t *testing.T) {
+	series, err := BuildSeries(context.Background(), &curveProvider{}, "9447130", "2024-01-01", "2024-01-01")
+	require.NoError(t, err)
+
+	csv, err := series.CSV()
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(csv)), "\n")
+	assert.Equal(t, "timestamp,local_time,height_ft", lines[0])
+	assert.Equal(t, "2024-01-01T01:00:00Z,2024-01-01T01:00:00,0.250", lines[2])
+	assert.Len(t, lines, 26)
+
+	svg, err := series.Chart(FormatSVG)
+	require.NoError(t, err)
+	assert.Contains(t, string(svg), "<svg")
+	assert.Contains(t, string(svg), "Seattle")
+	png, err := series.Chart(FormatPNG)
+	require.NoError(t, err)
+	assert.Equal(t, "\x89PNG", string(png[:4]))
+}
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// JobPrefix is where jobs are kept; the export function is invoked when one is written
+	JobPrefix = "export-jobs/"
+	// tablePrefix is where rendered tables are kept, and filePrefix every other export
+	tablePrefix = "tide-tables/"
+	filePrefix  = "exports/"
+)
+
+// Bucket is the part of the S3 API exports and jobs are stored with
+type Bucket interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// Store keeps exports and their jobs in an S3 bucket
+type Store struct {
+	bucket Bucket
+	name   string
+	// URLTTL is how long a download URL works for
+	URLTTL time.Duration
+}
+
+// NewStore creates a store in the bucket called name, whose download URLs last urlTTL
+func NewStore(bucket Bucket, name string, urlTTL time.Duration) *Store {
+	return &Store{bucket: bucket, name: name, URLTTL: urlTTL}
+}
+
+// JobKey is where the job for req's export is kept
+func JobKey(req Request) string {
+	return JobPrefix + req.ID() + ".json"
+}
+
+// FileKey is where req's rendered export is kept
+func FileKey(req Request) string {
+	if req.kind() == KindTable {
+		return tablePrefix + req.ID()
+	}
+	return filePrefix + req.ID()
+}
+
+// Job returns the job for req's export, or nil when it was never submitted
+func (s *Store) Job(ctx context.Context, req Request) (*Job, error) {
+	return s.JobAt(ctx, JobKey(req))
+}
+
+// JobAt returns the job kept at key, or nil when there's none
+func (s *Store) JobAt(ctx context.Context, key string) (*Job, error) {
+	result, err := s.bucket.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.name),
+		Key:    aws.String(key),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading export job: %w", err)
+	}
+	defer func() {
+		if err := result.Body.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing S3 object body")
+		}
+	}()
+
+	var job Job
+	if err := json.NewDecoder(result.Body).Decode(&job); err != nil {
+		return nil, fmt.Errorf("decoding export job: %w", err)
+	}
+	return &job, nil
+}
+
+// PutJob saves job, replacing any earlier job for the same export
+func (s *Store) PutJob(ctx context.Context, job *Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("encoding export job: %w", err)
+	}
+	if err := s.put(ctx, JobKey(job.Request), body, "application/json"); err != nil {
+		return fmt.Errorf("saving export job: %w", err)
+	}
+	return nil
+}
+
+// PutFile saves req's rendered export
+func (s *Store) PutFile(ctx context.Context, req Request, file []byte) error {
+	if err := s.put(ctx, FileKey(req), file, contentType(req.Format)); err != nil {
+		return fmt.Errorf("saving export: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) put(ctx context.Context, key string, body []byte, contentType string) error {
+	_, err := s.bucket.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.name),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// DownloadURL returns a presigned URL req's export can be downloaded from for URLTTL
+func (s *Store) DownloadURL(ctx context.Context, req Request) (string, error) {
+	presigned, err := s.bucket.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket:                     aws.String(s.name),
+		Key:                        aws.String(FileKey(req)),
+		ResponseContentDisposition: aws.String(fmt.Sprintf(`attachment; filename="%s"`, req.filename())),
+	}, s3.WithPresignExpires(s.URLTTL))
+	if err != nil {
+		return "", fmt.Errorf("presigning export: %w", err)
+	}
+	return presigned.URL, nil
+}
+
+func contentType(format Format) string {
+	switch format {
+	case FormatPDF:
+		return "application/pdf"
+	case FormatSVG:
+		return "image/svg+xml"
+	case FormatPNG:
+		return "image/png"
+	default:
+		return "text/csv"
+	}
+}
+
+// s3Bucket is a Bucket backed by an S3 client and its presigner
+type s3Bucket struct {
+	*s3.Client
+	presigner *s3.PresignClient
+}
+
+func (b *s3Bucket) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	return b.presigner.PresignGetObject(ctx, params, optFns...)
+}
+
+// lazyBucket creates its S3 client the first time it's used, so requests answered without
+// an export don't load the AWS configuration. If that fails, every call returns the error.
+type lazyBucket struct {
+	once   sync.Once
+	bucket *s3Bucket
+	err    error
+}
+
+// NewS3Bucket returns a Bucket that creates an S3 client from the AWS configuration the
+// first time it's used
+func NewS3Bucket() Bucket {
+	return &lazyBucket{}
+}
+
+func (l *lazyBucket) get(ctx context.Context) (*s3Bucket, error) {
+	l.once.Do(func() {
+		start := time.Now()
+		// The client outlives the request that happens to create it
+		cfg, err := awsconfig.LoadDefaultConfig(context.WithoutCancel(ctx))
+		if err != nil {
+			l.err = fmt.Errorf("loading AWS config: %w", err)
+			log.Error().Err(l.err).Str("client", "exports").Msg("Created client")
+			return
+		}
+		client := s3.NewFromConfig(cfg)
+		l.bucket = &s3Bucket{Client: client, presigner: s3.NewPresignClient(client)}
+		log.Debug().Str("client", "exports").Dur("elapsed", time.Since(start)).Msg("Created client")
+	})
+	return l.bucket, l.err
+}
+
+func (l *lazyBucket) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	bucket, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.GetObject(ctx, params, optFns...)
+}
+
+func (l *lazyBucket) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	bucket, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.PutObject(ctx, params, optFns...)
+}
+
+func (l *lazyBucket) PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error) {
+	bucket, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.PresignGetObject(ctx, params, optFns...)
+}
@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Table is a station's highs and lows for every day of a year, in its time zone
+type Table struct {
+	StationID   string
+	StationName string
+	TimeZone    string
+	Year        int
+	Days        []models.DailyExtremes
+}
+
+// BuildTable looks up the station's extremes for every day of year, a month at a time
+func BuildTable(ctx context.Context, tides models.TideProvider, stationID string, year int) (*Table, error) {
+	table := &Table{StationID: stationID, Year: year}
+	for month := time.January; month <= time.December; month++ {
+		start := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		startDate := start.Format("2006-01-02")
+		days := start.AddDate(0, 1, -1).Day()
+		summary, err := tides.GetDailyExtremes(ctx, stationID, &startDate, days)
+		if err != nil {
+			return nil, fmt.Errorf("getting %s extremes: %w", month, err)
+		}
+		table.StationID = summary.StationID
+		table.StationName = summary.StationName
+		table.TimeZone = summary.TimeZone
+		table.Days = append(table.Days, summary.Days...)
+	}
+	return table, nil
+}
+
+// Render renders the table in format
+func (t *Table) Render(format Format) ([]byte, error) {
+	switch format {
+	case FormatCSV:
+		return t.csv()
+	case FormatPDF:
+		return t.pdf(), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// csv renders one row per high or low, with times local to the station
+func (t *Table) csv() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"date", "time", "type", "height_ft"})
+	for _, day := range t.Days {
+		for _, e := range day.Extremes {
+			_ = w.Write([]string{day.Date, e.Time, string(e.Type), strconv.FormatFloat(e.Height, 'f', 2, 64)})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("writing CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// extremesProvider answers GetDailyExtremes with a high and a low every day, failing for
+// the months in fail
+type extremesProvider struct {
+	models.TideProvider
+	fail  map[time.Month]bool
+	calls int
+}
+
+func (p *extremesProvider) GetDailyExtremes(_ context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error) {
+	p.calls++
+	start, err := time.Parse("2006-01-02", *startDate)
+	if err != nil {
+		return nil, err
+	}
+	if p.fail[start.Month()] {
+		return nil, errors.New("NOAA is down")
+	}
+	summary := &models.ExtremesSummary{StationID: stationID, StationName: "Seattle (Elliott Bay)", TimeZone: "America/Los_Angeles"}
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		summary.Days = append(summary.Days, models.DailyExtremes{
+			Date: date,
+			Extremes: []models.CompactExtreme{
+				{Type: models.TideTypeHigh, Time: "04:12", Height: 11.5},
+				{Type: models.TideTypeLow, Time: "10:48", Height: -1.25},
+			},
+		})
+	}
+	return summary, nil
+}
+
+func TestBuildTable(t *testing.T) {
+	provider := &extremesProvider{}
+	table, err := BuildTable(context.Background(), provider, "9447130", 2024)
+	require.NoError(t, err)
+	assert.Equal(t, 12, provider.calls)
+	assert.Len(t, table.Days, 366, "a leap year")
+	assert.Equal(t, "2024-01-01", table.Days[0].Date)
+	assert.Equal(t, "2024-12-31", table.Days[365].Date)
+	assert.Equal(t, "Seattle (Elliott Bay)", table.StationName)
+
+	_, err = BuildTable(context.Background(), &extremesProvider{fail: map[time.Month]bool{time.March: true}}, "9447130", 2024)
+	assert.ErrorContains(t, err, "March")
+}
+
+func TestRenderCSV(t *testing.T) {
+	table, err := BuildTable(context.Background(), &extremesProvider{}, "9447130", 2025)
+	require.NoError(t, err)
+
+	body, err := table.Render(FormatCSV)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, 1+2*365)
+	assert.Equal(t, "date,time,type,height_ft", lines[0])
+	assert.Equal(t, "2025-01-01,04:12,HIGH,11.50", lines[1])
+	assert.Equal(t, "2025-01-01,10:48,LOW,-1.25", lines[2])
+
+	_, err = table.Render("xls")
+	assert.Error(t, err)
+}
+
+func TestRenderPDF(t *testing.T) {
+	table, err := BuildTable(context.Background(), &extremesProvider{}, "9447130", 2025)
+	require.NoError(t, err)
+
+	body, err := table.Render(FormatPDF)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(body, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(body, []byte("%%EOF\n")))
+	assert.Contains(t, string(body), "/Count 12")
+	assert.Contains(t, string(body), "(Tide table January 2025) Tj")
+	assert.Contains(t, string(body), `(Seattle \(Elliott Bay\) \(9447130\)) Tj`)
+	assert.Contains(t, string(body), "(Wed 01   H 04:12  11.50   L 10:48  -1.25) Tj")
+
+	// Every cross-reference entry points at the object it names
+	xref := bytes.LastIndex(body, []byte("\nxref\n")) + 1
+	require.Positive(t, xref)
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(body)
+	require.NotNil(t, startxref)
+	assert.Equal(t, strconv.Itoa(xref), string(startxref[1]))
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(body[xref:], -1)
+	require.Len(t, offsets, 3+2*12)
+	for i, match := range offsets {
+		offset, err := strconv.Atoi(string(match[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(body[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestPDFString(t *testing.T) {
+	assert.Equal(t, `a\(b\)\\c`, pdfString(`a(b)\c`))
+	assert.Equal(t, `Pe\361a`, pdfString("Peña"))
+	assert.Equal(t, "?", pdfString("→"))
+}
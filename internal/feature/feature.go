@@ -0,0 +1,119 @@
+// Package feature turns risky behavior on without a deploy, so it can ship dark and be
+// rolled out gradually. Every flag is off unless the FEATURE_FLAGS setting turns it on for
+// every request, its tenant's flags turn it on or off for the tenant's requests, or a
+// request's X-Feature-Flags header turns it on or off for that request.
+package feature
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Flag names a feature that can be turned on and off
+type Flag string
+
+const (
+	// HarmonicFallback synthesizes predictions from extremes with harmonic rather than
+	// spline interpolation, for subordinate stations and when 6-minute predictions are
+	// unavailable
+	HarmonicFallback Flag = "harmonic-fallback"
+	// SplinePredictions interpolates the current level from 6-minute predictions with a
+	// spline rather than straight lines
+	SplinePredictions Flag = "spline-predictions"
+	// V2Default serves REST requests that don't ask for a version the v2 format
+	V2Default Flag = "v2-default"
+)
+
+// Flags are every flag there is
+var Flags = []Flag{HarmonicFallback, SplinePredictions, V2Default}
+
+// Header overrides flags for one request. It lists flags to turn on, separated by commas,
+// with a "-" in front of those to turn off, e.g. "harmonic-fallback,-v2-default".
+const Header = "X-Feature-Flags"
+
+// defaults are the flags turned on for every request
+var defaults atomic.Pointer[map[Flag]bool]
+
+// Configure turns on the named flags for every request, and off the rest. Names that
+// aren't flags are logged and ignored, so a flag can be configured before the code that
+// reads it is deployed.
+func Configure(names []string) {
+	enabled := map[Flag]bool{}
+	for _, name := range names {
+		flag, ok := parse(name)
+		if !ok {
+			log.Warn().Str("flag", name).Msg("Ignoring unknown feature flag")
+			continue
+		}
+		enabled[flag] = true
+	}
+	defaults.Store(&enabled)
+}
+
+type (
+	overridesKey struct{}
+	flagsKey     struct{}
+)
+
+// WithFlags returns a context turning on the named flags, and off those with a "-" in
+// front, for the request ctx belongs to, over the flags turned on for every request. A
+// request's header still overrides them. Unknown flags are ignored.
+func WithFlags(ctx context.Context, names []string) context.Context {
+	if len(names) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, flagsKey{}, parseList(names))
+}
+
+// FromHeaders returns a context carrying the overrides in the request's Header, if any.
+// Unknown flags in the header are ignored.
+func FromHeaders(ctx context.Context, headers map[string]string) context.Context {
+	var value string
+	for key, v := range headers {
+		if strings.EqualFold(key, Header) {
+			value = v
+			break
+		}
+	}
+	if value == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, overridesKey{}, parseList(strings.Split(value, ",")))
+}
+
+// parseList reads flags to turn on, and off with a "-" in front, skipping unknown ones
+func parseList(names []string) map[Flag]bool {
+	flags := map[Flag]bool{}
+	for _, name := range names {
+		name, off := strings.CutPrefix(strings.TrimSpace(name), "-")
+		if flag, ok := parse(name); ok {
+			flags[flag] = !off
+		}
+	}
+	return flags
+}
+
+// Enabled reports whether flag is on for the request ctx belongs to
+func Enabled(ctx context.Context, flag Flag) bool {
+	for _, key := range []interface{}{overridesKey{}, flagsKey{}} {
+		if flags, ok := ctx.Value(key).(map[Flag]bool); ok {
+			if on, ok := flags[flag]; ok {
+				return on
+			}
+		}
+	}
+	if enabled := defaults.Load(); enabled != nil {
+		return (*enabled)[flag]
+	}
+	return false
+}
+
+func parse(name string) (Flag, bool) {
+	flag := Flag(strings.ToLower(strings.TrimSpace(name)))
+	return flag, slices.Contains(Flags, flag)
+}
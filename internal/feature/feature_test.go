@@ -0,0 +1,48 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnabled(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	ctx := context.Background()
+	assert.False(t, Enabled(ctx, HarmonicFallback), "flags are off by default")
+
+	Configure([]string{"harmonic-fallback", " V2-Default ", "not-a-flag"})
+	assert.True(t, Enabled(ctx, HarmonicFallback))
+	assert.True(t, Enabled(ctx, V2Default))
+	assert.False(t, Enabled(ctx, SplinePredictions))
+
+	Configure(nil)
+	assert.False(t, Enabled(ctx, HarmonicFallback), "configuring again replaces the defaults")
+}
+
+func TestFromHeaders(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	Configure([]string{string(V2Default)})
+
+	ctx := FromHeaders(context.Background(), map[string]string{"x-feature-flags": "spline-predictions, -v2-default,bogus"})
+	assert.True(t, Enabled(ctx, SplinePredictions))
+	assert.False(t, Enabled(ctx, V2Default), "a header can turn a flag off")
+	assert.False(t, Enabled(ctx, HarmonicFallback))
+
+	ctx = FromHeaders(context.Background(), map[string]string{"Accept": "application/json"})
+	assert.True(t, Enabled(ctx, V2Default), "without the header the defaults apply")
+}
+
+func TestWithFlags(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+	Configure([]string{string(V2Default)})
+
+	ctx := WithFlags(context.Background(), []string{"harmonic-fallback", "-v2-default"})
+	assert.True(t, Enabled(ctx, HarmonicFallback))
+	assert.False(t, Enabled(ctx, V2Default), "a tenant can turn a default off")
+
+	ctx = FromHeaders(ctx, map[string]string{Header: "v2-default,-harmonic-fallback"})
+	assert.True(t, Enabled(ctx, V2Default), "the header wins")
+	assert.False(t, Enabled(ctx, HarmonicFallback))
+}
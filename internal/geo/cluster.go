@@ -0,0 +1,86 @@
+package geo
+
+import "math"
+
+// Bounds is a map's visible area. West is greater than East when it crosses the
+// antimeridian.
+type Bounds struct {
+	North, South, East, West float64
+}
+
+// Contains reports whether the point is within the bounds, edges included
+func (b Bounds) Contains(lat, lon float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.West <= b.East {
+		return lon >= b.West && lon <= b.East
+	}
+	return lon >= b.West || lon <= b.East
+}
+
+const (
+	// MaxZoom is the deepest web map zoom level
+	MaxZoom = 22
+	// tileSize and clusterCellSize are in screen pixels: points that would fall in the
+	// same 64px square of a 256px tile map share a cluster
+	tileSize        = 256
+	clusterCellSize = 64
+	// maxMercatorLatitude is where web maps stop
+	maxMercatorLatitude = 85.05112878
+)
+
+// Cluster is a group of points close together at a zoom level
+type Cluster struct {
+	// Latitude and Longitude are the members' centroid
+	Latitude, Longitude float64
+	// Bounds is the smallest box holding every member
+	Bounds Bounds
+	// Members are the indexes of the points in the cluster, in the order given
+	Members []int
+}
+
+// GridClusters groups points that fall in the same cell of a grid laid over a web
+// Mercator map at zoom, so each cell covers the same area on screen at every zoom level.
+// Clusters are in the order of their first member; a point alone in its cell is a cluster
+// of one.
+func GridClusters(lats, lons []float64, zoom int) []Cluster {
+	cells := math.Exp2(float64(zoom)) * tileSize / clusterCellSize
+	var clusters []Cluster
+	byCell := make(map[[2]int]int)
+	for i := range lats {
+		x, y := mercator(lats[i], lons[i])
+		cell := [2]int{int(math.Min(x*cells, cells-1)), int(math.Min(y*cells, cells-1))}
+		c, ok := byCell[cell]
+		if !ok {
+			c = len(clusters)
+			byCell[cell] = c
+			clusters = append(clusters, Cluster{Bounds: Bounds{North: lats[i], South: lats[i], East: lons[i], West: lons[i]}})
+		}
+		cluster := &clusters[c]
+		cluster.Members = append(cluster.Members, i)
+		cluster.Latitude += lats[i]
+		cluster.Longitude += lons[i]
+		cluster.Bounds.North = math.Max(cluster.Bounds.North, lats[i])
+		cluster.Bounds.South = math.Min(cluster.Bounds.South, lats[i])
+		cluster.Bounds.East = math.Max(cluster.Bounds.East, lons[i])
+		cluster.Bounds.West = math.Min(cluster.Bounds.West, lons[i])
+	}
+	// Cells never cross the antimeridian, so plain means are the centroids
+	for i := range clusters {
+		n := float64(len(clusters[i].Members))
+		clusters[i].Latitude /= n
+		clusters[i].Longitude /= n
+	}
+	return clusters
+}
+
+// mercator projects a point onto a web Mercator map one unit across, from 0 at the
+// top left corner
+func mercator(lat, lon float64) (float64, float64) {
+	lat = math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, lat))
+	phi := toRadians(lat)
+	x := (lon + 180) / 360
+	y := (1 - math.Log(math.Tan(phi)+1/math.Cos(phi))/math.Pi) / 2
+	return math.Max(0, x), math.Max(0, y)
+}
@@ -0,0 +1,50 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBounds_Contains(t *testing.T) {
+	pugetSound := Bounds{North: 48.5, South: 47, East: -122, West: -123}
+	assert.True(t, pugetSound.Contains(47.6062, -122.3321))
+	assert.True(t, pugetSound.Contains(48.5, -123), "edges are inside")
+	assert.False(t, pugetSound.Contains(45.5155, -122.6789))
+	assert.False(t, pugetSound.Contains(47.6062, -121))
+
+	pacific := Bounds{North: 60, South: 10, East: -150, West: 170}
+	assert.True(t, pacific.Contains(21.3, -157.9))
+	assert.True(t, pacific.Contains(52, 175))
+	assert.False(t, pacific.Contains(47.6, -122.3))
+}
+
+func TestGridClusters(t *testing.T) {
+	// Seattle, Tacoma, Portland, Honolulu
+	lats := []float64{47.6062, 47.2529, 45.5155, 21.3069}
+	lons := []float64{-122.3321, -122.4443, -122.6789, -157.8583}
+
+	clusters := GridClusters(lats, lons, 3)
+	require.Len(t, clusters, 2)
+	assert.Equal(t, []int{0, 1, 2}, clusters[0].Members)
+	assert.InDelta(t, (47.6062+47.2529+45.5155)/3, clusters[0].Latitude, 1e-9)
+	assert.InDelta(t, (-122.3321-122.4443-122.6789)/3, clusters[0].Longitude, 1e-9)
+	assert.Equal(t, Bounds{North: 47.6062, South: 45.5155, East: -122.3321, West: -122.6789}, clusters[0].Bounds)
+	assert.Equal(t, []int{3}, clusters[1].Members)
+	assert.Equal(t, 21.3069, clusters[1].Latitude)
+
+	clusters = GridClusters(lats, lons, 5)
+	require.Len(t, clusters, 3, "Portland gets its own cell zoomed in")
+	assert.Equal(t, []int{0, 1}, clusters[0].Members)
+
+	assert.Len(t, GridClusters(lats, lons, MaxZoom), 4)
+	assert.Empty(t, GridClusters(nil, nil, 0))
+}
+
+func TestGridClusters_Edges(t *testing.T) {
+	// Points on the antimeridian and poles stay within the grid
+	clusters := GridClusters([]float64{90, -90, 0}, []float64{180, -180, 180}, 0)
+	require.Len(t, clusters, 3)
+	assert.Equal(t, []int{0}, clusters[0].Members)
+}
@@ -0,0 +1,27 @@
+package geo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistanceKm(t *testing.T) {
+	assert.InDelta(t, 0, DistanceKm(47.6062, -122.3321, 47.6062, -122.3321), 0.0001)
+	assert.InDelta(t, 234.0, DistanceKm(47.6062, -122.3321, 45.5155, -122.6789), 1.0) // Seattle to Portland
+	assert.InDelta(t, 20015.1, DistanceKm(90, 0, -90, 0), 0.1)
+}
+
+func TestInitialBearing(t *testing.T) {
+	assert.InDelta(t, 0, InitialBearing(0, 0, 10, 0), 0.1)
+	assert.InDelta(t, 90, InitialBearing(0, 179, 0, -179), 0.1)
+	assert.InDelta(t, 186.6, InitialBearing(47.6062, -122.3321, 45.5155, -122.6789), 0.1)
+}
+
+func TestCompassPoint(t *testing.T) {
+	tests := map[float64]string{0: "N", 22.4: "N", 22.6: "NE", 180: "S", 290: "W", 337.6: "N", 359.9: "N", -45: "NW"}
+	for bearing, want := range tests {
+		assert.Equal(t, want, CompassPoint(bearing), fmt.Sprint("bearing ", bearing))
+	}
+}
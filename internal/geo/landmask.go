@@ -0,0 +1,220 @@
+package geo
+
+import (
+	"bytes"
+	"compress/gzip"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:generate go run ../../cmd/landmask -o landmask/land.bin.gz
+
+// landMaskData is the world's land as of the last build that refreshed it, for judging
+// whether the way to a station crosses land. The committed file holds no land; the build
+// scripts and deploy workflow build it from Natural Earth's land polygons before building
+// and fail if it still has none.
+//
+//go:embed landmask/land.bin.gz
+var landMaskData []byte
+
+// landMaskMagic starts every land mask file
+var landMaskMagic = [4]byte{'F', 'L', 'M', 'K'}
+
+// DefaultLandMaskCell is the land mask's cell size in degrees, about 5.5km north to south
+const DefaultLandMaskCell = 0.05
+
+// LandMask is a coarse grid of the world's land and water. Cells are rows of longitude
+// from -180, one row per cell of latitude from -90.
+type LandMask struct {
+	cell       float64
+	rows, cols int
+	land       []byte
+	landCells  int
+}
+
+// NewLandMask returns a mask of cell-degree cells that are all water
+func NewLandMask(cell float64) (*LandMask, error) {
+	rows, cols := math.Round(180/cell), math.Round(360/cell)
+	if cell <= 0 || math.Abs(rows*cell-180) > 1e-9 || math.Abs(cols*cell-360) > 1e-9 {
+		return nil, fmt.Errorf("invalid land mask cell size %g: must divide 180 degrees", cell)
+	}
+	return &LandMask{
+		cell: cell,
+		rows: int(rows),
+		cols: int(cols),
+		land: make([]byte, (int(rows)*int(cols)+7)/8),
+	}, nil
+}
+
+// LandCells is how many of the mask's cells are land
+func (m *LandMask) LandCells() int {
+	return m.landCells
+}
+
+// IsLand reports whether the cell holding the point is land
+func (m *LandMask) IsLand(lat, lon float64) bool {
+	i := m.index(m.row(lat), m.col(lon))
+	return m.land[i/8]&(1<<(i%8)) != 0
+}
+
+func (m *LandMask) setLand(row, col int) {
+	i := m.index(row, col)
+	if m.land[i/8]&(1<<(i%8)) == 0 {
+		m.land[i/8] |= 1 << (i % 8)
+		m.landCells++
+	}
+}
+
+func (m *LandMask) index(row, col int) int {
+	return row*m.cols + col
+}
+
+func (m *LandMask) row(lat float64) int {
+	return min(max(int(math.Floor((lat+90)/m.cell)), 0), m.rows-1)
+}
+
+func (m *LandMask) col(lon float64) int {
+	col := int(math.Floor((lon + 180) / m.cell))
+	return ((col % m.cols) + m.cols) % m.cols
+}
+
+// LandFraction is the share of the straight path between two points, in degrees and the
+// short way round, that crosses land. The cell either end is in is left out, since a
+// coastal point may share its cell with the shore.
+func (m *LandMask) LandFraction(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := lat2 - lat1
+	dLon := math.Mod(lon2-lon1+540, 360) - 180
+	// Half-cell steps don't skip a cell the path crosses squarely
+	step := m.cell / 2
+	steps := int(math.Ceil(math.Max(math.Abs(dLat), math.Abs(dLon)) / step))
+	skip := 2
+	if steps <= 2*skip {
+		return 0
+	}
+
+	var land, samples int
+	for i := skip; i <= steps-skip; i++ {
+		t := float64(i) / float64(steps)
+		samples++
+		if m.IsLand(lat1+t*dLat, lon1+t*dLon) {
+			land++
+		}
+	}
+	return float64(land) / float64(samples)
+}
+
+// FillPolygon marks the cells whose centers are inside the polygon as land. Rings are
+// lists of [lon, lat] points, the first the outline and the rest holes, as in GeoJSON.
+func (m *LandMask) FillPolygon(rings [][][2]float64) {
+	south, north := math.Inf(1), math.Inf(-1)
+	for _, ring := range rings {
+		for _, p := range ring {
+			south, north = math.Min(south, p[1]), math.Max(north, p[1])
+		}
+	}
+	if len(rings) == 0 || south > north {
+		return
+	}
+
+	var crossings []float64
+	for row := m.row(south); row <= m.row(north); row++ {
+		lat := -90 + (float64(row)+0.5)*m.cell
+		crossings = crossings[:0]
+		for _, ring := range rings {
+			for i := range ring {
+				a, b := ring[i], ring[(i+1)%len(ring)]
+				if (a[1] <= lat) == (b[1] <= lat) {
+					continue
+				}
+				crossings = append(crossings, a[0]+(lat-a[1])/(b[1]-a[1])*(b[0]-a[0]))
+			}
+		}
+		sort.Float64s(crossings)
+		// Even-odd: the centers between each pair of crossings are inside
+		for i := 0; i+1 < len(crossings); i += 2 {
+			first := int(math.Ceil((crossings[i]+180)/m.cell - 0.5))
+			last := int(math.Ceil((crossings[i+1]+180)/m.cell-0.5)) - 1
+			for col := max(first, 0); col <= min(last, m.cols-1); col++ {
+				m.setLand(row, col)
+			}
+		}
+	}
+}
+
+// Write writes the mask, compressed, in the format ReadLandMask reads
+func (m *LandMask) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(landMaskMagic[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(gz, binary.BigEndian, m.cell); err != nil {
+		return err
+	}
+	if _, err := gz.Write(m.land); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadLandMask reads a mask written by Write
+func ReadLandMask(r io.Reader) (*LandMask, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing land mask: %w", err)
+	}
+	defer gz.Close()
+
+	var magic [4]byte
+	if _, err := io.ReadFull(gz, magic[:]); err != nil {
+		return nil, fmt.Errorf("reading land mask header: %w", err)
+	}
+	if magic != landMaskMagic {
+		return nil, errors.New("not a land mask")
+	}
+	var cell float64
+	if err := binary.Read(gz, binary.BigEndian, &cell); err != nil {
+		return nil, fmt.Errorf("reading land mask header: %w", err)
+	}
+	m, err := NewLandMask(cell)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(gz, m.land); err != nil {
+		return nil, fmt.Errorf("reading land mask cells: %w", err)
+	}
+	for _, b := range m.land {
+		for ; b != 0; b &= b - 1 {
+			m.landCells++
+		}
+	}
+	return m, nil
+}
+
+var (
+	embeddedMaskOnce sync.Once
+	embeddedMask     *LandMask
+)
+
+// EmbeddedLandMask returns the mask built into the binary, decoded the first time it's
+// asked for. It returns nil when the mask has no land or can't be read.
+func EmbeddedLandMask() *LandMask {
+	embeddedMaskOnce.Do(func() {
+		m, err := ReadLandMask(bytes.NewReader(landMaskData))
+		if err != nil {
+			log.Error().Err(err).Msg("Reading the embedded land mask")
+			return
+		}
+		if m.LandCells() > 0 {
+			embeddedMask = m
+		}
+	})
+	return embeddedMask
+}
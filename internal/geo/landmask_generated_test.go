@@ -0,0 +1,20 @@
+//go:build generated
+
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Built with -tags generated by the build scripts once go generate has built the mask, so
+// a build never embeds the committed one, which has no land
+
+func TestEmbeddedLandMask_MarksLand(t *testing.T) {
+	mask := EmbeddedLandMask()
+	require.NotNil(t, mask, "the embedded land mask has no land; run go generate ./internal/geo")
+	assert.True(t, mask.IsLand(39.74, -104.99), "Denver should be land")
+	assert.False(t, mask.IsLand(30, -140), "the middle of the Pacific should be water")
+}
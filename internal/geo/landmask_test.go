@@ -0,0 +1,75 @@
+package geo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// peninsulaMask is a mask with land from 47 to 48N between 123 and 122.5W, less a lake
+// from 47.4 to 47.6N between 122.9 and 122.7W
+func peninsulaMask(t *testing.T) *LandMask {
+	t.Helper()
+	mask, err := NewLandMask(0.1)
+	require.NoError(t, err)
+	mask.FillPolygon([][][2]float64{
+		{{-123, 47}, {-122.5, 47}, {-122.5, 48}, {-123, 48}, {-123, 47}},
+		{{-122.9, 47.4}, {-122.7, 47.4}, {-122.7, 47.6}, {-122.9, 47.6}, {-122.9, 47.4}},
+	})
+	return mask
+}
+
+func TestLandMask_FillPolygon(t *testing.T) {
+	mask := peninsulaMask(t)
+	assert.Equal(t, 5*10-2*2, mask.LandCells())
+	assert.True(t, mask.IsLand(47.2, -122.95))
+	assert.True(t, mask.IsLand(47.95, -122.55))
+	assert.False(t, mask.IsLand(47.5, -122.8), "holes are water")
+	assert.False(t, mask.IsLand(47.5, -122.45))
+	assert.False(t, mask.IsLand(48.05, -122.8))
+	assert.False(t, mask.IsLand(47.5, 57.2), "only the polygon's longitudes")
+}
+
+func TestLandMask_LandFraction(t *testing.T) {
+	mask := peninsulaMask(t)
+
+	across := mask.LandFraction(47.25, -123.5, 47.25, -122)
+	assert.InDelta(t, 1.0/3, across, 0.1, "a third of the way is the peninsula")
+	assert.Zero(t, mask.LandFraction(48.5, -123.5, 48.5, -122), "around its tip")
+	assert.Zero(t, mask.LandFraction(47.25, -122.51, 47.25, -122.45), "the shore's own cell")
+	assert.Equal(t, mask.LandFraction(47.25, -123.5, 47.25, -122), mask.LandFraction(47.25, -122, 47.25, -123.5))
+
+	antimeridian, err := NewLandMask(1)
+	require.NoError(t, err)
+	antimeridian.FillPolygon([][][2]float64{{{170, 50}, {180, 50}, {180, 52}, {170, 52}, {170, 50}}})
+	assert.Zero(t, antimeridian.LandFraction(51, 160, 51, 168)+antimeridian.LandFraction(51, -175, 51, -160))
+	assert.Greater(t, antimeridian.LandFraction(51, 160, 51, -170), 0.0, "the short way round crosses it")
+}
+
+func TestLandMask_WriteRead(t *testing.T) {
+	mask := peninsulaMask(t)
+	var buf bytes.Buffer
+	require.NoError(t, mask.Write(&buf))
+
+	read, err := ReadLandMask(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, mask, read)
+
+	_, err = ReadLandMask(bytes.NewReader(nil))
+	assert.Error(t, err)
+	_, err = NewLandMask(0.07)
+	assert.EqualError(t, err, "invalid land mask cell size 0.07: must divide 180 degrees")
+}
+
+func TestEmbeddedLandMask(t *testing.T) {
+	// The committed mask holds no land, so there's nothing to snap with until it's built
+	mask, err := ReadLandMask(bytes.NewReader(landMaskData))
+	require.NoError(t, err)
+	if mask.LandCells() == 0 {
+		assert.Nil(t, EmbeddedLandMask())
+	} else {
+		assert.Same(t, EmbeddedLandMask(), EmbeddedLandMask())
+	}
+}
@@ -0,0 +1,73 @@
+// Package geocode describes where a point is in words, such as "40 km W of Astoria, OR",
+// so messages about a requested location can say where it is
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+)
+
+// Geocoder reverse geocodes a point
+type Geocoder interface {
+	// PlaceName describes the point, e.g. "Astoria, OR" or "40 km W of Astoria, OR".
+	// It returns "" when it has nothing useful to say.
+	PlaceName(ctx context.Context, lat, lon float64) (string, error)
+}
+
+// Place is a named point
+type Place struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+const (
+	// DefaultNearKm is how close a point must be to a place to be described as the place itself
+	DefaultNearKm = 10.0
+	// DefaultMaxKm is how far a point can be from every place before it gets no description
+	DefaultMaxKm = 1000.0
+)
+
+// Gazetteer is an offline Geocoder that describes a point relative to the nearest of a
+// fixed list of places
+type Gazetteer struct {
+	places []Place
+	nearKm float64
+	maxKm  float64
+}
+
+var _ Geocoder = (*Gazetteer)(nil)
+
+// NewGazetteer returns a Gazetteer over the built-in list of coastal places
+func NewGazetteer() *Gazetteer {
+	return NewGazetteerWithPlaces(coastalPlaces, DefaultNearKm, DefaultMaxKm)
+}
+
+// NewGazetteerWithPlaces returns a Gazetteer over places
+func NewGazetteerWithPlaces(places []Place, nearKm, maxKm float64) *Gazetteer {
+	return &Gazetteer{places: places, nearKm: nearKm, maxKm: maxKm}
+}
+
+func (g *Gazetteer) PlaceName(_ context.Context, lat, lon float64) (string, error) {
+	var nearest *Place
+	nearestKm := math.Inf(1)
+	for i := range g.places {
+		if d := geo.DistanceKm(lat, lon, g.places[i].Latitude, g.places[i].Longitude); d < nearestKm {
+			nearest, nearestKm = &g.places[i], d
+		}
+	}
+
+	switch {
+	case nearest == nil || nearestKm > g.maxKm:
+		return "", nil
+	case nearestKm <= g.nearKm:
+		return nearest.Name, nil
+	default:
+		// Describe the point from the place, so the bearing runs from the place to the point
+		bearing := geo.InitialBearing(nearest.Latitude, nearest.Longitude, lat, lon)
+		return fmt.Sprintf("%.0f km %s of %s", nearestKm, geo.CompassPoint(bearing), nearest.Name), nil
+	}
+}
@@ -0,0 +1,52 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGazetteer_PlaceName(t *testing.T) {
+	places := []Place{
+		{Name: "Astoria, OR", Latitude: 46.188, Longitude: -123.831},
+		{Name: "Seattle, WA", Latitude: 47.606, Longitude: -122.332},
+	}
+	gazetteer := NewGazetteerWithPlaces(places, 10, 500)
+
+	tests := []struct {
+		name string
+		lat  float64
+		lon  float64
+		want string
+	}{
+		{name: "at a place", lat: 47.61, lon: -122.34, want: "Seattle, WA"},
+		{name: "offshore", lat: 46.188, lon: -124.35, want: "40 km W of Astoria, OR"},
+		{name: "too far from anywhere", lat: 30, lon: -140, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gazetteer.PlaceName(context.Background(), tt.lat, tt.lon)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewGazetteer(t *testing.T) {
+	gazetteer := NewGazetteer()
+
+	for _, p := range coastalPlaces {
+		assert.True(t, p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180, p.Name)
+	}
+
+	got, err := gazetteer.PlaceName(context.Background(), 21.3, -157.86)
+	require.NoError(t, err)
+	assert.Equal(t, "Honolulu, HI", got)
+
+	got, err = gazetteer.PlaceName(context.Background(), 50.0, -4.9)
+	require.NoError(t, err)
+	assert.Contains(t, got, "of Penzance, England")
+}
@@ -0,0 +1,135 @@
+package geocode
+
+// coastalPlaces is a small built-in gazetteer of coastal towns and cities across the
+// regions the station sources cover. It only needs to be dense enough to describe roughly
+// where a point is, not to resolve addresses.
+var coastalPlaces = []Place{
+	// US Pacific coast
+	{Name: "San Diego, CA", Latitude: 32.715, Longitude: -117.163},
+	{Name: "Los Angeles, CA", Latitude: 33.740, Longitude: -118.270},
+	{Name: "Santa Barbara, CA", Latitude: 34.420, Longitude: -119.698},
+	{Name: "Morro Bay, CA", Latitude: 35.366, Longitude: -120.850},
+	{Name: "Monterey, CA", Latitude: 36.600, Longitude: -121.894},
+	{Name: "San Francisco, CA", Latitude: 37.775, Longitude: -122.419},
+	{Name: "Fort Bragg, CA", Latitude: 39.446, Longitude: -123.805},
+	{Name: "Eureka, CA", Latitude: 40.802, Longitude: -124.164},
+	{Name: "Crescent City, CA", Latitude: 41.756, Longitude: -124.202},
+	{Name: "Coos Bay, OR", Latitude: 43.367, Longitude: -124.218},
+	{Name: "Newport, OR", Latitude: 44.637, Longitude: -124.053},
+	{Name: "Astoria, OR", Latitude: 46.188, Longitude: -123.831},
+	{Name: "Westport, WA", Latitude: 46.891, Longitude: -124.104},
+	{Name: "Port Angeles, WA", Latitude: 48.118, Longitude: -123.430},
+	{Name: "Seattle, WA", Latitude: 47.606, Longitude: -122.332},
+	{Name: "Bellingham, WA", Latitude: 48.750, Longitude: -122.478},
+
+	// Alaska
+	{Name: "Ketchikan, AK", Latitude: 55.342, Longitude: -131.646},
+	{Name: "Juneau, AK", Latitude: 58.302, Longitude: -134.420},
+	{Name: "Sitka, AK", Latitude: 57.053, Longitude: -135.330},
+	{Name: "Yakutat, AK", Latitude: 59.547, Longitude: -139.727},
+	{Name: "Cordova, AK", Latitude: 60.543, Longitude: -145.757},
+	{Name: "Anchorage, AK", Latitude: 61.218, Longitude: -149.900},
+	{Name: "Seward, AK", Latitude: 60.104, Longitude: -149.443},
+	{Name: "Kodiak, AK", Latitude: 57.790, Longitude: -152.407},
+	{Name: "Dutch Harbor, AK", Latitude: 53.889, Longitude: -166.542},
+	{Name: "Adak, AK", Latitude: 51.880, Longitude: -176.658},
+	{Name: "Nome, AK", Latitude: 64.501, Longitude: -165.406},
+	{Name: "Utqiagvik, AK", Latitude: 71.290, Longitude: -156.789},
+
+	// Hawaii and the Pacific islands
+	{Name: "Hilo, HI", Latitude: 19.707, Longitude: -155.082},
+	{Name: "Kahului, HI", Latitude: 20.890, Longitude: -156.470},
+	{Name: "Honolulu, HI", Latitude: 21.307, Longitude: -157.858},
+	{Name: "Nawiliwili, HI", Latitude: 21.957, Longitude: -159.356},
+	{Name: "Midway Atoll", Latitude: 28.208, Longitude: -177.378},
+	{Name: "Pago Pago, American Samoa", Latitude: -14.279, Longitude: -170.700},
+	{Name: "Hagåtña, Guam", Latitude: 13.476, Longitude: 144.748},
+	{Name: "Saipan, Northern Mariana Islands", Latitude: 15.178, Longitude: 145.750},
+	{Name: "Majuro, Marshall Islands", Latitude: 7.090, Longitude: 171.380},
+	{Name: "Wake Island", Latitude: 19.283, Longitude: 166.647},
+
+	// US Gulf coast
+	{Name: "Key West, FL", Latitude: 24.555, Longitude: -81.780},
+	{Name: "Naples, FL", Latitude: 26.142, Longitude: -81.795},
+	{Name: "Tampa, FL", Latitude: 27.951, Longitude: -82.457},
+	{Name: "Cedar Key, FL", Latitude: 29.139, Longitude: -83.035},
+	{Name: "Apalachicola, FL", Latitude: 29.726, Longitude: -84.983},
+	{Name: "Pensacola, FL", Latitude: 30.421, Longitude: -87.217},
+	{Name: "Mobile, AL", Latitude: 30.695, Longitude: -88.040},
+	{Name: "Biloxi, MS", Latitude: 30.396, Longitude: -88.885},
+	{Name: "Grand Isle, LA", Latitude: 29.237, Longitude: -90.003},
+	{Name: "Cameron, LA", Latitude: 29.798, Longitude: -93.325},
+	{Name: "Galveston, TX", Latitude: 29.301, Longitude: -94.798},
+	{Name: "Corpus Christi, TX", Latitude: 27.801, Longitude: -97.396},
+	{Name: "South Padre Island, TX", Latitude: 26.104, Longitude: -97.165},
+
+	// US Atlantic coast
+	{Name: "Miami, FL", Latitude: 25.762, Longitude: -80.192},
+	{Name: "West Palm Beach, FL", Latitude: 26.715, Longitude: -80.053},
+	{Name: "Cape Canaveral, FL", Latitude: 28.392, Longitude: -80.604},
+	{Name: "Jacksonville, FL", Latitude: 30.332, Longitude: -81.656},
+	{Name: "Savannah, GA", Latitude: 32.081, Longitude: -81.091},
+	{Name: "Charleston, SC", Latitude: 32.777, Longitude: -79.931},
+	{Name: "Wilmington, NC", Latitude: 34.226, Longitude: -77.945},
+	{Name: "Cape Hatteras, NC", Latitude: 35.251, Longitude: -75.529},
+	{Name: "Norfolk, VA", Latitude: 36.851, Longitude: -76.286},
+	{Name: "Baltimore, MD", Latitude: 39.290, Longitude: -76.612},
+	{Name: "Ocean City, MD", Latitude: 38.336, Longitude: -75.085},
+	{Name: "Cape May, NJ", Latitude: 38.935, Longitude: -74.906},
+	{Name: "Atlantic City, NJ", Latitude: 39.364, Longitude: -74.423},
+	{Name: "New York, NY", Latitude: 40.700, Longitude: -74.014},
+	{Name: "Montauk, NY", Latitude: 41.036, Longitude: -71.954},
+	{Name: "New London, CT", Latitude: 41.356, Longitude: -72.099},
+	{Name: "Newport, RI", Latitude: 41.490, Longitude: -71.313},
+	{Name: "Nantucket, MA", Latitude: 41.283, Longitude: -70.099},
+	{Name: "Provincetown, MA", Latitude: 42.052, Longitude: -70.186},
+	{Name: "Boston, MA", Latitude: 42.360, Longitude: -71.058},
+	{Name: "Portland, ME", Latitude: 43.661, Longitude: -70.255},
+	{Name: "Bar Harbor, ME", Latitude: 44.388, Longitude: -68.204},
+	{Name: "Eastport, ME", Latitude: 44.906, Longitude: -66.990},
+
+	// Caribbean
+	{Name: "San Juan, PR", Latitude: 18.466, Longitude: -66.106},
+	{Name: "Mayagüez, PR", Latitude: 18.201, Longitude: -67.140},
+	{Name: "Charlotte Amalie, USVI", Latitude: 18.342, Longitude: -64.931},
+	{Name: "Christiansted, USVI", Latitude: 17.746, Longitude: -64.703},
+
+	// Canada
+	{Name: "Victoria, BC", Latitude: 48.428, Longitude: -123.366},
+	{Name: "Vancouver, BC", Latitude: 49.283, Longitude: -123.121},
+	{Name: "Tofino, BC", Latitude: 49.153, Longitude: -125.907},
+	{Name: "Port Hardy, BC", Latitude: 50.725, Longitude: -127.497},
+	{Name: "Prince Rupert, BC", Latitude: 54.315, Longitude: -130.320},
+	{Name: "Halifax, NS", Latitude: 44.649, Longitude: -63.575},
+	{Name: "Yarmouth, NS", Latitude: 43.837, Longitude: -66.117},
+	{Name: "Saint John, NB", Latitude: 45.273, Longitude: -66.063},
+	{Name: "Charlottetown, PE", Latitude: 46.238, Longitude: -63.131},
+	{Name: "Sydney, NS", Latitude: 46.137, Longitude: -60.194},
+	{Name: "St. John's, NL", Latitude: 47.562, Longitude: -52.713},
+	{Name: "Quebec City, QC", Latitude: 46.813, Longitude: -71.208},
+	{Name: "Sept-Îles, QC", Latitude: 50.212, Longitude: -66.376},
+	{Name: "Churchill, MB", Latitude: 58.768, Longitude: -94.165},
+	{Name: "Iqaluit, NU", Latitude: 63.749, Longitude: -68.522},
+
+	// United Kingdom
+	{Name: "Plymouth, England", Latitude: 50.375, Longitude: -4.143},
+	{Name: "Southampton, England", Latitude: 50.910, Longitude: -1.404},
+	{Name: "Dover, England", Latitude: 51.128, Longitude: 1.316},
+	{Name: "London, England", Latitude: 51.507, Longitude: -0.128},
+	{Name: "Great Yarmouth, England", Latitude: 52.608, Longitude: 1.729},
+	{Name: "Hull, England", Latitude: 53.745, Longitude: -0.336},
+	{Name: "Newcastle upon Tyne, England", Latitude: 54.978, Longitude: -1.618},
+	{Name: "Liverpool, England", Latitude: 53.408, Longitude: -2.992},
+	{Name: "Bristol, England", Latitude: 51.455, Longitude: -2.588},
+	{Name: "Penzance, England", Latitude: 50.118, Longitude: -5.537},
+	{Name: "Cardiff, Wales", Latitude: 51.481, Longitude: -3.179},
+	{Name: "Holyhead, Wales", Latitude: 53.309, Longitude: -4.633},
+	{Name: "Edinburgh, Scotland", Latitude: 55.953, Longitude: -3.188},
+	{Name: "Aberdeen, Scotland", Latitude: 57.150, Longitude: -2.094},
+	{Name: "Wick, Scotland", Latitude: 58.441, Longitude: -3.094},
+	{Name: "Lerwick, Scotland", Latitude: 60.155, Longitude: -1.145},
+	{Name: "Stornoway, Scotland", Latitude: 58.209, Longitude: -6.387},
+	{Name: "Oban, Scotland", Latitude: 56.415, Longitude: -5.472},
+	{Name: "Glasgow, Scotland", Latitude: 55.864, Longitude: -4.252},
+	{Name: "Belfast, Northern Ireland", Latitude: 54.597, Longitude: -5.930},
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/logging"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/task"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AdminKeyHeader carries the admin API key
+const AdminKeyHeader = "X-Admin-Key"
+
+// CacheAdmin inspects and invalidates cached prediction records
+type CacheAdmin interface {
+	Inspect(ctx context.Context, stationID string, date time.Time) (*cache.CacheEntryInfo, error)
+	Invalidate(ctx context.Context, stationID string, date time.Time) error
+}
+
+// PredictionWarmer refetches predictions into the cache
+type PredictionWarmer interface {
+	WarmPredictions(ctx context.Context, stationID string, startDate, endDate time.Time) (int, error)
+}
+
+var (
+	_ CacheAdmin       = (*cache.LRUCacheService)(nil)
+	_ PredictionWarmer = (*tide.Service)(nil)
+)
+
+// AdminHandler serves the admin API:
+//
+//	GET    /admin/cache?stationId=&date=                 inspect a cached record
+//	DELETE /admin/cache?stationId=&date=                 purge it from the store and this instance's LRU
+//	POST   /admin/cache/warm?stationId=&startDate=&endDate= refetch a range into the cache, or queue it
+//	GET    /admin/dashboard                              NOAA errors, cache hits and endpoint latencies over the last hour
+//	GET    /admin/log-level                              the log level and hot path sampling of this instance
+//	PUT    /admin/log-level?level=&sampleRate=           change them in every instance
+//
+// Every request must carry the configured key in the X-Admin-Key header.
+type AdminHandler struct {
+	apiKey string
+	cache  CacheAdmin
+	warmer PredictionWarmer
+	// metrics is nil unless a metrics table is configured
+	metrics metrics.Store
+	// queue is nil unless a task queue is configured, and warming runs in the request
+	queue task.Queue
+	// setRemote writes a setting to the remote configuration, like config.SetRemote
+	setRemote func(ctx context.Context, name, value string) error
+	now       func() time.Time
+}
+
+func NewAdminHandler(apiKey string, cacheAdmin CacheAdmin, warmer PredictionWarmer) *AdminHandler {
+	return &AdminHandler{
+		apiKey: apiKey,
+		cache:  cacheAdmin,
+		warmer: warmer,
+		now:    time.Now,
+	}
+}
+
+// UseRemoteConfig has the handler change the log level and sampling through setRemote,
+// which writes a setting to the configuration every instance watches
+func (h *AdminHandler) UseRemoteConfig(setRemote func(ctx context.Context, name, value string) error) {
+	h.setRemote = setRemote
+}
+
+// UseDashboard has the handler serve the dashboard from what instances added to store
+func (h *AdminHandler) UseDashboard(store metrics.Store) {
+	h.metrics = store
+}
+
+// UseQueue has the handler queue cache warming for the worker rather than wait for it
+func (h *AdminHandler) UseQueue(queue task.Queue) {
+	h.queue = queue
+}
+
+func (h *AdminHandler) HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// With no key configured the API stays closed rather than open to anyone
+	if h.apiKey == "" {
+		return api.Error(api.CodeForbidden, "Admin API is disabled", http.StatusForbidden)
+	}
+	if !h.authorized(request.Headers) {
+		return api.Error(api.CodeUnauthenticated, "Unauthorized", http.StatusUnauthorized)
+	}
+
+	if strings.HasSuffix(strings.TrimSuffix(request.Path, "/"), "/dashboard") {
+		if request.HTTPMethod != http.MethodGet {
+			return api.Error(api.CodeMethodNotAllowed, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return h.dashboard(ctx)
+	}
+	if strings.HasSuffix(strings.TrimSuffix(request.Path, "/"), "/log-level") {
+		switch request.HTTPMethod {
+		case http.MethodGet:
+			return api.Success(api.NewLogLevelResponse(zerolog.GlobalLevel().String(), logging.SampleRate()))
+		case http.MethodPut:
+			return h.setLogLevel(ctx, request.QueryStringParameters)
+		default:
+			return api.Error(api.CodeMethodNotAllowed, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+
+	params := request.QueryStringParameters
+	stationID := params["stationId"]
+	if stationID == "" {
+		return api.Error(api.CodeInvalidRequest, "Missing required parameter: stationId", http.StatusBadRequest)
+	}
+
+	if strings.HasSuffix(strings.TrimSuffix(request.Path, "/"), "/warm") {
+		if request.HTTPMethod != http.MethodPost {
+			return api.Error(api.CodeMethodNotAllowed, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+		return h.warm(ctx, stationID, params)
+	}
+
+	date, err := parseAdminDate(params, "date")
+	if err != nil {
+		return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	}
+
+	switch request.HTTPMethod {
+	case http.MethodGet:
+		info, err := h.cache.Inspect(ctx, stationID, date)
+		if err != nil {
+			log.Error().Err(err).Str("station_id", stationID).Msg("Error inspecting cache")
+			return api.Error(api.CodeInternal, "Error inspecting cache", http.StatusInternalServerError)
+		}
+		return api.Success(api.NewCacheEntryResponse("cacheEntry", info))
+	case http.MethodDelete:
+		if err := h.cache.Invalidate(ctx, stationID, date); err != nil {
+			log.Error().Err(err).Str("station_id", stationID).Msg("Error invalidating cache")
+			return api.Error(api.CodeInternal, "Error invalidating cache", http.StatusInternalServerError)
+		}
+		log.Info().Str("station_id", stationID).Time("date", date).Msg("Invalidated cached predictions")
+
+		// Report what's left so callers can confirm the purge
+		info, err := h.cache.Inspect(ctx, stationID, date)
+		if err != nil {
+			log.Error().Err(err).Str("station_id", stationID).Msg("Error inspecting cache")
+			return api.Error(api.CodeInternal, "Error inspecting cache", http.StatusInternalServerError)
+		}
+		response := api.NewCacheEntryResponse("cacheInvalidated", info)
+		response.Note = fmt.Sprintf("Removed from the shared store and this instance's memory. Other instances "+
+			"may keep serving their in-memory copy for up to %d seconds.", info.LRUTTLSeconds)
+		return api.Success(response)
+	default:
+		return api.Error(api.CodeMethodNotAllowed, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *AdminHandler) warm(ctx context.Context, stationID string, params map[string]string) (events.APIGatewayProxyResponse, error) {
+	startDate, err := parseAdminDate(params, "startDate")
+	if err != nil {
+		return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	}
+	endDate := startDate
+	if _, ok := params["endDate"]; ok {
+		if endDate, err = parseAdminDate(params, "endDate"); err != nil {
+			return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	if h.queue != nil {
+		return h.queueWarm(ctx, stationID, startDate, endDate)
+	}
+
+	days, err := h.warmer.WarmPredictions(ctx, stationID, startDate, endDate)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.Success(api.NewCacheWarmResponse(stationID, days))
+}
+
+// queueWarm queues warming a range for the worker and answers 202 with the days queued.
+// The range is checked here so a request that can't succeed fails now rather than in the
+// worker; an unknown station is only found there.
+func (h *AdminHandler) queueWarm(ctx context.Context, stationID string, startDate, endDate time.Time) (events.APIGatewayProxyResponse, error) {
+	if endDate.Before(startDate) {
+		return api.ErrorFor(tide.NewInvalidRangeError("end date must not be before start date"))
+	}
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	if days > tide.MaxWarmDays {
+		return api.ErrorFor(tide.NewRangeTooLargeError(fmt.Sprintf("date range cannot exceed %d days", tide.MaxWarmDays)))
+	}
+
+	err := h.queue.Enqueue(ctx, task.TypeWarmCache, task.WarmCache{
+		StationID: stationID,
+		StartDate: startDate.Format(time.DateOnly),
+		EndDate:   endDate.Format(time.DateOnly),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("station_id", stationID).Msg("Error queueing cache warming")
+		return api.Error(api.CodeInternal, "Error queueing cache warming", http.StatusInternalServerError)
+	}
+
+	response := api.NewCacheWarmResponse(stationID, days)
+	response.Queued = true
+	accepted, err := api.Success(response)
+	accepted.StatusCode = http.StatusAccepted
+	return accepted, err
+}
+
+func (h *AdminHandler) dashboard(ctx context.Context) (events.APIGatewayProxyResponse, error) {
+	if h.metrics == nil {
+		return api.Error(api.CodeForbidden, "Metrics are not configured", http.StatusForbidden)
+	}
+	dashboard, err := metrics.LoadDashboard(ctx, h.metrics, h.now())
+	if err != nil {
+		log.Error().Err(err).Msg("Error loading metrics")
+		return api.Error(api.CodeInternal, "Error loading metrics", http.StatusInternalServerError)
+	}
+	return api.Success(api.NewDashboardResponse(dashboard))
+}
+
+// setLogLevel writes the level and sample rate given to the remote configuration. This
+// instance applies them at once and the others when they next read it.
+func (h *AdminHandler) setLogLevel(ctx context.Context, params map[string]string) (events.APIGatewayProxyResponse, error) {
+	level, hasLevel := params["level"]
+	sampleRate, hasSampleRate := params["sampleRate"]
+	if !hasLevel && !hasSampleRate {
+		return api.Error(api.CodeInvalidRequest, "Missing required parameter: level or sampleRate", http.StatusBadRequest)
+	}
+	if hasLevel {
+		if _, err := zerolog.ParseLevel(level); err != nil || level == "" {
+			return api.ErrorFor(&validate.Error{Parameter: "level", Value: level, Message: "must be a log level such as debug or info"})
+		}
+	}
+	if hasSampleRate {
+		n, err := validate.Integer("sampleRate", sampleRate)
+		if err == nil {
+			err = validate.AtLeast("sampleRate", float64(n), 0)
+		}
+		if err != nil {
+			return api.ErrorFor(err)
+		}
+	}
+	if h.setRemote == nil {
+		return api.Error(api.CodeForbidden, "Remote configuration is not configured", http.StatusForbidden)
+	}
+
+	settings := map[string]string{}
+	if hasLevel {
+		settings["LOG_LEVEL"] = level
+	}
+	if hasSampleRate {
+		settings["LOG_SAMPLE_RATE"] = sampleRate
+	}
+	for name, value := range settings {
+		if err := h.setRemote(ctx, name, value); err != nil {
+			if errors.Is(err, config.ErrNotWritable) {
+				return api.Error(api.CodeForbidden, err.Error(), http.StatusForbidden)
+			}
+			log.Error().Err(err).Str("setting", name).Msg("Error changing the log level")
+			return api.Error(api.CodeInternal, "Error changing the log level", http.StatusInternalServerError)
+		}
+	}
+	log.Info().Str("level", zerolog.GlobalLevel().String()).Int("sample_rate", logging.SampleRate()).Msg("Changed the log level")
+	return api.Success(api.NewLogLevelResponse(zerolog.GlobalLevel().String(), logging.SampleRate()))
+}
+
+// authorized compares the request's admin key in constant time. API Gateway doesn't
+// normalize header case, so the header is matched case-insensitively.
+func (h *AdminHandler) authorized(headers map[string]string) bool {
+	for name, value := range headers {
+		if strings.EqualFold(name, AdminKeyHeader) {
+			return subtle.ConstantTimeCompare([]byte(value), []byte(h.apiKey)) == 1
+		}
+	}
+	return false
+}
+
+func parseAdminDate(params map[string]string, name string) (time.Time, error) {
+	value, ok := params[name]
+	if !ok {
+		return time.Time{}, errors.New("Missing required parameter: " + name)
+	}
+	return validate.Date(name, value, time.UTC)
+}
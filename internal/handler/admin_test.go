@@ -0,0 +1,374 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/task"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type mockCacheAdmin struct {
+	entries     map[string]*cache.CacheEntryInfo
+	invalidated []string
+	err         error
+}
+
+func (m *mockCacheAdmin) Inspect(_ context.Context, stationID string, date time.Time) (*cache.CacheEntryInfo, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	key := stationID + ":" + date.Format("2006-01-02")
+	if info, ok := m.entries[key]; ok {
+		return info, nil
+	}
+	return &cache.CacheEntryInfo{StationID: stationID, Date: date.Format("2006-01-02")}, nil
+}
+
+func (m *mockCacheAdmin) Invalidate(_ context.Context, stationID string, date time.Time) error {
+	if m.err != nil {
+		return m.err
+	}
+	key := stationID + ":" + date.Format("2006-01-02")
+	delete(m.entries, key)
+	m.invalidated = append(m.invalidated, key)
+	return nil
+}
+
+type mockWarmer struct {
+	warmFn func(ctx context.Context, stationID string, startDate, endDate time.Time) (int, error)
+}
+
+func (m *mockWarmer) WarmPredictions(ctx context.Context, stationID string, startDate, endDate time.Time) (int, error) {
+	return m.warmFn(ctx, stationID, startDate, endDate)
+}
+
+func adminRequest(method, path string, params map[string]string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		HTTPMethod:            method,
+		Path:                  path,
+		Headers:               map[string]string{"x-admin-key": "s3cret"},
+		QueryStringParameters: params,
+	}
+}
+
+func TestAdminHandler_Auth(t *testing.T) {
+	params := map[string]string{"stationId": "9447130", "date": "2024-01-01"}
+
+	t.Run("disabled without a key", func(t *testing.T) {
+		h := NewAdminHandler("", &mockCacheAdmin{}, nil)
+		response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodGet, "/admin/cache", params))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		h := NewAdminHandler("other", &mockCacheAdmin{}, nil)
+		response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodGet, "/admin/cache", params))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		h := NewAdminHandler("s3cret", &mockCacheAdmin{}, nil)
+		request := adminRequest(http.MethodGet, "/admin/cache", params)
+		request.Headers = nil
+		response, err := h.HandleRequest(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+}
+
+func TestAdminHandler_InspectAndInvalidate(t *testing.T) {
+	cacheAdmin := &mockCacheAdmin{
+		entries: map[string]*cache.CacheEntryInfo{
+			"9447130:2024-01-01": {
+				StationID: "9447130",
+				Date:      "2024-01-01",
+				StoreName: "dynamo",
+				Store:     &cache.TierEntryInfo{SizeBytes: 2048, Predictions: 240, Extremes: 4},
+			},
+		},
+	}
+	h := NewAdminHandler("s3cret", cacheAdmin, nil)
+	params := map[string]string{"stationId": "9447130", "date": "2024-01-01"}
+
+	response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodGet, "/admin/cache", params))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var body struct {
+		ResponseType string               `json:"responseType"`
+		Entry        cache.CacheEntryInfo `json:"entry"`
+		Note         string               `json:"note"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "cacheEntry", body.ResponseType)
+	require.NotNil(t, body.Entry.Store)
+	assert.Equal(t, int64(2048), body.Entry.Store.SizeBytes)
+
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodDelete, "/admin/cache", params))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"9447130:2024-01-01"}, cacheAdmin.invalidated)
+
+	body.Entry = cache.CacheEntryInfo{}
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "cacheInvalidated", body.ResponseType)
+	assert.Nil(t, body.Entry.Store)
+	assert.Contains(t, body.Note, "Other instances may keep serving their in-memory copy")
+}
+
+func TestAdminHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    events.APIGatewayProxyRequest
+		cacheErr   error
+		wantStatus int
+	}{
+		{
+			name:       "missing station",
+			request:    adminRequest(http.MethodGet, "/admin/cache", map[string]string{"date": "2024-01-01"}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid date",
+			request:    adminRequest(http.MethodGet, "/admin/cache", map[string]string{"stationId": "9447130", "date": "01/01/2024"}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported method",
+			request:    adminRequest(http.MethodPut, "/admin/cache", map[string]string{"stationId": "9447130", "date": "2024-01-01"}),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "warm requires POST",
+			request:    adminRequest(http.MethodGet, "/admin/cache/warm", map[string]string{"stationId": "9447130", "startDate": "2024-01-01"}),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "cache failure",
+			request:    adminRequest(http.MethodDelete, "/admin/cache", map[string]string{"stationId": "9447130", "date": "2024-01-01"}),
+			cacheErr:   errors.New("table unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAdminHandler("s3cret", &mockCacheAdmin{err: tt.cacheErr}, nil)
+			response, err := h.HandleRequest(context.Background(), tt.request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, response.StatusCode)
+		})
+	}
+}
+
+func TestAdminHandler_Warm(t *testing.T) {
+	var gotStart, gotEnd time.Time
+	warmer := &mockWarmer{
+		warmFn: func(_ context.Context, stationID string, startDate, endDate time.Time) (int, error) {
+			if stationID == "bad-range" {
+				return 0, tide.NewInvalidRangeError("date range cannot exceed 30 days")
+			}
+			gotStart, gotEnd = startDate, endDate
+			return int(endDate.Sub(startDate).Hours()/24) + 1, nil
+		},
+	}
+	h := NewAdminHandler("s3cret", &mockCacheAdmin{}, warmer)
+
+	response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/cache/warm",
+		map[string]string{"stationId": "9447130", "startDate": "2024-01-01", "endDate": "2024-01-07"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "2024-01-01", gotStart.Format("2006-01-02"))
+	assert.Equal(t, "2024-01-07", gotEnd.Format("2006-01-02"))
+	assert.JSONEq(t, `{"responseType":"cacheWarm","stationId":"9447130","days":7}`, response.Body)
+
+	// endDate defaults to startDate
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/cache/warm",
+		map[string]string{"stationId": "9447130", "startDate": "2024-01-01"}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"responseType":"cacheWarm","stationId":"9447130","days":1}`, response.Body)
+
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/cache/warm",
+		map[string]string{"stationId": "bad-range", "startDate": "2024-01-01"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+// recordingQueue keeps the tasks queued on it
+type recordingQueue struct {
+	tasks []any
+	err   error
+}
+
+func (q *recordingQueue) Enqueue(_ context.Context, _ string, payload any) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.tasks = append(q.tasks, payload)
+	return nil
+}
+
+func TestAdminHandler_WarmQueued(t *testing.T) {
+	warmer := &mockWarmer{warmFn: func(context.Context, string, time.Time, time.Time) (int, error) {
+		t.Fatal("warming is left to the worker")
+		return 0, nil
+	}}
+	queue := &recordingQueue{}
+	h := NewAdminHandler("s3cret", &mockCacheAdmin{}, warmer)
+	h.UseQueue(queue)
+
+	response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/cache/warm",
+		map[string]string{"stationId": "9447130", "startDate": "2024-01-01", "endDate": "2024-01-07"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, response.StatusCode, response.Body)
+	assert.JSONEq(t, `{"responseType":"cacheWarm","stationId":"9447130","days":7,"queued":true}`, response.Body)
+	assert.Equal(t, []any{task.WarmCache{StationID: "9447130", StartDate: "2024-01-01", EndDate: "2024-01-07"}}, queue.tasks)
+
+	for _, params := range []map[string]string{
+		{"stationId": "9447130", "startDate": "2024-01-07", "endDate": "2024-01-01"},
+		{"stationId": "9447130", "startDate": "2024-01-01", "endDate": "2024-02-01"},
+	} {
+		response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/cache/warm", params))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, params)
+	}
+	assert.Len(t, queue.tasks, 1, "ranges the worker would reject aren't queued")
+
+	queue.err = errors.New("throttled")
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/cache/warm",
+		map[string]string{"stationId": "9447130", "startDate": "2024-01-01"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+}
+
+type fakeMetricsStore struct {
+	totals map[time.Time]map[string]int64
+}
+
+func (s *fakeMetricsStore) Add(context.Context, time.Time, map[string]int64) error {
+	return errors.New("not implemented")
+}
+
+func (s *fakeMetricsStore) Load(_ context.Context, minutes []time.Time) ([]map[string]int64, error) {
+	totals := make([]map[string]int64, len(minutes))
+	for i, minute := range minutes {
+		totals[i] = s.totals[minute]
+	}
+	return totals, nil
+}
+
+func TestAdminHandler_Dashboard(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 30, 20, 0, time.UTC)
+	h := NewAdminHandler("s3cret", &mockCacheAdmin{}, nil)
+	h.now = func() time.Time { return now }
+
+	response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodGet, "/admin/dashboard", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode, "metrics aren't configured")
+
+	h.UseDashboard(&fakeMetricsStore{totals: map[time.Time]map[string]int64{
+		time.Date(2024, 1, 1, 12, 29, 0, 0, time.UTC): {"noaa|requests": 4, "noaa|errors": 1},
+		// The current minute is still being counted and a minute over an hour ago is out
+		time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC): {"noaa|requests": 100},
+		time.Date(2024, 1, 1, 11, 29, 0, 0, time.UTC): {"noaa|requests": 100},
+	}})
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodGet, "/admin/dashboard", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var body struct {
+		ResponseType string    `json:"responseType"`
+		From         time.Time `json:"from"`
+		To           time.Time `json:"to"`
+		Upstream     struct {
+			Requests  int64   `json:"requests"`
+			ErrorRate float64 `json:"errorRate"`
+		} `json:"upstream"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "dashboard", body.ResponseType)
+	assert.Equal(t, time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC), body.From)
+	assert.Equal(t, time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC), body.To)
+	assert.Equal(t, int64(4), body.Upstream.Requests)
+	assert.Equal(t, 0.25, body.Upstream.ErrorRate)
+
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPost, "/admin/dashboard", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+
+	request := adminRequest(http.MethodGet, "/admin/dashboard", nil)
+	request.Headers = nil
+	response, err = h.HandleRequest(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestAdminHandler_LogLevel(t *testing.T) {
+	level := zerolog.GlobalLevel()
+	t.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	h := NewAdminHandler("s3cret", &mockCacheAdmin{}, nil)
+
+	response, err := h.HandleRequest(context.Background(), adminRequest(http.MethodGet, "/admin/log-level", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.JSONEq(t, `{"responseType":"logLevel","level":"info","sampleRate":1}`, response.Body)
+
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPut, "/admin/log-level", map[string]string{"level": "debug"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode, "there's no remote configuration to write")
+
+	set := map[string]string{}
+	h.UseRemoteConfig(func(_ context.Context, name, value string) error {
+		set[name] = value
+		if name == "LOG_LEVEL" {
+			parsed, _ := zerolog.ParseLevel(value)
+			zerolog.SetGlobalLevel(parsed)
+		}
+		return nil
+	})
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPut, "/admin/log-level",
+		map[string]string{"level": "debug", "sampleRate": "10"}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug", "LOG_SAMPLE_RATE": "10"}, set)
+	assert.Contains(t, response.Body, `"level":"debug"`)
+
+	for _, params := range []map[string]string{
+		{},
+		{"level": "loud"},
+		{"level": ""},
+		{"sampleRate": "-1"},
+		{"sampleRate": "often"},
+	} {
+		response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPut, "/admin/log-level", params))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, params)
+	}
+
+	h.UseRemoteConfig(func(context.Context, string, string) error { return config.ErrNotWritable })
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPut, "/admin/log-level", map[string]string{"level": "warn"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+
+	h.UseRemoteConfig(func(context.Context, string, string) error { return errors.New("throttled") })
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodPut, "/admin/log-level", map[string]string{"level": "warn"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+
+	response, err = h.HandleRequest(context.Background(), adminRequest(http.MethodDelete, "/admin/log-level", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"net/http"
 	"strconv"
@@ -12,6 +13,7 @@ import (
 
 type StationsHandler struct {
 	stationFinder models.StationFinder
+	limits        models.StationLimits
 }
 
 func NewStationsHandler(finder models.StationFinder) *StationsHandler {
@@ -20,43 +22,120 @@ func NewStationsHandler(finder models.StationFinder) *StationsHandler {
 	}
 }
 
+// SetLimits replaces the default and largest nearest-station limits
+func (h *StationsHandler) SetLimits(limits models.StationLimits) {
+	h.limits = limits
+}
+
 func (h *StationsHandler) HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	params := request.QueryStringParameters
 
+	// The stations format is the same in every version so far, but for V3's envelope
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.Error(api.CodeUnsupportedVersion, err.Error(), http.StatusNotAcceptable)
+	}
+
+	unit, err := models.ParseDistanceUnit(params["distanceUnit"])
+	if err != nil {
+		return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	}
+
 	// Check if we're looking up by station ID or coordinates
 	if stationID, ok := params["stationId"]; ok {
-		stationLocal, err := h.stationFinder.FindStation(ctx, stationID)
-		if err != nil {
-			return api.Error("Error finding station", http.StatusInternalServerError)
+		stationLocal, err := models.FindStation(ctx, h.stationFinder, stationID)
+		if errors.Is(err, models.ErrStationNotFound) || (err == nil && stationLocal == nil) {
+			return api.StationNotFound("Station not found", err)
 		}
-		if stationLocal == nil {
-			return api.Error("Station not found", http.StatusNotFound)
+		if err != nil {
+			return api.Error(api.CodeInternal, "Error finding station", http.StatusInternalServerError)
 		}
-		return api.Success(api.NewStationsResponse([]models.Station{*stationLocal}))
+		models.AddSensorCapabilities(ctx, h.stationFinder, stationLocal)
+		stationLocal.Offsets = models.FindTideOffsets(ctx, h.stationFinder, *stationLocal)
+		return respond(ctx, version, request, api.NewStationsResponse(models.WithDistanceUnit([]models.Station{*stationLocal}, unit)))
 	}
 
 	// Parse coordinates
 	lat, lon, err := api.ParseCoordinates(params)
 	if err != nil {
-		var invalidCoordErr api.InvalidCoordinatesError
-		if errors.As(err, &invalidCoordErr) {
-			return api.Error(err.Error(), http.StatusBadRequest)
-		}
-		return api.Error("Invalid parameters", http.StatusBadRequest)
+		return api.Error(api.CodeInvalidCoordinates, err.Error(), http.StatusBadRequest)
 	}
 
-	// Default limit to 5 if not specified
-	limit := 5
+	var requested *int
 	if limitStr, ok := params["limit"]; ok {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil {
-			limit = parsedLimit
+		parsedLimit, err := validate.Integer("limit", limitStr)
+		if err != nil {
+			return api.ErrorFor(err)
 		}
+		requested = &parsedLimit
+	}
+	limit, err := h.limits.Resolve("limit", requested)
+	if err != nil {
+		return api.ErrorFor(err)
 	}
 
-	stations, err := h.stationFinder.FindNearestStations(ctx, lat, lon, limit)
+	offset := 0
+	if offsetStr, ok := params["offset"]; ok {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	filter := models.StationFilter{
+		StationType: params["stationType"],
+		Capability:  params["capability"],
+		Source:      models.Source(params["source"]),
+	}
+	if err := filter.Validate(); err != nil {
+		return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	}
+
+	page, err := h.stationFinder.FindNearestStationsPage(ctx, lat, lon, filter, offset, limit)
+	if err != nil {
+		return api.Error(api.CodeInternal, "Error finding stations", http.StatusInternalServerError)
+	}
+
+	stations := models.WithDistanceUnit(page.Stations, unit)
+	return respond(ctx, version, request, api.NewStationsPageResponse(stations, page, limit))
+}
+
+// HandleRegions lists the stations' states and regions, from finders that can group them
+func (h *StationsHandler) HandleRegions(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.Error(api.CodeUnsupportedVersion, err.Error(), http.StatusNotAcceptable)
+	}
+
+	finder, ok := h.stationFinder.(models.RegionFinder)
+	if !ok {
+		return api.Error(api.CodeInternal, "Stations can't be listed by region", http.StatusInternalServerError)
+	}
+	filter := models.StationFilter{
+		StationType: params["stationType"],
+		Capability:  params["capability"],
+		Source:      models.Source(params["source"]),
+	}
+	if err := filter.Validate(); err != nil {
+		return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	}
+
+	regions, err := finder.FindRegions(ctx, filter, params["state"])
 	if err != nil {
-		return api.Error("Error finding stations", http.StatusInternalServerError)
+		return api.Error(api.CodeInternal, "Error finding regions", http.StatusInternalServerError)
 	}
+	return api.VersionedSuccess(ctx, version, request.Path, api.NewRegionsResponse(regions))
+}
 
-	return api.Success(api.NewStationsResponse(stations))
+// respond answers with the stations as GeoJSON when the request asks for it, for mapping
+// libraries, or else JSON
+func respond(ctx context.Context, version api.Version, request events.APIGatewayProxyRequest, response *api.StationsResponse) (events.APIGatewayProxyResponse, error) {
+	for _, station := range response.Stations {
+		models.RecordSource(ctx, station.Source)
+	}
+	if api.WantsGeoJSON(request) {
+		return api.VersionedGeoJSON(version, request.Path, api.NewStationFeatureCollection(response))
+	}
+	return api.VersionedSuccess(ctx, version, request.Path, response)
 }
@@ -3,56 +3,17 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/api"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"testing"
 )
 
-// mockStationFinder implements models.StationFinder interface for testing
-type mockStationFinder struct {
-	findStationFn         func(ctx context.Context, stationID string) (*models.Station, error)
-	findNearestStationsFn func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error)
-}
-
-func (m *mockStationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
-	if m.findStationFn != nil {
-		return m.findStationFn(ctx, stationID)
-	}
-	return nil, nil
-}
-
-func (m *mockStationFinder) FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
-	if m.findNearestStationsFn != nil {
-		return m.findNearestStationsFn(ctx, lat, lon, limit)
-	}
-	return nil, nil
-}
-
-// Helper function to create test stations
-func createTestStation(id string) models.Station {
-	state := "WA"
-	region := "Puget Sound"
-	level := "R"
-	stationType := "R"
-	return models.Station{
-		ID:             id,
-		Name:           "Test Station " + id,
-		State:          &state,
-		Region:         &region,
-		Distance:       0,
-		Latitude:       47.6062,
-		Longitude:      -122.3321,
-		Source:         models.SourceNOAA,
-		Capabilities:   []string{"WATER_LEVEL"},
-		TimeZoneOffset: -8 * 3600,
-		Level:          &level,
-		StationType:    &stationType,
-	}
-}
-
 func TestStationsHandler_HandleRequest(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -70,9 +31,9 @@ func TestStationsHandler_HandleRequest(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
-						localStation := createTestStation(stationID)
+				return &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+						localStation := testsupport.Station(stationID)
 						return &localStation, nil
 					},
 				}
@@ -90,11 +51,11 @@ func TestStationsHandler_HandleRequest(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return &testsupport.StationFinder{
+					FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 						return []models.Station{
-							createTestStation("TEST001"),
-							createTestStation("TEST002"),
+							testsupport.Station("TEST001"),
+							testsupport.Station("TEST002"),
 						}, nil
 					},
 				}
@@ -149,7 +110,7 @@ func TestStationsHandler_ParameterValidation(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid coordinates",
+			expectedError:  `Invalid coordinates: invalid lat "91": must be between -90 and 90`,
 		},
 		{
 			name: "invalid longitude",
@@ -160,7 +121,7 @@ func TestStationsHandler_ParameterValidation(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid coordinates",
+			expectedError:  `Invalid coordinates: invalid lon "181": must be between -180 and 180`,
 		},
 		{
 			name: "non-numeric coordinates",
@@ -171,14 +132,26 @@ func TestStationsHandler_ParameterValidation(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "Invalid parameters",
+			expectedError:  `Invalid coordinates: invalid lat "invalid": must be a number`,
+		},
+		{
+			name: "unknown distance unit",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"lat":          "47.6062",
+					"lon":          "-122.3321",
+					"distanceUnit": "furlongs",
+				},
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `invalid distanceUnit "furlongs": must be one of km, mi, nmi`,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Create handler with empty mock
-			handler := NewStationsHandler(&mockStationFinder{})
+			handler := NewStationsHandler(&testsupport.StationFinder{})
 
 			// Call handler
 			response, err := handler.HandleRequest(context.Background(), tt.request)
@@ -199,6 +172,228 @@ func TestStationsHandler_ParameterValidation(t *testing.T) {
 	}
 }
 
+func TestStationsHandler_DistanceUnit(t *testing.T) {
+	bearing := 45.0
+	handler := NewStationsHandler(&testsupport.StationFinder{
+		FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+			station := testsupport.Station("TEST001")
+			station.Distance = 1.852
+			station.Bearing = &bearing
+			return []models.Station{station}, nil
+		},
+	})
+
+	response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"lat": "47.6", "lon": "-122.3", "distanceUnit": "nmi"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var body struct {
+		Stations []models.Station `json:"stations"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	require.Len(t, body.Stations, 1)
+	assert.InDelta(t, 1.0, body.Stations[0].Distance, 1e-9)
+	assert.Equal(t, models.DistanceNauticalMiles, body.Stations[0].DistanceUnit)
+	assert.Equal(t, &bearing, body.Stations[0].Bearing)
+}
+
+func TestStationsHandler_Pagination(t *testing.T) {
+	all := []models.Station{testsupport.Station("A"), testsupport.Station("B"), testsupport.Station("C")}
+	handler := NewStationsHandler(&testsupport.StationFinder{
+		FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+			return all, nil
+		},
+	})
+
+	tests := []struct {
+		name    string
+		offset  string
+		wantIDs []string
+		want    api.Pagination
+	}{
+		{name: "first page", offset: "", wantIDs: []string{"A", "B"}, want: api.Pagination{Offset: 0, Limit: 2, Total: 3, HasMore: true}},
+		{name: "last page", offset: "2", wantIDs: []string{"C"}, want: api.Pagination{Offset: 2, Limit: 2, Total: 3, HasMore: false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]string{"lat": "47.6", "lon": "-122.3", "limit": "2"}
+			if tt.offset != "" {
+				params["offset"] = tt.offset
+			}
+			response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: params})
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, response.StatusCode)
+
+			var body api.StationsResponse
+			require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+			var ids []string
+			for _, s := range body.Stations {
+				ids = append(ids, s.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			require.NotNil(t, body.Pagination)
+			assert.Equal(t, tt.want, *body.Pagination)
+		})
+	}
+}
+
+func TestStationsHandler_GeoJSON(t *testing.T) {
+	handler := NewStationsHandler(&testsupport.StationFinder{Stations: []models.Station{testsupport.Station("A"), testsupport.Station("B")}})
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		headers map[string]string
+	}{
+		{name: "format parameter", params: map[string]string{"format": "geojson"}},
+		{name: "accept header", headers: map[string]string{"accept": "application/geo+json;q=0.9, application/json;q=0.5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]string{"lat": "47.6", "lon": "-122.3", "limit": "1"}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: params, Headers: tt.headers})
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			assert.Equal(t, api.GeoJSONMediaType, response.Headers["Content-Type"])
+			assert.Equal(t, "1", response.Headers["API-Version"])
+
+			var body struct {
+				Type     string `json:"type"`
+				Features []struct {
+					Type     string `json:"type"`
+					ID       string `json:"id"`
+					Geometry struct {
+						Type        string     `json:"type"`
+						Coordinates [2]float64 `json:"coordinates"`
+					} `json:"geometry"`
+					Properties models.Station `json:"properties"`
+				} `json:"features"`
+				Pagination *api.Pagination `json:"pagination"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+			assert.Equal(t, "FeatureCollection", body.Type)
+			require.Len(t, body.Features, 1)
+			feature := body.Features[0]
+			assert.Equal(t, "Feature", feature.Type)
+			assert.Equal(t, "A", feature.ID)
+			assert.Equal(t, "Point", feature.Geometry.Type)
+			assert.Equal(t, [2]float64{-122.3321, 47.6062}, feature.Geometry.Coordinates, "longitude comes first")
+			assert.Equal(t, "Test Station A", feature.Properties.Name)
+			require.NotNil(t, body.Pagination)
+			assert.Equal(t, 1, body.Pagination.Limit)
+		})
+	}
+
+	response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"stationId": "B", "format": "geojson"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, api.GeoJSONMediaType, response.Headers["Content-Type"])
+	assert.Contains(t, response.Body, `"id":"B"`)
+	assert.NotContains(t, response.Body, `"pagination"`)
+}
+
+func TestStationsHandler_Stale(t *testing.T) {
+	finder := &testsupport.StationFinder{Stations: []models.Station{testsupport.Station("A")}}
+	handler := NewStationsHandler(finder)
+	params := map[string]string{"lat": "47.6", "lon": "-122.3"}
+
+	response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: params})
+	require.NoError(t, err)
+	assert.NotContains(t, response.Body, `"stale"`, "an up-to-date list isn't marked")
+
+	finder.Stale = true
+	response, err = handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: params})
+	require.NoError(t, err)
+	var body api.StationsResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.True(t, body.Stale)
+	assert.Len(t, body.Stations, 1)
+}
+
+func TestStationsHandler_Limits(t *testing.T) {
+	var limit int
+	handler := NewStationsHandler(&testsupport.StationFinder{
+		FindNearestStationsFn: func(ctx context.Context, lat, lon float64, l int) ([]models.Station, error) {
+			limit = l
+			return nil, nil
+		},
+	})
+	handler.SetLimits(models.StationLimits{Default: 3, Max: 10})
+
+	tests := []struct {
+		name       string
+		limit      string
+		wantStatus int
+		wantLimit  int
+		wantError  string
+	}{
+		{name: "configured default", wantStatus: http.StatusOK, wantLimit: 3},
+		{name: "at the cap", limit: "10", wantStatus: http.StatusOK, wantLimit: 10},
+		{name: "over the cap", limit: "11", wantStatus: http.StatusBadRequest, wantError: "must be at most 10"},
+		{name: "zero", limit: "0", wantStatus: http.StatusBadRequest, wantError: "must be at least 1"},
+		{name: "not a number", limit: "lots", wantStatus: http.StatusBadRequest, wantError: "must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit = 0
+			params := map[string]string{"lat": "47.6", "lon": "-122.3"}
+			if tt.limit != "" {
+				params["limit"] = tt.limit
+			}
+			response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{QueryStringParameters: params})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, response.StatusCode, response.Body)
+			if tt.wantStatus != http.StatusOK {
+				var body api.ValidationErrorResponse
+				require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+				require.Len(t, body.Details, 1)
+				assert.Equal(t, "limit", body.Details[0].Parameter)
+				assert.Equal(t, tt.wantError, body.Details[0].Message)
+				assert.Zero(t, limit, "the finder isn't asked")
+				return
+			}
+			assert.Equal(t, tt.wantLimit, limit)
+		})
+	}
+}
+
+func TestStationsHandler_Filters(t *testing.T) {
+	subordinate := testsupport.Station("SUB")
+	subordinate.StationType = models.StationKindSubordinate
+	handler := NewStationsHandler(&testsupport.StationFinder{
+		FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+			return []models.Station{subordinate, testsupport.Station("REF")}, nil
+		},
+	})
+
+	response, err := handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"lat": "47.6", "lon": "-122.3", "stationType": "R", "source": "NOAA"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	var body api.StationsResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	require.Len(t, body.Stations, 1)
+	assert.Equal(t, "REF", body.Stations[0].ID)
+	assert.Equal(t, 1, body.Pagination.Total)
+
+	response, err = handler.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		QueryStringParameters: map[string]string{"lat": "47.6", "lon": "-122.3", "stationType": "X"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
 func TestStationsHandler_ErrorHandling(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -206,6 +401,7 @@ func TestStationsHandler_ErrorHandling(t *testing.T) {
 		setupMock      func() models.StationFinder
 		expectedStatus int
 		expectedError  string
+		expectedCode   api.ErrorCode
 	}{
 		{
 			name: "station not found",
@@ -215,14 +411,33 @@ func TestStationsHandler_ErrorHandling(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
 						return nil, nil // Simulate station not found
 					},
 				}
 			},
 			expectedStatus: http.StatusNotFound,
 			expectedError:  "Station not found",
+			expectedCode:   api.CodeStationNotFound,
+		},
+		{
+			name: "finder reports an unknown station",
+			request: events.APIGatewayProxyRequest{
+				QueryStringParameters: map[string]string{
+					"stationId": "NONEXISTENT",
+				},
+			},
+			setupMock: func() models.StationFinder {
+				return &testsupport.StationFinder{
+					FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+						return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+					},
+				}
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedError:  "Station not found",
+			expectedCode:   api.CodeStationNotFound,
 		},
 		{
 			name: "internal server error during lookup",
@@ -233,14 +448,15 @@ func TestStationsHandler_ErrorHandling(t *testing.T) {
 				},
 			},
 			setupMock: func() models.StationFinder {
-				return &mockStationFinder{
-					findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return &testsupport.StationFinder{
+					FindNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
 						return nil, assert.AnError // Simulate internal error
 					},
 				}
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedError:  "Error finding stations",
+			expectedCode:   api.CodeInternal,
 		},
 	}
 
@@ -264,6 +480,77 @@ func TestStationsHandler_ErrorHandling(t *testing.T) {
 
 			assert.Equal(t, "error", responseBody["responseType"])
 			assert.Equal(t, tt.expectedError, responseBody["error"])
+			assert.Equal(t, string(tt.expectedCode), responseBody["code"])
 		})
 	}
 }
+
+func TestStationsHandler_Versions(t *testing.T) {
+	finder := &testsupport.StationFinder{
+		FindStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+			station := testsupport.Station(stationID)
+			return &station, nil
+		},
+	}
+	h := NewStationsHandler(finder)
+	params := map[string]string{"stationId": "9447130"}
+
+	response, err := h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/stations",
+		QueryStringParameters: params,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "1", response.Headers["API-Version"])
+	assert.Equal(t, "true", response.Headers["Deprecation"])
+
+	response, err = h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/stations",
+		QueryStringParameters: params,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, "2", response.Headers["API-Version"])
+
+	response, err = h.HandleRequest(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/stations",
+		Headers:               map[string]string{"Accept": "application/vnd.flowebb.v7+json"},
+		QueryStringParameters: params,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotAcceptable, response.StatusCode)
+}
+
+func TestStationsHandler_HandleRegions(t *testing.T) {
+	seattle, tacoma := testsupport.Station("9447130"), testsupport.Station("9446484")
+	tacoma.StationType = models.StationKindSubordinate
+	h := NewStationsHandler(&testsupport.StationFinder{Stations: []models.Station{tacoma, seattle}})
+
+	response, err := h.HandleRegions(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/regions",
+		QueryStringParameters: map[string]string{"state": "wa"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	var body api.RegionsResponse
+	require.NoError(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, "regions", body.ResponseType)
+	require.Len(t, body.Regions, 1)
+	assert.Equal(t, "Puget Sound", body.Regions[0].Region)
+	assert.Equal(t, 2, body.Regions[0].StationCount)
+	assert.Equal(t, "9447130", body.Regions[0].Representatives[0].ID)
+
+	response, err = h.HandleRegions(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/regions",
+		QueryStringParameters: map[string]string{"state": "ME"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, response.Body, `"regions":[]`)
+
+	response, err = h.HandleRegions(context.Background(), events.APIGatewayProxyRequest{
+		Path:                  "/api/v2/regions",
+		QueryStringParameters: map[string]string{"stationType": "X"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
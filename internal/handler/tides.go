@@ -0,0 +1,584 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/api"
+	"github.com/bbernstein/flowebb-go/internal/chart"
+	"github.com/bbernstein/flowebb-go/internal/export"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/offline"
+	"github.com/bbernstein/flowebb-go/internal/quota"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/internal/tidetable"
+	"github.com/bbernstein/flowebb-go/internal/timefmt"
+	"github.com/bbernstein/flowebb-go/internal/widget"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TidesHandler serves the tides, extremes, chart, table, compare, observation, accuracy,
+// widget, export, sync and usage endpoints
+type TidesHandler struct {
+	Service models.TideProvider
+	// Finder looks up the station metadata printed on tide tables
+	Finder  models.StationFinder
+	Widgets *widget.Service
+	Delta   *offline.Delta
+	// Exports is nil when no export bucket is configured
+	Exports *export.Service
+	// Quotas is nil unless a quota table is configured
+	Quotas *quota.Tracker
+}
+
+// HandleRequest routes a request to its endpoint by the end of its path. Every endpoint
+// but usage counts against the API key's quota.
+func (h *TidesHandler) HandleRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Asking what's been used doesn't use any of it
+	if strings.HasSuffix(request.Path, "/usage") {
+		return api.ValidateRequest(api.UsageOperation, h.getUsage)(ctx, request)
+	}
+	return h.Quotas.Wrap(h.serveMetered, api.ErrorFor)(ctx, request)
+}
+
+// serveMetered serves the endpoints counted against the API key's quota
+func (h *TidesHandler) serveMetered(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if strings.HasSuffix(request.Path, "/extremes/next") {
+		return api.ValidateRequest(api.NextExtremesOperation, h.getNextExtremes)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/extremes") {
+		return api.ValidateRequest(api.ExtremesOperation, h.getExtremes)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/daylight-lows") {
+		return api.ValidateRequest(api.DaylightLowsOperation, h.getDaylightLows)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/tides/table") {
+		return api.ValidateRequest(api.TableOperation, h.getTideTable)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/chart") {
+		return api.ValidateRequest(api.ChartOperation, h.getChart)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/compare") {
+		return api.ValidateRequest(api.CompareOperation, h.compareStations)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/observations") {
+		return api.ValidateRequest(api.ObservationOperation, h.getObservation)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/accuracy") {
+		return api.ValidateRequest(api.AccuracyOperation, h.getAccuracy)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/widget") {
+		return api.ValidateRequest(api.WidgetOperation, h.getWidget)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/oembed") {
+		return api.ValidateRequest(api.OEmbedOperation, h.getOEmbed)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/sync") {
+		return api.ValidateRequest(api.SyncOperation, h.getSync)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/exports/status") {
+		return api.ValidateRequest(api.ExportStatusOperation, h.getExportStatus)(ctx, request)
+	}
+	if strings.HasSuffix(request.Path, "/exports") {
+		return api.ValidateRequest(api.ExportOperation, h.getExport)(ctx, request)
+	}
+	return api.ValidateRequest(api.TidesOperation, api.SelectFields(h.getTides))(ctx, request)
+}
+
+func (h *TidesHandler) getTides(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling tides request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	ctx, startTimeStr, endTimeStr := requestRange(ctx, params)
+	if method, ok := params["interpolation"]; ok {
+		interpolator, err := tide.NewInterpolator(method)
+		if err != nil {
+			return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+		}
+		ctx = tide.WithInterpolator(ctx, interpolator)
+	}
+	// ValidateRequest has already checked it's a boolean
+	if includeWeather, _ := strconv.ParseBool(params["includeWeather"]); includeWeather {
+		ctx = tide.WithWeather(ctx)
+	}
+
+	var response *models.ExtendedTideResponse
+	var lat, lon float64
+
+	// Check if we're looking up by station ID or coordinates
+	if stationID, ok := params["stationId"]; ok {
+		response, err = h.Service.GetCurrentTideForStation(ctx, stationID, startTimeStr, endTimeStr)
+	} else if lat, lon, err = api.ParseCoordinates(params); err == nil {
+		response, err = h.Service.GetCurrentTide(ctx, lat, lon, startTimeStr, endTimeStr)
+	} else {
+		return api.Error(api.CodeInvalidRequest, "Missing required parameters", http.StatusBadRequest)
+	}
+
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	if str, ok := params["points"]; ok {
+		// ValidateRequest has already checked it's an integer in range
+		points, _ := strconv.Atoi(str)
+		if response.Predictions, err = tide.Downsample(response.Predictions, points); err != nil {
+			return api.ErrorFor(err)
+		}
+	}
+	timeFormat(params).Apply(response)
+
+	if version >= api.V2 {
+		return api.VersionedNegotiated(ctx, request, version, api.NewTideResponseV2(response))
+	}
+	return api.VersionedNegotiated(ctx, request, version, response)
+}
+
+func (h *TidesHandler) getExtremes(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling extremes request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	var startDate *string
+	if str, ok := params["startDate"]; ok {
+		startDate = &str
+	}
+	days := tide.DefaultExtremesDays
+	if str, ok := params["days"]; ok {
+		// ValidateRequest has already checked it's an integer in range
+		days, _ = strconv.Atoi(str)
+	}
+
+	summary, err := h.Service.GetDailyExtremes(ctx, params["stationId"], startDate, days)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, summary)
+}
+
+func (h *TidesHandler) getNextExtremes(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling next extremes request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	count := tide.DefaultNextExtremes
+	if str, ok := params["count"]; ok {
+		// ValidateRequest has already checked it's an integer in range
+		count, _ = strconv.Atoi(str)
+	}
+
+	next, err := h.Service.GetNextExtremes(ctx, params["stationId"], count)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, next)
+}
+
+func (h *TidesHandler) getDaylightLows(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling daylight lows request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	// ValidateRequest has already checked below and days are numbers in range
+	var below *float64
+	if str, ok := params["below"]; ok {
+		value, _ := strconv.ParseFloat(str, 64)
+		below = &value
+	}
+	var startDate *string
+	if str, ok := params["startDate"]; ok {
+		startDate = &str
+	}
+	days := tide.DefaultExtremesDays
+	if str, ok := params["days"]; ok {
+		days, _ = strconv.Atoi(str)
+	}
+
+	lows, err := h.Service.GetDaylightLows(ctx, params["stationId"], below, startDate, days)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, lows)
+}
+
+func (h *TidesHandler) getChart(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling chart request")
+
+	ctx, startTimeStr, endTimeStr := requestRange(ctx, params)
+	format := chart.FormatSVG
+	if str, ok := params["format"]; ok {
+		format = chart.Format(str)
+	}
+	// ValidateRequest has already checked these are integers in range
+	width, _ := strconv.Atoi(params["width"])
+	height, _ := strconv.Atoi(params["height"])
+
+	response, err := h.Service.GetCurrentTideForStation(ctx, params["stationId"], startTimeStr, endTimeStr)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	var title string
+	if response.Location != nil {
+		title = *response.Location
+	}
+	image, err := chart.Render(format, response.Predictions, response.Extremes, chart.Options{Width: width, Height: height, Title: title})
+	if err != nil {
+		log.Error().Err(err).Msg("Error rendering chart")
+		return api.Error(api.CodeRenderFailed, "Error rendering chart: "+err.Error(), http.StatusUnprocessableEntity)
+	}
+	return api.Image(format.ContentType(), image)
+}
+
+func (h *TidesHandler) getTideTable(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling tide table request")
+
+	station, err := models.FindStation(ctx, h.Finder, params["stationId"])
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+	if station == nil {
+		return api.ErrorFor(fmt.Errorf("%w: %s", models.ErrStationNotFound, params["stationId"]))
+	}
+	station.Offsets = models.FindTideOffsets(ctx, h.Finder, *station)
+
+	location := station.Location()
+	now := time.Now().In(location)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	if str, ok := params["month"]; ok {
+		// ValidateRequest has already checked it's a month
+		month, _ = time.ParseInLocation(tidetable.MonthLayout, str, location)
+	}
+	startDate := month.Format("2006-01-02")
+	days := month.AddDate(0, 1, -1).Day()
+
+	summary, err := h.Service.GetDailyExtremes(ctx, station.ID, &startDate, days)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	page, err := tidetable.Render(station, month, summary)
+	if err != nil {
+		log.Error().Err(err).Msg("Error rendering tide table")
+		return api.Error(api.CodeRenderFailed, "Error rendering tide table: "+err.Error(), http.StatusUnprocessableEntity)
+	}
+	return api.HTML(page)
+}
+
+func (h *TidesHandler) compareStations(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling compare request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	stationIDs := stationIDList(params["stationIds"])
+	ctx, startTimeStr, endTimeStr := requestRange(ctx, params)
+	interval := tide.DefaultCompareInterval
+	if str, ok := params["interval"]; ok {
+		// ValidateRequest has already checked it's an integer in range
+		interval, _ = strconv.Atoi(str)
+	}
+
+	comparison, err := h.Service.CompareStations(ctx, stationIDs, startTimeStr, endTimeStr, interval)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, comparison)
+}
+
+func (h *TidesHandler) getSync(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling sync request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	// ValidateRequest has already checked since and days are integers in range
+	var since time.Time
+	if ms, _ := strconv.ParseInt(params["since"], 10, 64); ms > 0 {
+		since = models.Millis(ms).Time()
+	}
+	days := offline.DefaultSyncDays
+	if str, ok := params["days"]; ok {
+		days, _ = strconv.Atoi(str)
+	}
+
+	sync, err := h.Delta.Sync(ctx, stationIDList(params["stationIds"]), since, days)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedNegotiated(ctx, request, version, sync)
+}
+
+func (h *TidesHandler) getObservation(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling observation request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	response, err := h.Service.GetLatestObservation(ctx, params["stationId"], params["product"])
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, response)
+}
+
+func (h *TidesHandler) getAccuracy(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling accuracy request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	days := accuracy.DefaultDays
+	if str, ok := params["days"]; ok {
+		// ValidateRequest has already checked it's an integer in range
+		days, _ = strconv.Atoi(str)
+	}
+
+	stats, err := h.Service.GetAccuracy(ctx, params["stationId"], days)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, stats)
+}
+
+func (h *TidesHandler) getExport(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling export request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+	if h.Exports == nil {
+		return api.Error(api.CodeForbidden, "Exports are not configured", http.StatusForbidden)
+	}
+
+	// ValidateRequest has already checked the kind and format are known and the year is an
+	// integer in range; the service checks they go together
+	kind := export.KindTable
+	if str, ok := params["kind"]; ok {
+		kind = export.Kind(str)
+	}
+	year, _ := strconv.Atoi(params["year"])
+	req := export.Request{
+		StationID: params["stationId"],
+		Year:      year,
+		StartDate: params["startDate"],
+		EndDate:   params["endDate"],
+		Format:    export.DefaultFormat(kind),
+	}
+	if kind != export.KindTable {
+		req.Kind = kind
+	}
+	if format, ok := params["format"]; ok {
+		req.Format = export.Format(format)
+	}
+
+	response, err := h.Exports.Request(ctx, req)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+	response.StatusURL = exportStatusURL(strings.TrimSuffix(request.Path, "/exports"), response.JobID)
+
+	return api.VersionedSuccess(ctx, version, request.Path, response)
+}
+
+func (h *TidesHandler) getExportStatus(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	log.Info().Msg("Handling export status request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+	if h.Exports == nil {
+		return api.Error(api.CodeForbidden, "Exports are not configured", http.StatusForbidden)
+	}
+
+	response, err := h.Exports.Status(ctx, request.QueryStringParameters["jobId"])
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+	response.StatusURL = exportStatusURL(strings.TrimSuffix(request.Path, "/exports/status"), response.JobID)
+
+	return api.VersionedSuccess(ctx, version, request.Path, response)
+}
+
+// exportStatusURL is the path a job's status is polled at, under base, the path the
+// request came in on less the endpoint, so a versioned request polls the same version
+func exportStatusURL(base, jobID string) string {
+	return base + "/exports/status?jobId=" + url.QueryEscape(jobID)
+}
+
+func (h *TidesHandler) getUsage(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	log.Info().Msg("Handling usage request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+	if h.Quotas == nil {
+		return api.Error(api.CodeForbidden, "Quotas are not configured", http.StatusForbidden)
+	}
+
+	month := time.Now()
+	if str, ok := request.QueryStringParameters["month"]; ok {
+		// ValidateRequest has already checked it's a YYYY-MM month
+		month, _ = quota.ParseMonth(str)
+	}
+
+	usage, err := h.Quotas.Usage(ctx, request, month)
+	if errors.Is(err, quota.ErrNoAPIKey) {
+		return api.Error(api.CodeUnauthenticated, "Usage is reported per API key; send one in the x-api-key header", http.StatusUnauthorized)
+	}
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	return api.VersionedSuccess(ctx, version, request.Path, api.NewUsageResponse(usage))
+}
+
+func (h *TidesHandler) getWidget(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling widget request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	theme := widget.Theme(params["theme"], params["accent"])
+	payload, err := h.Widgets.Widget(ctx, params["stationId"], theme)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	response, err := api.VersionedSuccess(ctx, version, request.Path, payload)
+	if response.StatusCode == http.StatusOK {
+		response.Headers["Cache-Control"] = cacheControl(widget.MaxAge)
+	}
+	return response, err
+}
+
+func (h *TidesHandler) getOEmbed(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	params := request.QueryStringParameters
+	log.Info().Msg("Handling oEmbed request")
+
+	version, err := api.NegotiateVersion(ctx, request)
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	// The oEmbed spec answers formats a provider doesn't implement with a 501, and URLs it
+	// doesn't embed with a 404
+	if params["format"] == "xml" {
+		return api.Error(api.CodeInvalidRequest, "Only the json format is implemented", http.StatusNotImplemented)
+	}
+	// ValidateRequest has already checked these are integers in range
+	maxWidth, _ := strconv.Atoi(params["maxwidth"])
+	maxHeight, _ := strconv.Atoi(params["maxheight"])
+
+	embed, err := h.Widgets.OEmbed(ctx, params["url"], maxWidth, maxHeight)
+	if errors.Is(err, widget.ErrNotEmbeddable) {
+		return api.Error(api.CodeInvalidRequest, err.Error(), http.StatusNotFound)
+	}
+	if err != nil {
+		return api.ErrorFor(err)
+	}
+
+	response, err := api.VersionedUnenveloped(version, request.Path, embed)
+	if response.StatusCode == http.StatusOK {
+		response.Headers["Cache-Control"] = cacheControl(widget.OEmbedMaxAge)
+	}
+	return response, err
+}
+
+// cacheControl lets browsers and CDNs keep a response for maxAge
+func cacheControl(maxAge time.Duration) string {
+	return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+}
+
+// requestRange reads startDateTime and endDateTime for the handlers over a range of time,
+// and applies tz to ctx
+func requestRange(ctx context.Context, params map[string]string) (context.Context, *string, *string) {
+	var startTimeStr, endTimeStr *string
+	if str, ok := params["startDateTime"]; ok {
+		startTimeStr = &str
+	}
+	if str, ok := params["endDateTime"]; ok {
+		endTimeStr = &str
+	}
+	// ValidateRequest has already checked it names a zone
+	if name, ok := params["tz"]; ok {
+		if location, err := time.LoadLocation(name); err == nil {
+			ctx = tide.WithTimeZone(ctx, location)
+		}
+	}
+	return ctx, startTimeStr, endTimeStr
+}
+
+// stationIDList splits a comma-separated stationIds parameter, skipping empty entries
+func stationIDList(value string) []string {
+	var stationIDs []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			stationIDs = append(stationIDs, id)
+		}
+	}
+	return stationIDs
+}
+
+// timeFormat reads the locale and hour12 parameters, which ValidateRequest has already
+// checked
+func timeFormat(params map[string]string) timefmt.Options {
+	var hour12 *bool
+	if str, ok := params["hour12"]; ok {
+		b, _ := strconv.ParseBool(str)
+		hour12 = &b
+	}
+	options, _ := timefmt.New(params["locale"], hour12)
+	return options
+}
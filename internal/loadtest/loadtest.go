@@ -0,0 +1,291 @@
+// Package loadtest drives the tide service, in process or through a running server, with a
+// realistic mix of stations and dates, and reports latency percentiles and cache hit rates
+// so regressions in the service layer show up before they reach production.
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Request is one tide lookup: a station's predictions for Days days from Date
+type Request struct {
+	StationID string
+	Date      time.Time
+	Days      int
+}
+
+// Target serves one request, returning an error when it fails
+type Target func(ctx context.Context, r Request) error
+
+// Mix describes the traffic to generate. Stations earlier in the list are requested more
+// often, the way a few popular harbors get most of the real traffic, and dates fall within
+// Span days from Start, so the same station/day pairs repeat and exercise the caches.
+type Mix struct {
+	Stations []string
+	Start    time.Time
+	Span     int
+	// MaxDays is the longest range requested; most requests are for a single day
+	MaxDays int
+}
+
+// Requests returns n requests drawn from the mix. The same seed always gives the same
+// requests, so runs can be compared with each other.
+func (m Mix) Requests(n int, seed int64) []Request {
+	if len(m.Stations) == 0 || n <= 0 {
+		return nil
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// Zipf-like weights: the i-th station is requested 1/(i+1) as often as the first
+	weights := make([]float64, len(m.Stations))
+	total := 0.0
+	for i := range m.Stations {
+		total += 1 / float64(i+1)
+		weights[i] = total
+	}
+
+	span := max(m.Span, 1)
+	maxDays := max(m.MaxDays, 1)
+	start := time.Date(m.Start.Year(), m.Start.Month(), m.Start.Day(), 0, 0, 0, 0, time.UTC)
+
+	requests := make([]Request, n)
+	for i := range requests {
+		station := sort.SearchFloat64s(weights, rng.Float64()*total)
+		days := 1
+		if maxDays > 1 && rng.Intn(4) == 0 {
+			days = 1 + rng.Intn(maxDays)
+		}
+		requests[i] = Request{
+			StationID: m.Stations[min(station, len(m.Stations)-1)],
+			Date:      start.AddDate(0, 0, rng.Intn(span)),
+			Days:      days,
+		}
+	}
+	return requests
+}
+
+// StatsSource reports cumulative cache hits and misses as *_hits and *_misses counters,
+// the way cache.LRUCacheService.GetCacheStats does
+type StatsSource interface {
+	GetCacheStats() map[string]uint64
+}
+
+// Options configures a run
+type Options struct {
+	// Workers is how many requests are in flight at once; zero means one
+	Workers int
+	// Stats, when set, is read before and after the run to report cache hit rates
+	Stats StatsSource
+}
+
+// Budget is the most each latency percentile may take; zero leaves a percentile unchecked
+type Budget struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+}
+
+// Report summarizes a run
+type Report struct {
+	Requests int
+	Errors   int
+	// FirstError is the first failure seen, to say why requests failed
+	FirstError error
+	Elapsed    time.Duration
+	P50        time.Duration
+	P95        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	// HitRates is the fraction of lookups each cache tier answered during the run, keyed by
+	// tier name (e.g. lru, dynamo). It's empty when no StatsSource was given.
+	HitRates map[string]float64
+}
+
+// Run sends every request to target from opts.Workers workers and reports how it went.
+// It stops early, with the requests sent so far, when ctx is done.
+func Run(ctx context.Context, target Target, requests []Request, opts Options) Report {
+	workers := max(opts.Workers, 1)
+	var before map[string]uint64
+	if opts.Stats != nil {
+		before = opts.Stats.GetCacheStats()
+	}
+
+	queue := make(chan Request)
+	latencies := make([]time.Duration, 0, len(requests))
+	report := Report{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	started := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range queue {
+				begin := time.Now()
+				err := target(ctx, r)
+				took := time.Since(begin)
+
+				mu.Lock()
+				latencies = append(latencies, took)
+				if err != nil {
+					report.Errors++
+					if report.FirstError == nil {
+						report.FirstError = err
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+send:
+	for _, r := range requests {
+		select {
+		case queue <- r:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(queue)
+	wg.Wait()
+	report.Elapsed = time.Since(started)
+
+	report.Requests = len(latencies)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	report.P50 = percentile(latencies, 50)
+	report.P95 = percentile(latencies, 95)
+	report.P99 = percentile(latencies, 99)
+	if len(latencies) > 0 {
+		report.Max = latencies[len(latencies)-1]
+	}
+	if opts.Stats != nil {
+		report.HitRates = hitRates(before, opts.Stats.GetCacheStats())
+	}
+	return report
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// hitRates works out each tier's hit rate from the change in its counters
+func hitRates(before, after map[string]uint64) map[string]float64 {
+	rates := map[string]float64{}
+	for key, hits := range after {
+		tier, ok := strings.CutSuffix(key, "_hits")
+		if !ok {
+			continue
+		}
+		hits -= before[key]
+		misses := after[tier+"_misses"] - before[tier+"_misses"]
+		if hits+misses > 0 {
+			rates[tier] = float64(hits) / float64(hits+misses)
+		}
+	}
+	return rates
+}
+
+// Exceeds lists the percentiles over budget, empty when the run was within it
+func (r Report) Exceeds(b Budget) []string {
+	var over []string
+	for _, check := range []struct {
+		name          string
+		took, allowed time.Duration
+	}{
+		{"p50", r.P50, b.P50},
+		{"p95", r.P95, b.P95},
+		{"p99", r.P99, b.P99},
+	} {
+		if check.allowed > 0 && check.took > check.allowed {
+			over = append(over, fmt.Sprintf("%s %v exceeds the %v budget", check.name, check.took, check.allowed))
+		}
+	}
+	return over
+}
+
+// Throughput is the requests completed per second
+func (r Report) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Elapsed.Seconds()
+}
+
+// Write prints the report for people
+func (r Report) Write(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "requests: %d in %v (%.1f/s), %d failed\n", r.Requests, r.Elapsed.Round(time.Millisecond), r.Throughput(), r.Errors)
+	if r.FirstError != nil {
+		_, _ = fmt.Fprintf(w, "first error: %v\n", r.FirstError)
+	}
+	_, _ = fmt.Fprintf(w, "latency: p50 %v  p95 %v  p99 %v  max %v\n", r.P50, r.P95, r.P99, r.Max)
+	tiers := make([]string, 0, len(r.HitRates))
+	for tier := range r.HitRates {
+		tiers = append(tiers, tier)
+	}
+	sort.Strings(tiers)
+	for _, tier := range tiers {
+		_, _ = fmt.Fprintf(w, "%s hit rate: %.1f%%\n", tier, 100*r.HitRates[tier])
+	}
+}
+
+// TideGetter is the part of tide.Service a run exercises in process
+type TideGetter interface {
+	GetCurrentTideForStation(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error)
+}
+
+// ServiceTarget sends requests straight to the service, skipping HTTP and API Gateway
+func ServiceTarget(service TideGetter) Target {
+	return func(ctx context.Context, r Request) error {
+		start, end := r.dateRange()
+		_, err := service.GetCurrentTideForStation(ctx, r.StationID, &start, &end)
+		return err
+	}
+}
+
+// HTTPTarget sends requests to the /api/tides endpoint of the server at baseURL, e.g. the
+// one scripts/gostart.sh runs on http://localhost:8080
+func HTTPTarget(baseURL string, httpClient *http.Client) Target {
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/api/tides"
+	return func(ctx context.Context, r Request) error {
+		start, end := r.dateRange()
+		query := url.Values{"stationId": {r.StationID}, "startDateTime": {start}, "endDateTime": {end}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s %s: status %d", r.StationID, start, resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// dateRange gives the request's range as startDateTime and endDateTime values. A date
+// alone runs through the end of that day.
+func (r Request) dateRange() (string, string) {
+	days := max(r.Days, 1)
+	return r.Date.Format("2006-01-02"), r.Date.AddDate(0, 0, days-1).Format("2006-01-02")
+}
@@ -0,0 +1,204 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStart = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func TestMixRequests(t *testing.T) {
+	t.Parallel()
+	mix := Mix{Stations: []string{"A", "B", "C", "D"}, Start: testStart, Span: 7, MaxDays: 3}
+
+	requests := mix.Requests(2000, 42)
+	require.Len(t, requests, 2000)
+	assert.Equal(t, requests, mix.Requests(2000, 42), "the same seed gives the same requests")
+	assert.NotEqual(t, requests, mix.Requests(2000, 43))
+
+	counts := map[string]int{}
+	for _, r := range requests {
+		counts[r.StationID]++
+		assert.False(t, r.Date.Before(testStart))
+		assert.True(t, r.Date.Before(testStart.AddDate(0, 0, 7)))
+		assert.True(t, r.Days >= 1 && r.Days <= 3, "days %d", r.Days)
+	}
+	assert.Greater(t, counts["A"], counts["B"])
+	assert.Greater(t, counts["B"], counts["D"])
+	assert.Positive(t, counts["D"])
+
+	assert.Empty(t, Mix{Start: testStart}.Requests(10, 1))
+	for _, r := range (Mix{Stations: []string{"A"}, Start: testStart}).Requests(20, 1) {
+		assert.Equal(t, Request{StationID: "A", Date: testStart, Days: 1}, r)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	t.Parallel()
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(sorted, 50))
+	assert.Equal(t, 95*time.Millisecond, percentile(sorted, 95))
+	assert.Equal(t, 99*time.Millisecond, percentile(sorted, 99))
+	assert.Equal(t, time.Millisecond, percentile(sorted[:1], 99))
+	assert.Zero(t, percentile(nil, 50))
+}
+
+type fakeStats struct{ stats map[string]uint64 }
+
+// GetCacheStats returns a copy, as the LRU cache does
+func (f *fakeStats) GetCacheStats() map[string]uint64 { return maps.Clone(f.stats) }
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	stats := &fakeStats{stats: map[string]uint64{"lru_hits": 10, "lru_misses": 10, "file_hits": 0, "file_misses": 0, "lru_bytes": 5}}
+	var calls atomic.Int64
+	target := func(ctx context.Context, r Request) error {
+		n := calls.Add(1)
+		if r.StationID == "bad" {
+			return errors.New("no such station")
+		}
+		// The LRU answers three lookups in four; the file store answers the rest
+		if n%4 == 0 {
+			stats.stats["lru_misses"]++
+			stats.stats["file_hits"]++
+		} else {
+			stats.stats["lru_hits"]++
+		}
+		return nil
+	}
+
+	requests := Mix{Stations: []string{"A", "B"}, Start: testStart, Span: 3}.Requests(100, 1)
+	requests = append(requests, Request{StationID: "bad", Date: testStart})
+	report := Run(context.Background(), target, requests, Options{Workers: 1, Stats: stats})
+
+	assert.Equal(t, 101, report.Requests)
+	assert.Equal(t, 1, report.Errors)
+	assert.EqualError(t, report.FirstError, "no such station")
+	assert.LessOrEqual(t, report.P50, report.P95)
+	assert.LessOrEqual(t, report.P99, report.Max)
+	assert.Equal(t, map[string]float64{"lru": 0.75, "file": 1}, report.HitRates)
+
+	var out bytes.Buffer
+	report.Write(&out)
+	assert.Contains(t, out.String(), "requests: 101 in ")
+	assert.Contains(t, out.String(), "1 failed")
+	assert.Contains(t, out.String(), "first error: no such station")
+	assert.Contains(t, out.String(), "file hit rate: 100.0%\nlru hit rate: 75.0%\n")
+
+	// Without stats no hit rates are reported
+	report = Run(context.Background(), func(context.Context, Request) error { return nil }, requests[:10], Options{Workers: 4})
+	assert.Equal(t, 10, report.Requests)
+	assert.Empty(t, report.HitRates)
+}
+
+func TestRun_StopsWhenCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int64
+	target := func(ctx context.Context, r Request) error {
+		if calls.Add(1) == 5 {
+			cancel()
+		}
+		return nil
+	}
+
+	report := Run(ctx, target, Mix{Stations: []string{"A"}, Start: testStart}.Requests(1000, 1), Options{Workers: 2})
+	assert.Less(t, report.Requests, 1000)
+	assert.Zero(t, report.Errors)
+}
+
+func TestReportExceeds(t *testing.T) {
+	t.Parallel()
+	report := Report{P50: 10 * time.Millisecond, P95: 80 * time.Millisecond, P99: 200 * time.Millisecond}
+
+	assert.Empty(t, report.Exceeds(Budget{}))
+	assert.Empty(t, report.Exceeds(Budget{P50: 10 * time.Millisecond, P95: 100 * time.Millisecond, P99: time.Second}))
+	assert.Equal(t, []string{
+		"p95 80ms exceeds the 50ms budget",
+		"p99 200ms exceeds the 100ms budget",
+	}, report.Exceeds(Budget{P50: 20 * time.Millisecond, P95: 50 * time.Millisecond, P99: 100 * time.Millisecond}))
+}
+
+func TestHTTPTarget(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tides", r.URL.Path)
+		if r.URL.Query().Get("stationId") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "2024-06-01", r.URL.Query().Get("startDateTime"))
+		assert.Equal(t, "2024-06-03", r.URL.Query().Get("endDateTime"))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	target := HTTPTarget(srv.URL+"/", srv.Client())
+	require.NoError(t, target(context.Background(), Request{StationID: "9447130", Date: testStart, Days: 3}))
+	assert.EqualError(t, target(context.Background(), Request{StationID: "missing", Date: testStart, Days: 3}),
+		"missing 2024-06-01: status 404")
+}
+
+// benchmarkService builds the tide service over the testsupport NOAA, with an LRU in front
+// of a file store the way it runs locally, in place of the in-memory cache
+func benchmarkService(b *testing.B) (*tide.Service, *cache.LRUCacheService) {
+	env := testsupport.New(b, testsupport.AnyStation())
+	env.NOAA.SetDelay(2 * time.Millisecond)
+
+	cacheConfig := config.GetCacheConfig()
+	cacheConfig.Backend = config.BackendFile
+	cacheConfig.FileCacheDir = b.TempDir()
+	predictionCache, err := cache.NewCacheService(context.Background(), cacheConfig)
+	require.NoError(b, err)
+	env.Service.PredictionCache = predictionCache
+	return env.Service, predictionCache
+}
+
+// BenchmarkServiceMix runs the default mix of stations and dates through the service in
+// process and reports the latency percentiles and LRU hit rate, so a regression in the
+// service layer shows up as a change in them:
+//
+//	go test ./internal/loadtest -run '^$' -bench ServiceMix -benchtime 2000x
+func BenchmarkServiceMix(b *testing.B) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.WarnLevel)
+	defer zerolog.SetGlobalLevel(level)
+
+	service, predictionCache := benchmarkService(b)
+
+	mix := Mix{
+		Stations: []string{"9414290", "8518750", "9447130", "8443970", "8723214", "9410170", "1612340", "8638610"},
+		Start:    testStart,
+		Span:     14,
+		MaxDays:  3,
+	}
+	requests := mix.Requests(b.N, 1)
+
+	b.ResetTimer()
+	report := Run(context.Background(), ServiceTarget(service), requests, Options{Workers: 8, Stats: predictionCache})
+	b.StopTimer()
+
+	require.Zero(b, report.Errors, "first error: %v", report.FirstError)
+	b.ReportMetric(float64(report.P50.Microseconds()), "p50-µs")
+	b.ReportMetric(float64(report.P95.Microseconds()), "p95-µs")
+	b.ReportMetric(float64(report.P99.Microseconds()), "p99-µs")
+	b.ReportMetric(100*report.HitRates["lru"], "lru-hit-%")
+}
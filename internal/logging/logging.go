@@ -0,0 +1,41 @@
+// Package logging samples the debug logs of hot paths, the ones logged for every day,
+// cache lookup or prediction of a request, so debug logging stays affordable when it's
+// turned on in production
+package logging
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// hot is the logger Hot returns once SetSampleRate has been called
+	hot        atomic.Pointer[zerolog.Logger]
+	sampleRate atomic.Int64
+)
+
+// SetSampleRate has Hot keep one in n debug events; events at other levels are all kept.
+// An n of 0 or 1 keeps every event. Call it again after replacing log.Logger.
+func SetSampleRate(n int) {
+	logger := log.Logger
+	if n > 1 {
+		logger = logger.Sample(zerolog.LevelSampler{DebugSampler: &zerolog.BasicSampler{N: uint32(n)}})
+	}
+	hot.Store(&logger)
+	sampleRate.Store(int64(max(n, 1)))
+}
+
+// SampleRate returns how many debug events on hot paths one is kept of
+func SampleRate() int {
+	return int(max(sampleRate.Load(), 1))
+}
+
+// Hot returns the logger for hot paths, sampled as SetSampleRate last said
+func Hot() *zerolog.Logger {
+	if logger := hot.Load(); logger != nil {
+		return logger
+	}
+	return &log.Logger
+}
@@ -0,0 +1,45 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSampleRate(t *testing.T) {
+	previous := log.Logger
+	level := zerolog.GlobalLevel()
+	t.Cleanup(func() {
+		log.Logger = previous
+		zerolog.SetGlobalLevel(level)
+		hot.Store(nil)
+		sampleRate.Store(0)
+	})
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	var out bytes.Buffer
+	log.Logger = zerolog.New(&out)
+
+	assert.Same(t, &log.Logger, Hot(), "nothing is sampled before a rate is set")
+	assert.Equal(t, 1, SampleRate())
+
+	SetSampleRate(5)
+	assert.Equal(t, 5, SampleRate())
+	for i := 0; i < 20; i++ {
+		Hot().Debug().Msg("day")
+	}
+	Hot().Warn().Msg("warning")
+	assert.Equal(t, 4, strings.Count(out.String(), `"day"`), "one in five debug events is kept")
+	assert.Equal(t, 1, strings.Count(out.String(), `"warning"`), "warnings aren't sampled")
+
+	out.Reset()
+	SetSampleRate(0)
+	assert.Equal(t, 1, SampleRate())
+	for i := 0; i < 20; i++ {
+		Hot().Debug().Msg("day")
+	}
+	assert.Equal(t, 20, strings.Count(out.String(), `"day"`))
+}
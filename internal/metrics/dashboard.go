@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DashboardWindow is how far back the dashboard reports
+const DashboardWindow = time.Hour
+
+// Dashboard sums up the counts of every instance over a window
+type Dashboard struct {
+	// From and To bound the window; To is the start of the current minute, which is still
+	// being counted
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Upstream Upstream  `json:"upstream"`
+	// Caches are by tier, e.g. lru or dynamo
+	Caches map[string]*CacheHealth `json:"caches"`
+	// Endpoints are by API Gateway resource path, or graphql's
+	Endpoints map[string]*EndpointHealth `json:"endpoints"`
+	// Tenants are the requests of each white-label tenant, by its ID
+	Tenants map[string]*TenantUsage `json:"tenants"`
+	// Panics are the recovered panics by fingerprint
+	Panics map[string]int64 `json:"panics"`
+}
+
+// Upstream is how NOAA's API answered
+type Upstream struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+}
+
+// CacheHealth is how often a cache tier had what was asked of it
+type CacheHealth struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hitRatio"`
+}
+
+// TenantUsage is how much a tenant's apps asked of the API, and how often it failed them
+type TenantUsage struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+}
+
+// EndpointHealth is how an endpoint answered. The latency percentiles are the upper bound
+// of the histogram bucket they fall in, so are accurate to that bucket.
+type EndpointHealth struct {
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+	P50Ms     int64   `json:"p50Ms"`
+	P90Ms     int64   `json:"p90Ms"`
+	P99Ms     int64   `json:"p99Ms"`
+
+	latency []int64
+}
+
+// LoadDashboard sums up the store's counts over the DashboardWindow before now
+func LoadDashboard(ctx context.Context, store Store, now time.Time) (*Dashboard, error) {
+	to := now.UTC().Truncate(time.Minute)
+	from := to.Add(-DashboardWindow)
+	var minutes []time.Time
+	for minute := from; minute.Before(to); minute = minute.Add(time.Minute) {
+		minutes = append(minutes, minute)
+	}
+	totals, err := store.Load(ctx, minutes)
+	if err != nil {
+		return nil, err
+	}
+	return Summarize(from, to, totals), nil
+}
+
+// Summarize sums up the totals of the minutes from up to to
+func Summarize(from, to time.Time, totals []map[string]int64) *Dashboard {
+	d := &Dashboard{
+		From:      from,
+		To:        to,
+		Caches:    map[string]*CacheHealth{},
+		Endpoints: map[string]*EndpointHealth{},
+		Tenants:   map[string]*TenantUsage{},
+		Panics:    map[string]int64{},
+	}
+	for _, counts := range totals {
+		for name, count := range counts {
+			d.add(strings.Split(name, separator), count)
+		}
+	}
+
+	d.Upstream.ErrorRate = ratio(d.Upstream.Errors, d.Upstream.Requests)
+	for _, c := range d.Caches {
+		c.HitRatio = ratio(c.Hits, c.Hits+c.Misses)
+	}
+	for _, u := range d.Tenants {
+		u.ErrorRate = ratio(u.Errors, u.Requests)
+	}
+	for _, e := range d.Endpoints {
+		e.ErrorRate = ratio(e.Errors, e.Requests)
+		e.P50Ms = percentile(e.latency, 0.5)
+		e.P90Ms = percentile(e.latency, 0.9)
+		e.P99Ms = percentile(e.latency, 0.99)
+	}
+	return d
+}
+
+// add adds a counter, named by its fields, to the dashboard. Counters it doesn't know
+// are ignored, so instances running newer code don't break older dashboards.
+func (d *Dashboard) add(fields []string, count int64) {
+	switch {
+	case len(fields) == 2 && fields[0] == "noaa":
+		switch fields[1] {
+		case "requests":
+			d.Upstream.Requests += count
+		case "errors":
+			d.Upstream.Errors += count
+		}
+	case len(fields) == 2 && fields[0] == "panic":
+		d.Panics[fields[1]] += count
+	case len(fields) == 3 && fields[0] == "cache":
+		c := d.Caches[fields[1]]
+		if c == nil {
+			c = &CacheHealth{}
+			d.Caches[fields[1]] = c
+		}
+		switch fields[2] {
+		case "hits":
+			c.Hits += count
+		case "misses":
+			c.Misses += count
+		}
+	case len(fields) == 3 && fields[0] == "tenant":
+		u := d.Tenants[fields[1]]
+		if u == nil {
+			u = &TenantUsage{}
+			d.Tenants[fields[1]] = u
+		}
+		switch fields[2] {
+		case "requests":
+			u.Requests += count
+		case "errors":
+			u.Errors += count
+		}
+	case len(fields) >= 3 && fields[0] == "endpoint":
+		e := d.Endpoints[fields[1]]
+		if e == nil {
+			e = &EndpointHealth{latency: make([]int64, len(latencyBounds)+1)}
+			d.Endpoints[fields[1]] = e
+		}
+		switch {
+		case len(fields) == 3 && fields[2] == "requests":
+			e.Requests += count
+		case len(fields) == 3 && fields[2] == "errors":
+			e.Errors += count
+		case len(fields) == 4 && fields[2] == "latency":
+			if bucket, err := strconv.Atoi(fields[3]); err == nil && bucket >= 0 && bucket < len(e.latency) {
+				e.latency[bucket] += count
+			}
+		}
+	}
+}
+
+func ratio(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
+
+// percentile returns the upper bound of the bucket the p-th latency falls in. Latencies
+// beyond the last bound are reported as that bound.
+func percentile(histogram []int64, p float64) int64 {
+	var total int64
+	for _, count := range histogram {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(p * float64(total)))
+	var seen int64
+	for i, count := range histogram {
+		seen += count
+		if seen >= rank {
+			return latencyBounds[min(i, len(latencyBounds)-1)]
+		}
+	}
+	return latencyBounds[len(latencyBounds)-1]
+}
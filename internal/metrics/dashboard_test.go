@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore adds counts up in memory
+type memoryStore struct {
+	totals map[time.Time]map[string]int64
+	err    error
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{totals: map[time.Time]map[string]int64{}}
+}
+
+func (s *memoryStore) Add(_ context.Context, minute time.Time, counts map[string]int64) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.totals[minute] == nil {
+		s.totals[minute] = map[string]int64{}
+	}
+	for name, count := range counts {
+		s.totals[minute][name] += count
+	}
+	return nil
+}
+
+func (s *memoryStore) Load(_ context.Context, minutes []time.Time) ([]map[string]int64, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	totals := make([]map[string]int64, len(minutes))
+	for i, minute := range minutes {
+		totals[i] = s.totals[minute]
+	}
+	return totals, nil
+}
+
+func TestSummarize(t *testing.T) {
+	from := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	d := Summarize(from, to, []map[string]int64{
+		{
+			"noaa|requests":              8,
+			"noaa|errors":                1,
+			"cache|lru|hits":             3,
+			"cache|lru|misses":           1,
+			"cache|dynamo|hits":          0,
+			"endpoint|/tides|requests":   98,
+			"endpoint|/tides|errors":     2,
+			"endpoint|/tides|latency|2":  50, // 25-50ms
+			"endpoint|/tides|latency|4":  40, // 100-250ms
+			"endpoint|/tides|latency|11": 8,  // slower than 30s
+			"endpoint|/tides|unknown":    5,
+			"something|new":              1,
+			"panic|0123456789ab":         2,
+			"tenant|acme|requests":       40,
+			"tenant|acme|errors":         4,
+		},
+		{"noaa|requests": 2},
+		nil,
+	})
+
+	assert.Equal(t, from, d.From)
+	assert.Equal(t, to, d.To)
+	assert.Equal(t, Upstream{Requests: 10, Errors: 1, ErrorRate: 0.1}, d.Upstream)
+	assert.Equal(t, map[string]*CacheHealth{
+		"lru":    {Hits: 3, Misses: 1, HitRatio: 0.75},
+		"dynamo": {},
+	}, d.Caches)
+	assert.Equal(t, map[string]int64{"0123456789ab": 2}, d.Panics)
+	assert.Equal(t, map[string]*TenantUsage{"acme": {Requests: 40, Errors: 4, ErrorRate: 0.1}}, d.Tenants)
+	require.Contains(t, d.Endpoints, "/tides")
+	tides := d.Endpoints["/tides"]
+	assert.Equal(t, int64(98), tides.Requests)
+	assert.Equal(t, 2.0/98, tides.ErrorRate)
+	assert.Equal(t, int64(50), tides.P50Ms)
+	assert.Equal(t, int64(250), tides.P90Ms)
+	assert.Equal(t, int64(30000), tides.P99Ms, "latencies past the last bound report it")
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	d := Summarize(time.Time{}, time.Time{}, nil)
+	assert.Zero(t, d.Upstream.ErrorRate)
+	assert.Empty(t, d.Caches)
+	assert.Empty(t, d.Endpoints)
+	assert.Empty(t, d.Tenants)
+	assert.Empty(t, d.Panics)
+}
+
+func TestLoadDashboard(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 30, 45, 0, time.UTC)
+	ctx := context.Background()
+	require.NoError(t, store.Add(ctx, time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC), map[string]int64{"noaa|requests": 1}))
+	require.NoError(t, store.Add(ctx, time.Date(2024, 1, 1, 12, 29, 0, 0, time.UTC), map[string]int64{"noaa|requests": 2}))
+	require.NoError(t, store.Add(ctx, time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC), map[string]int64{"noaa|requests": 4}))
+	require.NoError(t, store.Add(ctx, time.Date(2024, 1, 1, 11, 29, 0, 0, time.UTC), map[string]int64{"noaa|requests": 8}))
+
+	d, err := LoadDashboard(ctx, store, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), d.Upstream.Requests, "the last hour of whole minutes")
+	assert.Equal(t, time.Date(2024, 1, 1, 11, 30, 0, 0, time.UTC), d.From)
+
+	store.err = assert.AnError
+	_, err = LoadDashboard(ctx, store, now)
+	assert.ErrorIs(t, err, assert.AnError)
+}
@@ -0,0 +1,244 @@
+// Package metrics counts requests, errors, latencies, NOAA responses and cache hits per
+// minute in every Lambda instance and adds them up in a shared store, so the admin
+// dashboard can report on the last hour across all of them
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultFlushInterval is how often an instance adds its counts to the store. Counts are
+// also added as soon as their minute is over.
+const DefaultFlushInterval = 15 * time.Second
+
+// latencyBounds are the upper bounds, in milliseconds, of the latency histogram's buckets;
+// the last bucket holds everything slower
+var latencyBounds = []int64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+// Counter names are fields separated by |, the kind of counter first: noaa|requests,
+// cache|<tier>|hits, endpoint|<path>|latency|<bucket>, tenant|<id>|requests or
+// panic|<fingerprint>
+const separator = "|"
+
+func counterName(fields ...string) string {
+	return strings.Join(fields, separator)
+}
+
+// CacheStats reports cumulative cache hits and misses as *_hits and *_misses counters,
+// like cache.LRUCacheService
+type CacheStats interface {
+	GetCacheStats() map[string]uint64
+}
+
+// Handler is the signature of the Lambda handlers a Recorder observes. It is an alias so
+// handler types of other packages, like api.HandlerFunc, can be observed as they are.
+type Handler = func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Recorder counts what happens in one instance. A nil Recorder counts nothing, so
+// entrypoints can use one whether or not metrics are configured.
+type Recorder struct {
+	store    Store
+	interval time.Duration
+	now      func() time.Time
+
+	mu sync.Mutex
+	// counts are by the minute they were made in
+	counts  map[time.Time]map[string]int64
+	flushed time.Time
+	cache   CacheStats
+	// cacheSeen is what cache reported when its counts were last taken
+	cacheSeen map[string]uint64
+}
+
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{
+		store:    store,
+		interval: DefaultFlushInterval,
+		now:      time.Now,
+		counts:   make(map[time.Time]map[string]int64),
+	}
+}
+
+// UseCacheStats has the recorder count the cache hits and misses stats reports
+func (r *Recorder) UseCacheStats(stats CacheStats) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = stats
+	r.cacheSeen = stats.GetCacheStats()
+}
+
+// ObserveUpstream counts a NOAA request, which failed when there was no response or
+// NOAA answered with a server error or throttled it. Its signature is that of
+// client.Options.OnResponse.
+func (r *Recorder) ObserveUpstream(statusCode int, err error, _ time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := r.minuteCounts()
+	counts[counterName("noaa", "requests")]++
+	if err != nil || statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests {
+		counts[counterName("noaa", "errors")]++
+	}
+}
+
+// ObservePanic counts a recovered panic by its fingerprint. Its signature is that of
+// recovery.Tracker.OnPanic's function.
+func (r *Recorder) ObservePanic(fingerprint string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.minuteCounts()[counterName("panic", fingerprint)]++
+}
+
+// ObserveTenant counts a request of a tenant, which failed on the server's side or not.
+// Its signature is that of tenant.Registry.OnRequest's function.
+func (r *Recorder) ObserveTenant(tenant string, failed bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := r.minuteCounts()
+	counts[counterName("tenant", tenant, "requests")]++
+	if failed {
+		counts[counterName("tenant", tenant, "errors")]++
+	}
+}
+
+// Observe wraps next so each request it handles is counted against its endpoint, with its
+// latency and whether it failed on the server's side, and the counts are added to the
+// store once they're due
+func (r *Recorder) Observe(next Handler) Handler {
+	if r == nil {
+		return next
+	}
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		start := r.now()
+		response, err := next(ctx, request)
+		r.observeRequest(Endpoint(request), err != nil || response.StatusCode >= http.StatusInternalServerError, r.now().Sub(start))
+		r.flushIfDue(ctx)
+		return response, err
+	}
+}
+
+// Endpoint names the endpoint a request is for: API Gateway's resource path, which
+// leaves out path parameters, or else the request path
+func Endpoint(request events.APIGatewayProxyRequest) string {
+	if request.Resource != "" {
+		return request.Resource
+	}
+	return request.Path
+}
+
+func (r *Recorder) observeRequest(endpoint string, failed bool, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := r.minuteCounts()
+	counts[counterName("endpoint", endpoint, "requests")]++
+	if failed {
+		counts[counterName("endpoint", endpoint, "errors")]++
+	}
+	counts[counterName("endpoint", endpoint, "latency", strconv.Itoa(latencyBucket(latency)))]++
+}
+
+func latencyBucket(latency time.Duration) int {
+	ms := latency.Milliseconds()
+	for i, bound := range latencyBounds {
+		if ms <= bound {
+			return i
+		}
+	}
+	return len(latencyBounds)
+}
+
+// minuteCounts returns the counts of the current minute. r.mu must be held.
+func (r *Recorder) minuteCounts() map[string]int64 {
+	minute := r.now().UTC().Truncate(time.Minute)
+	counts, ok := r.counts[minute]
+	if !ok {
+		counts = make(map[string]int64)
+		r.counts[minute] = counts
+	}
+	return counts
+}
+
+// flushIfDue adds the counts to the store when the interval has passed since the last
+// flush or a minute with counts is over
+func (r *Recorder) flushIfDue(ctx context.Context) {
+	now := r.now()
+	minute := now.UTC().Truncate(time.Minute)
+	r.mu.Lock()
+	due := now.Sub(r.flushed) >= r.interval
+	for counted := range r.counts {
+		due = due || counted.Before(minute)
+	}
+	r.mu.Unlock()
+	if due {
+		r.Flush(ctx)
+	}
+}
+
+// Flush adds the counts to the store and starts over. Counts that can't be added are
+// dropped rather than kept growing.
+func (r *Recorder) Flush(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.addCacheCounts(r.minuteCounts())
+	pending := r.counts
+	r.counts = make(map[time.Time]map[string]int64)
+	r.flushed = r.now()
+	r.mu.Unlock()
+
+	for minute, counts := range pending {
+		if len(counts) == 0 {
+			continue
+		}
+		if err := r.store.Add(ctx, minute, counts); err != nil {
+			log.Warn().Err(err).Time("minute", minute).Msg("Failed to add metrics to the store")
+		}
+	}
+}
+
+// addCacheCounts adds the cache hits and misses since they were last taken to counts.
+// r.mu must be held.
+func (r *Recorder) addCacheCounts(counts map[string]int64) {
+	if r.cache == nil {
+		return
+	}
+	stats := r.cache.GetCacheStats()
+	for name, value := range stats {
+		tier, kind, ok := cacheCounter(name)
+		if !ok || value <= r.cacheSeen[name] {
+			continue
+		}
+		counts[counterName("cache", tier, kind)] += int64(value - r.cacheSeen[name])
+	}
+	r.cacheSeen = stats
+}
+
+// cacheCounter splits a cache stat like lru_hits into its tier and kind
+func cacheCounter(name string) (tier, kind string, ok bool) {
+	for _, kind := range []string{"hits", "misses"} {
+		if tier, found := strings.CutSuffix(name, "_"+kind); found {
+			return tier, kind, true
+		}
+	}
+	return "", "", false
+}
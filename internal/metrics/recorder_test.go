@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCacheStats map[string]uint64
+
+func (s fakeCacheStats) GetCacheStats() map[string]uint64 {
+	stats := make(map[string]uint64, len(s))
+	for name, value := range s {
+		stats[name] = value
+	}
+	return stats
+}
+
+// newTestRecorder returns a recorder whose clock is *now
+func newTestRecorder(store Store, now *time.Time) *Recorder {
+	r := NewRecorder(store)
+	r.now = func() time.Time { return *now }
+	return r
+}
+
+func TestRecorder_Observe(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 0, 10, 0, time.UTC)
+	r := newTestRecorder(store, &now)
+	r.interval = time.Hour
+	r.flushed = now
+
+	handler := r.Observe(func(_ context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		now = now.Add(120 * time.Millisecond)
+		switch request.Path {
+		case "/tides/fail":
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusBadGateway}, nil
+		case "/tides/error":
+			return events.APIGatewayProxyResponse{}, errors.New("boom")
+		case "/tides/invalid":
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusBadRequest}, nil
+		}
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	ctx := context.Background()
+	for _, path := range []string{"/tides", "/tides/fail", "/tides/error", "/tides/invalid"} {
+		_, _ = handler(ctx, events.APIGatewayProxyRequest{Resource: "/tides", Path: path})
+	}
+	r.ObserveUpstream(http.StatusOK, nil, time.Second)
+	r.ObserveUpstream(http.StatusServiceUnavailable, nil, time.Second)
+	r.ObserveUpstream(http.StatusTooManyRequests, nil, time.Second)
+	r.ObserveUpstream(0, errors.New("timeout"), time.Second)
+	r.ObserveUpstream(http.StatusNotFound, nil, time.Second)
+	r.ObservePanic("0123456789ab")
+	r.ObserveTenant("acme", false)
+	r.ObserveTenant("acme", true)
+	assert.Empty(t, store.totals, "nothing is due until the interval passes or the minute ends")
+
+	// The next minute's first request adds the last minute's counts
+	now = now.Add(time.Minute)
+	_, _ = handler(ctx, events.APIGatewayProxyRequest{Path: "/graphql"})
+	assert.Equal(t, map[time.Time]map[string]int64{
+		time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC): {
+			"endpoint|/tides|requests":  4,
+			"endpoint|/tides|errors":    2,
+			"endpoint|/tides|latency|4": 4,
+			"noaa|requests":             5,
+			"noaa|errors":               3,
+			"panic|0123456789ab":        1,
+			"tenant|acme|requests":      2,
+			"tenant|acme|errors":        1,
+		},
+		time.Date(2024, 1, 1, 12, 1, 0, 0, time.UTC): {
+			"endpoint|/graphql|requests":  1,
+			"endpoint|/graphql|latency|4": 1,
+		},
+	}, store.totals)
+}
+
+func TestRecorder_FlushInterval(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRecorder(store, &now)
+	handler := r.Observe(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	_, _ = handler(context.Background(), events.APIGatewayProxyRequest{Path: "/stations"})
+	require.Len(t, store.totals, 1, "the first request is flushed")
+	now = now.Add(DefaultFlushInterval / 2)
+	_, _ = handler(context.Background(), events.APIGatewayProxyRequest{Path: "/stations"})
+	assert.Equal(t, int64(1), store.totals[now.Truncate(time.Minute)]["endpoint|/stations|requests"])
+	now = now.Add(DefaultFlushInterval)
+	_, _ = handler(context.Background(), events.APIGatewayProxyRequest{Path: "/stations"})
+	assert.Equal(t, int64(3), store.totals[now.Truncate(time.Minute)]["endpoint|/stations|requests"])
+}
+
+func TestRecorder_CacheStats(t *testing.T) {
+	store := newMemoryStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRecorder(store, &now)
+	stats := fakeCacheStats{"lru_hits": 10, "lru_misses": 5, "dynamo_hits": 2, "lru_bytes": 1024}
+	r.UseCacheStats(stats)
+
+	stats["lru_hits"] = 13
+	stats["lru_misses"] = 6
+	r.Flush(context.Background())
+	assert.Equal(t, map[string]int64{"cache|lru|hits": 3, "cache|lru|misses": 1}, store.totals[now],
+		"only what changed since the stats were first taken is counted, and only hits and misses")
+
+	r.Flush(context.Background())
+	assert.Equal(t, map[string]int64{"cache|lru|hits": 3, "cache|lru|misses": 1}, store.totals[now])
+}
+
+func TestRecorder_StoreFailure(t *testing.T) {
+	store := newMemoryStore()
+	store.err = errors.New("throttled")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRecorder(store, &now)
+	r.ObserveUpstream(http.StatusOK, nil, time.Second)
+	r.Flush(context.Background())
+
+	store.err = nil
+	r.Flush(context.Background())
+	assert.Empty(t, store.totals, "counts that couldn't be added are dropped")
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var r *Recorder
+	r.UseCacheStats(fakeCacheStats{})
+	r.ObserveUpstream(http.StatusOK, nil, time.Second)
+	r.ObservePanic("0123456789ab")
+	r.ObserveTenant("acme", true)
+	r.Flush(context.Background())
+	handler := r.Observe(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusTeapot}, nil
+	})
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, response.StatusCode)
+}
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "/tides/{stationId}", Endpoint(events.APIGatewayProxyRequest{Resource: "/tides/{stationId}", Path: "/tides/9447130"}))
+	assert.Equal(t, "/graphql", Endpoint(events.APIGatewayProxyRequest{Path: "/graphql"}))
+}
@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+)
+
+// Retention is how long the store keeps a minute's counts: the dashboard's hour, and
+// another for late flushes and comparisons
+const Retention = 2 * time.Hour
+
+// minuteFormat keys a minute's counts
+const minuteFormat = "2006-01-02T15:04Z"
+
+// Store adds up the counts of every instance by minute
+type Store interface {
+	// Add adds counts to the minute's totals
+	Add(ctx context.Context, minute time.Time, counts map[string]int64) error
+	// Load returns the totals of each minute, empty for a minute without any
+	Load(ctx context.Context, minutes []time.Time) ([]map[string]int64, error)
+}
+
+// DynamoStore keeps each minute's totals as the number attributes of an item keyed by
+// minute, which every instance adds to atomically
+type DynamoStore struct {
+	client cache.DynamoDBClient
+	table  string
+}
+
+func NewDynamoStore(client cache.DynamoDBClient, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table}
+}
+
+func minuteKey(minute time.Time) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"minute": &types.AttributeValueMemberS{Value: minute.UTC().Format(minuteFormat)},
+	}
+}
+
+func (s *DynamoStore) Add(ctx context.Context, minute time.Time, counts map[string]int64) error {
+	names := map[string]string{"#ttl": "ttl"}
+	values := map[string]types.AttributeValue{
+		":ttl": &types.AttributeValueMemberN{Value: strconv.FormatInt(minute.Add(Retention).Unix(), 10)},
+	}
+	expression := "SET #ttl = :ttl ADD "
+	i := 0
+	for name, count := range counts {
+		if i > 0 {
+			expression += ", "
+		}
+		// Counter names hold characters expressions can't, so each goes by a placeholder
+		expression += fmt.Sprintf("#c%d :c%d", i, i)
+		names[fmt.Sprintf("#c%d", i)] = name
+		values[fmt.Sprintf(":c%d", i)] = &types.AttributeValueMemberN{Value: strconv.FormatInt(count, 10)}
+		i++
+	}
+
+	if _, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       minuteKey(minute),
+		UpdateExpression:          aws.String(expression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}); err != nil {
+		return fmt.Errorf("adding metrics in DynamoDB: %w", err)
+	}
+	return nil
+}
+
+// maxBatchGetKeys is the most keys BatchGetItem takes at once
+const maxBatchGetKeys = 100
+
+func (s *DynamoStore) Load(ctx context.Context, minutes []time.Time) ([]map[string]int64, error) {
+	byMinute := make(map[string]map[string]int64, len(minutes))
+	for start := 0; start < len(minutes); start += maxBatchGetKeys {
+		keys := make([]map[string]types.AttributeValue, 0, maxBatchGetKeys)
+		for _, minute := range minutes[start:min(start+maxBatchGetKeys, len(minutes))] {
+			keys = append(keys, minuteKey(minute))
+		}
+		request := map[string]types.KeysAndAttributes{s.table: {Keys: keys}}
+		// DynamoDB may leave keys unprocessed when throttled; ask again for those
+		for attempt := 0; len(request) > 0 && attempt < 3; attempt++ {
+			output, err := s.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: request})
+			if err != nil {
+				return nil, fmt.Errorf("loading metrics from DynamoDB: %w", err)
+			}
+			for _, item := range output.Responses[s.table] {
+				minute, counts := itemCounts(item)
+				byMinute[minute] = counts
+			}
+			request = output.UnprocessedKeys
+		}
+	}
+
+	totals := make([]map[string]int64, len(minutes))
+	for i, minute := range minutes {
+		totals[i] = byMinute[minute.UTC().Format(minuteFormat)]
+		if totals[i] == nil {
+			totals[i] = map[string]int64{}
+		}
+	}
+	return totals, nil
+}
+
+// itemCounts returns the minute an item is for and its counts, which are its number
+// attributes other than the TTL
+func itemCounts(item map[string]types.AttributeValue) (string, map[string]int64) {
+	var minute string
+	if key, ok := item["minute"].(*types.AttributeValueMemberS); ok {
+		minute = key.Value
+	}
+	counts := make(map[string]int64, len(item))
+	for name, value := range item {
+		number, ok := value.(*types.AttributeValueMemberN)
+		if !ok || name == "ttl" {
+			continue
+		}
+		if count, err := strconv.ParseInt(number.Value, 10, 64); err == nil {
+			counts[name] = count
+		}
+	}
+	return minute, counts
+}
+
+// MemoryStore keeps each minute's totals in memory, for a single long-running instance
+// such as the self-hosted server. Minutes older than Retention are dropped as counts are
+// added.
+type MemoryStore struct {
+	mu      sync.Mutex
+	minutes map[string]map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{minutes: map[string]map[string]int64{}}
+}
+
+func (s *MemoryStore) Add(_ context.Context, minute time.Time, counts map[string]int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := minute.UTC().Format(minuteFormat)
+	totals := s.minutes[key]
+	if totals == nil {
+		totals = map[string]int64{}
+		s.minutes[key] = totals
+	}
+	for name, count := range counts {
+		totals[name] += count
+	}
+
+	expired := minute.Add(-Retention).UTC().Format(minuteFormat)
+	for key := range s.minutes {
+		// The format sorts as the minutes do
+		if key < expired {
+			delete(s.minutes, key)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Load(_ context.Context, minutes []time.Time) ([]map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	totals := make([]map[string]int64, len(minutes))
+	for i, minute := range minutes {
+		totals[i] = map[string]int64{}
+		for name, count := range s.minutes[minute.UTC().Format(minuteFormat)] {
+			totals[i][name] = count
+		}
+	}
+	return totals, nil
+}
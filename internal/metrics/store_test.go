@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB applies the ADD of each update to items by minute, and leaves the first
+// unprocessed keys of a batch get unprocessed once
+type fakeDynamoDB struct {
+	cache.DynamoDBClient
+	items       map[string]map[string]types.AttributeValue
+	unprocessed int
+	batchGets   int
+	err         error
+}
+
+func (f *fakeDynamoDB) UpdateItem(_ context.Context, params *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	minute := params.Key["minute"].(*types.AttributeValueMemberS).Value
+	item := f.items[minute]
+	if item == nil {
+		item = map[string]types.AttributeValue{"minute": params.Key["minute"]}
+		f.items[minute] = item
+	}
+	item["ttl"] = params.ExpressionAttributeValues[":ttl"]
+	for placeholder, name := range params.ExpressionAttributeNames {
+		if placeholder == "#ttl" {
+			continue
+		}
+		value := params.ExpressionAttributeValues[":"+strings.TrimPrefix(placeholder, "#")].(*types.AttributeValueMemberN).Value
+		add, _ := strconv.ParseInt(value, 10, 64)
+		var total int64
+		if existing, ok := item[name].(*types.AttributeValueMemberN); ok {
+			total, _ = strconv.ParseInt(existing.Value, 10, 64)
+		}
+		item[name] = &types.AttributeValueMemberN{Value: strconv.FormatInt(total+add, 10)}
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchGetItem(_ context.Context, params *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.batchGets++
+	output := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+	for table, request := range params.RequestItems {
+		keys := request.Keys
+		if f.unprocessed > 0 {
+			n := min(f.unprocessed, len(keys))
+			output.UnprocessedKeys = map[string]types.KeysAndAttributes{table: {Keys: keys[:n]}}
+			keys = keys[n:]
+			f.unprocessed = 0
+		}
+		for _, key := range keys {
+			if item, ok := f.items[key["minute"].(*types.AttributeValueMemberS).Value]; ok {
+				output.Responses[table] = append(output.Responses[table], item)
+			}
+		}
+	}
+	return output, nil
+}
+
+func TestDynamoStore(t *testing.T) {
+	client := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}, unprocessed: 1}
+	store := NewDynamoStore(client, "metrics")
+	ctx := context.Background()
+	first := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	require.NoError(t, store.Add(ctx, first, map[string]int64{"noaa|requests": 2, "endpoint|/tides|latency|3": 1}))
+	require.NoError(t, store.Add(ctx, first, map[string]int64{"noaa|requests": 3}))
+	assert.Equal(t, &types.AttributeValueMemberN{Value: strconv.FormatInt(first.Add(Retention).Unix(), 10)},
+		client.items["2024-01-01T12:00Z"]["ttl"])
+
+	totals, err := store.Load(ctx, []time.Time{first, second})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]int64{
+		{"noaa|requests": 5, "endpoint|/tides|latency|3": 1},
+		{},
+	}, totals, "the TTL isn't a count and a minute without an item has none")
+	assert.Equal(t, 2, client.batchGets, "the unprocessed key was asked for again")
+
+	client.err = errors.New("throttled")
+	assert.ErrorContains(t, store.Add(ctx, second, map[string]int64{"noaa|requests": 1}), "throttled")
+	_, err = store.Load(ctx, []time.Time{first})
+	assert.ErrorContains(t, err, "throttled")
+}
+
+func TestDynamoStore_LoadBatches(t *testing.T) {
+	client := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+	store := NewDynamoStore(client, "metrics")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	minutes := make([]time.Time, 150)
+	for i := range minutes {
+		minutes[i] = start.Add(time.Duration(i) * time.Minute)
+	}
+	require.NoError(t, store.Add(context.Background(), minutes[120], map[string]int64{"noaa|errors": 1}))
+
+	totals, err := store.Load(context.Background(), minutes)
+	require.NoError(t, err)
+	require.Len(t, totals, 150)
+	assert.Equal(t, map[string]int64{"noaa|errors": 1}, totals[120])
+	assert.Equal(t, 2, client.batchGets, "BatchGetItem takes 100 keys at most")
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	first := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	require.NoError(t, store.Add(ctx, first, map[string]int64{"noaa|requests": 2}))
+	require.NoError(t, store.Add(ctx, first, map[string]int64{"noaa|requests": 3, "noaa|errors": 1}))
+	totals, err := store.Load(ctx, []time.Time{first, second})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]int64{{"noaa|requests": 5, "noaa|errors": 1}, {}}, totals)
+
+	require.NoError(t, store.Add(ctx, first.Add(Retention+time.Minute), map[string]int64{"noaa|requests": 1}))
+	totals, err = store.Load(ctx, []time.Time{first})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]int64{{}}, totals, "minutes past the retention are dropped")
+}
@@ -0,0 +1,35 @@
+package models
+
+// Confidence levels say how closely a station's recent predictions matched what its water
+// level gauge observed
+const (
+	ConfidenceHigh   = "HIGH"
+	ConfidenceMedium = "MEDIUM"
+	ConfidenceLow    = "LOW"
+)
+
+// AccuracyStats compare a station's predictions with its gauge's readings over the last
+// Days UTC days. Errors are observed minus predicted, in feet.
+type AccuracyStats struct {
+	ResponseType string `json:"responseType"`
+	StationID    string `json:"stationId"`
+	StationName  string `json:"stationName"`
+	Days         int    `json:"days"`
+	Samples      int    `json:"samples"`
+	// MeanAbsoluteError and Bias are nil without samples. A positive bias means the water
+	// ran higher than predicted.
+	MeanAbsoluteError *float64 `json:"meanAbsoluteError"`
+	Bias              *float64 `json:"bias"`
+	// Confidence is empty until there are enough samples to judge
+	Confidence string `json:"confidence,omitempty"`
+}
+
+// PredictionConfidence is how far a tide response's predictions can be trusted, judged
+// from its station's recent accuracy
+type PredictionConfidence struct {
+	Level             string  `json:"level"`
+	MeanAbsoluteError float64 `json:"meanAbsoluteError"`
+	Bias              float64 `json:"bias"`
+	Samples           int     `json:"samples"`
+	Days              int     `json:"days"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "github.com/bbernstein/flowebb-go/internal/api/validate"
+
+// DistanceUnit is the unit station distances are reported in
+type DistanceUnit string
+
+const (
+	DistanceKilometers    DistanceUnit = "km"
+	DistanceMiles         DistanceUnit = "mi"
+	DistanceNauticalMiles DistanceUnit = "nmi"
+)
+
+// DistanceUnits lists the supported units, default first
+var DistanceUnits = []DistanceUnit{DistanceKilometers, DistanceMiles, DistanceNauticalMiles}
+
+var kilometersPerUnit = map[DistanceUnit]float64{
+	DistanceKilometers:    1,
+	DistanceMiles:         1.609344,
+	DistanceNauticalMiles: 1.852,
+}
+
+// ParseDistanceUnit returns the unit named by s; "" means kilometers
+func ParseDistanceUnit(s string) (DistanceUnit, error) {
+	if s == "" {
+		return DistanceKilometers, nil
+	}
+	if err := validate.OneOf("distanceUnit", s, string(DistanceKilometers), string(DistanceMiles), string(DistanceNauticalMiles)); err != nil {
+		return "", err
+	}
+	return DistanceUnit(s), nil
+}
+
+// FromKilometers converts a distance in kilometers to u
+func (u DistanceUnit) FromKilometers(km float64) float64 {
+	return km / kilometersPerUnit[u]
+}
+
+// WithDistanceUnit returns copies of stations with Distance, which finders report in
+// kilometers, converted to unit
+func WithDistanceUnit(stations []Station, unit DistanceUnit) []Station {
+	result := make([]Station, len(stations))
+	for i, s := range stations {
+		s.Distance = unit.FromKilometers(s.Distance)
+		s.DistanceUnit = unit
+		result[i] = s
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDistanceUnit(t *testing.T) {
+	for input, want := range map[string]DistanceUnit{
+		"":    DistanceKilometers,
+		"km":  DistanceKilometers,
+		"mi":  DistanceMiles,
+		"nmi": DistanceNauticalMiles,
+	} {
+		unit, err := ParseDistanceUnit(input)
+		require.NoError(t, err)
+		assert.Equal(t, want, unit)
+	}
+
+	_, err := ParseDistanceUnit("MI")
+	assert.EqualError(t, err, `invalid distanceUnit "MI": must be one of km, mi, nmi (did you mean "mi"?)`)
+}
+
+func TestWithDistanceUnit(t *testing.T) {
+	stations := []Station{{ID: "A", Distance: 16.09344}, {ID: "B", Distance: 0}}
+
+	miles := WithDistanceUnit(stations, DistanceMiles)
+	assert.InDelta(t, 10, miles[0].Distance, 1e-9)
+	assert.Equal(t, DistanceMiles, miles[0].DistanceUnit)
+	assert.Zero(t, miles[1].Distance)
+	assert.Equal(t, 16.09344, stations[0].Distance, "the input is left in kilometers")
+
+	assert.InDelta(t, 5, DistanceNauticalMiles.FromKilometers(9.26), 1e-9)
+	assert.Equal(t, 9.26, DistanceKilometers.FromKilometers(9.26))
+}
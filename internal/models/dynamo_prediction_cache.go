@@ -9,7 +9,7 @@ import (
 type TidePredictionRecord struct {
 	StationID   string           `dynamodbav:"stationId"`
 	Date        string           `dynamodbav:"date"`
-	StationType string           `dynamodbav:"stationType"` // R for reference, S for subordinate
+	StationType StationKind      `dynamodbav:"stationType"` // R for reference, S for subordinate, V for virtual
 	Predictions []TidePrediction `dynamodbav:"predictions"`
 	Extremes    []TideExtreme    `dynamodbav:"extremes"`
 	LastUpdated int64            `dynamodbav:"lastUpdated"`
@@ -32,9 +32,9 @@ func (r *TidePredictionRecord) Validate() error {
 		return fmt.Errorf("invalid date format: %s", r.Date)
 	}
 
-	// Validate StationType (R for reference, S for subordinate)
+	// Validate StationType (R for reference, S for subordinate, V for virtual)
 	switch r.StationType {
-	case "R", "S":
+	case StationKindReference, StationKindSubordinate, StationKindVirtual:
 		// Valid type
 	default:
 		return fmt.Errorf("invalid station type: %s", r.StationType)
@@ -17,7 +17,7 @@ func TestTidePredictionRecord_Validate(t *testing.T) {
 			StationType: "R",
 			Predictions: []TidePrediction{
 				{
-					Timestamp: now.UnixMilli(),
+					Timestamp: MillisOf(now),
 					LocalTime: now.Format("2006-01-02T15:04:05"),
 					Height:    1.5,
 				},
@@ -25,7 +25,7 @@ func TestTidePredictionRecord_Validate(t *testing.T) {
 			Extremes: []TideExtreme{
 				{
 					Type:      TideTypeHigh,
-					Timestamp: now.UnixMilli(),
+					Timestamp: MillisOf(now),
 					LocalTime: now.Format("2006-01-02T15:04:05"),
 					Height:    2.0,
 				},
@@ -140,7 +140,7 @@ func BenchmarkTidePredictionRecord_Validate(b *testing.B) {
 	for i := range record.Predictions {
 		ts := now.Add(time.Duration(i) * time.Hour)
 		record.Predictions[i] = TidePrediction{
-			Timestamp: ts.UnixMilli(),
+			Timestamp: MillisOf(ts),
 			LocalTime: ts.Format("2006-01-02T15:04:05"),
 			Height:    float64(i) / 10,
 		}
@@ -150,7 +150,7 @@ func BenchmarkTidePredictionRecord_Validate(b *testing.B) {
 		ts := now.Add(time.Duration(i*6) * time.Hour)
 		record.Extremes[i] = TideExtreme{
 			Type:      TideTypeHigh,
-			Timestamp: ts.UnixMilli(),
+			Timestamp: MillisOf(ts),
 			LocalTime: ts.Format("2006-01-02T15:04:05"),
 			Height:    float64(i),
 		}
@@ -0,0 +1,31 @@
+package models
+
+// Export statuses
+const (
+	ExportPending  = "PENDING"
+	ExportComplete = "COMPLETE"
+	ExportFailed   = "FAILED"
+)
+
+// TideTableExport is the status of an export rendered in the background: a station's tide
+// table for a year as CSV or PDF, or its predictions as CSV or chart as an image from
+// StartDate through EndDate. JobID polls it through the export status endpoint.
+// DownloadURL and ExpiresAt are set once it's COMPLETE, and Error when it's FAILED.
+type TideTableExport struct {
+	ResponseType string `json:"responseType"`
+	JobID        string `json:"jobId"`
+	// Kind is table, predictions or chart
+	Kind      string `json:"kind"`
+	StationID string `json:"stationId"`
+	// Year is only set for tables, and StartDate and EndDate for the other kinds
+	Year      int     `json:"year,omitempty"`
+	StartDate *string `json:"startDate,omitempty"`
+	EndDate   *string `json:"endDate,omitempty"`
+	Format    string  `json:"format"`
+	Status    string  `json:"status"`
+	// StatusURL is the path the job's status can be polled at
+	StatusURL   string  `json:"statusUrl,omitempty"`
+	DownloadURL *string `json:"downloadUrl,omitempty"`
+	ExpiresAt   *Millis `json:"expiresAt,omitempty"`
+	Error       *string `json:"error,omitempty"`
+}
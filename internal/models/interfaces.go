@@ -1,8 +1,95 @@
 package models
 
-import "context"
+import (
+	"context"
+	"slices"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+)
 
 type StationFinder interface {
 	FindStation(ctx context.Context, stationID string) (*Station, error)
 	FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]Station, error)
+	// FindNearestStationsPage returns up to limit stations starting offset places into the
+	// list of stations that pass filter sorted by distance, along with how many there are
+	FindNearestStationsPage(ctx context.Context, lat, lon float64, filter StationFilter, offset, limit int) (*StationPage, error)
+}
+
+// BoundsFinder is implemented by station finders that can list the stations on a map,
+// for clients that show every station in view rather than those nearest a point
+type BoundsFinder interface {
+	// FindStationsInBounds returns the stations within bounds that pass filter, in the
+	// station list's order
+	FindStationsInBounds(ctx context.Context, bounds geo.Bounds, filter StationFilter) ([]Station, error)
+}
+
+// RegionFinder is implemented by station finders that can group their stations by area,
+// for clients that browse stations by state and region
+type RegionFinder interface {
+	// FindRegions groups the stations that pass filter like GroupRegions, only those in
+	// state when it's given
+	FindRegions(ctx context.Context, filter StationFilter, state string) ([]StationRegion, error)
+}
+
+// TideProvider answers tide queries for stations and places. tide.Service provides them
+// from NOAA through the prediction cache; handlers and resolvers depend on this interface
+// so another provider, or a fake in tests, can stand in for it.
+type TideProvider interface {
+	GetCurrentTide(ctx context.Context, lat, lon float64, startTimeStr, endTimeStr *string) (*ExtendedTideResponse, error)
+	GetCurrentTideForStation(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*ExtendedTideResponse, error)
+	GetDailyExtremes(ctx context.Context, stationID string, startDate *string, days int) (*ExtremesSummary, error)
+	GetDailyPredictions(ctx context.Context, stationID string, startDate *string, days int) (*DailyPredictions, error)
+	GetNextExtremes(ctx context.Context, stationID string, count int) (*NextExtremes, error)
+	GetTideExtremes(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*TideExtremes, error)
+	GetDaylightLows(ctx context.Context, stationID string, below *float64, startDate *string, days int) (*DaylightLows, error)
+	CompareStations(ctx context.Context, stationIDs []string, startTimeStr, endTimeStr *string, intervalMinutes int) (*StationComparison, error)
+	GetLatestObservation(ctx context.Context, stationID, product string) (*ObservationResponse, error)
+	GetAccuracy(ctx context.Context, stationID string, days int) (*AccuracyStats, error)
+}
+
+// SensorLister is implemented by station finders that can list a station's own sensors.
+// FindStation only reports what the station list knows; listing the sensors costs a request
+// per station, so it's left to the callers that show or need the full set.
+type SensorLister interface {
+	// SensorCapabilities returns the capabilities of the station's sensors, or nil when it
+	// has none of its own or they can't be listed
+	SensorCapabilities(ctx context.Context, stationID string) []string
+}
+
+// AddSensorCapabilities adds the capabilities of the station's own sensors to those it
+// has when finder can list them
+func AddSensorCapabilities(ctx context.Context, finder StationFinder, station *Station) {
+	lister, ok := finder.(SensorLister)
+	if !ok {
+		return
+	}
+	// The station may share its capabilities with a cached list, so they're copied
+	capabilities := append([]string{}, station.Capabilities...)
+	for _, c := range lister.SensorCapabilities(ctx, station.ID) {
+		if !slices.Contains(capabilities, c) {
+			capabilities = append(capabilities, c)
+		}
+	}
+	station.Capabilities = capabilities
+}
+
+// OffsetLister is implemented by station finders that can look up the offsets subordinate
+// stations are predicted with. Like a station's sensors, they cost a request per station.
+type OffsetLister interface {
+	// TideOffsets returns the station's offsets from its reference station, or nil when it
+	// has none or they can't be looked up
+	TideOffsets(ctx context.Context, stationID string) *TideOffsets
+}
+
+// FindTideOffsets returns a subordinate station's offsets when finder can look them up,
+// or nil
+func FindTideOffsets(ctx context.Context, finder StationFinder, station Station) *TideOffsets {
+	if station.Kind() != StationKindSubordinate {
+		return nil
+	}
+	lister, ok := finder.(OffsetLister)
+	if !ok {
+		return nil
+	}
+	return lister.TideOffsets(ctx, station.ID)
 }
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"context"
+	"sync"
+)
+
+// stationLookups are the stations one request has found, by ID. GraphQL resolves fields
+// concurrently, so they're guarded.
+type stationLookups struct {
+	mu       sync.Mutex
+	stations map[string]Station
+}
+
+type stationLookupsKey struct{}
+
+// WithStationLookups returns a context that remembers the stations FindStation finds
+// within it, so a request resolves each station once however many layers look it up
+func WithStationLookups(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(stationLookupsKey{}).(*stationLookups); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, stationLookupsKey{}, &stationLookups{stations: map[string]Station{}})
+}
+
+// RememberStation has ctx's request answer later lookups of the station's ID with it.
+// Distance and bearing are left out, since they're relative to where a search started.
+// It does nothing for a context without WithStationLookups.
+func RememberStation(ctx context.Context, station Station) {
+	lookups, ok := ctx.Value(stationLookupsKey{}).(*stationLookups)
+	if !ok {
+		return
+	}
+	station.Distance = 0
+	station.DistanceUnit = ""
+	station.Bearing = nil
+	lookups.mu.Lock()
+	defer lookups.mu.Unlock()
+	lookups.stations[station.ID] = station
+}
+
+// FindStation looks up a station with finder, once per request when ctx was made
+// WithStationLookups. Each call gets its own copy, which it may change.
+func FindStation(ctx context.Context, finder StationFinder, stationID string) (*Station, error) {
+	lookups, ok := ctx.Value(stationLookupsKey{}).(*stationLookups)
+	if !ok {
+		return finder.FindStation(ctx, stationID)
+	}
+	lookups.mu.Lock()
+	remembered, ok := lookups.stations[stationID]
+	lookups.mu.Unlock()
+	if ok {
+		return &remembered, nil
+	}
+
+	station, err := finder.FindStation(ctx, stationID)
+	if err != nil || station == nil {
+		return station, err
+	}
+	RememberStation(ctx, *station)
+	return station, nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFinder finds any station, counting the lookups
+type countingFinder struct {
+	StationFinder
+	lookups int
+}
+
+func (f *countingFinder) FindStation(_ context.Context, stationID string) (*Station, error) {
+	f.lookups++
+	if stationID == "missing" {
+		return nil, fmt.Errorf("%w: %s", ErrStationNotFound, stationID)
+	}
+	return &Station{ID: stationID, Name: "Station " + stationID}, nil
+}
+
+func TestFindStation(t *testing.T) {
+	finder := &countingFinder{}
+	ctx := WithStationLookups(context.Background())
+
+	first, err := FindStation(ctx, finder, "9447130")
+	require.NoError(t, err)
+	first.Offsets = &TideOffsets{ReferenceStationID: "9444900"}
+	second, err := FindStation(WithStationLookups(ctx), finder, "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, 1, finder.lookups, "the request looks the station up once")
+	assert.Nil(t, second.Offsets, "each caller gets its own copy")
+
+	// Failures aren't remembered
+	_, err = FindStation(ctx, finder, "missing")
+	assert.ErrorIs(t, err, ErrStationNotFound)
+	_, err = FindStation(ctx, finder, "missing")
+	assert.ErrorIs(t, err, ErrStationNotFound)
+	assert.Equal(t, 3, finder.lookups)
+
+	// Outside a request every lookup goes to the finder
+	_, err = FindStation(context.Background(), finder, "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, 4, finder.lookups)
+}
+
+func TestRememberStation(t *testing.T) {
+	finder := &countingFinder{}
+	ctx := WithStationLookups(context.Background())
+	bearing := 90.0
+	RememberStation(ctx, Station{ID: "9447130", Name: "Seattle", Distance: 12.5, DistanceUnit: DistanceKilometers, Bearing: &bearing})
+
+	station, err := FindStation(ctx, finder, "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, 0, finder.lookups)
+	assert.Equal(t, "Seattle", station.Name)
+	assert.Zero(t, station.Distance, "distance is relative to a search")
+	assert.Nil(t, station.Bearing)
+
+	// A context without lookups remembers nothing
+	RememberStation(context.Background(), Station{ID: "9414290"})
+	_, err = FindStation(ctx, finder, "9414290")
+	require.NoError(t, err)
+	assert.Equal(t, 1, finder.lookups)
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Millis is a Unix time in milliseconds, the unit of every timestamp in responses and
+// cached predictions. Converting through MillisOf and its methods, rather than
+// multiplying or dividing by 1000, keeps seconds from being mistaken for milliseconds.
+type Millis int64
+
+// MillisOf returns t's Unix time in milliseconds
+func MillisOf(t time.Time) Millis {
+	return Millis(t.UnixMilli())
+}
+
+// Time returns m in UTC
+func (m Millis) Time() time.Time {
+	return time.UnixMilli(int64(m)).UTC()
+}
+
+// In returns m in location
+func (m Millis) In(location *time.Location) time.Time {
+	return time.UnixMilli(int64(m)).In(location)
+}
+
+// Add returns m moved by d, truncated to the millisecond
+func (m Millis) Add(d time.Duration) Millis {
+	return m + Millis(d.Milliseconds())
+}
+
+// Sub returns the duration m-u
+func (m Millis) Sub(u Millis) time.Duration {
+	return time.Duration(m-u) * time.Millisecond
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMillis(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 500*int(time.Millisecond), location)
+
+	m := MillisOf(noon)
+	assert.Equal(t, Millis(1704139200500), m)
+	assert.True(t, noon.Equal(m.Time()))
+	assert.Equal(t, time.UTC, m.Time().Location())
+	assert.Equal(t, "2024-01-01T12:00:00", m.In(location).Format("2006-01-02T15:04:05"))
+
+	later := m.Add(6 * time.Minute)
+	assert.Equal(t, Millis(1704139560500), later)
+	assert.Equal(t, 6*time.Minute, later.Sub(m))
+	assert.Equal(t, -6*time.Minute, m.Sub(later))
+	// Anything finer than a millisecond is dropped
+	assert.Equal(t, m, m.Add(999*time.Microsecond))
+}
+
+func TestMillisJSON(t *testing.T) {
+	body, err := json.Marshal(TidePrediction{Timestamp: 1704139200000, Height: 1.5})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"timestamp":1704139200000,"localTime":"","height":1.5}`, string(body))
+}
@@ -0,0 +1,364 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noaaTimeLayout is the format NOAA uses for times in the station's local time zone
+const noaaTimeLayout = "2006-01-02 15:04"
+
+// maxPlausibleHeight bounds predicted water levels, in feet from the datum. The largest
+// tides anywhere, in the Bay of Fundy, range about 53 feet; anything beyond this is bad data.
+const maxPlausibleHeight = 100.0
+
+// NoaaTime is a NOAA wall-clock time. NOAA reports times without a zone, so they're
+// decoded once into their fields and placed in the station's zone with In.
+type NoaaTime struct {
+	Year   int
+	Month  time.Month
+	Day    int
+	Hour   int
+	Minute int
+}
+
+// In returns the time in loc
+func (t NoaaTime) In(loc *time.Location) time.Time {
+	return time.Date(t.Year, t.Month, t.Day, t.Hour, t.Minute, 0, 0, loc)
+}
+
+func (t NoaaTime) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d %02d:%02d", t.Year, t.Month, t.Day, t.Hour, t.Minute)
+}
+
+// ParseNoaaTime parses a time in NOAA's "2006-01-02 15:04" format
+func ParseNoaaTime(value []byte) (NoaaTime, error) {
+	if len(value) == len(noaaTimeLayout) && value[4] == '-' && value[7] == '-' && value[10] == ' ' && value[13] == ':' {
+		year, ok1 := atoi(value[0:4])
+		month, ok2 := atoi(value[5:7])
+		day, ok3 := atoi(value[8:10])
+		hour, ok4 := atoi(value[11:13])
+		minute, ok5 := atoi(value[14:16])
+		if ok1 && ok2 && ok3 && ok4 && ok5 &&
+			month >= 1 && month <= 12 && day >= 1 && day <= daysIn(time.Month(month), year) &&
+			hour <= 23 && minute <= 59 {
+			return NoaaTime{Year: year, Month: time.Month(month), Day: day, Hour: hour, Minute: minute}, nil
+		}
+	}
+
+	// Let the time package produce the error
+	_, err := time.Parse(noaaTimeLayout, string(value))
+	if err == nil {
+		err = fmt.Errorf("unexpected time format")
+	}
+	return NoaaTime{}, fmt.Errorf("parsing time %s: %w", value, err)
+}
+
+// NoaaPrediction represents the raw NOAA API prediction response. Time and height are
+// parsed while decoding, so callers don't parse NOAA's strings a second time.
+type NoaaPrediction struct {
+	Time   NoaaTime // Time of prediction, in the station's local time
+	Height float64  // Predicted water level
+	Type   string   // Type of prediction (H for high, L for low), empty for 6-minute predictions
+}
+
+// UnmarshalJSON decodes a {"t":"...","v":"...","type":"..."} object. NOAA's objects are
+// flat and string-valued, so they're scanned directly; anything else goes through
+// encoding/json.
+func (p *NoaaPrediction) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if handled, err := p.scan(data); handled {
+		return err
+	}
+
+	var raw struct {
+		Time   string `json:"t"`
+		Height string `json:"v"`
+		Type   string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*p = NoaaPrediction{}
+	for _, field := range []struct{ key, value string }{{"t", raw.Time}, {"v", raw.Height}, {"type", raw.Type}} {
+		if err := p.setField([]byte(field.key), []byte(field.value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan decodes a flat object whose values are all unescaped strings. It reports
+// handled=false, without an error, when data needs the general decoder.
+func (p *NoaaPrediction) scan(data []byte) (handled bool, err error) {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return false, nil
+	}
+	*p = NoaaPrediction{}
+	var seenTime, seenHeight bool
+
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return false, nil
+	}
+	for {
+		key, next, ok := scanString(data, i)
+		if !ok {
+			return false, nil
+		}
+		i = skipSpace(data, next)
+		if i >= len(data) || data[i] != ':' {
+			return false, nil
+		}
+		value, next, ok := scanString(data, skipSpace(data, i+1))
+		if !ok {
+			return false, nil
+		}
+
+		switch string(key) {
+		case "t":
+			seenTime = true
+		case "v":
+			seenHeight = true
+		}
+		if err := p.setField(key, value); err != nil {
+			return true, err
+		}
+
+		i = skipSpace(data, next)
+		if i >= len(data) {
+			return false, nil
+		}
+		if data[i] == '}' {
+			if skipSpace(data, i+1) != len(data) {
+				return false, nil
+			}
+			break
+		}
+		if data[i] != ',' {
+			return false, nil
+		}
+		i = skipSpace(data, i+1)
+	}
+
+	if !seenTime || !seenHeight {
+		// Leave missing fields to the general decoder so they fail the same way
+		return false, nil
+	}
+	return true, nil
+}
+
+func (p *NoaaPrediction) setField(key, value []byte) error {
+	switch string(key) {
+	case "t":
+		t, err := ParseNoaaTime(value)
+		if err != nil {
+			return err
+		}
+		p.Time = t
+	case "v":
+		height, err := strconv.ParseFloat(string(value), 64)
+		if err != nil {
+			return fmt.Errorf("parsing height %s: %w", value, err)
+		}
+		if math.IsNaN(height) || math.Abs(height) > maxPlausibleHeight {
+			return fmt.Errorf("parsing height %s: not a plausible water level", value)
+		}
+		p.Height = height
+	case "type":
+		p.Type = string(value)
+	}
+	return nil
+}
+
+// ErrNoaaEmptyResponse is returned for a NOAA response holding neither predictions nor an
+// error, which NOAA sends in place of either when it changes shape
+var ErrNoaaEmptyResponse = errors.New("response has neither predictions nor an error")
+
+// NoaaError is the error NOAA reports in place of data, often with a 200 status
+type NoaaError struct {
+	Message string `json:"message"`
+}
+
+func (e *NoaaError) Error() string {
+	if e.Message == "" {
+		return "NOAA reported an error without a message"
+	}
+	return e.Message
+}
+
+type NoaaResponse struct {
+	Predictions []NoaaPrediction `json:"predictions"`
+	Error       *NoaaError       `json:"error,omitempty"`
+	// Skipped counts the predictions left out of Predictions because their time or height
+	// couldn't be read, and SkipReason says what was wrong with the first of them
+	Skipped    int   `json:"-"`
+	SkipReason error `json:"-"`
+	// Unrecognized names the first field the response has that the decoder doesn't read,
+	// e.g. one NOAA added. Decoding ignores it; callers decide whether it matters.
+	Unrecognized error `json:"-"`
+}
+
+// lenientPrediction decodes a prediction without failing the response it's in, keeping
+// the error so the prediction can be left out instead
+type lenientPrediction struct {
+	NoaaPrediction
+	err error
+}
+
+func (p *lenientPrediction) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		p.err = fmt.Errorf("null prediction")
+		return nil
+	}
+	p.err = p.NoaaPrediction.UnmarshalJSON(data)
+	return nil
+}
+
+// DecodeNoaaResponse decodes a NOAA datagetter response. Predictions with an unreadable
+// time or height are left out and counted in Skipped, so one bad record doesn't lose the
+// rest; only a body that isn't a response at all, or has neither predictions nor an error,
+// is an error. Fields the decoder doesn't read are reported in Unrecognized. The
+// predictions slice is sized up front from the number of time fields in body, so decoding
+// a month of 6-minute predictions doesn't repeatedly grow it.
+func DecodeNoaaResponse(body []byte) (*NoaaResponse, error) {
+	resp, err := decodeNoaaResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	hasPredictions, unrecognized := inspectNoaaResponse(body)
+	if !hasPredictions && resp.Error == nil {
+		return nil, ErrNoaaEmptyResponse
+	}
+	resp.Unrecognized = unrecognized
+	return resp, nil
+}
+
+func decodeNoaaResponse(body []byte) (*NoaaResponse, error) {
+	n := bytes.Count(body, []byte(`"t"`))
+	resp := &NoaaResponse{}
+	if n > 0 {
+		resp.Predictions = make([]NoaaPrediction, 0, n)
+	}
+	if err := json.Unmarshal(body, resp); err == nil && !slices.Contains(resp.Predictions, NoaaPrediction{}) {
+		return resp, nil
+	}
+
+	// Something didn't decode, or was null; go through the predictions one at a time to
+	// find out what
+	var raw struct {
+		Predictions []lenientPrediction `json:"predictions"`
+		Error       *NoaaError          `json:"error,omitempty"`
+	}
+	if n > 0 {
+		raw.Predictions = make([]lenientPrediction, 0, n)
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	resp = &NoaaResponse{Error: raw.Error}
+	if raw.Predictions != nil {
+		resp.Predictions = make([]NoaaPrediction, 0, len(raw.Predictions))
+	}
+	for _, p := range raw.Predictions {
+		if p.err != nil {
+			if resp.Skipped == 0 {
+				resp.SkipReason = p.err
+			}
+			resp.Skipped++
+			continue
+		}
+		resp.Predictions = append(resp.Predictions, p.NoaaPrediction)
+	}
+	return resp, nil
+}
+
+// inspectNoaaResponse decodes body again with unknown fields disallowed, reporting whether
+// it has a list of predictions and returning the error naming the first field the decoder
+// doesn't read. NOAA's predictions all have the same fields, so only the first is checked.
+func inspectNoaaResponse(body []byte) (hasPredictions bool, unrecognized error) {
+	var top struct {
+		Predictions json.RawMessage `json:"predictions"`
+		Error       *NoaaError      `json:"error"`
+	}
+	// An unknown field doesn't stop the rest being decoded
+	unrecognized = decodeKnownFields(body, &top)
+
+	predictions := json.NewDecoder(bytes.NewReader(top.Predictions))
+	if token, err := predictions.Token(); err != nil || token != json.Delim('[') {
+		return false, unrecognized
+	}
+	if unrecognized != nil || !predictions.More() {
+		return true, unrecognized
+	}
+	var first json.RawMessage
+	if err := predictions.Decode(&first); err != nil {
+		return true, nil
+	}
+	var prediction struct {
+		Time   string `json:"t"`
+		Height string `json:"v"`
+		Type   string `json:"type"`
+	}
+	return true, decodeKnownFields(first, &prediction)
+}
+
+// decodeKnownFields decodes data into v, returning only the error for a field v doesn't
+// have. Other errors are the main decoder's to report.
+func decodeKnownFields(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil && strings.HasPrefix(err.Error(), "json: unknown field ") {
+		return err
+	}
+	return nil
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// scanString returns the contents of the string starting at data[i] and the index after
+// it, or ok=false if there's no string there or it contains escapes
+func scanString(data []byte, i int) (value []byte, next int, ok bool) {
+	if i >= len(data) || data[i] != '"' {
+		return nil, 0, false
+	}
+	end := bytes.IndexByte(data[i+1:], '"')
+	if end < 0 {
+		return nil, 0, false
+	}
+	value = data[i+1 : i+1+end]
+	if bytes.IndexByte(value, '\\') >= 0 {
+		return nil, 0, false
+	}
+	return value, i + end + 2, true
+}
+
+func atoi(digits []byte) (int, bool) {
+	n := 0
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
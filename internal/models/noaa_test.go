@@ -0,0 +1,341 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNoaaTime(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    NoaaTime
+		wantErr bool
+	}{
+		{input: "2024-01-15 05:12", want: NoaaTime{Year: 2024, Month: time.January, Day: 15, Hour: 5, Minute: 12}},
+		{input: "2024-02-29 23:59", want: NoaaTime{Year: 2024, Month: time.February, Day: 29, Hour: 23, Minute: 59}},
+		{input: "2023-02-29 00:00", wantErr: true},
+		{input: "2024-13-01 00:00", wantErr: true},
+		{input: "2024-01-01 24:00", wantErr: true},
+		{input: "2024-01-01T00:00", wantErr: true},
+		{input: "2024-1-1 0:00", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseNoaaTime([]byte(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.input, got.String())
+		})
+	}
+}
+
+func TestNoaaTimeIn(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	got := NoaaTime{Year: 2024, Month: time.July, Day: 4, Hour: 12}.In(la)
+	assert.Equal(t, time.Date(2024, 7, 4, 19, 0, 0, 0, time.UTC).UnixMilli(), got.UnixMilli())
+}
+
+func TestNoaaPredictionUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    NoaaPrediction
+		wantErr string
+	}{
+		{
+			name:  "prediction",
+			input: `{"t":"2024-01-01 00:06","v":"3.142"}`,
+			want:  NoaaPrediction{Time: NoaaTime{Year: 2024, Month: time.January, Day: 1, Minute: 6}, Height: 3.142},
+		},
+		{
+			name:  "extreme with whitespace",
+			input: "{ \"t\" : \"2024-01-01 05:12\",\n \"v\": \"-0.4\", \"type\": \"L\" }",
+			want:  NoaaPrediction{Time: NoaaTime{Year: 2024, Month: time.January, Day: 1, Hour: 5, Minute: 12}, Height: -0.4, Type: "L"},
+		},
+		{
+			name:  "unknown fields are ignored",
+			input: `{"t":"2024-01-01 00:06","v":"1.0","s":"0.01","f":"0,0,0"}`,
+			want:  NoaaPrediction{Time: NoaaTime{Year: 2024, Month: time.January, Day: 1, Minute: 6}, Height: 1.0},
+		},
+		{
+			name:  "non-string values use the general decoder",
+			input: `{"t":"2024-01-01 00:06","v":"1.0","q":1}`,
+			want:  NoaaPrediction{Time: NoaaTime{Year: 2024, Month: time.January, Day: 1, Minute: 6}, Height: 1.0},
+		},
+		{
+			name:  "escaped strings use the general decoder",
+			input: `{"t":"2024-01-01 00:06","v":"1.0","type":"\u0048"}`,
+			want:  NoaaPrediction{Time: NoaaTime{Year: 2024, Month: time.January, Day: 1, Minute: 6}, Height: 1.0, Type: "H"},
+		},
+		{
+			name:    "bad height",
+			input:   `{"t":"2024-01-01 00:06","v":"n/a"}`,
+			wantErr: "parsing height",
+		},
+		{
+			name:    "bad time",
+			input:   `{"t":"01/01/2024","v":"1.0"}`,
+			wantErr: "parsing time",
+		},
+		{
+			name:    "missing height",
+			input:   `{"t":"2024-01-01 00:06"}`,
+			wantErr: "parsing height",
+		},
+		{
+			name:    "NaN height",
+			input:   `{"t":"2024-01-01 00:06","v":"NaN"}`,
+			wantErr: "not a plausible water level",
+		},
+		{
+			name:    "infinite height",
+			input:   `{"t":"2024-01-01 00:06","v":"-Inf"}`,
+			wantErr: "not a plausible water level",
+		},
+		{
+			name:    "implausible height",
+			input:   `{"t":"2024-01-01 00:06","v":"99999.9"}`,
+			wantErr: "not a plausible water level",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got NoaaPrediction
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecodeNoaaResponse(t *testing.T) {
+	resp, err := DecodeNoaaResponse([]byte(`{"predictions":[
+		{"t":"2024-01-01 05:12","v":"9.1","type":"H"},
+		{"t":"2024-01-01 11:30","v":"1.2","type":"L"}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, resp.Predictions, 2)
+	assert.Equal(t, "H", resp.Predictions[0].Type)
+	assert.Equal(t, 1.2, resp.Predictions[1].Height)
+	assert.Nil(t, resp.Error)
+
+	resp, err = DecodeNoaaResponse([]byte(`{"error":{"message":"No Predictions data was found."}}`))
+	require.NoError(t, err)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "No Predictions data was found.", resp.Error.Message)
+	assert.Empty(t, resp.Predictions)
+
+	_, err = DecodeNoaaResponse([]byte(`{"predictions":[`))
+	assert.Error(t, err)
+
+	_, err = DecodeNoaaResponse([]byte(`{"predictions":{"t":"2024-01-01 05:12"}}`))
+	assert.Error(t, err)
+}
+
+func TestDecodeNoaaResponse_SkipsUnreadablePredictions(t *testing.T) {
+	resp, err := DecodeNoaaResponse([]byte(`{"predictions":[
+		{"t":"2024-01-01 00:00","v":"1.5"},
+		{"t":"2024-01-01 00:06","v":""},
+		{"t":"2024-02-30 00:12","v":"1.7"},
+		{"t":"2024-01-01 00:18","v":"NaN"},
+		"2024-01-01 00:24",
+		null,
+		{"t":"2024-01-01 00:30","v":"1.9"}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, resp.Predictions, 2)
+	assert.Equal(t, 1.5, resp.Predictions[0].Height)
+	assert.Equal(t, 30, resp.Predictions[1].Time.Minute)
+	assert.Equal(t, 5, resp.Skipped)
+	assert.ErrorContains(t, resp.SkipReason, `parsing height : strconv.ParseFloat`)
+
+	resp, err = DecodeNoaaResponse([]byte(`{"predictions":[null,{"t":"2024-01-01 00:00","v":"1.5"}]}`))
+	require.NoError(t, err)
+	require.Len(t, resp.Predictions, 1)
+	assert.EqualError(t, resp.SkipReason, "null prediction")
+
+	resp, err = DecodeNoaaResponse(noaaMonthBody())
+	require.NoError(t, err)
+	assert.Zero(t, resp.Skipped)
+	assert.NoError(t, resp.SkipReason)
+}
+
+func TestDecodeNoaaResponse_Unrecognized(t *testing.T) {
+	resp, err := DecodeNoaaResponse([]byte(`{"predictions":[{"t":"2024-01-01 00:00","v":"1.5"}]}`))
+	require.NoError(t, err)
+	assert.NoError(t, resp.Unrecognized)
+
+	resp, err = DecodeNoaaResponse([]byte(`{"metadata":{"id":"9447130"},"predictions":[{"t":"2024-01-01 00:00","v":"1.5"}]}`))
+	require.NoError(t, err)
+	assert.Len(t, resp.Predictions, 1)
+	assert.EqualError(t, resp.Unrecognized, `json: unknown field "metadata"`)
+
+	resp, err = DecodeNoaaResponse([]byte(`{"predictions":[{"t":"2024-01-01 00:00","v":"1.5","f":"0,0"},{"t":"2024-01-01 00:06","v":"1.6"}]}`))
+	require.NoError(t, err)
+	assert.Len(t, resp.Predictions, 2)
+	assert.EqualError(t, resp.Unrecognized, `json: unknown field "f"`)
+
+	resp, err = DecodeNoaaResponse([]byte(`{"error":{"message":"Station not found","code":404}}`))
+	require.NoError(t, err)
+	assert.EqualError(t, resp.Error, "Station not found")
+	assert.EqualError(t, resp.Unrecognized, `json: unknown field "code"`)
+
+	// Unreadable predictions are reported as skipped, not unrecognized
+	resp, err = DecodeNoaaResponse([]byte(`{"predictions":[null,{"t":"2024-01-01 00:00","v":"1.5"}]}`))
+	require.NoError(t, err)
+	assert.NoError(t, resp.Unrecognized)
+}
+
+func TestDecodeNoaaResponse_Empty(t *testing.T) {
+	for _, body := range []string{`{}`, `{"predictions":null}`, `{"data":[{"t":"2024-01-01 00:00","v":"1.5"}]}`} {
+		_, err := DecodeNoaaResponse([]byte(body))
+		assert.ErrorIs(t, err, ErrNoaaEmptyResponse, body)
+	}
+
+	// An empty list is an answer
+	resp, err := DecodeNoaaResponse([]byte(`{"predictions":[]}`))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Predictions)
+
+	resp, err = DecodeNoaaResponse([]byte(`{"error":{}}`))
+	require.NoError(t, err)
+	assert.EqualError(t, resp.Error, "NOAA reported an error without a message")
+}
+
+// FuzzParseNoaaTime checks that whatever NOAA sends as a time, parsing it either fails or
+// gives a real time that prints back as the input
+func FuzzParseNoaaTime(f *testing.F) {
+	for _, seed := range []string{"2024-01-15 05:12", "2024-02-29 23:59", "2023-02-29 00:00", "9999-12-31 23:59",
+		"0000-01-01 00:00", "2024-01-01T00:00", "2024-1-1 0:00", "2024-01-01 24:00", "-024-01-01 00:00", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		parsed, err := ParseNoaaTime([]byte(value))
+		if err != nil {
+			return
+		}
+		if parsed.String() != value {
+			t.Fatalf("ParseNoaaTime(%q) = %v, which doesn't print back as the input", value, parsed)
+		}
+		at := parsed.In(time.UTC)
+		if at.Year() != parsed.Year || at.Month() != parsed.Month || at.Day() != parsed.Day ||
+			at.Hour() != parsed.Hour || at.Minute() != parsed.Minute {
+			t.Fatalf("ParseNoaaTime(%q) = %v isn't a real time, normalizing to %v", value, parsed, at)
+		}
+	})
+}
+
+// FuzzDecodeNoaaResponse checks that decoding never panics, and that every prediction it
+// keeps has a real time and a plausible height, however malformed the body
+func FuzzDecodeNoaaResponse(f *testing.F) {
+	for _, seed := range []string{
+		`{"predictions":[{"t":"2024-01-01 05:12","v":"9.1","type":"H"},{"t":"2024-01-01 11:30","v":"1.2","type":"L"}]}`,
+		`{"predictions":[{"t":"2024-01-01 00:00","v":"1e308"},{"t":"2024-13-01 00:00","v":"1"},{"t":"2024-01-01 00:06","v":"-0"}]}`,
+		`{"predictions":[{"t":"2024-01-01 00:00","v":"NaN"},{"t":"2024-01-01 00:06","v":"0x1p-2"},null,{}]}`,
+		`{"predictions":[{"t":"2024-01-01 00:00","v":"1.0","t":"bad"},{"t":"\u0032024-01-01 00:00","v":"1"}]}`,
+		`{"error":{"message":"No Predictions data was found."}}`,
+		`{"predictions":null}`,
+		`{"predictions":[`,
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		resp, err := DecodeNoaaResponse(body)
+		if err != nil {
+			return
+		}
+		if resp.Skipped > 0 && resp.SkipReason == nil {
+			t.Fatalf("%d predictions skipped without a reason", resp.Skipped)
+		}
+		for _, p := range resp.Predictions {
+			if math.IsNaN(p.Height) || math.IsInf(p.Height, 0) || math.Abs(p.Height) > maxPlausibleHeight {
+				t.Fatalf("kept implausible height %v", p.Height)
+			}
+			if at := p.Time.In(time.UTC); at.Day() != p.Time.Day || at.Hour() != p.Time.Hour {
+				t.Fatalf("kept impossible time %v", p.Time)
+			}
+		}
+	})
+}
+
+// noaaMonthBody builds a month of 6-minute predictions as NOAA returns them
+func noaaMonthBody() []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"predictions":[`)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 31*240; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		t := start.Add(time.Duration(i) * 6 * time.Minute)
+		fmt.Fprintf(&sb, `{"t":"%s","v":"%.3f"}`, t.Format("2006-01-02 15:04"), float64(i%100)/10)
+	}
+	sb.WriteString("]}")
+	return []byte(sb.String())
+}
+
+func BenchmarkDecodeNoaaResponse(b *testing.B) {
+	body := noaaMonthBody()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeNoaaResponse(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeNoaaResponse_Strings is the previous approach, decoding into strings and
+// parsing them afterwards, kept for comparison
+func BenchmarkDecodeNoaaResponse_Strings(b *testing.B) {
+	body := noaaMonthBody()
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+
+	type stringPrediction struct {
+		Time   string `json:"t"`
+		Height string `json:"v"`
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var resp struct {
+			Predictions []stringPrediction `json:"predictions"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			b.Fatal(err)
+		}
+		for _, p := range resp.Predictions {
+			if _, err := time.ParseInLocation("2006-01-02 15:04", p.Time, time.UTC); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := strconv.ParseFloat(p.Height, 64); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+// Observation is a sensor reading
+type Observation struct {
+	Timestamp Millis  `json:"timestamp"`
+	LocalTime string  `json:"localTime"`
+	Value     float64 `json:"value"`
+	Units     string  `json:"units"`
+}
+
+// WaterConditions are the latest readings of a station's water sensors. Readings the
+// station doesn't measure, or that couldn't be fetched, are nil.
+type WaterConditions struct {
+	// WaterTemperature is in degrees Fahrenheit
+	WaterTemperature *Observation `json:"waterTemperature"`
+	// Conductivity, which tracks salinity, is in millisiemens per centimeter
+	Conductivity *Observation `json:"conductivity"`
+}
+
+// ObservationResponse is a station's latest reading of one product
+type ObservationResponse struct {
+	ResponseType string      `json:"responseType"`
+	StationID    string      `json:"stationId"`
+	StationName  string      `json:"stationName"`
+	Product      string      `json:"product"`
+	Observation  Observation `json:"observation"`
+}
+
+// WaterLevelAnomaly compares a station's latest water level reading with the level
+// predicted for the same moment. Detected is set when they differ by Threshold or more,
+// e.g. in a storm surge, when the predictions alone can't be trusted.
+type WaterLevelAnomaly struct {
+	Detected bool `json:"detected"`
+	// Residual is observed minus predicted, in feet; positive when the water is higher
+	Residual       float64 `json:"residual"`
+	Threshold      float64 `json:"threshold"`
+	ObservedLevel  float64 `json:"observedLevel"`
+	PredictedLevel float64 `json:"predictedLevel"`
+	// Timestamp and LocalTime are when the reading was taken
+	Timestamp Millis `json:"timestamp"`
+	LocalTime string `json:"localTime"`
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// Cache statuses of a request's data
+const (
+	// CacheHit means all of the data came from the cache
+	CacheHit = "hit"
+	// CacheMiss means some of it had to be fetched from its source
+	CacheMiss = "miss"
+)
+
+// HeightUnits are the units tide heights are given in. NOAA is asked for english units,
+// and the cached predictions keep them.
+const HeightUnits = "ft"
+
+// Provenance is where one request's data came from, recorded by the services that
+// produce it so clients can show it. GraphQL resolves fields concurrently, so it's
+// guarded.
+type Provenance struct {
+	mu      sync.Mutex
+	hits    int
+	misses  int
+	sources []Source
+	units   string
+	datum   string
+}
+
+type provenanceKey struct{}
+
+// WithProvenance returns a context that collects the provenance of the data produced
+// within it
+func WithProvenance(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(provenanceKey{}).(*Provenance); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, provenanceKey{}, &Provenance{})
+}
+
+// ProvenanceFrom returns what ctx's request has recorded, or nil for a context without
+// WithProvenance
+func ProvenanceFrom(ctx context.Context) *Provenance {
+	p, _ := ctx.Value(provenanceKey{}).(*Provenance)
+	return p
+}
+
+// RecordCacheLookups counts hits and misses of cache lookups for ctx's request. It does
+// nothing for a context without WithProvenance, as do the other Record functions.
+func RecordCacheLookups(ctx context.Context, hits, misses int) {
+	p := ProvenanceFrom(ctx)
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.hits += hits
+	p.misses += misses
+}
+
+// RecordSource notes that some of ctx's request's data came from source
+func RecordSource(ctx context.Context, source Source) {
+	p := ProvenanceFrom(ctx)
+	if p == nil || source == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.sources {
+		if s == source {
+			return
+		}
+	}
+	p.sources = append(p.sources, source)
+}
+
+// RecordUnits notes the units and datum of ctx's request's measurements. The first
+// recorded are kept, since they're the request's main data; extras like the water
+// temperature beside a tide have their own.
+func RecordUnits(ctx context.Context, units, datum string) {
+	p := ProvenanceFrom(ctx)
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.units == "" && p.datum == "" {
+		p.units, p.datum = units, datum
+	}
+}
+
+// CacheStatus is CacheHit or CacheMiss, or "" when no cache was looked in
+func (p *Provenance) CacheStatus() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.misses > 0:
+		return CacheMiss
+	case p.hits > 0:
+		return CacheHit
+	}
+	return ""
+}
+
+// Source names the sources the data came from, comma-separated in the order they were
+// first recorded
+func (p *Provenance) Source() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := make([]string, len(p.sources))
+	for i, s := range p.sources {
+		names[i] = string(s)
+	}
+	return strings.Join(names, ",")
+}
+
+// Units returns the recorded units and datum, empty for data without them
+func (p *Provenance) Units() (units, datum string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.units, p.datum
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvenance(t *testing.T) {
+	// Recording without WithProvenance does nothing
+	RecordSource(context.Background(), SourceNOAA)
+	assert.Nil(t, ProvenanceFrom(context.Background()))
+
+	ctx := WithProvenance(context.Background())
+	assert.Equal(t, ctx, WithProvenance(ctx), "a request collects into one provenance")
+	p := ProvenanceFrom(ctx)
+	require.NotNil(t, p)
+	assert.Empty(t, p.CacheStatus())
+	assert.Empty(t, p.Source())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			RecordCacheLookups(ctx, 1, 0)
+			RecordSource(ctx, SourceNOAA)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, CacheHit, p.CacheStatus())
+
+	RecordSource(ctx, SourceCHS)
+	RecordSource(ctx, "")
+	assert.Equal(t, "NOAA,CHS", p.Source())
+
+	RecordCacheLookups(ctx, 0, 1)
+	assert.Equal(t, CacheMiss, p.CacheStatus(), "any miss makes the data a miss")
+
+	RecordUnits(ctx, HeightUnits, DefaultDatum)
+	RecordUnits(ctx, "degF", "")
+	units, datum := p.Units()
+	assert.Equal(t, "ft", units, "the first units recorded are the main data's")
+	assert.Equal(t, "MLLW", datum)
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// RegionRepresentatives is how many stations each region shows for itself
+const RegionRepresentatives = 3
+
+// StationRegion is the stations of one state and NOAA region, for browsing stations by
+// area without downloading them all. State or Region is empty for stations listed without
+// one.
+type StationRegion struct {
+	State        string `json:"state,omitempty"`
+	Region       string `json:"region,omitempty"`
+	StationCount int    `json:"stationCount"`
+	// Representatives are up to RegionRepresentatives of the region's stations, reference
+	// stations first, in the station list's order; their distance is 0
+	Representatives []Station `json:"representatives"`
+}
+
+// GroupRegions groups stations by state and region, sorted by state then region with the
+// stations missing either last. Only stations in state are grouped when it's given,
+// ignoring case.
+func GroupRegions(stations []Station, state string) []StationRegion {
+	type key struct{ state, region string }
+	indexes := map[key]int{}
+	regions := []StationRegion{}
+	for _, s := range stations {
+		k := key{deref(s.State), deref(s.Region)}
+		if state != "" && !strings.EqualFold(k.state, state) {
+			continue
+		}
+		i, ok := indexes[k]
+		if !ok {
+			i = len(regions)
+			indexes[k] = i
+			regions = append(regions, StationRegion{State: k.state, Region: k.region, Representatives: []Station{}})
+		}
+		regions[i].StationCount++
+		regions[i].Representatives = addRepresentative(regions[i].Representatives, s)
+	}
+
+	sort.Slice(regions, func(i, j int) bool {
+		a, b := regions[i], regions[j]
+		if a.State != b.State {
+			return a.State != "" && (b.State == "" || a.State < b.State)
+		}
+		return a.Region != "" && (b.Region == "" || a.Region < b.Region)
+	})
+	return regions
+}
+
+// addRepresentative adds s to a region's representatives if it belongs among the first
+// RegionRepresentatives, with reference stations ahead of subordinate ones
+func addRepresentative(representatives []Station, s Station) []Station {
+	at := len(representatives)
+	if s.Kind() == StationKindReference {
+		at = sort.Search(len(representatives), func(i int) bool {
+			return representatives[i].Kind() != StationKindReference
+		})
+	}
+	if at >= RegionRepresentatives {
+		return representatives
+	}
+	s.DistanceUnit = DistanceKilometers
+	representatives = slices.Insert(representatives, at, s)
+	return representatives[:min(len(representatives), RegionRepresentatives)]
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
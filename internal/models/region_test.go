@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func regionStation(id, state, region string, kind StationKind) Station {
+	station := Station{ID: id, StationType: kind}
+	if state != "" {
+		station.State = &state
+	}
+	if region != "" {
+		station.Region = &region
+	}
+	return station
+}
+
+func TestGroupRegions(t *testing.T) {
+	stations := []Station{
+		regionStation("1", "WA", "Puget Sound", StationKindSubordinate),
+		regionStation("2", "WA", "Puget Sound", StationKindSubordinate),
+		regionStation("3", "", "", StationKindReference),
+		regionStation("4", "WA", "Puget Sound", StationKindSubordinate),
+		regionStation("5", "ME", "Casco Bay", StationKindSubordinate),
+		regionStation("6", "WA", "Puget Sound", StationKindReference),
+		regionStation("7", "WA", "", StationKindReference),
+		regionStation("8", "WA", "Grays Harbor", StationKindReference),
+		regionStation("9", "WA", "Puget Sound", StationKindReference),
+	}
+
+	regions := GroupRegions(stations, "")
+	var keys []string
+	for _, r := range regions {
+		keys = append(keys, r.State+"/"+r.Region)
+	}
+	assert.Equal(t, []string{"ME/Casco Bay", "WA/Grays Harbor", "WA/Puget Sound", "WA/", "/"}, keys)
+
+	pugetSound := regions[2]
+	assert.Equal(t, 5, pugetSound.StationCount)
+	var ids []string
+	for _, s := range pugetSound.Representatives {
+		ids = append(ids, s.ID)
+		assert.Equal(t, DistanceKilometers, s.DistanceUnit)
+	}
+	assert.Equal(t, []string{"6", "9", "1"}, ids, "reference stations first, then the list's order")
+
+	regions = GroupRegions(stations, "wa")
+	require.Len(t, regions, 3)
+	for _, r := range regions {
+		assert.Equal(t, "WA", r.State)
+	}
+	assert.Equal(t, []StationRegion{}, GroupRegions(stations, "ZZ"), "an empty list, not null")
+}
@@ -0,0 +1,34 @@
+package models
+
+// Conditions an observation report can describe when the reporter has no measured height
+const (
+	ConditionHigherThanPredicted = "HIGHER_THAN_PREDICTED"
+	ConditionLowerThanPredicted  = "LOWER_THAN_PREDICTED"
+	ConditionAsPredicted         = "AS_PREDICTED"
+	ConditionFlooding            = "FLOODING"
+)
+
+// ReportConditions lists the conditions a report can name
+var ReportConditions = []string{
+	ConditionHigherThanPredicted,
+	ConditionLowerThanPredicted,
+	ConditionAsPredicted,
+	ConditionFlooding,
+}
+
+// ObservationReport is what someone saw of the water at a station, submitted to flag when
+// real conditions diverge from the predictions. It has an observed height, a condition or
+// both.
+type ObservationReport struct {
+	StationID string `json:"stationId" dynamodbav:"stationId"`
+	// ReportID sorts a station's reports by when they were observed
+	ReportID string `json:"reportId" dynamodbav:"reportId"`
+	UserID   string `json:"-" dynamodbav:"userId"`
+	// Timestamp is when the water was observed
+	Timestamp Millis `json:"timestamp" dynamodbav:"timestamp"`
+	// ObservedHeight is in feet above MLLW
+	ObservedHeight *float64 `json:"observedHeight,omitempty" dynamodbav:"observedHeight,omitempty"`
+	Condition      string   `json:"condition,omitempty" dynamodbav:"condition,omitempty"`
+	PhotoURL       string   `json:"photoUrl,omitempty" dynamodbav:"photoUrl,omitempty"`
+	SubmittedAt    int64    `json:"submittedAt" dynamodbav:"submittedAt"`
+}
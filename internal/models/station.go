@@ -1,6 +1,54 @@
 package models
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+
+	// Embed the IANA database so station timezones resolve on hosts without zoneinfo (e.g. Lambda)
+	_ "time/tzdata"
+)
+
+// ErrStationNotFound is wrapped by lookups of a station ID no source knows
+var ErrStationNotFound = errors.New("station not found")
+
+// StationNotFoundError is a lookup of a station ID no source knows, along with the stations
+// the caller may have meant. It matches ErrStationNotFound.
+type StationNotFoundError struct {
+	StationID   string
+	Suggestions []StationSuggestion
+}
+
+func (e *StationNotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrStationNotFound, e.StationID)
+}
+
+func (e *StationNotFoundError) Is(target error) bool {
+	return target == ErrStationNotFound
+}
+
+// StationRetiredError is a lookup of a station NOAA retired, along with the current station
+// nearest it that replaces it. It matches ErrStationNotFound, since the ID is no longer a
+// station.
+type StationRetiredError struct {
+	StationID string
+	Successor Station
+}
+
+func (e *StationRetiredError) Error() string {
+	return fmt.Sprintf("station %s was retired; its successor is %s (%s)", e.StationID, e.Successor.ID, e.Successor.Name)
+}
+
+func (e *StationRetiredError) Is(target error) bool {
+	return target == ErrStationNotFound
+}
+
+// WarningStationRetired names the deprecation warning sent with a retired station's successor
+const WarningStationRetired = "STATION_RETIRED"
 
 type Source string
 
@@ -10,19 +58,211 @@ const (
 	SourceCHS  Source = "CHS"
 )
 
+// Station is a tide station. Distance and Bearing are only set on search results: Distance
+// is from the search point in kilometers unless DistanceUnit says otherwise, and Bearing is
+// the initial great-circle bearing to the station in degrees clockwise from true north.
 type Station struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	State          *string  `json:"state,omitempty"`
-	Region         *string  `json:"region,omitempty"`
-	Distance       float64  `json:"distance"`
-	Latitude       float64  `json:"latitude"`
-	Longitude      float64  `json:"longitude"`
-	Source         Source   `json:"source"`
-	Capabilities   []string `json:"capabilities"`
-	TimeZoneOffset int      `json:"timeZoneOffset"`
-	Level          *string  `json:"level,omitempty"`
-	StationType    *string  `json:"stationType,omitempty"`
+	ID             string       `json:"id"`
+	Name           string       `json:"name"`
+	State          *string      `json:"state,omitempty"`
+	Region         *string      `json:"region,omitempty"`
+	Distance       float64      `json:"distance"`
+	DistanceUnit   DistanceUnit `json:"distanceUnit,omitempty"`
+	Bearing        *float64     `json:"bearing,omitempty"`
+	Latitude       float64      `json:"latitude"`
+	Longitude      float64      `json:"longitude"`
+	Source         Source       `json:"source"`
+	Capabilities   []string     `json:"capabilities"`
+	TimeZoneOffset int          `json:"timeZoneOffset"`
+	TimeZone       string       `json:"timeZone,omitempty"`
+	Level          *string      `json:"level,omitempty"`
+	// StationType is empty for stations whose source didn't say; use Kind to read it
+	StationType StationKind `json:"stationType,omitempty"`
+	// Offsets are only set on subordinate stations looked up by ID (see FindTideOffsets)
+	Offsets *TideOffsets `json:"offsets,omitempty"`
+}
+
+// How a subordinate station's height offsets apply to its reference station's heights
+const (
+	// HeightAdjustmentRatio multiplies the reference heights by the offsets
+	HeightAdjustmentRatio = "RATIO"
+	// HeightAdjustmentFixed adds the offsets, in feet, to the reference heights
+	HeightAdjustmentFixed = "FIXED"
+)
+
+// TideOffsets are how NOAA predicts a subordinate station's highs and lows from its
+// reference station's: the times are shifted by the minute offsets, so a client can show
+// "based on Seattle +0:24", and the heights adjusted as HeightAdjustment says
+type TideOffsets struct {
+	ReferenceStationID string `json:"referenceStationId"`
+	// ReferenceStationName is empty when the reference station isn't in the station list
+	ReferenceStationName  string  `json:"referenceStationName,omitempty"`
+	TimeOffsetHighMinutes int     `json:"timeOffsetHighMinutes"`
+	TimeOffsetLowMinutes  int     `json:"timeOffsetLowMinutes"`
+	HeightOffsetHigh      float64 `json:"heightOffsetHigh"`
+	HeightOffsetLow       float64 `json:"heightOffsetLow"`
+	HeightAdjustment      string  `json:"heightAdjustment"`
+}
+
+// StationKind is a station's type. NOAA reports reference stations, which have their own
+// harmonic constituents, and subordinate stations, predicted from offsets against a
+// reference station. Virtual stations are ours, blended from two other stations (see
+// VirtualStation).
+type StationKind string
+
+const (
+	StationKindReference   StationKind = "R"
+	StationKindSubordinate StationKind = "S"
+	StationKindVirtual     StationKind = "V"
+)
+
+// Kind returns the station's type. A station whose source didn't give one is a subordinate
+// station when it has offsets against a reference station, and otherwise a reference
+// station, which NOAA has 6-minute predictions for when it has any.
+func (s Station) Kind() StationKind {
+	switch {
+	case s.StationType != "":
+		return s.StationType
+	case s.Offsets != nil:
+		return StationKindSubordinate
+	default:
+		return StationKindReference
+	}
+}
+
+// Capabilities a station can have. Every tide station has WATER_LEVEL; the others come
+// from the sensors NOAA lists for the station.
+const (
+	CapabilityWaterLevel       = "WATER_LEVEL"
+	CapabilityWaterTemperature = "WATER_TEMPERATURE"
+	CapabilityConductivity     = "CONDUCTIVITY"
+	CapabilityAirTemperature   = "AIR_TEMPERATURE"
+	CapabilityAirPressure      = "AIR_PRESSURE"
+	CapabilityWind             = "WIND"
+	CapabilityCurrents         = "CURRENTS"
+)
+
+// FilterableCapabilities are the capabilities the station list records, and so the only
+// ones a station search can filter by. The rest are only known once a station is looked up.
+var FilterableCapabilities = []string{CapabilityWaterLevel, CapabilityWaterTemperature, CapabilityConductivity}
+
+// HasCapability reports whether the station has capability, ignoring case
+func (s Station) HasCapability(capability string) bool {
+	for _, c := range s.Capabilities {
+		if strings.EqualFold(c, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// StationFilter narrows a station search; empty fields match every station
+type StationFilter struct {
+	StationType string
+	Capability  string
+	Source      Source
+}
+
+// Validate checks the filter names a known station type, source and a capability the
+// station list can answer for
+func (f StationFilter) Validate() error {
+	if f.StationType != "" {
+		if err := validate.OneOfFold("stationType", f.StationType, string(StationKindReference), string(StationKindSubordinate)); err != nil {
+			return err
+		}
+	}
+	if f.Capability != "" {
+		if err := validate.OneOfFold("capability", f.Capability, FilterableCapabilities...); err != nil {
+			return err
+		}
+	}
+	if f.Source != "" {
+		return validate.OneOfFold("source", string(f.Source), string(SourceNOAA), string(SourceUKHO), string(SourceCHS))
+	}
+	return nil
+}
+
+// Matches reports whether s passes the filter. Comparisons ignore case.
+func (f StationFilter) Matches(s Station) bool {
+	if f.StationType != "" && !strings.EqualFold(string(s.Kind()), f.StationType) {
+		return false
+	}
+	if f.Source != "" && !strings.EqualFold(string(s.Source), string(f.Source)) {
+		return false
+	}
+	return f.Capability == "" || s.HasCapability(f.Capability)
+}
+
+// Apply returns the stations that pass the filter, in order
+func (f StationFilter) Apply(stations []Station) []Station {
+	if f == (StationFilter{}) {
+		return stations
+	}
+	var result []Station
+	for _, s := range stations {
+		if f.Matches(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// StationPage is a window onto a list of stations sorted by distance
+type StationPage struct {
+	Stations []Station
+	Offset   int
+	Total    int
+	// Stale reports that the stations came from a list that may be out of date, such as
+	// the snapshot built into the binary while the current list is downloaded
+	Stale bool
+}
+
+// NewStationPage returns the limit stations of sorted starting at offset
+func NewStationPage(sorted []Station, offset, limit int) *StationPage {
+	start := min(max(offset, 0), len(sorted))
+	end := min(start+max(limit, 0), len(sorted))
+	return &StationPage{Stations: sorted[start:end], Offset: offset, Total: len(sorted)}
+}
+
+// HasMore reports whether stations follow this page
+func (p *StationPage) HasMore() bool {
+	return p.Offset+len(p.Stations) < p.Total
+}
+
+const (
+	// DefaultStationLimit is how many nearest stations a lookup returns when it doesn't say
+	DefaultStationLimit = 5
+	// MaxStationLimit is the most nearest stations a lookup may ask for
+	MaxStationLimit = 100
+)
+
+// StationLimits are how many nearest stations a lookup returns when it doesn't ask for a
+// number, and the most it may ask for. Zero values use DefaultStationLimit and
+// MaxStationLimit.
+type StationLimits struct {
+	Default int
+	Max     int
+}
+
+// Resolve returns the limit for a lookup that asked for requested stations, or the
+// default when requested is nil. Limits below 1 or above the cap are rejected rather than
+// clamped, so a caller asking for a million stations learns it won't get them.
+func (l StationLimits) Resolve(param string, requested *int) (int, error) {
+	maxLimit := l.Max
+	if maxLimit <= 0 {
+		maxLimit = MaxStationLimit
+	}
+	if requested == nil {
+		if l.Default <= 0 {
+			return min(DefaultStationLimit, maxLimit), nil
+		}
+		return min(l.Default, maxLimit), nil
+	}
+	lowest, highest := 1.0, float64(maxLimit)
+	if err := validate.Bounds(param, float64(*requested), &lowest, &highest); err != nil {
+		return 0, err
+	}
+	return *requested, nil
 }
 
 // Validate checks if a Station's fields are valid
@@ -54,5 +294,30 @@ func (s *Station) Validate() error {
 		return fmt.Errorf("invalid timezone offset: %d", s.TimeZoneOffset)
 	}
 
+	if s.TimeZone != "" {
+		if _, err := time.LoadLocation(s.TimeZone); err != nil {
+			return fmt.Errorf("invalid timezone: %s", s.TimeZone)
+		}
+	}
+
 	return nil
 }
+
+// locations caches loaded timezones by name, since time.LoadLocation parses the zone's
+// rules on every call and Location is called per request
+var locations sync.Map
+
+// Location returns the station's IANA timezone so local times follow DST transitions.
+// Stations without a known zone fall back to their fixed standard offset.
+func (s *Station) Location() *time.Location {
+	if s.TimeZone != "" {
+		if loc, ok := locations.Load(s.TimeZone); ok {
+			return loc.(*time.Location)
+		}
+		if loc, err := time.LoadLocation(s.TimeZone); err == nil {
+			locations.Store(s.TimeZone, loc)
+			return loc
+		}
+	}
+	return time.FixedZone("Station", s.TimeZoneOffset)
+}
@@ -0,0 +1,56 @@
+package models
+
+// How a station changed from one refresh of its source's station list to the next
+const (
+	StationAdded    = "ADDED"
+	StationRemoved  = "REMOVED"
+	StationModified = "MODIFIED"
+)
+
+// StationChange is a station added to, removed from or modified in a station list. Name is
+// the station's name in the newer list, or the older one for a removed station.
+type StationChange struct {
+	StationID string `json:"stationId" dynamodbav:"stationId"`
+	Name      string `json:"name" dynamodbav:"name"`
+	Change    string `json:"change" dynamodbav:"change"`
+	// Fields are what changed of a modified station
+	Fields []StationFieldChange `json:"fields,omitempty" dynamodbav:"fields,omitempty"`
+}
+
+// StationFieldChange is a field of a modified station, such as name or latitude, and its
+// value before and after, both empty for a field that was unset
+type StationFieldChange struct {
+	Field  string `json:"field" dynamodbav:"field"`
+	Before string `json:"before,omitempty" dynamodbav:"before,omitempty"`
+	After  string `json:"after,omitempty" dynamodbav:"after,omitempty"`
+}
+
+// StationListChange is how a refresh of a source's station list differed from the list
+// it replaced
+type StationListChange struct {
+	Source Source `json:"source" dynamodbav:"source"`
+	// ChangeID sorts a source's changes by the day they were detected, and tells a change
+	// detected again by another instance from a different one the same day
+	ChangeID   string `json:"changeId" dynamodbav:"changeId"`
+	DetectedAt Millis `json:"detectedAt" dynamodbav:"detectedAt"`
+	// Stations are sorted by station ID
+	Stations []StationChange `json:"stations" dynamodbav:"stations"`
+	// Omitted counts the changed stations left out of Stations to keep the change small
+	// enough to store and publish
+	Omitted int `json:"omitted,omitempty" dynamodbav:"omitted,omitempty"`
+}
+
+// Count returns how many of Stations were added, removed and modified
+func (c StationListChange) Count() (added, removed, modified int) {
+	for _, s := range c.Stations {
+		switch s.Change {
+		case StationAdded:
+			added++
+		case StationRemoved:
+			removed++
+		case StationModified:
+			modified++
+		}
+	}
+	return added, removed, modified
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,7 +32,7 @@ func TestStationSerialization(t *testing.T) {
 				Capabilities:   []string{"WATER_LEVEL"},
 				TimeZoneOffset: -28800, // -8 hours in seconds
 				Level:          stringPtr("R"),
-				StationType:    stringPtr("R"),
+				StationType:    StationKindReference,
 			},
 			wantError: false,
 		},
@@ -168,6 +169,20 @@ func TestStationValidation(t *testing.T) {
 			wantError: true,
 			errorMsg:  "invalid timezone offset",
 		},
+		{
+			name: "invalid timezone name",
+			station: Station{
+				ID:             "TEST005",
+				Name:           "Invalid Timezone Name",
+				Latitude:       47.6062,
+				Longitude:      -122.3321,
+				Source:         SourceNOAA,
+				TimeZoneOffset: -28800,
+				TimeZone:       "Pacific/Atlantis",
+			},
+			wantError: true,
+			errorMsg:  "invalid timezone",
+		},
 	}
 
 	for _, tt := range tests {
@@ -186,6 +201,34 @@ func TestStationValidation(t *testing.T) {
 	}
 }
 
+func TestStationLocation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("IANA timezone follows DST", func(t *testing.T) {
+		station := Station{TimeZone: "America/Los_Angeles", TimeZoneOffset: -28800}
+		loc := station.Location()
+
+		_, winterOffset := time.Date(2024, 1, 15, 12, 0, 0, 0, loc).Zone()
+		_, summerOffset := time.Date(2024, 7, 15, 12, 0, 0, 0, loc).Zone()
+		assert.Equal(t, -8*3600, winterOffset)
+		assert.Equal(t, -7*3600, summerOffset)
+	})
+
+	t.Run("loaded once per zone", func(t *testing.T) {
+		a := Station{ID: "A", TimeZone: "America/New_York"}
+		b := Station{ID: "B", TimeZone: "America/New_York"}
+		assert.Same(t, a.Location(), b.Location())
+	})
+
+	t.Run("falls back to fixed offset", func(t *testing.T) {
+		for _, tz := range []string{"", "Pacific/Atlantis"} {
+			station := Station{TimeZone: tz, TimeZoneOffset: -28800}
+			_, offset := time.Date(2024, 7, 15, 12, 0, 0, 0, station.Location()).Zone()
+			assert.Equal(t, -28800, offset, tz)
+		}
+	})
+}
+
 func TestStationSourceValidation(t *testing.T) {
 	t.Parallel()
 
@@ -260,7 +303,7 @@ func BenchmarkStationValidation(b *testing.B) {
 		Capabilities:   []string{"WATER_LEVEL"},
 		TimeZoneOffset: -28800,
 		Level:          stringPtr("R"),
-		StationType:    stringPtr("R"),
+		StationType:    StationKindReference,
 	}
 
 	b.ResetTimer()
@@ -283,7 +326,7 @@ func BenchmarkStationSerialization(b *testing.B) {
 		Capabilities:   []string{"WATER_LEVEL"},
 		TimeZoneOffset: -28800,
 		Level:          stringPtr("R"),
-		StationType:    stringPtr("R"),
+		StationType:    StationKindReference,
 	}
 
 	b.Run("Marshal", func(b *testing.B) {
@@ -310,3 +353,111 @@ func BenchmarkStationSerialization(b *testing.B) {
 		}
 	})
 }
+
+func TestNewStationPage(t *testing.T) {
+	sorted := []Station{{ID: "A"}, {ID: "B"}, {ID: "C"}}
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		wantIDs       []string
+		wantMore      bool
+	}{
+		{name: "first page", offset: 0, limit: 2, wantIDs: []string{"A", "B"}, wantMore: true},
+		{name: "last page", offset: 2, limit: 2, wantIDs: []string{"C"}, wantMore: false},
+		{name: "exact fit", offset: 1, limit: 2, wantIDs: []string{"B", "C"}, wantMore: false},
+		{name: "past the end", offset: 5, limit: 2, wantIDs: []string{}, wantMore: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewStationPage(sorted, tt.offset, tt.limit)
+			ids := []string{}
+			for _, s := range page.Stations {
+				ids = append(ids, s.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, tt.offset, page.Offset)
+			assert.Equal(t, 3, page.Total)
+			assert.Equal(t, tt.wantMore, page.HasMore())
+		})
+	}
+}
+
+func TestStationLimits_Resolve(t *testing.T) {
+	ask := func(n int) *int { return &n }
+
+	tests := []struct {
+		name      string
+		limits    StationLimits
+		requested *int
+		want      int
+		wantErr   string
+	}{
+		{name: "package default", limits: StationLimits{}, want: DefaultStationLimit},
+		{name: "configured default", limits: StationLimits{Default: 8, Max: 20}, want: 8},
+		{name: "default above the cap", limits: StationLimits{Default: 8, Max: 4}, want: 4},
+		{name: "requested", limits: StationLimits{}, requested: ask(MaxStationLimit), want: MaxStationLimit},
+		{name: "zero", limits: StationLimits{}, requested: ask(0), wantErr: `invalid limit "0": must be at least 1`},
+		{name: "over the package cap", limits: StationLimits{}, requested: ask(100000), wantErr: `invalid limit "100000": must be at most 100`},
+		{name: "over the configured cap", limits: StationLimits{Max: 20}, requested: ask(21), wantErr: `invalid limit "21": must be at most 20`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.limits.Resolve("limit", tt.requested)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestStation_Kind(t *testing.T) {
+	assert.Equal(t, StationKindSubordinate, Station{StationType: StationKindSubordinate}.Kind())
+	assert.Equal(t, StationKindVirtual, Station{StationType: StationKindVirtual}.Kind())
+	assert.Equal(t, StationKindReference, Station{}.Kind(), "untyped stations are predicted as reference stations")
+	assert.Equal(t, StationKindSubordinate, Station{Offsets: &TideOffsets{ReferenceStationID: "9447130"}}.Kind(),
+		"untyped stations with offsets against a reference station are subordinate")
+}
+
+func TestStationFilter(t *testing.T) {
+	reference := Station{ID: "R1", Source: SourceNOAA, StationType: StationKindReference, Capabilities: []string{"WATER_LEVEL", "WATER_TEMPERATURE"}}
+	subordinate := Station{ID: "S1", Source: SourceNOAA, StationType: StationKindSubordinate}
+	untyped := Station{ID: "U1", Source: SourceCHS, Capabilities: []string{"WATER_LEVEL"}}
+	all := []Station{reference, subordinate, untyped}
+
+	tests := []struct {
+		name    string
+		filter  StationFilter
+		wantIDs []string
+	}{
+		{name: "empty filter", filter: StationFilter{}, wantIDs: []string{"R1", "S1", "U1"}},
+		{name: "reference stations", filter: StationFilter{StationType: "R"}, wantIDs: []string{"R1", "U1"}},
+		{name: "ignores case", filter: StationFilter{StationType: "s", Source: "noaa"}, wantIDs: []string{"S1"}},
+		{name: "capability", filter: StationFilter{Capability: "water_level"}, wantIDs: []string{"R1", "U1"}},
+		{name: "sensor capability", filter: StationFilter{Capability: CapabilityWaterTemperature}, wantIDs: []string{"R1"}},
+		{name: "source", filter: StationFilter{Source: SourceCHS}, wantIDs: []string{"U1"}},
+		{name: "no matches", filter: StationFilter{Source: SourceUKHO}, wantIDs: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.filter.Validate())
+			var ids []string
+			for _, s := range tt.filter.Apply(all) {
+				ids = append(ids, s.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+
+	assert.EqualError(t, StationFilter{StationType: "X"}.Validate(), `invalid stationType "X": must be one of R, S`)
+	assert.EqualError(t, StationFilter{Source: "BOM"}.Validate(), `invalid source "BOM": must be one of NOAA, UKHO, CHS`)
+	assert.EqualError(t, StationFilter{Capability: CapabilityCurrents}.Validate(),
+		`invalid capability "CURRENTS": must be one of WATER_LEVEL, WATER_TEMPERATURE, CONDUCTIVITY`)
+	assert.NoError(t, StationFilter{Capability: "conductivity"}.Validate())
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+)
+
+// StationSuggestion is a station offered in place of an ID that isn't one
+type StationSuggestion struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SuggestStations returns up to limit stations whose ID or name is close to query, closest
+// first, for "did you mean" suggestions. It returns an empty list when none are close.
+func SuggestStations(stations []Station, query string, limit int) []StationSuggestion {
+	query = strings.ToLower(query)
+	type match struct {
+		station  *Station
+		distance int
+	}
+	var matches []match
+	for i := range stations {
+		if distance, ok := matchDistance(query, &stations[i]); ok {
+			matches = append(matches, match{&stations[i], distance})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].station.ID < matches[j].station.ID
+	})
+
+	suggestions := make([]StationSuggestion, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		suggestions = append(suggestions, StationSuggestion{ID: m.station.ID, Name: m.station.Name})
+	}
+	return suggestions
+}
+
+// matchDistance is how many edits turn query, in lower case, into the station's ID or a
+// word of its name, and whether that's few enough to be a typo: at most one in three of
+// query's characters. A name containing query matches outright.
+func matchDistance(query string, station *Station) (int, bool) {
+	name := strings.ToLower(station.Name)
+	if len(query) >= 3 && strings.Contains(name, query) {
+		return 0, true
+	}
+	distance := validate.EditDistance(query, strings.ToLower(station.ID))
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		distance = min(distance, validate.EditDistance(query, word))
+	}
+	return distance, distance <= max(1, len(query)/3) && distance < len(query)
+}
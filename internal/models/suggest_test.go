@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestStations(t *testing.T) {
+	stations := []Station{
+		{ID: "9447130", Name: "Seattle"},
+		{ID: "9447110", Name: "Seattle, Elliott Bay"},
+		{ID: "9446484", Name: "Tacoma"},
+		{ID: "9444900", Name: "Port Townsend"},
+		{ID: "8454000", Name: "Providence"},
+	}
+	ids := func(suggestions []StationSuggestion) []string {
+		ids := make([]string, len(suggestions))
+		for i, s := range suggestions {
+			ids[i] = s.ID
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		want  []string
+	}{
+		{"one digit off", "9447131", 3, []string{"9447130", "9447110"}},
+		{"ties by ID", "9447100", 3, []string{"9447110", "9447130", "9444900"}},
+		{"at most a third of the query may differ", "944", 3, []string{}},
+		{"name contains the query", "seattle", 3, []string{"9447110", "9447130"}},
+		{"misspelled name word", "townsent", 3, []string{"9444900"}},
+		{"limit keeps the closest", "9447100", 1, []string{"9447110"}},
+		{"nothing close", "8123456", 3, []string{}},
+		{"too short to judge", "9", 3, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(SuggestStations(stations, tt.query, tt.limit)))
+		})
+	}
+}
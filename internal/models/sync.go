@@ -0,0 +1,25 @@
+package models
+
+// PredictionSync is what an offline client is missing of its stations' predictions since
+// it last synced
+type PredictionSync struct {
+	ResponseType string `json:"responseType"`
+	// SyncedAt is when the sync was made, to send as since next time
+	SyncedAt Millis        `json:"syncedAt"`
+	Stations []StationSync `json:"stations"`
+}
+
+// StationSync is what a client is missing of one station's predictions
+type StationSync struct {
+	StationID   string `json:"stationId"`
+	StationName string `json:"stationName"`
+	TimeZone    string `json:"timeZone,omitempty"` // IANA zone, when known
+	// StartDate and EndDate are the days the client should now have, in the station's
+	// time zone; it can drop the days before StartDate
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	// Full is set when Days are every day from StartDate to EndDate, to replace what the
+	// client has, rather than the days it's missing, to add to it
+	Full bool             `json:"full"`
+	Days []DayPredictions `json:"days"`
+}
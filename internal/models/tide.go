@@ -17,26 +17,26 @@ const (
 // TideExtreme represents a high or low tide
 type TideExtreme struct {
 	Type      TideType `json:"type"`
-	Timestamp int64    `json:"timestamp"`
+	Timestamp Millis   `json:"timestamp"`
 	LocalTime string   `json:"localTime"`
 	Height    float64  `json:"height"`
 }
 
 // TidePrediction represents a tide prediction at a specific time
 type TidePrediction struct {
-	Timestamp int64   `json:"timestamp"`
+	Timestamp Millis  `json:"timestamp"`
 	LocalTime string  `json:"localTime"`
 	Height    float64 `json:"height"`
 }
 
-func (tp TidePrediction) GetTimestamp() int64 {
+func (tp TidePrediction) GetTimestamp() Millis {
 	return tp.Timestamp
 }
 
 // ExtendedTideResponse represents the full tide response including predictions and extremes
 type ExtendedTideResponse struct {
 	ResponseType          string           `json:"responseType"`
-	Timestamp             int64            `json:"timestamp"`
+	Timestamp             Millis           `json:"timestamp"`
 	LocalTime             string           `json:"localTime"` // Add this field
 	WaterLevel            *float64         `json:"waterLevel"`
 	PredictedLevel        *float64         `json:"predictedLevel"`
@@ -50,20 +50,177 @@ type ExtendedTideResponse struct {
 	Extremes              []TideExtreme    `json:"extremes"`
 	Predictions           []TidePrediction `json:"predictions"`
 	TimeZoneOffsetSeconds *int             `json:"timeZoneOffsetSeconds"`
+	Summary               *TideSummary     `json:"summary,omitempty"`
+	Astronomy             *TideAstronomy   `json:"astronomy,omitempty"`
+	// Conditions are only included for stations with water sensors
+	Conditions *WaterConditions `json:"conditions,omitempty"`
+	// Weather is only included when requested
+	Weather *MarineWeather `json:"weather,omitempty"`
+	// Confidence is only included for stations whose accuracy is tracked
+	Confidence *PredictionConfidence `json:"confidence,omitempty"`
+	// Offsets are only included for subordinate stations
+	Offsets *TideOffsets `json:"offsets,omitempty"`
+	// Anomaly is only included for stations with a water level gauge that has a recent reading
+	Anomaly *WaterLevelAnomaly `json:"anomaly,omitempty"`
+	// Warnings list the parts of the response that are missing or approximated
+	Warnings []ResponseWarning `json:"warnings,omitempty"`
 }
 
-// NoaaPrediction represents the raw NOAA API prediction response
-type NoaaPrediction struct {
-	Time   string  `json:"t"`              // Time of prediction
-	Height string  `json:"v"`              // Predicted water level
-	Type   *string `json:"type,omitempty"` // Type of prediction (H for high, L for low)
+// Warning codes name the part of a response an upstream failure, or its size, affected
+const (
+	// WarningPredictionsUnavailable means the curve was interpolated from the extremes
+	WarningPredictionsUnavailable = "PREDICTIONS_UNAVAILABLE"
+	// WarningExtremesUnavailable means the highs and lows are missing
+	WarningExtremesUnavailable = "EXTREMES_UNAVAILABLE"
+	// WarningWeatherUnavailable means the requested forecast is missing
+	WarningWeatherUnavailable = "WEATHER_UNAVAILABLE"
+	// WarningConditionsUnavailable means some sensor readings are missing
+	WarningConditionsUnavailable = "CONDITIONS_UNAVAILABLE"
+	// WarningPredictionsDownsampled means the range held more predictions than a response
+	// carries, so the curve was thinned
+	WarningPredictionsDownsampled = "PREDICTIONS_DOWNSAMPLED"
+)
+
+// ResponseWarning reports partial data: rather than failing a request when one of its
+// upstream sources does, the rest is returned along with a warning
+type ResponseWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// TideSummary is a computed at-a-glance view of the tide at the response's timestamp, so
+// simple clients don't have to derive it from the extremes. Fields are nil when the
+// extremes around that time weren't part of the lookup.
+type TideSummary struct {
+	NextHigh *TideExtreme `json:"nextHigh"`
+	NextLow  *TideExtreme `json:"nextLow"`
+	Trend    *TideType    `json:"trend"`
+	// TodayRange spans the lowest low and highest high of the station's local day
+	TodayRange *TideRange `json:"todayRange"`
+	// CycleElapsedPercent is how far, from 0 to 100, the tide has moved from the previous
+	// extreme toward the next one
+	CycleElapsedPercent *float64 `json:"cycleElapsedPercent"`
+}
+
+// TideAstronomy explains the size of the tides from the lunar cycle at the response's
+// timestamp: ranges are largest around new and full moons and smallest around the quarters
+type TideAstronomy struct {
+	// TideCycle is SPRING, NEAP or INTERMEDIATE
+	TideCycle string `json:"tideCycle"`
+	// MoonPhase is NEW, WAXING_CRESCENT, FIRST_QUARTER, WAXING_GIBBOUS, FULL, WANING_GIBBOUS,
+	// LAST_QUARTER or WANING_CRESCENT
+	MoonPhase         string  `json:"moonPhase"`
+	DaysSinceNewMoon  float64 `json:"daysSinceNewMoon"`
+	DaysSinceFullMoon float64 `json:"daysSinceFullMoon"`
+}
+
+// TideRange is a span of water heights
+type TideRange struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+// ExtremesSummary lists a station's highs and lows day by day without the 6-minute
+// predictions, which views such as calendars don't need
+type ExtremesSummary struct {
+	ResponseType string          `json:"responseType"`
+	StationID    string          `json:"stationId"`
+	StationName  string          `json:"stationName"`
+	TimeZone     string          `json:"timeZone,omitempty"` // IANA zone, when known
+	Days         []DailyExtremes `json:"days"`
+}
+
+// DailyExtremes holds the extremes of one calendar day in the station's time zone
+type DailyExtremes struct {
+	Date     string           `json:"date"` // YYYY-MM-DD
+	Extremes []CompactExtreme `json:"extremes"`
+}
+
+// CompactExtreme is a TideExtreme whose local time leaves out the date its day implies
+type CompactExtreme struct {
+	Type      TideType `json:"type"`
+	Time      string   `json:"time"` // HH:MM
+	Timestamp Millis   `json:"timestamp"`
+	Height    float64  `json:"height"`
 }
 
-type NoaaResponse struct {
-	Predictions []NoaaPrediction `json:"predictions"`
-	Error       *struct {
-		Message string `json:"message"`
-	} `json:"error,omitempty"`
+// DailyPredictions lists a station's predictions and highs and lows day by day, for
+// clients that keep them to answer without the API
+type DailyPredictions struct {
+	ResponseType string           `json:"responseType"`
+	StationID    string           `json:"stationId"`
+	StationName  string           `json:"stationName"`
+	TimeZone     string           `json:"timeZone,omitempty"` // IANA zone, when known
+	Days         []DayPredictions `json:"days"`
+}
+
+// DayPredictions holds the predictions and extremes of one calendar day in the station's
+// time zone
+type DayPredictions struct {
+	Date        string           `json:"date"` // YYYY-MM-DD
+	Predictions []TidePrediction `json:"predictions"`
+	Extremes    []TideExtreme    `json:"extremes"`
+}
+
+// NextExtremes lists a station's next highs and lows from now
+type NextExtremes struct {
+	ResponseType string        `json:"responseType"`
+	StationID    string        `json:"stationId"`
+	StationName  string        `json:"stationName"`
+	TimeZone     string        `json:"timeZone,omitempty"` // IANA zone, when known
+	Extremes     []TideExtreme `json:"extremes"`
+}
+
+// DaylightLows lists a station's low tides between sunrise and sunset day by day, for
+// planning beachcombing and tidepooling
+type DaylightLows struct {
+	ResponseType string `json:"responseType"`
+	StationID    string `json:"stationId"`
+	StationName  string `json:"stationName"`
+	TimeZone     string `json:"timeZone,omitempty"` // IANA zone, when known
+	// Below is the height, in feet above MLLW, the lows are under, when the request gave one
+	Below *float64      `json:"below,omitempty"`
+	Days  []DaylightDay `json:"days"`
+}
+
+// DaylightDay holds the daylight lows of one calendar day in the station's time zone.
+// Sunrise and Sunset are nil on days the sun doesn't rise and set, when DaylightMinutes is
+// 0 or 1440.
+type DaylightDay struct {
+	Date            string           `json:"date"` // YYYY-MM-DD
+	Sunrise         *Millis          `json:"sunrise"`
+	Sunset          *Millis          `json:"sunset"`
+	DaylightMinutes int              `json:"daylightMinutes"`
+	Lows            []CompactExtreme `json:"lows"`
+}
+
+// TideExtremes lists a station's highs and lows over a range, without the curve between them
+type TideExtremes struct {
+	ResponseType string        `json:"responseType"`
+	StationID    string        `json:"stationId"`
+	StationName  string        `json:"stationName"`
+	TimeZone     string        `json:"timeZone,omitempty"` // IANA zone, when known
+	Extremes     []TideExtreme `json:"extremes"`
+}
+
+// StationComparison lines several stations' predictions up on a shared timeline, for
+// estimating the tide between them
+type StationComparison struct {
+	ResponseType    string            `json:"responseType"`
+	IntervalMinutes int               `json:"intervalMinutes"`
+	Timestamps      []Millis          `json:"timestamps"`
+	Stations        []ComparedStation `json:"stations"`
+}
+
+// ComparedStation is one station's series in a StationComparison. LagMinutes and
+// RangeRatio relate it to the first station, which has 0 and 1; they're nil when the
+// two stations' extremes couldn't be matched up.
+type ComparedStation struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Heights    []float64 `json:"heights"` // One per timestamp
+	LagMinutes *float64  `json:"lagMinutes"`
+	RangeRatio *float64  `json:"rangeRatio"`
 }
 
 // Validate checks if a TidePrediction's fields are valid
@@ -78,15 +235,8 @@ func (tp *TidePrediction) Validate() error {
 		if err != nil {
 			return fmt.Errorf("invalid local time format: %s", tp.LocalTime)
 		}
-		timeDiff := func(a int64) int64 {
-			if a < 0 {
-				a = -a
-			}
-			return a
-		}(t.UnixMilli() - tp.Timestamp)
-
 		// must be within 24 hours of localtime
-		if timeDiff > 1000*60*60*24 {
+		if diff := MillisOf(t).Sub(tp.Timestamp); diff > 24*time.Hour || diff < -24*time.Hour {
 			return fmt.Errorf("local time does not match timestamp")
 		}
 	}
@@ -115,15 +265,8 @@ func (te *TideExtreme) Validate() error {
 			return fmt.Errorf("invalid local time format: %s", te.LocalTime)
 		}
 
-		timeDiff := func(a int64) int64 {
-			if a < 0 {
-				a = -a
-			}
-			return a
-		}(t.UnixMilli() - te.Timestamp)
-
 		// must be within 24 hours of localtime
-		if timeDiff > 1000*60*60*24 {
+		if diff := MillisOf(t).Sub(te.Timestamp); diff > 24*time.Hour || diff < -24*time.Hour {
 			return fmt.Errorf("local time does not match timestamp")
 		}
 	}
@@ -150,7 +150,7 @@ func TestTideTypeValidation(t *testing.T) {
 
 			extreme := TideExtreme{
 				Type:      tt.tideType,
-				Timestamp: time.Now().UnixMilli(),
+				Timestamp: MillisOf(time.Now()),
 				LocalTime: time.Now().Format("2006-01-02T15:04:05"),
 				Height:    4.5,
 			}
@@ -171,7 +171,7 @@ func TestTimeFormatting(t *testing.T) {
 
 	tests := []struct {
 		name      string
-		timestamp int64
+		timestamp Millis
 		localTime string
 		wantErr   bool
 	}{
@@ -243,7 +243,7 @@ func tideTypePtr(t TideType) *TideType {
 func BenchmarkTideResponseValidation(b *testing.B) {
 	response := ExtendedTideResponse{
 		ResponseType:   "tide",
-		Timestamp:      time.Now().UnixMilli(),
+		Timestamp:      MillisOf(time.Now()),
 		LocalTime:      time.Now().Format(time.RFC3339),
 		NearestStation: "TEST001",
 		Location:       stringPtr("Test Location"),
@@ -256,20 +256,20 @@ func BenchmarkTideResponseValidation(b *testing.B) {
 
 	// Fill predictions and extremes with test data
 	for i := 0; i < 100; i++ {
-		ts := time.Now().Add(time.Duration(i) * time.Hour).UnixMilli()
+		ts := MillisOf(time.Now().Add(time.Duration(i) * time.Hour))
 		response.Predictions[i] = TidePrediction{
 			Timestamp: ts,
-			LocalTime: time.Unix(ts/1000, 0).Format(time.RFC3339),
+			LocalTime: ts.In(time.Local).Format(time.RFC3339),
 			Height:    float64(i) / 10,
 		}
 	}
 
 	for i := 0; i < 10; i++ {
-		ts := time.Now().Add(time.Duration(i*6) * time.Hour).UnixMilli()
+		ts := MillisOf(time.Now().Add(time.Duration(i*6) * time.Hour))
 		response.Extremes[i] = TideExtreme{
 			Type:      TideTypeHigh,
 			Timestamp: ts,
-			LocalTime: time.Unix(ts/1000, 0).Format(time.RFC3339),
+			LocalTime: ts.In(time.Local).Format(time.RFC3339),
 			Height:    float64(i),
 		}
 	}
@@ -302,7 +302,7 @@ func TestTideExtreme_Validate(t *testing.T) {
 			name: "invalid local time format",
 			extreme: TideExtreme{
 				Type:      TideTypeHigh,
-				Timestamp: time.Now().UnixMilli(),
+				Timestamp: MillisOf(time.Now()),
 				LocalTime: "invalid-time-format",
 				Height:    1.5,
 			},
@@ -313,7 +313,7 @@ func TestTideExtreme_Validate(t *testing.T) {
 			name: "local time more than 24 hours from timestamp",
 			extreme: TideExtreme{
 				Type:      TideTypeHigh,
-				Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli(),
+				Timestamp: MillisOf(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
 				LocalTime: "2024-01-03T00:00:00", // 48 hours difference
 				Height:    1.5,
 			},
@@ -336,7 +336,7 @@ func TestTideExtreme_Validate(t *testing.T) {
 }
 
 func TestTidePrediction_GetTimestamp(t *testing.T) {
-	timestamp := time.Now().UnixMilli()
+	timestamp := MillisOf(time.Now())
 	prediction := TidePrediction{
 		Timestamp: timestamp,
 		LocalTime: time.Now().Format("2006-01-02T15:04:05"),
@@ -349,13 +349,13 @@ func TestTidePrediction_GetTimestamp(t *testing.T) {
 func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 	baseTime := time.Now()
 	validPrediction := TidePrediction{
-		Timestamp: baseTime.UnixMilli(),
+		Timestamp: MillisOf(baseTime),
 		LocalTime: baseTime.Format("2006-01-02T15:04:05"),
 		Height:    1.5,
 	}
 	validExtreme := TideExtreme{
 		Type:      TideTypeHigh,
-		Timestamp: baseTime.UnixMilli(),
+		Timestamp: MillisOf(baseTime),
 		LocalTime: baseTime.Format("2006-01-02T15:04:05"),
 		Height:    1.5,
 	}
@@ -369,7 +369,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "missing nearest station",
 			response: ExtendedTideResponse{
-				Timestamp:  time.Now().UnixMilli(),
+				Timestamp:  MillisOf(time.Now()),
 				WaterLevel: &[]float64{1.5}[0],
 			},
 			wantErr:   true,
@@ -378,7 +378,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "invalid longitude",
 			response: ExtendedTideResponse{
-				Timestamp:      time.Now().UnixMilli(),
+				Timestamp:      MillisOf(time.Now()),
 				NearestStation: "TEST001",
 				Longitude:      181.0,
 			},
@@ -388,7 +388,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "negative station distance",
 			response: ExtendedTideResponse{
-				Timestamp:       time.Now().UnixMilli(),
+				Timestamp:       MillisOf(time.Now()),
 				NearestStation:  "TEST001",
 				Longitude:       -122.3321,
 				StationDistance: -1.0,
@@ -399,7 +399,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "invalid tide type",
 			response: ExtendedTideResponse{
-				Timestamp:      time.Now().UnixMilli(),
+				Timestamp:      MillisOf(time.Now()),
 				NearestStation: "TEST001",
 				Longitude:      -122.3321,
 				TideType:       &[]TideType{"INVALID"}[0],
@@ -410,7 +410,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "invalid timezone offset",
 			response: ExtendedTideResponse{
-				Timestamp:             time.Now().UnixMilli(),
+				Timestamp:             MillisOf(time.Now()),
 				NearestStation:        "TEST001",
 				Longitude:             -122.3321,
 				TimeZoneOffsetSeconds: &[]int{-50000}[0], // Too negative
@@ -421,7 +421,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "invalid prediction in array",
 			response: ExtendedTideResponse{
-				Timestamp:      time.Now().UnixMilli(),
+				Timestamp:      MillisOf(time.Now()),
 				NearestStation: "TEST001",
 				Longitude:      -122.3321,
 				Predictions: []TidePrediction{
@@ -435,7 +435,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 		{
 			name: "invalid extreme in array",
 			response: ExtendedTideResponse{
-				Timestamp:      time.Now().UnixMilli(),
+				Timestamp:      MillisOf(time.Now()),
 				NearestStation: "TEST001",
 				Longitude:      -122.3321,
 				Extremes: []TideExtreme{
@@ -450,7 +450,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 			name: "valid response with all fields",
 			response: ExtendedTideResponse{
 				ResponseType:          "tide",
-				Timestamp:             time.Now().UnixMilli(),
+				Timestamp:             MillisOf(time.Now()),
 				NearestStation:        "TEST001",
 				Longitude:             -122.3321,
 				Latitude:              47.6062,
@@ -479,7 +479,7 @@ func TestExtendedTideResponse_Validate_Additional(t *testing.T) {
 func BenchmarkTideExtreme_Validate(b *testing.B) {
 	extreme := TideExtreme{
 		Type:      TideTypeHigh,
-		Timestamp: time.Now().UnixMilli(),
+		Timestamp: MillisOf(time.Now()),
 		LocalTime: time.Now().Format("2006-01-02T15:04:05"),
 		Height:    1.5,
 	}
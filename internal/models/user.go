@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/persist"
+)
+
+// ErrInvalidUnits and ErrInvalidDatum are wrapped by ValidateUnits and ValidateDatum
+var (
+	ErrInvalidUnits = errors.New("invalid units")
+	ErrInvalidDatum = errors.New("invalid datum")
+)
+
+// Height units and tidal datums a user can prefer, named as NOAA names them
+const (
+	UnitsEnglish = "english"
+	UnitsMetric  = "metric"
+
+	DefaultUnits = UnitsEnglish
+	DefaultDatum = "MLLW"
+
+	// MaxFavorites keeps a profile well inside DynamoDB's item size limit, as does keeping
+	// at most as many removed ones
+	MaxFavorites = 100
+)
+
+var validDatums = []string{"MHHW", "MHW", "MTL", "MSL", "MLW", "MLLW", "NAVD", "STND"}
+
+// UserProfile is a user's favorite stations and display preferences, synced across devices
+type UserProfile struct {
+	UserID    string            `json:"userId" dynamodbav:"userId"`
+	Favorites []FavoriteStation `json:"favorites" dynamodbav:"favorites"`
+	// DeletedFavorites are favorites removed recently enough to be restored, most recently
+	// removed last
+	DeletedFavorites []FavoriteStation `json:"deletedFavorites" dynamodbav:"deletedFavorites"`
+	Units            string            `json:"units" dynamodbav:"units"`
+	Datum            string            `json:"datum" dynamodbav:"datum"`
+	UpdatedAt        int64             `json:"updatedAt" dynamodbav:"updatedAt"`
+	// Version increases with every save so concurrent edits from two devices can't
+	// overwrite each other
+	Version int64 `json:"-" dynamodbav:"version"`
+}
+
+// FavoriteStation is a station a user saved, with its name as of when it was saved
+type FavoriteStation struct {
+	StationID string `json:"stationId" dynamodbav:"stationId"`
+	Name      string `json:"name" dynamodbav:"name"`
+	persist.Stamps
+}
+
+// NewUserProfile returns the profile of a user who hasn't saved anything yet
+func NewUserProfile(userID string) *UserProfile {
+	return &UserProfile{
+		UserID:           userID,
+		Favorites:        []FavoriteStation{},
+		DeletedFavorites: []FavoriteStation{},
+		Units:            DefaultUnits,
+		Datum:            DefaultDatum,
+	}
+}
+
+// Validate checks a profile before it's saved
+func (p *UserProfile) Validate() error {
+	if p.UserID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+	if err := ValidateUnits(p.Units); err != nil {
+		return err
+	}
+	if err := ValidateDatum(p.Datum); err != nil {
+		return err
+	}
+	if len(p.Favorites) > MaxFavorites {
+		return fmt.Errorf("at most %d favorite stations are allowed", MaxFavorites)
+	}
+	if len(p.DeletedFavorites) > MaxFavorites {
+		return fmt.Errorf("at most %d removed favorite stations are kept", MaxFavorites)
+	}
+
+	seen := make(map[string]bool, len(p.Favorites))
+	for _, f := range p.Favorites {
+		if f.StationID == "" {
+			return fmt.Errorf("favorite station ID is required")
+		}
+		if seen[f.StationID] {
+			return fmt.Errorf("duplicate favorite station: %s", f.StationID)
+		}
+		seen[f.StationID] = true
+	}
+	return nil
+}
+
+// FavoriteIndex returns the position of stationID in the favorites, or -1
+func (p *UserProfile) FavoriteIndex(stationID string) int {
+	return favoriteIndex(p.Favorites, stationID)
+}
+
+// DeletedFavoriteIndex returns the position of stationID in the removed favorites, or -1
+func (p *UserProfile) DeletedFavoriteIndex(stationID string) int {
+	return favoriteIndex(p.DeletedFavorites, stationID)
+}
+
+func favoriteIndex(favorites []FavoriteStation, stationID string) int {
+	for i, f := range favorites {
+		if f.StationID == stationID {
+			return i
+		}
+	}
+	return -1
+}
+
+func ValidateUnits(units string) error {
+	return validate.Kind(validate.OneOf("units", units, UnitsEnglish, UnitsMetric), ErrInvalidUnits)
+}
+
+func ValidateDatum(datum string) error {
+	return validate.Kind(validate.OneOf("datum", datum, validDatums...), ErrInvalidDatum)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserProfile_Validate(t *testing.T) {
+	assert.NoError(t, NewUserProfile("user-1").Validate())
+
+	tests := []struct {
+		name   string
+		modify func(p *UserProfile)
+		want   string
+	}{
+		{name: "missing user", modify: func(p *UserProfile) { p.UserID = "" }, want: "user ID is required"},
+		{name: "bad units", modify: func(p *UserProfile) { p.Units = "imperial" }, want: `invalid units "imperial"`},
+		{name: "bad datum", modify: func(p *UserProfile) { p.Datum = "XYZ" }, want: `invalid datum "XYZ"`},
+		{name: "missing station", modify: func(p *UserProfile) {
+			p.Favorites = []FavoriteStation{{Name: "Seattle"}}
+		}, want: "favorite station ID is required"},
+		{name: "duplicate station", modify: func(p *UserProfile) {
+			p.Favorites = []FavoriteStation{{StationID: "9447130"}, {StationID: "9447130"}}
+		}, want: "duplicate favorite station: 9447130"},
+		{name: "too many", modify: func(p *UserProfile) {
+			p.Favorites = make([]FavoriteStation, MaxFavorites+1)
+		}, want: "at most 100 favorite stations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewUserProfile("user-1")
+			tt.modify(p)
+			assert.ErrorContains(t, p.Validate(), tt.want)
+		})
+	}
+}
+
+func TestUserProfile_FavoriteIndex(t *testing.T) {
+	p := NewUserProfile("user-1")
+	p.Favorites = []FavoriteStation{{StationID: "9447130"}, {StationID: "9446484"}}
+	assert.Equal(t, 1, p.FavoriteIndex("9446484"))
+	assert.Equal(t, -1, p.FavoriteIndex("0000000"))
+}
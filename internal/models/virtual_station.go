@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// VirtualStationPrefix starts the ID of every virtual station
+const VirtualStationPrefix = "virtual:"
+
+// MaxVirtualOffsetMinutes bounds how far a reference station's tides can be shifted
+const MaxVirtualOffsetMinutes = 360
+
+// VirtualReference is one of the stations a virtual station is blended from. Weight is its
+// share of the blend and OffsetMinutes how much later its tides arrive at the virtual station.
+type VirtualReference struct {
+	StationID     string
+	Weight        float64
+	OffsetMinutes int
+}
+
+// VirtualStation estimates tides at a point without a station of its own, such as a bay
+// between two stations, by blending the two stations' predictions. Its ID carries the whole
+// definition, so it needs no registration and can be used anywhere a station ID can:
+//
+//	virtual:LAT:LON:STATION*WEIGHT@OFFSET:STATION*WEIGHT@OFFSET
+//
+// e.g. virtual:47.55:-122.45:9447130*0.6@15:9446484*0.4@-10. An omitted @OFFSET is 0.
+type VirtualStation struct {
+	Latitude   float64
+	Longitude  float64
+	References [2]VirtualReference
+}
+
+// IsVirtualStationID reports whether id names a virtual station
+func IsVirtualStationID(id string) bool {
+	return strings.HasPrefix(id, VirtualStationPrefix)
+}
+
+// ParseVirtualStationID parses and validates a virtual station ID
+func ParseVirtualStationID(id string) (*VirtualStation, error) {
+	if !IsVirtualStationID(id) {
+		return nil, fmt.Errorf("invalid virtual station %q: must start with %q", id, VirtualStationPrefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(id, VirtualStationPrefix), ":")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("invalid virtual station %q: must be virtual:LAT:LON:STATION*WEIGHT@OFFSET:STATION*WEIGHT@OFFSET", id)
+	}
+
+	v := &VirtualStation{}
+	var err error
+	if v.Latitude, err = strconv.ParseFloat(parts[0], 64); err != nil || v.Latitude < -90 || v.Latitude > 90 {
+		return nil, fmt.Errorf("invalid virtual station %q: latitude must be between -90 and 90", id)
+	}
+	if v.Longitude, err = strconv.ParseFloat(parts[1], 64); err != nil || v.Longitude < -180 || v.Longitude > 180 {
+		return nil, fmt.Errorf("invalid virtual station %q: longitude must be between -180 and 180", id)
+	}
+	for i, part := range parts[2:] {
+		if v.References[i], err = parseVirtualReference(part); err != nil {
+			return nil, fmt.Errorf("invalid virtual station %q: %w", id, err)
+		}
+	}
+	if v.References[0].StationID == v.References[1].StationID {
+		return nil, fmt.Errorf("invalid virtual station %q: reference stations must differ", id)
+	}
+	return v, nil
+}
+
+func parseVirtualReference(s string) (VirtualReference, error) {
+	var ref VirtualReference
+	spec, offset, hasOffset := strings.Cut(s, "@")
+	stationID, weight, ok := strings.Cut(spec, "*")
+	if !ok || stationID == "" {
+		return ref, fmt.Errorf("reference %q must be STATION*WEIGHT@OFFSET", s)
+	}
+	ref.StationID = stationID
+
+	var err error
+	if ref.Weight, err = strconv.ParseFloat(weight, 64); err != nil || !(ref.Weight > 0) || math.IsInf(ref.Weight, 0) {
+		return ref, fmt.Errorf("weight %q for station %s must be a positive number", weight, stationID)
+	}
+	if hasOffset {
+		if ref.OffsetMinutes, err = strconv.Atoi(offset); err != nil || ref.OffsetMinutes < -MaxVirtualOffsetMinutes || ref.OffsetMinutes > MaxVirtualOffsetMinutes {
+			return ref, fmt.Errorf("offset %q for station %s must be whole minutes between -%d and %d",
+				offset, stationID, MaxVirtualOffsetMinutes, MaxVirtualOffsetMinutes)
+		}
+	}
+	return ref, nil
+}
+
+// ID returns the station's canonical ID. Coordinates are rounded to 4 decimal places
+// (about 10 m), so equivalent definitions share one ID and one set of cached predictions.
+func (v *VirtualStation) ID() string {
+	var b strings.Builder
+	b.WriteString(VirtualStationPrefix)
+	b.WriteString(formatVirtualFloat(math.Round(v.Latitude*1e4) / 1e4))
+	b.WriteByte(':')
+	b.WriteString(formatVirtualFloat(math.Round(v.Longitude*1e4) / 1e4))
+	for _, ref := range v.References {
+		fmt.Fprintf(&b, ":%s*%s", ref.StationID, formatVirtualFloat(ref.Weight))
+		if ref.OffsetMinutes != 0 {
+			fmt.Fprintf(&b, "@%d", ref.OffsetMinutes)
+		}
+	}
+	return b.String()
+}
+
+func formatVirtualFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVirtualStationID(t *testing.T) {
+	v, err := ParseVirtualStationID("virtual:47.55:-122.45:9447130*0.6@15:9446484*0.4@-10")
+	require.NoError(t, err)
+	assert.Equal(t, &VirtualStation{
+		Latitude:  47.55,
+		Longitude: -122.45,
+		References: [2]VirtualReference{
+			{StationID: "9447130", Weight: 0.6, OffsetMinutes: 15},
+			{StationID: "9446484", Weight: 0.4, OffsetMinutes: -10},
+		},
+	}, v)
+	assert.Equal(t, "virtual:47.55:-122.45:9447130*0.6@15:9446484*0.4@-10", v.ID())
+
+	// Equivalent spellings share one canonical ID
+	v, err = ParseVirtualStationID("virtual:47.550001:-122.4500:9447130*1.0@0:9446484*2")
+	require.NoError(t, err)
+	assert.Equal(t, "virtual:47.55:-122.45:9447130*1:9446484*2", v.ID())
+
+	for name, id := range map[string]string{
+		"not virtual":       "9447130",
+		"one reference":     "virtual:47.55:-122.45:9447130*1",
+		"bad latitude":      "virtual:95:-122.45:9447130*1:9446484*1",
+		"bad longitude":     "virtual:47.55:east:9447130*1:9446484*1",
+		"missing weight":    "virtual:47.55:-122.45:9447130:9446484*1",
+		"zero weight":       "virtual:47.55:-122.45:9447130*0:9446484*1",
+		"negative weight":   "virtual:47.55:-122.45:9447130*-1:9446484*1",
+		"fractional offset": "virtual:47.55:-122.45:9447130*1@1.5:9446484*1",
+		"offset too large":  "virtual:47.55:-122.45:9447130*1@361:9446484*1",
+		"same station":      "virtual:47.55:-122.45:9447130*1:9447130*1@30",
+	} {
+		_, err := ParseVirtualStationID(id)
+		assert.Error(t, err, name)
+	}
+	assert.True(t, IsVirtualStationID("virtual:47.55:-122.45:9447130*1:9446484*1"))
+	assert.False(t, IsVirtualStationID("9447130"))
+}
@@ -0,0 +1,24 @@
+package models
+
+// WeatherSourceNWS marks forecasts from the US National Weather Service
+const WeatherSourceNWS = "NWS"
+
+// MarineWeather is the wind and pressure forecast at a station over a tide response's range.
+// When the forecast can't be fetched Available is false and Forecast is empty, so the tides
+// are still returned.
+type MarineWeather struct {
+	Source    string            `json:"source"`
+	Available bool              `json:"available"`
+	Forecast  []WeatherForecast `json:"forecast"`
+}
+
+// WeatherForecast is the forecast for the hour starting at Timestamp. Fields the
+// forecast doesn't cover are nil.
+type WeatherForecast struct {
+	Timestamp            Millis   `json:"timestamp"`
+	LocalTime            string   `json:"localTime"`
+	WindSpeedKnots       *float64 `json:"windSpeedKnots"`
+	WindGustKnots        *float64 `json:"windGustKnots"`
+	WindDirectionDegrees *float64 `json:"windDirectionDegrees"`
+	PressureHpa          *float64 `json:"pressureHpa"`
+}
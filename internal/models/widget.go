@@ -0,0 +1,41 @@
+package models
+
+// TideWidget is the compact payload of the embeddable tide module: the level now, which
+// way it's heading and the next highs and lows, with the colors to draw them in
+type TideWidget struct {
+	ResponseType string        `json:"responseType"`
+	StationID    string        `json:"stationId"`
+	StationName  string        `json:"stationName"`
+	TimeZone     string        `json:"timeZone,omitempty"` // IANA zone, when known
+	Timestamp    Millis        `json:"timestamp"`
+	LocalTime    string        `json:"localTime"`
+	WaterLevel   *float64      `json:"waterLevel"`
+	TideType     *TideType     `json:"tideType"`
+	Next         []TideExtreme `json:"next"`
+	Theme        WidgetTheme   `json:"theme"`
+	// URL is the widget's page, which the module links to
+	URL string `json:"url"`
+}
+
+// WidgetTheme is a named color scheme, with colors as #rrggbb
+type WidgetTheme struct {
+	Name       string `json:"name"`
+	Background string `json:"background"`
+	Foreground string `json:"foreground"`
+	Accent     string `json:"accent"`
+}
+
+// OEmbed is an oEmbed 1.0 response for a widget page: a "rich" embed whose HTML is an
+// iframe of the page. The field names are the spec's.
+type OEmbed struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	// CacheAge is how many seconds the consumer may cache the response for
+	CacheAge int    `json:"cache_age"`
+	HTML     string `json:"html"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
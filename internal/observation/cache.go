@@ -0,0 +1,68 @@
+package observation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	// DefaultCacheSize is how many station readings are kept
+	DefaultCacheSize = 1000
+	// DefaultTTL matches the 6 minutes between NOAA sensor readings
+	DefaultTTL = 6 * time.Minute
+	// failureTTL is how long a failed lookup is remembered, so a broken sensor doesn't
+	// cost an upstream request on every tide lookup
+	failureTTL = time.Minute
+)
+
+type cacheEntry struct {
+	observation *models.Observation
+	err         error
+	expiresAt   time.Time
+}
+
+// Cached is an Observer that reuses another's readings for ttl. Failures are cached
+// briefly too.
+type Cached struct {
+	next    Observer
+	entries *lru.Cache[string, cacheEntry]
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+var _ Observer = (*Cached)(nil)
+
+// NewCached returns a Cached holding up to size readings
+func NewCached(next Observer, size int, ttl time.Duration) (*Cached, error) {
+	entries, err := lru.New[string, cacheEntry](size)
+	if err != nil {
+		return nil, fmt.Errorf("creating observation cache: %w", err)
+	}
+	return &Cached{next: next, entries: entries, ttl: ttl, now: time.Now}, nil
+}
+
+func (c *Cached) Latest(ctx context.Context, stationID string, product Product) (*models.Observation, error) {
+	key := stationID + ":" + product.Name
+	if entry, ok := c.entries.Get(key); ok && c.now().Before(entry.expiresAt) {
+		models.RecordCacheLookups(ctx, 1, 0)
+		return entry.observation, entry.err
+	}
+	models.RecordCacheLookups(ctx, 0, 1)
+
+	observation, err := c.next.Latest(ctx, stationID, product)
+	if errors.Is(err, context.Canceled) {
+		// The caller gave up, which says nothing about the next request
+		return nil, err
+	}
+	ttl := c.ttl
+	if err != nil {
+		ttl = failureTTL
+	}
+	c.entries.Add(key, cacheEntry{observation: observation, err: err, expiresAt: c.now().Add(ttl)})
+	return observation, err
+}
@@ -0,0 +1,84 @@
+package observation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+type mockObserver struct {
+	calls int
+	err   error
+}
+
+func (m *mockObserver) Latest(_ context.Context, _ string, product Product) (*models.Observation, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Observation{Timestamp: models.Millis(m.calls), Units: product.Units}, nil
+}
+
+func newTestCached(t *testing.T, next Observer, now *time.Time) *Cached {
+	cached, err := NewCached(next, 10, DefaultTTL)
+	require.NoError(t, err)
+	cached.now = func() time.Time { return *now }
+	return cached
+}
+
+func TestCachedLatest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := &mockObserver{}
+	cached := newTestCached(t, next, &now)
+
+	first, err := cached.Latest(ctx, "9447130", WaterTemperature)
+	require.NoError(t, err)
+	again, err := cached.Latest(ctx, "9447130", WaterTemperature)
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+	assert.Equal(t, 1, next.calls)
+
+	// Each product and station has its own reading
+	_, err = cached.Latest(ctx, "9447130", Conductivity)
+	require.NoError(t, err)
+	_, err = cached.Latest(ctx, "9444900", WaterTemperature)
+	require.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+
+	now = now.Add(DefaultTTL)
+	refreshed, err := cached.Latest(ctx, "9447130", WaterTemperature)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Timestamp, refreshed.Timestamp)
+}
+
+func TestCachedLatestFailures(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := &mockObserver{err: errors.New("fetching water_temperature: No data was found")}
+	cached := newTestCached(t, next, &now)
+
+	_, err := cached.Latest(ctx, "9447130", WaterTemperature)
+	assert.Error(t, err)
+	_, err = cached.Latest(ctx, "9447130", WaterTemperature)
+	assert.Error(t, err)
+	assert.Equal(t, 1, next.calls, "failures should be remembered")
+
+	now = now.Add(2 * time.Minute)
+	next.err = nil
+	_, err = cached.Latest(ctx, "9447130", WaterTemperature)
+	assert.NoError(t, err)
+
+	next.err = context.Canceled
+	_, err = cached.Latest(ctx, "9444900", WaterTemperature)
+	assert.Error(t, err)
+	next.err = nil
+	_, err = cached.Latest(ctx, "9444900", WaterTemperature)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+package observation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+// NOAA is an Observer backed by the NOAA CO-OPS datagetter API, the same API tide
+// predictions come from
+type NOAA struct {
+	httpClient *client.Client
+}
+
+var _ Observer = (*NOAA)(nil)
+
+// NewNOAA returns a NOAA observer. httpClient's base URL is the CO-OPS API's.
+func NewNOAA(httpClient *client.Client) *NOAA {
+	return &NOAA{httpClient: httpClient}
+}
+
+// noaaObservations is a datagetter response for an observed product
+type noaaObservations struct {
+	Data []struct {
+		Time  string `json:"t"`
+		Value string `json:"v"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func (n *NOAA) Latest(ctx context.Context, stationID string, product Product) (*models.Observation, error) {
+	path := fmt.Sprintf("/api/prod/datagetter"+
+		"?station=%s&date=latest&product=%s&units=english&time_zone=gmt&format=json",
+		url.QueryEscape(stationID), product.Name)
+	if product.Datum != "" {
+		path += "&datum=" + product.Datum
+	}
+	resp, err := n.httpClient.Get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", product.Name, err)
+	}
+
+	var observations noaaObservations
+	if err := json.Unmarshal(resp.Body, &observations); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", product.Name, err)
+	}
+	if observations.Error != nil {
+		return nil, fmt.Errorf("fetching %s: %s", product.Name, observations.Error.Message)
+	}
+	if len(observations.Data) == 0 {
+		return nil, fmt.Errorf("no %s reading for station %s", product.Name, stationID)
+	}
+
+	latest := observations.Data[len(observations.Data)-1]
+	t, err := time.Parse("2006-01-02 15:04", latest.Time)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s time %q: %w", product.Name, latest.Time, err)
+	}
+	// NOAA leaves the value empty when the sensor reported nothing
+	value, err := strconv.ParseFloat(latest.Value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("no %s reading for station %s", product.Name, stationID)
+	}
+	return &models.Observation{
+		Timestamp: models.MillisOf(t),
+		Value:     value,
+		Units:     product.Units,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package observation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+func newTestNOAA(t *testing.T, handler http.HandlerFunc) *NOAA {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewNOAA(client.New(client.Options{BaseURL: srv.URL}))
+}
+
+func TestNOAALatest(t *testing.T) {
+	var query map[string]string
+	noaa := newTestNOAA(t, func(w http.ResponseWriter, r *http.Request) {
+		query = map[string]string{}
+		for key := range r.URL.Query() {
+			query[key] = r.URL.Query().Get(key)
+		}
+		_, _ = w.Write([]byte(`{"metadata": {"id": "9447130", "name": "Seattle"},
+			"data": [{"t": "2024-01-01 12:00", "v": "48.2", "f": "0,0,0"}, {"t": "2024-01-01 12:06", "v": "48.4", "f": "0,0,0"}]}`))
+	})
+
+	got, err := noaa.Latest(context.Background(), "9447130", WaterTemperature)
+	require.NoError(t, err)
+	assert.Equal(t, "water_temperature", query["product"])
+	assert.Equal(t, "latest", query["date"])
+	assert.Equal(t, "gmt", query["time_zone"])
+	assert.NotContains(t, query, "datum")
+	assert.Equal(t, &models.Observation{
+		Timestamp: models.MillisOf(time.Date(2024, 1, 1, 12, 6, 0, 0, time.UTC)),
+		Value:     48.4,
+		Units:     "degF",
+	}, got)
+
+	got, err = noaa.Latest(context.Background(), "9447130", WaterLevel)
+	require.NoError(t, err)
+	assert.Equal(t, "water_level", query["product"])
+	assert.Equal(t, "MLLW", query["datum"], "levels are measured from the predictions' datum")
+	assert.Equal(t, "ft", got.Units)
+}
+
+func TestNOAALatestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"product not offered", `{"error": {"message": "No data was found. This product may not be offered at this station at the requested time."}}`},
+		{"no readings", `{"data": []}`},
+		{"empty value", `{"data": [{"t": "2024-01-01 12:00", "v": "", "f": "1,1,1"}]}`},
+		{"bad time", `{"data": [{"t": "noon", "v": "48.2"}]}`},
+		{"not JSON", `<html>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			noaa := newTestNOAA(t, func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			})
+			_, err := noaa.Latest(context.Background(), "9447130", Conductivity)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestProductsFor(t *testing.T) {
+	station := &models.Station{Capabilities: []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature}}
+	assert.Equal(t, []Product{WaterTemperature}, ProductsFor(station))
+
+	station.Capabilities = append(station.Capabilities, models.CapabilityConductivity)
+	assert.Equal(t, []Product{WaterTemperature, Conductivity}, ProductsFor(station))
+
+	assert.Empty(t, ProductsFor(&models.Station{Capabilities: []string{models.CapabilityWaterLevel}}))
+}
@@ -0,0 +1,73 @@
+// Package observation fetches live readings from the sensors some tide stations carry
+// besides their water level gauge, such as water temperature for swimmers and anglers
+package observation
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Product is a NOAA data product measured by a station sensor
+type Product struct {
+	// Name is the datagetter product, e.g. water_temperature
+	Name string
+	// Capability marks the stations that measure it
+	Capability string
+	Units      string
+	// Datum is the datum levels are measured from, for products that are levels
+	Datum string
+}
+
+var (
+	WaterTemperature = Product{Name: "water_temperature", Capability: models.CapabilityWaterTemperature, Units: "degF"}
+	Conductivity     = Product{Name: "conductivity", Capability: models.CapabilityConductivity, Units: "mS/cm"}
+	AirTemperature   = Product{Name: "air_temperature", Capability: models.CapabilityAirTemperature, Units: "degF"}
+	AirPressure      = Product{Name: "air_pressure", Capability: models.CapabilityAirPressure, Units: "mb"}
+)
+
+// Products lists the sensor products an Observer can be asked for
+var Products = []Product{WaterTemperature, Conductivity, AirTemperature, AirPressure}
+
+// WaterLevel is the gauge reading predictions are checked against. It isn't one of
+// Products, since tide responses already carry the predicted level; it's measured from
+// MLLW like the predictions.
+var WaterLevel = Product{Name: "water_level", Capability: models.CapabilityWaterLevel, Units: "ft", Datum: "MLLW"}
+
+// Names lists the products' names
+func Names(products []Product) []string {
+	names := make([]string, len(products))
+	for i, p := range products {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// ProductByName returns the product with the given name, ignoring case
+func ProductByName(name string) (Product, bool) {
+	for _, p := range Products {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return Product{}, false
+}
+
+// ProductsFor returns the products the station measures
+func ProductsFor(station *models.Station) []Product {
+	var products []Product
+	for _, p := range Products {
+		if station.HasCapability(p.Capability) {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+// Observer gets a station's latest sensor readings
+type Observer interface {
+	// Latest returns the station's most recent reading of product. LocalTime is left for the
+	// caller to fill in, since only it knows the station's time zone.
+	Latest(ctx context.Context, stationID string, product Product) (*models.Observation, error)
+}
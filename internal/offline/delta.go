@@ -0,0 +1,167 @@
+package offline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/stationchange"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultSyncDays is how many days from today a client keeps when it doesn't say
+	DefaultSyncDays = 7
+	// MaxSyncDays is the most days from today a client can keep
+	MaxSyncDays = 14
+	// MaxSyncStations is the most stations a client can sync at once
+	MaxSyncStations = 20
+)
+
+// changeLimit is how many of a source's station list changes are read for a sync. A
+// client that has missed more than that many is synced in full.
+const changeLimit = 100
+
+// Delta works out which days of predictions an offline client is missing since it last
+// synced. A client keeps the same number of days from today, so after its first sync it's
+// missing only the days that have come into that window since, unless a station changed:
+// then its predictions may have too, and the client gets all of that station's days again.
+type Delta struct {
+	tides  models.TideProvider
+	finder models.StationFinder
+	// changes is nil when station list changes aren't recorded, and then only new days
+	// are sent after a client's first sync
+	changes stationchange.Store
+	now     func() time.Time
+}
+
+func NewDelta(tides models.TideProvider, finder models.StationFinder, changes stationchange.Store) *Delta {
+	return &Delta{tides: tides, finder: finder, changes: changes, now: time.Now}
+}
+
+// Sync returns what a client keeping days days of stationIDs' predictions is missing since
+// it synced at since, or every day when since is zero. The client should ask for the same
+// number of days each time, or sync from zero when it changes them.
+func (d *Delta) Sync(ctx context.Context, stationIDs []string, since time.Time, days int) (*models.PredictionSync, error) {
+	if err := validate.Between("stationIds", float64(len(stationIDs)), 1, MaxSyncStations); err != nil {
+		return nil, err
+	}
+	if err := validate.Between("days", float64(days), 1, MaxSyncDays); err != nil {
+		return nil, err
+	}
+
+	now := d.now()
+	// A sync from the future can't be trusted to have anything
+	if since.After(now) {
+		since = time.Time{}
+	}
+	changed := make(map[models.Source]changedStations)
+	response := &models.PredictionSync{
+		ResponseType: "sync",
+		SyncedAt:     models.MillisOf(now),
+		Stations:     make([]models.StationSync, 0, len(stationIDs)),
+	}
+	for _, id := range stationIDs {
+		station, err := models.FindStation(ctx, d.finder, id)
+		if err != nil {
+			return nil, fmt.Errorf("finding station: %w", err)
+		}
+		if station == nil {
+			return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, id)
+		}
+
+		source := station.Source
+		if source == "" {
+			source = models.SourceNOAA
+		}
+		if _, ok := changed[source]; !ok && !since.IsZero() {
+			changed[source] = d.changedSince(ctx, source, since)
+		}
+
+		location := station.Location()
+		start := startOfDay(now.In(location))
+		end := start.AddDate(0, 0, days-1)
+		sync := models.StationSync{
+			StationID:   station.ID,
+			StationName: station.Name,
+			TimeZone:    station.TimeZone,
+			StartDate:   start.Format("2006-01-02"),
+			EndDate:     end.Format("2006-01-02"),
+			Full:        since.IsZero() || changed[source].includes(station.ID),
+			Days:        []models.DayPredictions{},
+		}
+		from := start
+		if !sync.Full {
+			// The first day the last sync didn't cover
+			if next := startOfDay(since.In(location)).AddDate(0, 0, days); next.After(from) {
+				from = next
+			}
+		}
+
+		missing := 0
+		for day := from; !day.After(end); day = day.AddDate(0, 0, 1) {
+			missing++
+		}
+		if missing > 0 {
+			startDate := from.Format("2006-01-02")
+			predictions, err := d.tides.GetDailyPredictions(ctx, station.ID, &startDate, missing)
+			if err != nil {
+				return nil, err
+			}
+			sync.Days = predictions.Days
+		}
+		response.Stations = append(response.Stations, sync)
+	}
+	return response, nil
+}
+
+// changedStations are the stations added to or modified in a source's list since a sync.
+// all is set when some of them can't be told, so every station is treated as changed.
+type changedStations struct {
+	ids map[string]bool
+	all bool
+}
+
+func (c changedStations) includes(stationID string) bool {
+	return c.all || c.ids[stationID]
+}
+
+// changedSince returns the stations added or modified in source's list after since. When
+// the changes can't be read every station is treated as changed, since a client sent
+// everything again is better off than one that keeps predictions that changed.
+func (d *Delta) changedSince(ctx context.Context, source models.Source, since time.Time) changedStations {
+	changed := changedStations{ids: make(map[string]bool)}
+	if d.changes == nil {
+		return changed
+	}
+	changes, err := d.changes.List(ctx, source, since, changeLimit)
+	if err != nil {
+		log.Warn().Err(err).Str("source", string(source)).Msg("Reading station list changes failed, syncing stations in full")
+		return changedStations{all: true}
+	}
+	if len(changes) == changeLimit {
+		changed.all = true
+	}
+	for _, change := range changes {
+		// List goes by the day; only those after the last sync count
+		if !change.DetectedAt.Time().After(since) {
+			continue
+		}
+		if change.Omitted > 0 {
+			changed.all = true
+		}
+		for _, s := range change.Stations {
+			if s.Change == models.StationAdded || s.Change == models.StationModified {
+				changed.ids[s.StationID] = true
+			}
+		}
+	}
+	return changed
+}
+
+// startOfDay returns midnight of t's day in t's location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
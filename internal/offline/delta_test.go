@@ -0,0 +1,159 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeFinder struct {
+	models.StationFinder
+}
+
+func (fakeFinder) FindStation(_ context.Context, stationID string) (*models.Station, error) {
+	if stationID == "0000000" {
+		return nil, nil
+	}
+	return &models.Station{ID: stationID, Name: "Station " + stationID, TimeZone: "America/Los_Angeles", Source: models.SourceNOAA}, nil
+}
+
+type requested struct {
+	stationID, startDate string
+	days                 int
+}
+
+type fakeTides struct {
+	models.TideProvider
+	requested []requested
+}
+
+func (f *fakeTides) GetDailyPredictions(_ context.Context, stationID string, startDate *string, days int) (*models.DailyPredictions, error) {
+	f.requested = append(f.requested, requested{stationID, *startDate, days})
+	start, _ := time.Parse("2006-01-02", *startDate)
+	response := &models.DailyPredictions{StationID: stationID}
+	for i := 0; i < days; i++ {
+		response.Days = append(response.Days, models.DayPredictions{Date: start.AddDate(0, 0, i).Format("2006-01-02")})
+	}
+	return response, nil
+}
+
+type fakeChanges struct {
+	changes []models.StationListChange
+	err     error
+	since   time.Time
+}
+
+func (f *fakeChanges) Put(context.Context, *models.StationListChange) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeChanges) List(_ context.Context, _ models.Source, since time.Time, _ int) ([]models.StationListChange, error) {
+	f.since = since
+	return f.changes, f.err
+}
+
+func dates(days []models.DayPredictions) []string {
+	var dates []string
+	for _, day := range days {
+		dates = append(dates, day.Date)
+	}
+	return dates
+}
+
+func TestDelta_Sync(t *testing.T) {
+	seattle, _ := time.LoadLocation("America/Los_Angeles")
+	now := time.Date(2025, 7, 10, 9, 0, 0, 0, seattle)
+	tides := &fakeTides{}
+	changes := &fakeChanges{}
+	delta := NewDelta(tides, fakeFinder{}, changes)
+	delta.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	sync, err := delta.Sync(ctx, []string{"9447130", "9444900"}, time.Time{}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "sync", sync.ResponseType)
+	assert.Equal(t, models.MillisOf(now), sync.SyncedAt)
+	require.Len(t, sync.Stations, 2)
+	first := sync.Stations[0]
+	assert.Equal(t, "9447130", first.StationID)
+	assert.Equal(t, "Station 9447130", first.StationName)
+	assert.Equal(t, "2025-07-10", first.StartDate)
+	assert.Equal(t, "2025-07-12", first.EndDate)
+	assert.True(t, first.Full, "a first sync gets every day")
+	assert.Equal(t, []string{"2025-07-10", "2025-07-11", "2025-07-12"}, dates(first.Days))
+	assert.Zero(t, changes.since, "there's nothing to have changed since")
+
+	// Two days later, the client is missing the two days that came into its window
+	lastSync := now
+	now = now.AddDate(0, 0, 2)
+	tides.requested = nil
+	sync, err = delta.Sync(ctx, []string{"9447130"}, lastSync, 3)
+	require.NoError(t, err)
+	station := sync.Stations[0]
+	assert.False(t, station.Full)
+	assert.Equal(t, "2025-07-12", station.StartDate, "the client can drop the days before")
+	assert.Equal(t, []string{"2025-07-13", "2025-07-14"}, dates(station.Days))
+	assert.Equal(t, []requested{{"9447130", "2025-07-13", 2}}, tides.requested)
+	assert.Equal(t, lastSync, changes.since)
+
+	// The same day, there's nothing new
+	tides.requested = nil
+	sync, err = delta.Sync(ctx, []string{"9447130"}, now.Add(-time.Hour), 3)
+	require.NoError(t, err)
+	assert.Empty(t, sync.Stations[0].Days)
+	assert.NotNil(t, sync.Stations[0].Days, "days is an empty list rather than null")
+	assert.Empty(t, tides.requested)
+
+	// A station modified since is sent in full; changes before the last sync don't count
+	changes.changes = []models.StationListChange{
+		{DetectedAt: models.MillisOf(now.Add(-time.Minute)), Stations: []models.StationChange{{StationID: "9447130", Change: models.StationModified}}},
+		{DetectedAt: models.MillisOf(now.Add(-2 * time.Hour)), Stations: []models.StationChange{{StationID: "9444900", Change: models.StationModified}}},
+	}
+	sync, err = delta.Sync(ctx, []string{"9447130", "9444900"}, now.Add(-time.Hour), 3)
+	require.NoError(t, err)
+	assert.True(t, sync.Stations[0].Full)
+	assert.Equal(t, []string{"2025-07-12", "2025-07-13", "2025-07-14"}, dates(sync.Stations[0].Days))
+	assert.False(t, sync.Stations[1].Full)
+	assert.Empty(t, sync.Stations[1].Days)
+
+	// Changes that leave stations out, or can't be read, could be any station's
+	changes.changes = []models.StationListChange{{DetectedAt: models.MillisOf(now), Omitted: 3}}
+	sync, err = delta.Sync(ctx, []string{"9444900"}, now.Add(-time.Hour), 3)
+	require.NoError(t, err)
+	assert.True(t, sync.Stations[0].Full)
+	changes.changes, changes.err = nil, errors.New("throttled")
+	sync, err = delta.Sync(ctx, []string{"9444900"}, now.Add(-time.Hour), 3)
+	require.NoError(t, err)
+	assert.True(t, sync.Stations[0].Full)
+
+	// A sync from the future is no sync at all
+	changes.err = nil
+	sync, err = delta.Sync(ctx, []string{"9444900"}, now.Add(time.Hour), 3)
+	require.NoError(t, err)
+	assert.True(t, sync.Stations[0].Full)
+}
+
+func TestDelta_Sync_Errors(t *testing.T) {
+	delta := NewDelta(&fakeTides{}, fakeFinder{}, nil)
+	ctx := context.Background()
+
+	_, err := delta.Sync(ctx, []string{"0000000"}, time.Time{}, 7)
+	assert.ErrorIs(t, err, models.ErrStationNotFound)
+
+	_, err = delta.Sync(ctx, nil, time.Time{}, 7)
+	assert.ErrorContains(t, err, "invalid stationIds")
+	_, err = delta.Sync(ctx, make([]string, MaxSyncStations+1), time.Time{}, 7)
+	assert.ErrorContains(t, err, "invalid stationIds")
+	_, err = delta.Sync(ctx, []string{"9447130"}, time.Time{}, MaxSyncDays+1)
+	assert.ErrorContains(t, err, "invalid days")
+
+	// Without recorded changes only new days are sent
+	sync, err := delta.Sync(ctx, []string{"9447130"}, time.Now().Add(-time.Minute), 7)
+	require.NoError(t, err)
+	assert.False(t, sync.Stations[0].Full)
+}
@@ -0,0 +1,118 @@
+// Package offline keeps predictions at hand where NOAA can't be reached, like on a boat
+// out of range of shore: it fills a self-hosted server's caches while it can reach NOAA,
+// and works out what mobile clients keeping their own copies are missing since they last
+// synced
+package offline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// StationLoader loads the station list, revalidating an expired one with NOAA, like
+// station.NOAAStationFinder
+type StationLoader interface {
+	LoadStations(ctx context.Context) error
+}
+
+// PredictionWarmer refetches predictions into the cache, like tide.Service
+type PredictionWarmer interface {
+	WarmPredictions(ctx context.Context, stationID string, startDate, endDate time.Time) (int, error)
+}
+
+// Status is how the last sync went
+type Status struct {
+	// At is when it finished, zero before the first sync
+	At models.Millis `json:"at,omitempty"`
+	// Stations counts the stations whose predictions were refetched
+	Stations int `json:"stations"`
+	// Errors are what failed, empty when everything was synced
+	Errors []string `json:"errors,omitempty"`
+}
+
+// OK reports whether everything was synced
+func (s Status) OK() bool {
+	return len(s.Errors) == 0
+}
+
+// Syncer refreshes the station list and refetches days of predictions, from today on, for
+// each of its stations
+type Syncer struct {
+	stations   StationLoader
+	warmer     PredictionWarmer
+	stationIDs []string
+	days       int
+	now        func() time.Time
+
+	mu     sync.Mutex
+	status Status
+}
+
+func NewSyncer(stations StationLoader, warmer PredictionWarmer, stationIDs []string, days int) *Syncer {
+	return &Syncer{
+		stations:   stations,
+		warmer:     warmer,
+		stationIDs: stationIDs,
+		days:       days,
+		now:        time.Now,
+	}
+}
+
+// Sync refreshes the station list and predictions once. What fails is logged and kept in
+// the status rather than stopping the rest, since whatever does sync is worth keeping.
+func (s *Syncer) Sync(ctx context.Context) Status {
+	var status Status
+	if err := s.stations.LoadStations(ctx); err != nil {
+		log.Warn().Err(err).Msg("Syncing the station list failed")
+		status.Errors = append(status.Errors, fmt.Sprintf("station list: %v", err))
+	}
+
+	start := s.now()
+	end := start.AddDate(0, 0, s.days-1)
+	for _, id := range s.stationIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		days, err := s.warmer.WarmPredictions(ctx, id, start, end)
+		if err != nil {
+			log.Warn().Err(err).Str("station_id", id).Msg("Syncing predictions failed")
+			status.Errors = append(status.Errors, fmt.Sprintf("station %s: %v", id, err))
+			continue
+		}
+		log.Debug().Str("station_id", id).Int("days", days).Msg("Synced predictions")
+		status.Stations++
+	}
+
+	status.At = models.MillisOf(s.now())
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	log.Info().Int("stations", status.Stations).Int("errors", len(status.Errors)).Msg("Synced with NOAA")
+	return status
+}
+
+// Run syncs at once and then every interval until ctx is done
+func (s *Syncer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.Sync(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns how the last sync went
+func (s *Syncer) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
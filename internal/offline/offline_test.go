@@ -0,0 +1,92 @@
+package offline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLoader struct {
+	mu    sync.Mutex
+	loads int
+	err   error
+}
+
+func (f *fakeLoader) LoadStations(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loads++
+	return f.err
+}
+
+type warmed struct {
+	stationID  string
+	start, end time.Time
+}
+
+type fakeWarmer struct {
+	warmed []warmed
+	failed map[string]error
+}
+
+func (f *fakeWarmer) WarmPredictions(_ context.Context, stationID string, start, end time.Time) (int, error) {
+	if err := f.failed[stationID]; err != nil {
+		return 0, err
+	}
+	f.warmed = append(f.warmed, warmed{stationID, start, end})
+	return int(end.Sub(start).Hours()/24) + 1, nil
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	loader := &fakeLoader{}
+	warmer := &fakeWarmer{failed: map[string]error{"9444900": errors.New("NOAA unreachable")}}
+	syncer := NewSyncer(loader, warmer, []string{"9447130", "9444900"}, 7)
+	syncer.now = func() time.Time { return now }
+	assert.Zero(t, syncer.Status(), "nothing is synced before the first sync")
+
+	status := syncer.Sync(context.Background())
+	assert.Equal(t, 1, loader.loads)
+	assert.Equal(t, []warmed{{"9447130", now, now.AddDate(0, 0, 6)}}, warmer.warmed, "a week from today")
+	assert.Equal(t, 1, status.Stations)
+	assert.Equal(t, []string{"station 9444900: NOAA unreachable"}, status.Errors, "one station failing doesn't stop the others")
+	assert.False(t, status.OK())
+	assert.Equal(t, models.MillisOf(now), status.At)
+	assert.Equal(t, status, syncer.Status())
+
+	loader.err = errors.New("no route to host")
+	warmer.failed = nil
+	status = syncer.Sync(context.Background())
+	assert.Equal(t, 2, status.Stations, "predictions are synced even when the station list isn't")
+	assert.Equal(t, []string{"station list: no route to host"}, status.Errors)
+}
+
+func TestSyncer_Run(t *testing.T) {
+	loader := &fakeLoader{}
+	syncer := NewSyncer(loader, &fakeWarmer{}, nil, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		syncer.Run(ctx, time.Millisecond)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		loader.mu.Lock()
+		defer loader.mu.Unlock()
+		return loader.loads >= 2
+	}, time.Second, time.Millisecond, "it syncs at once and then on every tick")
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return once its context was done")
+	}
+	assert.True(t, syncer.Status().OK())
+}
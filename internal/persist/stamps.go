@@ -0,0 +1,64 @@
+// Package persist holds the bookkeeping shared by resources users create, such as
+// favorite stations: when each was created, changed and deleted, keeping deleted ones
+// for a while so they can be restored. Package audit logs the changes themselves.
+package persist
+
+import "time"
+
+// Stamps are when a resource was created, last changed and, once deleted, deleted.
+// Resources embed them, so they're stored as attributes of the resource itself.
+type Stamps struct {
+	CreatedAt int64 `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt int64 `json:"updatedAt" dynamodbav:"updatedAt"`
+	// DeletedAt is zero until the resource is deleted. A deleted resource is kept, hidden,
+	// until Expired, so an accidental deletion can be undone.
+	DeletedAt int64 `json:"deletedAt,omitempty" dynamodbav:"deletedAt,omitempty"`
+}
+
+// Create stamps a new resource
+func (s *Stamps) Create(now time.Time) {
+	s.CreatedAt = now.Unix()
+	s.UpdatedAt = s.CreatedAt
+	s.DeletedAt = 0
+}
+
+// Touch stamps a change to the resource
+func (s *Stamps) Touch(now time.Time) {
+	s.UpdatedAt = now.Unix()
+}
+
+// Delete soft deletes the resource
+func (s *Stamps) Delete(now time.Time) {
+	s.DeletedAt = now.Unix()
+	s.UpdatedAt = s.DeletedAt
+}
+
+// Restore undoes Delete
+func (s *Stamps) Restore(now time.Time) {
+	s.DeletedAt = 0
+	s.UpdatedAt = now.Unix()
+}
+
+// Deleted reports whether the resource was soft deleted
+func (s Stamps) Deleted() bool {
+	return s.DeletedAt != 0
+}
+
+// Expired reports whether the resource was deleted more than retention ago, so can no
+// longer be restored and may be purged
+func (s Stamps) Expired(now time.Time, retention time.Duration) bool {
+	return s.Deleted() && now.Sub(time.Unix(s.DeletedAt, 0)) > retention
+}
+
+// Purge returns the resources not deleted more than retention ago
+func Purge[T interface {
+	Expired(time.Time, time.Duration) bool
+}](resources []T, now time.Time, retention time.Duration) []T {
+	kept := make([]T, 0, len(resources))
+	for _, r := range resources {
+		if !r.Expired(now, retention) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,42 @@
+package persist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStamps(t *testing.T) {
+	created := time.Unix(1700000000, 0)
+	var s Stamps
+	s.Create(created)
+	assert.Equal(t, Stamps{CreatedAt: 1700000000, UpdatedAt: 1700000000}, s)
+
+	s.Touch(created.Add(time.Minute))
+	assert.Equal(t, int64(1700000060), s.UpdatedAt)
+	assert.False(t, s.Deleted())
+
+	deleted := created.Add(time.Hour)
+	s.Delete(deleted)
+	assert.True(t, s.Deleted())
+	assert.Equal(t, Stamps{CreatedAt: 1700000000, UpdatedAt: 1700003600, DeletedAt: 1700003600}, s)
+	assert.False(t, s.Expired(deleted.Add(24*time.Hour), 24*time.Hour))
+	assert.True(t, s.Expired(deleted.Add(24*time.Hour+time.Second), 24*time.Hour))
+
+	s.Restore(deleted.Add(time.Hour))
+	assert.Equal(t, Stamps{CreatedAt: 1700000000, UpdatedAt: 1700007200}, s)
+	assert.False(t, s.Expired(deleted.Add(1000*time.Hour), 24*time.Hour), "a live resource never expires")
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	resources := []Stamps{
+		{CreatedAt: 1, DeletedAt: now.Add(-48 * time.Hour).Unix()},
+		{CreatedAt: 2},
+		{CreatedAt: 3, DeletedAt: now.Add(-time.Hour).Unix()},
+	}
+
+	kept := Purge(resources, now, 24*time.Hour)
+	assert.Equal(t, []Stamps{resources[1], resources[2]}, kept)
+}
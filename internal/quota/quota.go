@@ -0,0 +1,196 @@
+// Package quota counts each API key's requests by month, day and endpoint in a store
+// every instance shares, turning away those beyond the key's monthly quota and reporting
+// what each key has used
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/metrics"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/userdata"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers tell a client with a quota how much of it is left on each response
+const (
+	LimitHeader     = "X-Quota-Limit"
+	RemainingHeader = "X-Quota-Remaining"
+	// ResetHeader is when the quota starts over, in RFC 3339
+	ResetHeader = "X-Quota-Reset"
+)
+
+const (
+	monthFormat = "2006-01"
+	dayFormat   = "2006-01-02"
+)
+
+// Error is returned for a request beyond its API key's monthly quota
+type Error struct {
+	Quota int
+	// ResetsAt is when the quota starts over: the start of the next month, UTC
+	ResetsAt time.Time
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("monthly quota of %d requests exceeded; it resets at %s", e.Quota, e.ResetsAt.Format(time.RFC3339))
+}
+
+// RetryAfterSeconds is how long until the quota resets in whole seconds, rounded up, for
+// a Retry-After header
+func (e *Error) RetryAfterSeconds() int {
+	return max(1, int(math.Ceil(time.Until(e.ResetsAt).Seconds())))
+}
+
+// Usage is what one API key asked of the API in a month
+type Usage struct {
+	// Month is YYYY-MM, in UTC
+	Month string `json:"month"`
+	// Quota is how many requests the key may make in the month; 0 doesn't limit it
+	Quota int `json:"quota"`
+	Used  int `json:"used"`
+	// Remaining is left out when there's no quota
+	Remaining *int          `json:"remaining,omitempty"`
+	ResetsAt  models.Millis `json:"resetsAt"`
+	// Days are the days with requests, in order
+	Days []DayUsage `json:"days"`
+}
+
+// DayUsage is what one API key asked of the API in a day, in UTC
+type DayUsage struct {
+	Date     string `json:"date"`
+	Requests int    `json:"requests"`
+	// Endpoints are the day's requests by API Gateway resource path
+	Endpoints map[string]int `json:"endpoints"`
+}
+
+// Handler is the signature of the Lambda handlers a Tracker counts the requests of
+type Handler = func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Tracker counts each API key's requests, holding each to its monthly quota. A nil
+// Tracker counts nothing, so entrypoints can use one whether or not quotas are configured.
+type Tracker struct {
+	store Store
+	// quota is every key's monthly quota but those in quotas, by key ID
+	quota  int
+	quotas map[string]int
+	now    func() time.Time
+}
+
+// NewTracker counts requests in store, allowing each API key quota requests a month, or
+// its entry in quotas, keyed by API Gateway key ID; zero doesn't limit a key
+func NewTracker(store Store, quota int, quotas map[string]int) *Tracker {
+	return &Tracker{store: store, quota: quota, quotas: quotas, now: time.Now}
+}
+
+// QuotaFor returns the monthly quota of the API key a request was made with
+func (t *Tracker) QuotaFor(request events.APIGatewayProxyRequest) int {
+	if quota, ok := t.quotas[request.RequestContext.Identity.APIKeyID]; ok {
+		return quota
+	}
+	return t.quota
+}
+
+// Wrap returns next with each request made with an API key counted against the key's
+// month, and those beyond its quota answered by reject with an *Error. Requests without a
+// key pass straight through, as do all of them when the store can't be reached. Responses
+// to keys with a quota say how much of it is left.
+func (t *Tracker) Wrap(next Handler, reject func(error) (events.APIGatewayProxyResponse, error)) Handler {
+	if t == nil {
+		return next
+	}
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		key := userdata.APIKeyFromRequest(request)
+		if key == "" {
+			return next(ctx, request)
+		}
+		now := t.now().UTC()
+		quota := t.QuotaFor(request)
+		resetsAt := monthStart(now).AddDate(0, 1, 0)
+		used, err := t.store.Add(ctx, key, now, metrics.Endpoint(request), quota)
+		switch {
+		case errors.Is(err, ErrExhausted):
+			return reject(&Error{Quota: quota, ResetsAt: resetsAt})
+		case err != nil:
+			log.Warn().Err(err).Msg("Request not counted against its API key's quota")
+			return next(ctx, request)
+		}
+
+		response, err := next(ctx, request)
+		if quota > 0 {
+			headers := make(map[string]string, len(response.Headers)+3)
+			maps.Copy(headers, response.Headers)
+			headers[LimitHeader] = strconv.Itoa(quota)
+			headers[RemainingHeader] = strconv.Itoa(max(0, quota-used))
+			headers[ResetHeader] = resetsAt.Format(time.RFC3339)
+			response.Headers = headers
+		}
+		return response, err
+	}
+}
+
+// Usage returns what the API key a request was made with used in the month holding month,
+// or ErrNoAPIKey for a request made without one
+func (t *Tracker) Usage(ctx context.Context, request events.APIGatewayProxyRequest, month time.Time) (*Usage, error) {
+	key := userdata.APIKeyFromRequest(request)
+	if key == "" {
+		return nil, ErrNoAPIKey
+	}
+	start := monthStart(month.UTC())
+	counts, err := t.store.Load(ctx, key, start)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := t.QuotaFor(request)
+	usage := &Usage{
+		Month:    start.Format(monthFormat),
+		Quota:    quota,
+		Used:     counts[totalAttribute],
+		ResetsAt: models.MillisOf(start.AddDate(0, 1, 0)),
+		Days:     []DayUsage{},
+	}
+	if quota > 0 {
+		remaining := max(0, quota-usage.Used)
+		usage.Remaining = &remaining
+	}
+	days := map[string]*DayUsage{}
+	for name, count := range counts {
+		date, endpoint, ok := strings.Cut(name, separator)
+		if !ok {
+			continue
+		}
+		day := days[date]
+		if day == nil {
+			day = &DayUsage{Date: date, Endpoints: map[string]int{}}
+			days[date] = day
+		}
+		day.Requests += count
+		day.Endpoints[endpoint] += count
+	}
+	for _, date := range slices.Sorted(maps.Keys(days)) {
+		usage.Days = append(usage.Days, *days[date])
+	}
+	return usage, nil
+}
+
+// ErrNoAPIKey is asking for the usage of a request made without an API key
+var ErrNoAPIKey = errors.New("usage is counted per API key, and the request has none")
+
+// ParseMonth reads a YYYY-MM month
+func ParseMonth(value string) (time.Time, error) {
+	return time.Parse(monthFormat, value)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,110 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keyRequest(keyID, resource string) events.APIGatewayProxyRequest {
+	request := events.APIGatewayProxyRequest{Resource: resource, Path: resource}
+	request.RequestContext.Identity.APIKeyID = keyID
+	return request
+}
+
+func newTestTracker(now time.Time) (*Tracker, *fakeDynamoDB) {
+	client := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+	tracker := NewTracker(NewDynamoStore(client, "usage"), 2, map[string]int{"partner": 0})
+	tracker.now = func() time.Time { return now }
+	return tracker, client
+}
+
+func TestTracker_Wrap(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker, client := newTestTracker(now)
+	var rejected error
+	handler := tracker.Wrap(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Headers: map[string]string{"Content-Type": "application/json"}}, nil
+	}, func(err error) (events.APIGatewayProxyResponse, error) {
+		rejected = err
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusTooManyRequests}, nil
+	})
+	ctx := context.Background()
+
+	response, err := handler(ctx, keyRequest("k1", "/api/tides"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Content-Type":  "application/json",
+		LimitHeader:     "2",
+		RemainingHeader: "1",
+		ResetHeader:     "2024-04-01T00:00:00Z",
+	}, response.Headers)
+	response, _ = handler(ctx, keyRequest("k1", "/api/tides"))
+	assert.Equal(t, "0", response.Headers[RemainingHeader])
+
+	response, _ = handler(ctx, keyRequest("k1", "/api/tides"))
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	var quotaErr *Error
+	require.ErrorAs(t, rejected, &quotaErr)
+	assert.Equal(t, &Error{Quota: 2, ResetsAt: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}, quotaErr)
+	assert.Equal(t, "monthly quota of 2 requests exceeded; it resets at 2024-04-01T00:00:00Z", quotaErr.Error())
+
+	for range 3 {
+		response, _ = handler(ctx, keyRequest("partner", "/api/stations"))
+		assert.Equal(t, http.StatusOK, response.StatusCode, "a quota of 0 doesn't limit the key")
+		assert.NotContains(t, response.Headers, LimitHeader)
+	}
+	response, _ = handler(ctx, keyRequest("", "/api/tides"))
+	assert.Equal(t, http.StatusOK, response.StatusCode, "requests without a key aren't counted")
+	assert.Len(t, client.items, 2)
+
+	client.err = errors.New("throttled")
+	response, _ = handler(ctx, keyRequest("k1", "/api/tides"))
+	assert.Equal(t, http.StatusOK, response.StatusCode, "an unreachable store doesn't turn requests away")
+
+	var none *Tracker
+	response, _ = none.Wrap(handler, nil)(ctx, keyRequest("k1", "/api/tides"))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestTracker_Usage(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	tracker, _ := newTestTracker(now)
+	handler := tracker.Wrap(func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK}, nil
+	}, nil)
+	ctx := context.Background()
+	_, _ = handler(ctx, keyRequest("k1", "/api/tides"))
+	tracker.now = func() time.Time { return now.AddDate(0, 0, -2) }
+	_, _ = handler(ctx, keyRequest("k1", "/api/stations"))
+
+	usage, err := tracker.Usage(ctx, keyRequest("k1", "/api/usage"), now)
+	require.NoError(t, err)
+	remaining := 0
+	assert.Equal(t, &Usage{
+		Month:     "2024-03",
+		Quota:     2,
+		Used:      2,
+		Remaining: &remaining,
+		ResetsAt:  models.MillisOf(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)),
+		Days: []DayUsage{
+			{Date: "2024-03-13", Requests: 1, Endpoints: map[string]int{"/api/stations": 1}},
+			{Date: "2024-03-15", Requests: 1, Endpoints: map[string]int{"/api/tides": 1}},
+		},
+	}, usage)
+
+	usage, err = tracker.Usage(ctx, keyRequest("partner", "/api/usage"), now.AddDate(0, -1, 0))
+	require.NoError(t, err)
+	assert.Equal(t, &Usage{Month: "2024-02", ResetsAt: models.MillisOf(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), Days: []DayUsage{}}, usage)
+
+	_, err = tracker.Usage(ctx, keyRequest("", "/api/usage"), now)
+	assert.ErrorIs(t, err, ErrNoAPIKey)
+}
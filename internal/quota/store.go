@@ -0,0 +1,119 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+)
+
+// Retention is how long the store keeps a month's counts after the month starts: a year
+// of months to report on, and some to spare
+const Retention = 400 * 24 * time.Hour
+
+// ErrExhausted is a request beyond its key's quota, which isn't counted
+var ErrExhausted = errors.New("quota exhausted")
+
+const (
+	// totalAttribute counts a month's requests; the rest are named day|endpoint
+	totalAttribute = "total"
+	separator      = "|"
+)
+
+// Store adds up the requests of every API key by month
+type Store interface {
+	// Add counts a request to endpoint at at against key's month and returns the month's
+	// requests with it. When the month already has quota requests it returns ErrExhausted
+	// instead; a quota of 0 doesn't limit them.
+	Add(ctx context.Context, key string, at time.Time, endpoint string, quota int) (int, error)
+	// Load returns the counts of key's month starting at month: its total and each
+	// day|endpoint's
+	Load(ctx context.Context, key string, month time.Time) (map[string]int, error)
+}
+
+// DynamoStore keeps each key's month as an item keyed by key and month, whose number
+// attributes every instance adds to atomically
+type DynamoStore struct {
+	client cache.DynamoDBClient
+	table  string
+}
+
+func NewDynamoStore(client cache.DynamoDBClient, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table}
+}
+
+func usageKey(key string, month time.Time) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"usageKey": &types.AttributeValueMemberS{Value: key + "#" + month.Format(monthFormat)},
+	}
+}
+
+func number(n int64) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(n, 10)}
+}
+
+func (s *DynamoStore) Add(ctx context.Context, key string, at time.Time, endpoint string, quota int) (int, error) {
+	month := monthStart(at.UTC())
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(s.table),
+		Key:              usageKey(key, month),
+		UpdateExpression: aws.String("SET #ttl = if_not_exists(#ttl, :ttl) ADD #total :one, #day :one"),
+		// The day and endpoint hold characters expressions can't, so they go by a placeholder
+		ExpressionAttributeNames: map[string]string{
+			"#ttl":   "ttl",
+			"#total": totalAttribute,
+			"#day":   at.UTC().Format(dayFormat) + separator + endpoint,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ttl": number(month.Add(Retention).Unix()),
+			":one": number(1),
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	}
+	if quota > 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(#total) OR #total < :quota")
+		input.ExpressionAttributeValues[":quota"] = number(int64(quota))
+	}
+
+	output, err := s.client.UpdateItem(ctx, input)
+	var conditionErr *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &conditionErr):
+		return quota, ErrExhausted
+	case err != nil:
+		return 0, fmt.Errorf("counting usage in DynamoDB: %w", err)
+	}
+	return counts(output.Attributes)[totalAttribute], nil
+}
+
+func (s *DynamoStore) Load(ctx context.Context, key string, month time.Time) (map[string]int, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key:       usageKey(key, month),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading usage from DynamoDB: %w", err)
+	}
+	return counts(output.Item), nil
+}
+
+// counts returns an item's number attributes other than the TTL
+func counts(item map[string]types.AttributeValue) map[string]int {
+	counts := make(map[string]int, len(item))
+	for name, value := range item {
+		n, ok := value.(*types.AttributeValueMemberN)
+		if !ok || name == "ttl" {
+			continue
+		}
+		if count, err := strconv.Atoi(n.Value); err == nil {
+			counts[name] = count
+		}
+	}
+	return counts
+}
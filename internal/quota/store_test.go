@@ -0,0 +1,102 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB applies the store's update, including its quota condition, to items by key
+type fakeDynamoDB struct {
+	cache.DynamoDBClient
+	items map[string]map[string]types.AttributeValue
+	err   error
+}
+
+func numberValue(v types.AttributeValue) int {
+	n, _ := strconv.Atoi(v.(*types.AttributeValueMemberN).Value)
+	return n
+}
+
+func (f *fakeDynamoDB) UpdateItem(_ context.Context, params *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	key := params.Key["usageKey"].(*types.AttributeValueMemberS).Value
+	item := f.items[key]
+	if item == nil {
+		item = map[string]types.AttributeValue{"usageKey": params.Key["usageKey"]}
+	}
+	if limit, ok := params.ExpressionAttributeValues[":quota"]; ok {
+		if total, ok := item["total"]; ok && numberValue(total) >= numberValue(limit) {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+	}
+	if _, ok := item["ttl"]; !ok {
+		item["ttl"] = params.ExpressionAttributeValues[":ttl"]
+	}
+	for _, placeholder := range []string{"#total", "#day"} {
+		name := params.ExpressionAttributeNames[placeholder]
+		var n int
+		if existing, ok := item[name]; ok {
+			n = numberValue(existing)
+		}
+		item[name] = number(int64(n + 1))
+	}
+	f.items[key] = item
+	return &dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+		"total":                                 item["total"],
+		params.ExpressionAttributeNames["#day"]: item[params.ExpressionAttributeNames["#day"]],
+	}}, nil
+}
+
+func (f *fakeDynamoDB) GetItem(_ context.Context, params *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.GetItemOutput{Item: f.items[params.Key["usageKey"].(*types.AttributeValueMemberS).Value]}, nil
+}
+
+func TestDynamoStore(t *testing.T) {
+	client := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+	store := NewDynamoStore(client, "usage")
+	ctx := context.Background()
+	at := time.Date(2024, 3, 31, 23, 30, 0, 0, time.UTC)
+
+	used, err := store.Add(ctx, "apikey:k1", at, "/api/tides", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, used)
+	used, err = store.Add(ctx, "apikey:k1", at.Add(time.Minute), "/api/stations", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, used)
+	_, err = store.Add(ctx, "apikey:k1", at, "/api/tides", 2)
+	assert.ErrorIs(t, err, ErrExhausted)
+
+	used, err = store.Add(ctx, "apikey:k1", at.Add(time.Hour), "/api/tides", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, used, "April starts over")
+
+	counts, err := store.Load(ctx, "apikey:k1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"total": 2, "2024-03-31|/api/tides": 1, "2024-03-31|/api/stations": 1}, counts)
+	assert.Equal(t, strconv.FormatInt(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Add(Retention).Unix(), 10),
+		client.items["apikey:k1#2024-03"]["ttl"].(*types.AttributeValueMemberN).Value)
+
+	counts, err = store.Load(ctx, "apikey:k2", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, counts)
+
+	client.err = errors.New("throttled")
+	_, err = store.Add(ctx, "apikey:k1", at, "/api/tides", 0)
+	assert.ErrorContains(t, err, "counting usage in DynamoDB: throttled")
+	_, err = store.Load(ctx, "apikey:k1", at)
+	assert.ErrorContains(t, err, "loading usage from DynamoDB: throttled")
+}
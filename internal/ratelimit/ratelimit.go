@@ -0,0 +1,84 @@
+// Package ratelimit limits how often each client may do something, in memory per instance
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+// maxClients bounds how many clients' allowances are remembered. Forgetting the least
+// recently seen one only gives it a full allowance again.
+const maxClients = 10000
+
+// Error is returned for a request beyond its client's limit
+type Error struct {
+	// RetryAfter is how long until the client may make another request
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry in %d seconds", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds is RetryAfter in whole seconds, rounded up, for a Retry-After header
+func (e *Error) RetryAfterSeconds() int {
+	return max(1, int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// Limiter lets each client make a number of requests a period, in bursts of up to that
+// many. Each instance counts on its own, so a client whose requests are spread across
+// instances gets more.
+type Limiter struct {
+	limit int
+	per   time.Duration
+	now   func() time.Time
+
+	mu      sync.Mutex
+	clients *lru.Cache[string, *allowance]
+}
+
+// allowance is how many requests a client has left, as of updated
+type allowance struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New creates a limiter allowing limit requests per period per client, or returns nil,
+// which allows everything, when limit isn't positive
+func New(limit int, per time.Duration) *Limiter {
+	if limit <= 0 {
+		return nil
+	}
+	clients, _ := lru.New[string, *allowance](maxClients)
+	return &Limiter{limit: limit, per: per, now: time.Now, clients: clients}
+}
+
+// Allow counts a request from client, returning an *Error when it has none
+// left. A nil Limiter allows every request.
+func (l *Limiter) Allow(client string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	limit := float64(l.limit)
+	perSecond := limit / l.per.Seconds()
+	a, ok := l.clients.Get(client)
+	if !ok {
+		a = &allowance{tokens: limit, updated: now}
+		l.clients.Add(client, a)
+	}
+	a.tokens = min(limit, a.tokens+now.Sub(a.updated).Seconds()*perSecond)
+	a.updated = now
+	if a.tokens < 1 {
+		return &Error{RetryAfter: time.Duration((1 - a.tokens) / perSecond * float64(time.Second))}
+	}
+	a.tokens--
+	return nil
+}
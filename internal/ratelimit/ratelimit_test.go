@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(3, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	for range 3 {
+		assert.NoError(t, limiter.Allow("198.51.100.7"))
+	}
+	err := limiter.Allow("198.51.100.7")
+	var limited *Error
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, 20*time.Second, limited.RetryAfter, "one request comes back every 20 seconds")
+	assert.Equal(t, 20, limited.RetryAfterSeconds())
+	assert.NoError(t, limiter.Allow("203.0.113.9"), "each client has its own allowance")
+
+	now = now.Add(20 * time.Second)
+	assert.NoError(t, limiter.Allow("198.51.100.7"))
+	assert.Error(t, limiter.Allow("198.51.100.7"))
+}
+
+func TestLimiter_Period(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New(2, time.Hour)
+	limiter.now = func() time.Time { return now }
+
+	assert.NoError(t, limiter.Allow("user-1"))
+	assert.NoError(t, limiter.Allow("user-1"))
+	var limited *Error
+	require.ErrorAs(t, limiter.Allow("user-1"), &limited)
+	assert.Equal(t, 30*time.Minute, limited.RetryAfter)
+}
+
+func TestLimiter_Off(t *testing.T) {
+	limiter := New(0, time.Minute)
+	assert.Nil(t, limiter)
+	assert.NoError(t, limiter.Allow("198.51.100.7"))
+}
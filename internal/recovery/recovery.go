@@ -0,0 +1,138 @@
+// Package recovery turns panics into errors carrying a fingerprint of where they happened,
+// so one bad record fails the request it's in rather than the whole invocation, and the
+// same panic can be told apart from others in logs and metrics
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LogInterval is how long after logging a panic's stack it's logged again; occurrences in
+// between are logged without it
+const LogInterval = time.Hour
+
+// Error is a recovered panic
+type Error struct {
+	// Fingerprint is the same for every panic at the same place
+	Fingerprint string
+	// Value is what was passed to panic
+	Value any
+}
+
+// Error leaves out the panic's value, which is logged but may not be fit to show a client
+func (e *Error) Error() string {
+	return fmt.Sprintf("internal error (fingerprint %s)", e.Fingerprint)
+}
+
+var (
+	// goroutineHeader is a stack's first line, e.g. goroutine 7 [running]:
+	goroutineHeader = regexp.MustCompile(`^goroutine \d+ `)
+	// frameArguments are the argument words of a function line, e.g. (0xc000123, 0x4)
+	frameArguments = regexp.MustCompile(`\([^()]*\)$`)
+	// frameOffset is the program counter offset of a file line, e.g. +0x1d
+	frameOffset = regexp.MustCompile(` \+0x[0-9a-f]+$`)
+	// creatorGoroutine ends the line naming what started a goroutine
+	creatorGoroutine = regexp.MustCompile(` in goroutine \d+$`)
+)
+
+// Fingerprint hashes the functions and lines of stack, as debug.Stack formats it, leaving
+// out what differs between occurrences of the same panic: goroutine numbers, argument
+// values and program counter offsets
+func Fingerprint(stack []byte) string {
+	hash := sha256.New()
+	scanner := bufio.NewScanner(bytes.NewReader(stack))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || goroutineHeader.MatchString(line) {
+			continue
+		}
+		line = frameOffset.ReplaceAllString(line, "")
+		line = creatorGoroutine.ReplaceAllString(line, "")
+		line = frameArguments.ReplaceAllString(line, "")
+		hash.Write([]byte(line))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// Tracker logs recovered panics, each fingerprint's stack at most once per LogInterval,
+// and reports them to whatever counts them
+type Tracker struct {
+	now func() time.Time
+
+	mu sync.Mutex
+	// logged is when each fingerprint's stack was last logged
+	logged  map[string]time.Time
+	onPanic func(fingerprint string)
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{now: time.Now, logged: make(map[string]time.Time)}
+}
+
+// Default tracks the panics recovered by Guard and the API and GraphQL handlers
+var Default = NewTracker()
+
+// OnPanic has fn called with the fingerprint of every panic recovered, e.g. to count it
+func (t *Tracker) OnPanic(fn func(fingerprint string)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onPanic = fn
+}
+
+// Recovered logs a panic recovered with value, whose stack is given as debug.Stack
+// formats it, and returns it as an *Error
+func (t *Tracker) Recovered(value any, stack []byte) *Error {
+	err := &Error{Fingerprint: Fingerprint(stack), Value: value}
+	now := t.now()
+
+	t.mu.Lock()
+	last, seen := t.logged[err.Fingerprint]
+	withStack := !seen || now.Sub(last) >= LogInterval
+	if withStack {
+		t.logged[err.Fingerprint] = now
+		// Forget fingerprints whose stacks would be logged again anyway
+		for fingerprint, at := range t.logged {
+			if now.Sub(at) >= LogInterval {
+				delete(t.logged, fingerprint)
+			}
+		}
+	}
+	onPanic := t.onPanic
+	t.mu.Unlock()
+
+	event := log.Error().Str("fingerprint", err.Fingerprint).Interface("panic", value)
+	if withStack {
+		event = event.Str("stack", string(stack))
+	}
+	event.Msg("Recovered from panic")
+	if onPanic != nil {
+		onPanic(err.Fingerprint)
+	}
+	return err
+}
+
+// Guard wraps a Lambda handler that returns only an error, like a scheduled job's, so a
+// panic in it is tracked by Default and returned as an *Error
+func Guard[E any](handler func(ctx context.Context, event E) error) func(ctx context.Context, event E) error {
+	return func(ctx context.Context, event E) (err error) {
+		defer func() {
+			if value := recover(); value != nil {
+				err = Default.Recovered(value, debug.Stack())
+			}
+		}()
+		return handler(ctx, event)
+	}
+}
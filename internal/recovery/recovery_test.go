@@ -0,0 +1,111 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs sends logs to the returned buffer until the test ends
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	previous := log.Logger
+	t.Cleanup(func() { log.Logger = previous })
+	var out bytes.Buffer
+	log.Logger = zerolog.New(&out)
+	return &out
+}
+
+// panicStack returns the stack a panic in explode recovers with
+func panicStack(value any) (stack []byte) {
+	defer func() {
+		recover()
+		stack = debug.Stack()
+	}()
+	explode(value)
+	return nil
+}
+
+func explode(value any) {
+	panic(value)
+}
+
+func TestFingerprint(t *testing.T) {
+	var stacks [][]byte
+	for _, value := range []any{"bad record", 42} {
+		stacks = append(stacks, panicStack(value))
+	}
+	assert.Equal(t, Fingerprint(stacks[0]), Fingerprint(stacks[1]), "the same place gives the same fingerprint")
+	assert.Len(t, Fingerprint(stacks[0]), 12)
+
+	stack := []byte(`goroutine 7 [running]:
+main.parse(0xc000123456, 0x4)
+	/src/main.go:12 +0x1d
+created by main.serve in goroutine 1
+	/src/main.go:40 +0x5f
+`)
+	other := []byte(`goroutine 19 [running]:
+main.parse(0xc000999999, 0x8)
+	/src/main.go:12 +0x2a
+created by main.serve in goroutine 3
+	/src/main.go:40 +0x5f
+`)
+	moved := bytes.Replace(stack, []byte("main.go:12"), []byte("main.go:13"), 1)
+	assert.Equal(t, Fingerprint(stack), Fingerprint(other))
+	assert.NotEqual(t, Fingerprint(stack), Fingerprint(moved), "a panic elsewhere gets its own")
+}
+
+func TestTracker_Recovered(t *testing.T) {
+	out := captureLogs(t)
+	tracker := NewTracker()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+	var counted []string
+	tracker.OnPanic(func(fingerprint string) { counted = append(counted, fingerprint) })
+
+	stack := panicStack("bad record")
+	err := tracker.Recovered("bad record", stack)
+	assert.Equal(t, Fingerprint(stack), err.Fingerprint)
+	assert.Equal(t, "internal error (fingerprint "+err.Fingerprint+")", err.Error())
+	assert.Equal(t, 1, strings.Count(out.String(), `"stack"`))
+
+	now = now.Add(30 * time.Minute)
+	tracker.Recovered("bad record", stack)
+	assert.Equal(t, 1, strings.Count(out.String(), `"stack"`), "the stack is logged once an hour")
+	assert.Equal(t, 2, strings.Count(out.String(), "Recovered from panic"))
+
+	now = now.Add(time.Hour)
+	tracker.Recovered("bad record", stack)
+	assert.Equal(t, 2, strings.Count(out.String(), `"stack"`))
+	assert.Equal(t, []string{err.Fingerprint, err.Fingerprint, err.Fingerprint}, counted)
+}
+
+func TestGuard(t *testing.T) {
+	captureLogs(t)
+	handler := Guard(func(_ context.Context, station string) error {
+		if station == "bad" {
+			var record *struct{ Name string }
+			_ = record.Name
+		}
+		if station == "missing" {
+			return errors.New("not found")
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler(context.Background(), "good"))
+	assert.EqualError(t, handler(context.Background(), "missing"), "not found")
+	err := handler(context.Background(), "bad")
+	var panicErr *Error
+	require.ErrorAs(t, err, &panicErr)
+	assert.Contains(t, panicErr.Value.(error).Error(), "nil pointer dereference")
+}
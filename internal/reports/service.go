@@ -0,0 +1,148 @@
+package reports
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+)
+
+const (
+	// MaxAge is how long after the fact a sighting can still be reported
+	MaxAge = 24 * time.Hour
+	// maxClockSkew allows for reporters' clocks running a little fast
+	maxClockSkew = 5 * time.Minute
+
+	// Observed heights outside these bounds, in feet above MLLW, are typos
+	minHeight = -20.0
+	maxHeight = 60.0
+
+	maxPhotoURLLength = 2048
+)
+
+// Submission is a report as the reporter sent it
+type Submission struct {
+	StationID      string
+	Timestamp      models.Millis
+	ObservedHeight *float64
+	Condition      *string
+	PhotoURL       *string
+}
+
+// Service validates and saves observation reports
+type Service struct {
+	store   Store
+	finder  models.StationFinder
+	limiter *ratelimit.Limiter
+	now     func() time.Time
+}
+
+// NewService creates a service that lets each user submit perHour reports an hour, or any
+// number when perHour isn't positive
+func NewService(store Store, finder models.StationFinder, perHour int) *Service {
+	return &Service{store: store, finder: finder, limiter: ratelimit.New(perHour, time.Hour), now: time.Now}
+}
+
+// Submit saves the user's report, once it's within the user's rate limit and describes a
+// known station in the last MaxAge
+func (s *Service) Submit(ctx context.Context, userID string, sub Submission) (*models.ObservationReport, error) {
+	if err := s.limiter.Allow(userID); err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	report, err := s.validate(sub, now)
+	if err != nil {
+		return nil, err
+	}
+	station, err := models.FindStation(ctx, s.finder, sub.StationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if station == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, sub.StationID)
+	}
+
+	report.StationID = station.ID
+	report.UserID = userID
+	report.SubmittedAt = now.Unix()
+	report.ReportID = reportID(report.Timestamp)
+	if err := s.store.SaveReport(ctx, report); err != nil {
+		return nil, fmt.Errorf("saving report: %w", err)
+	}
+	return report, nil
+}
+
+// validate checks a submission's fields, returning the report they make
+func (s *Service) validate(sub Submission, now time.Time) (*models.ObservationReport, error) {
+	if err := validate.StationID("stationId", sub.StationID); err != nil {
+		return nil, err
+	}
+	observed := sub.Timestamp.Time()
+	if observed.Before(now.Add(-MaxAge)) || observed.After(now.Add(maxClockSkew)) {
+		return nil, &validate.Error{
+			Parameter: "timestamp",
+			Value:     strconv.FormatInt(int64(sub.Timestamp), 10),
+			Message:   "must be within the last 24 hours",
+			Allowed:   fmt.Sprintf("%d to %d", models.MillisOf(now.Add(-MaxAge)), models.MillisOf(now)),
+		}
+	}
+
+	report := &models.ObservationReport{Timestamp: sub.Timestamp, ObservedHeight: sub.ObservedHeight}
+	if sub.ObservedHeight == nil && sub.Condition == nil {
+		return nil, &validate.Error{
+			Parameter: "observedHeight",
+			Message:   "an observed height or a condition is required",
+		}
+	}
+	if sub.ObservedHeight != nil {
+		if err := validate.Between("observedHeight", *sub.ObservedHeight, minHeight, maxHeight); err != nil {
+			return nil, err
+		}
+	}
+	if sub.Condition != nil {
+		if err := validate.OneOf("condition", *sub.Condition, models.ReportConditions...); err != nil {
+			return nil, err
+		}
+		report.Condition = *sub.Condition
+	}
+	if sub.PhotoURL != nil {
+		if err := validatePhotoURL(*sub.PhotoURL); err != nil {
+			return nil, err
+		}
+		report.PhotoURL = *sub.PhotoURL
+	}
+	return report, nil
+}
+
+// validatePhotoURL accepts an absolute https URL of reasonable length. The photo itself
+// isn't fetched; it's hosted wherever the reporter put it.
+func validatePhotoURL(photoURL string) error {
+	invalid := &validate.Error{Parameter: "photoUrl", Value: photoURL, Allowed: "an https URL"}
+	if len(photoURL) > maxPhotoURLLength {
+		invalid.Value = photoURL[:64] + "..."
+		invalid.Message = fmt.Sprintf("must be at most %d characters", maxPhotoURLLength)
+		return invalid
+	}
+	parsed, err := url.Parse(photoURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		invalid.Message = "must be an https URL"
+		return invalid
+	}
+	return nil
+}
+
+// reportID sorts by the time observed, then by random bytes that keep two reports of the
+// same moment apart
+func reportID(observed models.Millis) string {
+	suffix := make([]byte, 6)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%013d#%s", observed, hex.EncodeToString(suffix))
+}
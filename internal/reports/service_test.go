@@ -0,0 +1,121 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	reports []*models.ObservationReport
+	err     error
+}
+
+func (s *memoryStore) SaveReport(_ context.Context, report *models.ObservationReport) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func newTestService(perHour int) (*Service, *memoryStore, time.Time) {
+	store := &memoryStore{}
+	finder := &testsupport.StationFinder{Stations: []models.Station{{ID: "9447130", Name: "Seattle"}}}
+	service := NewService(store, finder, perHour)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	return service, store, now
+}
+
+func TestSubmit(t *testing.T) {
+	service, store, now := newTestService(10)
+	height, condition, photo := 11.4, models.ConditionHigherThanPredicted, "https://photos.example.com/seattle.jpg"
+	observed := models.MillisOf(now.Add(-time.Hour))
+
+	report, err := service.Submit(context.Background(), "apikey:key-1", Submission{
+		StationID:      "9447130",
+		Timestamp:      observed,
+		ObservedHeight: &height,
+		Condition:      &condition,
+		PhotoURL:       &photo,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "9447130", report.StationID)
+	assert.Equal(t, "apikey:key-1", report.UserID)
+	assert.Equal(t, observed, report.Timestamp)
+	assert.Equal(t, &height, report.ObservedHeight)
+	assert.Equal(t, condition, report.Condition)
+	assert.Equal(t, photo, report.PhotoURL)
+	assert.Equal(t, now.Unix(), report.SubmittedAt)
+	assert.Regexp(t, `^1735729200000#[0-9a-f]{12}$`, report.ReportID)
+	assert.Equal(t, []*models.ObservationReport{report}, store.reports)
+
+	other, err := service.Submit(context.Background(), "apikey:key-1", Submission{StationID: "9447130", Timestamp: observed, Condition: &condition})
+	require.NoError(t, err)
+	assert.NotEqual(t, report.ReportID, other.ReportID, "reports of the same moment get their own IDs")
+	assert.Nil(t, other.ObservedHeight)
+}
+
+func TestSubmit_Invalid(t *testing.T) {
+	service, store, now := newTestService(0)
+	height, tooHigh, condition, unknown := 5.0, 120.0, models.ConditionFlooding, "CHOPPY"
+	insecure, garbage := "http://photos.example.com/a.jpg", "not a url"
+	recent := models.MillisOf(now.Add(-time.Minute))
+
+	tests := []struct {
+		name      string
+		sub       Submission
+		parameter string
+	}{
+		{"bad station ID", Submission{StationID: "94/47", Timestamp: recent, ObservedHeight: &height}, "stationId"},
+		{"too old", Submission{StationID: "9447130", Timestamp: models.MillisOf(now.Add(-25 * time.Hour)), ObservedHeight: &height}, "timestamp"},
+		{"in the future", Submission{StationID: "9447130", Timestamp: models.MillisOf(now.Add(time.Hour)), ObservedHeight: &height}, "timestamp"},
+		{"nothing observed", Submission{StationID: "9447130", Timestamp: recent}, "observedHeight"},
+		{"implausible height", Submission{StationID: "9447130", Timestamp: recent, ObservedHeight: &tooHigh}, "observedHeight"},
+		{"unknown condition", Submission{StationID: "9447130", Timestamp: recent, Condition: &unknown}, "condition"},
+		{"insecure photo", Submission{StationID: "9447130", Timestamp: recent, Condition: &condition, PhotoURL: &insecure}, "photoUrl"},
+		{"not a photo URL", Submission{StationID: "9447130", Timestamp: recent, Condition: &condition, PhotoURL: &garbage}, "photoUrl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Submit(context.Background(), "user-1", tt.sub)
+			var paramErr *validate.Error
+			require.ErrorAs(t, err, &paramErr)
+			assert.Equal(t, tt.parameter, paramErr.Parameter)
+		})
+	}
+
+	_, err := service.Submit(context.Background(), "user-1", Submission{StationID: "1234567", Timestamp: recent, Condition: &condition})
+	assert.ErrorIs(t, err, models.ErrStationNotFound)
+	assert.Empty(t, store.reports)
+
+	store.err = errors.New("throttled")
+	_, err = service.Submit(context.Background(), "user-1", Submission{StationID: "9447130", Timestamp: recent, Condition: &condition})
+	assert.ErrorContains(t, err, "saving report: throttled")
+}
+
+func TestSubmit_RateLimited(t *testing.T) {
+	service, store, now := newTestService(2)
+	condition := models.ConditionAsPredicted
+	sub := Submission{StationID: "9447130", Timestamp: models.MillisOf(now), Condition: &condition}
+
+	for range 2 {
+		_, err := service.Submit(context.Background(), "user-1", sub)
+		require.NoError(t, err)
+	}
+	_, err := service.Submit(context.Background(), "user-1", sub)
+	var limited *ratelimit.Error
+	assert.ErrorAs(t, err, &limited)
+	_, err = service.Submit(context.Background(), "user-2", sub)
+	assert.NoError(t, err, "each user has their own limit")
+	assert.Len(t, store.reports, 3)
+}
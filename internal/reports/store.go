@@ -0,0 +1,47 @@
+// Package reports collects observation reports, in which the community says what the water
+// at a station is actually doing, so divergence from the predictions can be spotted
+package reports
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Store persists observation reports
+type Store interface {
+	SaveReport(ctx context.Context, report *models.ObservationReport) error
+}
+
+// DynamoStore keeps each report as an item keyed by stationId and reportId, so a
+// station's reports can be queried in the order they were observed
+type DynamoStore struct {
+	client cache.DynamoDBClient
+	table  string
+}
+
+func NewDynamoStore(client cache.DynamoDBClient, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table}
+}
+
+func (s *DynamoStore) SaveReport(ctx context.Context, report *models.ObservationReport) error {
+	item, err := attributevalue.MarshalMap(report)
+	if err != nil {
+		return fmt.Errorf("marshaling report: %w", err)
+	}
+
+	// Report IDs end in random bytes, so a collision is a bug rather than a retry
+	if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(reportId)"),
+	}); err != nil {
+		return fmt.Errorf("putting report in DynamoDB: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB records the puts it's given
+type fakeDynamoDB struct {
+	cache.DynamoDBClient
+	puts []*dynamodb.PutItemInput
+	err  error
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.puts = append(f.puts, params)
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func TestDynamoStore_SaveReport(t *testing.T) {
+	client := &fakeDynamoDB{}
+	store := NewDynamoStore(client, "reports")
+	height := 9.5
+	report := &models.ObservationReport{
+		StationID:      "9447130",
+		ReportID:       "1735740000000#0a1b2c3d4e5f",
+		UserID:         "apikey:key-1",
+		Timestamp:      1735740000000,
+		ObservedHeight: &height,
+		SubmittedAt:    1735740100,
+	}
+
+	require.NoError(t, store.SaveReport(context.Background(), report))
+	require.Len(t, client.puts, 1)
+	assert.Equal(t, "reports", *client.puts[0].TableName)
+	assert.Equal(t, "attribute_not_exists(reportId)", *client.puts[0].ConditionExpression)
+	var saved models.ObservationReport
+	require.NoError(t, attributevalue.UnmarshalMap(client.puts[0].Item, &saved))
+	assert.Equal(t, *report, saved)
+
+	client.err = errors.New("throttled")
+	assert.ErrorContains(t, store.SaveReport(context.Background(), report), "putting report in DynamoDB: throttled")
+}
@@ -0,0 +1,183 @@
+package sdkgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+// GenerateGo renders the Go client as a single gofmt'ed source file in package pkg
+func GenerateGo(api *API, pkg string) ([]byte, error) {
+	var body bytes.Buffer
+	if err := goTemplate.Execute(&body, api); err != nil {
+		return nil, fmt.Errorf("rendering Go client: %w", err)
+	}
+
+	// Import only what the generated code uses
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by cmd/sdkgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	for _, imp := range []string{"context", "encoding/json", "net/url", "strconv"} {
+		if strings.Contains(body.String(), imp[strings.LastIndex(imp, "/")+1:]+".") {
+			fmt.Fprintf(&buf, "\t%q\n", imp)
+		}
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting Go client: %w", err)
+	}
+	return src, nil
+}
+
+// goType is the Go type for ref. Optional and nullable scalars and structs are pointers;
+// slices and maps can already be nil.
+func goType(ref TypeRef, optional bool) string {
+	var t string
+	switch ref.Kind {
+	case "string":
+		t = "string"
+	case "number":
+		t = "float64"
+	case "integer":
+		t = "int64"
+	case "boolean":
+		t = "bool"
+	case "array":
+		return "[]" + goType(*ref.Elem, false)
+	case "map":
+		return "map[string]" + goType(*ref.Elem, false)
+	case "ref":
+		t = ref.Ref
+	default:
+		return "json.RawMessage"
+	}
+	if ref.Nullable || optional {
+		return "*" + t
+	}
+	return t
+}
+
+// goFormatParam renders the expression that formats a scalar parameter value v as a
+// query string value
+func goFormatParam(ref TypeRef, v string) string {
+	switch ref.Kind {
+	case "number":
+		return fmt.Sprintf("strconv.FormatFloat(%s, 'f', -1, 64)", v)
+	case "integer":
+		return fmt.Sprintf("strconv.FormatInt(%s, 10)", v)
+	case "boolean":
+		return fmt.Sprintf("strconv.FormatBool(%s)", v)
+	default:
+		return v
+	}
+}
+
+func goJSONTag(f Field) string {
+	if f.Optional {
+		return fmt.Sprintf("`json:\"%s,omitempty\"`", f.Name)
+	}
+	return fmt.Sprintf("`json:\"%s\"`", f.Name)
+}
+
+// graphQLDocument renders the query or mutation document for q, passing every argument
+// as a variable
+func graphQLDocument(q GraphQLQuery) string {
+	operation := "query"
+	if q.Mutation {
+		operation = "mutation"
+	}
+	if len(q.Args) == 0 {
+		return strings.Join(strings.Fields(fmt.Sprintf("%s { %s %s }", operation, q.Name, q.Selection)), " ")
+	}
+	vars := make([]string, len(q.Args))
+	args := make([]string, len(q.Args))
+	for i, a := range q.Args {
+		vars[i] = "$" + a.Name + ": " + a.GraphQLType
+		args[i] = a.Name + ": $" + a.Name
+	}
+	return fmt.Sprintf("%s(%s) { %s(%s) %s }", operation, strings.Join(vars, ", "), q.Name, strings.Join(args, ", "), q.Selection)
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"exported":        exportedName,
+	"goType":          goType,
+	"formatParam":     goFormatParam,
+	"jsonTag":         goJSONTag,
+	"quote":           func(s string) string { return fmt.Sprintf("%q", s) },
+	"comment":         func(s string) string { return strings.ReplaceAll(s, "\n", "\n// ") },
+	"graphQLDocument": graphQLDocument,
+}).Parse(`{{range .Types}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{exported .Name}} {{goType .Type .Optional}} {{jsonTag .}}
+{{- end}}
+}
+{{end}}
+{{- range .Operations}}
+// {{.ID}}Params are the query parameters of {{.Method}} {{.Path}}
+type {{.ID}}Params struct {
+{{- range .Params}}
+{{- if .Description}}
+	// {{comment .Description}}
+{{- end}}
+	{{exported .Name}} {{goType .Type (not .Required)}}
+{{- end}}
+}
+
+// {{.ID}} calls {{.Method}} {{.Path}}. {{.Summary}}.
+{{- if .Deprecated}}
+//
+// Deprecated: use the latest version of this operation.
+{{- end}}
+func (c *Client) {{.ID}}(ctx context.Context, params {{.ID}}Params) (*{{.Response}}, error) {
+	query := url.Values{}
+{{- range .Params}}
+{{- if .Required}}
+	query.Set({{quote .Name}}, {{formatParam .Type (printf "params.%s" (exported .Name))}})
+{{- else}}
+	if params.{{exported .Name}} != nil {
+		query.Set({{quote .Name}}, {{formatParam .Type (printf "*params.%s" (exported .Name))}})
+	}
+{{- end}}
+{{- end}}
+
+	var out {{.Response}}
+	if err := c.get(ctx, {{quote .Path}}, query, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+{{end}}
+{{- range .GraphQLTypes}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{exported .Name}} {{goType .Type .Optional}} {{jsonTag .}}
+{{- end}}
+}
+{{end}}
+{{- range .GraphQLQueries}}
+{{- $method := .Method}}
+{{- $kind := "query"}}{{if .Mutation}}{{$kind = "mutation"}}{{end}}
+// {{$method}}Args are the arguments of the GraphQL {{.Name}} {{$kind}}
+type {{$method}}Args struct {
+{{- range .Args}}
+	{{exported .Name}} {{goType .Type false}} ` + "`" + `json:"{{.Name}}{{if not .Required}},omitempty{{end}}"` + "`" + `
+{{- end}}
+}
+
+// {{$method}} runs the GraphQL {{.Name}} {{$kind}}, selecting every field
+func (c *Client) {{$method}}(ctx context.Context, args {{$method}}Args) ({{goType .Result false}}, error) {
+	const query = {{quote (graphQLDocument .)}}
+	var out struct {
+		Value {{goType .Result false}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+	}
+	if err := c.graphQL(ctx, query, args, &out); err != nil {
+		return out.Value, err
+	}
+	return out.Value, nil
+}
+{{end}}`))
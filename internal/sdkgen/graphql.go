@@ -0,0 +1,356 @@
+package sdkgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// graphQLTypePrefix keeps GraphQL types apart from REST types of the same name
+const graphQLTypePrefix = "GraphQL"
+
+var graphQLScalars = map[string]string{
+	"ID":      "string",
+	"String":  "string",
+	"Float":   "number",
+	"Int":     "integer",
+	"Boolean": "boolean",
+}
+
+// ParseGraphQLSchema reads object types and the Query and Mutation types' fields from a
+// GraphQL schema. It understands the subset of SDL the schema uses: object types, field
+// arguments, lists, non-null markers and directives, which are ignored.
+func ParseGraphQLSchema(src string) (types []Type, queries []GraphQLQuery, err error) {
+	p := &sdlParser{tokens: tokenizeSDL(src)}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(sdlError)
+			if !ok {
+				panic(r)
+			}
+			err = perr
+		}
+	}()
+
+	var queryFields, mutationFields []sdlField
+	objects := map[string][]sdlField{}
+	var order []string
+	for !p.done() {
+		switch keyword := p.name(); keyword {
+		case "directive":
+			p.skipDirectiveDefinition()
+		case "type":
+			name := p.name()
+			p.skipDirectives()
+			fields := p.fields()
+			switch name {
+			case "Query":
+				queryFields = fields
+				continue
+			case "Mutation":
+				mutationFields = fields
+				continue
+			}
+			objects[name] = fields
+			order = append(order, name)
+		default:
+			p.fail("unsupported definition %q", keyword)
+		}
+	}
+
+	for _, name := range order {
+		typ := Type{Name: graphQLTypePrefix + name}
+		for _, f := range objects[name] {
+			ref, err := graphQLTypeRef(f.typ, objects)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s.%s: %w", name, f.name, err)
+			}
+			typ.Fields = append(typ.Fields, Field{Name: f.name, Type: ref})
+		}
+		types = append(types, typ)
+	}
+
+	for _, root := range []struct {
+		name     string
+		fields   []sdlField
+		mutation bool
+	}{{"Query", queryFields, false}, {"Mutation", mutationFields, true}} {
+		for _, f := range root.fields {
+			result, err := graphQLTypeRef(f.typ, objects)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s.%s: %w", root.name, f.name, err)
+			}
+			query := GraphQLQuery{Name: f.name, Mutation: root.mutation, Result: result, Selection: selection(f.typ, objects)}
+			for _, arg := range f.args {
+				ref, err := graphQLTypeRef(arg.typ, objects)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%s.%s(%s): %w", root.name, f.name, arg.name, err)
+				}
+				query.Args = append(query.Args, Param{
+					Name:        arg.name,
+					Type:        ref,
+					Required:    !ref.Nullable,
+					GraphQLType: arg.typ.String(),
+				})
+			}
+			queries = append(queries, query)
+		}
+	}
+	return types, queries, nil
+}
+
+type sdlType struct {
+	name    string
+	list    *sdlType
+	nonNull bool
+}
+
+func (t sdlType) String() string {
+	s := t.name
+	if t.list != nil {
+		s = "[" + t.list.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// base is the named type inside any lists
+func (t sdlType) base() string {
+	if t.list != nil {
+		return t.list.base()
+	}
+	return t.name
+}
+
+type sdlField struct {
+	name string
+	args []sdlField
+	typ  sdlType
+}
+
+func graphQLTypeRef(t sdlType, objects map[string][]sdlField) (TypeRef, error) {
+	ref := TypeRef{Nullable: !t.nonNull}
+	switch {
+	case t.list != nil:
+		elem, err := graphQLTypeRef(*t.list, objects)
+		if err != nil {
+			return TypeRef{}, err
+		}
+		ref.Kind = "array"
+		ref.Elem = &elem
+	case graphQLScalars[t.name] != "":
+		ref.Kind = graphQLScalars[t.name]
+	case objects[t.name] != nil:
+		ref.Kind = "ref"
+		ref.Ref = graphQLTypePrefix + t.name
+	default:
+		return TypeRef{}, fmt.Errorf("unknown type %q", t.name)
+	}
+	return ref, nil
+}
+
+// selection selects every field of t, descending into object fields
+func selection(t sdlType, objects map[string][]sdlField) string {
+	fields, ok := objects[t.base()]
+	if !ok {
+		return ""
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = f.name
+		if sub := selection(f.typ, objects); sub != "" {
+			parts[i] += " " + sub
+		}
+	}
+	return "{ " + strings.Join(parts, " ") + " }"
+}
+
+type sdlError struct{ msg string }
+
+func (e sdlError) Error() string { return "parsing GraphQL schema: " + e.msg }
+
+type sdlParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *sdlParser) fail(format string, args ...interface{}) {
+	panic(sdlError{msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *sdlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *sdlParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sdlParser) next() string {
+	if p.done() {
+		p.fail("unexpected end of schema")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *sdlParser) expect(tok string) {
+	if got := p.next(); got != tok {
+		p.fail("expected %q, got %q", tok, got)
+	}
+}
+
+func (p *sdlParser) name() string {
+	tok := p.next()
+	if !isSDLName(tok) {
+		p.fail("expected a name, got %q", tok)
+	}
+	return tok
+}
+
+// skipBalanced skips a parenthesized group, including nested groups
+func (p *sdlParser) skipBalanced() {
+	p.expect("(")
+	for depth := 1; depth > 0; {
+		switch p.next() {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+	}
+}
+
+func (p *sdlParser) skipDirectives() {
+	for p.peek() == "@" {
+		p.next()
+		p.name()
+		if p.peek() == "(" {
+			p.skipBalanced()
+		}
+	}
+}
+
+// skipDirectiveDefinition skips "@name(args) on A | B"
+func (p *sdlParser) skipDirectiveDefinition() {
+	p.expect("@")
+	p.name()
+	if p.peek() == "(" {
+		p.skipBalanced()
+	}
+	p.expect("on")
+	if p.peek() == "|" {
+		p.next()
+	}
+	p.name()
+	for p.peek() == "|" {
+		p.next()
+		p.name()
+	}
+}
+
+func (p *sdlParser) fields() []sdlField {
+	p.expect("{")
+	var fields []sdlField
+	for p.peek() != "}" {
+		f := sdlField{name: p.name()}
+		if p.peek() == "(" {
+			p.next()
+			for p.peek() != ")" {
+				arg := sdlField{name: p.name()}
+				p.expect(":")
+				arg.typ = p.typ()
+				if p.peek() == "=" {
+					p.fail("default values are not supported (%s.%s)", f.name, arg.name)
+				}
+				p.skipDirectives()
+				f.args = append(f.args, arg)
+			}
+			p.expect(")")
+		}
+		p.expect(":")
+		f.typ = p.typ()
+		p.skipDirectives()
+		fields = append(fields, f)
+	}
+	p.expect("}")
+	return fields
+}
+
+func (p *sdlParser) typ() sdlType {
+	var t sdlType
+	if p.peek() == "[" {
+		p.next()
+		elem := p.typ()
+		p.expect("]")
+		t.list = &elem
+	} else {
+		t.name = p.name()
+	}
+	if p.peek() == "!" {
+		p.next()
+		t.nonNull = true
+	}
+	return t
+}
+
+// tokenizeSDL splits src into names and punctuation, dropping comments, commas and
+// descriptions
+func tokenizeSDL(src string) []string {
+	var tokens []string
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '"':
+			// Descriptions, including """block""" strings, aren't needed
+			if strings.HasPrefix(string(runes[i:]), `"""`) {
+				end := strings.Index(string(runes[i+3:]), `"""`)
+				if end < 0 {
+					return append(tokens, string(runes[i:]))
+				}
+				i += 3 + len([]rune(string(runes[i+3:])[:end])) + 3
+				continue
+			}
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens
+}
+
+func isSDLName(tok string) bool {
+	for i, r := range tok {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return tok != ""
+}
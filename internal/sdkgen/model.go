@@ -0,0 +1,103 @@
+// Package sdkgen generates typed Go and TypeScript clients for the REST API, from its
+// OpenAPI document, and for the GraphQL API, from its schema.
+package sdkgen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// API is everything the generators emit
+type API struct {
+	Types      []Type      // REST schemas
+	Operations []Operation // REST endpoints
+
+	GraphQLTypes   []Type
+	GraphQLQueries []GraphQLQuery
+}
+
+// Type is a named object type
+type Type struct {
+	Name   string
+	Fields []Field
+}
+
+// Field is a property of a Type, Name being its JSON name
+type Field struct {
+	Name     string
+	Type     TypeRef
+	Optional bool // May be absent from the JSON
+}
+
+// TypeRef is a field, parameter or result type. Scalar kinds are string, number,
+// integer, boolean and any; array uses Elem, map uses Elem for its values and ref names
+// a Type.
+type TypeRef struct {
+	Kind     string
+	Elem     *TypeRef
+	Ref      string
+	Nullable bool
+}
+
+// Operation is a REST endpoint
+type Operation struct {
+	ID         string
+	Method     string
+	Path       string
+	Summary    string
+	Deprecated bool
+	Params     []Param
+	Response   string // Name of the success body's Type
+}
+
+// Param is a REST query parameter or a GraphQL argument
+type Param struct {
+	Name        string
+	Description string
+	Type        TypeRef
+	Required    bool
+	GraphQLType string // The argument's type as written in the schema, e.g. "ID!"
+}
+
+// GraphQLQuery is a field of the schema's Query or Mutation type
+type GraphQLQuery struct {
+	Name      string
+	Mutation  bool
+	Args      []Param
+	Result    TypeRef
+	Selection string // Selects every field of the result, recursively
+}
+
+// Method is the client method's exported name: QueryX for queries and MutateX for
+// mutations, keeping them apart from the REST operations
+func (q GraphQLQuery) Method() string {
+	if q.Mutation {
+		return "Mutate" + exportedName(q.Name)
+	}
+	return "Query" + exportedName(q.Name)
+}
+
+// exportedName turns a JSON or GraphQL name into an exported Go name, following Go's
+// initialism convention for IDs (stationId -> StationID)
+func exportedName(name string) string {
+	if name == "" {
+		return ""
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	exported := string(runes)
+	if strings.HasSuffix(exported, "Id") {
+		exported = strings.TrimSuffix(exported, "Id") + "ID"
+	}
+	return exported
+}
+
+// lowerFirst turns an exported name into a TypeScript method name
+func lowerFirst(name string) string {
+	if name == "" {
+		return ""
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
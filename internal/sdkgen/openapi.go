@@ -0,0 +1,140 @@
+package sdkgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type openAPIDoc struct {
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary"`
+	Deprecated  bool   `json:"deprecated"`
+	Parameters  []struct {
+		Name        string        `json:"name"`
+		In          string        `json:"in"`
+		Description string        `json:"description"`
+		Required    bool          `json:"required"`
+		Schema      openAPISchema `json:"schema"`
+	} `json:"parameters"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema openAPISchema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+type openAPISchema struct {
+	Ref                  string                   `json:"$ref"`
+	Type                 string                   `json:"type"`
+	Nullable             bool                     `json:"nullable"`
+	Items                *openAPISchema           `json:"items"`
+	AdditionalProperties *openAPISchema           `json:"additionalProperties"`
+	AllOf                []openAPISchema          `json:"allOf"`
+	Properties           map[string]openAPISchema `json:"properties"`
+	Required             []string                 `json:"required"`
+}
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// FromOpenAPI reads the REST types and operations from an OpenAPI 3 document. Types and
+// fields are sorted by name so the output is stable.
+func FromOpenAPI(doc []byte) (*API, error) {
+	var spec openAPIDoc
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("parsing OpenAPI document: %w", err)
+	}
+
+	api := &API{}
+	for _, name := range sortedKeys(spec.Components.Schemas) {
+		schema := spec.Components.Schemas[name]
+		required := map[string]bool{}
+		for _, field := range schema.Required {
+			required[field] = true
+		}
+
+		typ := Type{Name: name}
+		for _, fieldName := range sortedKeys(schema.Properties) {
+			typ.Fields = append(typ.Fields, Field{
+				Name:     fieldName,
+				Type:     schemaType(schema.Properties[fieldName]),
+				Optional: !required[fieldName],
+			})
+		}
+		api.Types = append(api.Types, typ)
+	}
+
+	for _, path := range sortedKeys(spec.Paths) {
+		for _, method := range sortedKeys(spec.Paths[path]) {
+			op := spec.Paths[path][method]
+			operation := Operation{
+				ID:         exportedName(op.OperationID),
+				Method:     strings.ToUpper(method),
+				Path:       path,
+				Summary:    op.Summary,
+				Deprecated: op.Deprecated,
+			}
+			for _, p := range op.Parameters {
+				if p.In != "query" {
+					return nil, fmt.Errorf("%s %s: unsupported parameter location %q", method, path, p.In)
+				}
+				operation.Params = append(operation.Params, Param{
+					Name:        p.Name,
+					Description: p.Description,
+					Type:        schemaType(p.Schema),
+					Required:    p.Required,
+				})
+			}
+
+			success, ok := op.Responses["200"].Content["application/json"]
+			if !ok || !strings.HasPrefix(success.Schema.Ref, schemaRefPrefix) {
+				return nil, fmt.Errorf("%s %s: no JSON success response", method, path)
+			}
+			operation.Response = strings.TrimPrefix(success.Schema.Ref, schemaRefPrefix)
+			api.Operations = append(api.Operations, operation)
+		}
+	}
+	return api, nil
+}
+
+func schemaType(schema openAPISchema) TypeRef {
+	if len(schema.AllOf) == 1 {
+		ref := schemaType(schema.AllOf[0])
+		ref.Nullable = schema.Nullable
+		return ref
+	}
+
+	ref := TypeRef{Kind: schema.Type, Nullable: schema.Nullable}
+	switch {
+	case schema.Ref != "":
+		ref.Kind = "ref"
+		ref.Ref = strings.TrimPrefix(schema.Ref, schemaRefPrefix)
+	case schema.Type == "array" && schema.Items != nil:
+		elem := schemaType(*schema.Items)
+		ref.Elem = &elem
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		elem := schemaType(*schema.AdditionalProperties)
+		ref.Kind = "map"
+		ref.Elem = &elem
+	case schema.Type == "":
+		ref.Kind = "any"
+	}
+	return ref
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,239 @@
+package sdkgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenAPI = `{
+  "paths": {
+    "/api/things": {
+      "get": {
+        "operationId": "getThings",
+        "summary": "List things",
+        "deprecated": true,
+        "parameters": [
+          {"name": "thingId", "in": "query", "description": "Thing ID", "schema": {"type": "string"}},
+          {"name": "limit", "in": "query", "required": true, "schema": {"type": "integer"}}
+        ],
+        "responses": {
+          "200": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/ThingsResponse"}}}}
+        }
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "ThingsResponse": {
+        "type": "object",
+        "required": ["things"],
+        "properties": {
+          "things": {"type": "array", "items": {"$ref": "#/components/schemas/Thing"}},
+          "owner": {"allOf": [{"$ref": "#/components/schemas/Thing"}], "nullable": true},
+          "tags": {"type": "object", "additionalProperties": {"type": "number"}}
+        }
+      },
+      "Thing": {
+        "type": "object",
+        "required": ["id"],
+        "properties": {"id": {"type": "string"}, "extra": {}}
+      }
+    }
+  }
+}`
+
+const testSchema = `
+directive @goModel(model: String) on OBJECT | INTERFACE
+
+"""
+The query root
+"""
+type Query @goModel(model: "example.com/graph.Resolver") {
+    # comments are skipped
+    things(limit: Int, kind: ID!): [Thing!]!
+    count: Int!
+}
+
+type Mutation {
+    renameThing(id: ID!, name: String!): Thing!
+}
+
+type Thing {
+    id: ID!
+    "the thing's parts"
+    parts: [Part]
+}
+
+type Part {
+    name: String!
+}
+`
+
+func TestFromOpenAPI(t *testing.T) {
+	api, err := FromOpenAPI([]byte(testOpenAPI))
+	require.NoError(t, err)
+
+	require.Len(t, api.Types, 2)
+	assert.Equal(t, "Thing", api.Types[0].Name)
+	assert.Equal(t, []Field{
+		{Name: "extra", Type: TypeRef{Kind: "any"}, Optional: true},
+		{Name: "id", Type: TypeRef{Kind: "string"}},
+	}, api.Types[0].Fields)
+
+	response := api.Types[1]
+	assert.Equal(t, "ThingsResponse", response.Name)
+	assert.Equal(t, []Field{
+		{Name: "owner", Type: TypeRef{Kind: "ref", Ref: "Thing", Nullable: true}, Optional: true},
+		{Name: "tags", Type: TypeRef{Kind: "map", Elem: &TypeRef{Kind: "number"}}, Optional: true},
+		{Name: "things", Type: TypeRef{Kind: "array", Elem: &TypeRef{Kind: "ref", Ref: "Thing"}}},
+	}, response.Fields)
+
+	require.Len(t, api.Operations, 1)
+	op := api.Operations[0]
+	assert.Equal(t, "GetThings", op.ID)
+	assert.Equal(t, "GET", op.Method)
+	assert.Equal(t, "/api/things", op.Path)
+	assert.True(t, op.Deprecated)
+	assert.Equal(t, "ThingsResponse", op.Response)
+	assert.Equal(t, []Param{
+		{Name: "thingId", Description: "Thing ID", Type: TypeRef{Kind: "string"}},
+		{Name: "limit", Type: TypeRef{Kind: "integer"}, Required: true},
+	}, op.Params)
+}
+
+func TestFromOpenAPIErrors(t *testing.T) {
+	_, err := FromOpenAPI([]byte(`{`))
+	assert.ErrorContains(t, err, "parsing OpenAPI document")
+
+	_, err = FromOpenAPI([]byte(`{"paths": {"/x": {"get": {"parameters": [{"name": "id", "in": "path"}]}}}}`))
+	assert.ErrorContains(t, err, `unsupported parameter location "path"`)
+
+	_, err = FromOpenAPI([]byte(`{"paths": {"/x": {"get": {}}}}`))
+	assert.ErrorContains(t, err, "no JSON success response")
+}
+
+func TestParseGraphQLSchema(t *testing.T) {
+	types, queries, err := ParseGraphQLSchema(testSchema)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Type{
+		{Name: "GraphQLThing", Fields: []Field{
+			{Name: "id", Type: TypeRef{Kind: "string"}},
+			{Name: "parts", Type: TypeRef{Kind: "array", Nullable: true, Elem: &TypeRef{Kind: "ref", Ref: "GraphQLPart", Nullable: true}}},
+		}},
+		{Name: "GraphQLPart", Fields: []Field{
+			{Name: "name", Type: TypeRef{Kind: "string"}},
+		}},
+	}, types)
+
+	require.Len(t, queries, 3)
+	things := queries[0]
+	assert.Equal(t, "things", things.Name)
+	assert.Equal(t, TypeRef{Kind: "array", Elem: &TypeRef{Kind: "ref", Ref: "GraphQLThing"}}, things.Result)
+	assert.Equal(t, "{ id parts { name } }", things.Selection)
+	assert.Equal(t, []Param{
+		{Name: "limit", Type: TypeRef{Kind: "integer", Nullable: true}, GraphQLType: "Int"},
+		{Name: "kind", Type: TypeRef{Kind: "string"}, Required: true, GraphQLType: "ID!"},
+	}, things.Args)
+	assert.Equal(t, "query($limit: Int, $kind: ID!) { things(limit: $limit, kind: $kind) { id parts { name } } }", graphQLDocument(things))
+
+	count := queries[1]
+	assert.Empty(t, count.Selection)
+	assert.Equal(t, "query { count }", graphQLDocument(count))
+	assert.Equal(t, "QueryCount", count.Method())
+
+	rename := queries[2]
+	assert.True(t, rename.Mutation)
+	assert.Equal(t, "MutateRenameThing", rename.Method())
+	assert.Equal(t, "mutation($id: ID!, $name: String!) { renameThing(id: $id, name: $name) { id parts { name } } }", graphQLDocument(rename))
+}
+
+func TestParseGraphQLSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{name: "unsupported definition", schema: `enum Kind { A }`, want: `unsupported definition "enum"`},
+		{name: "unknown type", schema: `type Query { thing: Thing }`, want: `Query.thing: unknown type "Thing"`},
+		{name: "default value", schema: `type Query { things(limit: Int = 5): Int }`, want: "default values are not supported"},
+		{name: "truncated", schema: `type Query { things: `, want: "unexpected end of schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseGraphQLSchema(tt.schema)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func testAPI(t *testing.T) *API {
+	t.Helper()
+	api, err := FromOpenAPI([]byte(testOpenAPI))
+	require.NoError(t, err)
+	api.GraphQLTypes, api.GraphQLQueries, err = ParseGraphQLSchema(testSchema)
+	require.NoError(t, err)
+	return api
+}
+
+func TestGenerateGo(t *testing.T) {
+	src, err := GenerateGo(testAPI(t), "things")
+	require.NoError(t, err)
+	code := string(src)
+
+	assert.True(t, strings.HasPrefix(code, "// Code generated by cmd/sdkgen. DO NOT EDIT.\n\npackage things\n"))
+	for _, want := range []string{
+		"\"encoding/json\"",
+		"Extra json.RawMessage `json:\"extra,omitempty\"`",
+		"Owner  *Thing             `json:\"owner,omitempty\"`",
+		"Tags   map[string]float64 `json:\"tags,omitempty\"`",
+		"ThingID *string",
+		"Limit   int64",
+		"// Deprecated: use the latest version of this operation.",
+		`query.Set("limit", strconv.FormatInt(params.Limit, 10))`,
+		`query.Set("thingId", *params.ThingID)`,
+		"func (c *Client) GetThings(ctx context.Context, params GetThingsParams) (*ThingsResponse, error)",
+		"Parts []*GraphQLPart `json:\"parts\"`",
+		"Limit *int64 `json:\"limit,omitempty\"`",
+		"Kind  string `json:\"kind\"`",
+		"func (c *Client) QueryThings(ctx context.Context, args QueryThingsArgs) ([]GraphQLThing, error)",
+		"func (c *Client) QueryCount(ctx context.Context, args QueryCountArgs) (int64, error)",
+		"// MutateRenameThingArgs are the arguments of the GraphQL renameThing mutation",
+		"func (c *Client) MutateRenameThing(ctx context.Context, args MutateRenameThingArgs) (GraphQLThing, error)",
+	} {
+		assert.Contains(t, code, want)
+	}
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	src, err := GenerateTypeScript(testAPI(t))
+	require.NoError(t, err)
+	code := string(src)
+
+	for _, want := range []string{
+		"export interface Thing {\n  extra?: unknown;\n  id: string;\n}",
+		"  owner?: Thing | null;\n  tags?: Record<string, number>;\n  things: Thing[];",
+		"export interface GetThingsParams {\n  /** Thing ID */\n  thingId?: string;\n  limit: number;\n}",
+		"   * @deprecated use the latest version of this operation",
+		"getThings(params: GetThingsParams): Promise<ThingsResponse> {",
+		"  parts: (GraphQLPart | null)[] | null;",
+		"export interface QueryThingsArgs {\n  limit?: number | null;\n  kind: string;\n}",
+		"async queryThings(args: QueryThingsArgs): Promise<GraphQLThing[]> {",
+		"async queryCount(args: QueryCountArgs = {}): Promise<number> {",
+		"/** Arguments of the GraphQL renameThing mutation */\nexport interface MutateRenameThingArgs {",
+		"async mutateRenameThing(args: MutateRenameThingArgs): Promise<GraphQLThing> {",
+		`"query($limit: Int, $kind: ID!) { things(limit: $limit, kind: $kind) { id parts { name } } }"`,
+	} {
+		assert.Contains(t, code, want)
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	assert.Equal(t, "StationID", exportedName("stationId"))
+	assert.Equal(t, "GetTidesV2", exportedName("getTidesV2"))
+	assert.Equal(t, "", exportedName(""))
+	assert.Equal(t, "getTides", lowerFirst("GetTides"))
+}
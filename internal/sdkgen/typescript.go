@@ -0,0 +1,184 @@
+package sdkgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// GenerateTypeScript renders the TypeScript client, runtime included, as one module
+func GenerateTypeScript(api *API) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tsTemplate.Execute(&buf, api); err != nil {
+		return nil, fmt.Errorf("rendering TypeScript client: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func tsType(ref TypeRef) string {
+	var t string
+	switch ref.Kind {
+	case "string":
+		t = "string"
+	case "number", "integer":
+		t = "number"
+	case "boolean":
+		t = "boolean"
+	case "array":
+		elem := tsType(*ref.Elem)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		t = elem + "[]"
+	case "map":
+		t = "Record<string, " + tsType(*ref.Elem) + ">"
+	case "ref":
+		t = ref.Ref
+	default:
+		t = "unknown"
+	}
+	if ref.Nullable {
+		return t + " | null"
+	}
+	return t
+}
+
+var tsTemplate = template.Must(template.New("ts").Funcs(template.FuncMap{
+	"tsType":          tsType,
+	"lowerFirst":      lowerFirst,
+	"quote":           func(s string) string { return fmt.Sprintf("%q", s) },
+	"graphQLDocument": graphQLDocument,
+	"allOptional": func(params []Param) bool {
+		for _, p := range params {
+			if p.Required {
+				return false
+			}
+		}
+		return true
+	},
+}).Parse(`// Code generated by cmd/sdkgen. DO NOT EDIT.
+
+/** Raised for non-2xx responses and GraphQL errors */
+export class FlowebbError extends Error {
+  constructor(
+    message: string,
+    readonly status: number,
+    readonly details: ParamError[] = [],
+    /** e.g. STATION_NOT_FOUND or RANGE_TOO_LARGE; for GraphQL, the first error's */
+    readonly code?: string,
+  ) {
+    super(message);
+    this.name = "FlowebbError";
+  }
+}
+
+export interface FlowebbClientOptions {
+  /** Path of the GraphQL endpoint, default "/graphql" */
+  graphQLPath?: string;
+  /** fetch implementation, default the global fetch */
+  fetch?: typeof fetch;
+  /** Sent with every request, e.g. x-api-key or Authorization for the user profile operations */
+  headers?: Record<string, string>;
+}
+
+type QueryValue = string | number | boolean | undefined | null;
+{{range .Types}}
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}{{if .Optional}}?{{end}}: {{tsType .Type}};
+{{- end}}
+}
+{{end}}
+{{- range .Operations}}
+/** Query parameters of {{.Method}} {{.Path}} */
+export interface {{.ID}}Params {
+{{- range .Params}}
+{{- if .Description}}
+  /** {{.Description}} */
+{{- end}}
+  {{.Name}}{{if not .Required}}?{{end}}: {{tsType .Type}};
+{{- end}}
+}
+{{end}}
+{{- range .GraphQLTypes}}
+export interface {{.Name}} {
+{{- range .Fields}}
+  {{.Name}}{{if .Optional}}?{{end}}: {{tsType .Type}};
+{{- end}}
+}
+{{end}}
+{{- range .GraphQLQueries}}
+/** Arguments of the GraphQL {{.Name}} {{if .Mutation}}mutation{{else}}query{{end}} */
+export interface {{.Method}}Args {
+{{- range .Args}}
+  {{.Name}}{{if not .Required}}?{{end}}: {{tsType .Type}};
+{{- end}}
+}
+{{end}}
+export class FlowebbClient {
+  private readonly baseUrl: string;
+  private readonly graphQLPath: string;
+  private readonly fetchImpl: typeof fetch;
+  private readonly headers: Record<string, string>;
+
+  constructor(baseUrl: string, options: FlowebbClientOptions = {}) {
+    this.baseUrl = baseUrl.replace(/\/+$/, "");
+    this.graphQLPath = options.graphQLPath ?? "/graphql";
+    this.fetchImpl = options.fetch ?? fetch;
+    this.headers = options.headers ?? {};
+  }
+{{range .Operations}}
+  /**
+   * {{.Summary}} ({{.Method}} {{.Path}})
+{{- if .Deprecated}}
+   * @deprecated use the latest version of this operation
+{{- end}}
+   */
+  {{lowerFirst .ID}}(params: {{.ID}}Params{{if allOptional .Params}} = {}{{end}}): Promise<{{.Response}}> {
+    return this.get<{{.Response}}>({{quote .Path}}, { ...params });
+  }
+{{end}}
+{{- range .GraphQLQueries}}
+  /** Runs the GraphQL {{.Name}} {{if .Mutation}}mutation{{else}}query{{end}}, selecting every field */
+  async {{lowerFirst .Method}}(args: {{.Method}}Args{{if allOptional .Args}} = {}{{end}}): Promise<{{tsType .Result}}> {
+    const data = await this.graphQL<{ {{.Name}}: {{tsType .Result}} }>(
+      {{quote (graphQLDocument .)}},
+      { ...args },
+    );
+    return data.{{.Name}};
+  }
+{{end}}
+  private async get<T>(path: string, params: Record<string, QueryValue>): Promise<T> {
+    const query = new URLSearchParams();
+    for (const [key, value] of Object.entries(params)) {
+      if (value !== undefined && value !== null) {
+        query.set(key, String(value));
+      }
+    }
+    const qs = query.toString();
+    const response = await this.fetchImpl(this.baseUrl + path + (qs ? "?" + qs : ""), {
+      headers: { ...this.headers, Accept: "application/json" },
+    });
+    const body = await response.json();
+    if (!response.ok) {
+      throw new FlowebbError(body?.error ?? response.statusText, response.status, body?.details ?? [], body?.code);
+    }
+    return body as T;
+  }
+
+  private async graphQL<T>(query: string, variables: Record<string, unknown>): Promise<T> {
+    const response = await this.fetchImpl(this.baseUrl + this.graphQLPath, {
+      method: "POST",
+      headers: { ...this.headers, "Content-Type": "application/json", Accept: "application/json" },
+      body: JSON.stringify({ query, variables }),
+    });
+    const body = await response.json();
+    if (!response.ok || body?.errors?.length) {
+      const message = body?.errors?.map((e: { message: string }) => e.message).join("; ");
+      throw new FlowebbError(message || response.statusText, response.status, [], body?.errors?.[0]?.extensions?.code);
+    }
+    return body.data as T;
+  }
+}
+`))
@@ -0,0 +1,92 @@
+// Package startup initializes a Lambda function's dependencies on the first request
+// rather than at cold start. If a dependency is briefly down, requests fail with a 503
+// and initialization is tried again after a backoff, instead of the runtime crashing and
+// restarting in a loop.
+package startup
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// minBackoff is how long the first failure waits before initialization is tried again
+	minBackoff = time.Second
+	// maxBackoff caps the wait as failures repeat
+	maxBackoff = time.Minute
+)
+
+// now is replaced in tests
+var now = time.Now
+
+// NotReadyError is returned while a function's dependencies aren't initialized
+type NotReadyError struct {
+	// Err is why the last attempt failed
+	Err error
+	// RetryAfter is how long until initialization is tried again
+	RetryAfter time.Duration
+}
+
+func (e *NotReadyError) Error() string {
+	return fmt.Sprintf("service is starting up: %v", e.Err)
+}
+
+func (e *NotReadyError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfterSeconds is RetryAfter rounded up to whole seconds, for a Retry-After header
+func (e *NotReadyError) RetryAfterSeconds() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// Once runs an initialization function until it succeeds
+type Once struct {
+	fn func() error
+
+	mu       sync.Mutex
+	done     bool
+	err      error
+	failures int
+	retryAt  time.Time
+}
+
+// New returns a Once that runs fn
+func New(fn func() error) *Once {
+	return &Once{fn: fn}
+}
+
+// Do runs the initialization function unless it has succeeded, returning a
+// *NotReadyError if it fails. After a failure it isn't run again until a backoff has
+// passed, doubling with each failure up to a minute; until then Do returns the last
+// failure at once.
+func (o *Once) Do() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.done {
+		return nil
+	}
+	if wait := o.retryAt.Sub(now()); wait > 0 {
+		return &NotReadyError{Err: o.err, RetryAfter: wait}
+	}
+
+	start := now()
+	if err := o.fn(); err != nil {
+		backoff := maxBackoff
+		if o.failures < 6 {
+			backoff = min(minBackoff<<o.failures, maxBackoff)
+		}
+		o.failures++
+		o.err = err
+		o.retryAt = now().Add(backoff)
+		log.Error().Err(err).Int("attempt", o.failures).Dur("elapsed", now().Sub(start)).Dur("retryAfter", backoff).Msg("Initialization failed")
+		return &NotReadyError{Err: err, RetryAfter: backoff}
+	}
+	log.Info().Int("attempt", o.failures+1).Dur("elapsed", now().Sub(start)).Msg("Initialization succeeded")
+	o.done = true
+	o.err = nil
+	return nil
+}
@@ -0,0 +1,69 @@
+package startup
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useClock makes now return *clock for the rest of the test
+func useClock(t *testing.T, clock *time.Time) {
+	t.Helper()
+	previous := now
+	now = func() time.Time { return *clock }
+	t.Cleanup(func() { now = previous })
+}
+
+func TestOnce(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	useClock(t, &clock)
+
+	calls := 0
+	failure := errors.New("dynamo unavailable")
+	once := New(func() error {
+		calls++
+		if calls < 3 {
+			return failure
+		}
+		return nil
+	})
+
+	var notReady *NotReadyError
+	require.ErrorAs(t, once.Do(), &notReady)
+	assert.ErrorIs(t, notReady, failure)
+	assert.Equal(t, time.Second, notReady.RetryAfter)
+
+	clock = clock.Add(500 * time.Millisecond)
+	require.ErrorAs(t, once.Do(), &notReady)
+	assert.Equal(t, 500*time.Millisecond, notReady.RetryAfter)
+	assert.Equal(t, 1, notReady.RetryAfterSeconds())
+	assert.Equal(t, 1, calls, "initialization waits out the backoff")
+
+	clock = clock.Add(500 * time.Millisecond)
+	require.ErrorAs(t, once.Do(), &notReady)
+	assert.Equal(t, 2*time.Second, notReady.RetryAfter, "the backoff doubles")
+	assert.Equal(t, 2, calls)
+
+	clock = clock.Add(2 * time.Second)
+	assert.NoError(t, once.Do())
+	assert.NoError(t, once.Do())
+	assert.Equal(t, 3, calls, "initialization runs until it succeeds")
+}
+
+func TestOnce_BackoffCap(t *testing.T) {
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	useClock(t, &clock)
+
+	once := New(func() error { return errors.New("boom") })
+	var notReady *NotReadyError
+	for range 100 {
+		require.ErrorAs(t, once.Do(), &notReady)
+		assert.LessOrEqual(t, notReady.RetryAfter, maxBackoff)
+		assert.Positive(t, notReady.RetryAfter)
+		clock = clock.Add(notReady.RetryAfter)
+	}
+	assert.Equal(t, maxBackoff, notReady.RetryAfter)
+}
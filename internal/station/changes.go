@@ -0,0 +1,81 @@
+package station
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// ListChangeFunc hears of the stations that changed when a source's station list is
+// refreshed
+type ListChangeFunc func(ctx context.Context, source models.Source, changes []models.StationChange)
+
+// OnListChange registers fn to hear how the station list changed each time a refresh
+// downloads one that differs from the list it replaces. It's called within the refresh,
+// which runs in a request, so fn holds that request up while it runs. Register it before
+// the finder is used.
+func (f *NOAAStationFinder) OnListChange(fn ListChangeFunc) {
+	f.onListChange = fn
+}
+
+// stationFields are the fields of a station compared between lists. Capabilities are left
+// out: they come from sensor lists that are fetched separately and may fail on their own,
+// so they'd report changes NOAA never made.
+var stationFields = []struct {
+	name  string
+	value func(models.Station) string
+}{
+	{"name", func(s models.Station) string { return s.Name }},
+	{"state", func(s models.Station) string { return stringValue(s.State) }},
+	{"region", func(s models.Station) string { return stringValue(s.Region) }},
+	{"latitude", func(s models.Station) string { return strconv.FormatFloat(s.Latitude, 'f', -1, 64) }},
+	{"longitude", func(s models.Station) string { return strconv.FormatFloat(s.Longitude, 'f', -1, 64) }},
+	{"timeZoneOffset", func(s models.Station) string { return strconv.Itoa(s.TimeZoneOffset) }},
+	{"level", func(s models.Station) string { return stringValue(s.Level) }},
+	{"stationType", func(s models.Station) string { return string(s.Kind()) }},
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Diff returns the stations added to, removed from and modified between the before and
+// after lists, sorted by station ID. It's empty when the lists hold the same stations.
+func Diff(before, after []models.Station) []models.StationChange {
+	previous := make(map[string]models.Station, len(before))
+	for _, s := range before {
+		previous[s.ID] = s
+	}
+
+	var changes []models.StationChange
+	for _, s := range after {
+		old, ok := previous[s.ID]
+		if !ok {
+			changes = append(changes, models.StationChange{StationID: s.ID, Name: s.Name, Change: models.StationAdded})
+			continue
+		}
+		delete(previous, s.ID)
+		var fields []models.StationFieldChange
+		for _, field := range stationFields {
+			if was, is := field.value(old), field.value(s); was != is {
+				fields = append(fields, models.StationFieldChange{Field: field.name, Before: was, After: is})
+			}
+		}
+		if len(fields) > 0 {
+			changes = append(changes, models.StationChange{StationID: s.ID, Name: s.Name, Change: models.StationModified, Fields: fields})
+		}
+	}
+	for _, s := range previous {
+		changes = append(changes, models.StationChange{StationID: s.ID, Name: s.Name, Change: models.StationRemoved})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].StationID < changes[j].StationID
+	})
+	return changes
+}
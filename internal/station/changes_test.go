@@ -0,0 +1,38 @@
+package station
+
+import (
+	"testing"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	moved := createTestStation("9447130")
+	moved.Latitude = 47.6026
+	moved.Name = "Seattle, Elliott Bay"
+	moved.Capabilities = append(moved.Capabilities, models.CapabilityWaterTemperature)
+	retyped := createTestStation("9446484")
+	retyped.Level = nil
+	retyped.StationType = models.StationKindSubordinate
+	unchanged := createTestStation("9444900")
+	unchanged.Capabilities = nil
+
+	before := []models.Station{createTestStation("9447130"), createTestStation("9444900"), createTestStation("9446484"), createTestStation("9449880")}
+	after := []models.Station{createTestStation("9414290"), moved, unchanged, retyped}
+
+	assert.Equal(t, []models.StationChange{
+		{StationID: "9414290", Name: "Test Station 9414290", Change: models.StationAdded},
+		{StationID: "9446484", Name: "Test Station 9446484", Change: models.StationModified, Fields: []models.StationFieldChange{
+			{Field: "level", Before: "R"},
+			{Field: "stationType", Before: "R", After: "S"},
+		}},
+		{StationID: "9447130", Name: "Seattle, Elliott Bay", Change: models.StationModified, Fields: []models.StationFieldChange{
+			{Field: "name", Before: "Test Station 9447130", After: "Seattle, Elliott Bay"},
+			{Field: "latitude", Before: "47.6062", After: "47.6026"},
+		}},
+		{StationID: "9449880", Name: "Test Station 9449880", Change: models.StationRemoved},
+	}, Diff(before, after), "capabilities come from sensor lists and aren't compared")
+
+	assert.Empty(t, Diff(before, before))
+}
@@ -6,13 +6,19 @@ import (
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"math"
+	"net/http"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
 	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/geo"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/hashicorp/golang-lru/v2"
 )
 
 type FinderFactory interface {
@@ -22,118 +28,358 @@ type FinderFactory interface {
 type DefaultFinderFactory struct{}
 
 func (f *DefaultFinderFactory) NewFinder(httpClient *client.Client, memCache *cache.StationCache) (*NOAAStationFinder, error) {
-	return NewNOAAStationFinder(httpClient, memCache)
+	finder, err := NewNOAAStationFinder(httpClient, memCache)
+	if err != nil {
+		return nil, err
+	}
+	if err := finder.UseStationListCacheFromEnv(context.Background()); err != nil {
+		log.Warn().Err(err).Msg("Station list cache unavailable, using memory cache only")
+	}
+	return finder, nil
 }
 
 type NOAAStationFinder struct {
 	httpClient *client.Client
 	memCache   *cache.StationCache
-	s3Cache    cache.StationListCacheProvider
+	listCache  cache.StationListCacheProvider
 	cacheMutex sync.RWMutex
+	// sensorCache holds each looked-up station's capabilities from its sensor list
+	sensorCache *lru.Cache[string, sensorEntry]
+	// offsetCache holds each looked-up subordinate station's offsets
+	offsetCache *lru.Cache[string, offsetEntry]
+	// snapshot is served, marked stale, until the station list is first loaded
+	snapshot []models.Station
+	// refreshMu guards refresh and retryAfter
+	refreshMu sync.Mutex
+	// refresh is closed when the station list load running in the background, if any,
+	// finishes
+	refresh chan struct{}
+	// retryAfter holds off background loads after one fails, so lookups don't ask an
+	// unreachable NOAA again each time
+	retryAfter time.Time
+	// landMask, when set, ranks nearest stations by how much of the way to them is water
+	landMask *geo.LandMask
+	// retired is NOAA's retired stations, downloaded when a retired ID is first looked up
+	// and dropped whenever the station list loads; guarded by cacheMutex
+	retired *retiredEntry
+	// onListChange hears how a refreshed station list differs from the one it replaces
+	onListChange ListChangeFunc
 }
 
-var _ models.StationFinder = (*NOAAStationFinder)(nil)
+var (
+	_ models.StationFinder = (*NOAAStationFinder)(nil)
+	_ models.BoundsFinder  = (*NOAAStationFinder)(nil)
+	_ models.RegionFinder  = (*NOAAStationFinder)(nil)
+)
 
 func NewNOAAStationFinder(httpClient *client.Client, memCache *cache.StationCache) (*NOAAStationFinder, error) {
 	if memCache == nil {
 		memCache = cache.NewStationCache(nil) // Use default config
 	}
 
+	sensorCache, err := lru.New[string, sensorEntry](sensorCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("creating sensor cache: %w", err)
+	}
+	offsetCache, err := lru.New[string, offsetEntry](offsetCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("creating offset cache: %w", err)
+	}
+
 	return &NOAAStationFinder{
-		httpClient: httpClient,
-		memCache:   memCache,
+		httpClient:  httpClient,
+		memCache:    memCache,
+		sensorCache: sensorCache,
+		offsetCache: offsetCache,
+		snapshot:    embeddedSnapshot(),
 	}, nil
 }
 
+// UseStationListCacheFromEnv wires in the persistent station list cache (S3, or files when
+// CACHE_BACKEND=file) if one is configured
+func (f *NOAAStationFinder) UseStationListCacheFromEnv(ctx context.Context) error {
+	listCache, err := cache.NewStationListCacheFromEnv(ctx, models.SourceNOAA)
+	if err != nil {
+		return fmt.Errorf("creating station list cache: %w", err)
+	}
+	if listCache != nil {
+		f.SetStationListCache(listCache)
+	}
+	return nil
+}
+
+// SetStationListCache persists the station list (e.g. in S3) so cold starts don't have to download it from NOAA
+func (f *NOAAStationFinder) SetStationListCache(listCache cache.StationListCacheProvider) {
+	f.listCache = listCache
+}
+
 func (f *NOAAStationFinder) FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
-	// Validate coordinates
-	if lat < -90 || lat > 90 {
-		return nil, fmt.Errorf("invalid latitude: %f", lat)
+	page, err := f.FindNearestStationsPage(ctx, lat, lon, models.StationFilter{}, 0, limit)
+	if err != nil {
+		return nil, err
 	}
-	if lon < -180 || lon > 180 {
-		return nil, fmt.Errorf("invalid longitude: %f", lon)
+	return page.Stations, nil
+}
+
+func (f *NOAAStationFinder) FindNearestStationsPage(ctx context.Context, lat, lon float64, filter models.StationFilter, offset, limit int) (*models.StationPage, error) {
+	if err := validate.Coordinates(lat, lon); err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset: %d", offset)
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
 	}
 
 	// Get all stations
-	stations, err := f.getStationList(ctx)
+	stations, stale, err := f.stationList(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting station list: %w", err)
 	}
+	stations = filter.Apply(stations)
 
-	// Calculate distances and sort
-	type stationDistance struct {
-		station  models.Station
-		distance float64
-	}
-
-	stationDistances := make([]stationDistance, len(stations))
+	// Calculate distances and sort. The sort is stable so equidistant stations keep the
+	// same order from one page request to the next.
+	sorted := make([]models.Station, len(stations))
 	for i, station := range stations {
-		distance := calculateDistance(lat, lon, station.Latitude, station.Longitude)
-		stationDistances[i] = stationDistance{
-			station:  station,
-			distance: distance,
-		}
+		station.Distance = calculateDistance(lat, lon, station.Latitude, station.Longitude)
+		sorted[i] = station
 	}
-
-	// Sort by distance
-	sort.Slice(stationDistances, func(i, j int) bool {
-		return stationDistances[i].distance < stationDistances[j].distance
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Distance < sorted[j].Distance
 	})
 
-	// Limit results and convert back to Station slice
 	if limit <= 0 {
-		limit = 5 // Default limit if not specified
+		limit = models.DefaultStationLimit
 	}
-	if limit > len(stationDistances) {
-		limit = len(stationDistances)
+	if f.landMask != nil {
+		snapToWater(f.landMask, lat, lon, sorted, offset+limit)
 	}
+	page := models.NewStationPage(sorted, offset, limit)
+	page.Stale = stale
 
-	result := make([]models.Station, limit)
-	for i := 0; i < limit; i++ {
-		station := stationDistances[i].station
-		station.Distance = stationDistances[i].distance // Add distance to result
-		result[i] = station
+	// Bearings are only needed for the stations returned
+	for i := range page.Stations {
+		bearing := calculateBearing(lat, lon, page.Stations[i].Latitude, page.Stations[i].Longitude)
+		page.Stations[i].Bearing = &bearing
 	}
 
-	return result, nil
+	return page, nil
 }
 
-func (f *NOAAStationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
+func (f *NOAAStationFinder) FindStationsInBounds(ctx context.Context, bounds geo.Bounds, filter models.StationFilter) ([]models.Station, error) {
+	if err := validateBounds(bounds); err != nil {
+		return nil, err
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	stations, err := f.getStationList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting station list: %w", err)
+	}
+	var inBounds []models.Station
+	for _, station := range filter.Apply(stations) {
+		if bounds.Contains(station.Latitude, station.Longitude) {
+			inBounds = append(inBounds, station)
+		}
+	}
+	return inBounds, nil
+}
+
+func (f *NOAAStationFinder) FindRegions(ctx context.Context, filter models.StationFilter, state string) ([]models.StationRegion, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
 	stations, err := f.getStationList(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("getting station list: %w", err)
 	}
+	return models.GroupRegions(filter.Apply(stations), state), nil
+}
+
+// validateBounds checks a map's edges, reporting them north, south, east then west
+func validateBounds(bounds geo.Bounds) error {
+	if err := validate.Latitude("north", bounds.North); err != nil {
+		return err
+	}
+	if err := validate.Between("south", bounds.South, -90, bounds.North); err != nil {
+		return err
+	}
+	if err := validate.Longitude("east", bounds.East); err != nil {
+		return err
+	}
+	return validate.Longitude("west", bounds.West)
+}
+
+// maxStationSuggestions is how many stations a lookup of an unknown ID suggests instead
+const maxStationSuggestions = 3
+
+func (f *NOAAStationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
+	stations, stale, err := f.stationList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting station list: %w", err)
+	}
 
+	if station := findByID(stations, stationID); station != nil {
+		return station, nil
+	}
+	// The station may be newer than an out-of-date list, so wait for the load under way
+	// rather than starting another; without a fresh list, the one at hand answers
+	if stale && f.FlushCacheWrites(ctx) == nil {
+		f.cacheMutex.RLock()
+		fresh := f.memCache.GetStations()
+		f.cacheMutex.RUnlock()
+		if fresh != nil {
+			stations = fresh
+			if station := findByID(stations, stationID); station != nil {
+				return station, nil
+			}
+		}
+	}
+
+	// NOAA retires stations, and links to them outlive them
+	if err := f.retiredError(ctx, stations, stationID); err != nil {
+		return nil, err
+	}
+	return nil, &models.StationNotFoundError{
+		StationID:   stationID,
+		Suggestions: models.SuggestStations(stations, stationID, maxStationSuggestions),
+	}
+}
+
+func findByID(stations []models.Station, stationID string) *models.Station {
 	for _, station := range stations {
 		if station.ID == stationID {
-			return &station, nil
+			return &station
 		}
 	}
+	return nil
+}
 
-	return nil, fmt.Errorf("station not found: %s", stationID)
+// LoadStations loads the station list if it isn't loaded, and revalidates an expired one
+// with NOAA, so later lookups don't wait on either. An expired list is kept when NOAA
+// can't be reached.
+func (f *NOAAStationFinder) LoadStations(ctx context.Context) error {
+	if _, err := f.getStationList(ctx); err != nil {
+		return err
+	}
+	return f.FlushCacheWrites(ctx)
 }
 
 func (f *NOAAStationFinder) getStationList(ctx context.Context) ([]models.Station, error) {
+	stations, _, err := f.stationList(ctx)
+	return stations, err
+}
+
+// stationList returns the station list and whether it may be out of date: an expired list
+// or, until the first download, the snapshot, either served while the list loads in the
+// background
+func (f *NOAAStationFinder) stationList(ctx context.Context) ([]models.Station, bool, error) {
 	// Check memory cache first
 	f.cacheMutex.RLock()
 	stations := f.memCache.GetStations()
+	stale := f.memCache.GetStaleStations()
 	f.cacheMutex.RUnlock()
 
 	if stations != nil {
 		log.Debug().Msg("Memory cache HIT for station list")
-		return stations, nil
+		return stations, false, nil
+	}
+
+	if stale != nil {
+		log.Debug().Msg("Memory cache STALE for station list, revalidating in the background")
+		f.refreshInBackground(stale)
+		return stale, true, nil
+	}
+
+	if f.snapshot != nil {
+		log.Debug().Msg("Memory cache MISS for station list, serving the snapshot while it loads")
+		f.refreshInBackground(nil)
+		return f.snapshot, true, nil
+	}
+
+	stations, err := f.loadStationList(ctx, nil)
+	return stations, false, err
+}
+
+const (
+	// listRefreshTimeout bounds a station list load in the background
+	listRefreshTimeout = 30 * time.Second
+	// listRefreshBackoff is how long after a failed load in the background the next starts
+	listRefreshBackoff = time.Minute
+)
+
+// refreshInBackground starts loading the station list unless a load is already running or
+// the last one failed less than listRefreshBackoff ago. Lookups don't wait for it; they're
+// answered with the list at hand, marked stale. stale is that list when it's an expired
+// one, so NOAA is asked only for a list that changed since, and nil for the snapshot.
+//
+// The load outlives the request that started it, and Lambda freezes the instance once the
+// response is sent, so Lambda entrypoints call FlushCacheWrites before returning. A load
+// the freeze cuts short fails at listRefreshTimeout and is retried after the backoff.
+func (f *NOAAStationFinder) refreshInBackground(stale []models.Station) {
+	f.refreshMu.Lock()
+	defer f.refreshMu.Unlock()
+	if f.refresh != nil || time.Now().Before(f.retryAfter) {
+		return
+	}
+	refresh := make(chan struct{})
+	f.refresh = refresh
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), listRefreshTimeout)
+		defer cancel()
+		_, err := f.loadStationList(ctx, stale)
+		if err != nil {
+			log.Warn().Err(err).Dur("retryIn", listRefreshBackoff).Msg("Loading the station list in the background failed, serving the list at hand")
+		}
+
+		f.refreshMu.Lock()
+		if err != nil {
+			f.retryAfter = time.Now().Add(listRefreshBackoff)
+		}
+		f.refresh = nil
+		f.refreshMu.Unlock()
+		close(refresh)
+	}()
+}
+
+// FlushCacheWrites waits for the station list loading in the background, if any, to be
+// saved to the caches, returning ctx's error if ctx is done first. A load that fails keeps
+// the list at hand and logs why.
+func (f *NOAAStationFinder) FlushCacheWrites(ctx context.Context) error {
+	f.refreshMu.Lock()
+	refresh := f.refresh
+	f.refreshMu.Unlock()
+	if refresh == nil {
+		return nil
+	}
+	select {
+	case <-refresh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
 
-	// Check S3 cache if available
-	if f.s3Cache != nil {
-		stations, err := f.s3Cache.GetStations(ctx)
+// loadStationList reads the station list from the persistent cache or NOAA. When stale stations are given,
+// NOAA is asked only for a list that changed since they were downloaded.
+func (f *NOAAStationFinder) loadStationList(ctx context.Context, stale []models.Station) ([]models.Station, error) {
+	// Check the persistent cache (S3 or file) if available
+	if f.listCache != nil {
+		stations, err := f.listCache.GetStations(ctx)
 		if err != nil {
-			log.Error().Err(err).Msg("Error getting stations from S3 cache")
+			log.Error().Err(err).Msg("Error getting stations from station list cache")
 		} else if stations != nil {
-			log.Debug().Msg("S3 cache HIT for station list")
+			log.Debug().Msg("Station list cache HIT")
 			// Update memory cache
 			f.cacheMutex.Lock()
-			f.memCache.SetStations(stations)
+			stations = f.memCache.SetStations(stations)
+			f.retired = nil
 			f.cacheMutex.Unlock()
 			return stations, nil
 		}
@@ -141,8 +387,21 @@ func (f *NOAAStationFinder) getStationList(ctx context.Context) ([]models.Statio
 
 	log.Debug().Msg("Cache MISS for station list, fetching from NOAA API")
 
+	headers := make(map[string]string)
+	if stale != nil {
+		f.cacheMutex.RLock()
+		etag, lastModified := f.memCache.Validators()
+		f.cacheMutex.RUnlock()
+		if etag != "" {
+			headers["If-None-Match"] = etag
+		}
+		if lastModified != "" {
+			headers["If-Modified-Since"] = lastModified
+		}
+	}
+
 	// Fetch from NOAA API
-	resp, err := f.httpClient.Get(ctx, "/mdapi/prod/webapi/tidepredstations.json")
+	resp, err := f.httpClient.GetWithHeaders(ctx, "/mdapi/prod/webapi/tidepredstations.json", headers)
 	if err != nil {
 		return nil, fmt.Errorf("fetching stations: %w", err)
 	}
@@ -150,6 +409,14 @@ func (f *NOAAStationFinder) getStationList(ctx context.Context) ([]models.Statio
 		return nil, fmt.Errorf("no response from NOAA API")
 	}
 
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		log.Debug().Msg("Station list not modified, keeping cached stations")
+		f.cacheMutex.Lock()
+		f.memCache.Touch()
+		f.cacheMutex.Unlock()
+		return stale, nil
+	}
+
 	var noaaResp struct {
 		Stations []struct {
 			ID           string  `json:"stationId"`
@@ -169,18 +436,15 @@ func (f *NOAAStationFinder) getStationList(ctx context.Context) ([]models.Statio
 	}
 
 	// Convert to Station objects
-	stations = make([]models.Station, len(noaaResp.Stations))
+	stations := make([]models.Station, len(noaaResp.Stations))
 	for i, s := range noaaResp.Stations {
-		var level, stationType *string
+		var level *string
 		if s.Level != "" {
 			levelValue := s.Level
 			level = &levelValue
 		}
-		if s.StationType != "" {
-			stationTypeValue := s.StationType
-			stationType = &stationTypeValue
-		}
 
+		offset := parseTimeZoneOffset(s.TimeZoneCorr)
 		stations[i] = models.Station{
 			ID:             s.ID,
 			Name:           s.Name,
@@ -189,49 +453,151 @@ func (f *NOAAStationFinder) getStationList(ctx context.Context) ([]models.Statio
 			Latitude:       s.Lat,
 			Longitude:      s.Lon,
 			Source:         models.SourceNOAA,
-			Capabilities:   []string{"WATER_LEVEL"},
-			TimeZoneOffset: parseTimeZoneOffset(s.TimeZoneCorr),
+			Capabilities:   []string{models.CapabilityWaterLevel},
+			TimeZoneOffset: offset,
+			TimeZone:       resolveTimeZone(offset, s.State),
 			Level:          level,
-			StationType:    stationType,
+			StationType:    models.StationKind(s.StationType),
 		}
+		// A station NOAA didn't type is listed as the type it's predicted as
+		stations[i].StationType = stations[i].Kind()
 	}
 
-	// Save to both caches asynchronously
-	if f.s3Cache != nil {
-		go func() {
-			if err := f.s3Cache.SaveStations(context.Background(), stations); err != nil {
-				log.Error().Err(err).Msg("Failed to save stations to S3 cache")
-			}
-		}()
+	f.addSensorCapabilities(ctx, stations)
+	if stale != nil && f.onListChange != nil {
+		if changes := Diff(stale, stations); len(changes) > 0 {
+			f.onListChange(ctx, models.SourceNOAA, changes)
+		}
+	}
+
+	// A failure only costs the next cold instance a download
+	if f.listCache != nil {
+		if err := f.listCache.SaveStations(ctx, stations); err != nil {
+			log.Error().Err(err).Msg("Failed to save stations to station list cache")
+		}
 	}
 
 	f.cacheMutex.Lock()
-	f.memCache.SetStations(stations)
+	stations = f.memCache.SetStations(stations)
+	f.memCache.SetValidators(resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+	f.retired = nil
 	f.cacheMutex.Unlock()
 
 	return stations, nil
 }
 
-func parseTimeZoneOffset(tzCorr string) int {
-	offset, err := strconv.Atoi(tzCorr)
+// sensorCapabilities maps the NOAA metadata API's sensor station types to the capability
+// each adds
+var sensorCapabilities = []struct {
+	stationType string
+	capability  string
+}{
+	{"watertemp", models.CapabilityWaterTemperature},
+	{"conductivity", models.CapabilityConductivity},
+}
+
+// addSensorCapabilities marks the stations that also measure water temperature or
+// conductivity. The prediction station list doesn't say, so each sensor's station list is
+// fetched too, all at once so a cold load waits on only the slowest; one that can't be
+// fetched only leaves its capability off.
+func (f *NOAAStationFinder) addSensorCapabilities(ctx context.Context, stations []models.Station) {
+	indexes := make(map[string]int, len(stations))
+	for i, s := range stations {
+		indexes[s.ID] = i
+	}
+
+	sensorIDs := make([][]string, len(sensorCapabilities))
+	var wg sync.WaitGroup
+	for i, sensor := range sensorCapabilities {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids, err := f.fetchSensorStationIDs(ctx, sensor.stationType)
+			if err != nil {
+				log.Warn().Err(err).Str("type", sensor.stationType).Msg("Sensor station list unavailable")
+				return
+			}
+			sensorIDs[i] = ids
+		}()
+	}
+	wg.Wait()
+
+	// Capabilities are added in sensorCapabilities order whichever list arrived first
+	for i, sensor := range sensorCapabilities {
+		for _, id := range sensorIDs[i] {
+			if i, ok := indexes[id]; ok {
+				stations[i].Capabilities = append(stations[i].Capabilities, sensor.capability)
+			}
+		}
+	}
+}
+
+func (f *NOAAStationFinder) fetchSensorStationIDs(ctx context.Context, stationType string) ([]string, error) {
+	resp, err := f.httpClient.Get(ctx, "/mdapi/prod/webapi/stations.json?type="+stationType)
 	if err != nil {
+		return nil, fmt.Errorf("fetching %s stations: %w", stationType, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s stations: status %d", stationType, resp.StatusCode)
+	}
+
+	var sensorResp struct {
+		Stations []struct {
+			ID string `json:"id"`
+		} `json:"stations"`
+	}
+	if err := json.Unmarshal(resp.Body, &sensorResp); err != nil {
+		return nil, fmt.Errorf("decoding %s stations: %w", stationType, err)
+	}
+	ids := make([]string, len(sensorResp.Stations))
+	for i, s := range sensorResp.Stations {
+		ids[i] = s.ID
+	}
+	return ids, nil
+}
+
+// parseTimeZoneOffset converts a timeZoneCorr, in hours from UTC, to seconds. Fractional
+// hours may be decimal ("-3.5") or hours and minutes ("+5:30", "-3:30"), so zones like
+// Newfoundland's or India's keep their half hour. Anything unreadable or outside the -12
+// to +14 hours real zones span is treated as UTC rather than failing the station list.
+func parseTimeZoneOffset(tzCorr string) int {
+	tzCorr = strings.TrimSpace(tzCorr)
+	if hoursPart, minutesPart, ok := strings.Cut(tzCorr, ":"); ok {
+		return parseHoursAndMinutes(hoursPart, minutesPart)
+	}
+	hours, err := strconv.ParseFloat(tzCorr, 64)
+	if err != nil || math.IsNaN(hours) || hours < -12 || hours > 14 {
 		return 0
 	}
-	return offset * 3600 // Convert hours to seconds
+	return int(math.Round(hours * 3600))
 }
 
-func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadius = 6371.0 // km
+// parseHoursAndMinutes converts the two halves of an offset like "-3:30" to seconds, the
+// minutes taking the hours' sign
+func parseHoursAndMinutes(hoursPart, minutesPart string) int {
+	hours, err := strconv.Atoi(hoursPart)
+	if err != nil || len(minutesPart) != 2 {
+		return 0
+	}
+	minutes, err := strconv.Atoi(minutesPart)
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0
+	}
+	sign := 1
+	if strings.HasPrefix(hoursPart, "-") {
+		sign = -1
+	}
+	seconds := hours*3600 + sign*minutes*60
+	if seconds < -12*3600 || seconds > 14*3600 {
+		return 0
+	}
+	return seconds
+}
 
-	dLat := toRadians(lat2 - lat1)
-	dLon := toRadians(lon2 - lon1)
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return earthRadius * c
+func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	return geo.DistanceKm(lat1, lon1, lat2, lon2)
 }
 
-func toRadians(deg float64) float64 {
-	return deg * math.Pi / 180
+func calculateBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	return geo.InitialBearing(lat1, lon1, lat2, lon2)
 }
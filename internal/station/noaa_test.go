@@ -9,11 +9,14 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/bbernstein/flowebb-go/internal/cache"
 	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/geo"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
 )
 
@@ -41,7 +44,6 @@ func createTestStation(id string) models.Station {
 	state := "WA"
 	region := "Puget Sound"
 	level := "R"
-	stationType := "R"
 	return models.Station{
 		ID:             id,
 		Name:           "Test Station " + id,
@@ -53,8 +55,9 @@ func createTestStation(id string) models.Station {
 		Source:         models.SourceNOAA,
 		Capabilities:   []string{"WATER_LEVEL"},
 		TimeZoneOffset: -8 * 3600,
+		TimeZone:       "America/Los_Angeles",
 		Level:          &level,
-		StationType:    &stationType,
+		StationType:    models.StationKindReference,
 	}
 }
 
@@ -84,7 +87,7 @@ func createNOAAResponse(stations []models.Station) string {
 			Lon:          s.Longitude,
 			TimeZoneCorr: "-8",
 			Level:        *s.Level,
-			StationType:  *s.StationType,
+			StationType:  string(s.StationType),
 		}
 	}
 
@@ -198,6 +201,122 @@ func TestFindStation(t *testing.T) {
 	}
 }
 
+func TestFindStation_Suggestions(t *testing.T) {
+	var testStations []models.Station
+	for id, name := range map[string]string{"9447130": "Seattle", "9446484": "Tacoma", "8454000": "Providence"} {
+		station := createTestStation(id)
+		station.Name = name
+		testStations = append(testStations, station)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(createNOAAResponse(testStations)))
+	}))
+	defer srv.Close()
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		stationID string
+		want      []models.StationSuggestion
+	}{
+		{"9447131", []models.StationSuggestion{{ID: "9447130", Name: "Seattle"}}},
+		{"seatle", []models.StationSuggestion{{ID: "9447130", Name: "Seattle"}}},
+		{"TACOMA", []models.StationSuggestion{{ID: "9446484", Name: "Tacoma"}}},
+		{"zzz", []models.StationSuggestion{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stationID, func(t *testing.T) {
+			_, err := finder.FindStation(context.Background(), tt.stationID)
+			require.ErrorIs(t, err, models.ErrStationNotFound)
+			var notFoundErr *models.StationNotFoundError
+			require.ErrorAs(t, err, &notFoundErr)
+			assert.Equal(t, tt.stationID, notFoundErr.StationID)
+			assert.Equal(t, tt.want, notFoundErr.Suggestions)
+		})
+	}
+}
+
+func TestFindStation_Retired(t *testing.T) {
+	seattle := createTestStation("9447130")
+	seattle.Name, seattle.Latitude, seattle.Longitude = "Seattle", 47.6026, -122.3393
+	var historicFetches int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("type") == "historicwl" {
+			historicFetches++
+			_, _ = w.Write([]byte(`{"stations":[
+				{"id":"9447110","name":"Seattle Pier 70","lat":47.6170,"lng":-122.3570},
+				{"id":"9440000","name":"Far Away","lat":46.0,"lng":-124.0}
+			]}`))
+			return
+		}
+		_, _ = w.Write([]byte(createNOAAResponse([]models.Station{seattle})))
+	}))
+	defer srv.Close()
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	finder.SetSnapshot(nil)
+	ctx := context.Background()
+
+	_, err = finder.FindStation(ctx, "9447110")
+	require.ErrorIs(t, err, models.ErrStationNotFound, "a retired station isn't a station")
+	var retiredErr *models.StationRetiredError
+	require.ErrorAs(t, err, &retiredErr)
+	assert.Equal(t, "9447110", retiredErr.StationID)
+	assert.Equal(t, "9447130", retiredErr.Successor.ID)
+	assert.EqualError(t, err, "station 9447110 was retired; its successor is 9447130 (Seattle)")
+
+	_, err = finder.FindStation(ctx, "9440000")
+	var notFoundErr *models.StationNotFoundError
+	assert.ErrorAs(t, err, &notFoundErr, "no current station is near enough to succeed it")
+	_, err = finder.FindStation(ctx, "1234567")
+	assert.ErrorAs(t, err, &notFoundErr)
+	assert.Equal(t, 1, historicFetches, "downloaded once per station list")
+
+	_, err = finder.loadStationList(ctx, nil)
+	require.NoError(t, err)
+	_, err = finder.FindStation(ctx, "9447110")
+	assert.ErrorAs(t, err, &retiredErr)
+	assert.Equal(t, 2, historicFetches, "and again once it reloads")
+}
+
+func TestFindStation_FractionalOffsets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"stationList":[
+			{"stationId":"NL00001","name":"St. John's","lat":47.56,"lon":-52.71,"timeZoneCorr":"-3:30"},
+			{"stationId":"NL00002","name":"Argentia","lat":47.3,"lon":-53.98,"timeZoneCorr":"-3.5"},
+			{"stationId":"IN00001","name":"Chennai","lat":13.1,"lon":80.3,"timeZoneCorr":"+5:30"},
+			{"stationId":"XX00001","name":"Nowhere","lat":0,"lon":-30,"timeZoneCorr":"-1:30"}
+		]}`))
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+
+	winter := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		id         string
+		wantOffset int
+		wantZone   string
+	}{
+		{id: "NL00001", wantOffset: -12600, wantZone: "America/St_Johns"},
+		{id: "NL00002", wantOffset: -12600, wantZone: "America/St_Johns"},
+		{id: "IN00001", wantOffset: 19800, wantZone: "Asia/Kolkata"},
+		// No zone observes it, so local times use the fixed offset
+		{id: "XX00001", wantOffset: -5400, wantZone: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			station, err := finder.FindStation(context.Background(), tt.id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOffset, station.TimeZoneOffset)
+			assert.Equal(t, tt.wantZone, station.TimeZone)
+			_, offset := winter.In(station.Location()).Zone()
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
+}
+
 func TestFindNearestStations(t *testing.T) {
 	// Create test stations at different distances
 	stations := []models.Station{
@@ -252,7 +371,7 @@ func TestFindNearestStations(t *testing.T) {
 			lon:         -122.3321,
 			limit:       2,
 			wantErr:     true,
-			errContains: "invalid latitude",
+			errContains: `invalid lat "91": must be between -90 and 90`,
 		},
 		{
 			name:        "invalid longitude",
@@ -260,7 +379,7 @@ func TestFindNearestStations(t *testing.T) {
 			lon:         -181.0,
 			limit:       2,
 			wantErr:     true,
-			errContains: "invalid longitude",
+			errContains: `invalid lon "-181": must be between -180 and 180`,
 		},
 		{
 			name:      "zero limit uses default",
@@ -307,10 +426,129 @@ func TestFindNearestStations(t *testing.T) {
 				assert.GreaterOrEqual(t, got[i].Distance, got[i-1].Distance,
 					"Distances should be in ascending order")
 			}
+
+			// The farther stations are due north of the query point
+			for _, station := range got {
+				require.NotNil(t, station.Bearing)
+				if station.ID != "NEAR" {
+					assert.InDelta(t, 0, *station.Bearing, 0.01)
+				}
+			}
 		})
 	}
 }
 
+func TestFindNearestStationsPage(t *testing.T) {
+	stations := []models.Station{
+		createTestStation("NEAR"),
+		createTestStation("MEDIUM"),
+		createTestStation("FAR"),
+	}
+	stations[1].Latitude += 0.1
+	stations[2].Latitude += 0.2
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(createNOAAResponse(stations)))
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	page, err := finder.FindNearestStationsPage(ctx, 47.6062, -122.3321, models.StationFilter{}, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 2)
+	assert.Equal(t, "NEAR", page.Stations[0].ID)
+	assert.Equal(t, "MEDIUM", page.Stations[1].ID)
+	assert.Equal(t, 3, page.Total)
+	assert.True(t, page.HasMore())
+
+	page, err = finder.FindNearestStationsPage(ctx, 47.6062, -122.3321, models.StationFilter{}, 2, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 1)
+	assert.Equal(t, "FAR", page.Stations[0].ID)
+	assert.Greater(t, page.Stations[0].Distance, 20.0)
+	require.NotNil(t, page.Stations[0].Bearing)
+	assert.False(t, page.HasMore())
+
+	_, err = finder.FindNearestStationsPage(ctx, 47.6062, -122.3321, models.StationFilter{}, -1, 2)
+	assert.ErrorContains(t, err, "invalid offset")
+}
+
+func TestFindNearestStationsPage_Filter(t *testing.T) {
+	stations := []models.Station{
+		createTestStation("NEAR"),
+		createTestStation("MEDIUM"),
+		createTestStation("FAR"),
+	}
+	stations[0].StationType = models.StationKindSubordinate
+	stations[1].Latitude += 0.1
+	stations[2].Latitude += 0.2
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(createNOAAResponse(stations)))
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	page, err := finder.FindNearestStationsPage(ctx, 47.6062, -122.3321, models.StationFilter{StationType: "r"}, 0, 5)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 2)
+	assert.Equal(t, "MEDIUM", page.Stations[0].ID)
+	assert.Equal(t, 2, page.Total)
+
+	page, err = finder.FindNearestStationsPage(ctx, 47.6062, -122.3321, models.StationFilter{Source: models.SourceUKHO}, 0, 5)
+	require.NoError(t, err)
+	assert.Empty(t, page.Stations)
+	assert.Zero(t, page.Total)
+
+	_, err = finder.FindNearestStationsPage(ctx, 47.6062, -122.3321, models.StationFilter{StationType: "X"}, 0, 5)
+	assert.EqualError(t, err, `invalid stationType "X": must be one of R, S`)
+}
+
+func TestFindStationsInBounds(t *testing.T) {
+	stations := []models.Station{
+		createTestStation("SEATTLE"),
+		createTestStation("SUBORDINATE"),
+		createTestStation("PORTLAND"),
+	}
+	stations[1].StationType = models.StationKindSubordinate
+	stations[2].Latitude = 45.5155
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(createNOAAResponse(stations)))
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	pugetSound := geo.Bounds{North: 48.5, South: 47, East: -122, West: -123}
+
+	found, err := finder.FindStationsInBounds(ctx, pugetSound, models.StationFilter{})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "SEATTLE", found[0].ID)
+	assert.Equal(t, "SUBORDINATE", found[1].ID)
+
+	found, err = finder.FindStationsInBounds(ctx, pugetSound, models.StationFilter{StationType: "S"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "SUBORDINATE", found[0].ID)
+
+	_, err = finder.FindStationsInBounds(ctx, geo.Bounds{North: 47, South: 48.5, East: -122, West: -123}, models.StationFilter{})
+	assert.EqualError(t, err, `invalid south "48.5": must be between -90 and 47`)
+	_, err = finder.FindStationsInBounds(ctx, geo.Bounds{North: 48.5, South: 47, East: 200, West: -123}, models.StationFilter{})
+	assert.EqualError(t, err, `invalid east "200": must be between -180 and 180`)
+}
+
 func TestParseTimeZoneOffset(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -342,6 +580,66 @@ func TestParseTimeZoneOffset(t *testing.T) {
 			input:    "",
 			expected: 0,
 		},
+		{
+			name:     "fractional hours",
+			input:    "-3.5",
+			expected: -12600,
+		},
+		{
+			name:     "hours and minutes",
+			input:    "+5:30",
+			expected: 19800,
+		},
+		{
+			name:     "negative hours and minutes",
+			input:    "-3:30",
+			expected: -12600,
+		},
+		{
+			name:     "negative minutes alone",
+			input:    "-0:30",
+			expected: -1800,
+		},
+		{
+			name:     "quarter hour",
+			input:    "5:45",
+			expected: 20700,
+		},
+		{
+			name:     "one digit minutes",
+			input:    "5:3",
+			expected: 0,
+		},
+		{
+			name:     "minutes past the hour",
+			input:    "5:60",
+			expected: 0,
+		},
+		{
+			name:     "hours and minutes beyond any real zone",
+			input:    "+14:30",
+			expected: 0,
+		},
+		{
+			name:     "unreadable hours",
+			input:    "x:30",
+			expected: 0,
+		},
+		{
+			name:     "surrounding space",
+			input:    " 10 ",
+			expected: 36000,
+		},
+		{
+			name:     "beyond any real zone",
+			input:    "99999999999",
+			expected: 0,
+		},
+		{
+			name:     "not a number",
+			input:    "NaN",
+			expected: 0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -352,6 +650,47 @@ func TestParseTimeZoneOffset(t *testing.T) {
 	}
 }
 
+func TestResolveTimeZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int
+		state    string
+		expected string
+	}{
+		{name: "pacific", offset: -8 * 3600, state: "WA", expected: "America/Los_Angeles"},
+		{name: "eastern", offset: -5 * 3600, state: "ME", expected: "America/New_York"},
+		{name: "arizona skips DST", offset: -7 * 3600, state: "AZ", expected: "America/Phoenix"},
+		{name: "hawaii", offset: -10 * 3600, state: "HI", expected: "Pacific/Honolulu"},
+		{name: "aleutians", offset: -10 * 3600, state: "AK", expected: "America/Adak"},
+		{name: "anchorage", offset: -9 * 3600, state: "AK", expected: "America/Anchorage"},
+		{name: "florida panhandle", offset: -6 * 3600, state: "FL", expected: "America/Chicago"},
+		{name: "florida peninsula", offset: -5 * 3600, state: "FL", expected: "America/New_York"},
+		{name: "virgin islands", offset: -4 * 3600, state: "VI", expected: "America/St_Thomas"},
+		{name: "state wins over a wrong offset", offset: 0, state: "WA", expected: "America/Los_Angeles"},
+		{name: "split state with unknown offset", offset: -3 * 3600, state: "AK", expected: "Etc/GMT+3"},
+		{name: "guam", offset: 10 * 3600, state: "", expected: "Pacific/Guam"},
+		{name: "utc", offset: 0, state: "", expected: "UTC"},
+		{name: "unmapped offset", offset: -3 * 3600, state: "", expected: "Etc/GMT+3"},
+		{name: "out of range", offset: -20 * 3600, state: "", expected: ""},
+		{name: "newfoundland", offset: -12600, state: "", expected: "America/St_Johns"},
+		{name: "india", offset: 19800, state: "", expected: "Asia/Kolkata"},
+		{name: "nepal", offset: 20700, state: "", expected: "Asia/Kathmandu"},
+		{name: "unmapped fractional offset stays fixed", offset: -5400, state: "", expected: ""},
+		{name: "fractional offset in a split state", offset: -12600, state: "AK", expected: "America/St_Johns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := resolveTimeZone(tt.offset, tt.state)
+			assert.Equal(t, tt.expected, zone)
+			if zone != "" {
+				_, err := time.LoadLocation(zone)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestCalculateDistance(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -399,6 +738,30 @@ func TestCalculateDistance(t *testing.T) {
 	}
 }
 
+func TestCalculateBearing(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat1     float64
+		lon1     float64
+		lat2     float64
+		lon2     float64
+		expected float64
+	}{
+		{name: "due north", lat1: 0, lon1: 0, lat2: 10, lon2: 0, expected: 0},
+		{name: "due east on the equator", lat1: 0, lon1: 0, lat2: 0, lon2: 10, expected: 90},
+		{name: "due south", lat1: 10, lon1: 0, lat2: 0, lon2: 0, expected: 180},
+		{name: "due west on the equator", lat1: 0, lon1: 10, lat2: 0, lon2: 0, expected: 270},
+		{name: "Seattle to Portland", lat1: 47.6062, lon1: -122.3321, lat2: 45.5155, lon2: -122.6789, expected: 186.6},
+		{name: "across the antimeridian", lat1: 0, lon1: 179, lat2: 0, lon2: -179, expected: 90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.expected, calculateBearing(tt.lat1, tt.lon1, tt.lat2, tt.lon2), 0.1)
+		})
+	}
+}
+
 func TestCacheInteraction(t *testing.T) {
 	// Create test station
 	testStation := createTestStation("TEST001")
@@ -448,6 +811,262 @@ func TestCacheInteraction(t *testing.T) {
 	assert.Equal(t, stations, stations2)
 }
 
+func TestStaleWhileRevalidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		changed       bool
+		wantStationID string
+		wantChanges   []models.StationChange
+	}{
+		{name: "unchanged list is revalidated with 304", changed: false, wantStationID: "TEST001"},
+		{name: "changed list is replaced", changed: true, wantStationID: "TEST002", wantChanges: []models.StationChange{
+			{StationID: "TEST001", Name: "Test Station TEST001", Change: models.StationRemoved},
+			{StationID: "TEST002", Name: "Test Station TEST002", Change: models.StationAdded},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			var conditional atomic.Bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if serveSensorList(w, r, nil) {
+					return
+				}
+				n := requests.Add(1)
+				if n > 1 && r.Header.Get("If-None-Match") == `"v1"` {
+					conditional.Store(true)
+					if !tt.changed {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+				station := createTestStation("TEST001")
+				w.Header().Set("ETag", `"v1"`)
+				if n > 1 {
+					station = createTestStation("TEST002")
+					w.Header().Set("ETag", `"v2"`)
+				}
+				_, _ = w.Write([]byte(createNOAAResponse([]models.Station{station})))
+			}))
+			defer srv.Close()
+
+			// A zero TTL makes every cached list stale but still servable
+			memCache := cache.NewStationCache(&config.CacheConfig{
+				StationListTTLDays:      0,
+				StationListMaxStaleDays: 1,
+			})
+			finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), memCache)
+			require.NoError(t, err)
+			var changes []models.StationChange
+			finder.OnListChange(func(_ context.Context, source models.Source, c []models.StationChange) {
+				assert.Equal(t, models.SourceNOAA, source)
+				changes = append(changes, c...)
+			})
+
+			// Nothing cached yet, so the first call downloads synchronously
+			stations, err := finder.getStationList(context.Background())
+			require.NoError(t, err)
+			require.Len(t, stations, 1)
+			assert.Equal(t, "TEST001", stations[0].ID)
+
+			// The stale list is served while it's revalidated in the background
+			stations, err = finder.getStationList(context.Background())
+			require.NoError(t, err)
+			require.Len(t, stations, 1)
+			assert.Equal(t, "TEST001", stations[0].ID)
+			require.NoError(t, finder.FlushCacheWrites(context.Background()))
+			assert.Equal(t, int32(2), requests.Load())
+			assert.True(t, conditional.Load(), "refresh should send If-None-Match")
+
+			assert.Equal(t, tt.wantStationID, memCache.GetStaleStations()[0].ID)
+			assert.Equal(t, tt.wantChanges, changes, "only a list that replaces another is compared with it")
+		})
+	}
+}
+
+func TestStaleWhileRevalidate_FailedRefreshServesStale(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveSensorList(w, r, nil) {
+			return
+		}
+		if requests.Add(1) > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(createNOAAResponse([]models.Station{createTestStation("TEST001")})))
+	}))
+	defer srv.Close()
+
+	memCache := cache.NewStationCache(&config.CacheConfig{
+		StationListTTLDays:      0,
+		StationListMaxStaleDays: 1,
+	})
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second, MaxRetries: 1}), memCache)
+	require.NoError(t, err)
+
+	_, err = finder.getStationList(context.Background())
+	require.NoError(t, err)
+
+	// Every lookup while NOAA fails is answered from the expired list, and only one of
+	// them asks NOAA, even those for a station the list doesn't have
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				_, err := finder.FindStation(context.Background(), "MISSING")
+				assert.ErrorIs(t, err, models.ErrStationNotFound)
+				return
+			}
+			station, err := finder.FindStation(context.Background(), "TEST001")
+			if assert.NoError(t, err) {
+				assert.Equal(t, "TEST001", station.ID)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, finder.FlushCacheWrites(context.Background()))
+	assert.Equal(t, int32(2), requests.Load(), "one revalidation runs at a time")
+
+	stations, err := finder.getStationList(context.Background())
+	require.NoError(t, err)
+	require.Len(t, stations, 1)
+	assert.Equal(t, "TEST001", stations[0].ID)
+	assert.NoError(t, finder.LoadStations(context.Background()), "an expired list NOAA can't revalidate is kept")
+	assert.Equal(t, int32(2), requests.Load(), "a failed revalidation holds off the next")
+
+	// Once the backoff passes, the next lookup revalidates again
+	finder.refreshMu.Lock()
+	finder.retryAfter = time.Time{}
+	finder.refreshMu.Unlock()
+	assert.NoError(t, finder.LoadStations(context.Background()))
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestGetStationList_BeyondMaxStaleFetchesSynchronously(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if serveSensorList(w, r, nil) {
+			return
+		}
+		requests.Add(1)
+		assert.Empty(t, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(createNOAAResponse([]models.Station{createTestStation("TEST001")})))
+	}))
+	defer srv.Close()
+
+	memCache := cache.NewStationCache(&config.CacheConfig{
+		StationListTTLDays:      0,
+		StationListMaxStaleDays: 0,
+	})
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), memCache)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		stations, err := finder.getStationList(context.Background())
+		require.NoError(t, err)
+		require.Len(t, stations, 1)
+	}
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+// serveSensorList answers the metadata API's sensor station lists, listing the given
+// station IDs for every sensor, and reports whether r was for one
+func serveSensorList(w http.ResponseWriter, r *http.Request, ids []string) bool {
+	if r.URL.Path != "/mdapi/prod/webapi/stations.json" {
+		return false
+	}
+	stations := make([]map[string]string, len(ids))
+	for i, id := range ids {
+		stations[i] = map[string]string{"id": id}
+	}
+	body, _ := json.Marshal(map[string]interface{}{"stations": stations})
+	_, _ = w.Write(body)
+	return true
+}
+
+func TestSensorCapabilities(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("type") {
+		case "watertemp":
+			serveSensorList(w, r, []string{"TEST001", "OTHER"})
+		case "conductivity":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(createNOAAResponse([]models.Station{createTestStation("TEST001"), createTestStation("TEST002")})))
+		}
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+
+	// A sensor list that fails only leaves its capability off
+	station, err := finder.FindStation(context.Background(), "TEST001")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature}, station.Capabilities)
+
+	station, err = finder.FindStation(context.Background(), "TEST002")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.CapabilityWaterLevel}, station.Capabilities)
+}
+
+func TestSensorCapabilities_FetchedConcurrently(t *testing.T) {
+	// Each sensor list is only answered once both have been asked for
+	var waiting atomic.Int32
+	both := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("type") == "" {
+			_, _ = w.Write([]byte(createNOAAResponse([]models.Station{createTestStation("TEST001")})))
+			return
+		}
+		if waiting.Add(1) == int32(len(sensorCapabilities)) {
+			close(both)
+		}
+		select {
+		case <-both:
+			serveSensorList(w, r, []string{"TEST001"})
+		case <-time.After(2 * time.Second):
+			w.WriteHeader(http.StatusGatewayTimeout)
+		}
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second, MaxRetries: 1}), nil)
+	require.NoError(t, err)
+
+	station, err := finder.FindStation(context.Background(), "TEST001")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature, models.CapabilityConductivity}, station.Capabilities)
+}
+
+func TestUseStationListCacheFromEnv(t *testing.T) {
+	t.Run("no bucket", func(t *testing.T) {
+		t.Setenv("STATION_LIST_BUCKET", "")
+		t.Setenv("CACHE_BACKEND", "")
+
+		finder, err := NewNOAAStationFinder(client.New(client.Options{}), nil)
+		require.NoError(t, err)
+		require.NoError(t, finder.UseStationListCacheFromEnv(context.Background()))
+		assert.Nil(t, finder.listCache, "no station list cache should be wired without a bucket")
+	})
+
+	t.Run("file backend", func(t *testing.T) {
+		t.Setenv("CACHE_BACKEND", "file")
+		t.Setenv("CACHE_DIR", t.TempDir())
+
+		finder, err := NewNOAAStationFinder(client.New(client.Options{}), nil)
+		require.NoError(t, err)
+		require.NoError(t, finder.UseStationListCacheFromEnv(context.Background()))
+		assert.IsType(t, &cache.FileStationCache{}, finder.listCache)
+	})
+}
+
 // Benchmarks for key operations
 func BenchmarkCalculateDistance(b *testing.B) {
 	lat1, lon1 := 47.6062, -122.3321 // Seattle
@@ -493,6 +1112,25 @@ func BenchmarkFindNearestStations(b *testing.B) {
 	}
 }
 
+// FuzzParseTimeZoneOffset checks that any timeZoneCorr gives an offset within the real
+// zones, and a zone that loads or none at all
+func FuzzParseTimeZoneOffset(f *testing.F) {
+	for _, seed := range []string{"-8", "0", "10", "-3.5", "+5", "+5:30", "-3:30", "-0:59", "14:01", ":", "1e9", "-Inf", "NaN", "0x10", "", "--8", "٣"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, tzCorr string) {
+		offset := parseTimeZoneOffset(tzCorr)
+		if offset < -12*3600 || offset > 14*3600 {
+			t.Fatalf("parseTimeZoneOffset(%q) = %d, outside -12h to +14h", tzCorr, offset)
+		}
+		if zone := resolveTimeZone(offset, ""); zone != "" {
+			if _, err := time.LoadLocation(zone); err != nil {
+				t.Fatalf("parseTimeZoneOffset(%q) = %d resolves to %q: %v", tzCorr, offset, zone, err)
+			}
+		}
+	})
+}
+
 func BenchmarkParseTimeZoneOffset(b *testing.B) {
 	offset := "-8"
 	b.ResetTimer()
@@ -556,7 +1194,7 @@ func TestS3CacheScenarios(t *testing.T) {
 			require.NoError(t, err)
 
 			// Set the S3 cache
-			finder.s3Cache = tt.setupS3Cache()
+			finder.listCache = tt.setupS3Cache()
 
 			// Test getStationList
 			stations, err := finder.getStationList(context.Background())
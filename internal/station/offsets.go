@@ -0,0 +1,104 @@
+package station
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// offsetCacheSize is how many subordinate stations' offsets are kept
+	offsetCacheSize = 1000
+	// offsetCacheTTL is how long a station's offsets are reused; NOAA revises them rarely
+	offsetCacheTTL = 24 * time.Hour
+	// offsetFailureTTL is how long a failed offset lookup is remembered
+	offsetFailureTTL = time.Minute
+)
+
+type offsetEntry struct {
+	offsets   *models.TideOffsets
+	expiresAt time.Time
+}
+
+// heightAdjustments maps NOAA's heightAdjustedType to how the height offsets apply
+var heightAdjustments = map[string]string{
+	"R": models.HeightAdjustmentRatio,
+	"F": models.HeightAdjustmentFixed,
+}
+
+var _ models.OffsetLister = (*NOAAStationFinder)(nil)
+
+// TideOffsets returns the offsets NOAA predicts the subordinate station with, named after
+// its reference station when that's in the station list, cached for a day. It returns nil
+// when the station has no offsets, as with reference stations, or they can't be looked up.
+func (f *NOAAStationFinder) TideOffsets(ctx context.Context, stationID string) *models.TideOffsets {
+	if entry, ok := f.offsetCache.Get(stationID); ok && time.Now().Before(entry.expiresAt) {
+		return entry.offsets
+	}
+
+	offsets, err := f.fetchTideOffsets(ctx, stationID)
+	ttl := offsetCacheTTL
+	if err != nil {
+		log.Warn().Err(err).Str("station_id", stationID).Msg("Station offsets unavailable")
+		if ctx.Err() != nil {
+			return nil
+		}
+		ttl = offsetFailureTTL
+	}
+	if offsets != nil {
+		if stations, _, err := f.stationList(ctx); err == nil {
+			if reference := findByID(stations, offsets.ReferenceStationID); reference != nil {
+				offsets.ReferenceStationName = reference.Name
+			}
+		}
+	}
+	f.offsetCache.Add(stationID, offsetEntry{offsets: offsets, expiresAt: time.Now().Add(ttl)})
+	return offsets
+}
+
+func (f *NOAAStationFinder) fetchTideOffsets(ctx context.Context, stationID string) (*models.TideOffsets, error) {
+	resp, err := f.httpClient.Get(ctx, "/mdapi/prod/webapi/stations/"+url.PathEscape(stationID)+"/tidepredoffsets.json")
+	if err != nil {
+		return nil, fmt.Errorf("fetching offsets: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching offsets: status %d", resp.StatusCode)
+	}
+
+	var offsetsResp struct {
+		RefStationID         string  `json:"refStationId"`
+		HeightOffsetHighTide float64 `json:"heightOffsetHighTide"`
+		HeightOffsetLowTide  float64 `json:"heightOffsetLowTide"`
+		TimeOffsetHighTide   int     `json:"timeOffsetHighTide"`
+		TimeOffsetLowTide    int     `json:"timeOffsetLowTide"`
+		HeightAdjustedType   string  `json:"heightAdjustedType"`
+	}
+	if err := json.Unmarshal(resp.Body, &offsetsResp); err != nil {
+		return nil, fmt.Errorf("decoding offsets: %w", err)
+	}
+	if offsetsResp.RefStationID == "" {
+		return nil, nil
+	}
+	adjustment, ok := heightAdjustments[strings.ToUpper(offsetsResp.HeightAdjustedType)]
+	if !ok {
+		return nil, fmt.Errorf("unknown height adjustment %q", offsetsResp.HeightAdjustedType)
+	}
+	return &models.TideOffsets{
+		ReferenceStationID:    offsetsResp.RefStationID,
+		TimeOffsetHighMinutes: offsetsResp.TimeOffsetHighTide,
+		TimeOffsetLowMinutes:  offsetsResp.TimeOffsetLowTide,
+		HeightOffsetHigh:      offsetsResp.HeightOffsetHighTide,
+		HeightOffsetLow:       offsetsResp.HeightOffsetLowTide,
+		HeightAdjustment:      adjustment,
+	}, nil
+}
@@ -0,0 +1,90 @@
+package station
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+func TestTideOffsets(t *testing.T) {
+	var offsetRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mdapi/prod/webapi/stations/SUB001/tidepredoffsets.json":
+			offsetRequests.Add(1)
+			_, _ = w.Write([]byte(`{
+				"refStationId": "REF001",
+				"type": "S",
+				"heightOffsetHighTide": 0.98,
+				"heightOffsetLowTide": 0.94,
+				"timeOffsetHighTide": 24,
+				"timeOffsetLowTide": -6,
+				"heightAdjustedType": "R"
+			}`))
+		case "/mdapi/prod/webapi/stations/SUB002/tidepredoffsets.json":
+			_, _ = w.Write([]byte(`{"refStationId": "ELSEWHERE", "heightOffsetHighTide": 1.2, "heightAdjustedType": "F"}`))
+		case "/mdapi/prod/webapi/stations/REF001/tidepredoffsets.json":
+			http.NotFound(w, r)
+		case "/mdapi/prod/webapi/stations.json":
+			serveSensorList(w, r, nil)
+		default:
+			_, _ = w.Write([]byte(createNOAAResponse([]models.Station{createTestStation("REF001")})))
+		}
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.Equal(t, &models.TideOffsets{
+		ReferenceStationID:    "REF001",
+		ReferenceStationName:  "Test Station REF001",
+		TimeOffsetHighMinutes: 24,
+		TimeOffsetLowMinutes:  -6,
+		HeightOffsetHigh:      0.98,
+		HeightOffsetLow:       0.94,
+		HeightAdjustment:      models.HeightAdjustmentRatio,
+	}, finder.TideOffsets(ctx, "SUB001"))
+	finder.TideOffsets(ctx, "SUB001")
+	assert.Equal(t, int32(1), offsetRequests.Load(), "offsets are cached")
+
+	offsets := finder.TideOffsets(ctx, "SUB002")
+	require.NotNil(t, offsets)
+	assert.Equal(t, models.HeightAdjustmentFixed, offsets.HeightAdjustment)
+	assert.Empty(t, offsets.ReferenceStationName, "the reference station isn't in the list")
+
+	assert.Nil(t, finder.TideOffsets(ctx, "REF001"), "reference stations have no offsets")
+}
+
+func TestFindTideOffsets(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	reference := createTestStation("REF001")
+	assert.Nil(t, models.FindTideOffsets(ctx, finder, reference))
+	assert.Zero(t, requests.Load(), "reference stations aren't looked up")
+
+	subordinate := createTestStation("SUB001")
+	subordinate.StationType = models.StationKindSubordinate
+	assert.Nil(t, models.FindTideOffsets(ctx, finder, subordinate))
+	assert.Nil(t, models.FindTideOffsets(ctx, finder, subordinate))
+	assert.Equal(t, int32(1), requests.Load(), "failures should be remembered briefly")
+}
@@ -0,0 +1,110 @@
+package station
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxSuccessorDistanceKm is how far a retired station's successor may be from it; a
+// retired station with no current station this close has none
+const maxSuccessorDistanceKm = 10
+
+// retiredFailureTTL is how long a failed download of the retired stations is remembered
+const retiredFailureTTL = time.Minute
+
+// retiredStation is a station NOAA no longer predicts tides for
+type retiredStation struct {
+	ID        string
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// retiredEntry is the retired stations by ID, downloaded once per station list. A failed
+// download is kept, empty, until retryAt.
+type retiredEntry struct {
+	stations map[string]retiredStation
+	retryAt  time.Time
+}
+
+// retiredError returns a StationRetiredError naming the successor of the station with the
+// ID, when NOAA lists it among its historic stations and a current station is near it. It
+// returns nil otherwise.
+func (f *NOAAStationFinder) retiredError(ctx context.Context, stations []models.Station, stationID string) error {
+	retired, ok := f.retiredStations(ctx)[stationID]
+	if !ok {
+		return nil
+	}
+
+	var successor *models.Station
+	nearest := float64(maxSuccessorDistanceKm)
+	for i := range stations {
+		if distance := calculateDistance(retired.Latitude, retired.Longitude, stations[i].Latitude, stations[i].Longitude); distance <= nearest {
+			successor, nearest = &stations[i], distance
+		}
+	}
+	if successor == nil {
+		return nil
+	}
+	log.Info().
+		Str("station_id", stationID).
+		Str("successor_id", successor.ID).
+		Float64("distance_km", nearest).
+		Msg("Retired station requested")
+	return &models.StationRetiredError{StationID: stationID, Successor: *successor}
+}
+
+// retiredStations returns NOAA's historic water level stations that aren't current ones,
+// downloading them the first time they're needed after the station list loads
+func (f *NOAAStationFinder) retiredStations(ctx context.Context) map[string]retiredStation {
+	f.cacheMutex.RLock()
+	entry := f.retired
+	f.cacheMutex.RUnlock()
+	if entry != nil && (entry.stations != nil || time.Now().Before(entry.retryAt)) {
+		return entry.stations
+	}
+
+	stations, err := f.fetchRetiredStations(ctx)
+	entry = &retiredEntry{stations: stations}
+	if err != nil {
+		log.Warn().Err(err).Msg("Retired station list unavailable")
+		entry.retryAt = time.Now().Add(retiredFailureTTL)
+	}
+	f.cacheMutex.Lock()
+	f.retired = entry
+	f.cacheMutex.Unlock()
+	return stations
+}
+
+func (f *NOAAStationFinder) fetchRetiredStations(ctx context.Context) (map[string]retiredStation, error) {
+	resp, err := f.httpClient.Get(ctx, "/mdapi/prod/webapi/stations.json?type=historicwl")
+	if err != nil {
+		return nil, fmt.Errorf("fetching historic stations: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching historic stations: status %d", resp.StatusCode)
+	}
+
+	var historicResp struct {
+		Stations []struct {
+			ID   string  `json:"id"`
+			Name string  `json:"name"`
+			Lat  float64 `json:"lat"`
+			Lng  float64 `json:"lng"`
+		} `json:"stations"`
+	}
+	if err := json.Unmarshal(resp.Body, &historicResp); err != nil {
+		return nil, fmt.Errorf("decoding historic stations: %w", err)
+	}
+	stations := make(map[string]retiredStation, len(historicResp.Stations))
+	for _, s := range historicResp.Stations {
+		stations[s.ID] = retiredStation{ID: s.ID, Name: s.Name, Latitude: s.Lat, Longitude: s.Lng}
+	}
+	return stations, nil
+}
@@ -0,0 +1,118 @@
+package station
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// sensorCacheSize is how many stations' sensor lists are kept
+	sensorCacheSize = 1000
+	// sensorCacheTTL is how long a station's sensor list is reused; sensors are added and
+	// removed rarely
+	sensorCacheTTL = 24 * time.Hour
+	// sensorFailureTTL is how long a failed sensor lookup is remembered
+	sensorFailureTTL = time.Minute
+)
+
+type sensorEntry struct {
+	capabilities []string
+	expiresAt    time.Time
+}
+
+// sensorIDCapabilities maps the letter NOAA CO-OPS sensor IDs start with to the capability
+// the sensor gives a station, e.g. E1 is the primary water temperature sensor
+var sensorIDCapabilities = map[byte]string{
+	'A': models.CapabilityWaterLevel, // acoustic or Aquatrak
+	'B': models.CapabilityWaterLevel, // backup
+	'N': models.CapabilityWaterLevel, // microwave
+	'Y': models.CapabilityWaterLevel, // pressure
+	'C': models.CapabilityWind,
+	'D': models.CapabilityAirTemperature,
+	'E': models.CapabilityWaterTemperature,
+	'F': models.CapabilityAirPressure,
+	'G': models.CapabilityConductivity,
+}
+
+var _ models.SensorLister = (*NOAAStationFinder)(nil)
+
+// SensorCapabilities returns the capabilities of the sensors NOAA lists for the station,
+// cached for a day. It returns nil when the station has no sensors of its own, as with
+// subordinate stations, or they can't be listed.
+func (f *NOAAStationFinder) SensorCapabilities(ctx context.Context, stationID string) []string {
+	if entry, ok := f.sensorCache.Get(stationID); ok && time.Now().Before(entry.expiresAt) {
+		return entry.capabilities
+	}
+
+	capabilities, err := f.fetchStationCapabilities(ctx, stationID)
+	ttl := sensorCacheTTL
+	if err != nil {
+		log.Warn().Err(err).Str("station_id", stationID).Msg("Station sensors unavailable")
+		if ctx.Err() != nil {
+			return nil
+		}
+		ttl = sensorFailureTTL
+	}
+	f.sensorCache.Add(stationID, sensorEntry{capabilities: capabilities, expiresAt: time.Now().Add(ttl)})
+	return capabilities
+}
+
+func (f *NOAAStationFinder) fetchStationCapabilities(ctx context.Context, stationID string) ([]string, error) {
+	resp, err := f.httpClient.Get(ctx, "/mdapi/prod/webapi/stations/"+url.PathEscape(stationID)+"/sensors.json")
+	if err != nil {
+		return nil, fmt.Errorf("fetching sensors: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching sensors: status %d", resp.StatusCode)
+	}
+
+	var sensorsResp struct {
+		Sensors []struct {
+			SensorID string `json:"sensorID"`
+			Name     string `json:"name"`
+		} `json:"sensors"`
+	}
+	if err := json.Unmarshal(resp.Body, &sensorsResp); err != nil {
+		return nil, fmt.Errorf("decoding sensors: %w", err)
+	}
+
+	var capabilities []string
+	for _, sensor := range sensorsResp.Sensors {
+		var capability string
+		switch {
+		case strings.Contains(strings.ToLower(sensor.Name), "current"):
+			// Current meters don't follow the water level station sensor IDs
+			capability = models.CapabilityCurrents
+		case sensor.SensorID != "":
+			capability = sensorIDCapabilities[strings.ToUpper(sensor.SensorID)[0]]
+		}
+		if capability != "" {
+			capabilities = mergeCapabilities(capabilities, []string{capability})
+		}
+	}
+	return capabilities, nil
+}
+
+// mergeCapabilities returns capabilities with those of more it doesn't already have
+// appended, without modifying capabilities
+func mergeCapabilities(capabilities, more []string) []string {
+	merged := append([]string{}, capabilities...)
+	for _, c := range more {
+		if !slices.Contains(merged, c) {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
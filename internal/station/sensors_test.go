@@ -0,0 +1,93 @@
+package station
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+func TestSensorCapabilities_AddedOnlyOnRequest(t *testing.T) {
+	var sensorRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mdapi/prod/webapi/stations/TEST001/sensors.json":
+			sensorRequests.Add(1)
+			_, _ = w.Write([]byte(`{"sensors": [
+				{"sensorID": "A1", "name": "Aquatrak"},
+				{"sensorID": "B1", "name": "Backup"},
+				{"sensorID": "C1", "name": "Wind"},
+				{"sensorID": "E1", "name": "Water Temperature"},
+				{"sensorID": "F1", "name": "Barometric Pressure"},
+				{"sensorID": "Q1", "name": "ADCP Currents"},
+				{"sensorID": "Z1", "name": "Unknown"}
+			]}`))
+		case "/mdapi/prod/webapi/stations/TEST002/sensors.json":
+			http.NotFound(w, r)
+		case "/mdapi/prod/webapi/stations.json":
+			serveSensorList(w, r, []string{"TEST001"})
+		default:
+			_, _ = w.Write([]byte(createNOAAResponse([]models.Station{createTestStation("TEST001"), createTestStation("TEST002")})))
+		}
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Finding a station only reads the list
+	station, err := finder.FindStation(ctx, "TEST001")
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature, models.CapabilityConductivity}, station.Capabilities)
+	assert.Zero(t, sensorRequests.Load())
+
+	models.AddSensorCapabilities(ctx, finder, station)
+	assert.Equal(t, []string{
+		models.CapabilityWaterLevel,
+		models.CapabilityWaterTemperature,
+		models.CapabilityConductivity,
+		models.CapabilityWind,
+		models.CapabilityAirPressure,
+		models.CapabilityCurrents,
+	}, station.Capabilities)
+
+	// Sensor lists are cached, and don't leak into the station list
+	station, err = finder.FindStation(ctx, "TEST001")
+	require.NoError(t, err)
+	models.AddSensorCapabilities(ctx, finder, station)
+	assert.Equal(t, int32(1), sensorRequests.Load())
+	stations, err := finder.getStationList(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature, models.CapabilityConductivity}, stations[0].Capabilities)
+
+	// Stations without sensors of their own keep the list's capabilities
+	station, err = finder.FindStation(ctx, "TEST002")
+	require.NoError(t, err)
+	models.AddSensorCapabilities(ctx, finder, station)
+	assert.Equal(t, []string{models.CapabilityWaterLevel}, station.Capabilities)
+}
+
+func TestStationCapabilitiesFailure(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+
+	assert.Nil(t, finder.SensorCapabilities(context.Background(), "TEST001"))
+	assert.Nil(t, finder.SensorCapabilities(context.Background(), "TEST001"))
+	assert.Equal(t, int32(1), requests.Load(), "failures should be remembered briefly")
+}
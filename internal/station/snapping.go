@@ -0,0 +1,45 @@
+package station
+
+import (
+	"sort"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// coastalCandidates is how many of the nearest stations coastal snapping reorders at
+// least; a detour around land doesn't bring stations further off than these any closer
+const coastalCandidates = 20
+
+// coastalLandPenalty is how much longer the way to a station counts for the part of it
+// over land: a straight line entirely over land ranks as 4 times its length
+const coastalLandPenalty = 3
+
+// SetLandMask turns on coastal snapping: nearest-station searches rank a station whose
+// straight path from the point crosses land, e.g. one across a peninsula, behind stations
+// reached over water. Distances are still reported as the crow flies. nil turns it off.
+func (f *NOAAStationFinder) SetLandMask(mask *geo.LandMask) {
+	f.landMask = mask
+}
+
+// snapToWater reorders the first count (and at least coastalCandidates) of the stations,
+// sorted by distance from the point, by their distance with the part over land penalized.
+// The stations after them keep their place.
+func snapToWater(mask *geo.LandMask, lat, lon float64, sorted []models.Station, count int) {
+	candidates := sorted[:min(len(sorted), max(count, coastalCandidates))]
+	ranked := make([]struct {
+		station models.Station
+		rank    float64
+	}, len(candidates))
+	for i, station := range candidates {
+		ranked[i].station = station
+		landFraction := mask.LandFraction(lat, lon, station.Latitude, station.Longitude)
+		ranked[i].rank = station.Distance * (1 + coastalLandPenalty*landFraction)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].rank < ranked[j].rank
+	})
+	for i := range ranked {
+		candidates[i] = ranked[i].station
+	}
+}
@@ -0,0 +1,54 @@
+package station
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindNearestStationsPage_CoastalSnapping(t *testing.T) {
+	// The point is west of a peninsula from 123 to 122.5W; one station is across it and
+	// the other further away up the water around its tip
+	across, around := createTestStation("ACROSS"), createTestStation("AROUND")
+	across.Latitude, across.Longitude = 47.5, -122.4
+	around.Latitude, around.Longitude = 48.2, -123.2
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(createNOAAResponse([]models.Station{across, around})))
+	}))
+	defer srv.Close()
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	page, err := finder.FindNearestStationsPage(ctx, 47.5, -123.2, models.StationFilter{}, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 2)
+	assert.Equal(t, "ACROSS", page.Stations[0].ID, "as the crow flies")
+	acrossDistance := page.Stations[0].Distance
+
+	mask, err := geo.NewLandMask(0.1)
+	require.NoError(t, err)
+	mask.FillPolygon([][][2]float64{{{-123, 47}, {-122.5, 47}, {-122.5, 48}, {-123, 48}, {-123, 47}}})
+	finder.SetLandMask(mask)
+
+	page, err = finder.FindNearestStationsPage(ctx, 47.5, -123.2, models.StationFilter{}, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 2)
+	assert.Equal(t, "AROUND", page.Stations[0].ID)
+	assert.Equal(t, "ACROSS", page.Stations[1].ID)
+	assert.Equal(t, acrossDistance, page.Stations[1].Distance, "distances are still straight-line")
+
+	page, err = finder.FindNearestStationsPage(ctx, 47.5, -123.2, models.StationFilter{}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "ACROSS", page.Stations[0].ID, "later pages follow the same order")
+}
@@ -0,0 +1,97 @@
+package station
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+//go:generate go run ../../cmd/stationsnapshot -o snapshot/noaa.json.gz
+
+// snapshotData is the NOAA station list as of the last build that refreshed it, so a cold
+// start can answer station lookups while the list loads in the background, as
+// refreshInBackground describes. The committed file holds no stations; the build scripts
+// and deploy workflow regenerate it before building and fail if it's still empty.
+//
+//go:embed snapshot/noaa.json.gz
+var snapshotData []byte
+
+// SnapshotRecord is the embedded snapshot's format: the station list and when it was
+// downloaded
+type SnapshotRecord struct {
+	Stations    []models.Station `json:"stations"`
+	GeneratedAt int64            `json:"generatedAt"`
+}
+
+var (
+	snapshotOnce     sync.Once
+	snapshotStations []models.Station
+)
+
+// embeddedSnapshot returns the stations in the embedded snapshot, decoded the first time
+// it's asked for. It returns nil when the snapshot is empty or can't be read.
+func embeddedSnapshot() []models.Station {
+	snapshotOnce.Do(func() {
+		record, err := readSnapshot(bytes.NewReader(snapshotData))
+		if err != nil {
+			log.Error().Err(err).Msg("Reading the embedded station list snapshot")
+			return
+		}
+		if len(record.Stations) > 0 {
+			log.Debug().
+				Int("stations", len(record.Stations)).
+				Time("generatedAt", time.Unix(record.GeneratedAt, 0)).
+				Msg("Loaded the embedded station list snapshot")
+			snapshotStations = record.Stations
+		}
+	})
+	return snapshotStations
+}
+
+func readSnapshot(r io.Reader) (*SnapshotRecord, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	var record SnapshotRecord
+	if err := json.NewDecoder(gz).Decode(&record); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	return &record, nil
+}
+
+// WriteSnapshot downloads the station list from NOAA, bypassing every cache, and writes it
+// to w in the embedded snapshot's format
+func (f *NOAAStationFinder) WriteSnapshot(ctx context.Context, w io.Writer) error {
+	stations, err := f.loadStationList(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	record := SnapshotRecord{Stations: stations, GeneratedAt: time.Now().Unix()}
+	if err := json.NewEncoder(gz).Encode(record); err != nil {
+		return fmt.Errorf("encoding snapshot: %w", err)
+	}
+	return gz.Close()
+}
+
+// SetSnapshot replaces the stations served while the list is first loaded; nil serves none,
+// so the first lookup waits for the download
+func (f *NOAAStationFinder) SetSnapshot(stations []models.Station) {
+	f.snapshot = stations
+}
@@ -0,0 +1,19 @@
+//go:build generated
+
+package station
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Built with -tags generated by the build scripts once go generate has refreshed the
+// snapshot, so a build never embeds the empty one that's committed
+
+func TestEmbeddedSnapshot_HasStations(t *testing.T) {
+	stations := embeddedSnapshot()
+	require.NotEmpty(t, stations, "the embedded snapshot is empty; run go generate ./internal/station")
+	assert.NotNil(t, findByID(stations, "9447130"), "Seattle should be in the snapshot")
+}
@@ -0,0 +1,88 @@
+package station
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSnapshotFinder returns a finder whose NOAA station list holds current, and waits for
+// release before answering when release isn't nil
+func newSnapshotFinder(t *testing.T, current []models.Station, release chan struct{}) *NOAAStationFinder {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if release != nil && strings.HasSuffix(r.URL.Path, "tidepredstations.json") {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(createNOAAResponse(current)))
+	}))
+	t.Cleanup(srv.Close)
+
+	finder, err := NewNOAAStationFinder(client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}), nil)
+	require.NoError(t, err)
+	return finder
+}
+
+func TestSnapshot_ServedWhileLoading(t *testing.T) {
+	release := make(chan struct{})
+	finder := newSnapshotFinder(t, []models.Station{createTestStation("9447130")}, release)
+	finder.SetSnapshot([]models.Station{createTestStation("8454000")})
+
+	page, err := finder.FindNearestStationsPage(context.Background(), 47.6, -122.3, models.StationFilter{}, 0, 5)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 1)
+	assert.Equal(t, "8454000", page.Stations[0].ID, "the snapshot is served without waiting for NOAA")
+	assert.True(t, page.Stale)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		return finder.memCache.GetStations() != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	page, err = finder.FindNearestStationsPage(context.Background(), 47.6, -122.3, models.StationFilter{}, 0, 5)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 1)
+	assert.Equal(t, "9447130", page.Stations[0].ID)
+	assert.False(t, page.Stale)
+}
+
+func TestSnapshot_FindStationMissingFromSnapshot(t *testing.T) {
+	finder := newSnapshotFinder(t, []models.Station{createTestStation("9447130")}, nil)
+	finder.SetSnapshot([]models.Station{createTestStation("8454000")})
+
+	station, err := finder.FindStation(context.Background(), "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, "9447130", station.ID, "a station newer than the snapshot is downloaded")
+
+	_, err = finder.FindStation(context.Background(), "0000000")
+	assert.ErrorIs(t, err, models.ErrStationNotFound)
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	finder := newSnapshotFinder(t, []models.Station{createTestStation("9447130")}, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, finder.WriteSnapshot(context.Background(), &buf))
+
+	record, err := readSnapshot(&buf)
+	require.NoError(t, err)
+	require.Len(t, record.Stations, 1)
+	assert.Equal(t, "9447130", record.Stations[0].ID)
+	assert.Equal(t, "America/Los_Angeles", record.Stations[0].TimeZone)
+	assert.WithinDuration(t, time.Now(), time.Unix(record.GeneratedAt, 0), time.Minute)
+}
+
+func TestEmbeddedSnapshot(t *testing.T) {
+	_, err := readSnapshot(bytes.NewReader(snapshotData))
+	assert.NoError(t, err, "the embedded snapshot must be readable")
+}
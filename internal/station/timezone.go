@@ -0,0 +1,103 @@
+package station
+
+import "fmt"
+
+// stateZones maps the states and territories whose coastal stations all share one IANA zone
+// to it. NOAA publishes lst_ldt data in local standard/daylight time, so a station's zone
+// must carry its DST rules, and the state says more about those than the offset does.
+var stateZones = map[string]string{
+	"ME": "America/New_York",
+	"NH": "America/New_York",
+	"MA": "America/New_York",
+	"RI": "America/New_York",
+	"CT": "America/New_York",
+	"NY": "America/New_York",
+	"NJ": "America/New_York",
+	"PA": "America/New_York",
+	"DE": "America/New_York",
+	"MD": "America/New_York",
+	"DC": "America/New_York",
+	"VA": "America/New_York",
+	"NC": "America/New_York",
+	"SC": "America/New_York",
+	"GA": "America/New_York",
+	"AL": "America/Chicago",
+	"MS": "America/Chicago",
+	"LA": "America/Chicago",
+	"TX": "America/Chicago",
+	"AZ": "America/Phoenix", // no daylight saving time
+	"CA": "America/Los_Angeles",
+	"OR": "America/Los_Angeles",
+	"WA": "America/Los_Angeles",
+	"HI": "Pacific/Honolulu",
+	"PR": "America/Puerto_Rico",
+	"VI": "America/St_Thomas",
+	"GU": "Pacific/Guam",
+	"MP": "Pacific/Saipan",
+	"AS": "Pacific/Pago_Pago",
+}
+
+// splitStateZones maps the states whose coast spans zones to the zone of each of their
+// NOAA standard-time corrections (hours from UTC)
+var splitStateZones = map[string]map[int]string{
+	"FL": {-5: "America/New_York", -6: "America/Chicago"}, // the panhandle west of the Apalachicola
+	"AK": {-9: "America/Anchorage", -10: "America/Adak"},  // the Aleutians observe DST, unlike Hawaii
+}
+
+// standardZones maps a standard-time correction to the IANA zone most stations with that
+// correction observe, for stations outside the states above
+var standardZones = map[int]string{
+	-4:  "America/Puerto_Rico",
+	-5:  "America/New_York",
+	-6:  "America/Chicago",
+	-7:  "America/Denver",
+	-8:  "America/Los_Angeles",
+	-9:  "America/Anchorage",
+	-10: "Pacific/Honolulu",
+	-11: "Pacific/Pago_Pago",
+	10:  "Pacific/Guam",
+	12:  "Pacific/Majuro",
+}
+
+// fractionalZones maps the standard-time corrections that aren't whole hours, in seconds,
+// to the IANA zone observing them
+var fractionalZones = map[int]string{
+	-34200: "Pacific/Marquesas",
+	-12600: "America/St_Johns",
+	12600:  "Asia/Tehran",
+	16200:  "Asia/Kabul",
+	19800:  "Asia/Kolkata",
+	20700:  "Asia/Kathmandu",
+	23400:  "Asia/Yangon",
+	31500:  "Australia/Eucla",
+	34200:  "Australia/Darwin",
+	45900:  "Pacific/Chatham",
+}
+
+// resolveTimeZone picks an IANA timezone for a station from its state, falling back to its
+// standard-time correction (in seconds) for states that don't have one zone and stations
+// outside them. Whole-hour offsets without a known zone map to a fixed Etc/GMT zone; other
+// offsets without one get none, leaving the station on its fixed offset.
+func resolveTimeZone(offset int, state string) string {
+	if zone, ok := stateZones[state]; ok {
+		return zone
+	}
+	if offset%3600 != 0 {
+		return fractionalZones[offset]
+	}
+	hours := offset / 3600
+	if zone, ok := splitStateZones[state][hours]; ok {
+		return zone
+	}
+	if zone, ok := standardZones[hours]; ok {
+		return zone
+	}
+	if hours == 0 {
+		return "UTC"
+	}
+	if hours < -12 || hours > 14 {
+		return ""
+	}
+	// Etc/GMT zones use POSIX signs, so UTC-3 is Etc/GMT+3
+	return fmt.Sprintf("Etc/GMT%+d", -hours)
+}
@@ -0,0 +1,95 @@
+package stationchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// sourceAttribute names the message attribute carrying the change's source, so
+// subscriptions can filter on it
+const sourceAttribute = "source"
+
+// SNSClient is the part of the SNS API changes are published with
+type SNSClient interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// Publisher publishes station list changes to an SNS topic, as the change's JSON
+type Publisher struct {
+	client   SNSClient
+	topicARN string
+}
+
+// NewPublisher publishes to the topic with topicARN
+func NewPublisher(client SNSClient, topicARN string) *Publisher {
+	return &Publisher{client: client, topicARN: topicARN}
+}
+
+func (p *Publisher) Publish(ctx context.Context, change *models.StationListChange) error {
+	body, err := json.Marshal(change)
+	if err != nil {
+		return fmt.Errorf("encoding station list change: %w", err)
+	}
+	added, removed, modified := change.Count()
+	_, err = p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn: aws.String(p.topicARN),
+		Subject:  aws.String(fmt.Sprintf("%s station list changed: %d added, %d removed, %d modified", change.Source, added, removed, modified)),
+		Message:  aws.String(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			sourceAttribute: {DataType: aws.String("String"), StringValue: aws.String(string(change.Source))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("publishing station list change: %w", err)
+	}
+	return nil
+}
+
+// lazyClient creates its SNS client the first time it's used, so instances that never see
+// the station list change don't load the AWS configuration. If that fails, every call
+// returns the error.
+type lazyClient struct {
+	once   sync.Once
+	client *sns.Client
+	err    error
+}
+
+// NewSNSClient returns an SNSClient that creates a client from the AWS configuration the
+// first time it's used
+func NewSNSClient() SNSClient {
+	return &lazyClient{}
+}
+
+func (l *lazyClient) get(ctx context.Context) (*sns.Client, error) {
+	l.once.Do(func() {
+		start := time.Now()
+		// The client outlives the request that happens to create it
+		cfg, err := awsconfig.LoadDefaultConfig(context.WithoutCancel(ctx))
+		if err != nil {
+			l.err = fmt.Errorf("loading AWS config: %w", err)
+			log.Error().Err(l.err).Str("client", "station changes").Msg("Created client")
+			return
+		}
+		l.client = sns.NewFromConfig(cfg)
+		log.Debug().Str("client", "station changes").Dur("elapsed", time.Since(start)).Msg("Created client")
+	})
+	return l.client, l.err
+}
+
+func (l *lazyClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	client, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Publish(ctx, params, optFns...)
+}
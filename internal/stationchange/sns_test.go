@@ -0,0 +1,47 @@
+package stationchange
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSNS records the messages published to it
+type fakeSNS struct {
+	published []*sns.PublishInput
+	err       error
+}
+
+func (f *fakeSNS) Publish(_ context.Context, params *sns.PublishInput, _ ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.published = append(f.published, params)
+	return &sns.PublishOutput{}, nil
+}
+
+func TestPublisher_Publish(t *testing.T) {
+	client := &fakeSNS{}
+	publisher := NewPublisher(client, "arn:aws:sns:us-east-1:123456789012:flowebb-station-changes")
+	change := newChange(t, 1, "9447130")
+
+	require.NoError(t, publisher.Publish(context.Background(), change))
+	require.Len(t, client.published, 1)
+	published := client.published[0]
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:flowebb-station-changes", *published.TopicArn)
+	assert.Equal(t, "NOAA station list changed: 1 added, 0 removed, 0 modified", *published.Subject)
+	assert.Equal(t, "NOAA", *published.MessageAttributes[sourceAttribute].StringValue)
+	assert.JSONEq(t, `{
+		"source": "NOAA",
+		"changeId": "`+change.ChangeID+`",
+		"detectedAt": 1709272800000,
+		"stations": [{"stationId": "9447130", "name": "Seattle", "change": "ADDED"}]
+	}`, *published.Message)
+
+	client.err = errors.New("throttled")
+	assert.EqualError(t, publisher.Publish(context.Background(), change), "publishing station list change: throttled")
+}
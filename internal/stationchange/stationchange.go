@@ -0,0 +1,81 @@
+// Package stationchange records how NOAA's station list changes from one refresh to the
+// next, so operators and power users can see when stations are added, retired or moved,
+// and publishes each new change to an SNS topic for whatever needs to react to it
+package stationchange
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// maxStations caps the stations a change lists, keeping it within DynamoDB's item size
+// and SNS's message size when NOAA changes much of its list at once
+const maxStations = 500
+
+// NewChange describes how source's station list changed, as detected at now. The ID is
+// the day it was detected and a hash of the stations, so instances that detect the same
+// change that day describe it the same way.
+func NewChange(source models.Source, stations []models.StationChange, now time.Time) (*models.StationListChange, error) {
+	body, err := json.Marshal(stations)
+	if err != nil {
+		return nil, fmt.Errorf("encoding station changes: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	change := &models.StationListChange{
+		Source:     source,
+		ChangeID:   now.UTC().Format(time.DateOnly) + "#" + hex.EncodeToString(hash[:6]),
+		DetectedAt: models.MillisOf(now),
+		Stations:   stations,
+	}
+	if len(stations) > maxStations {
+		change.Stations, change.Omitted = stations[:maxStations], len(stations)-maxStations
+	}
+	return change, nil
+}
+
+// Recorder saves each change to the station list, and publishes those no other instance
+// saved first
+type Recorder struct {
+	store Store
+	// publisher is nil when changes aren't published
+	publisher *Publisher
+	now       func() time.Time
+}
+
+// NewRecorder saves changes in store and publishes them with publisher, which may be nil
+func NewRecorder(store Store, publisher *Publisher) *Recorder {
+	return &Recorder{store: store, publisher: publisher, now: time.Now}
+}
+
+// Record saves how source's station list changed, publishing the change unless another
+// instance saved it already. It's the finder's OnListChange hook, so a failure is logged
+// rather than failing the lookup that refreshed the list.
+func (r *Recorder) Record(ctx context.Context, source models.Source, stations []models.StationChange) {
+	change, err := NewChange(source, stations, r.now())
+	if err != nil {
+		log.Error().Err(err).Msg("Recording station list change failed")
+		return
+	}
+	added, removed, modified := change.Count()
+	logger := log.With().Str("source", string(source)).Str("change_id", change.ChangeID).Logger()
+	logger.Info().Int("added", added).Int("removed", removed).Int("modified", modified).Int("omitted", change.Omitted).Msg("Station list changed")
+
+	saved, err := r.store.Put(ctx, change)
+	if err != nil {
+		logger.Error().Err(err).Msg("Recording station list change failed")
+		return
+	}
+	if !saved || r.publisher == nil {
+		return
+	}
+	if err := r.publisher.Publish(ctx, change); err != nil {
+		logger.Error().Err(err).Msg("Publishing station list change failed")
+	}
+}
@@ -0,0 +1,67 @@
+package stationchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChange(t *testing.T) {
+	stations := []models.StationChange{
+		{StationID: "9414290", Name: "San Francisco", Change: models.StationRemoved},
+		{StationID: "9447130", Name: "Seattle", Change: models.StationModified, Fields: []models.StationFieldChange{{Field: "latitude", Before: "47.6062", After: "47.6026"}}},
+	}
+	morning := time.Date(2024, 3, 15, 6, 0, 0, 0, time.UTC)
+	change, err := NewChange(models.SourceNOAA, stations, morning)
+	require.NoError(t, err)
+	assert.Regexp(t, `^2024-03-15#[0-9a-f]{12}$`, change.ChangeID)
+	assert.Equal(t, models.MillisOf(morning), change.DetectedAt)
+	assert.Equal(t, stations, change.Stations)
+	assert.Zero(t, change.Omitted)
+
+	again, err := NewChange(models.SourceNOAA, stations, morning.Add(8*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, change.ChangeID, again.ChangeID, "the same change detected later that day has the same ID")
+	other, err := NewChange(models.SourceNOAA, stations[:1], morning)
+	require.NoError(t, err)
+	assert.NotEqual(t, change.ChangeID, other.ChangeID)
+
+	many := make([]models.StationChange, maxStations+20)
+	for i := range many {
+		many[i] = models.StationChange{StationID: fmt.Sprintf("%07d", i), Change: models.StationAdded}
+	}
+	change, err = NewChange(models.SourceNOAA, many, morning)
+	require.NoError(t, err)
+	assert.Len(t, change.Stations, maxStations)
+	assert.Equal(t, 20, change.Omitted)
+}
+
+func TestRecorder_Record(t *testing.T) {
+	client := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+	topic := &fakeSNS{}
+	recorder := NewRecorder(NewDynamoStore(client, "station-changes"), NewPublisher(topic, "arn:aws:sns:us-east-1:123456789012:flowebb-station-changes"))
+	recorder.now = func() time.Time { return time.Date(2024, 3, 15, 6, 0, 0, 0, time.UTC) }
+	stations := []models.StationChange{{StationID: "9447130", Name: "Seattle", Change: models.StationAdded}}
+	ctx := context.Background()
+
+	recorder.Record(ctx, models.SourceNOAA, stations)
+	recorder.Record(ctx, models.SourceNOAA, stations)
+	assert.Len(t, client.items, 1)
+	assert.Len(t, topic.published, 1, "a change another instance saved first isn't published again")
+
+	client.err = errors.New("throttled")
+	recorder.Record(ctx, models.SourceNOAA, []models.StationChange{{StationID: "9414290", Change: models.StationRemoved}})
+	assert.Len(t, topic.published, 1, "a change that wasn't saved isn't published")
+
+	client.err = nil
+	recorder = NewRecorder(NewDynamoStore(client, "station-changes"), nil)
+	recorder.Record(ctx, models.SourceNOAA, []models.StationChange{{StationID: "9414290", Change: models.StationRemoved}})
+	assert.Len(t, client.items, 2, "changes are recorded without a topic")
+}
@@ -0,0 +1,88 @@
+package stationchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Retention is how long the store keeps a change after it's detected
+const Retention = 2 * 365 * 24 * time.Hour
+
+// Store keeps the changes to each source's station list
+type Store interface {
+	// Put saves change, reporting false when the same change was already saved
+	Put(ctx context.Context, change *models.StationListChange) (bool, error)
+	// List returns at most limit of source's changes detected on or after since's day,
+	// newest first
+	List(ctx context.Context, source models.Source, since time.Time, limit int) ([]models.StationListChange, error)
+}
+
+// DynamoStore keeps each change as an item keyed by source and changeId, so a source's
+// changes can be queried by the day they were detected, and lets DynamoDB drop it after
+// Retention
+type DynamoStore struct {
+	client cache.DynamoDBClient
+	table  string
+}
+
+var _ Store = (*DynamoStore)(nil)
+
+func NewDynamoStore(client cache.DynamoDBClient, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table}
+}
+
+func (s *DynamoStore) Put(ctx context.Context, change *models.StationListChange) (bool, error) {
+	item, err := attributevalue.MarshalMap(change)
+	if err != nil {
+		return false, fmt.Errorf("marshaling station list change: %w", err)
+	}
+	item["ttl"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(change.DetectedAt.Time().Add(Retention).Unix(), 10)}
+
+	// Change IDs hash the stations, so an existing one is the same change from another instance
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(changeId)"),
+	})
+	var conditionErr *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &conditionErr):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("putting station list change in DynamoDB: %w", err)
+	}
+	return true, nil
+}
+
+func (s *DynamoStore) List(ctx context.Context, source models.Source, since time.Time, limit int) ([]models.StationListChange, error) {
+	output, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("#source = :source AND changeId >= :since"),
+		// source is a reserved word
+		ExpressionAttributeNames: map[string]string{"#source": "source"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":source": &types.AttributeValueMemberS{Value: string(source)},
+			":since":  &types.AttributeValueMemberS{Value: since.UTC().Format(time.DateOnly)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying station list changes in DynamoDB: %w", err)
+	}
+	changes := make([]models.StationListChange, 0, len(output.Items))
+	if err := attributevalue.UnmarshalListOfMaps(output.Items, &changes); err != nil {
+		return nil, fmt.Errorf("unmarshaling station list changes: %w", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,106 @@
+package stationchange
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB keeps items by changeId, honoring the put condition, and answers queries
+// with every item at or after :since, newest first
+type fakeDynamoDB struct {
+	cache.DynamoDBClient
+	items   map[string]map[string]types.AttributeValue
+	queries []*dynamodb.QueryInput
+	err     error
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	id := params.Item["changeId"].(*types.AttributeValueMemberS).Value
+	if _, ok := f.items[id]; ok {
+		return nil, &types.ConditionalCheckFailedException{}
+	}
+	f.items[id] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) Query(_ context.Context, params *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.queries = append(f.queries, params)
+	since := params.ExpressionAttributeValues[":since"].(*types.AttributeValueMemberS).Value
+	var ids []string
+	for id := range f.items {
+		if id >= since {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	output := &dynamodb.QueryOutput{}
+	for _, id := range ids[:min(len(ids), int(*params.Limit))] {
+		output.Items = append(output.Items, f.items[id])
+	}
+	return output, nil
+}
+
+func newChange(t *testing.T, day int, stationID string) *models.StationListChange {
+	t.Helper()
+	change, err := NewChange(models.SourceNOAA, []models.StationChange{{StationID: stationID, Name: "Seattle", Change: models.StationAdded}},
+		time.Date(2024, 3, day, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	return change
+}
+
+func TestDynamoStore(t *testing.T) {
+	client := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+	store := NewDynamoStore(client, "station-changes")
+	ctx := context.Background()
+
+	first, second := newChange(t, 1, "9447130"), newChange(t, 15, "9414290")
+	for _, change := range []*models.StationListChange{first, second} {
+		saved, err := store.Put(ctx, change)
+		require.NoError(t, err)
+		assert.True(t, saved)
+	}
+	saved, err := store.Put(ctx, newChange(t, 15, "9414290"))
+	require.NoError(t, err)
+	assert.False(t, saved, "the same change detected by another instance is saved once")
+
+	var ttl struct {
+		TTL int64 `dynamodbav:"ttl"`
+	}
+	require.NoError(t, attributevalue.UnmarshalMap(client.items[first.ChangeID], &ttl))
+	assert.Equal(t, first.DetectedAt.Time().Add(Retention).Unix(), ttl.TTL)
+
+	changes, err := store.List(ctx, models.SourceNOAA, time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.StationListChange{*second, *first}, changes)
+	query := client.queries[0]
+	assert.Equal(t, "station-changes", *query.TableName)
+	assert.Equal(t, "NOAA", query.ExpressionAttributeValues[":source"].(*types.AttributeValueMemberS).Value)
+	assert.False(t, *query.ScanIndexForward)
+
+	changes, err = store.List(ctx, models.SourceNOAA, time.Date(2024, 3, 15, 23, 0, 0, 0, time.UTC), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.StationListChange{*second}, changes, "since is a day, so changes detected earlier that day are listed")
+
+	client.err = errors.New("throttled")
+	_, err = store.Put(ctx, first)
+	assert.EqualError(t, err, "putting station list change in DynamoDB: throttled")
+	_, err = store.List(ctx, models.SourceNOAA, time.Time{}, 10)
+	assert.EqualError(t, err, "querying station list changes in DynamoDB: throttled")
+}
@@ -0,0 +1,105 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/rs/zerolog/log"
+)
+
+// typeAttribute names the message attribute carrying the task type, so queued tasks can
+// be told apart without decoding their bodies
+const typeAttribute = "taskType"
+
+// Queue defers tasks to the worker
+type Queue interface {
+	// Enqueue queues a task of taskType carrying payload
+	Enqueue(ctx context.Context, taskType string, payload any) error
+}
+
+// SQSClient is the part of the SQS API tasks are queued with
+type SQSClient interface {
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+}
+
+// SQSQueue queues tasks on the SQS queue the worker consumes
+type SQSQueue struct {
+	client SQSClient
+	url    string
+	now    func() time.Time
+}
+
+var _ Queue = (*SQSQueue)(nil)
+
+// NewSQSQueue queues tasks on the queue at url
+func NewSQSQueue(client SQSClient, url string) *SQSQueue {
+	return &SQSQueue{client: client, url: url, now: time.Now}
+}
+
+func (q *SQSQueue) Enqueue(ctx context.Context, taskType string, payload any) error {
+	task, err := New(taskType, payload, q.now())
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("encoding %s task: %w", taskType, err)
+	}
+	_, err = q.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(q.url),
+		MessageBody: aws.String(string(body)),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			typeAttribute: {DataType: aws.String("String"), StringValue: aws.String(taskType)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("queueing %s task: %w", taskType, err)
+	}
+	log.Debug().Str("task", taskType).Msg("Queued task")
+	return nil
+}
+
+// lazyClient creates its SQS client the first time it's used, so requests that queue
+// nothing don't load the AWS configuration. If that fails, every call returns the error.
+type lazyClient struct {
+	once   sync.Once
+	client *sqs.Client
+	err    error
+}
+
+// NewSQSClient returns an SQSClient that creates a client from the AWS configuration the
+// first time it's used
+func NewSQSClient() SQSClient {
+	return &lazyClient{}
+}
+
+func (l *lazyClient) get(ctx context.Context) (*sqs.Client, error) {
+	l.once.Do(func() {
+		start := time.Now()
+		// The client outlives the request that happens to create it
+		cfg, err := awsconfig.LoadDefaultConfig(context.WithoutCancel(ctx))
+		if err != nil {
+			l.err = fmt.Errorf("loading AWS config: %w", err)
+			log.Error().Err(l.err).Str("client", "tasks").Msg("Created client")
+			return
+		}
+		l.client = sqs.NewFromConfig(cfg)
+		log.Debug().Str("client", "tasks").Dur("elapsed", time.Since(start)).Msg("Created client")
+	})
+	return l.client, l.err
+}
+
+func (l *lazyClient) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	client, err := l.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.SendMessage(ctx, params, optFns...)
+}
@@ -0,0 +1,58 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQS records the messages sent to it
+type fakeSQS struct {
+	sent []*sqs.SendMessageInput
+	err  error
+}
+
+func (f *fakeSQS) SendMessage(_ context.Context, params *sqs.SendMessageInput, _ ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.sent = append(f.sent, params)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func TestSQSQueue_Enqueue(t *testing.T) {
+	client := &fakeSQS{}
+	queue := NewSQSQueue(client, "https://sqs.us-east-1.amazonaws.com/123456789012/flowebb-tasks")
+	queue.now = func() time.Time { return time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, queue.Enqueue(context.Background(), TypeWarmCache, WarmCache{StationID: "9447130", StartDate: "2024-03-01", EndDate: "2024-03-07"}))
+	require.Len(t, client.sent, 1)
+	sent := client.sent[0]
+	assert.Equal(t, "https://sqs.us-east-1.amazonaws.com/123456789012/flowebb-tasks", *sent.QueueUrl)
+	assert.Equal(t, TypeWarmCache, *sent.MessageAttributes[typeAttribute].StringValue)
+	assert.JSONEq(t, `{
+		"type": "cache.warm",
+		"payload": {"stationId": "9447130", "startDate": "2024-03-01", "endDate": "2024-03-07"},
+		"enqueuedAt": 1710504000000
+	}`, *sent.MessageBody)
+
+	var task Task
+	require.NoError(t, json.Unmarshal([]byte(*sent.MessageBody), &task))
+	registry := NewRegistry()
+	var got WarmCache
+	registry.Register(TypeWarmCache, Typed(func(_ context.Context, payload WarmCache) error {
+		got = payload
+		return nil
+	}))
+	require.NoError(t, registry.Handle(context.Background(), &task))
+	assert.Equal(t, WarmCache{StationID: "9447130", StartDate: "2024-03-01", EndDate: "2024-03-07"}, got, "what's queued is what's handled")
+
+	client.err = errors.New("throttled")
+	assert.EqualError(t, queue.Enqueue(context.Background(), TypeExport, Export{}), "queueing export.run task: throttled")
+}
@@ -0,0 +1,152 @@
+// Package task defers slow work from the request path to the worker function: a request
+// enqueues a typed task on an SQS queue and returns, and the worker hands each task it
+// receives to the handler registered for its type
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/rs/zerolog/log"
+)
+
+// Task types
+const (
+	// TypeWarmCache refetches a station's predictions into the cache, with a WarmCache
+	TypeWarmCache = "cache.warm"
+	// TypeExport renders a pending export job, with an Export
+	TypeExport = "export.run"
+	// TypeSampleAccuracy samples prediction accuracy, with a SampleAccuracy
+	TypeSampleAccuracy = "accuracy.sample"
+)
+
+// Task is the envelope every task is queued in
+type Task struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+	// EnqueuedAt tells how long the task waited in the queue
+	EnqueuedAt models.Millis `json:"enqueuedAt"`
+}
+
+// New puts payload in an envelope of the given type
+func New(taskType string, payload any, now time.Time) (*Task, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s task: %w", taskType, err)
+	}
+	return &Task{Type: taskType, Payload: body, EnqueuedAt: models.MillisOf(now)}, nil
+}
+
+// WarmCache refetches a station's predictions for each day from StartDate through EndDate,
+// both YYYY-MM-DD
+type WarmCache struct {
+	StationID string `json:"stationId"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+}
+
+// Export renders the pending export job kept at JobKey in the export bucket
+type Export struct {
+	JobKey string `json:"jobKey"`
+}
+
+// SampleAccuracy samples the prediction accuracy of Stations, or of every tracked station
+// when there are none
+type SampleAccuracy struct {
+	Stations []string `json:"stations,omitempty"`
+}
+
+// ErrPermanent marks a task that would fail however often it was retried, such as one
+// that can't be decoded. It's dropped rather than returned to the queue.
+var ErrPermanent = errors.New("task can't succeed")
+
+// Permanent marks err as a failure retrying won't fix
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// Handler runs a task given its payload
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Typed returns a Handler that decodes the payload into a T before handing it to handle.
+// Payloads that don't decode fail permanently.
+func Typed[T any](handle func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p T
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		return handle(ctx, p)
+	}
+}
+
+// Registry hands each task to the handler registered for its type
+type Registry struct {
+	handlers map[string]Handler
+	now      func() time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: map[string]Handler{}, now: time.Now}
+}
+
+// Register has handler run the tasks of taskType. Registering a type twice is a
+// programming error and panics.
+func (r *Registry) Register(taskType string, handler Handler) {
+	if _, ok := r.handlers[taskType]; ok {
+		panic("task: " + taskType + " is already registered")
+	}
+	r.handlers[taskType] = handler
+}
+
+// Handle runs task with the handler registered for its type. A type without one fails
+// permanently, since no retry will find a handler either. A panic in the handler fails
+// only its task, as a *recovery.Error.
+func (r *Registry) Handle(ctx context.Context, task *Task) (err error) {
+	handler, ok := r.handlers[task.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for %q tasks", task.Type))
+	}
+	defer func() {
+		if value := recover(); value != nil {
+			err = recovery.Default.Recovered(value, debug.Stack())
+		}
+	}()
+	return handler(ctx, task.Payload)
+}
+
+// HandleSQS runs the task in each message of a batch, reporting those that failed and may
+// succeed on a retry so SQS delivers only them again. Tasks that fail permanently are
+// logged and dropped; the queue's redrive policy moves those that keep failing aside.
+func (r *Registry) HandleSQS(ctx context.Context, event events.SQSEvent) events.SQSEventResponse {
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for _, message := range event.Records {
+		logger := log.With().Str("message_id", message.MessageId).Logger()
+		var task Task
+		err := json.Unmarshal([]byte(message.Body), &task)
+		if err != nil {
+			err = Permanent(fmt.Errorf("decoding task: %w", err))
+		} else {
+			logger = logger.With().Str("task", task.Type).Logger()
+			start := r.now()
+			err = r.Handle(ctx, &task)
+			logger.Debug().Dur("queued", start.Sub(task.EnqueuedAt.Time())).Dur("elapsed", r.now().Sub(start)).Msg("Ran task")
+		}
+
+		switch {
+		case errors.Is(err, ErrPermanent):
+			logger.Error().Err(err).Msg("Task dropped")
+		case err != nil:
+			logger.Warn().Err(err).Msg("Task failed, it will be retried")
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: message.MessageId})
+		}
+	}
+	return response
+}
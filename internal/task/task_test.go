@@ -0,0 +1,86 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/recovery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func message(t *testing.T, id string, taskType string, payload any) events.SQSMessage {
+	t.Helper()
+	task, err := New(taskType, payload, time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	body, err := json.Marshal(task)
+	require.NoError(t, err)
+	return events.SQSMessage{MessageId: id, Body: string(body)}
+}
+
+func TestRegistry_HandleSQS(t *testing.T) {
+	registry := NewRegistry()
+	var warmed []WarmCache
+	registry.Register(TypeWarmCache, Typed(func(_ context.Context, payload WarmCache) error {
+		warmed = append(warmed, payload)
+		if payload.StationID == "flaky" {
+			return errors.New("NOAA timed out")
+		}
+		if payload.StationID == "unknown" {
+			return Permanent(errors.New("station not found"))
+		}
+		return nil
+	}))
+
+	response := registry.HandleSQS(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		message(t, "1", TypeWarmCache, WarmCache{StationID: "9447130", StartDate: "2024-03-01", EndDate: "2024-03-07"}),
+		message(t, "2", TypeWarmCache, WarmCache{StationID: "flaky"}),
+		message(t, "3", TypeWarmCache, WarmCache{StationID: "unknown"}),
+		message(t, "4", TypeExport, Export{JobKey: "export-jobs/9447130/2025.pdf.json"}),
+		message(t, "5", TypeWarmCache, "not a payload"),
+		{MessageId: "6", Body: "not a task"},
+	}})
+
+	assert.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "2"}}, response.BatchItemFailures,
+		"only failures a retry may fix go back to the queue")
+	assert.Equal(t, []WarmCache{
+		{StationID: "9447130", StartDate: "2024-03-01", EndDate: "2024-03-07"},
+		{StationID: "flaky"},
+		{StationID: "unknown"},
+	}, warmed)
+
+	response = registry.HandleSQS(context.Background(), events.SQSEvent{})
+	assert.NotNil(t, response.BatchItemFailures, "an empty list rather than null reports no failures")
+}
+
+func TestRegistry_Handle(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(TypeSampleAccuracy, Typed(func(_ context.Context, payload SampleAccuracy) error {
+		assert.Empty(t, payload.Stations)
+		return nil
+	}))
+	task, err := New(TypeSampleAccuracy, SampleAccuracy{}, time.Now())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(task.Payload))
+	assert.NoError(t, registry.Handle(context.Background(), task))
+
+	err = registry.Handle(context.Background(), &Task{Type: "webhook.deliver"})
+	assert.ErrorIs(t, err, ErrPermanent)
+	assert.EqualError(t, err, `task can't succeed: no handler for "webhook.deliver" tasks`)
+
+	assert.Panics(t, func() { registry.Register(TypeSampleAccuracy, nil) })
+
+	registry.Register(TypeExport, Typed(func(context.Context, Export) error {
+		var job *Export
+		_ = job.JobKey
+		return nil
+	}))
+	task, err = New(TypeExport, Export{}, time.Now())
+	require.NoError(t, err)
+	var recovered *recovery.Error
+	assert.ErrorAs(t, registry.Handle(context.Background(), task), &recovered, "a panic fails only its task")
+}
@@ -0,0 +1,183 @@
+// Package tenant lets one deployment serve several white-label tide apps. Each tenant is
+// recognized by the API key or hostname a request arrives with, and gets its own rate
+// limit, feature flags, branding in responses and usage counts.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+)
+
+// Tenant is one white-label app the deployment serves, as the TENANTS setting lists them
+type Tenant struct {
+	// ID names the tenant in usage counts and responses
+	ID string `json:"id"`
+	// APIKeyIDs are the IDs of the API Gateway keys the tenant's apps call with
+	APIKeyIDs []string `json:"apiKeyIds,omitempty"`
+	// Hosts are the hostnames the tenant's requests arrive at, like tides.example.com
+	Hosts []string `json:"hosts,omitempty"`
+	// RateLimit is how many requests a minute each of the tenant's clients may make per
+	// instance; zero doesn't limit them
+	RateLimit int `json:"rateLimit,omitempty"`
+	// FeatureFlags turn flags on for the tenant's requests, or off with a "-" in front,
+	// ahead of FEATURE_FLAGS; a request's X-Feature-Flags header still wins
+	FeatureFlags []string `json:"featureFlags,omitempty"`
+	// Branding is what the tenant's responses tell its app to show
+	Branding Branding `json:"branding"`
+}
+
+// Branding is the metadata a tenant's responses carry for its app to present itself with
+type Branding struct {
+	Name         string `json:"name,omitempty"`
+	LogoURL      string `json:"logoUrl,omitempty"`
+	PrimaryColor string `json:"primaryColor,omitempty"`
+	SupportURL   string `json:"supportUrl,omitempty"`
+}
+
+// Metadata is the tenant as its responses describe it
+type Metadata struct {
+	ID string `json:"id"`
+	Branding
+}
+
+// Metadata returns the tenant as its responses describe it
+func (t *Tenant) Metadata() Metadata {
+	return Metadata{ID: t.ID, Branding: t.Branding}
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context carrying the tenant a request is for
+func WithTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// FromContext returns the tenant set by WithTenant, or nil for requests that aren't any
+// tenant's
+func FromContext(ctx context.Context) *Tenant {
+	t, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return t
+}
+
+// Handler is the signature of the Lambda handlers a Registry serves
+type Handler = func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Registry recognizes the tenants of requests. A nil Registry recognizes none, so
+// entrypoints can use one whether or not tenants are configured.
+type Registry struct {
+	byAPIKey map[string]*Tenant
+	byHost   map[string]*Tenant
+	limiters map[string]*ratelimit.Limiter
+	observe  func(tenant string, failed bool)
+}
+
+// NewRegistry indexes tenants by their API keys and hosts, or returns nil when there are
+// none. It fails when a tenant has no ID, or an ID, key or host is given twice.
+func NewRegistry(tenants []Tenant) (*Registry, error) {
+	if len(tenants) == 0 {
+		return nil, nil
+	}
+	r := &Registry{
+		byAPIKey: map[string]*Tenant{},
+		byHost:   map[string]*Tenant{},
+		limiters: map[string]*ratelimit.Limiter{},
+	}
+	ids := map[string]bool{}
+	var errs []error
+	for i := range tenants {
+		t := &tenants[i]
+		if t.ID == "" {
+			errs = append(errs, fmt.Errorf("tenant %d has no id", i+1))
+			continue
+		}
+		if ids[t.ID] {
+			errs = append(errs, fmt.Errorf("tenant %s is listed twice", t.ID))
+		}
+		ids[t.ID] = true
+		if t.RateLimit < 0 {
+			errs = append(errs, fmt.Errorf("tenant %s has a negative rate limit", t.ID))
+		}
+		for _, key := range t.APIKeyIDs {
+			if other, ok := r.byAPIKey[key]; ok {
+				errs = append(errs, fmt.Errorf("API key %s belongs to tenants %s and %s", key, other.ID, t.ID))
+			}
+			r.byAPIKey[key] = t
+		}
+		for _, host := range t.Hosts {
+			host = strings.ToLower(host)
+			if other, ok := r.byHost[host]; ok {
+				errs = append(errs, fmt.Errorf("host %s belongs to tenants %s and %s", host, other.ID, t.ID))
+			}
+			r.byHost[host] = t
+		}
+		if limiter := ratelimit.New(t.RateLimit, time.Minute); limiter != nil {
+			r.limiters[t.ID] = limiter
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return r, nil
+}
+
+// OnRequest registers fn to be called with the tenant of each request served, and
+// whether it failed on the server's side
+func (r *Registry) OnRequest(fn func(tenant string, failed bool)) {
+	if r != nil {
+		r.observe = fn
+	}
+}
+
+// Resolve returns the tenant of the API key the request was made with, or else of the
+// host it was made to, or nil when it's neither's
+func (r *Registry) Resolve(request events.APIGatewayProxyRequest) *Tenant {
+	if r == nil {
+		return nil
+	}
+	if t, ok := r.byAPIKey[request.RequestContext.Identity.APIKeyID]; ok {
+		return t
+	}
+	for name, value := range request.Headers {
+		if strings.EqualFold(name, "Host") {
+			host, _, err := net.SplitHostPort(value)
+			if err != nil {
+				host = value
+			}
+			return r.byHost[strings.ToLower(host)]
+		}
+	}
+	return nil
+}
+
+// Serve wraps next so each request of a tenant is held to its rate limit, runs with its
+// feature flags and the tenant in its context, and is counted against it. Requests over
+// the limit are answered by reject with a *ratelimit.Error.
+func (r *Registry) Serve(next Handler, reject func(error) (events.APIGatewayProxyResponse, error)) Handler {
+	if r == nil {
+		return next
+	}
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		t := r.Resolve(request)
+		if t == nil {
+			return next(ctx, request)
+		}
+		if err := r.limiters[t.ID].Allow(request.RequestContext.Identity.SourceIP); err != nil {
+			return reject(err)
+		}
+		ctx = WithTenant(ctx, t)
+		ctx = feature.WithFlags(ctx, t.FeatureFlags)
+		response, err := next(ctx, request)
+		if r.observe != nil {
+			r.observe(t.ID, err != nil || response.StatusCode >= 500)
+		}
+		return response, err
+	}
+}
@@ -0,0 +1,120 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func request(apiKeyID, host, sourceIP string) events.APIGatewayProxyRequest {
+	r := events.APIGatewayProxyRequest{Headers: map[string]string{}}
+	r.RequestContext.Identity.APIKeyID = apiKeyID
+	r.RequestContext.Identity.SourceIP = sourceIP
+	if host != "" {
+		r.Headers["host"] = host
+	}
+	return r
+}
+
+func testRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r, err := NewRegistry([]Tenant{
+		{ID: "acme", APIKeyIDs: []string{"key-acme"}, Hosts: []string{"Tides.Acme.com"}, RateLimit: 2, FeatureFlags: []string{"v2-default"}},
+		{ID: "globex", Hosts: []string{"tides.globex.com"}},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	r := testRegistry(t)
+	assert.Equal(t, "acme", r.Resolve(request("key-acme", "tides.globex.com", "")).ID, "the API key wins over the host")
+	assert.Equal(t, "acme", r.Resolve(request("", "tides.acme.com:443", "")).ID)
+	assert.Equal(t, "globex", r.Resolve(request("other-key", "TIDES.GLOBEX.COM", "")).ID)
+	assert.Nil(t, r.Resolve(request("", "api.flowebb.com", "")))
+	assert.Nil(t, r.Resolve(request("", "", "")))
+
+	var none *Registry
+	assert.Nil(t, none.Resolve(request("key-acme", "", "")))
+}
+
+func TestNewRegistry(t *testing.T) {
+	r, err := NewRegistry(nil)
+	require.NoError(t, err)
+	assert.Nil(t, r, "no tenants serves every request alike")
+
+	_, err = NewRegistry([]Tenant{
+		{ID: "acme", APIKeyIDs: []string{"k1"}},
+		{ID: "acme"},
+		{Hosts: []string{"tides.example.com"}},
+		{ID: "globex", APIKeyIDs: []string{"k1"}, RateLimit: -1},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenant acme is listed twice")
+	assert.Contains(t, err.Error(), "tenant 3 has no id")
+	assert.Contains(t, err.Error(), "API key k1 belongs to tenants acme and globex")
+	assert.Contains(t, err.Error(), "tenant globex has a negative rate limit")
+}
+
+func TestRegistry_Serve(t *testing.T) {
+	r := testRegistry(t)
+	type observed struct {
+		tenant string
+		failed bool
+	}
+	var seen []observed
+	r.OnRequest(func(tenant string, failed bool) { seen = append(seen, observed{tenant, failed}) })
+
+	handler := r.Serve(func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		tenant := FromContext(ctx)
+		if tenant == nil {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: "none"}, nil
+		}
+		if tenant.ID == "globex" {
+			return events.APIGatewayProxyResponse{StatusCode: http.StatusBadGateway}, nil
+		}
+		assert.True(t, feature.Enabled(ctx, feature.V2Default), "the tenant's flags are on")
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusOK, Body: tenant.ID}, nil
+	}, func(err error) (events.APIGatewayProxyResponse, error) {
+		var limited *ratelimit.Error
+		require.True(t, errors.As(err, &limited))
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusTooManyRequests}, nil
+	})
+
+	ctx := context.Background()
+	for range 2 {
+		response, err := handler(ctx, request("key-acme", "", "203.0.113.1"))
+		require.NoError(t, err)
+		assert.Equal(t, "acme", response.Body)
+	}
+	response, _ := handler(ctx, request("key-acme", "", "203.0.113.1"))
+	assert.Equal(t, http.StatusTooManyRequests, response.StatusCode, "acme allows 2 a minute")
+	response, _ = handler(ctx, request("key-acme", "", "203.0.113.2"))
+	assert.Equal(t, "acme", response.Body, "each client has its own allowance")
+
+	response, _ = handler(ctx, request("", "tides.globex.com", "203.0.113.1"))
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	response, _ = handler(ctx, request("", "api.flowebb.com", "203.0.113.1"))
+	assert.Equal(t, "none", response.Body)
+
+	assert.Equal(t, []observed{{"acme", false}, {"acme", false}, {"acme", false}, {"globex", true}}, seen)
+}
+
+func TestRegistry_ServeNil(t *testing.T) {
+	var r *Registry
+	r.OnRequest(func(string, bool) {})
+	handler := r.Serve(func(ctx context.Context, _ events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		assert.Nil(t, FromContext(ctx))
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusTeapot}, nil
+	}, nil)
+	response, err := handler(context.Background(), request("key-acme", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, response.StatusCode)
+}
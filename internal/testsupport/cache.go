@@ -0,0 +1,89 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+var _ cache.CacheService = (*MemoryCache)(nil)
+
+// MemoryCache is a cache.CacheService that keeps prediction records in a map, with no
+// expiry or size limit, and counts hits and misses like cache.LRUCacheService
+type MemoryCache struct {
+	mu      sync.Mutex
+	records map[string]models.TidePredictionRecord
+	hits    uint64
+	misses  uint64
+	saves   int
+}
+
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{records: map[string]models.TidePredictionRecord{}}
+}
+
+func memoryKey(stationID string, date time.Time) string {
+	return stationID + ":" + date.Format("2006-01-02")
+}
+
+func (c *MemoryCache) GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	record, ok := c.records[memoryKey(stationID, date)]
+	if !ok {
+		c.misses++
+		return nil, nil
+	}
+	c.hits++
+	return &record, nil
+}
+
+func (c *MemoryCache) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	records := make([]*models.TidePredictionRecord, len(dates))
+	for i, date := range dates {
+		records[i], _ = c.GetPredictions(ctx, stationID, date)
+	}
+	return records, nil
+}
+
+func (c *MemoryCache) SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, record := range records {
+		c.records[record.StationID+":"+record.Date] = record
+	}
+	c.saves++
+	return nil
+}
+
+// Put caches a record, as though an earlier request had fetched it. It isn't counted
+// among the saves.
+func (c *MemoryCache) Put(record models.TidePredictionRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.records[record.StationID+":"+record.Date] = record
+}
+
+// Len returns how many station days are cached
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.records)
+}
+
+// Saves returns how many batches the service has saved
+func (c *MemoryCache) Saves() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saves
+}
+
+// GetCacheStats reports the hits and misses as memory_hits and memory_misses
+func (c *MemoryCache) GetCacheStats() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]uint64{"memory_hits": c.hits, "memory_misses": c.misses}
+}
@@ -0,0 +1,105 @@
+package testsupport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/bbernstein/flowebb-go/internal/geo"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+var (
+	_ models.StationFinder = (*StationFinder)(nil)
+	_ models.BoundsFinder  = (*StationFinder)(nil)
+	_ models.RegionFinder  = (*StationFinder)(nil)
+)
+
+// StationFinder is a models.StationFinder over a fixed list of stations. Setting
+// FindStationFn or FindNearestStationsFn replaces the list lookup for that method.
+type StationFinder struct {
+	Stations              []models.Station
+	FindStationFn         func(ctx context.Context, stationID string) (*models.Station, error)
+	FindNearestStationsFn func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error)
+	// Stale marks every page as coming from an out-of-date list
+	Stale bool
+}
+
+// FindStation returns a copy of the listed station with the ID, or an error wrapping
+// models.ErrStationNotFound
+func (f *StationFinder) FindStation(ctx context.Context, stationID string) (*models.Station, error) {
+	if f.FindStationFn != nil {
+		return f.FindStationFn(ctx, stationID)
+	}
+	for _, station := range f.Stations {
+		if station.ID == stationID {
+			return &station, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+}
+
+// FindNearestStations returns up to limit of the listed stations, nearest first, with
+// their distance from the point set
+func (f *StationFinder) FindNearestStations(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+	if f.FindNearestStationsFn != nil {
+		return f.FindNearestStationsFn(ctx, lat, lon, limit)
+	}
+	stations := make([]models.Station, len(f.Stations))
+	for i, station := range f.Stations {
+		station.Distance = geo.DistanceKm(lat, lon, station.Latitude, station.Longitude)
+		stations[i] = station
+	}
+	sort.SliceStable(stations, func(i, j int) bool { return stations[i].Distance < stations[j].Distance })
+	if limit > 0 && len(stations) > limit {
+		stations = stations[:limit]
+	}
+	return stations, nil
+}
+
+func (f *StationFinder) FindNearestStationsPage(ctx context.Context, lat, lon float64, filter models.StationFilter, offset, limit int) (*models.StationPage, error) {
+	stations, err := f.FindNearestStations(ctx, lat, lon, offset+limit)
+	if err != nil {
+		return nil, err
+	}
+	page := models.NewStationPage(filter.Apply(stations), offset, limit)
+	page.Stale = f.Stale
+	return page, nil
+}
+
+// FindStationsInBounds returns the listed stations within bounds that pass filter
+func (f *StationFinder) FindStationsInBounds(ctx context.Context, bounds geo.Bounds, filter models.StationFilter) ([]models.Station, error) {
+	var stations []models.Station
+	for _, station := range filter.Apply(f.Stations) {
+		if bounds.Contains(station.Latitude, station.Longitude) {
+			stations = append(stations, station)
+		}
+	}
+	return stations, nil
+}
+
+// FindRegions groups the listed stations that pass filter by state and region
+func (f *StationFinder) FindRegions(ctx context.Context, filter models.StationFilter, state string) ([]models.StationRegion, error) {
+	return models.GroupRegions(filter.Apply(f.Stations), state), nil
+}
+
+// Station returns a NOAA reference station on Puget Sound with the ID, named
+// "Test Station <id>"
+func Station(id string) models.Station {
+	state := "WA"
+	region := "Puget Sound"
+	level := "R"
+	return models.Station{
+		ID:             id,
+		Name:           "Test Station " + id,
+		State:          &state,
+		Region:         &region,
+		Latitude:       47.6062,
+		Longitude:      -122.3321,
+		Source:         models.SourceNOAA,
+		Capabilities:   []string{models.CapabilityWaterLevel},
+		TimeZoneOffset: -8 * 3600,
+		Level:          &level,
+		StationType:    models.StationKindReference,
+	}
+}
@@ -0,0 +1,113 @@
+package testsupport
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// tidePeriod is the principal lunar semidiurnal (M2) period
+const tidePeriod = 12.42 * float64(time.Hour)
+
+// NOAA is an httptest server standing in for the CO-OPS datagetter. It answers 6-minute
+// and high/low prediction requests for any station and range from one semidiurnal curve
+// between 0.5 and 9 feet, and answers every other product with NOAA's "No data was found"
+// error.
+type NOAA struct {
+	*httptest.Server
+	requests atomic.Int64
+
+	mu    sync.Mutex
+	delay time.Duration
+	fail  string
+}
+
+func NewNOAA() *NOAA {
+	n := &NOAA{}
+	n.Server = httptest.NewServer(http.HandlerFunc(n.serve))
+	return n
+}
+
+// Requests returns how many requests the server has had
+func (n *NOAA) Requests() int {
+	return int(n.requests.Load())
+}
+
+// SetDelay makes every response wait, to stand in for the trip to NOAA
+func (n *NOAA) SetDelay(delay time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.delay = delay
+}
+
+// Fail makes every response a NOAA error with the message; an empty message stops it
+func (n *NOAA) Fail(message string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.fail = message
+}
+
+func (n *NOAA) serve(w http.ResponseWriter, r *http.Request) {
+	n.requests.Add(1)
+	n.mu.Lock()
+	delay, fail := n.delay, n.fail
+	n.mu.Unlock()
+	time.Sleep(delay)
+
+	query := r.URL.Query()
+	if fail == "" && query.Get("product") != "predictions" {
+		fail = "No data was found. This product may not be offered at this station at the requested time."
+	}
+	if fail != "" {
+		_, _ = fmt.Fprintf(w, `{"error":{"message":%q}}`, fail)
+		return
+	}
+	begin, err1 := time.Parse("20060102", query.Get("begin_date"))
+	end, err2 := time.Parse("20060102", query.Get("end_date"))
+	if err1 != nil || err2 != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	end = end.AddDate(0, 0, 1)
+
+	var body bytes.Buffer
+	body.WriteString(`{"predictions":[`)
+	if query.Get("interval") == "hilo" {
+		// Highs fall where the curve peaks, every whole period since the Unix epoch
+		half := time.Duration(tidePeriod / 2)
+		first := time.Unix(0, 0).UTC().Add(begin.Sub(time.Unix(0, 0)).Truncate(half))
+		for at := first; at.Before(end); at = at.Add(half) {
+			if at.Before(begin) {
+				continue
+			}
+			kind := "L"
+			if int64(at.Sub(time.Unix(0, 0))/half)%2 == 0 {
+				kind = "H"
+			}
+			if body.Len() > len(`{"predictions":[`) {
+				body.WriteString(",")
+			}
+			_, _ = fmt.Fprintf(&body, `{"t":"%s","v":"%.3f","type":"%s"}`, at.Format("2006-01-02 15:04"), Height(at), kind)
+		}
+	} else {
+		for at := begin; at.Before(end); at = at.Add(6 * time.Minute) {
+			if at != begin {
+				body.WriteString(",")
+			}
+			_, _ = fmt.Fprintf(&body, `{"t":"%s","v":"%.3f"}`, at.Format("2006-01-02 15:04"), Height(at))
+		}
+	}
+	body.WriteString("]}")
+	_, _ = w.Write(body.Bytes())
+}
+
+// Height is the predicted height, in feet, the server gives for a local time
+func Height(at time.Time) float64 {
+	local := time.Date(at.Year(), at.Month(), at.Day(), at.Hour(), at.Minute(), 0, 0, time.UTC)
+	return 4.75 + 4.25*math.Cos(2*math.Pi*float64(local.Sub(time.Unix(0, 0)))/tidePeriod)
+}
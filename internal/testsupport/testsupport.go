@@ -0,0 +1,66 @@
+// Package testsupport wires the tide service end to end for tests: a tide.Service whose
+// NOAA is an httptest server, whose station finder is a fixed list and whose prediction
+// cache is in memory, so handler and resolver tests don't each declare their own mocks.
+package testsupport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/tide"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+// Env is a tide service and the fakes behind it. Tests can change the fakes, e.g. set
+// Finder.FindStationFn, or replace the service's fields, before making requests.
+type Env struct {
+	NOAA    *NOAA
+	Finder  *StationFinder
+	Cache   *MemoryCache
+	Service *tide.Service
+}
+
+// Option changes how New sets up the Env
+type Option func(*Env)
+
+// WithStations replaces the default station list
+func WithStations(stations ...models.Station) Option {
+	return func(e *Env) {
+		e.Finder.Stations = stations
+	}
+}
+
+// AnyStation makes the finder answer for any station ID with Station(id), for tests that
+// don't care which stations exist
+func AnyStation() Option {
+	return func(e *Env) {
+		e.Finder.FindStationFn = func(_ context.Context, stationID string) (*models.Station, error) {
+			station := Station(stationID)
+			return &station, nil
+		}
+	}
+}
+
+// New returns an Env whose finder lists Station("9447130") and Station("9446484"), and
+// whose service has no weather forecaster, sensor observer or geocoder. The NOAA server is
+// closed when the test ends.
+func New(t testing.TB, opts ...Option) *Env {
+	t.Helper()
+	e := &Env{
+		NOAA:   NewNOAA(),
+		Finder: &StationFinder{Stations: []models.Station{Station("9447130"), Station("9446484")}},
+		Cache:  NewMemoryCache(),
+	}
+	t.Cleanup(e.NOAA.Close)
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.Service = &tide.Service{
+		HttpClient:      client.New(client.Options{BaseURL: e.NOAA.URL, Timeout: 5 * time.Second}),
+		StationFinder:   e.Finder,
+		PredictionCache: e.Cache,
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+package testsupport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_ServesTidesThroughTheCache(t *testing.T) {
+	env := New(t)
+	ctx := context.Background()
+	start, end := "2024-06-01T00:00:00", "2024-06-02T23:59:00"
+
+	response, err := env.Service.GetCurrentTideForStation(ctx, "9447130", &start, &end)
+	require.NoError(t, err)
+	assert.Equal(t, "9447130", response.NearestStation)
+	require.NotEmpty(t, response.Predictions)
+	require.NotEmpty(t, response.Extremes)
+	for i, extreme := range response.Extremes {
+		assert.InDelta(t, Height(timeOf(t, extreme.LocalTime)), extreme.Height, 0.01)
+		if i > 0 {
+			assert.NotEqual(t, response.Extremes[i-1].Type, extreme.Type, "highs and lows alternate")
+		}
+	}
+	fetched := env.NOAA.Requests()
+	assert.Positive(t, fetched)
+	days := env.Cache.Len()
+	assert.GreaterOrEqual(t, days, 2)
+	assert.Equal(t, 1, env.Cache.Saves())
+
+	_, err = env.Service.GetCurrentTideForStation(ctx, "9447130", &start, &end)
+	require.NoError(t, err)
+	assert.Equal(t, fetched, env.NOAA.Requests(), "the second lookup is served from the cache")
+	assert.Equal(t, map[string]uint64{"memory_hits": uint64(days), "memory_misses": uint64(days)}, env.Cache.GetCacheStats())
+
+	_, err = env.Service.GetCurrentTideForStation(ctx, "0000000", &start, &end)
+	assert.ErrorIs(t, err, models.ErrStationNotFound)
+}
+
+func TestNOAA_Fail(t *testing.T) {
+	env := New(t, AnyStation())
+	env.NOAA.Fail("Station ID is invalid")
+	start := "2024-06-01T00:00:00"
+
+	_, err := env.Service.GetCurrentTideForStation(context.Background(), "1234567", &start, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Station ID is invalid")
+	assert.Zero(t, env.Cache.Len())
+}
+
+func TestStationFinder(t *testing.T) {
+	ctx := context.Background()
+	near, far := Station("near"), Station("far")
+	far.Latitude += 1
+	finder := &StationFinder{Stations: []models.Station{far, near}}
+
+	station, err := finder.FindStation(ctx, "far")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Station far", station.Name)
+	station.Name = "changed"
+	station, _ = finder.FindStation(ctx, "far")
+	assert.Equal(t, "Test Station far", station.Name, "callers get a copy")
+
+	_, err = finder.FindStation(ctx, "missing")
+	assert.True(t, errors.Is(err, models.ErrStationNotFound))
+
+	stations, err := finder.FindNearestStations(ctx, near.Latitude, near.Longitude, 1)
+	require.NoError(t, err)
+	require.Len(t, stations, 1)
+	assert.Equal(t, "near", stations[0].ID)
+
+	page, err := finder.FindNearestStationsPage(ctx, near.Latitude, near.Longitude, models.StationFilter{}, 1, 5)
+	require.NoError(t, err)
+	require.Len(t, page.Stations, 1)
+	assert.Equal(t, "far", page.Stations[0].ID)
+	assert.InDelta(t, 111, page.Stations[0].Distance, 1)
+
+	finder.FindNearestStationsFn = func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+		return nil, errors.New("unavailable")
+	}
+	_, err = finder.FindNearestStationsPage(ctx, 0, 0, models.StationFilter{}, 0, 5)
+	assert.EqualError(t, err, "unavailable")
+}
+
+// timeOf parses a response's local time as the wall time NOAA gave it
+func timeOf(t *testing.T, localTime string) time.Time {
+	at, err := time.Parse("2006-01-02T15:04:05", localTime)
+	require.NoError(t, err)
+	return at
+}
@@ -0,0 +1,125 @@
+package tide
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// confidenceDays is how many days of accuracy a tide response's confidence is judged from
+const confidenceDays = accuracy.DefaultDays
+
+var _ accuracy.Predictor = (*Service)(nil)
+
+// PredictedLevel returns the station's predicted water level at at, interpolated from its
+// 6-minute predictions. Subordinate stations, which only publish highs and lows, can't be
+// compared with a gauge this closely and are rejected.
+func (s *Service) PredictedLevel(ctx context.Context, stationID string, at time.Time) (float64, error) {
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return 0, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return 0, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+	return s.predictedLevelAt(ctx, localStation, at)
+}
+
+// predictedLevelAt interpolates the station's predicted level at at from its 6-minute
+// predictions
+func (s *Service) predictedLevelAt(ctx context.Context, localStation *models.Station, at time.Time) (float64, error) {
+	if isSubordinate(localStation) {
+		return 0, fmt.Errorf("station %s is subordinate and has no 6-minute predictions", localStation.ID)
+	}
+
+	// The days either side of at's are read near midnight, so it lies between two predictions
+	location := localStation.Location()
+	local := at.In(location)
+	start := startOfDay(local.Add(-time.Hour))
+	end := startOfDay(local.Add(time.Hour)).AddDate(0, 0, 1)
+	records, _, err := s.getPredictionsForDateRange(ctx, localStation, start, end, location)
+	if err != nil {
+		return 0, fmt.Errorf("getting predictions: %w", err)
+	}
+	var predictions []models.TidePrediction
+	for _, record := range records {
+		predictions = append(predictions, record.Predictions...)
+	}
+	if len(predictions) == 0 {
+		return 0, errors.New("no predictions for " + local.Format("2006-01-02"))
+	}
+	return s.interpolatorFor(ctx, predictionsFallback(ctx)).Interpolate(predictions, models.MillisOf(at)), nil
+}
+
+// GetAccuracy returns how closely the station's predictions matched its gauge over the
+// last days UTC days. Stations that aren't tracked have no samples.
+func (s *Service) GetAccuracy(ctx context.Context, stationID string, days int) (*models.AccuracyStats, error) {
+	if err := validate.Between("days", float64(days), 1, accuracy.MaxDays); err != nil {
+		return nil, newParamRangeError(err)
+	}
+	if s.Accuracy == nil {
+		return nil, fmt.Errorf("accuracy tracking is not configured")
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+
+	summary, err := s.Accuracy.Summary(ctx, localStation.ID, days)
+	if err != nil {
+		return nil, fmt.Errorf("summarizing accuracy: %w", err)
+	}
+	stats := &models.AccuracyStats{
+		ResponseType: "accuracy",
+		StationID:    localStation.ID,
+		StationName:  localStation.Name,
+		Days:         days,
+		Samples:      summary.Samples,
+		Confidence:   summary.Confidence(),
+	}
+	if summary.Samples > 0 {
+		stats.MeanAbsoluteError = &summary.MeanAbsoluteError
+		stats.Bias = &summary.Bias
+	}
+	return stats, nil
+}
+
+// predictionConfidence judges a tide response's predictions from its station's recent
+// accuracy, or returns nil when the station isn't tracked or hasn't enough samples. It's
+// extra, so a summary that can't be read is logged and left out.
+func (s *Service) predictionConfidence(ctx context.Context, stationID string) *models.PredictionConfidence {
+	if s.Accuracy == nil || !s.Accuracy.Tracked(stationID) {
+		return nil
+	}
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Cache)
+	defer cancel()
+	summary, err := s.Accuracy.Summary(ctx, stationID, confidenceDays)
+	if err != nil {
+		log.Warn().Err(err).Str("station_id", stationID).Msg("Prediction accuracy unavailable")
+		return nil
+	}
+	level := summary.Confidence()
+	if level == "" {
+		return nil
+	}
+	return &models.PredictionConfidence{
+		Level:             level,
+		MeanAbsoluteError: summary.MeanAbsoluteError,
+		Bias:              summary.Bias,
+		Samples:           summary.Samples,
+		Days:              summary.Days,
+	}
+}
@@ -0,0 +1,136 @@
+package tide
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// risingPredictions returns a record whose 6-minute predictions rise a foot an hour from
+// midnight on date
+func risingPredictions(stationID string, date time.Time) *models.TidePredictionRecord {
+	record := &models.TidePredictionRecord{StationID: stationID, Date: date.Format("2006-01-02")}
+	for minutes := 0; minutes < 24*60; minutes += 6 {
+		at := date.Add(time.Duration(minutes) * time.Minute)
+		record.Predictions = append(record.Predictions, models.TidePrediction{
+			Timestamp: models.MillisOf(at), LocalTime: at.Format("2006-01-02T15:04:05"), Height: float64(minutes) / 60,
+		})
+	}
+	return record
+}
+
+func newAccuracyService(t *testing.T, station *models.Station, reporter *accuracy.Reporter) *Service {
+	t.Helper()
+	return &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				return risingPredictions(stationID, date), nil
+			},
+		},
+		Accuracy: reporter,
+	}
+}
+
+func TestPredictedLevel(t *testing.T) {
+	station := createTestStation(-28800)
+	service := newAccuracyService(t, station, nil)
+
+	at := time.Date(2024, 6, 1, 10, 3, 0, 0, station.Location())
+	level, err := service.PredictedLevel(context.Background(), "TEST001", at)
+	require.NoError(t, err)
+	assert.InDelta(t, 10.05, level, 0.01, "interpolated between the 6-minute predictions")
+
+	station.StationType = models.StationKindSubordinate
+	_, err = service.PredictedLevel(context.Background(), "TEST001", at)
+	assert.ErrorContains(t, err, "subordinate")
+}
+
+func TestGetAccuracy(t *testing.T) {
+	ctx := context.Background()
+	store := accuracy.NewMemoryStore()
+	now := models.MillisOf(time.Now())
+	for i := range accuracy.MinSamples {
+		require.NoError(t, store.Add(ctx, accuracy.Sample{StationID: "TEST001", Timestamp: now.Add(time.Duration(i-accuracy.MinSamples) * time.Minute), Predicted: 4, Observed: 4.3}))
+	}
+	reporter, err := accuracy.NewReporter(store, []string{"TEST001"}, time.Hour)
+	require.NoError(t, err)
+	station := createTestStation(-28800)
+	service := newAccuracyService(t, station, reporter)
+
+	stats, err := service.GetAccuracy(ctx, "TEST001", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "accuracy", stats.ResponseType)
+	assert.Equal(t, "Test Station", stats.StationName)
+	assert.Equal(t, accuracy.MinSamples, stats.Samples)
+	require.NotNil(t, stats.MeanAbsoluteError)
+	assert.InDelta(t, 0.3, *stats.MeanAbsoluteError, 1e-9)
+	assert.InDelta(t, 0.3, *stats.Bias, 1e-9)
+
+	_, err = service.GetAccuracy(ctx, "TEST001", accuracy.MaxDays+1)
+	var rangeErr *InvalidRangeError
+	assert.ErrorAs(t, err, &rangeErr)
+
+	_, err = newAccuracyService(t, station, nil).GetAccuracy(ctx, "TEST001", 7)
+	assert.EqualError(t, err, "accuracy tracking is not configured")
+
+	station.ID = "UNTRACKED"
+	stats, err = service.GetAccuracy(ctx, "UNTRACKED", 7)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Samples)
+	assert.Nil(t, stats.MeanAbsoluteError, "no samples is told apart from no error")
+	assert.Empty(t, stats.Confidence)
+}
+
+func TestPredictionConfidence(t *testing.T) {
+	ctx := context.Background()
+	store := accuracy.NewMemoryStore()
+	now := models.MillisOf(time.Now())
+	for i := range accuracy.MinSamples {
+		require.NoError(t, store.Add(ctx, accuracy.Sample{StationID: "TEST001", Timestamp: now.Add(time.Duration(i-accuracy.MinSamples) * time.Hour), Predicted: 4, Observed: 3.9}))
+		// Repeats of one reading count once
+		require.NoError(t, store.Add(ctx, accuracy.Sample{StationID: "FEW", Timestamp: now, Predicted: 4, Observed: 3.9}))
+	}
+	reporter, err := accuracy.NewReporter(store, []string{"TEST001", "FEW"}, time.Hour)
+	require.NoError(t, err)
+	service := newAccuracyService(t, createTestStation(-28800), reporter)
+
+	confidence := service.predictionConfidence(ctx, "TEST001")
+	require.NotNil(t, confidence)
+	assert.Equal(t, models.ConfidenceHigh, confidence.Level)
+	assert.InDelta(t, 0.1, confidence.MeanAbsoluteError, 1e-9)
+	assert.InDelta(t, -0.1, confidence.Bias, 1e-9)
+	assert.Equal(t, accuracy.MinSamples, confidence.Samples)
+
+	assert.Nil(t, service.predictionConfidence(ctx, "FEW"), "too few samples to judge")
+	assert.Nil(t, service.predictionConfidence(ctx, "UNTRACKED"))
+	assert.Nil(t, newAccuracyService(t, createTestStation(-28800), nil).predictionConfidence(ctx, "TEST001"))
+}
+
+func TestGetCurrentTideForStation_Confidence(t *testing.T) {
+	ctx := context.Background()
+	store := accuracy.NewMemoryStore()
+	now := models.MillisOf(time.Now())
+	for i := range accuracy.MinSamples {
+		require.NoError(t, store.Add(ctx, accuracy.Sample{StationID: "TEST001", Timestamp: now.Add(time.Duration(i-accuracy.MinSamples) * time.Hour), Predicted: 4, Observed: 5}))
+	}
+	reporter, err := accuracy.NewReporter(store, []string{"TEST001"}, time.Hour)
+	require.NoError(t, err)
+	service := newAccuracyService(t, createTestStation(-28800), reporter)
+
+	response, err := service.GetCurrentTideForStation(ctx, "TEST001", nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, response.Confidence)
+	assert.Equal(t, models.ConfidenceLow, response.Confidence.Level)
+}
@@ -0,0 +1,49 @@
+package tide
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/rs/zerolog/log"
+)
+
+// maxAnomalyReadingAge is how old a water level reading can be and still say whether the
+// predictions are trustworthy now
+const maxAnomalyReadingAge = time.Hour
+
+// waterLevelAnomaly compares the station's latest water level reading with its predicted
+// level at the same moment, or returns nil when the station has no gauge, no recent
+// reading or no 6-minute predictions. It's extra, so a failed lookup is logged and left out.
+func (s *Service) waterLevelAnomaly(ctx context.Context, station *models.Station, location *time.Location) *models.WaterLevelAnomaly {
+	if s.Observer == nil || s.AnomalyThreshold <= 0 || !station.HasCapability(models.CapabilityWaterLevel) || isSubordinate(station) {
+		return nil
+	}
+
+	reading, err := s.latestObservation(ctx, station.ID, observation.WaterLevel)
+	if err != nil {
+		log.Warn().Err(err).Str("station_id", station.ID).Msg("Water level reading unavailable")
+		return nil
+	}
+	if time.Since(reading.Timestamp.Time()) > maxAnomalyReadingAge {
+		return nil
+	}
+	predicted, err := s.predictedLevelAt(ctx, station, reading.Timestamp.Time())
+	if err != nil {
+		log.Warn().Err(err).Str("station_id", station.ID).Msg("Predicted water level unavailable")
+		return nil
+	}
+
+	residual := reading.Value - predicted
+	return &models.WaterLevelAnomaly{
+		Detected:       math.Abs(residual) >= s.AnomalyThreshold,
+		Residual:       residual,
+		Threshold:      s.AnomalyThreshold,
+		ObservedLevel:  reading.Value,
+		PredictedLevel: predicted,
+		Timestamp:      reading.Timestamp,
+		LocalTime:      formatLocalTime(reading.Timestamp, location),
+	}
+}
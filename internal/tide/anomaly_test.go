@@ -0,0 +1,84 @@
+package tide
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+)
+
+func TestWaterLevelAnomaly(t *testing.T) {
+	ctx := context.Background()
+	station := createTestStation(-28800)
+	station.Capabilities = []string{models.CapabilityWaterLevel}
+	service := newAccuracyService(t, station, nil)
+	// Flat predictions keep the expected level independent of the time of day
+	service.PredictionCache = &mockStationService2{
+		getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+			record := risingPredictions(stationID, date)
+			for i := range record.Predictions {
+				record.Predictions[i].Height = 4
+			}
+			return record, nil
+		},
+	}
+	service.AnomalyThreshold = 1
+	readAt := models.MillisOf(time.Now().Add(-10 * time.Minute).Truncate(time.Second))
+	level := 5.5
+	var readErr error
+	service.Observer = &mockObserver{latestFn: func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+		if product != observation.WaterLevel {
+			return nil, errors.New("unexpected product " + product.Name)
+		}
+		return &models.Observation{Timestamp: readAt, Value: level, Units: "ft"}, readErr
+	}}
+	location := station.Location()
+
+	anomaly := service.waterLevelAnomaly(ctx, station, location)
+	require.NotNil(t, anomaly)
+	assert.True(t, anomaly.Detected)
+	assert.InDelta(t, 1.5, anomaly.Residual, 1e-9, "observed minus predicted")
+	assert.InDelta(t, 4, anomaly.PredictedLevel, 1e-9)
+	assert.Equal(t, readAt, anomaly.Timestamp)
+	assert.Equal(t, readAt.In(location).Format("2006-01-02T15:04:05"), anomaly.LocalTime)
+
+	level = 3.5
+	anomaly = service.waterLevelAnomaly(ctx, station, location)
+	require.NotNil(t, anomaly)
+	assert.False(t, anomaly.Detected, "within the threshold")
+	assert.InDelta(t, -0.5, anomaly.Residual, 1e-9)
+
+	readAt = readAt.Add(-2 * time.Hour)
+	assert.Nil(t, service.waterLevelAnomaly(ctx, station, location), "stale readings aren't compared")
+
+	readErr = errors.New("No data was found")
+	assert.Nil(t, service.waterLevelAnomaly(ctx, station, location))
+
+	station.Capabilities = nil
+	assert.Nil(t, service.waterLevelAnomaly(ctx, station, location), "stations without a gauge aren't asked")
+}
+
+func TestGetCurrentTideForStation_Anomaly(t *testing.T) {
+	station := createTestStation(-28800)
+	station.Capabilities = []string{models.CapabilityWaterLevel}
+	service := newAccuracyService(t, station, nil)
+	service.Observer = &mockObserver{latestFn: func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+		return &models.Observation{Timestamp: models.MillisOf(time.Now()), Value: 30, Units: "ft"}, nil
+	}}
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001", nil, nil)
+	require.NoError(t, err)
+	assert.Nil(t, response.Anomaly, "a zero threshold turns the check off")
+
+	service.AnomalyThreshold = 1
+	response, err = service.GetCurrentTideForStation(context.Background(), "TEST001", nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, response.Anomaly)
+	assert.True(t, response.Anomaly.Detected, "30ft is above any rising prediction")
+}
@@ -0,0 +1,166 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+const (
+	minCompareStations = 2
+	maxCompareStations = 5
+
+	// DefaultCompareInterval is the spacing, in minutes, of a comparison's timeline when a
+	// request doesn't say
+	DefaultCompareInterval = 6
+	minCompareInterval     = 6
+	maxCompareInterval     = 60
+
+	// maxLagMatch is how far apart two stations' extremes of the same type can be and still
+	// count as the same tide
+	maxLagMatch = 6 * time.Hour
+)
+
+// CompareStations resamples each station's predictions onto a shared timeline every
+// intervalMinutes from startTimeStr to endTimeStr, local times at the first station that
+// default to its current day, and relates each station's extremes to the first one's
+func (s *Service) CompareStations(ctx context.Context, stationIDs []string, startTimeStr, endTimeStr *string, intervalMinutes int) (*models.StationComparison, error) {
+	if len(stationIDs) < minCompareStations || len(stationIDs) > maxCompareStations {
+		return nil, newParamRangeError(&validate.Error{
+			Parameter: "stationIds",
+			Value:     strings.Join(stationIDs, ","),
+			Message:   fmt.Sprintf("must list between %d and %d stations", minCompareStations, maxCompareStations),
+			Allowed:   fmt.Sprintf("%d to %d station IDs", minCompareStations, maxCompareStations),
+		})
+	}
+	if err := validate.Between("interval", float64(intervalMinutes), minCompareInterval, maxCompareInterval); err != nil {
+		return nil, newParamRangeError(err)
+	}
+
+	reference, err := s.findStation(ctx, stationIDs[0])
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if reference == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationIDs[0])
+	}
+	start, end, err := parseLocalRange(startTimeStr, endTimeStr, reference.Location(), requestLocation(ctx, reference.Location()), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(intervalMinutes) * time.Minute
+	var timestamps []models.Millis
+	for t, last := models.MillisOf(start), models.MillisOf(end); t <= last; t = t.Add(interval) {
+		timestamps = append(timestamps, t)
+	}
+
+	comparison := &models.StationComparison{
+		ResponseType:    "comparison",
+		IntervalMinutes: intervalMinutes,
+		Timestamps:      timestamps,
+		Stations:        make([]models.ComparedStation, 0, len(stationIDs)),
+	}
+	var referenceExtremes []models.TideExtreme
+	for i, stationID := range stationIDs {
+		station := reference
+		if i > 0 {
+			if station, err = s.findStation(ctx, stationID); err != nil {
+				return nil, fmt.Errorf("finding station: %w", err)
+			}
+			if station == nil {
+				return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+			}
+		}
+
+		// Each station takes the same instants in its own local time
+		location := station.Location()
+		stationStart := start.In(location).Format("2006-01-02T15:04:05")
+		stationEnd := end.In(location).Format("2006-01-02T15:04:05")
+		response, err := s.GetCurrentTideForStation(ctx, station.ID, &stationStart, &stationEnd)
+		if err != nil {
+			return nil, fmt.Errorf("getting tides for station %s: %w", station.ID, err)
+		}
+
+		compared := models.ComparedStation{
+			ID:      station.ID,
+			Name:    station.Name,
+			Heights: resample(response.Predictions, timestamps),
+		}
+		if i == 0 {
+			referenceExtremes = response.Extremes
+			lag, ratio := 0.0, 1.0
+			compared.LagMinutes, compared.RangeRatio = &lag, &ratio
+		} else {
+			compared.LagMinutes, compared.RangeRatio = relateExtremes(referenceExtremes, response.Extremes)
+		}
+		comparison.Stations = append(comparison.Stations, compared)
+	}
+	return comparison, nil
+}
+
+// resample interpolates predictions at each timestamp, to the millimeter
+func resample(predictions []models.TidePrediction, timestamps []models.Millis) []float64 {
+	heights := make([]float64, len(timestamps))
+	for i, t := range timestamps {
+		heights[i] = math.Round(linearInterpolator{}.Interpolate(predictions, t)*1000) / 1000
+	}
+	return heights
+}
+
+// relateExtremes returns how many minutes other's tides come after reference's, the mean
+// offset from each reference extreme to other's nearest extreme of the same type within
+// maxLagMatch, and the ratio of other's mean range to reference's
+func relateExtremes(reference, other []models.TideExtreme) (lagMinutes, rangeRatio *float64) {
+	var totalLag time.Duration
+	var matches int
+	for _, r := range reference {
+		var bestLag time.Duration
+		found := false
+		for _, o := range other {
+			lag := o.Timestamp.Sub(r.Timestamp)
+			if o.Type != r.Type || lag.Abs() > maxLagMatch {
+				continue
+			}
+			if !found || lag.Abs() < bestLag.Abs() {
+				bestLag, found = lag, true
+			}
+		}
+		if found {
+			totalLag += bestLag
+			matches++
+		}
+	}
+	if matches > 0 {
+		lag := math.Round(totalLag.Minutes()/float64(matches)*10) / 10
+		lagMinutes = &lag
+	}
+
+	if referenceRange, otherRange := meanRange(reference), meanRange(other); referenceRange > 0 && otherRange > 0 {
+		ratio := math.Round(otherRange/referenceRange*1000) / 1000
+		rangeRatio = &ratio
+	}
+	return lagMinutes, rangeRatio
+}
+
+// meanRange is the mean rise or fall between consecutive highs and lows
+func meanRange(extremes []models.TideExtreme) float64 {
+	var total float64
+	var n int
+	for i := 1; i < len(extremes); i++ {
+		if extremes[i].Type == extremes[i-1].Type {
+			continue
+		}
+		total += math.Abs(extremes[i].Height - extremes[i-1].Height)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
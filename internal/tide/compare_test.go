@@ -0,0 +1,143 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// semidiurnalExtremes alternates lows and highs every 6 hours from start, offset by lag
+func semidiurnalExtremes(start time.Time, lag time.Duration, low, high float64, count int) []models.TideExtreme {
+	extremes := make([]models.TideExtreme, count)
+	for i := range extremes {
+		t := start.Add(time.Duration(i)*6*time.Hour + lag)
+		extremes[i] = models.TideExtreme{Type: models.TideTypeLow, Timestamp: models.MillisOf(t), Height: low}
+		if i%2 == 1 {
+			extremes[i].Type, extremes[i].Height = models.TideTypeHigh, high
+		}
+	}
+	return extremes
+}
+
+// newExtremesService serves each station's extremes, and the same points as its
+// predictions, from the cache on the station's local days. Other stations miss the cache.
+func newExtremesService(stations map[string]*models.Station, extremes map[string][]models.TideExtreme) *Service {
+	return &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				if station, ok := stations[stationID]; ok {
+					return station, nil
+				}
+				return nil, fmt.Errorf("station not found: %s", stationID)
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				station, ok := stations[stationID]
+				if !ok {
+					return nil, nil
+				}
+				location := station.Location()
+				record := &models.TidePredictionRecord{StationID: stationID, Date: date.Format("2006-01-02")}
+				for _, e := range extremes[stationID] {
+					local := e.Timestamp.In(location)
+					if local.Format("2006-01-02") != record.Date {
+						continue
+					}
+					e.LocalTime = local.Format("2006-01-02T15:04:05")
+					record.Extremes = append(record.Extremes, e)
+					record.Predictions = append(record.Predictions, models.TidePrediction{Timestamp: e.Timestamp, LocalTime: e.LocalTime, Height: e.Height})
+				}
+				return record, nil
+			},
+		},
+	}
+}
+
+func TestCompareStations(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Downstream's tides come half an hour later and rise half as far
+	extremes := map[string][]models.TideExtreme{
+		"UP":   semidiurnalExtremes(start, 0, 0, 10, 13),
+		"DOWN": semidiurnalExtremes(start, 30*time.Minute, 2.5, 7.5, 13),
+	}
+	stations := map[string]*models.Station{
+		"UP":   createTestStation(0),
+		"DOWN": createTestStation(3600),
+	}
+	stations["UP"].ID, stations["DOWN"].ID = "UP", "DOWN"
+
+	service := newExtremesService(stations, extremes)
+	ctx := context.Background()
+	from, to := "2024-01-02T00:00:00", "2024-01-02T23:59:59"
+
+	comparison, err := service.CompareStations(ctx, []string{"UP", "DOWN"}, &from, &to, 60)
+	require.NoError(t, err)
+	assert.Equal(t, "comparison", comparison.ResponseType)
+	assert.Equal(t, 60, comparison.IntervalMinutes)
+	require.Len(t, comparison.Timestamps, 24)
+	assert.Equal(t, models.MillisOf(start.AddDate(0, 0, 1)), comparison.Timestamps[0])
+
+	require.Len(t, comparison.Stations, 2)
+	up, down := comparison.Stations[0], comparison.Stations[1]
+	assert.Equal(t, "UP", up.ID)
+	require.Len(t, up.Heights, 24)
+	assert.Equal(t, 0.0, up.Heights[0])
+	assert.Equal(t, 5.0, up.Heights[3])
+	assert.Equal(t, 10.0, up.Heights[6])
+	assert.Equal(t, 0.0, *up.LagMinutes)
+	assert.Equal(t, 1.0, *up.RangeRatio)
+
+	// DOWN is an hour ahead of UP's clock, but its series uses the same instants
+	assert.Equal(t, "DOWN", down.ID)
+	require.Len(t, down.Heights, 24)
+	assert.Equal(t, 7.083, down.Heights[6])
+	require.NotNil(t, down.LagMinutes)
+	assert.Equal(t, 30.0, *down.LagMinutes)
+	require.NotNil(t, down.RangeRatio)
+	assert.Equal(t, 0.5, *down.RangeRatio)
+
+	for name, call := range map[string]func() error{
+		"one station": func() error {
+			_, err := service.CompareStations(ctx, []string{"UP"}, &from, &to, 60)
+			return err
+		},
+		"interval too short": func() error {
+			_, err := service.CompareStations(ctx, []string{"UP", "DOWN"}, &from, &to, 1)
+			return err
+		},
+	} {
+		var rangeErr *InvalidRangeError
+		assert.ErrorAs(t, call(), &rangeErr, name)
+	}
+
+	_, err = service.CompareStations(ctx, []string{"UP", "NOPE"}, &from, &to, 60)
+	assert.ErrorContains(t, err, "station not found: NOPE")
+}
+
+func TestRelateExtremes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reference := semidiurnalExtremes(start, 0, 0, 8, 6)
+
+	lag, ratio := relateExtremes(reference, semidiurnalExtremes(start, -45*time.Minute, 1, 13, 6))
+	require.NotNil(t, lag)
+	assert.Equal(t, -45.0, *lag, "earlier tides lead")
+	require.NotNil(t, ratio)
+	assert.Equal(t, 1.5, *ratio)
+
+	// Extremes too far apart to be the same tide aren't matched
+	lag, ratio = relateExtremes(reference, semidiurnalExtremes(start.AddDate(0, 0, 3), 0, 0, 8, 6))
+	assert.Nil(t, lag)
+	assert.Equal(t, 1.0, *ratio)
+
+	lag, ratio = relateExtremes(reference, nil)
+	assert.Nil(t, lag)
+	assert.Nil(t, ratio)
+}
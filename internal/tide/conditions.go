@@ -0,0 +1,108 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/rs/zerolog/log"
+)
+
+// waterProducts are the readings tide responses carry as conditions
+var waterProducts = []observation.Product{observation.WaterTemperature, observation.Conductivity}
+
+// waterConditions returns the latest readings of the station's water sensors, or nil when
+// it has none. Readings are extra, so one that can't be fetched is logged and left out,
+// and named in the returned warning.
+func (s *Service) waterConditions(ctx context.Context, station *models.Station, location *time.Location) (*models.WaterConditions, *models.ResponseWarning) {
+	if s.Observer == nil || (!station.HasCapability(models.CapabilityWaterTemperature) && !station.HasCapability(models.CapabilityConductivity)) {
+		return nil, nil
+	}
+
+	conditions := &models.WaterConditions{}
+	var missing []string
+	for _, product := range waterProducts {
+		if !station.HasCapability(product.Capability) {
+			continue
+		}
+		reading, err := s.latestObservation(ctx, station.ID, product)
+		if err != nil {
+			log.Warn().Err(err).Str("station_id", station.ID).Str("product", product.Name).Msg("Sensor reading unavailable")
+			missing = append(missing, product.Name)
+			continue
+		}
+		// The reading may be shared through a cache, so it's copied before being localized
+		local := *reading
+		local.LocalTime = formatLocalTime(local.Timestamp, location)
+		switch product {
+		case observation.WaterTemperature:
+			conditions.WaterTemperature = &local
+		case observation.Conductivity:
+			conditions.Conductivity = &local
+		}
+	}
+	if len(missing) > 0 {
+		return conditions, &models.ResponseWarning{
+			Code:    models.WarningConditionsUnavailable,
+			Message: "readings are unavailable for " + strings.Join(missing, ", "),
+		}
+	}
+	return conditions, nil
+}
+
+// GetLatestObservation returns a station's latest reading of the named product. Products
+// the station has no sensor for are rejected with an UnsupportedProductError rather than
+// asked of NOAA.
+func (s *Service) GetLatestObservation(ctx context.Context, stationID, productName string) (*models.ObservationResponse, error) {
+	product, ok := observation.ProductByName(productName)
+	if !ok {
+		return nil, &UnsupportedProductError{StationID: stationID, Product: productName}
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
+	station, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if station == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+	if !station.HasCapability(product.Capability) && !models.IsVirtualStationID(station.ID) {
+		// The station list only knows about water sensors; the station's own list has the rest
+		models.AddSensorCapabilities(ctx, s.StationFinder, station)
+	}
+	if !station.HasCapability(product.Capability) {
+		return nil, &UnsupportedProductError{StationID: station.ID, Product: product.Name, Supported: observation.Names(observation.ProductsFor(station))}
+	}
+	if s.Observer == nil {
+		return nil, fmt.Errorf("sensor readings are not configured")
+	}
+
+	reading, err := s.latestObservation(ctx, station.ID, product)
+	if err != nil {
+		return nil, NewNoaaAPIError("error fetching "+product.Name, err)
+	}
+	// Sensor readings only come from NOAA
+	models.RecordSource(ctx, models.SourceNOAA)
+	models.RecordUnits(ctx, product.Units, product.Datum)
+	local := *reading
+	local.LocalTime = formatLocalTime(local.Timestamp, station.Location())
+	return &models.ObservationResponse{
+		ResponseType: "observation",
+		StationID:    station.ID,
+		StationName:  station.Name,
+		Product:      product.Name,
+		Observation:  local,
+	}, nil
+}
+
+func (s *Service) latestObservation(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Upstream)
+	defer cancel()
+	return s.Observer.Latest(ctx, stationID, product)
+}
@@ -0,0 +1,146 @@
+package tide
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+)
+
+type mockObserver struct {
+	latestFn func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error)
+}
+
+func (m *mockObserver) Latest(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+	return m.latestFn(ctx, stationID, product)
+}
+
+func TestGetCurrentTideForStation_Conditions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	reading := models.MillisOf(time.Date(2024, 1, 1, 12, 6, 0, 0, time.UTC))
+	station := createTestStation(3600)
+	service := newExtremesService(map[string]*models.Station{"TEST001": station}, map[string][]models.TideExtreme{
+		"TEST001": semidiurnalExtremes(start, 0, 0, 10, 13),
+	})
+	var requested []string
+	service.Observer = &mockObserver{latestFn: func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+		requested = append(requested, product.Name)
+		if product == observation.Conductivity {
+			return nil, errors.New("fetching conductivity: No data was found")
+		}
+		return &models.Observation{Timestamp: reading, Value: 48.4, Units: product.Units}, nil
+	}}
+	from, to := "2024-01-01T00:00:00", "2024-01-01T23:59:59"
+	ctx := context.Background()
+
+	// Stations without water sensors aren't asked for readings
+	response, err := service.GetCurrentTideForStation(ctx, "TEST001", &from, &to)
+	require.NoError(t, err)
+	assert.Nil(t, response.Conditions)
+	assert.Empty(t, requested)
+	assert.Empty(t, response.Warnings)
+
+	station.Capabilities = []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature, models.CapabilityConductivity}
+	response, err = service.GetCurrentTideForStation(ctx, "TEST001", &from, &to)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"water_temperature", "conductivity"}, requested)
+
+	// The failed conductivity reading is left out without failing the tides
+	require.NotNil(t, response.Conditions)
+	assert.Nil(t, response.Conditions.Conductivity)
+	assert.Equal(t, &models.Observation{
+		Timestamp: reading,
+		LocalTime: "2024-01-01T13:06:00",
+		Value:     48.4,
+		Units:     "degF",
+	}, response.Conditions.WaterTemperature)
+	assert.Equal(t, []models.ResponseWarning{{
+		Code:    models.WarningConditionsUnavailable,
+		Message: "readings are unavailable for conductivity",
+	}}, response.Warnings)
+}
+
+func TestGetLatestObservation(t *testing.T) {
+	station := createTestStation(0)
+	station.Capabilities = []string{models.CapabilityWaterLevel, models.CapabilityAirPressure}
+	service := newExtremesService(map[string]*models.Station{"TEST001": station}, nil)
+	var requested []string
+	service.Observer = &mockObserver{latestFn: func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+		requested = append(requested, product.Name)
+		return &models.Observation{Timestamp: 1704110760000, Value: 1012.4, Units: product.Units}, nil
+	}}
+	ctx := context.Background()
+
+	got, err := service.GetLatestObservation(ctx, "TEST001", "AIR_PRESSURE")
+	require.NoError(t, err)
+	assert.Equal(t, &models.ObservationResponse{
+		ResponseType: "observation",
+		StationID:    "TEST001",
+		StationName:  "Test Station",
+		Product:      "air_pressure",
+		Observation:  models.Observation{Timestamp: 1704110760000, LocalTime: "2024-01-01T12:06:00", Value: 1012.4, Units: "mb"},
+	}, got)
+
+	// Products the station has no sensor for aren't asked of NOAA
+	var productErr *UnsupportedProductError
+	_, err = service.GetLatestObservation(ctx, "TEST001", "water_temperature")
+	require.ErrorAs(t, err, &productErr)
+	assert.EqualError(t, err, "station TEST001 does not measure water_temperature; it measures air_pressure")
+
+	_, err = service.GetLatestObservation(ctx, "TEST001", "salinity")
+	require.ErrorAs(t, err, &productErr)
+	assert.Equal(t, []string{"air_pressure"}, requested)
+
+	service.Observer = &mockObserver{latestFn: func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+		return nil, errors.New("fetching air_pressure: No data was found")
+	}}
+	var noaaErr *NoaaAPIError
+	_, err = service.GetLatestObservation(ctx, "TEST001", "air_pressure")
+	assert.ErrorAs(t, err, &noaaErr)
+}
+
+// sensorListingFinder adds a sensor list to a station finder, counting the lookups
+type sensorListingFinder struct {
+	models.StationFinder
+	capabilities []string
+	lookups      int
+}
+
+func (f *sensorListingFinder) SensorCapabilities(ctx context.Context, stationID string) []string {
+	f.lookups++
+	return f.capabilities
+}
+
+func TestGetLatestObservation_ListsSensorsOnlyWhenNeeded(t *testing.T) {
+	station := createTestStation(0)
+	station.Capabilities = []string{models.CapabilityWaterLevel, models.CapabilityWaterTemperature}
+	service := newExtremesService(map[string]*models.Station{"TEST001": station}, nil)
+	finder := &sensorListingFinder{StationFinder: service.StationFinder, capabilities: []string{models.CapabilityAirPressure}}
+	service.StationFinder = finder
+	service.Observer = &mockObserver{latestFn: func(ctx context.Context, stationID string, product observation.Product) (*models.Observation, error) {
+		return &models.Observation{Timestamp: 1704110760000, Value: 1, Units: product.Units}, nil
+	}}
+	ctx := context.Background()
+
+	// The station list already says it measures water temperature
+	_, err := service.GetLatestObservation(ctx, "TEST001", "water_temperature")
+	require.NoError(t, err)
+	assert.Zero(t, finder.lookups)
+
+	// Air pressure is only in the station's own sensor list
+	got, err := service.GetLatestObservation(ctx, "TEST001", "air_pressure")
+	require.NoError(t, err)
+	assert.Equal(t, "air_pressure", got.Product)
+	assert.Equal(t, 1, finder.lookups)
+
+	var productErr *UnsupportedProductError
+	_, err = service.GetLatestObservation(ctx, "TEST001", "conductivity")
+	require.ErrorAs(t, err, &productErr)
+	assert.Equal(t, []string{"water_temperature", "air_pressure"}, productErr.Supported)
+}
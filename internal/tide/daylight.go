@@ -0,0 +1,71 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/astro"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// maxDaylightBelow bounds the below height of GetDaylightLows, in feet either side of MLLW;
+// no tide comes near it
+const maxDaylightBelow = 100
+
+// GetDaylightLows returns the station's low tides between sunrise and sunset at the station
+// for days days from startDate (default today), keeping only those under below feet when
+// it's given. The days are read like GetDailyExtremes.
+func (s *Service) GetDaylightLows(ctx context.Context, stationID string, below *float64, startDate *string, days int) (*models.DaylightLows, error) {
+	if below != nil {
+		if err := validate.Between("below", *below, -maxDaylightBelow, maxDaylightBelow); err != nil {
+			return nil, newParamRangeError(err)
+		}
+	}
+
+	summary, err := s.GetDailyExtremes(ctx, stationID, startDate, days)
+	if err != nil {
+		return nil, err
+	}
+	// Already found for the summary, so this is answered from the request's lookups
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+
+	lows := &models.DaylightLows{
+		ResponseType: "daylightLows",
+		StationID:    summary.StationID,
+		StationName:  summary.StationName,
+		TimeZone:     summary.TimeZone,
+		Below:        below,
+		Days:         make([]models.DaylightDay, 0, len(summary.Days)),
+	}
+	for _, day := range summary.Days {
+		date, err := time.Parse("2006-01-02", day.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parsing day %s: %w", day.Date, err)
+		}
+		daylight := astro.DaylightOn(date.Year(), date.Month(), date.Day(), localStation.Latitude, localStation.Longitude)
+		lowsDay := models.DaylightDay{
+			Date:            day.Date,
+			DaylightMinutes: int(daylight.Duration().Round(time.Minute).Minutes()),
+			Lows:            []models.CompactExtreme{},
+		}
+		if !daylight.Sunrise.IsZero() {
+			sunrise, sunset := models.MillisOf(daylight.Sunrise), models.MillisOf(daylight.Sunset)
+			lowsDay.Sunrise, lowsDay.Sunset = &sunrise, &sunset
+		}
+		for _, e := range day.Extremes {
+			if e.Type == models.TideTypeLow && daylight.Contains(e.Timestamp.Time()) && (below == nil || e.Height < *below) {
+				lowsDay.Lows = append(lowsDay.Lows, e)
+			}
+		}
+		lows.Days = append(lows.Days, lowsDay)
+	}
+	return lows, nil
+}
@@ -0,0 +1,57 @@
+package tide
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDaylightLows(t *testing.T) {
+	station := createTestStation(-28800)
+	station.TimeZone = "America/Los_Angeles"
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				return sixHourlyExtremes(stationID, date), nil
+			},
+		},
+	}
+
+	// Seattle's December sun is up from about 07:55 to 16:20, so only the 09:00 low is in daylight
+	startDate := "2024-12-10"
+	lows, err := service.GetDaylightLows(context.Background(), "TEST001", nil, &startDate, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "daylightLows", lows.ResponseType)
+	assert.Nil(t, lows.Below)
+	require.Len(t, lows.Days, 2)
+	for _, day := range lows.Days {
+		require.NotNil(t, day.Sunrise)
+		require.NotNil(t, day.Sunset)
+		assert.InDelta(t, 8*60+25, day.DaylightMinutes, 10)
+		require.Len(t, day.Lows, 1, day.Date)
+		assert.Equal(t, models.TideTypeLow, day.Lows[0].Type)
+		assert.Equal(t, "09:00", day.Lows[0].Time)
+	}
+
+	below := 0.5
+	lows, err = service.GetDaylightLows(context.Background(), "TEST001", &below, &startDate, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &below, lows.Below)
+	assert.Empty(t, lows.Days[0].Lows, "the lows are at 1ft")
+
+	below = 101
+	_, err = service.GetDaylightLows(context.Background(), "TEST001", &below, &startDate, 1)
+	var rangeErr *InvalidRangeError
+	assert.ErrorAs(t, err, &rangeErr)
+}
@@ -0,0 +1,57 @@
+package tide
+
+import (
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Bounds on how many points a downsampled series can be asked for
+const (
+	MinDownsamplePoints = 10
+	MaxDownsamplePoints = 5000
+)
+
+// Downsample reduces predictions to at most points for charting. The first and last
+// predictions are kept, and the rest are split into equal buckets of which only the lowest
+// and highest survive, so highs and lows aren't shaved off the way striding would. Series
+// already within points are returned as is.
+func Downsample(predictions []models.TidePrediction, points int) ([]models.TidePrediction, error) {
+	if err := validate.Between("points", float64(points), MinDownsamplePoints, MaxDownsamplePoints); err != nil {
+		return nil, newParamRangeError(err)
+	}
+	if len(predictions) <= points {
+		return predictions, nil
+	}
+	return downsample(predictions, points), nil
+}
+
+// downsample is Downsample for any number of points above two, for series known to have
+// more than that
+func downsample(predictions []models.TidePrediction, points int) []models.TidePrediction {
+
+	interior := predictions[1 : len(predictions)-1]
+	buckets := (points - 2) / 2
+	result := make([]models.TidePrediction, 0, points)
+	result = append(result, predictions[0])
+	for b := 0; b < buckets; b++ {
+		bucket := interior[b*len(interior)/buckets : (b+1)*len(interior)/buckets]
+		lo, hi := 0, 0
+		for i, p := range bucket {
+			if p.Height < bucket[lo].Height {
+				lo = i
+			}
+			if p.Height > bucket[hi].Height {
+				hi = i
+			}
+		}
+		switch {
+		case lo < hi:
+			result = append(result, bucket[lo], bucket[hi])
+		case hi < lo:
+			result = append(result, bucket[hi], bucket[lo])
+		default:
+			result = append(result, bucket[lo])
+		}
+	}
+	return append(result, predictions[len(predictions)-1])
+}
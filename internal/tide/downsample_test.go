@@ -0,0 +1,51 @@
+package tide
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownsample(t *testing.T) {
+	// A week of 6-minute predictions with two tides a day
+	week := make([]models.TidePrediction, 7*240)
+	highest, lowest := 0, 0
+	for i := range week {
+		week[i] = models.TidePrediction{
+			Timestamp: models.Millis(0).Add(time.Duration(i) * predictionInterval),
+			Height:    5 + 5*math.Sin(float64(i)*2*math.Pi/124) + 0.001*float64(i%7),
+		}
+		if week[i].Height > week[highest].Height {
+			highest = i
+		}
+		if week[i].Height < week[lowest].Height {
+			lowest = i
+		}
+	}
+
+	chart, err := Downsample(week, 300)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(chart), 300)
+	assert.Greater(t, len(chart), 250)
+	assert.Equal(t, week[0], chart[0])
+	assert.Equal(t, week[len(week)-1], chart[len(chart)-1])
+	assert.Contains(t, chart, week[highest])
+	assert.Contains(t, chart, week[lowest])
+	for i := 1; i < len(chart); i++ {
+		assert.Less(t, chart[i-1].Timestamp, chart[i].Timestamp)
+	}
+
+	short, err := Downsample(week[:50], 300)
+	require.NoError(t, err)
+	assert.Equal(t, week[:50], short)
+
+	for _, points := range []int{MinDownsamplePoints - 1, MaxDownsamplePoints + 1} {
+		_, err := Downsample(week, points)
+		var rangeErr *InvalidRangeError
+		assert.ErrorAs(t, err, &rangeErr)
+	}
+}
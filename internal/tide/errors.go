@@ -1,6 +1,11 @@
 package tide
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
 
 // NoaaAPIError represents an error from the NOAA API
 type NoaaAPIError struct {
@@ -30,14 +35,74 @@ func NewNoaaAPIError(message string, err error) *NoaaAPIError {
 // Error when user requests data for too much data
 type InvalidRangeError struct {
 	Message string
+	// TooLarge is set when the range is well formed but longer than allowed
+	TooLarge bool
+	// Err is the validation error for the parameter that set the range, if one did
+	Err error
 }
 
 func (e *InvalidRangeError) Error() string {
 	return e.Message
 }
 
+func (e *InvalidRangeError) Unwrap() error {
+	return e.Err
+}
+
 func NewInvalidRangeError(message string) *InvalidRangeError {
 	return &InvalidRangeError{
 		Message: message,
 	}
 }
+
+// newParamRangeError creates an InvalidRangeError for a parameter that failed validation
+func newParamRangeError(err error) *InvalidRangeError {
+	return &InvalidRangeError{
+		Message: err.Error(),
+		Err:     err,
+	}
+}
+
+// NewRangeTooLargeError creates an InvalidRangeError for a range longer than allowed
+func NewRangeTooLargeError(message string) *InvalidRangeError {
+	return &InvalidRangeError{
+		Message:  message,
+		TooLarge: true,
+	}
+}
+
+// NoNearbyStationError is returned for coordinate lookups whose nearest station is
+// farther away than the service allows
+type NoNearbyStationError struct {
+	Latitude      float64
+	Longitude     float64
+	Station       models.Station // the nearest station, with Distance set
+	MaxDistanceKm float64
+	// PlaceName describes the requested point, e.g. "40 km W of Astoria, OR"; empty when unknown
+	PlaceName string
+}
+
+func (e *NoNearbyStationError) Error() string {
+	place := e.PlaceName
+	if place == "" {
+		place = fmt.Sprintf("%.4f, %.4f", e.Latitude, e.Longitude)
+	}
+	return fmt.Sprintf("no tide station within %.0f km of %s: the nearest station is %s (%s), %.0f km away",
+		e.MaxDistanceKm, place, e.Station.Name, e.Station.ID, e.Station.Distance)
+}
+
+// UnsupportedProductError is returned when a station is asked for a reading it has no
+// sensor for, or for a product that doesn't exist
+type UnsupportedProductError struct {
+	StationID string
+	Product   string
+	// Supported lists the products the station can be asked for
+	Supported []string
+}
+
+func (e *UnsupportedProductError) Error() string {
+	if len(e.Supported) == 0 {
+		return fmt.Sprintf("station %s does not measure %s", e.StationID, e.Product)
+	}
+	return fmt.Sprintf("station %s does not measure %s; it measures %s", e.StationID, e.Product, strings.Join(e.Supported, ", "))
+}
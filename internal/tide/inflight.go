@@ -0,0 +1,85 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// stationLocks lets one request at a time fetch a station's predictions from NOAA, so a
+// burst of requests for the same station makes one upstream fetch rather than one each.
+// The zero value is ready to use.
+type stationLocks struct {
+	mu    sync.Mutex
+	locks map[string]*stationLock
+}
+
+// stationLock is one station's lock. It's removed once nothing holds or waits for it.
+type stationLock struct {
+	held  chan struct{}
+	users int
+	// fetched holds the complete records fetched while the lock was held, for requests
+	// that waited: the write-behind queue may not have cached them yet
+	fetched map[string]*models.TidePredictionRecord
+}
+
+// lock waits until no other request is fetching stationID or ctx is done
+func (l *stationLocks) lock(ctx context.Context, stationID string) (*stationLock, error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*stationLock)
+	}
+	lock, ok := l.locks[stationID]
+	if !ok {
+		lock = &stationLock{held: make(chan struct{}, 1), fetched: make(map[string]*models.TidePredictionRecord)}
+		l.locks[stationID] = lock
+	}
+	lock.users++
+	l.mu.Unlock()
+
+	select {
+	case lock.held <- struct{}{}:
+		return lock, nil
+	case <-ctx.Done():
+		l.release(stationID, lock)
+		return nil, fmt.Errorf("waiting for another fetch of station %s: %w", stationID, ctx.Err())
+	}
+}
+
+// unlock releases a lock returned by lock
+func (l *stationLocks) unlock(stationID string, lock *stationLock) {
+	<-lock.held
+	l.release(stationID, lock)
+}
+
+func (l *stationLocks) release(stationID string, lock *stationLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock.users--
+	if lock.users == 0 {
+		delete(l.locks, stationID)
+	}
+}
+
+// take splits dates into the records an earlier holder fetched and the dates still
+// missing. Only the holder may call it.
+func (lock *stationLock) take(dates []time.Time) (found []*models.TidePredictionRecord, missing []time.Time) {
+	for _, date := range dates {
+		if record, ok := lock.fetched[date.Format("2006-01-02")]; ok {
+			found = append(found, record)
+		} else {
+			missing = append(missing, date)
+		}
+	}
+	return found, missing
+}
+
+// keep shares records with the requests waiting for the lock. Only the holder may call it.
+func (lock *stationLock) keep(records []*models.TidePredictionRecord) {
+	for _, record := range records {
+		lock.fetched[record.Date] = record
+	}
+}
@@ -0,0 +1,107 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStationLocks(t *testing.T) {
+	var locks stationLocks
+	ctx := context.Background()
+
+	first, err := locks.lock(ctx, "9447130")
+	require.NoError(t, err)
+
+	// Other stations aren't held up
+	other, err := locks.lock(ctx, "9446484")
+	require.NoError(t, err)
+	locks.unlock("9446484", other)
+
+	// The same station waits until the deadline
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = locks.lock(waitCtx, "9447130")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Records kept by one holder are taken by the next
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	first.keep([]*models.TidePredictionRecord{{StationID: "9447130", Date: "2024-01-02"}})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		second, err := locks.lock(ctx, "9447130")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer locks.unlock("9447130", second)
+		found, missing := second.take([]time.Time{day, day.AddDate(0, 0, 1)})
+		assert.Len(t, found, 1)
+		assert.Equal(t, []time.Time{day.AddDate(0, 0, 1)}, missing)
+	}()
+	require.Eventually(t, func() bool {
+		locks.mu.Lock()
+		defer locks.mu.Unlock()
+		return first.users == 2
+	}, time.Second, time.Millisecond)
+	locks.unlock("9447130", first)
+	<-done
+
+	// Nothing is left behind once every holder is gone
+	assert.Empty(t, locks.locks)
+}
+
+func TestGetCurrentTideForStation_OneFetchPerStation(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		_, _ = fmt.Fprint(w, `{"predictions":[
+			{"t":"2024-01-01 17:48","v":"8.4","type":"H"},
+			{"t":"2024-01-02 00:06","v":"0.4","type":"L"},
+			{"t":"2024-01-02 06:00","v":"9.3","type":"H"},
+			{"t":"2024-01-02 12:18","v":"1.0","type":"L"},
+			{"t":"2024-01-02 18:36","v":"8.7","type":"H"},
+			{"t":"2024-01-03 00:54","v":"0.2","type":"L"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	station := &models.Station{ID: "SUB001", Name: "Subordinate Station", StationType: models.StationKindSubordinate}
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		// Nothing is ever cached, as when the write-behind queue hasn't caught up
+		PredictionCache: &mockStationService2{},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := service.GetCurrentTideForStation(context.Background(), "SUB001",
+				stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T23:59:00"))
+			if assert.NoError(t, err) {
+				assert.Len(t, response.Extremes, 4)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), fetches.Load())
+}
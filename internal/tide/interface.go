@@ -6,13 +6,9 @@ import (
 	"time"
 )
 
-type TideService interface {
-	GetCurrentTide(ctx context.Context, lat, lon float64, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error)
-	GetCurrentTideForStation(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error)
-}
-
 type CacheProvider interface {
 	GetPredictions(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error)
+	GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error)
 	SavePredictions(ctx context.Context, record models.TidePredictionRecord) error
 	SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error
 	GetCacheStats() map[string]uint64
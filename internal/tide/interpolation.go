@@ -0,0 +1,172 @@
+package tide
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// InterpolationMethod names a strategy for estimating water levels between known points
+type InterpolationMethod string
+
+const (
+	InterpolationLinear   InterpolationMethod = "linear"
+	InterpolationSpline   InterpolationMethod = "spline"
+	InterpolationHarmonic InterpolationMethod = "harmonic"
+)
+
+// Interpolator estimates the water level at a timestamp from points sorted by timestamp
+type Interpolator interface {
+	Method() InterpolationMethod
+	Interpolate(points []models.TidePrediction, timestamp models.Millis) float64
+}
+
+// NewInterpolator returns the interpolator for the named method
+func NewInterpolator(method string) (Interpolator, error) {
+	switch InterpolationMethod(strings.ToLower(strings.TrimSpace(method))) {
+	case InterpolationLinear:
+		return linearInterpolator{}, nil
+	case InterpolationSpline:
+		return splineInterpolator{}, nil
+	case InterpolationHarmonic:
+		return harmonicInterpolator{}, nil
+	default:
+		return nil, validate.OneOfFold("interpolation", method,
+			string(InterpolationLinear), string(InterpolationSpline), string(InterpolationHarmonic))
+	}
+}
+
+type interpolationKey struct{}
+
+// WithInterpolator returns a context that overrides the service interpolator for a single request
+func WithInterpolator(ctx context.Context, interpolator Interpolator) context.Context {
+	return context.WithValue(ctx, interpolationKey{}, interpolator)
+}
+
+// interpolatorFor picks the request override, then the service setting, then the given fallback
+func (s *Service) interpolatorFor(ctx context.Context, fallback Interpolator) Interpolator {
+	if interpolator, ok := ctx.Value(interpolationKey{}).(Interpolator); ok && interpolator != nil {
+		return interpolator
+	}
+	if s.Interpolator != nil {
+		return s.Interpolator
+	}
+	return fallback
+}
+
+// extremesFallback is how predictions are synthesized from extremes when neither the
+// request nor the service picks an interpolator
+func extremesFallback(ctx context.Context) Interpolator {
+	if feature.Enabled(ctx, feature.HarmonicFallback) {
+		return harmonicInterpolator{}
+	}
+	return splineInterpolator{}
+}
+
+// predictionsFallback is how the current level is read from 6-minute predictions when
+// neither the request nor the service picks an interpolator
+func predictionsFallback(ctx context.Context) Interpolator {
+	if feature.Enabled(ctx, feature.SplinePredictions) {
+		return splineInterpolator{}
+	}
+	return linearInterpolator{}
+}
+
+// linearInterpolator draws straight lines between neighboring points
+type linearInterpolator struct{}
+
+func (linearInterpolator) Method() InterpolationMethod { return InterpolationLinear }
+
+func (linearInterpolator) Interpolate(points []models.TidePrediction, timestamp models.Millis) float64 {
+	p1, p2, ok := bracket(points, timestamp)
+	if !ok {
+		return p1.Height
+	}
+
+	ratio := float64(timestamp-p1.Timestamp) / float64(p2.Timestamp-p1.Timestamp)
+	return p1.Height + (p2.Height-p1.Height)*ratio
+}
+
+// splineInterpolator uses cubic Hermite segments with tangents approximated from neighboring points
+type splineInterpolator struct{}
+
+func (splineInterpolator) Method() InterpolationMethod { return InterpolationSpline }
+
+func (splineInterpolator) Interpolate(points []models.TidePrediction, timestamp models.Millis) float64 {
+	e1, e2, ok := bracket(points, timestamp)
+	if !ok {
+		return e1.Height
+	}
+
+	idx := findNearestIndex(points, timestamp)
+	t := float64(timestamp-e1.Timestamp) / float64(e2.Timestamp-e1.Timestamp)
+
+	// Hermite basis functions
+	h00 := 2*math.Pow(t, 3) - 3*math.Pow(t, 2) + 1
+	h10 := math.Pow(t, 3) - 2*math.Pow(t, 2) + t
+	h01 := -2*math.Pow(t, 3) + 3*math.Pow(t, 2)
+	h11 := math.Pow(t, 3) - math.Pow(t, 2)
+
+	// Approximate tangents using neighboring points
+	m1 := 0.0
+	m2 := 0.0
+	if idx > 1 {
+		m1 = (e2.Height - points[idx-2].Height) / float64(e2.Timestamp-points[idx-2].Timestamp)
+	}
+	if idx < len(points)-1 {
+		m2 = (points[idx+1].Height - e1.Height) / float64(points[idx+1].Timestamp-e1.Timestamp)
+	}
+
+	span := float64(e2.Timestamp - e1.Timestamp)
+	return h00*e1.Height + h10*m1*span + h01*e2.Height + h11*m2*span
+}
+
+// harmonicInterpolator follows half a cosine wave between points, which matches the
+// shape of a semidiurnal tide between a high and a low
+type harmonicInterpolator struct{}
+
+func (harmonicInterpolator) Method() InterpolationMethod { return InterpolationHarmonic }
+
+func (harmonicInterpolator) Interpolate(points []models.TidePrediction, timestamp models.Millis) float64 {
+	p1, p2, ok := bracket(points, timestamp)
+	if !ok {
+		return p1.Height
+	}
+
+	t := float64(timestamp-p1.Timestamp) / float64(p2.Timestamp-p1.Timestamp)
+	return p1.Height + (p2.Height-p1.Height)*(1-math.Cos(math.Pi*t))/2
+}
+
+// bracket finds the points surrounding timestamp. When the timestamp falls outside the
+// points (or lands exactly on the first one) ok is false and p1 holds the nearest point.
+func bracket(points []models.TidePrediction, timestamp models.Millis) (p1, p2 models.TidePrediction, ok bool) {
+	if len(points) == 0 {
+		return models.TidePrediction{}, models.TidePrediction{}, false
+	}
+
+	idx := findNearestIndex(points, timestamp)
+	if idx <= 0 {
+		return points[0], models.TidePrediction{}, false
+	}
+	if idx >= len(points) {
+		return points[len(points)-1], models.TidePrediction{}, false
+	}
+	return points[idx-1], points[idx], true
+}
+
+// extremePoints converts extremes into plain timestamp/height points for interpolation
+func extremePoints(extremes []models.TideExtreme) []models.TidePrediction {
+	points := make([]models.TidePrediction, len(extremes))
+	for i, e := range extremes {
+		points[i] = models.TidePrediction{
+			Timestamp: e.Timestamp,
+			LocalTime: e.LocalTime,
+			Height:    e.Height,
+		}
+	}
+	return points
+}
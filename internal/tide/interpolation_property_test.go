@@ -0,0 +1,147 @@
+package tide
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+// propertySeed fixes the generated cases so a failure reproduces on the next run
+const propertySeed = 20240601
+
+// maxSplineOvershoot is how far, as a fraction of the range between a high and the
+// following low, the spline may swing past either of them. Tides do rise a little past
+// the reported extreme between samples, but never by a large part of the range.
+const maxSplineOvershoot = 0.25
+
+// series builds points at the heights, each following the one before by the gap in
+// minutes, so generated inputs are always sorted and never share a timestamp
+func series(heights []float64, gapMinutes []int) []models.TidePrediction {
+	points := make([]models.TidePrediction, min(len(heights), len(gapMinutes)))
+	at := models.Millis(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+	for i := range points {
+		points[i] = models.TidePrediction{Timestamp: at, Height: heights[i]}
+		at += models.Millis(time.Duration(gapMinutes[i]) * time.Minute / time.Millisecond)
+	}
+	return points
+}
+
+// between returns the timestamp a fraction of the way from p1 to p2
+func between(p1, p2 models.TidePrediction, fraction float64) models.Millis {
+	return p1.Timestamp + models.Millis(fraction*float64(p2.Timestamp-p1.Timestamp))
+}
+
+func propertyParameters() *gopter.TestParameters {
+	parameters := gopter.DefaultTestParametersWithSeed(propertySeed)
+	parameters.MinSuccessfulTests = 500
+	return parameters
+}
+
+func TestInterpolationProperties(t *testing.T) {
+	properties := gopter.NewProperties(propertyParameters())
+	heights := gen.SliceOfN(8, gen.Float64Range(-5, 20))
+	gaps := gen.SliceOfN(8, gen.IntRange(1, 12*60))
+	fraction := gen.Float64Range(0, 1)
+
+	for _, interpolator := range []Interpolator{linearInterpolator{}, harmonicInterpolator{}} {
+		properties.Property(string(interpolator.Method())+" stays between neighboring heights", prop.ForAll(
+			func(heights []float64, gaps []int, fraction float64) bool {
+				points := series(heights, gaps)
+				for i := 1; i < len(points); i++ {
+					height := interpolator.Interpolate(points, between(points[i-1], points[i], fraction))
+					low, high := min(points[i-1].Height, points[i].Height), max(points[i-1].Height, points[i].Height)
+					if height < low-1e-9 || height > high+1e-9 {
+						return false
+					}
+				}
+				return true
+			},
+			heights, gaps, fraction,
+		))
+	}
+
+	properties.Property("harmonic moves one way between neighbors", prop.ForAll(
+		func(heights []float64, gaps []int, a, b float64) bool {
+			points := series(heights, gaps)
+			earlier, later := min(a, b), max(a, b)
+			for i := 1; i < len(points); i++ {
+				rise := harmonicInterpolator{}.Interpolate(points, between(points[i-1], points[i], later)) -
+					harmonicInterpolator{}.Interpolate(points, between(points[i-1], points[i], earlier))
+				if rise*(points[i].Height-points[i-1].Height) < -1e-9 {
+					return false
+				}
+			}
+			return true
+		},
+		heights, gaps, fraction, fraction,
+	))
+
+	for _, interpolator := range []Interpolator{linearInterpolator{}, harmonicInterpolator{}, splineInterpolator{}} {
+		properties.Property(string(interpolator.Method())+" is continuous through each point", prop.ForAll(
+			func(heights []float64, gaps []int) bool {
+				points := series(heights, gaps)
+				for _, p := range points {
+					at := interpolator.Interpolate(points, p.Timestamp)
+					if math.Abs(at-p.Height) > 1e-9 {
+						return false
+					}
+					// A millisecond either side moves the curve by no more than a
+					// hundredth of a foot, however steep the segment
+					for _, step := range []models.Millis{-1, 1} {
+						if math.Abs(interpolator.Interpolate(points, p.Timestamp+step)-at) > 0.01 {
+							return false
+						}
+					}
+				}
+				return true
+			},
+			heights, gaps,
+		))
+	}
+
+	properties.TestingRun(t)
+}
+
+func TestSplineExtremesProperties(t *testing.T) {
+	properties := gopter.NewProperties(propertyParameters())
+	highs := gen.SliceOfN(4, gen.Float64Range(5, 15))
+	lows := gen.SliceOfN(4, gen.Float64Range(-3, 3))
+	gaps := gen.SliceOfN(8, gen.Float64Range(4.5, 7.5))
+	fraction := gen.Float64Range(0, 1)
+
+	properties.Property("spline stays near the range between a high and a low", prop.ForAll(
+		func(highs, lows, gapHours []float64, fraction float64) bool {
+			var extremes []models.TideExtreme
+			at := models.Millis(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+			for i := range min(len(highs), len(lows)) {
+				for j, height := range []float64{highs[i], lows[i]} {
+					kind := models.TideTypeHigh
+					if j == 1 {
+						kind = models.TideTypeLow
+					}
+					extremes = append(extremes, models.TideExtreme{Type: kind, Timestamp: at, Height: height})
+					at += models.Millis(gapHours[(2*i+j)%len(gapHours)] * float64(time.Hour/time.Millisecond))
+				}
+			}
+
+			points := extremePoints(extremes)
+			for i := 1; i < len(points); i++ {
+				low, high := min(points[i-1].Height, points[i].Height), max(points[i-1].Height, points[i].Height)
+				margin := maxSplineOvershoot * (high - low)
+				height := splineInterpolator{}.Interpolate(points, between(points[i-1], points[i], fraction))
+				if height < low-margin || height > high+margin {
+					return false
+				}
+			}
+			return true
+		},
+		highs, lows, gaps, fraction,
+	))
+
+	properties.TestingRun(t)
+}
@@ -0,0 +1,240 @@
+package tide
+
+import (
+	"context"
+	"flag"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/feature"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateFixtures = flag.Bool("update", false, "refresh testdata from the live NOAA API")
+
+// seattleFixture is NOAA's 6-minute predictions for Seattle (9447130) over three days, in
+// GMT, as the datagetter returns them
+const (
+	seattleFixture = "testdata/predictions_9447130_6min.json"
+	seattleQuery   = "/api/prod/datagetter?station=9447130&begin_date=20240601&end_date=20240603" +
+		"&product=predictions&datum=MLLW&units=english&time_zone=gmt&format=json&interval=6"
+)
+
+// loadSeattleFixture decodes the fixture through the service's NOAA client path. With
+// -update it's first downloaded again.
+func loadSeattleFixture(t *testing.T) []models.TidePrediction {
+	t.Helper()
+	if *updateFixtures {
+		resp, err := http.Get("https://api.tidesandcurrents.noaa.gov" + seattleQuery)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(seattleFixture, body, 0o644))
+	}
+
+	body, err := os.ReadFile(seattleFixture)
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	service := &Service{HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second})}
+	predictions, err := service.fetchNoaaPredictions(context.Background(), "9447130", "20240601", "20240603", time.UTC)
+	require.NoError(t, err)
+	return predictions
+}
+
+// referenceCurve builds a 6-minute series from the dominant harmonic constituents
+// (amplitudes in feet, speeds in degrees/hour), approximating NOAA's published predictions for Seattle.
+func referenceCurve(start time.Time, hours int) []models.TidePrediction {
+	constituents := []struct {
+		amplitude, speed, phase float64
+	}{
+		{3.52, 28.984104, 5.0},   // M2
+		{0.86, 30.0, 32.0},       // S2
+		{0.68, 28.43973, 348.0},  // N2
+		{2.74, 15.041069, 255.0}, // K1
+		{1.52, 13.943035, 235.0}, // O1
+	}
+
+	var points []models.TidePrediction
+	for t := start; t.Before(start.Add(time.Duration(hours) * time.Hour)); t = t.Add(6 * time.Minute) {
+		elapsed := t.Sub(start).Hours()
+		height := 6.8 // mean level above MLLW
+		for _, c := range constituents {
+			height += c.amplitude * math.Cos((c.speed*elapsed-c.phase)*math.Pi/180)
+		}
+		points = append(points, models.TidePrediction{Timestamp: models.MillisOf(t), Height: height})
+	}
+	return points
+}
+
+// curveExtremes picks the local highs and lows out of a 6-minute series, like NOAA's hilo product
+func curveExtremes(points []models.TidePrediction) []models.TidePrediction {
+	var extremes []models.TidePrediction
+	for i := 1; i < len(points)-1; i++ {
+		prev, cur, next := points[i-1].Height, points[i].Height, points[i+1].Height
+		if (cur > prev && cur >= next) || (cur < prev && cur <= next) {
+			extremes = append(extremes, points[i])
+		}
+	}
+	return extremes
+}
+
+func rmse(interpolator Interpolator, known, reference []models.TidePrediction) float64 {
+	var sum float64
+	var n int
+	for _, p := range reference {
+		if p.Timestamp < known[0].Timestamp || p.Timestamp > known[len(known)-1].Timestamp {
+			continue
+		}
+		diff := interpolator.Interpolate(known, p.Timestamp) - p.Height
+		sum += diff * diff
+		n++
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func TestNewInterpolator(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		want    InterpolationMethod
+		wantErr bool
+	}{
+		{name: "linear", method: "linear", want: InterpolationLinear},
+		{name: "spline", method: "spline", want: InterpolationSpline},
+		{name: "harmonic", method: "harmonic", want: InterpolationHarmonic},
+		{name: "case and whitespace insensitive", method: " Harmonic ", want: InterpolationHarmonic},
+		{name: "unknown method", method: "quadratic", wantErr: true},
+		{name: "empty method", method: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interpolator, err := NewInterpolator(tt.method)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "must be one of linear, spline, harmonic")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, interpolator.Method())
+		})
+	}
+}
+
+func TestInterpolators_Endpoints(t *testing.T) {
+	points := []models.TidePrediction{
+		{Timestamp: 1000, Height: 2.0},
+		{Timestamp: 2000, Height: 8.0},
+		{Timestamp: 3000, Height: 1.0},
+	}
+
+	for _, method := range []string{"linear", "spline", "harmonic"} {
+		t.Run(method, func(t *testing.T) {
+			interpolator, err := NewInterpolator(method)
+			require.NoError(t, err)
+
+			assert.Equal(t, 0.0, interpolator.Interpolate(nil, 1500))
+			assert.InDelta(t, 2.0, interpolator.Interpolate(points, 500), 0.001)
+			assert.InDelta(t, 2.0, interpolator.Interpolate(points, 1000), 0.001)
+			assert.InDelta(t, 8.0, interpolator.Interpolate(points, 2000), 0.001)
+			assert.InDelta(t, 1.0, interpolator.Interpolate(points, 3500), 0.001)
+		})
+	}
+}
+
+func TestInterpolators_AccuracyAgainstSixMinuteData(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	reference := referenceCurve(start, 72)
+	extremes := curveExtremes(reference)
+	require.GreaterOrEqual(t, len(extremes), 8, "expected about four extremes per day")
+
+	rmseByMethod := make(map[InterpolationMethod]float64)
+	for _, method := range []string{"linear", "spline", "harmonic"} {
+		interpolator, err := NewInterpolator(method)
+		require.NoError(t, err)
+		rmseByMethod[interpolator.Method()] = rmse(interpolator, extremes, reference)
+		t.Logf("%s RMSE from extremes: %.3f ft", method, rmseByMethod[interpolator.Method()])
+	}
+
+	// Curves built from highs and lows should track the 6-minute data closely
+	assert.Less(t, rmseByMethod[InterpolationHarmonic], 0.25)
+	assert.Less(t, rmseByMethod[InterpolationSpline], 0.75)
+	// and straight lines between extremes should be the worst fit
+	assert.Less(t, rmseByMethod[InterpolationHarmonic], rmseByMethod[InterpolationLinear])
+	assert.Less(t, rmseByMethod[InterpolationSpline], rmseByMethod[InterpolationLinear])
+
+	// With the full 6-minute series every method should be nearly exact between samples
+	for _, method := range []string{"linear", "spline", "harmonic"} {
+		interpolator, _ := NewInterpolator(method)
+		everyOther := make([]models.TidePrediction, 0, len(reference)/2)
+		for i := 0; i < len(reference); i += 2 {
+			everyOther = append(everyOther, reference[i])
+		}
+		assert.Less(t, rmse(interpolator, everyOther, reference), 0.02, method)
+	}
+}
+
+func TestInterpolators_AccuracyAgainstNOAAFixture(t *testing.T) {
+	reference := loadSeattleFixture(t)
+	require.Len(t, reference, 3*24*10, "three days of 6-minute predictions")
+	extremes := curveExtremes(reference)
+	require.GreaterOrEqual(t, len(extremes), 10, "expected about four extremes per day")
+
+	rmseByMethod := make(map[InterpolationMethod]float64)
+	for _, method := range []string{"linear", "spline", "harmonic"} {
+		interpolator, err := NewInterpolator(method)
+		require.NoError(t, err)
+		rmseByMethod[interpolator.Method()] = rmse(interpolator, extremes, reference)
+		t.Logf("%s RMSE from extremes: %.3f ft", method, rmseByMethod[interpolator.Method()])
+	}
+
+	assert.Less(t, rmseByMethod[InterpolationHarmonic], 0.25)
+	assert.Less(t, rmseByMethod[InterpolationSpline], 0.75)
+	assert.Less(t, rmseByMethod[InterpolationHarmonic], rmseByMethod[InterpolationLinear])
+	assert.Less(t, rmseByMethod[InterpolationSpline], rmseByMethod[InterpolationLinear])
+
+	// The hourly predictions charts are drawn from should reproduce the 6-minute curve
+	hourly := make([]models.TidePrediction, 0, len(reference)/10)
+	for i := 0; i < len(reference); i += 10 {
+		hourly = append(hourly, reference[i])
+	}
+	assert.Less(t, rmse(linearInterpolator{}, hourly, reference), 0.15)
+	assert.Less(t, rmse(splineInterpolator{}, hourly, reference), 0.05)
+}
+
+func TestService_InterpolatorSelection(t *testing.T) {
+	service := &Service{}
+	ctx := context.Background()
+
+	assert.Equal(t, InterpolationLinear, service.interpolatorFor(ctx, linearInterpolator{}).Method())
+
+	service.Interpolator = splineInterpolator{}
+	assert.Equal(t, InterpolationSpline, service.interpolatorFor(ctx, linearInterpolator{}).Method())
+
+	ctx = WithInterpolator(ctx, harmonicInterpolator{})
+	assert.Equal(t, InterpolationHarmonic, service.interpolatorFor(ctx, linearInterpolator{}).Method())
+}
+
+func TestFallbackFeatureFlags(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, InterpolationSpline, extremesFallback(ctx).Method())
+	assert.Equal(t, InterpolationLinear, predictionsFallback(ctx).Method())
+
+	ctx = feature.FromHeaders(ctx, map[string]string{feature.Header: "harmonic-fallback,spline-predictions"})
+	assert.Equal(t, InterpolationHarmonic, extremesFallback(ctx).Method())
+	assert.Equal(t, InterpolationSpline, predictionsFallback(ctx).Method())
+}
@@ -0,0 +1,79 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+const (
+	// DefaultNextExtremes is how many upcoming extremes to return when a request doesn't say
+	DefaultNextExtremes = 4
+	// maxNextExtremes caps how many upcoming extremes a single GetNextExtremes call returns
+	maxNextExtremes = 20
+)
+
+// GetNextExtremes returns the station's next count highs and lows from now. They're
+// answered from the prediction cache's extremes index when it holds every day they fall
+// on; otherwise the days are read through the cache like GetDailyExtremes, which indexes
+// them for the next lookup.
+func (s *Service) GetNextExtremes(ctx context.Context, stationID string, count int) (*models.NextExtremes, error) {
+	if err := validate.Between("count", float64(count), 1, maxNextExtremes); err != nil {
+		return nil, newParamRangeError(err)
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+
+	location := localStation.Location()
+	now := time.Now().In(location)
+	response := &models.NextExtremes{
+		ResponseType: "nextExtremes",
+		StationID:    localStation.ID,
+		StationName:  localStation.Name,
+		TimeZone:     localStation.TimeZone,
+	}
+
+	if index, ok := s.PredictionCache.(cache.ExtremesIndexer); ok {
+		if extremes, ok := index.NextExtremes(localStation.ID, now, count, location); ok {
+			response.Extremes = extremes
+			return response, nil
+		}
+	}
+
+	// Stations with one high and one low a day need the most days
+	start := startOfDay(now)
+	end := start.AddDate(0, 0, (count+1)/2)
+	records, warnings, err := s.getPredictionsForDateRange(ctx, localStation, start, end, location)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions: %w", err)
+	}
+	for _, w := range warnings {
+		if w.Code == models.WarningExtremesUnavailable {
+			return nil, NewNoaaAPIError(w.Message, nil)
+		}
+	}
+
+	response.Extremes = []models.TideExtreme{}
+	after := models.Millis(now.UnixMilli())
+	for _, record := range records {
+		for _, e := range record.Extremes {
+			if e.Timestamp > after && len(response.Extremes) < count {
+				response.Extremes = append(response.Extremes, e)
+			}
+		}
+	}
+	return response, nil
+}
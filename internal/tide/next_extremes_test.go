@@ -0,0 +1,102 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// indexedCache is a prediction cache whose extremes index answers every lookup
+type indexedCache struct {
+	mockStationService2
+	next []models.TideExtreme
+}
+
+func (c *indexedCache) NextExtremes(_ string, _ time.Time, count int, _ *time.Location) ([]models.TideExtreme, bool) {
+	return c.next[:count], true
+}
+
+// sixHourlyExtremes returns a record with a high at 03:00 and 15:00 and a low at 09:00
+// and 21:00 on date
+func sixHourlyExtremes(stationID string, date time.Time) *models.TidePredictionRecord {
+	record := &models.TidePredictionRecord{StationID: stationID, Date: date.Format("2006-01-02")}
+	for i, tideType := range []models.TideType{models.TideTypeHigh, models.TideTypeLow, models.TideTypeHigh, models.TideTypeLow} {
+		at := date.Add(time.Duration(3+6*i) * time.Hour)
+		record.Extremes = append(record.Extremes, models.TideExtreme{
+			Type: tideType, Timestamp: models.MillisOf(at), LocalTime: at.Format("2006-01-02T15:04:05"), Height: 1,
+		})
+	}
+	return record
+}
+
+func TestGetNextExtremes(t *testing.T) {
+	station := createTestStation(-28800)
+	station.TimeZone = "America/Los_Angeles"
+	location := station.Location()
+
+	var requested []string
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				requested = append(requested, date.Format("2006-01-02"))
+				return sixHourlyExtremes(stationID, date), nil
+			},
+		},
+	}
+
+	before := time.Now()
+	next, err := service.GetNextExtremes(context.Background(), "TEST001", 5)
+	require.NoError(t, err)
+	assert.Equal(t, "nextExtremes", next.ResponseType)
+	assert.Equal(t, "America/Los_Angeles", next.TimeZone)
+	require.Len(t, next.Extremes, 5)
+	assert.Greater(t, next.Extremes[0].Timestamp, models.MillisOf(before))
+	assert.LessOrEqual(t, next.Extremes[0].Timestamp.Sub(models.MillisOf(before)), 6*time.Hour)
+	for i := 1; i < len(next.Extremes); i++ {
+		assert.Equal(t, 6*time.Hour, next.Extremes[i].Timestamp.Sub(next.Extremes[i-1].Timestamp))
+	}
+	assert.Equal(t, time.Now().In(location).Format("2006-01-02"), requested[0], "days are read from today")
+
+	for _, count := range []int{0, 21} {
+		_, err = service.GetNextExtremes(context.Background(), "TEST001", count)
+		var rangeErr *InvalidRangeError
+		assert.ErrorAs(t, err, &rangeErr, fmt.Sprint(count, " extremes"))
+	}
+}
+
+func TestGetNextExtremes_FromIndex(t *testing.T) {
+	indexed := []models.TideExtreme{{Type: models.TideTypeHigh, Timestamp: 1}, {Type: models.TideTypeLow, Timestamp: 2}}
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: &indexedCache{
+			mockStationService2: mockStationService2{
+				getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+					t.Fatal("day records were read")
+					return nil, nil
+				},
+			},
+			next: indexed,
+		},
+	}
+
+	next, err := service.GetNextExtremes(context.Background(), "TEST001", 2)
+	require.NoError(t, err)
+	assert.Equal(t, indexed, next.Extremes)
+}
@@ -2,19 +2,52 @@ package tide
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/bbernstein/flowebb-go/internal/accuracy"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
 	"github.com/bbernstein/flowebb-go/internal/cache"
 	"github.com/bbernstein/flowebb-go/internal/config"
+	"github.com/bbernstein/flowebb-go/internal/geocode"
+	"github.com/bbernstein/flowebb-go/internal/logging"
 	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/observation"
+	"github.com/bbernstein/flowebb-go/internal/weather"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
 	"github.com/rs/zerolog/log"
-	"math"
+	"slices"
 	"sort"
-	"strconv"
 	"time"
 )
 
+const (
+	// predictionInterval matches NOAA's 6-minute prediction spacing
+	predictionInterval = 6 * time.Minute
+
+	calculationMethodPredictions = "NOAA API"
+	calculationMethodExtremes    = "NOAA API (interpolated from extremes)"
+
+	// MaxWarmDays caps how many days a single WarmPredictions call refetches
+	MaxWarmDays = 30
+
+	// maxExtremesDays caps how many days a single GetDailyExtremes call covers
+	maxExtremesDays = 31
+
+	// maxRangeDays caps a tide lookup's range, except that ranges from the last
+	// historyWindowDays that are already over may span up to maxHistoricalRangeDays, a
+	// season, since their predictions never change and are cached for longer
+	maxRangeDays           = 30
+	maxHistoricalRangeDays = 92
+	historyWindowDays      = 366
+
+	// maxFetchDays is the longest span requested from NOAA at once; longer ranges are
+	// fetched in pieces so each stays within the upstream deadline
+	maxFetchDays = 31
+)
+
+// DefaultExtremesDays is how many days of extremes to return when a request doesn't say
+const DefaultExtremesDays = 7
+
 type ServiceFactory interface {
 	NewService(ctx context.Context, httpClient *client.Client, finder models.StationFinder) (*Service, error)
 }
@@ -23,6 +56,58 @@ type Service struct {
 	HttpClient      *client.Client
 	StationFinder   models.StationFinder
 	PredictionCache cache.CacheService
+	// CacheWriter saves newly fetched predictions in the background. When nil they're saved
+	// before the request returns.
+	CacheWriter *cache.WriteBehindQueue
+	// Interpolator overrides the default interpolation for every request when set
+	Interpolator Interpolator
+	// Timeouts bounds each stage of a lookup so one slow dependency can't use up the
+	// whole Lambda timeout
+	Timeouts StageTimeouts
+	// MaxStationDistance, in kilometers, makes GetCurrentTide fail with a
+	// NoNearbyStationError when the nearest station is farther away. Zero disables it.
+	MaxStationDistance float64
+	// Geocoder names the requested point in NoNearbyStationError; nil leaves it unnamed
+	Geocoder geocode.Geocoder
+	// Weather supplies the marine forecast for requests made WithWeather; nil reports it
+	// as unavailable
+	Weather weather.Forecaster
+	// Observer supplies the latest sensor readings for stations with water temperature or
+	// conductivity sensors; nil leaves them out
+	Observer observation.Observer
+	// AnomalyThreshold is how far, in feet, a station's latest water level reading may be
+	// from its predicted level before the response flags an anomaly. Zero skips the check.
+	AnomalyThreshold float64
+	// Accuracy summarizes tracked stations' recent prediction accuracy, which tide
+	// responses carry as their confidence; nil leaves it out
+	Accuracy *accuracy.Reporter
+	// MaxPredictions caps the predictions of one response, keeping long ranges within the
+	// Lambda's memory and response size. Ranges that would hold more at NOAA's 6-minute
+	// spacing are thinned to about this many, with a warning. Zero disables it.
+	MaxPredictions int
+	// StrictDecoding fails NOAA responses with fields the decoder doesn't read, rather than
+	// only logging them
+	StrictDecoding bool
+
+	inFlight stationLocks
+}
+
+var _ models.TideProvider = (*Service)(nil)
+
+// StageTimeouts are per-stage deadlines applied with context.WithTimeout. Zero disables
+// a stage's limit.
+type StageTimeouts struct {
+	Upstream time.Duration // each NOAA fetch
+	Cache    time.Duration // each cache read
+	Total    time.Duration // a whole tide lookup
+}
+
+// withTimeout derives a context bounded by d, or returns ctx unchanged when d is zero
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
 }
 
 type DefaultServiceFactory struct{}
@@ -38,26 +123,88 @@ func NewService(ctx context.Context, httpClient *client.Client, stationFinder mo
 		return nil, fmt.Errorf("station finder is required")
 	}
 
-	cacheService, err := cache.NewCacheService(ctx, config.GetCacheConfig())
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	cacheConfig := cfg.Cache
+	cacheService, err := cache.NewCacheService(ctx, cacheConfig)
 	if err != nil {
 		return nil, fmt.Errorf("creating cache service: %w", err)
 	}
 
+	var interpolator Interpolator
+	if method := cfg.InterpolationMethod; method != "" {
+		interpolator, err = NewInterpolator(method)
+		if err != nil {
+			return nil, fmt.Errorf("configuring interpolation: %w", err)
+		}
+	}
+
+	// The NWS client shares the NOAA client's cassette, so both record to or replay from it.
+	// Demo mode has no canned weather, so its responses say the forecast is unavailable.
+	var forecaster weather.Forecaster
+	if !cfg.DemoMode {
+		nwsClient := client.New(client.Options{BaseURL: cfg.NWSBaseURL, Timeout: cfg.HTTPTimeout, Cassette: httpClient.Cassette()})
+		forecaster, err = weather.NewCached(weather.NewNWS(nwsClient, cfg.NWSUserAgent), weather.DefaultCacheSize, cfg.WeatherCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("configuring weather: %w", err)
+		}
+	}
+	observer, err := observation.NewCached(observation.NewNOAA(httpClient), observation.DefaultCacheSize, observation.DefaultTTL)
+	if err != nil {
+		return nil, fmt.Errorf("configuring sensor readings: %w", err)
+	}
+	var reporter *accuracy.Reporter
+	if len(cfg.AccuracyStations) > 0 {
+		store := accuracy.NewDynamoStore(cache.NewLazyDynamoClient("accuracy", cache.NewDynamoClient), cfg.AccuracyTable)
+		reporter, err = accuracy.NewReporter(store, cfg.AccuracyStations, accuracy.DefaultReportTTL)
+		if err != nil {
+			return nil, fmt.Errorf("configuring prediction accuracy: %w", err)
+		}
+	}
+
 	return &Service{
 		HttpClient:      httpClient,
 		StationFinder:   stationFinder,
 		PredictionCache: cacheService,
+		CacheWriter: cache.NewWriteBehindQueue(cacheService, cache.WriteBehindOptions{
+			Workers:    cacheConfig.WriteBehindWorkers,
+			QueueSize:  cacheConfig.WriteBehindQueueSize,
+			MaxRetries: cacheConfig.WriteBehindMaxRetries,
+		}),
+		Interpolator: interpolator,
+		Timeouts: StageTimeouts{
+			Upstream: cfg.UpstreamTimeout,
+			Cache:    cfg.CacheTimeout,
+			Total:    cfg.RequestTimeout,
+		},
+		MaxStationDistance: cfg.MaxStationDistanceKm,
+		AnomalyThreshold:   cfg.AnomalyThresholdFt,
+		MaxPredictions:     cfg.MaxResponsePredictions,
+		StrictDecoding:     cfg.NOAAStrictDecoding,
+		Geocoder:           geocode.NewGazetteer(),
+		Weather:            forecaster,
+		Observer:           observer,
+		Accuracy:           reporter,
 	}, nil
 }
 
-func (s *Service) GetCurrentTide(ctx context.Context, lat, lon float64, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
-	// validate params
-	if lat < -90 || lat > 90 {
-		return nil, fmt.Errorf("invalid latitude: %f", lat)
+// ApplyConfig applies a reloaded configuration's prediction cache TTLs to later lookups
+func (s *Service) ApplyConfig(cfg *config.Config) {
+	if reconfigurable, ok := s.PredictionCache.(cache.Reconfigurable); ok {
+		reconfigurable.SetConfig(cfg.Cache)
 	}
-	if lon < -180 || lon > 180 {
-		return nil, fmt.Errorf("invalid longitude: %f", lon)
+}
+
+func (s *Service) GetCurrentTide(ctx context.Context, lat, lon float64, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
+	if err := validate.Coordinates(lat, lon); err != nil {
+		return nil, err
 	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
 	stations, err := s.StationFinder.FindNearestStations(ctx, lat, lon, 1)
 	if err != nil {
 		return nil, fmt.Errorf("finding nearest station: %w", err)
@@ -66,11 +213,25 @@ func (s *Service) GetCurrentTide(ctx context.Context, lat, lon float64, startTim
 	if len(stations) == 0 {
 		return nil, fmt.Errorf("no stations found near coordinates")
 	}
+	nearest := stations[0]
+	if s.MaxStationDistance > 0 && nearest.Distance > s.MaxStationDistance {
+		return nil, &NoNearbyStationError{
+			Latitude:      lat,
+			Longitude:     lon,
+			Station:       nearest,
+			MaxDistanceKm: s.MaxStationDistance,
+			PlaceName:     s.placeName(ctx, lat, lon),
+		}
+	}
 
-	response, err := s.GetCurrentTideForStation(ctx, stations[0].ID, startTimeStr, endTimeStr)
+	// The lookup by ID below gets the station from here rather than the station list
+	models.RememberStation(ctx, nearest)
+	response, err := s.GetCurrentTideForStation(ctx, nearest.ID, startTimeStr, endTimeStr)
 	if err != nil {
 		return nil, fmt.Errorf("getting current tide: %w", err)
 	}
+	// Station lookups by ID don't know where the request came from
+	response.StationDistance = nearest.Distance
 
 	if err := response.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid response data: %w", err)
@@ -79,63 +240,61 @@ func (s *Service) GetCurrentTide(ctx context.Context, lat, lon float64, startTim
 	return response, nil
 }
 
+// placeName describes a point for error messages. Failing to name it isn't worth failing
+// the request over.
+func (s *Service) placeName(ctx context.Context, lat, lon float64) string {
+	if s.Geocoder == nil {
+		return ""
+	}
+	name, err := s.Geocoder.PlaceName(ctx, lat, lon)
+	if err != nil {
+		log.Warn().Err(err).Float64("lat", lat).Float64("lon", lon).Msg("Reverse geocoding failed")
+		return ""
+	}
+	return name
+}
+
 func (s *Service) GetCurrentTideForStation(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.ExtendedTideResponse, error) {
-	log.Debug().Str("station_id", stationID).Msg("Getting current tide for station")
+	logging.Hot().Debug().Str("station_id", stationID).Msg("Getting current tide for station")
 
-	localStation, err := s.StationFinder.FindStation(ctx, stationID)
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
+	localStation, err := s.findStation(ctx, stationID)
 	if err != nil {
 		return nil, fmt.Errorf("finding localStation: %w", err)
 	}
 
-	// Create timezone location for the localStation
-	location := time.FixedZone("Station", localStation.TimeZoneOffset)
+	// Use the station's IANA zone so local times stay correct across DST transitions
+	location := localStation.Location()
 	now := time.Now().In(location)
 
-	// Parse start time if provided, otherwise use start of today in localStation's timezone
-	var startTime time.Time
-	if startTimeStr != nil {
-		// Parse local datetime string in localStation's timezone
-		var err error
-		startTime, err = time.ParseInLocation("2006-01-02T15:04:05", *startTimeStr, location)
-		if err != nil {
-			return nil, fmt.Errorf("parsing start time: %w", err)
-		}
-	} else {
-		// Use start of today in localStation's timezone
-		startTime = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
-	}
-
-	// Parse end time if provided, otherwise use next day
-	var endTime time.Time
-	if endTimeStr != nil {
-		var err error
-		endTime, err = time.ParseInLocation("2006-01-02T15:04:05", *endTimeStr, location)
-		if err != nil {
-			return nil, fmt.Errorf("parsing end time: %w", err)
-		}
-	} else {
-		// don't add an extra day here, we add that to the query below
-		endTime = startTime.AddDate(0, 0, 1).Add(-time.Second)
+	startTime, endTime, err := parseLocalRange(startTimeStr, endTimeStr, location, requestLocation(ctx, location), now)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate date range
-	daysDataAllowed := time.Duration(30)
-	if endTime.Sub(startTime) > daysDataAllowed*24*time.Hour {
-		return nil, NewInvalidRangeError(fmt.Sprintf("date range cannot exceed %d days", daysDataAllowed))
+	if daysAllowed := maxRangeDaysFor(startTime, endTime, now); endTime.Sub(startTime) > time.Duration(daysAllowed)*24*time.Hour {
+		return nil, NewRangeTooLargeError(fmt.Sprintf("date range cannot exceed %d days", daysAllowed))
 	}
 
-	// Calculate query range
-	useExtremes := localStation.StationType != nil && *localStation.StationType == "S"
+	// Estimate the response's size before assembling it, so long ranges are thinned rather
+	// than built in full
+	step := s.predictionStep(startTime, endTime)
+
+	// Subordinate stations only publish highs and lows, so their curves are built from extremes
+	useExtremes := isSubordinate(localStation)
 	queryStart := startTime
 	if useExtremes {
 		// For extremes, go back one day for better interpolation
-		queryStart = startTime.Truncate(24*time.Hour).AddDate(0, 0, -1)
+		queryStart = startOfDay(startTime).AddDate(0, 0, -1)
 	}
 
 	// End time should be the start of the day after the last day
-	queryEnd := endTime.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	queryEnd := startOfDay(endTime).AddDate(0, 0, 1)
 
-	records, err := s.getPredictionsForDateRange(ctx, localStation, queryStart, queryEnd, location)
+	records, warnings, err := s.getPredictionsForDateRange(ctx, localStation, queryStart, queryEnd, location)
 	if err != nil {
 		return nil, fmt.Errorf("getting predictions: %w", err)
 	}
@@ -157,38 +316,48 @@ func (s *Service) GetCurrentTideForStation(ctx context.Context, stationID string
 	})
 
 	// Filter to requested time range
-	startTimestamp := startTime.Unix() * 1000
-	endTimestamp := endTime.Unix() * 1000
+	startTimestamp := models.MillisOf(startTime)
+	endTimestamp := models.MillisOf(endTime)
 
 	// Calculate current tide level and type
 	var currentLevel *float64
 	var currentType *models.TideType
 
 	// Convert times for filtering while preserving local time meaning
-	nowLocal := now.Unix() * 1000 // milliseconds
-
-	if allPredictions == nil {
-		allPredictions = make([]models.TidePrediction, 0)
-		log.Debug().Msg("Using extremes for prediction")
-		// Generate predictions at 6-minute intervals
-		for t := startTimestamp; t <= endTimestamp; t += 6 * 60 * 1000 {
-			height := interpolateExtremes(allExtremes, t)
-			allPredictions = append(allPredictions, models.TidePrediction{
-				Timestamp: t,
-				LocalTime: formatLocalTime(t, location),
-				Height:    height,
-			})
-		}
-		level := interpolateExtremes(allExtremes, nowLocal)
+	nowLocal := models.MillisOf(now)
+
+	calculationMethod := calculationMethodPredictions
+	if useExtremes || len(allPredictions) == 0 {
+		// Reference stations fall back to extremes when the 6-minute predictions are unavailable
+		interpolator := s.interpolatorFor(ctx, extremesFallback(ctx))
+		logging.Hot().Debug().
+			Str("station_id", localStation.ID).
+			Bool("subordinate", useExtremes).
+			Str("method", string(interpolator.Method())).
+			Msg("Synthesizing predictions from extremes")
+		allPredictions = synthesizePredictions(interpolator, allExtremes, startTimestamp, endTimestamp, step, location)
+		level := interpolator.Interpolate(extremePoints(allExtremes), nowLocal)
 		currentLevel = &level
+		calculationMethod = calculationMethodExtremes
 	} else {
-		log.Debug().Msg("Using predictions for prediction")
-		level := interpolatePredictions(allPredictions, nowLocal)
+		interpolator := s.interpolatorFor(ctx, predictionsFallback(ctx))
+		logging.Hot().Debug().Str("method", string(interpolator.Method())).Msg("Using predictions for prediction")
+		level := interpolator.Interpolate(allPredictions, nowLocal)
 		currentLevel = &level
 	}
 
 	filteredPredictions := filterTimestamps(allPredictions, startTimestamp, endTimestamp)
 	filteredExtremes := filterExtremes(allExtremes, startTimestamp, endTimestamp)
+	if step > predictionInterval {
+		// Synthesized curves are already spaced by step; NOAA's are thinned to match
+		if len(filteredPredictions) > s.MaxPredictions {
+			filteredPredictions = downsample(filteredPredictions, s.MaxPredictions)
+		}
+		warnings = addWarnings(warnings, models.ResponseWarning{
+			Code:    models.WarningPredictionsDownsampled,
+			Message: fmt.Sprintf("the range holds more than %d predictions, so they were thinned; ask for a shorter range for every 6 minutes", s.MaxPredictions),
+		})
+	}
 
 	// Determine tide type
 	if len(filteredPredictions) >= 2 {
@@ -206,6 +375,8 @@ func (s *Service) GetCurrentTideForStation(ctx context.Context, stationID string
 
 	// Format current time in local timezone for response
 	nowStr := now.Format("2006-01-02T15:04:05")
+	// Report the offset in effect now, which includes daylight saving time
+	_, currentOffset := now.Zone()
 
 	response := &models.ExtendedTideResponse{
 		ResponseType:          "tide",
@@ -219,11 +390,27 @@ func (s *Service) GetCurrentTideForStation(ctx context.Context, stationID string
 		Longitude:             localStation.Longitude,
 		StationDistance:       localStation.Distance,
 		TideType:              currentType,
-		CalculationMethod:     "NOAA API",
+		CalculationMethod:     calculationMethod,
 		Extremes:              filteredExtremes,
 		Predictions:           filteredPredictions,
-		TimeZoneOffsetSeconds: &localStation.TimeZoneOffset,
+		TimeZoneOffsetSeconds: &currentOffset,
+		Summary:               summarize(allExtremes, now, currentType),
+		Astronomy:             astronomy(now),
+	}
+	var conditionsWarning, weatherWarning *models.ResponseWarning
+	response.Conditions, conditionsWarning = s.waterConditions(ctx, localStation, location)
+	response.Confidence = s.predictionConfidence(ctx, localStation.ID)
+	response.Offsets = models.FindTideOffsets(ctx, s.StationFinder, *localStation)
+	response.Anomaly = s.waterLevelAnomaly(ctx, localStation, location)
+	if weatherRequested(ctx) {
+		response.Weather, weatherWarning = s.marineWeather(ctx, localStation, startTimestamp, endTimestamp, location)
+	}
+	for _, w := range []*models.ResponseWarning{conditionsWarning, weatherWarning} {
+		if w != nil {
+			warnings = addWarnings(warnings, *w)
+		}
 	}
+	response.Warnings = warnings
 
 	if err := response.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid response data: %w", err)
@@ -241,38 +428,66 @@ func (s *Service) fetchNoaaPredictions(ctx context.Context, stationID, startDate
 		return nil, NewNoaaAPIError("error making HTTP request for predictions", err)
 	}
 
-	log.Debug().Msgf("Fetched predictions from noaa: station=%s begin_date=%s end_date=%s",
+	logging.Hot().Debug().Msgf("Fetched predictions from noaa: station=%s begin_date=%s end_date=%s",
 		stationID, startDate, endDate)
 
-	var noaaResp models.NoaaResponse
-	if err := json.Unmarshal(resp.Body, &noaaResp); err != nil {
+	noaaResp, err := models.DecodeNoaaResponse(resp.Body)
+	if err != nil {
 		return nil, NewNoaaAPIError("error decoding predictions response", err)
 	}
 
-	if noaaResp.Error != nil {
-		return nil, NewNoaaAPIError(noaaResp.Error.Message, nil)
+	if err := s.checkNoaaResponse(noaaResp, stationID, "predictions"); err != nil {
+		return nil, err
 	}
 
 	predictions := make([]models.TidePrediction, len(noaaResp.Predictions))
 	for i, p := range noaaResp.Predictions {
-		timestamp, err := parseNoaaTime(p.Time, location)
-		if err != nil {
-			return nil, err
+		t := p.Time.In(location)
+		predictions[i] = models.TidePrediction{
+			Timestamp: models.MillisOf(t),
+			LocalTime: t.Format("2006-01-02T15:04:05"),
+			Height:    p.Height,
 		}
+	}
 
-		height, err := strconv.ParseFloat(p.Height, 64)
-		if err != nil {
-			return nil, fmt.Errorf("parsing height %s: %w", p.Height, err)
-		}
+	return predictions, nil
+}
 
-		predictions[i] = models.TidePrediction{
-			Timestamp: timestamp,
-			LocalTime: formatLocalTime(timestamp, location),
-			Height:    height,
+// checkNoaaResponse fails a fetch NOAA answered with an error, or, with StrictDecoding, with
+// fields the decoder doesn't read; without it they're logged, since NOAA adding a field
+// rarely changes the ones read
+func (s *Service) checkNoaaResponse(noaaResp *models.NoaaResponse, stationID, what string) error {
+	if noaaResp.Error != nil {
+		return NewNoaaAPIError(noaaResp.Error.Error(), nil)
+	}
+	if noaaResp.Unrecognized != nil {
+		if s.StrictDecoding {
+			return NewNoaaAPIError("unrecognized "+what+" response", noaaResp.Unrecognized)
 		}
+		log.Warn().
+			Err(noaaResp.Unrecognized).
+			Str("station_id", stationID).
+			Msgf("NOAA %s response has fields that aren't read", what)
 	}
+	return checkSkipped(noaaResp, stationID, what)
+}
 
-	return predictions, nil
+// checkSkipped logs the predictions NOAA sent that couldn't be read, and fails the fetch
+// only when none of them could
+func checkSkipped(noaaResp *models.NoaaResponse, stationID, what string) error {
+	if noaaResp.Skipped == 0 {
+		return nil
+	}
+	if len(noaaResp.Predictions) == 0 {
+		return NewNoaaAPIError("no readable "+what+" in response", noaaResp.SkipReason)
+	}
+	log.Warn().
+		Err(noaaResp.SkipReason).
+		Str("station_id", stationID).
+		Int("skipped", noaaResp.Skipped).
+		Int("kept", len(noaaResp.Predictions)).
+		Msgf("Left unreadable %s out of the NOAA response", what)
+	return nil
 }
 
 func (s *Service) fetchNoaaExtremes(ctx context.Context, stationID, startDate, endDate string, location *time.Location) ([]models.TideExtreme, error) {
@@ -284,113 +499,173 @@ func (s *Service) fetchNoaaExtremes(ctx context.Context, stationID, startDate, e
 		return nil, NewNoaaAPIError("error making HTTP request for extremes", err)
 	}
 
-	log.Debug().Msgf("Fetched extremes from noaa: station=%s begin_date=%s end_date=%s",
+	logging.Hot().Debug().Msgf("Fetched extremes from noaa: station=%s begin_date=%s end_date=%s",
 		stationID, startDate, endDate)
 
-	var noaaResp models.NoaaResponse
-	if err := json.Unmarshal(resp.Body, &noaaResp); err != nil {
+	noaaResp, err := models.DecodeNoaaResponse(resp.Body)
+	if err != nil {
 		return nil, NewNoaaAPIError("error decoding extremes response", err)
 	}
 
-	if noaaResp.Error != nil {
-		return nil, NewNoaaAPIError(noaaResp.Error.Message, nil)
+	if err := s.checkNoaaResponse(noaaResp, stationID, "extremes"); err != nil {
+		return nil, err
 	}
 
 	extremes := make([]models.TideExtreme, len(noaaResp.Predictions))
 	for i, p := range noaaResp.Predictions {
-		timestamp, err := parseNoaaTime(p.Time, location)
-		if err != nil {
-			return nil, err
-		}
-
-		height, err := strconv.ParseFloat(p.Height, 64)
-		if err != nil {
-			return nil, fmt.Errorf("parsing height %s: %w", p.Height, err)
-		}
-
 		var tideType models.TideType
-		if p.Type != nil {
-			if *p.Type == "H" {
+		if p.Type != "" {
+			if p.Type == "H" {
 				tideType = models.TideTypeHigh
 			} else {
 				tideType = models.TideTypeLow
 			}
 		}
 
+		t := p.Time.In(location)
 		extremes[i] = models.TideExtreme{
 			Type:      tideType,
-			Timestamp: timestamp,
-			LocalTime: formatLocalTime(timestamp, location),
-			Height:    height,
+			Timestamp: models.MillisOf(t),
+			LocalTime: t.Format("2006-01-02T15:04:05"),
+			Height:    p.Height,
 		}
 	}
 
 	return extremes, nil
 }
 
-// Helper functions for interpolation and filtering
+// GetDailyExtremes returns the station's highs and lows for days calendar days starting
+// at startDate, a YYYY-MM-DD date in the station's time zone that defaults to today.
+// Cached days are served as is; missing ones are fetched in full and cached, so later
+// tide requests for them hit the cache too.
+func (s *Service) GetDailyExtremes(ctx context.Context, stationID string, startDate *string, days int) (*models.ExtremesSummary, error) {
+	if err := validate.Between("days", float64(days), 1, maxExtremesDays); err != nil {
+		return nil, newParamRangeError(err)
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
 
-func interpolatePredictions(predictions []models.TidePrediction, timestamp int64) float64 {
-	if len(predictions) == 0 {
-		return 0
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
 	}
 
-	// Find the two predictions that bracket the requested timestamp
-	idx := findNearestIndex(predictions, timestamp)
-	if idx <= 0 {
-		return predictions[0].Height
+	location := localStation.Location()
+	var start time.Time
+	if startDate != nil {
+		if start, err = validate.Date("startDate", *startDate, location); err != nil {
+			return nil, newParamRangeError(err)
+		}
+	} else {
+		start = startOfDay(time.Now().In(location))
 	}
-	if idx >= len(predictions) {
-		return predictions[len(predictions)-1].Height
+	end := start.AddDate(0, 0, days-1)
+
+	records, warnings, err := s.getPredictionsForDateRange(ctx, localStation, start, end, location)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions: %w", err)
+	}
+	// The summary is nothing but extremes, so it can't be served without them
+	for _, w := range warnings {
+		if w.Code == models.WarningExtremesUnavailable {
+			return nil, NewNoaaAPIError(w.Message, nil)
+		}
+	}
+	recordsByDate := make(map[string]*models.TidePredictionRecord, len(records))
+	for _, record := range records {
+		recordsByDate[record.Date] = record
 	}
 
-	// Linear interpolation
-	p1 := predictions[idx-1]
-	p2 := predictions[idx]
-	ratio := float64(timestamp-p1.Timestamp) / float64(p2.Timestamp-p1.Timestamp)
-	return p1.Height + (p2.Height-p1.Height)*ratio
+	summary := &models.ExtremesSummary{
+		ResponseType: "extremes",
+		StationID:    localStation.ID,
+		StationName:  localStation.Name,
+		TimeZone:     localStation.TimeZone,
+		Days:         make([]models.DailyExtremes, 0, days),
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day := models.DailyExtremes{Date: d.Format("2006-01-02"), Extremes: []models.CompactExtreme{}}
+		if record := recordsByDate[day.Date]; record != nil {
+			for _, e := range record.Extremes {
+				day.Extremes = append(day.Extremes, models.CompactExtreme{
+					Type:      e.Type,
+					Time:      e.Timestamp.In(location).Format("15:04"),
+					Timestamp: e.Timestamp,
+					Height:    e.Height,
+				})
+			}
+		}
+		summary.Days = append(summary.Days, day)
+	}
+	return summary, nil
 }
 
-func interpolateExtremes(extremes []models.TideExtreme, timestamp int64) float64 {
-	if len(extremes) == 0 {
-		return 0
+// GetDailyPredictions returns the station's predictions and highs and lows for days
+// calendar days starting at startDate, a YYYY-MM-DD date in the station's time zone that
+// defaults to today. Unlike the tide endpoints it fails rather than serving days with
+// predictions missing, since clients keep what it returns.
+func (s *Service) GetDailyPredictions(ctx context.Context, stationID string, startDate *string, days int) (*models.DailyPredictions, error) {
+	if err := validate.Between("days", float64(days), 1, maxExtremesDays); err != nil {
+		return nil, newParamRangeError(err)
 	}
 
-	// Find the two extremes that bracket the requested timestamp
-	idx := findNearestExtremeIndex(extremes, timestamp)
-	if idx <= 0 {
-		return extremes[0].Height
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
 	}
-	if idx >= len(extremes) {
-		return extremes[len(extremes)-1].Height
+	if localStation == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
 	}
 
-	// Cubic spline interpolation for smoother transitions between extremes
-	e1 := extremes[idx-1]
-	e2 := extremes[idx]
-	t := float64(timestamp-e1.Timestamp) / float64(e2.Timestamp-e1.Timestamp)
-
-	// Hermite interpolation
-	h00 := 2*math.Pow(t, 3) - 3*math.Pow(t, 2) + 1
-	h10 := math.Pow(t, 3) - 2*math.Pow(t, 2) + t
-	h01 := -2*math.Pow(t, 3) + 3*math.Pow(t, 2)
-	h11 := math.Pow(t, 3) - math.Pow(t, 2)
+	location := localStation.Location()
+	var start time.Time
+	if startDate != nil {
+		if start, err = validate.Date("startDate", *startDate, location); err != nil {
+			return nil, newParamRangeError(err)
+		}
+	} else {
+		start = startOfDay(time.Now().In(location))
+	}
+	end := start.AddDate(0, 0, days-1)
 
-	// Approximate tangents using neighboring points
-	m1 := 0.0
-	m2 := 0.0
-	if idx > 1 {
-		m1 = (e2.Height - extremes[idx-2].Height) / float64(e2.Timestamp-extremes[idx-2].Timestamp)
+	records, warnings, err := s.getPredictionsForDateRange(ctx, localStation, start, end, location)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions: %w", err)
+	}
+	if len(warnings) > 0 {
+		return nil, NewNoaaAPIError(warnings[0].Message, nil)
 	}
-	if idx < len(extremes)-1 {
-		m2 = (extremes[idx+1].Height - e1.Height) / float64(extremes[idx+1].Timestamp-e1.Timestamp)
+	recordsByDate := make(map[string]*models.TidePredictionRecord, len(records))
+	for _, record := range records {
+		recordsByDate[record.Date] = record
 	}
 
-	return h00*e1.Height + h10*m1*float64(e2.Timestamp-e1.Timestamp) +
-		h01*e2.Height + h11*m2*float64(e2.Timestamp-e1.Timestamp)
+	response := &models.DailyPredictions{
+		ResponseType: "predictions",
+		StationID:    localStation.ID,
+		StationName:  localStation.Name,
+		TimeZone:     localStation.TimeZone,
+		Days:         make([]models.DayPredictions, 0, days),
+	}
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		day := models.DayPredictions{Date: d.Format("2006-01-02"), Predictions: []models.TidePrediction{}, Extremes: []models.TideExtreme{}}
+		if record := recordsByDate[day.Date]; record != nil {
+			day.Predictions = append(day.Predictions, record.Predictions...)
+			day.Extremes = append(day.Extremes, record.Extremes...)
+		}
+		response.Days = append(response.Days, day)
+	}
+	return response, nil
 }
 
-func (s *Service) getPredictionsForDateRange(ctx context.Context, station *models.Station, startDate, endDate time.Time, location *time.Location) ([]*models.TidePredictionRecord, error) {
+func (s *Service) getPredictionsForDateRange(ctx context.Context, station *models.Station, startDate, endDate time.Time, location *time.Location) ([]*models.TidePredictionRecord, []models.ResponseWarning, error) {
 	// Get list of dates in the range
 	var dates []time.Time
 	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
@@ -401,40 +676,194 @@ func (s *Service) getPredictionsForDateRange(ctx context.Context, station *model
 	var cachedRecords []*models.TidePredictionRecord
 	var missingDates []time.Time
 
-	log.Debug().Times("dates", dates).Msg("Checking cache for predictions on dates")
+	logging.Hot().Debug().Times("dates", dates).Msg("Checking cache for predictions on dates")
 
-	for _, date := range dates {
-		record, err := s.PredictionCache.GetPredictions(ctx, station.ID, date)
-		if err != nil {
-			log.Error().Err(err).
-				Str("station_id", station.ID).
-				Time("date", date).
-				Msg("Error getting predictions from cache")
-		}
+	cacheCtx, cancel := withTimeout(ctx, s.Timeouts.Cache)
+	records, err := s.PredictionCache.GetPredictionsBatch(cacheCtx, station.ID, dates)
+	cancel()
+	if err != nil {
+		// treat the whole range as missing and refetch it
+		log.Error().Err(err).
+			Str("station_id", station.ID).
+			Msg("Error getting predictions from cache")
+		records = make([]*models.TidePredictionRecord, len(dates))
+	}
 
-		if record != nil {
-			cachedRecords = append(cachedRecords, record)
+	for i, date := range dates {
+		if records[i] != nil {
+			cachedRecords = append(cachedRecords, records[i])
 		} else {
 			missingDates = append(missingDates, date)
 		}
 	}
 
-	log.Debug().Times("missing_dates", missingDates).Msg("Missing dates from cache")
-	log.Debug().Int("cached_records", len(cachedRecords)).Msg("Cached records")
+	logging.Hot().Debug().Times("missing_dates", missingDates).Msg("Missing dates from cache")
+	logging.Hot().Debug().Int("cached_records", len(cachedRecords)).Msg("Cached records")
+	source := station.Source
+	if source == "" {
+		source = models.SourceNOAA
+	}
+	models.RecordSource(ctx, source)
+	models.RecordUnits(ctx, models.HeightUnits, models.DefaultDatum)
+	models.RecordCacheLookups(ctx, len(cachedRecords), len(missingDates))
 
 	// If we have all dates cached, return them
 	if len(missingDates) == 0 {
-		log.Debug().
+		logging.Hot().Debug().
 			Str("station_id", station.ID).
 			Int("num_days", len(dates)).
 			Msg("Complete cache hit for date range")
-		return cachedRecords, nil
+		return cachedRecords, nil, nil
+	}
+
+	// Fetch a station's days from NOAA one request at a time. Requests that waited take
+	// what the one before them fetched, which may not be cached yet.
+	lock, err := s.inFlight.lock(ctx, station.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.inFlight.unlock(station.ID, lock)
+	shared, missingDates := lock.take(missingDates)
+	cachedRecords = append(cachedRecords, shared...)
+
+	var newRecords []*models.TidePredictionRecord
+	var warnings []models.ResponseWarning
+	for _, dates := range chunkDates(missingDates, maxFetchDays) {
+		fetched, chunkWarnings, err := s.fetchRecords(ctx, station, dates, location)
+		if err != nil {
+			return nil, nil, err
+		}
+		newRecords = append(newRecords, fetched...)
+		warnings = addWarnings(warnings, chunkWarnings...)
+	}
+
+	if len(warnings) > 0 {
+		// Serve what we have, but don't cache it or later requests would be stuck with it
+		log.Warn().
+			Str("station_id", station.ID).
+			Int("record_count", len(newRecords)).
+			Msg("Skipping cache write for records missing predictions")
+	} else if len(newRecords) > 0 {
+		lock.keep(newRecords)
+		s.queueCacheWrite(ctx, station.ID, newRecords)
+	}
+
+	// Combine cached and new records
+	allRecords := append(cachedRecords, newRecords...)
+
+	// Sort records by date
+	sort.Slice(allRecords, func(i, j int) bool {
+		return allRecords[i].Date < allRecords[j].Date
+	})
+
+	return allRecords, warnings, nil
+}
+
+// cacheFlusher is implemented by station finders that load their list in the background,
+// like station.NOAAStationFinder
+type cacheFlusher interface {
+	FlushCacheWrites(ctx context.Context) error
+}
+
+// FlushCacheWrites waits for queued cache writes, and the station finder's, to finish.
+// Call it before a Lambda invocation returns, since the instance may be frozen as soon as
+// it does.
+func (s *Service) FlushCacheWrites(ctx context.Context) error {
+	var errs []error
+	if s.CacheWriter != nil {
+		errs = append(errs, s.CacheWriter.Flush(ctx))
+	}
+	if finder, ok := s.StationFinder.(cacheFlusher); ok {
+		errs = append(errs, finder.FlushCacheWrites(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// queueCacheWrite hands newly fetched records to the write-behind queue, or saves them
+// inline when the service has none
+func (s *Service) queueCacheWrite(ctx context.Context, stationID string, records []*models.TidePredictionRecord) {
+	recordsToSave := make([]models.TidePredictionRecord, len(records))
+	for i, r := range records {
+		recordsToSave[i] = *r
+	}
+
+	if s.CacheWriter != nil {
+		s.CacheWriter.Enqueue(recordsToSave)
+		return
+	}
+	if err := s.PredictionCache.SavePredictionsBatch(ctx, recordsToSave); err != nil {
+		log.Error().Err(err).
+			Str("station_id", stationID).
+			Int("record_count", len(records)).
+			Msg("Error saving predictions to cache")
+	}
+}
+
+// WarmPredictions refetches predictions from NOAA for each calendar day from startDate to
+// endDate, inclusive, and writes them through every cache tier, replacing whatever was
+// cached. It returns the number of days written.
+func (s *Service) WarmPredictions(ctx context.Context, stationID string, startDate, endDate time.Time) (int, error) {
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return 0, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return 0, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+
+	// Cache records are keyed by the station's local calendar day
+	location := localStation.Location()
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, location)
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, location)
+	if end.Before(start) {
+		return 0, NewInvalidRangeError("end date must not be before start date")
+	}
+
+	var dates []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dates = append(dates, d)
+	}
+	if len(dates) > MaxWarmDays {
+		return 0, NewRangeTooLargeError(fmt.Sprintf("date range cannot exceed %d days", MaxWarmDays))
+	}
+
+	records, warnings, err := s.fetchRecords(ctx, localStation, dates, location)
+	if err != nil {
+		return 0, err
+	}
+	if len(warnings) > 0 {
+		return 0, NewNoaaAPIError("predictions incomplete, nothing warmed", nil)
+	}
+
+	recordsToSave := make([]models.TidePredictionRecord, len(records))
+	for i, r := range records {
+		recordsToSave[i] = *r
+	}
+	if err := s.PredictionCache.SavePredictionsBatch(ctx, recordsToSave); err != nil {
+		return 0, fmt.Errorf("saving warmed predictions: %w", err)
+	}
+
+	log.Info().
+		Str("station_id", stationID).
+		Int("days", len(records)).
+		Msg("Warmed prediction cache")
+	return len(records), nil
+}
+
+// fetchRecords fetches predictions and extremes from NOAA for the span covering dates
+// and splits them into one record per date, or blends them for a virtual station. Each
+// fetch gets its own upstream deadline.
+// If one of the two fetches fails but the other succeeds, the records are returned without
+// the missing half along with a warning, and shouldn't be cached.
+func (s *Service) fetchRecords(ctx context.Context, station *models.Station, dates []time.Time, location *time.Location) (records []*models.TidePredictionRecord, warnings []models.ResponseWarning, err error) {
+	if isVirtual(station) {
+		return s.blendRecords(ctx, station, dates, location)
 	}
 
 	// Find the min and max dates that need fetching
-	minDate := missingDates[0]
-	maxDate := missingDates[0]
-	for _, date := range missingDates[1:] {
+	minDate := dates[0]
+	maxDate := dates[0]
+	for _, date := range dates[1:] {
 		if date.Before(minDate) {
 			minDate = date
 		}
@@ -443,58 +872,67 @@ func (s *Service) getPredictionsForDateRange(ctx context.Context, station *model
 		}
 	}
 
-	// Fetch from NOAA API for the full range that includes missing dates
-	log.Debug().
+	// Fetch from NOAA API for the full range that includes every date
+	logging.Hot().Debug().
 		Str("station_id", station.ID).
 		Time("min_date", minDate).
 		Time("max_date", maxDate).
-		Int("missing_days", len(missingDates)).
-		Msg("Fetching missing dates from NOAA")
+		Int("days", len(dates)).
+		Msg("Fetching dates from NOAA")
 
 	startStr := minDate.Format("20060102")
 	endStr := maxDate.Format("20060102")
 
-	predictions, err := s.fetchNoaaPredictions(ctx, station.ID, startStr, endStr, location)
-	if err != nil {
-		// don't return error, we can interpolate from extremes instead
-		log.Warn().Err(err).
-			Str("station-id", station.ID).
-			Msg("Error fetching predictions from NOAA")
-		//return nil, err
+	// NOAA has no 6-minute predictions for subordinate stations, so don't ask for them
+	var predictions []models.TidePrediction
+	if !isSubordinate(station) {
+		upstreamCtx, cancel := withTimeout(ctx, s.Timeouts.Upstream)
+		predictions, err = s.fetchNoaaPredictions(upstreamCtx, station.ID, startStr, endStr, location)
+		cancel()
+		if err != nil {
+			// don't return error, we can interpolate from extremes instead
+			log.Warn().Err(err).
+				Str("station-id", station.ID).
+				Msg("Error fetching predictions from NOAA")
+			if transientFailure(err) {
+				warnings = append(warnings, models.ResponseWarning{
+					Code:    models.WarningPredictionsUnavailable,
+					Message: "6-minute predictions are unavailable; the curve is interpolated from the highs and lows",
+				})
+			}
+		}
 	}
 
-	extremes, err := s.fetchNoaaExtremes(ctx, station.ID, startStr, endStr, location)
+	upstreamCtx, cancel := withTimeout(ctx, s.Timeouts.Upstream)
+	extremes, err := s.fetchNoaaExtremes(upstreamCtx, station.ID, startStr, endStr, location)
+	cancel()
 	if err != nil {
 		// it's possible there were no extremes for the station
 		log.Warn().Err(err).
 			Str("station-id", station.ID).
 			Msg("Error fetching extremes from NOAA")
 		if len(predictions) == 0 {
-			return nil, err
+			return nil, nil, err
+		}
+		if transientFailure(err) {
+			warnings = append(warnings, models.ResponseWarning{
+				Code:    models.WarningExtremesUnavailable,
+				Message: "high and low tides are unavailable",
+			})
 		}
 	}
 
 	// Group predictions and extremes by day
-	predictionsByDay := make(map[string][]models.TidePrediction)
-	extremesByDay := make(map[string][]models.TideExtreme)
+	predictionsByDay := groupByDay(predictions, len(dates), location, func(p models.TidePrediction) models.Millis { return p.Timestamp })
+	extremesByDay := groupByDay(extremes, len(dates), location, func(e models.TideExtreme) models.Millis { return e.Timestamp })
 
-	for _, p := range predictions {
-		day := time.Unix(p.Timestamp/1000, 0).In(location).Format("2006-01-02")
-		predictionsByDay[day] = append(predictionsByDay[day], p)
-	}
-
-	for _, e := range extremes {
-		day := time.Unix(e.Timestamp/1000, 0).In(location).Format("2006-01-02")
-		extremesByDay[day] = append(extremesByDay[day], e)
-	}
-
-	// Create and save cache records for missing dates
-	var newRecords []*models.TidePredictionRecord
-	for _, date := range missingDates {
+	// Create cache records for each requested date
+	records = make([]*models.TidePredictionRecord, 0, len(dates))
+	for _, date := range dates {
 		dateStr := date.Format("2006-01-02")
 		dayExtremes := extremesByDay[dateStr]
 		if dayExtremes == nil {
-			log.Debug().Str("station_id", station.ID).
+			logging.Hot().Debug().Str("station_id", station.ID).
 				Str("date", dateStr).
 				Msg("No extremes for date")
 			dayExtremes = make([]models.TideExtreme, 0)
@@ -502,52 +940,167 @@ func (s *Service) getPredictionsForDateRange(ctx context.Context, station *model
 		record := &models.TidePredictionRecord{
 			StationID:   station.ID,
 			Date:        dateStr,
-			StationType: *station.StationType,
+			StationType: station.Kind(),
 			Predictions: predictionsByDay[dateStr],
 			Extremes:    dayExtremes,
 		}
-		newRecords = append(newRecords, record)
+		records = append(records, record)
 	}
 
-	// Save new records to cache asynchronously
-	go func(records []*models.TidePredictionRecord) {
-		recordsToSave := make([]models.TidePredictionRecord, len(records))
-		for i, r := range records {
-			recordsToSave[i] = *r
+	return records, warnings, nil
+}
+
+// transientFailure reports whether a failed NOAA fetch might succeed if retried. An
+// error answer from NOAA means the data doesn't exist, and the station is served without
+// it; anything else, such as a timeout, says nothing about whether it does.
+func transientFailure(err error) bool {
+	var noaaErr *NoaaAPIError
+	return !errors.As(err, &noaaErr) || noaaErr.Err != nil
+}
+
+// addWarnings appends the warnings whose codes aren't already in warnings
+func addWarnings(warnings []models.ResponseWarning, more ...models.ResponseWarning) []models.ResponseWarning {
+	for _, w := range more {
+		if !slices.ContainsFunc(warnings, func(existing models.ResponseWarning) bool { return existing.Code == w.Code }) {
+			warnings = append(warnings, w)
 		}
+	}
+	return warnings
+}
 
-		if err := s.PredictionCache.SavePredictionsBatch(context.Background(), recordsToSave); err != nil {
-			log.Error().Err(err).
-				Str("station_id", station.ID).
-				Int("record_count", len(records)).
-				Msg("Error saving predictions to cache")
+// groupByDay splits items into the local calendar day of each timestamp. NOAA returns
+// items in time order, so each day is a sub-slice of items rather than a copy.
+func groupByDay[T any](items []T, days int, location *time.Location, timestamp func(T) models.Millis) map[string][]T {
+	byDay := make(map[string][]T, days)
+	for start := 0; start < len(items); {
+		y, m, d := timestamp(items[start]).In(location).Date()
+		end := start + 1
+		for end < len(items) {
+			y2, m2, d2 := timestamp(items[end]).In(location).Date()
+			if y2 != y || m2 != m || d2 != d {
+				break
+			}
+			end++
 		}
-	}(newRecords)
 
-	// Combine cached and new records
-	allRecords := append(cachedRecords, newRecords...)
+		day := time.Date(y, m, d, 0, 0, 0, 0, location).Format("2006-01-02")
+		if existing, ok := byDay[day]; ok {
+			// Out of order; fall back to copying
+			byDay[day] = append(existing[:len(existing):len(existing)], items[start:end]...)
+		} else {
+			byDay[day] = items[start:end:end]
+		}
+		start = end
+	}
+	return byDay
+}
 
-	// Sort records by date
-	sort.Slice(allRecords, func(i, j int) bool {
-		return allRecords[i].Date < allRecords[j].Date
-	})
+// synthesizePredictions builds a 6-minute curve between start and end from the surrounding extremes
+func synthesizePredictions(interpolator Interpolator, extremes []models.TideExtreme, start, end models.Millis, step time.Duration, location *time.Location) []models.TidePrediction {
+	points := extremePoints(extremes)
+	predictions := make([]models.TidePrediction, 0, end.Sub(start)/step+1)
+	for t := start; t <= end; t = t.Add(step) {
+		predictions = append(predictions, models.TidePrediction{
+			Timestamp: t,
+			LocalTime: formatLocalTime(t, location),
+			Height:    interpolator.Interpolate(points, t),
+		})
+	}
+	return predictions
+}
+
+type timeZoneKey struct{}
 
-	return allRecords, nil
+// WithTimeZone returns a context whose request gives date-times without an offset in
+// location rather than in the station's time zone
+func WithTimeZone(ctx context.Context, location *time.Location) context.Context {
+	return context.WithValue(ctx, timeZoneKey{}, location)
 }
 
-func findNearestIndex(predictions []models.TidePrediction, timestamp int64) int {
-	return sort.Search(len(predictions), func(i int) bool {
-		return predictions[i].Timestamp >= timestamp
-	})
+// requestLocation is the zone the request's date-times without an offset are read in
+func requestLocation(ctx context.Context, station *time.Location) *time.Location {
+	if location, ok := ctx.Value(timeZoneKey{}).(*time.Location); ok && location != nil {
+		return location
+	}
+	return station
+}
+
+// parseLocalRange parses a range at a station in location from any of the forms
+// validate.Instant accepts, reading those without an offset in input, and returns it in
+// the station's time. The start defaults to the start of the station's day at now and the
+// end to a day after the start; an end given as a date alone runs through that day.
+func parseLocalRange(startTimeStr, endTimeStr *string, location, input *time.Location, now time.Time) (startTime, endTime time.Time, err error) {
+	if startTimeStr != nil {
+		if startTime, err = validate.Instant("startDateTime", *startTimeStr, input, now, false); err != nil {
+			return time.Time{}, time.Time{}, newParamRangeError(err)
+		}
+		startTime = startTime.In(location)
+	} else {
+		startTime = startOfDay(now.In(location))
+	}
+
+	if endTimeStr != nil {
+		if endTime, err = validate.Instant("endDateTime", *endTimeStr, input, now, true); err != nil {
+			return time.Time{}, time.Time{}, newParamRangeError(err)
+		}
+		endTime = endTime.In(location)
+	} else {
+		// don't add an extra day here, callers fetch through the end of the last day
+		endTime = startTime.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return startTime, endTime, nil
+}
+
+// predictionStep returns how far apart the predictions of a lookup from start to end are:
+// NOAA's 6 minutes, or the multiple of them that keeps the range within MaxPredictions
+func (s *Service) predictionStep(start, end time.Time) time.Duration {
+	estimate := int(end.Sub(start)/predictionInterval) + 1
+	if s.MaxPredictions <= 0 || estimate <= s.MaxPredictions {
+		return predictionInterval
+	}
+	return predictionInterval * time.Duration((estimate+s.MaxPredictions-1)/s.MaxPredictions)
 }
 
-func findNearestExtremeIndex(extremes []models.TideExtreme, timestamp int64) int {
-	return sort.Search(len(extremes), func(i int) bool {
-		return extremes[i].Timestamp >= timestamp
+// maxRangeDaysFor returns how many days a lookup from start to end may span at now
+func maxRangeDaysFor(start, end, now time.Time) int {
+	today := startOfDay(now)
+	if end.Before(today) && !start.Before(today.AddDate(0, 0, -historyWindowDays)) {
+		return maxHistoricalRangeDays
+	}
+	return maxRangeDays
+}
+
+// chunkDates splits ascending dates into runs that each span fewer than days days
+func chunkDates(dates []time.Time, days int) [][]time.Time {
+	var chunks [][]time.Time
+	for start := 0; start < len(dates); {
+		limit := dates[start].AddDate(0, 0, days)
+		end := start + 1
+		for end < len(dates) && dates[end].Before(limit) {
+			end++
+		}
+		chunks = append(chunks, dates[start:end])
+		start = end
+	}
+	return chunks
+}
+
+// startOfDay returns local midnight for t's calendar day in t's location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func isSubordinate(station *models.Station) bool {
+	return station.Kind() == models.StationKindSubordinate
+}
+
+func findNearestIndex(predictions []models.TidePrediction, timestamp models.Millis) int {
+	return sort.Search(len(predictions), func(i int) bool {
+		return predictions[i].Timestamp >= timestamp
 	})
 }
 
-func filterTimestamps(predictions []models.TidePrediction, start, end int64) []models.TidePrediction {
+func filterTimestamps(predictions []models.TidePrediction, start, end models.Millis) []models.TidePrediction {
 	var filtered []models.TidePrediction
 	for _, p := range predictions {
 		if p.Timestamp >= start && p.Timestamp <= end {
@@ -557,7 +1110,7 @@ func filterTimestamps(predictions []models.TidePrediction, start, end int64) []m
 	return filtered
 }
 
-func filterExtremes(extremes []models.TideExtreme, start, end int64) []models.TideExtreme {
+func filterExtremes(extremes []models.TideExtreme, start, end models.Millis) []models.TideExtreme {
 	var filtered []models.TideExtreme
 	for _, extreme := range extremes {
 		if extreme.Timestamp >= start && extreme.Timestamp <= end {
@@ -567,17 +1120,6 @@ func filterExtremes(extremes []models.TideExtreme, start, end int64) []models.Ti
 	return filtered
 }
 
-func parseNoaaTime(timeStr string, location *time.Location) (int64, error) {
-	// NOAA time format is "2006-01-02 15:04"
-	// Parse in the station's local timezone
-	t, err := time.ParseInLocation("2006-01-02 15:04", timeStr, location)
-	if err != nil {
-		return 0, fmt.Errorf("parsing time %s: %w", timeStr, err)
-	}
-	return t.UnixMilli(), nil
-}
-
-func formatLocalTime(timestamp int64, location *time.Location) string {
-	t := time.Unix(timestamp/1000, 0).In(location)
-	return t.Format("2006-01-02T15:04:05")
+func formatLocalTime(timestamp models.Millis, location *time.Location) string {
+	return timestamp.In(location).Format("2006-01-02T15:04:05")
 }
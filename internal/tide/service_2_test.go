@@ -3,11 +3,14 @@ package tide
 import (
 	"context"
 	"fmt"
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/cache"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -36,9 +39,18 @@ func (m *mockStationFinder2) FindNearestStations(ctx context.Context, lat, lon f
 	return nil, nil
 }
 
+func (m *mockStationFinder2) FindNearestStationsPage(ctx context.Context, lat, lon float64, filter models.StationFilter, offset, limit int) (*models.StationPage, error) {
+	stations, err := m.FindNearestStations(ctx, lat, lon, offset+limit)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewStationPage(filter.Apply(stations), offset, limit), nil
+}
+
 // Mock CacheService for testing
 type mockStationService2 struct {
 	getPredictionsFn       func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error)
+	getPredictionsBatchFn  func(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error)
 	savePredictionsBatchFn func(ctx context.Context, records []models.TidePredictionRecord) error
 }
 
@@ -49,6 +61,21 @@ func (m *mockStationService2) GetPredictions(ctx context.Context, stationID stri
 	return nil, nil
 }
 
+func (m *mockStationService2) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	if m.getPredictionsBatchFn != nil {
+		return m.getPredictionsBatchFn(ctx, stationID, dates)
+	}
+	records := make([]*models.TidePredictionRecord, len(dates))
+	for i, date := range dates {
+		record, err := m.GetPredictions(ctx, stationID, date)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
 func (m *mockStationService2) SavePredictionsBatch(ctx context.Context, records []models.TidePredictionRecord) error {
 	if m.savePredictionsBatchFn != nil {
 		return m.savePredictionsBatchFn(ctx, records)
@@ -57,14 +84,13 @@ func (m *mockStationService2) SavePredictionsBatch(ctx context.Context, records
 }
 
 func createTestStation(timeZoneOffset int) *models.Station {
-	stationType := "R" // Reference station
 	return &models.Station{
 		ID:             "TEST001",
 		Name:           "Test Station",
 		Latitude:       47.6062,
 		Longitude:      -122.3321,
 		TimeZoneOffset: timeZoneOffset,
-		StationType:    &stationType,
+		StationType:    models.StationKindReference,
 	}
 }
 
@@ -81,28 +107,28 @@ func TestGetCurrentTide_InvalidParameters(t *testing.T) {
 			lat:        91.0,
 			lon:        0.0,
 			wantErr:    true,
-			errMessage: "invalid latitude",
+			errMessage: "invalid lat",
 		},
 		{
 			name:       "invalid latitude too low",
 			lat:        -91.0,
 			lon:        0.0,
 			wantErr:    true,
-			errMessage: "invalid latitude",
+			errMessage: "invalid lat",
 		},
 		{
 			name:       "invalid longitude too high",
 			lat:        0.0,
 			lon:        181.0,
 			wantErr:    true,
-			errMessage: "invalid longitude",
+			errMessage: "invalid lon",
 		},
 		{
 			name:       "invalid longitude too low",
 			lat:        0.0,
 			lon:        -181.0,
 			wantErr:    true,
-			errMessage: "invalid longitude",
+			errMessage: "invalid lon",
 		},
 	}
 
@@ -153,14 +179,14 @@ func TestGetCurrentTideForStation_DateRangeValidation(t *testing.T) {
 			startTime:  "invalid",
 			endTime:    time.Now().Format("2006-01-02T15:04:05"),
 			wantErr:    true,
-			errMessage: "parsing start time",
+			errMessage: `invalid startDateTime "invalid"`,
 		},
 		{
 			name:       "invalid end time format",
 			startTime:  time.Now().Format("2006-01-02T15:04:05"),
 			endTime:    "invalid",
 			wantErr:    true,
-			errMessage: "parsing end time",
+			errMessage: `invalid endDateTime "invalid"`,
 		},
 	}
 
@@ -195,9 +221,9 @@ func TestTideTypeDetermination(t *testing.T) {
 	// Create test predictions with known rising/falling patterns
 	now := time.Now()
 	predictions := []models.TidePrediction{
-		{Timestamp: now.Add(-1 * time.Hour).UnixMilli(), Height: 1.0},
-		{Timestamp: now.UnixMilli(), Height: 2.0}, // Current (rising)
-		{Timestamp: now.Add(1 * time.Hour).UnixMilli(), Height: 3.0},
+		{Timestamp: models.MillisOf(now.Add(-1 * time.Hour)), Height: 1.0},
+		{Timestamp: models.MillisOf(now), Height: 2.0}, // Current (rising)
+		{Timestamp: models.MillisOf(now.Add(1 * time.Hour)), Height: 3.0},
 	}
 
 	// Mock NOAA API response
@@ -260,7 +286,7 @@ func TestInterpolationAccuracy(t *testing.T) {
 	tests := []struct {
 		name          string
 		predictions   []models.TidePrediction
-		timestamp     int64
+		timestamp     models.Millis
 		expectedLevel float64
 		tolerance     float64
 	}{
@@ -308,7 +334,7 @@ func TestInterpolationAccuracy(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := interpolatePredictions(tt.predictions, tt.timestamp)
+			result := linearInterpolator{}.Interpolate(tt.predictions, tt.timestamp)
 			assert.InDelta(t, tt.expectedLevel, result, tt.tolerance)
 		})
 	}
@@ -575,13 +601,13 @@ func TestCacheIntegration(t *testing.T) {
 	expectedDate := nowPacific.Format("2006-01-02")
 	expectedDate2 := nowPacific.Add(24 * time.Hour).Format("2006-01-02")
 	for _, p := range response.Predictions {
-		predTime := time.Unix(p.Timestamp/1000, 0).In(location)
+		predTime := p.Timestamp.In(location)
 		isValid := expectedDate == predTime.Format("2006-01-02") || expectedDate2 == predTime.Format("2006-01-02")
 		assert.True(t, isValid, "Prediction timestamp should match test date or next day")
 	}
 
 	for _, e := range response.Extremes {
-		extremeTime := time.Unix(e.Timestamp/1000, 0).In(location)
+		extremeTime := e.Timestamp.In(location)
 		isValid := expectedDate == extremeTime.Format("2006-01-02") || expectedDate2 == extremeTime.Format("2006-01-02")
 		assert.True(t, isValid, "Extreme timestamp should match test date or next day")
 	}
@@ -592,7 +618,7 @@ func TestInterpolateExtremes(t *testing.T) {
 	tests := []struct {
 		name      string
 		extremes  []models.TideExtreme
-		timestamp int64
+		timestamp models.Millis
 		expected  float64
 		tolerance float64
 	}{
@@ -601,16 +627,16 @@ func TestInterpolateExtremes(t *testing.T) {
 			extremes: []models.TideExtreme{
 				{
 					Type:      models.TideTypeHigh,
-					Timestamp: now.UnixMilli(),
+					Timestamp: models.MillisOf(now),
 					Height:    10.0,
 				},
 				{
 					Type:      models.TideTypeLow,
-					Timestamp: now.Add(6 * time.Hour).UnixMilli(),
+					Timestamp: models.MillisOf(now.Add(6 * time.Hour)),
 					Height:    2.0,
 				},
 			},
-			timestamp: now.Add(3 * time.Hour).UnixMilli(),
+			timestamp: models.MillisOf(now.Add(3 * time.Hour)),
 			expected:  6.0, // Should be roughly halfway between high and low
 			tolerance: 0.5,
 		},
@@ -619,11 +645,11 @@ func TestInterpolateExtremes(t *testing.T) {
 			extremes: []models.TideExtreme{
 				{
 					Type:      models.TideTypeHigh,
-					Timestamp: now.UnixMilli(),
+					Timestamp: models.MillisOf(now),
 					Height:    10.0,
 				},
 			},
-			timestamp: now.Add(-1 * time.Hour).UnixMilli(),
+			timestamp: models.MillisOf(now.Add(-1 * time.Hour)),
 			expected:  10.0, // Should use first extreme
 			tolerance: 0.001,
 		},
@@ -632,11 +658,11 @@ func TestInterpolateExtremes(t *testing.T) {
 			extremes: []models.TideExtreme{
 				{
 					Type:      models.TideTypeLow,
-					Timestamp: now.UnixMilli(),
+					Timestamp: models.MillisOf(now),
 					Height:    2.0,
 				},
 			},
-			timestamp: now.Add(1 * time.Hour).UnixMilli(),
+			timestamp: models.MillisOf(now.Add(1 * time.Hour)),
 			expected:  2.0, // Should use last extreme
 			tolerance: 0.001,
 		},
@@ -645,11 +671,11 @@ func TestInterpolateExtremes(t *testing.T) {
 			extremes: []models.TideExtreme{
 				{
 					Type:      models.TideTypeHigh,
-					Timestamp: now.UnixMilli(),
+					Timestamp: models.MillisOf(now),
 					Height:    10.0,
 				},
 			},
-			timestamp: now.UnixMilli(),
+			timestamp: models.MillisOf(now),
 			expected:  10.0,
 			tolerance: 0.001,
 		},
@@ -657,7 +683,7 @@ func TestInterpolateExtremes(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := interpolateExtremes(tt.extremes, tt.timestamp)
+			result := splineInterpolator{}.Interpolate(extremePoints(tt.extremes), tt.timestamp)
 			assert.InDelta(t, tt.expected, result, tt.tolerance)
 		})
 	}
@@ -722,14 +748,13 @@ func TestFetchNoaaExtremes_ErrorResponse(t *testing.T) {
 	stationFinder := &mockStationFinder2{
 		findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
 			// Return a test station with some basic data
-			stationType := "R" // Reference station
 			return &models.Station{
 				ID:             "TEST001",
 				Name:           "Test Station",
 				Latitude:       47.6062,
 				Longitude:      -122.3321,
 				TimeZoneOffset: 0,
-				StationType:    &stationType,
+				StationType:    models.StationKindReference,
 			}, nil
 		},
 	}
@@ -792,3 +817,823 @@ func TestFetchNoaaExtremes_ErrorResponse(t *testing.T) {
 	assert.Contains(t, err.Error(), "product may not be offered")
 	assert.Nil(t, response)
 }
+
+func TestFetchNoaaPredictions_UnreadablePredictions(t *testing.T) {
+	body := `{"predictions":[{"t":"2024-01-01 00:00","v":"1.5"},{"t":"2024-01-01 00:06","v":""},{"t":"2024-01-01 00:12","v":"1.7"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	service := &Service{HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second})}
+
+	// One bad record is left out rather than losing the rest
+	predictions, err := service.fetchNoaaPredictions(context.Background(), "TEST001", "20240101", "20240101", time.UTC)
+	require.NoError(t, err)
+	require.Len(t, predictions, 2)
+	assert.Equal(t, 1.7, predictions[1].Height)
+
+	// With nothing readable the fetch fails
+	body = `{"predictions":[{"t":"2024-01-01 00:00","v":"NaN"},{"t":"01/01/2024","v":"1.0","type":"H"}]}`
+	_, err = service.fetchNoaaExtremes(context.Background(), "TEST001", "20240101", "20240101", time.UTC)
+	var apiErr *NoaaAPIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "no readable extremes in response", apiErr.Message)
+}
+
+func TestFetchNoaaPredictions_StrictDecoding(t *testing.T) {
+	body := `{"predictions":[{"t":"2024-01-01 00:00","v":"1.5","f":"0,0"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	service := &Service{HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second})}
+
+	// A field NOAA added is only logged
+	predictions, err := service.fetchNoaaPredictions(context.Background(), "TEST001", "20240101", "20240101", time.UTC)
+	require.NoError(t, err)
+	assert.Len(t, predictions, 1)
+
+	service.StrictDecoding = true
+	_, err = service.fetchNoaaPredictions(context.Background(), "TEST001", "20240101", "20240101", time.UTC)
+	var apiErr *NoaaAPIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "unrecognized predictions response", apiErr.Message)
+	assert.ErrorContains(t, err, `unknown field "f"`)
+
+	// A response without predictions fails rather than giving none, strict or not
+	service.StrictDecoding = false
+	body = `{"data":[{"t":"2024-01-01 00:00","v":"1.5"}]}`
+	_, err = service.fetchNoaaExtremes(context.Background(), "TEST001", "20240101", "20240101", time.UTC)
+	require.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, models.ErrNoaaEmptyResponse)
+}
+
+func subordinateExtremesServer(t *testing.T, predictionsBody string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("interval") {
+		case "6":
+			if predictionsBody == "" {
+				t.Errorf("6-minute predictions should not be requested for subordinate stations")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = fmt.Fprint(w, predictionsBody)
+		case "hilo":
+			_, _ = fmt.Fprint(w, `{"predictions":[
+				{"t":"2024-01-01 05:12","v":"9.1","type":"H"},
+				{"t":"2024-01-01 11:30","v":"1.2","type":"L"},
+				{"t":"2024-01-01 17:48","v":"8.4","type":"H"},
+				{"t":"2024-01-02 00:06","v":"0.4","type":"L"},
+				{"t":"2024-01-02 06:00","v":"9.3","type":"H"},
+				{"t":"2024-01-02 12:18","v":"1.0","type":"L"},
+				{"t":"2024-01-02 18:36","v":"8.7","type":"H"},
+				{"t":"2024-01-03 00:54","v":"0.2","type":"L"},
+				{"t":"2024-01-03 06:48","v":"9.5","type":"H"}
+			]}`)
+		}
+	}))
+}
+
+func TestGetCurrentTideForStation_SubordinateStation(t *testing.T) {
+	srv := subordinateExtremesServer(t, "")
+	defer srv.Close()
+
+	station := &models.Station{
+		ID:             "SUB001",
+		Name:           "Subordinate Station",
+		Latitude:       47.6,
+		Longitude:      -122.3,
+		TimeZoneOffset: 0,
+		StationType:    models.StationKindSubordinate,
+	}
+
+	var wg sync.WaitGroup
+	var saved []models.TidePredictionRecord
+	wg.Add(1)
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			savePredictionsBatchFn: func(ctx context.Context, records []models.TidePredictionRecord) error {
+				defer wg.Done()
+				saved = records
+				return nil
+			},
+		},
+	}
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "SUB001",
+		stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T23:59:00"))
+	require.NoError(t, err)
+	require.NotNil(t, response)
+	wg.Wait()
+
+	assert.Equal(t, calculationMethodExtremes, response.CalculationMethod)
+	assert.NotNil(t, response.Summary)
+
+	// A full day of 6-minute points synthesized from the extremes
+	require.Len(t, response.Predictions, 240)
+	for i := 1; i < len(response.Predictions); i++ {
+		assert.Equal(t, predictionInterval, response.Predictions[i].Timestamp.Sub(response.Predictions[i-1].Timestamp))
+	}
+	assert.Equal(t, "2024-01-02T00:00:00", response.Predictions[0].LocalTime)
+
+	// Only the requested day's extremes are returned, and the curve passes through them
+	require.Len(t, response.Extremes, 4)
+	for _, e := range response.Extremes {
+		idx := findNearestIndex(response.Predictions, e.Timestamp)
+		require.Less(t, idx, len(response.Predictions))
+		assert.Equal(t, e.Timestamp, response.Predictions[idx].Timestamp)
+		assert.InDelta(t, e.Height, response.Predictions[idx].Height, 0.001)
+	}
+
+	// The curve rises from the midnight low toward the morning high
+	assert.Less(t, response.Predictions[1].Height, response.Predictions[30].Height)
+	assert.Less(t, response.Predictions[30].Height, response.Predictions[55].Height)
+
+	// Cached records keep the subordinate type and carry only extremes
+	require.NotEmpty(t, saved)
+	for _, record := range saved {
+		assert.Equal(t, models.StationKindSubordinate, record.StationType)
+		assert.Empty(t, record.Predictions)
+	}
+}
+
+func TestGetCurrentTideForStation_UntypedStation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("interval") == "hilo" {
+			_, _ = w.Write([]byte(`{"predictions":[{"t":"2024-01-02 06:00","v":"9.1","type":"H"},{"t":"2024-01-02 12:15","v":"0.4","type":"L"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"predictions":[{"t":"2024-01-02 00:00","v":"5.0"},{"t":"2024-01-02 00:06","v":"5.1"}]}`))
+	}))
+	defer srv.Close()
+
+	// A station whose source didn't give its type
+	station := createTestStation(0)
+	station.StationType = ""
+
+	var wg sync.WaitGroup
+	var saved []models.TidePredictionRecord
+	wg.Add(1)
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			savePredictionsBatchFn: func(ctx context.Context, records []models.TidePredictionRecord) error {
+				defer wg.Done()
+				saved = records
+				return nil
+			},
+		},
+	}
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T23:59:00"))
+	require.NoError(t, err)
+	wg.Wait()
+
+	// It's predicted, and cached, as a reference station
+	assert.Equal(t, calculationMethodPredictions, response.CalculationMethod)
+	require.NotEmpty(t, saved)
+	for _, record := range saved {
+		assert.Equal(t, models.StationKindReference, record.StationType)
+		assert.NoError(t, record.Validate())
+	}
+}
+
+func TestGetCurrentTideForStation_ReferenceFallsBackToExtremes(t *testing.T) {
+	srv := subordinateExtremesServer(t, `{"error":{"message":"No Predictions data was found."}}`)
+	defer srv.Close()
+
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: &mockStationService2{},
+	}
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T11:59:00"))
+	require.NoError(t, err)
+	require.NotNil(t, response)
+
+	assert.Equal(t, calculationMethodExtremes, response.CalculationMethod)
+	assert.Len(t, response.Predictions, 120)
+	assert.Len(t, response.Extremes, 2)
+	// NOAA has no predictions for the station, so nothing is missing
+	assert.Empty(t, response.Warnings)
+}
+
+func TestGetCurrentTideForStation_ExtremesUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("interval") == "hilo" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"predictions":[
+			{"t":"2024-01-02 00:00","v":"5.0"},
+			{"t":"2024-01-02 00:06","v":"5.1"},
+			{"t":"2024-01-02 00:12","v":"5.2"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	var saved []models.TidePredictionRecord
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			savePredictionsBatchFn: func(ctx context.Context, records []models.TidePredictionRecord) error {
+				saved = append(saved, records...)
+				return nil
+			},
+		},
+	}
+
+	// The predictions are served without the extremes, with a warning, and not cached
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T11:59:00"))
+	require.NoError(t, err)
+	assert.Equal(t, calculationMethodPredictions, response.CalculationMethod)
+	assert.Len(t, response.Predictions, 3)
+	assert.Empty(t, response.Extremes)
+	require.Len(t, response.Warnings, 1)
+	assert.Equal(t, models.WarningExtremesUnavailable, response.Warnings[0].Code)
+	assert.Empty(t, saved)
+
+	// A summary of extremes has nothing to show without them
+	_, err = service.GetDailyExtremes(context.Background(), "TEST001", stringPtr("2024-01-02"), 1)
+	var noaaErr *NoaaAPIError
+	assert.ErrorAs(t, err, &noaaErr)
+}
+
+func TestGetCurrentTideForStation_AcrossDSTTransition(t *testing.T) {
+	// Pacific time springs forward at 2024-03-10 02:00, so NOAA's lst_ldt series skips 02:00-02:54
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("interval") {
+		case "6":
+			_, _ = fmt.Fprint(w, `{"predictions":[
+				{"t":"2024-03-10 01:48","v":"5.0"},
+				{"t":"2024-03-10 01:54","v":"5.1"},
+				{"t":"2024-03-10 03:00","v":"5.2"},
+				{"t":"2024-03-10 03:06","v":"5.3"}
+			]}`)
+		case "hilo":
+			_, _ = fmt.Fprint(w, `{"predictions":[
+				{"t":"2024-03-09 20:30","v":"1.0","type":"L"},
+				{"t":"2024-03-10 09:15","v":"9.0","type":"H"}
+			]}`)
+		}
+	}))
+	defer srv.Close()
+
+	station := createTestStation(-8 * 3600)
+	station.TimeZone = "America/Los_Angeles"
+
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{},
+	}
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-03-09T00:00:00"), stringPtr("2024-03-10T23:59:00"))
+	require.NoError(t, err)
+	require.Len(t, response.Predictions, 4)
+
+	// Consecutive samples stay 6 minutes apart even though the wall clock jumps an hour
+	for i := 1; i < len(response.Predictions); i++ {
+		assert.Equal(t, predictionInterval, response.Predictions[i].Timestamp.Sub(response.Predictions[i-1].Timestamp))
+	}
+	assert.Equal(t, "2024-03-10T01:54:00", response.Predictions[1].LocalTime)
+	assert.Equal(t, "2024-03-10T03:00:00", response.Predictions[2].LocalTime)
+
+	// Extremes on either side of the change use standard and daylight offsets respectively
+	require.Len(t, response.Extremes, 2)
+	assert.Equal(t, models.MillisOf(time.Date(2024, 3, 10, 4, 30, 0, 0, time.UTC)), response.Extremes[0].Timestamp)
+	assert.Equal(t, models.MillisOf(time.Date(2024, 3, 10, 16, 15, 0, 0, time.UTC)), response.Extremes[1].Timestamp)
+
+	// The reported offset is the one in effect now, not the fixed standard offset
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	_, expectedOffset := time.Now().In(loc).Zone()
+	require.NotNil(t, response.TimeZoneOffsetSeconds)
+	assert.Equal(t, expectedOffset, *response.TimeZoneOffsetSeconds)
+}
+
+func TestWarmPredictions(t *testing.T) {
+	srv := subordinateExtremesServer(t, `{"error":{"message":"No Predictions data was found."}}`)
+	defer srv.Close()
+
+	var requests []string
+	var saved []models.TidePredictionRecord
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				if stationID != "TEST001" {
+					return nil, nil
+				}
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				requests = append(requests, date.Format("2006-01-02"))
+				return &models.TidePredictionRecord{}, nil
+			},
+			savePredictionsBatchFn: func(ctx context.Context, records []models.TidePredictionRecord) error {
+				saved = records
+				return nil
+			},
+		},
+	}
+
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("refetches and saves every day in range", func(t *testing.T) {
+		days, err := service.WarmPredictions(ctx, "TEST001", start, start.AddDate(0, 0, 2))
+		require.NoError(t, err)
+		assert.Equal(t, 3, days)
+
+		// Existing cache entries are ignored, and the save happens before returning
+		assert.Empty(t, requests)
+		require.Len(t, saved, 3)
+		assert.Equal(t, "2024-01-01", saved[0].Date)
+		assert.Len(t, saved[1].Extremes, 4)
+		assert.Equal(t, "2024-01-03", saved[2].Date)
+	})
+
+	t.Run("invalid ranges", func(t *testing.T) {
+		var rangeErr *InvalidRangeError
+
+		_, err := service.WarmPredictions(ctx, "TEST001", start, start.AddDate(0, 0, -1))
+		assert.ErrorAs(t, err, &rangeErr)
+
+		_, err = service.WarmPredictions(ctx, "TEST001", start, start.AddDate(0, 0, MaxWarmDays))
+		assert.ErrorAs(t, err, &rangeErr)
+	})
+
+	t.Run("unknown station", func(t *testing.T) {
+		_, err := service.WarmPredictions(ctx, "MISSING", start, start)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "station not found")
+	})
+}
+
+func TestGetCurrentTideForStation_ReadsCacheInOneBatch(t *testing.T) {
+	srv := subordinateExtremesServer(t, `{"error":{"message":"No Predictions data was found."}}`)
+	defer srv.Close()
+
+	var batchCalls int
+	var requested []string
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				t.Errorf("per-day cache reads should not be used")
+				return nil, nil
+			},
+			getPredictionsBatchFn: func(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+				batchCalls++
+				for _, d := range dates {
+					requested = append(requested, d.Format("2006-01-02"))
+				}
+				return make([]*models.TidePredictionRecord, len(dates)), nil
+			},
+		},
+	}
+
+	_, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-01-01T00:00:00"), stringPtr("2024-01-02T23:59:00"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, batchCalls)
+	assert.Contains(t, requested, "2024-01-01")
+	assert.Contains(t, requested, "2024-01-02")
+}
+
+func TestGetCurrentTideForStation_QueuesCacheWrites(t *testing.T) {
+	srv := subordinateExtremesServer(t, `{"error":{"message":"No Predictions data was found."}}`)
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var saved []models.TidePredictionRecord
+	predictionCache := &mockStationService2{
+		savePredictionsBatchFn: func(ctx context.Context, records []models.TidePredictionRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+			saved = append(saved, records...)
+			return nil
+		},
+	}
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: predictionCache,
+		CacheWriter:     cache.NewWriteBehindQueue(predictionCache, cache.WriteBehindOptions{}),
+	}
+
+	_, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T11:59:00"))
+	require.NoError(t, err)
+
+	require.NoError(t, service.FlushCacheWrites(context.Background()))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, saved)
+}
+
+func TestGetCurrentTideForStation_StageTimeouts(t *testing.T) {
+	extremes := subordinateExtremesServer(t, `{"predictions":[]}`)
+	defer extremes.Close()
+
+	// Predictions hang until the client gives up; extremes answer immediately
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("interval") == "6" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		extremes.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var saved []models.TidePredictionRecord
+	predictionCache := &mockStationService2{
+		// A cache read that hangs is abandoned and treated as a miss
+		getPredictionsBatchFn: func(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		savePredictionsBatchFn: func(ctx context.Context, records []models.TidePredictionRecord) error {
+			mu.Lock()
+			defer mu.Unlock()
+			saved = append(saved, records...)
+			return nil
+		},
+	}
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: predictionCache,
+		Timeouts: StageTimeouts{
+			Upstream: 50 * time.Millisecond,
+			Cache:    20 * time.Millisecond,
+			Total:    2 * time.Second,
+		},
+	}
+
+	start := time.Now()
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+		stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T11:59:00"))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The response falls back to the extremes with a warning, but isn't cached
+	assert.Equal(t, calculationMethodExtremes, response.CalculationMethod)
+	assert.NotEmpty(t, response.Predictions)
+	require.Len(t, response.Warnings, 1)
+	assert.Equal(t, models.WarningPredictionsUnavailable, response.Warnings[0].Code)
+	mu.Lock()
+	assert.Empty(t, saved)
+	mu.Unlock()
+
+	t.Run("warming refuses degraded records", func(t *testing.T) {
+		_, err := service.WarmPredictions(context.Background(), "TEST001",
+			time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+		var noaaErr *NoaaAPIError
+		assert.ErrorAs(t, err, &noaaErr)
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Empty(t, saved)
+	})
+
+	t.Run("total deadline", func(t *testing.T) {
+		service.Timeouts = StageTimeouts{Total: 30 * time.Millisecond}
+		_, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+			stringPtr("2024-01-02T00:00:00"), stringPtr("2024-01-02T11:59:00"))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestGetDailyExtremes(t *testing.T) {
+	station := createTestStation(-28800)
+	station.TimeZone = "America/Los_Angeles"
+	location := station.Location()
+
+	var requested []string
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				requested = append(requested, date.Format("2006-01-02"))
+				high := date.Add(4*time.Hour + 30*time.Minute)
+				low := date.Add(10*time.Hour + 5*time.Minute)
+				return &models.TidePredictionRecord{
+					StationID: stationID,
+					Date:      date.Format("2006-01-02"),
+					Extremes: []models.TideExtreme{
+						{Type: models.TideTypeHigh, Timestamp: models.MillisOf(high), LocalTime: high.Format("2006-01-02T15:04:05"), Height: 3.2},
+						{Type: models.TideTypeLow, Timestamp: models.MillisOf(low), LocalTime: low.Format("2006-01-02T15:04:05"), Height: -0.4},
+					},
+				}, nil
+			},
+		},
+	}
+
+	// The range crosses the start of daylight saving time on March 10
+	summary, err := service.GetDailyExtremes(context.Background(), "TEST001", stringPtr("2024-03-09"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-03-09", "2024-03-10", "2024-03-11"}, requested)
+	assert.Equal(t, "extremes", summary.ResponseType)
+	assert.Equal(t, "TEST001", summary.StationID)
+	assert.Equal(t, "America/Los_Angeles", summary.TimeZone)
+	require.Len(t, summary.Days, 3)
+	for _, day := range summary.Days {
+		require.Len(t, day.Extremes, 2, day.Date)
+		assert.Equal(t, models.TideTypeHigh, day.Extremes[0].Type)
+		assert.Equal(t, 3.2, day.Extremes[0].Height)
+	}
+	assert.Equal(t, "04:30", summary.Days[0].Extremes[0].Time)
+	// A day that starts in standard time is an hour short, so 4.5 hours after midnight is 05:30
+	assert.Equal(t, "05:30", summary.Days[1].Extremes[0].Time)
+	assert.Equal(t, "10:05", summary.Days[2].Extremes[1].Time)
+
+	today := time.Now().In(location).Format("2006-01-02")
+	summary, err = service.GetDailyExtremes(context.Background(), "TEST001", nil, 1)
+	require.NoError(t, err)
+	assert.Equal(t, today, summary.Days[0].Date)
+
+	for _, days := range []int{0, 32} {
+		_, err = service.GetDailyExtremes(context.Background(), "TEST001", nil, days)
+		var rangeErr *InvalidRangeError
+		assert.ErrorAs(t, err, &rangeErr, fmt.Sprint(days, " days"))
+	}
+	_, err = service.GetDailyExtremes(context.Background(), "TEST001", stringPtr("03/09/2024"), 3)
+	assert.ErrorContains(t, err, `invalid startDate "03/09/2024": must be YYYY-MM-DD`)
+}
+
+func TestGetDailyPredictions(t *testing.T) {
+	station := createTestStation(-28800)
+	station.TimeZone = "America/Los_Angeles"
+
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				high := date.Add(4*time.Hour + 30*time.Minute)
+				return &models.TidePredictionRecord{
+					StationID: stationID,
+					Date:      date.Format("2006-01-02"),
+					Predictions: []models.TidePrediction{
+						{Timestamp: models.MillisOf(date), LocalTime: date.Format("2006-01-02T15:04:05"), Height: 1.1},
+						{Timestamp: models.MillisOf(high), LocalTime: high.Format("2006-01-02T15:04:05"), Height: 3.2},
+					},
+					Extremes: []models.TideExtreme{
+						{Type: models.TideTypeHigh, Timestamp: models.MillisOf(high), LocalTime: high.Format("2006-01-02T15:04:05"), Height: 3.2},
+					},
+				}, nil
+			},
+		},
+	}
+
+	daily, err := service.GetDailyPredictions(context.Background(), "TEST001", stringPtr("2024-03-09"), 2)
+	require.NoError(t, err)
+	assert.Equal(t, "predictions", daily.ResponseType)
+	assert.Equal(t, "TEST001", daily.StationID)
+	assert.Equal(t, "America/Los_Angeles", daily.TimeZone)
+	require.Len(t, daily.Days, 2)
+	assert.Equal(t, "2024-03-09", daily.Days[0].Date)
+	assert.Equal(t, "2024-03-10", daily.Days[1].Date)
+	for _, day := range daily.Days {
+		assert.Len(t, day.Predictions, 2, day.Date)
+		assert.Len(t, day.Extremes, 1, day.Date)
+	}
+
+	for _, days := range []int{0, 32} {
+		_, err = service.GetDailyPredictions(context.Background(), "TEST001", nil, days)
+		var rangeErr *InvalidRangeError
+		assert.ErrorAs(t, err, &rangeErr, fmt.Sprint(days, " days"))
+	}
+}
+
+func TestGetCurrentTideForStation_MalformedStationID(t *testing.T) {
+	service := &Service{
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				t.Fatalf("looked up %q", stationID)
+				return nil, nil
+			},
+		},
+	}
+
+	_, err := service.GetCurrentTideForStation(context.Background(), "9447130 OR 1=1", nil, nil)
+	var paramErr *validate.Error
+	require.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, "stationId", paramErr.Parameter)
+	assert.Equal(t, "9447130 OR 1=1", paramErr.Value)
+}
+
+func TestGetCurrentTideForStation_HistoricalRange(t *testing.T) {
+	var mu sync.Mutex
+	var spans [][2]time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin, err := time.Parse("20060102", r.URL.Query().Get("begin_date"))
+		require.NoError(t, err)
+		end, err := time.Parse("20060102", r.URL.Query().Get("end_date"))
+		require.NoError(t, err)
+		if r.URL.Query().Get("interval") == "hilo" {
+			mu.Lock()
+			spans = append(spans, [2]time.Time{begin, end})
+			mu.Unlock()
+			_, _ = fmt.Fprintf(w, `{"predictions":[{"t":"%s 06:00","v":"9.1","type":"H"}]}`, begin.Format("2006-01-02"))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"predictions":[{"t":"%s 00:00","v":"5.0"}]}`, begin.Format("2006-01-02"))
+	}))
+	defer srv.Close()
+
+	service := &Service{
+		HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return createTestStation(0), nil
+			},
+		},
+		PredictionCache: &mockStationService2{},
+	}
+
+	today := startOfDay(time.Now().UTC())
+	lookup := func(startDaysAgo, endDaysAgo int) error {
+		start := today.AddDate(0, 0, -startDaysAgo).Format("2006-01-02T15:04:05")
+		end := today.AddDate(0, 0, -endDaysAgo).Format("2006-01-02T15:04:05")
+		_, err := service.GetCurrentTideForStation(context.Background(), "TEST001", &start, &end)
+		return err
+	}
+
+	// Last season can be pulled in one lookup, fetched from NOAA a month at a time
+	require.NoError(t, lookup(100, 10))
+	require.Len(t, spans, 3)
+	for _, span := range spans {
+		assert.Less(t, span[1].Sub(span[0]), maxFetchDays*24*time.Hour)
+	}
+	assert.Equal(t, today.AddDate(0, 0, -100), spans[0][0])
+
+	assert.ErrorContains(t, lookup(110, 10), "date range cannot exceed 92 days")
+	assert.ErrorContains(t, lookup(40, -1), "date range cannot exceed 30 days", "ranges reaching today keep the shorter limit")
+	assert.ErrorContains(t, lookup(500, 460), "date range cannot exceed 30 days", "beyond the history window")
+}
+
+func TestGetCurrentTideForStation_MaxPredictions(t *testing.T) {
+	// NOAA answers every 6 minutes of the requested days, and a high and low each
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		begin, err := time.Parse("20060102", r.URL.Query().Get("begin_date"))
+		require.NoError(t, err)
+		end, err := time.Parse("20060102", r.URL.Query().Get("end_date"))
+		require.NoError(t, err)
+		var rows []string
+		for day := begin; !day.After(end); day = day.AddDate(0, 0, 1) {
+			if r.URL.Query().Get("interval") == "hilo" {
+				rows = append(rows,
+					fmt.Sprintf(`{"t":"%s 03:00","v":"9.0","type":"H"}`, day.Format("2006-01-02")),
+					fmt.Sprintf(`{"t":"%s 15:00","v":"1.0","type":"L"}`, day.Format("2006-01-02")))
+				continue
+			}
+			for t := day; t.Before(day.AddDate(0, 0, 1)); t = t.Add(predictionInterval) {
+				rows = append(rows, fmt.Sprintf(`{"t":"%s","v":"%.1f"}`, t.Format("2006-01-02 15:04"), 5+4*math.Cos(float64(t.Hour())/24*2*math.Pi)))
+			}
+		}
+		_, _ = fmt.Fprintf(w, `{"predictions":[%s]}`, strings.Join(rows, ","))
+	}))
+	defer srv.Close()
+
+	for _, stationType := range []models.StationKind{models.StationKindReference, models.StationKindSubordinate} {
+		t.Run(string(stationType), func(t *testing.T) {
+			service := &Service{
+				HttpClient: client.New(client.Options{BaseURL: srv.URL, Timeout: 5 * time.Second}),
+				StationFinder: &mockStationFinder2{
+					findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+						station := createTestStation(0)
+						station.StationType = stationType
+						return station, nil
+					},
+				},
+				PredictionCache: &mockStationService2{},
+				MaxPredictions:  200,
+			}
+
+			// A day is 240 predictions, so it's thinned
+			response, err := service.GetCurrentTideForStation(context.Background(), "TEST001",
+				stringPtr("2024-03-01T00:00:00"), stringPtr("2024-03-01T23:54:00"))
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(response.Predictions), 200)
+			assert.Greater(t, len(response.Predictions), 100)
+			require.Len(t, response.Warnings, 1)
+			assert.Equal(t, models.WarningPredictionsDownsampled, response.Warnings[0].Code)
+			assert.Len(t, response.Extremes, 2, "highs and lows are never thinned")
+
+			// Half a day fits
+			response, err = service.GetCurrentTideForStation(context.Background(), "TEST001",
+				stringPtr("2024-03-01T00:00:00"), stringPtr("2024-03-01T11:54:00"))
+			require.NoError(t, err)
+			assert.Len(t, response.Predictions, 120)
+			assert.Empty(t, response.Warnings)
+		})
+	}
+}
+
+func TestChunkDates(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var dates []time.Time
+	for i := 0; i < 70; i++ {
+		if i == 40 {
+			continue // a day already cached
+		}
+		dates = append(dates, start.AddDate(0, 0, i))
+	}
+
+	chunks := chunkDates(dates, 31)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], 31)
+	assert.Equal(t, start.AddDate(0, 0, 31), chunks[1][0])
+	assert.Len(t, chunks[1], 30)
+	assert.Len(t, chunks[2], 8)
+	assert.Nil(t, chunkDates(nil, 31))
+}
+
+// offsetFinder is a station finder that can also look up subordinate stations' offsets
+type offsetFinder struct {
+	models.StationFinder
+	offsets *models.TideOffsets
+}
+
+func (f *offsetFinder) TideOffsets(ctx context.Context, stationID string) *models.TideOffsets {
+	return f.offsets
+}
+
+func TestGetCurrentTideForStation_Offsets(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	station := createTestStation(0)
+	service := newExtremesService(map[string]*models.Station{"TEST001": station}, map[string][]models.TideExtreme{
+		"TEST001": semidiurnalExtremes(start, 0, 0, 10, 13),
+	})
+	offsets := &models.TideOffsets{ReferenceStationID: "9447130", ReferenceStationName: "Seattle", TimeOffsetHighMinutes: 24, HeightAdjustment: models.HeightAdjustmentRatio}
+	service.StationFinder = &offsetFinder{StationFinder: service.StationFinder, offsets: offsets}
+	from, to := "2024-01-01T00:00:00", "2024-01-01T23:59:59"
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001", &from, &to)
+	require.NoError(t, err)
+	assert.Nil(t, response.Offsets, "reference stations have no offsets")
+
+	station.StationType = models.StationKindSubordinate
+	response, err = service.GetCurrentTideForStation(context.Background(), "TEST001", &from, &to)
+	require.NoError(t, err)
+	assert.Equal(t, offsets, response.Offsets)
+}
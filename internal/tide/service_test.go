@@ -3,6 +3,8 @@ package tide
 import (
 	"context"
 	"errors"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/config"
 	"github.com/bbernstein/flowebb-go/internal/models"
 	"github.com/bbernstein/flowebb-go/pkg/http/client"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +24,18 @@ func (m *mockCacheService) GetPredictions(_ context.Context, stationID string, d
 	}, nil
 }
 
+func (m *mockCacheService) GetPredictionsBatch(ctx context.Context, stationID string, dates []time.Time) ([]*models.TidePredictionRecord, error) {
+	records := make([]*models.TidePredictionRecord, len(dates))
+	for i, date := range dates {
+		record, err := m.GetPredictions(ctx, stationID, date)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
 func (m *mockCacheService) SavePredictionsBatch(_ context.Context, _ []models.TidePredictionRecord) error {
 	return nil
 }
@@ -43,6 +57,14 @@ func (m *mockStationFinder) FindNearestStations(_ context.Context, lat, lon floa
 	}, nil
 }
 
+func (m *mockStationFinder) FindNearestStationsPage(ctx context.Context, lat, lon float64, filter models.StationFilter, offset, limit int) (*models.StationPage, error) {
+	stations, err := m.FindNearestStations(ctx, lat, lon, offset+limit)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewStationPage(filter.Apply(stations), offset, limit), nil
+}
+
 func (m *mockStationFinder) FindStation(_ context.Context, stationID string) (*models.Station, error) {
 	if stationID == "1234567" {
 		return &models.Station{
@@ -81,7 +103,7 @@ func TestGetCurrentTide(t *testing.T) {
 			lat:        181.0, // Invalid latitude
 			lon:        -70.0,
 			wantErr:    true,
-			errMessage: "invalid latitude",
+			errMessage: "invalid lat",
 		},
 	}
 
@@ -106,6 +128,82 @@ func TestGetCurrentTide(t *testing.T) {
 	}
 }
 
+type mockGeocoder struct {
+	name string
+	err  error
+}
+
+func (g mockGeocoder) PlaceName(context.Context, float64, float64) (string, error) {
+	return g.name, g.err
+}
+
+func TestGetCurrentTide_MaxStationDistance(t *testing.T) {
+	farFinder := &mockStationFinder2{
+		findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+			return []models.Station{{ID: "9447130", Name: "Seattle", Distance: 212.4}}, nil
+		},
+	}
+	service := &Service{
+		HttpClient:         &client.Client{},
+		StationFinder:      farFinder,
+		PredictionCache:    &mockCacheService{},
+		MaxStationDistance: 100,
+		Geocoder:           mockGeocoder{name: "40 km W of Astoria, OR"},
+	}
+
+	_, err := service.GetCurrentTide(context.Background(), 46.2, -124.35, nil, nil)
+	var noStationErr *NoNearbyStationError
+	require.ErrorAs(t, err, &noStationErr)
+	assert.Equal(t, "9447130", noStationErr.Station.ID)
+	assert.Equal(t, 212.4, noStationErr.Station.Distance)
+	assert.EqualError(t, err, "no tide station within 100 km of 40 km W of Astoria, OR: the nearest station is Seattle (9447130), 212 km away")
+
+	// A failing geocoder leaves the point described by its coordinates
+	service.Geocoder = mockGeocoder{err: errors.New("unavailable")}
+	_, err = service.GetCurrentTide(context.Background(), 46.2, -124.35, nil, nil)
+	assert.EqualError(t, err, "no tide station within 100 km of 46.2000, -124.3500: the nearest station is Seattle (9447130), 212 km away")
+}
+
+func TestGetCurrentTide_ReportsStationDistance(t *testing.T) {
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return []models.Station{{ID: "1234567", Distance: 12.5}}, nil
+			},
+			findStationFn: (&mockStationFinder{}).FindStation,
+		},
+		PredictionCache:    &mockCacheService{},
+		MaxStationDistance: 100,
+	}
+
+	response, err := service.GetCurrentTide(context.Background(), 42.0, -70.0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 12.5, response.StationDistance)
+}
+
+func TestGetCurrentTide_ResolvesStationOnce(t *testing.T) {
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findNearestStationsFn: func(ctx context.Context, lat, lon float64, limit int) ([]models.Station, error) {
+				return []models.Station{{ID: "1234567", Name: "Test Station", Latitude: 42.0, Longitude: -70.0, Distance: 12.5}}, nil
+			},
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				t.Fatalf("looked %s up again", stationID)
+				return nil, nil
+			},
+		},
+		PredictionCache: &mockCacheService{},
+	}
+
+	// The nearest station found is the one the lookup by ID uses
+	response, err := service.GetCurrentTide(models.WithStationLookups(context.Background()), 42.0, -70.0, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567", response.NearestStation)
+	assert.Equal(t, 12.5, response.StationDistance)
+}
+
 func TestGetCurrentTideForStation(t *testing.T) {
 	mockService := &Service{
 		HttpClient:      &client.Client{},     // Mock HTTP client
@@ -153,11 +251,34 @@ func TestGetCurrentTideForStation(t *testing.T) {
 	}
 }
 
+func TestApplyConfig(t *testing.T) {
+	cacheConfig := &config.CacheConfig{
+		TidePredictionLRUSize:       10,
+		TidePredictionLRUTTLMinutes: 15,
+		Backend:                     config.BackendFile,
+		FileCacheDir:                t.TempDir(),
+	}
+	predictionCache, err := cache.NewCacheService(context.Background(), cacheConfig)
+	require.NoError(t, err)
+	service := &Service{PredictionCache: predictionCache}
+
+	reloaded := *cacheConfig
+	reloaded.TidePredictionLRUTTLMinutes = 45
+	service.ApplyConfig(config.New(config.WithCache(&reloaded)))
+
+	info, err := predictionCache.Inspect(context.Background(), "9447130", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(45*60), info.LRUTTLSeconds)
+
+	// Caches that can't be reconfigured are left alone
+	(&Service{PredictionCache: &mockCacheService{}}).ApplyConfig(config.New(config.WithCache(&reloaded)))
+}
+
 func TestInterpolation(t *testing.T) {
 	tests := []struct {
 		name           string
 		predictions    []models.TidePrediction
-		targetTime     int64
+		targetTime     models.Millis
 		expectedHeight float64
 		tolerance      float64
 	}{
@@ -205,7 +326,7 @@ func TestInterpolation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := interpolatePredictions(tt.predictions, tt.targetTime)
+			result := linearInterpolator{}.Interpolate(tt.predictions, tt.targetTime)
 			assert.InDelta(t, tt.expectedHeight, result, tt.tolerance)
 		})
 	}
@@ -247,10 +368,10 @@ func TestDateRangeValidation(t *testing.T) {
 		},
 		{
 			name:        "invalid date format",
-			startTime:   stringPtr("2024-01-01"), // Missing time component
+			startTime:   stringPtr("01/01/2024"),
 			endTime:     stringPtr("2024-01-02T00:00:00"),
 			wantErr:     true,
-			errContains: "parsing start time",
+			errContains: `invalid startDateTime "01/01/2024"`,
 		},
 	}
 
@@ -270,6 +391,110 @@ func TestDateRangeValidation(t *testing.T) {
 	}
 }
 
+func TestParseLocalRange(t *testing.T) {
+	station, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	eastern, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		start, end *string
+		input      *time.Location
+		wantStart  time.Time
+		wantEnd    time.Time
+	}{
+		{
+			name:      "station-local date-times",
+			start:     stringPtr("2024-03-10T06:00:00"),
+			end:       stringPtr("2024-03-10T18:00:00"),
+			input:     station,
+			wantStart: time.Date(2024, 3, 10, 6, 0, 0, 0, station),
+			wantEnd:   time.Date(2024, 3, 10, 18, 0, 0, 0, station),
+		},
+		{
+			name:      "another time zone",
+			start:     stringPtr("2024-03-10T09:00:00"),
+			end:       stringPtr("2024-03-10T21:00:00"),
+			input:     eastern,
+			wantStart: time.Date(2024, 3, 10, 6, 0, 0, 0, station),
+			wantEnd:   time.Date(2024, 3, 10, 18, 0, 0, 0, station),
+		},
+		{
+			name:      "offsets override the time zone",
+			start:     stringPtr("2024-03-10T14:00:00Z"),
+			end:       stringPtr("2024-03-11T02:00:00Z"),
+			input:     eastern,
+			wantStart: time.Date(2024, 3, 10, 7, 0, 0, 0, station),
+			wantEnd:   time.Date(2024, 3, 10, 19, 0, 0, 0, station),
+		},
+		{
+			name:      "dates cover whole days",
+			start:     stringPtr("2024-03-10"),
+			end:       stringPtr("2024-03-11"),
+			input:     station,
+			wantStart: time.Date(2024, 3, 10, 0, 0, 0, 0, station),
+			wantEnd:   time.Date(2024, 3, 11, 23, 59, 59, 0, station),
+		},
+		{
+			name:      "relative to now",
+			start:     stringPtr("now"),
+			end:       stringPtr("+48h"),
+			input:     station,
+			wantStart: now,
+			wantEnd:   now.Add(48 * time.Hour),
+		},
+		{
+			name:      "defaults to the station's day",
+			input:     eastern,
+			wantStart: time.Date(2024, 3, 10, 0, 0, 0, 0, station),
+			wantEnd:   time.Date(2024, 3, 10, 23, 59, 59, 0, station),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := parseLocalRange(tt.start, tt.end, station, tt.input, now)
+			require.NoError(t, err)
+			assert.True(t, tt.wantStart.Equal(start), "start "+start.String())
+			assert.True(t, tt.wantEnd.Equal(end), "end "+end.String())
+			assert.Equal(t, station, start.Location())
+			assert.Equal(t, station, end.Location())
+		})
+	}
+}
+
+func TestRequestLocation(t *testing.T) {
+	station, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	assert.Equal(t, station, requestLocation(context.Background(), station))
+	assert.Equal(t, time.UTC, requestLocation(WithTimeZone(context.Background(), time.UTC), station))
+}
+
+func TestGroupByDay(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	at := func(day, hour int) models.TidePrediction {
+		return models.TidePrediction{Timestamp: models.MillisOf(time.Date(2024, 1, day, hour, 0, 0, 0, location))}
+	}
+	timestamp := func(p models.TidePrediction) models.Millis { return p.Timestamp }
+
+	// 11pm local is already the next day in UTC, but stays on its local day
+	predictions := []models.TidePrediction{at(1, 0), at(1, 23), at(2, 0), at(2, 12), at(1, 12)}
+	byDay := groupByDay(predictions, 2, location, timestamp)
+
+	require.Len(t, byDay, 2)
+	assert.Equal(t, []models.TidePrediction{at(1, 0), at(1, 23), at(1, 12)}, byDay["2024-01-01"])
+	assert.Equal(t, []models.TidePrediction{at(2, 0), at(2, 12)}, byDay["2024-01-02"])
+
+	// The out-of-order append must not have overwritten the next day
+	assert.Equal(t, at(2, 0), predictions[2])
+	assert.Empty(t, groupByDay(nil, 1, location, timestamp))
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
@@ -284,10 +509,10 @@ func BenchmarkInterpolation(b *testing.B) {
 		{Timestamp: 4000, Height: 3.5},
 		{Timestamp: 5000, Height: 2.5},
 	}
-	targetTime := int64(2500)
+	targetTime := models.Millis(2500)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = interpolatePredictions(predictions, targetTime)
+		_ = linearInterpolator{}.Interpolate(predictions, targetTime)
 	}
 }
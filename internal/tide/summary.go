@@ -0,0 +1,78 @@
+package tide
+
+import (
+	"math"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/astro"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// summarize builds the at-a-glance summary at now, in the station's location, from
+// extremes sorted by timestamp. trend is the one already found from the predictions;
+// when it's nil the direction of the next extreme is used.
+func summarize(extremes []models.TideExtreme, now time.Time, trend *models.TideType) *models.TideSummary {
+	nowMillis := models.MillisOf(now)
+	summary := &models.TideSummary{Trend: trend}
+
+	var previous, next *models.TideExtreme
+	for i := range extremes {
+		e := extremes[i]
+		if e.Timestamp <= nowMillis {
+			previous = &e
+			continue
+		}
+		if next == nil {
+			next = &e
+		}
+		if e.Type == models.TideTypeHigh && summary.NextHigh == nil {
+			summary.NextHigh = &e
+		} else if e.Type == models.TideTypeLow && summary.NextLow == nil {
+			summary.NextLow = &e
+		}
+		if summary.NextHigh != nil && summary.NextLow != nil {
+			break
+		}
+	}
+
+	if summary.Trend == nil && next != nil {
+		direction := models.TideFalling
+		if next.Type == models.TideTypeHigh {
+			direction = models.TideTypeRising
+		}
+		summary.Trend = &direction
+	}
+
+	if previous != nil && next != nil {
+		elapsed := float64(nowMillis-previous.Timestamp) / float64(next.Timestamp-previous.Timestamp)
+		percent := math.Round(elapsed*1000) / 10
+		summary.CycleElapsedPercent = &percent
+	}
+
+	year, month, day := now.Date()
+	for _, e := range extremes {
+		y, m, d := e.Timestamp.In(now.Location()).Date()
+		if y != year || m != month || d != day {
+			continue
+		}
+		if summary.TodayRange == nil {
+			summary.TodayRange = &models.TideRange{Low: e.Height, High: e.Height}
+			continue
+		}
+		summary.TodayRange.Low = math.Min(summary.TodayRange.Low, e.Height)
+		summary.TodayRange.High = math.Max(summary.TodayRange.High, e.Height)
+	}
+
+	return summary
+}
+
+// astronomy describes the lunar cycle at now, with days to the tenth
+func astronomy(now time.Time) *models.TideAstronomy {
+	moon := astro.MoonAt(now)
+	return &models.TideAstronomy{
+		TideCycle:         string(moon.TideCycle),
+		MoonPhase:         string(moon.Phase),
+		DaysSinceNewMoon:  math.Round(moon.Age*10) / 10,
+		DaysSinceFullMoon: math.Round(moon.DaysSinceFull*10) / 10,
+	}
+}
@@ -0,0 +1,66 @@
+package tide
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+	at := func(day, hour, minute int) models.Millis {
+		return models.MillisOf(time.Date(2024, time.January, day, hour, minute, 0, 0, location))
+	}
+	extremes := []models.TideExtreme{
+		{Type: models.TideTypeLow, Timestamp: at(1, 22, 0), Height: 0.5},
+		{Type: models.TideTypeHigh, Timestamp: at(2, 4, 0), Height: 11.2},
+		{Type: models.TideTypeLow, Timestamp: at(2, 10, 0), Height: -1.3},
+		{Type: models.TideTypeHigh, Timestamp: at(2, 16, 0), Height: 9.8},
+		{Type: models.TideTypeLow, Timestamp: at(2, 22, 30), Height: 2.1},
+		{Type: models.TideTypeHigh, Timestamp: at(3, 4, 45), Height: 11.5},
+	}
+
+	t.Run("between extremes", func(t *testing.T) {
+		summary := summarize(extremes, time.Date(2024, time.January, 2, 11, 30, 0, 0, location), nil)
+		require.NotNil(t, summary.NextHigh)
+		assert.Equal(t, at(2, 16, 0), summary.NextHigh.Timestamp)
+		require.NotNil(t, summary.NextLow)
+		assert.Equal(t, at(2, 22, 30), summary.NextLow.Timestamp)
+		require.NotNil(t, summary.Trend)
+		assert.Equal(t, models.TideTypeRising, *summary.Trend, "derived from the next extreme")
+		require.NotNil(t, summary.CycleElapsedPercent)
+		assert.Equal(t, 25.0, *summary.CycleElapsedPercent)
+		assert.Equal(t, &models.TideRange{Low: -1.3, High: 11.2}, summary.TodayRange,
+			"only the local day's extremes count")
+	})
+
+	t.Run("keeps a known trend", func(t *testing.T) {
+		falling := models.TideFalling
+		summary := summarize(extremes, time.Date(2024, time.January, 2, 11, 30, 0, 0, location), &falling)
+		assert.Equal(t, &falling, summary.Trend)
+	})
+
+	t.Run("outside the extremes", func(t *testing.T) {
+		summary := summarize(extremes, time.Date(2024, time.January, 4, 12, 0, 0, 0, location), nil)
+		assert.Nil(t, summary.NextHigh)
+		assert.Nil(t, summary.NextLow)
+		assert.Nil(t, summary.Trend)
+		assert.Nil(t, summary.CycleElapsedPercent)
+		assert.Nil(t, summary.TodayRange)
+	})
+}
+
+func TestAstronomy(t *testing.T) {
+	// The full moon of 2024-01-25 17:54 UTC
+	got := astronomy(time.Date(2024, time.January, 26, 6, 0, 0, 0, time.UTC))
+	assert.Equal(t, "FULL", got.MoonPhase)
+	assert.Equal(t, "SPRING", got.TideCycle)
+	assert.InDelta(t, 0.5, got.DaysSinceFullMoon, 1)
+	assert.InDelta(t, 15.3, got.DaysSinceNewMoon, 1)
+	assert.Equal(t, got.DaysSinceNewMoon, math.Round(got.DaysSinceNewMoon*10)/10, "rounded to the tenth")
+}
@@ -0,0 +1,66 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// GetTideExtremes returns the station's highs and lows from startTimeStr through
+// endTimeStr, which take the forms GetCurrentTideForStation's range does. The days are
+// read through the prediction cache in one batch, so calendar views get the extremes
+// without the 6-minute curve, conditions or weather.
+func (s *Service) GetTideExtremes(ctx context.Context, stationID string, startTimeStr, endTimeStr *string) (*models.TideExtremes, error) {
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Total)
+	defer cancel()
+
+	localStation, err := s.findStation(ctx, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if localStation == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+
+	location := localStation.Location()
+	now := time.Now().In(location)
+	start, end, err := parseLocalRange(startTimeStr, endTimeStr, location, requestLocation(ctx, location), now)
+	if err != nil {
+		return nil, err
+	}
+	if daysAllowed := maxRangeDaysFor(start, end, now); end.Sub(start) > time.Duration(daysAllowed)*24*time.Hour {
+		return nil, NewRangeTooLargeError(fmt.Sprintf("date range cannot exceed %d days", daysAllowed))
+	}
+
+	records, warnings, err := s.getPredictionsForDateRange(ctx, localStation, startOfDay(start), startOfDay(end), location)
+	if err != nil {
+		return nil, fmt.Errorf("getting predictions: %w", err)
+	}
+	for _, w := range warnings {
+		if w.Code == models.WarningExtremesUnavailable {
+			return nil, NewNoaaAPIError(w.Message, nil)
+		}
+	}
+
+	var extremes []models.TideExtreme
+	for _, record := range records {
+		extremes = append(extremes, record.Extremes...)
+	}
+	sort.Slice(extremes, func(i, j int) bool {
+		return extremes[i].Timestamp < extremes[j].Timestamp
+	})
+	extremes = filterExtremes(extremes, models.MillisOf(start), models.MillisOf(end))
+	if extremes == nil {
+		extremes = []models.TideExtreme{}
+	}
+	return &models.TideExtremes{
+		ResponseType: "tideExtremes",
+		StationID:    localStation.ID,
+		StationName:  localStation.Name,
+		TimeZone:     localStation.TimeZone,
+		Extremes:     extremes,
+	}, nil
+}
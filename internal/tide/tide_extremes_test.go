@@ -0,0 +1,53 @@
+package tide
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTideExtremes(t *testing.T) {
+	station := createTestStation(-28800)
+	station.TimeZone = "America/Los_Angeles"
+	location := station.Location()
+
+	var requested []string
+	service := &Service{
+		HttpClient: &client.Client{},
+		StationFinder: &mockStationFinder2{
+			findStationFn: func(ctx context.Context, stationID string) (*models.Station, error) {
+				return station, nil
+			},
+		},
+		PredictionCache: &mockStationService2{
+			getPredictionsFn: func(ctx context.Context, stationID string, date time.Time) (*models.TidePredictionRecord, error) {
+				requested = append(requested, date.Format("2006-01-02"))
+				return sixHourlyExtremes(stationID, date), nil
+			},
+		},
+	}
+
+	start, end := "2025-01-01T06:00:00", "2025-01-03T12:00:00"
+	extremes, err := service.GetTideExtremes(context.Background(), "TEST001", &start, &end)
+	require.NoError(t, err)
+	assert.Equal(t, "tideExtremes", extremes.ResponseType)
+	assert.Equal(t, "America/Los_Angeles", extremes.TimeZone)
+	assert.Equal(t, []string{"2025-01-01", "2025-01-02", "2025-01-03"}, requested)
+
+	// 09:00 through 09:00 two days later, six hours apart
+	require.Len(t, extremes.Extremes, 9)
+	first := time.Date(2025, 1, 1, 9, 0, 0, 0, location)
+	assert.Equal(t, models.MillisOf(first), extremes.Extremes[0].Timestamp)
+	assert.Equal(t, models.MillisOf(first.AddDate(0, 0, 2)), extremes.Extremes[8].Timestamp)
+
+	end = "2026-03-01"
+	_, err = service.GetTideExtremes(context.Background(), "TEST001", &start, &end)
+	var rangeErr *InvalidRangeError
+	require.ErrorAs(t, err, &rangeErr)
+	assert.True(t, rangeErr.TooLarge)
+}
@@ -0,0 +1,193 @@
+package tide
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// findStation looks up a station by ID, building virtual stations from their references
+func (s *Service) findStation(ctx context.Context, stationID string) (*models.Station, error) {
+	if err := validate.StationID("stationId", stationID); err != nil {
+		return nil, err
+	}
+	if !models.IsVirtualStationID(stationID) {
+		return models.FindStation(ctx, s.StationFinder, stationID)
+	}
+
+	virtual, err := models.ParseVirtualStationID(stationID)
+	if err != nil {
+		return nil, err
+	}
+	references, err := s.findReferences(ctx, virtual)
+	if err != nil {
+		return nil, err
+	}
+
+	// The virtual station keeps its first reference's clock, so its cached days line up
+	primary := references[0]
+	return &models.Station{
+		ID:             virtual.ID(),
+		Name:           fmt.Sprintf("Between %s and %s", primary.Name, references[1].Name),
+		Latitude:       virtual.Latitude,
+		Longitude:      virtual.Longitude,
+		Source:         primary.Source,
+		Capabilities:   []string{},
+		TimeZoneOffset: primary.TimeZoneOffset,
+		TimeZone:       primary.TimeZone,
+		StationType:    models.StationKindVirtual,
+	}, nil
+}
+
+func (s *Service) findReferences(ctx context.Context, virtual *models.VirtualStation) ([2]*models.Station, error) {
+	var references [2]*models.Station
+	for i, ref := range virtual.References {
+		station, err := models.FindStation(ctx, s.StationFinder, ref.StationID)
+		if err != nil {
+			return references, fmt.Errorf("finding reference station: %w", err)
+		}
+		if station == nil {
+			return references, fmt.Errorf("%w: %s", models.ErrStationNotFound, ref.StationID)
+		}
+		references[i] = station
+	}
+	return references, nil
+}
+
+// blendRecords builds a virtual station's records for dates. Each reference's curve,
+// synthesized from extremes where it has no predictions, is shifted by its offset and
+// the two are averaged by weight every 6 minutes; highs and lows are the turning points
+// of the blended curve. The references' own records come from the cache like any lookup.
+func (s *Service) blendRecords(ctx context.Context, station *models.Station, dates []time.Time, location *time.Location) ([]*models.TidePredictionRecord, []models.ResponseWarning, error) {
+	virtual, err := models.ParseVirtualStationID(station.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	references, err := s.findReferences(ctx, virtual)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// One step either side of the days lets turning points at midnight be found
+	start := models.MillisOf(dates[0]).Add(-predictionInterval)
+	end := models.MillisOf(dates[len(dates)-1].AddDate(0, 0, 1)).Add(predictionInterval)
+
+	var curves [2][]models.TidePrediction
+	var warnings []models.ResponseWarning
+	var totalWeight float64
+	for i, ref := range virtual.References {
+		offset := time.Duration(ref.OffsetMinutes) * time.Minute
+		var refWarnings []models.ResponseWarning
+		curves[i], refWarnings, err = s.referenceCurve(ctx, references[i], start.Add(-offset), end.Add(-offset))
+		if err != nil {
+			return nil, nil, fmt.Errorf("getting predictions for reference station %s: %w", ref.StationID, err)
+		}
+		warnings = addWarnings(warnings, refWarnings...)
+		totalWeight += ref.Weight
+	}
+
+	blended := make([]models.TidePrediction, 0, end.Sub(start)/predictionInterval+1)
+	for t := start; t <= end; t = t.Add(predictionInterval) {
+		var height float64
+		for i, ref := range virtual.References {
+			offset := time.Duration(ref.OffsetMinutes) * time.Minute
+			height += ref.Weight * linearInterpolator{}.Interpolate(curves[i], t.Add(-offset))
+		}
+		blended = append(blended, models.TidePrediction{
+			Timestamp: t,
+			LocalTime: formatLocalTime(t, location),
+			Height:    math.Round(height/totalWeight*1000) / 1000,
+		})
+	}
+	extremes := turningPoints(blended)
+	predictions := blended[1 : len(blended)-1]
+
+	log.Debug().
+		Str("station_id", station.ID).
+		Int("days", len(dates)).
+		Int("extremes", len(extremes)).
+		Msg("Blended virtual station predictions")
+
+	predictionsByDay := groupByDay(predictions, len(dates), location, func(p models.TidePrediction) models.Millis { return p.Timestamp })
+	extremesByDay := groupByDay(extremes, len(dates), location, func(e models.TideExtreme) models.Millis { return e.Timestamp })
+	records := make([]*models.TidePredictionRecord, 0, len(dates))
+	for _, date := range dates {
+		dateStr := date.Format("2006-01-02")
+		dayExtremes := extremesByDay[dateStr]
+		if dayExtremes == nil {
+			dayExtremes = make([]models.TideExtreme, 0)
+		}
+		records = append(records, &models.TidePredictionRecord{
+			StationID:   station.ID,
+			Date:        dateStr,
+			StationType: models.StationKindVirtual,
+			Predictions: predictionsByDay[dateStr],
+			Extremes:    dayExtremes,
+		})
+	}
+	return records, warnings, nil
+}
+
+// referenceCurve returns a station's 6-minute curve from start to end, along with any
+// warnings about the data it was built from
+func (s *Service) referenceCurve(ctx context.Context, station *models.Station, start, end models.Millis) ([]models.TidePrediction, []models.ResponseWarning, error) {
+	location := station.Location()
+	// A day either side gives the spline the extremes around the range
+	queryStart := startOfDay(start.In(location)).AddDate(0, 0, -1)
+	queryEnd := startOfDay(end.In(location)).AddDate(0, 0, 1)
+	records, warnings, err := s.getPredictionsForDateRange(ctx, station, queryStart, queryEnd, location)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var predictions []models.TidePrediction
+	var extremes []models.TideExtreme
+	for _, record := range records {
+		predictions = append(predictions, record.Predictions...)
+		extremes = append(extremes, record.Extremes...)
+	}
+	if isSubordinate(station) || len(predictions) == 0 {
+		sort.Slice(extremes, func(i, j int) bool {
+			return extremes[i].Timestamp < extremes[j].Timestamp
+		})
+		return synthesizePredictions(s.interpolatorFor(ctx, extremesFallback(ctx)), extremes, start, end, predictionInterval, location), warnings, nil
+	}
+	sort.Slice(predictions, func(i, j int) bool {
+		return predictions[i].Timestamp < predictions[j].Timestamp
+	})
+	return predictions, warnings, nil
+}
+
+// turningPoints returns the highs and lows of a curve, excluding its first and last points
+func turningPoints(curve []models.TidePrediction) []models.TideExtreme {
+	extremes := make([]models.TideExtreme, 0)
+	for i := 1; i < len(curve)-1; i++ {
+		prev, p, next := curve[i-1].Height, curve[i].Height, curve[i+1].Height
+		var tideType models.TideType
+		switch {
+		case p > prev && p >= next:
+			tideType = models.TideTypeHigh
+		case p < prev && p <= next:
+			tideType = models.TideTypeLow
+		default:
+			continue
+		}
+		extremes = append(extremes, models.TideExtreme{
+			Type:      tideType,
+			Timestamp: curve[i].Timestamp,
+			LocalTime: curve[i].LocalTime,
+			Height:    p,
+		})
+	}
+	return extremes
+}
+
+func isVirtual(station *models.Station) bool {
+	return station.Kind() == models.StationKindVirtual
+}
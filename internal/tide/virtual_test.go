@@ -0,0 +1,95 @@
+package tide
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCurrentTideForStation_Virtual(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	extremes := map[string][]models.TideExtreme{
+		"UP":   semidiurnalExtremes(start, 0, 0, 10, 13),
+		"DOWN": semidiurnalExtremes(start, 0, 2.5, 7.5, 13),
+	}
+	stations := map[string]*models.Station{
+		"UP":   createTestStation(0),
+		"DOWN": createTestStation(3600),
+	}
+	stations["UP"].ID, stations["UP"].Name = "UP", "Upstream"
+	stations["DOWN"].ID, stations["DOWN"].Name = "DOWN", "Downstream"
+
+	service := newExtremesService(stations, extremes)
+	var saved []models.TidePredictionRecord
+	service.PredictionCache.(*mockStationService2).savePredictionsBatchFn = func(ctx context.Context, records []models.TidePredictionRecord) error {
+		saved = append(saved, records...)
+		return nil
+	}
+	ctx := context.Background()
+	from, to := "2024-01-02T00:00:00", "2024-01-02T23:59:59"
+
+	// Three parts upstream to one part downstream, both an hour later
+	response, err := service.GetCurrentTideForStation(ctx, "virtual:47.50:-122.5:UP*3@60:DOWN*1@60", &from, &to)
+	require.NoError(t, err)
+	assert.Equal(t, "virtual:47.5:-122.5:UP*3@60:DOWN*1@60", response.NearestStation)
+	assert.Equal(t, "Between Upstream and Downstream", *response.Location)
+	assert.Equal(t, 47.5, response.Latitude)
+	assert.Equal(t, calculationMethodPredictions, response.CalculationMethod)
+
+	require.Len(t, response.Extremes, 4)
+	for i, want := range []struct {
+		hour   int
+		height float64
+		typ    models.TideType
+	}{
+		{1, 0.625, models.TideTypeLow},
+		{7, 9.375, models.TideTypeHigh},
+		{13, 0.625, models.TideTypeLow},
+		{19, 9.375, models.TideTypeHigh},
+	} {
+		got := response.Extremes[i]
+		assert.Equal(t, models.MillisOf(start.AddDate(0, 0, 1).Add(time.Duration(want.hour)*time.Hour)), got.Timestamp)
+		assert.Equal(t, want.height, got.Height)
+		assert.Equal(t, want.typ, got.Type)
+	}
+
+	// 04:00 is halfway from low to high at both references
+	four := models.MillisOf(start.AddDate(0, 0, 1).Add(4 * time.Hour))
+	idx := findNearestIndex(response.Predictions, four)
+	require.Less(t, idx, len(response.Predictions))
+	assert.Equal(t, four, response.Predictions[idx].Timestamp)
+	assert.Equal(t, 5.0, response.Predictions[idx].Height)
+
+	// Blended days are cached under the canonical ID like any station's
+	require.Len(t, saved, 2)
+	for _, record := range saved {
+		assert.Equal(t, "virtual:47.5:-122.5:UP*3@60:DOWN*1@60", record.StationID)
+		assert.Equal(t, models.StationKindVirtual, record.StationType)
+		assert.NoError(t, record.Validate())
+	}
+	assert.Equal(t, "2024-01-02", saved[0].Date)
+
+	_, err = service.GetCurrentTideForStation(ctx, "virtual:47.5:-122.5:UP*3:NOPE*1", &from, &to)
+	assert.ErrorContains(t, err, "station not found: NOPE")
+
+	_, err = service.GetCurrentTideForStation(ctx, "virtual:47.5:-122.5:UP*3", &from, &to)
+	assert.ErrorContains(t, err, "invalid virtual station")
+}
+
+func TestTurningPoints(t *testing.T) {
+	curve := make([]models.TidePrediction, 0)
+	for i, h := range []float64{1, 2, 3, 3, 2, 1, 1, 2} {
+		curve = append(curve, models.TidePrediction{Timestamp: models.Millis(i), Height: h})
+	}
+
+	// A plateau's turning point is its first point
+	assert.Equal(t, []models.TideExtreme{
+		{Type: models.TideTypeHigh, Timestamp: 2, Height: 3},
+		{Type: models.TideTypeLow, Timestamp: 5, Height: 1},
+	}, turningPoints(curve))
+	assert.Empty(t, turningPoints(curve[:2]))
+}
@@ -0,0 +1,56 @@
+package tide
+
+import (
+	"context"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+type weatherKey struct{}
+
+// WithWeather returns a context that asks for the marine weather forecast to be attached
+// to tide responses for a single request
+func WithWeather(ctx context.Context) context.Context {
+	return context.WithValue(ctx, weatherKey{}, true)
+}
+
+func weatherRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(weatherKey{}).(bool)
+	return requested
+}
+
+// marineWeather returns the station's forecast from start to end. Weather is extra, so a
+// failed lookup is logged and reported as unavailable with a warning rather than failing
+// the tides.
+func (s *Service) marineWeather(ctx context.Context, station *models.Station, start, end models.Millis, location *time.Location) (*models.MarineWeather, *models.ResponseWarning) {
+	weather := &models.MarineWeather{
+		Source:   models.WeatherSourceNWS,
+		Forecast: make([]models.WeatherForecast, 0),
+	}
+	unavailable := &models.ResponseWarning{
+		Code:    models.WarningWeatherUnavailable,
+		Message: "the weather forecast is unavailable",
+	}
+	if s.Weather == nil {
+		return weather, unavailable
+	}
+
+	ctx, cancel := withTimeout(ctx, s.Timeouts.Upstream)
+	defer cancel()
+	forecast, err := s.Weather.Forecast(ctx, station.Latitude, station.Longitude)
+	if err != nil {
+		log.Warn().Err(err).Str("station_id", station.ID).Msg("Weather forecast unavailable")
+		return weather, unavailable
+	}
+
+	weather.Available = true
+	for _, f := range forecast {
+		if f.Timestamp >= start && f.Timestamp <= end {
+			f.LocalTime = formatLocalTime(f.Timestamp, location)
+			weather.Forecast = append(weather.Forecast, f)
+		}
+	}
+	return weather, nil
+}
@@ -0,0 +1,102 @@
+package tide
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+type mockForecaster struct {
+	forecastFn func(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error)
+}
+
+func (m *mockForecaster) Forecast(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error) {
+	return m.forecastFn(ctx, lat, lon)
+}
+
+func TestGetCurrentTideForStation_Weather(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stations := map[string]*models.Station{"TEST001": createTestStation(3600)}
+	service := newExtremesService(stations, map[string][]models.TideExtreme{
+		"TEST001": semidiurnalExtremes(start, 0, 0, 10, 13),
+	})
+
+	var forecastLat, forecastLon float64
+	service.Weather = &mockForecaster{forecastFn: func(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error) {
+		forecastLat, forecastLon = lat, lon
+		forecast := make([]models.WeatherForecast, 0, 72)
+		for h := 0; h < 72; h++ {
+			speed := float64(h)
+			forecast = append(forecast, models.WeatherForecast{
+				Timestamp:      models.MillisOf(start.Add(time.Duration(h) * time.Hour)),
+				WindSpeedKnots: &speed,
+			})
+		}
+		return forecast, nil
+	}}
+	from, to := "2024-01-02T00:00:00", "2024-01-02T05:59:59"
+
+	response, err := service.GetCurrentTideForStation(context.Background(), "TEST001", &from, &to)
+	require.NoError(t, err)
+	assert.Nil(t, response.Weather, "weather is only included when requested")
+
+	response, err = service.GetCurrentTideForStation(WithWeather(context.Background()), "TEST001", &from, &to)
+	require.NoError(t, err)
+	require.NotNil(t, response.Weather)
+	assert.Equal(t, 47.6062, forecastLat)
+	assert.Equal(t, -122.3321, forecastLon)
+	assert.True(t, response.Weather.Available)
+	assert.Empty(t, response.Warnings)
+	assert.Equal(t, models.WeatherSourceNWS, response.Weather.Source)
+
+	// Only the hours in the range, in the station's local time (UTC+1)
+	require.Len(t, response.Weather.Forecast, 6)
+	assert.Equal(t, "2024-01-02T00:00:00", response.Weather.Forecast[0].LocalTime)
+	assert.Equal(t, 23.0, *response.Weather.Forecast[0].WindSpeedKnots)
+	assert.Equal(t, "2024-01-02T05:00:00", response.Weather.Forecast[5].LocalTime)
+}
+
+func TestGetCurrentTideForStation_WeatherUnavailable(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stations := map[string]*models.Station{"TEST001": createTestStation(0)}
+	service := newExtremesService(stations, map[string][]models.TideExtreme{
+		"TEST001": semidiurnalExtremes(start, 0, 0, 10, 13),
+	})
+	from, to := "2024-01-02T00:00:00", "2024-01-02T05:59:59"
+	ctx := WithWeather(context.Background())
+
+	tests := []struct {
+		name       string
+		forecaster *mockForecaster
+	}{
+		{"no forecaster", nil},
+		{"forecaster fails", &mockForecaster{forecastFn: func(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error) {
+			return nil, errors.New("NWS API returned status 503")
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.Weather = nil
+			if tt.forecaster != nil {
+				service.Weather = tt.forecaster
+			}
+
+			// The tides are still returned
+			response, err := service.GetCurrentTideForStation(ctx, "TEST001", &from, &to)
+			require.NoError(t, err)
+			assert.NotEmpty(t, response.Predictions)
+			require.NotNil(t, response.Weather)
+			assert.False(t, response.Weather.Available)
+			assert.NotNil(t, response.Weather.Forecast)
+			assert.Empty(t, response.Weather.Forecast)
+			require.Len(t, response.Warnings, 1)
+			assert.Equal(t, models.WarningWeatherUnavailable, response.Warnings[0].Code)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package tidetable renders a station's highs and lows for a month as a plain HTML page,
+// laid out to print on a sheet of paper without the web app.
+package tidetable
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+//go:embed table.html
+var pageTemplate string
+
+var page = template.Must(template.New("table").Parse(pageTemplate))
+
+// MonthLayout is how a month is written in requests
+const MonthLayout = "2006-01"
+
+// MonthPattern matches a month in MonthLayout
+const MonthPattern = `^\d{4}-(0[1-9]|1[0-2])$`
+
+// data is what the page template is filled with
+type data struct {
+	StationName string
+	StationID   string
+	State       string
+	Position    string
+	Reference   string
+	TimeZone    string
+	Month       string
+	// Columns is the most highs and lows any day has; shorter rows are padded to it
+	Columns int
+	Rows    []row
+}
+
+type row struct {
+	Date    string
+	Weekend bool
+	Cells   []cell
+}
+
+// cell is an extreme, or padding when it's empty. Type, high or low, is also its class.
+type cell struct {
+	Type   string
+	Time   string
+	Height string
+}
+
+// Render renders the page for station's month, whose days are in summary. Offsets on a
+// subordinate station name the reference station it's predicted from.
+func Render(station *models.Station, month time.Time, summary *models.ExtremesSummary) ([]byte, error) {
+	d := data{
+		StationName: station.Name,
+		StationID:   station.ID,
+		Position:    position(station.Latitude, station.Longitude),
+		TimeZone:    station.TimeZone,
+		Month:       month.Format("January 2006"),
+	}
+	if station.State != nil {
+		d.State = *station.State
+	}
+	if d.TimeZone == "" {
+		d.TimeZone = month.In(station.Location()).Format("UTC-07:00")
+	}
+	if station.Offsets != nil {
+		d.Reference = reference(station.Offsets)
+	}
+
+	for _, day := range summary.Days {
+		r := row{Date: day.Date}
+		if date, err := time.Parse("2006-01-02", day.Date); err == nil {
+			r.Date = date.Format("Mon 02")
+			r.Weekend = date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+		}
+		for _, e := range day.Extremes {
+			r.Cells = append(r.Cells, cell{
+				Type:   strings.ToLower(string(e.Type)),
+				Time:   e.Time,
+				Height: fmt.Sprintf("%.2f ft", e.Height),
+			})
+		}
+		d.Columns = max(d.Columns, len(r.Cells))
+		d.Rows = append(d.Rows, r)
+	}
+	for i := range d.Rows {
+		for len(d.Rows[i].Cells) < d.Columns {
+			d.Rows[i].Cells = append(d.Rows[i].Cells, cell{})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := page.Execute(&buf, d); err != nil {
+		return nil, fmt.Errorf("rendering tide table: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// position writes a latitude and longitude in degrees with their hemispheres
+func position(lat, lon float64) string {
+	ns, ew := "N", "E"
+	if lat < 0 {
+		ns, lat = "S", -lat
+	}
+	if lon < 0 {
+		ew, lon = "W", -lon
+	}
+	return fmt.Sprintf("%.4f° %s, %.4f° %s", lat, ns, lon, ew)
+}
+
+// reference names the reference station and how far its highs and lows are shifted
+func reference(offsets *models.TideOffsets) string {
+	name := offsets.ReferenceStationID
+	if offsets.ReferenceStationName != "" {
+		name = fmt.Sprintf("%s (%s)", offsets.ReferenceStationName, offsets.ReferenceStationID)
+	}
+	return fmt.Sprintf("%s, highs %s, lows %s", name, minutes(offsets.TimeOffsetHighMinutes), minutes(offsets.TimeOffsetLowMinutes))
+}
+
+// minutes writes an offset as a signed hours and minutes, e.g. +0:24
+func minutes(m int) string {
+	sign := "+"
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d:%02d", sign, m/60, m%60)
+}
@@ -0,0 +1,83 @@
+package tidetable
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	state := "WA"
+	station := &models.Station{
+		ID:        "9447130",
+		Name:      "Seattle <Elliott Bay>",
+		State:     &state,
+		Latitude:  47.6026,
+		Longitude: -122.3393,
+		TimeZone:  "America/Los_Angeles",
+	}
+	summary := &models.ExtremesSummary{
+		StationID: "9447130",
+		Days: []models.DailyExtremes{
+			{Date: "2025-02-01", Extremes: []models.CompactExtreme{
+				{Type: models.TideTypeHigh, Time: "04:12", Height: 11.5},
+				{Type: models.TideTypeLow, Time: "10:48", Height: -1.25},
+				{Type: models.TideTypeHigh, Time: "17:02", Height: 9.8},
+				{Type: models.TideTypeLow, Time: "23:30", Height: 4.1},
+			}},
+			{Date: "2025-02-03", Extremes: []models.CompactExtreme{
+				{Type: models.TideTypeHigh, Time: "05:40", Height: 11.1},
+				{Type: models.TideTypeLow, Time: "12:15", Height: 0.3},
+				{Type: models.TideTypeHigh, Time: "18:55", Height: 10.2},
+			}},
+		},
+	}
+
+	page, err := Render(station, time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC), summary)
+	require.NoError(t, err)
+	html := string(page)
+
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, "<h1>Seattle &lt;Elliott Bay&gt;</h1>", "station names are escaped")
+	assert.Contains(t, html, "Tide table for February 2025")
+	assert.Contains(t, html, "<dd>9447130, WA</dd>")
+	assert.Contains(t, html, "47.6026° N, 122.3393° W")
+	assert.Contains(t, html, "<dd>America/Los_Angeles</dd>")
+	assert.NotContains(t, html, "Predicted from")
+	assert.Contains(t, html, `<th colspan="4">`)
+	assert.Contains(t, html, `<tr class="weekend"><td>Sat 01</td><td class="high"><span class="type">high</span> 04:12 <span class="height">11.50 ft</span></td>`)
+	assert.Contains(t, html, `<tr><td>Mon 03</td>`)
+	assert.Contains(t, html, `<td class="low"><span class="type">low</span> 12:15 <span class="height">0.30 ft</span></td>`)
+	assert.Equal(t, 1, strings.Count(html, "<td></td>"), "the shorter day is padded to four cells")
+}
+
+func TestRender_Subordinate(t *testing.T) {
+	station := &models.Station{
+		ID:             "9446484",
+		Name:           "Tacoma",
+		StationType:    models.StationKindSubordinate,
+		TimeZoneOffset: -8 * 3600,
+		Offsets: &models.TideOffsets{
+			ReferenceStationID:    "9447130",
+			ReferenceStationName:  "Seattle",
+			TimeOffsetHighMinutes: 24,
+			TimeOffsetLowMinutes:  -75,
+		},
+	}
+
+	page, err := Render(station, time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC), &models.ExtremesSummary{})
+	require.NoError(t, err)
+	html := string(page)
+	assert.Contains(t, html, "<dd>Seattle (9447130), highs &#43;0:24, lows -1:15</dd>")
+	assert.Contains(t, html, "<dd>UTC-08:00</dd>", "stations without a zone show their standard offset")
+}
+
+func TestMinutes(t *testing.T) {
+	assert.Equal(t, "+0:00", minutes(0))
+	assert.Equal(t, "+1:05", minutes(65))
+	assert.Equal(t, "-0:30", minutes(-30))
+}
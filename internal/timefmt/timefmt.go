@@ -0,0 +1,189 @@
+// Package timefmt writes the localTime strings of responses in a caller's locale and
+// clock, so display code doesn't have to re-parse and re-format every timestamp. Without
+// any options the ISO 8601 form the service produces is left as it is.
+package timefmt
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// isoLayout is the layout the tide service writes localTime strings in
+const isoLayout = validate.DateTimeFormat
+
+// locale is how one locale writes a date and time of day
+type locale struct {
+	date   string // Go layout of the date
+	hour12 bool   // whether the locale uses a 12-hour clock by default
+	am, pm string
+	// markerFirst puts the AM/PM marker before the time, as in 午後3:04:05
+	markerFirst bool
+}
+
+var locales = map[string]locale{
+	"en-US": {date: "1/2/2006", hour12: true, am: "AM", pm: "PM"},
+	"en-GB": {date: "02/01/2006", am: "am", pm: "pm"},
+	"en-AU": {date: "2/01/2006", hour12: true, am: "am", pm: "pm"},
+	"en-CA": {date: "2006-01-02", hour12: true, am: "a.m.", pm: "p.m."},
+	"de-DE": {date: "2.1.2006", am: "AM", pm: "PM"},
+	"es-ES": {date: "2/1/2006", am: "a. m.", pm: "p. m."},
+	"es-MX": {date: "2/1/2006", hour12: true, am: "a.m.", pm: "p.m."},
+	"fr-FR": {date: "02/01/2006", am: "AM", pm: "PM"},
+	"fr-CA": {date: "2006-01-02", am: "a.m.", pm: "p.m."},
+	"it-IT": {date: "2/1/2006", am: "AM", pm: "PM"},
+	"nl-NL": {date: "2-1-2006", am: "a.m.", pm: "p.m."},
+	"pt-BR": {date: "02/01/2006", am: "AM", pm: "PM"},
+	"ja-JP": {date: "2006/1/2", am: "午前", pm: "午後", markerFirst: true},
+	"zh-CN": {date: "2006/1/2", am: "上午", pm: "下午", markerFirst: true},
+}
+
+// Locales lists the supported locale tags
+var Locales = []string{
+	"en-US", "en-GB", "en-AU", "en-CA", "de-DE", "es-ES", "es-MX",
+	"fr-FR", "fr-CA", "it-IT", "nl-NL", "pt-BR", "ja-JP", "zh-CN",
+}
+
+// Options choose how local times are written. The zero value keeps ISO 8601.
+type Options struct {
+	// Locale is one of Locales, or empty for ISO 8601 dates
+	Locale string
+	// Hour12 picks a 12-hour clock with an AM/PM marker, or a 24-hour one; nil uses the
+	// locale's convention
+	Hour12 *bool
+}
+
+// New checks a locale tag, matched without regard to case, and returns options for it.
+// An empty locale keeps ISO 8601 dates.
+func New(localeTag string, hour12 *bool) (Options, error) {
+	if localeTag == "" {
+		return Options{Hour12: hour12}, nil
+	}
+	for _, tag := range Locales {
+		if strings.EqualFold(localeTag, tag) {
+			return Options{Locale: tag, Hour12: hour12}, nil
+		}
+	}
+	return Options{}, validate.OneOfFold("locale", localeTag, Locales...)
+}
+
+// IsDefault reports whether the options leave localTime strings unchanged
+func (o Options) IsDefault() bool {
+	return o.Locale == "" && (o.Hour12 == nil || !*o.Hour12)
+}
+
+// Format writes t, which should already be in the station's time zone
+func (o Options) Format(t time.Time) string {
+	if o.IsDefault() {
+		return t.Format(isoLayout)
+	}
+
+	l, ok := locales[o.Locale]
+	if !ok {
+		// No locale but a 12-hour clock: an ISO date with the time of day as in en-US
+		l = locale{date: "2006-01-02", hour12: true, am: "AM", pm: "PM"}
+	}
+	hour12 := l.hour12
+	if o.Hour12 != nil {
+		hour12 = *o.Hour12
+	}
+
+	date := t.Format(l.date)
+	if !hour12 {
+		return date + " " + t.Format("15:04:05")
+	}
+	marker := l.am
+	if t.Hour() >= 12 {
+		marker = l.pm
+	}
+	if l.markerFirst {
+		return date + " " + marker + t.Format("3:04:05")
+	}
+	return date + " " + t.Format("3:04:05") + " " + marker
+}
+
+// LocalTime rewrites an ISO 8601 localTime string. Strings in any other form, including
+// empty ones, are returned as they are.
+func (o Options) LocalTime(localTime string) string {
+	if o.IsDefault() {
+		return localTime
+	}
+	t, err := time.Parse(isoLayout, localTime)
+	if err != nil {
+		return localTime
+	}
+	return o.Format(t)
+}
+
+// Apply rewrites every localTime in response. Predictions, extremes and the rest are
+// copied before they're changed, since they may be shared with the tide cache.
+func (o Options) Apply(response *models.ExtendedTideResponse) {
+	if o.IsDefault() || response == nil {
+		return
+	}
+
+	response.LocalTime = o.LocalTime(response.LocalTime)
+
+	if response.Predictions != nil {
+		predictions := make([]models.TidePrediction, len(response.Predictions))
+		for i, p := range response.Predictions {
+			p.LocalTime = o.LocalTime(p.LocalTime)
+			predictions[i] = p
+		}
+		response.Predictions = predictions
+	}
+
+	if response.Extremes != nil {
+		extremes := make([]models.TideExtreme, len(response.Extremes))
+		for i, e := range response.Extremes {
+			extremes[i] = o.extreme(e)
+		}
+		response.Extremes = extremes
+	}
+
+	if s := response.Summary; s != nil {
+		summary := *s
+		if s.NextHigh != nil {
+			high := o.extreme(*s.NextHigh)
+			summary.NextHigh = &high
+		}
+		if s.NextLow != nil {
+			low := o.extreme(*s.NextLow)
+			summary.NextLow = &low
+		}
+		response.Summary = &summary
+	}
+
+	if c := response.Conditions; c != nil {
+		conditions := *c
+		conditions.WaterTemperature = o.observation(c.WaterTemperature)
+		conditions.Conductivity = o.observation(c.Conductivity)
+		response.Conditions = &conditions
+	}
+
+	if w := response.Weather; w != nil && w.Forecast != nil {
+		weather := *w
+		weather.Forecast = make([]models.WeatherForecast, len(w.Forecast))
+		for i, f := range w.Forecast {
+			f.LocalTime = o.LocalTime(f.LocalTime)
+			weather.Forecast[i] = f
+		}
+		response.Weather = &weather
+	}
+}
+
+func (o Options) extreme(e models.TideExtreme) models.TideExtreme {
+	e.LocalTime = o.LocalTime(e.LocalTime)
+	return e
+}
+
+func (o Options) observation(obs *models.Observation) *models.Observation {
+	if obs == nil {
+		return nil
+	}
+	copied := *obs
+	copied.LocalTime = o.LocalTime(copied.LocalTime)
+	return &copied
+}
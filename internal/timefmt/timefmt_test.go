@@ -0,0 +1,101 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestFormat(t *testing.T) {
+	afternoon := time.Date(2024, 3, 9, 15, 4, 5, 0, time.UTC)
+	morning := time.Date(2024, 3, 9, 0, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		locale string
+		hour12 *bool
+		t      time.Time
+		want   string
+	}{
+		{"default", "", nil, afternoon, "2024-03-09T15:04:05"},
+		{"24-hour without locale", "", boolPtr(false), afternoon, "2024-03-09T15:04:05"},
+		{"12-hour without locale", "", boolPtr(true), afternoon, "2024-03-09 3:04:05 PM"},
+		{"en-US", "en-US", nil, afternoon, "3/9/2024 3:04:05 PM"},
+		{"en-US midnight hour", "en-US", nil, morning, "3/9/2024 12:30:00 AM"},
+		{"en-US 24-hour", "en-US", boolPtr(false), afternoon, "3/9/2024 15:04:05"},
+		{"en-GB", "en-GB", nil, afternoon, "09/03/2024 15:04:05"},
+		{"en-GB 12-hour", "en-GB", boolPtr(true), afternoon, "09/03/2024 3:04:05 pm"},
+		{"de-DE", "de-DE", nil, afternoon, "9.3.2024 15:04:05"},
+		{"ja-JP 12-hour", "ja-JP", boolPtr(true), afternoon, "2024/3/9 午後3:04:05"},
+		{"lower case", "fr-fr", nil, morning, "09/03/2024 00:30:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := New(tt.locale, tt.hour12)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, options.Format(tt.t))
+		})
+	}
+}
+
+func TestNewUnknownLocale(t *testing.T) {
+	_, err := New("en-UK", nil)
+	var verr *validate.Error
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "locale", verr.Parameter)
+	assert.Equal(t, "en-US", verr.Suggestion)
+}
+
+func TestLocalTime(t *testing.T) {
+	options, err := New("en-US", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1/15/2024 6:00:00 AM", options.LocalTime("2024-01-15T06:00:00"))
+	// Anything that isn't an ISO local time is left alone
+	assert.Equal(t, "", options.LocalTime(""))
+	assert.Equal(t, "06:00", options.LocalTime("06:00"))
+}
+
+func TestApply(t *testing.T) {
+	cached := []models.TidePrediction{{Timestamp: 1, LocalTime: "2024-01-15T06:00:00", Height: 1}}
+	high := models.TideExtreme{Type: models.TideTypeHigh, LocalTime: "2024-01-15T13:30:00", Height: 9}
+	response := &models.ExtendedTideResponse{
+		LocalTime:   "2024-01-15T06:00:00",
+		Predictions: cached,
+		Extremes:    []models.TideExtreme{high},
+		Summary:     &models.TideSummary{NextHigh: &high},
+		Conditions: &models.WaterConditions{
+			WaterTemperature: &models.Observation{LocalTime: "2024-01-15T05:54:00", Value: 48},
+		},
+	}
+
+	options, err := New("en-GB", nil)
+	require.NoError(t, err)
+	options.Apply(response)
+
+	assert.Equal(t, "15/01/2024 06:00:00", response.LocalTime)
+	assert.Equal(t, "15/01/2024 06:00:00", response.Predictions[0].LocalTime)
+	assert.Equal(t, "15/01/2024 13:30:00", response.Extremes[0].LocalTime)
+	assert.Equal(t, "15/01/2024 13:30:00", response.Summary.NextHigh.LocalTime)
+	assert.Equal(t, "15/01/2024 05:54:00", response.Conditions.WaterTemperature.LocalTime)
+	assert.Nil(t, response.Conditions.Conductivity)
+	assert.Nil(t, response.Weather)
+
+	// The values the response shared with its caller are untouched
+	assert.Equal(t, "2024-01-15T06:00:00", cached[0].LocalTime)
+	assert.Equal(t, "2024-01-15T13:30:00", high.LocalTime)
+}
+
+func TestApplyDefaultLeavesResponse(t *testing.T) {
+	response := &models.ExtendedTideResponse{LocalTime: "2024-01-15T06:00:00"}
+	Options{}.Apply(response)
+	assert.Equal(t, "2024-01-15T06:00:00", response.LocalTime)
+	Options{}.Apply(nil)
+}
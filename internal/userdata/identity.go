@@ -0,0 +1,49 @@
+package userdata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+type userIDKey struct{}
+
+// WithUserID returns a context carrying the caller's user ID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the user ID set by WithUserID
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// UserIDFromRequest identifies the caller from what API Gateway verified: the sub claim
+// of a Cognito user pool authorizer, or else the API key the request was made with.
+// It returns "" for anonymous requests. Raw API keys are hashed so they're never stored.
+func UserIDFromRequest(request events.APIGatewayProxyRequest) string {
+	if claims, ok := request.RequestContext.Authorizer["claims"].(map[string]interface{}); ok {
+		if sub, ok := claims["sub"].(string); ok && sub != "" {
+			return "cognito:" + sub
+		}
+	}
+
+	return APIKeyFromRequest(request)
+}
+
+// APIKeyFromRequest identifies the API key a request was made with, as UserIDFromRequest
+// does for callers without a Cognito token, or returns "" for requests made without one
+func APIKeyFromRequest(request events.APIGatewayProxyRequest) string {
+	identity := request.RequestContext.Identity
+	if identity.APIKeyID != "" {
+		return "apikey:" + identity.APIKeyID
+	}
+	if identity.APIKey != "" {
+		sum := sha256.Sum256([]byte(identity.APIKey))
+		return "apikey:" + hex.EncodeToString(sum[:16])
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package userdata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserIDFromRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		context events.APIGatewayProxyRequestContext
+		want    string
+	}{
+		{
+			name: "cognito claim",
+			context: events.APIGatewayProxyRequestContext{
+				Authorizer: map[string]interface{}{"claims": map[string]interface{}{"sub": "abc-123"}},
+				Identity:   events.APIGatewayRequestIdentity{APIKeyID: "key-1"},
+			},
+			want: "cognito:abc-123",
+		},
+		{
+			name:    "api key ID",
+			context: events.APIGatewayProxyRequestContext{Identity: events.APIGatewayRequestIdentity{APIKeyID: "key-1", APIKey: "s3cret"}},
+			want:    "apikey:key-1",
+		},
+		{
+			name:    "hashed api key",
+			context: events.APIGatewayProxyRequestContext{Identity: events.APIGatewayRequestIdentity{APIKey: "s3cret"}},
+			want:    "apikey:1ec1c26b50d5d3c58d9583181af80766",
+		},
+		{
+			name:    "anonymous",
+			context: events.APIGatewayProxyRequestContext{Authorizer: map[string]interface{}{"claims": map[string]interface{}{}}},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UserIDFromRequest(events.APIGatewayProxyRequest{RequestContext: tt.context}))
+		})
+	}
+}
+
+func TestAPIKeyFromRequest(t *testing.T) {
+	request := events.APIGatewayProxyRequest{}
+	request.RequestContext.Authorizer = map[string]interface{}{"claims": map[string]interface{}{"sub": "abc-123"}}
+	assert.Empty(t, APIKeyFromRequest(request), "a Cognito user isn't an API key")
+
+	request.RequestContext.Identity.APIKeyID = "key-1"
+	assert.Equal(t, "apikey:key-1", APIKeyFromRequest(request))
+}
+
+func TestUserIDContext(t *testing.T) {
+	_, ok := UserIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	_, ok = UserIDFromContext(WithUserID(context.Background(), ""))
+	assert.False(t, ok)
+
+	userID, ok := UserIDFromContext(WithUserID(context.Background(), "cognito:abc-123"))
+	assert.True(t, ok)
+	assert.Equal(t, "cognito:abc-123", userID)
+}
@@ -0,0 +1,224 @@
+package userdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/audit"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/persist"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxUpdateAttempts bounds retries when another device saves the same profile first
+	maxUpdateAttempts = 3
+
+	// DeletedRetention is how long a removed favorite can be restored
+	DeletedRetention = 30 * 24 * time.Hour
+
+	// Resources as the audit log names them
+	favoriteResource    = "favorite"
+	preferencesResource = "preferences"
+)
+
+var errTooManyFavorites = fmt.Errorf("at most %d favorite stations are allowed", models.MaxFavorites)
+
+// Service reads and edits user profiles
+type Service struct {
+	store  Store
+	finder models.StationFinder
+	// auditLog records every change saved; nil doesn't
+	auditLog audit.Log
+	now      func() time.Time
+}
+
+func NewService(store Store, finder models.StationFinder) *Service {
+	return &Service{store: store, finder: finder, now: time.Now}
+}
+
+// UseAuditLog has the service record every change it saves in auditLog
+func (s *Service) UseAuditLog(auditLog audit.Log) {
+	s.auditLog = auditLog
+}
+
+// Profile returns the user's profile, or the defaults when nothing is saved yet. Removed
+// favorites past restoring are left out, and so purged when the profile is next saved.
+func (s *Service) Profile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	profile, err := s.store.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		return models.NewUserProfile(userID), nil
+	}
+	profile.DeletedFavorites = persist.Purge(profile.DeletedFavorites, s.now(), DeletedRetention)
+	return profile, nil
+}
+
+// change is what an edit did to a resource, for the audit log
+type change struct {
+	resource   string
+	resourceID string
+	action     audit.Action
+	// before and after are the resource; before is nil for a create
+	before, after interface{}
+}
+
+// AddFavorite saves a station to the user's favorites. Adding a station that's already a
+// favorite leaves the profile unchanged, and adding one removed recently restores it.
+func (s *Service) AddFavorite(ctx context.Context, userID, stationID string) (*models.UserProfile, error) {
+	station, err := models.FindStation(ctx, s.finder, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+
+	return s.update(ctx, userID, func(profile *models.UserProfile) (*change, error) {
+		if profile.FavoriteIndex(station.ID) >= 0 {
+			return nil, nil
+		}
+		if len(profile.Favorites) >= models.MaxFavorites {
+			return nil, errTooManyFavorites
+		}
+		if i := profile.DeletedFavoriteIndex(station.ID); i >= 0 {
+			return s.restore(profile, i), nil
+		}
+		favorite := models.FavoriteStation{StationID: station.ID, Name: station.Name}
+		favorite.Create(s.now())
+		profile.Favorites = append(profile.Favorites, favorite)
+		return &change{resource: favoriteResource, resourceID: station.ID, action: audit.ActionCreate, after: favorite}, nil
+	})
+}
+
+// RemoveFavorite drops a station from the user's favorites, if it's there. It can be
+// restored for DeletedRetention.
+func (s *Service) RemoveFavorite(ctx context.Context, userID, stationID string) (*models.UserProfile, error) {
+	return s.update(ctx, userID, func(profile *models.UserProfile) (*change, error) {
+		i := profile.FavoriteIndex(stationID)
+		if i < 0 {
+			return nil, nil
+		}
+		before := profile.Favorites[i]
+		profile.Favorites = append(profile.Favorites[:i], profile.Favorites[i+1:]...)
+
+		deleted := before
+		deleted.Delete(s.now())
+		profile.DeletedFavorites = append(profile.DeletedFavorites, deleted)
+		if over := len(profile.DeletedFavorites) - models.MaxFavorites; over > 0 {
+			// Those removed longest ago can no longer be restored
+			profile.DeletedFavorites = profile.DeletedFavorites[over:]
+		}
+		return &change{resource: favoriteResource, resourceID: stationID, action: audit.ActionDelete, before: before, after: deleted}, nil
+	})
+}
+
+// RestoreFavorite puts back a favorite the user removed within DeletedRetention. Restoring
+// a station that's a favorite leaves the profile unchanged.
+func (s *Service) RestoreFavorite(ctx context.Context, userID, stationID string) (*models.UserProfile, error) {
+	return s.update(ctx, userID, func(profile *models.UserProfile) (*change, error) {
+		if profile.FavoriteIndex(stationID) >= 0 {
+			return nil, nil
+		}
+		i := profile.DeletedFavoriteIndex(stationID)
+		if i < 0 {
+			return nil, &validate.Error{Parameter: "stationId", Value: stationID, Message: "isn't a recently removed favorite"}
+		}
+		if len(profile.Favorites) >= models.MaxFavorites {
+			return nil, errTooManyFavorites
+		}
+		return s.restore(profile, i), nil
+	})
+}
+
+// restore moves the removed favorite at i back to the end of the favorites
+func (s *Service) restore(profile *models.UserProfile, i int) *change {
+	before := profile.DeletedFavorites[i]
+	profile.DeletedFavorites = append(profile.DeletedFavorites[:i], profile.DeletedFavorites[i+1:]...)
+
+	restored := before
+	restored.Restore(s.now())
+	profile.Favorites = append(profile.Favorites, restored)
+	return &change{resource: favoriteResource, resourceID: restored.StationID, action: audit.ActionRestore, before: before, after: restored}
+}
+
+// preferences is how the audit log shows a profile's preferences
+type preferences struct {
+	Units string `json:"units"`
+	Datum string `json:"datum"`
+}
+
+// UpdatePreferences sets the user's preferred units and datum; nil leaves one unchanged
+func (s *Service) UpdatePreferences(ctx context.Context, userID string, units, datum *string) (*models.UserProfile, error) {
+	if units != nil {
+		if err := models.ValidateUnits(*units); err != nil {
+			return nil, err
+		}
+	}
+	if datum != nil {
+		if err := models.ValidateDatum(*datum); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.update(ctx, userID, func(profile *models.UserProfile) (*change, error) {
+		before := preferences{Units: profile.Units, Datum: profile.Datum}
+		if units != nil {
+			profile.Units = *units
+		}
+		if datum != nil {
+			profile.Datum = *datum
+		}
+		after := preferences{Units: profile.Units, Datum: profile.Datum}
+		if after == before {
+			return nil, nil
+		}
+		return &change{resource: preferencesResource, resourceID: userID, action: audit.ActionUpdate, before: before, after: after}, nil
+	})
+}
+
+// update applies edit to the current profile and saves it when edit reports a change,
+// starting over from a fresh read if another device saved in between. The change saved
+// is recorded in the audit log.
+func (s *Service) update(ctx context.Context, userID string, edit func(*models.UserProfile) (*change, error)) (*models.UserProfile, error) {
+	for attempt := 1; ; attempt++ {
+		profile, err := s.Profile(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := edit(profile)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return profile, nil
+		}
+		profile.UpdatedAt = s.now().Unix()
+
+		err = s.store.SaveProfile(ctx, profile)
+		if err == nil {
+			s.record(ctx, userID, c)
+			return profile, nil
+		}
+		if !errors.Is(err, ErrConflict) || attempt == maxUpdateAttempts {
+			return nil, fmt.Errorf("saving profile: %w", err)
+		}
+	}
+}
+
+// record adds a saved change to the audit log. The change stands even if it can't be.
+func (s *Service) record(ctx context.Context, userID string, c *change) {
+	if s.auditLog == nil {
+		return
+	}
+	entry, err := audit.NewChange(userID, c.resource, c.resourceID, c.action, c.before, c.after, s.now())
+	if err == nil {
+		err = s.auditLog.Record(ctx, entry)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("userId", userID).Str("resource", c.resource).Msg("Failed to record change in audit log")
+	}
+}
@@ -0,0 +1,215 @@
+package userdata
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/audit"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/persist"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stationFinder knows Seattle and Tacoma
+func stationFinder() *testsupport.StationFinder {
+	return &testsupport.StationFinder{Stations: []models.Station{
+		{ID: "9447130", Name: "Seattle"},
+		{ID: "9446484", Name: "Tacoma"},
+	}}
+}
+
+func newTestService() (*Service, *fakeDynamoDB) {
+	client := newFakeDynamoDB()
+	service := NewService(NewDynamoStore(client, "profiles"), stationFinder())
+	service.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return service, client
+}
+
+func TestService_DefaultProfile(t *testing.T) {
+	service, client := newTestService()
+
+	profile, err := service.Profile(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.NewUserProfile("user-1"), profile)
+	assert.Zero(t, client.puts, "reading doesn't create a profile")
+}
+
+func TestService_Favorites(t *testing.T) {
+	ctx := context.Background()
+	service, client := newTestService()
+
+	profile, err := service.AddFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, []models.FavoriteStation{{
+		StationID: "9447130",
+		Name:      "Seattle",
+		Stamps:    persist.Stamps{CreatedAt: 1700000000, UpdatedAt: 1700000000},
+	}}, profile.Favorites)
+	assert.Equal(t, int64(1700000000), profile.UpdatedAt)
+
+	_, err = service.AddFavorite(ctx, "user-1", "9446484")
+	require.NoError(t, err)
+	profile, err = service.AddFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	assert.Len(t, profile.Favorites, 2)
+	assert.Equal(t, 2, client.puts, "adding an existing favorite doesn't write")
+
+	profile, err = service.RemoveFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, "9446484", profile.Favorites[0].StationID)
+	assert.Len(t, profile.Favorites, 1)
+	require.Len(t, profile.DeletedFavorites, 1, "a removed favorite is kept to be restored")
+	assert.Equal(t, int64(1700000000), profile.DeletedFavorites[0].DeletedAt)
+
+	_, err = service.RemoveFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, 3, client.puts, "removing a missing favorite doesn't write")
+
+	_, err = service.AddFavorite(ctx, "user-1", "0000000")
+	assert.ErrorContains(t, err, "finding station: station not found")
+}
+
+func TestService_FavoritesLimit(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+
+	full := models.NewUserProfile("user-1")
+	for i := 0; i < models.MaxFavorites; i++ {
+		full.Favorites = append(full.Favorites, models.FavoriteStation{StationID: fmt.Sprintf("S%d", i)})
+	}
+	require.NoError(t, service.store.SaveProfile(ctx, full))
+
+	_, err := service.AddFavorite(ctx, "user-1", "9447130")
+	assert.ErrorContains(t, err, "at most 100 favorite stations")
+}
+
+// recordingLog keeps the changes it's given
+type recordingLog struct {
+	changes []audit.Change
+}
+
+func (l *recordingLog) Record(_ context.Context, change audit.Change) error {
+	l.changes = append(l.changes, change)
+	return nil
+}
+
+func TestService_RestoreFavorite(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+	auditLog := &recordingLog{}
+	service.UseAuditLog(auditLog)
+
+	_, err := service.AddFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	_, err = service.RemoveFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+
+	service.now = func() time.Time { return time.Unix(1700000000, 0).Add(time.Hour) }
+	profile, err := service.RestoreFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, []models.FavoriteStation{{
+		StationID: "9447130",
+		Name:      "Seattle",
+		Stamps:    persist.Stamps{CreatedAt: 1700000000, UpdatedAt: 1700003600},
+	}}, profile.Favorites, "a restored favorite keeps when it was added")
+	assert.Empty(t, profile.DeletedFavorites)
+
+	_, err = service.RestoreFavorite(ctx, "user-1", "9446484")
+	assert.ErrorContains(t, err, `invalid stationId "9446484": isn't a recently removed favorite`)
+
+	// Adding a removed favorite again restores it too
+	_, err = service.RemoveFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	profile, err = service.AddFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1700000000), profile.Favorites[0].CreatedAt)
+
+	actions := make([]audit.Action, len(auditLog.changes))
+	for i, change := range auditLog.changes {
+		actions[i] = change.Action
+		assert.Equal(t, "user-1", change.UserID)
+		assert.Equal(t, "favorite", change.Resource)
+		assert.Equal(t, "9447130", change.ResourceID)
+	}
+	assert.Equal(t, []audit.Action{audit.ActionCreate, audit.ActionDelete, audit.ActionRestore, audit.ActionDelete, audit.ActionRestore}, actions)
+	assert.Empty(t, auditLog.changes[0].Before)
+	assert.JSONEq(t, `{"stationId":"9447130","name":"Seattle","createdAt":1700000000,"updatedAt":1700000000}`, auditLog.changes[0].After)
+	assert.JSONEq(t, `{"stationId":"9447130","name":"Seattle","createdAt":1700000000,"updatedAt":1700000000,"deletedAt":1700000000}`, auditLog.changes[1].After)
+}
+
+func TestService_DeletedFavoritesExpire(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+	_, err := service.AddFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+	_, err = service.RemoveFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+
+	service.now = func() time.Time { return time.Unix(1700000000, 0).Add(DeletedRetention + time.Second) }
+	profile, err := service.Profile(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, profile.DeletedFavorites)
+	_, err = service.RestoreFavorite(ctx, "user-1", "9447130")
+	assert.ErrorContains(t, err, "isn't a recently removed favorite")
+}
+
+func TestService_UpdatePreferences(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newTestService()
+
+	metric, msl := models.UnitsMetric, "MSL"
+	profile, err := service.UpdatePreferences(ctx, "user-1", &metric, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.UnitsMetric, profile.Units)
+	assert.Equal(t, models.DefaultDatum, profile.Datum)
+
+	profile, err = service.UpdatePreferences(ctx, "user-1", nil, &msl)
+	require.NoError(t, err)
+	assert.Equal(t, models.UnitsMetric, profile.Units)
+	assert.Equal(t, "MSL", profile.Datum)
+
+	bad := "cubits"
+	_, err = service.UpdatePreferences(ctx, "user-1", &bad, nil)
+	assert.ErrorContains(t, err, `invalid units "cubits"`)
+	_, err = service.UpdatePreferences(ctx, "user-1", nil, &bad)
+	assert.ErrorContains(t, err, `invalid datum "cubits"`)
+}
+
+func TestService_RetriesConflicts(t *testing.T) {
+	ctx := context.Background()
+	service, client := newTestService()
+	_, err := service.AddFavorite(ctx, "user-1", "9447130")
+	require.NoError(t, err)
+
+	// Another device adds a favorite between our read and our write, once
+	other := NewService(NewDynamoStore(client, "profiles"), stationFinder())
+	client.beforePut = func() {
+		client.beforePut = nil
+		_, err := other.AddFavorite(ctx, "user-1", "9446484")
+		require.NoError(t, err)
+	}
+
+	metric := models.UnitsMetric
+	profile, err := service.UpdatePreferences(ctx, "user-1", &metric, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.UnitsMetric, profile.Units)
+	assert.Len(t, profile.Favorites, 2, "the other device's favorite survives")
+
+	// A profile that keeps changing gives up after maxUpdateAttempts
+	var conflicts int
+	client.beforePut = func() {
+		conflicts++
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		client.items["user-1"]["version"] = &types.AttributeValueMemberN{Value: strconv.Itoa(1000 + conflicts)}
+	}
+	_, err = service.RemoveFavorite(ctx, "user-1", "9447130")
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, maxUpdateAttempts, conflicts)
+}
@@ -0,0 +1,124 @@
+// Package userdata keeps each user's favorite stations and display preferences so
+// clients can sync them across devices
+package userdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/cache"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// ErrConflict is returned when a profile changed between reading and saving it
+var ErrConflict = errors.New("profile was modified concurrently")
+
+// Store persists user profiles
+type Store interface {
+	// GetProfile returns nil when the user hasn't saved a profile yet
+	GetProfile(ctx context.Context, userID string) (*models.UserProfile, error)
+	// SaveProfile writes the profile only if its Version still matches the stored one,
+	// returning ErrConflict otherwise, and increments Version on success
+	SaveProfile(ctx context.Context, profile *models.UserProfile) error
+}
+
+// DynamoStore keeps one item per user, keyed by userId
+type DynamoStore struct {
+	client cache.DynamoDBClient
+	table  string
+}
+
+func NewDynamoStore(client cache.DynamoDBClient, table string) *DynamoStore {
+	return &DynamoStore{client: client, table: table}
+}
+
+func (s *DynamoStore) GetProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"userId": &types.AttributeValueMemberS{Value: userID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting profile from DynamoDB: %w", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var profile models.UserProfile
+	if err := attributevalue.UnmarshalMap(result.Item, &profile); err != nil {
+		return nil, fmt.Errorf("unmarshaling profile: %w", err)
+	}
+	if profile.Favorites == nil {
+		profile.Favorites = []models.FavoriteStation{}
+	}
+	if profile.DeletedFavorites == nil {
+		profile.DeletedFavorites = []models.FavoriteStation{}
+	}
+	stampLegacyFavorites(result.Item, profile.Favorites)
+	return &profile, nil
+}
+
+// stampLegacyFavorites stamps favorites saved before they had stamps with the addedAt
+// they were saved with instead
+func stampLegacyFavorites(item map[string]types.AttributeValue, favorites []models.FavoriteStation) {
+	var legacy struct {
+		Favorites []struct {
+			AddedAt int64 `dynamodbav:"addedAt"`
+		} `dynamodbav:"favorites"`
+	}
+	if err := attributevalue.UnmarshalMap(item, &legacy); err != nil {
+		return
+	}
+	for i, f := range legacy.Favorites {
+		if i < len(favorites) && favorites[i].CreatedAt == 0 {
+			favorites[i].CreatedAt = f.AddedAt
+			favorites[i].UpdatedAt = f.AddedAt
+		}
+	}
+}
+
+func (s *DynamoStore) SaveProfile(ctx context.Context, profile *models.UserProfile) error {
+	if err := profile.Validate(); err != nil {
+		return fmt.Errorf("invalid profile: %w", err)
+	}
+
+	saved := *profile
+	saved.Version++
+	item, err := attributevalue.MarshalMap(saved)
+	if err != nil {
+		return fmt.Errorf("marshaling profile: %w", err)
+	}
+
+	// A new profile must not exist yet; an existing one must be unchanged since it was read
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(s.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(userId)"),
+	}
+	if profile.Version > 0 {
+		input.ConditionExpression = aws.String("version = :version")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.FormatInt(profile.Version, 10)},
+		}
+	}
+
+	if _, err := s.client.PutItem(ctx, input); err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return ErrConflict
+		}
+		return fmt.Errorf("putting profile in DynamoDB: %w", err)
+	}
+
+	profile.Version = saved.Version
+	return nil
+}
@@ -0,0 +1,180 @@
+package userdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/persist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDynamoDB keeps items in memory keyed by userId and evaluates the two conditions
+// DynamoStore uses
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+	puts  int
+	// beforePut runs before each put, e.g. to simulate another device saving first
+	beforePut func()
+	err       error
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+}
+
+func userIDOf(item map[string]types.AttributeValue) string {
+	return item["userId"].(*types.AttributeValueMemberS).Value
+}
+
+func (f *fakeDynamoDB) GetItem(_ context.Context, params *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.GetItemOutput{Item: f.items[userIDOf(params.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, params *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.beforePut != nil {
+		f.beforePut()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	userID := userIDOf(params.Item)
+	existing, exists := f.items[userID]
+	switch *params.ConditionExpression {
+	case "attribute_not_exists(userId)":
+		if exists {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+	case "version = :version":
+		want := params.ExpressionAttributeValues[":version"].(*types.AttributeValueMemberN).Value
+		if !exists || existing["version"].(*types.AttributeValueMemberN).Value != want {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+	}
+	f.items[userID] = params.Item
+	f.puts++
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchGetItem(context.Context, *dynamodb.BatchGetItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) BatchWriteItem(context.Context, *dynamodb.BatchWriteItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) UpdateItem(context.Context, *dynamodb.UpdateItemInput, ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeDynamoDB) Query(context.Context, *dynamodb.QueryInput, ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return &dynamodb.QueryOutput{}, nil
+}
+
+func (f *fakeDynamoDB) ListTables(context.Context, *dynamodb.ListTablesInput, ...func(*dynamodb.Options)) (*dynamodb.ListTablesOutput, error) {
+	return &dynamodb.ListTablesOutput{}, nil
+}
+
+func TestDynamoStore_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := NewDynamoStore(newFakeDynamoDB(), "profiles")
+
+	profile, err := store.GetProfile(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Nil(t, profile)
+
+	profile = models.NewUserProfile("user-1")
+	profile.Favorites = append(profile.Favorites, models.FavoriteStation{
+		StationID: "9447130",
+		Name:      "Seattle",
+		Stamps:    persist.Stamps{CreatedAt: 1700000000, UpdatedAt: 1700000100},
+	})
+	profile.DeletedFavorites = append(profile.DeletedFavorites, models.FavoriteStation{
+		StationID: "9446484",
+		Name:      "Tacoma",
+		Stamps:    persist.Stamps{CreatedAt: 1700000000, UpdatedAt: 1700000200, DeletedAt: 1700000200},
+	})
+	profile.Units = models.UnitsMetric
+	require.NoError(t, store.SaveProfile(ctx, profile))
+	assert.Equal(t, int64(1), profile.Version)
+
+	got, err := store.GetProfile(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, profile, got)
+}
+
+func TestDynamoStore_LegacyFavorites(t *testing.T) {
+	client := newFakeDynamoDB()
+	client.items["user-1"] = map[string]types.AttributeValue{
+		"userId": &types.AttributeValueMemberS{Value: "user-1"},
+		"favorites": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+				"stationId": &types.AttributeValueMemberS{Value: "9447130"},
+				"name":      &types.AttributeValueMemberS{Value: "Seattle"},
+				"addedAt":   &types.AttributeValueMemberN{Value: "1600000000"},
+			}},
+		}},
+		"units":   &types.AttributeValueMemberS{Value: models.UnitsEnglish},
+		"datum":   &types.AttributeValueMemberS{Value: models.DefaultDatum},
+		"version": &types.AttributeValueMemberN{Value: "3"},
+	}
+
+	profile, err := NewDynamoStore(client, "profiles").GetProfile(context.Background(), "user-1")
+	require.NoError(t, err)
+	require.Len(t, profile.Favorites, 1)
+	assert.Equal(t, persist.Stamps{CreatedAt: 1600000000, UpdatedAt: 1600000000}, profile.Favorites[0].Stamps,
+		"favorites saved before stamps are stamped with when they were added")
+	assert.Equal(t, []models.FavoriteStation{}, profile.DeletedFavorites)
+}
+
+func TestDynamoStore_OptimisticLocking(t *testing.T) {
+	ctx := context.Background()
+	store := NewDynamoStore(newFakeDynamoDB(), "profiles")
+
+	require.NoError(t, store.SaveProfile(ctx, models.NewUserProfile("user-1")))
+	assert.ErrorIs(t, store.SaveProfile(ctx, models.NewUserProfile("user-1")), ErrConflict, "a second new profile conflicts")
+
+	first, err := store.GetProfile(ctx, "user-1")
+	require.NoError(t, err)
+	second, err := store.GetProfile(ctx, "user-1")
+	require.NoError(t, err)
+
+	first.Datum = "MSL"
+	require.NoError(t, store.SaveProfile(ctx, first))
+	second.Units = models.UnitsMetric
+	assert.ErrorIs(t, store.SaveProfile(ctx, second), ErrConflict, "a stale profile conflicts")
+}
+
+func TestDynamoStore_Errors(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeDynamoDB()
+	store := NewDynamoStore(client, "profiles")
+
+	invalid := models.NewUserProfile("user-1")
+	invalid.Units = "furlongs"
+	assert.ErrorContains(t, store.SaveProfile(ctx, invalid), "invalid profile")
+
+	client.err = errors.New("throttled")
+	_, err := store.GetProfile(ctx, "user-1")
+	assert.ErrorContains(t, err, "getting profile from DynamoDB: throttled")
+	assert.ErrorContains(t, store.SaveProfile(ctx, models.NewUserProfile("user-1")), "putting profile in DynamoDB: throttled")
+}
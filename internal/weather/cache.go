@@ -0,0 +1,65 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	// DefaultCacheSize is how many points' forecasts are kept
+	DefaultCacheSize = 500
+	// failureTTL is how long a failed lookup is remembered, so an outage doesn't add an
+	// upstream timeout to every tide request
+	failureTTL = time.Minute
+)
+
+type cacheEntry struct {
+	forecast  []models.WeatherForecast
+	err       error
+	expiresAt time.Time
+}
+
+// Cached is a Forecaster that reuses another's forecasts for ttl. Points are keyed to two
+// decimal places (about 1 km), finer than the NWS forecast grid, so stations sharing a
+// grid cell share a lookup. Failures are cached briefly too.
+type Cached struct {
+	next    Forecaster
+	entries *lru.Cache[string, cacheEntry]
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+var _ Forecaster = (*Cached)(nil)
+
+// NewCached returns a Cached holding up to size points' forecasts
+func NewCached(next Forecaster, size int, ttl time.Duration) (*Cached, error) {
+	entries, err := lru.New[string, cacheEntry](size)
+	if err != nil {
+		return nil, fmt.Errorf("creating weather cache: %w", err)
+	}
+	return &Cached{next: next, entries: entries, ttl: ttl, now: time.Now}, nil
+}
+
+func (c *Cached) Forecast(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error) {
+	key := fmt.Sprintf("%.2f,%.2f", lat, lon)
+	if entry, ok := c.entries.Get(key); ok && c.now().Before(entry.expiresAt) {
+		return entry.forecast, entry.err
+	}
+
+	forecast, err := c.next.Forecast(ctx, lat, lon)
+	if errors.Is(err, context.Canceled) {
+		// The caller gave up, which says nothing about the next request
+		return nil, err
+	}
+	ttl := c.ttl
+	if err != nil {
+		ttl = failureTTL
+	}
+	c.entries.Add(key, cacheEntry{forecast: forecast, err: err, expiresAt: c.now().Add(ttl)})
+	return forecast, err
+}
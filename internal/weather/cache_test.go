@@ -0,0 +1,92 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+type mockForecaster struct {
+	calls int
+	err   error
+}
+
+func (m *mockForecaster) Forecast(_ context.Context, _, _ float64) ([]models.WeatherForecast, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return []models.WeatherForecast{{Timestamp: models.Millis(m.calls)}}, nil
+}
+
+func newTestCached(t *testing.T, next Forecaster, now *time.Time) *Cached {
+	cached, err := NewCached(next, 10, 30*time.Minute)
+	require.NoError(t, err)
+	cached.now = func() time.Time { return *now }
+	return cached
+}
+
+func TestCachedForecast(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := &mockForecaster{}
+	cached := newTestCached(t, next, &now)
+
+	first, err := cached.Forecast(ctx, 47.6026, -122.3393)
+	require.NoError(t, err)
+
+	// Nearby points share a cache entry until it expires
+	again, err := cached.Forecast(ctx, 47.6041, -122.3388)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.Equal(t, 1, next.calls)
+
+	_, err = cached.Forecast(ctx, 47.7, -122.3393)
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+
+	now = now.Add(31 * time.Minute)
+	refreshed, err := cached.Forecast(ctx, 47.6026, -122.3393)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, refreshed)
+	assert.Equal(t, 3, next.calls)
+}
+
+func TestCachedForecastFailures(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := &mockForecaster{err: errors.New("NWS API returned status 503")}
+	cached := newTestCached(t, next, &now)
+
+	_, err := cached.Forecast(ctx, 47.6, -122.3)
+	assert.Error(t, err)
+	_, err = cached.Forecast(ctx, 47.6, -122.3)
+	assert.Error(t, err)
+	assert.Equal(t, 1, next.calls, "failures should be remembered")
+
+	// Failures are retried much sooner than forecasts are refreshed
+	now = now.Add(2 * time.Minute)
+	next.err = nil
+	_, err = cached.Forecast(ctx, 47.6, -122.3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+
+	// A canceled request isn't a failure worth remembering
+	next.err = context.Canceled
+	_, err = cached.Forecast(ctx, 10, 10)
+	assert.Error(t, err)
+	next.err = nil
+	_, err = cached.Forecast(ctx, 10, 10)
+	assert.NoError(t, err)
+}
+
+func TestNewCachedInvalidSize(t *testing.T) {
+	_, err := NewCached(&mockForecaster{}, 0, time.Minute)
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+// NWS is a Forecaster backed by the US National Weather Service API (api.weather.gov),
+// which only covers the US and its coastal waters
+type NWS struct {
+	httpClient *client.Client
+	userAgent  string
+}
+
+var _ Forecaster = (*NWS)(nil)
+
+// NewNWS returns an NWS forecaster. httpClient's base URL is the API's, and userAgent
+// identifies the application as the API requires.
+func NewNWS(httpClient *client.Client, userAgent string) *NWS {
+	return &NWS{httpClient: httpClient, userAgent: userAgent}
+}
+
+// nwsPoint is the part of a /points response naming the point's forecast grid
+type nwsPoint struct {
+	Properties struct {
+		ForecastGridData string `json:"forecastGridData"`
+	} `json:"properties"`
+}
+
+// nwsGridData is the part of a /gridpoints response holding the forecast layers used
+type nwsGridData struct {
+	Properties struct {
+		WindSpeed     nwsLayer `json:"windSpeed"`
+		WindGust      nwsLayer `json:"windGust"`
+		WindDirection nwsLayer `json:"windDirection"`
+		Pressure      nwsLayer `json:"pressure"`
+	} `json:"properties"`
+}
+
+// nwsLayer is a forecast variable as a run of values, each valid for an interval
+type nwsLayer struct {
+	UOM    string `json:"uom"`
+	Values []struct {
+		// ValidTime is an ISO 8601 interval, e.g. "2024-01-01T12:00:00+00:00/PT3H"
+		ValidTime string   `json:"validTime"`
+		Value     *float64 `json:"value"`
+	} `json:"values"`
+}
+
+func (n *NWS) Forecast(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error) {
+	var point nwsPoint
+	if err := n.get(ctx, fmt.Sprintf("/points/%.4f,%.4f", lat, lon), &point); err != nil {
+		return nil, fmt.Errorf("finding forecast grid: %w", err)
+	}
+	gridURL, err := url.Parse(point.Properties.ForecastGridData)
+	if err != nil || gridURL.Path == "" {
+		return nil, fmt.Errorf("no forecast grid for %.4f,%.4f", lat, lon)
+	}
+
+	var grid nwsGridData
+	if err := n.get(ctx, gridURL.Path, &grid); err != nil {
+		return nil, fmt.Errorf("getting forecast grid: %w", err)
+	}
+
+	hours := make(map[models.Millis]*models.WeatherForecast)
+	layers := []struct {
+		layer nwsLayer
+		set   func(f *models.WeatherForecast, v float64)
+	}{
+		{grid.Properties.WindSpeed, func(f *models.WeatherForecast, v float64) { f.WindSpeedKnots = &v }},
+		{grid.Properties.WindGust, func(f *models.WeatherForecast, v float64) { f.WindGustKnots = &v }},
+		{grid.Properties.WindDirection, func(f *models.WeatherForecast, v float64) { f.WindDirectionDegrees = &v }},
+		{grid.Properties.Pressure, func(f *models.WeatherForecast, v float64) { f.PressureHpa = &v }},
+	}
+	for _, l := range layers {
+		if err := expandLayer(l.layer, hours, l.set); err != nil {
+			return nil, err
+		}
+	}
+
+	forecast := make([]models.WeatherForecast, 0, len(hours))
+	for _, f := range hours {
+		forecast = append(forecast, *f)
+	}
+	sort.Slice(forecast, func(i, j int) bool {
+		return forecast[i].Timestamp < forecast[j].Timestamp
+	})
+	return forecast, nil
+}
+
+func (n *NWS) get(ctx context.Context, path string, v any) error {
+	resp, err := n.httpClient.GetWithHeaders(ctx, path, map[string]string{
+		"User-Agent": n.userAgent,
+		"Accept":     "application/geo+json",
+	})
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("NWS API returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return fmt.Errorf("decoding NWS response: %w", err)
+	}
+	return nil
+}
+
+// expandLayer converts a layer's values to knots or hPa and sets them on every hour of
+// their interval
+func expandLayer(layer nwsLayer, hours map[models.Millis]*models.WeatherForecast, set func(*models.WeatherForecast, float64)) error {
+	if len(layer.Values) == 0 {
+		return nil
+	}
+	convert, err := unitConversion(layer.UOM)
+	if err != nil {
+		return err
+	}
+	for _, v := range layer.Values {
+		if v.Value == nil {
+			continue
+		}
+		start, duration, err := parseValidTime(v.ValidTime)
+		if err != nil {
+			return err
+		}
+		value := math.Round(convert(*v.Value)*10) / 10
+		for t := start.Truncate(time.Hour); t.Before(start.Add(duration)); t = t.Add(time.Hour) {
+			timestamp := models.MillisOf(t)
+			f, ok := hours[timestamp]
+			if !ok {
+				f = &models.WeatherForecast{Timestamp: timestamp}
+				hours[timestamp] = f
+			}
+			set(f, value)
+		}
+	}
+	return nil
+}
+
+// unitConversion returns the conversion from an NWS unit of measure to the knots, degrees
+// and hPa responses use
+func unitConversion(uom string) (func(float64) float64, error) {
+	switch strings.TrimPrefix(uom, "wmoUnit:") {
+	case "km_h-1":
+		return func(v float64) float64 { return v / 1.852 }, nil
+	case "m_s-1":
+		return func(v float64) float64 { return v * 3600 / 1852 }, nil
+	case "Pa":
+		return func(v float64) float64 { return v / 100 }, nil
+	case "kn", "degree_(angle)", "hPa":
+		return func(v float64) float64 { return v }, nil
+	default:
+		return nil, fmt.Errorf("unsupported NWS unit %q", uom)
+	}
+}
+
+// isoDuration matches the ISO 8601 durations NWS uses, e.g. "PT1H" or "P1DT6H"
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?)?$`)
+
+// parseValidTime parses an ISO 8601 interval such as "2024-01-01T12:00:00+00:00/PT3H"
+func parseValidTime(validTime string) (time.Time, time.Duration, error) {
+	startStr, durationStr, ok := strings.Cut(validTime, "/")
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid NWS valid time %q", validTime)
+	}
+	start, err := time.Parse(time.RFC3339, startStr)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("invalid NWS valid time %q: %w", validTime, err)
+	}
+	m := isoDuration.FindStringSubmatch(durationStr)
+	if m == nil || durationStr == "P" || durationStr == "PT" {
+		return time.Time{}, 0, fmt.Errorf("invalid NWS valid time %q", validTime)
+	}
+	var duration time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute} {
+		if m[i+1] != "" {
+			n, _ := strconv.Atoi(m[i+1])
+			duration += time.Duration(n) * unit
+		}
+	}
+	return start, duration, nil
+}
@@ -0,0 +1,140 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/pkg/http/client"
+)
+
+const testGridData = `{
+  "properties": {
+    "windSpeed": {"uom": "wmoUnit:km_h-1", "values": [
+      {"validTime": "2024-01-01T12:00:00+00:00/PT2H", "value": 18.52},
+      {"validTime": "2024-01-01T14:00:00+00:00/PT1H", "value": null}
+    ]},
+    "windGust": {"uom": "wmoUnit:km_h-1", "values": [
+      {"validTime": "2024-01-01T12:00:00+00:00/PT1H", "value": 37.04}
+    ]},
+    "windDirection": {"uom": "wmoUnit:degree_(angle)", "values": [
+      {"validTime": "2024-01-01T12:00:00+00:00/PT3H", "value": 225}
+    ]},
+    "pressure": {"uom": "wmoUnit:Pa", "values": [
+      {"validTime": "2024-01-01T13:00:00+00:00/PT1H", "value": 101325}
+    ]}
+  }
+}`
+
+func newTestNWS(t *testing.T, handler http.HandlerFunc) *NWS {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewNWS(client.New(client.Options{BaseURL: srv.URL}), "flowebb-test")
+}
+
+func TestNWSForecast(t *testing.T) {
+	var userAgents []string
+	nws := newTestNWS(t, func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/points/47.6026,-122.3393":
+			_, _ = w.Write([]byte(`{"properties": {"forecastGridData": "https://api.weather.gov/gridpoints/SEW/124,67"}}`))
+		case "/gridpoints/SEW/124,67":
+			_, _ = w.Write([]byte(testGridData))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	forecast, err := nws.Forecast(context.Background(), 47.60262, -122.33931)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"flowebb-test", "flowebb-test"}, userAgents)
+
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.Len(t, forecast, 3)
+	for i, f := range forecast {
+		assert.Equal(t, models.MillisOf(noon.Add(time.Duration(i)*time.Hour)), f.Timestamp)
+		require.NotNil(t, f.WindDirectionDegrees)
+		assert.Equal(t, 225.0, *f.WindDirectionDegrees)
+	}
+
+	require.NotNil(t, forecast[0].WindSpeedKnots)
+	assert.Equal(t, 10.0, *forecast[0].WindSpeedKnots)
+	require.NotNil(t, forecast[0].WindGustKnots)
+	assert.Equal(t, 20.0, *forecast[0].WindGustKnots)
+	assert.Nil(t, forecast[0].PressureHpa)
+
+	require.NotNil(t, forecast[1].WindSpeedKnots)
+	assert.Nil(t, forecast[1].WindGustKnots)
+	require.NotNil(t, forecast[1].PressureHpa)
+	assert.Equal(t, 1013.3, *forecast[1].PressureHpa)
+
+	// A null value leaves the hour without wind speed
+	assert.Nil(t, forecast[2].WindSpeedKnots)
+}
+
+func TestNWSForecastErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"outside coverage", func(w http.ResponseWriter, r *http.Request) {
+			http.NotFound(w, r)
+		}},
+		{"service down", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}},
+		{"no grid", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"properties": {}}`))
+		}},
+		{"unknown unit", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/gridpoints/SEW/1,1" {
+				_, _ = w.Write([]byte(`{"properties": {"windSpeed": {"uom": "wmoUnit:furlong", "values": [
+					{"validTime": "2024-01-01T12:00:00+00:00/PT1H", "value": 1}]}}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"properties": {"forecastGridData": "https://api.weather.gov/gridpoints/SEW/1,1"}}`))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestNWS(t, tt.handler).Forecast(context.Background(), 47.6, -122.3)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseValidTime(t *testing.T) {
+	tests := []struct {
+		validTime string
+		duration  time.Duration
+		wantErr   bool
+	}{
+		{"2024-01-01T12:00:00+00:00/PT1H", time.Hour, false},
+		{"2024-01-01T12:00:00+00:00/P1DT6H", 30 * time.Hour, false},
+		{"2024-01-01T12:00:00+00:00/P2D", 48 * time.Hour, false},
+		{"2024-01-01T12:00:00+00:00/PT30M", 30 * time.Minute, false},
+		{"2024-01-01T12:00:00+00:00", 0, true},
+		{"2024-01-01T12:00:00+00:00/PT", 0, true},
+		{"2024-01-01T12:00:00+00:00/1H", 0, true},
+		{"yesterday/PT1H", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.validTime, func(t *testing.T) {
+			start, duration, err := parseValidTime(tt.validTime)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), start.UTC())
+			assert.Equal(t, tt.duration, duration)
+		})
+	}
+}
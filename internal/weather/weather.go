@@ -0,0 +1,16 @@
+// Package weather fetches the wind and pressure forecasts shown alongside the tides, since
+// whether a tide is good for going out depends on the wind as much as the water
+package weather
+
+import (
+	"context"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Forecaster gets weather forecasts for a point
+type Forecaster interface {
+	// Forecast returns the point's hourly forecast, oldest first. LocalTime is left for
+	// the caller to fill in, since only it knows the time zone the forecast is shown in.
+	Forecast(ctx context.Context, lat, lon float64) ([]models.WeatherForecast, error)
+}
@@ -0,0 +1,172 @@
+// Package widget serves the embeddable tide module: a compact payload of a station's tide
+// for third-party sites, and oEmbed responses that embed the widget page, which draws it,
+// as an iframe.
+package widget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/flowebb-go/internal/api/validate"
+	"github.com/bbernstein/flowebb-go/internal/models"
+)
+
+// Themes the module can be drawn in; light is the default
+var themes = map[string]models.WidgetTheme{
+	"light": {Name: "light", Background: "#ffffff", Foreground: "#1b2a3a", Accent: "#1f78b4"},
+	"dark":  {Name: "dark", Background: "#0f1a24", Foreground: "#e6eef5", Accent: "#4fb3e8"},
+}
+
+// DefaultTheme is used when a request doesn't name one
+const DefaultTheme = "light"
+
+// ThemeNames lists the themes a request can name
+var ThemeNames = []string{"light", "dark"}
+
+// AccentPattern matches an accent color, #rrggbb with or without the #
+const AccentPattern = `^#?[0-9a-fA-F]{6}$`
+
+var accentRegexp = regexp.MustCompile(AccentPattern)
+
+// NextCount is how many upcoming highs and lows the module shows
+const NextCount = 2
+
+const (
+	// MaxAge is how long the payload may be cached: the level moves little in a few minutes
+	MaxAge = 5 * time.Minute
+	// OEmbedMaxAge is how long an oEmbed response may be cached. The iframe markup doesn't
+	// change with the tide, so it can be kept a day.
+	OEmbedMaxAge = 24 * time.Hour
+)
+
+// The iframe's size in pixels, which maxwidth and maxheight can shrink
+const (
+	DefaultWidth  = 320
+	DefaultHeight = 180
+)
+
+// ErrNotEmbeddable is returned for oEmbed URLs that aren't a station's widget page
+var ErrNotEmbeddable = errors.New("URL is not an embeddable widget")
+
+// Theme returns the named theme, with its accent replaced when accent is set. Unknown
+// names get the default theme.
+func Theme(name, accent string) models.WidgetTheme {
+	theme, ok := themes[name]
+	if !ok {
+		theme = themes[DefaultTheme]
+	}
+	if accent != "" {
+		theme.Accent = "#" + strings.ToLower(strings.TrimPrefix(accent, "#"))
+	}
+	return theme
+}
+
+// Service builds widget payloads and oEmbed responses
+type Service struct {
+	Tides    models.TideProvider
+	Stations models.StationFinder
+	// URL is the widget page, which takes the station ID, theme and accent as query
+	// parameters
+	URL string
+}
+
+// NewService creates a service whose widget page is at pageURL
+func NewService(tides models.TideProvider, stations models.StationFinder, pageURL string) *Service {
+	return &Service{Tides: tides, Stations: stations, URL: pageURL}
+}
+
+// Widget returns the module's payload for the station, drawn in theme
+func (s *Service) Widget(ctx context.Context, stationID string, theme models.WidgetTheme) (*models.TideWidget, error) {
+	current, err := s.Tides.GetCurrentTideForStation(ctx, stationID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	next, err := s.Tides.GetNextExtremes(ctx, stationID, NextCount)
+	if err != nil {
+		return nil, err
+	}
+	return &models.TideWidget{
+		ResponseType: "widget",
+		StationID:    next.StationID,
+		StationName:  next.StationName,
+		TimeZone:     next.TimeZone,
+		Timestamp:    current.Timestamp,
+		LocalTime:    current.LocalTime,
+		WaterLevel:   current.WaterLevel,
+		TideType:     current.TideType,
+		Next:         next.Extremes,
+		Theme:        theme,
+		URL:          s.pageURL(next.StationID, theme),
+	}, nil
+}
+
+// OEmbed returns the oEmbed response for a widget page URL, whose iframe fits within
+// maxWidth and maxHeight when they're set
+func (s *Service) OEmbed(ctx context.Context, rawURL string, maxWidth, maxHeight int) (*models.OEmbed, error) {
+	page, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing widget URL: %w", err)
+	}
+	embedded, err := url.Parse(rawURL)
+	if err != nil || embedded.Host != page.Host || strings.TrimSuffix(embedded.Path, "/") != strings.TrimSuffix(page.Path, "/") {
+		return nil, ErrNotEmbeddable
+	}
+	query := embedded.Query()
+	stationID := query.Get("stationId")
+	if validate.StationID("stationId", stationID) != nil {
+		return nil, ErrNotEmbeddable
+	}
+	station, err := models.FindStation(ctx, s.Stations, stationID)
+	if err != nil {
+		return nil, fmt.Errorf("finding station: %w", err)
+	}
+	if station == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrStationNotFound, stationID)
+	}
+
+	width, height := DefaultWidth, DefaultHeight
+	if maxWidth > 0 {
+		width = min(width, maxWidth)
+	}
+	if maxHeight > 0 {
+		height = min(height, maxHeight)
+	}
+	title := "Tides for " + station.Name
+	src := s.pageURL(station.ID, Theme(query.Get("theme"), validAccent(query.Get("accent"))))
+	return &models.OEmbed{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        title,
+		ProviderName: "Flowebb",
+		ProviderURL:  page.Scheme + "://" + page.Host,
+		CacheAge:     int(OEmbedMaxAge.Seconds()),
+		HTML: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" title="%s" style="border:0" loading="lazy"></iframe>`,
+			html.EscapeString(src), width, height, html.EscapeString(title)),
+		Width:  width,
+		Height: height,
+	}, nil
+}
+
+// pageURL links to the widget page for the station, drawn in theme
+func (s *Service) pageURL(stationID string, theme models.WidgetTheme) string {
+	query := url.Values{"stationId": {stationID}, "theme": {theme.Name}}
+	if theme.Accent != themes[theme.Name].Accent {
+		query.Set("accent", strings.TrimPrefix(theme.Accent, "#"))
+	}
+	return s.URL + "?" + query.Encode()
+}
+
+// validAccent returns accent when it's a color, or empty so the theme's own is kept.
+// Widget page URLs come from third parties, so a bad accent is dropped rather than refused.
+func validAccent(accent string) string {
+	if !accentRegexp.MatchString(accent) {
+		return ""
+	}
+	return accent
+}
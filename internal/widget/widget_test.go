@@ -0,0 +1,104 @@
+package widget
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bbernstein/flowebb-go/internal/models"
+	"github.com/bbernstein/flowebb-go/internal/testsupport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tides answers with a rising level and the next highs and lows
+type tides struct {
+	models.TideProvider
+	counts []int
+}
+
+func (p *tides) GetCurrentTideForStation(_ context.Context, stationID string, _, _ *string) (*models.ExtendedTideResponse, error) {
+	level, rising := 6.2, models.TideTypeRising
+	return &models.ExtendedTideResponse{Timestamp: 1735740000000, LocalTime: "2025-01-01T06:00:00", WaterLevel: &level, TideType: &rising}, nil
+}
+
+func (p *tides) GetNextExtremes(_ context.Context, stationID string, count int) (*models.NextExtremes, error) {
+	p.counts = append(p.counts, count)
+	return &models.NextExtremes{
+		StationID:   stationID,
+		StationName: "Seattle",
+		TimeZone:    "America/Los_Angeles",
+		Extremes: []models.TideExtreme{
+			{Type: models.TideTypeHigh, Timestamp: 1735748000000, LocalTime: "2025-01-01T08:13:20", Height: 11.2},
+			{Type: models.TideTypeLow, Timestamp: 1735770000000, LocalTime: "2025-01-01T14:20:00", Height: 2.1},
+		},
+	}, nil
+}
+
+func newTestService() (*Service, *tides) {
+	provider := &tides{}
+	finder := &testsupport.StationFinder{Stations: []models.Station{{ID: "9447130", Name: "Seattle & Elliott Bay"}}}
+	return NewService(provider, finder, "https://app.flowebb.com/widget"), provider
+}
+
+func TestTheme(t *testing.T) {
+	assert.Equal(t, "light", Theme("", "").Name)
+	assert.Equal(t, "light", Theme("neon", "").Name)
+	dark := Theme("dark", "")
+	assert.Equal(t, "#0f1a24", dark.Background)
+	assert.Equal(t, "#ff6600", Theme("dark", "FF6600").Accent)
+	assert.Equal(t, "#ff6600", Theme("dark", "#ff6600").Accent)
+}
+
+func TestWidget(t *testing.T) {
+	service, provider := newTestService()
+
+	payload, err := service.Widget(context.Background(), "9447130", Theme("dark", "ff6600"))
+	require.NoError(t, err)
+	assert.Equal(t, []int{NextCount}, provider.counts)
+	assert.Equal(t, "widget", payload.ResponseType)
+	assert.Equal(t, "Seattle", payload.StationName)
+	assert.Equal(t, 6.2, *payload.WaterLevel)
+	assert.Equal(t, models.TideTypeRising, *payload.TideType)
+	assert.Len(t, payload.Next, 2)
+	assert.Equal(t, "#ff6600", payload.Theme.Accent)
+	assert.Equal(t, "https://app.flowebb.com/widget?accent=ff6600&stationId=9447130&theme=dark", payload.URL)
+
+	payload, err = service.Widget(context.Background(), "9447130", Theme("light", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "https://app.flowebb.com/widget?stationId=9447130&theme=light", payload.URL, "the theme's own accent isn't repeated")
+}
+
+func TestOEmbed(t *testing.T) {
+	service, _ := newTestService()
+	ctx := context.Background()
+
+	embed, err := service.OEmbed(ctx, "https://app.flowebb.com/widget?stationId=9447130&theme=dark", 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "rich", embed.Type)
+	assert.Equal(t, "1.0", embed.Version)
+	assert.Equal(t, "Tides for Seattle & Elliott Bay", embed.Title)
+	assert.Equal(t, "https://app.flowebb.com", embed.ProviderURL)
+	assert.Equal(t, 86400, embed.CacheAge)
+	assert.Equal(t, DefaultWidth, embed.Width)
+	assert.Equal(t, DefaultHeight, embed.Height)
+	assert.Equal(t, `<iframe src="https://app.flowebb.com/widget?stationId=9447130&amp;theme=dark" width="320" height="180" title="Tides for Seattle &amp; Elliott Bay" style="border:0" loading="lazy"></iframe>`, embed.HTML)
+
+	embed, err = service.OEmbed(ctx, "https://app.flowebb.com/widget/?stationId=9447130&accent=bogus", 240, 400)
+	require.NoError(t, err)
+	assert.Equal(t, 240, embed.Width, "maxwidth shrinks the iframe")
+	assert.Equal(t, DefaultHeight, embed.Height)
+	assert.Contains(t, embed.HTML, `src="https://app.flowebb.com/widget?stationId=9447130&amp;theme=light"`, "a bad accent is dropped")
+
+	for _, url := range []string{
+		"https://evil.example.com/widget?stationId=9447130",
+		"https://app.flowebb.com/stations?stationId=9447130",
+		"https://app.flowebb.com/widget",
+		"://",
+	} {
+		_, err := service.OEmbed(ctx, url, 0, 0)
+		assert.ErrorIs(t, err, ErrNotEmbeddable, url)
+	}
+
+	_, err = service.OEmbed(ctx, "https://app.flowebb.com/widget?stationId=1234567", 0, 0)
+	assert.ErrorIs(t, err, models.ErrStationNotFound)
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// CassetteMode says whether a Cassette records responses or replays them
+type CassetteMode string
+
+const (
+	// CassetteRecord sends requests as usual and saves each response
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers requests from saved responses without touching the network
+	CassetteReplay CassetteMode = "replay"
+)
+
+// ErrNotRecorded is returned in replay mode for a URL the cassette has no response for
+var ErrNotRecorded = errors.New("response not recorded")
+
+// Cassette records upstream responses to a directory, one file per URL, and replays them
+// later, so tests and offline demos don't depend on the upstream being up
+type Cassette struct {
+	mode CassetteMode
+	dir  string
+}
+
+// recording is a saved response. Bodies are stored decompressed, as Get returns them.
+type recording struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// NewCassette returns a cassette that records to or replays from dir. An empty mode
+// returns nil, which leaves requests alone.
+func NewCassette(mode CassetteMode, dir string) (*Cassette, error) {
+	switch mode {
+	case "":
+		return nil, nil
+	case CassetteRecord, CassetteReplay:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q: must be %s or %s", mode, CassetteRecord, CassetteReplay)
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("cassette mode %s needs a directory", mode)
+	}
+	return &Cassette{mode: mode, dir: dir}, nil
+}
+
+// Mode returns whether the cassette records or replays
+func (c *Cassette) Mode() CassetteMode {
+	return c.mode
+}
+
+// path is the file holding url's response
+func (c *Cassette) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// replay returns the response recorded for url
+func (c *Cassette) replay(url string) (*Response, error) {
+	data, err := os.ReadFile(c.path(url))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("replaying %s from %s: %w", url, c.dir, ErrNotRecorded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replaying %s: %w", url, err)
+	}
+	var r recording
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("replaying %s: %w", url, err)
+	}
+	return &Response{StatusCode: r.StatusCode, Header: r.Header, Body: []byte(r.Body)}, nil
+}
+
+// record saves resp as url's response, replacing any earlier one. A 304 answers a
+// conditional request and has no body, so it's not recorded over the full response it
+// revalidated; replaying that one to a conditional request is still correct.
+func (c *Cassette) record(url string, resp *Response) error {
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	data, err := json.MarshalIndent(recording{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(resp.Body),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	// Write then rename so a concurrent replay never reads half a file
+	tmp, err := os.CreateTemp(c.dir, ".recording-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(url))
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"station":"` + r.URL.Query().Get("station") + `"}`))
+	}))
+
+	recorder, err := NewCassette(CassetteRecord, dir)
+	require.NoError(t, err)
+	c := New(Options{BaseURL: server.URL, Cassette: recorder})
+	resp, err := c.Get(context.Background(), "/api?station=9447130")
+	require.NoError(t, err)
+	assert.Equal(t, `{"station":"9447130"}`, string(resp.Body))
+	server.Close()
+
+	// Replay needs nothing but the recordings
+	player, err := NewCassette(CassetteReplay, dir)
+	require.NoError(t, err)
+	c = New(Options{BaseURL: server.URL, Cassette: player})
+	resp, err = c.Get(context.Background(), "/api?station=9447130")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"station":"9447130"}`, string(resp.Body))
+	assert.Equal(t, 1, requests)
+
+	// Responses are keyed by the full URL
+	_, err = c.Get(context.Background(), "/api?station=9446484")
+	assert.ErrorIs(t, err, ErrNotRecorded)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestCassetteKeepsResponseRevalidatedByNotModified(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"stationList":[{"stationId":"9447130"}]}`))
+	}))
+	defer server.Close()
+
+	recorder, err := NewCassette(CassetteRecord, dir)
+	require.NoError(t, err)
+	c := New(Options{BaseURL: server.URL, Cassette: recorder})
+	resp, err := c.Get(context.Background(), "/stations.json")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = c.GetWithHeaders(context.Background(), "/stations.json", map[string]string{"If-None-Match": `"v1"`})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	player, err := NewCassette(CassetteReplay, dir)
+	require.NoError(t, err)
+	c = New(Options{BaseURL: server.URL, Cassette: player})
+	resp, err = c.Get(context.Background(), "/stations.json")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"stationList":[{"stationId":"9447130"}]}`, string(resp.Body))
+}
+
+func TestNewCassette(t *testing.T) {
+	t.Parallel()
+
+	cassette, err := NewCassette("", "testdata")
+	require.NoError(t, err)
+	assert.Nil(t, cassette)
+
+	_, err = NewCassette("rewind", "testdata")
+	assert.EqualError(t, err, `unknown cassette mode "rewind": must be record or replay`)
+
+	_, err = NewCassette(CassetteReplay, "")
+	assert.Error(t, err)
+
+	cassette, err = NewCassette(CassetteRecord, "testdata")
+	require.NoError(t, err)
+	assert.Equal(t, CassetteRecord, cassette.Mode())
+}
@@ -1,14 +1,30 @@
 package client
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// Transport defaults, tuned for a Lambda that makes a handful of requests to one host
+// per invocation and reuses its connections across warm invocations
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 10
+	defaultIdleConnTimeout     = 90 * time.Second
+	defaultKeepAlive           = 30 * time.Second
+	defaultTLSSessionCacheSize = 64
+)
+
 type Response struct {
 	StatusCode int
+	Header     http.Header
 	Body       []byte
 }
 
@@ -20,6 +36,10 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	maxRetries int
+	gzip       bool
+	limiter    *Limiter
+	cassette   *Cassette
+	onResponse func(statusCode int, err error, elapsed time.Duration)
 	GetFunc    func(ctx context.Context, path string) (*Response, error)
 }
 
@@ -27,6 +47,26 @@ type Options struct {
 	BaseURL    string
 	Timeout    time.Duration
 	MaxRetries int
+
+	// Connection pooling. Zero values use the package defaults.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	KeepAlive           time.Duration
+	// TLSSessionCacheSize is how many TLS sessions are kept for resumption, which saves a
+	// round trip when a connection has to be reopened
+	TLSSessionCacheSize int
+	// DisableGzip stops the client from asking for gzip-encoded responses
+	DisableGzip bool
+	// Limiter, when set, caps how many of this client's requests, and those of any other
+	// client sharing it, are open at once
+	Limiter *Limiter
+	// Cassette, when set, records every response or replays recorded ones instead of
+	// making requests; see NewCassette
+	Cassette *Cassette
+	// OnResponse, when set, is told the outcome of every request sent: its status code, or
+	// the error when there was no response, and how long it took
+	OnResponse func(statusCode int, err error, elapsed time.Duration)
 }
 
 func New(opts Options) *Client {
@@ -41,28 +81,118 @@ func New(opts Options) *Client {
 	return &Client{
 		baseURL: opts.BaseURL,
 		httpClient: &http.Client{
-			Timeout: opts.Timeout,
+			Timeout:   opts.Timeout,
+			Transport: newTransport(opts),
 		},
 		maxRetries: opts.MaxRetries,
+		gzip:       !opts.DisableGzip,
+		limiter:    opts.Limiter,
+		cassette:   opts.Cassette,
+		onResponse: opts.OnResponse,
 	}
 }
 
-func (c *Client) Get(ctx context.Context, path string) (*Response, error) {
-	if c.GetFunc != nil {
-		return c.GetFunc(ctx, path)
+func newTransport(opts Options) *http.Transport {
+	if opts.MaxIdleConns == 0 {
+		opts.MaxIdleConns = defaultMaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost == 0 {
+		opts.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout == 0 {
+		opts.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = defaultKeepAlive
+	}
+	if opts.TLSSessionCacheSize == 0 {
+		opts.TLSSessionCacheSize = defaultTLSSessionCacheSize
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: opts.KeepAlive,
+	}
+	return &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         dialer.DialContext,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        opts.MaxIdleConns,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		TLSHandshakeTimeout: 10 * time.Second,
+		TLSClientConfig: &tls.Config{
+			ClientSessionCache: tls.NewLRUClientSessionCache(opts.TLSSessionCacheSize),
+		},
+		ExpectContinueTimeout: time.Second,
+		// Compression is negotiated in GetWithHeaders so it also applies when callers
+		// set their own headers
+		DisableCompression: true,
 	}
+}
+
+// Cassette returns the cassette the client records to or replays from, or nil if it has none
+func (c *Client) Cassette() *Cassette {
+	return c.cassette
+}
+
+func (c *Client) Get(ctx context.Context, path string) (*Response, error) {
+	return c.GetWithHeaders(ctx, path, nil)
+}
 
+// GetWithHeaders is like Get but adds the given request headers, e.g. for conditional requests
+func (c *Client) GetWithHeaders(ctx context.Context, path string, headers map[string]string) (*Response, error) {
 	var fullURL string
 	if c.baseURL == "" {
 		fullURL = path // If no base URL, treat path as full URL
 	} else {
 		fullURL = c.baseURL + path // Otherwise combine them
 	}
+	if c.cassette != nil && c.cassette.mode == CassetteReplay {
+		return c.cassette.replay(fullURL)
+	}
+
+	if err := c.limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.limiter.Release()
 
+	if c.GetFunc != nil {
+		return c.GetFunc(ctx, path)
+	}
+
+	start := time.Now()
+	response, err := c.do(ctx, fullURL, headers)
+	if c.onResponse != nil {
+		statusCode := 0
+		if response != nil {
+			statusCode = response.StatusCode
+		}
+		c.onResponse(statusCode, err, time.Since(start))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.cassette != nil {
+		if err := c.cassette.record(fullURL, response); err != nil {
+			return nil, fmt.Errorf("recording %s: %w", fullURL, err)
+		}
+	}
+	return response, nil
+}
+
+// do sends one GET request and reads its response
+func (c *Client) do(ctx context.Context, fullURL string, headers map[string]string) (*Response, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if c.gzip && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -75,13 +205,40 @@ func (c *Client) Get(ctx context.Context, path string) (*Response, error) {
 		}
 	}(resp.Body)
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Response{
 		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
 		Body:       body,
 	}, nil
 }
+
+// readBody reads the response body, decompressing it if the server gzipped it. The
+// encoding headers are removed since they no longer describe the body.
+func readBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return io.ReadAll(resp.Body)
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		if err == io.EOF {
+			// An empty body, e.g. on a 304, has nothing to decompress
+			return []byte{}, nil
+		}
+		return nil, err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	return body, nil
+}
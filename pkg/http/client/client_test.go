@@ -1,10 +1,13 @@
 package client
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
@@ -118,6 +121,28 @@ func TestRequestFormatting(t *testing.T) {
 	}
 }
 
+func TestGetWithHeaders(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, `"abc"`, r.Header.Get("If-None-Match"))
+		w.Header().Set("ETag", `"abc"`)
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	client := New(Options{
+		BaseURL: server.URL,
+		Timeout: 5 * time.Second,
+	})
+
+	resp, err := client.GetWithHeaders(context.Background(), "/test", map[string]string{"If-None-Match": `"abc"`})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, `"abc"`, resp.Header.Get("ETag"))
+	assert.Empty(t, resp.Body)
+}
+
 func TestTimeout(t *testing.T) {
 	t.Parallel()
 
@@ -279,3 +304,133 @@ func TestGetRequestError(t *testing.T) {
 		})
 	}
 }
+
+func TestOnResponse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	type outcome struct {
+		statusCode int
+		err        error
+	}
+	var outcomes []outcome
+	client := New(Options{
+		BaseURL: server.URL,
+		OnResponse: func(statusCode int, err error, elapsed time.Duration) {
+			assert.Positive(t, elapsed)
+			outcomes = append(outcomes, outcome{statusCode, err})
+		},
+	})
+
+	_, err := client.Get(context.Background(), "/api")
+	require.NoError(t, err)
+	_, err = client.Get(context.Background(), ":\\invalid")
+	require.Error(t, err)
+
+	require.Len(t, outcomes, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, outcomes[0].statusCode)
+	assert.NoError(t, outcomes[0].err)
+	assert.Zero(t, outcomes[1].statusCode)
+	assert.Error(t, outcomes[1].err)
+}
+
+func TestTransportOptions(t *testing.T) {
+	t.Parallel()
+
+	defaults, ok := New(Options{}).httpClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, defaultMaxIdleConnsPerHost, defaults.MaxIdleConnsPerHost)
+	assert.Equal(t, defaultIdleConnTimeout, defaults.IdleConnTimeout)
+	assert.NotNil(t, defaults.TLSClientConfig.ClientSessionCache)
+	assert.True(t, defaults.DisableCompression)
+
+	tuned, ok := New(Options{MaxIdleConns: 4, MaxIdleConnsPerHost: 2, IdleConnTimeout: time.Minute}).httpClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 4, tuned.MaxIdleConns)
+	assert.Equal(t, 2, tuned.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, tuned.IdleConnTimeout)
+}
+
+func TestConnectionReuse(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	newConns := 0
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := New(Options{BaseURL: server.URL, Timeout: 5 * time.Second})
+	for i := 0; i < 5; i++ {
+		_, err := client.Get(context.Background(), "/test")
+		require.NoError(t, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, newConns)
+}
+
+func TestGzipNegotiation(t *testing.T) {
+	t.Parallel()
+
+	payload := `{"predictions":[{"t":"2024-01-01 00:00","v":"1.0"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			_, _ = w.Write([]byte(payload))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(payload))
+		_ = gz.Close()
+	}))
+	defer server.Close()
+
+	t.Run("decompresses gzip responses", func(t *testing.T) {
+		client := New(Options{BaseURL: server.URL, Timeout: 5 * time.Second})
+		resp, err := client.Get(context.Background(), "/test")
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(resp.Body))
+		assert.Empty(t, resp.Header.Get("Content-Encoding"))
+	})
+
+	t.Run("gzip disabled", func(t *testing.T) {
+		client := New(Options{BaseURL: server.URL, Timeout: 5 * time.Second, DisableGzip: true})
+		resp, err := client.Get(context.Background(), "/test")
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(resp.Body))
+	})
+
+	t.Run("caller's Accept-Encoding wins", func(t *testing.T) {
+		client := New(Options{BaseURL: server.URL, Timeout: 5 * time.Second})
+		resp, err := client.GetWithHeaders(context.Background(), "/test", map[string]string{"Accept-Encoding": "identity"})
+		require.NoError(t, err)
+		assert.Equal(t, payload, string(resp.Body))
+	})
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write([]byte("not gzip"))
+		}))
+		defer bad.Close()
+
+		client := New(Options{BaseURL: bad.URL, Timeout: 5 * time.Second})
+		_, err := client.Get(context.Background(), "/test")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Limiter caps how many requests are open at once across every Client that shares it,
+// e.g. all of one Lambda instance's requests to an upstream with a rate limit
+type Limiter struct {
+	mu    sync.Mutex
+	limit int
+	inUse int
+	// freed is closed, and replaced, whenever a slot frees up or the limit changes, waking
+	// every waiter to try again
+	freed chan struct{}
+}
+
+// NewLimiter allows n requests at once. It returns nil, which doesn't limit anything, when
+// n is zero or less.
+func NewLimiter(n int) *Limiter {
+	if n <= 0 {
+		return nil
+	}
+	return &Limiter{limit: n, freed: make(chan struct{})}
+}
+
+// Acquire waits for a free slot or for ctx to be done. Every successful Acquire must be
+// paired with a Release.
+func (l *Limiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		if l.limit <= 0 || l.inUse < l.limit {
+			l.inUse++
+			l.mu.Unlock()
+			return nil
+		}
+		freed := l.freed
+		l.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for a request slot: %w", ctx.Err())
+		}
+	}
+}
+
+// Release frees the slot taken by Acquire
+func (l *Limiter) Release() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inUse--
+	l.wake()
+}
+
+// SetLimit changes how many requests may be open at once; zero or less removes the cap.
+// Requests already open keep their slots when the limit is lowered, and new ones wait until
+// enough of them finish.
+func (l *Limiter) SetLimit(n int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = n
+	l.wake()
+}
+
+// wake releases the waiters; l.mu must be held
+func (l *Limiter) wake() {
+	close(l.freed)
+	l.freed = make(chan struct{})
+}
+
+// InUse returns how many slots are taken
+func (l *Limiter) InUse() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inUse
+}
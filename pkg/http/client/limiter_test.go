@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiterSharedAcrossClients(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter(2)
+	var open, most atomic.Int32
+	getFunc := func(ctx context.Context, path string) (*Response, error) {
+		n := open.Add(1)
+		for {
+			m := most.Load()
+			if n <= m || most.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		open.Add(-1)
+		return &Response{StatusCode: 200}, nil
+	}
+	clients := []*Client{New(Options{Limiter: limiter}), New(Options{Limiter: limiter})}
+	for _, c := range clients {
+		c.GetFunc = getFunc
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		c := clients[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Get(context.Background(), "/test")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), most.Load())
+	assert.Zero(t, limiter.InUse())
+}
+
+func TestLimiterCanceledWhileWaiting(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+	defer limiter.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c := New(Options{Limiter: limiter})
+	c.GetFunc = func(ctx context.Context, path string) (*Response, error) {
+		t.Error("the request shouldn't be sent without a slot")
+		return nil, nil
+	}
+	_, err := c.Get(ctx, "/test")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNilLimiter(t *testing.T) {
+	t.Parallel()
+
+	var limiter *Limiter
+	assert.Nil(t, NewLimiter(0))
+	require.NoError(t, limiter.Acquire(context.Background()))
+	limiter.Release()
+	assert.Zero(t, limiter.InUse())
+}
+
+func TestLimiterSetLimit(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewLimiter(1)
+	require.NoError(t, limiter.Acquire(context.Background()))
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.NoError(t, limiter.Acquire(context.Background()))
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("acquired a slot over the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	limiter.SetLimit(2)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("raising the limit didn't wake the waiter")
+	}
+	assert.Equal(t, 2, limiter.InUse())
+
+	limiter.SetLimit(1)
+	limiter.Release()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Acquire(ctx), context.DeadlineExceeded, "the open request still fills the lowered limit")
+
+	limiter.SetLimit(0)
+	require.NoError(t, limiter.Acquire(context.Background()), "a limit of zero removes the cap")
+	assert.Equal(t, 2, limiter.InUse())
+
+	var unlimited *Limiter
+	unlimited.SetLimit(3)
+	assert.Zero(t, unlimited.InUse())
+}
@@ -15,6 +15,12 @@ rm -f "$BUILD_DIR/*.zip"
 echo "Building Go binaries..."
 cd "$BUILD_DIR"
 
+# The committed station list snapshot and land mask are empty; refresh them and refuse to
+# build without them
+echo "Refreshing station list snapshot and land mask..."
+go generate ./internal/station ./internal/geo
+go test -tags generated -run TestEmbedded ./internal/station ./internal/geo
+
 # Build and zip graphql function
 echo "Building graphql function..."
 GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o bootstrap ./cmd/graphql
@@ -21,6 +21,12 @@ gqlgen generate
 echo "Running go mod tidy..."
 go mod tidy
 
+# The committed station list snapshot and land mask are empty; refresh them and refuse to
+# build without them
+echo "Refreshing station list snapshot and land mask..."
+go generate ./internal/station ./internal/geo
+go test -tags generated -run TestEmbedded ./internal/station ./internal/geo
+
 # Clean up any existing build artifacts
 rm -rf .aws-sam/build
 
@@ -28,6 +34,10 @@ rm -rf .aws-sam/build
 mkdir -p .aws-sam/build/GraphQLFunction/
 mkdir -p .aws-sam/build/StationsFunction/
 mkdir -p .aws-sam/build/TidesFunction/
+mkdir -p .aws-sam/build/AdminFunction/
+mkdir -p .aws-sam/build/AccuracyFunction/
+mkdir -p .aws-sam/build/ExportFunction/
+mkdir -p .aws-sam/build/WorkerFunction/
 
 # Build the Lambda functions
 echo "Building graphql function..."
@@ -41,6 +51,22 @@ GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o .aws-sam/build/StationsFunctio
 echo "Building tides function..."
 GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o .aws-sam/build/TidesFunction/bootstrap ./cmd/tides
 
+# Build the cache admin Lambda
+echo "Building admin function..."
+GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o .aws-sam/build/AdminFunction/bootstrap ./cmd/admin
+
+# Build the scheduled prediction accuracy Lambda
+echo "Building accuracy function..."
+GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o .aws-sam/build/AccuracyFunction/bootstrap ./cmd/accuracy
+
+# Build the tide table export Lambda
+echo "Building export function..."
+GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o .aws-sam/build/ExportFunction/bootstrap ./cmd/export
+
+# Build the task queue worker Lambda
+echo "Building worker function..."
+GOOS=linux GOARCH=arm64 CGO_ENABLED=0 go build -o .aws-sam/build/WorkerFunction/bootstrap ./cmd/worker
+
 # Verify builds
 echo "Verifying builds..."
 if [ ! -x .aws-sam/build/StationsFunction/bootstrap ]; then
@@ -53,8 +79,32 @@ if [ ! -x .aws-sam/build/TidesFunction/bootstrap ]; then
     exit 1
 fi
 
+if [ ! -x .aws-sam/build/AdminFunction/bootstrap ]; then
+    echo "Error: AdminFunction bootstrap not found or not executable"
+    exit 1
+fi
+
+if [ ! -x .aws-sam/build/AccuracyFunction/bootstrap ]; then
+    echo "Error: AccuracyFunction bootstrap not found or not executable"
+    exit 1
+fi
+
+if [ ! -x .aws-sam/build/ExportFunction/bootstrap ]; then
+    echo "Error: ExportFunction bootstrap not found or not executable"
+    exit 1
+fi
+
+if [ ! -x .aws-sam/build/WorkerFunction/bootstrap ]; then
+    echo "Error: WorkerFunction bootstrap not found or not executable"
+    exit 1
+fi
+
 # Make sure binaries are executable
 chmod +x .aws-sam/build/StationsFunction/bootstrap
 chmod +x .aws-sam/build/TidesFunction/bootstrap
+chmod +x .aws-sam/build/AdminFunction/bootstrap
+chmod +x .aws-sam/build/AccuracyFunction/bootstrap
+chmod +x .aws-sam/build/ExportFunction/bootstrap
+chmod +x .aws-sam/build/WorkerFunction/bootstrap
 
 echo "Build complete!"
@@ -5,26 +5,62 @@ export PAGER=""
 # check if dynamodb table tide-prediction-cache exists
 if aws dynamodb describe-table --table-name tide-predictions-cache --endpoint-url http://localhost:8000 > /dev/null 2>&1; then
     echo "Table tide-predictions-cache already exists. Skipping table creation."
-    exit 0
+else
+    # Create tide predictions cache table with composite key
+    aws dynamodb create-table \
+        --table-name tide-predictions-cache \
+        --attribute-definitions \
+            AttributeName=stationId,AttributeType=S \
+            AttributeName=date,AttributeType=S \
+        --key-schema \
+            AttributeName=stationId,KeyType=HASH \
+            AttributeName=date,KeyType=RANGE \
+        --provisioned-throughput \
+            ReadCapacityUnits=5,WriteCapacityUnits=5 \
+        --endpoint-url http://localhost:8000
+
+    aws dynamodb update-time-to-live \
+        --table-name tide-predictions-cache \
+        --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+        --endpoint-url http://localhost:8000
+fi
+
+# User profiles (favorite stations and preferences), one item per user
+if aws dynamodb describe-table --table-name flowebb-user-profiles --endpoint-url http://localhost:8000 > /dev/null 2>&1; then
+    echo "Table flowebb-user-profiles already exists. Skipping table creation."
+else
+    aws dynamodb create-table \
+        --table-name flowebb-user-profiles \
+        --attribute-definitions \
+            AttributeName=userId,AttributeType=S \
+        --key-schema \
+            AttributeName=userId,KeyType=HASH \
+        --provisioned-throughput \
+            ReadCapacityUnits=5,WriteCapacityUnits=5 \
+        --endpoint-url http://localhost:8000
 fi
 
-# Create tide predictions cache table with composite key
-aws dynamodb create-table \
-    --table-name tide-predictions-cache \
-    --attribute-definitions \
-        AttributeName=stationId,AttributeType=S \
-        AttributeName=date,AttributeType=S \
-    --key-schema \
-        AttributeName=stationId,KeyType=HASH \
-        AttributeName=date,KeyType=RANGE \
-    --provisioned-throughput \
-        ReadCapacityUnits=5,WriteCapacityUnits=5 \
-    --endpoint-url http://localhost:8000
-
-aws dynamodb update-time-to-live \
-    --table-name tide-predictions-cache \
-    --time-to-live-specification "Enabled=true, AttributeName=ttl" \
-    --endpoint-url http://localhost:8000
+# Prediction accuracy totals, one item per tracked station and UTC day
+if aws dynamodb describe-table --table-name flowebb-prediction-accuracy --endpoint-url http://localhost:8000 > /dev/null 2>&1; then
+    echo "Table flowebb-prediction-accuracy already exists. Skipping table creation."
+else
+    aws dynamodb create-table \
+        --table-name flowebb-prediction-accuracy \
+        --attribute-definitions \
+            AttributeName=stationId,AttributeType=S \
+            AttributeName=date,AttributeType=S \
+        --key-schema \
+            AttributeName=stationId,KeyType=HASH \
+            AttributeName=date,KeyType=RANGE \
+        --provisioned-throughput \
+            ReadCapacityUnits=5,WriteCapacityUnits=5 \
+        --endpoint-url http://localhost:8000
+
+    aws dynamodb update-time-to-live \
+        --table-name flowebb-prediction-accuracy \
+        --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+        --endpoint-url http://localhost:8000
+fi
 
 echo "Tables created successfully!"
 